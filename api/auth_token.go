@@ -176,6 +176,34 @@ func (c *TokenAuth) RenewAccessorWithContext(ctx context.Context, accessor strin
 	return ParseSecret(resp.Body)
 }
 
+// RenewAccessors renews the tokens associated with the given accessors in a
+// single request. The returned secret's data contains a "results" map keyed
+// by accessor describing the outcome of each renewal.
+func (c *TokenAuth) RenewAccessors(accessors []string, increment int) (*Secret, error) {
+	return c.RenewAccessorsWithContext(context.Background(), accessors, increment)
+}
+
+func (c *TokenAuth) RenewAccessorsWithContext(ctx context.Context, accessors []string, increment int) (*Secret, error) {
+	ctx, cancelFunc := c.c.withConfiguredTimeout(ctx)
+	defer cancelFunc()
+
+	r := c.c.NewRequest(http.MethodPost, "/v1/auth/token/renew-batch")
+	if err := r.SetJSONBody(map[string]interface{}{
+		"accessors": accessors,
+		"increment": increment,
+	}); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.c.rawRequestWithContext(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return ParseSecret(resp.Body)
+}
+
 func (c *TokenAuth) Renew(token string, increment int) (*Secret, error) {
 	return c.RenewWithContext(context.Background(), token, increment)
 }
@@ -0,0 +1,285 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package api
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-secure-stdlib/parseutil"
+)
+
+// PKI is used to return a client for issuing, signing and revoking
+// certificates with a PKI secrets engine in Vault.
+type PKI struct {
+	c         *Client
+	mountPath string
+}
+
+// PKI is used to return a client for the PKI secrets engine mounted at the
+// given path, such as "pki".
+//
+// Learn more about the PKI secrets engine here:
+// https://developer.hashicorp.com/vault/docs/secrets/pki
+func (c *Client) PKI(mountPath string) *PKI {
+	return &PKI{c: c, mountPath: strings.Trim(mountPath, "/")}
+}
+
+// PKIIssueRequest holds the parameters of a certificate issued or signed by a
+// PKI role. Fields left empty use the defaults of the role.
+type PKIIssueRequest struct {
+	// IssuerRef is the name or ID of the issuer to use instead of the
+	// default issuer of the mount.
+	IssuerRef string
+
+	CommonName        string
+	AltNames          []string
+	IPSANs            []string
+	URISANs           []string
+	OtherSANs         []string
+	TTL               time.Duration
+	NotAfter          time.Time
+	ExcludeCNFromSANs bool
+}
+
+func (r *PKIIssueRequest) data() map[string]interface{} {
+	data := map[string]interface{}{
+		"format": "pem",
+	}
+	if r == nil {
+		return data
+	}
+
+	if r.CommonName != "" {
+		data["common_name"] = r.CommonName
+	}
+	if len(r.AltNames) > 0 {
+		data["alt_names"] = strings.Join(r.AltNames, ",")
+	}
+	if len(r.IPSANs) > 0 {
+		data["ip_sans"] = r.IPSANs
+	}
+	if len(r.URISANs) > 0 {
+		data["uri_sans"] = r.URISANs
+	}
+	if len(r.OtherSANs) > 0 {
+		data["other_sans"] = r.OtherSANs
+	}
+	if r.TTL > 0 {
+		data["ttl"] = r.TTL.String()
+	}
+	if !r.NotAfter.IsZero() {
+		data["not_after"] = r.NotAfter.UTC().Format(time.RFC3339)
+	}
+	if r.ExcludeCNFromSANs {
+		data["exclude_cn_from_sans"] = true
+	}
+	return data
+}
+
+// PKICertificate is a certificate issued or signed by a PKI secrets engine,
+// along with the chain of the issuer which signed it.
+type PKICertificate struct {
+	Certificate *x509.Certificate
+	IssuingCA   *x509.Certificate
+	CAChain     []*x509.Certificate
+
+	// CertificatePEM, IssuingCAPEM and CAChainPEM hold the PEM encoding of
+	// the certificates, as returned by Vault.
+	CertificatePEM string
+	IssuingCAPEM   string
+	CAChainPEM     []string
+
+	// PrivateKeyPEM and PrivateKeyType are only set for issued certificates,
+	// as the private key of a signed CSR is held by the caller.
+	PrivateKeyPEM  string
+	PrivateKeyType string
+
+	SerialNumber string
+	Expiration   time.Time
+
+	// Raw is the response returned by Vault, which can be inspected for
+	// information about the lease if the role generates leases.
+	Raw *Secret
+}
+
+// IssueCertificate generates a new private key and certificate under the
+// given role. The type of the private key is set by the role.
+func (p *PKI) IssueCertificate(ctx context.Context, role string, req *PKIIssueRequest) (*PKICertificate, error) {
+	pathToWriteTo := p.issuerPath(req, "issue", role)
+
+	secret, err := p.c.Logical().WriteWithContext(ctx, pathToWriteTo, req.data())
+	if err != nil {
+		return nil, fmt.Errorf("error issuing certificate at %s: %w", pathToWriteTo, err)
+	}
+
+	return parsePKICertificate(pathToWriteTo, secret)
+}
+
+// SignCSR signs the certificate signing request under the given role. The
+// subject and SANs of the request are used unless overridden in req, subject
+// to the constraints of the role.
+func (p *PKI) SignCSR(ctx context.Context, role string, csr *x509.CertificateRequest, req *PKIIssueRequest) (*PKICertificate, error) {
+	if csr == nil {
+		return nil, errors.New("a certificate signing request must be provided")
+	}
+	pathToWriteTo := p.issuerPath(req, "sign", role)
+
+	data := req.data()
+	data["csr"] = string(pem.EncodeToMemory(&pem.Block{
+		Type:  "CERTIFICATE REQUEST",
+		Bytes: csr.Raw,
+	}))
+
+	secret, err := p.c.Logical().WriteWithContext(ctx, pathToWriteTo, data)
+	if err != nil {
+		return nil, fmt.Errorf("error signing certificate request at %s: %w", pathToWriteTo, err)
+	}
+
+	return parsePKICertificate(pathToWriteTo, secret)
+}
+
+// RevokeBySerial revokes the certificate with the given serial number, in
+// either colon or hyphen separated hexadecimal form, and returns the time it
+// was revoked at.
+func (p *PKI) RevokeBySerial(ctx context.Context, serialNumber string) (time.Time, error) {
+	pathToWriteTo := fmt.Sprintf("%s/revoke", p.mountPath)
+
+	secret, err := p.c.Logical().WriteWithContext(ctx, pathToWriteTo, map[string]interface{}{
+		"serial_number": serialNumber,
+	})
+	if err != nil {
+		return time.Time{}, fmt.Errorf("error revoking certificate at %s: %w", pathToWriteTo, err)
+	}
+	if secret == nil || secret.Data == nil {
+		return time.Time{}, fmt.Errorf("no revocation information returned at %s", pathToWriteTo)
+	}
+
+	if raw, ok := secret.Data["revocation_time_rfc3339"].(string); ok && raw != "" {
+		return time.Parse(time.RFC3339Nano, raw)
+	}
+	revocationTime, err := parseutil.ParseInt(secret.Data["revocation_time"])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("error parsing revocation time: %w", err)
+	}
+	return time.Unix(revocationTime, 0).UTC(), nil
+}
+
+// FetchCAChain returns the certificate chain of the default issuer of the
+// mount, starting with the issuer itself.
+func (p *PKI) FetchCAChain(ctx context.Context) ([]*x509.Certificate, error) {
+	pathToRead := fmt.Sprintf("%s/cert/ca_chain", p.mountPath)
+
+	secret, err := p.c.Logical().ReadWithContext(ctx, pathToRead)
+	if err != nil {
+		return nil, fmt.Errorf("error reading CA chain at %s: %w", pathToRead, err)
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, fmt.Errorf("no CA chain found at %s", pathToRead)
+	}
+
+	chain, _ := secret.Data["certificate"].(string)
+	return ParsePEMCertificates(chain)
+}
+
+// issuerPath returns the path of the operation on the role, under the issuer
+// of the request if one is given.
+func (p *PKI) issuerPath(req *PKIIssueRequest, operation, role string) string {
+	if req != nil && req.IssuerRef != "" {
+		return fmt.Sprintf("%s/issuer/%s/%s/%s", p.mountPath, req.IssuerRef, operation, role)
+	}
+	return fmt.Sprintf("%s/%s/%s", p.mountPath, operation, role)
+}
+
+func parsePKICertificate(path string, secret *Secret) (*PKICertificate, error) {
+	if secret == nil || secret.Data == nil {
+		return nil, fmt.Errorf("no certificate returned at %s", path)
+	}
+
+	cert := &PKICertificate{Raw: secret}
+	cert.CertificatePEM, _ = secret.Data["certificate"].(string)
+	cert.IssuingCAPEM, _ = secret.Data["issuing_ca"].(string)
+	cert.PrivateKeyPEM, _ = secret.Data["private_key"].(string)
+	cert.PrivateKeyType, _ = secret.Data["private_key_type"].(string)
+	cert.SerialNumber, _ = secret.Data["serial_number"].(string)
+
+	var err error
+	if cert.Certificate, err = parsePEMCertificate(cert.CertificatePEM); err != nil {
+		return nil, fmt.Errorf("error parsing certificate: %w", err)
+	}
+	if cert.IssuingCAPEM != "" {
+		if cert.IssuingCA, err = parsePEMCertificate(cert.IssuingCAPEM); err != nil {
+			return nil, fmt.Errorf("error parsing issuing CA: %w", err)
+		}
+	}
+
+	if rawChain, ok := secret.Data["ca_chain"].([]interface{}); ok {
+		for _, raw := range rawChain {
+			certPEM, ok := raw.(string)
+			if !ok {
+				return nil, fmt.Errorf("unexpected type %T in CA chain", raw)
+			}
+			caCert, err := parsePEMCertificate(certPEM)
+			if err != nil {
+				return nil, fmt.Errorf("error parsing CA chain: %w", err)
+			}
+			cert.CAChainPEM = append(cert.CAChainPEM, certPEM)
+			cert.CAChain = append(cert.CAChain, caCert)
+		}
+	}
+
+	if raw, ok := secret.Data["expiration"]; ok {
+		expiration, err := parseutil.ParseInt(raw)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing expiration: %w", err)
+		}
+		cert.Expiration = time.Unix(expiration, 0).UTC()
+	} else {
+		cert.Expiration = cert.Certificate.NotAfter
+	}
+
+	return cert, nil
+}
+
+func parsePEMCertificate(certPEM string) (*x509.Certificate, error) {
+	certs, err := ParsePEMCertificates(certPEM)
+	if err != nil {
+		return nil, err
+	}
+	if len(certs) != 1 {
+		return nil, fmt.Errorf("expected a single certificate, found %d", len(certs))
+	}
+	return certs[0], nil
+}
+
+// ParsePEMCertificates parses the PEM encoded certificates, as returned by
+// the PKI secrets engine, in the order they appear.
+func ParsePEMCertificates(certsPEM string) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	rest := []byte(certsPEM)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			return nil, fmt.Errorf("unexpected PEM block of type %q", block.Type)
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, errors.New("no PEM encoded certificates found")
+	}
+	return certs, nil
+}
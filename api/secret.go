@@ -34,6 +34,11 @@ type Secret struct {
 	// client should be aware of.
 	Warnings []string `json:"warnings"`
 
+	// WarningsDetail contains the warnings which have a stable code, such
+	// as "ttl_truncated", allowing clients to react to them without
+	// matching on their text.
+	WarningsDetail []*SecretWarning `json:"warnings_detail,omitempty"`
+
 	// Auth, if non-nil, means that there was authentication information
 	// attached to this response.
 	Auth *SecretAuth `json:"auth,omitempty"`
@@ -48,6 +53,12 @@ type Secret struct {
 	MountType string `json:"mount_type,omitempty"`
 }
 
+// SecretWarning is a warning of a response along with its code.
+type SecretWarning struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
 // TokenID returns the standardized token ID (token) for the given secret.
 func (s *Secret) TokenID() (string, error) {
 	if s == nil {
@@ -20,11 +20,16 @@ var sudoPaths = map[string]*regexp.Regexp{
 	"/pki/root/sign-self-issued":                    regexp.MustCompile(`^/pki/root/sign-self-issued$`),
 	"/sys/audit":                                    regexp.MustCompile(`^/sys/audit$`),
 	"/sys/audit/{path}":                             regexp.MustCompile(`^/sys/audit/.+$`),
+	"/sys/audit-hash-rotate/{path}":                 regexp.MustCompile(`^/sys/audit-hash-rotate/.+$`),
+	"/sys/break-glass/approve/{id}":                 regexp.MustCompile(`^/sys/break-glass/approve/[^/]+$`),
+	"/sys/break-glass/config":                       regexp.MustCompile(`^/sys/break-glass/config$`),
 	"/sys/auth/{path}":                              regexp.MustCompile(`^/sys/auth/.+$`),
 	"/sys/auth/{path}/tune":                         regexp.MustCompile(`^/sys/auth/.+/tune$`),
 	"/sys/config/auditing/request-headers":          regexp.MustCompile(`^/sys/config/auditing/request-headers$`),
 	"/sys/config/auditing/request-headers/{header}": regexp.MustCompile(`^/sys/config/auditing/request-headers/.+$`),
 	"/sys/config/cors":                              regexp.MustCompile(`^/sys/config/cors$`),
+	"/sys/config/history":                           regexp.MustCompile(`^/sys/config/history/?$`),
+	"/sys/config/history/{id}":                      regexp.MustCompile(`^/sys/config/history/[^/]+$`),
 	"/sys/config/ui/headers":                        regexp.MustCompile(`^/sys/config/ui/headers/?$`),
 	"/sys/config/ui/headers/{header}":               regexp.MustCompile(`^/sys/config/ui/headers/.+$`),
 	"/sys/internal/inspect/router/{tag}":            regexp.MustCompile(`^/sys/internal/inspect/router/.+$`),
@@ -33,6 +38,7 @@ var sudoPaths = map[string]*regexp.Regexp{
 	// This entry is a bit wrong... sys/leases/lookup does NOT require sudo. But sys/leases/lookup/ with a trailing
 	// slash DOES require sudo. But the part of the Vault CLI that uses this logic doesn't pass operation-appropriate
 	// trailing slashes, it always strips them off, so we end up giving the wrong answer for one of these.
+	"/sys/leases/irrevocable/acknowledge":         regexp.MustCompile(`^/sys/leases/irrevocable/acknowledge$`),
 	"/sys/leases/lookup/{prefix}":                 regexp.MustCompile(`^/sys/leases/lookup(?:/.+)?$`),
 	"/sys/leases/revoke-force/{prefix}":           regexp.MustCompile(`^/sys/leases/revoke-force/.+$`),
 	"/sys/leases/revoke-prefix/{prefix}":          regexp.MustCompile(`^/sys/leases/revoke-prefix/.+$`),
@@ -43,6 +49,8 @@ var sudoPaths = map[string]*regexp.Regexp{
 	"/sys/plugins/runtimes/catalog/{type}/{name}": regexp.MustCompile(`^/sys/plugins/runtimes/catalog/[\w-]+/[^/]+$`),
 	"/sys/raw/{path}":                             regexp.MustCompile(`^/sys/raw(?:/.+)?$`),
 	"/sys/remount":                                regexp.MustCompile(`^/sys/remount$`),
+	"/sys/requests/in-flight/{id}":                regexp.MustCompile(`^/sys/requests/in-flight/[^/]+$`),
+	"/sys/requests/max-durations":                 regexp.MustCompile(`^/sys/requests/max-durations$`),
 	"/sys/revoke-force/{prefix}":                  regexp.MustCompile(`^/sys/revoke-force/.+$`),
 	"/sys/revoke-prefix/{prefix}":                 regexp.MustCompile(`^/sys/revoke-prefix/.+$`),
 	"/sys/rotate":                                 regexp.MustCompile(`^/sys/rotate$`),
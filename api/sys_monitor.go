@@ -8,23 +8,63 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 )
 
+// MonitorInput is used as input to the MonitorWithOptions function.
+type MonitorInput struct {
+	// LogLevel is the level to stream logs at, "info" by default.
+	LogLevel string
+
+	// LogFormat is the format of the streamed logs, "standard" or "json".
+	LogFormat string
+
+	// Subsystems, if set, limits the streamed logs to those emitted by these
+	// subsystems, such as "storage.raft" or "expiration".
+	Subsystems []string
+
+	// SampleRate, if set, is the fraction of logs below the warn level to
+	// stream, between 0 and 1.
+	SampleRate float64
+}
+
 // Monitor returns a channel that outputs strings containing the log messages
 // coming from the server.
 func (c *Sys) Monitor(ctx context.Context, logLevel string, logFormat string) (chan string, error) {
+	return c.MonitorWithOptions(ctx, &MonitorInput{
+		LogLevel:  logLevel,
+		LogFormat: logFormat,
+	})
+}
+
+// MonitorWithOptions returns a channel that outputs strings containing the log
+// messages coming from the server, filtered according to the given input.
+func (c *Sys) MonitorWithOptions(ctx context.Context, input *MonitorInput) (chan string, error) {
+	if input == nil {
+		input = &MonitorInput{}
+	}
+
 	r := c.c.NewRequest(http.MethodGet, "/v1/sys/monitor")
 
-	if logLevel == "" {
+	if input.LogLevel == "" {
 		r.Params.Add("log_level", "info")
 	} else {
-		r.Params.Add("log_level", logLevel)
+		r.Params.Add("log_level", input.LogLevel)
 	}
 
-	if logFormat == "" {
+	if input.LogFormat == "" {
 		r.Params.Add("log_format", "standard")
 	} else {
-		r.Params.Add("log_format", logFormat)
+		r.Params.Add("log_format", input.LogFormat)
+	}
+
+	if len(input.Subsystems) > 0 {
+		r.Params.Add("subsystems", strings.Join(input.Subsystems, ","))
+	}
+
+	if input.SampleRate != 0 {
+		r.Params.Add("sample_rate", strconv.FormatFloat(input.SampleRate, 'f', -1, 64))
 	}
 
 	resp, err := c.c.RawRequestWithContext(ctx, r)
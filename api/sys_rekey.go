@@ -436,6 +436,7 @@ type RekeyInitRequest struct {
 	PGPKeys             []string `json:"pgp_keys"`
 	Backup              bool
 	RequireVerification bool `json:"require_verification"`
+	RotateShares        bool `json:"rotate_shares"`
 }
 
 type RekeyStatusResponse struct {
@@ -449,6 +450,7 @@ type RekeyStatusResponse struct {
 	Backup               bool     `json:"backup"`
 	VerificationRequired bool     `json:"verification_required"`
 	VerificationNonce    string   `json:"verification_nonce"`
+	RotateShares         bool     `json:"rotate_shares"`
 }
 
 type RekeyUpdateResponse struct {
@@ -0,0 +1,77 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// SecretsManifestItem is a reference to a secret in a secrets manifest, along
+// with how to render its value.
+type SecretsManifestItem struct {
+	Name     string                 `json:"name"`
+	Path     string                 `json:"path"`
+	Method   string                 `json:"method,omitempty"`
+	Args     map[string]interface{} `json:"args,omitempty"`
+	Key      string                 `json:"key,omitempty"`
+	Template string                 `json:"template,omitempty"`
+}
+
+// SecretsManifestResult is the rendered value of an item of a secrets
+// manifest, or the error which prevented it from being rendered.
+type SecretsManifestResult struct {
+	Name          string `json:"name"`
+	Value         string `json:"value"`
+	Version       int64  `json:"version"`
+	LeaseID       string `json:"lease_id"`
+	LeaseDuration int    `json:"lease_duration"`
+	Renewable     bool   `json:"renewable"`
+	Error         string `json:"error"`
+}
+
+// SecretsManifest renders the values of all the items of the manifest in a
+// single request.
+func (c *Sys) SecretsManifest(items []*SecretsManifestItem) ([]*SecretsManifestResult, error) {
+	return c.SecretsManifestWithContext(context.Background(), items)
+}
+
+func (c *Sys) SecretsManifestWithContext(ctx context.Context, items []*SecretsManifestItem) ([]*SecretsManifestResult, error) {
+	ctx, cancelFunc := c.c.withConfiguredTimeout(ctx)
+	defer cancelFunc()
+
+	r := c.c.NewRequest(http.MethodPost, "/v1/sys/secrets-manifest")
+	if err := r.SetJSONBody(map[string]interface{}{
+		"items": items,
+	}); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.c.rawRequestWithContext(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	secret, err := ParseSecret(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, errors.New("data from server response is empty")
+	}
+
+	raw, err := json.Marshal(secret.Data["items"])
+	if err != nil {
+		return nil, err
+	}
+	var results []*SecretsManifestResult
+	if err := json.Unmarshal(raw, &results); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
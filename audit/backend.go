@@ -6,8 +6,10 @@ package audit
 import (
 	"context"
 	"fmt"
+	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/hashicorp/eventlogger"
 	"github.com/hashicorp/go-hclog"
@@ -28,11 +30,15 @@ const (
 	optionPrefix             = "prefix"
 
 	TypeFile   = "file"
+	TypeKafka  = "kafka"
 	TypeSocket = "socket"
 	TypeSyslog = "syslog"
 )
 
-var _ Backend = (*backend)(nil)
+var (
+	_ Backend     = (*backend)(nil)
+	_ SaltRotator = (*backend)(nil)
+)
 
 // Factory is the factory function to create an audit backend.
 type Factory func(*BackendConfig, HeaderFormatter) (Backend, error)
@@ -75,7 +81,7 @@ type Salter interface {
 
 // backend represents an audit backend's shared fields across supported devices (file, socket, syslog).
 // NOTE: Use newBackend to initialize the backend.
-// e.g. within NewFileBackend, NewSocketBackend, NewSyslogBackend.
+// e.g. within NewFileBackend, NewKafkaBackend, NewSocketBackend, NewSyslogBackend.
 type backend struct {
 	*backendEnt
 	name       string
@@ -120,6 +126,30 @@ func newBackend(headersConfig HeaderFormatter, conf *BackendConfig) (*backend, e
 	return b, nil
 }
 
+// configureFilterNode is used to configure a filter node and associated ID on the Backend.
+// Empty (including whitespace) filters skip the configuration of the node.
+func (b *backend) configureFilterNode(filter string) error {
+	filter = strings.TrimSpace(filter)
+	if filter == "" {
+		return nil
+	}
+
+	filterNodeID, err := event.GenerateNodeID()
+	if err != nil {
+		return fmt.Errorf("error generating random NodeID for filter node: %w: %w", ErrInternal, err)
+	}
+
+	filterNode, err := newEntryFilter(filter)
+	if err != nil {
+		return fmt.Errorf("error creating filter node: %w", err)
+	}
+
+	b.nodeIDList = append(b.nodeIDList, filterNodeID)
+	b.nodeMap[filterNodeID] = filterNode
+
+	return nil
+}
+
 // configureFormatterNode is used to configure a formatter node and associated ID on the Backend.
 func (b *backend) configureFormatterNode(name string, formatConfig formatterConfig, logger hclog.Logger) error {
 	formatterNodeID, err := event.GenerateNodeID()
@@ -191,6 +221,30 @@ func (b *backend) Salt(ctx context.Context) (*salt.Salt, error) {
 	return newSalt, nil
 }
 
+// RotateSalt replaces the salt used for HMAC'ing data with a new one, keeping
+// the current salt available via PreviousSalt for the duration of the overlap.
+func (b *backend) RotateSalt(ctx context.Context, overlap time.Duration) error {
+	b.saltMutex.Lock()
+	defer b.saltMutex.Unlock()
+
+	if err := rotateSalt(ctx, b.saltView, b.saltConfig, overlap); err != nil {
+		return err
+	}
+
+	// Clear the loaded salt so the rotated salt is read from storage.
+	b.salt.Store((*salt.Salt)(nil))
+	return nil
+}
+
+// PreviousSalt returns the salt replaced by the most recent rotation, if it
+// is still within its overlap window.
+func (b *backend) PreviousSalt(ctx context.Context) (*RotatedSalt, error) {
+	b.saltMutex.RLock()
+	defer b.saltMutex.RUnlock()
+
+	return previousSalt(ctx, b.saltView, b.saltConfig)
+}
+
 // EventType returns the event type for the backend.
 func (b *backend) EventType() eventlogger.EventType {
 	return event.AuditType.AsEventType()
@@ -256,7 +310,6 @@ func hasEnterpriseAuditOptions(options map[string]string) bool {
 	enterpriseAuditOptions := []string{
 		optionExclude,
 		optionFallback,
-		optionFilter,
 	}
 
 	for _, o := range enterpriseAuditOptions {
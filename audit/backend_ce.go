@@ -17,11 +17,6 @@ func (b *backendEnt) IsFallback() bool {
 	return false
 }
 
-// configureFilterNode is a no-op as filters are an Enterprise-only feature.
-func (b *backend) configureFilterNode(_ string) error {
-	return nil
-}
-
 func (b *backend) getMetricLabeler() event.Labeler {
 	return &metricLabelerAuditSink{}
 }
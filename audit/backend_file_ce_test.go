@@ -8,7 +8,6 @@ package audit
 import (
 	"testing"
 
-	"github.com/hashicorp/eventlogger"
 	"github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/vault/sdk/helper/salt"
 	"github.com/hashicorp/vault/sdk/logical"
@@ -16,8 +15,8 @@ import (
 )
 
 // TestFileBackend_newFileBackend_fallback ensures that we get the correct errors
-// in CE when we try to enable a fileBackend with enterprise options like fallback
-// and filter.
+// in CE when we try to enable a fileBackend with the enterprise fallback option,
+// whether or not a filter is also supplied.
 func TestFileBackend_newFileBackend_fallback(t *testing.T) {
 	t.Parallel()
 
@@ -75,49 +74,6 @@ func TestFileBackend_newFileBackend_fallback(t *testing.T) {
 	}
 }
 
-// TestFileBackend_newFileBackend_FilterFormatterSink ensures that when configuring
-// a backend in community edition we cannot configure a filter node.
-// We can verify that we have formatter and sink nodes added to the backend.
-// The order of calls influences the slice of IDs on the Backend.
-func TestFileBackend_newFileBackend_FilterFormatterSink(t *testing.T) {
-	t.Parallel()
-
-	cfg := map[string]string{
-		"file_path": "/tmp/foo",
-		"mode":      "0777",
-		"format":    "json",
-		"filter":    "mount_type == \"kv\"",
-	}
-
-	backendConfig := &BackendConfig{
-		SaltView:   &logical.InmemStorage{},
-		SaltConfig: &salt.Config{},
-		Config:     cfg,
-		MountPath:  "bar",
-		Logger:     hclog.NewNullLogger(),
-	}
-
-	b, err := newFileBackend(backendConfig, &noopHeaderFormatter{})
-	require.Error(t, err)
-	require.EqualError(t, err, "enterprise-only options supplied: invalid configuration")
-
-	// Try without filter option
-	delete(cfg, "filter")
-	b, err = newFileBackend(backendConfig, &noopHeaderFormatter{})
-	require.NoError(t, err)
-
-	require.Len(t, b.nodeIDList, 2)
-	require.Len(t, b.nodeMap, 2)
-
-	id := b.nodeIDList[0]
-	node := b.nodeMap[id]
-	require.Equal(t, eventlogger.NodeTypeFormatter, node.Type())
-
-	id = b.nodeIDList[1]
-	node = b.nodeMap[id]
-	require.Equal(t, eventlogger.NodeTypeSink, node.Type())
-}
-
 // TestBackend_IsFallback ensures that no CE audit device can be a fallback.
 func TestBackend_IsFallback(t *testing.T) {
 	t.Parallel()
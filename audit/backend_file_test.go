@@ -287,3 +287,49 @@ func TestFileBackend_newFileBackend(t *testing.T) {
 		})
 	}
 }
+
+// TestFileBackend_newFileBackend_FilterFormatterSink ensures that when configuring
+// a backend with a filter we get filter, formatter and sink nodes, in that order.
+func TestFileBackend_newFileBackend_FilterFormatterSink(t *testing.T) {
+	t.Parallel()
+
+	cfg := map[string]string{
+		"file_path": "/tmp/foo",
+		"mode":      "0777",
+		"format":    "json",
+		"filter":    "mount_type == \"kv\"",
+	}
+
+	backendConfig := &BackendConfig{
+		SaltView:   &logical.InmemStorage{},
+		SaltConfig: &salt.Config{},
+		Config:     cfg,
+		MountPath:  "bar",
+		Logger:     hclog.NewNullLogger(),
+	}
+
+	b, err := newFileBackend(backendConfig, &noopHeaderFormatter{})
+	require.NoError(t, err)
+	require.True(t, b.HasFiltering())
+
+	require.Len(t, b.nodeIDList, 3)
+	require.Len(t, b.nodeMap, 3)
+
+	id := b.nodeIDList[0]
+	node := b.nodeMap[id]
+	require.Equal(t, eventlogger.NodeTypeFilter, node.Type())
+
+	id = b.nodeIDList[1]
+	node = b.nodeMap[id]
+	require.Equal(t, eventlogger.NodeTypeFormatter, node.Type())
+
+	id = b.nodeIDList[2]
+	node = b.nodeMap[id]
+	require.Equal(t, eventlogger.NodeTypeSink, node.Type())
+
+	// An invalid filter cannot be configured.
+	cfg["filter"] = "foo == bar"
+	_, err = newFileBackend(backendConfig, &noopHeaderFormatter{})
+	require.Error(t, err)
+	require.ErrorIs(t, err, ErrExternalOptions)
+}
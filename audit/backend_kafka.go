@@ -0,0 +1,206 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package audit
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/hashicorp/go-secure-stdlib/parseutil"
+	"github.com/hashicorp/vault/internal/observability/event"
+)
+
+const (
+	optionBrokers          = "brokers"
+	optionTopic            = "topic"
+	optionBatchSize        = "batch_size"
+	optionBatchTimeout     = "batch_timeout"
+	optionFailOpen         = "fail_open"
+	optionTLS              = "tls"
+	optionTLSCAFile        = "tls_ca_file"
+	optionTLSCertFile      = "tls_cert_file"
+	optionTLSKeyFile       = "tls_key_file"
+	optionTLSSkipVerify    = "tls_skip_verify"
+	optionSASLMechanism    = "sasl_mechanism"
+	optionSASLUsername     = "sasl_username"
+	optionSASLPasswordFile = "sasl_password_file"
+)
+
+var _ Backend = (*kafkaBackend)(nil)
+
+type kafkaBackend struct {
+	*backend
+}
+
+// NewKafkaBackend provides a means to create Kafka backend audit devices that
+// satisfy the Factory pattern expected elsewhere in Vault.
+func NewKafkaBackend(conf *BackendConfig, headersConfig HeaderFormatter) (be Backend, err error) {
+	be, err = newKafkaBackend(conf, headersConfig)
+	return
+}
+
+// newKafkaBackend creates a backend and configures all nodes including a Kafka sink.
+func newKafkaBackend(conf *BackendConfig, headersConfig HeaderFormatter) (*kafkaBackend, error) {
+	if headersConfig == nil || reflect.ValueOf(headersConfig).IsNil() {
+		return nil, fmt.Errorf("nil header formatter: %w", ErrInvalidParameter)
+	}
+	if conf == nil {
+		return nil, fmt.Errorf("nil config: %w", ErrInvalidParameter)
+	}
+	if err := conf.Validate(); err != nil {
+		return nil, err
+	}
+
+	bec, err := newBackend(headersConfig, conf)
+	if err != nil {
+		return nil, err
+	}
+
+	var brokers []string
+	for _, broker := range strings.Split(conf.Config[optionBrokers], ",") {
+		if broker = strings.TrimSpace(broker); broker != "" {
+			brokers = append(brokers, broker)
+		}
+	}
+	if len(brokers) == 0 {
+		return nil, fmt.Errorf("%q is required: %w", optionBrokers, ErrExternalOptions)
+	}
+
+	topic := strings.TrimSpace(conf.Config[optionTopic])
+	if topic == "" {
+		return nil, fmt.Errorf("%q is required: %w", optionTopic, ErrExternalOptions)
+	}
+
+	writeDeadline, ok := conf.Config[optionWriteTimeout]
+	if !ok {
+		writeDeadline = "2s"
+	}
+
+	tlsConfig, err := newKafkaTLSConfig(conf.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	var saslPassword string
+	if passwordFile := strings.TrimSpace(conf.Config[optionSASLPasswordFile]); passwordFile != "" {
+		password, err := os.ReadFile(passwordFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read %q: %w: %w", optionSASLPasswordFile, ErrExternalOptions, err)
+		}
+		saslPassword = strings.TrimSpace(string(password))
+	}
+
+	sinkOpts := []event.Option{
+		event.WithMaxDuration(writeDeadline),
+		event.WithBatchSize(conf.Config[optionBatchSize]),
+		event.WithBatchTimeout(conf.Config[optionBatchTimeout]),
+		event.WithFailOpen(conf.Config[optionFailOpen]),
+		event.WithTLSConfig(tlsConfig),
+		event.WithSASL(conf.Config[optionSASLMechanism], conf.Config[optionSASLUsername], saslPassword),
+		event.WithLogger(conf.Logger),
+	}
+
+	err = event.ValidateOptions(sinkOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &kafkaBackend{backend: bec}
+
+	// Configure the sink.
+	cfg, err := newFormatterConfig(headersConfig, conf.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	err = b.configureSinkNode(conf.MountPath, brokers, topic, cfg.requiredFormat, sinkOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// newKafkaTLSConfig returns the TLS configuration to connect to the brokers
+// with, or nil if TLS is not enabled.
+func newKafkaTLSConfig(config map[string]string) (*tls.Config, error) {
+	enabled := false
+	if v, ok := config[optionTLS]; ok {
+		var err error
+		enabled, err = parseutil.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse %q: %w: %w", optionTLS, ErrExternalOptions, err)
+		}
+	}
+	if !enabled {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if v, ok := config[optionTLSSkipVerify]; ok {
+		skipVerify, err := parseutil.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse %q: %w: %w", optionTLSSkipVerify, ErrExternalOptions, err)
+		}
+		tlsConfig.InsecureSkipVerify = skipVerify
+	}
+
+	if caFile := strings.TrimSpace(config[optionTLSCAFile]); caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read %q: %w: %w", optionTLSCAFile, ErrExternalOptions, err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %q: %w", optionTLSCAFile, ErrExternalOptions)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	certFile := strings.TrimSpace(config[optionTLSCertFile])
+	keyFile := strings.TrimSpace(config[optionTLSKeyFile])
+	switch {
+	case certFile != "" && keyFile != "":
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load client certificate: %w: %w", ErrExternalOptions, err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	case certFile != "" || keyFile != "":
+		return nil, fmt.Errorf("%q and %q must be set together: %w", optionTLSCertFile, optionTLSKeyFile, ErrExternalOptions)
+	}
+
+	return tlsConfig, nil
+}
+
+func (b *kafkaBackend) configureSinkNode(name string, brokers []string, topic string, format format, opts ...event.Option) error {
+	sinkNodeID, err := event.GenerateNodeID()
+	if err != nil {
+		return fmt.Errorf("error generating random NodeID for sink node: %w", err)
+	}
+
+	n, err := event.NewKafkaSink(brokers, topic, format.String(), opts...)
+	if err != nil {
+		return err
+	}
+
+	// Wrap the sink node with metrics middleware
+	err = b.wrapMetrics(name, sinkNodeID, n)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Reload is a no-op for the Kafka backend, as connections to the brokers are
+// re-established as required.
+func (b *kafkaBackend) Reload() error {
+	return nil
+}
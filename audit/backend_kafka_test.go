@@ -0,0 +1,122 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package audit
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/eventlogger"
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/internal/observability/event"
+	"github.com/hashicorp/vault/sdk/helper/salt"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+// TestKafkaBackend_newKafkaBackend ensures that we can correctly configure the sink
+// node on the Backend, and any incorrect parameters result in the relevant errors.
+func TestKafkaBackend_newKafkaBackend(t *testing.T) {
+	t.Parallel()
+
+	passwordFile := filepath.Join(t.TempDir(), "password")
+	require.NoError(t, os.WriteFile(passwordFile, []byte("hunter2\n"), 0o600))
+
+	tests := map[string]struct {
+		mountPath      string
+		options        map[string]string
+		wantErr        bool
+		expectedErrMsg string
+		expectedName   string
+	}{
+		"name-empty": {
+			mountPath:      "",
+			options:        map[string]string{"brokers": "localhost:9092", "topic": "vault"},
+			wantErr:        true,
+			expectedErrMsg: "mount path cannot be empty: invalid configuration",
+		},
+		"brokers-empty": {
+			mountPath:      "foo",
+			options:        map[string]string{"brokers": " , ", "topic": "vault"},
+			wantErr:        true,
+			expectedErrMsg: "\"brokers\" is required: invalid configuration",
+		},
+		"topic-empty": {
+			mountPath:      "foo",
+			options:        map[string]string{"brokers": "localhost:9092", "topic": "   "},
+			wantErr:        true,
+			expectedErrMsg: "\"topic\" is required: invalid configuration",
+		},
+		"batch-size-not-valid": {
+			mountPath:      "foo",
+			options:        map[string]string{"brokers": "localhost:9092", "topic": "vault", "batch_size": "0"},
+			wantErr:        true,
+			expectedErrMsg: "batch size must be at least 1: invalid parameter",
+		},
+		"sasl-mechanism-not-valid": {
+			mountPath:      "foo",
+			options:        map[string]string{"brokers": "localhost:9092", "topic": "vault", "sasl_mechanism": "gssapi", "sasl_username": "vault"},
+			wantErr:        true,
+			expectedErrMsg: "unsupported SASL mechanism \"gssapi\": invalid parameter",
+		},
+		"sasl-password-file-missing": {
+			mountPath:      "foo",
+			options:        map[string]string{"brokers": "localhost:9092", "topic": "vault", "sasl_mechanism": "plain", "sasl_username": "vault", "sasl_password_file": "/does/not/exist"},
+			wantErr:        true,
+			expectedErrMsg: "unable to read \"sasl_password_file\": invalid configuration: open /does/not/exist: no such file or directory",
+		},
+		"tls-cert-without-key": {
+			mountPath:      "foo",
+			options:        map[string]string{"brokers": "localhost:9092", "topic": "vault", "tls": "true", "tls_cert_file": "/path/to/cert.pem"},
+			wantErr:        true,
+			expectedErrMsg: "\"tls_cert_file\" and \"tls_key_file\" must be set together: invalid configuration",
+		},
+		"happy-sasl-tls": {
+			mountPath:    "foo",
+			options:      map[string]string{"brokers": "localhost:9092,localhost:9093", "topic": "vault", "tls": "true", "sasl_mechanism": "scram-sha-512", "sasl_username": "vault", "sasl_password_file": passwordFile},
+			expectedName: "foo",
+		},
+		"happy": {
+			mountPath:    "foo",
+			options:      map[string]string{"brokers": "localhost:9092", "topic": "vault", "batch_size": "10", "batch_timeout": "50ms", "fail_open": "true"},
+			expectedName: "foo",
+		},
+	}
+
+	for name, tc := range tests {
+		name := name
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := &BackendConfig{
+				SaltView:   &logical.InmemStorage{},
+				SaltConfig: &salt.Config{},
+				Logger:     hclog.NewNullLogger(),
+				Config:     tc.options,
+				MountPath:  tc.mountPath,
+			}
+			b, err := newKafkaBackend(cfg, &noopHeaderFormatter{})
+
+			if tc.wantErr {
+				require.Error(t, err)
+				require.EqualError(t, err, tc.expectedErrMsg)
+				require.Nil(t, b)
+			} else {
+				require.NoError(t, err)
+				require.Len(t, b.nodeIDList, 2) // formatter + sink
+				require.Len(t, b.nodeMap, 2)
+				id := b.nodeIDList[1] // sink is 2nd
+				node := b.nodeMap[id]
+				require.Equal(t, eventlogger.NodeTypeSink, node.Type())
+				mc, ok := node.(*event.MetricsCounter)
+				require.True(t, ok)
+				require.Equal(t, tc.expectedName, mc.Name)
+				require.NoError(t, eventlogger.NewNodeController(node).Close(context.Background()))
+			}
+		})
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/eventlogger"
 	"github.com/hashicorp/vault/helper/testhelpers/corehelpers"
@@ -22,6 +23,7 @@ import (
 
 var (
 	_ Backend          = (*NoopAudit)(nil)
+	_ SaltRotator      = (*NoopAudit)(nil)
 	_ eventlogger.Node = (*noopWrapper)(nil)
 )
 
@@ -309,6 +311,22 @@ func (n *NoopAudit) GetHash(ctx context.Context, data string) (string, error) {
 	return s.GetIdentifiedHMAC(data), nil
 }
 
+func (n *NoopAudit) RotateSalt(ctx context.Context, overlap time.Duration) error {
+	n.saltMutex.Lock()
+	defer n.saltMutex.Unlock()
+	if err := rotateSalt(ctx, n.Config.SaltView, n.Config.SaltConfig, overlap); err != nil {
+		return err
+	}
+	n.salt = nil
+	return nil
+}
+
+func (n *NoopAudit) PreviousSalt(ctx context.Context) (*RotatedSalt, error) {
+	n.saltMutex.RLock()
+	defer n.saltMutex.RUnlock()
+	return previousSalt(ctx, n.Config.SaltView, n.Config.SaltConfig)
+}
+
 func (n *NoopAudit) Reload() error {
 	return nil
 }
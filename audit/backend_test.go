@@ -146,6 +146,77 @@ func TestBackend_configureFormatterNode(t *testing.T) {
 	require.Equal(t, eventlogger.NodeTypeFormatter, node.Type())
 }
 
+// TestBackend_configureFilterNode ensures that configureFilterNode handles various
+// filter values as expected. Empty (including whitespace) strings should return
+// no error but skip configuration of the node.
+func TestBackend_configureFilterNode(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		filter           string
+		shouldSkipNode   bool
+		wantErr          bool
+		expectedErrorMsg string
+	}{
+		"happy": {
+			filter: "operation == \"update\"",
+		},
+		"empty": {
+			filter:         "",
+			shouldSkipNode: true,
+		},
+		"spacey": {
+			filter:         "    ",
+			shouldSkipNode: true,
+		},
+		"bad": {
+			filter:           "___qwerty",
+			wantErr:          true,
+			expectedErrorMsg: "error creating filter node: cannot create new audit filter",
+		},
+		"unsupported-field": {
+			filter:           "foo == bar",
+			wantErr:          true,
+			expectedErrorMsg: "filter references an unsupported field: foo == bar",
+		},
+	}
+	for name, tc := range tests {
+		name := name
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			b := &backend{
+				nodeIDList: []eventlogger.NodeID{},
+				nodeMap:    map[eventlogger.NodeID]eventlogger.Node{},
+			}
+
+			err := b.configureFilterNode(tc.filter)
+
+			switch {
+			case tc.wantErr:
+				require.Error(t, err)
+				require.ErrorContains(t, err, tc.expectedErrorMsg)
+				require.ErrorIs(t, err, ErrExternalOptions)
+				require.Len(t, b.nodeIDList, 0)
+				require.Len(t, b.nodeMap, 0)
+			case tc.shouldSkipNode:
+				require.NoError(t, err)
+				require.Len(t, b.nodeIDList, 0)
+				require.Len(t, b.nodeMap, 0)
+			default:
+				require.NoError(t, err)
+				require.Len(t, b.nodeIDList, 1)
+				require.Len(t, b.nodeMap, 1)
+				id := b.nodeIDList[0]
+				node := b.nodeMap[id]
+				require.Equal(t, eventlogger.NodeTypeFilter, node.Type())
+				require.True(t, b.HasFiltering())
+			}
+		})
+	}
+}
+
 // TestBackend_hasEnterpriseAuditOptions checks that the existence of any Enterprise
 // only options in the options which can be supplied to enable an audit device can
 // be flagged.
@@ -169,11 +240,11 @@ func TestBackend_hasEnterpriseAuditOptions(t *testing.T) {
 			},
 			expected: false,
 		},
-		"ent-opt-filter": {
+		"opt-filter": {
 			input: map[string]string{
 				"filter": "mount_type == kv",
 			},
-			expected: true,
+			expected: false,
 		},
 		"ent-opt-fallback": {
 			input: map[string]string{
@@ -213,7 +284,7 @@ func TestBackend_hasEnterpriseAuditOptions(t *testing.T) {
 // an Enterprise or non-Enterprise version of Vault, the options supplied to enable
 // an audit device may or may not be valid.
 // NOTE: In the non-Enterprise version of Vault supplying audit options such as
-// 'filter' or 'fallback' is not allowed.
+// 'fallback' or 'exclude' is not allowed.
 func TestBackend_hasInvalidAuditOptions(t *testing.T) {
 	tests := map[string]struct {
 		input    map[string]string
@@ -225,17 +296,11 @@ func TestBackend_hasInvalidAuditOptions(t *testing.T) {
 			},
 			expected: false,
 		},
-		"ent-opt": {
+		"opt-filter": {
 			input: map[string]string{
 				"filter": "mount_type == kv",
 			},
-			expected: !constants.IsEnterprise,
-		},
-		"ent-opt-filter": {
-			input: map[string]string{
-				"filter": "mount_type == kv",
-			},
-			expected: !constants.IsEnterprise,
+			expected: false,
 		},
 		"ent-opt-fallback": {
 			input: map[string]string{
@@ -133,12 +133,9 @@ func (b *Broker) register(backend Backend) error {
 		return fmt.Errorf("audit pipeline registration error: %w", err)
 	}
 
-	threshold := 0
-	if !backend.HasFiltering() {
-		threshold = 1
-	} else {
-		threshold = b.requiredSuccessThresholdSinks()
-	}
+	// A sink is required to succeed now that a device is registered, whether
+	// it is filtered or not.
+	threshold := 1
 
 	// Update the success threshold now that the pipeline is registered.
 	err = b.broker.SetSuccessThresholdSinks(event.AuditType.AsEventType(), threshold)
@@ -287,7 +284,7 @@ func (b *Broker) LogRequest(ctx context.Context, in *logical.LogInput) (retErr e
 	var status eventlogger.Status
 	if hasAuditPipelines(b.broker) {
 		status, err = b.broker.Send(auditContext, event.AuditType.AsEventType(), e)
-		if err != nil {
+		if err != nil && !b.filteredOut(auditContext, status) {
 			return errors.Join(append([]error{err}, status.Warnings...)...)
 		}
 	}
@@ -354,7 +351,7 @@ func (b *Broker) LogResponse(ctx context.Context, in *logical.LogInput) (retErr
 	var status eventlogger.Status
 	if hasAuditPipelines(b.broker) {
 		status, err = b.broker.Send(auditContext, event.AuditType.AsEventType(), e)
-		if err != nil {
+		if err != nil && !b.filteredOut(auditContext, status) {
 			return errors.Join(append([]error{err}, status.Warnings...)...)
 		}
 	}
@@ -384,6 +381,18 @@ func (b *Broker) LogResponse(ctx context.Context, in *logical.LogInput) (retErr
 	return nil
 }
 
+// filteredOut determines whether the event was filtered out by the filter
+// node of every registered device, rather than failing to reach their sinks, in
+// which case it is not an audit failure even though no sink succeeded.
+// NOTE: filteredOut assumes that the broker's lock is held.
+func (b *Broker) filteredOut(ctx context.Context, status eventlogger.Status) bool {
+	if ctx.Err() != nil || len(status.Warnings) > 0 || len(status.CompleteSinks()) > 0 {
+		return false
+	}
+
+	return len(status.Complete()) == len(b.backends)
+}
+
 func (b *Broker) Invalidate(ctx context.Context, _ string) {
 	// For now, we ignore the key as this would only apply to salts.
 	// We just sort of brute force it on each one.
@@ -421,6 +430,91 @@ func (b *Broker) GetHash(ctx context.Context, name string, input string) (string
 	return hashString(ctx, be.backend, input)
 }
 
+// Hashes holds the hashes of a set of inputs using the salt of an audit backend.
+// During the overlap window following a salt rotation it also holds the hashes
+// of the inputs using the previous salt, so that new values can be correlated
+// with those recorded before the rotation.
+type Hashes struct {
+	Hashes            []string
+	PreviousHashes    []string
+	PreviousExpiresAt time.Time
+}
+
+// GetHashes returns the hashes of each of the inputs using the salt of the
+// given backend, and its previous salt if it is still within its overlap window.
+func (b *Broker) GetHashes(ctx context.Context, name string, inputs []string) (*Hashes, error) {
+	b.RLock()
+	defer b.RUnlock()
+
+	be, ok := b.backends[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown audit backend %q", name)
+	}
+
+	s, err := be.backend.Salt(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Hashes{
+		Hashes: make([]string, 0, len(inputs)),
+	}
+	for _, input := range inputs {
+		result.Hashes = append(result.Hashes, s.GetIdentifiedHMAC(input))
+	}
+
+	rotator, ok := be.backend.(SaltRotator)
+	if !ok {
+		return result, nil
+	}
+
+	previous, err := rotator.PreviousSalt(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if previous == nil {
+		return result, nil
+	}
+
+	result.PreviousHashes = make([]string, 0, len(inputs))
+	for _, input := range inputs {
+		result.PreviousHashes = append(result.PreviousHashes, previous.GetIdentifiedHMAC(input))
+	}
+	result.PreviousExpiresAt = previous.ExpiresAt
+
+	return result, nil
+}
+
+// RotateSalt rotates the salt of the given backend, keeping the previous salt
+// available for hashing for the duration of the overlap.
+func (b *Broker) RotateSalt(ctx context.Context, name string, overlap time.Duration) error {
+	b.RLock()
+	defer b.RUnlock()
+
+	be, ok := b.backends[name]
+	if !ok {
+		return fmt.Errorf("unknown audit backend %q", name)
+	}
+
+	rotator, ok := be.backend.(SaltRotator)
+	if !ok {
+		return fmt.Errorf("audit backend %q does not support salt rotation: %w", name, ErrInvalidParameter)
+	}
+
+	return rotator.RotateSalt(ctx, overlap)
+}
+
+// InvalidateSalt drops the loaded salt of the given backend so that it is read
+// again from storage, following a rotation of the salt by another node.
+func (b *Broker) InvalidateSalt(ctx context.Context, name string) {
+	b.RLock()
+	defer b.RUnlock()
+
+	if be, ok := b.backends[name]; ok {
+		be.backend.Invalidate(ctx)
+	}
+}
+
 // IsRegistered is used to check if a given audit backend is registered.
 func (b *Broker) IsRegistered(name string) bool {
 	b.RLock()
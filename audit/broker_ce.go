@@ -35,7 +35,9 @@ func (b *Broker) handlePipelineDeregistration(ctx context.Context, name string)
 }
 
 // requiredSuccessThresholdSinks is the value that should be used as the success
-// threshold in the eventlogger broker.
+// threshold in the eventlogger broker. A sink is required to succeed whenever a
+// device is enabled, including filtered ones: events filtered out by every
+// device are told apart from sink failures by filteredOut.
 func (b *Broker) requiredSuccessThresholdSinks() int {
 	if len(b.backends) > 0 {
 		return 1
@@ -6,6 +6,9 @@ package audit
 import (
 	"context"
 	"crypto/sha256"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -88,6 +91,94 @@ func TestAuditBroker_Register_MultipleFails(t *testing.T) {
 	require.EqualError(t, err, "backend already registered 'b2-no-filter': invalid configuration")
 }
 
+// TestAuditBroker_LogRequest_Filtered ensures that a filtered device only
+// writes the entries matching its filter, and that requests filtered out by
+// every device are not treated as audit failures.
+func TestAuditBroker_LogRequest_Filtered(t *testing.T) {
+	t.Parallel()
+
+	filePath := filepath.Join(t.TempDir(), "audit.log")
+	be, err := NewFileBackend(&BackendConfig{
+		Config: map[string]string{
+			"file_path": filePath,
+			"filter":    `operation == "update"`,
+		},
+		MountPath:  "filtered",
+		SaltConfig: &salt.Config{},
+		SaltView:   &logical.InmemStorage{},
+		Logger:     hclog.NewNullLogger(),
+	}, &noopHeaderFormatter{})
+	require.NoError(t, err)
+
+	broker, err := NewBroker(corehelpers.NewTestLogger(t))
+	require.NoError(t, err)
+	require.NoError(t, broker.Register(be, false))
+
+	ctx := nshelper.RootContext(context.Background())
+	for _, op := range []logical.Operation{logical.ReadOperation, logical.UpdateOperation} {
+		err = broker.LogRequest(ctx, &logical.LogInput{
+			Request: &logical.Request{
+				Operation: op,
+				Path:      "secret/foo",
+			},
+		})
+		require.NoError(t, err)
+	}
+
+	logged, err := os.ReadFile(filePath)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(logged)), "\n")
+	require.Len(t, lines, 1)
+	require.Contains(t, lines[0], `"operation":"update"`)
+}
+
+// TestAuditBroker_LogRequest_FilteredSinkFailure ensures that a request which
+// matches the filter of a device but fails to be written by its sink is treated
+// as an audit failure, even when every enabled device is filtered.
+func TestAuditBroker_LogRequest_FilteredSinkFailure(t *testing.T) {
+	t.Parallel()
+
+	filePath := filepath.Join(t.TempDir(), "audit.log")
+	be, err := NewFileBackend(&BackendConfig{
+		Config: map[string]string{
+			"file_path": filePath,
+			"filter":    `operation == "update"`,
+		},
+		MountPath:  "filtered",
+		SaltConfig: &salt.Config{},
+		SaltView:   &logical.InmemStorage{},
+		Logger:     hclog.NewNullLogger(),
+	}, &noopHeaderFormatter{})
+	require.NoError(t, err)
+
+	broker, err := NewBroker(corehelpers.NewTestLogger(t))
+	require.NoError(t, err)
+	require.NoError(t, broker.Register(be, false))
+
+	// Replace the log file with a directory, so that the sink fails to reopen
+	// it.
+	require.NoError(t, os.Remove(filePath))
+	require.NoError(t, os.Mkdir(filePath, 0o700))
+	require.Error(t, be.Reload())
+
+	ctx := nshelper.RootContext(context.Background())
+	err = broker.LogRequest(ctx, &logical.LogInput{
+		Request: &logical.Request{
+			Operation: logical.ReadOperation,
+			Path:      "secret/foo",
+		},
+	})
+	require.NoError(t, err)
+
+	err = broker.LogRequest(ctx, &logical.LogInput{
+		Request: &logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      "secret/foo",
+		},
+	})
+	require.Error(t, err)
+}
+
 // BenchmarkAuditBroker_File_Request_DevNull Attempts to register a single `file`
 // audit device on the broker, which points at /dev/null.
 // It will then attempt to benchmark how long it takes Vault to complete logging
@@ -207,3 +298,57 @@ func TestBroker_getAuditContext(t *testing.T) {
 	auditCancel()
 	require.NotNil(t, auditContext.Err())
 }
+
+// TestBroker_RotateSalt ensures that rotating the salt of a backend changes the
+// hashes it produces, and that hashes using the previous salt are returned only
+// during the overlap window.
+func TestBroker_RotateSalt(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	l := corehelpers.NewTestLogger(t)
+	broker, err := NewBroker(l)
+	require.NoError(t, err)
+
+	be, err := newFileBackend(&BackendConfig{
+		MountPath:  "foo",
+		SaltConfig: &salt.Config{},
+		SaltView:   &logical.InmemStorage{},
+		Logger:     hclog.NewNullLogger(),
+		Config:     map[string]string{"file_path": discard},
+	}, &noopHeaderFormatter{})
+	require.NoError(t, err)
+	err = broker.Register(be, false)
+	require.NoError(t, err)
+
+	inputs := []string{"bar", "baz"}
+	before, err := broker.GetHashes(ctx, "foo", inputs)
+	require.NoError(t, err)
+	require.Len(t, before.Hashes, 2)
+	require.Empty(t, before.PreviousHashes)
+
+	hash, err := broker.GetHash(ctx, "foo", "bar")
+	require.NoError(t, err)
+	require.Equal(t, before.Hashes[0], hash)
+
+	err = broker.RotateSalt(ctx, "foo", time.Hour)
+	require.NoError(t, err)
+
+	after, err := broker.GetHashes(ctx, "foo", inputs)
+	require.NoError(t, err)
+	require.NotEqual(t, before.Hashes, after.Hashes)
+	require.Equal(t, before.Hashes, after.PreviousHashes)
+	require.WithinDuration(t, time.Now().Add(time.Hour), after.PreviousExpiresAt, time.Minute)
+
+	// Rotating without an overlap discards the previous salt immediately.
+	err = broker.RotateSalt(ctx, "foo", 0)
+	require.NoError(t, err)
+
+	final, err := broker.GetHashes(ctx, "foo", inputs)
+	require.NoError(t, err)
+	require.NotEqual(t, after.Hashes, final.Hashes)
+	require.Empty(t, final.PreviousHashes)
+
+	err = broker.RotateSalt(ctx, "unknown", time.Hour)
+	require.Error(t, err)
+}
@@ -366,8 +366,10 @@ func newRequest(req *logical.Request, ns *nshelper.Namespace) (*request, error)
 	return &request{
 		ClientCertificateSerialNumber: clientCertSerial,
 		ClientID:                      req.ClientID,
+		ClientName:                    req.ClientName,
 		ClientToken:                   req.ClientToken,
 		ClientTokenAccessor:           req.ClientTokenAccessor,
+		ClientVersion:                 req.ClientVersion,
 		Data:                          data,
 		Headers:                       headers,
 		ID:                            req.ID,
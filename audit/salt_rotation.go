@@ -0,0 +1,139 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"time"
+
+	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/sdk/helper/jsonutil"
+	"github.com/hashicorp/vault/sdk/helper/salt"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// previousSaltSuffix is appended to the salt's storage location to determine
+// where the salt which was replaced by the most recent rotation is kept.
+const previousSaltSuffix = "-previous"
+
+// SaltRotator is implemented by audit backends which support rotating the salt
+// used to HMAC sensitive values. Following a rotation the previous salt remains
+// available until the end of the requested overlap window, so that values
+// hashed before the rotation can still be correlated with new ones.
+type SaltRotator interface {
+	// RotateSalt replaces the backend's salt with a newly generated one,
+	// keeping the current salt available as the previous salt for overlap.
+	RotateSalt(ctx context.Context, overlap time.Duration) error
+
+	// PreviousSalt returns the salt replaced by the most recent rotation, or
+	// nil if there is no previous salt or its overlap window has ended.
+	PreviousSalt(ctx context.Context) (*RotatedSalt, error)
+}
+
+// RotatedSalt is a salt which has been replaced by a rotation, but which may
+// still be used to hash values until it expires.
+type RotatedSalt struct {
+	Value     string    `json:"value"`
+	ExpiresAt time.Time `json:"expires_at"`
+
+	hmacType string
+	hmac     func() hash.Hash
+}
+
+// GetIdentifiedHMAC is used to HMAC the supplied data with the rotated salt,
+// in the same format as salt.Salt.GetIdentifiedHMAC.
+func (s *RotatedSalt) GetIdentifiedHMAC(data string) string {
+	return salt.HMACIdentifiedValue(s.Value, data, s.hmacType, s.hmac)
+}
+
+// saltLocation returns the storage location of the salt for the config,
+// applying the same default as salt.NewSalt.
+func saltLocation(config *salt.Config) string {
+	if config == nil || config.Location == "" {
+		return salt.DefaultLocation
+	}
+
+	return config.Location
+}
+
+// rotateSalt generates a new salt in the supplied storage view, retaining the
+// current salt (if any) as the previous salt until the overlap has elapsed.
+func rotateSalt(ctx context.Context, view logical.Storage, config *salt.Config, overlap time.Duration) error {
+	if view == nil {
+		return fmt.Errorf("salt storage is not configured: %w", ErrInvalidParameter)
+	}
+	if overlap < 0 {
+		return fmt.Errorf("overlap cannot be negative: %w", ErrInvalidParameter)
+	}
+
+	location := saltLocation(config)
+
+	current, err := view.Get(ctx, location)
+	if err != nil {
+		return fmt.Errorf("failed to read salt: %w", err)
+	}
+
+	if current != nil && len(current.Value) > 0 && overlap > 0 {
+		previous, err := logical.StorageEntryJSON(location+previousSaltSuffix, &RotatedSalt{
+			Value:     string(current.Value),
+			ExpiresAt: time.Now().Add(overlap).UTC(),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to encode previous salt: %w", err)
+		}
+		if err := view.Put(ctx, previous); err != nil {
+			return fmt.Errorf("failed to persist previous salt: %w", err)
+		}
+	} else if err := view.Delete(ctx, location+previousSaltSuffix); err != nil {
+		return fmt.Errorf("failed to remove previous salt: %w", err)
+	}
+
+	newSalt, err := uuid.GenerateUUID()
+	if err != nil {
+		return fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	if err := view.Put(ctx, &logical.StorageEntry{Key: location, Value: []byte(newSalt)}); err != nil {
+		return fmt.Errorf("failed to persist salt: %w", err)
+	}
+
+	return nil
+}
+
+// previousSalt loads the salt replaced by the most recent rotation from the
+// supplied storage view, returning nil if it does not exist or has expired.
+func previousSalt(ctx context.Context, view logical.Storage, config *salt.Config) (*RotatedSalt, error) {
+	if view == nil {
+		return nil, nil
+	}
+
+	entry, err := view.Get(ctx, saltLocation(config)+previousSaltSuffix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read previous salt: %w", err)
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var rotated RotatedSalt
+	if err := jsonutil.DecodeJSON(entry.Value, &rotated); err != nil {
+		return nil, fmt.Errorf("failed to decode previous salt: %w", err)
+	}
+	if rotated.Value == "" || time.Now().After(rotated.ExpiresAt) {
+		return nil, nil
+	}
+
+	// Match the defaults applied by salt.NewSalt.
+	rotated.hmacType = "hmac-sha256"
+	rotated.hmac = sha256.New
+	if config != nil && config.HMAC != nil {
+		rotated.hmacType = config.HMACType
+		rotated.hmac = config.HMAC
+	}
+
+	return &rotated, nil
+}
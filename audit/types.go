@@ -23,8 +23,10 @@ type entry struct {
 type request struct {
 	ClientCertificateSerialNumber string                 `json:"client_certificate_serial_number,omitempty"`
 	ClientID                      string                 `json:"client_id,omitempty"`
+	ClientName                    string                 `json:"client_name,omitempty"`
 	ClientToken                   string                 `json:"client_token,omitempty"`
 	ClientTokenAccessor           string                 `json:"client_token_accessor,omitempty"`
+	ClientVersion                 string                 `json:"client_version,omitempty"`
 	Data                          map[string]interface{} `json:"data,omitempty"`
 	Headers                       map[string][]string    `json:"headers,omitempty"`
 	ID                            string                 `json:"id,omitempty"`
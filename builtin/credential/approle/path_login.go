@@ -134,7 +134,7 @@ func (b *backend) pathLoginUpdate(ctx context.Context, req *logical.Request, dat
 		return nil, err
 	}
 	if roleIDIndex == nil {
-		return logical.ErrorResponse("invalid role or secret ID"), nil
+		return logical.AuthErrorResponse(logical.AuthErrorCodeInvalidCredentials, "invalid role or secret ID"), nil
 	}
 
 	roleName := roleIDIndex.Name
@@ -148,7 +148,7 @@ func (b *backend) pathLoginUpdate(ctx context.Context, req *logical.Request, dat
 		return nil, err
 	}
 	if role == nil {
-		return logical.ErrorResponse("invalid role or secret ID"), nil
+		return logical.AuthErrorResponse(logical.AuthErrorCodeInvalidCredentials, "invalid role or secret ID"), nil
 	}
 
 	metadata := make(map[string]string)
@@ -184,7 +184,13 @@ func (b *backend) pathLoginUpdate(ctx context.Context, req *logical.Request, dat
 			return nil, err
 		}
 		if entry == nil {
-			return logical.ErrorResponse("invalid role or secret ID"), logical.ErrInvalidCredentials
+			return logical.AuthErrorResponse(logical.AuthErrorCodeInvalidCredentials, "invalid role or secret ID"), logical.ErrInvalidCredentials
+		}
+
+		// Secret IDs are only removed from storage once tidied, so reject
+		// any which have outlived their TTL in the meantime
+		if !entry.ExpirationTime.IsZero() && time.Now().After(entry.ExpirationTime) {
+			return logical.AuthErrorResponse(logical.AuthErrorCodeExpiredCredential, "secret ID has expired"), nil
 		}
 
 		// If a secret ID entry does not have a corresponding accessor
@@ -204,7 +210,7 @@ func (b *backend) pathLoginUpdate(ctx context.Context, req *logical.Request, dat
 				return nil, err
 			}
 			if entry == nil {
-				return logical.ErrorResponse("invalid role or secret ID"), nil
+				return logical.AuthErrorResponse(logical.AuthErrorCodeInvalidCredentials, "invalid role or secret ID"), nil
 			}
 
 			accessorEntry, err := b.secretIDAccessorEntry(ctx, req.Storage, entry.SecretIDAccessor, role.SecretIDPrefix)
@@ -217,7 +223,7 @@ func (b *backend) pathLoginUpdate(ctx context.Context, req *logical.Request, dat
 					return nil, fmt.Errorf("error deleting secret ID %q from storage: %w", secretIDHMAC, err)
 				}
 			}
-			return logical.ErrorResponse("invalid role or secret ID"), nil
+			return logical.AuthErrorResponse(logical.AuthErrorCodeInvalidCredentials, "invalid role or secret ID"), nil
 		}
 
 		switch {
@@ -248,10 +254,10 @@ func (b *backend) pathLoginUpdate(ctx context.Context, req *logical.Request, dat
 				}
 
 				if !belongs {
-					return logical.ErrorResponse(fmt.Errorf(
+					return logical.AuthErrorResponse(logical.AuthErrorCodeUnauthorizedSource,
 						"source address %q unauthorized through CIDR restrictions on the secret ID",
 						req.Connection.RemoteAddr,
-					).Error()), nil
+					), nil
 				}
 			}
 		default:
@@ -271,7 +277,7 @@ func (b *backend) pathLoginUpdate(ctx context.Context, req *logical.Request, dat
 				return nil, err
 			}
 			if entry == nil {
-				return logical.ErrorResponse(fmt.Sprintf("invalid secret_id %q", secretID)), nil
+				return logical.AuthErrorResponse(logical.AuthErrorCodeInvalidCredentials, "invalid secret_id %q", secretID), nil
 			}
 
 			// If there exists a single use left, delete the SecretID entry from
@@ -319,7 +325,7 @@ func (b *backend) pathLoginUpdate(ctx context.Context, req *logical.Request, dat
 
 				belongs, err := cidrutil.IPBelongsToCIDRBlocksSlice(req.Connection.RemoteAddr, entry.CIDRList)
 				if err != nil || !belongs {
-					return logical.ErrorResponse(
+					return logical.AuthErrorResponse(logical.AuthErrorCodeUnauthorizedSource,
 						fmt.Errorf(
 							"source address %q unauthorized by CIDR restrictions on the secret ID: %w",
 							req.Connection.RemoteAddr,
@@ -338,7 +344,7 @@ func (b *backend) pathLoginUpdate(ctx context.Context, req *logical.Request, dat
 		}
 		belongs, err := cidrutil.IPBelongsToCIDRBlocksSlice(req.Connection.RemoteAddr, role.SecretIDBoundCIDRs)
 		if err != nil || !belongs {
-			return logical.ErrorResponse(
+			return logical.AuthErrorResponse(logical.AuthErrorCodeUnauthorizedSource,
 				fmt.Errorf(
 					"source address %q unauthorized by CIDR restrictions on the role: %w",
 					req.Connection.RemoteAddr,
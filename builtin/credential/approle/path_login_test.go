@@ -358,3 +358,55 @@ func TestAppRole_RoleDoesNotExist(t *testing.T) {
 		t.Fatalf("Error was not due to invalid role ID. Error: %s", errString)
 	}
 }
+
+func TestAppRole_ExpiredSecretIDLogin(t *testing.T) {
+	b, s := createBackendWithStorage(t)
+
+	b.requestNoErr(t, &logical.Request{
+		Path:      "role/testrole",
+		Operation: logical.CreateOperation,
+		Data: map[string]interface{}{
+			"secret_id_ttl": "1s",
+		},
+		Storage: s,
+	})
+	resp := b.requestNoErr(t, &logical.Request{
+		Path:      "role/testrole/role-id",
+		Operation: logical.ReadOperation,
+		Storage:   s,
+	})
+	roleID := resp.Data["role_id"]
+	resp = b.requestNoErr(t, &logical.Request{
+		Path:      "role/testrole/secret-id",
+		Operation: logical.UpdateOperation,
+		Storage:   s,
+	})
+	secretID := resp.Data["secret_id"]
+
+	loginReq := &logical.Request{
+		Path:      "login",
+		Operation: logical.UpdateOperation,
+		Data: map[string]interface{}{
+			"role_id":   roleID,
+			"secret_id": "not-the-secret-id",
+		},
+		Storage:    s,
+		Connection: &logical.Connection{RemoteAddr: "127.0.0.1"},
+	}
+	resp, _ = b.HandleRequest(context.Background(), loginReq)
+	if !resp.IsError() || logical.ErrorCode(resp, nil) != string(logical.AuthErrorCodeInvalidCredentials) {
+		t.Fatalf("expected invalid credentials error, got: %#v", resp)
+	}
+
+	// Wait for the secret ID to expire, without tidying it
+	time.Sleep(2 * time.Second)
+
+	loginReq.Data["secret_id"] = secretID
+	resp, err := b.HandleRequest(context.Background(), loginReq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.IsError() || logical.ErrorCode(resp, nil) != string(logical.AuthErrorCodeExpiredCredential) {
+		t.Fatalf("expected expired credential error, got: %#v", resp)
+	}
+}
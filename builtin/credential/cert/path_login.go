@@ -133,7 +133,7 @@ func (b *backend) pathLogin(ctx context.Context, req *logical.Request, data *fra
 			return nil, logical.ErrPermissionDenied
 		}
 		if !cidrutil.RemoteAddrIsOk(req.Connection.RemoteAddr, matched.Entry.TokenBoundCIDRs) {
-			return nil, logical.ErrPermissionDenied
+			return nil, logical.NewAuthError(logical.AuthErrorCodeUnauthorizedSource, logical.ErrPermissionDenied)
 		}
 	}
 
@@ -316,10 +316,10 @@ func (b *backend) verifyCredentials(ctx context.Context, req *logical.Request, d
 	// This check happens after checking for a matching configured non-CA certs
 	if len(trustedChains) == 0 {
 		if retErr != nil {
-			return nil, logical.ErrorResponse(fmt.Sprintf("%s; additionally got errors during verification: %v", certAuthFailMsg, retErr)), nil
+			return nil, logical.AuthErrorResponse(logical.AuthErrorCodeInvalidCredentials, "%s; additionally got errors during verification: %v", certAuthFailMsg, retErr), nil
 		}
 
-		return nil, logical.ErrorResponse(certAuthFailMsg), nil
+		return nil, logical.AuthErrorResponse(logical.AuthErrorCodeInvalidCredentials, certAuthFailMsg), nil
 	}
 
 	// Search for a ParsedCert that intersects with the validated chains and any additional constraints
@@ -354,10 +354,10 @@ func (b *backend) verifyCredentials(ctx context.Context, req *logical.Request, d
 	}
 
 	if retErr != nil {
-		return nil, logical.ErrorResponse(fmt.Sprintf("%s; additionally got errors during verification: %v", certAuthFailMsg, retErr)), nil
+		return nil, logical.AuthErrorResponse(logical.AuthErrorCodeInvalidCredentials, "%s; additionally got errors during verification: %v", certAuthFailMsg, retErr), nil
 	}
 
-	return nil, logical.ErrorResponse(certAuthFailMsg), nil
+	return nil, logical.AuthErrorResponse(logical.AuthErrorCodeInvalidCredentials, certAuthFailMsg), nil
 }
 
 func (b *backend) matchesConstraints(ctx context.Context, clientCert *x509.Certificate, trustedChain []*x509.Certificate,
@@ -101,18 +101,18 @@ func (b *backend) pathLogin(ctx context.Context, req *logical.Request, d *framew
 			// The failed login info of existing users alone are tracked as only
 			// existing user's failed login information is stored in storage for optimization
 			if user == nil || userError != nil {
-				return logical.ErrorResponse("invalid username or password"), nil
+				return logical.AuthErrorResponse(logical.AuthErrorCodeInvalidCredentials, "invalid username or password"), nil
 			}
-			return logical.ErrorResponse("invalid username or password"), logical.ErrInvalidCredentials
+			return logical.AuthErrorResponse(logical.AuthErrorCodeInvalidCredentials, "invalid username or password"), logical.ErrInvalidCredentials
 		}
 	default:
 		if subtle.ConstantTimeCompare(userPassword, passwordBytes) != 1 {
 			// The failed login info of existing users alone are tracked as only
 			// existing user's failed login information is stored in storage for optimization
 			if user == nil || userError != nil {
-				return logical.ErrorResponse("invalid username or password"), nil
+				return logical.AuthErrorResponse(logical.AuthErrorCodeInvalidCredentials, "invalid username or password"), nil
 			}
-			return logical.ErrorResponse("invalid username or password"), logical.ErrInvalidCredentials
+			return logical.AuthErrorResponse(logical.AuthErrorCodeInvalidCredentials, "invalid username or password"), logical.ErrInvalidCredentials
 		}
 
 	}
@@ -121,7 +121,7 @@ func (b *backend) pathLogin(ctx context.Context, req *logical.Request, d *framew
 		return nil, userError
 	}
 	if user == nil {
-		return logical.ErrorResponse("invalid username or password"), nil
+		return logical.AuthErrorResponse(logical.AuthErrorCodeInvalidCredentials, "invalid username or password"), nil
 	}
 
 	// Check for a CIDR match.
@@ -131,7 +131,7 @@ func (b *backend) pathLogin(ctx context.Context, req *logical.Request, d *framew
 			return nil, logical.ErrPermissionDenied
 		}
 		if !cidrutil.RemoteAddrIsOk(req.Connection.RemoteAddr, user.TokenBoundCIDRs) {
-			return nil, logical.ErrPermissionDenied
+			return nil, logical.NewAuthError(logical.AuthErrorCodeUnauthorizedSource, logical.ErrPermissionDenied)
 		}
 	}
 
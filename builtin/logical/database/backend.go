@@ -5,6 +5,9 @@ package database
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/rpc"
@@ -36,6 +39,10 @@ const (
 	databaseRolePath        = "role/"
 	databaseStaticRolePath  = "static-role/"
 	minRootCredRollbackAge  = 1 * time.Minute
+
+	// databaseOverriddenConnectionPrefix prefixes the names of the
+	// connections with connection details overridden by dynamic roles
+	databaseOverriddenConnectionPrefix = "overridden/"
 )
 
 type dbPluginInstance struct {
@@ -103,6 +110,7 @@ func Backend(conf *logical.BackendConfig) *databaseBackend {
 			SealWrapStorage: []string{
 				"config/*",
 				"static-role/*",
+				databaseCredentialPoolPath,
 			},
 		},
 		Paths: framework.PathAppend(
@@ -186,6 +194,12 @@ type databaseBackend struct {
 	gaugeCollectionProcessStop sync.Once
 
 	schedule schedule.Scheduler
+
+	// credentialPoolRoles is the set of the dynamic roles with a credential
+	// pool, loaded from storage by the first refill and then kept up to date
+	// as roles are written, so that refilling pools does not read every role.
+	credentialPoolRolesLock sync.Mutex
+	credentialPoolRoles     map[string]struct{}
 }
 
 func (b *databaseBackend) DatabaseConfig(ctx context.Context, s logical.Storage, name string) (*DatabaseConfig, error) {
@@ -303,6 +317,90 @@ func (b *databaseBackend) GetConnectionSkipVerify(ctx context.Context, s logical
 	return b.GetConnectionWithConfig(ctx, name, config)
 }
 
+// connectionOverrideKeys are the connection details of a database that a
+// dynamic role may override. Anyone able to write a role can set them, so the
+// address of the database and the credentials of the connection are never
+// among them, as the connection would otherwise hand its root credentials to
+// whichever host the role points at.
+var connectionOverrideKeys = []string{
+	"max_open_connections",
+	"max_idle_connections",
+	"max_connection_lifetime",
+	"connect_timeout",
+	"socket_timeout",
+	"server_selection_timeout",
+	"consistency",
+	"local_datacenter",
+	"write_concern",
+}
+
+// validateConnectionOverrides returns an error if the overrides hold a
+// connection detail which roles may not override.
+func validateConnectionOverrides(overrides map[string]interface{}) error {
+	for k := range overrides {
+		if !strutil.StrListContains(connectionOverrideKeys, k) {
+			return fmt.Errorf("connection detail %q cannot be overridden, allowed overrides are %s", k, strings.Join(connectionOverrideKeys, ", "))
+		}
+	}
+	return nil
+}
+
+// GetRoleConnection returns the connection used for the credentials of the
+// dynamic role, which is the connection of its database unless the role
+// overrides some of its connection details.
+func (b *databaseBackend) GetRoleConnection(ctx context.Context, s logical.Storage, role *roleEntry) (*dbPluginInstance, error) {
+	return b.GetConnectionWithOverrides(ctx, s, role.DBName, role.ConnectionOverrides)
+}
+
+// GetConnectionWithOverrides returns the connection of the database with the
+// given connection details overridden. Connections are shared by all the
+// credentials created with the same overrides, so that credentials are
+// renewed and revoked through the connection they were created with even once
+// their role changes.
+func (b *databaseBackend) GetConnectionWithOverrides(ctx context.Context, s logical.Storage, dbName string, overrides map[string]interface{}) (*dbPluginInstance, error) {
+	if len(overrides) == 0 {
+		return b.GetConnection(ctx, s, dbName)
+	}
+	// Overrides are checked again as they may come from roles or leases
+	// written before they were restricted
+	if err := validateConnectionOverrides(overrides); err != nil {
+		return nil, err
+	}
+
+	config, err := b.DatabaseConfig(ctx, s, dbName)
+	if err != nil {
+		return nil, err
+	}
+
+	connectionDetails := make(map[string]interface{}, len(config.ConnectionDetails)+len(overrides))
+	for k, v := range config.ConnectionDetails {
+		connectionDetails[k] = v
+	}
+	for k, v := range overrides {
+		connectionDetails[k] = v
+	}
+	config.ConnectionDetails = connectionDetails
+
+	name, err := overriddenConnectionName(dbName, overrides)
+	if err != nil {
+		return nil, err
+	}
+	return b.GetConnectionWithConfig(ctx, name, config)
+}
+
+// overriddenConnectionName returns the name the connection of a database with
+// overridden connection details is held under. Database names cannot contain
+// slashes, so it never collides with the name of a database.
+func overriddenConnectionName(dbName string, overrides map[string]interface{}) (string, error) {
+	// Map keys are sorted when encoded, so equal overrides share a name
+	encoded, err := json.Marshal(overrides)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(encoded)
+	return databaseOverriddenConnectionPrefix + dbName + "/" + hex.EncodeToString(sum[:]), nil
+}
+
 func (b *databaseBackend) GetConnectionWithConfig(ctx context.Context, name string, config *DatabaseConfig) (*dbPluginInstance, error) {
 	// fast path, reuse the existing connection
 	dbi := b.connections.Get(name)
@@ -378,9 +476,25 @@ func (b *databaseBackend) ClearConnection(name string) error {
 		// Ignore error here since the database client is always killed
 		db.Close()
 	}
+
+	// The connections with overridden connection details are built from the
+	// configuration of their database, so clear them along with it
+	b.clearOverriddenConnections(name)
 	return nil
 }
 
+// clearOverriddenConnections closes the connections of the database with
+// overridden connection details, and removes them from the b.connections map.
+func (b *databaseBackend) clearOverriddenConnections(dbName string) {
+	for _, db := range b.connections.Values() {
+		if strings.HasPrefix(db.name, databaseOverriddenConnectionPrefix+dbName+"/") {
+			if db := b.connections.PopIfEqual(db.name, db.id); db != nil {
+				db.Close()
+			}
+		}
+	}
+}
+
 // ClearConnectionId closes the database connection with a specific id and
 // removes it from the b.connections map.
 func (b *databaseBackend) ClearConnectionId(name, id string) error {
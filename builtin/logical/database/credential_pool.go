@@ -0,0 +1,366 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package database
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-secure-stdlib/strutil"
+	uuid "github.com/hashicorp/go-uuid"
+	v5 "github.com/hashicorp/vault/sdk/database/dbplugin/v5"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/locksutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	databaseCredentialPoolPath = "credential-pool/"
+
+	// credentialPoolDisplayName is the display name used in the usernames of
+	// pooled credentials, as they are created before being requested.
+	credentialPoolDisplayName = "pool"
+
+	defaultCredentialPoolMaxAge = time.Hour
+	maxCredentialPoolSize       = 100
+
+	// credentialPoolRefillInterval is how often the credential pools are
+	// refilled, apart from the rotation of static roles.
+	credentialPoolRefillInterval = 30 * time.Second
+)
+
+// pooledCredential is a credential of a dynamic role created ahead of being
+// requested, so that it can be handed out without waiting on the database.
+type pooledCredential struct {
+	// Data is the response data holding the credential
+	Data      map[string]interface{} `json:"data"`
+	CreatedAt time.Time              `json:"created_at"`
+
+	// DBName, ConnectionOverrides and RevocationStatements are kept so that
+	// the credential can be revoked even once its role is deleted
+	DBName               string                 `json:"db_name"`
+	ConnectionOverrides  map[string]interface{} `json:"connection_overrides,omitempty"`
+	RevocationStatements []string               `json:"revocation_statements"`
+}
+
+func credentialPoolLock(b *databaseBackend, name string) *locksutil.LockEntry {
+	return locksutil.LockForKey(b.roleLocks, databaseCredentialPoolPath+name)
+}
+
+// expired returns whether the credential was created too long ago to be
+// handed out, as the database may expire it before the end of its lease.
+func (c *pooledCredential) expired(role *roleEntry, now time.Time) bool {
+	return now.After(c.CreatedAt.Add(role.credentialPoolMaxAge()))
+}
+
+func (r *roleEntry) credentialPoolMaxAge() time.Duration {
+	if r.CredentialPoolMaxAge <= 0 {
+		return defaultCredentialPoolMaxAge
+	}
+	return r.CredentialPoolMaxAge
+}
+
+// takePooledCredential removes a credential from the role's pool and returns
+// it. It returns nil if the pool is empty.
+func (b *databaseBackend) takePooledCredential(ctx context.Context, s logical.Storage, name string, role *roleEntry) (*pooledCredential, error) {
+	lock := credentialPoolLock(b, name)
+	lock.Lock()
+	defer lock.Unlock()
+
+	keys, err := s.List(ctx, databaseCredentialPoolPath+name+"/")
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for _, key := range keys {
+		cred, err := b.pooledCredential(ctx, s, name, key)
+		if err != nil {
+			return nil, err
+		}
+		// Expired credentials are left for the pool to revoke
+		if cred == nil || cred.expired(role, now) {
+			continue
+		}
+
+		if err := s.Delete(ctx, databaseCredentialPoolPath+name+"/"+key); err != nil {
+			return nil, err
+		}
+		return cred, nil
+	}
+
+	b.Logger().Debug("credential pool is empty", "role", name)
+	return nil, nil
+}
+
+func (b *databaseBackend) pooledCredential(ctx context.Context, s logical.Storage, name, key string) (*pooledCredential, error) {
+	entry, err := s.Get(ctx, databaseCredentialPoolPath+name+"/"+key)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var cred pooledCredential
+	if err := entry.DecodeJSON(&cred); err != nil {
+		return nil, err
+	}
+	return &cred, nil
+}
+
+// runCredentialPoolTicker periodically refills the credential pools until
+// ctx is done.
+func (b *databaseBackend) runCredentialPoolTicker(ctx context.Context, s logical.Storage) {
+	tick := time.NewTicker(credentialPoolRefillInterval)
+	defer tick.Stop()
+	for {
+		select {
+		case <-tick.C:
+			b.refillCredentialPools(ctx, s)
+
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// trackCredentialPoolRole records whether the role has a credential pool to
+// refill. Until the pooled roles are loaded from storage there is nothing to
+// update, as loading them will pick up the change.
+func (b *databaseBackend) trackCredentialPoolRole(name string, pooled bool) {
+	b.credentialPoolRolesLock.Lock()
+	defer b.credentialPoolRolesLock.Unlock()
+
+	if b.credentialPoolRoles == nil {
+		return
+	}
+	if pooled {
+		b.credentialPoolRoles[name] = struct{}{}
+	} else {
+		delete(b.credentialPoolRoles, name)
+	}
+}
+
+// credentialPoolRoleNames returns the names of the roles with a credential
+// pool, reading every role from storage only the first time.
+func (b *databaseBackend) credentialPoolRoleNames(ctx context.Context, s logical.Storage) ([]string, error) {
+	b.credentialPoolRolesLock.Lock()
+	defer b.credentialPoolRolesLock.Unlock()
+
+	if b.credentialPoolRoles == nil {
+		roles, err := s.List(ctx, databaseRolePath)
+		if err != nil {
+			return nil, err
+		}
+		pooled := make(map[string]struct{})
+		for _, name := range roles {
+			role, err := b.Role(ctx, s, name)
+			if err != nil {
+				return nil, err
+			}
+			if role != nil && role.CredentialPoolSize > 0 {
+				pooled[name] = struct{}{}
+			}
+		}
+		b.credentialPoolRoles = pooled
+	}
+
+	names := make([]string, 0, len(b.credentialPoolRoles))
+	for name := range b.credentialPoolRoles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// refillCredentialPools refills the pools of the roles with a credential
+// pool, and revokes the credentials left in the pools of deleted roles or of
+// roles which no longer have one.
+func (b *databaseBackend) refillCredentialPools(ctx context.Context, s logical.Storage) {
+	roles, err := b.credentialPoolRoleNames(ctx, s)
+	if err != nil {
+		b.Logger().Error("unable to load roles for refilling credential pools", "error", err)
+		return
+	}
+	pools, err := s.List(ctx, databaseCredentialPoolPath)
+	if err != nil {
+		b.Logger().Error("unable to list credential pools", "error", err)
+		return
+	}
+	for _, pool := range pools {
+		roles = strutil.AppendIfMissing(roles, strings.TrimSuffix(pool, "/"))
+	}
+
+	for _, name := range roles {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		if err := b.refillCredentialPool(ctx, s, name); err != nil {
+			b.Logger().Warn("unable to refill credential pool", "role", name, "error", err)
+		}
+	}
+}
+
+// refillCredentialPool revokes the expired and surplus credentials of the
+// role's pool, then creates credentials until the pool is full.
+func (b *databaseBackend) refillCredentialPool(ctx context.Context, s logical.Storage, name string) error {
+	lock := credentialPoolLock(b, name)
+	lock.Lock()
+	defer lock.Unlock()
+
+	role, err := b.Role(ctx, s, name)
+	if err != nil {
+		return err
+	}
+	if role == nil {
+		// The role was deleted, so revoke whatever is left in its pool
+		return b.drainCredentialPoolLocked(ctx, s, name)
+	}
+
+	keys, err := s.List(ctx, databaseCredentialPoolPath+name+"/")
+	if err != nil {
+		return err
+	}
+	if role.CredentialPoolSize == 0 && len(keys) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	available := 0
+	for _, key := range keys {
+		cred, err := b.pooledCredential(ctx, s, name, key)
+		if err != nil {
+			return err
+		}
+		if cred == nil {
+			continue
+		}
+		if available < role.CredentialPoolSize && !cred.expired(role, now) {
+			available++
+			continue
+		}
+		if err := b.revokePooledCredential(ctx, s, name, key, cred); err != nil {
+			return err
+		}
+	}
+	if available >= role.CredentialPoolSize {
+		return nil
+	}
+
+	dbConfig, err := b.DatabaseConfig(ctx, s, role.DBName)
+	if err != nil {
+		return err
+	}
+	if !strutil.StrListContains(dbConfig.AllowedRoles, "*") && !strutil.StrListContainsGlob(dbConfig.AllowedRoles, name) {
+		return fmt.Errorf("%q is not an allowed role", name)
+	}
+	if !dbConfig.SupportsCredentialType(role.CredentialType) {
+		return fmt.Errorf("unsupported credential_type: %q", role.CredentialType.String())
+	}
+
+	dbi, err := b.GetRoleConnection(ctx, s, role)
+	if err != nil {
+		return err
+	}
+	dbi.RLock()
+	defer dbi.RUnlock()
+
+	// The credentials must outlive both their time in the pool and the lease
+	// they are handed out with
+	ttl, _, err := framework.CalculateTTL(b.System(), 0, role.DefaultTTL, 0, role.MaxTTL, 0, time.Time{})
+	if err != nil {
+		return err
+	}
+	expiration := time.Now().Add(role.credentialPoolMaxAge() + ttl + 5*time.Second)
+
+	for ; available < role.CredentialPoolSize; available++ {
+		respData, err := b.newDynamicUser(ctx, dbi, dbConfig, role, name, credentialPoolDisplayName, role.Statements.Creation, expiration)
+		if err != nil {
+			return err
+		}
+
+		id, err := uuid.GenerateUUID()
+		if err != nil {
+			return err
+		}
+		entry, err := logical.StorageEntryJSON(databaseCredentialPoolPath+name+"/"+id, &pooledCredential{
+			Data:                 respData,
+			CreatedAt:            time.Now(),
+			DBName:               role.DBName,
+			ConnectionOverrides:  role.ConnectionOverrides,
+			RevocationStatements: role.Statements.Revocation,
+		})
+		if err != nil {
+			return err
+		}
+		if err := s.Put(ctx, entry); err != nil {
+			return err
+		}
+	}
+
+	b.Logger().Debug("refilled credential pool", "role", name, "size", role.CredentialPoolSize)
+	return nil
+}
+
+// drainCredentialPool revokes all the credentials of the role's pool, such as
+// when the role is deleted or its statements change.
+func (b *databaseBackend) drainCredentialPool(ctx context.Context, s logical.Storage, name string) error {
+	lock := credentialPoolLock(b, name)
+	lock.Lock()
+	defer lock.Unlock()
+
+	return b.drainCredentialPoolLocked(ctx, s, name)
+}
+
+func (b *databaseBackend) drainCredentialPoolLocked(ctx context.Context, s logical.Storage, name string) error {
+	keys, err := s.List(ctx, databaseCredentialPoolPath+name+"/")
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		cred, err := b.pooledCredential(ctx, s, name, key)
+		if err != nil {
+			return err
+		}
+		if cred == nil {
+			continue
+		}
+		if err := b.revokePooledCredential(ctx, s, name, key, cred); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// revokePooledCredential deletes the credential's user from the database and
+// removes it from the pool.
+func (b *databaseBackend) revokePooledCredential(ctx context.Context, s logical.Storage, name, key string, cred *pooledCredential) error {
+	if username, ok := cred.Data["username"].(string); ok && username != "" {
+		dbi, err := b.GetConnectionWithOverrides(ctx, s, cred.DBName, cred.ConnectionOverrides)
+		if err != nil {
+			return err
+		}
+		dbi.RLock()
+		_, err = dbi.database.DeleteUser(ctx, v5.DeleteUserRequest{
+			Username: username,
+			Statements: v5.Statements{
+				Commands: cred.RevocationStatements,
+			},
+		})
+		dbi.RUnlock()
+		if err != nil {
+			b.CloseIfShutdown(dbi, err)
+			return fmt.Errorf("error revoking pooled credential: %w", err)
+		}
+	}
+
+	return s.Delete(ctx, databaseCredentialPoolPath+name+"/"+key)
+}
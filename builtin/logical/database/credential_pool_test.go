@@ -0,0 +1,151 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	v5 "github.com/hashicorp/vault/sdk/database/dbplugin/v5"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackend_CredentialPool(t *testing.T) {
+	ctx := context.Background()
+	b, storage, mockDB := getBackend(t)
+	defer b.Cleanup(ctx)
+	configureDBMount(t, storage)
+
+	mockDB.On("NewUser", mock.Anything, mock.Anything).
+		Return(v5.NewUserResponse{Username: "pooled-user"}, nil).
+		Times(2)
+
+	resp, err := b.HandleRequest(ctx, &logical.Request{
+		Operation: logical.CreateOperation,
+		Path:      "roles/pooled",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"db_name":              mockv5,
+			"creation_statements":  []string{"CREATE USER"},
+			"default_ttl":          "1h",
+			"credential_pool_size": 2,
+		},
+	})
+	require.NoError(t, err)
+	require.False(t, resp.IsError(), resp)
+
+	resp, err = b.HandleRequest(ctx, &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "roles/pooled",
+		Storage:   storage,
+	})
+	require.NoError(t, err)
+	require.Equal(t, 2, resp.Data["credential_pool_size"])
+	require.Equal(t, defaultCredentialPoolMaxAge.Seconds(), resp.Data["credential_pool_max_age"])
+
+	// Fill the pool, creating the users in the database up front
+	b.refillCredentialPools(ctx, storage)
+	keys, err := storage.List(ctx, databaseCredentialPoolPath+"pooled/")
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+
+	// Only roles with a pool are visited when refilling
+	resp, err = b.HandleRequest(ctx, &logical.Request{
+		Operation: logical.CreateOperation,
+		Path:      "roles/unpooled",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"db_name":             mockv5,
+			"creation_statements": []string{"CREATE USER"},
+		},
+	})
+	require.NoError(t, err)
+	require.False(t, resp.IsError(), resp)
+	names, err := b.credentialPoolRoleNames(ctx, storage)
+	require.NoError(t, err)
+	require.Equal(t, []string{"pooled"}, names)
+
+	// Requesting credentials hands out pooled ones without creating users
+	resp, err = b.HandleRequest(ctx, &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "creds/pooled",
+		Storage:   storage,
+	})
+	require.NoError(t, err)
+	require.False(t, resp.IsError(), resp)
+	require.Equal(t, "pooled-user", resp.Data["username"])
+	require.NotEmpty(t, resp.Data["password"])
+	require.Equal(t, time.Hour, resp.Secret.TTL)
+	mockDB.AssertNumberOfCalls(t, "NewUser", 2)
+
+	keys, err = storage.List(ctx, databaseCredentialPoolPath+"pooled/")
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+
+	// Deleting the role revokes the credentials left in the pool
+	mockDB.On("DeleteUser", mock.Anything, mock.Anything).
+		Return(v5.DeleteUserResponse{}, nil).
+		Once()
+	_, err = b.HandleRequest(ctx, &logical.Request{
+		Operation: logical.DeleteOperation,
+		Path:      "roles/pooled",
+		Storage:   storage,
+	})
+	require.NoError(t, err)
+	mockDB.AssertNumberOfCalls(t, "DeleteUser", 1)
+
+	keys, err = storage.List(ctx, databaseCredentialPoolPath+"pooled/")
+	require.NoError(t, err)
+	require.Empty(t, keys)
+	names, err = b.credentialPoolRoleNames(ctx, storage)
+	require.NoError(t, err)
+	require.Empty(t, names)
+}
+
+func TestBackend_CredentialPool_Expired(t *testing.T) {
+	ctx := context.Background()
+	b, storage, mockDB := getBackend(t)
+	defer b.Cleanup(ctx)
+	configureDBMount(t, storage)
+
+	role := &roleEntry{
+		DBName:             mockv5,
+		DefaultTTL:         time.Hour,
+		CredentialPoolSize: 1,
+	}
+	entry, err := logical.StorageEntryJSON(databaseRolePath+"pooled", role)
+	require.NoError(t, err)
+	require.NoError(t, storage.Put(ctx, entry))
+
+	entry, err = logical.StorageEntryJSON(databaseCredentialPoolPath+"pooled/expired", &pooledCredential{
+		Data:      map[string]interface{}{"username": "expired-user"},
+		CreatedAt: time.Now().Add(-2 * defaultCredentialPoolMaxAge),
+		DBName:    mockv5,
+	})
+	require.NoError(t, err)
+	require.NoError(t, storage.Put(ctx, entry))
+
+	// Expired credentials are not handed out
+	data, err := b.takePooledCredential(ctx, storage, "pooled", role)
+	require.NoError(t, err)
+	require.Nil(t, data)
+
+	// and are replaced when the pool is refilled
+	mockDB.On("DeleteUser", mock.Anything, v5.DeleteUserRequest{Username: "expired-user", Statements: v5.Statements{}}).
+		Return(v5.DeleteUserResponse{}, nil).
+		Once()
+	mockDB.On("NewUser", mock.Anything, mock.Anything).
+		Return(v5.NewUserResponse{Username: "fresh-user"}, nil).
+		Once()
+	b.refillCredentialPools(ctx, storage)
+	mockDB.AssertNumberOfCalls(t, "DeleteUser", 1)
+	mockDB.AssertNumberOfCalls(t, "NewUser", 1)
+
+	data, err = b.takePooledCredential(ctx, storage, "pooled", role)
+	require.NoError(t, err)
+	require.Equal(t, "fresh-user", data.Data["username"])
+}
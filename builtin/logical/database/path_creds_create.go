@@ -6,6 +6,7 @@ package database
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/hashicorp/go-secure-stdlib/strutil"
@@ -34,12 +35,35 @@ func pathCredsCreate(b *databaseBackend) []*framework.Path {
 			},
 
 			Callbacks: map[logical.Operation]framework.OperationFunc{
-				logical.ReadOperation: b.pathCredsCreateRead(),
+				logical.ReadOperation: b.pathCredsCreateRead(false),
 			},
 
 			HelpSynopsis:    pathCredsCreateReadHelpSyn,
 			HelpDescription: pathCredsCreateReadHelpDesc,
 		},
+		{
+			Pattern: "creds-read/" + framework.GenericNameRegex("name"),
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: operationPrefixDatabase,
+				OperationVerb:   "generate",
+				OperationSuffix: "read-only-credentials",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"name": {
+					Type:        framework.TypeString,
+					Description: "Name of the role.",
+				},
+			},
+
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.ReadOperation: b.pathCredsCreateRead(true),
+			},
+
+			HelpSynopsis:    pathCredsReadOnlyHelpSyn,
+			HelpDescription: pathCredsReadOnlyHelpDesc,
+		},
 		{
 			Pattern: "static-creds/" + framework.GenericNameRegex("name"),
 
@@ -66,15 +90,18 @@ func pathCredsCreate(b *databaseBackend) []*framework.Path {
 	}
 }
 
-func (b *databaseBackend) pathCredsCreateRead() framework.OperationFunc {
+// pathCredsCreateRead returns the handler of the creds endpoint, or of the
+// creds-read endpoint when readOnly is set, which creates users with the
+// read-only creation statements of the role and bypasses its pool.
+func (b *databaseBackend) pathCredsCreateRead(readOnly bool) framework.OperationFunc {
 	return func(ctx context.Context, req *logical.Request, data *framework.FieldData) (resp *logical.Response, err error) {
 		name := data.Get("name").(string)
 		modified := false
 		defer func() {
 			if err == nil && (resp == nil || !resp.IsError()) {
-				b.dbEvent(ctx, "creds-create", req.Path, name, modified)
+				b.dbEvent(ctx, "creds-create", req.Path, name, modified, "read_only", strconv.FormatBool(readOnly))
 			} else {
-				b.dbEvent(ctx, "creds-create-fail", req.Path, name, modified)
+				b.dbEvent(ctx, "creds-create-fail", req.Path, name, modified, "read_only", strconv.FormatBool(readOnly))
 			}
 		}()
 
@@ -104,132 +131,177 @@ func (b *databaseBackend) pathCredsCreateRead() framework.OperationFunc {
 				role.CredentialType.String()), nil
 		}
 
-		// Get the Database object
-		dbi, err := b.GetConnection(ctx, req.Storage, role.DBName)
-		if err != nil {
-			return nil, err
-		}
-
-		dbi.RLock()
-		defer dbi.RUnlock()
-
-		ttl, _, err := framework.CalculateTTL(b.System(), 0, role.DefaultTTL, 0, role.MaxTTL, 0, time.Time{})
-		if err != nil {
-			return nil, err
-		}
-		expiration := time.Now().Add(ttl)
-		// Adding a small buffer since the TTL will be calculated again after this call
-		// to ensure the database credential does not expire before the lease
-		expiration = expiration.Add(5 * time.Second)
-
-		newUserReq := v5.NewUserRequest{
-			UsernameConfig: v5.UsernameMetadata{
-				DisplayName: req.DisplayName,
-				RoleName:    name,
-			},
-			Statements: v5.Statements{
-				Commands: role.Statements.Creation,
-			},
-			RollbackStatements: v5.Statements{
-				Commands: role.Statements.Rollback,
-			},
-			Expiration: expiration,
+		creationStatements := role.Statements.Creation
+		if readOnly {
+			if len(role.ReadOnlyStatements) == 0 {
+				return logical.ErrorResponse("role %q has no read_only_creation_statements", name), nil
+			}
+			creationStatements = role.ReadOnlyStatements
 		}
 
-		respData := make(map[string]interface{})
-
-		// Generate the credential based on the role's credential type
-		switch role.CredentialType {
-		case v5.CredentialTypePassword:
-			generator, err := newPasswordGenerator(role.CredentialConfig)
+		// The lease records the connection the credential was created through,
+		// so that it is renewed and revoked through it even once the role
+		// changes
+		var respData map[string]interface{}
+		dbName := role.DBName
+		connectionOverrides := role.ConnectionOverrides
+
+		// Hand out a credential from the role's pool if there is one, before
+		// acquiring the connection which refilling the pool also needs
+		if role.CredentialPoolSize > 0 && !readOnly {
+			cred, err := b.takePooledCredential(ctx, req.Storage, name, role)
 			if err != nil {
-				return nil, fmt.Errorf("failed to construct credential generator: %s", err)
+				return nil, err
 			}
-
-			// Fall back to database config-level password policy if not set on role
-			if generator.PasswordPolicy == "" {
-				generator.PasswordPolicy = dbConfig.PasswordPolicy
+			if cred != nil {
+				respData = cred.Data
+				dbName = cred.DBName
+				connectionOverrides = cred.ConnectionOverrides
 			}
+		}
 
-			// Generate the password
-			password, err := generator.generate(ctx, b, dbi.database)
+		if respData == nil {
+			// Get the Database object
+			dbi, err := b.GetRoleConnection(ctx, req.Storage, role)
 			if err != nil {
-				b.CloseIfShutdown(dbi, err)
-				return nil, fmt.Errorf("failed to generate password: %s", err)
+				return nil, err
 			}
 
-			// Set input credential
-			newUserReq.CredentialType = v5.CredentialTypePassword
-			newUserReq.Password = password
+			dbi.RLock()
+			defer dbi.RUnlock()
 
-		case v5.CredentialTypeRSAPrivateKey:
-			generator, err := newRSAKeyGenerator(role.CredentialConfig)
+			ttl, _, err := framework.CalculateTTL(b.System(), 0, role.DefaultTTL, 0, role.MaxTTL, 0, time.Time{})
 			if err != nil {
-				return nil, fmt.Errorf("failed to construct credential generator: %s", err)
+				return nil, err
 			}
+			expiration := time.Now().Add(ttl)
+			// Adding a small buffer since the TTL will be calculated again after this call
+			// to ensure the database credential does not expire before the lease
+			expiration = expiration.Add(5 * time.Second)
 
-			// Generate the RSA key pair
-			public, private, err := generator.generate(b.GetRandomReader())
+			respData, err = b.newDynamicUser(ctx, dbi, dbConfig, role, name, req.DisplayName, creationStatements, expiration)
 			if err != nil {
-				return nil, fmt.Errorf("failed to generate RSA key pair: %s", err)
+				return nil, err
 			}
+			modified = true
+		}
 
-			// Set input credential
-			newUserReq.CredentialType = v5.CredentialTypeRSAPrivateKey
-			newUserReq.PublicKey = public
+		internal := map[string]interface{}{
+			"username":              respData["username"],
+			"role":                  name,
+			"db_name":               dbName,
+			"revocation_statements": role.Statements.Revocation,
+		}
+		if len(connectionOverrides) > 0 {
+			internal["connection_overrides"] = connectionOverrides
+		}
+		resp = b.Secret(SecretCredsType).Response(respData, internal)
+		resp.Secret.TTL = role.DefaultTTL
+		resp.Secret.MaxTTL = role.MaxTTL
+		return resp, nil
+	}
+}
 
-			// Set output credential
-			respData["rsa_private_key"] = string(private)
-		case v5.CredentialTypeClientCertificate:
-			generator, err := newClientCertificateGenerator(role.CredentialConfig)
-			if err != nil {
-				return nil, fmt.Errorf("failed to construct credential generator: %s", err)
-			}
+// newDynamicUser creates a user for the role in the database with the given
+// creation statements, returning the response data holding its credentials.
+func (b *databaseBackend) newDynamicUser(ctx context.Context, dbi *dbPluginInstance, dbConfig *DatabaseConfig, role *roleEntry, name, displayName string, creationStatements []string, expiration time.Time) (map[string]interface{}, error) {
+	newUserReq := v5.NewUserRequest{
+		UsernameConfig: v5.UsernameMetadata{
+			DisplayName: displayName,
+			RoleName:    name,
+		},
+		Statements: v5.Statements{
+			Commands: creationStatements,
+		},
+		RollbackStatements: v5.Statements{
+			Commands: role.Statements.Rollback,
+		},
+		Expiration: expiration,
+	}
 
-			// Generate the client certificate
-			cb, subject, err := generator.generate(b.GetRandomReader(), expiration,
-				newUserReq.UsernameConfig)
-			if err != nil {
-				return nil, fmt.Errorf("failed to generate client certificate: %w", err)
-			}
+	respData := make(map[string]interface{})
 
-			// Set input credential
-			newUserReq.CredentialType = dbplugin.CredentialTypeClientCertificate
-			newUserReq.Subject = subject
+	// Generate the credential based on the role's credential type
+	switch role.CredentialType {
+	case v5.CredentialTypePassword:
+		generator, err := newPasswordGenerator(role.CredentialConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to construct credential generator: %s", err)
+		}
 
-			// Set output credential
-			respData["client_certificate"] = cb.Certificate
-			respData["private_key"] = cb.PrivateKey
-			respData["private_key_type"] = cb.PrivateKeyType
+		// Fall back to database config-level password policy if not set on role
+		if generator.PasswordPolicy == "" {
+			generator.PasswordPolicy = dbConfig.PasswordPolicy
 		}
 
-		// Overwriting the password in the event this is a legacy database
-		// plugin and the provided password is ignored
-		newUserResp, password, err := dbi.database.NewUser(ctx, newUserReq)
+		// Generate the password
+		password, err := generator.generate(ctx, b, dbi.database)
 		if err != nil {
 			b.CloseIfShutdown(dbi, err)
-			return nil, err
+			return nil, fmt.Errorf("failed to generate password: %s", err)
 		}
-		modified = true
-		respData["username"] = newUserResp.Username
 
-		// Database plugins using the v4 interface generate and return the password.
-		// Set the password response to what is returned by the NewUser request.
-		if role.CredentialType == v5.CredentialTypePassword {
-			respData["password"] = password
+		// Set input credential
+		newUserReq.CredentialType = v5.CredentialTypePassword
+		newUserReq.Password = password
+
+	case v5.CredentialTypeRSAPrivateKey:
+		generator, err := newRSAKeyGenerator(role.CredentialConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to construct credential generator: %s", err)
 		}
 
-		internal := map[string]interface{}{
-			"username":              newUserResp.Username,
-			"role":                  name,
-			"db_name":               role.DBName,
-			"revocation_statements": role.Statements.Revocation,
+		// Generate the RSA key pair
+		public, private, err := generator.generate(b.GetRandomReader())
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate RSA key pair: %s", err)
 		}
-		resp = b.Secret(SecretCredsType).Response(respData, internal)
-		resp.Secret.TTL = role.DefaultTTL
-		resp.Secret.MaxTTL = role.MaxTTL
-		return resp, nil
+
+		// Set input credential
+		newUserReq.CredentialType = v5.CredentialTypeRSAPrivateKey
+		newUserReq.PublicKey = public
+
+		// Set output credential
+		respData["rsa_private_key"] = string(private)
+	case v5.CredentialTypeClientCertificate:
+		generator, err := newClientCertificateGenerator(role.CredentialConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to construct credential generator: %s", err)
+		}
+
+		// Generate the client certificate
+		cb, subject, err := generator.generate(b.GetRandomReader(), expiration,
+			newUserReq.UsernameConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate client certificate: %w", err)
+		}
+
+		// Set input credential
+		newUserReq.CredentialType = dbplugin.CredentialTypeClientCertificate
+		newUserReq.Subject = subject
+
+		// Set output credential
+		respData["client_certificate"] = cb.Certificate
+		respData["private_key"] = cb.PrivateKey
+		respData["private_key_type"] = cb.PrivateKeyType
+	}
+
+	// Overwriting the password in the event this is a legacy database
+	// plugin and the provided password is ignored
+	newUserResp, password, err := dbi.database.NewUser(ctx, newUserReq)
+	if err != nil {
+		b.CloseIfShutdown(dbi, err)
+		return nil, err
+	}
+	respData["username"] = newUserResp.Username
+
+	// Database plugins using the v4 interface generate and return the password.
+	// Set the password response to what is returned by the NewUser request.
+	if role.CredentialType == v5.CredentialTypePassword {
+		respData["password"] = password
 	}
+
+	return respData, nil
 }
 
 func (b *databaseBackend) pathStaticCredsRead() framework.OperationFunc {
@@ -293,6 +365,18 @@ database credentials will be generated on demand and will be automatically
 revoked when the lease is up.
 `
 
+const pathCredsReadOnlyHelpSyn = `
+Request read-only database credentials for a certain role.
+`
+
+const pathCredsReadOnlyHelpDesc = `
+This path reads read-only database credentials for a certain role. The
+database credentials are generated on demand with the read_only_creation_statements
+of the role, in place of its creation_statements, and will be automatically
+revoked when the lease is up. Roles without read_only_creation_statements do
+not issue read-only credentials.
+`
+
 const pathStaticCredsReadHelpSyn = `
 Request database credentials for a certain static role. These credentials are
 rotated periodically.
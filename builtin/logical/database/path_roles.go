@@ -180,6 +180,33 @@ func dynamicFields() map[string]*framework.FieldSchema {
 	type will support this functionality. See the plugin's API page for
 	more information on support and formatting for this parameter.`,
 		},
+		"read_only_creation_statements": {
+			Type: framework.TypeStringSlice,
+			Description: `Specifies the database statements executed to
+	create and configure a read-only user, in place of the creation
+	statements, when credentials are requested from the creds-read
+	endpoint. Read-only credentials cannot be requested if unset.`,
+		},
+		"connection_overrides": {
+			Type: framework.TypeMap,
+			Description: `Connection details of the database to override for
+	the credentials of this role, such as max_open_connections or
+	connect_timeout. They are merged over the connection details of the
+	database. The address of the database and the credentials of the
+	connection cannot be overridden.`,
+		},
+		"credential_pool_size": {
+			Type: framework.TypeInt,
+			Description: `Number of credentials to create ahead of them being
+	requested, so that they are handed out without waiting on the database.
+	Defaults to 0, which disables the pool.`,
+		},
+		"credential_pool_max_age": {
+			Type: framework.TypeDurationSecond,
+			Description: `How long a pooled credential may wait to be handed
+	out before it is revoked and replaced. Defaults to 1 hour.`,
+			Default: int(defaultCredentialPoolMaxAge.Seconds()),
+		},
 	}
 	return fields
 }
@@ -244,10 +271,21 @@ func (b *databaseBackend) pathStaticRoleExistenceCheck(ctx context.Context, req
 
 func (b *databaseBackend) pathRoleDelete(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
 	name := data.Get("name").(string)
+
+	// Drain the pool while holding its lock, so that it is not refilled
+	// before the role is gone
+	lock := credentialPoolLock(b, name)
+	lock.Lock()
+	defer lock.Unlock()
+
+	if err := b.drainCredentialPoolLocked(ctx, req.Storage, name); err != nil {
+		return nil, err
+	}
 	err := req.Storage.Delete(ctx, databaseRolePath+name)
 	if err != nil {
 		return nil, err
 	}
+	b.trackCredentialPoolRole(name, false)
 	b.dbEvent(ctx, "role-delete", req.Path, name, true)
 	return nil, nil
 }
@@ -350,14 +388,16 @@ func (b *databaseBackend) pathRoleRead(ctx context.Context, req *logical.Request
 	}
 
 	data := map[string]interface{}{
-		"db_name":               role.DBName,
-		"creation_statements":   role.Statements.Creation,
-		"revocation_statements": role.Statements.Revocation,
-		"rollback_statements":   role.Statements.Rollback,
-		"renew_statements":      role.Statements.Renewal,
-		"default_ttl":           role.DefaultTTL.Seconds(),
-		"max_ttl":               role.MaxTTL.Seconds(),
-		"credential_type":       role.CredentialType.String(),
+		"db_name":                 role.DBName,
+		"creation_statements":     role.Statements.Creation,
+		"revocation_statements":   role.Statements.Revocation,
+		"rollback_statements":     role.Statements.Rollback,
+		"renew_statements":        role.Statements.Renewal,
+		"default_ttl":             role.DefaultTTL.Seconds(),
+		"max_ttl":                 role.MaxTTL.Seconds(),
+		"credential_type":         role.CredentialType.String(),
+		"credential_pool_size":    role.CredentialPoolSize,
+		"credential_pool_max_age": role.credentialPoolMaxAge().Seconds(),
 	}
 	if len(role.CredentialConfig) > 0 {
 		data["credential_config"] = role.CredentialConfig
@@ -374,6 +414,12 @@ func (b *databaseBackend) pathRoleRead(ctx context.Context, req *logical.Request
 	if len(role.Statements.Renewal) == 0 {
 		data["renew_statements"] = []string{}
 	}
+	if len(role.ReadOnlyStatements) > 0 {
+		data["read_only_creation_statements"] = role.ReadOnlyStatements
+	}
+	if len(role.ConnectionOverrides) > 0 {
+		data["connection_overrides"] = role.ConnectionOverrides
+	}
 
 	return &logical.Response{
 		Data: data,
@@ -472,6 +518,10 @@ func (b *databaseBackend) pathRoleCreateUpdate(ctx context.Context, req *logical
 			role.Statements.Renewal = data.Get("renew_statements").([]string)
 		}
 
+		if readOnlyStmtsRaw, ok := data.GetOk("read_only_creation_statements"); ok {
+			role.ReadOnlyStatements = readOnlyStmtsRaw.([]string)
+		}
+
 		// Do not persist deprecated statements that are populated on role read
 		role.Statements.CreationStatements = ""
 		role.Statements.RevocationStatements = ""
@@ -495,6 +545,43 @@ func (b *databaseBackend) pathRoleCreateUpdate(ctx context.Context, req *logical
 		}
 	}
 
+	if connectionOverridesRaw, ok := data.GetOk("connection_overrides"); ok {
+		connectionOverrides := connectionOverridesRaw.(map[string]interface{})
+		if err := validateConnectionOverrides(connectionOverrides); err != nil {
+			return logical.ErrorResponse(err.Error()), nil
+		}
+		role.ConnectionOverrides = connectionOverrides
+	}
+
+	// Credential pool
+	{
+		if poolSizeRaw, ok := data.GetOk("credential_pool_size"); ok {
+			role.CredentialPoolSize = poolSizeRaw.(int)
+		}
+		if role.CredentialPoolSize < 0 || role.CredentialPoolSize > maxCredentialPoolSize {
+			return logical.ErrorResponse("credential_pool_size must be between 0 and %d", maxCredentialPoolSize), nil
+		}
+
+		if poolMaxAgeRaw, ok := data.GetOk("credential_pool_max_age"); ok {
+			role.CredentialPoolMaxAge = time.Duration(poolMaxAgeRaw.(int)) * time.Second
+		} else if createOperation {
+			role.CredentialPoolMaxAge = time.Duration(data.Get("credential_pool_max_age").(int)) * time.Second
+		}
+		if role.CredentialPoolMaxAge < 0 {
+			return logical.ErrorResponse("credential_pool_max_age must not be negative"), nil
+		}
+	}
+
+	// Pooled credentials were created with the role's previous configuration,
+	// so revoke them and let the pool be refilled
+	lock := credentialPoolLock(b, name)
+	lock.Lock()
+	defer lock.Unlock()
+
+	if err := b.drainCredentialPoolLocked(ctx, req.Storage, name); err != nil {
+		return nil, err
+	}
+
 	// Store it
 	entry, err := logical.StorageEntryJSON(databaseRolePath+name, role)
 	if err != nil {
@@ -504,6 +591,7 @@ func (b *databaseBackend) pathRoleCreateUpdate(ctx context.Context, req *logical
 	if err := req.Storage.Put(ctx, entry); err != nil {
 		return nil, err
 	}
+	b.trackCredentialPoolRole(name, role.CredentialPoolSize > 0)
 
 	b.dbEvent(ctx, fmt.Sprintf("role-%s", req.Operation), req.Path, name, true)
 	return nil, nil
@@ -720,6 +808,22 @@ type roleEntry struct {
 	CredentialType   v5.CredentialType      `json:"credential_type"`
 	CredentialConfig map[string]interface{} `json:"credential_config"`
 	StaticAccount    *staticAccount         `json:"static_account" mapstructure:"static_account"`
+
+	// CredentialPoolSize is the number of credentials of a dynamic role to
+	// create ahead of them being requested
+	CredentialPoolSize int `json:"credential_pool_size"`
+
+	// CredentialPoolMaxAge is how long a pooled credential may wait to be
+	// handed out before it is revoked and replaced
+	CredentialPoolMaxAge time.Duration `json:"credential_pool_max_age"`
+
+	// ReadOnlyStatements create the users of the read-only credentials of a
+	// dynamic role, in place of its creation statements
+	ReadOnlyStatements []string `json:"read_only_creation_statements,omitempty"`
+
+	// ConnectionOverrides are merged over the connection details of the
+	// database for the credentials of a dynamic role
+	ConnectionOverrides map[string]interface{} `json:"connection_overrides,omitempty"`
 }
 
 // setCredentialType sets the credential type for the role given its string form.
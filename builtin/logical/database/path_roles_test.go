@@ -18,6 +18,7 @@ import (
 	"github.com/hashicorp/vault/sdk/logical"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 func TestBackend_Roles_CredentialTypes(t *testing.T) {
@@ -1331,3 +1332,138 @@ ALTER USER "{{name}}" WITH PASSWORD '{{password}}';
 const testRoleStaticUpdateRotation = `
 ALTER USER "{{name}}" WITH PASSWORD '{{password}}';GRANT ALL PRIVILEGES ON ALL TABLES IN SCHEMA public TO "{{name}}";
 `
+
+func TestBackend_Roles_ConnectionOverridesAndReadOnlyCreds(t *testing.T) {
+	ctx := context.Background()
+	b, storage, mockDB := getBackend(t)
+	defer b.Cleanup(ctx)
+	configureDBMount(t, storage)
+
+	// The address of the database and the credentials of the connection
+	// cannot be overridden
+	for _, key := range []string{"connection_url", "username", "password", "private_key", "hosts"} {
+		resp, err := b.HandleRequest(ctx, &logical.Request{
+			Operation: logical.CreateOperation,
+			Path:      "roles/replica",
+			Storage:   storage,
+			Data: map[string]interface{}{
+				"db_name":             mockv5,
+				"creation_statements": []string{"CREATE USER"},
+				"connection_overrides": map[string]interface{}{
+					key: "postgresql://attacker:5432/app",
+				},
+			},
+		})
+		require.NoError(t, err)
+		require.True(t, resp.IsError(), key)
+	}
+
+	overrides := map[string]interface{}{
+		"max_open_connections": "2",
+	}
+	resp, err := b.HandleRequest(ctx, &logical.Request{
+		Operation: logical.CreateOperation,
+		Path:      "roles/replica",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"db_name":                       mockv5,
+			"creation_statements":           []string{"CREATE USER"},
+			"read_only_creation_statements": []string{"CREATE READ-ONLY USER"},
+			"connection_overrides":          overrides,
+			"default_ttl":                   "1h",
+		},
+	})
+	require.NoError(t, err)
+	require.False(t, resp.IsError(), resp)
+
+	resp, err = b.HandleRequest(ctx, &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "roles/replica",
+		Storage:   storage,
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"CREATE READ-ONLY USER"}, resp.Data["read_only_creation_statements"])
+	require.Equal(t, overrides, resp.Data["connection_overrides"])
+
+	// The credentials of the role are created through a connection with its
+	// overrides
+	connName, err := overriddenConnectionName(mockv5, overrides)
+	require.NoError(t, err)
+	replicaDB := &mockNewDatabase{}
+	replicaDB.On("Close").Return(nil)
+	replicaDB.On("Type").Return("mock", nil)
+	b.connections.Put(connName, &dbPluginInstance{
+		database: databaseVersionWrapper{v5: replicaDB},
+		id:       "replica-id",
+		name:     connName,
+	})
+
+	replicaDB.On("NewUser", mock.Anything, mock.MatchedBy(func(req v5.NewUserRequest) bool {
+		return len(req.Statements.Commands) == 1 && req.Statements.Commands[0] == "CREATE READ-ONLY USER"
+	})).Return(v5.NewUserResponse{Username: "read-only-user"}, nil).Once()
+	resp, err = b.HandleRequest(ctx, &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "creds-read/replica",
+		Storage:   storage,
+	})
+	require.NoError(t, err)
+	require.False(t, resp.IsError(), resp)
+	require.Equal(t, "read-only-user", resp.Data["username"])
+	require.Equal(t, time.Hour, resp.Secret.TTL)
+	require.Equal(t, overrides, resp.Secret.InternalData["connection_overrides"])
+
+	// Once the role drops its overrides, the credential is still revoked
+	// through the connection it was created with
+	resp2, err := b.HandleRequest(ctx, &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "roles/replica",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"connection_overrides": map[string]interface{}{},
+		},
+	})
+	require.NoError(t, err)
+	require.False(t, resp2.IsError(), resp2)
+
+	replicaDB.On("DeleteUser", mock.Anything, mock.Anything).
+		Return(v5.DeleteUserResponse{}, nil).
+		Once()
+	_, err = b.HandleRequest(ctx, &logical.Request{
+		Operation: logical.RevokeOperation,
+		Storage:   storage,
+		Secret: &logical.Secret{
+			InternalData: resp.Secret.InternalData,
+		},
+	})
+	require.NoError(t, err)
+	replicaDB.AssertNumberOfCalls(t, "NewUser", 1)
+	replicaDB.AssertNumberOfCalls(t, "DeleteUser", 1)
+	mockDB.AssertNotCalled(t, "NewUser", mock.Anything, mock.Anything)
+	mockDB.AssertNotCalled(t, "DeleteUser", mock.Anything, mock.Anything)
+
+	// Roles without read-only statements do not issue read-only credentials
+	resp, err = b.HandleRequest(ctx, &logical.Request{
+		Operation: logical.CreateOperation,
+		Path:      "roles/writer",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"db_name":             mockv5,
+			"creation_statements": []string{"CREATE USER"},
+		},
+	})
+	require.NoError(t, err)
+	require.False(t, resp.IsError(), resp)
+	resp, err = b.HandleRequest(ctx, &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "creds-read/writer",
+		Storage:   storage,
+	})
+	require.NoError(t, err)
+	require.True(t, resp.IsError())
+
+	// Overridden connections are cleared along with the connection of their
+	// database
+	require.NoError(t, b.ClearConnection(mockv5))
+	require.Nil(t, b.connections.Get(connName))
+	replicaDB.AssertCalled(t, "Close")
+}
@@ -116,6 +116,7 @@ func (b *databaseBackend) pathRotateRootCredentialsUpdate() framework.OperationF
 			dbi.Unlock()
 			// Even on error, still remove the connection
 			b.ClearConnectionId(name, dbi.id)
+			b.clearOverriddenConnections(name)
 		}()
 		defer func() {
 			// Close the plugin
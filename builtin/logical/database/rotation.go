@@ -625,6 +625,7 @@ func (b *databaseBackend) initQueue(ctx context.Context, conf *logical.BackendCo
 			}
 		}
 		go b.runTicker(ctx, queueTickerInterval, conf.StorageView)
+		go b.runCredentialPoolTicker(ctx, conf.StorageView)
 	}
 }
 
@@ -52,7 +52,7 @@ func (b *databaseBackend) secretCredsRenew() framework.OperationFunc {
 		}
 
 		// Get the Database object
-		dbi, err := b.GetConnection(ctx, req.Storage, role.DBName)
+		dbi, err := b.leaseConnection(ctx, req.Storage, req.Secret.InternalData, role.DBName)
 		if err != nil {
 			return nil, err
 		}
@@ -149,7 +149,7 @@ func (b *databaseBackend) secretCredsRevoke() framework.OperationFunc {
 		}
 
 		// Get our connection
-		dbi, err := b.GetConnection(ctx, req.Storage, dbName)
+		dbi, err := b.leaseConnection(ctx, req.Storage, req.Secret.InternalData, dbName)
 		if err != nil {
 			return nil, err
 		}
@@ -171,3 +171,23 @@ func (b *databaseBackend) secretCredsRevoke() framework.OperationFunc {
 		return resp, nil
 	}
 }
+
+// leaseConnection returns the connection the credential of a lease was
+// created through, as recorded in its internal data, rather than the one its
+// role currently uses. The database name is used for leases which predate it
+// being recorded.
+func (b *databaseBackend) leaseConnection(ctx context.Context, s logical.Storage, internal map[string]interface{}, dbName string) (*dbPluginInstance, error) {
+	if leaseDBName, ok := internal["db_name"].(string); ok && leaseDBName != "" {
+		dbName = leaseDBName
+	}
+
+	var overrides map[string]interface{}
+	if overridesRaw, ok := internal["connection_overrides"]; ok && overridesRaw != nil {
+		overrides, ok = overridesRaw.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("connection_overrides internal data is not a map")
+		}
+	}
+
+	return b.GetConnectionWithOverrides(ctx, s, dbName, overrides)
+}
@@ -0,0 +1,60 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package gpg
+
+import (
+	"context"
+	"strings"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const operationPrefixGPG = "gpg"
+
+func Factory(ctx context.Context, conf *logical.BackendConfig) (logical.Backend, error) {
+	b := Backend()
+	if err := b.Setup(ctx, conf); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func Backend() *backend {
+	var b backend
+	b.Backend = &framework.Backend{
+		Help: strings.TrimSpace(backendHelp),
+
+		PathsSpecial: &logical.Paths{
+			SealWrapStorage: []string{
+				"key/",
+			},
+		},
+
+		Paths: []*framework.Path{
+			pathListKeys(&b),
+			pathKeys(&b),
+			pathExportKey(&b),
+			pathSign(&b),
+			pathDetachedSign(&b),
+			pathVerify(&b),
+			pathEncrypt(&b),
+			pathDecrypt(&b),
+		},
+
+		Secrets:     []*framework.Secret{},
+		BackendType: logical.TypeLogical,
+	}
+
+	return &b
+}
+
+type backend struct {
+	*framework.Backend
+}
+
+const backendHelp = `
+The GPG backend stores or generates OpenPGP keys, and uses them to sign,
+verify, encrypt and decrypt data without the private keys leaving Vault.
+`
@@ -0,0 +1,338 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package gpg
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/clearsign"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+func createBackendWithStorage(t *testing.T) (*backend, logical.Storage) {
+	t.Helper()
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+
+	b := Backend()
+	if err := b.Setup(context.Background(), config); err != nil {
+		t.Fatal(err)
+	}
+	return b, config.StorageView
+}
+
+func TestBackend_KeyLifecycle(t *testing.T) {
+	b, s := createBackendWithStorage(t)
+
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "keys/debian",
+		Storage:   s,
+		Data: map[string]interface{}{
+			"key_type":  keyTypeED25519,
+			"real_name": "Example Archive",
+			"email":     "archive@example.com",
+		},
+	})
+	require.NoError(t, err)
+	require.False(t, resp.IsError(), resp)
+	require.Equal(t, []string{"Example Archive <archive@example.com>"}, resp.Data["identities"])
+	require.Len(t, resp.Data["fingerprint"], 40)
+	fingerprint := resp.Data["fingerprint"]
+
+	keyring, err := openpgp.ReadArmoredKeyRing(strings.NewReader(resp.Data["public_key"].(string)))
+	require.NoError(t, err)
+	require.Len(t, keyring, 1)
+	require.Nil(t, keyring[0].PrivateKey)
+
+	// Keys cannot be overwritten
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "keys/debian",
+		Storage:   s,
+	})
+	require.NoError(t, err)
+	require.True(t, resp.IsError())
+
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "keys/debian",
+		Storage:   s,
+	})
+	require.NoError(t, err)
+	require.Equal(t, fingerprint, resp.Data["fingerprint"])
+
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.ListOperation,
+		Path:      "keys/",
+		Storage:   s,
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"debian"}, resp.Data["keys"])
+
+	// Keys are not exportable by default
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "export/debian",
+		Storage:   s,
+	})
+	require.NoError(t, err)
+	require.True(t, resp.IsError())
+
+	_, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.DeleteOperation,
+		Path:      "keys/debian",
+		Storage:   s,
+	})
+	require.NoError(t, err)
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "keys/debian",
+		Storage:   s,
+	})
+	require.NoError(t, err)
+	require.Nil(t, resp)
+}
+
+func TestBackend_ImportExport(t *testing.T) {
+	b, s := createBackendWithStorage(t)
+
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "keys/exportable",
+		Storage:   s,
+		Data: map[string]interface{}{
+			"key_type":   keyTypeRSA,
+			"key_bits":   2048,
+			"exportable": true,
+		},
+	})
+	require.NoError(t, err)
+	require.False(t, resp.IsError(), resp)
+	fingerprint := resp.Data["fingerprint"]
+
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "export/exportable",
+		Storage:   s,
+	})
+	require.NoError(t, err)
+	require.False(t, resp.IsError(), resp)
+	privateKey := resp.Data["key"].(string)
+	require.Contains(t, privateKey, "PGP PRIVATE KEY BLOCK")
+
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "keys/imported",
+		Storage:   s,
+		Data: map[string]interface{}{
+			"generate": false,
+			"key":      privateKey,
+		},
+	})
+	require.NoError(t, err)
+	require.False(t, resp.IsError(), resp)
+	require.Equal(t, fingerprint, resp.Data["fingerprint"])
+	require.Equal(t, false, resp.Data["exportable"])
+
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "keys/public-only",
+		Storage:   s,
+		Data: map[string]interface{}{
+			"generate": false,
+			"key":      resp.Data["public_key"],
+		},
+	})
+	require.NoError(t, err)
+	require.True(t, resp.IsError())
+}
+
+func TestBackend_SignVerify(t *testing.T) {
+	b, s := createBackendWithStorage(t)
+
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "keys/release",
+		Storage:   s,
+		Data: map[string]interface{}{
+			"key_type": keyTypeED25519,
+		},
+	})
+	require.NoError(t, err)
+	require.False(t, resp.IsError(), resp)
+	keyring, err := openpgp.ReadArmoredKeyRing(strings.NewReader(resp.Data["public_key"].(string)))
+	require.NoError(t, err)
+
+	input := []byte("Origin: Example\nSuite: stable\n")
+	encoded := base64.StdEncoding.EncodeToString(input)
+
+	t.Run("clearsign", func(t *testing.T) {
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      "sign/release",
+			Storage:   s,
+			Data: map[string]interface{}{
+				"input":          encoded,
+				"hash_algorithm": "sha2-512",
+			},
+		})
+		require.NoError(t, err)
+		require.False(t, resp.IsError(), resp)
+
+		block, _ := clearsign.Decode([]byte(resp.Data["signature"].(string)))
+		require.NotNil(t, block)
+		require.Equal(t, input, block.Plaintext)
+		_, err = block.VerifySignature(keyring, nil)
+		require.NoError(t, err)
+	})
+
+	t.Run("detached", func(t *testing.T) {
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      "detached-sign/release",
+			Storage:   s,
+			Data: map[string]interface{}{
+				"input": encoded,
+			},
+		})
+		require.NoError(t, err)
+		require.False(t, resp.IsError(), resp)
+		armored := resp.Data["signature"].(string)
+
+		_, err = openpgp.CheckArmoredDetachedSignature(keyring, bytes.NewReader(input), strings.NewReader(armored), nil)
+		require.NoError(t, err)
+
+		resp, err = b.HandleRequest(context.Background(), &logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      "verify/release",
+			Storage:   s,
+			Data: map[string]interface{}{
+				"input":     encoded,
+				"signature": armored,
+			},
+		})
+		require.NoError(t, err)
+		require.Equal(t, true, resp.Data["valid"])
+
+		resp, err = b.HandleRequest(context.Background(), &logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      "detached-sign/release",
+			Storage:   s,
+			Data: map[string]interface{}{
+				"input":  encoded,
+				"format": formatBase64,
+			},
+		})
+		require.NoError(t, err)
+		require.False(t, resp.IsError(), resp)
+
+		resp, err = b.HandleRequest(context.Background(), &logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      "verify/release",
+			Storage:   s,
+			Data: map[string]interface{}{
+				"input":     encoded,
+				"signature": resp.Data["signature"],
+			},
+		})
+		require.NoError(t, err)
+		require.Equal(t, true, resp.Data["valid"])
+
+		resp, err = b.HandleRequest(context.Background(), &logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      "verify/release",
+			Storage:   s,
+			Data: map[string]interface{}{
+				"input":     base64.StdEncoding.EncodeToString([]byte("tampered")),
+				"signature": armored,
+			},
+		})
+		require.NoError(t, err)
+		require.Equal(t, false, resp.Data["valid"])
+		require.NotEmpty(t, resp.Warnings)
+	})
+
+	t.Run("unknown hash algorithm", func(t *testing.T) {
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      "detached-sign/release",
+			Storage:   s,
+			Data: map[string]interface{}{
+				"input":          encoded,
+				"hash_algorithm": "md5",
+			},
+		})
+		require.NoError(t, err)
+		require.True(t, resp.IsError())
+	})
+}
+
+func TestBackend_EncryptDecrypt(t *testing.T) {
+	b, s := createBackendWithStorage(t)
+
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "keys/secrets",
+		Storage:   s,
+		Data: map[string]interface{}{
+			"key_type": keyTypeED25519,
+		},
+	})
+	require.NoError(t, err)
+	require.False(t, resp.IsError(), resp)
+
+	plaintext := base64.StdEncoding.EncodeToString([]byte("the secret"))
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "encrypt/secrets",
+		Storage:   s,
+		Data: map[string]interface{}{
+			"plaintext": plaintext,
+		},
+	})
+	require.NoError(t, err)
+	require.False(t, resp.IsError(), resp)
+	ciphertext := resp.Data["ciphertext"].(string)
+	require.Contains(t, ciphertext, "BEGIN PGP MESSAGE")
+
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "decrypt/secrets",
+		Storage:   s,
+		Data: map[string]interface{}{
+			"ciphertext": ciphertext,
+		},
+	})
+	require.NoError(t, err)
+	require.False(t, resp.IsError(), resp)
+	require.Equal(t, plaintext, resp.Data["plaintext"])
+
+	// Messages encrypted to another key cannot be decrypted
+	_, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "keys/other",
+		Storage:   s,
+		Data: map[string]interface{}{
+			"key_type": keyTypeED25519,
+		},
+	})
+	require.NoError(t, err)
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "decrypt/other",
+		Storage:   s,
+		Data: map[string]interface{}{
+			"ciphertext": ciphertext,
+		},
+	})
+	require.NoError(t, err)
+	require.True(t, resp.IsError())
+}
@@ -0,0 +1,183 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package gpg
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"io"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func pathEncrypt(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "encrypt/" + framework.GenericNameRegex("name"),
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixGPG,
+			OperationVerb:   "encrypt",
+		},
+
+		Fields: map[string]*framework.FieldSchema{
+			"name": {
+				Type:        framework.TypeString,
+				Description: "Name of the key.",
+			},
+
+			"plaintext": {
+				Type:        framework.TypeString,
+				Description: "The base64-encoded data to encrypt.",
+			},
+		},
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.UpdateOperation: &framework.PathOperation{
+				Callback: b.pathEncryptWrite,
+			},
+		},
+
+		HelpSynopsis:    pathEncryptHelpSyn,
+		HelpDescription: pathEncryptHelpDesc,
+	}
+}
+
+func pathDecrypt(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "decrypt/" + framework.GenericNameRegex("name"),
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixGPG,
+			OperationVerb:   "decrypt",
+		},
+
+		Fields: map[string]*framework.FieldSchema{
+			"name": {
+				Type:        framework.TypeString,
+				Description: "Name of the key.",
+			},
+
+			"ciphertext": {
+				Type:        framework.TypeString,
+				Description: "The ASCII-armored message to decrypt.",
+			},
+		},
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.UpdateOperation: &framework.PathOperation{
+				Callback: b.pathDecryptWrite,
+			},
+		},
+
+		HelpSynopsis:    pathDecryptHelpSyn,
+		HelpDescription: pathDecryptHelpDesc,
+	}
+}
+
+func (b *backend) pathEncryptWrite(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	name := data.Get("name").(string)
+	key, err := b.Key(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		return logical.ErrorResponse("key %q not found", name), nil
+	}
+	entity, err := key.entity()
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := base64.StdEncoding.DecodeString(data.Get("plaintext").(string))
+	if err != nil {
+		return logical.ErrorResponse("unable to decode plaintext as base64: %s", err), nil
+	}
+
+	var buf bytes.Buffer
+	armored, err := armor.Encode(&buf, "PGP MESSAGE", nil)
+	if err != nil {
+		return nil, err
+	}
+	w, err := openpgp.Encrypt(armored, []*openpgp.Entity{entity}, nil, nil, nil)
+	if err != nil {
+		return logical.ErrorResponse("unable to encrypt: %s", err), nil
+	}
+	if _, err := w.Write(plaintext); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	if err := armored.Close(); err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"ciphertext": buf.String(),
+		},
+	}, nil
+}
+
+func (b *backend) pathDecryptWrite(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	name := data.Get("name").(string)
+	key, err := b.Key(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		return logical.ErrorResponse("key %q not found", name), nil
+	}
+	entity, err := key.entity()
+	if err != nil {
+		return nil, err
+	}
+
+	ciphertext := data.Get("ciphertext").(string)
+	if ciphertext == "" {
+		return logical.ErrorResponse("missing ciphertext"), nil
+	}
+	block, err := armor.Decode(strings.NewReader(ciphertext))
+	if err != nil {
+		return logical.ErrorResponse("unable to decode ciphertext: %s", err), nil
+	}
+
+	md, err := openpgp.ReadMessage(block.Body, openpgp.EntityList{entity}, nil, nil)
+	if err != nil {
+		return logical.ErrorResponse("unable to decrypt: %s", err), nil
+	}
+	plaintext, err := io.ReadAll(md.UnverifiedBody)
+	if err != nil {
+		return logical.ErrorResponse("unable to decrypt: %s", err), nil
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"plaintext": base64.StdEncoding.EncodeToString(plaintext),
+		},
+	}, nil
+}
+
+const pathEncryptHelpSyn = `
+Encrypt data to a key.
+`
+
+const pathEncryptHelpDesc = `
+This path encrypts the plaintext to the named key's encryption subkey,
+returning an ASCII-armored OpenPGP message.
+`
+
+const pathDecryptHelpSyn = `
+Decrypt data encrypted to a key.
+`
+
+const pathDecryptHelpDesc = `
+This path decrypts an ASCII-armored OpenPGP message encrypted to the named
+key, returning the base64-encoded plaintext.
+`
@@ -0,0 +1,390 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package gpg
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	keyTypeRSA     = "rsa"
+	keyTypeED25519 = "ed25519"
+)
+
+func pathListKeys(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "keys/?$",
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixGPG,
+			OperationSuffix: "keys",
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ListOperation: b.pathKeyList,
+		},
+
+		HelpSynopsis:    pathKeyHelpSyn,
+		HelpDescription: pathKeyHelpDesc,
+	}
+}
+
+func pathKeys(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "keys/" + framework.GenericNameRegex("name"),
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixGPG,
+			OperationSuffix: "key",
+		},
+
+		Fields: map[string]*framework.FieldSchema{
+			"name": {
+				Type:        framework.TypeString,
+				Description: "Name of the key.",
+			},
+
+			"generate": {
+				Type:        framework.TypeBool,
+				Default:     true,
+				Description: "Determines if a key should be generated by Vault or if an existing key is being imported.",
+			},
+
+			"key": {
+				Type:        framework.TypeString,
+				Description: "The ASCII-armored private key to import. Only used if generate is false.",
+			},
+
+			"key_type": {
+				Type:        framework.TypeString,
+				Default:     keyTypeRSA,
+				Description: `The type of key to generate. Options include rsa and ed25519. Only used if generate is true.`,
+			},
+
+			"key_bits": {
+				Type:        framework.TypeInt,
+				Default:     4096,
+				Description: `The size in bits of generated RSA keys. Options include 2048, 3072 and 4096. Only used if generate is true.`,
+			},
+
+			"real_name": {
+				Type:        framework.TypeString,
+				Description: `The real name of the identity of generated keys.`,
+			},
+
+			"email": {
+				Type:        framework.TypeString,
+				Description: `The email of the identity of generated keys.`,
+			},
+
+			"comment": {
+				Type:        framework.TypeString,
+				Description: `The comment of the identity of generated keys.`,
+			},
+
+			"exportable": {
+				Type:        framework.TypeBool,
+				Description: `Determines if the private key can be exported.`,
+			},
+		},
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.ReadOperation: &framework.PathOperation{
+				Callback: b.pathKeyRead,
+				DisplayAttrs: &framework.DisplayAttributes{
+					OperationVerb: "read",
+				},
+			},
+			logical.UpdateOperation: &framework.PathOperation{
+				Callback: b.pathKeyCreate,
+				DisplayAttrs: &framework.DisplayAttributes{
+					OperationVerb: "create",
+				},
+			},
+			logical.DeleteOperation: &framework.PathOperation{
+				Callback: b.pathKeyDelete,
+				DisplayAttrs: &framework.DisplayAttributes{
+					OperationVerb: "delete",
+				},
+			},
+		},
+
+		HelpSynopsis:    pathKeyHelpSyn,
+		HelpDescription: pathKeyHelpDesc,
+	}
+}
+
+func pathExportKey(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "export/" + framework.GenericNameRegex("name"),
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixGPG,
+			OperationVerb:   "export",
+			OperationSuffix: "key",
+		},
+
+		Fields: map[string]*framework.FieldSchema{
+			"name": {
+				Type:        framework.TypeString,
+				Description: "Name of the key.",
+			},
+		},
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.ReadOperation: &framework.PathOperation{
+				Callback: b.pathKeyExport,
+			},
+		},
+
+		HelpSynopsis:    pathExportHelpSyn,
+		HelpDescription: pathExportHelpDesc,
+	}
+}
+
+func (b *backend) Key(ctx context.Context, s logical.Storage, n string) (*keyEntry, error) {
+	entry, err := s.Get(ctx, "key/"+n)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var result keyEntry
+	if err := entry.DecodeJSON(&result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (b *backend) pathKeyDelete(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	err := req.Storage.Delete(ctx, "key/"+data.Get("name").(string))
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+func (b *backend) pathKeyRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	key, err := b.Key(ctx, req.Storage, data.Get("name").(string))
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		return nil, nil
+	}
+
+	entity, err := key.entity()
+	if err != nil {
+		return nil, err
+	}
+	publicKey, err := armorEntity(entity, false)
+	if err != nil {
+		return nil, err
+	}
+
+	identities := make([]string, 0, len(entity.Identities))
+	for identity := range entity.Identities {
+		identities = append(identities, identity)
+	}
+	sort.Strings(identities)
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"fingerprint": strings.ToUpper(hex.EncodeToString(entity.PrimaryKey.Fingerprint)),
+			"public_key":  publicKey,
+			"identities":  identities,
+			"exportable":  key.Exportable,
+		},
+	}, nil
+}
+
+func (b *backend) pathKeyList(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	entries, err := req.Storage.List(ctx, "key/")
+	if err != nil {
+		return nil, err
+	}
+
+	return logical.ListResponse(entries), nil
+}
+
+func (b *backend) pathKeyCreate(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	name := data.Get("name").(string)
+
+	existing, err := b.Key(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return logical.ErrorResponse("key %q already exists; delete it to create a new one", name), nil
+	}
+
+	var entity *openpgp.Entity
+	if data.Get("generate").(bool) {
+		config := &packet.Config{}
+		switch keyType := data.Get("key_type").(string); keyType {
+		case keyTypeRSA:
+			config.Algorithm = packet.PubKeyAlgoRSA
+			switch keyBits := data.Get("key_bits").(int); keyBits {
+			case 2048, 3072, 4096:
+				config.RSABits = keyBits
+			default:
+				return logical.ErrorResponse("invalid key_bits %d; must be 2048, 3072 or 4096", keyBits), nil
+			}
+		case keyTypeED25519:
+			config.Algorithm = packet.PubKeyAlgoEdDSA
+		default:
+			return logical.ErrorResponse("unknown key_type %q", keyType), nil
+		}
+
+		realName := data.Get("real_name").(string)
+		if realName == "" {
+			realName = name
+		}
+		entity, err = openpgp.NewEntity(realName, data.Get("comment").(string), data.Get("email").(string), config)
+		if err != nil {
+			return logical.ErrorResponse("unable to generate key: %s", err), nil
+		}
+	} else {
+		armored := data.Get("key").(string)
+		if armored == "" {
+			return logical.ErrorResponse("the key to import must be given if generate is false"), nil
+		}
+		entities, err := openpgp.ReadArmoredKeyRing(strings.NewReader(armored))
+		if err != nil {
+			return logical.ErrorResponse("unable to parse key: %s", err), nil
+		}
+		if len(entities) != 1 {
+			return logical.ErrorResponse("the key must contain exactly one entity, not %d", len(entities)), nil
+		}
+		entity = entities[0]
+		if entity.PrivateKey == nil {
+			return logical.ErrorResponse("the key must contain a private key"), nil
+		}
+		if entity.PrivateKey.Encrypted {
+			return logical.ErrorResponse("the private key must not be protected by a passphrase"), nil
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := entity.SerializePrivateWithoutSigning(&buf, nil); err != nil {
+		return nil, err
+	}
+
+	entry, err := logical.StorageEntryJSON("key/"+name, &keyEntry{
+		SerializedKey: buf.Bytes(),
+		Exportable:    data.Get("exportable").(bool),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+
+	return b.pathKeyRead(ctx, req, data)
+}
+
+func (b *backend) pathKeyExport(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	name := data.Get("name").(string)
+	key, err := b.Key(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		return nil, nil
+	}
+	if !key.Exportable {
+		return logical.ErrorResponse("key %q is not exportable", name), nil
+	}
+
+	entity, err := key.entity()
+	if err != nil {
+		return nil, err
+	}
+	privateKey, err := armorEntity(entity, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"name": name,
+			"key":  privateKey,
+		},
+	}, nil
+}
+
+type keyEntry struct {
+	// SerializedKey is the binary serialization of the key's private entity
+	SerializedKey []byte `json:"serialized_key"`
+	Exportable    bool   `json:"exportable"`
+}
+
+func (k *keyEntry) entity() (*openpgp.Entity, error) {
+	entity, err := openpgp.ReadEntity(packet.NewReader(bytes.NewReader(k.SerializedKey)))
+	if err != nil {
+		return nil, fmt.Errorf("unable to read stored key: %w", err)
+	}
+	return entity, nil
+}
+
+// armorEntity returns the ASCII-armored public key of the entity, or its
+// private key if private is set.
+func armorEntity(entity *openpgp.Entity, private bool) (string, error) {
+	blockType := openpgp.PublicKeyType
+	serialize := entity.Serialize
+	if private {
+		blockType = openpgp.PrivateKeyType
+		serialize = func(w io.Writer) error {
+			return entity.SerializePrivateWithoutSigning(w, nil)
+		}
+	}
+
+	var buf bytes.Buffer
+	w, err := armor.Encode(&buf, blockType, nil)
+	if err != nil {
+		return "", err
+	}
+	if err := serialize(w); err != nil {
+		return "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+const pathKeyHelpSyn = `
+Manage the OpenPGP keys of the backend.
+`
+
+const pathKeyHelpDesc = `
+This path lets you generate, import, read and delete OpenPGP keys. Reading a
+key returns its fingerprint, identities and ASCII-armored public key.
+`
+
+const pathExportHelpSyn = `
+Export the private key of an exportable key.
+`
+
+const pathExportHelpDesc = `
+This path returns the ASCII-armored private key of a key created with
+exportable set to true.
+`
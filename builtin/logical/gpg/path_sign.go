@@ -0,0 +1,304 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package gpg
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/clearsign"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	formatASCIIArmor = "ascii-armor"
+	formatBase64     = "base64"
+)
+
+func signFields() map[string]*framework.FieldSchema {
+	return map[string]*framework.FieldSchema{
+		"name": {
+			Type:        framework.TypeString,
+			Description: "Name of the key.",
+		},
+
+		"input": {
+			Type:        framework.TypeString,
+			Description: "The base64-encoded data to sign.",
+		},
+
+		"hash_algorithm": {
+			Type:        framework.TypeString,
+			Default:     "sha2-256",
+			Description: `The hash algorithm of the signature. Options include sha2-256, sha2-384 and sha2-512.`,
+		},
+	}
+}
+
+func pathSign(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "sign/" + framework.GenericNameRegex("name"),
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixGPG,
+			OperationVerb:   "sign",
+		},
+
+		Fields: signFields(),
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.UpdateOperation: &framework.PathOperation{
+				Callback: b.pathSignWrite,
+			},
+		},
+
+		HelpSynopsis:    pathSignHelpSyn,
+		HelpDescription: pathSignHelpDesc,
+	}
+}
+
+func pathDetachedSign(b *backend) *framework.Path {
+	fields := signFields()
+	fields["format"] = &framework.FieldSchema{
+		Type:        framework.TypeString,
+		Default:     formatASCIIArmor,
+		Description: `The encoding of the signature. Options include ascii-armor and base64.`,
+	}
+
+	return &framework.Path{
+		Pattern: "detached-sign/" + framework.GenericNameRegex("name"),
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixGPG,
+			OperationVerb:   "sign",
+			OperationSuffix: "detached",
+		},
+
+		Fields: fields,
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.UpdateOperation: &framework.PathOperation{
+				Callback: b.pathDetachedSignWrite,
+			},
+		},
+
+		HelpSynopsis:    pathDetachedSignHelpSyn,
+		HelpDescription: pathDetachedSignHelpDesc,
+	}
+}
+
+func pathVerify(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "verify/" + framework.GenericNameRegex("name"),
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixGPG,
+			OperationVerb:   "verify",
+		},
+
+		Fields: map[string]*framework.FieldSchema{
+			"name": {
+				Type:        framework.TypeString,
+				Description: "Name of the key.",
+			},
+
+			"input": {
+				Type:        framework.TypeString,
+				Description: "The base64-encoded data the signature is of.",
+			},
+
+			"signature": {
+				Type:        framework.TypeString,
+				Description: "The detached signature, either ASCII-armored or base64-encoded.",
+			},
+		},
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.UpdateOperation: &framework.PathOperation{
+				Callback: b.pathVerifyWrite,
+			},
+		},
+
+		HelpSynopsis:    pathVerifyHelpSyn,
+		HelpDescription: pathVerifyHelpDesc,
+	}
+}
+
+// signingKey returns the entity of the named key along with the decoded input
+// and the signature config requested by the fields.
+func (b *backend) signingKey(ctx context.Context, req *logical.Request, data *framework.FieldData) (*openpgp.Entity, []byte, *packet.Config, *logical.Response, error) {
+	name := data.Get("name").(string)
+	key, err := b.Key(ctx, req.Storage, name)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	if key == nil {
+		return nil, nil, nil, logical.ErrorResponse("key %q not found", name), nil
+	}
+	entity, err := key.entity()
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+
+	input, err := base64.StdEncoding.DecodeString(data.Get("input").(string))
+	if err != nil {
+		return nil, nil, nil, logical.ErrorResponse("unable to decode input as base64: %s", err), nil
+	}
+
+	config := &packet.Config{}
+	switch hashAlgorithm := data.Get("hash_algorithm").(string); hashAlgorithm {
+	case "sha2-256":
+		config.DefaultHash = crypto.SHA256
+	case "sha2-384":
+		config.DefaultHash = crypto.SHA384
+	case "sha2-512":
+		config.DefaultHash = crypto.SHA512
+	default:
+		return nil, nil, nil, logical.ErrorResponse("unsupported hash_algorithm %q", hashAlgorithm), nil
+	}
+
+	return entity, input, config, nil, nil
+}
+
+func (b *backend) pathSignWrite(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	entity, input, config, resp, err := b.signingKey(ctx, req, data)
+	if resp != nil || err != nil {
+		return resp, err
+	}
+
+	signingKey, ok := entity.SigningKey(config.Now())
+	if !ok {
+		return logical.ErrorResponse("key has no valid signing key"), nil
+	}
+
+	var buf bytes.Buffer
+	w, err := clearsign.Encode(&buf, signingKey.PrivateKey, config)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(input); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"signature": buf.String(),
+		},
+	}, nil
+}
+
+func (b *backend) pathDetachedSignWrite(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	entity, input, config, resp, err := b.signingKey(ctx, req, data)
+	if resp != nil || err != nil {
+		return resp, err
+	}
+
+	var buf bytes.Buffer
+	var signature string
+	switch format := data.Get("format").(string); format {
+	case formatASCIIArmor:
+		if err := openpgp.ArmoredDetachSign(&buf, entity, bytes.NewReader(input), config); err != nil {
+			return logical.ErrorResponse("unable to sign input: %s", err), nil
+		}
+		signature = buf.String()
+	case formatBase64:
+		if err := openpgp.DetachSign(&buf, entity, bytes.NewReader(input), config); err != nil {
+			return logical.ErrorResponse("unable to sign input: %s", err), nil
+		}
+		signature = base64.StdEncoding.EncodeToString(buf.Bytes())
+	default:
+		return logical.ErrorResponse("unsupported format %q", format), nil
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"signature": signature,
+		},
+	}, nil
+}
+
+func (b *backend) pathVerifyWrite(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	name := data.Get("name").(string)
+	key, err := b.Key(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		return logical.ErrorResponse("key %q not found", name), nil
+	}
+	entity, err := key.entity()
+	if err != nil {
+		return nil, err
+	}
+
+	input, err := base64.StdEncoding.DecodeString(data.Get("input").(string))
+	if err != nil {
+		return logical.ErrorResponse("unable to decode input as base64: %s", err), nil
+	}
+
+	signature := data.Get("signature").(string)
+	if signature == "" {
+		return logical.ErrorResponse("missing signature"), nil
+	}
+
+	keyring := openpgp.EntityList{entity}
+	if strings.HasPrefix(strings.TrimSpace(signature), "-----BEGIN") {
+		_, err = openpgp.CheckArmoredDetachedSignature(keyring, bytes.NewReader(input), strings.NewReader(signature), nil)
+	} else {
+		var decoded []byte
+		decoded, err = base64.StdEncoding.DecodeString(signature)
+		if err != nil {
+			return logical.ErrorResponse("unable to decode signature as base64: %s", err), nil
+		}
+		_, err = openpgp.CheckDetachedSignature(keyring, bytes.NewReader(input), bytes.NewReader(decoded), nil)
+	}
+
+	resp := &logical.Response{
+		Data: map[string]interface{}{
+			"valid": err == nil,
+		},
+	}
+	if err != nil {
+		resp.AddWarning(fmt.Sprintf("signature verification failed: %s", err))
+	}
+	return resp, nil
+}
+
+const pathSignHelpSyn = `
+Produce a cleartext signature of data.
+`
+
+const pathSignHelpDesc = `
+This path signs the input with the named key, returning it as a cleartext
+signed message as "gpg --clearsign" does, such as for the InRelease files of
+Debian repositories.
+`
+
+const pathDetachedSignHelpSyn = `
+Produce a detached signature of data.
+`
+
+const pathDetachedSignHelpDesc = `
+This path signs the input with the named key, returning a detached signature
+as "gpg --detach-sign" does, such as for git tags and Release.gpg files.
+`
+
+const pathVerifyHelpSyn = `
+Verify a detached signature of data.
+`
+
+const pathVerifyHelpDesc = `
+This path verifies that a detached signature of the input was made with the
+named key.
+`
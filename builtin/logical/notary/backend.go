@@ -0,0 +1,65 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package notary
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/hashicorp/go-cleanhttp"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const operationPrefixNotary = "notary"
+
+func Factory(ctx context.Context, conf *logical.BackendConfig) (logical.Backend, error) {
+	b := Backend()
+	if err := b.Setup(ctx, conf); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func Backend() *backend {
+	var b backend
+	b.Backend = &framework.Backend{
+		Help: strings.TrimSpace(backendHelp),
+
+		PathsSpecial: &logical.Paths{
+			SealWrapStorage: []string{
+				"key/",
+			},
+		},
+
+		Paths: []*framework.Path{
+			pathListKeys(&b),
+			pathKeys(&b),
+			pathListRoles(&b),
+			pathRoles(&b),
+			pathSign(&b),
+		},
+
+		Secrets:     []*framework.Secret{},
+		BackendType: logical.TypeLogical,
+	}
+
+	b.httpClient = cleanhttp.DefaultClient()
+
+	return &b
+}
+
+type backend struct {
+	*framework.Backend
+
+	// httpClient is used to submit signatures to transparency logs
+	httpClient *http.Client
+}
+
+const backendHelp = `
+The notary backend signs software artifacts, such as container images and
+binaries, with keys held by Vault. Signatures are produced in formats that
+cosign and GPG can verify, and can be recorded in a transparency log.
+`
@@ -0,0 +1,301 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package notary
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+func createBackendWithStorage(t *testing.T) (*backend, logical.Storage) {
+	t.Helper()
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+
+	b := Backend()
+	if err := b.Setup(context.Background(), config); err != nil {
+		t.Fatal(err)
+	}
+	return b, config.StorageView
+}
+
+func parsePublicKey(t *testing.T, resp *logical.Response) interface{} {
+	t.Helper()
+
+	block, _ := pem.Decode([]byte(resp.Data["public_key"].(string)))
+	require.NotNil(t, block)
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	require.NoError(t, err)
+	return pub
+}
+
+func TestBackend_SignCosign(t *testing.T) {
+	b, s := createBackendWithStorage(t)
+
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "keys/ci",
+		Storage:   s,
+	})
+	require.NoError(t, err)
+	require.False(t, resp.IsError(), resp)
+	require.Equal(t, keyTypeECDSAP256, resp.Data["type"])
+	require.Nil(t, resp.Data["gpg_public_key"])
+	pub := parsePublicKey(t, resp).(*ecdsa.PublicKey)
+
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "roles/app",
+		Storage:   s,
+		Data: map[string]interface{}{
+			"key":                  "ci",
+			"allowed_repositories": "registry.example.com/team/*",
+		},
+	})
+	require.NoError(t, err)
+	require.Nil(t, resp)
+
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "roles/app",
+		Storage:   s,
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{formatCosign, formatBlob}, resp.Data["allowed_formats"])
+
+	digest := "sha256:" + strings.Repeat("ab", sha256.Size)
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "sign/app",
+		Storage:   s,
+		Data: map[string]interface{}{
+			"repository":  "registry.example.com/team/app",
+			"digest":      digest,
+			"annotations": "commit=abc123",
+		},
+	})
+	require.NoError(t, err)
+	require.False(t, resp.IsError(), resp)
+
+	payload, err := base64.StdEncoding.DecodeString(resp.Data["payload"].(string))
+	require.NoError(t, err)
+	var decoded simpleSigningPayload
+	require.NoError(t, json.Unmarshal(payload, &decoded))
+	require.Equal(t, "registry.example.com/team/app", decoded.Critical.Identity.DockerReference)
+	require.Equal(t, digest, decoded.Critical.Image.DockerManifestDigest)
+	require.Equal(t, cosignSignatureType, decoded.Critical.Type)
+	require.Equal(t, map[string]string{"commit": "abc123"}, decoded.Optional)
+
+	signature, err := base64.StdEncoding.DecodeString(resp.Data["signature"].(string))
+	require.NoError(t, err)
+	payloadDigest := sha256.Sum256(payload)
+	require.True(t, ecdsa.VerifyASN1(pub, payloadDigest[:], signature))
+
+	// Repositories and formats not allowed by the role are rejected
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "sign/app",
+		Storage:   s,
+		Data: map[string]interface{}{
+			"repository": "registry.example.com/other/app",
+			"digest":     digest,
+		},
+	})
+	require.NoError(t, err)
+	require.True(t, resp.IsError())
+
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "sign/app",
+		Storage:   s,
+		Data: map[string]interface{}{
+			"repository": "registry.example.com/team/app",
+			"format":     formatGPG,
+			"input":      base64.StdEncoding.EncodeToString([]byte("artifact")),
+		},
+	})
+	require.NoError(t, err)
+	require.True(t, resp.IsError())
+
+	// GPG signatures require an RSA key
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "roles/app",
+		Storage:   s,
+		Data: map[string]interface{}{
+			"allowed_formats": "cosign,gpg",
+		},
+	})
+	require.NoError(t, err)
+	require.True(t, resp.IsError())
+}
+
+func TestBackend_SignBlob(t *testing.T) {
+	b, s := createBackendWithStorage(t)
+
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "keys/ci",
+		Storage:   s,
+		Data: map[string]interface{}{
+			"type": keyTypeED25519,
+		},
+	})
+	require.NoError(t, err)
+	require.False(t, resp.IsError(), resp)
+	pub := parsePublicKey(t, resp).(ed25519.PublicKey)
+
+	_, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "roles/bin",
+		Storage:   s,
+		Data: map[string]interface{}{
+			"key":                  "ci",
+			"allowed_repositories": "github.com/example/tool",
+			"allowed_formats":      formatBlob,
+		},
+	})
+	require.NoError(t, err)
+
+	artifact := []byte("binary contents")
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "sign/bin",
+		Storage:   s,
+		Data: map[string]interface{}{
+			"repository": "github.com/example/tool",
+			"format":     formatBlob,
+			"input":      base64.StdEncoding.EncodeToString(artifact),
+		},
+	})
+	require.NoError(t, err)
+	require.False(t, resp.IsError(), resp)
+
+	artifactDigest := sha256.Sum256(artifact)
+	require.Equal(t, "sha256:"+hex.EncodeToString(artifactDigest[:]), resp.Data["digest"])
+	signature, err := base64.StdEncoding.DecodeString(resp.Data["signature"].(string))
+	require.NoError(t, err)
+	require.True(t, ed25519.Verify(pub, artifact, signature))
+
+	// ed25519 keys cannot sign a digest alone
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "sign/bin",
+		Storage:   s,
+		Data: map[string]interface{}{
+			"repository": "github.com/example/tool",
+			"format":     formatBlob,
+			"digest":     resp.Data["digest"],
+		},
+	})
+	require.NoError(t, err)
+	require.True(t, resp.IsError())
+}
+
+func TestBackend_SignGPG(t *testing.T) {
+	b, s := createBackendWithStorage(t)
+
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "keys/release",
+		Storage:   s,
+		Data: map[string]interface{}{
+			"type":      keyTypeRSA2048,
+			"gpg_name":  "Release Signing",
+			"gpg_email": "release@example.com",
+		},
+	})
+	require.NoError(t, err)
+	require.False(t, resp.IsError(), resp)
+	gpgPublicKey := resp.Data["gpg_public_key"].(string)
+
+	// The GPG public key is stable across reads
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "keys/release",
+		Storage:   s,
+	})
+	require.NoError(t, err)
+	require.Equal(t, gpgPublicKey, resp.Data["gpg_public_key"])
+
+	log := make(chan transparencyLogEntry, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var entry transparencyLogEntry
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&entry))
+		log <- entry
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "roles/release",
+		Storage:   s,
+		Data: map[string]interface{}{
+			"key":                  "release",
+			"allowed_repositories": "github.com/example/*",
+			"transparency_log_url": server.URL,
+		},
+	})
+	require.NoError(t, err)
+	require.Nil(t, resp)
+
+	artifact := []byte("release tarball")
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "sign/release",
+		Storage:   s,
+		Data: map[string]interface{}{
+			"repository": "github.com/example/tool",
+			"format":     formatGPG,
+			"input":      base64.StdEncoding.EncodeToString(artifact),
+		},
+	})
+	require.NoError(t, err)
+	require.False(t, resp.IsError(), resp)
+
+	keyring, err := openpgp.ReadArmoredKeyRing(strings.NewReader(gpgPublicKey))
+	require.NoError(t, err)
+	signer, err := openpgp.CheckArmoredDetachedSignature(keyring, bytes.NewReader(artifact), strings.NewReader(resp.Data["signature"].(string)), nil)
+	require.NoError(t, err)
+	require.Contains(t, signer.Identities, "Release Signing <release@example.com>")
+
+	entry := <-log
+	require.Equal(t, "release", entry.Role)
+	require.Equal(t, "github.com/example/tool", entry.Repository)
+	require.Equal(t, resp.Data["signature"], entry.Signature)
+	require.Equal(t, resp.Data["digest"], entry.Digest)
+
+	// Signing fails when the transparency log rejects the signature
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	_, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "sign/release",
+		Storage:   s,
+		Data: map[string]interface{}{
+			"repository": "github.com/example/tool",
+			"format":     formatGPG,
+			"input":      base64.StdEncoding.EncodeToString(artifact),
+		},
+	})
+	require.ErrorContains(t, err, "transparency log rejected signature")
+}
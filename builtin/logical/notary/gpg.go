@@ -0,0 +1,108 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package notary
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rsa"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+)
+
+// supportsGPG returns whether the key can produce GPG signatures. Only RSA
+// keys are supported, as the OpenPGP library does not accept the standard
+// library's elliptic curve keys.
+func (k *keyEntry) supportsGPG() bool {
+	return strings.HasPrefix(k.Type, "rsa-")
+}
+
+// gpgConfig pins the time of the key's self-signatures to the key's creation
+// time, so that the GPG public key and its fingerprint are stable.
+func (k *keyEntry) gpgConfig() *packet.Config {
+	return &packet.Config{
+		DefaultHash: crypto.SHA256,
+		Time: func() time.Time {
+			return k.CreatedTime
+		},
+	}
+}
+
+// gpgEntity returns the OpenPGP entity of the key, using the key as its
+// primary signing key.
+func (k *keyEntry) gpgEntity(name string) (*openpgp.Entity, error) {
+	if !k.supportsGPG() {
+		return nil, fmt.Errorf("GPG signatures require an RSA key, not %q", k.Type)
+	}
+
+	signer, err := k.signer()
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := signer.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("unexpected private key type %T", signer)
+	}
+
+	primary := packet.NewSignerPrivateKey(k.CreatedTime, rsaKey)
+	entity := &openpgp.Entity{
+		PrimaryKey: &primary.PublicKey,
+		PrivateKey: primary,
+		Identities: make(map[string]*openpgp.Identity),
+	}
+
+	gpgName := k.GPGName
+	if gpgName == "" {
+		gpgName = name
+	}
+	if err := entity.AddUserId(gpgName, "", k.GPGEmail, k.gpgConfig()); err != nil {
+		return nil, err
+	}
+
+	return entity, nil
+}
+
+// gpgPublicKey returns the key's armored GPG public key.
+func (k *keyEntry) gpgPublicKey(name string) (string, error) {
+	entity, err := k.gpgEntity(name)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	w, err := armor.Encode(&buf, openpgp.PublicKeyType, nil)
+	if err != nil {
+		return "", err
+	}
+	if err := entity.Serialize(w); err != nil {
+		return "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// gpgSign returns an armored detached GPG signature of input.
+func (k *keyEntry) gpgSign(name string, input io.Reader) (string, error) {
+	entity, err := k.gpgEntity(name)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	config := &packet.Config{
+		DefaultHash: crypto.SHA256,
+	}
+	if err := openpgp.ArmoredDetachSign(&buf, entity, input, config); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,284 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package notary
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	keyTypeECDSAP256 = "ecdsa-p256"
+	keyTypeED25519   = "ed25519"
+	keyTypeRSA2048   = "rsa-2048"
+	keyTypeRSA4096   = "rsa-4096"
+)
+
+func pathListKeys(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "keys/?$",
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixNotary,
+			OperationSuffix: "keys",
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ListOperation: b.pathKeyList,
+		},
+
+		HelpSynopsis:    pathKeyHelpSyn,
+		HelpDescription: pathKeyHelpDesc,
+	}
+}
+
+func pathKeys(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "keys/" + framework.GenericNameRegex("name"),
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixNotary,
+			OperationSuffix: "key",
+		},
+
+		Fields: map[string]*framework.FieldSchema{
+			"name": {
+				Type:        framework.TypeString,
+				Description: "Name of the key.",
+			},
+
+			"type": {
+				Type:        framework.TypeString,
+				Default:     keyTypeECDSAP256,
+				Description: `The type of key to generate. Options include ecdsa-p256, ed25519, rsa-2048 and rsa-4096. GPG signatures require an RSA key.`,
+			},
+
+			"gpg_name": {
+				Type:        framework.TypeString,
+				Description: `The name of the user ID of the key's GPG public key. Defaults to the name of the key.`,
+			},
+
+			"gpg_email": {
+				Type:        framework.TypeString,
+				Description: `The email of the user ID of the key's GPG public key.`,
+			},
+		},
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.ReadOperation: &framework.PathOperation{
+				Callback: b.pathKeyRead,
+				DisplayAttrs: &framework.DisplayAttributes{
+					OperationVerb: "read",
+				},
+			},
+			logical.UpdateOperation: &framework.PathOperation{
+				Callback: b.pathKeyCreate,
+				DisplayAttrs: &framework.DisplayAttributes{
+					OperationVerb: "create",
+				},
+			},
+			logical.DeleteOperation: &framework.PathOperation{
+				Callback: b.pathKeyDelete,
+				DisplayAttrs: &framework.DisplayAttributes{
+					OperationVerb: "delete",
+				},
+			},
+		},
+
+		HelpSynopsis:    pathKeyHelpSyn,
+		HelpDescription: pathKeyHelpDesc,
+	}
+}
+
+func (b *backend) Key(ctx context.Context, s logical.Storage, n string) (*keyEntry, error) {
+	entry, err := s.Get(ctx, "key/"+n)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var result keyEntry
+	if err := entry.DecodeJSON(&result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (b *backend) pathKeyDelete(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	err := req.Storage.Delete(ctx, "key/"+data.Get("name").(string))
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+func (b *backend) pathKeyRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	name := data.Get("name").(string)
+	key, err := b.Key(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		return nil, nil
+	}
+
+	publicKey, err := key.publicKeyPEM()
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &logical.Response{
+		Data: map[string]interface{}{
+			"type":         key.Type,
+			"public_key":   publicKey,
+			"created_time": key.CreatedTime,
+		},
+	}
+
+	if key.supportsGPG() {
+		gpgPublicKey, err := key.gpgPublicKey(name)
+		if err != nil {
+			return nil, err
+		}
+		resp.Data["gpg_public_key"] = gpgPublicKey
+	}
+
+	return resp, nil
+}
+
+func (b *backend) pathKeyList(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	entries, err := req.Storage.List(ctx, "key/")
+	if err != nil {
+		return nil, err
+	}
+
+	return logical.ListResponse(entries), nil
+}
+
+func (b *backend) pathKeyCreate(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	name := data.Get("name").(string)
+
+	existing, err := b.Key(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return logical.ErrorResponse("key %q already exists; delete it to generate a new one", name), nil
+	}
+
+	keyType := data.Get("type").(string)
+	signer, err := generateSigner(keyType)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(signer)
+	if err != nil {
+		return nil, err
+	}
+
+	key := &keyEntry{
+		Type:        keyType,
+		PrivateKey:  string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		CreatedTime: time.Now().UTC().Truncate(time.Second),
+		GPGName:     data.Get("gpg_name").(string),
+		GPGEmail:    data.Get("gpg_email").(string),
+	}
+
+	// Ensure the GPG user ID is valid up front rather than on signing
+	if key.supportsGPG() {
+		if _, err := key.gpgEntity(name); err != nil {
+			return logical.ErrorResponse("invalid GPG user ID: %s", err), nil
+		}
+	}
+
+	entry, err := logical.StorageEntryJSON("key/"+name, key)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+
+	return b.pathKeyRead(ctx, req, data)
+}
+
+func generateSigner(keyType string) (crypto.Signer, error) {
+	switch keyType {
+	case keyTypeECDSAP256:
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case keyTypeED25519:
+		_, priv, err := ed25519.GenerateKey(rand.Reader)
+		return priv, err
+	case keyTypeRSA2048:
+		return rsa.GenerateKey(rand.Reader, 2048)
+	case keyTypeRSA4096:
+		return rsa.GenerateKey(rand.Reader, 4096)
+	default:
+		return nil, fmt.Errorf("unknown key type %q", keyType)
+	}
+}
+
+type keyEntry struct {
+	Type        string    `json:"type"`
+	PrivateKey  string    `json:"private_key"`
+	CreatedTime time.Time `json:"created_time"`
+	GPGName     string    `json:"gpg_name"`
+	GPGEmail    string    `json:"gpg_email"`
+}
+
+func (k *keyEntry) signer() (crypto.Signer, error) {
+	block, _ := pem.Decode([]byte(k.PrivateKey))
+	if block == nil {
+		return nil, fmt.Errorf("unable to decode private key")
+	}
+	priv, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := priv.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type %T", priv)
+	}
+	return signer, nil
+}
+
+func (k *keyEntry) publicKeyPEM() (string, error) {
+	signer, err := k.signer()
+	if err != nil {
+		return "", err
+	}
+	der, err := x509.MarshalPKIXPublicKey(signer.Public())
+	if err != nil {
+		return "", err
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})), nil
+}
+
+const pathKeyHelpSyn = `
+Manage the keys that artifacts are signed with.
+`
+
+const pathKeyHelpDesc = `
+This path lets you generate, read and delete the keys used to sign artifacts.
+Private keys never leave Vault; reading a key returns its public key in PEM
+format, for verifying cosign signatures, and for RSA keys in GPG armored
+format as well.
+`
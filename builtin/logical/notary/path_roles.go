@@ -0,0 +1,242 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package notary
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/hashicorp/go-secure-stdlib/strutil"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	formatCosign = "cosign"
+	formatBlob   = "blob"
+	formatGPG    = "gpg"
+)
+
+var allFormats = []string{formatCosign, formatBlob, formatGPG}
+
+func pathListRoles(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "roles/?$",
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixNotary,
+			OperationSuffix: "roles",
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ListOperation: b.pathRoleList,
+		},
+
+		HelpSynopsis:    pathRoleHelpSyn,
+		HelpDescription: pathRoleHelpDesc,
+	}
+}
+
+func pathRoles(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "roles/" + framework.GenericNameRegex("name"),
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixNotary,
+			OperationSuffix: "role",
+		},
+
+		Fields: map[string]*framework.FieldSchema{
+			"name": {
+				Type:        framework.TypeString,
+				Description: "Name of the role.",
+			},
+
+			"key": {
+				Type:        framework.TypeString,
+				Description: "Name of the key that artifacts are signed with.",
+			},
+
+			"allowed_repositories": {
+				Type:        framework.TypeCommaStringSlice,
+				Description: `The repositories the role may sign artifacts of, such as "registry.example.com/team/*". A leading or trailing "*" matches any prefix or suffix.`,
+			},
+
+			"allowed_formats": {
+				Type:        framework.TypeCommaStringSlice,
+				Description: `The signature formats the role may produce. Options include cosign, blob and gpg. Defaults to all the formats the key supports.`,
+			},
+
+			"transparency_log_url": {
+				Type:        framework.TypeString,
+				Description: `The URL of a transparency log that every signature is submitted to before being returned. Signing fails if the submission fails.`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation:   b.pathRoleRead,
+			logical.UpdateOperation: b.pathRoleUpdate,
+			logical.DeleteOperation: b.pathRoleDelete,
+		},
+
+		HelpSynopsis:    pathRoleHelpSyn,
+		HelpDescription: pathRoleHelpDesc,
+	}
+}
+
+// Reads the role configuration from the storage
+func (b *backend) Role(ctx context.Context, s logical.Storage, n string) (*roleEntry, error) {
+	entry, err := s.Get(ctx, "role/"+n)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var result roleEntry
+	if err := entry.DecodeJSON(&result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// Deletes an existing role
+func (b *backend) pathRoleDelete(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	return nil, req.Storage.Delete(ctx, "role/"+d.Get("name").(string))
+}
+
+// Reads an existing role
+func (b *backend) pathRoleRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	role, err := b.Role(ctx, req.Storage, d.Get("name").(string))
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return nil, nil
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"key":                  role.Key,
+			"allowed_repositories": role.AllowedRepositories,
+			"allowed_formats":      role.AllowedFormats,
+			"transparency_log_url": role.TransparencyLogURL,
+		},
+	}, nil
+}
+
+// Lists all the roles registered with the backend
+func (b *backend) pathRoleList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	roles, err := req.Storage.List(ctx, "role/")
+	if err != nil {
+		return nil, err
+	}
+
+	return logical.ListResponse(roles), nil
+}
+
+// Registers a new role with the backend, or updates an existing one
+func (b *backend) pathRoleUpdate(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	role, err := b.Role(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		role = &roleEntry{}
+	}
+
+	if key, ok := d.GetOk("key"); ok {
+		role.Key = key.(string)
+	}
+	if allowedRepositories, ok := d.GetOk("allowed_repositories"); ok {
+		role.AllowedRepositories = allowedRepositories.([]string)
+	}
+	if allowedFormats, ok := d.GetOk("allowed_formats"); ok {
+		role.AllowedFormats = allowedFormats.([]string)
+	}
+	if transparencyLogURL, ok := d.GetOk("transparency_log_url"); ok {
+		role.TransparencyLogURL = transparencyLogURL.(string)
+	}
+
+	if role.Key == "" {
+		return logical.ErrorResponse("missing key"), nil
+	}
+	if len(role.AllowedRepositories) == 0 {
+		return logical.ErrorResponse("at least one allowed repository must be given"), nil
+	}
+	for _, format := range role.AllowedFormats {
+		if !strutil.StrListContains(allFormats, format) {
+			return logical.ErrorResponse("unknown format %q", format), nil
+		}
+	}
+	if role.TransparencyLogURL != "" {
+		u, err := url.Parse(role.TransparencyLogURL)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return logical.ErrorResponse("invalid transparency_log_url %q", role.TransparencyLogURL), nil
+		}
+	}
+
+	key, err := b.Key(ctx, req.Storage, role.Key)
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		return logical.ErrorResponse("key %q does not exist", role.Key), nil
+	}
+	if len(role.AllowedFormats) == 0 {
+		role.AllowedFormats = []string{formatCosign, formatBlob}
+		if key.supportsGPG() {
+			role.AllowedFormats = append(role.AllowedFormats, formatGPG)
+		}
+	}
+	if strutil.StrListContains(role.AllowedFormats, formatGPG) && !key.supportsGPG() {
+		return logical.ErrorResponse("the gpg format requires an RSA key, but key %q is of type %q", role.Key, key.Type), nil
+	}
+
+	// Store it
+	entry, err := logical.StorageEntryJSON("role/"+name, role)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+// roleEntry restricts which repositories may be signed for with a key, and in
+// which formats.
+type roleEntry struct {
+	Key                 string   `json:"key"`
+	AllowedRepositories []string `json:"allowed_repositories"`
+	AllowedFormats      []string `json:"allowed_formats"`
+	TransparencyLogURL  string   `json:"transparency_log_url"`
+}
+
+func (r *roleEntry) repositoryAllowed(repository string) bool {
+	for _, pattern := range r.AllowedRepositories {
+		if strutil.GlobbedStringsMatch(pattern, repository) {
+			return true
+		}
+	}
+	return false
+}
+
+const pathRoleHelpSyn = `
+Manage the roles that can sign artifacts with this backend.
+`
+
+const pathRoleHelpDesc = `
+This path lets you manage the roles used to sign artifacts. A role binds a key
+to the repositories it may sign artifacts of, typically one role per
+repository or team, and to the signature formats it may produce.
+
+When a transparency log URL is set, every signature is submitted to it before
+being returned, so that all uses of the key can be audited.
+`
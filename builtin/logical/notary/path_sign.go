@@ -0,0 +1,277 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package notary
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-secure-stdlib/strutil"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// cosignSignatureType is the type of the simple signing payloads cosign signs
+// for container images.
+const cosignSignatureType = "cosign container image signature"
+
+func pathSign(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "sign/" + framework.GenericNameRegex("name"),
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixNotary,
+			OperationVerb:   "sign",
+		},
+
+		Fields: map[string]*framework.FieldSchema{
+			"name": {
+				Type:        framework.TypeString,
+				Description: "Name of the role to sign with.",
+			},
+
+			"repository": {
+				Type:        framework.TypeString,
+				Description: `The repository of the artifact, such as "registry.example.com/team/app". It must be allowed by the role.`,
+			},
+
+			"format": {
+				Type:        framework.TypeString,
+				Default:     formatCosign,
+				Description: `The signature format. "cosign" signs a container image digest as cosign does, "blob" signs an artifact as "cosign sign-blob" does, and "gpg" produces an armored detached GPG signature of an artifact.`,
+			},
+
+			"digest": {
+				Type:        framework.TypeString,
+				Description: `The digest of the artifact, as "sha256:<hex>". Required for the cosign format, and an alternative to input for the blob format with non-ed25519 keys.`,
+			},
+
+			"input": {
+				Type:        framework.TypeString,
+				Description: `The base64-encoded artifact to sign, for the blob and gpg formats.`,
+			},
+
+			"annotations": {
+				Type:        framework.TypeKVPairs,
+				Description: `Annotations to include in the optional section of cosign signature payloads.`,
+			},
+		},
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.UpdateOperation: &framework.PathOperation{
+				Callback: b.pathSignWrite,
+			},
+		},
+
+		HelpSynopsis:    pathSignHelpSyn,
+		HelpDescription: pathSignHelpDesc,
+	}
+}
+
+// simpleSigningPayload is the payload cosign signs for container images, see
+// https://github.com/containers/image/blob/main/docs/containers-signature.5.md
+type simpleSigningPayload struct {
+	Critical simpleSigningCritical `json:"critical"`
+	Optional map[string]string     `json:"optional"`
+}
+
+type simpleSigningCritical struct {
+	Identity struct {
+		DockerReference string `json:"docker-reference"`
+	} `json:"identity"`
+	Image struct {
+		DockerManifestDigest string `json:"docker-manifest-digest"`
+	} `json:"image"`
+	Type string `json:"type"`
+}
+
+func (b *backend) pathSignWrite(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	name := data.Get("name").(string)
+	role, err := b.Role(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return logical.ErrorResponse("unknown role %q", name), nil
+	}
+
+	repository := data.Get("repository").(string)
+	if repository == "" {
+		return logical.ErrorResponse("missing repository"), nil
+	}
+	if !role.repositoryAllowed(repository) {
+		return logical.ErrorResponse("repository %q is not allowed by role %q", repository, name), nil
+	}
+
+	format := data.Get("format").(string)
+	if !strutil.StrListContains(role.AllowedFormats, format) {
+		return logical.ErrorResponse("format %q is not allowed by role %q", format, name), nil
+	}
+
+	key, err := b.Key(ctx, req.Storage, role.Key)
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		return logical.ErrorResponse("key %q of role %q does not exist", role.Key, name), nil
+	}
+	signer, err := key.signer()
+	if err != nil {
+		return nil, err
+	}
+	publicKey, err := key.publicKeyPEM()
+	if err != nil {
+		return nil, err
+	}
+
+	var digest []byte
+	if rawDigest := data.Get("digest").(string); rawDigest != "" {
+		digest, err = parseDigest(rawDigest)
+		if err != nil {
+			return logical.ErrorResponse(err.Error()), nil
+		}
+	}
+
+	var input []byte
+	if rawInput := data.Get("input").(string); rawInput != "" {
+		input, err = base64.StdEncoding.DecodeString(rawInput)
+		if err != nil {
+			return logical.ErrorResponse("unable to decode input as base64: %s", err), nil
+		}
+		inputDigest := sha256.Sum256(input)
+		if digest != nil && !bytes.Equal(digest, inputDigest[:]) {
+			return logical.ErrorResponse("digest does not match the digest of input"), nil
+		}
+		digest = inputDigest[:]
+	}
+
+	resp := &logical.Response{
+		Data: map[string]interface{}{
+			"format":     format,
+			"public_key": publicKey,
+		},
+	}
+
+	// signedDigest is the digest of what was signed, recorded in the
+	// transparency log
+	var signedDigest []byte
+	switch format {
+	case formatCosign:
+		if digest == nil || input != nil {
+			return logical.ErrorResponse("the cosign format requires a digest and no input"), nil
+		}
+
+		payload := simpleSigningPayload{}
+		payload.Critical.Identity.DockerReference = repository
+		payload.Critical.Image.DockerManifestDigest = "sha256:" + hex.EncodeToString(digest)
+		payload.Critical.Type = cosignSignatureType
+		if annotations := data.Get("annotations").(map[string]string); len(annotations) > 0 {
+			payload.Optional = annotations
+		}
+		payloadJSON, err := json.Marshal(payload)
+		if err != nil {
+			return nil, err
+		}
+
+		payloadDigest := sha256.Sum256(payloadJSON)
+		signature, err := signMessage(signer, payloadJSON, payloadDigest[:])
+		if err != nil {
+			return nil, err
+		}
+		signedDigest = payloadDigest[:]
+		resp.Data["payload"] = base64.StdEncoding.EncodeToString(payloadJSON)
+		resp.Data["signature"] = base64.StdEncoding.EncodeToString(signature)
+
+	case formatBlob:
+		if digest == nil {
+			return logical.ErrorResponse("the blob format requires input or a digest"), nil
+		}
+
+		signature, err := signMessage(signer, input, digest)
+		if err != nil {
+			return logical.ErrorResponse(err.Error()), nil
+		}
+		signedDigest = digest
+		resp.Data["signature"] = base64.StdEncoding.EncodeToString(signature)
+
+	case formatGPG:
+		if input == nil {
+			return logical.ErrorResponse("the gpg format requires input"), nil
+		}
+
+		signature, err := key.gpgSign(role.Key, bytes.NewReader(input))
+		if err != nil {
+			return logical.ErrorResponse(err.Error()), nil
+		}
+		signedDigest = digest
+		resp.Data["signature"] = signature
+	}
+	resp.Data["digest"] = "sha256:" + hex.EncodeToString(signedDigest)
+
+	if role.TransparencyLogURL != "" {
+		entry := &transparencyLogEntry{
+			Role:       name,
+			Key:        role.Key,
+			Repository: repository,
+			Format:     format,
+			Digest:     resp.Data["digest"].(string),
+			Signature:  resp.Data["signature"].(string),
+			PublicKey:  publicKey,
+			SignedTime: time.Now().UTC(),
+		}
+		if err := b.submitToTransparencyLog(ctx, role.TransparencyLogURL, entry); err != nil {
+			return nil, err
+		}
+	}
+
+	return resp, nil
+}
+
+// parseDigest parses a "sha256:<hex>" digest.
+func parseDigest(digest string) ([]byte, error) {
+	algorithm, encoded, ok := strings.Cut(digest, ":")
+	if !ok || algorithm != "sha256" {
+		return nil, fmt.Errorf(`digest must be of the form "sha256:<hex>"`)
+	}
+	decoded, err := hex.DecodeString(encoded)
+	if err != nil || len(decoded) != sha256.Size {
+		return nil, fmt.Errorf("invalid sha256 digest %q", digest)
+	}
+	return decoded, nil
+}
+
+// signMessage signs message as cosign does: ed25519 keys sign the message
+// itself, while other keys sign its SHA-256 digest. message may be nil for
+// keys other than ed25519.
+func signMessage(signer crypto.Signer, message, digest []byte) ([]byte, error) {
+	if _, ok := signer.(ed25519.PrivateKey); ok {
+		if message == nil {
+			return nil, fmt.Errorf("ed25519 keys sign the artifact itself, so input is required")
+		}
+		return signer.Sign(rand.Reader, message, crypto.Hash(0))
+	}
+	return signer.Sign(rand.Reader, digest, crypto.SHA256)
+}
+
+const pathSignHelpSyn = `
+Sign an artifact with the key of a role.
+`
+
+const pathSignHelpDesc = `
+This path signs an artifact of a repository allowed by the role, with the
+role's key. Container images are signed by digest in cosign's format, and the
+resulting payload and signature can be attached to the image with
+"cosign attach signature". Binaries and other artifacts are signed in the
+format of "cosign sign-blob", or as armored detached GPG signatures.
+`
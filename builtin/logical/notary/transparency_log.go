@@ -0,0 +1,62 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package notary
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// transparencyLogTimeout bounds how long signing waits on a transparency log.
+const transparencyLogTimeout = 10 * time.Second
+
+// transparencyLogEntry is the record of a signature submitted to a role's
+// transparency log.
+type transparencyLogEntry struct {
+	Role       string    `json:"role"`
+	Key        string    `json:"key"`
+	Repository string    `json:"repository"`
+	Format     string    `json:"format"`
+	Digest     string    `json:"digest"`
+	Signature  string    `json:"signature"`
+	PublicKey  string    `json:"public_key"`
+	SignedTime time.Time `json:"signed_time"`
+}
+
+// submitToTransparencyLog posts the entry as JSON to the transparency log at
+// logURL. Any response status other than 2xx is treated as a failure, so that
+// signatures are never handed out without being recorded.
+func (b *backend) submitToTransparencyLog(ctx context.Context, logURL string, entry *transparencyLogEntry) error {
+	body, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, transparencyLogTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, logURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := b.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error submitting signature to transparency log: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("transparency log rejected signature with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,84 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package oauth2
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/go-cleanhttp"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/locksutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const operationPrefixOAuth2 = "oauth2"
+
+func Factory(ctx context.Context, conf *logical.BackendConfig) (logical.Backend, error) {
+	b := Backend()
+	if err := b.Setup(ctx, conf); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func Backend() *backend {
+	var b backend
+	b.Backend = &framework.Backend{
+		Help: strings.TrimSpace(backendHelp),
+
+		PathsSpecial: &logical.Paths{
+			SealWrapStorage: []string{
+				"role/",
+			},
+		},
+
+		Paths: []*framework.Path{
+			pathListRoles(&b),
+			pathRoles(&b),
+			pathCreds(&b),
+		},
+
+		Secrets:     []*framework.Secret{},
+		Invalidate:  b.invalidate,
+		BackendType: logical.TypeLogical,
+	}
+
+	b.httpClient = cleanhttp.DefaultClient()
+	b.roleLocks = locksutil.CreateLocks()
+	b.tokens = make(map[string]*cachedToken)
+
+	return &b
+}
+
+type backend struct {
+	*framework.Backend
+
+	// httpClient is used to request tokens from the authorization servers
+	httpClient *http.Client
+
+	// roleLocks serialize token requests and refresh token rotations of
+	// each role
+	roleLocks []*locksutil.LockEntry
+
+	// tokens caches the access token of each role until it is close to
+	// expiring
+	tokens     map[string]*cachedToken
+	tokensLock sync.Mutex
+}
+
+func (b *backend) invalidate(_ context.Context, key string) {
+	if strings.HasPrefix(key, "role/") {
+		b.clearToken(strings.TrimPrefix(key, "role/"))
+	}
+}
+
+const backendHelp = `
+The OAuth2 backend brokers access tokens for third-party APIs. It holds the
+OAuth2 client credentials of each API and returns fresh access tokens obtained
+with the client credentials or refresh token grants, so that applications
+never see the client secret. Tokens are cached and renewed centrally.
+`
@@ -0,0 +1,405 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package oauth2
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v3/jwt"
+	"github.com/hashicorp/vault/sdk/helper/consts"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+func createBackendWithStorage(t *testing.T) (*backend, logical.Storage) {
+	t.Helper()
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+
+	b := Backend()
+	if err := b.Setup(context.Background(), config); err != nil {
+		t.Fatal(err)
+	}
+	return b, config.StorageView
+}
+
+// tokenServer returns a token endpoint which checks each request with the
+// given function, and responds with what it returns.
+func tokenServer(t *testing.T, check func(r *http.Request) (int, map[string]interface{})) (*httptest.Server, *int32) {
+	t.Helper()
+
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		require.Equal(t, http.MethodPost, r.Method)
+		require.NoError(t, r.ParseForm())
+
+		status, body := check(r)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		require.NoError(t, json.NewEncoder(w).Encode(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &requests
+}
+
+func TestBackend_ClientCredentials(t *testing.T) {
+	b, s := createBackendWithStorage(t)
+
+	srv, requests := tokenServer(t, func(r *http.Request) (int, map[string]interface{}) {
+		// The credentials are form encoded before basic authentication
+		id, secret, ok := r.BasicAuth()
+		if !ok || id != "my-client" || secret != url.QueryEscape("s3cr%t") {
+			return http.StatusUnauthorized, map[string]interface{}{
+				"error":             "invalid_client",
+				"error_description": "bad credentials",
+			}
+		}
+		require.Equal(t, "client_credentials", r.PostForm.Get("grant_type"))
+		require.Equal(t, "read write", r.PostForm.Get("scope"))
+		require.Equal(t, "https://api.example.com", r.PostForm.Get("audience"))
+		return http.StatusOK, map[string]interface{}{
+			"access_token": "token-" + time.Now().String(),
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+		}
+	})
+
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "roles/api",
+		Storage:   s,
+		Data: map[string]interface{}{
+			"token_url":     srv.URL,
+			"client_id":     "my-client",
+			"client_secret": "s3cr%t",
+			"scopes":        "read,write",
+			"token_params":  map[string]interface{}{"audience": "https://api.example.com"},
+		},
+	})
+	require.NoError(t, err)
+	require.Nil(t, resp)
+
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "roles/api",
+		Storage:   s,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "client_secret_basic", resp.Data["auth_method"])
+	require.Equal(t, "client_credentials", resp.Data["grant_type"])
+	require.Equal(t, int64(60), resp.Data["refresh_before"])
+	require.NotContains(t, resp.Data, "client_secret")
+
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "creds/api",
+		Storage:   s,
+	})
+	require.NoError(t, err)
+	require.False(t, resp.IsError(), resp)
+	token := resp.Data["access_token"]
+	require.Equal(t, "Bearer", resp.Data["token_type"])
+	require.Equal(t, "read write", resp.Data["scope"])
+	require.InDelta(t, 3600, resp.Data["expires_in"], 5)
+
+	// Tokens are cached
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "creds/api",
+		Storage:   s,
+	})
+	require.NoError(t, err)
+	require.Equal(t, token, resp.Data["access_token"])
+	require.Equal(t, int32(1), atomic.LoadInt32(requests))
+
+	// Tokens are renewed before they expire
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "roles/api",
+		Storage:   s,
+		Data: map[string]interface{}{
+			"refresh_before": "2h",
+		},
+	})
+	require.NoError(t, err)
+	require.Nil(t, resp)
+	_, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "creds/api",
+		Storage:   s,
+	})
+	require.NoError(t, err)
+	_, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "creds/api",
+		Storage:   s,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(3), atomic.LoadInt32(requests))
+
+	// Errors of the authorization server are returned
+	_, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "roles/api",
+		Storage:   s,
+		Data: map[string]interface{}{
+			"client_secret": "wrong",
+		},
+	})
+	require.NoError(t, err)
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "creds/api",
+		Storage:   s,
+	})
+	require.NoError(t, err)
+	require.True(t, resp.IsError())
+	require.Contains(t, resp.Error().Error(), "invalid_client: bad credentials")
+
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "creds/unknown",
+		Storage:   s,
+	})
+	require.NoError(t, err)
+	require.True(t, resp.IsError())
+
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.ListOperation,
+		Path:      "roles/",
+		Storage:   s,
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"api"}, resp.Data["keys"])
+}
+
+func TestBackend_RefreshToken(t *testing.T) {
+	b, s := createBackendWithStorage(t)
+
+	var issued int32
+	srv, _ := tokenServer(t, func(r *http.Request) (int, map[string]interface{}) {
+		require.Equal(t, "refresh_token", r.PostForm.Get("grant_type"))
+		require.Equal(t, "my-client", r.PostForm.Get("client_id"))
+		require.Equal(t, "secret", r.PostForm.Get("client_secret"))
+
+		// Each refresh token may only be used once
+		n := atomic.AddInt32(&issued, 1)
+		require.Equal(t, refreshTokenN(n-1), r.PostForm.Get("refresh_token"))
+		return http.StatusOK, map[string]interface{}{
+			"access_token":  "access",
+			"token_type":    "Bearer",
+			"refresh_token": refreshTokenN(n),
+		}
+	})
+
+	_, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "roles/api",
+		Storage:   s,
+		Data: map[string]interface{}{
+			"token_url":     srv.URL,
+			"client_id":     "my-client",
+			"client_secret": "secret",
+			"auth_method":   "client_secret_post",
+			"grant_type":    "refresh_token",
+			"refresh_token": refreshTokenN(0),
+		},
+	})
+	require.NoError(t, err)
+
+	// Tokens without an expiry are not cached
+	for i := 0; i < 3; i++ {
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Operation: logical.ReadOperation,
+			Path:      "creds/api",
+			Storage:   s,
+		})
+		require.NoError(t, err)
+		require.False(t, resp.IsError(), resp)
+		require.NotContains(t, resp.Data, "expires_in")
+	}
+	require.Equal(t, int32(3), atomic.LoadInt32(&issued))
+
+	role, err := b.Role(context.Background(), s, "api")
+	require.NoError(t, err)
+	require.Equal(t, refreshTokenN(3), role.RefreshToken)
+}
+
+// TestBackend_RefreshToken_PerfStandby checks that performance standbys, which
+// cannot store the rotated refresh token, forward the request before using up
+// the current one.
+func TestBackend_RefreshToken_PerfStandby(t *testing.T) {
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+	config.System = &logical.StaticSystemView{
+		DefaultLeaseTTLVal:  config.System.DefaultLeaseTTL(),
+		MaxLeaseTTLVal:      config.System.MaxLeaseTTL(),
+		ReplicationStateVal: consts.ReplicationPerformanceStandby,
+	}
+	b := Backend()
+	require.NoError(t, b.Setup(context.Background(), config))
+	s := config.StorageView
+
+	srv, requests := tokenServer(t, func(r *http.Request) (int, map[string]interface{}) {
+		return http.StatusOK, map[string]interface{}{
+			"access_token":  "access",
+			"token_type":    "Bearer",
+			"refresh_token": refreshTokenN(1),
+		}
+	})
+
+	role := &roleEntry{
+		TokenURL:     srv.URL,
+		ClientID:     "my-client",
+		ClientSecret: "secret",
+		AuthMethod:   authMethodClientSecretPost,
+		GrantType:    grantTypeRefreshToken,
+		RefreshToken: refreshTokenN(0),
+	}
+	require.NoError(t, b.putRole(context.Background(), s, "api", role))
+
+	_, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "creds/api",
+		Storage:   s,
+	})
+	require.ErrorIs(t, err, logical.ErrReadOnly)
+	require.Zero(t, atomic.LoadInt32(requests))
+}
+
+func refreshTokenN(n int32) string {
+	return "refresh-" + string(rune('a'+n))
+}
+
+func TestBackend_PrivateKeyJWT(t *testing.T) {
+	b, s := createBackendWithStorage(t)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	var srv *httptest.Server
+	srv, _ = tokenServer(t, func(r *http.Request) (int, map[string]interface{}) {
+		require.Equal(t, clientAssertionType, r.PostForm.Get("client_assertion_type"))
+		assertion, err := jwt.ParseSigned(r.PostForm.Get("client_assertion"))
+		require.NoError(t, err)
+		require.Equal(t, "key-1", assertion.Headers[0].KeyID)
+
+		var claims jwt.Claims
+		require.NoError(t, assertion.Claims(&key.PublicKey, &claims))
+		require.NoError(t, claims.Validate(jwt.Expected{
+			Issuer:   "my-client",
+			Subject:  "my-client",
+			Audience: jwt.Audience{srv.URL},
+			Time:     time.Now(),
+		}))
+		return http.StatusOK, map[string]interface{}{
+			"access_token": "access",
+			"token_type":   "Bearer",
+			"expires_in":   "600",
+		}
+	})
+
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "roles/api",
+		Storage:   s,
+		Data: map[string]interface{}{
+			"token_url":   srv.URL,
+			"client_id":   "my-client",
+			"auth_method": "private_key_jwt",
+			"private_key": string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+			"key_id":      "key-1",
+		},
+	})
+	require.NoError(t, err)
+	require.Nil(t, resp)
+
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "creds/api",
+		Storage:   s,
+	})
+	require.NoError(t, err)
+	require.False(t, resp.IsError(), resp)
+	require.Equal(t, "access", resp.Data["access_token"])
+	require.InDelta(t, 600, resp.Data["expires_in"], 5)
+}
+
+func TestBackend_RoleValidation(t *testing.T) {
+	b, s := createBackendWithStorage(t)
+
+	valid := func() map[string]interface{} {
+		return map[string]interface{}{
+			"token_url":     "https://auth.example.com/oauth/token",
+			"client_id":     "my-client",
+			"client_secret": "secret",
+		}
+	}
+	for name, change := range map[string]map[string]interface{}{
+		"invalid token url":     {"token_url": "auth.example.com"},
+		"no client id":          {"client_id": ""},
+		"no client secret":      {"client_secret": ""},
+		"unknown auth method":   {"auth_method": "tls_client_auth"},
+		"invalid private key":   {"auth_method": "private_key_jwt", "private_key": "not a key"},
+		"unknown grant type":    {"grant_type": "password"},
+		"no refresh token":      {"grant_type": "refresh_token"},
+		"reserved token param":  {"token_params": map[string]interface{}{"grant_type": "password"}},
+		"negative refresh time": {"refresh_before": -1},
+	} {
+		t.Run(name, func(t *testing.T) {
+			data := valid()
+			for k, v := range change {
+				data[k] = v
+			}
+			resp, err := b.HandleRequest(context.Background(), &logical.Request{
+				Operation: logical.UpdateOperation,
+				Path:      "roles/api",
+				Storage:   s,
+				Data:      data,
+			})
+			require.NoError(t, err)
+			require.True(t, resp.IsError())
+		})
+	}
+
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "roles/api",
+		Storage:   s,
+		Data:      valid(),
+	})
+	require.NoError(t, err)
+	require.Nil(t, resp)
+
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.DeleteOperation,
+		Path:      "roles/api",
+		Storage:   s,
+	})
+	require.NoError(t, err)
+	require.Nil(t, resp)
+
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "roles/api",
+		Storage:   s,
+	})
+	require.NoError(t, err)
+	require.Nil(t, resp)
+}
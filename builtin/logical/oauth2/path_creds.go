@@ -0,0 +1,136 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package oauth2
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/consts"
+	"github.com/hashicorp/vault/sdk/helper/locksutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func pathCreds(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "creds/" + framework.GenericNameRegex("name"),
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixOAuth2,
+			OperationVerb:   "generate",
+			OperationSuffix: "credentials",
+		},
+
+		Fields: map[string]*framework.FieldSchema{
+			"name": {
+				Type:        framework.TypeString,
+				Description: "Name of the role.",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation: b.pathCredsRead,
+		},
+
+		HelpSynopsis:    pathCredsHelpSyn,
+		HelpDescription: pathCredsHelpDesc,
+	}
+}
+
+func (b *backend) pathCredsRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	role, err := b.Role(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return logical.ErrorResponse("unknown role: %s", name), nil
+	}
+
+	if token := b.getToken(name, role.RefreshBefore); token != nil {
+		return tokenResponseData(token), nil
+	}
+
+	// Only one token request is made at a time for each role, so that
+	// concurrent requests share the new token and refresh tokens are
+	// rotated in order
+	lock := locksutil.LockForKey(b.roleLocks, name)
+	lock.Lock()
+	defer lock.Unlock()
+
+	role, err = b.Role(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return logical.ErrorResponse("unknown role: %s", name), nil
+	}
+	if token := b.getToken(name, role.RefreshBefore); token != nil {
+		return tokenResponseData(token), nil
+	}
+
+	// Refresh tokens are rotated by the token request, and the old one is
+	// used up even if the new one cannot be stored, so forward the request
+	// to the node which can store it before making the token request
+	if role.GrantType == grantTypeRefreshToken && b.cannotStoreRoles() {
+		return nil, logical.ErrReadOnly
+	}
+
+	token, refreshToken, err := b.requestToken(ctx, role)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
+	if role.GrantType == grantTypeRefreshToken && refreshToken != "" && refreshToken != role.RefreshToken {
+		role.RefreshToken = refreshToken
+		if err := b.putRole(ctx, req.Storage, name, role); err != nil {
+			return nil, fmt.Errorf("failed to store rotated refresh token: %w", err)
+		}
+	}
+
+	if !token.Expiry.IsZero() {
+		b.putToken(name, token)
+	}
+
+	return tokenResponseData(token), nil
+}
+
+// cannotStoreRoles returns whether roles cannot be written from this node,
+// such as on performance standbys.
+func (b *backend) cannotStoreRoles() bool {
+	replicationState := b.System().ReplicationState()
+	return replicationState.HasState(consts.ReplicationPerformanceStandby) ||
+		(!b.System().LocalMount() && replicationState.HasState(consts.ReplicationPerformanceSecondary))
+}
+
+func tokenResponseData(token *cachedToken) *logical.Response {
+	resp := &logical.Response{
+		Data: map[string]interface{}{
+			"access_token": token.AccessToken,
+			"token_type":   token.TokenType,
+			"scope":        token.Scope,
+		},
+	}
+	if !token.Expiry.IsZero() {
+		resp.Data["expires_at"] = token.Expiry.UTC().Format(time.RFC3339)
+		resp.Data["expires_in"] = int64(time.Until(token.Expiry).Seconds())
+	}
+	return resp
+}
+
+const pathCredsHelpSyn = `
+Request an access token for a role.
+`
+
+const pathCredsHelpDesc = `
+This path returns an access token for the third-party API of the role. Tokens
+are cached, and the same token is returned until it is about to expire, as
+configured by the refresh_before parameter of the role. A new token is then
+requested from the authorization server with the grant of the role. Roles
+using the refresh_token grant store the refresh token rotated by each request,
+so their token requests are made by the active node.
+`
@@ -0,0 +1,340 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package oauth2
+
+import (
+	"context"
+	"net/url"
+	"time"
+
+	"github.com/hashicorp/go-secure-stdlib/strutil"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/locksutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	grantTypeClientCredentials = "client_credentials"
+	grantTypeRefreshToken      = "refresh_token"
+
+	authMethodClientSecretBasic = "client_secret_basic"
+	authMethodClientSecretPost  = "client_secret_post"
+	authMethodPrivateKeyJWT     = "private_key_jwt"
+
+	defaultRefreshBefore = time.Minute
+)
+
+var (
+	allGrantTypes  = []string{grantTypeClientCredentials, grantTypeRefreshToken}
+	allAuthMethods = []string{authMethodClientSecretBasic, authMethodClientSecretPost, authMethodPrivateKeyJWT}
+)
+
+func pathListRoles(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "roles/?$",
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixOAuth2,
+			OperationSuffix: "roles",
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ListOperation: b.pathRoleList,
+		},
+
+		HelpSynopsis:    pathRoleHelpSyn,
+		HelpDescription: pathRoleHelpDesc,
+	}
+}
+
+func pathRoles(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "roles/" + framework.GenericNameRegex("name"),
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixOAuth2,
+			OperationSuffix: "role",
+		},
+
+		Fields: map[string]*framework.FieldSchema{
+			"name": {
+				Type:        framework.TypeString,
+				Description: "Name of the role.",
+			},
+
+			"token_url": {
+				Type:        framework.TypeString,
+				Description: "The token endpoint of the authorization server.",
+			},
+
+			"client_id": {
+				Type:        framework.TypeString,
+				Description: "The OAuth2 client ID.",
+			},
+
+			"client_secret": {
+				Type:        framework.TypeString,
+				Description: "The OAuth2 client secret. Required unless auth_method is private_key_jwt. Never returned.",
+				DisplayAttrs: &framework.DisplayAttributes{
+					Sensitive: true,
+				},
+			},
+
+			"auth_method": {
+				Type:        framework.TypeString,
+				Description: "How the client authenticates to the token endpoint. Options include client_secret_basic, client_secret_post and private_key_jwt. Defaults to client_secret_basic.",
+			},
+
+			"private_key": {
+				Type:        framework.TypeString,
+				Description: "The PEM encoded RSA or ECDSA private key signing the client assertions of the private_key_jwt auth method. Never returned.",
+				DisplayAttrs: &framework.DisplayAttributes{
+					Sensitive: true,
+				},
+			},
+
+			"key_id": {
+				Type:        framework.TypeString,
+				Description: "The key ID set in the header of client assertions.",
+			},
+
+			"grant_type": {
+				Type:        framework.TypeString,
+				Description: "The grant used to obtain access tokens. Options include client_credentials and refresh_token. Defaults to client_credentials.",
+			},
+
+			"refresh_token": {
+				Type:        framework.TypeString,
+				Description: "The refresh token of the refresh_token grant. Replaced by the refresh tokens returned by the authorization server. Never returned.",
+				DisplayAttrs: &framework.DisplayAttributes{
+					Sensitive: true,
+				},
+			},
+
+			"scopes": {
+				Type:        framework.TypeCommaStringSlice,
+				Description: "The scopes requested for access tokens.",
+			},
+
+			"token_params": {
+				Type:        framework.TypeKVPairs,
+				Description: `Additional parameters of token requests, such as "audience".`,
+			},
+
+			"refresh_before": {
+				Type:        framework.TypeDurationSecond,
+				Description: "How long before they expire cached access tokens are renewed. Defaults to 1 minute.",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation:   b.pathRoleRead,
+			logical.UpdateOperation: b.pathRoleUpdate,
+			logical.DeleteOperation: b.pathRoleDelete,
+		},
+
+		HelpSynopsis:    pathRoleHelpSyn,
+		HelpDescription: pathRoleHelpDesc,
+	}
+}
+
+// Reads the role configuration from the storage
+func (b *backend) Role(ctx context.Context, s logical.Storage, n string) (*roleEntry, error) {
+	entry, err := s.Get(ctx, "role/"+n)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var result roleEntry
+	if err := entry.DecodeJSON(&result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (b *backend) putRole(ctx context.Context, s logical.Storage, name string, role *roleEntry) error {
+	entry, err := logical.StorageEntryJSON("role/"+name, role)
+	if err != nil {
+		return err
+	}
+	return s.Put(ctx, entry)
+}
+
+// Deletes an existing role
+func (b *backend) pathRoleDelete(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	lock := locksutil.LockForKey(b.roleLocks, name)
+	lock.Lock()
+	defer lock.Unlock()
+
+	if err := req.Storage.Delete(ctx, "role/"+name); err != nil {
+		return nil, err
+	}
+	b.clearToken(name)
+
+	return nil, nil
+}
+
+// Reads an existing role
+func (b *backend) pathRoleRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	role, err := b.Role(ctx, req.Storage, d.Get("name").(string))
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return nil, nil
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"token_url":      role.TokenURL,
+			"client_id":      role.ClientID,
+			"auth_method":    role.AuthMethod,
+			"key_id":         role.KeyID,
+			"grant_type":     role.GrantType,
+			"scopes":         role.Scopes,
+			"token_params":   role.TokenParams,
+			"refresh_before": int64(role.RefreshBefore.Seconds()),
+		},
+	}, nil
+}
+
+// Lists all the roles registered with the backend
+func (b *backend) pathRoleList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	roles, err := req.Storage.List(ctx, "role/")
+	if err != nil {
+		return nil, err
+	}
+
+	return logical.ListResponse(roles), nil
+}
+
+// Registers a new role with the backend, or updates an existing one
+func (b *backend) pathRoleUpdate(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	lock := locksutil.LockForKey(b.roleLocks, name)
+	lock.Lock()
+	defer lock.Unlock()
+
+	role, err := b.Role(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		role = &roleEntry{
+			AuthMethod:    authMethodClientSecretBasic,
+			GrantType:     grantTypeClientCredentials,
+			RefreshBefore: defaultRefreshBefore,
+		}
+	}
+
+	if tokenURL, ok := d.GetOk("token_url"); ok {
+		role.TokenURL = tokenURL.(string)
+	}
+	if clientID, ok := d.GetOk("client_id"); ok {
+		role.ClientID = clientID.(string)
+	}
+	if clientSecret, ok := d.GetOk("client_secret"); ok {
+		role.ClientSecret = clientSecret.(string)
+	}
+	if authMethod, ok := d.GetOk("auth_method"); ok {
+		role.AuthMethod = authMethod.(string)
+	}
+	if privateKey, ok := d.GetOk("private_key"); ok {
+		role.PrivateKey = privateKey.(string)
+	}
+	if keyID, ok := d.GetOk("key_id"); ok {
+		role.KeyID = keyID.(string)
+	}
+	if grantType, ok := d.GetOk("grant_type"); ok {
+		role.GrantType = grantType.(string)
+	}
+	if refreshToken, ok := d.GetOk("refresh_token"); ok {
+		role.RefreshToken = refreshToken.(string)
+	}
+	if scopes, ok := d.GetOk("scopes"); ok {
+		role.Scopes = scopes.([]string)
+	}
+	if tokenParams, ok := d.GetOk("token_params"); ok {
+		role.TokenParams = tokenParams.(map[string]string)
+	}
+	if refreshBefore, ok := d.GetOk("refresh_before"); ok {
+		role.RefreshBefore = time.Duration(refreshBefore.(int)) * time.Second
+	}
+
+	u, err := url.Parse(role.TokenURL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return logical.ErrorResponse("invalid token_url %q", role.TokenURL), nil
+	}
+	if role.ClientID == "" {
+		return logical.ErrorResponse("missing client_id"), nil
+	}
+	if !strutil.StrListContains(allAuthMethods, role.AuthMethod) {
+		return logical.ErrorResponse("unknown auth_method %q", role.AuthMethod), nil
+	}
+	if role.AuthMethod == authMethodPrivateKeyJWT {
+		if _, err := parseSigningKey(role.PrivateKey); err != nil {
+			return logical.ErrorResponse("invalid private_key: %s", err), nil
+		}
+	} else if role.ClientSecret == "" {
+		return logical.ErrorResponse("missing client_secret"), nil
+	}
+	if !strutil.StrListContains(allGrantTypes, role.GrantType) {
+		return logical.ErrorResponse("unknown grant_type %q", role.GrantType), nil
+	}
+	if role.GrantType == grantTypeRefreshToken && role.RefreshToken == "" {
+		return logical.ErrorResponse("missing refresh_token"), nil
+	}
+	for _, param := range reservedTokenParams {
+		if _, ok := role.TokenParams[param]; ok {
+			return logical.ErrorResponse("token_params may not set %q", param), nil
+		}
+	}
+	if role.RefreshBefore < 0 {
+		return logical.ErrorResponse("refresh_before may not be negative"), nil
+	}
+
+	if err := b.putRole(ctx, req.Storage, name, role); err != nil {
+		return nil, err
+	}
+	b.clearToken(name)
+
+	return nil, nil
+}
+
+// roleEntry holds the client credentials of a third-party API, and how access
+// tokens are requested for it.
+type roleEntry struct {
+	TokenURL      string            `json:"token_url"`
+	ClientID      string            `json:"client_id"`
+	ClientSecret  string            `json:"client_secret"`
+	AuthMethod    string            `json:"auth_method"`
+	PrivateKey    string            `json:"private_key"`
+	KeyID         string            `json:"key_id"`
+	GrantType     string            `json:"grant_type"`
+	RefreshToken  string            `json:"refresh_token"`
+	Scopes        []string          `json:"scopes"`
+	TokenParams   map[string]string `json:"token_params"`
+	RefreshBefore time.Duration     `json:"refresh_before"`
+}
+
+const pathRoleHelpSyn = `
+Manage the roles that access tokens can be requested for.
+`
+
+const pathRoleHelpDesc = `
+This path lets you manage the roles of this backend. A role holds the OAuth2
+client credentials of a third-party API, the token endpoint of its
+authorization server, and the grant and scopes used to request access tokens.
+
+The client secret, private key and refresh token of a role are never returned.
+When the refresh_token grant is used, the refresh tokens returned by the
+authorization server replace the stored one.
+`
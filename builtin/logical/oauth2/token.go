@@ -0,0 +1,273 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package oauth2
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/go-jose/go-jose/v3/jwt"
+	"github.com/hashicorp/go-uuid"
+)
+
+const (
+	clientAssertionType = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+
+	// clientAssertionTTL is the lifetime of the client assertions of the
+	// private_key_jwt auth method
+	clientAssertionTTL = 5 * time.Minute
+
+	// maxTokenResponseSize bounds the size of the token endpoint responses
+	maxTokenResponseSize = 1024 * 1024
+)
+
+// reservedTokenParams are the token request parameters which are set by the
+// backend, and which token_params may not override.
+var reservedTokenParams = []string{
+	"grant_type",
+	"scope",
+	"refresh_token",
+	"client_id",
+	"client_secret",
+	"client_assertion",
+	"client_assertion_type",
+}
+
+// cachedToken is an access token obtained for a role.
+type cachedToken struct {
+	AccessToken string
+	TokenType   string
+	Scope       string
+
+	// Expiry is zero if the authorization server did not return the
+	// lifetime of the token, in which case it is not cached.
+	Expiry time.Time
+}
+
+// tokenResponse is the response of a token endpoint, as defined in RFC 6749
+// section 5.
+type tokenResponse struct {
+	AccessToken  string      `json:"access_token"`
+	TokenType    string      `json:"token_type"`
+	ExpiresIn    json.Number `json:"expires_in"`
+	RefreshToken string      `json:"refresh_token"`
+	Scope        string      `json:"scope"`
+
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// getToken returns the cached token of the role, if it is not close to
+// expiring.
+func (b *backend) getToken(name string, refreshBefore time.Duration) *cachedToken {
+	b.tokensLock.Lock()
+	defer b.tokensLock.Unlock()
+
+	token, ok := b.tokens[name]
+	if !ok || time.Until(token.Expiry) <= refreshBefore {
+		return nil
+	}
+	return token
+}
+
+func (b *backend) putToken(name string, token *cachedToken) {
+	b.tokensLock.Lock()
+	defer b.tokensLock.Unlock()
+
+	b.tokens[name] = token
+}
+
+func (b *backend) clearToken(name string) {
+	b.tokensLock.Lock()
+	defer b.tokensLock.Unlock()
+
+	delete(b.tokens, name)
+}
+
+// requestToken requests an access token for the role from its token
+// endpoint. It also returns the refresh token of the response, if any.
+func (b *backend) requestToken(ctx context.Context, role *roleEntry) (*cachedToken, string, error) {
+	form := url.Values{}
+	for k, v := range role.TokenParams {
+		form.Set(k, v)
+	}
+	form.Set("grant_type", role.GrantType)
+	if role.GrantType == grantTypeRefreshToken {
+		form.Set("refresh_token", role.RefreshToken)
+	}
+	if len(role.Scopes) != 0 {
+		form.Set("scope", strings.Join(role.Scopes, " "))
+	}
+
+	switch role.AuthMethod {
+	case authMethodClientSecretPost:
+		form.Set("client_id", role.ClientID)
+		form.Set("client_secret", role.ClientSecret)
+	case authMethodPrivateKeyJWT:
+		assertion, err := clientAssertion(role)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to sign client assertion: %w", err)
+		}
+		form.Set("client_id", role.ClientID)
+		form.Set("client_assertion_type", clientAssertionType)
+		form.Set("client_assertion", assertion)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, role.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if role.AuthMethod == authMethodClientSecretBasic {
+		// The credentials are form encoded first, see RFC 6749 section 2.3.1
+		req.SetBasicAuth(url.QueryEscape(role.ClientID), url.QueryEscape(role.ClientSecret))
+	}
+
+	now := time.Now()
+	resp, err := b.httpClient.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxTokenResponseSize))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read token response: %w", err)
+	}
+
+	var tr tokenResponse
+	if err := json.Unmarshal(body, &tr); err != nil {
+		return nil, "", fmt.Errorf("token request failed with status %d: invalid response", resp.StatusCode)
+	}
+	if tr.Error != "" {
+		if tr.ErrorDescription != "" {
+			return nil, "", fmt.Errorf("token request failed with status %d: %s: %s", resp.StatusCode, tr.Error, tr.ErrorDescription)
+		}
+		return nil, "", fmt.Errorf("token request failed with status %d: %s", resp.StatusCode, tr.Error)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, "", fmt.Errorf("token request failed with status %d", resp.StatusCode)
+	}
+	if tr.AccessToken == "" {
+		return nil, "", errors.New("token response has no access token")
+	}
+
+	token := &cachedToken{
+		AccessToken: tr.AccessToken,
+		TokenType:   tr.TokenType,
+		Scope:       tr.Scope,
+	}
+	if tr.ExpiresIn != "" {
+		expiresIn, err := tr.ExpiresIn.Int64()
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid expires_in %q in token response", tr.ExpiresIn)
+		}
+		if expiresIn > 0 {
+			token.Expiry = now.Add(time.Duration(expiresIn) * time.Second)
+		}
+	}
+	if token.Scope == "" {
+		token.Scope = strings.Join(role.Scopes, " ")
+	}
+
+	return token, tr.RefreshToken, nil
+}
+
+// clientAssertion returns a JWT authenticating the client of the role, as
+// defined in RFC 7523 section 2.2.
+func clientAssertion(role *roleEntry) (string, error) {
+	key, err := parseSigningKey(role.PrivateKey)
+	if err != nil {
+		return "", err
+	}
+
+	var alg jose.SignatureAlgorithm
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		alg = jose.RS256
+	case *ecdsa.PrivateKey:
+		switch k.Curve {
+		case elliptic.P256():
+			alg = jose.ES256
+		case elliptic.P384():
+			alg = jose.ES384
+		default:
+			alg = jose.ES512
+		}
+	}
+
+	opts := &jose.SignerOptions{}
+	if role.KeyID != "" {
+		opts = opts.WithHeader(jose.HeaderKey("kid"), role.KeyID)
+	}
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: alg, Key: key}, opts.WithType("JWT"))
+	if err != nil {
+		return "", err
+	}
+
+	id, err := uuid.GenerateUUID()
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	claims := jwt.Claims{
+		Issuer:   role.ClientID,
+		Subject:  role.ClientID,
+		Audience: jwt.Audience{role.TokenURL},
+		ID:       id,
+		IssuedAt: jwt.NewNumericDate(now),
+		Expiry:   jwt.NewNumericDate(now.Add(clientAssertionTTL)),
+	}
+
+	return jwt.Signed(signer).Claims(claims).CompactSerialize()
+}
+
+// parseSigningKey parses a PEM encoded RSA or ECDSA private key.
+func parseSigningKey(pemKey string) (crypto.Signer, error) {
+	block, _ := pem.Decode([]byte(pemKey))
+	if block == nil {
+		return nil, errors.New("no PEM encoded key found")
+	}
+
+	var key interface{}
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		if k.N.BitLen() < 2048 {
+			return nil, errors.New("RSA keys must be at least 2048 bits")
+		}
+		return k, nil
+	case *ecdsa.PrivateKey:
+		return k, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", key)
+	}
+}
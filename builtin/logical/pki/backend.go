@@ -6,6 +6,7 @@ package pki
 import (
 	"context"
 	"crypto/x509"
+	"errors"
 	"fmt"
 	"strings"
 	"sync"
@@ -130,7 +131,10 @@ func Backend(conf *logical.BackendConfig) *backend {
 				issuing.PathCrls,
 				issuing.PathCerts,
 				issuing.PathCertMetadata,
+				pathCertIndex,
+				pathCommonNameQuota,
 				acmePathPrefix,
+				storageScepChallengePrefix,
 			},
 
 			Root: []string{
@@ -176,6 +180,10 @@ func Backend(conf *logical.BackendConfig) *backend {
 			pathConfigCRL(&b),
 			pathConfigURLs(&b),
 			pathConfigCluster(&b),
+			pathConfigCryptoPolicy(&b),
+			pathConfigCT(&b),
+			pathConfigIssuancePolicy(&b),
+			pathConfigIssuanceQuotas(&b),
 			pathSignVerbatim(&b),
 			pathSign(&b),
 			pathIssue(&b),
@@ -236,6 +244,13 @@ func Backend(conf *logical.BackendConfig) *backend {
 			pathAcmeConfig(&b),
 			pathAcmeEabList(&b),
 			pathAcmeEabDelete(&b),
+
+			// EST
+			pathEstConfig(&b),
+
+			// SCEP
+			pathScepConfig(&b),
+			pathScepChallenge(&b),
 		},
 
 		Secrets: []*framework.Secret{
@@ -279,6 +294,30 @@ func Backend(conf *logical.BackendConfig) *backend {
 		setupAcmeDirectory(&b, prefix.acmePrefix, prefix.unauthPrefix, prefix.opts)
 	}
 
+	// Add EST paths to backend; labels are only reachable under the default
+	// prefix, as defined in RFC 7030 Section 3.2.2.
+	for _, prefix := range []struct {
+		estPrefix    string
+		unauthPrefix string
+	}{
+		{"est", "est"},
+		{"est/" + framework.GenericNameRegex("label"), "est/+"},
+		{"roles/" + framework.GenericNameRegex("role") + "/est", "roles/+/est"},
+	} {
+		setupEstPaths(&b, prefix.estPrefix, prefix.unauthPrefix)
+	}
+
+	// Add SCEP paths to backend
+	for _, prefix := range []struct {
+		scepPrefix   string
+		unauthPrefix string
+	}{
+		{"scep", "scep"},
+		{"roles/" + framework.GenericNameRegex("role") + "/scep", "roles/+/scep"},
+	} {
+		setupScepPaths(&b, prefix.scepPrefix, prefix.unauthPrefix)
+	}
+
 	b.tidyCASGuard = new(uint32)
 	b.tidyCancelCAS = new(uint32)
 	b.tidyStatus = &tidyStatus{state: tidyStatusInactive}
@@ -301,6 +340,7 @@ func Backend(conf *logical.BackendConfig) *backend {
 
 	b.acmeState = NewACMEState()
 	b.certificateCounter = NewCertificateCounter(b.backendUUID)
+	b.issuanceQuotas = newIssuanceQuotas()
 
 	// It is important that we call SetupEnt at the very end as
 	// some ENT backends need access to the member vars initialized above.
@@ -335,6 +375,16 @@ type backend struct {
 	// Context around ACME operations
 	acmeState       *acmeState
 	acmeAccountLock sync.RWMutex // (Write) Locked on Tidy, (Read) Locked on Account Creation
+
+	// The .well-known/est paths currently redirected to this mount
+	estRedirectsLock sync.Mutex
+	estRedirects     []string
+
+	// Held while consuming or tidying dynamic SCEP challenges
+	scepChallengeLock     sync.Mutex
+	lastScepChallengeTidy time.Time
+
+	issuanceQuotas *issuanceQuotas
 }
 
 // BackendOps a bridge/legacy interface until we can further
@@ -423,6 +473,38 @@ func (b *backend) metricsWrap(callType string, roleMode int, ofunc roleOperation
 	}
 }
 
+// certEvent emits a pki/<operation> event describing cert, so inventory
+// systems can follow the certificates of this mount over the event system.
+// Failures to send are only logged.
+func (b *backend) certEvent(ctx context.Context, operation string, path string, cert *x509.Certificate, additionalMetadataPairs ...string) {
+	metadata := []string{
+		logical.EventMetadataModified, "true",
+		logical.EventMetadataOperation, operation,
+		"serial_number", serialFromCert(cert),
+		"common_name", cert.Subject.CommonName,
+		"not_after", cert.NotAfter.UTC().Format(time.RFC3339),
+	}
+	if path != "" {
+		metadata = append(metadata, "path", path)
+	}
+	metadata = append(metadata, additionalMetadataPairs...)
+	err := logical.SendEvent(ctx, b, "pki/"+operation, metadata...)
+	if err != nil && !errors.Is(err, framework.ErrNoEvents) {
+		b.Logger().Error("Error sending event", "error", err)
+	}
+}
+
+// issuedCertEvent emits the pki/<operation> event of a leaf certificate
+// issued under input. Issuance through the API, ACME, EST and SCEP shares
+// generateCert and signCert, which call this once the certificate is signed.
+func (b *backend) issuedCertEvent(ctx context.Context, operation string, input *inputBundle, cert *x509.Certificate) {
+	var path string
+	if input.req != nil {
+		path = input.req.Path
+	}
+	b.certEvent(ctx, operation, path, cert, "role", input.role.Name, "issuer_id", input.issuerId.String())
+}
+
 // initialize is used to perform a possible PKI storage migration if needed
 func (b *backend) initialize(ctx context.Context, ir *logical.InitializationRequest) error {
 	sc := b.makeStorageContext(ctx, b.storage)
@@ -440,6 +522,8 @@ func (b *backend) initialize(ctx context.Context, ir *logical.InitializationRequ
 		return err
 	}
 
+	b.reloadEstWellKnownRedirects(sc)
+
 	// Initialize also needs to populate our certificate and revoked certificate count
 	err = b.initializeStoredCertificateCounts(ctx)
 	if err != nil {
@@ -593,6 +677,8 @@ func (b *backend) invalidate(ctx context.Context, key string) {
 		b.CrlBuilder().markConfigDirty()
 	case key == storageAcmeConfig:
 		b.GetAcmeState().markConfigDirty()
+	case key == storageEstConfig:
+		b.reloadEstWellKnownRedirects(b.makeStorageContext(ctx, b.storage))
 	case key == storageIssuerConfig:
 		b.CrlBuilder().invalidateCRLBuildTime()
 	case strings.HasPrefix(key, crossRevocationPrefix):
@@ -740,6 +826,17 @@ func (b *backend) periodicFunc(ctx context.Context, request *logical.Request) er
 		return nil
 	}
 
+	doScepChallengeTidy := func() error {
+		// Challenges are local to the cluster, but only the active node
+		// can modify storage.
+		if b.System().ReplicationState().HasState(consts.ReplicationPerformanceStandby) ||
+			b.System().ReplicationState().HasState(consts.ReplicationDRSecondary) {
+			return nil
+		}
+
+		return b.tidyScepChallenges(sc)
+	}
+
 	// First tidy any ACME nonces to free memory.
 	b.GetAcmeState().DoTidyNonces()
 
@@ -750,9 +847,11 @@ func (b *backend) periodicFunc(ctx context.Context, request *logical.Request) er
 	// Then run the CRL rebuild and tidy operation.
 	crlErr := doCRL()
 	tidyErr := doAutoTidy()
+	scepErr := doScepChallengeTidy()
 
 	// Periodically re-emit gauges so that they don't disappear/go stale
 	b.GetCertificateCounter().EmitCertStoreMetrics()
+	metricsErr := b.emitIssuerExpiryMetrics(sc)
 
 	var errors error
 	if crlErr != nil {
@@ -763,6 +862,14 @@ func (b *backend) periodicFunc(ctx context.Context, request *logical.Request) er
 		errors = multierror.Append(errors, fmt.Errorf("Error running auto-tidy:\n - %w\n", tidyErr))
 	}
 
+	if scepErr != nil {
+		errors = multierror.Append(errors, fmt.Errorf("Error removing expired SCEP challenges:\n - %w\n", scepErr))
+	}
+
+	if metricsErr != nil {
+		errors = multierror.Append(errors, fmt.Errorf("Error publishing issuer expiry metrics:\n - %w\n", metricsErr))
+	}
+
 	if errors != nil {
 		return errors
 	}
@@ -15,6 +15,7 @@ import (
 	"crypto/rsa"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"encoding/asn1"
 	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
@@ -2396,6 +2397,105 @@ func runTestSignVerbatim(t *testing.T, keyType string) {
 	}
 }
 
+// TestBackend_SignVerbatimRestrictedRole tests that sign-verbatim requests
+// using a role with restrict_sign_verbatim are constrained by the role.
+func TestBackend_SignVerbatimRestrictedRole(t *testing.T) {
+	t.Parallel()
+	b, s := CreateBackendWithStorage(t)
+
+	resp, err := CBWrite(b, s, "root/generate/internal", map[string]interface{}{
+		"common_name": "Root X1",
+		"key_type":    "ec",
+		"ttl":         "7200h",
+	})
+	requireSuccessNonNilResponse(t, resp, err, "failed generating root issuer")
+
+	resp, err = CBWrite(b, s, "roles/restricted", map[string]interface{}{
+		"allowed_domains":        "example.com",
+		"allow_subdomains":       true,
+		"key_type":               "ec",
+		"key_bits":               256,
+		"key_usage":              "DigitalSignature",
+		"server_flag":            true,
+		"client_flag":            false,
+		"allowed_extensions":     "1.3.6.1.4.1.41482.1.1",
+		"restrict_sign_verbatim": true,
+	})
+	requireSuccessNonNilResponse(t, resp, err, "failed creating role")
+	require.Equal(t, true, resp.Data["restrict_sign_verbatim"])
+
+	allowedExt := pkix.Extension{Id: asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 41482, 1, 1}, Value: []byte{0x05, 0x00}}
+	otherExt := pkix.Extension{Id: asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 41482, 1, 2}, Value: []byte{0x05, 0x00}}
+	ekuExt := pkix.Extension{Id: certutil.ExtendedKeyUsageOID, Value: []byte{0x30, 0x0a, 0x06, 0x08, 0x2b, 0x06, 0x01, 0x05, 0x05, 0x07, 0x03, 0x03}}
+	_, _, csrPem := generateCSR(t, &x509.CertificateRequest{
+		Subject:         pkix.Name{CommonName: "www.example.com"},
+		DNSNames:        []string{"api.example.com"},
+		ExtraExtensions: []pkix.Extension{allowedExt, otherExt, ekuExt},
+	}, "ec", 256)
+
+	resp, err = CBWrite(b, s, "sign-verbatim/restricted", map[string]interface{}{
+		"csr": csrPem,
+	})
+	requireSuccessNonNilResponse(t, resp, err, "failed signing verbatim with restricted role")
+	require.NotEmpty(t, resp.Warnings)
+
+	cert := parseCert(t, resp.Data["certificate"].(string))
+	require.Equal(t, "www.example.com", cert.Subject.CommonName)
+	require.Equal(t, []string{"api.example.com"}, cert.DNSNames)
+	require.Equal(t, x509.KeyUsageDigitalSignature, cert.KeyUsage)
+	require.Equal(t, []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}, cert.ExtKeyUsage)
+	var extensions []string
+	for _, ext := range cert.Extensions {
+		extensions = append(extensions, ext.Id.String())
+	}
+	require.Contains(t, extensions, allowedExt.Id.String())
+	require.NotContains(t, extensions, otherExt.Id.String())
+
+	// Names, keys and usages outside of the role are refused.
+	_, _, csrPem = generateCSR(t, &x509.CertificateRequest{
+		Subject: pkix.Name{CommonName: "www.example.org"},
+	}, "ec", 256)
+	_, err = CBWrite(b, s, "sign-verbatim/restricted", map[string]interface{}{
+		"csr": csrPem,
+	})
+	require.Error(t, err)
+
+	_, _, csrPem = generateCSR(t, &x509.CertificateRequest{
+		Subject: pkix.Name{CommonName: "www.example.com"},
+	}, "rsa", 2048)
+	_, err = CBWrite(b, s, "sign-verbatim/restricted", map[string]interface{}{
+		"csr": csrPem,
+	})
+	require.Error(t, err)
+
+	_, _, csrPem = generateCSR(t, &x509.CertificateRequest{
+		Subject: pkix.Name{CommonName: "www.example.com"},
+	}, "ec", 256)
+	_, err = CBWrite(b, s, "sign-verbatim/restricted", map[string]interface{}{
+		"csr":       csrPem,
+		"key_usage": "DigitalSignature,CertSign",
+	})
+	require.Error(t, err)
+	_, err = CBWrite(b, s, "sign-verbatim/restricted", map[string]interface{}{
+		"csr":           csrPem,
+		"ext_key_usage": "ClientAuth",
+	})
+	require.Error(t, err)
+
+	// Without restrict_sign_verbatim, the role does not constrain the CSR.
+	resp, err = CBPatch(b, s, "roles/restricted", map[string]interface{}{
+		"restrict_sign_verbatim": false,
+	})
+	requireSuccessNonNilResponse(t, resp, err, "failed updating role")
+	_, _, csrPem = generateCSR(t, &x509.CertificateRequest{
+		Subject: pkix.Name{CommonName: "www.example.org"},
+	}, "rsa", 2048)
+	resp, err = CBWrite(b, s, "sign-verbatim/restricted", map[string]interface{}{
+		"csr": csrPem,
+	})
+	requireSuccessNonNilResponse(t, resp, err, "failed signing verbatim with unrestricted role")
+}
+
 func TestBackend_Root_Idempotency(t *testing.T) {
 	t.Parallel()
 	b, s := CreateBackendWithStorage(t)
@@ -2456,7 +2556,8 @@ func TestBackend_Root_Idempotency(t *testing.T) {
 	// Now let's validate that the import bundle is idempotent.
 	pemBundleRootCA := rootCACertPEM + "\n" + rootCAKeyPEM
 	resp, err = CBWrite(b, s, "config/ca", map[string]interface{}{
-		"pem_bundle": pemBundleRootCA,
+		"pem_bundle":       pemBundleRootCA,
+		"allow_expired_ca": true,
 	})
 	schema.ValidateResponse(t, schema.GetResponseSchema(t, b.Route("config/ca"), logical.UpdateOperation), resp, true)
 
@@ -2486,7 +2587,8 @@ func TestBackend_Root_Idempotency(t *testing.T) {
 
 	// Performing this again should result in no key/issuer ids being imported/generated.
 	resp, err = CBWrite(b, s, "config/ca", map[string]interface{}{
-		"pem_bundle": pemBundleRootCA,
+		"pem_bundle":       pemBundleRootCA,
+		"allow_expired_ca": true,
 	})
 	require.NoError(t, err)
 	require.NotNil(t, resp, "expected ca info")
@@ -2518,7 +2620,8 @@ func TestBackend_Root_Idempotency(t *testing.T) {
 
 	// We should be able to import the same ca bundle as before and get a different key/issuer ids
 	resp, err = CBWrite(b, s, "config/ca", map[string]interface{}{
-		"pem_bundle": pemBundleRootCA,
+		"pem_bundle":       pemBundleRootCA,
+		"allow_expired_ca": true,
 	})
 	require.NoError(t, err)
 	require.NotNil(t, resp, "expected ca info")
@@ -2593,6 +2696,84 @@ func TestBackend_SignIntermediate_EnforceLeafFlag(t *testing.T) {
 		"root cert notAfter %v was not before ca cert's notAfter %v", rootCert.NotAfter, intCert.NotAfter)
 }
 
+func TestBackend_SignIntermediate_PathLength(t *testing.T) {
+	t.Parallel()
+	bRoot, sRoot := CreateBackendWithStorage(t)
+	bInt, sInt := CreateBackendWithStorage(t)
+	bIss, sIss := CreateBackendWithStorage(t)
+
+	_, err := CBWrite(bRoot, sRoot, "root/generate/internal", map[string]interface{}{
+		"common_name":     "root.example.com",
+		"ttl":             "40h",
+		"max_path_length": 2,
+	})
+	require.NoError(t, err)
+
+	// The path length requested at generation travels in the CSR.
+	resp, err := CBWrite(bInt, sInt, "intermediate/generate/internal", map[string]interface{}{
+		"common_name":     "int.example.com",
+		"max_path_length": 1,
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	csr := resp.Data["csr"].(string)
+
+	// Explicit path lengths must fit beneath the signing issuer's.
+	_, err = CBWrite(bRoot, sRoot, "root/sign-intermediate", map[string]interface{}{
+		"csr":             csr,
+		"ttl":             "20h",
+		"max_path_length": 2,
+	})
+	require.ErrorContains(t, err, "must be less than the signing issuer's (2)")
+
+	_, err = CBWrite(bRoot, sRoot, "root/sign-intermediate", map[string]interface{}{
+		"csr":             csr,
+		"ttl":             "20h",
+		"max_path_length": -1,
+	})
+	require.ErrorContains(t, err, "signing issuer limits path length to 2")
+
+	resp, err = CBWrite(bRoot, sRoot, "root/sign-intermediate", map[string]interface{}{
+		"csr":            csr,
+		"ttl":            "20h",
+		"use_csr_values": true,
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	intCert := parseCert(t, resp.Data["certificate"].(string))
+	require.Equal(t, 1, intCert.MaxPathLen)
+
+	_, err = CBWrite(bInt, sInt, "intermediate/set-signed", map[string]interface{}{
+		"certificate": resp.Data["certificate"].(string),
+	})
+	require.NoError(t, err)
+
+	// A third tier beneath the intermediate.
+	resp, err = CBWrite(bIss, sIss, "intermediate/generate/internal", map[string]interface{}{
+		"common_name":     "issuing.example.com",
+		"max_path_length": 1,
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	csr = resp.Data["csr"].(string)
+
+	_, err = CBWrite(bInt, sInt, "root/sign-intermediate", map[string]interface{}{
+		"csr":            csr,
+		"ttl":            "10h",
+		"use_csr_values": true,
+	})
+	require.ErrorContains(t, err, "must be less than the signing issuer's (1)")
+
+	resp, err = CBWrite(bInt, sInt, "root/sign-intermediate", map[string]interface{}{
+		"csr":             csr,
+		"ttl":             "10h",
+		"use_csr_values":  true,
+		"max_path_length": 0,
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	issCert := parseCert(t, resp.Data["certificate"].(string))
+	require.Equal(t, 0, issCert.MaxPathLen)
+	require.True(t, issCert.MaxPathLenZero)
+	require.NoError(t, issCert.CheckSignatureFrom(intCert))
+}
+
 func TestBackend_SignIntermediate_AllowedPastCAValidity(t *testing.T) {
 	t.Parallel()
 	b_root, s_root := CreateBackendWithStorage(t)
@@ -3311,6 +3492,136 @@ func TestBackend_AllowedSerialNumbers(t *testing.T) {
 	}
 }
 
+func TestBackend_SerialNumberBits(t *testing.T) {
+	t.Parallel()
+	b, s := CreateBackendWithStorage(t)
+
+	_, err := CBWrite(b, s, "root/generate/internal", map[string]interface{}{
+		"ttl":         "40h",
+		"common_name": "myvault.com",
+		"key_type":    "ec",
+	})
+	require.NoError(t, err)
+
+	for _, bits := range []int{-1, 63, 160} {
+		_, err = CBWrite(b, s, "roles/test", map[string]interface{}{
+			"allow_any_name":     true,
+			"serial_number_bits": bits,
+		})
+		require.ErrorContains(t, err, "serial_number_bits must be 0 or between 64 and 159")
+	}
+
+	resp, err := CBWrite(b, s, "roles/test", map[string]interface{}{
+		"allow_any_name":         true,
+		"key_type":               "ec",
+		"serial_number_bits":     64,
+		"allowed_serial_numbers": "device-*",
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	require.Equal(t, 64, resp.Data["serial_number_bits"])
+
+	for i := 0; i < 10; i++ {
+		resp, err = CBWrite(b, s, "issue/test", map[string]interface{}{
+			"common_name":   "device.example.com",
+			"serial_number": "device-1234",
+			"ttl":           "1h",
+		})
+		requireSuccessNonNilResponse(t, resp, err)
+		cert := parseCert(t, resp.Data["certificate"].(string))
+		require.LessOrEqual(t, cert.SerialNumber.BitLen(), 64)
+		require.Equal(t, "device-1234", cert.Subject.SerialNumber)
+	}
+}
+
+func TestBackend_CSRMergeMode(t *testing.T) {
+	t.Parallel()
+	b, s := CreateBackendWithStorage(t)
+
+	_, err := CBWrite(b, s, "root/generate/internal", map[string]interface{}{
+		"ttl":         "40h",
+		"common_name": "myvault.com",
+		"key_type":    "ec",
+	})
+	require.NoError(t, err)
+
+	_, err = CBWrite(b, s, "roles/test", map[string]interface{}{
+		"allow_any_name": true,
+		"csr_merge_mode": map[string]interface{}{"fqdn": "merge"},
+	})
+	require.ErrorContains(t, err, "unknown SAN type")
+
+	_, err = CBWrite(b, s, "roles/test", map[string]interface{}{
+		"allow_any_name": true,
+		"csr_merge_mode": map[string]interface{}{"dns": "union"},
+	})
+	require.ErrorContains(t, err, "unknown mode")
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	csrDer, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:     pkix.Name{CommonName: "csr.example.com"},
+		DNSNames:    []string{"csr.example.com", "alt.example.com"},
+		IPAddresses: []net.IP{net.ParseIP("10.0.0.1")},
+	}, key)
+	require.NoError(t, err)
+	csr := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csrDer}))
+
+	sign := func(altNames, ipSANs string) (*x509.Certificate, error) {
+		resp, err := CBWrite(b, s, "sign/test", map[string]interface{}{
+			"csr":       csr,
+			"alt_names": altNames,
+			"ip_sans":   ipSANs,
+			"ttl":       "1h",
+		})
+		if err != nil {
+			return nil, err
+		}
+		requireSuccessNonNilResponse(t, resp, err)
+		return parseCert(t, resp.Data["certificate"].(string)), nil
+	}
+
+	resp, err := CBWrite(b, s, "roles/test", map[string]interface{}{
+		"allow_any_name": true,
+		"key_type":       "ec",
+		"csr_merge_mode": map[string]interface{}{"DNS": "Merge", "ip": "ignore"},
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	require.Equal(t, map[string]string{"dns": "merge", "ip": "ignore"}, resp.Data["csr_merge_mode"])
+
+	cert, err := sign("alt.example.com,req.example.com", "10.0.0.2")
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"csr.example.com", "alt.example.com", "req.example.com"}, cert.DNSNames)
+	require.Len(t, cert.IPAddresses, 1)
+	require.True(t, cert.IPAddresses[0].Equal(net.ParseIP("10.0.0.2")))
+
+	_, err = CBPatch(b, s, "roles/test", map[string]interface{}{
+		"csr_merge_mode": map[string]interface{}{"dns": "enforce", "ip": "enforce"},
+	})
+	require.NoError(t, err)
+
+	cert, err = sign("alt.example.com", "10.0.0.1")
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"csr.example.com", "alt.example.com"}, cert.DNSNames)
+	require.Len(t, cert.IPAddresses, 1)
+	require.True(t, cert.IPAddresses[0].Equal(net.ParseIP("10.0.0.1")))
+
+	_, err = sign("req.example.com", "")
+	require.ErrorContains(t, err, "subject alternate name req.example.com was requested but is not present in the CSR")
+	_, err = sign("", "10.0.0.2")
+	require.ErrorContains(t, err, "IP Subject Alternative Name 10.0.0.2 was requested but is not present in the CSR")
+
+	// SAN types without a mode still follow use_csr_sans.
+	_, err = CBPatch(b, s, "roles/test", map[string]interface{}{
+		"csr_merge_mode": map[string]interface{}{"ip": "merge"},
+	})
+	require.NoError(t, err)
+
+	cert, err = sign("req.example.com", "10.0.0.2")
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"csr.example.com", "alt.example.com"}, cert.DNSNames)
+	require.Len(t, cert.IPAddresses, 2)
+}
+
 func TestBackend_URI_SANs(t *testing.T) {
 	t.Parallel()
 	b, s := CreateBackendWithStorage(t)
@@ -3721,10 +4032,12 @@ func TestReadWriteDeleteRoles(t *testing.T) {
 	expectedData := map[string]interface{}{
 		"key_type":                           "rsa",
 		"use_csr_sans":                       true,
+		"csr_merge_mode":                     nil,
 		"client_flag":                        true,
 		"allowed_serial_numbers":             []interface{}{},
 		"generate_lease":                     false,
 		"signature_bits":                     json.Number("256"),
+		"serial_number_bits":                 json.Number("0"),
 		"use_pss":                            false,
 		"allowed_domains":                    []interface{}{},
 		"allowed_uri_sans_template":          false,
@@ -3743,6 +4056,7 @@ func TestReadWriteDeleteRoles(t *testing.T) {
 		"allowed_other_sans":                 []interface{}{},
 		"allowed_uri_sans":                   []interface{}{},
 		"basic_constraints_valid_for_non_ca": false,
+		"ct_submission":                      false,
 		"key_usage":                          []interface{}{"DigitalSignature", "KeyAgreement", "KeyEncipherment"},
 		"not_before_duration":                json.Number("30"),
 		"allow_glob_domains":                 false,
@@ -3754,6 +4068,8 @@ func TestReadWriteDeleteRoles(t *testing.T) {
 		"allow_bare_domains":                 false,
 		"allow_ip_sans":                      true,
 		"ext_key_usage_oids":                 []interface{}{},
+		"allowed_extensions":                 []interface{}{},
+		"restrict_sign_verbatim":             false,
 		"allow_any_name":                     false,
 		"ext_key_usage":                      []interface{}{},
 		"key_bits":                           json.Number("2048"),
@@ -3766,6 +4082,8 @@ func TestReadWriteDeleteRoles(t *testing.T) {
 		"issuer_ref":                         "default",
 		"cn_validations":                     []interface{}{"email", "hostname"},
 		"allowed_user_ids":                   []interface{}{},
+		"subject_rdns":                       []interface{}{},
+		"allowed_subject_rdns":               []interface{}{},
 	}
 
 	if issuing.MetadataPermitted {
@@ -4110,6 +4428,7 @@ func TestBackend_RevokePlusTidy_Intermediate(t *testing.T) {
 			"tidy_revoked_certs":                    true,
 			"tidy_revoked_cert_issuer_associations": false,
 			"tidy_expired_issuers":                  false,
+			"tidy_dangling_keys":                    false,
 			"tidy_move_legacy_ca_bundle":            false,
 			"tidy_revocation_queue":                 false,
 			"tidy_cross_cluster_revoked_certs":      false,
@@ -4138,6 +4457,7 @@ func TestBackend_RevokePlusTidy_Intermediate(t *testing.T) {
 			"total_acme_account_count":              json.Number("0"),
 			"cert_metadata_deleted_count":           json.Number("0"),
 			"cmpv2_nonce_deleted_count":             json.Number("0"),
+			"dangling_keys_deleted_count":           json.Number("0"),
 		}
 		// Let's copy the times from the response so that we can use deep.Equal()
 		timeStarted, ok := tidyStatus.Data["time_started"]
@@ -4427,6 +4747,62 @@ func runFullCAChainTest(t *testing.T, keyType string) {
 	}
 }
 
+// TestBackend_ConfigCA_ChainBundle ensures a CA imported through config/ca
+// together with its parents, in any order, serves its full chain.
+func TestBackend_ConfigCA_ChainBundle(t *testing.T) {
+	t.Parallel()
+
+	b_root, s_root := CreateBackendWithStorage(t)
+	resp, err := CBWrite(b_root, s_root, "root/generate/internal", map[string]interface{}{
+		"common_name": "root myvault.com",
+		"key_type":    "ec",
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	rootCert := resp.Data["certificate"].(string)
+
+	// Build a root -> intermediate -> issuing hierarchy.
+	signedBy := func(commonName string, b_parent *backend, s_parent logical.Storage) (string, string, *backend, logical.Storage) {
+		b_int, s_int := CreateBackendWithStorage(t)
+		resp, err := CBWrite(b_int, s_int, "intermediate/generate/exported", map[string]interface{}{
+			"common_name": commonName,
+			"key_type":    "ec",
+		})
+		requireSuccessNonNilResponse(t, resp, err)
+		key := resp.Data["private_key"].(string)
+
+		resp, err = CBWrite(b_parent, s_parent, "root/sign-intermediate", map[string]interface{}{
+			"csr":         resp.Data["csr"],
+			"common_name": commonName,
+		})
+		requireSuccessNonNilResponse(t, resp, err)
+		cert := resp.Data["certificate"].(string)
+
+		_, err = CBWrite(b_int, s_int, "intermediate/set-signed", map[string]interface{}{
+			"certificate": cert,
+		})
+		require.NoError(t, err)
+		return cert, key, b_int, s_int
+	}
+	intCert, _, b_int, s_int := signedBy("intermediate myvault.com", b_root, s_root)
+	issuingCert, issuingKey, _, _ := signedBy("issuing myvault.com", b_int, s_int)
+
+	b, s := CreateBackendWithStorage(t)
+	resp, err = CBWrite(b, s, "config/ca", map[string]interface{}{
+		"pem_bundle": rootCert + "\n" + issuingCert + "\n" + intCert + "\n" + issuingKey,
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	require.Len(t, resp.Data["imported_issuers"], 3)
+
+	resp, err = CBRead(b, s, "cert/ca_chain")
+	requireSuccessNonNilResponse(t, resp, err)
+	fullChain := resp.Data["ca_chain"].(string)
+	require.Equal(t, 1, strings.Count(fullChain, issuingCert))
+	require.Equal(t, 1, strings.Count(fullChain, intCert))
+	require.Equal(t, 1, strings.Count(fullChain, rootCert))
+	require.Less(t, strings.Index(fullChain, issuingCert), strings.Index(fullChain, intCert))
+	require.Less(t, strings.Index(fullChain, intCert), strings.Index(fullChain, rootCert))
+}
+
 func requireCertInCaChainArray(t *testing.T, chain []string, cert string, msgAndArgs ...interface{}) {
 	var fullChain string
 	for _, caCert := range chain {
@@ -5344,7 +5720,8 @@ TgM7RZnmEjNdeaa4M52o7VY=
 -----END PRIVATE KEY-----
 	`
 	resp, err := CBWrite(b, s, "issuers/import/bundle", map[string]interface{}{
-		"pem_bundle": customBundleWithoutCRLBits,
+		"pem_bundle":       customBundleWithoutCRLBits,
+		"allow_expired_ca": true,
 	})
 	schema.ValidateResponse(t, schema.GetResponseSchema(t, b.Route("issuers/import/bundle"), logical.UpdateOperation), resp, true)
 	require.NoError(t, err)
@@ -6292,6 +6669,218 @@ func TestPKI_TemplatedAIAs(t *testing.T) {
 	require.Error(t, err)
 }
 
+func TestPKI_IssuePKCS12(t *testing.T) {
+	t.Parallel()
+	b, s := CreateBackendWithStorage(t)
+
+	resp, err := CBWrite(b, s, "root/generate/internal", map[string]interface{}{
+		"common_name": "Root X1",
+		"key_type":    "ec",
+		"ttl":         "72h",
+	})
+	require.NoError(t, err)
+	rootPem := resp.Data["certificate"].(string)
+
+	_, err = CBWrite(b, s, "roles/web", map[string]interface{}{
+		"allowed_domains":  "example.com",
+		"allow_subdomains": true,
+		"key_type":         "rsa",
+	})
+	require.NoError(t, err)
+
+	resp, err = CBWrite(b, s, "issue/web", map[string]interface{}{
+		"common_name":     "www.example.com",
+		"format":          "pkcs12",
+		"pkcs12_password": "hunter2",
+	})
+	require.NoError(t, err)
+	require.NotContains(t, resp.Data, "private_key")
+	require.Equal(t, certutil.RSAPrivateKey, resp.Data["private_key_type"])
+	require.NotEmpty(t, resp.Data["certificate"])
+	require.Contains(t, resp.Data["ca_chain"], rootPem)
+
+	pfx, err := base64.StdEncoding.DecodeString(resp.Data["pkcs12"].(string))
+	require.NoError(t, err)
+	var archive struct {
+		Version  int
+		AuthSafe asn1.RawValue
+		MacData  asn1.RawValue
+	}
+	_, err = asn1.Unmarshal(pfx, &archive)
+	require.NoError(t, err)
+	require.Equal(t, 3, archive.Version)
+
+	// Signing CSRs has no private key to archive.
+	_, csrPem := generateTestCsr(t, certutil.RSAPrivateKey, 2048)
+	_, err = CBWrite(b, s, "sign/web", map[string]interface{}{
+		"common_name": "www.example.com",
+		"csr":         csrPem,
+		"format":      "pkcs12",
+	})
+	require.ErrorContains(t, err, `the "format" path parameter must be`)
+}
+
+func TestPKI_IssueWrappedPrivateKey(t *testing.T) {
+	t.Parallel()
+
+	coreConfig := &vault.CoreConfig{
+		LogicalBackends: map[string]logical.Factory{
+			"pki": Factory,
+		},
+	}
+	cluster := vault.NewTestCluster(t, coreConfig, &vault.TestClusterOptions{
+		NumCores:    1,
+		HandlerFunc: vaulthttp.Handler,
+	})
+	cluster.Start()
+	defer cluster.Cleanup()
+	client := cluster.Cores[0].Client
+	vault.TestWaitActive(t, cluster.Cores[0].Core)
+
+	mountPKIEndpoint(t, client, "pki")
+	_, err := client.Logical().Write("pki/root/generate/internal", map[string]interface{}{
+		"common_name": "Root X1",
+		"key_type":    "ec",
+		"ttl":         "24h",
+	})
+	require.NoError(t, err)
+	_, err = client.Logical().Write("pki/roles/web", map[string]interface{}{
+		"allowed_domains":  "example.com",
+		"allow_subdomains": true,
+		"key_type":         "ec",
+	})
+	require.NoError(t, err)
+
+	resp, err := client.Logical().Write("pki/issue/web", map[string]interface{}{
+		"common_name":          "www.example.com",
+		"private_key_wrap_ttl": "5m",
+	})
+	require.NoError(t, err)
+	require.NotContains(t, resp.Data, "private_key")
+	require.Equal(t, string(certutil.ECPrivateKey), resp.Data["private_key_type"])
+	require.Nil(t, resp.WrapInfo, "only the private key should be wrapped")
+
+	wrapInfo := resp.Data["private_key_wrap_info"].(map[string]interface{})
+	require.Equal(t, json.Number("300"), wrapInfo["ttl"])
+	require.Equal(t, "sys/wrapping/wrap", wrapInfo["creation_path"])
+
+	cert := parseCert(t, resp.Data["certificate"].(string))
+
+	// The private key matches the certificate, and can only be unwrapped once
+	unwrapped, err := client.Logical().Unwrap(wrapInfo["token"].(string))
+	require.NoError(t, err)
+	require.NotContains(t, unwrapped.Data, "private_key_type")
+	key, err := certutil.ParsePEMBundle(unwrapped.Data["private_key"].(string))
+	require.NoError(t, err)
+	require.Equal(t, cert.PublicKey, key.PrivateKey.Public())
+
+	_, err = client.Logical().Unwrap(wrapInfo["token"].(string))
+	require.Error(t, err)
+
+	// The PKCS#12 archive holds the private key, so it is wrapped instead
+	resp, err = client.Logical().Write("pki/issue/web", map[string]interface{}{
+		"common_name":          "www.example.com",
+		"format":               "pkcs12",
+		"private_key_wrap_ttl": "5m",
+	})
+	require.NoError(t, err)
+	require.NotContains(t, resp.Data, "pkcs12")
+	wrapInfo = resp.Data["private_key_wrap_info"].(map[string]interface{})
+	unwrapped, err = client.Logical().Unwrap(wrapInfo["token"].(string))
+	require.NoError(t, err)
+	require.NotEmpty(t, unwrapped.Data["pkcs12"])
+
+	// PEM bundles embed the private key in the certificate field
+	_, err = client.Logical().Write("pki/issue/web", map[string]interface{}{
+		"common_name":          "www.example.com",
+		"format":               "pem_bundle",
+		"private_key_wrap_ttl": "5m",
+	})
+	require.ErrorContains(t, err, "private_key_wrap_ttl")
+}
+
+func TestPKI_EncryptedPrivateKeys(t *testing.T) {
+	t.Parallel()
+	b, s := CreateBackendWithStorage(t)
+
+	requireEncrypted := func(t *testing.T, privateKey string, isPem bool) {
+		t.Helper()
+		var der []byte
+		if isPem {
+			block, _ := pem.Decode([]byte(privateKey))
+			require.NotNil(t, block)
+			require.Equal(t, "ENCRYPTED PRIVATE KEY", block.Type)
+			der = block.Bytes
+		} else {
+			var err error
+			der, err = base64.StdEncoding.DecodeString(privateKey)
+			require.NoError(t, err)
+		}
+
+		var info struct {
+			Algorithm     pkix.AlgorithmIdentifier
+			EncryptedData []byte
+		}
+		_, err := asn1.Unmarshal(der, &info)
+		require.NoError(t, err)
+		require.Equal(t, asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}, info.Algorithm.Algorithm)
+	}
+
+	_, err := CBWrite(b, s, "root/generate/exported", map[string]interface{}{
+		"common_name":            "Root X1",
+		"format":                 "pem_bundle",
+		"private_key_passphrase": "hunter2",
+	})
+	require.ErrorContains(t, err, "private_key_passphrase")
+
+	resp, err := CBWrite(b, s, "root/generate/exported", map[string]interface{}{
+		"common_name":            "Root X1",
+		"key_type":               "ec",
+		"ttl":                    "72h",
+		"private_key_passphrase": "hunter2",
+	})
+	require.NoError(t, err)
+	requireEncrypted(t, resp.Data["private_key"].(string), true)
+
+	// The issuer's key is stored unencrypted and keeps issuing.
+	_, err = CBWrite(b, s, "roles/web", map[string]interface{}{
+		"allow_any_name": true,
+		"ttl":            "1h",
+	})
+	require.NoError(t, err)
+
+	resp, err = CBWrite(b, s, "issue/web", map[string]interface{}{
+		"common_name":            "www.example.com",
+		"format":                 "der",
+		"private_key_passphrase": "hunter2",
+	})
+	require.NoError(t, err)
+	requireEncrypted(t, resp.Data["private_key"].(string), false)
+
+	resp, err = CBWrite(b, s, "issue/web", map[string]interface{}{
+		"common_name":            "www.example.com",
+		"private_key_format":     "pkcs8",
+		"private_key_passphrase": "hunter2",
+	})
+	require.NoError(t, err)
+	requireEncrypted(t, resp.Data["private_key"].(string), true)
+
+	_, err = CBWrite(b, s, "issue/web", map[string]interface{}{
+		"common_name":            "www.example.com",
+		"format":                 "pkcs12",
+		"private_key_passphrase": "hunter2",
+	})
+	require.ErrorContains(t, err, "private_key_passphrase")
+
+	resp, err = CBWrite(b, s, "intermediate/generate/exported", map[string]interface{}{
+		"common_name":            "Intermediate X1",
+		"key_type":               "rsa",
+		"private_key_passphrase": "hunter2",
+	})
+	require.NoError(t, err)
+	requireEncrypted(t, resp.Data["private_key"].(string), true)
+}
+
 func requireSubjectUserIDAttr(t *testing.T, cert string, target string) {
 	xCert := parseCert(t, cert)
 
@@ -6549,6 +7138,176 @@ func TestUserIDsInLeafCerts(t *testing.T) {
 	requireSubjectUserIDAttr(t, resp.Data["certificate"].(string), "humanoid")
 }
 
+// TestSubjectRDNsInLeafCerts tests that the subject_rdns of roles and requests
+// are placed in the subject of issued certificates in order.
+func TestSubjectRDNsInLeafCerts(t *testing.T) {
+	t.Parallel()
+	b, s := CreateBackendWithStorage(t)
+
+	resp, err := CBWrite(b, s, "root/generate/internal", map[string]interface{}{
+		"common_name": "Vault Root CA",
+		"key_type":    "ec",
+		"ttl":         "7200h",
+	})
+	requireSuccessNonNilResponse(t, resp, err, "failed generating root issuer")
+
+	requireSubjectRDNs := func(cert string, expected ...string) {
+		t.Helper()
+		var rdns []string
+		for _, attr := range parseCert(t, cert).Subject.Names {
+			rdns = append(rdns, fmt.Sprintf("%v=%v", attr.Type, attr.Value))
+		}
+		require.Equal(t, expected, rdns)
+	}
+
+	// Role subject_rdns cannot name the common name, nor be combined with the
+	// fixed subject fields.
+	_, err = CBWrite(b, s, "roles/testing", map[string]interface{}{
+		"allow_any_name": true,
+		"subject_rdns":   []string{"CN=foo"},
+	})
+	require.Error(t, err)
+	_, err = CBWrite(b, s, "roles/testing", map[string]interface{}{
+		"allow_any_name": true,
+		"subject_rdns":   []string{"OU=Engineering"},
+		"organization":   "Example",
+	})
+	require.Error(t, err)
+
+	resp, err = CBWrite(b, s, "roles/testing", map[string]interface{}{
+		"allow_any_name":       true,
+		"key_type":             "ec",
+		"subject_rdns":         []string{"DC=com", "DC=example", "O=Example, Inc.", "OU=Engineering", "OU=Platform", "1.3.6.1.4.1.311.60.2.1.3=US"},
+		"allowed_subject_rdns": []string{"DC=*", "OU=*"},
+	})
+	requireSuccessNonNilResponse(t, resp, err, "failed setting up role")
+
+	resp, err = CBWrite(b, s, "issue/testing", map[string]interface{}{
+		"common_name": "localhost",
+	})
+	requireSuccessNonNilResponse(t, resp, err, "failed issuing leaf cert")
+	requireSubjectRDNs(resp.Data["certificate"].(string),
+		"0.9.2342.19200300.100.1.25=com",
+		"0.9.2342.19200300.100.1.25=example",
+		"2.5.4.10=Example, Inc.",
+		"2.5.4.11=Engineering",
+		"2.5.4.11=Platform",
+		"1.3.6.1.4.1.311.60.2.1.3=US",
+		"2.5.4.3=localhost",
+	)
+
+	// Requests may replace the RDNs of the role with allowed ones.
+	resp, err = CBWrite(b, s, "issue/testing", map[string]interface{}{
+		"common_name":  "localhost",
+		"subject_rdns": []string{"OU=Ops", "DC=internal"},
+	})
+	requireSuccessNonNilResponse(t, resp, err, "failed issuing leaf cert")
+	requireSubjectRDNs(resp.Data["certificate"].(string),
+		"2.5.4.11=Ops",
+		"0.9.2342.19200300.100.1.25=internal",
+		"2.5.4.3=localhost",
+	)
+
+	_, err = CBWrite(b, s, "issue/testing", map[string]interface{}{
+		"common_name":  "localhost",
+		"subject_rdns": []string{"O=Other"},
+	})
+	require.Error(t, err)
+}
+
+// TestCustomExtensionsInLeafCerts tests that requests may add the custom
+// extensions allowed by their role.
+func TestCustomExtensionsInLeafCerts(t *testing.T) {
+	t.Parallel()
+	b, s := CreateBackendWithStorage(t)
+
+	resp, err := CBWrite(b, s, "root/generate/internal", map[string]interface{}{
+		"common_name": "Vault Root CA",
+		"key_type":    "ec",
+		"ttl":         "7200h",
+	})
+	requireSuccessNonNilResponse(t, resp, err, "failed generating root issuer")
+
+	// Extensions Vault sets itself cannot be allowed.
+	for _, oid := range []string{"2.5.29.17", "1.3.6.1.5.5.7.1.1", "1.3.6.1.4.1.11129.2.4.2", "not-an-oid"} {
+		_, err = CBWrite(b, s, "roles/testing", map[string]interface{}{
+			"allow_any_name":     true,
+			"allowed_extensions": oid,
+		})
+		require.Error(t, err, "oid %v", oid)
+	}
+
+	resp, err = CBWrite(b, s, "roles/testing", map[string]interface{}{
+		"allow_any_name":     true,
+		"key_type":           "ec",
+		"allowed_extensions": "1.3.6.1.4.1.41482.1.1,1.3.6.1.4.1.41482.1.2",
+		"policy_identifiers": `[{"oid":"1.3.6.1.4.1.41482.2.1","cps":"https://example.com/cps"}]`,
+	})
+	requireSuccessNonNilResponse(t, resp, err, "failed setting up role")
+	require.Equal(t, []string{"1.3.6.1.4.1.41482.1.1", "1.3.6.1.4.1.41482.1.2"}, resp.Data["allowed_extensions"])
+
+	deviceClass, err := asn1.Marshal("meter")
+	require.NoError(t, err)
+	flags, err := asn1.Marshal(asn1.BitString{Bytes: []byte{0x80}, BitLength: 1})
+	require.NoError(t, err)
+
+	resp, err = CBWrite(b, s, "issue/testing", map[string]interface{}{
+		"common_name": "meter-0001.example.com",
+		"extensions": []string{
+			"1.3.6.1.4.1.41482.1.1;DER:" + base64.StdEncoding.EncodeToString(deviceClass),
+			"1.3.6.1.4.1.41482.1.2;critical;DER:" + base64.StdEncoding.EncodeToString(flags),
+		},
+	})
+	requireSuccessNonNilResponse(t, resp, err, "failed issuing leaf cert")
+	cert := parseCert(t, resp.Data["certificate"].(string))
+
+	found := map[string]pkix.Extension{}
+	for _, ext := range cert.Extensions {
+		found[ext.Id.String()] = ext
+	}
+	require.Equal(t, deviceClass, found["1.3.6.1.4.1.41482.1.1"].Value)
+	require.False(t, found["1.3.6.1.4.1.41482.1.1"].Critical)
+	require.Equal(t, flags, found["1.3.6.1.4.1.41482.1.2"].Value)
+	require.True(t, found["1.3.6.1.4.1.41482.1.2"].Critical)
+	require.Len(t, cert.PolicyIdentifiers, 1)
+	require.Equal(t, "1.3.6.1.4.1.41482.2.1", cert.PolicyIdentifiers[0].String())
+
+	// Signing accepts extensions as well.
+	_, _, csrPem := generateCSR(t, &x509.CertificateRequest{
+		Subject: pkix.Name{CommonName: "meter-0002.example.com"},
+	}, "ec", 256)
+	resp, err = CBWrite(b, s, "sign/testing", map[string]interface{}{
+		"common_name": "meter-0002.example.com",
+		"csr":         csrPem,
+		"extensions":  []string{"1.3.6.1.4.1.41482.1.1;DER:" + base64.StdEncoding.EncodeToString(deviceClass)},
+	})
+	requireSuccessNonNilResponse(t, resp, err, "failed signing leaf cert")
+	cert = parseCert(t, resp.Data["certificate"].(string))
+	found = map[string]pkix.Extension{}
+	for _, ext := range cert.Extensions {
+		found[ext.Id.String()] = ext
+	}
+	require.Equal(t, deviceClass, found["1.3.6.1.4.1.41482.1.1"].Value)
+
+	for name, extensions := range map[string][]string{
+		"not allowed": {"1.3.6.1.4.1.41482.1.3;DER:" + base64.StdEncoding.EncodeToString(deviceClass)},
+		"reserved":    {"2.5.29.19;critical;DER:MAMBAf8="},
+		"duplicate": {
+			"1.3.6.1.4.1.41482.1.1;DER:" + base64.StdEncoding.EncodeToString(deviceClass),
+			"1.3.6.1.4.1.41482.1.1;DER:" + base64.StdEncoding.EncodeToString(deviceClass),
+		},
+		"bad format": {"1.3.6.1.4.1.41482.1.1;" + base64.StdEncoding.EncodeToString(deviceClass)},
+		"bad base64": {"1.3.6.1.4.1.41482.1.1;DER:!!"},
+		"bad der":    {"1.3.6.1.4.1.41482.1.1;DER:" + base64.StdEncoding.EncodeToString([]byte("meter"))},
+	} {
+		_, err = CBWrite(b, s, "issue/testing", map[string]interface{}{
+			"common_name": "meter-0003.example.com",
+			"extensions":  extensions,
+		})
+		require.Error(t, err, "case %v", name)
+	}
+}
+
 // TestStandby_Operations test proper forwarding for PKI requests from a standby node to the
 // active node within a cluster.
 func TestStandby_Operations(t *testing.T) {
@@ -6761,6 +7520,34 @@ func pathShouldBeUnauthedWriteOnly(t *testing.T, client *api.Client, path string
 	}
 }
 
+func pathShouldBeUnauthedReadWriteOnly(t *testing.T, client *api.Client, path string, token string) {
+	for _, authToken := range []string{"", token} {
+		client.SetToken(authToken)
+		resp, err := client.Logical().ReadWithContext(ctx, path)
+		if err != nil && isPermDenied(err) {
+			t.Fatalf("unexpected failure to read %v (token set: %v): %v / %v", path, authToken != "", err, resp)
+		}
+		resp, err = client.Logical().WriteWithContext(ctx, path, map[string]interface{}{})
+		if err != nil && isPermDenied(err) {
+			t.Fatalf("unexpected failure to write %v (token set: %v): %v / %v", path, authToken != "", err, resp)
+		}
+
+		// These should all be denied.
+		resp, err = client.Logical().ListWithContext(ctx, path)
+		if (err == nil && resp != nil) || (err != nil && !isDeniedOp(err)) {
+			t.Fatalf("unexpected failure during list on read-write-only path %v (token set: %v): %v / %v", path, authToken != "", err, resp)
+		}
+		resp, err = client.Logical().DeleteWithContext(ctx, path)
+		if (err == nil && resp != nil) || (err != nil && !isDeniedOp(err)) {
+			t.Fatalf("unexpected failure during delete on read-write-only path %v (token set: %v): %v / %v", path, authToken != "", err, resp)
+		}
+		resp, err = client.Logical().JSONMergePatch(ctx, path, map[string]interface{}{})
+		if (err == nil && resp != nil) || (err != nil && !isDeniedOp(err)) {
+			t.Fatalf("unexpected failure during patch on read-write-only path %v (token set: %v): %v / %v", path, authToken != "", err, resp)
+		}
+	}
+}
+
 type pathAuthChecker int
 
 const (
@@ -6774,7 +7561,7 @@ var pathAuthChckerMap = map[pathAuthChecker]pathAuthCheckerFunc{
 	shouldBeAuthed:                pathShouldBeAuthed,
 	shouldBeUnauthedReadList:      pathShouldBeUnauthedReadList,
 	shouldBeUnauthedWriteOnly:     pathShouldBeUnauthedWriteOnly,
-	shouldBeUnauthedReadWriteOnly: pathShouldBeUnauthedWriteOnly,
+	shouldBeUnauthedReadWriteOnly: pathShouldBeUnauthedReadWriteOnly,
 }
 
 func TestProperAuthing(t *testing.T) {
@@ -6857,7 +7644,14 @@ func TestProperAuthing(t *testing.T) {
 		"config/auto-tidy":                       shouldBeAuthed,
 		"config/ca":                              shouldBeAuthed,
 		"config/cluster":                         shouldBeAuthed,
+		"config/crypto-policy":                   shouldBeAuthed,
 		"config/crl":                             shouldBeAuthed,
+		"config/ct":                              shouldBeAuthed,
+		"config/issuance-policy":                 shouldBeAuthed,
+		"config/issuance-quotas":                 shouldBeAuthed,
+		"config/est":                             shouldBeAuthed,
+		"config/scep":                            shouldBeAuthed,
+		"scep/challenge":                         shouldBeAuthed,
 		"config/issuers":                         shouldBeAuthed,
 		"config/keys":                            shouldBeAuthed,
 		"config/urls":                            shouldBeAuthed,
@@ -6974,6 +7768,18 @@ func TestProperAuthing(t *testing.T) {
 		paths[acmePrefix+"new-eab"] = shouldBeAuthed
 	}
 
+	// Add EST based paths to the test suite
+	for _, estPrefix := range []string{"est/", "est/test-label/", "roles/test/est/"} {
+		paths[estPrefix+"cacerts"] = shouldBeUnauthedReadList
+		paths[estPrefix+"simpleenroll"] = shouldBeUnauthedWriteOnly
+		paths[estPrefix+"simplereenroll"] = shouldBeUnauthedWriteOnly
+	}
+
+	// Add SCEP based paths to the test suite
+	for _, scepPath := range []string{"scep", "scep/pkiclient.exe", "roles/test/scep", "roles/test/scep/pkiclient.exe"} {
+		paths[scepPath] = shouldBeUnauthedReadWriteOnly
+	}
+
 	for path, checkerType := range paths {
 		checker := pathAuthChckerMap[checkerType]
 		checker(t, client, "pki/"+path, token)
@@ -7032,6 +7838,9 @@ func TestProperAuthing(t *testing.T) {
 		if strings.Contains(raw_path, "eab") && strings.Contains(raw_path, "{key_id}") {
 			raw_path = strings.ReplaceAll(raw_path, "{key_id}", eabKid)
 		}
+		if strings.Contains(raw_path, "est/") && strings.Contains(raw_path, "{label}") {
+			raw_path = strings.ReplaceAll(raw_path, "{label}", "test-label")
+		}
 		if strings.Contains(raw_path, "external-policy/") && strings.Contains(raw_path, "{policy}") {
 			raw_path = strings.ReplaceAll(raw_path, "{policy}", "a-policy")
 		}
@@ -7233,3 +8042,278 @@ var (
 	edCAKey   string
 	edCACert  string
 )
+
+func TestPKI_NameConstraints(t *testing.T) {
+	t.Parallel()
+	b, s := CreateBackendWithStorage(t)
+
+	_, err := CBWrite(b, s, "root/generate/internal", map[string]interface{}{
+		"common_name":         "Root X1",
+		"key_type":            "ec",
+		"ttl":                 "72h",
+		"permitted_ip_ranges": "not-a-cidr",
+	})
+	require.ErrorContains(t, err, "permitted_ip_ranges")
+
+	resp, err := CBWrite(b, s, "root/generate/internal", map[string]interface{}{
+		"common_name":          "Root X1",
+		"key_type":             "ec",
+		"ttl":                  "72h",
+		"excluded_dns_domains": "forbidden.example.com",
+	})
+	require.NoError(t, err)
+	rootCert := parseCert(t, resp.Data["certificate"].(string))
+	require.Equal(t, []string{"forbidden.example.com"}, rootCert.ExcludedDNSDomains)
+	require.True(t, rootCert.PermittedDNSDomainsCritical)
+
+	intKey, _, csrPem := generateCSR(t, &x509.CertificateRequest{
+		Subject: pkix.Name{CommonName: "Team Intermediate"},
+	}, "ec", 256)
+	resp, err = CBWrite(b, s, "root/sign-intermediate", map[string]interface{}{
+		"csr":                       csrPem,
+		"common_name":               "Team Intermediate",
+		"ttl":                       "48h",
+		"permitted_dns_domains":     "team.example.com",
+		"excluded_dns_domains":      "legacy.team.example.com",
+		"permitted_ip_ranges":       "10.10.0.0/16",
+		"excluded_ip_ranges":        "10.10.255.0/24",
+		"permitted_email_addresses": "team.example.com",
+		"excluded_email_addresses":  "root@team.example.com",
+		"permitted_uri_domains":     ".team.example.com",
+		"excluded_uri_domains":      "legacy.team.example.com",
+	})
+	require.NoError(t, err)
+	intCert := parseCert(t, resp.Data["certificate"].(string))
+	require.True(t, intCert.PermittedDNSDomainsCritical)
+	require.Equal(t, []string{"team.example.com"}, intCert.PermittedDNSDomains)
+	require.Equal(t, []string{"legacy.team.example.com"}, intCert.ExcludedDNSDomains)
+	require.Len(t, intCert.PermittedIPRanges, 1)
+	require.Equal(t, "10.10.0.0/16", intCert.PermittedIPRanges[0].String())
+	require.Len(t, intCert.ExcludedIPRanges, 1)
+	require.Equal(t, "10.10.255.0/24", intCert.ExcludedIPRanges[0].String())
+	require.Equal(t, []string{"team.example.com"}, intCert.PermittedEmailAddresses)
+	require.Equal(t, []string{"root@team.example.com"}, intCert.ExcludedEmailAddresses)
+	require.Equal(t, []string{".team.example.com"}, intCert.PermittedURIDomains)
+	require.Equal(t, []string{"legacy.team.example.com"}, intCert.ExcludedURIDomains)
+
+	// Clients verifying chains enforce the constraints.
+	roots := x509.NewCertPool()
+	roots.AddCert(rootCert)
+	intermediates := x509.NewCertPool()
+	intermediates.AddCert(intCert)
+
+	verify := func(dnsName string, ip string) error {
+		leafKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		der, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+			SerialNumber: big.NewInt(1),
+			Subject:      pkix.Name{CommonName: dnsName},
+			DNSNames:     []string{dnsName},
+			IPAddresses:  []net.IP{net.ParseIP(ip)},
+			NotBefore:    time.Now().Add(-time.Minute),
+			NotAfter:     time.Now().Add(time.Hour),
+			ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		}, intCert, leafKey.Public(), intKey)
+		require.NoError(t, err)
+		leaf, err := x509.ParseCertificate(der)
+		require.NoError(t, err)
+		_, err = leaf.Verify(x509.VerifyOptions{Roots: roots, Intermediates: intermediates})
+		return err
+	}
+	require.NoError(t, verify("www.team.example.com", "10.10.1.1"))
+	require.Error(t, verify("www.example.com", "10.10.1.1"))
+	require.Error(t, verify("legacy.team.example.com", "10.10.1.1"))
+	require.Error(t, verify("www.team.example.com", "10.10.255.1"))
+}
+
+func TestPKI_CertificateEvents(t *testing.T) {
+	t.Parallel()
+
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+	events := logical.NewMockEventSender()
+	config.EventsSender = events
+	b := Backend(config)
+	require.NoError(t, b.Setup(context.Background(), config))
+	b.pkiStorageVersion.Store(1)
+	s := config.StorageView
+
+	resp, err := CBWrite(b, s, "root/generate/internal", map[string]interface{}{
+		"common_name": "Root X1",
+		"key_type":    "ec",
+		"ttl":         "720h",
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	issuerId := resp.Data["issuer_id"].(issuing.IssuerID).String()
+	_, err = CBWrite(b, s, "roles/web", map[string]interface{}{
+		"allow_any_name": true,
+		"key_type":       "ec",
+	})
+	require.NoError(t, err)
+
+	eventsOfType := func(eventType string) []map[string]interface{} {
+		events.Lock()
+		defer events.Unlock()
+		var metadata []map[string]interface{}
+		for _, event := range events.Events {
+			if string(event.Type) == eventType {
+				metadata = append(metadata, event.Event.Metadata.AsMap())
+			}
+		}
+		return metadata
+	}
+
+	resp, err = CBWrite(b, s, "issue/web", map[string]interface{}{
+		"common_name": "www.example.com",
+		"ttl":         "2s",
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	issued := parseCert(t, resp.Data["certificate"].(string))
+	issueEvents := eventsOfType("pki/issue")
+	require.Len(t, issueEvents, 1)
+	require.Equal(t, map[string]interface{}{
+		"modified":      "true",
+		"operation":     "issue",
+		"serial_number": serialFromCert(issued),
+		"common_name":   "www.example.com",
+		"not_after":     issued.NotAfter.UTC().Format(time.RFC3339),
+		"path":          "issue/web",
+		"role":          "web",
+		"issuer_id":     issuerId,
+	}, issueEvents[0])
+
+	_, _, csrPem := generateCSR(t, &x509.CertificateRequest{
+		Subject: pkix.Name{CommonName: "api.example.com"},
+	}, "ec", 256)
+	resp, err = CBWrite(b, s, "sign/web", map[string]interface{}{
+		"common_name": "api.example.com",
+		"csr":         csrPem,
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	signEvents := eventsOfType("pki/sign")
+	require.Len(t, signEvents, 1)
+	require.Equal(t, "api.example.com", signEvents[0]["common_name"])
+	require.Equal(t, resp.Data["serial_number"], signEvents[0]["serial_number"])
+
+	// Enrollment protocols share the same issuance path.
+	sc := b.makeStorageContext(context.Background(), s)
+	policy, err := resolveEnrollmentPolicy(sc, "role:web")
+	require.NoError(t, err)
+	_, csrDer, _ := generateCSR(t, &x509.CertificateRequest{
+		Subject: pkix.Name{CommonName: "printer.example.com"},
+	}, "ec", 256)
+	csr, err := x509.ParseCertificateRequest(csrDer)
+	require.NoError(t, err)
+	enrolled, err := b.issueEnrollmentCert(sc, &logical.Request{Path: "est/simpleenroll", Storage: s}, policy, csr)
+	require.NoError(t, err)
+	signEvents = eventsOfType("pki/sign")
+	require.Len(t, signEvents, 2)
+	require.Equal(t, serialFromCert(enrolled.Certificate), signEvents[1]["serial_number"])
+	require.Equal(t, "est/simpleenroll", signEvents[1]["path"])
+	require.Equal(t, "web", signEvents[1]["role"])
+	require.Equal(t, issuerId, signEvents[1]["issuer_id"])
+
+	_, err = CBWrite(b, s, "revoke", map[string]interface{}{
+		"serial_number": resp.Data["serial_number"],
+	})
+	require.NoError(t, err)
+	revokeEvents := eventsOfType("pki/revoke")
+	require.Len(t, revokeEvents, 1)
+	require.Equal(t, resp.Data["serial_number"], revokeEvents[0]["serial_number"])
+	require.Equal(t, issuerId, revokeEvents[0]["issuer_id"])
+
+	// Tidying the expired certificate reports its removal.
+	time.Sleep(4 * time.Second)
+	_, err = CBWrite(b, s, "tidy", map[string]interface{}{
+		"tidy_cert_store": true,
+		"safety_buffer":   "1s",
+	})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		return len(eventsOfType("pki/tidy")) > 0
+	}, 10*time.Second, 100*time.Millisecond)
+	require.Equal(t, serialFromCert(issued), eventsOfType("pki/tidy")[0]["serial_number"])
+}
+
+// TestPKI_Metrics tests that issuance, revocation, CRL builds and issuer
+// expiry are published as metrics.
+//
+// This test is not parallelizable, as it replaces the global metrics sink.
+func TestPKI_Metrics(t *testing.T) {
+	inmemSink := metrics.NewInmemSink(1000000*time.Hour, 2000000*time.Hour)
+	metricsConf := metrics.DefaultConfig("")
+	metricsConf.EnableHostname = false
+	metricsConf.EnableHostnameLabel = false
+	metricsConf.EnableServiceLabel = false
+	metricsConf.EnableTypePrefix = false
+	_, err := metrics.NewGlobal(metricsConf, inmemSink)
+	require.NoError(t, err)
+
+	b, s := CreateBackendWithStorage(t)
+	prefix := "secrets.pki." + b.backendUUID + "."
+
+	resp, err := CBWrite(b, s, "root/generate/internal", map[string]interface{}{
+		"common_name": "Root X1",
+		"key_type":    "ec",
+		"issuer_name": "root",
+		"ttl":         "24h",
+	})
+	requireSuccessNonNilResponse(t, resp, err, "failed generating root issuer")
+	_, err = CBWrite(b, s, "roles/web", map[string]interface{}{
+		"allow_any_name": true,
+		"key_type":       "ec",
+	})
+	require.NoError(t, err)
+
+	resp, err = CBWrite(b, s, "issue/web", map[string]interface{}{"common_name": "www.example.com", "ttl": "1h"})
+	requireSuccessNonNilResponse(t, resp, err, "failed issuing certificate")
+	_, err = CBWrite(b, s, "revoke", map[string]interface{}{"serial_number": resp.Data["serial_number"]})
+	require.NoError(t, err)
+
+	sc := b.makeStorageContext(ctx, s)
+	require.NoError(t, b.emitIssuerExpiryMetrics(sc))
+
+	data := inmemSink.Data()
+	interval := data[len(data)-1]
+	interval.RLock()
+	defer interval.RUnlock()
+
+	requireLabel := func(labels []metrics.Label, name string, value string) {
+		t.Helper()
+		require.Contains(t, labels, metrics.Label{Name: name, Value: value})
+	}
+
+	var issued, revoked, crlBuilt, crlSize, expiry bool
+	for _, counter := range interval.Counters {
+		switch counter.Name {
+		case prefix + "issued_certificates":
+			requireLabel(counter.Labels, "role", "web")
+			issued = true
+		case prefix + "revoked_certificates":
+			requireLabel(counter.Labels, "role", "web")
+			revoked = true
+		}
+	}
+	for _, sample := range interval.Samples {
+		if sample.Name == prefix+"crl.build_time" {
+			crlBuilt = true
+		}
+	}
+	for _, gauge := range interval.Gauges {
+		switch gauge.Name {
+		case prefix + "crl.size_bytes":
+			require.Greater(t, gauge.Value, float32(0))
+			crlSize = true
+		case prefix + "issuer.days_until_expiry":
+			requireLabel(gauge.Labels, "issuer_name", "root")
+			require.InDelta(t, 1, gauge.Value, 0.1)
+			expiry = true
+		}
+	}
+
+	require.True(t, issued, "missing issued certificates counter")
+	require.True(t, revoked, "missing revoked certificates counter")
+	require.True(t, crlBuilt, "missing CRL build time sample")
+	require.True(t, crlSize, "missing CRL size gauge")
+	require.True(t, expiry, "missing issuer expiry gauge")
+}
@@ -42,12 +42,25 @@ func getGenerationParams(sc *storageContext, data *framework.FieldData) (exporte
 		return
 	}
 
+	if exported && format == "pem_bundle" && data.Get("private_key_passphrase").(string) != "" {
+		errorResp = logical.ErrorResponse(
+			`the "private_key_passphrase" parameter is not supported with the "pem_bundle" format`)
+		return
+	}
+
 	keyType, keyBits, err := sc.getKeyTypeAndBitsForRole(data)
 	if err != nil {
 		errorResp = logical.ErrorResponse(err.Error())
 		return
 	}
 
+	cryptoPolicy, err := sc.getCryptoPolicyConfig()
+	if err != nil {
+		errorResp = logical.ErrorResponse("unable to fetch crypto policy: " + err.Error())
+		return
+	}
+	keyBits = cryptoPolicy.DefaultKeyBits(keyType, keyBits)
+
 	role = &issuing.RoleEntry{
 		TTL:                       time.Duration(data.Get("ttl").(int)) * time.Second,
 		KeyType:                   keyType,
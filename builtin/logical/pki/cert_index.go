@@ -0,0 +1,187 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package pki
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/builtin/logical/pki/issuing"
+	"github.com/hashicorp/vault/builtin/logical/pki/parsing"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/errutil"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/ryanuber/go-glob"
+)
+
+// pathCertIndex holds, keyed by the storage serial of the certificate, the
+// fields certificates can be searched by. These are written alongside the
+// certificates stored under issuing.PathCerts, so that listing certificates
+// with filters doesn't require parsing every one of them.
+const pathCertIndex = "cert-index/"
+
+type certIndexEntry struct {
+	CommonName     string           `json:"common_name"`
+	DNSNames       []string         `json:"dns_names,omitempty"`
+	IPAddresses    []string         `json:"ip_addresses,omitempty"`
+	EmailAddresses []string         `json:"email_addresses,omitempty"`
+	URIs           []string         `json:"uris,omitempty"`
+	Role           string           `json:"role,omitempty"`
+	IssuerID       issuing.IssuerID `json:"issuer_id,omitempty"`
+	EntityID       string           `json:"entity_id,omitempty"`
+	NotAfter       time.Time        `json:"not_after"`
+}
+
+func newCertIndexEntry(cert *x509.Certificate) *certIndexEntry {
+	entry := &certIndexEntry{
+		CommonName:     cert.Subject.CommonName,
+		DNSNames:       cert.DNSNames,
+		EmailAddresses: cert.EmailAddresses,
+		NotAfter:       cert.NotAfter,
+	}
+	for _, ip := range cert.IPAddresses {
+		entry.IPAddresses = append(entry.IPAddresses, ip.String())
+	}
+	for _, uri := range cert.URIs {
+		entry.URIs = append(entry.URIs, uri.String())
+	}
+	return entry
+}
+
+// storeCertIndex indexes a certificate stored by issuing.StoreCertificate
+// along with the role, issuer and entity it was issued for.
+func storeCertIndex(ctx context.Context, s logical.Storage, cert *x509.Certificate, role string, issuerId issuing.IssuerID, entityId string) error {
+	entry := newCertIndexEntry(cert)
+	entry.Role = role
+	entry.IssuerID = issuerId
+	entry.EntityID = entityId
+
+	serial := parsing.NormalizeSerialForStorageFromBigInt(cert.SerialNumber)
+	json, err := logical.StorageEntryJSON(pathCertIndex+serial, entry)
+	if err != nil {
+		return fmt.Errorf("failed creating certificate index entry: %w", err)
+	}
+	if err := s.Put(ctx, json); err != nil {
+		return fmt.Errorf("unable to store certificate index entry: %w", err)
+	}
+	return nil
+}
+
+// fetchCertIndex returns the index entry of the stored certificate. Entries
+// of certificates stored before indexing was introduced, or by paths which
+// don't index, are built from the certificate itself, and so lack the role,
+// issuer and entity.
+func fetchCertIndex(ctx context.Context, s logical.Storage, serial string) (*certIndexEntry, error) {
+	indexEntry, err := s.Get(ctx, pathCertIndex+serial)
+	if err != nil {
+		return nil, err
+	}
+	if indexEntry != nil {
+		var entry certIndexEntry
+		if err := indexEntry.DecodeJSON(&entry); err != nil {
+			return nil, errutil.InternalError{Err: fmt.Sprintf("unable to decode index entry of certificate %v: %v", serial, err)}
+		}
+		return &entry, nil
+	}
+
+	certEntry, err := s.Get(ctx, issuing.PathCerts+serial)
+	if err != nil {
+		return nil, err
+	}
+	if certEntry == nil || len(certEntry.Value) == 0 {
+		return nil, nil
+	}
+	cert, err := x509.ParseCertificate(certEntry.Value)
+	if err != nil {
+		return nil, errutil.InternalError{Err: fmt.Sprintf("unable to parse stored certificate %v: %v", serial, err)}
+	}
+	return newCertIndexEntry(cert), nil
+}
+
+// certListFilter holds the filters of a certificate LIST request; empty
+// filters match every certificate.
+type certListFilter struct {
+	commonName    string
+	san           string
+	role          string
+	issuerId      string
+	entityId      string
+	expiresBefore time.Time
+	expiresAfter  time.Time
+}
+
+func newCertListFilter(data *framework.FieldData) (*certListFilter, bool) {
+	filter := &certListFilter{
+		commonName: strings.ToLower(data.Get("common_name").(string)),
+		san:        strings.ToLower(data.Get("san").(string)),
+		role:       data.Get("role").(string),
+		issuerId:   data.Get("issuer_id").(string),
+		entityId:   data.Get("entity_id").(string),
+	}
+	if expiresBefore, ok := data.GetOk("expires_before"); ok {
+		filter.expiresBefore = expiresBefore.(time.Time)
+	}
+	if expiresAfter, ok := data.GetOk("expires_after"); ok {
+		filter.expiresAfter = expiresAfter.(time.Time)
+	}
+
+	present := filter.commonName != "" || filter.san != "" || filter.role != "" ||
+		filter.issuerId != "" || filter.entityId != "" ||
+		!filter.expiresBefore.IsZero() || !filter.expiresAfter.IsZero()
+	return filter, present
+}
+
+func (f *certListFilter) matches(entry *certIndexEntry) bool {
+	if f.commonName != "" && !glob.Glob(f.commonName, strings.ToLower(entry.CommonName)) {
+		return false
+	}
+	if f.san != "" && !f.matchesSAN(entry) {
+		return false
+	}
+	if f.role != "" && f.role != entry.Role {
+		return false
+	}
+	if f.issuerId != "" && f.issuerId != string(entry.IssuerID) {
+		return false
+	}
+	if f.entityId != "" && f.entityId != entry.EntityID {
+		return false
+	}
+	if !f.expiresBefore.IsZero() && !entry.NotAfter.Before(f.expiresBefore) {
+		return false
+	}
+	if !f.expiresAfter.IsZero() && !entry.NotAfter.After(f.expiresAfter) {
+		return false
+	}
+	return true
+}
+
+func (f *certListFilter) matchesSAN(entry *certIndexEntry) bool {
+	for _, sans := range [][]string{entry.DNSNames, entry.IPAddresses, entry.EmailAddresses, entry.URIs} {
+		for _, san := range sans {
+			if glob.Glob(f.san, strings.ToLower(san)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (e *certIndexEntry) toResponseData() map[string]interface{} {
+	sans := []string{}
+	for _, names := range [][]string{e.DNSNames, e.IPAddresses, e.EmailAddresses, e.URIs} {
+		sans = append(sans, names...)
+	}
+	return map[string]interface{}{
+		"common_name": e.CommonName,
+		"sans":        sans,
+		"role":        e.Role,
+		"issuer_id":   string(e.IssuerID),
+		"entity_id":   e.EntityID,
+		"expiration":  e.NotAfter.Unix(),
+	}
+}
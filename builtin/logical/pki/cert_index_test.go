@@ -0,0 +1,114 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package pki
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/builtin/logical/pki/issuing"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListCertsWithFilters(t *testing.T) {
+	t.Parallel()
+	b, s := CreateBackendWithStorage(t)
+
+	resp, err := CBWrite(b, s, "root/generate/internal", map[string]interface{}{
+		"common_name": "Root X1",
+		"key_type":    "ec",
+		"ttl":         "720h",
+	})
+	require.NoError(t, err)
+	rootSerial := resp.Data["serial_number"].(string)
+	issuerId := string(resp.Data["issuer_id"].(issuing.IssuerID))
+
+	for _, role := range []string{"web", "mail"} {
+		_, err = CBWrite(b, s, "roles/"+role, map[string]interface{}{
+			"allow_any_name": true,
+			"key_type":       "ec",
+		})
+		require.NoError(t, err)
+	}
+
+	issue := func(role string, entityId string, data map[string]interface{}) string {
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Operation:  logical.UpdateOperation,
+			Path:       "issue/" + role,
+			Data:       data,
+			Storage:    s,
+			MountPoint: "pki/",
+			EntityID:   entityId,
+		})
+		require.NoError(t, err)
+		require.False(t, resp.IsError(), "issuance failed: %v", resp.Error())
+		return resp.Data["serial_number"].(string)
+	}
+
+	www := issue("web", "entity-a", map[string]interface{}{
+		"common_name": "www.example.com",
+		"alt_names":   "example.com",
+		"ttl":         "1h",
+	})
+	api := issue("web", "entity-b", map[string]interface{}{
+		"common_name": "api.example.com",
+		"ip_sans":     "192.0.2.10",
+		"ttl":         "30h",
+	})
+	mail := issue("mail", "entity-a", map[string]interface{}{
+		"common_name": "mail.example.org",
+		"ttl":         "2h",
+	})
+
+	list := func(filters map[string]interface{}) *logical.Response {
+		resp, err := CBReq(b, s, logical.ListOperation, "certs/", filters)
+		require.NoError(t, err)
+		return resp
+	}
+
+	// Without filters, all certificates are listed without key_info.
+	resp = list(map[string]interface{}{})
+	require.ElementsMatch(t, []string{rootSerial, www, api, mail}, resp.Data["keys"])
+	require.NotContains(t, resp.Data, "key_info")
+
+	for name, tc := range map[string]struct {
+		filters  map[string]interface{}
+		expected []string
+	}{
+		"common name":         {map[string]interface{}{"common_name": "WWW.example.com"}, []string{www}},
+		"common name glob":    {map[string]interface{}{"common_name": "*.example.com"}, []string{www, api}},
+		"dns san":             {map[string]interface{}{"san": "example.com"}, []string{www}},
+		"ip san":              {map[string]interface{}{"san": "192.0.2.*"}, []string{api}},
+		"role":                {map[string]interface{}{"role": "web"}, []string{www, api}},
+		"entity":              {map[string]interface{}{"entity_id": "entity-a"}, []string{www, mail}},
+		"issuer":              {map[string]interface{}{"issuer_id": issuerId}, []string{www, api, mail}},
+		"expires before":      {map[string]interface{}{"expires_before": time.Now().Add(12 * time.Hour).Format(time.RFC3339)}, []string{www, mail}},
+		"expires after":       {map[string]interface{}{"expires_after": time.Now().Add(12 * time.Hour).Format(time.RFC3339)}, []string{rootSerial, api}},
+		"combined":            {map[string]interface{}{"role": "web", "entity_id": "entity-a"}, []string{www}},
+		"unindexed root":      {map[string]interface{}{"common_name": "Root X1"}, []string{rootSerial}},
+		"no match":            {map[string]interface{}{"common_name": "unknown.example.com"}, []string{}},
+		"role and expiration": {map[string]interface{}{"role": "mail", "expires_after": time.Now().Format(time.RFC3339)}, []string{mail}},
+	} {
+		resp := list(tc.filters)
+		require.ElementsMatch(t, tc.expected, resp.Data["keys"], "filter %v", name)
+		keyInfo, _ := resp.Data["key_info"].(map[string]interface{})
+		require.Len(t, keyInfo, len(tc.expected), "filter %v", name)
+	}
+
+	resp = list(map[string]interface{}{"common_name": "api.example.com"})
+	info := resp.Data["key_info"].(map[string]interface{})[api].(map[string]interface{})
+	require.Equal(t, "api.example.com", info["common_name"])
+	require.Equal(t, []string{"api.example.com", "192.0.2.10"}, info["sans"])
+	require.Equal(t, "web", info["role"])
+	require.Equal(t, issuerId, info["issuer_id"])
+	require.Equal(t, "entity-b", info["entity_id"])
+
+	// Only issued certificates are indexed, the root falling back to its
+	// stored certificate.
+	entries, err := s.List(context.Background(), pathCertIndex)
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+}
@@ -5,6 +5,7 @@ package pki
 
 import (
 	"crypto"
+	"crypto/rand"
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"encoding/asn1"
@@ -23,6 +24,7 @@ import (
 	"github.com/hashicorp/vault/builtin/logical/pki/issuing"
 	"github.com/hashicorp/vault/builtin/logical/pki/parsing"
 	"github.com/hashicorp/vault/builtin/logical/pki/pki_backend"
+	"github.com/hashicorp/vault/helper/pkcs12"
 	"github.com/hashicorp/vault/sdk/framework"
 	"github.com/hashicorp/vault/sdk/helper/certutil"
 	"github.com/hashicorp/vault/sdk/helper/errutil"
@@ -35,6 +37,10 @@ type inputBundle struct {
 	role    *issuing.RoleEntry
 	req     *logical.Request
 	apiData *framework.FieldData
+
+	// issuerId is the issuer signing the certificate, reported in the
+	// events of issued leaf certificates.
+	issuerId issuing.IssuerID
 }
 
 var (
@@ -129,6 +135,15 @@ func getFormat(data *framework.FieldData) string {
 	return format
 }
 
+// getIssueFormat is getFormat for the issue paths, whose responses include
+// the generated private key and so can also be a PKCS#12 archive.
+func getIssueFormat(data *framework.FieldData) string {
+	if data.Get("format").(string) == "pkcs12" {
+		return "pkcs12"
+	}
+	return getFormat(data)
+}
+
 // fetchCAInfo will fetch the CA info, will return an error if no ca info exists, this does NOT support
 // loading using the legacyBundleShimID and should be used with care. This should be called only once
 // within the request path otherwise you run the risk of a race condition with the issuer migration on perf-secondaries.
@@ -203,8 +218,21 @@ func fetchCertBySerial(sc pki_backend.StorageContext, prefix, serial string) (*l
 			sc.Logger().Warn(msg)
 		}
 
+		// The default CRL paths may be served by an issuer other than the
+		// default issuer.
+		reference := issuing.DefaultRef
+		if !sc.UseLegacyBundleCaStorage() {
+			config, err := issuing.GetIssuersConfig(sc.GetContext(), sc.GetStorage())
+			if err != nil {
+				return nil, err
+			}
+			if len(config.DefaultCRLIssuerId) > 0 {
+				reference = config.DefaultCRLIssuerId.String()
+			}
+		}
+
 		unified := serial == issuing.UnifiedCRLPath || serial == issuing.UnifiedDeltaCRLPath
-		path, err = issuing.ResolveIssuerCRLPath(sc.GetContext(), sc.GetStorage(), sc.UseLegacyBundleCaStorage(), issuing.DefaultRef, unified)
+		path, err = issuing.ResolveIssuerCRLPath(sc.GetContext(), sc.GetStorage(), sc.UseLegacyBundleCaStorage(), reference, unified)
 		if err != nil {
 			return nil, err
 		}
@@ -337,6 +365,10 @@ func generateCert(sc *storageContext,
 		return nil, nil, errutil.UserError{Err: "RSA keys < 2048 bits are unsafe and not supported"}
 	}
 
+	if err := sc.checkCryptoPolicyForInput(input); err != nil {
+		return nil, nil, err
+	}
+
 	data, warnings, err := generateCreationBundle(sc.System(), input, caSign, nil)
 	if err != nil {
 		return nil, nil, err
@@ -348,6 +380,19 @@ func generateCert(sc *storageContext,
 	if isCA {
 		data.Params.IsCA = isCA
 		data.Params.PermittedDNSDomains = input.apiData.Get("permitted_dns_domains").([]string)
+		data.Params.ExcludedDNSDomains = input.apiData.Get("excluded_dns_domains").([]string)
+		data.Params.PermittedIPRanges, err = parseIpRanges(input.apiData, "permitted_ip_ranges")
+		if err != nil {
+			return nil, nil, err
+		}
+		data.Params.ExcludedIPRanges, err = parseIpRanges(input.apiData, "excluded_ip_ranges")
+		if err != nil {
+			return nil, nil, err
+		}
+		data.Params.PermittedEmailAddresses = input.apiData.Get("permitted_email_addresses").([]string)
+		data.Params.ExcludedEmailAddresses = input.apiData.Get("excluded_email_addresses").([]string)
+		data.Params.PermittedURIDomains = input.apiData.Get("permitted_uri_domains").([]string)
+		data.Params.ExcludedURIDomains = input.apiData.Get("excluded_uri_domains").([]string)
 
 		if data.SigningBundle == nil {
 			// Generating a self-signed root certificate. Since we have no
@@ -391,17 +436,49 @@ func generateCert(sc *storageContext,
 		}
 	}
 
+	var reservation *issuanceReservation
+	if !isCA {
+		if err := applyIssuancePolicy(sc, input, "issue", data); err != nil {
+			return nil, nil, err
+		}
+		reservation, err = sc.Backend.reserveIssuance(sc, input, data.Params.Subject.CommonName)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
 	parsedBundle, err := generateCABundle(sc, input, data, randomSource)
 	if err != nil {
+		reservation.release()
+		return nil, nil, err
+	}
+
+	if !isCA && input.role.CTSubmission {
+		parsedBundle, err = embedSignedCertificateTimestamps(sc, caSign, parsedBundle)
+		if err != nil {
+			reservation.release()
+			return nil, nil, err
+		}
+	}
+
+	if err := reservation.commit(sc, parsedBundle.Certificate); err != nil {
 		return nil, nil, err
 	}
 
+	if !isCA {
+		sc.Backend.issuedCertEvent(ctx, "issue", input, parsedBundle.Certificate)
+	}
+
 	return parsedBundle, warnings, nil
 }
 
 // N.B.: This is only meant to be used for generating intermediate CAs.
 // It skips some sanity checks.
 func generateIntermediateCSR(sc *storageContext, input *inputBundle, randomSource io.Reader) (*certutil.ParsedCSRBundle, []string, error) {
+	if err := sc.checkCryptoPolicyForInput(input); err != nil {
+		return nil, nil, err
+	}
+
 	creation, warnings, err := generateCreationBundle(sc.System(), input, nil, nil)
 	if err != nil {
 		return nil, nil, err
@@ -415,6 +492,14 @@ func generateIntermediateCSR(sc *storageContext, input *inputBundle, randomSourc
 		creation.Params.KeyUsage = 0
 	}
 	addBasicConstraints := input.apiData != nil && input.apiData.Get("add_basic_constraints").(bool)
+
+	// A requested path length constraint is carried in the CSR's Basic
+	// Constraints extension, for the signing issuer to honor.
+	if maxPathLengthIface, ok := input.apiData.GetOk("max_path_length"); ok {
+		creation.Params.IsCA = true
+		creation.Params.MaxPathLength = maxPathLengthIface.(int)
+		addBasicConstraints = true
+	}
 	parsedBundle, err := generateCSRBundle(sc, input, creation, addBasicConstraints, randomSource)
 	if err != nil {
 		return nil, nil, err
@@ -472,11 +557,52 @@ func (i SignCertInputFromDataFields) GetPermittedDomains() []string {
 	return i.data.Get("permitted_dns_domains").([]string)
 }
 
+func (i SignCertInputFromDataFields) GetExcludedDomains() []string {
+	return i.data.Get("excluded_dns_domains").([]string)
+}
+
+func (i SignCertInputFromDataFields) GetPermittedIpRanges() ([]*net.IPNet, error) {
+	return parseIpRanges(i.data, "permitted_ip_ranges")
+}
+
+func (i SignCertInputFromDataFields) GetExcludedIpRanges() ([]*net.IPNet, error) {
+	return parseIpRanges(i.data, "excluded_ip_ranges")
+}
+
+func (i SignCertInputFromDataFields) GetPermittedEmailAddresses() []string {
+	return i.data.Get("permitted_email_addresses").([]string)
+}
+
+func (i SignCertInputFromDataFields) GetExcludedEmailAddresses() []string {
+	return i.data.Get("excluded_email_addresses").([]string)
+}
+
+func (i SignCertInputFromDataFields) GetPermittedUriDomains() []string {
+	return i.data.Get("permitted_uri_domains").([]string)
+}
+
+func (i SignCertInputFromDataFields) GetExcludedUriDomains() []string {
+	return i.data.Get("excluded_uri_domains").([]string)
+}
+
+// parseIpRanges parses the CIDRs of the given name constraint field.
+func parseIpRanges(data *framework.FieldData, field string) ([]*net.IPNet, error) {
+	var ipRanges []*net.IPNet
+	for _, cidr := range data.Get(field).([]string) {
+		_, ipRange, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, errutil.UserError{Err: fmt.Sprintf("invalid CIDR %q in %v: %v", cidr, field, err)}
+		}
+		ipRanges = append(ipRanges, ipRange)
+	}
+	return ipRanges, nil
+}
+
 func (i SignCertInputFromDataFields) IgnoreCSRSignature() bool {
 	return false
 }
 
-func signCert(sysView logical.SystemView, data *inputBundle, caSign *certutil.CAInfoBundle, isCA bool, useCSRValues bool) (*certutil.ParsedCertBundle, []string, error) {
+func signCert(sc *storageContext, data *inputBundle, caSign *certutil.CAInfoBundle, isCA bool, useCSRValues bool) (*certutil.ParsedCertBundle, []string, error) {
 	if data.role == nil {
 		return nil, nil, errutil.InternalError{Err: "no role found in data bundle"}
 	}
@@ -484,7 +610,53 @@ func signCert(sysView logical.SystemView, data *inputBundle, caSign *certutil.CA
 	entityInfo := issuing.NewEntityInfoFromReq(data.req)
 	signCertInput := NewSignCertInputFromDataFields(data.apiData, isCA, useCSRValues)
 
-	return issuing.SignCert(sysView, data.role, entityInfo, caSign, signCertInput)
+	// Parsing errors are left to SignCert, which returns them along with
+	// its other validation errors of the CSR
+	if csr, err := signCertInput.GetCSR(); err == nil && csr.PublicKey != nil {
+		if err := sc.checkCryptoPolicyPublicKey(csr.PublicKey); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	creation, warnings, err := issuing.GenerateSignCreationBundle(sc.System(), data.role, entityInfo, caSign, signCertInput)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var reservation *issuanceReservation
+	if !isCA {
+		if err := applyIssuancePolicy(sc, data, "sign", creation); err != nil {
+			return nil, nil, err
+		}
+		reservation, err = sc.Backend.reserveIssuance(sc, data, creation.Params.Subject.CommonName)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	parsedBundle, err := certutil.SignCertificate(creation)
+	if err != nil {
+		reservation.release()
+		return nil, nil, err
+	}
+
+	if !isCA && data.role.CTSubmission {
+		parsedBundle, err = embedSignedCertificateTimestamps(sc, caSign, parsedBundle)
+		if err != nil {
+			reservation.release()
+			return nil, nil, err
+		}
+	}
+
+	if err := reservation.commit(sc, parsedBundle.Certificate); err != nil {
+		return nil, nil, err
+	}
+
+	if !isCA {
+		sc.Backend.issuedCertEvent(sc.Context, "sign", data, parsedBundle.Certificate)
+	}
+
+	return parsedBundle, warnings, nil
 }
 
 func getOtherSANsFromX509Extensions(exts []pkix.Extension) ([]certutil.OtherNameUtf8, error) {
@@ -551,6 +723,20 @@ func (cb CreationBundleInputFromFieldData) GetUserIds() []string {
 	return cb.data.Get("user_ids").([]string)
 }
 
+func (cb CreationBundleInputFromFieldData) GetSubjectRDNs() []string {
+	if _, present := cb.data.Schema["subject_rdns"]; !present {
+		return nil
+	}
+	return cb.data.Get("subject_rdns").([]string)
+}
+
+func (cb CreationBundleInputFromFieldData) GetExtensions() []string {
+	if _, present := cb.data.Schema["extensions"]; !present {
+		return nil
+	}
+	return cb.data.Get("extensions").([]string)
+}
+
 // generateCreationBundle is a shared function that reads parameters supplied
 // from the various endpoints and generates a CreationParameters with the
 // parameters that can be used to issue or sign
@@ -574,6 +760,68 @@ func applyIssuerLeafNotAfterBehavior(caSign *certutil.CAInfoBundle, notAfter tim
 	return issuing.ApplyIssuerLeafNotAfterBehavior(caSign, notAfter)
 }
 
+// formatRespPrivateKey applies the private_key_format and
+// private_key_passphrase parameters to the private key of the response.
+func formatRespPrivateKey(resp *logical.Response, data *framework.FieldData) error {
+	passphrase := data.Get("private_key_passphrase").(string)
+	if data.Get("private_key_format").(string) == "pkcs8" || passphrase != "" {
+		if err := convertRespToPKCS8(resp); err != nil {
+			return err
+		}
+	}
+
+	if passphrase != "" {
+		return encryptRespPrivateKey(resp, passphrase)
+	}
+
+	return nil
+}
+
+// encryptRespPrivateKey replaces the private key of the response, already
+// converted to PKCS#8, with its encryption under the passphrase.
+func encryptRespPrivateKey(resp *logical.Response, passphrase string) error {
+	privRaw, ok := resp.Data["private_key"]
+	if !ok {
+		return nil
+	}
+	priv, ok := privRaw.(string)
+	if !ok {
+		return fmt.Errorf("error encrypting private key: could not parse original value as string")
+	}
+
+	var keyData []byte
+	var err error
+	block, _ := pem.Decode([]byte(priv))
+	if block == nil {
+		keyData, err = base64.StdEncoding.DecodeString(priv)
+		if err != nil {
+			return fmt.Errorf("error encrypting private key: error decoding original value: %w", err)
+		}
+	} else {
+		keyData = block.Bytes
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(keyData)
+	if err != nil {
+		return fmt.Errorf("error encrypting private key: error parsing pkcs8 key: %w", err)
+	}
+	keyData, err = pkcs12.MarshalEncryptedPKCS8PrivateKey(rand.Reader, key, passphrase)
+	if err != nil {
+		return fmt.Errorf("error encrypting private key: %w", err)
+	}
+
+	if block != nil {
+		resp.Data["private_key"] = strings.TrimSpace(string(pem.EncodeToMemory(&pem.Block{
+			Type:  "ENCRYPTED PRIVATE KEY",
+			Bytes: keyData,
+		})))
+	} else {
+		resp.Data["private_key"] = base64.StdEncoding.EncodeToString(keyData)
+	}
+
+	return nil
+}
+
 func convertRespToPKCS8(resp *logical.Response) error {
 	privRaw, ok := resp.Data["private_key"]
 	if !ok {
@@ -285,6 +285,13 @@ func TestParseCertificate(t *testing.T) {
 		}
 		return u
 	}
+	parseCIDR := func(s string) *net.IPNet {
+		_, ipNet, err := net.ParseCIDR(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ipNet
+	}
 
 	tests := []*parseCertificateTestCase{
 		{
@@ -327,28 +334,35 @@ func TestParseCertificate(t *testing.T) {
 				SKID:                          []byte("We'll assert that it is not nil as an special case"),
 			},
 			wantFields: map[string]interface{}{
-				"common_name":           "the common name",
-				"alt_names":             "",
-				"ip_sans":               "",
-				"uri_sans":              "",
-				"other_sans":            "",
-				"signature_bits":        384,
-				"exclude_cn_from_sans":  true,
-				"ou":                    "",
-				"organization":          "",
-				"country":               "",
-				"locality":              "",
-				"province":              "",
-				"street_address":        "",
-				"postal_code":           "",
-				"serial_number":         "",
-				"ttl":                   "1h0m30s",
-				"max_path_length":       -1,
-				"permitted_dns_domains": "",
-				"use_pss":               false,
-				"key_type":              "ec",
-				"key_bits":              384,
-				"skid":                  "We'll assert that it is not nil as an special case",
+				"common_name":               "the common name",
+				"alt_names":                 "",
+				"ip_sans":                   "",
+				"uri_sans":                  "",
+				"other_sans":                "",
+				"signature_bits":            384,
+				"exclude_cn_from_sans":      true,
+				"ou":                        "",
+				"organization":              "",
+				"country":                   "",
+				"locality":                  "",
+				"province":                  "",
+				"street_address":            "",
+				"postal_code":               "",
+				"serial_number":             "",
+				"ttl":                       "1h0m30s",
+				"max_path_length":           -1,
+				"permitted_dns_domains":     "",
+				"excluded_dns_domains":      "",
+				"permitted_ip_ranges":       "",
+				"excluded_ip_ranges":        "",
+				"permitted_email_addresses": "",
+				"excluded_email_addresses":  "",
+				"permitted_uri_domains":     "",
+				"excluded_uri_domains":      "",
+				"use_pss":                   false,
+				"key_type":                  "ec",
+				"key_bits":                  384,
+				"skid":                      "We'll assert that it is not nil as an special case",
 			},
 			wantErr: false,
 		},
@@ -357,26 +371,33 @@ func TestParseCertificate(t *testing.T) {
 			name: "full CA",
 			data: map[string]interface{}{
 				// using the same order as in https://developer.hashicorp.com/vault/api-docs/secret/pki#sign-certificate
-				"common_name":           "the common name",
-				"alt_names":             "user@example.com,admin@example.com,example.com,www.example.com",
-				"ip_sans":               "1.2.3.4,1.2.3.5",
-				"uri_sans":              "https://example.com,https://www.example.com",
-				"other_sans":            "1.3.6.1.4.1.311.20.2.3;utf8:caadmin@example.com",
-				"ttl":                   "2h",
-				"max_path_length":       2,
-				"permitted_dns_domains": ".example.com,.www.example.com",
-				"ou":                    "unit1, unit2",
-				"organization":          "org1, org2",
-				"country":               "US, CA",
-				"locality":              "locality1, locality2",
-				"province":              "province1, province2",
-				"street_address":        "street_address1, street_address2",
-				"postal_code":           "postal_code1, postal_code2",
-				"not_before_duration":   "45s",
-				"key_type":              "rsa",
-				"use_pss":               true,
-				"key_bits":              2048,
-				"signature_bits":        384,
+				"common_name":               "the common name",
+				"alt_names":                 "user@example.com,admin@example.com,example.com,www.example.com",
+				"ip_sans":                   "1.2.3.4,1.2.3.5",
+				"uri_sans":                  "https://example.com,https://www.example.com",
+				"other_sans":                "1.3.6.1.4.1.311.20.2.3;utf8:caadmin@example.com",
+				"ttl":                       "2h",
+				"max_path_length":           2,
+				"permitted_dns_domains":     ".example.com,.www.example.com",
+				"excluded_dns_domains":      "bad.example.com",
+				"permitted_ip_ranges":       "1.2.3.0/24,2001:db8::/32",
+				"excluded_ip_ranges":        "1.2.3.128/25",
+				"permitted_email_addresses": "example.com",
+				"excluded_email_addresses":  "root@example.com",
+				"permitted_uri_domains":     ".example.com",
+				"excluded_uri_domains":      "bad.example.com",
+				"ou":                        "unit1, unit2",
+				"organization":              "org1, org2",
+				"country":                   "US, CA",
+				"locality":                  "locality1, locality2",
+				"province":                  "province1, province2",
+				"street_address":            "street_address1, street_address2",
+				"postal_code":               "postal_code1, postal_code2",
+				"not_before_duration":       "45s",
+				"key_type":                  "rsa",
+				"use_pss":                   true,
+				"key_bits":                  2048,
+				"signature_bits":            384,
 				// TODO(kitography): Specify key usage
 			},
 			ttl: 2 * time.Hour,
@@ -410,34 +431,48 @@ func TestParseCertificate(t *testing.T) {
 				ForceAppendCaChain:            false,
 				UseCSRValues:                  false,
 				PermittedDNSDomains:           []string{".example.com", ".www.example.com"},
+				ExcludedDNSDomains:            []string{"bad.example.com"},
+				PermittedIPRanges:             []*net.IPNet{parseCIDR("1.2.3.0/24"), parseCIDR("2001:db8::/32")},
+				ExcludedIPRanges:              []*net.IPNet{parseCIDR("1.2.3.128/25")},
+				PermittedEmailAddresses:       []string{"example.com"},
+				ExcludedEmailAddresses:        []string{"root@example.com"},
+				PermittedURIDomains:           []string{".example.com"},
+				ExcludedURIDomains:            []string{"bad.example.com"},
 				URLs:                          nil,
 				MaxPathLength:                 2,
 				NotBeforeDuration:             45 * time.Second,
 				SKID:                          []byte("We'll assert that it is not nil as an special case"),
 			},
 			wantFields: map[string]interface{}{
-				"common_name":           "the common name",
-				"alt_names":             "example.com,www.example.com,admin@example.com,user@example.com",
-				"ip_sans":               "1.2.3.4,1.2.3.5",
-				"uri_sans":              "https://example.com,https://www.example.com",
-				"other_sans":            "1.3.6.1.4.1.311.20.2.3;UTF-8:caadmin@example.com",
-				"signature_bits":        384,
-				"exclude_cn_from_sans":  true,
-				"ou":                    "unit1,unit2",
-				"organization":          "org1,org2",
-				"country":               "CA,US",
-				"locality":              "locality1,locality2",
-				"province":              "province1,province2",
-				"street_address":        "street_address1,street_address2",
-				"postal_code":           "postal_code1,postal_code2",
-				"serial_number":         "",
-				"ttl":                   "2h0m45s",
-				"max_path_length":       2,
-				"permitted_dns_domains": ".example.com,.www.example.com",
-				"use_pss":               true,
-				"key_type":              "rsa",
-				"key_bits":              2048,
-				"skid":                  "We'll assert that it is not nil as an special case",
+				"common_name":               "the common name",
+				"alt_names":                 "example.com,www.example.com,admin@example.com,user@example.com",
+				"ip_sans":                   "1.2.3.4,1.2.3.5",
+				"uri_sans":                  "https://example.com,https://www.example.com",
+				"other_sans":                "1.3.6.1.4.1.311.20.2.3;UTF-8:caadmin@example.com",
+				"signature_bits":            384,
+				"exclude_cn_from_sans":      true,
+				"ou":                        "unit1,unit2",
+				"organization":              "org1,org2",
+				"country":                   "CA,US",
+				"locality":                  "locality1,locality2",
+				"province":                  "province1,province2",
+				"street_address":            "street_address1,street_address2",
+				"postal_code":               "postal_code1,postal_code2",
+				"serial_number":             "",
+				"ttl":                       "2h0m45s",
+				"max_path_length":           2,
+				"permitted_dns_domains":     ".example.com,.www.example.com",
+				"excluded_dns_domains":      "bad.example.com",
+				"permitted_ip_ranges":       "1.2.3.0/24,2001:db8::/32",
+				"excluded_ip_ranges":        "1.2.3.128/25",
+				"permitted_email_addresses": "example.com",
+				"excluded_email_addresses":  "root@example.com",
+				"permitted_uri_domains":     ".example.com",
+				"excluded_uri_domains":      "bad.example.com",
+				"use_pss":                   true,
+				"key_type":                  "rsa",
+				"key_bits":                  2048,
+				"skid":                      "We'll assert that it is not nil as an special case",
 			},
 			wantErr: false,
 		},
@@ -505,28 +540,35 @@ func TestParseCertificate(t *testing.T) {
 				SKID:                          []byte("We'll assert that it is not nil as an special case"),
 			},
 			wantFields: map[string]interface{}{
-				"common_name":           "the common name non ca",
-				"alt_names":             "example.com,www.example.com,admin@example.com,user@example.com",
-				"ip_sans":               "1.2.3.4,1.2.3.5",
-				"uri_sans":              "https://example.com,https://www.example.com",
-				"other_sans":            "1.3.6.1.4.1.311.20.2.3;UTF-8:caadmin@example.com",
-				"signature_bits":        384,
-				"exclude_cn_from_sans":  true,
-				"ou":                    "",
-				"organization":          "",
-				"country":               "",
-				"locality":              "",
-				"province":              "",
-				"street_address":        "",
-				"postal_code":           "",
-				"serial_number":         "",
-				"ttl":                   "2h0m45s",
-				"max_path_length":       0,
-				"permitted_dns_domains": "",
-				"use_pss":               false,
-				"key_type":              "rsa",
-				"key_bits":              2048,
-				"skid":                  "We'll assert that it is not nil as an special case",
+				"common_name":               "the common name non ca",
+				"alt_names":                 "example.com,www.example.com,admin@example.com,user@example.com",
+				"ip_sans":                   "1.2.3.4,1.2.3.5",
+				"uri_sans":                  "https://example.com,https://www.example.com",
+				"other_sans":                "1.3.6.1.4.1.311.20.2.3;UTF-8:caadmin@example.com",
+				"signature_bits":            384,
+				"exclude_cn_from_sans":      true,
+				"ou":                        "",
+				"organization":              "",
+				"country":                   "",
+				"locality":                  "",
+				"province":                  "",
+				"street_address":            "",
+				"postal_code":               "",
+				"serial_number":             "",
+				"ttl":                       "2h0m45s",
+				"max_path_length":           0,
+				"permitted_dns_domains":     "",
+				"excluded_dns_domains":      "",
+				"permitted_ip_ranges":       "",
+				"excluded_ip_ranges":        "",
+				"permitted_email_addresses": "",
+				"excluded_email_addresses":  "",
+				"permitted_uri_domains":     "",
+				"excluded_uri_domains":      "",
+				"use_pss":                   false,
+				"key_type":                  "rsa",
+				"key_bits":                  2048,
+				"skid":                      "We'll assert that it is not nil as an special case",
 			},
 			wantErr: false,
 		},
@@ -55,3 +55,23 @@ func (sc *storageContext) updateDefaultIssuerId(id issuing.IssuerID) error {
 
 	return nil
 }
+
+// defaultChainIssuerRef returns a reference to the issuer served from the
+// default CA and CA chain paths: the configured chain default if one was
+// set, otherwise the default issuer.
+func (sc *storageContext) defaultChainIssuerRef() (string, error) {
+	if sc.UseLegacyBundleCaStorage() {
+		return defaultRef, nil
+	}
+
+	config, err := sc.getIssuersConfig()
+	if err != nil {
+		return "", err
+	}
+
+	if len(config.DefaultChainIssuerId) > 0 {
+		return config.DefaultChainIssuerId.String(), nil
+	}
+
+	return defaultRef, nil
+}
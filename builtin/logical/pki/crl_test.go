@@ -5,6 +5,8 @@ package pki
 
 import (
 	"context"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/asn1"
 	"encoding/json"
 	"fmt"
@@ -1393,7 +1395,8 @@ t3Jfh8QX85JjnGntuXuraYZJMUjpwXr3QHPx0jpvAM3Au5j6qD3biC9Vrwq9Chkg
 hbiiPARizZA/Tsna/9ox1qDT
 -----END PRIVATE KEY-----`
 	resp, err := CBWrite(b, s, "issuers/import/bundle", map[string]interface{}{
-		"pem_bundle": cert + "\n" + privKey,
+		"pem_bundle":       cert + "\n" + privKey,
+		"allow_expired_ca": true,
 	})
 	require.NoError(t, err)
 	require.NotNil(t, resp)
@@ -1422,7 +1425,8 @@ hbiiPARizZA/Tsna/9ox1qDT
 
 	// Adding back just the cert shouldn't cause CRL rebuild warnings.
 	resp, err = CBWrite(b, s, "issuers/import/bundle", map[string]interface{}{
-		"pem_bundle": cert,
+		"pem_bundle":       cert,
+		"allow_expired_ca": true,
 	})
 	require.NoError(t, err)
 	require.NotNil(t, resp)
@@ -1527,3 +1531,105 @@ func TestCRLIssuerRemoval(t *testing.T) {
 	}
 	require.Equal(t, len(afterUnifiedCRLList), len(unifiedCRLList))
 }
+
+func TestCRLDeferredRebuildOnImport(t *testing.T) {
+	t.Parallel()
+
+	b, s := CreateBackendWithStorage(t)
+
+	_, _, rootPem := genImportValidationCA(t, &x509.Certificate{
+		Subject: pkix.Name{CommonName: "Deferred Root"},
+	}, nil, nil)
+
+	resp, err := CBWrite(b, s, "issuers/import/cert", map[string]interface{}{
+		"pem_bundle":        rootPem,
+		"defer_crl_rebuild": true,
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	require.Len(t, resp.Data["imported_issuers"], 1)
+	requireWarningWithPrefix(t, resp, "CRL rebuild was deferred")
+	require.True(t, b.CrlBuilder().forceRebuild.Load(), "expected a rebuild to be scheduled")
+
+	// An explicit rotation performs the deferred rebuild.
+	resp, err = CBRead(b, s, "crl/rotate")
+	requireSuccessNonNilResponse(t, resp, err)
+	require.False(t, b.CrlBuilder().forceRebuild.Load(), "expected the scheduled rebuild to be consumed")
+}
+
+// TestCRLIssuerOverrides makes sure an issuer's crl_expiry and
+// crl_issuing_distribution_point apply to its CRLs only.
+func TestCRLIssuerOverrides(t *testing.T) {
+	t.Parallel()
+
+	b, s := CreateBackendWithStorage(t)
+
+	resp, err := CBWrite(b, s, "root/generate/internal", map[string]interface{}{
+		"common_name": "Offline Root",
+		"issuer_name": "root",
+		"key_type":    "ec",
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	resp, err = CBWrite(b, s, "root/generate/internal", map[string]interface{}{
+		"common_name": "Online Root",
+		"issuer_name": "online",
+		"key_type":    "ec",
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+
+	_, err = CBPatch(b, s, "issuer/root", map[string]interface{}{
+		"crl_expiry": "0",
+	})
+	require.ErrorContains(t, err, "crl_expiry must be positive")
+	_, err = CBPatch(b, s, "issuer/root", map[string]interface{}{
+		"crl_expiry": "bogus",
+	})
+	require.ErrorContains(t, err, "invalid crl_expiry")
+
+	resp, err = CBPatch(b, s, "issuer/root", map[string]interface{}{
+		"crl_expiry":                     "720h",
+		"crl_issuing_distribution_point": true,
+		"crl_distribution_points":        []string{"http://crl.example.com/root.crl"},
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	require.Equal(t, "720h", resp.Data["crl_expiry"])
+	require.Equal(t, true, resp.Data["crl_issuing_distribution_point"])
+
+	_, err = CBRead(b, s, "crl/rotate")
+	require.NoError(t, err)
+
+	crl := getParsedCrlFromBackend(t, b, s, "issuer/root/crl/der")
+	require.Equal(t, 720*time.Hour, crl.TBSCertList.NextUpdate.Sub(crl.TBSCertList.ThisUpdate))
+
+	var idp *pkix.Extension
+	for i, ext := range crl.TBSCertList.Extensions {
+		if ext.Id.Equal(oidExtensionIssuingDistributionPoint) {
+			idp = &crl.TBSCertList.Extensions[i]
+		}
+	}
+	require.NotNil(t, idp, "expected the issuing distribution point extension")
+	require.True(t, idp.Critical)
+
+	var parsedIdp issuingDistributionPoint
+	rest, err := asn1.Unmarshal(idp.Value, &parsedIdp)
+	require.NoError(t, err)
+	require.Empty(t, rest)
+	require.Len(t, parsedIdp.DistributionPoint.FullName, 1)
+	require.Equal(t, "http://crl.example.com/root.crl", string(parsedIdp.DistributionPoint.FullName[0].Bytes))
+
+	// The other issuer keeps the mount's defaults.
+	crl = getParsedCrlFromBackend(t, b, s, "issuer/online/crl/der")
+	require.Equal(t, 72*time.Hour, crl.TBSCertList.NextUpdate.Sub(crl.TBSCertList.ThisUpdate))
+	for _, ext := range crl.TBSCertList.Extensions {
+		require.False(t, ext.Id.Equal(oidExtensionIssuingDistributionPoint))
+	}
+
+	// Clearing the override restores the mount's expiry.
+	resp, err = CBPatch(b, s, "issuer/root", map[string]interface{}{
+		"crl_expiry": "",
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	_, err = CBRead(b, s, "crl/rotate")
+	require.NoError(t, err)
+	crl = getParsedCrlFromBackend(t, b, s, "issuer/root/crl/der")
+	require.Equal(t, 72*time.Hour, crl.TBSCertList.NextUpdate.Sub(crl.TBSCertList.ThisUpdate))
+}
@@ -8,6 +8,7 @@ import (
 	"crypto/rand"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"encoding/asn1"
 	"fmt"
 	"math/big"
 	"strings"
@@ -1024,6 +1025,8 @@ func revokeCert(sc *storageContext, config *pki_backend.CrlConfig, cert *x509.Ce
 		return nil, fmt.Errorf("error saving revoked certificate to new location: %w", err)
 	}
 	certCounter.IncrementTotalRevokedCertificatesCount(certsCounted, revEntry.Key)
+	sc.Backend.certEvent(sc.Context, "revoke", "", cert, "issuer_id", revInfo.CertificateIssuer.String())
+	sc.Backend.emitRevokedMetric(sc, hyphenSerial)
 
 	// From here on out, the certificate has been revoked locally. Any other
 	// persistence issues might still err, but any other failure messages
@@ -1673,13 +1676,13 @@ func buildAnyCRLsWithCerts(
 					continue
 				}
 
-				// Prefer to use the default as the representative of this
+				// Prefer to use the default CRL issuer as the representative of this
 				// set, if it is a member.
 				//
 				// If it is, we'll also pull in the unassigned certs to remain
 				// compatible with Vault's earlier, potentially questionable
 				// behavior.
-				if issuerId == issuersConfig.DefaultIssuerId {
+				if issuerId == issuersConfig.CRLIssuerId() {
 					if len(unassignedCerts) > 0 {
 						revokedCerts = append(revokedCerts, unassignedCerts...)
 					}
@@ -2128,6 +2131,7 @@ func buildCRL(sc *storageContext, crlInfo *pki_backend.CrlConfig, forceNew bool,
 	revokedCerts = revoked
 
 WRITE:
+	start := time.Now()
 	signingBundle, caErr := sc.fetchCAInfoByIssuerId(thisIssuerId, issuing.CRLSigningUsage)
 	if caErr != nil {
 		switch caErr.(type) {
@@ -2138,6 +2142,21 @@ WRITE:
 		}
 	}
 
+	var issuer *issuing.IssuerEntry
+	if thisIssuerId != legacyBundleShimID {
+		issuer, err = sc.fetchIssuerById(thisIssuerId)
+		if err != nil {
+			return nil, errutil.InternalError{Err: fmt.Sprintf("error fetching issuer %v: %s", thisIssuerId, err)}
+		}
+
+		if issuer.CRLExpiry != "" {
+			crlLifetime, err = parseutil.ParseDurationSecond(issuer.CRLExpiry)
+			if err != nil {
+				return nil, errutil.InternalError{Err: fmt.Sprintf("error parsing CRL duration of %s of issuer %v", issuer.CRLExpiry, thisIssuerId)}
+			}
+		}
+	}
+
 	now := time.Now()
 	nextUpdate := now.Add(crlLifetime)
 
@@ -2150,6 +2169,20 @@ WRITE:
 		extensions = []pkix.Extension{ext}
 	}
 
+	// Unified CRLs are served from every cluster's issuer and so can't name
+	// a single distribution point.
+	if issuer != nil && issuer.CRLIssuingDistributionPoint && !isUnified {
+		if len(signingBundle.URLs.CRLDistributionPoints) == 0 {
+			sc.Logger().Warn("not adding the Issuing Distribution Point extension to the CRL as the issuer has no CRL distribution points", "issuer_id", thisIssuerId)
+		} else {
+			ext, err := createIssuingDistributionPointExt(signingBundle.URLs.CRLDistributionPoints)
+			if err != nil {
+				return nil, fmt.Errorf("could not create crl issuing distribution point extension: %w", err)
+			}
+			extensions = append(extensions, ext)
+		}
+	}
+
 	revocationListTemplate := &x509.RevocationList{
 		RevokedCertificates: revokedCerts,
 		Number:              big.NewInt(crlNumber),
@@ -2188,9 +2221,45 @@ WRITE:
 		return nil, errutil.InternalError{Err: fmt.Sprintf("error storing CRL: %s", err)}
 	}
 
+	sc.Backend.emitCRLBuildMetrics(thisIssuerId, isUnified, isDelta, start, crlBytes, len(revokedCerts))
+
 	return &nextUpdate, nil
 }
 
+// issuingDistributionPoint is the IssuingDistributionPoint of RFC 5280
+// Section 5.2.5, restricted to the full name of the distribution point.
+type issuingDistributionPoint struct {
+	DistributionPoint distributionPointName `asn1:"optional,tag:0"`
+}
+
+type distributionPointName struct {
+	FullName []asn1.RawValue `asn1:"optional,tag:0"`
+}
+
+// createIssuingDistributionPointExt creates the critical Issuing Distribution
+// Point extension naming the given CRL distribution point URIs.
+func createIssuingDistributionPointExt(uris []string) (pkix.Extension, error) {
+	var idp issuingDistributionPoint
+	for _, uri := range uris {
+		idp.DistributionPoint.FullName = append(idp.DistributionPoint.FullName, asn1.RawValue{
+			Class: asn1.ClassContextSpecific,
+			Tag:   6, // uniformResourceIdentifier
+			Bytes: []byte(uri),
+		})
+	}
+
+	value, err := asn1.Marshal(idp)
+	if err != nil {
+		return pkix.Extension{}, err
+	}
+
+	return pkix.Extension{
+		Id:       oidExtensionIssuingDistributionPoint,
+		Critical: true,
+		Value:    value,
+	}, nil
+}
+
 // shouldLocalPathsUseUnified assuming a legacy path for a CRL/OCSP request, does our
 // configuration say we should be returning the unified response or not
 func shouldLocalPathsUseUnified(cfg *pki_backend.CrlConfig) bool {
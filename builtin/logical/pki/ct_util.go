@@ -0,0 +1,208 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package pki
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/hashicorp/go-cleanhttp"
+	"github.com/hashicorp/vault/sdk/helper/certutil"
+	"github.com/hashicorp/vault/sdk/helper/errutil"
+)
+
+// ctMaximumResponseSize bounds the add-pre-chain responses we are willing to
+// read from a log.
+const ctMaximumResponseSize = 64 * 1024
+
+var (
+	// oidExtensionCTPoison marks a precertificate, per RFC 6962 Section 3.1.
+	oidExtensionCTPoison = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 11129, 2, 4, 3}
+
+	// oidExtensionCTSCTList embeds the SCTs of the logs into the final
+	// certificate, per RFC 6962 Section 3.3.
+	oidExtensionCTSCTList = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 11129, 2, 4, 2}
+)
+
+// ctAddChainRequest and ctAddChainResponse are the JSON messages of the
+// add-pre-chain operation, per RFC 6962 Section 4.1.
+type ctAddChainRequest struct {
+	Chain [][]byte `json:"chain"`
+}
+
+type ctAddChainResponse struct {
+	SCTVersion uint8  `json:"sct_version"`
+	ID         []byte `json:"id"`
+	Timestamp  uint64 `json:"timestamp"`
+	Extensions string `json:"extensions"`
+	Signature  []byte `json:"signature"`
+}
+
+// embedSignedCertificateTimestamps re-issues the certificate of the bundle
+// with the SCTs of every configured CT log embedded. The certificate is
+// first issued as a precertificate, identical but for its poison extension,
+// and submitted to the logs; the SCTs they return are then added to the
+// certificate in place of the poison.
+func embedSignedCertificateTimestamps(sc *storageContext, caSign *certutil.CAInfoBundle, parsedBundle *certutil.ParsedCertBundle) (*certutil.ParsedCertBundle, error) {
+	config, err := getCTConfig(sc)
+	if err != nil {
+		return nil, err
+	}
+	if len(config.LogURLs) == 0 {
+		return nil, errutil.UserError{Err: "the role requires submission to CT logs, but no logs are configured in config/ct"}
+	}
+
+	leaf := parsedBundle.Certificate
+	precert, err := reissueWithExtension(caSign, leaf, pkix.Extension{
+		Id:       oidExtensionCTPoison,
+		Critical: true,
+		Value:    asn1.NullBytes,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed creating precertificate: %w", err)
+	}
+
+	chain := [][]byte{precert.Raw}
+	for _, block := range caSign.GetFullChain() {
+		chain = append(chain, block.Bytes)
+	}
+
+	client := cleanhttp.DefaultClient()
+	client.Timeout = config.SubmissionTimeout
+
+	var scts [][]byte
+	for _, logURL := range config.LogURLs {
+		sct, err := submitCTPrecertificate(sc.Context, client, logURL, chain)
+		if err != nil {
+			return nil, errutil.InternalError{Err: fmt.Sprintf("failed submitting precertificate to CT log %v: %v", logURL, err)}
+		}
+		scts = append(scts, sct)
+	}
+
+	sctList, err := marshalSCTList(scts)
+	if err != nil {
+		return nil, err
+	}
+	final, err := reissueWithExtension(caSign, leaf, pkix.Extension{
+		Id:    oidExtensionCTSCTList,
+		Value: sctList,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed creating certificate with SCTs: %w", err)
+	}
+
+	result := *parsedBundle
+	result.Certificate = final
+	result.CertificateBytes = final.Raw
+	return &result, nil
+}
+
+// reissueWithExtension signs a copy of the certificate with one extension
+// appended. All existing extensions are carried over verbatim and in order,
+// so that the precertificate and the final certificate only differ by the
+// extension appended to them.
+func reissueWithExtension(caSign *certutil.CAInfoBundle, cert *x509.Certificate, ext pkix.Extension) (*x509.Certificate, error) {
+	template := *cert
+	template.ExtraExtensions = make([]pkix.Extension, 0, len(cert.Extensions)+1)
+	for _, existing := range cert.Extensions {
+		if existing.Id.Equal(oidExtensionCTPoison) || existing.Id.Equal(oidExtensionCTSCTList) {
+			continue
+		}
+		template.ExtraExtensions = append(template.ExtraExtensions, existing)
+	}
+	template.ExtraExtensions = append(template.ExtraExtensions, ext)
+
+	der, err := x509.CreateCertificate(rand.Reader, &template, caSign.Certificate, cert.PublicKey, caSign.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	return x509.ParseCertificate(der)
+}
+
+// submitCTPrecertificate submits the precertificate and its chain to the
+// log, returning the serialized SCT of RFC 6962 Section 3.2.
+func submitCTPrecertificate(ctx context.Context, client *http.Client, logURL string, chain [][]byte) ([]byte, error) {
+	body, err := json.Marshal(&ctAddChainRequest{Chain: chain})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, logURL+"/ct/v1/add-pre-chain", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, ctMaximumResponseSize))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %v: %s", resp.StatusCode, bytes.TrimSpace(respBody))
+	}
+
+	var sct ctAddChainResponse
+	if err := json.Unmarshal(respBody, &sct); err != nil {
+		return nil, fmt.Errorf("failed decoding response: %w", err)
+	}
+	if sct.SCTVersion != 0 {
+		return nil, fmt.Errorf("unsupported SCT version %v", sct.SCTVersion)
+	}
+	if len(sct.ID) != 32 {
+		return nil, errors.New("invalid log ID in SCT")
+	}
+	extensions, err := base64.StdEncoding.DecodeString(sct.Extensions)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SCT extensions: %w", err)
+	}
+	if len(extensions) > 0xffff || len(sct.Signature) == 0 {
+		return nil, errors.New("invalid SCT")
+	}
+
+	// The signature is returned already encoded as a TLS digitally-signed
+	// struct, so it is appended as is.
+	serialized := []byte{sct.SCTVersion}
+	serialized = append(serialized, sct.ID...)
+	serialized = binary.BigEndian.AppendUint64(serialized, sct.Timestamp)
+	serialized = binary.BigEndian.AppendUint16(serialized, uint16(len(extensions)))
+	serialized = append(serialized, extensions...)
+	serialized = append(serialized, sct.Signature...)
+	return serialized, nil
+}
+
+// marshalSCTList encodes the value of the SCT list extension: a TLS-encoded
+// list of serialized SCTs, wrapped in an OCTET STRING.
+func marshalSCTList(scts [][]byte) ([]byte, error) {
+	var list []byte
+	for _, sct := range scts {
+		if len(sct) > 0xffff {
+			return nil, errors.New("SCT is too large")
+		}
+		list = binary.BigEndian.AppendUint16(list, uint16(len(sct)))
+		list = append(list, sct...)
+	}
+	if len(list) > 0xffff {
+		return nil, errors.New("SCT list is too large")
+	}
+
+	encoded := binary.BigEndian.AppendUint16(nil, uint16(len(list)))
+	return asn1.Marshal(append(encoded, list...))
+}
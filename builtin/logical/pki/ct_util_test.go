@@ -0,0 +1,178 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package pki
+
+import (
+	"bytes"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/binary"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeCTLog records the precertificates submitted to it and answers with a
+// well-formed, but unsigned, SCT.
+type fakeCTLog struct {
+	id       byte
+	fail     bool
+	lock     sync.Mutex
+	precerts []*x509.Certificate
+	chains   [][][]byte
+}
+
+func (l *fakeCTLog) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/ct/v1/add-pre-chain" || r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return
+	}
+	if l.fail {
+		http.Error(w, "log unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	var req ctAddChainRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.Chain) < 2 {
+		http.Error(w, "bad chain", http.StatusBadRequest)
+		return
+	}
+	precert, err := x509.ParseCertificate(req.Chain[0])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	l.lock.Lock()
+	l.precerts = append(l.precerts, precert)
+	l.chains = append(l.chains, req.Chain)
+	l.lock.Unlock()
+
+	json.NewEncoder(w).Encode(&ctAddChainResponse{
+		ID:        bytes.Repeat([]byte{l.id}, 32),
+		Timestamp: 1700000000000,
+		Signature: []byte{4, 3, 0, 2, 0xca, 0xfe},
+	})
+}
+
+func TestCTSubmission(t *testing.T) {
+	t.Parallel()
+	b, s := CreateBackendWithStorage(t)
+
+	resp, err := CBWrite(b, s, "root/generate/internal", map[string]interface{}{
+		"common_name": "Root CT CA",
+		"key_type":    "ec",
+		"ttl":         "720h",
+	})
+	require.NoError(t, err)
+	rootCert := parseCert(t, resp.Data["certificate"].(string))
+
+	_, err = CBWrite(b, s, "roles/public", map[string]interface{}{
+		"allowed_domains":  "example.com",
+		"allow_subdomains": true,
+		"key_type":         "ec",
+		"ct_submission":    true,
+		"ttl":              "24h",
+	})
+	require.NoError(t, err)
+
+	resp, err = CBRead(b, s, "roles/public")
+	require.NoError(t, err)
+	require.Equal(t, true, resp.Data["ct_submission"])
+
+	// Without logs, issuance must fail rather than skip submission.
+	_, err = CBWrite(b, s, "issue/public", map[string]interface{}{
+		"common_name": "www.example.com",
+	})
+	require.ErrorContains(t, err, "config/ct")
+
+	_, err = CBWrite(b, s, "config/ct", map[string]interface{}{
+		"log_urls": []string{"ftp://ct.example.com"},
+	})
+	require.Error(t, err)
+
+	logs := []*fakeCTLog{{id: 1}, {id: 2}}
+	var logURLs []string
+	for _, log := range logs {
+		server := httptest.NewServer(log)
+		defer server.Close()
+		logURLs = append(logURLs, server.URL+"/")
+	}
+
+	resp, err = CBWrite(b, s, "config/ct", map[string]interface{}{
+		"log_urls": logURLs,
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{logURLs[0][:len(logURLs[0])-1], logURLs[1][:len(logURLs[1])-1]}, resp.Data["log_urls"])
+
+	resp, err = CBWrite(b, s, "issue/public", map[string]interface{}{
+		"common_name": "www.example.com",
+	})
+	require.NoError(t, err)
+	cert := parseCert(t, resp.Data["certificate"].(string))
+	require.NoError(t, cert.CheckSignatureFrom(rootCert))
+
+	// The stored certificate is the one with the embedded SCTs.
+	stored, err := CBRead(b, s, "cert/"+resp.Data["serial_number"].(string))
+	require.NoError(t, err)
+	require.Equal(t, resp.Data["certificate"], stored.Data["certificate"])
+
+	var sctList []byte
+	for _, ext := range cert.Extensions {
+		require.False(t, ext.Id.Equal(oidExtensionCTPoison), "final certificate must not be poisoned")
+		if ext.Id.Equal(oidExtensionCTSCTList) {
+			_, err := asn1.Unmarshal(ext.Value, &sctList)
+			require.NoError(t, err)
+		}
+	}
+	require.NotEmpty(t, sctList)
+	require.Equal(t, int(binary.BigEndian.Uint16(sctList)), len(sctList)-2)
+
+	// Each log saw a precertificate matching the certificate but for the
+	// extension swapped, along with the issuer.
+	for index, log := range logs {
+		require.Len(t, log.precerts, 1)
+		precert := log.precerts[0]
+		require.NoError(t, precert.CheckSignatureFrom(rootCert))
+		require.Equal(t, cert.SerialNumber, precert.SerialNumber)
+		require.Equal(t, cert.RawSubject, precert.RawSubject)
+		require.Equal(t, cert.NotAfter, precert.NotAfter)
+		require.Len(t, precert.Extensions, len(cert.Extensions))
+		for i, ext := range precert.Extensions {
+			if ext.Id.Equal(oidExtensionCTPoison) {
+				require.True(t, ext.Critical)
+				require.True(t, cert.Extensions[i].Id.Equal(oidExtensionCTSCTList))
+				continue
+			}
+			require.Equal(t, ext, cert.Extensions[i])
+		}
+		require.Equal(t, rootCert.Raw, log.chains[0][1])
+
+		// SCTs are embedded in the order of the logs.
+		require.Contains(t, string(sctList), string(bytes.Repeat([]byte{logs[index].id}, 32)))
+	}
+
+	// Signing also embeds SCTs.
+	_, _, csrPem := generateCSR(t, &x509.CertificateRequest{
+		Subject: pkix.Name{CommonName: "api.example.com"},
+	}, "ec", 256)
+	_, err = CBWrite(b, s, "sign/public", map[string]interface{}{
+		"common_name": "api.example.com",
+		"csr":         csrPem,
+	})
+	require.NoError(t, err)
+	require.Len(t, logs[0].precerts, 2)
+
+	// A single failing log fails issuance.
+	logs[1].fail = true
+	_, err = CBWrite(b, s, "issue/public", map[string]interface{}{
+		"common_name": "www.example.com",
+	})
+	require.ErrorContains(t, err, "failed submitting precertificate")
+}
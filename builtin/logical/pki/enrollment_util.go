@@ -0,0 +1,137 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package pki
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+
+	"github.com/hashicorp/vault/builtin/logical/pki/issuing"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/certutil"
+	"github.com/hashicorp/vault/sdk/helper/consts"
+	"github.com/hashicorp/vault/sdk/helper/errutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// enrollmentPolicy is the resolved path policy of a certificate enrollment
+// protocol request: the role certificates are issued against, and whether
+// that role is sign-verbatim.
+type enrollmentPolicy struct {
+	role           *issuing.RoleEntry
+	isSignVerbatim bool
+}
+
+func (p *enrollmentPolicy) issuerRef() string {
+	if p.role.Issuer == "" {
+		return defaultRef
+	}
+	return p.role.Issuer
+}
+
+// resolveEnrollmentPolicy resolves a path policy, either "sign-verbatim" or
+// a role given as "role:<role_name>".
+func resolveEnrollmentPolicy(sc *storageContext, policy string) (*enrollmentPolicy, error) {
+	policyType, roleName, err := getDefaultDirectoryPolicyType(policy)
+	if err != nil {
+		return nil, err
+	}
+
+	switch policyType {
+	case SignVerbatim:
+		return &enrollmentPolicy{
+			role: issuing.SignVerbatimRoleWithOpts(
+				issuing.WithIssuer(""),
+				issuing.WithNoStore(false)),
+			isSignVerbatim: true,
+		}, nil
+	case Role:
+		role, err := sc.GetRole(roleName)
+		if err != nil {
+			return nil, err
+		}
+		if role == nil {
+			return nil, errutil.UserError{Err: fmt.Sprintf("role %q does not exist", roleName)}
+		}
+		return &enrollmentPolicy{role: role}, nil
+	default:
+		return nil, fmt.Errorf("unsupported path policy %q", policy)
+	}
+}
+
+// validateEnrollmentPathPolicy checks a path policy is either sign-verbatim
+// or an existing role.
+func validateEnrollmentPathPolicy(sc *storageContext, policy string) error {
+	policyType, roleName, err := getDefaultDirectoryPolicyType(policy)
+	if err != nil {
+		return err
+	}
+
+	switch policyType {
+	case SignVerbatim:
+		return nil
+	case Role:
+		role, err := sc.GetRole(roleName)
+		if err != nil {
+			return err
+		}
+		if role == nil {
+			return fmt.Errorf("role %q does not exist", roleName)
+		}
+		return nil
+	default:
+		return errors.New(`must be "sign-verbatim" or "role:<role_name>"`)
+	}
+}
+
+// issueEnrollmentCert signs the CSR of an enrollment protocol request under
+// its path policy, storing the certificate unless the role disables it.
+func (b *backend) issueEnrollmentCert(sc *storageContext, req *logical.Request, policy *enrollmentPolicy, csr *x509.CertificateRequest) (*certutil.ParsedCertBundle, error) {
+	if !policy.role.NoStore && b.System().ReplicationState().HasState(consts.ReplicationPerformanceStandby) {
+		return nil, logical.ErrReadOnly
+	}
+
+	pemCsr := string(pem.EncodeToMemory(&pem.Block{
+		Type:  "CERTIFICATE REQUEST",
+		Bytes: csr.Raw,
+	}))
+	apiData := &framework.FieldData{
+		Raw: map[string]interface{}{
+			"csr": pemCsr,
+		},
+		Schema: getCsrSignVerbatimSchemaFields(),
+	}
+
+	signingBundle, issuerId, err := sc.fetchCAInfoWithIssuer(policy.issuerRef(), issuing.IssuanceUsage)
+	if err != nil {
+		return nil, fmt.Errorf("failed loading CA %s: %w", policy.issuerRef(), err)
+	}
+
+	input := &inputBundle{
+		req:      req,
+		apiData:  apiData,
+		role:     policy.role,
+		issuerId: issuerId,
+	}
+
+	// As with the sign-verbatim API, only sign-verbatim takes the subject
+	// and extensions from the request; roles validate the request instead.
+	parsedBundle, _, err := signCert(sc, input, signingBundle, false /* is_ca=false */, policy.isSignVerbatim)
+	if err != nil {
+		return nil, err
+	}
+
+	if !policy.role.NoStore {
+		if err := issuing.StoreCertificate(sc.Context, req.Storage, b.GetCertificateCounter(), parsedBundle); err != nil {
+			return nil, err
+		}
+		if err := storeCertIndex(sc.Context, req.Storage, parsedBundle.Certificate, policy.role.Name, issuerId, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	return parsedBundle, nil
+}
@@ -63,6 +63,17 @@ pkcs8 instead. Defaults to "der".`,
 		},
 	}
 
+	fields["private_key_passphrase"] = &framework.FieldSchema{
+		Type: framework.TypeString,
+		Description: `If set, the returned private key is encrypted
+with this passphrase, as a PKCS#8 EncryptedPrivateKeyInfo
+in base64-encoded DER or PEM, depending on the value of
+"format". Not supported with the "pem_bundle" format.`,
+		DisplayAttrs: &framework.DisplayAttributes{
+			Sensitive: true,
+		},
+	}
+
 	fields["ip_sans"] = &framework.FieldSchema{
 		Type: framework.TypeCommaStringSlice,
 		Description: `The requested IP SANs, if any, in a
@@ -179,6 +190,91 @@ Any values are added with OID 0.9.2342.19200300.100.1.1.`,
 	return fields
 }
 
+// addSubjectRDNsField adds the subject_rdns field to the issuance paths
+// building the subject of certificates from their role
+func addSubjectRDNsField(fields map[string]*framework.FieldSchema) map[string]*framework.FieldSchema {
+	fields["subject_rdns"] = &framework.FieldSchema{
+		Type: framework.TypeStringSlice,
+		Description: `The ordered RDNs, as TYPE=value, making up the
+subject in place of those set by the role, followed by the serial number and
+common name. Restricted by allowed_subject_rdns.`,
+		DisplayAttrs: &framework.DisplayAttributes{
+			Name: "Subject RDNs",
+		},
+	}
+
+	return fields
+}
+
+// addPKCS12Fields allows the issue paths, which generate the private key, to
+// return the key and certificate as a PKCS#12 archive
+func addPKCS12Fields(fields map[string]*framework.FieldSchema) map[string]*framework.FieldSchema {
+	fields["format"] = &framework.FieldSchema{
+		Type:    framework.TypeString,
+		Default: "pem",
+		Description: `Format for returned data. Can be "pem", "der",
+"pem_bundle" or "pkcs12". If "pem_bundle", any private
+key and issuing cert will be appended to the
+certificate pem. If "der", the value will be
+base64 encoded. If "pkcs12", the private key,
+certificate and CA chain are returned in the
+pkcs12 field instead, as a base64 encoded PKCS#12
+archive protected by pkcs12_password.
+Defaults to "pem".`,
+		AllowedValues: []interface{}{"pem", "der", "pem_bundle", "pkcs12"},
+		DisplayAttrs: &framework.DisplayAttributes{
+			Value: "pem",
+		},
+	}
+
+	fields["pkcs12_password"] = &framework.FieldSchema{
+		Type: framework.TypeString,
+		Description: `The password protecting the PKCS#12 archive
+returned when format is "pkcs12". May be empty.`,
+		DisplayAttrs: &framework.DisplayAttributes{
+			Name:      "PKCS#12 Password",
+			Sensitive: true,
+		},
+	}
+
+	return fields
+}
+
+// addPrivateKeyWrapField adds the private_key_wrap_ttl field to the paths
+// generating a private key for a leaf certificate
+func addPrivateKeyWrapField(fields map[string]*framework.FieldSchema) map[string]*framework.FieldSchema {
+	fields["private_key_wrap_ttl"] = &framework.FieldSchema{
+		Type: framework.TypeDurationSecond,
+		Description: `If set, the private key is not returned in the
+response. It is instead wrapped in a single-use
+response wrapping token with this TTL, returned in
+the private_key_wrap_info field, so that only the
+holder of the token can unwrap the key. When format
+is "pkcs12", the archive is wrapped instead.`,
+		DisplayAttrs: &framework.DisplayAttributes{
+			Name: "Private Key Wrap TTL",
+		},
+	}
+
+	return fields
+}
+
+// addExtensionsField adds the extensions field to the issuance paths
+// checking requests against their role
+func addExtensionsField(fields map[string]*framework.FieldSchema) map[string]*framework.FieldSchema {
+	fields["extensions"] = &framework.FieldSchema{
+		Type: framework.TypeStringSlice,
+		Description: `Custom extensions to add to the certificate, each as
+OID;DER:value, or OID;critical;DER:value for critical extensions, where value
+is the base64-encoded DER extension value. Restricted by allowed_extensions.`,
+		DisplayAttrs: &framework.DisplayAttributes{
+			Name: "Extensions",
+		},
+	}
+
+	return fields
+}
+
 // addCACommonFields adds fields with help text specific to CA
 // certificate issuing and signing
 func addCACommonFields(fields map[string]*framework.FieldSchema) map[string]*framework.FieldSchema {
@@ -391,6 +487,62 @@ func addCAIssueFields(fields map[string]*framework.FieldSchema) map[string]*fram
 		},
 	}
 
+	fields["excluded_dns_domains"] = &framework.FieldSchema{
+		Type:        framework.TypeCommaStringSlice,
+		Description: `Domains for which this certificate is not allowed to sign or issue child certificates (see https://tools.ietf.org/html/rfc5280#section-4.2.1.10).`,
+		DisplayAttrs: &framework.DisplayAttributes{
+			Name: "Excluded DNS Domains",
+		},
+	}
+
+	fields["permitted_ip_ranges"] = &framework.FieldSchema{
+		Type:        framework.TypeCommaStringSlice,
+		Description: `IP ranges, in CIDR notation, for which this certificate is allowed to sign or issue child certificates (see https://tools.ietf.org/html/rfc5280#section-4.2.1.10).`,
+		DisplayAttrs: &framework.DisplayAttributes{
+			Name: "Permitted IP Ranges",
+		},
+	}
+
+	fields["excluded_ip_ranges"] = &framework.FieldSchema{
+		Type:        framework.TypeCommaStringSlice,
+		Description: `IP ranges, in CIDR notation, for which this certificate is not allowed to sign or issue child certificates (see https://tools.ietf.org/html/rfc5280#section-4.2.1.10).`,
+		DisplayAttrs: &framework.DisplayAttributes{
+			Name: "Excluded IP Ranges",
+		},
+	}
+
+	fields["permitted_email_addresses"] = &framework.FieldSchema{
+		Type:        framework.TypeCommaStringSlice,
+		Description: `Email addresses or domains for which this certificate is allowed to sign or issue child certificates (see https://tools.ietf.org/html/rfc5280#section-4.2.1.10).`,
+		DisplayAttrs: &framework.DisplayAttributes{
+			Name: "Permitted Email Addresses",
+		},
+	}
+
+	fields["excluded_email_addresses"] = &framework.FieldSchema{
+		Type:        framework.TypeCommaStringSlice,
+		Description: `Email addresses or domains for which this certificate is not allowed to sign or issue child certificates (see https://tools.ietf.org/html/rfc5280#section-4.2.1.10).`,
+		DisplayAttrs: &framework.DisplayAttributes{
+			Name: "Excluded Email Addresses",
+		},
+	}
+
+	fields["permitted_uri_domains"] = &framework.FieldSchema{
+		Type:        framework.TypeCommaStringSlice,
+		Description: `URI domains for which this certificate is allowed to sign or issue child certificates (see https://tools.ietf.org/html/rfc5280#section-4.2.1.10).`,
+		DisplayAttrs: &framework.DisplayAttributes{
+			Name: "Permitted URI Domains",
+		},
+	}
+
+	fields["excluded_uri_domains"] = &framework.FieldSchema{
+		Type:        framework.TypeCommaStringSlice,
+		Description: `URI domains for which this certificate is not allowed to sign or issue child certificates (see https://tools.ietf.org/html/rfc5280#section-4.2.1.10).`,
+		DisplayAttrs: &framework.DisplayAttributes{
+			Name: "Excluded URI Domains",
+		},
+	}
+
 	fields = addIssuerNameField(fields)
 
 	return fields
@@ -422,6 +574,55 @@ to the issuer.`,
 	return fields
 }
 
+func addIssuerImportFields(fields map[string]*framework.FieldSchema) map[string]*framework.FieldSchema {
+	fields["parent_certificate"] = &framework.FieldSchema{
+		Type: framework.TypeString,
+		Description: `PEM-format certificate of the CA which issued the
+imported certificate(s). When provided, imported intermediates are validated
+against it (signature, path length, name constraints and extended key usage
+chaining) in addition to any parents found in the bundle or already present
+on this mount.`,
+	}
+	fields["enforce_chain_validation"] = &framework.FieldSchema{
+		Type: framework.TypeBool,
+		Description: `If true, the import fails when an imported CA
+certificate is not a valid subordinate of its parent. Otherwise, problems
+are returned as warnings and the import proceeds. Defaults to false.`,
+		Default: false,
+	}
+	fields["fetch_issuing_certificates"] = &framework.FieldSchema{
+		Type: framework.TypeBool,
+		Description: `If true, the parents of imported certificates which
+are neither part of the import nor present on this mount are fetched from
+the issuing certificate URLs of their Authority Information Access extension
+and imported as well, completing their ca_chain. Defaults to false.`,
+		Default: false,
+	}
+	fields["allow_expired_ca"] = &framework.FieldSchema{
+		Type: framework.TypeBool,
+		Description: `If true, allow importing CA certificates which have
+already expired, returning a warning instead of an error. Defaults to false.`,
+		Default: false,
+	}
+	fields["defer_crl_rebuild"] = &framework.FieldSchema{
+		Type: framework.TypeBool,
+		Description: `If true, the CRLs are not rebuilt as part of this
+import. Instead, a rebuild is scheduled for the next periodic CRL check; it
+may also be triggered explicitly by reading crl/rotate. Useful when
+importing many issuers in succession. Defaults to false.`,
+		Default: false,
+	}
+	fields["derive_issuer_names"] = &framework.FieldSchema{
+		Type: framework.TypeBool,
+		Description: `If true, newly imported issuers are named after
+their certificate's common name and a prefix of its SHA-256 fingerprint,
+for example "example-root-ca-1a2b3c4d". Defaults to false, leaving
+imported issuers unnamed.`,
+		Default: false,
+	}
+	return fields
+}
+
 func addKeyRefNameFields(fields map[string]*framework.FieldSchema) map[string]*framework.FieldSchema {
 	fields = addKeyNameField(fields)
 	fields = addKeyRefField(fields)
@@ -479,7 +680,15 @@ and OCSP responses.`,
 		Type: framework.TypeBool,
 		Description: `Set to true to automatically remove expired issuers
 past the issuer_safety_buffer. No keys will be removed as part of this
-operation.`,
+operation; see tidy_dangling_keys.`,
+	}
+
+	fields["tidy_dangling_keys"] = &framework.FieldSchema{
+		Type: framework.TypeBool,
+		Description: `Set to true to automatically remove keys which are
+not used by any issuer, either as its key or as the key of its delegated OCSP
+responder, once they have remained unused for the issuer_safety_buffer. The
+default key is never removed.`,
 	}
 
 	fields["tidy_move_legacy_ca_bundle"] = &framework.FieldSchema{
@@ -0,0 +1,245 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package pki
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-cleanhttp"
+)
+
+const (
+	// maxFetchedImportIssuers bounds how many parents a single import may
+	// fetch over the Authority Information Access extension.
+	maxFetchedImportIssuers = 10
+
+	importIssuerFetchTimeout = 10 * time.Second
+
+	// maxImportIssuerFetchSize bounds the responses we are willing to read
+	// when fetching a parent certificate.
+	maxImportIssuerFetchSize = 64 * 1024
+)
+
+type importChainStatus int
+
+const (
+	importChainIncomplete importChainStatus = iota
+	importChainCycle
+	importChainComplete
+)
+
+func isSelfSignedCertificate(cert *x509.Certificate) bool {
+	return bytes.Equal(cert.RawIssuer, cert.RawSubject) && cert.CheckSignatureFrom(cert) == nil
+}
+
+// fetchMissingImportParents follows the issuing certificate URLs of every
+// certificate in certs whose parent is not amongst the candidates, and
+// repeats this for every parent fetched until each chain reaches a root or a
+// known issuer. It returns the fetched certificates in the order they were
+// fetched, along with a description of every fetch which failed.
+func fetchMissingImportParents(ctx context.Context, certs []*x509.Certificate, candidates []*x509.Certificate) ([]*x509.Certificate, []string) {
+	var fetched []*x509.Certificate
+	var problems []string
+
+	candidates = append([]*x509.Certificate{}, candidates...)
+	queue := append([]*x509.Certificate{}, certs...)
+	for len(queue) > 0 {
+		cert := queue[0]
+		queue = queue[1:]
+
+		if isSelfSignedCertificate(cert) || findImportParent(cert, candidates) != nil {
+			continue
+		}
+		if len(cert.IssuingCertificateURL) == 0 {
+			// Nothing to fetch; the chain check reports this certificate
+			// as incomplete.
+			continue
+		}
+		if len(fetched) >= maxFetchedImportIssuers {
+			problems = append(problems, fmt.Sprintf("certificate (%v): not fetching its parent as %d parents were already fetched", cert.Subject.String(), maxFetchedImportIssuers))
+			continue
+		}
+
+		parent, err := fetchImportParent(ctx, cert)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("certificate (%v): %v", cert.Subject.String(), err))
+			continue
+		}
+
+		fetched = append(fetched, parent)
+		candidates = append(candidates, parent)
+		queue = append(queue, parent)
+	}
+
+	return fetched, problems
+}
+
+// fetchImportParent fetches the certificate which issued child from the
+// issuing certificate URLs of its Authority Information Access extension,
+// returning the first one which verifies child's signature.
+func fetchImportParent(ctx context.Context, child *x509.Certificate) (*x509.Certificate, error) {
+	client := cleanhttp.DefaultClient()
+	client.Timeout = importIssuerFetchTimeout
+
+	var errs []string
+	for _, location := range child.IssuingCertificateURL {
+		parent, err := fetchImportParentFrom(ctx, client, location)
+		if err == nil {
+			if findImportParent(child, []*x509.Certificate{parent}) != nil {
+				return parent, nil
+			}
+			err = errors.New("fetched certificate did not issue this certificate")
+		}
+		errs = append(errs, fmt.Sprintf("%v: %v", location, err))
+	}
+
+	return nil, fmt.Errorf("unable to fetch parent certificate: %v", strings.Join(errs, "; "))
+}
+
+func fetchImportParentFrom(ctx context.Context, client *http.Client, location string) (*x509.Certificate, error) {
+	parsed, err := url.Parse(location)
+	if err != nil {
+		return nil, err
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported URL scheme %q", parsed.Scheme)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, location, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %v", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxImportIssuerFetchSize))
+	if err != nil {
+		return nil, err
+	}
+
+	// Issuing certificate URLs usually serve DER, but some serve PEM.
+	if cert, err := x509.ParseCertificate(body); err == nil {
+		return cert, nil
+	}
+	return parseCertificateFromBytes(body)
+}
+
+// checkImportedIssuerChains verifies that every certificate in certs chains
+// to a self-signed root through the candidates, returning a description of
+// every certificate whose chain is missing a parent, and of every
+// certificate whose chains all lead back to themselves.
+func checkImportedIssuerChains(certs []*x509.Certificate, candidates []*x509.Certificate) (incomplete []string, cycles []string) {
+	graph := newImportChainGraph(candidates)
+	for index, cert := range certs {
+		switch graph.status(cert) {
+		case importChainIncomplete:
+			incomplete = append(incomplete, fmt.Sprintf("certificate %d (%v): chain does not reach a root certificate; the parent of a certificate in it is neither part of the import nor present on this mount", index, cert.Subject.String()))
+		case importChainCycle:
+			cycles = append(cycles, fmt.Sprintf("certificate %d (%v): every chain loops back on itself without reaching a root certificate", index, cert.Subject.String()))
+		}
+	}
+
+	return incomplete, cycles
+}
+
+// importChainGraph memoizes the parents and chain status of certificates,
+// keyed by their raw encoding, so that the signatures of each certificate are
+// checked once however many chains share it. This keeps bundles of many
+// cross-signed certificates from stalling the import, which runs under the
+// issuers lock.
+type importChainGraph struct {
+	candidates []*x509.Certificate
+	parents    map[string][]*x509.Certificate
+	statuses   map[string]importChainStatus
+}
+
+func newImportChainGraph(candidates []*x509.Certificate) *importChainGraph {
+	return &importChainGraph{
+		candidates: candidates,
+		parents:    make(map[string][]*x509.Certificate),
+		statuses:   make(map[string]importChainStatus),
+	}
+}
+
+func (g *importChainGraph) parentsOf(cert *x509.Certificate) []*x509.Certificate {
+	key := string(cert.Raw)
+	parents, ok := g.parents[key]
+	if !ok {
+		parents = findImportParents(cert, g.candidates)
+		g.parents[key] = parents
+	}
+
+	return parents
+}
+
+// status searches the certificates reachable through the parents of cert for
+// a self-signed root. A chain is complete when any path reaches a root,
+// incomplete when none does but some path ends at a missing parent, and a
+// cycle otherwise. Since all three only depend on the certificates reachable
+// from cert, the result is memoized and reused by later searches reaching it.
+func (g *importChainGraph) status(cert *x509.Certificate) importChainStatus {
+	key := string(cert.Raw)
+	if status, ok := g.statuses[key]; ok {
+		return status
+	}
+
+	status := importChainCycle
+	visited := map[string]bool{key: true}
+	pending := []*x509.Certificate{cert}
+	for len(pending) > 0 {
+		current := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+
+		if isSelfSignedCertificate(current) {
+			status = importChainComplete
+			break
+		}
+		if current != cert {
+			if known, ok := g.statuses[string(current.Raw)]; ok {
+				if known == importChainComplete {
+					status = importChainComplete
+					break
+				}
+				if known == importChainIncomplete {
+					status = importChainIncomplete
+				}
+				// Nothing reachable from current reaches a root, so
+				// there is no need to search past it.
+				continue
+			}
+		}
+
+		parents := g.parentsOf(current)
+		if len(parents) == 0 {
+			status = importChainIncomplete
+		}
+		for _, parent := range parents {
+			parentKey := string(parent.Raw)
+			if !visited[parentKey] {
+				visited[parentKey] = true
+				pending = append(pending, parent)
+			}
+		}
+	}
+
+	g.statuses[key] = status
+	return status
+}
@@ -0,0 +1,466 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package pki
+
+import (
+	"bytes"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/builtin/logical/pki/issuing"
+)
+
+// findImportParent locates the certificate which issued child amongst the
+// candidates, returning nil if none of them match by name and signature.
+func findImportParent(child *x509.Certificate, candidates []*x509.Certificate) *x509.Certificate {
+	parents := findImportParents(child, candidates)
+	if len(parents) == 0 {
+		return nil
+	}
+
+	return parents[0]
+}
+
+// findImportParents returns every certificate amongst the candidates which
+// could have issued child; there may be several when the parent has been
+// cross-signed or reissued.
+func findImportParents(child *x509.Certificate, candidates []*x509.Certificate) []*x509.Certificate {
+	var parents []*x509.Certificate
+	for _, candidate := range candidates {
+		if candidate == child || bytes.Equal(candidate.Raw, child.Raw) {
+			continue
+		}
+		if !bytes.Equal(child.RawIssuer, candidate.RawSubject) {
+			continue
+		}
+		if err := child.CheckSignatureFrom(candidate); err == nil {
+			parents = append(parents, candidate)
+		}
+	}
+
+	return parents
+}
+
+// validateIssuerAgainstParent checks that the CA certificate child is a
+// valid subordinate of parent, returning a description of every problem
+// found. An empty result means the pair forms a valid link in a chain.
+func validateIssuerAgainstParent(child *x509.Certificate, parent *x509.Certificate) []string {
+	var problems []string
+
+	if !bytes.Equal(child.RawIssuer, parent.RawSubject) {
+		problems = append(problems, fmt.Sprintf("issuer name of certificate (%v) does not match subject of parent (%v)", child.Issuer.String(), parent.Subject.String()))
+	}
+	if err := child.CheckSignatureFrom(parent); err != nil {
+		problems = append(problems, fmt.Sprintf("signature of certificate could not be verified by parent: %v", err))
+	}
+	if !parent.IsCA || !parent.BasicConstraintsValid {
+		problems = append(problems, "parent certificate is not a CA")
+	}
+
+	// A parent with a path length constraint of zero may only issue leaf
+	// certificates; otherwise a child constraint, if any, must leave room
+	// beneath the parent's. A child without a constraint of its own is still
+	// bound by the parent's (RFC 5280, section 4.2.1.9).
+	if parent.BasicConstraintsValid && child.IsCA {
+		parentLimited := parent.MaxPathLen > 0 || parent.MaxPathLenZero
+		childLimited := child.MaxPathLen > 0 || child.MaxPathLenZero
+		switch {
+		case parentLimited && parent.MaxPathLen == 0:
+			problems = append(problems, "parent certificate has a path length constraint of zero and may not issue intermediate CAs")
+		case parentLimited && childLimited && child.MaxPathLen >= parent.MaxPathLen:
+			problems = append(problems, fmt.Sprintf("certificate path length constraint (%d) must be less than parent's (%d)", child.MaxPathLen, parent.MaxPathLen))
+		}
+	}
+
+	problems = append(problems, validateNameConstraintsAgainstParent(child, parent)...)
+	problems = append(problems, validateExtKeyUsageAgainstParent(child, parent)...)
+
+	return problems
+}
+
+// validateNameConstraintsAgainstParent ensures the child's names and own
+// name constraints do not exceed what the parent's name constraints allow:
+// every name and permitted subtree of the child must lie within the parent's
+// permitted subtrees (if any) and outside of its excluded subtrees.
+func validateNameConstraintsAgainstParent(child *x509.Certificate, parent *x509.Certificate) []string {
+	var problems []string
+
+	if len(parent.PermittedDNSDomains) > 0 {
+		var dnsNames []string
+		dnsNames = append(dnsNames, child.DNSNames...)
+		dnsNames = append(dnsNames, child.PermittedDNSDomains...)
+		for _, name := range dnsNames {
+			if !dnsNameWithinAny(name, parent.PermittedDNSDomains) {
+				problems = append(problems, fmt.Sprintf("DNS name %q is not permitted by parent's name constraints", name))
+			}
+		}
+	}
+	for _, name := range child.DNSNames {
+		if dnsNameWithinAny(name, parent.ExcludedDNSDomains) {
+			problems = append(problems, fmt.Sprintf("DNS name %q is excluded by parent's name constraints", name))
+		}
+	}
+	for _, name := range child.PermittedDNSDomains {
+		if dnsNameWithinAny(name, parent.ExcludedDNSDomains) {
+			problems = append(problems, fmt.Sprintf("permitted DNS domain %q is excluded by parent's name constraints", name))
+		}
+	}
+
+	if len(parent.PermittedIPRanges) > 0 {
+		for _, ip := range child.IPAddresses {
+			if !ipWithinAny(ip, parent.PermittedIPRanges) {
+				problems = append(problems, fmt.Sprintf("IP address %v is not permitted by parent's name constraints", ip))
+			}
+		}
+		for _, ipRange := range child.PermittedIPRanges {
+			if !ipRangeWithinAny(ipRange, parent.PermittedIPRanges) {
+				problems = append(problems, fmt.Sprintf("permitted IP range %v is not permitted by parent's name constraints", ipRange))
+			}
+		}
+	}
+	for _, ip := range child.IPAddresses {
+		if ipWithinAny(ip, parent.ExcludedIPRanges) {
+			problems = append(problems, fmt.Sprintf("IP address %v is excluded by parent's name constraints", ip))
+		}
+	}
+	for _, ipRange := range child.PermittedIPRanges {
+		if ipRangeWithinAny(ipRange, parent.ExcludedIPRanges) {
+			problems = append(problems, fmt.Sprintf("permitted IP range %v is excluded by parent's name constraints", ipRange))
+		}
+	}
+
+	if len(parent.PermittedEmailAddresses) > 0 {
+		var emails []string
+		emails = append(emails, child.EmailAddresses...)
+		emails = append(emails, child.PermittedEmailAddresses...)
+		for _, email := range emails {
+			if !emailWithinAny(email, parent.PermittedEmailAddresses) {
+				problems = append(problems, fmt.Sprintf("email address %q is not permitted by parent's name constraints", email))
+			}
+		}
+	}
+	for _, email := range child.EmailAddresses {
+		if emailWithinAny(email, parent.ExcludedEmailAddresses) {
+			problems = append(problems, fmt.Sprintf("email address %q is excluded by parent's name constraints", email))
+		}
+	}
+	for _, email := range child.PermittedEmailAddresses {
+		if emailWithinAny(email, parent.ExcludedEmailAddresses) {
+			problems = append(problems, fmt.Sprintf("permitted email address %q is excluded by parent's name constraints", email))
+		}
+	}
+
+	if len(parent.PermittedURIDomains) > 0 {
+		for _, uri := range child.URIs {
+			if !dnsNameWithinAny(uri.Hostname(), parent.PermittedURIDomains) {
+				problems = append(problems, fmt.Sprintf("URI %q is not permitted by parent's name constraints", uri.String()))
+			}
+		}
+		for _, domain := range child.PermittedURIDomains {
+			if !dnsNameWithinAny(domain, parent.PermittedURIDomains) {
+				problems = append(problems, fmt.Sprintf("permitted URI domain %q is not permitted by parent's name constraints", domain))
+			}
+		}
+	}
+	for _, uri := range child.URIs {
+		if dnsNameWithinAny(uri.Hostname(), parent.ExcludedURIDomains) {
+			problems = append(problems, fmt.Sprintf("URI %q is excluded by parent's name constraints", uri.String()))
+		}
+	}
+	for _, domain := range child.PermittedURIDomains {
+		if dnsNameWithinAny(domain, parent.ExcludedURIDomains) {
+			problems = append(problems, fmt.Sprintf("permitted URI domain %q is excluded by parent's name constraints", domain))
+		}
+	}
+
+	return problems
+}
+
+// validateExtKeyUsageAgainstParent ensures the child does not claim extended
+// key usages that the parent is not itself permitted to delegate.
+func validateExtKeyUsageAgainstParent(child *x509.Certificate, parent *x509.Certificate) []string {
+	if len(parent.ExtKeyUsage) == 0 && len(parent.UnknownExtKeyUsage) == 0 {
+		return nil
+	}
+	for _, usage := range parent.ExtKeyUsage {
+		if usage == x509.ExtKeyUsageAny {
+			return nil
+		}
+	}
+
+	if len(child.ExtKeyUsage) == 0 && len(child.UnknownExtKeyUsage) == 0 {
+		return []string{"certificate places no restriction on extended key usage but parent does"}
+	}
+
+	var problems []string
+	for _, usage := range child.ExtKeyUsage {
+		found := false
+		for _, parentUsage := range parent.ExtKeyUsage {
+			if usage == parentUsage {
+				found = true
+				break
+			}
+		}
+		if !found {
+			problems = append(problems, fmt.Sprintf("extended key usage %v is not permitted by parent", usage))
+		}
+	}
+	for _, usage := range child.UnknownExtKeyUsage {
+		found := false
+		for _, parentUsage := range parent.UnknownExtKeyUsage {
+			if usage.Equal(parentUsage) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			problems = append(problems, fmt.Sprintf("extended key usage %v is not permitted by parent", usage.String()))
+		}
+	}
+
+	return problems
+}
+
+func dnsNameWithinAny(name string, constraints []string) bool {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	for _, constraint := range constraints {
+		constraint = strings.ToLower(strings.TrimSuffix(constraint, "."))
+		switch {
+		case constraint == "":
+			return true
+		case strings.HasPrefix(constraint, "."):
+			if strings.HasSuffix(name, constraint) {
+				return true
+			}
+		case name == constraint || strings.HasSuffix(name, "."+constraint):
+			return true
+		}
+	}
+
+	return false
+}
+
+// ipRangeWithinAny reports whether every address of ipRange lies within one
+// of the given ranges.
+func ipRangeWithinAny(ipRange *net.IPNet, ranges []*net.IPNet) bool {
+	ones, bits := ipRange.Mask.Size()
+	for _, candidate := range ranges {
+		candidateOnes, candidateBits := candidate.Mask.Size()
+		if bits == candidateBits && candidateOnes <= ones && candidate.Contains(ipRange.IP) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// emailWithinAny reports whether email lies within one of the given email
+// constraints. Both email and the constraints may be a full mailbox
+// (user@example.com), a host (example.com) matching every mailbox on it, or a
+// domain (.example.com) matching every mailbox on its subdomains.
+func emailWithinAny(email string, constraints []string) bool {
+	local, host, isMailbox := strings.Cut(email, "@")
+	if !isMailbox {
+		local, host = "", email
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+
+	for _, constraint := range constraints {
+		constraintLocal, constraintHost, constraintIsMailbox := strings.Cut(constraint, "@")
+		if !constraintIsMailbox {
+			constraintHost = constraint
+		}
+		constraintHost = strings.ToLower(strings.TrimSuffix(constraintHost, "."))
+
+		switch {
+		case constraintIsMailbox:
+			if isMailbox && local == constraintLocal && host == constraintHost {
+				return true
+			}
+		case strings.HasPrefix(constraintHost, "."):
+			if strings.HasSuffix(host, constraintHost) {
+				return true
+			}
+		case !strings.HasPrefix(host, "."):
+			if host == constraintHost {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func ipWithinAny(ip net.IP, ranges []*net.IPNet) bool {
+	for _, ipRange := range ranges {
+		if ipRange.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// validateImportedIssuerChain validates every non-self-signed CA certificate
+// in certs against its parent, which is located amongst the explicitly
+// provided parent (if any), the other certificates being imported, and the
+// issuers already present on the mount. The returned problems are prefixed
+// with the index of the offending certificate in the request.
+func (sc *storageContext) validateImportedIssuerChain(certs []*x509.Certificate, parent *x509.Certificate) ([]string, error) {
+	candidates := make([]*x509.Certificate, 0, len(certs)+1)
+	if parent != nil {
+		candidates = append(candidates, parent)
+	}
+	candidates = append(candidates, certs...)
+
+	existing, err := sc.listIssuerCertificates()
+	if err != nil {
+		return nil, err
+	}
+	candidates = append(candidates, existing...)
+
+	var problems []string
+	for index, cert := range certs {
+		if isSelfSignedCertificate(cert) {
+			// Self-signed roots have nothing to be validated against.
+			continue
+		}
+
+		certParent := findImportParent(cert, candidates)
+		if certParent == nil {
+			if parent == nil {
+				// Without an explicit parent, there is nothing to
+				// validate a partial chain against.
+				continue
+			}
+
+			// The caller told us which parent to validate against; report
+			// why it doesn't fit.
+			certParent = parent
+		}
+
+		for _, problem := range validateIssuerAgainstParent(cert, certParent) {
+			problems = append(problems, fmt.Sprintf("certificate %d (%v): %v", index, cert.Subject.String(), problem))
+		}
+	}
+
+	return problems, nil
+}
+
+// listIssuerCertificates returns the certificates of every issuer already
+// present on this mount.
+func (sc *storageContext) listIssuerCertificates() ([]*x509.Certificate, error) {
+	issuerIds, err := sc.listIssuers()
+	if err != nil {
+		return nil, fmt.Errorf("unable to list existing issuers: %w", err)
+	}
+
+	certs := make([]*x509.Certificate, 0, len(issuerIds))
+	for _, issuerId := range issuerIds {
+		entry, err := sc.fetchIssuerById(issuerId)
+		if err != nil {
+			return nil, fmt.Errorf("unable to fetch existing issuer %v: %w", issuerId, err)
+		}
+		cert, err := entry.GetCertificate()
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse existing issuer %v: %w", issuerId, err)
+		}
+		certs = append(certs, cert)
+	}
+
+	return certs, nil
+}
+
+// parseImportParentCertificate parses the optional parent_certificate
+// request parameter used for chain validation on import.
+func parseImportParentCertificate(parentPem string) (*x509.Certificate, error) {
+	if len(strings.TrimSpace(parentPem)) == 0 {
+		return nil, nil
+	}
+
+	parent, err := parseCertificateFromBytes([]byte(parentPem))
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse parent_certificate: %w", err)
+	}
+
+	return parent, nil
+}
+
+// findExpiredImportedIssuers returns a description of every certificate in
+// certs which is no longer valid as of now.
+func findExpiredImportedIssuers(certs []*x509.Certificate, now time.Time) []string {
+	var expired []string
+	for index, cert := range certs {
+		if now.After(cert.NotAfter) {
+			expired = append(expired, fmt.Sprintf("certificate %d (%v) expired at %v", index, cert.Subject.String(), cert.NotAfter.Format(time.RFC3339)))
+		}
+	}
+
+	return expired
+}
+
+// findRolesExceedingIssuerLifetime scans the roles on this mount and reports
+// those which reference one of the given issuers (directly or through the
+// default issuer) and whose maximum TTL exceeds the time remaining before that
+// issuer expires. Roles without an explicit max TTL are checked against
+// mountMaxTTL. Like checkForRolesReferencing, the scan is bounded and reports
+// whether it stopped early.
+func (sc *storageContext) findRolesExceedingIssuerLifetime(expirations map[issuing.IssuerID]time.Time, mountMaxTTL time.Duration, now time.Time) (roles []string, timeout bool, err error) {
+	if len(expirations) == 0 {
+		return nil, false, nil
+	}
+
+	roleEntries, err := sc.Storage.List(sc.Context, "role/")
+	if err != nil {
+		return nil, false, err
+	}
+
+	checkedRoles := 0
+	for _, roleName := range roleEntries {
+		entry, err := sc.Storage.Get(sc.Context, "role/"+roleName)
+		if err != nil {
+			return nil, false, err
+		}
+		if entry != nil { // If nil, someone deleted an entry since we haven't taken a lock here so just continue
+			var role issuing.RoleEntry
+			if err := entry.DecodeJSON(&role); err != nil {
+				return roles, false, err
+			}
+
+			reference := role.Issuer
+			if len(reference) == 0 {
+				reference = defaultRef
+			}
+
+			// Roles pointing at issuers which no longer resolve have bigger
+			// problems than their TTL; skip them here.
+			issuerId, err := sc.resolveIssuerReference(reference)
+			if err == nil {
+				if notAfter, ok := expirations[issuerId]; ok {
+					maxTTL := role.MaxTTL
+					if maxTTL == 0 {
+						maxTTL = mountMaxTTL
+					}
+
+					remaining := notAfter.Sub(now)
+					if maxTTL > remaining {
+						roles = append(roles, fmt.Sprintf("%v (max_ttl %v exceeds the %v remaining on issuer %v)", roleName, maxTTL, remaining.Truncate(time.Second), issuerId))
+						if len(roles) >= maxRolesToFindOnIssuerChange {
+							return roles, true, nil
+						}
+					}
+				}
+			}
+		}
+		checkedRoles = checkedRoles + 1
+		if checkedRoles >= maxRolesToScanOnIssuerChange {
+			return roles, true, nil
+		}
+	}
+
+	return roles, false, nil
+}
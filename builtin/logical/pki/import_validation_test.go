@@ -0,0 +1,451 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package pki
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+func genImportValidationCA(t *testing.T, template *x509.Certificate, parent *x509.Certificate, parentKey crypto.Signer) (*x509.Certificate, crypto.Signer, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	require.NoError(t, err)
+
+	template.SerialNumber = serial
+	template.NotBefore = time.Now().Add(-1 * time.Minute)
+	template.NotAfter = time.Now().Add(24 * time.Hour)
+	template.IsCA = true
+	template.BasicConstraintsValid = true
+	template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign
+
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, key.Public(), parentKey)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return cert, key, string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+func TestValidateIssuerAgainstParent(t *testing.T) {
+	t.Parallel()
+
+	root, rootKey, _ := genImportValidationCA(t, &x509.Certificate{
+		Subject:             pkix.Name{CommonName: "Root"},
+		MaxPathLen:          1,
+		PermittedDNSDomains: []string{"example.com"},
+		ExtKeyUsage:         []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, nil, nil)
+
+	good, _, _ := genImportValidationCA(t, &x509.Certificate{
+		Subject:             pkix.Name{CommonName: "Good Intermediate"},
+		MaxPathLen:          0,
+		MaxPathLenZero:      true,
+		PermittedDNSDomains: []string{"team.example.com"},
+		ExtKeyUsage:         []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, root, rootKey)
+	require.Empty(t, validateIssuerAgainstParent(good, root))
+
+	bad, badKey, _ := genImportValidationCA(t, &x509.Certificate{
+		Subject:             pkix.Name{CommonName: "Bad Intermediate"},
+		MaxPathLen:          1,
+		PermittedDNSDomains: []string{"example.org"},
+		ExtKeyUsage:         []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, root, rootKey)
+	problems := validateIssuerAgainstParent(bad, root)
+	require.Len(t, problems, 3, "expected path length, name constraint and EKU problems: %v", problems)
+
+	// A child without a path length constraint of its own is bound by the
+	// parent's and is valid beneath it.
+	limitedRoot, limitedRootKey, _ := genImportValidationCA(t, &x509.Certificate{
+		Subject:    pkix.Name{CommonName: "Limited Root"},
+		MaxPathLen: 2,
+	}, nil, nil)
+	unconstrained, _, _ := genImportValidationCA(t, &x509.Certificate{
+		Subject:    pkix.Name{CommonName: "Unconstrained Intermediate"},
+		MaxPathLen: -1,
+	}, limitedRoot, limitedRootKey)
+	require.Equal(t, -1, unconstrained.MaxPathLen)
+	require.Empty(t, validateIssuerAgainstParent(unconstrained, limitedRoot))
+
+	// Validating against the wrong parent should flag name and signature.
+	unrelated, _, _ := genImportValidationCA(t, &x509.Certificate{
+		Subject: pkix.Name{CommonName: "Unrelated"},
+	}, bad, badKey)
+	problems = validateIssuerAgainstParent(unrelated, root)
+	require.GreaterOrEqual(t, len(problems), 2, "expected issuer name and signature problems: %v", problems)
+}
+
+func TestValidateNameConstraintsAgainstParent(t *testing.T) {
+	t.Parallel()
+
+	mustParseCIDR := func(cidr string) *net.IPNet {
+		_, ipNet, err := net.ParseCIDR(cidr)
+		require.NoError(t, err)
+		return ipNet
+	}
+	mustParseURL := func(raw string) *url.URL {
+		u, err := url.Parse(raw)
+		require.NoError(t, err)
+		return u
+	}
+
+	parent := &x509.Certificate{
+		PermittedDNSDomains:     []string{"example.com"},
+		ExcludedDNSDomains:      []string{"secret.example.com"},
+		PermittedIPRanges:       []*net.IPNet{mustParseCIDR("10.0.0.0/8")},
+		ExcludedIPRanges:        []*net.IPNet{mustParseCIDR("10.1.0.0/16")},
+		PermittedEmailAddresses: []string{".example.com", "example.com"},
+		ExcludedEmailAddresses:  []string{"secret.example.com", "ceo@example.com"},
+		PermittedURIDomains:     []string{".example.com"},
+		ExcludedURIDomains:      []string{".secret.example.com"},
+	}
+
+	for name, tc := range map[string]struct {
+		child    *x509.Certificate
+		problems int
+	}{
+		"within constraints": {&x509.Certificate{
+			DNSNames:                []string{"www.example.com"},
+			PermittedDNSDomains:     []string{"team.example.com"},
+			IPAddresses:             []net.IP{net.ParseIP("10.2.0.1")},
+			PermittedIPRanges:       []*net.IPNet{mustParseCIDR("10.2.0.0/16")},
+			EmailAddresses:          []string{"alice@example.com"},
+			PermittedEmailAddresses: []string{".team.example.com", "bob@example.com"},
+			URIs:                    []*url.URL{mustParseURL("spiffe://web.example.com/app")},
+			PermittedURIDomains:     []string{".team.example.com"},
+		}, 0},
+		"wider IP range": {&x509.Certificate{
+			PermittedIPRanges: []*net.IPNet{mustParseCIDR("0.0.0.0/0")},
+		}, 1},
+		"wider email constraint": {&x509.Certificate{
+			PermittedEmailAddresses: []string{".org", "example.org"},
+		}, 2},
+		"email outside constraints": {&x509.Certificate{
+			EmailAddresses: []string{"alice@example.org"},
+		}, 1},
+		"excluded email": {&x509.Certificate{
+			EmailAddresses: []string{"ceo@example.com", "alice@secret.example.com"},
+		}, 2},
+		"wider URI constraint": {&x509.Certificate{
+			PermittedURIDomains: []string{".com", "example.com"},
+		}, 2},
+		"URI outside constraints": {&x509.Certificate{
+			URIs: []*url.URL{mustParseURL("spiffe://example.org/app")},
+		}, 1},
+		"excluded URI": {&x509.Certificate{
+			URIs: []*url.URL{mustParseURL("spiffe://db.secret.example.com/app")},
+		}, 1},
+		"permitted DNS domain inside excluded": {&x509.Certificate{
+			PermittedDNSDomains: []string{"db.secret.example.com"},
+		}, 1},
+		"permitted IP range inside excluded": {&x509.Certificate{
+			PermittedIPRanges: []*net.IPNet{mustParseCIDR("10.1.2.0/24")},
+		}, 1},
+		"permitted email inside excluded": {&x509.Certificate{
+			PermittedEmailAddresses: []string{"ceo@example.com", "secret.example.com"},
+		}, 2},
+		"permitted URI domain inside excluded": {&x509.Certificate{
+			PermittedURIDomains: []string{".db.secret.example.com"},
+		}, 1},
+	} {
+		t.Run(name, func(t *testing.T) {
+			problems := validateNameConstraintsAgainstParent(tc.child, parent)
+			require.Len(t, problems, tc.problems, "%v", problems)
+		})
+	}
+}
+
+func TestPKI_ImportIssuerChainValidation(t *testing.T) {
+	t.Parallel()
+
+	root, rootKey, rootPem := genImportValidationCA(t, &x509.Certificate{
+		Subject:        pkix.Name{CommonName: "Root"},
+		MaxPathLen:     0,
+		MaxPathLenZero: true,
+	}, nil, nil)
+	_, _, intPem := genImportValidationCA(t, &x509.Certificate{
+		Subject: pkix.Name{CommonName: "Intermediate"},
+	}, root, rootKey)
+
+	b, s := CreateBackendWithStorage(t)
+
+	// Enforced validation should refuse the bundle outright.
+	_, err := CBWrite(b, s, "issuers/import/cert", map[string]interface{}{
+		"pem_bundle":               intPem + rootPem,
+		"enforce_chain_validation": true,
+	})
+	require.ErrorContains(t, err, "path length constraint of zero")
+
+	// By default, problems are surfaced as warnings only.
+	resp, err := CBWrite(b, s, "issuers/import/cert", map[string]interface{}{
+		"pem_bundle":         intPem,
+		"parent_certificate": rootPem,
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	require.Len(t, resp.Data["imported_issuers"], 1)
+
+	found := false
+	for _, warning := range resp.Warnings {
+		if strings.HasPrefix(warning, "Chain validation:") {
+			found = true
+		}
+	}
+	require.True(t, found, "expected chain validation warning: %v", resp.Warnings)
+}
+
+func TestPKI_ImportIssuerChainCompletion(t *testing.T) {
+	t.Parallel()
+
+	b, s := CreateBackendWithStorage(t)
+
+	// Parents are fetched without holding the issuers lock.
+	var rootDer []byte
+	var lockHeld atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if b.issuersLock.TryLock() {
+			b.issuersLock.Unlock()
+		} else {
+			lockHeld.Store(true)
+		}
+		w.Header().Set("Content-Type", "application/pkix-cert")
+		w.Write(rootDer)
+	}))
+	defer server.Close()
+
+	root, rootKey, _ := genImportValidationCA(t, &x509.Certificate{
+		Subject: pkix.Name{CommonName: "Root"},
+	}, nil, nil)
+	rootDer = root.Raw
+	_, _, intPem := genImportValidationCA(t, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "Intermediate"},
+		IssuingCertificateURL: []string{server.URL + "/root.der"},
+	}, root, rootKey)
+
+	// Without fetching, the partial chain is imported with a warning.
+	resp, err := CBWrite(b, s, "issuers/import/cert", map[string]interface{}{
+		"pem_bundle": intPem,
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	require.Len(t, resp.Data["imported_issuers"], 1)
+	requireWarningWithPrefix(t, resp, "Incomplete chain:")
+	intId := resp.Data["imported_issuers"].([]string)[0]
+
+	// Fetching the parent completes the chain of the intermediate.
+	resp, err = CBWrite(b, s, "issuers/import/cert", map[string]interface{}{
+		"pem_bundle":                 intPem,
+		"fetch_issuing_certificates": true,
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	require.Len(t, resp.Data["imported_issuers"], 1)
+	require.Equal(t, []string{intId}, resp.Data["existing_issuers"])
+	requireWarningWithPrefix(t, resp, "Fetched and imported missing parent certificate:")
+	require.False(t, lockHeld.Load(), "issuers lock was held while fetching parents")
+	for _, warning := range resp.Warnings {
+		require.False(t, strings.HasPrefix(warning, "Incomplete chain:"), "unexpected warning: %v", warning)
+	}
+
+	resp, err = CBRead(b, s, "issuer/"+intId)
+	requireSuccessNonNilResponse(t, resp, err)
+	require.Len(t, resp.Data["ca_chain"], 2)
+
+	// Chains which loop back on themselves are refused.
+	keyA, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	keyB, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	templateA := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Loop A"},
+		NotBefore:             time.Now().Add(-1 * time.Minute),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}
+	templateB := &x509.Certificate{
+		SerialNumber:          big.NewInt(2),
+		Subject:               pkix.Name{CommonName: "Loop B"},
+		NotBefore:             templateA.NotBefore,
+		NotAfter:              templateA.NotAfter,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              templateA.KeyUsage,
+	}
+	derA, err := x509.CreateCertificate(rand.Reader, templateA, templateB, keyA.Public(), keyB)
+	require.NoError(t, err)
+	derB, err := x509.CreateCertificate(rand.Reader, templateB, templateA, keyB.Public(), keyA)
+	require.NoError(t, err)
+
+	_, err = CBWrite(b, s, "issuers/import/cert", map[string]interface{}{
+		"pem_bundle": string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: derA})) +
+			string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: derB})),
+	})
+	require.ErrorContains(t, err, "chain forms a cycle")
+}
+
+// TestCheckImportedIssuerChains_CrossSigned verifies that a bundle of many
+// cross-signed certificates, whose chains share ancestors, is checked without
+// walking every path through them.
+func TestCheckImportedIssuerChains_CrossSigned(t *testing.T) {
+	t.Parallel()
+
+	const layers, versions = 12, 3
+
+	root, rootKey, _ := genImportValidationCA(t, &x509.Certificate{
+		Subject: pkix.Name{CommonName: "Root"},
+	}, nil, nil)
+
+	// Every layer holds several certificates sharing a subject and key, each
+	// signed by the previous layer's key, so every certificate has all the
+	// certificates of the previous layer as parents: a certificate of the
+	// last layer has versions^layers paths to the root.
+	var certs []*x509.Certificate
+	parent, parentKey := root, crypto.Signer(rootKey)
+	for layer := 0; layer < layers; layer++ {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+
+		var layerCerts []*x509.Certificate
+		for version := 0; version < versions; version++ {
+			template := &x509.Certificate{
+				SerialNumber:          big.NewInt(int64(layer*versions + version + 1)),
+				Subject:               pkix.Name{CommonName: fmt.Sprintf("Layer %d", layer)},
+				NotBefore:             time.Now().Add(-1 * time.Minute),
+				NotAfter:              time.Now().Add(24 * time.Hour),
+				IsCA:                  true,
+				BasicConstraintsValid: true,
+				KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+			}
+			der, err := x509.CreateCertificate(rand.Reader, template, parent, key.Public(), parentKey)
+			require.NoError(t, err)
+			cert, err := x509.ParseCertificate(der)
+			require.NoError(t, err)
+			layerCerts = append(layerCerts, cert)
+		}
+		certs = append(certs, layerCerts...)
+		parent, parentKey = layerCerts[0], key
+	}
+
+	// Without the root, no chain is complete and every path is a dead end.
+	incomplete, cycles := checkImportedIssuerChains(certs, certs)
+	require.Len(t, incomplete, len(certs))
+	require.Empty(t, cycles)
+
+	graph := newImportChainGraph(append([]*x509.Certificate{root}, certs...))
+	for _, cert := range certs {
+		require.Equal(t, importChainComplete, graph.status(cert))
+	}
+	require.Len(t, graph.parents, len(certs), "expected the parents of each certificate to be looked up once")
+}
+
+func TestPKI_ImportIssuerExpiry(t *testing.T) {
+	t.Parallel()
+
+	b, s := CreateBackendWithStorage(t)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	expiredTemplate := &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "Expired Root"},
+		SerialNumber:          big.NewInt(1),
+		NotBefore:             time.Now().Add(-2 * time.Hour),
+		NotAfter:              time.Now().Add(-1 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, expiredTemplate, expiredTemplate, key.Public(), key)
+	require.NoError(t, err)
+	expiredPem := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+
+	// Expired CAs are refused unless explicitly allowed.
+	_, err = CBWrite(b, s, "issuers/import/cert", map[string]interface{}{
+		"pem_bundle": expiredPem,
+	})
+	require.ErrorContains(t, err, "allow_expired_ca")
+
+	resp, err := CBWrite(b, s, "issuers/import/cert", map[string]interface{}{
+		"pem_bundle":       expiredPem,
+		"allow_expired_ca": true,
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	require.Len(t, resp.Data["imported_issuers"], 1)
+	requireWarningWithPrefix(t, resp, "Imported expired CA certificate:")
+
+	// A role outliving a freshly imported default issuer should be called out.
+	_, err = CBWrite(b, s, "roles/long-lived", map[string]interface{}{
+		"allow_any_name": true,
+		"max_ttl":        "720h",
+	})
+	require.NoError(t, err)
+
+	_, _, rootPem := genImportValidationCA(t, &x509.Certificate{
+		Subject: pkix.Name{CommonName: "Short Root"},
+	}, nil, nil)
+	resp, err = CBWrite(b, s, "issuers/import/cert", map[string]interface{}{
+		"pem_bundle": rootPem,
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	_, err = CBWrite(b, s, "config/issuers", map[string]interface{}{
+		"default": resp.Data["imported_issuers"].([]string)[0],
+	})
+	require.NoError(t, err)
+
+	_, _, otherPem := genImportValidationCA(t, &x509.Certificate{
+		Subject: pkix.Name{CommonName: "Other Root"},
+	}, nil, nil)
+	resp, err = CBWrite(b, s, "issuers/import/cert", map[string]interface{}{
+		"pem_bundle": otherPem,
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	for _, warning := range resp.Warnings {
+		require.NotContains(t, warning, "long-lived", "role does not use the newly imported issuer")
+	}
+
+	// Re-importing the default issuer reports the role.
+	resp, err = CBWrite(b, s, "issuers/import/cert", map[string]interface{}{
+		"pem_bundle": rootPem,
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	requireWarningWithPrefix(t, resp, "The following roles have a max TTL exceeding")
+}
+
+func requireWarningWithPrefix(t *testing.T, resp *logical.Response, prefix string) {
+	t.Helper()
+
+	for _, warning := range resp.Warnings {
+		if strings.HasPrefix(warning, prefix) {
+			return
+		}
+	}
+	t.Fatalf("expected warning with prefix %q: %v", prefix, resp.Warnings)
+}
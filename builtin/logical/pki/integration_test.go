@@ -10,6 +10,7 @@ import (
 	"crypto/ed25519"
 	"crypto/rsa"
 	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/pem"
 	"fmt"
 	"testing"
@@ -481,6 +482,133 @@ func TestIntegration_AutoIssuer(t *testing.T) {
 }
 
 // TestLDAPAiaCrlUrls validates we can properly handle CRL urls that are ldap based.
+func TestIntegration_PerUseDefaultIssuers(t *testing.T) {
+	t.Parallel()
+	b, s := CreateBackendWithStorage(t)
+
+	resp, err := CBWrite(b, s, "root/generate/internal", map[string]interface{}{
+		"common_name": "Root X1",
+		"issuer_name": "root-1",
+		"key_type":    "ec",
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	issuerIdOne := resp.Data["issuer_id"]
+	certOne := parseCert(t, resp.Data["certificate"].(string))
+
+	resp, err = CBWrite(b, s, "root/generate/internal", map[string]interface{}{
+		"common_name": "Root X2",
+		"issuer_name": "root-2",
+		"key_type":    "ec",
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	issuerIdTwo := resp.Data["issuer_id"]
+
+	// Move issuance to the second root while keeping chain and CRL
+	// responses on the first.
+	resp, err = CBWrite(b, s, "config/issuers", map[string]interface{}{
+		"default":       "root-2",
+		"default_chain": "root-1",
+		"default_crl":   "root-1",
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	schema.ValidateResponse(t, schema.GetResponseSchema(t, b.Route("config/issuers"), logical.UpdateOperation), resp, true)
+	require.Equal(t, issuerIdTwo, resp.Data["default"])
+	require.Equal(t, issuerIdOne, resp.Data["default_chain"])
+	require.Equal(t, issuerIdOne, resp.Data["default_crl"])
+	require.Empty(t, resp.Data["default_ocsp"])
+
+	resp, err = CBRead(b, s, "cert/ca")
+	requireSuccessNonNilResponse(t, resp, err)
+	require.Equal(t, certOne.Raw, parseCert(t, resp.Data["certificate"].(string)).Raw)
+
+	crl := getParsedCrlFromBackend(t, b, s, "crl")
+	require.Equal(t, certOne.Subject.String(), crl.TBSCertList.Issuer.String())
+
+	_, err = CBWrite(b, s, "roles/testing", map[string]interface{}{
+		"allow_any_name": true,
+		"ttl":            "1h",
+	})
+	require.NoError(t, err)
+	resp, err = CBWrite(b, s, "issue/testing", map[string]interface{}{
+		"common_name": "example.com",
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	require.Equal(t, "CN=Root X2", parseCert(t, resp.Data["certificate"].(string)).Issuer.String())
+
+	// Updating only a per-use default leaves the default issuer alone.
+	resp, err = CBWrite(b, s, "config/issuers", map[string]interface{}{
+		"default_crl": "",
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	require.Equal(t, issuerIdTwo, resp.Data["default"])
+	require.Empty(t, resp.Data["default_crl"])
+
+	// Deleting an issuer reverts any per-use defaults referencing it.
+	_, err = CBDelete(b, s, "issuer/root-1")
+	require.NoError(t, err)
+	resp, err = CBRead(b, s, "config/issuers")
+	requireSuccessNonNilResponse(t, resp, err)
+	require.Equal(t, issuerIdTwo, resp.Data["default"])
+	require.Empty(t, resp.Data["default_chain"])
+}
+
+func TestIntegration_IssuerNameHistory(t *testing.T) {
+	t.Parallel()
+	b, s := CreateBackendWithStorage(t)
+
+	_, _, rootPem := genImportValidationCA(t, &x509.Certificate{
+		Subject: pkix.Name{CommonName: "Example Root CA"},
+	}, nil, nil)
+
+	// Imported issuers may be named after their certificate.
+	resp, err := CBWrite(b, s, "issuers/import/cert", map[string]interface{}{
+		"pem_bundle":          rootPem,
+		"derive_issuer_names": true,
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	issuerId := resp.Data["imported_issuers"].([]string)[0]
+
+	resp, err = CBRead(b, s, "issuer/"+issuerId)
+	requireSuccessNonNilResponse(t, resp, err)
+	derivedName := resp.Data["issuer_name"].(string)
+	require.Regexp(t, `^example-root-ca-[0-9a-f]{8}$`, derivedName)
+
+	// Renaming keeps the old name resolving to the same issuer.
+	resp, err = CBPatch(b, s, "issuer/"+derivedName, map[string]interface{}{
+		"issuer_name": "root-2024",
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	schema.ValidateResponse(t, schema.GetResponseSchema(t, b.Route("issuer/root-2024"), logical.PatchOperation), resp, true)
+	require.Equal(t, []string{derivedName}, resp.Data["previous_issuer_names"])
+
+	resp, err = CBRead(b, s, "issuer/"+derivedName)
+	requireSuccessNonNilResponse(t, resp, err)
+	require.Equal(t, issuing.IssuerID(issuerId), resp.Data["issuer_id"])
+	require.Equal(t, "root-2024", resp.Data["issuer_name"])
+
+	// Another issuer may claim the previous name, taking over resolution.
+	resp, err = CBWrite(b, s, "root/generate/internal", map[string]interface{}{
+		"common_name": "Root X2",
+		"issuer_name": "root-x2",
+		"key_type":    "ec",
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	otherId := resp.Data["issuer_id"]
+
+	resp, err = CBWrite(b, s, "issuer/root-x2", map[string]interface{}{
+		"issuer_name": derivedName,
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+
+	resp, err = CBRead(b, s, "issuer/"+derivedName)
+	requireSuccessNonNilResponse(t, resp, err)
+	require.Equal(t, otherId, resp.Data["issuer_id"])
+
+	resp, err = CBRead(b, s, "issuer/root-2024")
+	requireSuccessNonNilResponse(t, resp, err)
+	require.Empty(t, resp.Data["previous_issuer_names"])
+}
+
 func TestLDAPAiaCrlUrls(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,247 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package pki
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/hashicorp/go-cleanhttp"
+	"github.com/hashicorp/vault/builtin/logical/pki/issuing"
+	"github.com/hashicorp/vault/sdk/helper/certutil"
+	"github.com/hashicorp/vault/sdk/helper/errutil"
+)
+
+// issuancePolicyMaximumResponseSize bounds the responses we are willing to
+// read from the policy service.
+const issuancePolicyMaximumResponseSize = 64 * 1024
+
+// issuancePolicyRequest is the JSON body POSTed to the policy service,
+// describing the certificate about to be signed.
+type issuancePolicyRequest struct {
+	Operation      string    `json:"operation"`
+	Role           string    `json:"role"`
+	EntityID       string    `json:"entity_id"`
+	DisplayName    string    `json:"display_name"`
+	CommonName     string    `json:"common_name"`
+	DNSNames       []string  `json:"dns_names"`
+	EmailAddresses []string  `json:"email_addresses"`
+	IPAddresses    []string  `json:"ip_addresses"`
+	URIs           []string  `json:"uris"`
+	NotAfter       time.Time `json:"not_after"`
+	CSR            string    `json:"csr,omitempty"`
+}
+
+// issuancePolicyResponse is the decision of the policy service. Absent
+// fields leave the corresponding values of the request unchanged.
+type issuancePolicyResponse struct {
+	Allow          bool       `json:"allow"`
+	Reason         string     `json:"reason"`
+	CommonName     *string    `json:"common_name"`
+	DNSNames       *[]string  `json:"dns_names"`
+	EmailAddresses *[]string  `json:"email_addresses"`
+	IPAddresses    *[]string  `json:"ip_addresses"`
+	URIs           *[]string  `json:"uris"`
+	NotAfter       *time.Time `json:"not_after"`
+}
+
+func (r *issuancePolicyResponse) rewritesNames() bool {
+	return r.CommonName != nil || r.DNSNames != nil || r.EmailAddresses != nil || r.IPAddresses != nil || r.URIs != nil
+}
+
+// applyIssuancePolicy sends the parameters of a leaf certificate to the
+// policy service configured in config/issuance-policy, if any, and applies
+// its decision to them: denied requests fail, and the names and expiration
+// may be rewritten, though never past what the role allows.
+func applyIssuancePolicy(sc *storageContext, input *inputBundle, operation string, creation *certutil.CreationBundle) error {
+	config, err := getIssuancePolicyConfig(sc)
+	if err != nil {
+		return err
+	}
+	if config.URL == "" {
+		return nil
+	}
+
+	// Requests signed verbatim are issued with the names of their CSR,
+	// rather than those of the parameters.
+	params := creation.Params
+	policyReq := &issuancePolicyRequest{
+		Operation:      operation,
+		Role:           input.role.Name,
+		CommonName:     params.Subject.CommonName,
+		DNSNames:       params.DNSNames,
+		EmailAddresses: params.EmailAddresses,
+		IPAddresses:    ipAddressStrings(params.IPAddresses),
+		URIs:           uriStrings(params.URIs),
+		NotAfter:       params.NotAfter,
+	}
+	if creation.CSR != nil {
+		policyReq.CSR = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: creation.CSR.Raw}))
+		if params.UseCSRValues {
+			policyReq.CommonName = creation.CSR.Subject.CommonName
+			policyReq.DNSNames = creation.CSR.DNSNames
+			policyReq.EmailAddresses = creation.CSR.EmailAddresses
+			policyReq.IPAddresses = ipAddressStrings(creation.CSR.IPAddresses)
+			policyReq.URIs = uriStrings(creation.CSR.URIs)
+		}
+	}
+	if input.req != nil {
+		policyReq.EntityID = input.req.EntityID
+		policyReq.DisplayName = input.req.DisplayName
+	}
+
+	decision, err := queryIssuancePolicy(sc, config, policyReq)
+	if err != nil {
+		return errutil.InternalError{Err: fmt.Sprintf("failed querying issuance policy service: %v", err)}
+	}
+	if !decision.Allow {
+		if decision.Reason != "" {
+			return errutil.UserError{Err: fmt.Sprintf("certificate request denied by issuance policy: %v", decision.Reason)}
+		}
+		return errutil.UserError{Err: "certificate request denied by issuance policy"}
+	}
+
+	if decision.rewritesNames() && params.UseCSRValues {
+		return errutil.InternalError{Err: "issuance policy service rewrote the names of a request signed verbatim"}
+	}
+	if decision.CommonName != nil {
+		params.Subject.CommonName = *decision.CommonName
+	}
+	if decision.DNSNames != nil {
+		params.DNSNames = *decision.DNSNames
+	}
+	if decision.EmailAddresses != nil {
+		params.EmailAddresses = *decision.EmailAddresses
+	}
+	if decision.IPAddresses != nil {
+		params.IPAddresses = nil
+		for _, ipStr := range *decision.IPAddresses {
+			ip := net.ParseIP(ipStr)
+			if ip == nil {
+				return errutil.InternalError{Err: fmt.Sprintf("issuance policy service returned an invalid IP address %q", ipStr)}
+			}
+			params.IPAddresses = append(params.IPAddresses, ip)
+		}
+	}
+	if decision.URIs != nil {
+		params.URIs = nil
+		for _, uriStr := range *decision.URIs {
+			uri, err := url.Parse(uriStr)
+			if err != nil {
+				return errutil.InternalError{Err: fmt.Sprintf("issuance policy service returned an invalid URI %q: %v", uriStr, err)}
+			}
+			params.URIs = append(params.URIs, uri)
+		}
+	}
+	if decision.rewritesNames() {
+		if err := validateIssuancePolicyNames(sc, input, params); err != nil {
+			return err
+		}
+	}
+	if decision.NotAfter != nil {
+		if decision.NotAfter.After(params.NotAfter) {
+			return errutil.InternalError{Err: fmt.Sprintf("issuance policy service extended the expiration of the certificate to %v, past %v", decision.NotAfter.Format(time.RFC3339), params.NotAfter.Format(time.RFC3339))}
+		}
+		params.NotAfter = *decision.NotAfter
+	}
+
+	return nil
+}
+
+// validateIssuancePolicyNames checks the names rewritten by the policy
+// service against the role, as they were checked when the request was made.
+func validateIssuancePolicyNames(sc *storageContext, input *inputBundle, params *certutil.CreationParameters) error {
+	role := input.role
+	entityInfo := issuing.NewEntityInfoFromReq(input.req)
+
+	if cn := params.Subject.CommonName; cn != "" {
+		if badName := issuing.ValidateCommonName(sc.System(), role, entityInfo, cn); badName != "" {
+			return errutil.InternalError{Err: fmt.Sprintf("issuance policy service rewrote the common name to %s, which is not allowed by this role", badName)}
+		}
+	}
+	if badName := issuing.ValidateNames(sc.System(), role, entityInfo, params.DNSNames); badName != "" {
+		return errutil.InternalError{Err: fmt.Sprintf("issuance policy service rewrote the subject alternate names to include %s, which is not allowed by this role", badName)}
+	}
+	if badName := issuing.ValidateNames(sc.System(), role, entityInfo, params.EmailAddresses); badName != "" {
+		return errutil.InternalError{Err: fmt.Sprintf("issuance policy service rewrote the email addresses to include %s, which is not allowed by this role", badName)}
+	}
+	if len(params.IPAddresses) > 0 && !role.AllowIPSANs {
+		return errutil.InternalError{Err: "issuance policy service rewrote the IP Subject Alternative Names, which are not allowed by this role"}
+	}
+	for _, uri := range params.URIs {
+		if !issuing.ValidateURISAN(sc.System(), role, entityInfo, uri.String()) {
+			return errutil.InternalError{Err: fmt.Sprintf("issuance policy service rewrote the URI Subject Alternative Names to include %s, which is not allowed by this role", uri)}
+		}
+	}
+
+	return nil
+}
+
+func ipAddressStrings(ips []net.IP) []string {
+	result := make([]string, 0, len(ips))
+	for _, ip := range ips {
+		result = append(result, ip.String())
+	}
+	return result
+}
+
+func uriStrings(uris []*url.URL) []string {
+	result := make([]string, 0, len(uris))
+	for _, uri := range uris {
+		result = append(result, uri.String())
+	}
+	return result
+}
+
+func queryIssuancePolicy(sc *storageContext, config *issuancePolicyConfigEntry, policyReq *issuancePolicyRequest) (*issuancePolicyResponse, error) {
+	body, err := json.Marshal(policyReq)
+	if err != nil {
+		return nil, err
+	}
+
+	client := cleanhttp.DefaultClient()
+	client.Timeout = config.Timeout
+	if config.CACertificate != "" {
+		roots := x509.NewCertPool()
+		roots.AppendCertsFromPEM([]byte(config.CACertificate))
+		transport := cleanhttp.DefaultTransport()
+		transport.TLSClientConfig = &tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS12}
+		client.Transport = transport
+	}
+
+	req, err := http.NewRequestWithContext(sc.Context, http.MethodPost, config.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, issuancePolicyMaximumResponseSize))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %v: %s", resp.StatusCode, bytes.TrimSpace(respBody))
+	}
+
+	var decision issuancePolicyResponse
+	if err := json.Unmarshal(respBody, &decision); err != nil {
+		return nil, fmt.Errorf("failed decoding response: %w", err)
+	}
+	return &decision, nil
+}
@@ -0,0 +1,210 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package pki
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakePolicyService records the requests sent to it and answers with the
+// decision returned by its decide function.
+type fakePolicyService struct {
+	lock     sync.Mutex
+	requests []issuancePolicyRequest
+	decide   func(req *issuancePolicyRequest) map[string]interface{}
+}
+
+func (p *fakePolicyService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req issuancePolicyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	p.lock.Lock()
+	p.requests = append(p.requests, req)
+	decide := p.decide
+	p.lock.Unlock()
+
+	json.NewEncoder(w).Encode(decide(&req))
+}
+
+func (p *fakePolicyService) lastRequest() issuancePolicyRequest {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.requests[len(p.requests)-1]
+}
+
+func TestIssuancePolicy(t *testing.T) {
+	t.Parallel()
+	b, s := CreateBackendWithStorage(t)
+
+	_, err := CBWrite(b, s, "root/generate/internal", map[string]interface{}{
+		"common_name": "Root X1",
+		"key_type":    "ec",
+		"ttl":         "720h",
+	})
+	require.NoError(t, err)
+	_, err = CBWrite(b, s, "roles/web", map[string]interface{}{
+		"allowed_domains":  "example.com",
+		"allow_subdomains": true,
+		"key_type":         "ec",
+		"ttl":              "2h",
+	})
+	require.NoError(t, err)
+
+	service := &fakePolicyService{}
+	server := httptest.NewTLSServer(service)
+	defer server.Close()
+	caPem := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}))
+
+	_, err = CBWrite(b, s, "config/issuance-policy", map[string]interface{}{
+		"url": "http://policy.example.com",
+	})
+	require.Error(t, err)
+	_, err = CBWrite(b, s, "config/issuance-policy", map[string]interface{}{
+		"url":            server.URL,
+		"ca_certificate": "not a certificate",
+	})
+	require.Error(t, err)
+
+	// Without the service's CA, the callout fails and so does issuance.
+	_, err = CBWrite(b, s, "config/issuance-policy", map[string]interface{}{
+		"url": server.URL,
+	})
+	require.NoError(t, err)
+	service.decide = func(*issuancePolicyRequest) map[string]interface{} {
+		return map[string]interface{}{"allow": true}
+	}
+	_, err = CBWrite(b, s, "issue/web", map[string]interface{}{
+		"common_name": "www.example.com",
+	})
+	require.ErrorContains(t, err, "failed querying issuance policy service")
+
+	resp, err := CBWrite(b, s, "config/issuance-policy", map[string]interface{}{
+		"ca_certificate": caPem,
+		"timeout":        "5s",
+	})
+	require.NoError(t, err)
+	require.Equal(t, server.URL, resp.Data["url"])
+	require.Equal(t, int64(5), resp.Data["timeout"])
+
+	// Allowed requests are issued unchanged.
+	resp, err = CBWrite(b, s, "issue/web", map[string]interface{}{
+		"common_name": "www.example.com",
+		"ip_sans":     "192.0.2.1",
+	})
+	require.NoError(t, err)
+	cert := parseCert(t, resp.Data["certificate"].(string))
+	require.Equal(t, "www.example.com", cert.Subject.CommonName)
+	policyReq := service.lastRequest()
+	require.Equal(t, "issue", policyReq.Operation)
+	require.Equal(t, "web", policyReq.Role)
+	require.Equal(t, "www.example.com", policyReq.CommonName)
+	require.Equal(t, []string{"www.example.com"}, policyReq.DNSNames)
+	require.Equal(t, []string{"192.0.2.1"}, policyReq.IPAddresses)
+	require.WithinDuration(t, cert.NotAfter, policyReq.NotAfter, time.Second)
+	require.Empty(t, policyReq.CSR)
+
+	// Denied requests fail with the reason of the service.
+	service.decide = func(*issuancePolicyRequest) map[string]interface{} {
+		return map[string]interface{}{"allow": false, "reason": "www is owned by another team"}
+	}
+	_, err = CBWrite(b, s, "issue/web", map[string]interface{}{
+		"common_name": "www.example.com",
+	})
+	require.ErrorContains(t, err, "www is owned by another team")
+
+	// Names and expiration may be rewritten.
+	notAfter := time.Now().Add(30 * time.Minute).Truncate(time.Second).UTC()
+	service.decide = func(*issuancePolicyRequest) map[string]interface{} {
+		return map[string]interface{}{
+			"allow":        true,
+			"dns_names":    []string{"www.example.com", "www.team.example.com"},
+			"ip_addresses": []string{},
+			"not_after":    notAfter,
+		}
+	}
+	_, _, csrPem := generateCSR(t, &x509.CertificateRequest{
+		Subject: pkix.Name{CommonName: "www.example.com"},
+	}, "ec", 256)
+	resp, err = CBWrite(b, s, "sign/web", map[string]interface{}{
+		"common_name": "www.example.com",
+		"ip_sans":     "192.0.2.1",
+		"csr":         csrPem,
+	})
+	require.NoError(t, err)
+	cert = parseCert(t, resp.Data["certificate"].(string))
+	require.Equal(t, []string{"www.example.com", "www.team.example.com"}, cert.DNSNames)
+	require.Empty(t, cert.IPAddresses)
+	require.Equal(t, notAfter, cert.NotAfter)
+	policyReq = service.lastRequest()
+	require.Equal(t, "sign", policyReq.Operation)
+	require.Equal(t, strings.TrimSpace(csrPem), strings.TrimSpace(policyReq.CSR))
+
+	// Rewritten names are still subject to the role.
+	service.decide = func(*issuancePolicyRequest) map[string]interface{} {
+		return map[string]interface{}{
+			"allow":     true,
+			"dns_names": []string{"www.example.com", "www.example.org"},
+		}
+	}
+	_, err = CBWrite(b, s, "issue/web", map[string]interface{}{
+		"common_name": "www.example.com",
+	})
+	require.ErrorContains(t, err, "www.example.org, which is not allowed by this role")
+	service.decide = func(*issuancePolicyRequest) map[string]interface{} {
+		return map[string]interface{}{"allow": true, "common_name": "evil.example.net"}
+	}
+	_, err = CBWrite(b, s, "issue/web", map[string]interface{}{
+		"common_name": "www.example.com",
+	})
+	require.ErrorContains(t, err, "common name to evil.example.net")
+	service.decide = func(*issuancePolicyRequest) map[string]interface{} {
+		return map[string]interface{}{"allow": true, "uris": []string{"spiffe://example.com/web"}}
+	}
+	_, err = CBWrite(b, s, "issue/web", map[string]interface{}{
+		"common_name": "www.example.com",
+	})
+	require.ErrorContains(t, err, "spiffe://example.com/web, which is not allowed by this role")
+
+	// The expiration cannot be extended.
+	service.decide = func(*issuancePolicyRequest) map[string]interface{} {
+		return map[string]interface{}{"allow": true, "not_after": time.Now().Add(24 * time.Hour)}
+	}
+	_, err = CBWrite(b, s, "issue/web", map[string]interface{}{
+		"common_name": "www.example.com",
+	})
+	require.ErrorContains(t, err, "extended the expiration")
+
+	// CA certificates are not subject to the policy.
+	_, err = CBWrite(b, s, "root/generate/internal", map[string]interface{}{
+		"common_name": "Root X2",
+		"key_type":    "ec",
+		"ttl":         "720h",
+		"issuer_name": "x2",
+	})
+	require.NoError(t, err)
+
+	// Disabling the callout lets requests through again.
+	_, err = CBWrite(b, s, "config/issuance-policy", map[string]interface{}{
+		"url": "",
+	})
+	require.NoError(t, err)
+	_, err = CBWrite(b, s, "issue/web", map[string]interface{}{
+		"common_name": "www.example.com",
+	})
+	require.NoError(t, err)
+}
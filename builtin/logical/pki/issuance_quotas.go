@@ -0,0 +1,290 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package pki
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/vault/builtin/logical/pki/parsing"
+	"github.com/hashicorp/vault/sdk/helper/consts"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// pathCommonNameQuota holds, keyed by the hash of a lowercased common name,
+// the serials and expiry of the certificates issued with that common name,
+// so that max_active_per_common_name can be enforced without scanning every
+// stored certificate.
+const pathCommonNameQuota = "quota/common-name/"
+
+type issuanceQuotaWindow struct {
+	start time.Time
+	count int
+}
+
+type issuanceQuotaLimit struct {
+	key string
+	max int
+}
+
+// issuanceQuotas counts the issuances of each role and entity over the
+// configured window, and the issuances of each common name in progress.
+type issuanceQuotas struct {
+	lock    sync.Mutex
+	windows map[string]*issuanceQuotaWindow
+
+	// commonNameLock serializes the updates to the common name quota
+	// entries in storage and guards pendingCommonNames.
+	commonNameLock     sync.Mutex
+	pendingCommonNames map[string]int
+}
+
+func newIssuanceQuotas() *issuanceQuotas {
+	return &issuanceQuotas{
+		windows:            make(map[string]*issuanceQuotaWindow),
+		pendingCommonNames: make(map[string]int),
+	}
+}
+
+func (q *issuanceQuotas) reset() {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	q.windows = make(map[string]*issuanceQuotaWindow)
+}
+
+// reserve counts one issuance against every limit, unless one of them is
+// already exhausted for the current window, in which case its key is
+// returned and nothing is counted.
+func (q *issuanceQuotas) reserve(now time.Time, window time.Duration, limits []issuanceQuotaLimit) (string, bool) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	for key, current := range q.windows {
+		if now.Sub(current.start) >= window {
+			delete(q.windows, key)
+		}
+	}
+
+	for _, limit := range limits {
+		if current, ok := q.windows[limit.key]; ok && current.count >= limit.max {
+			return limit.key, false
+		}
+	}
+
+	for _, limit := range limits {
+		current, ok := q.windows[limit.key]
+		if !ok {
+			current = &issuanceQuotaWindow{start: now}
+			q.windows[limit.key] = current
+		}
+		current.count++
+	}
+
+	return "", true
+}
+
+// release uncounts an issuance reserved at reservedAt, unless the window it
+// was counted in has since ended.
+func (q *issuanceQuotas) release(reservedAt time.Time, limits []issuanceQuotaLimit) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	for _, limit := range limits {
+		if current, ok := q.windows[limit.key]; ok && !current.start.After(reservedAt) && current.count > 0 {
+			current.count--
+		}
+	}
+}
+
+// issuanceReservation is the quota held by an issuance in progress. It is
+// committed once the certificate is signed, or released when it could not
+// be.
+type issuanceReservation struct {
+	b          *backend
+	limits     []issuanceQuotaLimit
+	reservedAt time.Time
+	commonName string
+}
+
+// reserveIssuance counts the issuance of a certificate with commonName
+// through the role of input against the configured quotas, returning an
+// error when one of them is exhausted. This is called before the certificate
+// is signed, so that no certificate is signed (nor, with CT submission,
+// logged) beyond the quotas. The reservation is nil when no quota applies.
+func (b *backend) reserveIssuance(sc *storageContext, input *inputBundle, commonName string) (*issuanceReservation, error) {
+	config, err := getIssuanceQuotasConfig(sc)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &issuanceReservation{b: b}
+	if config.MaxIssuancesPerRole > 0 && input.role.Name != "" {
+		r.limits = append(r.limits, issuanceQuotaLimit{key: "role/" + input.role.Name, max: config.MaxIssuancesPerRole})
+	}
+	if config.MaxIssuancesPerEntity > 0 && input.req != nil && input.req.EntityID != "" {
+		r.limits = append(r.limits, issuanceQuotaLimit{key: "entity/" + input.req.EntityID, max: config.MaxIssuancesPerEntity})
+	}
+	if config.MaxActivePerCommonName > 0 && commonName != "" {
+		r.commonName = strings.ToLower(commonName)
+	}
+	if len(r.limits) == 0 && r.commonName == "" {
+		return nil, nil
+	}
+
+	// Issuances are counted by the active node, so that the quotas hold
+	// for the whole cluster rather than for each of its nodes.
+	if b.System().ReplicationState().HasState(consts.ReplicationPerformanceStandby) {
+		return nil, logical.ErrReadOnly
+	}
+
+	if len(r.limits) > 0 {
+		r.reservedAt = time.Now()
+		exhausted, ok := b.issuanceQuotas.reserve(r.reservedAt, config.Window, r.limits)
+		if !ok {
+			kind, name, _ := strings.Cut(exhausted, "/")
+			return nil, logical.CodedError(http.StatusTooManyRequests, fmt.Sprintf("issuance quota of %v %v exceeded; retry after the current window of %v", kind, name, config.Window))
+		}
+	}
+
+	if r.commonName != "" {
+		if err := b.reserveCommonName(sc, config, r.commonName); err != nil {
+			b.issuanceQuotas.release(r.reservedAt, r.limits)
+			return nil, err
+		}
+	}
+
+	return r, nil
+}
+
+// release gives back the quota of an issuance which did not happen.
+func (r *issuanceReservation) release() {
+	if r == nil {
+		return
+	}
+
+	r.b.issuanceQuotas.release(r.reservedAt, r.limits)
+	if r.commonName != "" {
+		r.b.issuanceQuotas.commonNameLock.Lock()
+		defer r.b.issuanceQuotas.commonNameLock.Unlock()
+		r.b.issuanceQuotas.releasePendingCommonName(r.commonName)
+	}
+}
+
+// commit records cert, the certificate issued under the reservation, against
+// the active certificates of its common name.
+func (r *issuanceReservation) commit(sc *storageContext, cert *x509.Certificate) error {
+	if r == nil || r.commonName == "" {
+		return nil
+	}
+
+	q := r.b.issuanceQuotas
+	q.commonNameLock.Lock()
+	defer q.commonNameLock.Unlock()
+	defer q.releasePendingCommonName(r.commonName)
+
+	path := commonNameQuotaPath(r.commonName)
+	active, err := activeCommonNameCerts(sc, path)
+	if err != nil {
+		return err
+	}
+
+	entry := commonNameQuotaEntry{
+		Certificates: append(active, commonNameQuotaCert{
+			Serial:   parsing.NormalizeSerialForStorageFromBigInt(cert.SerialNumber),
+			NotAfter: cert.NotAfter,
+		}),
+	}
+	json, err := logical.StorageEntryJSON(path, entry)
+	if err != nil {
+		return fmt.Errorf("failed creating common name quota entry: %w", err)
+	}
+	if err := sc.Storage.Put(sc.Context, json); err != nil {
+		return fmt.Errorf("unable to store common name quota: %w", err)
+	}
+
+	return nil
+}
+
+// Callers must hold commonNameLock.
+func (q *issuanceQuotas) releasePendingCommonName(commonName string) {
+	q.pendingCommonNames[commonName]--
+	if q.pendingCommonNames[commonName] <= 0 {
+		delete(q.pendingCommonNames, commonName)
+	}
+}
+
+type commonNameQuotaEntry struct {
+	Certificates []commonNameQuotaCert `json:"certificates"`
+}
+
+type commonNameQuotaCert struct {
+	Serial   string    `json:"serial"`
+	NotAfter time.Time `json:"not_after"`
+}
+
+func commonNameQuotaPath(commonName string) string {
+	hash := sha256.Sum256([]byte(strings.ToLower(commonName)))
+	return pathCommonNameQuota + hex.EncodeToString(hash[:])
+}
+
+// activeCommonNameCerts returns the certificates recorded at path which have
+// neither expired nor been revoked since.
+func activeCommonNameCerts(sc *storageContext, path string) ([]commonNameQuotaCert, error) {
+	var entry commonNameQuotaEntry
+	storageEntry, err := sc.Storage.Get(sc.Context, path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to fetch common name quota: %w", err)
+	}
+	if storageEntry != nil {
+		if err := storageEntry.DecodeJSON(&entry); err != nil {
+			return nil, fmt.Errorf("unable to decode common name quota: %w", err)
+		}
+	}
+
+	now := time.Now()
+	var active []commonNameQuotaCert
+	for _, recorded := range entry.Certificates {
+		if !recorded.NotAfter.After(now) {
+			continue
+		}
+		revoked, err := sc.Storage.Get(sc.Context, revokedPath+recorded.Serial)
+		if err != nil {
+			return nil, fmt.Errorf("unable to fetch revocation status of %v: %w", recorded.Serial, err)
+		}
+		if revoked != nil {
+			continue
+		}
+		active = append(active, recorded)
+	}
+
+	return active, nil
+}
+
+// reserveCommonName counts an issuance in progress with commonName, returning
+// an error instead when its active certificates, along with the issuances of
+// it in progress, already reach max_active_per_common_name.
+func (b *backend) reserveCommonName(sc *storageContext, config *issuanceQuotasConfigEntry, commonName string) error {
+	q := b.issuanceQuotas
+	q.commonNameLock.Lock()
+	defer q.commonNameLock.Unlock()
+
+	active, err := activeCommonNameCerts(sc, commonNameQuotaPath(commonName))
+	if err != nil {
+		return err
+	}
+
+	if count := len(active) + q.pendingCommonNames[commonName]; count >= config.MaxActivePerCommonName {
+		return logical.CodedError(http.StatusTooManyRequests, fmt.Sprintf("common name %v already has %d active certificates, the maximum allowed; revoke one or wait for one to expire", commonName, count))
+	}
+
+	q.pendingCommonNames[commonName]++
+	return nil
+}
@@ -0,0 +1,162 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package pki
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+func requireQuotaExceeded(t *testing.T, err error) {
+	t.Helper()
+	require.Error(t, err)
+	coded, ok := err.(logical.HTTPCodedError)
+	require.True(t, ok, "expected a coded error, got: %v", err)
+	require.Equal(t, http.StatusTooManyRequests, coded.Code())
+}
+
+func TestIssuanceQuotas_PerRole(t *testing.T) {
+	t.Parallel()
+	b, s := CreateBackendWithStorage(t)
+
+	_, err := CBWrite(b, s, "root/generate/internal", map[string]interface{}{
+		"common_name": "Root X1",
+		"key_type":    "ec",
+		"ttl":         "720h",
+	})
+	require.NoError(t, err)
+	for _, role := range []string{"web", "db"} {
+		_, err = CBWrite(b, s, "roles/"+role, map[string]interface{}{
+			"allow_any_name": true,
+			"key_type":       "ec",
+			"no_store":       true,
+		})
+		require.NoError(t, err)
+	}
+
+	_, err = CBWrite(b, s, "config/issuance-quotas", map[string]interface{}{
+		"max_issuances_per_role": -1,
+	})
+	require.Error(t, err)
+	_, err = CBWrite(b, s, "config/issuance-quotas", map[string]interface{}{
+		"window": 0,
+	})
+	require.Error(t, err)
+
+	resp, err := CBWrite(b, s, "config/issuance-quotas", map[string]interface{}{
+		"max_issuances_per_role": 2,
+	})
+	requireSuccessNonNilResponse(t, resp, err, "failed configuring quotas")
+	require.Equal(t, 2, resp.Data["max_issuances_per_role"])
+	require.Equal(t, int64(3600), resp.Data["window"])
+
+	for i := 0; i < 2; i++ {
+		_, err = CBWrite(b, s, "issue/web", map[string]interface{}{"common_name": "www.example.com"})
+		require.NoError(t, err)
+	}
+	_, err = CBWrite(b, s, "issue/web", map[string]interface{}{"common_name": "www.example.com"})
+	requireQuotaExceeded(t, err)
+
+	// Other roles have their own quota.
+	_, err = CBWrite(b, s, "issue/db", map[string]interface{}{"common_name": "db.example.com"})
+	require.NoError(t, err)
+
+	// Counts start anew with the next window.
+	_, ok := b.issuanceQuotas.reserve(time.Now().Add(2*time.Hour), time.Hour, []issuanceQuotaLimit{{key: "role/web", max: 2}})
+	require.True(t, ok)
+}
+
+func TestIssuanceQuotas_ActivePerCommonName(t *testing.T) {
+	t.Parallel()
+	b, s := CreateBackendWithStorage(t)
+
+	_, err := CBWrite(b, s, "root/generate/internal", map[string]interface{}{
+		"common_name": "Root X1",
+		"key_type":    "ec",
+		"ttl":         "720h",
+	})
+	require.NoError(t, err)
+	_, err = CBWrite(b, s, "roles/web", map[string]interface{}{
+		"allow_any_name": true,
+		"key_type":       "ec",
+	})
+	require.NoError(t, err)
+	_, err = CBWrite(b, s, "config/issuance-quotas", map[string]interface{}{
+		"max_active_per_common_name": 1,
+	})
+	require.NoError(t, err)
+
+	resp, err := CBWrite(b, s, "issue/web", map[string]interface{}{"common_name": "www.example.com"})
+	requireSuccessNonNilResponse(t, resp, err, "failed issuing first certificate")
+	serial := resp.Data["serial_number"].(string)
+
+	// Common names are compared case-insensitively.
+	_, err = CBWrite(b, s, "issue/web", map[string]interface{}{"common_name": "WWW.example.com"})
+	requireQuotaExceeded(t, err)
+
+	_, err = CBWrite(b, s, "issue/web", map[string]interface{}{"common_name": "api.example.com"})
+	require.NoError(t, err)
+
+	// Revoking the active certificate frees up the quota.
+	_, err = CBWrite(b, s, "revoke", map[string]interface{}{"serial_number": serial})
+	require.NoError(t, err)
+	_, err = CBWrite(b, s, "issue/web", map[string]interface{}{"common_name": "www.example.com"})
+	require.NoError(t, err)
+}
+
+func TestIssuanceQuotas_CountedBeforeSigning(t *testing.T) {
+	t.Parallel()
+	b, s := CreateBackendWithStorage(t)
+
+	_, err := CBWrite(b, s, "root/generate/internal", map[string]interface{}{
+		"common_name": "Root X1",
+		"key_type":    "ec",
+		"ttl":         "720h",
+	})
+	require.NoError(t, err)
+	_, err = CBWrite(b, s, "roles/web", map[string]interface{}{
+		"allow_any_name": true,
+		"key_type":       "ec",
+		"ct_submission":  true,
+	})
+	require.NoError(t, err)
+
+	log := &fakeCTLog{id: 1, fail: true}
+	server := httptest.NewServer(log)
+	defer server.Close()
+	_, err = CBWrite(b, s, "config/ct", map[string]interface{}{
+		"log_urls": []string{server.URL},
+	})
+	require.NoError(t, err)
+	_, err = CBWrite(b, s, "config/issuance-quotas", map[string]interface{}{
+		"max_issuances_per_role":     1,
+		"max_active_per_common_name": 1,
+	})
+	require.NoError(t, err)
+
+	// Failed issuances give their quota back.
+	_, err = CBWrite(b, s, "issue/web", map[string]interface{}{"common_name": "www.example.com"})
+	require.ErrorContains(t, err, "failed submitting precertificate")
+
+	log.fail = false
+	_, err = CBWrite(b, s, "issue/web", map[string]interface{}{"common_name": "www.example.com"})
+	require.NoError(t, err)
+	require.Len(t, log.precerts, 1)
+
+	// Certificates beyond the quotas are neither signed nor logged.
+	_, err = CBWrite(b, s, "issue/web", map[string]interface{}{"common_name": "api.example.com"})
+	requireQuotaExceeded(t, err)
+	_, err = CBWrite(b, s, "config/issuance-quotas", map[string]interface{}{
+		"max_active_per_common_name": 1,
+	})
+	require.NoError(t, err)
+	_, err = CBWrite(b, s, "issue/web", map[string]interface{}{"common_name": "www.example.com"})
+	requireQuotaExceeded(t, err)
+	require.Len(t, log.precerts, 1)
+}
@@ -23,6 +23,49 @@ type IssuerConfigEntry struct {
 	fetchedDefault             IssuerID `json:"-"`
 	DefaultIssuerId            IssuerID `json:"default"`
 	DefaultFollowsLatestIssuer bool     `json:"default_follows_latest_issuer"`
+
+	// Per-use overrides of the default issuer. When unset, each of these
+	// follows DefaultIssuerId; see the accessors below. Like fetchedDefault,
+	// the fetched values of the effective defaults are tracked so that
+	// modification times can be updated when they change.
+	fetchedCRLDefault    IssuerID `json:"-"`
+	fetchedChainDefault  IssuerID `json:"-"`
+	DefaultCRLIssuerId   IssuerID `json:"default_crl,omitempty"`
+	DefaultChainIssuerId IssuerID `json:"default_chain,omitempty"`
+	DefaultOCSPIssuerId  IssuerID `json:"default_ocsp,omitempty"`
+}
+
+// CRLIssuerId returns the issuer whose CRL is served from the default CRL
+// paths and which is preferred when signing CRLs shared by several issuers.
+func (c *IssuerConfigEntry) CRLIssuerId() IssuerID {
+	if len(c.DefaultCRLIssuerId) > 0 {
+		return c.DefaultCRLIssuerId
+	}
+	return c.DefaultIssuerId
+}
+
+// ChainIssuerId returns the issuer served from the default CA and CA chain
+// paths.
+func (c *IssuerConfigEntry) ChainIssuerId() IssuerID {
+	if len(c.DefaultChainIssuerId) > 0 {
+		return c.DefaultChainIssuerId
+	}
+	return c.DefaultIssuerId
+}
+
+// OCSPIssuerId returns the issuer used to sign OCSP responses for requests
+// which don't match any issuer on this mount.
+func (c *IssuerConfigEntry) OCSPIssuerId() IssuerID {
+	if len(c.DefaultOCSPIssuerId) > 0 {
+		return c.DefaultOCSPIssuerId
+	}
+	return c.DefaultIssuerId
+}
+
+// IsDefaultForAnyUse returns true if the issuer is the default for issuance
+// or has been selected as the default for any other use.
+func (c *IssuerConfigEntry) IsDefaultForAnyUse(id IssuerID) bool {
+	return c.DefaultIssuerId == id || c.DefaultCRLIssuerId == id || c.DefaultChainIssuerId == id || c.DefaultOCSPIssuerId == id
 }
 
 func GetIssuersConfig(ctx context.Context, s logical.Storage) (*IssuerConfigEntry, error) {
@@ -38,6 +81,8 @@ func GetIssuersConfig(ctx context.Context, s logical.Storage) (*IssuerConfigEntr
 		}
 	}
 	issuerConfig.fetchedDefault = issuerConfig.DefaultIssuerId
+	issuerConfig.fetchedCRLDefault = issuerConfig.CRLIssuerId()
+	issuerConfig.fetchedChainDefault = issuerConfig.ChainIssuerId()
 
 	return issuerConfig, nil
 }
@@ -56,6 +101,19 @@ func SetIssuersConfig(ctx context.Context, s logical.Storage, config *IssuerConf
 		return err
 	}
 
+	// The /cert/ca and /cert/crl paths may be served by issuers other than
+	// the default; their timestamps need updating in the same way.
+	if config.fetchedChainDefault != config.fetchedDefault || config.ChainIssuerId() != config.DefaultIssuerId {
+		if err := changeDefaultIssuerTimestamps(ctx, s, config.fetchedChainDefault, config.ChainIssuerId()); err != nil {
+			return err
+		}
+	}
+	if config.fetchedCRLDefault != config.fetchedDefault || config.CRLIssuerId() != config.DefaultIssuerId {
+		if err := changeDefaultIssuerTimestamps(ctx, s, config.fetchedCRLDefault, config.CRLIssuerId()); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -0,0 +1,117 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package issuing
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/go-secure-stdlib/strutil"
+	"github.com/hashicorp/vault/sdk/helper/certutil"
+)
+
+const CryptoPolicyConfigPath = "config/crypto-policy"
+
+// CryptoPolicyKeyTypes are the key types a crypto policy may allow.
+var CryptoPolicyKeyTypes = []string{"rsa", "ec", "ed25519"}
+
+// CryptoPolicyConfigEntry restricts the keys of all certificates and CAs
+// issued, signed or generated by the mount, regardless of the role used.
+type CryptoPolicyConfigEntry struct {
+	// AllowedKeyTypes are the key types which may be used. All key types
+	// are allowed when empty.
+	AllowedKeyTypes []string `json:"allowed_key_types"`
+
+	// MinRSAKeyBits and MinECKeyBits are the minimum sizes of RSA and EC
+	// keys. Zero leaves the minimums of Vault itself in place.
+	MinRSAKeyBits int `json:"min_rsa_key_bits"`
+	MinECKeyBits  int `json:"min_ec_key_bits"`
+
+	// DefaultRSAKeyBits is the size of RSA keys generated, and the minimum
+	// of roles created, when no key_bits are given.
+	DefaultRSAKeyBits int `json:"default_rsa_key_bits"`
+}
+
+func (p *CryptoPolicyConfigEntry) Validate() error {
+	for _, keyType := range p.AllowedKeyTypes {
+		if !strutil.StrListContains(CryptoPolicyKeyTypes, keyType) {
+			return fmt.Errorf("unknown key type %q in allowed_key_types; must be one of %s", keyType, strings.Join(CryptoPolicyKeyTypes, ", "))
+		}
+	}
+
+	if p.MinRSAKeyBits != 0 {
+		if err := certutil.ValidateKeyTypeLength("rsa", p.MinRSAKeyBits); err != nil {
+			return fmt.Errorf("invalid min_rsa_key_bits: %w", err)
+		}
+	}
+	if p.MinECKeyBits != 0 {
+		if err := certutil.ValidateKeyTypeLength("ec", p.MinECKeyBits); err != nil {
+			return fmt.Errorf("invalid min_ec_key_bits: %w", err)
+		}
+	}
+	if p.DefaultRSAKeyBits != 0 {
+		if err := certutil.ValidateKeyTypeLength("rsa", p.DefaultRSAKeyBits); err != nil {
+			return fmt.Errorf("invalid default_rsa_key_bits: %w", err)
+		}
+		if p.DefaultRSAKeyBits < p.MinRSAKeyBits {
+			return fmt.Errorf("default_rsa_key_bits (%d) must not be less than min_rsa_key_bits (%d)", p.DefaultRSAKeyBits, p.MinRSAKeyBits)
+		}
+	}
+
+	return nil
+}
+
+// DefaultKeyBits returns the size of keys of the given type to use when none
+// was requested, or keyBits if one was.
+func (p *CryptoPolicyConfigEntry) DefaultKeyBits(keyType string, keyBits int) int {
+	if keyType == "rsa" && keyBits == 0 && p.DefaultRSAKeyBits != 0 {
+		return p.DefaultRSAKeyBits
+	}
+	return keyBits
+}
+
+// ValidateKey returns an error if keys of the given type and size are not
+// allowed by the policy. Roles allowing any key type are validated once the
+// actual key is known.
+func (p *CryptoPolicyConfigEntry) ValidateKey(keyType string, keyBits int) error {
+	if keyType == "any" {
+		return nil
+	}
+
+	if len(p.AllowedKeyTypes) > 0 && !strutil.StrListContains(p.AllowedKeyTypes, keyType) {
+		return fmt.Errorf("the crypto policy of this mount does not allow %s keys; allowed key types are: %s", keyType, strings.Join(p.AllowedKeyTypes, ", "))
+	}
+
+	switch keyType {
+	case "rsa":
+		if keyBits < p.MinRSAKeyBits {
+			return fmt.Errorf("the crypto policy of this mount requires RSA keys of at least %d bits, but the key is %d bits", p.MinRSAKeyBits, keyBits)
+		}
+	case "ec":
+		if keyBits < p.MinECKeyBits {
+			return fmt.Errorf("the crypto policy of this mount requires EC keys of at least %d bits, but the key is %d bits", p.MinECKeyBits, keyBits)
+		}
+	}
+
+	return nil
+}
+
+// ValidatePublicKey returns an error if the given public key is not allowed
+// by the policy.
+func (p *CryptoPolicyConfigEntry) ValidatePublicKey(pubKey crypto.PublicKey) error {
+	switch key := pubKey.(type) {
+	case *rsa.PublicKey:
+		return p.ValidateKey("rsa", key.N.BitLen())
+	case *ecdsa.PublicKey:
+		return p.ValidateKey("ec", key.Curve.Params().BitSize)
+	case ed25519.PublicKey:
+		return p.ValidateKey("ed25519", 0)
+	default:
+		return fmt.Errorf("unsupported public key: %T", pubKey)
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package issuing
+
+import (
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/vault/sdk/helper/certutil"
+	"github.com/hashicorp/vault/sdk/helper/errutil"
+)
+
+// reservedExtensionArcs holds the OIDs of extensions Vault sets itself,
+// which requests cannot override: the standard certificate extensions
+// (id-ce), the authority information access extension and the certificate
+// transparency extensions.
+var reservedExtensionArcs = []asn1.ObjectIdentifier{
+	{2, 5, 29},
+	{1, 3, 6, 1, 5, 5, 7, 1, 1},
+	{1, 3, 6, 1, 4, 1, 11129, 2, 4},
+}
+
+// ValidateAllowedExtension returns an error when the OID of the
+// allowed_extensions of a role is invalid or reserved to Vault.
+func ValidateAllowedExtension(oidStr string) error {
+	oid, err := certutil.StringToOid(oidStr)
+	if err != nil {
+		return fmt.Errorf("%q is not a valid OID", oidStr)
+	}
+	for _, arc := range reservedExtensionArcs {
+		if len(oid) >= len(arc) && oid[:len(arc)].Equal(arc) {
+			return fmt.Errorf("extension %v is set by Vault and cannot be allowed", oidStr)
+		}
+	}
+	return nil
+}
+
+// ParseExtensions parses custom extensions given as OID;DER:value, or as
+// OID;critical;DER:value for critical extensions, where value is the base64
+// encoding of the DER extension value.
+func ParseExtensions(extensions []string) ([]pkix.Extension, error) {
+	parsed := make([]pkix.Extension, 0, len(extensions))
+	for _, extension := range extensions {
+		fields := strings.Split(extension, ";")
+		critical := len(fields) == 3 && strings.EqualFold(fields[1], "critical")
+		if len(fields) != 2 && !critical {
+			return nil, fmt.Errorf("extension %q is not of the form OID;DER:value or OID;critical;DER:value", extension)
+		}
+
+		oid, err := certutil.StringToOid(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("extension %q has an invalid OID", extension)
+		}
+		for _, existing := range parsed {
+			if existing.Id.Equal(oid) {
+				return nil, fmt.Errorf("extension %v is given more than once", fields[0])
+			}
+		}
+
+		encoding, value, found := strings.Cut(fields[len(fields)-1], ":")
+		if !found || !strings.EqualFold(encoding, "DER") {
+			return nil, fmt.Errorf("extension %q is not of the form OID;DER:value or OID;critical;DER:value", extension)
+		}
+		der, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return nil, fmt.Errorf("extension %q has an invalid base64 value: %v", extension, err)
+		}
+		var raw asn1.RawValue
+		if rest, err := asn1.Unmarshal(der, &raw); err != nil || len(rest) > 0 {
+			return nil, fmt.Errorf("extension %q does not hold a single DER value", extension)
+		}
+
+		parsed = append(parsed, pkix.Extension{Id: oid, Critical: critical, Value: der})
+	}
+	return parsed, nil
+}
+
+// ValidateExtension returns whether the role allows requests to add the
+// given extension.
+func ValidateExtension(role *RoleEntry, extension pkix.Extension) bool {
+	for _, allowed := range role.AllowedExtensions {
+		if oid, err := certutil.StringToOid(allowed); err == nil && oid.Equal(extension.Id) {
+			return true
+		}
+	}
+	return false
+}
+
+// requestedExtensions returns the custom extensions of the request, checked
+// against the role.
+func requestedExtensions(role *RoleEntry, extensions []string) ([]pkix.Extension, error) {
+	if len(extensions) == 0 {
+		return nil, nil
+	}
+
+	parsed, err := ParseExtensions(extensions)
+	if err != nil {
+		return nil, errutil.UserError{Err: err.Error()}
+	}
+	for _, extension := range parsed {
+		if !ValidateExtension(role, extension) {
+			return nil, errutil.UserError{Err: fmt.Sprintf("extension %v is not allowed by this role", extension.Id)}
+		}
+	}
+	return parsed, nil
+}
@@ -91,6 +91,8 @@ type CreationBundleInput interface {
 	GetOptionalSkid() (interface{}, bool)
 	IsUserIdInSchema() (interface{}, bool)
 	GetUserIds() []string
+	GetSubjectRDNs() []string
+	GetExtensions() []string
 	IgnoreCSRSignature() bool
 }
 
@@ -122,11 +124,55 @@ func GenerateCreationBundle(b logical.SystemView, role *RoleEntry, entityInfo En
 			ridSerialNumber = csr.Subject.SerialNumber
 		}
 
-		if csr != nil && role.UseCSRSANs {
-			dnsNames = csr.DNSNames
-			emailAddresses = csr.EmailAddresses
+		dnsMode := csrSANsMode(role, csr, "dns")
+		emailMode := csrSANsMode(role, csr, "email")
+
+		var csrDNSNames, csrEmailAddresses []string
+		if csr != nil {
+			csrDNSNames = csr.DNSNames
+			csrEmailAddresses = csr.EmailAddresses
+		}
+
+		var reqDNSNames, reqEmailAddresses []string
+		if dnsMode != CSRMergeModeUse || emailMode != CSRMergeModeUse {
+			cnAltRaw, ok := cb.GetOptionalAltNames()
+			if ok {
+				cnAlt := strutil.ParseDedupAndSortStrings(cnAltRaw.(string), ",")
+				for _, v := range cnAlt {
+					if strings.Contains(v, "@") {
+						reqEmailAddresses = append(reqEmailAddresses, v)
+					} else {
+						// Only add to dnsNames if it's actually a DNS name but
+						// convert idn first
+						p := idna.New(
+							idna.StrictDomainName(true),
+							idna.VerifyDNSLength(true),
+						)
+						converted, err := p.ToASCII(v)
+						if err != nil {
+							return nil, nil, errutil.UserError{Err: err.Error()}
+						}
+						if hostnameRegex.MatchString(converted) {
+							reqDNSNames = append(reqDNSNames, converted)
+						}
+					}
+				}
+			}
 		}
 
+		csrDNSNames, reqDNSNames, missing := splitCSRSANs(dnsMode, csrDNSNames, reqDNSNames, strings.EqualFold)
+		if len(missing) > 0 {
+			return nil, nil, errutil.UserError{Err: fmt.Sprintf(
+				"subject alternate name %s was requested but is not present in the CSR", missing[0])}
+		}
+		csrEmailAddresses, reqEmailAddresses, missing = splitCSRSANs(emailMode, csrEmailAddresses, reqEmailAddresses, strings.EqualFold)
+		if len(missing) > 0 {
+			return nil, nil, errutil.UserError{Err: fmt.Sprintf(
+				"email address %s was requested but is not present in the CSR", missing[0])}
+		}
+		dnsNames = append(dnsNames, csrDNSNames...)
+		emailAddresses = append(emailAddresses, csrEmailAddresses...)
+
 		if cn != "" && !cb.GetExcludeCnFromSans() {
 			if strings.Contains(cn, "@") {
 				// Note: emails are not disallowed if the role's email protection
@@ -152,31 +198,8 @@ func GenerateCreationBundle(b logical.SystemView, role *RoleEntry, entityInfo En
 			}
 		}
 
-		if csr == nil || !role.UseCSRSANs {
-			cnAltRaw, ok := cb.GetOptionalAltNames()
-			if ok {
-				cnAlt := strutil.ParseDedupAndSortStrings(cnAltRaw.(string), ",")
-				for _, v := range cnAlt {
-					if strings.Contains(v, "@") {
-						emailAddresses = append(emailAddresses, v)
-					} else {
-						// Only add to dnsNames if it's actually a DNS name but
-						// convert idn first
-						p := idna.New(
-							idna.StrictDomainName(true),
-							idna.VerifyDNSLength(true),
-						)
-						converted, err := p.ToASCII(v)
-						if err != nil {
-							return nil, nil, errutil.UserError{Err: err.Error()}
-						}
-						if hostnameRegex.MatchString(converted) {
-							dnsNames = append(dnsNames, converted)
-						}
-					}
-				}
-			}
-		}
+		dnsNames = append(dnsNames, reqDNSNames...)
+		emailAddresses = append(emailAddresses, reqEmailAddresses...)
 
 		// Check the CN. This ensures that the CN is checked even if it's
 		// excluded from SANs.
@@ -217,17 +240,31 @@ func GenerateCreationBundle(b logical.SystemView, role *RoleEntry, entityInfo En
 	// otherSANs is the output of parseOtherSANs(otherSANsInput): its keys are
 	// the <oid> value, its values are of the form [<type>, <value>]
 	var otherSANs map[string][]string
-	if sans := cb.GetOtherSans(); len(sans) > 0 {
-		otherSANsInput = sans
-	}
-	if role.UseCSRSANs && csr != nil && len(csr.Extensions) > 0 {
-		others, err := certutil.GetOtherSANsFromX509Extensions(csr.Extensions)
-		if err != nil {
-			return nil, nil, errutil.UserError{Err: fmt.Errorf("could not parse requested other SAN: %w", err).Error()}
+	{
+		otherMode := csrSANsMode(role, csr, "other")
+
+		var csrOtherSANs []string
+		if otherMode != CSRMergeModeIgnore && len(csr.Extensions) > 0 {
+			others, err := certutil.GetOtherSANsFromX509Extensions(csr.Extensions)
+			if err != nil {
+				return nil, nil, errutil.UserError{Err: fmt.Errorf("could not parse requested other SAN: %w", err).Error()}
+			}
+			for _, other := range others {
+				csrOtherSANs = append(csrOtherSANs, other.String())
+			}
 		}
-		for _, other := range others {
-			otherSANsInput = append(otherSANsInput, other.String())
+
+		var reqOtherSANs []string
+		if otherMode != CSRMergeModeUse {
+			reqOtherSANs = cb.GetOtherSans()
+		}
+
+		csrOtherSANs, reqOtherSANs, missing := splitCSRSANs(otherMode, csrOtherSANs, reqOtherSANs, otherSANsEqual)
+		if len(missing) > 0 {
+			return nil, nil, errutil.UserError{Err: fmt.Sprintf(
+				"other SAN %s was requested but is not present in the CSR", missing[0])}
 		}
+		otherSANsInput = append(reqOtherSANs, csrOtherSANs...)
 	}
 	if len(otherSANsInput) > 0 {
 		requested, err := ParseOtherSANs(otherSANsInput)
@@ -252,14 +289,18 @@ func GenerateCreationBundle(b logical.SystemView, role *RoleEntry, entityInfo En
 	// Get and verify any IP SANs
 	ipAddresses := []net.IP{}
 	{
-		if csr != nil && role.UseCSRSANs {
-			if len(csr.IPAddresses) > 0 {
-				if !role.AllowIPSANs {
-					return nil, nil, errutil.UserError{Err: "IP Subject Alternative Names are not allowed in this role, but was provided some via CSR"}
-				}
-				ipAddresses = csr.IPAddresses
+		ipMode := csrSANsMode(role, csr, "ip")
+
+		var csrIPAddresses []net.IP
+		if ipMode != CSRMergeModeIgnore && len(csr.IPAddresses) > 0 {
+			if !role.AllowIPSANs {
+				return nil, nil, errutil.UserError{Err: "IP Subject Alternative Names are not allowed in this role, but was provided some via CSR"}
 			}
-		} else {
+			csrIPAddresses = csr.IPAddresses
+		}
+
+		var reqIPAddresses []net.IP
+		if ipMode != CSRMergeModeUse {
 			ipAlt := cb.GetIpSans()
 			if len(ipAlt) > 0 {
 				if !role.AllowIPSANs {
@@ -272,35 +313,47 @@ func GenerateCreationBundle(b logical.SystemView, role *RoleEntry, entityInfo En
 						return nil, nil, errutil.UserError{Err: fmt.Sprintf(
 							"the value %q is not a valid IP address", v)}
 					}
-					ipAddresses = append(ipAddresses, parsedIP)
+					reqIPAddresses = append(reqIPAddresses, parsedIP)
 				}
 			}
 		}
+
+		csrIPAddresses, reqIPAddresses, missing := splitCSRSANs(ipMode, csrIPAddresses, reqIPAddresses, net.IP.Equal)
+		if len(missing) > 0 {
+			return nil, nil, errutil.UserError{Err: fmt.Sprintf(
+				"IP Subject Alternative Name %s was requested but is not present in the CSR", missing[0])}
+		}
+		ipAddresses = append(ipAddresses, csrIPAddresses...)
+		ipAddresses = append(ipAddresses, reqIPAddresses...)
 	}
 
 	URIs := []*url.URL{}
 	{
-		if csr != nil && role.UseCSRSANs {
-			if len(csr.URIs) > 0 {
-				if len(role.AllowedURISANs) == 0 {
-					return nil, nil, errutil.UserError{
-						Err: "URI Subject Alternative Names are not allowed in this role, but were provided via CSR",
-					}
+		uriMode := csrSANsMode(role, csr, "uri")
+
+		var csrURIs []*url.URL
+		if uriMode != CSRMergeModeIgnore && len(csr.URIs) > 0 {
+			if len(role.AllowedURISANs) == 0 {
+				return nil, nil, errutil.UserError{
+					Err: "URI Subject Alternative Names are not allowed in this role, but were provided via CSR",
 				}
+			}
 
-				// validate uri sans
-				for _, uri := range csr.URIs {
-					valid := ValidateURISAN(b, role, entityInfo, uri.String())
-					if !valid {
-						return nil, nil, errutil.UserError{
-							Err: "URI Subject Alternative Names were provided via CSR which are not valid for this role",
-						}
+			// validate uri sans
+			for _, uri := range csr.URIs {
+				valid := ValidateURISAN(b, role, entityInfo, uri.String())
+				if !valid {
+					return nil, nil, errutil.UserError{
+						Err: "URI Subject Alternative Names were provided via CSR which are not valid for this role",
 					}
-
-					URIs = append(URIs, uri)
 				}
+
+				csrURIs = append(csrURIs, uri)
 			}
-		} else {
+		}
+
+		var reqURIs []*url.URL
+		if uriMode != CSRMergeModeUse {
 			uriAlt := cb.GetURISans()
 			if len(uriAlt) > 0 {
 				if len(role.AllowedURISANs) == 0 {
@@ -325,10 +378,20 @@ func GenerateCreationBundle(b logical.SystemView, role *RoleEntry, entityInfo En
 						}
 					}
 
-					URIs = append(URIs, parsedURI)
+					reqURIs = append(reqURIs, parsedURI)
 				}
 			}
 		}
+
+		csrURIs, reqURIs, missing := splitCSRSANs(uriMode, csrURIs, reqURIs, func(a, b *url.URL) bool {
+			return a.String() == b.String()
+		})
+		if len(missing) > 0 {
+			return nil, nil, errutil.UserError{Err: fmt.Sprintf(
+				"URI Subject Alternative Name %s was requested but is not present in the CSR", missing[0])}
+		}
+		URIs = append(URIs, csrURIs...)
+		URIs = append(URIs, reqURIs...)
 	}
 
 	// Most of these could also be RemoveDuplicateStable, or even
@@ -408,6 +471,21 @@ func GenerateCreationBundle(b logical.SystemView, role *RoleEntry, entityInfo En
 		}
 	}
 
+	// Replace the attributes set from the role with the requested RDNs, so
+	// that the subject keeps their order.
+	subjectRDNs, err := requestedSubjectRDNs(role, cb.GetSubjectRDNs())
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(subjectRDNs) > 0 {
+		subject = orderedSubject(subject, subjectRDNs)
+	}
+
+	extensions, err := requestedExtensions(role, cb.GetExtensions())
+	if err != nil {
+		return nil, nil, err
+	}
+
 	creation := &certutil.CreationBundle{
 		Params: &certutil.CreationParameters{
 			Subject:                       subject,
@@ -425,11 +503,13 @@ func GenerateCreationBundle(b logical.SystemView, role *RoleEntry, entityInfo En
 			ExtKeyUsage:                   ParseExtKeyUsagesFromRole(role),
 			ExtKeyUsageOIDs:               role.ExtKeyUsageOIDs,
 			PolicyIdentifiers:             role.PolicyIdentifiers,
+			Extensions:                    extensions,
 			BasicConstraintsValidForNonCA: role.BasicConstraintsValidForNonCA,
 			NotBeforeDuration:             role.NotBeforeDuration,
 			ForceAppendCaChain:            caSign != nil,
 			SKID:                          skid,
 			IgnoreCSRSignature:            cb.IgnoreCSRSignature(),
+			SerialNumberBits:              role.SerialNumberBits,
 		},
 		SigningBundle: caSign,
 		CSR:           csr,
@@ -863,6 +943,57 @@ func ParseOtherSANs(others []string) (map[string][]string, error) {
 	return result, nil
 }
 
+// csrSANsMode returns how the SANs of sanType in csr combine with those of
+// the request; without a CSR only the request's are used.
+func csrSANsMode(role *RoleEntry, csr *x509.CertificateRequest, sanType string) string {
+	if csr == nil {
+		return CSRMergeModeIgnore
+	}
+	return role.CSRSANsMode(sanType)
+}
+
+// splitCSRSANs applies a csr_merge_mode to the SANs of one type from a CSR
+// and from the request, returning those of each to issue. Under the enforce
+// mode, requested SANs absent from the CSR are returned as missing.
+func splitCSRSANs[T any](mode string, fromCSR, fromRequest []T, equal func(a, b T) bool) ([]T, []T, []T) {
+	var notInCSR []T
+	if mode == CSRMergeModeEnforce || mode == CSRMergeModeMerge {
+		for _, req := range fromRequest {
+			found := false
+			for _, c := range fromCSR {
+				if equal(req, c) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				notInCSR = append(notInCSR, req)
+			}
+		}
+	}
+
+	switch mode {
+	case CSRMergeModeIgnore:
+		return nil, fromRequest, nil
+	case CSRMergeModeEnforce:
+		return fromCSR, nil, notInCSR
+	case CSRMergeModeMerge:
+		return fromCSR, notInCSR, nil
+	default:
+		return fromCSR, nil, nil
+	}
+}
+
+// otherSANsEqual compares two other SANs of the form <oid>;<type>:<value>,
+// disregarding the spelling of the UTF-8 type.
+func otherSANsEqual(a, b string) bool {
+	aOID, aValue, _ := strings.Cut(a, ";")
+	bOID, bValue, _ := strings.Cut(b, ";")
+	_, aValue, _ = strings.Cut(aValue, ":")
+	_, bValue, _ = strings.Cut(bValue, ":")
+	return aOID == bOID && aValue == bValue
+}
+
 // Given a URI SAN, verify that it is allowed.
 func ValidateURISAN(b logical.SystemView, role *RoleEntry, entityInfo EntityInfo, uri string) bool {
 	valid := false
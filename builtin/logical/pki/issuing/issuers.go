@@ -8,6 +8,7 @@ import (
 	"context"
 	"crypto/x509"
 	"fmt"
+	"slices"
 	"sort"
 	"strings"
 	"time"
@@ -41,6 +42,10 @@ const (
 	IssuerRefNotFound   = IssuerID("not-found")
 	LatestIssuerVersion = 1
 
+	// MaxIssuerNameHistory bounds the number of previous names retained
+	// on an issuer after renames.
+	MaxIssuerNameHistory = 10
+
 	LegacyCertBundlePath  = "config/ca_bundle"
 	LegacyBundleShimID    = IssuerID("legacy-entry-shim-id")
 	LegacyBundleShimKeyID = KeyID("legacy-entry-shim-key-id")
@@ -137,6 +142,25 @@ type IssuerEntry struct {
 	AIAURIs              *AiaConfigEntry           `json:"aia_uris,omitempty"`
 	LastModified         time.Time                 `json:"last_modified"`
 	Version              uint                      `json:"version"`
+
+	// PreviousNames holds the names this issuer was known by prior to being
+	// renamed, most recent last, so references to them keep resolving.
+	PreviousNames []string `json:"previous_names,omitempty"`
+
+	// OCSPResponderCertificate is the delegated responder certificate, issued
+	// by this issuer, signing OCSP responses about its certificates in place
+	// of the issuer itself. Its key is the key OCSPResponderKeyID of the mount.
+	OCSPResponderCertificate string `json:"ocsp_responder_certificate,omitempty"`
+	OCSPResponderKeyID       KeyID  `json:"ocsp_responder_key_id,omitempty"`
+
+	// CRLExpiry and OCSPExpiry override, when set, the mount-wide expiry and
+	// ocsp_expiry of config/crl for the CRLs and OCSP responses of this issuer.
+	CRLExpiry  string `json:"crl_expiry,omitempty"`
+	OCSPExpiry string `json:"ocsp_expiry,omitempty"`
+
+	// CRLIssuingDistributionPoint includes the Issuing Distribution Point
+	// extension, naming this issuer's CRL distribution points, in its CRLs.
+	CRLIssuingDistributionPoint bool `json:"crl_issuing_distribution_point,omitempty"`
 }
 
 // GetCertificate returns a x509.Certificate of the CA certificate
@@ -299,6 +323,7 @@ func ResolveIssuerReference(ctx context.Context, s logical.Storage, reference st
 		return IssuerID("list-error"), err
 	}
 
+	aliasMatch := IssuerRefNotFound
 	for _, issuerId := range issuers {
 		issuer, err := FetchIssuerById(ctx, s, issuerId)
 		if err != nil {
@@ -308,12 +333,73 @@ func ResolveIssuerReference(ctx context.Context, s logical.Storage, reference st
 		if issuer.Name == reference {
 			return issuer.ID, nil
 		}
+
+		if aliasMatch == IssuerRefNotFound && slices.Contains(issuer.PreviousNames, reference) {
+			aliasMatch = issuer.ID
+		}
+	}
+
+	// Current names take precedence over previous ones; only fall back to
+	// an issuer's name history when nothing currently uses the name.
+	if aliasMatch != IssuerRefNotFound {
+		return aliasMatch, nil
 	}
 
 	// Otherwise, we must not have found the issuer.
 	return IssuerRefNotFound, errutil.UserError{Err: fmt.Sprintf("unable to find PKI issuer for reference: %v", reference)}
 }
 
+// RenameIssuer sets the issuer's name to newName, recording its prior name
+// in the issuer's name history. No other issuer may hold either name in its
+// history afterwards, so that a previous name always resolves to a single
+// issuer. Other issuers are persisted as necessary; persisting issuer itself
+// is left to the caller.
+func RenameIssuer(ctx context.Context, s logical.Storage, issuer *IssuerEntry, newName string) error {
+	oldName := issuer.Name
+	if oldName == newName {
+		return nil
+	}
+
+	issuers, err := ListIssuers(ctx, s)
+	if err != nil {
+		return err
+	}
+
+	for _, issuerId := range issuers {
+		if issuerId == issuer.ID {
+			continue
+		}
+
+		other, err := FetchIssuerById(ctx, s, issuerId)
+		if err != nil {
+			return err
+		}
+
+		pruned := slices.DeleteFunc(slices.Clone(other.PreviousNames), func(name string) bool {
+			return name == newName || name == oldName
+		})
+		if len(pruned) != len(other.PreviousNames) {
+			other.PreviousNames = pruned
+			if err := WriteIssuer(ctx, s, other); err != nil {
+				return err
+			}
+		}
+	}
+
+	issuer.PreviousNames = slices.DeleteFunc(issuer.PreviousNames, func(name string) bool {
+		return name == newName || name == oldName
+	})
+	if len(oldName) > 0 {
+		issuer.PreviousNames = append(issuer.PreviousNames, oldName)
+		if len(issuer.PreviousNames) > MaxIssuerNameHistory {
+			issuer.PreviousNames = issuer.PreviousNames[len(issuer.PreviousNames)-MaxIssuerNameHistory:]
+		}
+	}
+	issuer.Name = newName
+
+	return nil
+}
+
 func ListIssuers(ctx context.Context, s logical.Storage) ([]IssuerID, error) {
 	strList, err := s.List(ctx, IssuerPrefix)
 	if err != nil {
@@ -377,6 +463,31 @@ func DeleteIssuer(ctx context.Context, s logical.Storage, id IssuerID) (bool, er
 		// entry.
 		config.fetchedDefault = IssuerID("")
 		config.DefaultIssuerId = IssuerID("")
+	}
+
+	// Per-use defaults referencing this issuer revert to following the
+	// default issuer.
+	wasUseDefault := false
+	if config.DefaultCRLIssuerId == id {
+		wasUseDefault = true
+		config.DefaultCRLIssuerId = IssuerID("")
+	}
+	if config.DefaultChainIssuerId == id {
+		wasUseDefault = true
+		config.DefaultChainIssuerId = IssuerID("")
+	}
+	if config.DefaultOCSPIssuerId == id {
+		wasUseDefault = true
+		config.DefaultOCSPIssuerId = IssuerID("")
+	}
+	if config.fetchedCRLDefault == id {
+		config.fetchedCRLDefault = IssuerID("")
+	}
+	if config.fetchedChainDefault == id {
+		config.fetchedChainDefault = IssuerID("")
+	}
+
+	if wasDefault || wasUseDefault {
 		if err := SetIssuersConfig(ctx, s, config); err != nil {
 			return wasDefault, err
 		}
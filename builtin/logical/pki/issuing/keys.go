@@ -8,6 +8,7 @@ import (
 	"crypto"
 	"encoding/pem"
 	"fmt"
+	"time"
 
 	"github.com/hashicorp/vault/builtin/logical/pki/managed_key"
 	"github.com/hashicorp/vault/sdk/helper/certutil"
@@ -31,6 +32,10 @@ type KeyEntry struct {
 	Name           string                  `json:"name"`
 	PrivateKeyType certutil.PrivateKeyType `json:"private_key_type"`
 	PrivateKey     string                  `json:"private_key"`
+
+	// UnusedSince is when tidy first found this key without an issuer, or
+	// the zero time if it hasn't.
+	UnusedSince time.Time `json:"unused_since,omitempty"`
 }
 
 func (e KeyEntry) IsManagedPrivateKey() bool {
@@ -27,71 +27,113 @@ const (
 )
 
 type RoleEntry struct {
-	LeaseMax                      string        `json:"lease_max"`
-	Lease                         string        `json:"lease"`
-	DeprecatedMaxTTL              string        `json:"max_ttl"`
-	DeprecatedTTL                 string        `json:"ttl"`
-	TTL                           time.Duration `json:"ttl_duration"`
-	MaxTTL                        time.Duration `json:"max_ttl_duration"`
-	AllowLocalhost                bool          `json:"allow_localhost"`
-	AllowedBaseDomain             string        `json:"allowed_base_domain"`
-	AllowedDomainsOld             string        `json:"allowed_domains,omitempty"`
-	AllowedDomains                []string      `json:"allowed_domains_list"`
-	AllowedDomainsTemplate        bool          `json:"allowed_domains_template"`
-	AllowBaseDomain               bool          `json:"allow_base_domain"`
-	AllowBareDomains              bool          `json:"allow_bare_domains"`
-	AllowTokenDisplayName         bool          `json:"allow_token_displayname"`
-	AllowSubdomains               bool          `json:"allow_subdomains"`
-	AllowGlobDomains              bool          `json:"allow_glob_domains"`
-	AllowWildcardCertificates     *bool         `json:"allow_wildcard_certificates,omitempty"`
-	AllowAnyName                  bool          `json:"allow_any_name"`
-	EnforceHostnames              bool          `json:"enforce_hostnames"`
-	AllowIPSANs                   bool          `json:"allow_ip_sans"`
-	ServerFlag                    bool          `json:"server_flag"`
-	ClientFlag                    bool          `json:"client_flag"`
-	CodeSigningFlag               bool          `json:"code_signing_flag"`
-	EmailProtectionFlag           bool          `json:"email_protection_flag"`
-	UseCSRCommonName              bool          `json:"use_csr_common_name"`
-	UseCSRSANs                    bool          `json:"use_csr_sans"`
-	KeyType                       string        `json:"key_type"`
-	KeyBits                       int           `json:"key_bits"`
-	UsePSS                        bool          `json:"use_pss"`
-	SignatureBits                 int           `json:"signature_bits"`
-	MaxPathLength                 *int          `json:",omitempty"`
-	KeyUsageOld                   string        `json:"key_usage,omitempty"`
-	KeyUsage                      []string      `json:"key_usage_list"`
-	ExtKeyUsage                   []string      `json:"extended_key_usage_list"`
-	OUOld                         string        `json:"ou,omitempty"`
-	OU                            []string      `json:"ou_list"`
-	OrganizationOld               string        `json:"organization,omitempty"`
-	Organization                  []string      `json:"organization_list"`
-	Country                       []string      `json:"country"`
-	Locality                      []string      `json:"locality"`
-	Province                      []string      `json:"province"`
-	StreetAddress                 []string      `json:"street_address"`
-	PostalCode                    []string      `json:"postal_code"`
-	GenerateLease                 *bool         `json:"generate_lease,omitempty"`
-	NoStore                       bool          `json:"no_store"`
-	NoStoreMetadata               bool          `json:"no_store_metadata"`
-	RequireCN                     bool          `json:"require_cn"`
-	CNValidations                 []string      `json:"cn_validations"`
-	AllowedOtherSANs              []string      `json:"allowed_other_sans"`
-	AllowedSerialNumbers          []string      `json:"allowed_serial_numbers"`
-	AllowedUserIDs                []string      `json:"allowed_user_ids"`
-	AllowedURISANs                []string      `json:"allowed_uri_sans"`
-	AllowedURISANsTemplate        bool          `json:"allowed_uri_sans_template"`
-	PolicyIdentifiers             []string      `json:"policy_identifiers"`
-	ExtKeyUsageOIDs               []string      `json:"ext_key_usage_oids"`
-	BasicConstraintsValidForNonCA bool          `json:"basic_constraints_valid_for_non_ca"`
-	NotBeforeDuration             time.Duration `json:"not_before_duration"`
-	NotAfter                      string        `json:"not_after"`
-	Issuer                        string        `json:"issuer"`
+	LeaseMax                      string            `json:"lease_max"`
+	Lease                         string            `json:"lease"`
+	DeprecatedMaxTTL              string            `json:"max_ttl"`
+	DeprecatedTTL                 string            `json:"ttl"`
+	TTL                           time.Duration     `json:"ttl_duration"`
+	MaxTTL                        time.Duration     `json:"max_ttl_duration"`
+	AllowLocalhost                bool              `json:"allow_localhost"`
+	AllowedBaseDomain             string            `json:"allowed_base_domain"`
+	AllowedDomainsOld             string            `json:"allowed_domains,omitempty"`
+	AllowedDomains                []string          `json:"allowed_domains_list"`
+	AllowedDomainsTemplate        bool              `json:"allowed_domains_template"`
+	AllowBaseDomain               bool              `json:"allow_base_domain"`
+	AllowBareDomains              bool              `json:"allow_bare_domains"`
+	AllowTokenDisplayName         bool              `json:"allow_token_displayname"`
+	AllowSubdomains               bool              `json:"allow_subdomains"`
+	AllowGlobDomains              bool              `json:"allow_glob_domains"`
+	AllowWildcardCertificates     *bool             `json:"allow_wildcard_certificates,omitempty"`
+	AllowAnyName                  bool              `json:"allow_any_name"`
+	EnforceHostnames              bool              `json:"enforce_hostnames"`
+	AllowIPSANs                   bool              `json:"allow_ip_sans"`
+	ServerFlag                    bool              `json:"server_flag"`
+	ClientFlag                    bool              `json:"client_flag"`
+	CodeSigningFlag               bool              `json:"code_signing_flag"`
+	EmailProtectionFlag           bool              `json:"email_protection_flag"`
+	UseCSRCommonName              bool              `json:"use_csr_common_name"`
+	UseCSRSANs                    bool              `json:"use_csr_sans"`
+	CSRMergeMode                  map[string]string `json:"csr_merge_mode"`
+	KeyType                       string            `json:"key_type"`
+	KeyBits                       int               `json:"key_bits"`
+	UsePSS                        bool              `json:"use_pss"`
+	SignatureBits                 int               `json:"signature_bits"`
+	SerialNumberBits              int               `json:"serial_number_bits"`
+	MaxPathLength                 *int              `json:",omitempty"`
+	KeyUsageOld                   string            `json:"key_usage,omitempty"`
+	KeyUsage                      []string          `json:"key_usage_list"`
+	ExtKeyUsage                   []string          `json:"extended_key_usage_list"`
+	OUOld                         string            `json:"ou,omitempty"`
+	OU                            []string          `json:"ou_list"`
+	OrganizationOld               string            `json:"organization,omitempty"`
+	Organization                  []string          `json:"organization_list"`
+	Country                       []string          `json:"country"`
+	Locality                      []string          `json:"locality"`
+	Province                      []string          `json:"province"`
+	StreetAddress                 []string          `json:"street_address"`
+	PostalCode                    []string          `json:"postal_code"`
+	GenerateLease                 *bool             `json:"generate_lease,omitempty"`
+	NoStore                       bool              `json:"no_store"`
+	NoStoreMetadata               bool              `json:"no_store_metadata"`
+	RequireCN                     bool              `json:"require_cn"`
+	CNValidations                 []string          `json:"cn_validations"`
+	AllowedOtherSANs              []string          `json:"allowed_other_sans"`
+	AllowedSerialNumbers          []string          `json:"allowed_serial_numbers"`
+	AllowedUserIDs                []string          `json:"allowed_user_ids"`
+	SubjectRDNs                   []string          `json:"subject_rdns"`
+	AllowedSubjectRDNs            []string          `json:"allowed_subject_rdns"`
+	AllowedURISANs                []string          `json:"allowed_uri_sans"`
+	AllowedURISANsTemplate        bool              `json:"allowed_uri_sans_template"`
+	PolicyIdentifiers             []string          `json:"policy_identifiers"`
+	ExtKeyUsageOIDs               []string          `json:"ext_key_usage_oids"`
+	AllowedExtensions             []string          `json:"allowed_extensions"`
+	RestrictSignVerbatim          bool              `json:"restrict_sign_verbatim"`
+	BasicConstraintsValidForNonCA bool              `json:"basic_constraints_valid_for_non_ca"`
+	CTSubmission                  bool              `json:"ct_submission"`
+	NotBeforeDuration             time.Duration     `json:"not_before_duration"`
+	NotAfter                      string            `json:"not_after"`
+	Issuer                        string            `json:"issuer"`
 	// Name is only set when the role has been stored, on the fly roles have a blank name
 	Name string `json:"-"`
 	// WasModified indicates to callers if the returned entry is different than the persisted version
 	WasModified bool `json:"-"`
 }
 
+// How the SANs of a CSR signed through a role combine with those of the
+// request, per SAN type, in csr_merge_mode.
+const (
+	// CSRMergeModeIgnore uses the SANs of the request, ignoring the CSR's.
+	CSRMergeModeIgnore = "ignore"
+	// CSRMergeModeUse uses the SANs of the CSR, ignoring the request's.
+	CSRMergeModeUse = "use"
+	// CSRMergeModeEnforce uses the SANs of the CSR, refusing requests for
+	// SANs the CSR lacks.
+	CSRMergeModeEnforce = "enforce"
+	// CSRMergeModeMerge uses the SANs of both the CSR and the request.
+	CSRMergeModeMerge = "merge"
+)
+
+// CSRMergeModeSANTypes are the SAN types csr_merge_mode may be set for:
+// DNS names, email addresses, IP addresses, URIs and other SANs.
+var CSRMergeModeSANTypes = []string{"dns", "email", "ip", "uri", "other"}
+
+// CSRSANsMode returns how the SANs of sanType in a CSR combine with those of
+// the request; SAN types without a csr_merge_mode follow use_csr_sans.
+func (r *RoleEntry) CSRSANsMode(sanType string) string {
+	if mode, ok := r.CSRMergeMode[sanType]; ok {
+		return mode
+	}
+	if !r.UseCSRSANs {
+		return CSRMergeModeIgnore
+	}
+	// Other SANs of the request have always been kept alongside those of
+	// the CSR.
+	if sanType == "other" {
+		return CSRMergeModeMerge
+	}
+	return CSRMergeModeUse
+}
+
 func (r *RoleEntry) ToResponseData() map[string]interface{} {
 	responseData := map[string]interface{}{
 		"ttl":                                int64(r.TTL.Seconds()),
@@ -114,13 +156,17 @@ func (r *RoleEntry) ToResponseData() map[string]interface{} {
 		"email_protection_flag":              r.EmailProtectionFlag,
 		"use_csr_common_name":                r.UseCSRCommonName,
 		"use_csr_sans":                       r.UseCSRSANs,
+		"csr_merge_mode":                     r.CSRMergeMode,
 		"key_type":                           r.KeyType,
 		"key_bits":                           r.KeyBits,
 		"signature_bits":                     r.SignatureBits,
+		"serial_number_bits":                 r.SerialNumberBits,
 		"use_pss":                            r.UsePSS,
 		"key_usage":                          r.KeyUsage,
 		"ext_key_usage":                      r.ExtKeyUsage,
 		"ext_key_usage_oids":                 r.ExtKeyUsageOIDs,
+		"allowed_extensions":                 r.AllowedExtensions,
+		"restrict_sign_verbatim":             r.RestrictSignVerbatim,
 		"ou":                                 r.OU,
 		"organization":                       r.Organization,
 		"country":                            r.Country,
@@ -132,11 +178,14 @@ func (r *RoleEntry) ToResponseData() map[string]interface{} {
 		"allowed_other_sans":                 r.AllowedOtherSANs,
 		"allowed_serial_numbers":             r.AllowedSerialNumbers,
 		"allowed_user_ids":                   r.AllowedUserIDs,
+		"subject_rdns":                       r.SubjectRDNs,
+		"allowed_subject_rdns":               r.AllowedSubjectRDNs,
 		"allowed_uri_sans":                   r.AllowedURISANs,
 		"require_cn":                         r.RequireCN,
 		"cn_validations":                     r.CNValidations,
 		"policy_identifiers":                 r.PolicyIdentifiers,
 		"basic_constraints_valid_for_non_ca": r.BasicConstraintsValidForNonCA,
+		"ct_submission":                      r.CTSubmission,
 		"not_before_duration":                int64(r.NotBeforeDuration.Seconds()),
 		"not_after":                          r.NotAfter,
 		"issuer_ref":                         r.Issuer,
@@ -358,6 +407,34 @@ func WithIssuer(issuer string) RoleModifier {
 	}
 }
 
+// WithSignVerbatimRestrictions constrains a sign-verbatim role by the name,
+// key and extension restrictions of role, for roles with
+// restrict_sign_verbatim set.
+func WithSignVerbatimRestrictions(role *RoleEntry) RoleModifier {
+	return func(r *RoleEntry) {
+		r.RestrictSignVerbatim = true
+		r.AllowLocalhost = role.AllowLocalhost
+		r.AllowedDomains = role.AllowedDomains
+		r.AllowedDomainsTemplate = role.AllowedDomainsTemplate
+		r.AllowBareDomains = role.AllowBareDomains
+		r.AllowSubdomains = role.AllowSubdomains
+		r.AllowGlobDomains = role.AllowGlobDomains
+		r.AllowWildcardCertificates = role.AllowWildcardCertificates
+		r.AllowAnyName = role.AllowAnyName
+		r.EnforceHostnames = role.EnforceHostnames
+		r.AllowIPSANs = role.AllowIPSANs
+		r.AllowedOtherSANs = role.AllowedOtherSANs
+		r.AllowedSerialNumbers = role.AllowedSerialNumbers
+		r.AllowedUserIDs = role.AllowedUserIDs
+		r.AllowedURISANs = role.AllowedURISANs
+		r.AllowedURISANsTemplate = role.AllowedURISANsTemplate
+		r.CNValidations = role.CNValidations
+		r.KeyType = role.KeyType
+		r.KeyBits = role.KeyBits
+		r.AllowedExtensions = role.AllowedExtensions
+	}
+}
+
 // SignVerbatimRole create a sign-verbatim role with no overrides. This will store
 // the signed certificate, allowing any key type and Value from a role restriction.
 func SignVerbatimRole() *RoleEntry {
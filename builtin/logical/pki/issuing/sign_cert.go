@@ -9,7 +9,11 @@ import (
 	"crypto/rsa"
 	"crypto/x509"
 	"fmt"
+	"net"
+	"strings"
 
+	"github.com/hashicorp/go-secure-stdlib/strutil"
+	"github.com/hashicorp/vault/builtin/logical/pki/parsing"
 	"github.com/hashicorp/vault/sdk/helper/certutil"
 	"github.com/hashicorp/vault/sdk/helper/errutil"
 	"github.com/hashicorp/vault/sdk/logical"
@@ -21,6 +25,13 @@ type SignCertInput interface {
 	IsCA() bool
 	UseCSRValues() bool
 	GetPermittedDomains() []string
+	GetExcludedDomains() []string
+	GetPermittedIpRanges() ([]*net.IPNet, error)
+	GetExcludedIpRanges() ([]*net.IPNet, error)
+	GetPermittedEmailAddresses() []string
+	GetExcludedEmailAddresses() []string
+	GetPermittedUriDomains() []string
+	GetExcludedUriDomains() []string
 }
 
 func NewBasicSignCertInput(csr *x509.CertificateRequest, isCA, useCSRValues bool) BasicSignCertInput {
@@ -93,6 +104,14 @@ func (b BasicSignCertInput) GetUserIds() []string {
 	return []string{}
 }
 
+func (b BasicSignCertInput) GetSubjectRDNs() []string {
+	return []string{}
+}
+
+func (b BasicSignCertInput) GetExtensions() []string {
+	return []string{}
+}
+
 func (b BasicSignCertInput) GetCSR() (*x509.CertificateRequest, error) {
 	return b.csr, nil
 }
@@ -113,7 +132,52 @@ func (b BasicSignCertInput) GetPermittedDomains() []string {
 	return []string{}
 }
 
+func (b BasicSignCertInput) GetExcludedDomains() []string {
+	return []string{}
+}
+
+func (b BasicSignCertInput) GetPermittedIpRanges() ([]*net.IPNet, error) {
+	return []*net.IPNet{}, nil
+}
+
+func (b BasicSignCertInput) GetExcludedIpRanges() ([]*net.IPNet, error) {
+	return []*net.IPNet{}, nil
+}
+
+func (b BasicSignCertInput) GetPermittedEmailAddresses() []string {
+	return []string{}
+}
+
+func (b BasicSignCertInput) GetExcludedEmailAddresses() []string {
+	return []string{}
+}
+
+func (b BasicSignCertInput) GetPermittedUriDomains() []string {
+	return []string{}
+}
+
+func (b BasicSignCertInput) GetExcludedUriDomains() []string {
+	return []string{}
+}
+
 func SignCert(b logical.SystemView, role *RoleEntry, entityInfo EntityInfo, caSign *certutil.CAInfoBundle, signInput SignCertInput) (*certutil.ParsedCertBundle, []string, error) {
+	creation, warnings, err := GenerateSignCreationBundle(b, role, entityInfo, caSign, signInput)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	parsedBundle, err := certutil.SignCertificate(creation)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return parsedBundle, warnings, nil
+}
+
+// GenerateSignCreationBundle validates the CSR of the input against the role
+// and returns the parameters to sign it with, for callers which need to
+// inspect or adjust them before signing.
+func GenerateSignCreationBundle(b logical.SystemView, role *RoleEntry, entityInfo EntityInfo, caSign *certutil.CAInfoBundle, signInput SignCertInput) (*certutil.CreationBundle, []string, error) {
 	if role == nil {
 		return nil, nil, errutil.InternalError{Err: "no role found in data bundle"}
 	}
@@ -282,8 +346,52 @@ func SignCert(b logical.SystemView, role *RoleEntry, entityInfo EntityInfo, caSi
 	creation.Params.IsCA = signInput.IsCA()
 	creation.Params.UseCSRValues = signInput.UseCSRValues()
 
+	if role.RestrictSignVerbatim && signInput.UseCSRValues() {
+		var extensionWarnings []string
+		creation.CSR, extensionWarnings = restrictSignVerbatimExtensions(role, csr)
+		warnings = append(warnings, extensionWarnings...)
+	}
+
 	if signInput.IsCA() {
 		creation.Params.PermittedDNSDomains = signInput.GetPermittedDomains()
+		creation.Params.ExcludedDNSDomains = signInput.GetExcludedDomains()
+		creation.Params.PermittedIPRanges, err = signInput.GetPermittedIpRanges()
+		if err != nil {
+			return nil, nil, err
+		}
+		creation.Params.ExcludedIPRanges, err = signInput.GetExcludedIpRanges()
+		if err != nil {
+			return nil, nil, err
+		}
+		creation.Params.PermittedEmailAddresses = signInput.GetPermittedEmailAddresses()
+		creation.Params.ExcludedEmailAddresses = signInput.GetExcludedEmailAddresses()
+		creation.Params.PermittedURIDomains = signInput.GetPermittedUriDomains()
+		creation.Params.ExcludedURIDomains = signInput.GetExcludedUriDomains()
+
+		// Without an explicit max_path_length, a path length constraint
+		// requested by the CSR is honored when signing its values verbatim.
+		requestedPathLength := role.MaxPathLength != nil
+		if !requestedPathLength && signInput.UseCSRValues() {
+			for _, ext := range csr.Extensions {
+				if !ext.Id.Equal(certutil.ExtensionBasicConstraintsOID) {
+					continue
+				}
+				isCA, maxPathLen, err := certutil.ParseBasicConstraintExtension(ext)
+				if err != nil {
+					return nil, nil, errutil.UserError{Err: fmt.Sprintf("could not parse CSR's basic constraints: %v", err)}
+				}
+				if isCA && maxPathLen >= 0 {
+					creation.Params.MaxPathLength = maxPathLen
+					requestedPathLength = true
+				}
+			}
+		}
+
+		if requestedPathLength && caSign != nil {
+			if err := validateCAPathLength(caSign.Certificate, creation.Params.MaxPathLength); err != nil {
+				return nil, nil, errutil.UserError{Err: err.Error()}
+			}
+		}
 	} else {
 		for _, ext := range csr.Extensions {
 			if ext.Id.Equal(certutil.ExtensionBasicConstraintsOID) {
@@ -292,10 +400,72 @@ func SignCert(b logical.SystemView, role *RoleEntry, entityInfo EntityInfo, caSi
 		}
 	}
 
-	parsedBundle, err := certutil.SignCertificate(creation)
-	if err != nil {
-		return nil, nil, err
+	return creation, warnings, nil
+}
+
+// ValidateSignVerbatimUsages returns an error when the key usages, extended
+// key usages or extended key usage OIDs of a sign-verbatim request are not
+// amongst those of role.
+func ValidateSignVerbatimUsages(role *RoleEntry, keyUsages, extKeyUsages, extKeyUsageOIDs []string) error {
+	requestedKeyUsages := parsing.ParseKeyUsages(keyUsages)
+	if extra := requestedKeyUsages &^ parsing.ParseKeyUsages(role.KeyUsage); extra != 0 {
+		return errutil.UserError{Err: fmt.Sprintf("key_usage %v is not allowed by this role; allowed key usages are %v", strings.Join(keyUsages, ","), strings.Join(role.KeyUsage, ","))}
 	}
 
-	return parsedBundle, warnings, nil
+	requestedExtKeyUsages := ParseExtKeyUsagesFromRole(&RoleEntry{ExtKeyUsage: extKeyUsages})
+	if extra := requestedExtKeyUsages &^ ParseExtKeyUsagesFromRole(role); extra != 0 {
+		return errutil.UserError{Err: fmt.Sprintf("ext_key_usage %v is not allowed by this role", strings.Join(extKeyUsages, ","))}
+	}
+
+	for _, oid := range extKeyUsageOIDs {
+		if !strutil.StrListContains(role.ExtKeyUsageOIDs, oid) {
+			return errutil.UserError{Err: fmt.Sprintf("ext_key_usage_oids %v is not allowed by this role", oid)}
+		}
+	}
+
+	return nil
+}
+
+// restrictSignVerbatimExtensions returns csr with only the extensions a
+// restricted sign-verbatim role copies: the Subject Alternative Name, whose
+// names were validated against the role, the Basic Constraints, which are
+// ignored when signing, and the extensions of allowed_extensions. The key
+// usage extensions of the CSR are dropped in favor of those of the request.
+func restrictSignVerbatimExtensions(role *RoleEntry, csr *x509.CertificateRequest) (*x509.CertificateRequest, []string) {
+	var warnings []string
+
+	restricted := *csr
+	restricted.Extensions = nil
+	for _, ext := range csr.Extensions {
+		switch {
+		case ext.Id.Equal(certutil.ExtensionSubjectAltNameOID), ext.Id.Equal(certutil.ExtensionBasicConstraintsOID):
+		case ext.Id.Equal(certutil.KeyUsageOID), ext.Id.Equal(certutil.ExtendedKeyUsageOID):
+			continue
+		case !ValidateExtension(role, ext):
+			warnings = append(warnings, fmt.Sprintf("specified CSR contained extension %v which is not allowed by this role and was ignored during issuance", ext.Id))
+			continue
+		}
+		restricted.Extensions = append(restricted.Extensions, ext)
+	}
+
+	return &restricted, warnings
+}
+
+// validateCAPathLength checks that a CA certificate with the given path length
+// constraint may be issued by issuer without exceeding the issuer's own.
+func validateCAPathLength(issuer *x509.Certificate, maxPathLength int) error {
+	// Issuers constrained to a path length of zero are refused by
+	// certutil.SignCertificate, as they cannot issue CA certificates at all.
+	if issuer.MaxPathLen <= 0 {
+		return nil
+	}
+
+	if maxPathLength < 0 {
+		return fmt.Errorf("signing issuer limits path length to %d, but the requested certificate has no path length constraint", issuer.MaxPathLen)
+	}
+	if maxPathLength >= issuer.MaxPathLen {
+		return fmt.Errorf("requested path length constraint (%d) must be less than the signing issuer's (%d)", maxPathLength, issuer.MaxPathLen)
+	}
+
+	return nil
 }
@@ -0,0 +1,139 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package issuing
+
+import (
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/vault/sdk/helper/certutil"
+	"github.com/hashicorp/vault/sdk/helper/errutil"
+	"github.com/ryanuber/go-glob"
+)
+
+// subjectRDNTypes maps the short names accepted in subject_rdns to the OIDs
+// of their attribute types. Other attribute types are given by dotted OID.
+var subjectRDNTypes = map[string]asn1.ObjectIdentifier{
+	"C":            {2, 5, 4, 6},
+	"ST":           {2, 5, 4, 8},
+	"L":            {2, 5, 4, 7},
+	"STREET":       {2, 5, 4, 9},
+	"O":            {2, 5, 4, 10},
+	"OU":           {2, 5, 4, 11},
+	"POSTALCODE":   {2, 5, 4, 17},
+	"DC":           {0, 9, 2342, 19200300, 100, 1, 25},
+	"UID":          certutil.SubjectPilotUserIDAttributeOID,
+	"EMAILADDRESS": {1, 2, 840, 113549, 1, 9, 1},
+}
+
+var (
+	subjectRDNCommonNameOID   = asn1.ObjectIdentifier{2, 5, 4, 3}
+	subjectRDNSerialNumberOID = asn1.ObjectIdentifier{2, 5, 4, 5}
+)
+
+// ParseSubjectRDNs parses relative distinguished names given as TYPE=value,
+// where TYPE is either one of the short names of subjectRDNTypes or a dotted
+// OID, preserving their order. The common name and serial number are set
+// through their own parameters and cannot be given.
+func ParseSubjectRDNs(rdns []string) ([]pkix.AttributeTypeAndValue, error) {
+	attrs := make([]pkix.AttributeTypeAndValue, 0, len(rdns))
+	for _, rdn := range rdns {
+		attrType, value, found := strings.Cut(rdn, "=")
+		attrType = strings.TrimSpace(attrType)
+		if !found || attrType == "" || value == "" {
+			return nil, fmt.Errorf("subject RDN %q is not of the form TYPE=value", rdn)
+		}
+
+		oid, ok := subjectRDNTypes[strings.ToUpper(attrType)]
+		if !ok {
+			var err error
+			oid, err = certutil.StringToOid(attrType)
+			if err != nil {
+				return nil, fmt.Errorf("subject RDN %q has an unknown attribute type %q", rdn, attrType)
+			}
+		}
+		if oid.Equal(subjectRDNCommonNameOID) || oid.Equal(subjectRDNSerialNumberOID) {
+			return nil, fmt.Errorf("subject RDN %q cannot be given, use the common_name and serial_number parameters instead", rdn)
+		}
+
+		attrs = append(attrs, pkix.AttributeTypeAndValue{Type: oid, Value: value})
+	}
+	return attrs, nil
+}
+
+// subjectRDNString returns the canonical TYPE=value form of an attribute,
+// against which the allowed_subject_rdns of roles are matched.
+func subjectRDNString(attr pkix.AttributeTypeAndValue) string {
+	for name, oid := range subjectRDNTypes {
+		if oid.Equal(attr.Type) {
+			return fmt.Sprintf("%s=%v", name, attr.Value)
+		}
+	}
+	return fmt.Sprintf("%s=%v", attr.Type.String(), attr.Value)
+}
+
+// ValidateSubjectRDN returns whether the role allows requests to place the
+// given attribute in the subject.
+func ValidateSubjectRDN(role *RoleEntry, attr pkix.AttributeTypeAndValue) bool {
+	rdn := subjectRDNString(attr)
+	for _, pattern := range role.AllowedSubjectRDNs {
+		if pattern == "" {
+			continue
+		}
+		if strings.EqualFold(pattern, rdn) || (strings.Contains(pattern, "*") && glob.Glob(pattern, rdn)) {
+			return true
+		}
+	}
+	return false
+}
+
+// requestedSubjectRDNs returns the subject RDNs to issue the certificate
+// with: those of the request if any, checked against the role, or else
+// those of the role.
+func requestedSubjectRDNs(role *RoleEntry, rdns []string) ([]pkix.AttributeTypeAndValue, error) {
+	if len(rdns) == 0 {
+		attrs, err := ParseSubjectRDNs(role.SubjectRDNs)
+		if err != nil {
+			return nil, errutil.InternalError{Err: fmt.Sprintf("failed to parse the subject_rdns of the role: %v", err)}
+		}
+		return attrs, nil
+	}
+
+	attrs, err := ParseSubjectRDNs(rdns)
+	if err != nil {
+		return nil, errutil.UserError{Err: err.Error()}
+	}
+	for _, attr := range attrs {
+		if !ValidateSubjectRDN(role, attr) {
+			return nil, errutil.UserError{Err: fmt.Sprintf("subject RDN %q is not allowed by this role", subjectRDNString(attr))}
+		}
+		if attr.Type.Equal(certutil.SubjectPilotUserIDAttributeOID) && !ValidateUserId(role, attr.Value.(string)) {
+			return nil, errutil.UserError{Err: fmt.Sprintf("user_id %v is not allowed by this role", attr.Value)}
+		}
+	}
+	return attrs, nil
+}
+
+// orderedSubject returns the subject with the given RDNs, in order, in place
+// of the attributes set from the role, followed by the user IDs, serial
+// number and common name. As every attribute is an ExtraName, the subject is
+// encoded in exactly this order.
+func orderedSubject(subject pkix.Name, rdns []pkix.AttributeTypeAndValue) pkix.Name {
+	extraNames := append([]pkix.AttributeTypeAndValue{}, rdns...)
+	extraNames = append(extraNames, subject.ExtraNames...)
+	if subject.SerialNumber != "" {
+		extraNames = append(extraNames, pkix.AttributeTypeAndValue{Type: subjectRDNSerialNumberOID, Value: subject.SerialNumber})
+	}
+	if subject.CommonName != "" {
+		extraNames = append(extraNames, pkix.AttributeTypeAndValue{Type: subjectRDNCommonNameOID, Value: subject.CommonName})
+	}
+
+	return pkix.Name{
+		CommonName:   subject.CommonName,
+		SerialNumber: subject.SerialNumber,
+		ExtraNames:   extraNames,
+	}
+}
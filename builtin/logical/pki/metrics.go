@@ -5,9 +5,11 @@ package pki
 
 import (
 	"errors"
+	"fmt"
 	"sort"
 	"strings"
 	"sync/atomic"
+	"time"
 
 	"github.com/armon/go-metrics"
 	"github.com/hashicorp/vault/builtin/logical/pki/issuing"
@@ -262,3 +264,74 @@ func NewCertificateCounter(backendUuid string) *CertificateCounter {
 
 	return counter
 }
+
+// emitIssuedMetric counts a certificate issued or signed through role by
+// issuerId.
+func (b *backend) emitIssuedMetric(role string, issuerId issuing.IssuerID) {
+	metrics.IncrCounterWithLabels([]string{"secrets", "pki", b.backendUUID, "issued_certificates"}, 1, []metrics.Label{
+		{Name: "role", Value: role},
+		{Name: "issuer_id", Value: issuerId.String()},
+	})
+}
+
+// emitRevokedMetric counts the revocation of the certificate with the given
+// storage serial, labelled with the role it was issued through when the
+// certificate index records it.
+func (b *backend) emitRevokedMetric(sc *storageContext, serial string) {
+	var role string
+	if entry, err := fetchCertIndex(sc.Context, sc.Storage, serial); err == nil && entry != nil {
+		role = entry.Role
+	}
+
+	metrics.IncrCounterWithLabels([]string{"secrets", "pki", b.backendUUID, "revoked_certificates"}, 1, []metrics.Label{
+		{Name: "role", Value: role},
+	})
+}
+
+// emitCRLBuildMetrics publishes how long building a CRL of issuerId took,
+// along with its size and number of entries.
+func (b *backend) emitCRLBuildMetrics(issuerId issuing.IssuerID, isUnified bool, isDelta bool, start time.Time, crlBytes []byte, entries int) {
+	labels := []metrics.Label{
+		{Name: "issuer_id", Value: issuerId.String()},
+		{Name: "unified", Value: fmt.Sprintf("%v", isUnified)},
+		{Name: "delta", Value: fmt.Sprintf("%v", isDelta)},
+	}
+
+	metrics.MeasureSinceWithLabels([]string{"secrets", "pki", b.backendUUID, "crl", "build_time"}, start, labels)
+	metrics.SetGaugeWithLabels([]string{"secrets", "pki", b.backendUUID, "crl", "size_bytes"}, float32(len(crlBytes)), labels)
+	metrics.SetGaugeWithLabels([]string{"secrets", "pki", b.backendUUID, "crl", "entries"}, float32(entries), labels)
+}
+
+// emitIssuerExpiryMetrics publishes the number of days until each issuer of
+// the mount expires, so that operators can alert before one does.
+func (b *backend) emitIssuerExpiryMetrics(sc *storageContext) error {
+	if b.UseLegacyBundleCaStorage() {
+		return nil
+	}
+
+	issuers, err := sc.listIssuers()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, issuerId := range issuers {
+		issuer, err := sc.fetchIssuerById(issuerId)
+		if err != nil {
+			return err
+		}
+
+		cert, err := issuer.GetCertificate()
+		if err != nil {
+			return err
+		}
+
+		days := cert.NotAfter.Sub(now).Hours() / 24
+		metrics.SetGaugeWithLabels([]string{"secrets", "pki", b.backendUUID, "issuer", "days_until_expiry"}, float32(days), []metrics.Label{
+			{Name: "issuer_id", Value: issuerId.String()},
+			{Name: "issuer_name", Value: issuer.Name},
+		})
+	}
+
+	return nil
+}
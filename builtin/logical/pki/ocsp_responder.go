@@ -0,0 +1,99 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package pki
+
+import (
+	"crypto/x509"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/builtin/logical/pki/issuing"
+	"github.com/hashicorp/vault/builtin/logical/pki/parsing"
+	"github.com/hashicorp/vault/sdk/helper/certutil"
+	"github.com/hashicorp/vault/sdk/helper/errutil"
+)
+
+// validateOCSPResponder checks that the PEM-encoded certificate may act as a
+// delegated OCSP responder for the issuer, per RFC 6960 Section 4.2.2.2: it
+// must have been issued by the issuer and carry the OCSPSigning extended key
+// usage. Its key must have been imported into the mount beforehand; the ID of
+// that key is returned.
+func (sc *storageContext) validateOCSPResponder(issuer *issuing.IssuerEntry, certPEM string) (issuing.KeyID, error) {
+	cert, err := parsing.ParseCertificateFromString(strings.TrimSpace(certPEM))
+	if err != nil {
+		return "", errutil.UserError{Err: fmt.Sprintf("unable to parse ocsp_responder_certificate: %v", err)}
+	}
+
+	issuerCert, err := issuer.GetCertificate()
+	if err != nil {
+		return "", err
+	}
+	if err := cert.CheckSignatureFrom(issuerCert); err != nil {
+		return "", errutil.UserError{Err: fmt.Sprintf("ocsp_responder_certificate was not issued by this issuer: %v", err)}
+	}
+
+	hasOCSPSigning := false
+	for _, usage := range cert.ExtKeyUsage {
+		if usage == x509.ExtKeyUsageOCSPSigning {
+			hasOCSPSigning = true
+			break
+		}
+	}
+	if !hasOCSPSigning {
+		return "", errutil.UserError{Err: "ocsp_responder_certificate lacks the OCSPSigning extended key usage"}
+	}
+
+	knownKeys, err := sc.listKeys()
+	if err != nil {
+		return "", err
+	}
+	for _, identifier := range knownKeys {
+		existingKey, err := sc.fetchKeyById(identifier)
+		if err != nil {
+			return "", err
+		}
+
+		equal, err := comparePublicKey(sc, existingKey, cert.PublicKey)
+		if err != nil {
+			return "", err
+		}
+		if equal {
+			return existingKey.ID, nil
+		}
+	}
+
+	return "", errutil.UserError{Err: "the key of ocsp_responder_certificate was not found; import it through keys/import first"}
+}
+
+// fetchOCSPResponderBundle returns the delegated OCSP responder of the issuer
+// along with its key, or nil when the issuer signs its own OCSP responses.
+// Responses are signed by the issuer again once the responder certificate
+// has expired, rather than failing.
+func (sc *storageContext) fetchOCSPResponderBundle(issuer *issuing.IssuerEntry) (*certutil.ParsedCertBundle, error) {
+	if issuer.OCSPResponderCertificate == "" || issuer.OCSPResponderKeyID == "" {
+		return nil, nil
+	}
+
+	key, err := sc.fetchKeyById(issuer.OCSPResponderKeyID)
+	if err != nil {
+		return nil, err
+	}
+
+	bundle, err := parseCABundle(sc.Context, sc.GetPkiManagedView(), &certutil.CertBundle{
+		Certificate:    issuer.OCSPResponderCertificate,
+		PrivateKeyType: key.PrivateKeyType,
+		PrivateKey:     key.PrivateKey,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if now.Before(bundle.Certificate.NotBefore) || now.After(bundle.Certificate.NotAfter) {
+		return nil, nil
+	}
+
+	return bundle, nil
+}
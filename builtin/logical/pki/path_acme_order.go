@@ -275,6 +275,11 @@ func (b *backend) acmeFinalizeOrderHandler(ac *acmeContext, r *logical.Request,
 		if err != nil {
 			return nil, err
 		}
+
+		err = storeCertIndex(ac.sc.Context, ac.sc.Storage, signedCertBundle.Certificate, ac.Role.Name, issuerId, "")
+		if err != nil {
+			return nil, err
+		}
 	}
 	hyphenSerialNumber := normalizeSerialFromBigInt(signedCertBundle.Certificate.SerialNumber)
 
@@ -534,9 +539,10 @@ func issueCertFromCsr(ac *acmeContext, csr *x509.CertificateRequest) (*certutil.
 	}
 
 	input := &inputBundle{
-		req:     &logical.Request{},
-		apiData: data,
-		role:    ac.Role,
+		req:      &logical.Request{},
+		apiData:  data,
+		role:     ac.Role,
+		issuerId: issuerId,
 	}
 
 	normalNotAfter, _, err := getCertificateNotAfter(ac.sc.System(), input, signingBundle)
@@ -573,8 +579,14 @@ func issueCertFromCsr(ac *acmeContext, csr *x509.CertificateRequest) (*certutil.
 	// unit, we have no way of validating this (via ACME here, without perhaps
 	// an external policy engine), and thus should not be setting it on our
 	// final issued certificate.
-	parsedBundle, _, err := signCert(ac.sc.System(), input, signingBundle, false /* is_ca=false */, false /* use_csr_values */)
+	parsedBundle, _, err := signCert(ac.sc, input, signingBundle, false /* is_ca=false */, false /* use_csr_values */)
 	if err != nil {
+		if err == logical.ErrReadOnly {
+			return nil, "", err
+		}
+		if coded, ok := err.(logical.HTTPCodedError); ok && coded.Code() == http.StatusTooManyRequests {
+			return nil, "", fmt.Errorf("%w: %s", ErrRateLimited, err.Error())
+		}
 		return nil, "", fmt.Errorf("%w: refusing to sign CSR: %s", ErrBadCSR, err.Error())
 	}
 
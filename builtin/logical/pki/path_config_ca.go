@@ -5,6 +5,7 @@ package pki
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 
 	"github.com/hashicorp/vault/builtin/logical/pki/issuing"
@@ -22,13 +23,14 @@ func pathConfigCA(b *backend) *framework.Path {
 			OperationSuffix: "ca",
 		},
 
-		Fields: map[string]*framework.FieldSchema{
+		Fields: addIssuerImportFields(map[string]*framework.FieldSchema{
 			"pem_bundle": {
 				Type: framework.TypeString,
 				Description: `PEM-format, concatenated unencrypted
-secret key and certificate.`,
+secret key and certificate, optionally followed by the
+certificates of its parent CAs.`,
 			},
-		},
+		}),
 
 		Operations: map[logical.Operation]framework.OperationHandler{
 			logical.UpdateOperation: &framework.PathOperation{
@@ -85,6 +87,10 @@ This sets the CA information used for credentials generated by this
 by this mount. This must be a PEM-format, concatenated unencrypted
 secret key and certificate.
 
+The bundle may also contain the certificates of the parent CAs of the
+certificate, in any order. They are imported as issuers without keys, and
+the full chain of the certificate is then served from the ca_chain paths.
+
 For security reasons, the secret key cannot be retrieved later.
 `
 
@@ -106,6 +112,18 @@ func pathConfigIssuers(b *backend) *framework.Path {
 				Description: `Whether the default issuer should automatically follow the latest generated or imported issuer. Defaults to false.`,
 				Default:     false,
 			},
+			"default_crl": {
+				Type:        framework.TypeString,
+				Description: `Reference (name or identifier) to the issuer whose CRL is served from the default CRL paths and which is preferred when signing CRLs. If empty, follows the default issuer.`,
+			},
+			"default_chain": {
+				Type:        framework.TypeString,
+				Description: `Reference (name or identifier) to the issuer served from the default CA and CA chain paths. If empty, follows the default issuer.`,
+			},
+			"default_ocsp": {
+				Type:        framework.TypeString,
+				Description: `Reference (name or identifier) to the issuer used to sign OCSP responses for unknown issuers. If empty, follows the default issuer.`,
+			},
 		},
 		Operations: map[logical.Operation]framework.OperationHandler{
 			logical.ReadOperation: &framework.PathOperation{
@@ -127,6 +145,18 @@ func pathConfigIssuers(b *backend) *framework.Path {
 								Description: `Whether the default issuer should automatically follow the latest generated or imported issuer. Defaults to false.`,
 								Required:    true,
 							},
+							"default_crl": {
+								Type:        framework.TypeString,
+								Description: `Reference (name or identifier) to the issuer whose CRL is served from the default CRL paths and which is preferred when signing CRLs. If empty, follows the default issuer.`,
+							},
+							"default_chain": {
+								Type:        framework.TypeString,
+								Description: `Reference (name or identifier) to the issuer served from the default CA and CA chain paths. If empty, follows the default issuer.`,
+							},
+							"default_ocsp": {
+								Type:        framework.TypeString,
+								Description: `Reference (name or identifier) to the issuer used to sign OCSP responses for unknown issuers. If empty, follows the default issuer.`,
+							},
 						},
 					}},
 				},
@@ -149,6 +179,18 @@ func pathConfigIssuers(b *backend) *framework.Path {
 								Type:        framework.TypeBool,
 								Description: `Whether the default issuer should automatically follow the latest generated or imported issuer. Defaults to false.`,
 							},
+							"default_crl": {
+								Type:        framework.TypeString,
+								Description: `Reference (name or identifier) to the issuer whose CRL is served from the default CRL paths and which is preferred when signing CRLs. If empty, follows the default issuer.`,
+							},
+							"default_chain": {
+								Type:        framework.TypeString,
+								Description: `Reference (name or identifier) to the issuer served from the default CA and CA chain paths. If empty, follows the default issuer.`,
+							},
+							"default_ocsp": {
+								Type:        framework.TypeString,
+								Description: `Reference (name or identifier) to the issuer used to sign OCSP responses for unknown issuers. If empty, follows the default issuer.`,
+							},
 						},
 					}},
 				},
@@ -198,6 +240,18 @@ func pathReplaceRoot(b *backend) *framework.Path {
 								Description: `Whether the default issuer should automatically follow the latest generated or imported issuer. Defaults to false.`,
 								Required:    true,
 							},
+							"default_crl": {
+								Type:        framework.TypeString,
+								Description: `Reference (name or identifier) to the issuer whose CRL is served from the default CRL paths and which is preferred when signing CRLs. If empty, follows the default issuer.`,
+							},
+							"default_chain": {
+								Type:        framework.TypeString,
+								Description: `Reference (name or identifier) to the issuer served from the default CA and CA chain paths. If empty, follows the default issuer.`,
+							},
+							"default_ocsp": {
+								Type:        framework.TypeString,
+								Description: `Reference (name or identifier) to the issuer used to sign OCSP responses for unknown issuers. If empty, follows the default issuer.`,
+							},
 						},
 					}},
 				},
@@ -231,6 +285,9 @@ func (b *backend) formatCAIssuerConfigRead(config *issuing.IssuerConfigEntry) *l
 		Data: map[string]interface{}{
 			defaultRef:                      config.DefaultIssuerId,
 			"default_follows_latest_issuer": config.DefaultFollowsLatestIssuer,
+			"default_crl":                   config.DefaultCRLIssuerId,
+			"default_chain":                 config.DefaultChainIssuerId,
+			"default_ocsp":                  config.DefaultOCSPIssuerId,
 		},
 	}
 }
@@ -247,50 +304,111 @@ func (b *backend) pathCAIssuersWrite(ctx context.Context, req *logical.Request,
 
 	sc := b.makeStorageContext(ctx, req.Storage)
 
-	// Validate the new default reference.
-	newDefault := data.Get(defaultRef).(string)
-	if len(newDefault) == 0 || newDefault == defaultRef {
-		return logical.ErrorResponse("Invalid issuer specification; must be non-empty and can't be 'default'."), nil
-	}
-	parsedIssuer, err := sc.resolveIssuerReference(newDefault)
+	config, err := sc.getIssuersConfig()
 	if err != nil {
-		return logical.ErrorResponse("Error resolving issuer reference: " + err.Error()), nil
+		return logical.ErrorResponse("Unable to fetch existing issuers configuration: " + err.Error()), nil
 	}
-	entry, err := sc.fetchIssuerById(parsedIssuer)
-	if err != nil {
-		return logical.ErrorResponse("Unable to fetch issuer: " + err.Error()), nil
+
+	// The per-use defaults don't exist on the /root/replace variant of this
+	// call. When only those are being updated, the default issuer may be
+	// omitted.
+	var useDefaultsOk bool
+	for _, param := range []string{"default_crl", "default_chain", "default_ocsp"} {
+		if _, ok := data.GetOk(param); ok {
+			useDefaultsOk = true
+		}
+	}
+
+	var entry *issuing.IssuerEntry
+	if _, ok := data.GetOk(defaultRef); ok || !useDefaultsOk {
+		// Validate the new default reference.
+		newDefault := data.Get(defaultRef).(string)
+		if len(newDefault) == 0 || newDefault == defaultRef {
+			return logical.ErrorResponse("Invalid issuer specification; must be non-empty and can't be 'default'."), nil
+		}
+		parsedIssuer, err := sc.resolveIssuerReference(newDefault)
+		if err != nil {
+			return logical.ErrorResponse("Error resolving issuer reference: " + err.Error()), nil
+		}
+		entry, err = sc.fetchIssuerById(parsedIssuer)
+		if err != nil {
+			return logical.ErrorResponse("Unable to fetch issuer: " + err.Error()), nil
+		}
+
+		config.DefaultIssuerId = parsedIssuer
 	}
 
 	// Get the other new parameters. This doesn't exist on the /root/replace
 	// variant of this call.
-	var followIssuer bool
 	followIssuersRaw, followOk := data.GetOk("default_follows_latest_issuer")
 	if followOk {
-		followIssuer = followIssuersRaw.(bool)
+		config.DefaultFollowsLatestIssuer = followIssuersRaw.(bool)
 	}
 
-	// Update the config
-	config, err := sc.getIssuersConfig()
-	if err != nil {
-		return logical.ErrorResponse("Unable to fetch existing issuers configuration: " + err.Error()), nil
+	// Resolve the per-use defaults; an empty value (or 'default') reverts
+	// to following the default issuer.
+	priorCRLIssuer := config.CRLIssuerId()
+	useDefaults := []struct {
+		param  string
+		usage  issuing.IssuerUsage
+		target *issuing.IssuerID
+	}{
+		{"default_crl", issuing.CRLSigningUsage, &config.DefaultCRLIssuerId},
+		{"default_chain", issuing.ReadOnlyUsage, &config.DefaultChainIssuerId},
+		{"default_ocsp", issuing.OCSPSigningUsage, &config.DefaultOCSPIssuerId},
 	}
-	config.DefaultIssuerId = parsedIssuer
-	if followOk {
-		config.DefaultFollowsLatestIssuer = followIssuer
+	var warnings []string
+	for _, useDefault := range useDefaults {
+		raw, ok := data.GetOk(useDefault.param)
+		if !ok {
+			continue
+		}
+
+		reference := raw.(string)
+		if len(reference) == 0 || reference == defaultRef {
+			*useDefault.target = issuing.IssuerID("")
+			continue
+		}
+
+		issuerId, err := sc.resolveIssuerReference(reference)
+		if err != nil {
+			return logical.ErrorResponse(fmt.Sprintf("Error resolving %v issuer reference: %v", useDefault.param, err)), nil
+		}
+		useEntry, err := sc.fetchIssuerById(issuerId)
+		if err != nil {
+			return logical.ErrorResponse(fmt.Sprintf("Unable to fetch %v issuer: %v", useDefault.param, err)), nil
+		}
+		if err := useEntry.EnsureUsage(useDefault.usage); err != nil {
+			return logical.ErrorResponse(fmt.Sprintf("Unable to use issuer as %v: %v", useDefault.param, err)), nil
+		}
+		if useDefault.usage != issuing.ReadOnlyUsage && len(useEntry.KeyID) == 0 {
+			warnings = append(warnings, fmt.Sprintf("The issuer selected for %v has no key associated with it and will be unable to sign until a key is imported.", useDefault.param))
+		}
+
+		*useDefault.target = issuerId
 	}
 
 	// Add our warning if necessary.
 	response := b.formatCAIssuerConfigRead(config)
-	if len(entry.KeyID) == 0 {
+	if entry != nil && len(entry.KeyID) == 0 {
 		msg := "This selected default issuer has no key associated with it. Some operations like issuing certificates and signing CRLs will be unavailable with the requested default issuer until a key is imported or the default issuer is changed."
 		response.AddWarning(msg)
 		b.Logger().Error(msg)
 	}
+	for _, warning := range warnings {
+		response.AddWarning(warning)
+	}
 
 	if err := sc.setIssuersConfig(config); err != nil {
 		return logical.ErrorResponse("Error updating issuer configuration: " + err.Error()), nil
 	}
 
+	// Which issuer signs shared CRLs may have changed; have the next
+	// periodic function pick that up.
+	if config.CRLIssuerId() != priorCRLIssuer {
+		b.CrlBuilder().requestRebuildIfActiveNode(b)
+	}
+
 	return response, nil
 }
 
@@ -303,6 +421,14 @@ Presently, the "default" parameter controls which issuer is the default,
 accessible by the existing signing paths (/root/sign-intermediate,
 /root/sign-self-issued, /sign-verbatim, /sign/:role, and /issue/:role).
 
+The "default_crl", "default_chain", and "default_ocsp" parameters allow
+selecting a different issuer for the default CRL paths (/crl, /cert/crl,
+and their variants), the default CA paths (/ca, /ca_chain, /cert/ca, and
+/cert/ca_chain), and for signing OCSP responses to requests for unknown
+issuers, respectively. When unset, each follows "default". This allows,
+for example, issuance to move to a new issuer while relying parties keep
+fetching the old issuer's CRL and chain during a rotation.
+
 The /root/replace path is aliased to this path, with default taking the
 value of the issuer with the name "next", if it exists.
 `
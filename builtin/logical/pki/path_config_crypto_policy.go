@@ -0,0 +1,214 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package pki
+
+import (
+	"context"
+	"crypto"
+	"net/http"
+
+	"github.com/hashicorp/vault/builtin/logical/pki/issuing"
+	"github.com/hashicorp/vault/builtin/logical/pki/managed_key"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/errutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+var cryptoPolicyFields = map[string]*framework.FieldSchema{
+	"allowed_key_types": {
+		Type: framework.TypeCommaStringSlice,
+		Description: `Key types which may be used by certificates and CAs
+issued, signed or generated by this mount: any of "rsa", "ec" and "ed25519".
+All key types are allowed when empty.`,
+	},
+	"min_rsa_key_bits": {
+		Type: framework.TypeInt,
+		Description: `Minimum size of RSA keys, enforced on all issuance,
+CSR signing and CA generation regardless of the role used. Defaults to 0,
+which leaves the minimum of 2048 bits in place.`,
+	},
+	"min_ec_key_bits": {
+		Type: framework.TypeInt,
+		Description: `Minimum size of EC keys, enforced on all issuance,
+CSR signing and CA generation regardless of the role used. Defaults to 0,
+which allows all supported curves.`,
+	},
+	"default_rsa_key_bits": {
+		Type: framework.TypeInt,
+		Description: `Size of the RSA keys generated, and of the keys of
+roles created, when key_bits is not given; for example 3072 or 4096.
+Defaults to 0, which keeps the default of 2048 bits.`,
+	},
+}
+
+func pathConfigCryptoPolicy(b *backend) *framework.Path {
+	responseFields := make(map[string]*framework.FieldSchema, len(cryptoPolicyFields))
+	for name, field := range cryptoPolicyFields {
+		responseFields[name] = &framework.FieldSchema{
+			Type:        field.Type,
+			Description: field.Description,
+			Required:    true,
+		}
+	}
+	responses := map[int][]framework.Response{
+		http.StatusOK: {{
+			Description: "OK",
+			Fields:      responseFields,
+		}},
+	}
+
+	return &framework.Path{
+		Pattern: "config/crypto-policy",
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixPKI,
+		},
+
+		Fields: cryptoPolicyFields,
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.UpdateOperation: &framework.PathOperation{
+				DisplayAttrs: &framework.DisplayAttributes{
+					OperationVerb:   "configure",
+					OperationSuffix: "crypto-policy",
+				},
+				Callback:  b.pathWriteCryptoPolicy,
+				Responses: responses,
+			},
+			logical.ReadOperation: &framework.PathOperation{
+				Callback: b.pathReadCryptoPolicy,
+				DisplayAttrs: &framework.DisplayAttributes{
+					OperationSuffix: "crypto-policy-configuration",
+				},
+				Responses: responses,
+			},
+		},
+
+		HelpSynopsis:    pathConfigCryptoPolicyHelpSyn,
+		HelpDescription: pathConfigCryptoPolicyHelpDesc,
+	}
+}
+
+func cryptoPolicyResponse(cfg *issuing.CryptoPolicyConfigEntry) *logical.Response {
+	allowedKeyTypes := cfg.AllowedKeyTypes
+	if allowedKeyTypes == nil {
+		allowedKeyTypes = []string{}
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"allowed_key_types":    allowedKeyTypes,
+			"min_rsa_key_bits":     cfg.MinRSAKeyBits,
+			"min_ec_key_bits":      cfg.MinECKeyBits,
+			"default_rsa_key_bits": cfg.DefaultRSAKeyBits,
+		},
+	}
+}
+
+func (b *backend) pathReadCryptoPolicy(ctx context.Context, req *logical.Request, _ *framework.FieldData) (*logical.Response, error) {
+	sc := b.makeStorageContext(ctx, req.Storage)
+	cfg, err := sc.getCryptoPolicyConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	return cryptoPolicyResponse(cfg), nil
+}
+
+func (b *backend) pathWriteCryptoPolicy(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	sc := b.makeStorageContext(ctx, req.Storage)
+	cfg, err := sc.getCryptoPolicyConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	if value, ok := data.GetOk("allowed_key_types"); ok {
+		cfg.AllowedKeyTypes = value.([]string)
+	}
+	if value, ok := data.GetOk("min_rsa_key_bits"); ok {
+		cfg.MinRSAKeyBits = value.(int)
+	}
+	if value, ok := data.GetOk("min_ec_key_bits"); ok {
+		cfg.MinECKeyBits = value.(int)
+	}
+	if value, ok := data.GetOk("default_rsa_key_bits"); ok {
+		cfg.DefaultRSAKeyBits = value.(int)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
+	if err := sc.writeCryptoPolicyConfig(cfg); err != nil {
+		return nil, err
+	}
+
+	return cryptoPolicyResponse(cfg), nil
+}
+
+// checkCryptoPolicy returns a user error if keys of the given type and size
+// are not allowed by the crypto policy of the mount.
+func (sc *storageContext) checkCryptoPolicy(keyType string, keyBits int) error {
+	cfg, err := sc.getCryptoPolicyConfig()
+	if err != nil {
+		return errutil.InternalError{Err: "unable to fetch crypto policy: " + err.Error()}
+	}
+	if err := cfg.ValidateKey(keyType, keyBits); err != nil {
+		return errutil.UserError{Err: err.Error()}
+	}
+	return nil
+}
+
+// checkCryptoPolicyPublicKey returns a user error if the given public key is
+// not allowed by the crypto policy of the mount.
+func (sc *storageContext) checkCryptoPolicyPublicKey(pubKey crypto.PublicKey) error {
+	cfg, err := sc.getCryptoPolicyConfig()
+	if err != nil {
+		return errutil.InternalError{Err: "unable to fetch crypto policy: " + err.Error()}
+	}
+	if err := cfg.ValidatePublicKey(pubKey); err != nil {
+		return errutil.UserError{Err: err.Error()}
+	}
+	return nil
+}
+
+// checkCryptoPolicyForInput returns a user error if the key of the
+// certificate or CSR about to be generated for the input is not allowed by
+// the crypto policy of the mount. Existing and managed keys are checked by
+// their public key, as their size is not known from the role.
+func (sc *storageContext) checkCryptoPolicyForInput(input *inputBundle) error {
+	switch {
+	case existingKeyRequested(input):
+		pubKey, err := sc.getExistingPublicKey(input.apiData)
+		if err != nil {
+			return err
+		}
+		return sc.checkCryptoPolicyPublicKey(pubKey)
+	case kmsRequested(input):
+		keyId, err := getManagedKeyId(input.apiData)
+		if err != nil {
+			return err
+		}
+		pubKey, err := managed_key.GetManagedKeyPublicKey(sc.Context, sc.GetPkiManagedView(), keyId)
+		if err != nil {
+			return err
+		}
+		return sc.checkCryptoPolicyPublicKey(pubKey)
+	default:
+		return sc.checkCryptoPolicy(input.role.KeyType, input.role.KeyBits)
+	}
+}
+
+const pathConfigCryptoPolicyHelpSyn = `
+Restrict the key types and sizes used by this mount.
+`
+
+const pathConfigCryptoPolicyHelpDesc = `
+This path allows you to set a mount-wide crypto policy: the key types allowed
+and the minimum RSA and EC key sizes, which are enforced on all issuance, CSR
+signing and CA generation regardless of the role used, as well as the size of
+RSA keys generated when none is requested.
+
+Certificates and keys which already exist are not affected by the policy.
+`
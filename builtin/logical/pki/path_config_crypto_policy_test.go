@@ -0,0 +1,119 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package pki
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPki_CryptoPolicy(t *testing.T) {
+	t.Parallel()
+	b, s := CreateBackendWithStorage(t)
+
+	resp, err := CBRead(b, s, "config/crypto-policy")
+	requireSuccessNonNilResponse(t, resp, err)
+	require.Equal(t, []string{}, resp.Data["allowed_key_types"])
+	require.Equal(t, 0, resp.Data["min_rsa_key_bits"])
+
+	for name, data := range map[string]map[string]interface{}{
+		"unknown key type":          {"allowed_key_types": "rsa,dsa"},
+		"unsupported rsa size":      {"min_rsa_key_bits": 1024},
+		"unsupported ec size":       {"min_ec_key_bits": 300},
+		"default below the minimum": {"min_rsa_key_bits": 4096, "default_rsa_key_bits": 3072},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := CBWrite(b, s, "config/crypto-policy", data)
+			require.Error(t, err)
+		})
+	}
+
+	resp, err = CBWrite(b, s, "config/crypto-policy", map[string]interface{}{
+		"allowed_key_types":    "rsa,ec",
+		"min_rsa_key_bits":     3072,
+		"min_ec_key_bits":      384,
+		"default_rsa_key_bits": 4096,
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	require.Equal(t, []string{"rsa", "ec"}, resp.Data["allowed_key_types"])
+
+	// CA generation uses the default RSA key size, and enforces the policy
+	_, err = CBWrite(b, s, "root/generate/internal", map[string]interface{}{
+		"common_name": "weak.com",
+		"key_type":    "ec",
+		"key_bits":    256,
+	})
+	require.ErrorContains(t, err, "EC keys of at least 384 bits")
+	_, err = CBWrite(b, s, "root/generate/internal", map[string]interface{}{
+		"common_name": "ed.com",
+		"key_type":    "ed25519",
+	})
+	require.ErrorContains(t, err, "does not allow ed25519 keys")
+	resp, err = CBWrite(b, s, "root/generate/internal", map[string]interface{}{
+		"common_name": "root.com",
+		"ttl":         "24h",
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	require.Equal(t, 4096, parseCert(t, resp.Data["certificate"].(string)).PublicKey.(*rsa.PublicKey).N.BitLen())
+
+	// Roles default to the policy's RSA key size, and may not violate it
+	_, err = CBWrite(b, s, "roles/weak", map[string]interface{}{
+		"allow_any_name": true,
+		"key_bits":       2048,
+	})
+	require.ErrorContains(t, err, "RSA keys of at least 3072 bits")
+	resp, err = CBWrite(b, s, "roles/any", map[string]interface{}{
+		"allow_any_name": true,
+		"key_type":       "any",
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	resp, err = CBWrite(b, s, "roles/default", map[string]interface{}{
+		"allow_any_name": true,
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	require.Equal(t, 4096, resp.Data["key_bits"])
+
+	resp, err = CBWrite(b, s, "issue/default", map[string]interface{}{
+		"common_name": "leaf.com",
+		"ttl":         "1h",
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+
+	// CSR signing enforces the policy on the key of the CSR, even for roles
+	// allowing any key type
+	csrPEM := func(key interface{}) string {
+		t.Helper()
+		der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+			Subject: pkix.Name{CommonName: "csr.com"},
+		}, key)
+		require.NoError(t, err)
+		return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der}))
+	}
+	weakKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	strongKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+
+	for _, path := range []string{"sign/any", "sign-verbatim", "root/sign-intermediate"} {
+		_, err = CBWrite(b, s, path, map[string]interface{}{
+			"csr":         csrPEM(weakKey),
+			"common_name": "csr.com",
+		})
+		require.ErrorContains(t, err, "EC keys of at least 384 bits", path)
+	}
+	resp, err = CBWrite(b, s, "sign/any", map[string]interface{}{
+		"csr":         csrPEM(strongKey),
+		"common_name": "csr.com",
+		"ttl":         "1h",
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+}
@@ -0,0 +1,176 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package pki
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/errutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	storageCTConfig = "config/ct"
+
+	defaultCTSubmissionTimeout = 10 * time.Second
+)
+
+type ctConfigEntry struct {
+	LogURLs           []string      `json:"log_urls"`
+	SubmissionTimeout time.Duration `json:"submission_timeout"`
+}
+
+func getCTConfig(sc *storageContext) (*ctConfigEntry, error) {
+	entry, err := sc.Storage.Get(sc.Context, storageCTConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &ctConfigEntry{
+		LogURLs:           []string{},
+		SubmissionTimeout: defaultCTSubmissionTimeout,
+	}
+	if entry == nil {
+		return config, nil
+	}
+
+	if err := entry.DecodeJSON(config); err != nil {
+		return nil, errutil.InternalError{Err: fmt.Sprintf("unable to decode CT configuration: %v", err)}
+	}
+	if config.LogURLs == nil {
+		config.LogURLs = []string{}
+	}
+
+	return config, nil
+}
+
+func (sc *storageContext) setCTConfig(entry *ctConfigEntry) error {
+	json, err := logical.StorageEntryJSON(storageCTConfig, entry)
+	if err != nil {
+		return fmt.Errorf("failed creating storage entry: %w", err)
+	}
+
+	if err := sc.Storage.Put(sc.Context, json); err != nil {
+		return fmt.Errorf("failed writing storage entry: %w", err)
+	}
+
+	return nil
+}
+
+func pathConfigCT(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "config/ct",
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixPKI,
+		},
+
+		Fields: map[string]*framework.FieldSchema{
+			"log_urls": {
+				Type:        framework.TypeCommaStringSlice,
+				Description: `the base URLs of the Certificate Transparency (RFC 6962) logs precertificates are submitted to, for roles with ct_submission enabled; every log must return an SCT for a certificate to be issued`,
+			},
+			"submission_timeout": {
+				Type:        framework.TypeDurationSecond,
+				Description: `how long to wait for each log to return an SCT, defaults to 10s`,
+				Default:     int(defaultCTSubmissionTimeout.Seconds()),
+			},
+		},
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.ReadOperation: &framework.PathOperation{
+				DisplayAttrs: &framework.DisplayAttributes{
+					OperationSuffix: "ct-configuration",
+				},
+				Callback: b.pathCTConfigRead,
+			},
+			logical.UpdateOperation: &framework.PathOperation{
+				Callback: b.pathCTConfigWrite,
+				DisplayAttrs: &framework.DisplayAttributes{
+					OperationVerb:   "configure",
+					OperationSuffix: "ct",
+				},
+				// Read more about why these flags are set in backend.go.
+				ForwardPerformanceStandby:   true,
+				ForwardPerformanceSecondary: true,
+			},
+		},
+
+		HelpSynopsis:    pathConfigCTHelpSyn,
+		HelpDescription: pathConfigCTHelpDesc,
+	}
+}
+
+func (b *backend) pathCTConfigRead(ctx context.Context, req *logical.Request, _ *framework.FieldData) (*logical.Response, error) {
+	sc := b.makeStorageContext(ctx, req.Storage)
+	config, err := getCTConfig(sc)
+	if err != nil {
+		return nil, err
+	}
+
+	return genResponseFromCTConfig(config), nil
+}
+
+func genResponseFromCTConfig(config *ctConfigEntry) *logical.Response {
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"log_urls":           config.LogURLs,
+			"submission_timeout": int64(config.SubmissionTimeout.Seconds()),
+		},
+	}
+}
+
+func (b *backend) pathCTConfigWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	sc := b.makeStorageContext(ctx, req.Storage)
+
+	config, err := getCTConfig(sc)
+	if err != nil {
+		return nil, err
+	}
+
+	if logURLsRaw, ok := d.GetOk("log_urls"); ok {
+		config.LogURLs = []string{}
+		for _, logURL := range logURLsRaw.([]string) {
+			logURL = strings.TrimSpace(logURL)
+			if logURL == "" {
+				continue
+			}
+			parsed, err := url.Parse(logURL)
+			if err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+				return logical.ErrorResponse("invalid CT log URL %q, must be an http or https URL", logURL), nil
+			}
+			config.LogURLs = append(config.LogURLs, strings.TrimRight(logURL, "/"))
+		}
+	}
+	if timeoutRaw, ok := d.GetOk("submission_timeout"); ok {
+		config.SubmissionTimeout = time.Duration(timeoutRaw.(int)) * time.Second
+	}
+
+	if config.SubmissionTimeout <= 0 {
+		return logical.ErrorResponse("submission_timeout must be positive"), nil
+	}
+
+	if err := sc.setCTConfig(config); err != nil {
+		return nil, err
+	}
+
+	return genResponseFromCTConfig(config), nil
+}
+
+const pathConfigCTHelpSyn = `Configuration of Certificate Transparency log submission`
+
+const pathConfigCTHelpDesc = `
+This endpoint configures the Certificate Transparency (RFC 6962) logs of the
+mount. Certificates issued by roles with ct_submission enabled are first
+issued as precertificates, which are submitted to every log in log_urls; the
+Signed Certificate Timestamps (SCTs) returned by the logs are then embedded
+into the issued certificate.
+
+Issuance fails if any log does not return an SCT within submission_timeout.
+`
@@ -0,0 +1,365 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package pki
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/errutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	storageEstConfig = "config/est"
+
+	estAuthenticatorCert     = "cert"
+	estAuthenticatorUserpass = "userpass"
+
+	// estWellKnownPrefix is the path under /.well-known/ reserved for EST by
+	// RFC 7030 Section 3.2.2.
+	estWellKnownPrefix = "est"
+)
+
+var estLabelRegex = regexp.MustCompile(`^` + framework.GenericNameRegex("label") + `$`)
+
+type estAuthenticator struct {
+	Accessor string `json:"accessor"`
+	CertRole string `json:"cert_role,omitempty"`
+}
+
+type estConfigEntry struct {
+	Enabled           bool                         `json:"enabled"`
+	DefaultMount      bool                         `json:"default_mount"`
+	DefaultPathPolicy string                       `json:"default_path_policy"`
+	LabelToPathPolicy map[string]string            `json:"label_to_path_policy"`
+	Authenticators    map[string]*estAuthenticator `json:"authenticators"`
+	LastUpdated       time.Time                    `json:"last_updated"`
+}
+
+// wellKnownSources returns the paths under /.well-known/ the configuration
+// requires to be redirected to this mount, relative to the mount. The
+// redirect of the default mount also covers its labels.
+func (c *estConfigEntry) wellKnownSources() []string {
+	if !c.Enabled {
+		return nil
+	}
+	if c.DefaultMount {
+		return []string{estWellKnownPrefix}
+	}
+
+	sources := make([]string, 0, len(c.LabelToPathPolicy))
+	for label := range c.LabelToPathPolicy {
+		sources = append(sources, estWellKnownPrefix+"/"+label)
+	}
+	sort.Strings(sources)
+	return sources
+}
+
+func getEstConfig(sc *storageContext) (*estConfigEntry, error) {
+	entry, err := sc.Storage.Get(sc.Context, storageEstConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &estConfigEntry{
+		LabelToPathPolicy: map[string]string{},
+		Authenticators:    map[string]*estAuthenticator{},
+	}
+	if entry == nil {
+		return config, nil
+	}
+
+	if err := entry.DecodeJSON(config); err != nil {
+		return nil, errutil.InternalError{Err: fmt.Sprintf("unable to decode EST configuration: %v", err)}
+	}
+	if config.LabelToPathPolicy == nil {
+		config.LabelToPathPolicy = map[string]string{}
+	}
+	if config.Authenticators == nil {
+		config.Authenticators = map[string]*estAuthenticator{}
+	}
+
+	return config, nil
+}
+
+func (sc *storageContext) setEstConfig(entry *estConfigEntry) error {
+	json, err := logical.StorageEntryJSON(storageEstConfig, entry)
+	if err != nil {
+		return fmt.Errorf("failed creating storage entry: %w", err)
+	}
+
+	if err := sc.Storage.Put(sc.Context, json); err != nil {
+		return fmt.Errorf("failed writing storage entry: %w", err)
+	}
+
+	return nil
+}
+
+func pathEstConfig(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "config/est",
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixPKI,
+		},
+
+		Fields: map[string]*framework.FieldSchema{
+			"enabled": {
+				Type:        framework.TypeBool,
+				Description: `whether EST is enabled, defaults to false`,
+				Default:     false,
+			},
+			"default_mount": {
+				Type:        framework.TypeBool,
+				Description: `whether this mount serves the default .well-known/est path; only a single mount can enable this`,
+				Default:     false,
+			},
+			"default_path_policy": {
+				Type:        framework.TypeString,
+				Description: `the policy of requests to the default EST label, required if default_mount is set: either "sign-verbatim" or a role given as "role:<role_name>"`,
+			},
+			"label_to_path_policy": {
+				Type:        framework.TypeKVPairs,
+				Description: `a mapping of EST labels to their policy, either "sign-verbatim" or a role given as "role:<role_name>"; each label registers the .well-known/est/<label> path, unless this is the default mount`,
+			},
+			"authenticators": {
+				Type:        framework.TypeMap,
+				Description: `the auth mounts EST requests are authenticated against: a map from "cert" or "userpass" to a map holding the "accessor" of the mount, and for "cert" optionally the "cert_role" to log in with`,
+			},
+		},
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.ReadOperation: &framework.PathOperation{
+				DisplayAttrs: &framework.DisplayAttributes{
+					OperationSuffix: "est-configuration",
+				},
+				Callback: b.pathEstConfigRead,
+			},
+			logical.UpdateOperation: &framework.PathOperation{
+				Callback: b.pathEstConfigWrite,
+				DisplayAttrs: &framework.DisplayAttributes{
+					OperationVerb:   "configure",
+					OperationSuffix: "est",
+				},
+				// Read more about why these flags are set in backend.go.
+				ForwardPerformanceStandby:   true,
+				ForwardPerformanceSecondary: true,
+			},
+		},
+
+		HelpSynopsis:    pathConfigEstHelpSyn,
+		HelpDescription: pathConfigEstHelpDesc,
+	}
+}
+
+func (b *backend) pathEstConfigRead(ctx context.Context, req *logical.Request, _ *framework.FieldData) (*logical.Response, error) {
+	sc := b.makeStorageContext(ctx, req.Storage)
+	config, err := getEstConfig(sc)
+	if err != nil {
+		return nil, err
+	}
+
+	return genResponseFromEstConfig(config), nil
+}
+
+func genResponseFromEstConfig(config *estConfigEntry) *logical.Response {
+	authenticators := make(map[string]interface{}, len(config.Authenticators))
+	for name, authenticator := range config.Authenticators {
+		data := map[string]interface{}{
+			"accessor": authenticator.Accessor,
+		}
+		if authenticator.CertRole != "" {
+			data["cert_role"] = authenticator.CertRole
+		}
+		authenticators[name] = data
+	}
+
+	data := map[string]interface{}{
+		"enabled":              config.Enabled,
+		"default_mount":        config.DefaultMount,
+		"default_path_policy":  config.DefaultPathPolicy,
+		"label_to_path_policy": config.LabelToPathPolicy,
+		"authenticators":       authenticators,
+	}
+	if !config.LastUpdated.IsZero() {
+		data["last_updated"] = config.LastUpdated.Format(time.RFC3339)
+	}
+
+	return &logical.Response{
+		Data: data,
+	}
+}
+
+func (b *backend) pathEstConfigWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	sc := b.makeStorageContext(ctx, req.Storage)
+
+	oldConfig, err := getEstConfig(sc)
+	if err != nil {
+		return nil, err
+	}
+	config := *oldConfig
+
+	if enabledRaw, ok := d.GetOk("enabled"); ok {
+		config.Enabled = enabledRaw.(bool)
+	}
+	if defaultMountRaw, ok := d.GetOk("default_mount"); ok {
+		config.DefaultMount = defaultMountRaw.(bool)
+	}
+	if defaultPathPolicyRaw, ok := d.GetOk("default_path_policy"); ok {
+		config.DefaultPathPolicy = defaultPathPolicyRaw.(string)
+	}
+	if labelToPathPolicyRaw, ok := d.GetOk("label_to_path_policy"); ok {
+		config.LabelToPathPolicy = labelToPathPolicyRaw.(map[string]string)
+	}
+	if authenticatorsRaw, ok := d.GetOk("authenticators"); ok {
+		config.Authenticators, err = parseEstAuthenticators(authenticatorsRaw.(map[string]interface{}))
+		if err != nil {
+			return logical.ErrorResponse(err.Error()), nil
+		}
+	}
+
+	if config.DefaultPathPolicy != "" {
+		if err := validateEnrollmentPathPolicy(sc, config.DefaultPathPolicy); err != nil {
+			return logical.ErrorResponse("invalid default_path_policy: %s", err), nil
+		}
+	} else if config.DefaultMount {
+		return logical.ErrorResponse("default_path_policy must be set when default_mount is enabled"), nil
+	}
+
+	for label, policy := range config.LabelToPathPolicy {
+		if !estLabelRegex.MatchString(label) || isEstOperation(label) {
+			return logical.ErrorResponse("invalid EST label %q", label), nil
+		}
+		if err := validateEnrollmentPathPolicy(sc, policy); err != nil {
+			return logical.ErrorResponse("invalid path policy for label %q: %s", label, err), nil
+		}
+	}
+
+	config.LastUpdated = time.Now()
+
+	// Claim the .well-known paths before persisting the configuration, so
+	// conflicts with other mounts are reported to the operator.
+	if err := b.syncEstWellKnownRedirects(ctx, &config); err != nil {
+		if restoreErr := b.syncEstWellKnownRedirects(ctx, oldConfig); restoreErr != nil {
+			b.Logger().Error("failed restoring the EST .well-known redirects", "error", restoreErr)
+		}
+		return logical.ErrorResponse("unable to register the EST .well-known paths: %s", err), nil
+	}
+
+	if err := sc.setEstConfig(&config); err != nil {
+		return nil, err
+	}
+
+	return genResponseFromEstConfig(&config), nil
+}
+
+func parseEstAuthenticators(raw map[string]interface{}) (map[string]*estAuthenticator, error) {
+	authenticators := make(map[string]*estAuthenticator, len(raw))
+	for name, value := range raw {
+		if name != estAuthenticatorCert && name != estAuthenticatorUserpass {
+			return nil, fmt.Errorf("unknown authenticator %q, must be %q or %q", name, estAuthenticatorCert, estAuthenticatorUserpass)
+		}
+
+		fields, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("authenticator %q must be a map", name)
+		}
+
+		authenticator := &estAuthenticator{}
+		for key, fieldValue := range fields {
+			str, ok := fieldValue.(string)
+			if !ok {
+				return nil, fmt.Errorf("field %q of authenticator %q must be a string", key, name)
+			}
+			switch {
+			case key == "accessor":
+				authenticator.Accessor = strings.TrimSpace(str)
+			case key == "cert_role" && name == estAuthenticatorCert:
+				authenticator.CertRole = strings.TrimSpace(str)
+			default:
+				return nil, fmt.Errorf("unknown field %q of authenticator %q", key, name)
+			}
+		}
+		if authenticator.Accessor == "" {
+			return nil, fmt.Errorf("authenticator %q requires an accessor", name)
+		}
+
+		authenticators[name] = authenticator
+	}
+
+	return authenticators, nil
+}
+
+// syncEstWellKnownRedirects replaces the .well-known redirects registered
+// for EST by this mount with those required by the configuration.
+func (b *backend) syncEstWellKnownRedirects(ctx context.Context, config *estConfigEntry) error {
+	b.estRedirectsLock.Lock()
+	defer b.estRedirectsLock.Unlock()
+
+	sources := config.wellKnownSources()
+	if len(sources) == 0 && len(b.estRedirects) == 0 {
+		return nil
+	}
+
+	sys, ok := b.System().(logical.WellKnownSystemView)
+	if !ok {
+		return errors.New("this mount cannot register .well-known paths")
+	}
+
+	for _, source := range b.estRedirects {
+		sys.DeregisterWellKnownRedirect(ctx, source)
+	}
+	b.estRedirects = nil
+
+	for _, source := range sources {
+		if err := sys.RequestWellKnownRedirect(ctx, source, source); err != nil {
+			return err
+		}
+		b.estRedirects = append(b.estRedirects, source)
+	}
+
+	return nil
+}
+
+// reloadEstWellKnownRedirects registers the .well-known redirects of the
+// stored configuration, on startup or when it was changed on another node.
+func (b *backend) reloadEstWellKnownRedirects(sc *storageContext) {
+	config, err := getEstConfig(sc)
+	if err != nil {
+		b.Logger().Error("failed loading the EST configuration", "error", err)
+		return
+	}
+
+	if err := b.syncEstWellKnownRedirects(sc.Context, config); err != nil {
+		b.Logger().Error("failed registering the EST .well-known paths", "error", err)
+	}
+}
+
+const pathConfigEstHelpSyn = `Configuration of EST endpoints`
+
+const pathConfigEstHelpDesc = `
+This endpoint configures the EST (RFC 7030) endpoints of the mount:
+
+enabled, whether EST is enabled, defaults to false.
+
+default_mount, whether the .well-known/est path is redirected to this mount.
+Only a single mount can enable this.
+
+default_path_policy, the policy of requests to the default label, either
+"sign-verbatim" or a role given as "role:<role_name>".
+
+label_to_path_policy, a mapping of EST labels to their policy. Requests to
+.well-known/est/<label> are served by the policy of the label.
+
+authenticators, the cert and userpass auth mounts EST clients authenticate
+against, which must also be listed in the delegated_auth_accessors of the
+mount and issue batch tokens.
+`
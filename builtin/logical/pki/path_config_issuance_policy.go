@@ -0,0 +1,181 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package pki
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/errutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	storageIssuancePolicyConfig = "config/issuance-policy"
+
+	defaultIssuancePolicyTimeout = 10 * time.Second
+)
+
+type issuancePolicyConfigEntry struct {
+	URL           string        `json:"url"`
+	CACertificate string        `json:"ca_certificate"`
+	Timeout       time.Duration `json:"timeout"`
+}
+
+func getIssuancePolicyConfig(sc *storageContext) (*issuancePolicyConfigEntry, error) {
+	entry, err := sc.Storage.Get(sc.Context, storageIssuancePolicyConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &issuancePolicyConfigEntry{
+		Timeout: defaultIssuancePolicyTimeout,
+	}
+	if entry == nil {
+		return config, nil
+	}
+
+	if err := entry.DecodeJSON(config); err != nil {
+		return nil, errutil.InternalError{Err: fmt.Sprintf("unable to decode issuance policy configuration: %v", err)}
+	}
+
+	return config, nil
+}
+
+func (sc *storageContext) setIssuancePolicyConfig(entry *issuancePolicyConfigEntry) error {
+	json, err := logical.StorageEntryJSON(storageIssuancePolicyConfig, entry)
+	if err != nil {
+		return fmt.Errorf("failed creating storage entry: %w", err)
+	}
+
+	if err := sc.Storage.Put(sc.Context, json); err != nil {
+		return fmt.Errorf("failed writing storage entry: %w", err)
+	}
+
+	return nil
+}
+
+func pathConfigIssuancePolicy(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "config/issuance-policy",
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixPKI,
+		},
+
+		Fields: map[string]*framework.FieldSchema{
+			"url": {
+				Type:        framework.TypeString,
+				Description: `the https URL of the policy service which leaf certificate requests are sent to before signing; empty to disable the callout`,
+			},
+			"ca_certificate": {
+				Type:        framework.TypeString,
+				Description: `PEM-encoded CA certificates trusted to verify the TLS certificate of the policy service, in place of the system roots`,
+			},
+			"timeout": {
+				Type:        framework.TypeDurationSecond,
+				Description: `how long to wait for the policy service to answer, defaults to 10s`,
+				Default:     int(defaultIssuancePolicyTimeout.Seconds()),
+			},
+		},
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.ReadOperation: &framework.PathOperation{
+				DisplayAttrs: &framework.DisplayAttributes{
+					OperationSuffix: "issuance-policy-configuration",
+				},
+				Callback: b.pathIssuancePolicyConfigRead,
+			},
+			logical.UpdateOperation: &framework.PathOperation{
+				Callback: b.pathIssuancePolicyConfigWrite,
+				DisplayAttrs: &framework.DisplayAttributes{
+					OperationVerb:   "configure",
+					OperationSuffix: "issuance-policy",
+				},
+				// Read more about why these flags are set in backend.go.
+				ForwardPerformanceStandby:   true,
+				ForwardPerformanceSecondary: true,
+			},
+		},
+
+		HelpSynopsis:    pathConfigIssuancePolicyHelpSyn,
+		HelpDescription: pathConfigIssuancePolicyHelpDesc,
+	}
+}
+
+func (b *backend) pathIssuancePolicyConfigRead(ctx context.Context, req *logical.Request, _ *framework.FieldData) (*logical.Response, error) {
+	sc := b.makeStorageContext(ctx, req.Storage)
+	config, err := getIssuancePolicyConfig(sc)
+	if err != nil {
+		return nil, err
+	}
+
+	return genResponseFromIssuancePolicyConfig(config), nil
+}
+
+func genResponseFromIssuancePolicyConfig(config *issuancePolicyConfigEntry) *logical.Response {
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"url":            config.URL,
+			"ca_certificate": config.CACertificate,
+			"timeout":        int64(config.Timeout.Seconds()),
+		},
+	}
+}
+
+func (b *backend) pathIssuancePolicyConfigWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	sc := b.makeStorageContext(ctx, req.Storage)
+
+	config, err := getIssuancePolicyConfig(sc)
+	if err != nil {
+		return nil, err
+	}
+
+	if urlRaw, ok := d.GetOk("url"); ok {
+		config.URL = strings.TrimSpace(urlRaw.(string))
+	}
+	if caRaw, ok := d.GetOk("ca_certificate"); ok {
+		config.CACertificate = strings.TrimSpace(caRaw.(string))
+	}
+	if timeoutRaw, ok := d.GetOk("timeout"); ok {
+		config.Timeout = time.Duration(timeoutRaw.(int)) * time.Second
+	}
+
+	if config.URL != "" {
+		parsed, err := url.Parse(config.URL)
+		if err != nil || parsed.Scheme != "https" || parsed.Host == "" {
+			return logical.ErrorResponse("invalid policy service URL %q, must be an https URL", config.URL), nil
+		}
+	}
+	if config.CACertificate != "" && !x509.NewCertPool().AppendCertsFromPEM([]byte(config.CACertificate)) {
+		return logical.ErrorResponse("ca_certificate does not contain any PEM-encoded certificate"), nil
+	}
+	if config.Timeout <= 0 {
+		return logical.ErrorResponse("timeout must be positive"), nil
+	}
+
+	if err := sc.setIssuancePolicyConfig(config); err != nil {
+		return nil, err
+	}
+
+	return genResponseFromIssuancePolicyConfig(config), nil
+}
+
+const pathConfigIssuancePolicyHelpSyn = `Configuration of the external issuance policy service`
+
+const pathConfigIssuancePolicyHelpDesc = `
+This endpoint configures a policy service which every leaf certificate
+request of the mount, whether issued or signed through a role, ACME, SCEP or
+EST, is sent to before signing.
+
+The service answers whether to allow or deny the request, and may rewrite its
+common name, subject alternative names and expiration within what the role
+allows. Issuance fails if the service denies the request, or cannot be
+reached within timeout.
+`
@@ -0,0 +1,190 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package pki
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/errutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	storageIssuanceQuotasConfig = "config/issuance-quotas"
+
+	defaultIssuanceQuotaWindow = time.Hour
+)
+
+type issuanceQuotasConfigEntry struct {
+	MaxIssuancesPerRole    int           `json:"max_issuances_per_role"`
+	MaxIssuancesPerEntity  int           `json:"max_issuances_per_entity"`
+	Window                 time.Duration `json:"window"`
+	MaxActivePerCommonName int           `json:"max_active_per_common_name"`
+}
+
+func getIssuanceQuotasConfig(sc *storageContext) (*issuanceQuotasConfigEntry, error) {
+	entry, err := sc.Storage.Get(sc.Context, storageIssuanceQuotasConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &issuanceQuotasConfigEntry{
+		Window: defaultIssuanceQuotaWindow,
+	}
+	if entry == nil {
+		return config, nil
+	}
+
+	if err := entry.DecodeJSON(config); err != nil {
+		return nil, errutil.InternalError{Err: fmt.Sprintf("unable to decode issuance quotas configuration: %v", err)}
+	}
+
+	return config, nil
+}
+
+func (sc *storageContext) setIssuanceQuotasConfig(entry *issuanceQuotasConfigEntry) error {
+	json, err := logical.StorageEntryJSON(storageIssuanceQuotasConfig, entry)
+	if err != nil {
+		return fmt.Errorf("failed creating storage entry: %w", err)
+	}
+
+	if err := sc.Storage.Put(sc.Context, json); err != nil {
+		return fmt.Errorf("failed writing storage entry: %w", err)
+	}
+
+	return nil
+}
+
+func pathConfigIssuanceQuotas(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "config/issuance-quotas",
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixPKI,
+		},
+
+		Fields: map[string]*framework.FieldSchema{
+			"max_issuances_per_role": {
+				Type:        framework.TypeInt,
+				Description: `the maximum number of certificates each role may issue or sign per window; 0 for no limit`,
+				Default:     0,
+			},
+			"max_issuances_per_entity": {
+				Type:        framework.TypeInt,
+				Description: `the maximum number of certificates each entity may have issued or signed per window; 0 for no limit`,
+				Default:     0,
+			},
+			"window": {
+				Type:        framework.TypeDurationSecond,
+				Description: `the window over which issuances are counted, defaults to 1h`,
+				Default:     int(defaultIssuanceQuotaWindow.Seconds()),
+			},
+			"max_active_per_common_name": {
+				Type:        framework.TypeInt,
+				Description: `the maximum number of unexpired, unrevoked certificates issued with the same common name; 0 for no limit`,
+				Default:     0,
+			},
+		},
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.ReadOperation: &framework.PathOperation{
+				DisplayAttrs: &framework.DisplayAttributes{
+					OperationSuffix: "issuance-quotas-configuration",
+				},
+				Callback: b.pathIssuanceQuotasConfigRead,
+			},
+			logical.UpdateOperation: &framework.PathOperation{
+				Callback: b.pathIssuanceQuotasConfigWrite,
+				DisplayAttrs: &framework.DisplayAttributes{
+					OperationVerb:   "configure",
+					OperationSuffix: "issuance-quotas",
+				},
+				// Read more about why these flags are set in backend.go.
+				ForwardPerformanceStandby:   true,
+				ForwardPerformanceSecondary: true,
+			},
+		},
+
+		HelpSynopsis:    pathConfigIssuanceQuotasHelpSyn,
+		HelpDescription: pathConfigIssuanceQuotasHelpDesc,
+	}
+}
+
+func (b *backend) pathIssuanceQuotasConfigRead(ctx context.Context, req *logical.Request, _ *framework.FieldData) (*logical.Response, error) {
+	sc := b.makeStorageContext(ctx, req.Storage)
+	config, err := getIssuanceQuotasConfig(sc)
+	if err != nil {
+		return nil, err
+	}
+
+	return genResponseFromIssuanceQuotasConfig(config), nil
+}
+
+func genResponseFromIssuanceQuotasConfig(config *issuanceQuotasConfigEntry) *logical.Response {
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"max_issuances_per_role":     config.MaxIssuancesPerRole,
+			"max_issuances_per_entity":   config.MaxIssuancesPerEntity,
+			"window":                     int64(config.Window.Seconds()),
+			"max_active_per_common_name": config.MaxActivePerCommonName,
+		},
+	}
+}
+
+func (b *backend) pathIssuanceQuotasConfigWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	sc := b.makeStorageContext(ctx, req.Storage)
+
+	config, err := getIssuanceQuotasConfig(sc)
+	if err != nil {
+		return nil, err
+	}
+
+	if maxRaw, ok := d.GetOk("max_issuances_per_role"); ok {
+		config.MaxIssuancesPerRole = maxRaw.(int)
+	}
+	if maxRaw, ok := d.GetOk("max_issuances_per_entity"); ok {
+		config.MaxIssuancesPerEntity = maxRaw.(int)
+	}
+	if windowRaw, ok := d.GetOk("window"); ok {
+		config.Window = time.Duration(windowRaw.(int)) * time.Second
+	}
+	if maxRaw, ok := d.GetOk("max_active_per_common_name"); ok {
+		config.MaxActivePerCommonName = maxRaw.(int)
+	}
+
+	if config.MaxIssuancesPerRole < 0 || config.MaxIssuancesPerEntity < 0 || config.MaxActivePerCommonName < 0 {
+		return logical.ErrorResponse("max_issuances_per_role, max_issuances_per_entity and max_active_per_common_name must not be negative"), nil
+	}
+	if config.Window <= 0 {
+		return logical.ErrorResponse("window must be positive"), nil
+	}
+
+	if err := sc.setIssuanceQuotasConfig(config); err != nil {
+		return nil, err
+	}
+
+	// Start counting anew under the new limits.
+	b.issuanceQuotas.reset()
+
+	return genResponseFromIssuanceQuotasConfig(config), nil
+}
+
+const pathConfigIssuanceQuotasHelpSyn = `Configuration of the certificate issuance quotas`
+
+const pathConfigIssuanceQuotasHelpDesc = `
+This endpoint configures quotas on the certificates issued or signed through
+the roles of the mount, on the issue, sign and sign-verbatim endpoints.
+
+Issuances are counted per role and per entity over a fixed window; requests
+beyond max_issuances_per_role or max_issuances_per_entity are refused until
+the window ends. These counts are kept in memory by each node.
+
+Requests for a certificate whose common name already has
+max_active_per_common_name unexpired, unrevoked certificates are refused.
+
+Refused requests fail with status 429.
+`
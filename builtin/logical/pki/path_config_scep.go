@@ -0,0 +1,398 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package pki
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/go-secure-stdlib/base62"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/errutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	storageScepConfig = "config/scep"
+
+	// storageScepChallengePrefix holds the outstanding dynamic challenges,
+	// keyed by their hash. Challenges are consumed by the cluster which
+	// issued them, so this storage is local.
+	storageScepChallengePrefix = "scep/challenges/"
+
+	scepChallengeTypeStatic  = "static"
+	scepChallengeTypeDynamic = "dynamic"
+
+	scepDefaultDynamicChallengeTTL = 1 * time.Hour
+
+	// scepChallengeTidyInterval bounds how often expired dynamic challenges
+	// are removed by the periodic function.
+	scepChallengeTidyInterval = 1 * time.Hour
+)
+
+type scepConfigEntry struct {
+	Enabled             bool          `json:"enabled"`
+	DefaultPathPolicy   string        `json:"default_path_policy"`
+	ChallengeType       string        `json:"challenge_type"`
+	StaticChallenge     string        `json:"static_challenge"`
+	DynamicChallengeTTL time.Duration `json:"dynamic_challenge_ttl"`
+	LastUpdated         time.Time     `json:"last_updated"`
+}
+
+type scepChallengeEntry struct {
+	Expiration time.Time `json:"expiration"`
+}
+
+func getScepConfig(sc *storageContext) (*scepConfigEntry, error) {
+	entry, err := sc.Storage.Get(sc.Context, storageScepConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &scepConfigEntry{
+		ChallengeType:       scepChallengeTypeStatic,
+		DynamicChallengeTTL: scepDefaultDynamicChallengeTTL,
+	}
+	if entry == nil {
+		return config, nil
+	}
+
+	if err := entry.DecodeJSON(config); err != nil {
+		return nil, errutil.InternalError{Err: fmt.Sprintf("unable to decode SCEP configuration: %v", err)}
+	}
+
+	return config, nil
+}
+
+func (sc *storageContext) setScepConfig(entry *scepConfigEntry) error {
+	json, err := logical.StorageEntryJSON(storageScepConfig, entry)
+	if err != nil {
+		return fmt.Errorf("failed creating storage entry: %w", err)
+	}
+
+	if err := sc.Storage.Put(sc.Context, json); err != nil {
+		return fmt.Errorf("failed writing storage entry: %w", err)
+	}
+
+	return nil
+}
+
+func pathScepConfig(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "config/scep",
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixPKI,
+		},
+
+		Fields: map[string]*framework.FieldSchema{
+			"enabled": {
+				Type:        framework.TypeBool,
+				Description: `whether SCEP is enabled, defaults to false`,
+				Default:     false,
+			},
+			"default_path_policy": {
+				Type:        framework.TypeString,
+				Description: `the policy of requests to the scep path, required to enable SCEP: either "sign-verbatim" or a role given as "role:<role_name>"`,
+			},
+			"challenge_type": {
+				Type:          framework.TypeString,
+				Description:   `how enrollment requests are authenticated: "static" to accept a single shared challenge password, or "dynamic" to accept single-use challenges generated through the scep/challenge endpoint`,
+				Default:       scepChallengeTypeStatic,
+				AllowedValues: []interface{}{scepChallengeTypeStatic, scepChallengeTypeDynamic},
+			},
+			"static_challenge": {
+				Type:        framework.TypeString,
+				Description: `the challenge password accepted when challenge_type is "static"; it is never returned`,
+				DisplayAttrs: &framework.DisplayAttributes{
+					Sensitive: true,
+				},
+			},
+			"dynamic_challenge_ttl": {
+				Type:        framework.TypeDurationSecond,
+				Description: `how long dynamic challenges remain valid, defaults to 1h`,
+				Default:     int(scepDefaultDynamicChallengeTTL.Seconds()),
+			},
+		},
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.ReadOperation: &framework.PathOperation{
+				DisplayAttrs: &framework.DisplayAttributes{
+					OperationSuffix: "scep-configuration",
+				},
+				Callback: b.pathScepConfigRead,
+			},
+			logical.UpdateOperation: &framework.PathOperation{
+				Callback: b.pathScepConfigWrite,
+				DisplayAttrs: &framework.DisplayAttributes{
+					OperationVerb:   "configure",
+					OperationSuffix: "scep",
+				},
+				// Read more about why these flags are set in backend.go.
+				ForwardPerformanceStandby:   true,
+				ForwardPerformanceSecondary: true,
+			},
+		},
+
+		HelpSynopsis:    pathConfigScepHelpSyn,
+		HelpDescription: pathConfigScepHelpDesc,
+	}
+}
+
+func (b *backend) pathScepConfigRead(ctx context.Context, req *logical.Request, _ *framework.FieldData) (*logical.Response, error) {
+	sc := b.makeStorageContext(ctx, req.Storage)
+	config, err := getScepConfig(sc)
+	if err != nil {
+		return nil, err
+	}
+
+	return genResponseFromScepConfig(config), nil
+}
+
+func genResponseFromScepConfig(config *scepConfigEntry) *logical.Response {
+	data := map[string]interface{}{
+		"enabled":               config.Enabled,
+		"default_path_policy":   config.DefaultPathPolicy,
+		"challenge_type":        config.ChallengeType,
+		"static_challenge_set":  config.StaticChallenge != "",
+		"dynamic_challenge_ttl": int64(config.DynamicChallengeTTL.Seconds()),
+	}
+	if !config.LastUpdated.IsZero() {
+		data["last_updated"] = config.LastUpdated.Format(time.RFC3339)
+	}
+
+	return &logical.Response{
+		Data: data,
+	}
+}
+
+func (b *backend) pathScepConfigWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	sc := b.makeStorageContext(ctx, req.Storage)
+
+	config, err := getScepConfig(sc)
+	if err != nil {
+		return nil, err
+	}
+
+	if enabledRaw, ok := d.GetOk("enabled"); ok {
+		config.Enabled = enabledRaw.(bool)
+	}
+	if defaultPathPolicyRaw, ok := d.GetOk("default_path_policy"); ok {
+		config.DefaultPathPolicy = defaultPathPolicyRaw.(string)
+	}
+	if challengeTypeRaw, ok := d.GetOk("challenge_type"); ok {
+		config.ChallengeType = challengeTypeRaw.(string)
+	}
+	if staticChallengeRaw, ok := d.GetOk("static_challenge"); ok {
+		config.StaticChallenge = staticChallengeRaw.(string)
+	}
+	if ttlRaw, ok := d.GetOk("dynamic_challenge_ttl"); ok {
+		config.DynamicChallengeTTL = time.Duration(ttlRaw.(int)) * time.Second
+	}
+
+	switch config.ChallengeType {
+	case scepChallengeTypeStatic:
+		if config.Enabled && config.StaticChallenge == "" {
+			return logical.ErrorResponse("static_challenge must be set when challenge_type is %q", scepChallengeTypeStatic), nil
+		}
+	case scepChallengeTypeDynamic:
+		if config.DynamicChallengeTTL <= 0 {
+			return logical.ErrorResponse("dynamic_challenge_ttl must be positive"), nil
+		}
+	default:
+		return logical.ErrorResponse("invalid challenge_type %q, must be %q or %q", config.ChallengeType, scepChallengeTypeStatic, scepChallengeTypeDynamic), nil
+	}
+
+	if config.DefaultPathPolicy != "" {
+		if err := validateEnrollmentPathPolicy(sc, config.DefaultPathPolicy); err != nil {
+			return logical.ErrorResponse("invalid default_path_policy: %s", err), nil
+		}
+	} else if config.Enabled {
+		return logical.ErrorResponse("default_path_policy must be set to enable SCEP"), nil
+	}
+
+	config.LastUpdated = time.Now()
+
+	if err := sc.setScepConfig(config); err != nil {
+		return nil, err
+	}
+
+	return genResponseFromScepConfig(config), nil
+}
+
+func pathScepChallenge(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "scep/challenge",
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixPKI,
+		},
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.UpdateOperation: &framework.PathOperation{
+				Callback: b.pathScepChallengeWrite,
+				DisplayAttrs: &framework.DisplayAttributes{
+					OperationVerb:   "generate",
+					OperationSuffix: "scep-challenge",
+				},
+				// Challenges are stored locally, as they are consumed by
+				// the cluster the device enrolls with.
+				ForwardPerformanceStandby:   true,
+				ForwardPerformanceSecondary: false,
+			},
+		},
+
+		HelpSynopsis:    pathScepChallengeHelpSyn,
+		HelpDescription: pathScepChallengeHelpDesc,
+	}
+}
+
+func (b *backend) pathScepChallengeWrite(ctx context.Context, req *logical.Request, _ *framework.FieldData) (*logical.Response, error) {
+	sc := b.makeStorageContext(ctx, req.Storage)
+	config, err := getScepConfig(sc)
+	if err != nil {
+		return nil, err
+	}
+	if config.ChallengeType != scepChallengeTypeDynamic {
+		return logical.ErrorResponse("SCEP is not configured to use dynamic challenges"), nil
+	}
+
+	challenge, err := base62.Random(32)
+	if err != nil {
+		return nil, fmt.Errorf("failed generating challenge: %w", err)
+	}
+
+	expiration := time.Now().Add(config.DynamicChallengeTTL)
+	entry, err := logical.StorageEntryJSON(scepChallengeStorageKey(challenge), &scepChallengeEntry{
+		Expiration: expiration,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed creating storage entry: %w", err)
+	}
+	if err := req.Storage.Put(ctx, entry); err != nil {
+		return nil, fmt.Errorf("failed writing storage entry: %w", err)
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"challenge":  challenge,
+			"expiration": expiration.Format(time.RFC3339),
+		},
+	}, nil
+}
+
+// scepChallengeStorageKey stores dynamic challenges by their hash, so that
+// storage does not hold usable challenge passwords.
+func scepChallengeStorageKey(challenge string) string {
+	sum := sha256.Sum256([]byte(challenge))
+	return storageScepChallengePrefix + hex.EncodeToString(sum[:])
+}
+
+// validateScepChallenge checks the challenge password of an enrollment
+// request, consuming it if it is a dynamic challenge.
+func (b *backend) validateScepChallenge(sc *storageContext, config *scepConfigEntry, challenge string) (bool, error) {
+	if challenge == "" {
+		return false, nil
+	}
+
+	switch config.ChallengeType {
+	case scepChallengeTypeStatic:
+		return subtle.ConstantTimeCompare([]byte(challenge), []byte(config.StaticChallenge)) == 1, nil
+	case scepChallengeTypeDynamic:
+		key := scepChallengeStorageKey(challenge)
+
+		b.scepChallengeLock.Lock()
+		defer b.scepChallengeLock.Unlock()
+
+		entry, err := sc.Storage.Get(sc.Context, key)
+		if err != nil {
+			return false, err
+		}
+		if entry == nil {
+			return false, nil
+		}
+		if err := sc.Storage.Delete(sc.Context, key); err != nil {
+			return false, err
+		}
+
+		var challengeEntry scepChallengeEntry
+		if err := entry.DecodeJSON(&challengeEntry); err != nil {
+			return false, err
+		}
+		return time.Now().Before(challengeEntry.Expiration), nil
+	default:
+		return false, fmt.Errorf("unknown challenge type %q", config.ChallengeType)
+	}
+}
+
+// tidyScepChallenges removes expired dynamic challenges which were never
+// used, at most once per scepChallengeTidyInterval.
+func (b *backend) tidyScepChallenges(sc *storageContext) error {
+	now := time.Now()
+	if now.Before(b.lastScepChallengeTidy.Add(scepChallengeTidyInterval)) {
+		return nil
+	}
+	b.lastScepChallengeTidy = now
+
+	b.scepChallengeLock.Lock()
+	defer b.scepChallengeLock.Unlock()
+
+	keys, err := sc.Storage.List(sc.Context, storageScepChallengePrefix)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		entry, err := sc.Storage.Get(sc.Context, storageScepChallengePrefix+key)
+		if err != nil {
+			return err
+		}
+		if entry == nil {
+			continue
+		}
+
+		var challengeEntry scepChallengeEntry
+		if err := entry.DecodeJSON(&challengeEntry); err != nil {
+			return err
+		}
+		if now.After(challengeEntry.Expiration) {
+			if err := sc.Storage.Delete(sc.Context, storageScepChallengePrefix+key); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+const pathConfigScepHelpSyn = `Configuration of SCEP endpoints`
+
+const pathConfigScepHelpDesc = `
+This endpoint configures the SCEP (RFC 8894) endpoints of the mount:
+
+enabled, whether SCEP is enabled, defaults to false.
+
+default_path_policy, the policy of requests to the scep path, either
+"sign-verbatim" or a role given as "role:<role_name>". Requests to
+roles/<role>/scep always use that role.
+
+challenge_type, how enrollment requests are authenticated. With "static",
+requests must carry the static_challenge password. With "dynamic", requests
+must carry a single-use challenge from the scep/challenge endpoint, which
+expires after dynamic_challenge_ttl.
+
+Renewal requests are instead authenticated by the certificate being renewed,
+which must have been issued by the same issuer.
+`
+
+const pathScepChallengeHelpSyn = `Generate a single-use SCEP challenge password`
+
+const pathScepChallengeHelpDesc = `
+This endpoint generates a challenge password for a single SCEP enrollment,
+when config/scep uses the "dynamic" challenge type.
+`
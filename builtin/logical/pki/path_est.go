@@ -0,0 +1,376 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package pki
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/hashicorp/vault/helper/pkcs7"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/errutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	estOpCACerts        = "cacerts"
+	estOpSimpleEnroll   = "simpleenroll"
+	estOpSimpleReenroll = "simplereenroll"
+
+	estCertsOnlyContentType = "application/pkcs7-mime; smime-type=certs-only"
+
+	// estMaximumRequestSize bounds the base64-encoded PKCS#10 request bodies
+	// we are willing to read.
+	estMaximumRequestSize = 64 * 1024
+)
+
+var oidExtensionSubjectAltName = []int{2, 5, 29, 17}
+
+func isEstOperation(name string) bool {
+	switch name {
+	case estOpCACerts, estOpSimpleEnroll, estOpSimpleReenroll:
+		return true
+	default:
+		return false
+	}
+}
+
+// setupEstPaths registers the EST operations underneath the given prefix, as
+// defined in RFC 7030 Section 3.2.2. Like ACME, these paths authenticate
+// their clients on their own and are hence unauthenticated within Vault.
+func setupEstPaths(b *backend, estPrefix string, unauthPrefix string) {
+	estPrefix = strings.TrimRight(estPrefix, "/")
+	unauthPrefix = strings.TrimRight(unauthPrefix, "/")
+
+	b.Backend.Paths = append(b.Backend.Paths, pathEstCACerts(b, estPrefix))
+	b.Backend.Paths = append(b.Backend.Paths, pathEstEnroll(b, estPrefix, estOpSimpleEnroll))
+	b.Backend.Paths = append(b.Backend.Paths, pathEstEnroll(b, estPrefix, estOpSimpleReenroll))
+
+	// EST requests and responses are not JSON, so all paths are binary.
+	for _, op := range []string{estOpCACerts, estOpSimpleEnroll, estOpSimpleReenroll} {
+		b.PathsSpecial.Unauthenticated = append(b.PathsSpecial.Unauthenticated, unauthPrefix+"/"+op)
+		b.PathsSpecial.Binary = append(b.PathsSpecial.Binary, unauthPrefix+"/"+op)
+	}
+}
+
+func addFieldsForEstPath(fields map[string]*framework.FieldSchema, pattern string) {
+	if strings.Contains(pattern, framework.GenericNameRegex("role")) {
+		fields["role"] = &framework.FieldSchema{
+			Type:        framework.TypeString,
+			Description: `The desired role for the EST request`,
+			Required:    true,
+		}
+	}
+	if strings.Contains(pattern, framework.GenericNameRegex("label")) {
+		fields["label"] = &framework.FieldSchema{
+			Type:        framework.TypeString,
+			Description: `The EST label of the request, as configured in label_to_path_policy`,
+			Required:    true,
+		}
+	}
+}
+
+func pathEstCACerts(b *backend, estPrefix string) *framework.Path {
+	pattern := estPrefix + "/" + estOpCACerts
+	fields := map[string]*framework.FieldSchema{}
+	addFieldsForEstPath(fields, pattern)
+
+	return &framework.Path{
+		Pattern: pattern,
+		Fields:  fields,
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.ReadOperation: &framework.PathOperation{
+				Callback: b.pathEstCACertsRead,
+			},
+		},
+
+		HelpSynopsis:    pathEstHelpSyn,
+		HelpDescription: pathEstHelpDesc,
+	}
+}
+
+func pathEstEnroll(b *backend, estPrefix string, op string) *framework.Path {
+	pattern := estPrefix + "/" + op
+	fields := map[string]*framework.FieldSchema{}
+	addFieldsForEstPath(fields, pattern)
+
+	return &framework.Path{
+		Pattern: pattern,
+		Fields:  fields,
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.UpdateOperation: &framework.PathOperation{
+				Callback: b.pathEstEnrollWrite,
+				// Issued certificates are stored, so this must run on a node
+				// able to write to storage; see backend.go for more details.
+				ForwardPerformanceStandby:   true,
+				ForwardPerformanceSecondary: false,
+			},
+		},
+
+		HelpSynopsis:    pathEstHelpSyn,
+		HelpDescription: pathEstHelpDesc,
+	}
+}
+
+// resolveEstPolicy determines the policy of the request from the path it was
+// made on: a role path uses that role, a label path uses the policy of the
+// label, and otherwise the default policy applies.
+func resolveEstPolicy(sc *storageContext, config *estConfigEntry, data *framework.FieldData) (*enrollmentPolicy, error) {
+	policy := config.DefaultPathPolicy
+	if roleRaw, ok := data.GetOk("role"); ok {
+		policy = rolePrefix + roleRaw.(string)
+	} else if labelRaw, ok := data.GetOk("label"); ok {
+		var found bool
+		policy, found = config.LabelToPathPolicy[labelRaw.(string)]
+		if !found {
+			return nil, errutil.UserError{Err: fmt.Sprintf("unknown EST label %q", labelRaw.(string))}
+		}
+	}
+	if policy == "" {
+		return nil, errutil.UserError{Err: "no default EST path policy is configured"}
+	}
+
+	return resolveEnrollmentPolicy(sc, policy)
+}
+
+// loadEstRequestPolicy loads the configuration and policy of an EST request,
+// failing with the HTTP status EST clients expect if it can not be served.
+func (b *backend) loadEstRequestPolicy(sc *storageContext, data *framework.FieldData) (*estConfigEntry, *enrollmentPolicy, error) {
+	config, err := getEstConfig(sc)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !config.Enabled {
+		return nil, nil, logical.CodedError(http.StatusForbidden, "EST is disabled on this mount")
+	}
+
+	policy, err := resolveEstPolicy(sc, config, data)
+	if err != nil {
+		switch err.(type) {
+		case errutil.UserError:
+			return nil, nil, logical.CodedError(http.StatusNotFound, err.Error())
+		default:
+			return nil, nil, err
+		}
+	}
+
+	return config, policy, nil
+}
+
+func (b *backend) pathEstCACertsRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	sc := b.makeStorageContext(ctx, req.Storage)
+	_, policy, err := b.loadEstRequestPolicy(sc, data)
+	if err != nil {
+		return nil, err
+	}
+
+	issuerId, err := sc.resolveIssuerReference(policy.issuerRef())
+	if err != nil {
+		return nil, err
+	}
+	issuer, err := sc.fetchIssuerById(issuerId)
+	if err != nil {
+		return nil, err
+	}
+
+	var chain []byte
+	for _, certPEM := range issuer.CAChain {
+		block, _ := pem.Decode([]byte(certPEM))
+		if block == nil {
+			return nil, fmt.Errorf("unable to decode the CA chain of issuer %v", issuerId)
+		}
+		chain = append(chain, block.Bytes...)
+	}
+
+	return estCertsOnlyResponse(chain)
+}
+
+func (b *backend) pathEstEnrollWrite(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	sc := b.makeStorageContext(ctx, req.Storage)
+	config, policy, err := b.loadEstRequestPolicy(sc, data)
+	if err != nil {
+		return nil, err
+	}
+
+	// These paths are unauthenticated within Vault: any token on the request
+	// is ignored, and the client is instead authenticated against the
+	// configured auth mounts. Core then reissues the request with the token
+	// resulting from that login, checking it against the ACL of this path.
+	if req.ClientTokenSource != logical.ClientTokenFromInternalAuth {
+		return buildEstDelegatedAuthRequest(req, config)
+	}
+
+	csr, err := parseEstCSR(req)
+	if err != nil {
+		return logical.ErrorResponse("failed to parse the certificate request: %s", err), nil
+	}
+
+	if strings.HasSuffix(req.Path, "/"+estOpSimpleReenroll) {
+		if err := validateEstReenrollment(req, csr); err != nil {
+			return logical.ErrorResponse(err.Error()), nil
+		}
+	}
+
+	parsedBundle, err := b.issueEnrollmentCert(sc, req, policy, csr)
+	if err != nil {
+		switch err.(type) {
+		case errutil.UserError:
+			return logical.ErrorResponse(err.Error()), nil
+		default:
+			return nil, err
+		}
+	}
+
+	return estCertsOnlyResponse(parsedBundle.CertificateBytes)
+}
+
+// buildEstDelegatedAuthRequest requests core to log the client in, using the
+// HTTP Basic credentials against the userpass mount or else the TLS client
+// certificate against the cert mount.
+func buildEstDelegatedAuthRequest(req *logical.Request, config *estConfigEntry) (*logical.Response, error) {
+	if userpass, ok := config.Authenticators[estAuthenticatorUserpass]; ok && req.HTTPRequest != nil {
+		if username, password, ok := req.HTTPRequest.BasicAuth(); ok {
+			return nil, logical.NewDelegatedAuthenticationRequest(userpass.Accessor, "login/"+username,
+				map[string]interface{}{"password": password}, estAuthErrorHandler)
+		}
+	}
+
+	if cert, ok := config.Authenticators[estAuthenticatorCert]; ok && hasPeerCertificate(req) {
+		loginData := map[string]interface{}{}
+		if cert.CertRole != "" {
+			loginData["name"] = cert.CertRole
+		}
+		return nil, logical.NewDelegatedAuthenticationRequest(cert.Accessor, "login", loginData, estAuthErrorHandler)
+	}
+
+	return estUnauthorizedResponse(), nil
+}
+
+func estAuthErrorHandler(_ context.Context, _, _ *logical.Request, _ *logical.Response, _ error) (*logical.Response, error) {
+	return estUnauthorizedResponse(), nil
+}
+
+// estUnauthorizedResponse asks the client for HTTP Basic credentials, per
+// RFC 7030 Section 3.2.3.
+func estUnauthorizedResponse() *logical.Response {
+	return &logical.Response{
+		Data: map[string]interface{}{
+			logical.HTTPContentType:           "text/plain",
+			logical.HTTPStatusCode:            http.StatusUnauthorized,
+			logical.HTTPRawBody:               []byte("EST authentication failed\n"),
+			logical.HTTPWWWAuthenticateHeader: `Basic realm="vault-est"`,
+		},
+	}
+}
+
+func hasPeerCertificate(req *logical.Request) bool {
+	return req.Connection != nil && req.Connection.ConnState != nil &&
+		len(req.Connection.ConnState.PeerCertificates) > 0
+}
+
+// parseEstCSR reads the base64-encoded PKCS#10 request out of the body, as
+// defined in RFC 7030 Section 4.2.1.
+func parseEstCSR(req *logical.Request) (*x509.CertificateRequest, error) {
+	if req.HTTPRequest == nil || req.HTTPRequest.Body == nil {
+		return nil, errors.New("no data in request body")
+	}
+	rawBody := req.HTTPRequest.Body
+	defer rawBody.Close()
+
+	body, err := io.ReadAll(io.LimitReader(rawBody, estMaximumRequestSize))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) >= estMaximumRequestSize {
+		return nil, errors.New("request is too large")
+	}
+
+	// Base64 content may be split across lines; drop all whitespace.
+	encoded := strings.Join(strings.Fields(string(body)), "")
+	der, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("request is not base64 encoded: %w", err)
+	}
+
+	csr, err := x509.ParseCertificateRequest(der)
+	if err != nil {
+		return nil, err
+	}
+	if err := csr.CheckSignature(); err != nil {
+		return nil, fmt.Errorf("invalid signature: %w", err)
+	}
+
+	return csr, nil
+}
+
+// validateEstReenrollment enforces RFC 7030 Section 4.2.2: the request must
+// be authenticated with the certificate being renewed, and keep its subject
+// and subject alternative names.
+func validateEstReenrollment(req *logical.Request, csr *x509.CertificateRequest) error {
+	if !hasPeerCertificate(req) {
+		return errors.New("re-enrollment requires authenticating with the current certificate")
+	}
+	current := req.Connection.ConnState.PeerCertificates[0]
+
+	if !bytes.Equal(current.RawSubject, csr.RawSubject) {
+		return errors.New("the subject of the request does not match the current certificate")
+	}
+
+	var currentSANs, requestedSANs []byte
+	for _, ext := range current.Extensions {
+		if ext.Id.Equal(oidExtensionSubjectAltName) {
+			currentSANs = ext.Value
+		}
+	}
+	for _, ext := range csr.Extensions {
+		if ext.Id.Equal(oidExtensionSubjectAltName) {
+			requestedSANs = ext.Value
+		}
+	}
+	if !bytes.Equal(currentSANs, requestedSANs) {
+		return errors.New("the subject alternative names of the request do not match the current certificate")
+	}
+
+	return nil
+}
+
+// estCertsOnlyResponse builds the base64-encoded certs-only PKCS#7 response
+// of RFC 7030 Sections 4.1.3 and 4.2.3 from the concatenated certificates.
+func estCertsOnlyResponse(certs []byte) (*logical.Response, error) {
+	p7, err := pkcs7.DegenerateCertificate(certs)
+	if err != nil {
+		return nil, fmt.Errorf("failed building PKCS#7 response: %w", err)
+	}
+
+	resp := &logical.Response{
+		Data: map[string]interface{}{
+			logical.HTTPContentType: estCertsOnlyContentType,
+			logical.HTTPStatusCode:  http.StatusOK,
+			logical.HTTPRawBody:     []byte(base64.StdEncoding.EncodeToString(p7)),
+		},
+		// Only returned to clients when listed in the
+		// allowed_response_headers of the mount.
+		Headers: map[string][]string{
+			"Content-Transfer-Encoding": {"base64"},
+		},
+	}
+
+	return resp, nil
+}
+
+const pathEstHelpSyn = `EST (RFC 7030) enrollment endpoints`
+
+const pathEstHelpDesc = `
+These endpoints implement the cacerts, simpleenroll and simplereenroll
+operations of Enrollment over Secure Transport (RFC 7030). They are configured
+through the config/est endpoint.
+`
@@ -0,0 +1,256 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package pki
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/builtin/credential/userpass"
+	"github.com/hashicorp/vault/helper/pkcs7"
+	vaulthttp "github.com/hashicorp/vault/http"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/hashicorp/vault/vault"
+	"github.com/stretchr/testify/require"
+)
+
+// TestEstConfig validates the EST configuration is checked before it is
+// stored.
+func TestEstConfig(t *testing.T) {
+	t.Parallel()
+	b, s := CreateBackendWithStorage(t)
+
+	resp, err := CBRead(b, s, "config/est")
+	require.NoError(t, err)
+	require.Equal(t, false, resp.Data["enabled"])
+	require.NotContains(t, resp.Data, "last_updated")
+
+	_, err = CBWrite(b, s, "roles/devices", map[string]interface{}{
+		"allow_any_name": true,
+	})
+	require.NoError(t, err)
+
+	for name, data := range map[string]map[string]interface{}{
+		"default-mount-without-policy": {"default_mount": true},
+		"unknown-policy":               {"default_path_policy": "forbid"},
+		"missing-role":                 {"default_path_policy": "role:missing"},
+		"operation-as-label":           {"label_to_path_policy": map[string]string{"cacerts": "sign-verbatim"}},
+		"invalid-label":                {"label_to_path_policy": map[string]string{"a/b": "sign-verbatim"}},
+		"unknown-authenticator":        {"authenticators": map[string]interface{}{"ldap": map[string]interface{}{"accessor": "auth_ldap_1234"}}},
+		"missing-accessor":             {"authenticators": map[string]interface{}{"userpass": map[string]interface{}{}}},
+		"cert-role-on-userpass":        {"authenticators": map[string]interface{}{"userpass": map[string]interface{}{"accessor": "auth_userpass_1234", "cert_role": "web"}}},
+	} {
+		_, err = CBWrite(b, s, "config/est", data)
+		require.Error(t, err, "expected config %s to be rejected", name)
+	}
+
+	resp, err = CBWrite(b, s, "config/est", map[string]interface{}{
+		"default_path_policy":  "sign-verbatim",
+		"label_to_path_policy": map[string]string{"devices": "role:devices"},
+		"authenticators": map[string]interface{}{
+			"cert": map[string]interface{}{"accessor": "auth_cert_1234", "cert_role": "est-ca"},
+		},
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, resp.Data["last_updated"])
+
+	resp, err = CBRead(b, s, "config/est")
+	require.NoError(t, err)
+	require.Equal(t, "sign-verbatim", resp.Data["default_path_policy"])
+	require.Equal(t, map[string]string{"devices": "role:devices"}, resp.Data["label_to_path_policy"])
+	require.Equal(t, map[string]interface{}{
+		"cert": map[string]interface{}{"accessor": "auth_cert_1234", "cert_role": "est-ca"},
+	}, resp.Data["authenticators"])
+}
+
+// TestEstEnrollment enrolls through the .well-known/est paths, authenticating
+// with HTTP Basic credentials against a userpass mount.
+func TestEstEnrollment(t *testing.T) {
+	t.Parallel()
+
+	coreConfig := &vault.CoreConfig{
+		CredentialBackends: map[string]logical.Factory{
+			"userpass": userpass.Factory,
+		},
+		LogicalBackends: map[string]logical.Factory{
+			"pki": Factory,
+		},
+	}
+	cluster := vault.NewTestCluster(t, coreConfig, &vault.TestClusterOptions{
+		HandlerFunc: vaulthttp.Handler,
+	})
+	cluster.Start()
+	defer cluster.Cleanup()
+	client := cluster.Cores[0].Client
+
+	mountPKIEndpoint(t, client, "pki")
+	resp, err := client.Logical().Write("pki/root/generate/internal", map[string]interface{}{
+		"common_name": "Root EST CA",
+		"key_type":    "ec",
+		"ttl":         "720h",
+	})
+	require.NoError(t, err)
+	rootCert := parseCert(t, resp.Data["certificate"].(string))
+
+	_, err = client.Logical().Write("pki/roles/devices", map[string]interface{}{
+		"allowed_domains":  "devices.example.com",
+		"allow_subdomains": true,
+		"key_type":         "any",
+		"ttl":              "24h",
+	})
+	require.NoError(t, err)
+
+	err = client.Sys().PutPolicy("est-enroll", `
+path "pki/est/*" { capabilities = ["update"] }
+`)
+	require.NoError(t, err)
+
+	err = client.Sys().EnableAuthWithOptions("userpass", &api.EnableAuthOptions{Type: "userpass"})
+	require.NoError(t, err)
+	_, err = client.Logical().Write("auth/userpass/users/device", map[string]interface{}{
+		"password":   "secret",
+		"policies":   "est-enroll",
+		"token_type": "batch",
+	})
+	require.NoError(t, err)
+	resp, err = client.Logical().Read("sys/mounts/auth/userpass")
+	require.NoError(t, err)
+	upAccessor := resp.Data["accessor"].(string)
+
+	err = client.Sys().TuneMount("pki", api.MountConfigInput{
+		DelegatedAuthAccessors: []string{upAccessor},
+		AllowedResponseHeaders: []string{"Content-Transfer-Encoding"},
+	})
+	require.NoError(t, err)
+
+	_, err = client.Logical().Write("pki/config/est", map[string]interface{}{
+		"enabled":              true,
+		"default_mount":        true,
+		"default_path_policy":  "sign-verbatim",
+		"label_to_path_policy": map[string]string{"devices": "role:devices"},
+		"authenticators": map[string]interface{}{
+			"userpass": map[string]interface{}{"accessor": upAccessor},
+		},
+	})
+	require.NoError(t, err)
+
+	httpClient := client.CloneConfig().HttpClient
+	estRequest := func(method, path, username string, body []byte) *http.Response {
+		req, err := http.NewRequest(method, client.Address()+path, strings.NewReader(base64.StdEncoding.EncodeToString(body)))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/pkcs10")
+		if username != "" {
+			req.SetBasicAuth(username, "secret")
+		}
+		resp, err := httpClient.Do(req)
+		require.NoError(t, err)
+		return resp
+	}
+	readCerts := func(resp *http.Response) []*x509.Certificate {
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected response: %s", body)
+		require.Equal(t, estCertsOnlyContentType, resp.Header.Get("Content-Type"))
+		require.Equal(t, "base64", resp.Header.Get("Content-Transfer-Encoding"))
+
+		der, err := base64.StdEncoding.DecodeString(string(body))
+		require.NoError(t, err)
+		p7, err := pkcs7.Parse(der)
+		require.NoError(t, err)
+		return p7.Certificates
+	}
+	newCSR := func(commonName string) []byte {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+			Subject:  pkix.Name{CommonName: commonName},
+			DNSNames: []string{commonName},
+		}, key)
+		require.NoError(t, err)
+		return csr
+	}
+
+	// The CA certificates are available without authentication.
+	certs := readCerts(estRequest(http.MethodGet, "/.well-known/est/cacerts", "", nil))
+	require.Len(t, certs, 1)
+	require.True(t, certs[0].Equal(rootCert))
+
+	// Enrollment without credentials asks for HTTP Basic authentication.
+	httpResp := estRequest(http.MethodPost, "/.well-known/est/simpleenroll", "", newCSR("printer.example.org"))
+	httpResp.Body.Close()
+	require.Equal(t, http.StatusUnauthorized, httpResp.StatusCode)
+	require.Contains(t, httpResp.Header.Get("WWW-Authenticate"), "Basic")
+
+	httpResp = estRequest(http.MethodPost, "/.well-known/est/simpleenroll", "unknown", newCSR("printer.example.org"))
+	httpResp.Body.Close()
+	require.Equal(t, http.StatusUnauthorized, httpResp.StatusCode)
+	require.Contains(t, httpResp.Header.Get("WWW-Authenticate"), "Basic")
+
+	// The default label signs verbatim.
+	certs = readCerts(estRequest(http.MethodPost, "/.well-known/est/simpleenroll", "device", newCSR("printer.example.org")))
+	require.Len(t, certs, 1)
+	require.Equal(t, "printer.example.org", certs[0].Subject.CommonName)
+	require.NoError(t, certs[0].CheckSignatureFrom(rootCert))
+
+	// The issued certificate is stored.
+	resp, err = client.Logical().Read("pki/cert/" + normalizeSerialFromBigInt(certs[0].SerialNumber))
+	require.NoError(t, err)
+	require.NotNil(t, resp)
+
+	// Labels are validated against their role.
+	httpResp = estRequest(http.MethodPost, "/.well-known/est/devices/simpleenroll", "device", newCSR("printer.example.org"))
+	httpResp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, httpResp.StatusCode)
+
+	certs = readCerts(estRequest(http.MethodPost, "/.well-known/est/devices/simpleenroll", "device", newCSR("switch.devices.example.com")))
+	require.Len(t, certs, 1)
+	require.Equal(t, "switch.devices.example.com", certs[0].Subject.CommonName)
+
+	httpResp = estRequest(http.MethodPost, "/.well-known/est/unknown/simpleenroll", "device", newCSR("printer.example.org"))
+	httpResp.Body.Close()
+	require.Equal(t, http.StatusNotFound, httpResp.StatusCode)
+
+	// Re-enrollment requires authenticating with the current certificate.
+	httpResp = estRequest(http.MethodPost, "/.well-known/est/simplereenroll", "device", newCSR("printer.example.org"))
+	httpResp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, httpResp.StatusCode)
+
+	// Disabling EST releases the .well-known paths.
+	_, err = client.Logical().Write("pki/config/est", map[string]interface{}{
+		"enabled": false,
+	})
+	require.NoError(t, err)
+
+	httpResp = estRequest(http.MethodGet, "/.well-known/est/cacerts", "", nil)
+	httpResp.Body.Close()
+	require.Equal(t, http.StatusNotFound, httpResp.StatusCode)
+
+	httpResp = estRequest(http.MethodPost, "/v1/pki/est/simpleenroll", "device", newCSR("printer.example.org"))
+	httpResp.Body.Close()
+	require.Equal(t, http.StatusForbidden, httpResp.StatusCode)
+
+	// Another mount can not claim the default label while it is in use.
+	_, err = client.Logical().Write("pki/config/est", map[string]interface{}{
+		"enabled": true,
+	})
+	require.NoError(t, err)
+	mountPKIEndpoint(t, client, "pki2")
+	_, err = client.Logical().WriteWithContext(context.Background(), "pki2/config/est", map[string]interface{}{
+		"enabled":             true,
+		"default_mount":       true,
+		"default_path_policy": "sign-verbatim",
+	})
+	require.Error(t, err)
+}
@@ -237,26 +237,81 @@ func pathFetchListCerts(b *backend) *framework.Path {
 			OperationSuffix: "certs",
 		},
 
+		Fields: map[string]*framework.FieldSchema{
+			"common_name": {
+				Type:        framework.TypeString,
+				Description: `Only list certificates whose common name matches this glob, case-insensitively.`,
+			},
+			"san": {
+				Type:        framework.TypeString,
+				Description: `Only list certificates with a DNS, IP, email or URI Subject Alternative Name matching this glob, case-insensitively.`,
+			},
+			"role": {
+				Type:        framework.TypeString,
+				Description: `Only list certificates issued by this role.`,
+			},
+			"issuer_id": {
+				Type:        framework.TypeString,
+				Description: `Only list certificates issued by the issuer with this ID.`,
+			},
+			"entity_id": {
+				Type:        framework.TypeString,
+				Description: `Only list certificates requested by the entity with this ID.`,
+			},
+			"expires_before": {
+				Type:        framework.TypeTime,
+				Description: `Only list certificates expiring before this time.`,
+			},
+			"expires_after": {
+				Type:        framework.TypeTime,
+				Description: `Only list certificates expiring after this time.`,
+			},
+		},
+
 		Operations: map[logical.Operation]framework.OperationHandler{
 			logical.ListOperation: &framework.PathOperation{
 				Callback: b.pathFetchCertList,
 			},
 		},
 
-		HelpSynopsis:    pathFetchHelpSyn,
-		HelpDescription: pathFetchHelpDesc,
+		HelpSynopsis:    pathFetchListCertsHelpSyn,
+		HelpDescription: pathFetchListCertsHelpDesc,
 	}
 }
 
-func (b *backend) pathFetchCertList(ctx context.Context, req *logical.Request, _ *framework.FieldData) (response *logical.Response, retErr error) {
+func (b *backend) pathFetchCertList(ctx context.Context, req *logical.Request, data *framework.FieldData) (response *logical.Response, retErr error) {
 	entries, err := req.Storage.List(ctx, issuing.PathCerts)
 	if err != nil {
 		return nil, err
 	}
-	for i := range entries {
-		entries[i] = denormalizeSerial(entries[i])
+
+	filter, filtered := newCertListFilter(data)
+	if !filtered {
+		for i := range entries {
+			entries[i] = denormalizeSerial(entries[i])
+		}
+		return logical.ListResponse(entries), nil
 	}
-	return logical.ListResponse(entries), nil
+
+	// When filtering, the index entry of every certificate is checked, and
+	// returned along with the matching serial numbers.
+	responseKeys := []string{}
+	responseInfo := make(map[string]interface{})
+	for _, serial := range entries {
+		entry, err := fetchCertIndex(ctx, req.Storage, serial)
+		if err != nil {
+			return nil, err
+		}
+		if entry == nil || !filter.matches(entry) {
+			continue
+		}
+
+		serial = denormalizeSerial(serial)
+		responseKeys = append(responseKeys, serial)
+		responseInfo[serial] = entry.toResponseData()
+	}
+
+	return logical.ListResponseWithInfo(responseKeys, responseInfo), nil
 }
 
 func (b *backend) pathFetchRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (response *logical.Response, retErr error) {
@@ -268,6 +323,7 @@ func (b *backend) pathFetchRead(ctx context.Context, req *logical.Request, data
 	var revocationTime int64
 	var revocationIssuerId string
 	var revocationTimeRfc3339 string
+	var caRef string
 
 	response = &logical.Response{
 		Data: map[string]interface{}{},
@@ -285,7 +341,13 @@ func (b *backend) pathFetchRead(ctx context.Context, req *logical.Request, data
 	}
 	switch {
 	case req.Path == "ca" || req.Path == "ca/pem" || req.Path == "cert/ca" || req.Path == "cert/ca/raw" || req.Path == "cert/ca/raw/pem":
+		caRef, retErr = sc.defaultChainIssuerRef()
+		if retErr != nil {
+			goto reply
+		}
+
 		modifiedCtx.reqType = ifModifiedCA
+		modifiedCtx.issuerRef = issuing.IssuerID(caRef)
 		ret, err := sendNotModifiedResponseIfNecessary(modifiedCtx, sc, response)
 		if err != nil || ret {
 			retErr = err
@@ -302,6 +364,11 @@ func (b *backend) pathFetchRead(ctx context.Context, req *logical.Request, data
 			contentType = ""
 		}
 	case req.Path == "ca_chain" || req.Path == "cert/ca_chain":
+		caRef, retErr = sc.defaultChainIssuerRef()
+		if retErr != nil {
+			goto reply
+		}
+
 		serial = "ca_chain"
 		if req.Path == "ca_chain" {
 			contentType = "application/pkix-cert"
@@ -373,7 +440,7 @@ func (b *backend) pathFetchRead(ctx context.Context, req *logical.Request, data
 
 	// Prefer fetchCAInfo to fetchCertBySerial for CA certificates.
 	if serial == "ca_chain" || serial == "ca" {
-		caInfo, err := sc.fetchCAInfo(defaultRef, issuing.ReadOnlyUsage)
+		caInfo, err := sc.fetchCAInfo(caRef, issuing.ReadOnlyUsage)
 		if err != nil {
 			switch err.(type) {
 			case errutil.UserError:
@@ -526,3 +593,18 @@ Using "ca_chain" as the value fetches the certificate authority trust chain in P
 
 Otherwise, specify a serial number to fetch the specified certificate. Add "/raw" to get just the certificate in DER form, "/raw/pem" to get the PEM encoded certificate.
 `
+
+const pathFetchListCertsHelpSyn = `
+Fetch a list of the serial numbers of stored certificates, optionally filtered.
+`
+
+const pathFetchListCertsHelpDesc = `
+This lists the serial numbers of the certificates stored by this mount.
+
+Certificates can be filtered by common name, Subject Alternative Name, role,
+issuer, requesting entity and expiration. When any filter is given, the
+response also includes these fields for each matching certificate in key_info.
+
+The role, issuer and entity are only known for certificates issued after
+certificate indexing was introduced.
+`
@@ -12,9 +12,11 @@ import (
 	"strings"
 	"time"
 
+	"github.com/hashicorp/go-secure-stdlib/parseutil"
 	"github.com/hashicorp/vault/builtin/logical/pki/issuing"
 	"github.com/hashicorp/vault/sdk/framework"
 	"github.com/hashicorp/vault/sdk/helper/certutil"
+	"github.com/hashicorp/vault/sdk/helper/errutil"
 	"github.com/hashicorp/vault/sdk/logical"
 )
 
@@ -209,6 +211,32 @@ and using '{{cluster_aia_path}}' requires /config/cluster's 'aia_path' member
 to be set on all PR secondary clusters.`,
 		Default: false,
 	}
+	fields["ocsp_responder_certificate"] = &framework.FieldSchema{
+		Type: framework.TypeString,
+		Description: `PEM-encoded delegated OCSP responder certificate,
+issued by this issuer with the OCSPSigning extended key usage, to sign OCSP
+responses about this issuer's certificates with in place of the issuer. Its
+key must first be imported through keys/import. Responses are signed by the
+issuer again once it expires. Empty to have the issuer sign them.`,
+	}
+	fields["crl_expiry"] = &framework.FieldSchema{
+		Type: framework.TypeString,
+		Description: `The amount of time the CRLs of this issuer are valid
+for, overriding the expiry of config/crl. Empty to use the mount's value.`,
+	}
+	fields["ocsp_expiry"] = &framework.FieldSchema{
+		Type: framework.TypeString,
+		Description: `The amount of time the OCSP responses about this issuer's
+certificates are valid for, overriding the ocsp_expiry of config/crl; 0 to
+omit NextUpdate from the responses. Empty to use the mount's value.`,
+	}
+	fields["crl_issuing_distribution_point"] = &framework.FieldSchema{
+		Type: framework.TypeBool,
+		Description: `Whether to include the critical Issuing Distribution
+Point extension, naming this issuer's CRL distribution points, in its
+complete and delta CRLs. Unified CRLs never include it.`,
+		Default: false,
+	}
 
 	updateIssuerSchema := map[int][]framework.Response{
 		http.StatusOK: {{
@@ -224,6 +252,11 @@ to be set on all PR secondary clusters.`,
 					Description: `Issuer Name`,
 					Required:    false,
 				},
+				"previous_issuer_names": {
+					Type:        framework.TypeStringSlice,
+					Description: `Names this issuer was previously known by; references using them continue to resolve to this issuer`,
+					Required:    false,
+				},
 				"key_id": {
 					Type:        framework.TypeString,
 					Description: `Key Id`,
@@ -292,6 +325,31 @@ to be set on all PR secondary clusters.`,
 					Description: `Whether or not templating is enabled for AIA fields`,
 					Required:    false,
 				},
+				"ocsp_responder_certificate": {
+					Type:        framework.TypeString,
+					Description: `Delegated OCSP responder certificate`,
+					Required:    false,
+				},
+				"ocsp_responder_key_id": {
+					Type:        framework.TypeString,
+					Description: `Key Id of the delegated OCSP responder`,
+					Required:    false,
+				},
+				"crl_expiry": {
+					Type:        framework.TypeString,
+					Description: `CRL lifetime of this issuer, if overriding the mount's`,
+					Required:    false,
+				},
+				"ocsp_expiry": {
+					Type:        framework.TypeString,
+					Description: `OCSP response validity of this issuer, if overriding the mount's`,
+					Required:    false,
+				},
+				"crl_issuing_distribution_point": {
+					Type:        framework.TypeBool,
+					Description: `Whether CRLs include the Issuing Distribution Point extension`,
+					Required:    false,
+				},
 			},
 		}},
 	}
@@ -361,6 +419,11 @@ func buildPathGetIssuer(b *backend, pattern string, displayAttrs *framework.Disp
 					Description: `Issuer Name`,
 					Required:    true,
 				},
+				"previous_issuer_names": {
+					Type:        framework.TypeStringSlice,
+					Description: `Names this issuer was previously known by; references using them continue to resolve to this issuer`,
+					Required:    false,
+				},
 				"certificate": {
 					Type:        framework.TypeString,
 					Description: `Certificate`,
@@ -442,6 +505,7 @@ func respondReadIssuer(issuer *issuing.IssuerEntry) (*logical.Response, error) {
 	data := map[string]interface{}{
 		"issuer_id":                      issuer.ID,
 		"issuer_name":                    issuer.Name,
+		"previous_issuer_names":          issuer.PreviousNames,
 		"key_id":                         issuer.KeyID,
 		"certificate":                    issuer.Certificate,
 		"manual_chain":                   respManualChain,
@@ -453,6 +517,11 @@ func respondReadIssuer(issuer *issuing.IssuerEntry) (*logical.Response, error) {
 		"issuing_certificates":           []string{},
 		"crl_distribution_points":        []string{},
 		"ocsp_servers":                   []string{},
+		"ocsp_responder_certificate":     issuer.OCSPResponderCertificate,
+		"ocsp_responder_key_id":          issuer.OCSPResponderKeyID,
+		"crl_expiry":                     issuer.CRLExpiry,
+		"ocsp_expiry":                    issuer.OCSPExpiry,
+		"crl_issuing_distribution_point": issuer.CRLIssuingDistributionPoint,
 	}
 
 	if issuer.Revoked {
@@ -575,12 +644,40 @@ func (b *backend) pathUpdateIssuer(ctx context.Context, req *logical.Request, da
 		return logical.ErrorResponse(fmt.Sprintf("invalid URL found in Authority Information Access (AIA) parameter ocsp_servers: %s", badURL)), nil
 	}
 
+	// Delegated OCSP responder changes
+	ocspResponderCert := strings.TrimSpace(data.Get("ocsp_responder_certificate").(string))
+	var ocspResponderKeyID issuing.KeyID
+	if ocspResponderCert != "" {
+		ocspResponderKeyID, err = sc.validateOCSPResponder(issuer, ocspResponderCert)
+		if err != nil {
+			switch err.(type) {
+			case errutil.UserError:
+				return logical.ErrorResponse(err.Error()), nil
+			default:
+				return nil, err
+			}
+		}
+	}
+
+	// Revocation lifetime changes
+	crlExpiry := strings.TrimSpace(data.Get("crl_expiry").(string))
+	if err := validateIssuerRevocationExpiry("crl_expiry", crlExpiry); err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+	ocspExpiry := strings.TrimSpace(data.Get("ocsp_expiry").(string))
+	if err := validateIssuerRevocationExpiry("ocsp_expiry", ocspExpiry); err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+	crlIssuingDistributionPoint := data.Get("crl_issuing_distribution_point").(bool)
+
 	modified := false
 
 	var oldName string
 	if newName != issuer.Name {
 		oldName = issuer.Name
-		issuer.Name = newName
+		if err := sc.renameIssuer(issuer, newName); err != nil {
+			return nil, err
+		}
 		issuer.LastModified = time.Now().UTC()
 		// See note in updateDefaultIssuerId about why this is necessary.
 		b.CrlBuilder().invalidateCRLBuildTime()
@@ -618,6 +715,19 @@ func (b *backend) pathUpdateIssuer(ctx context.Context, req *logical.Request, da
 		modified = true
 	}
 
+	if ocspResponderCert != issuer.OCSPResponderCertificate || ocspResponderKeyID != issuer.OCSPResponderKeyID {
+		issuer.OCSPResponderCertificate = ocspResponderCert
+		issuer.OCSPResponderKeyID = ocspResponderKeyID
+		modified = true
+	}
+
+	if crlExpiry != issuer.CRLExpiry || ocspExpiry != issuer.OCSPExpiry || crlIssuingDistributionPoint != issuer.CRLIssuingDistributionPoint {
+		issuer.CRLExpiry = crlExpiry
+		issuer.OCSPExpiry = ocspExpiry
+		issuer.CRLIssuingDistributionPoint = crlIssuingDistributionPoint
+		modified = true
+	}
+
 	if issuer.AIAURIs == nil && (len(issuerCertificates) > 0 || len(crlDistributionPoints) > 0 || len(ocspServers) > 0) {
 		issuer.AIAURIs = &issuing.AiaConfigEntry{}
 	}
@@ -725,6 +835,27 @@ func (b *backend) pathUpdateIssuer(ctx context.Context, req *logical.Request, da
 	return response, err
 }
 
+// validateIssuerRevocationExpiry validates an issuer's crl_expiry or
+// ocsp_expiry, which are empty when the mount's configuration applies.
+func validateIssuerRevocationExpiry(field string, expiry string) error {
+	if expiry == "" {
+		return nil
+	}
+
+	duration, err := parseutil.ParseDurationSecond(expiry)
+	if err != nil {
+		return fmt.Errorf("invalid %v: %w", field, err)
+	}
+	if duration < 0 {
+		return fmt.Errorf("%v must not be negative", field)
+	}
+	if field == "crl_expiry" && duration == 0 {
+		return fmt.Errorf("crl_expiry must be positive")
+	}
+
+	return nil
+}
+
 func (b *backend) pathPatchIssuer(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
 	// Since we're planning on updating issuers here, grab the lock so we've
 	// got a consistent view.
@@ -780,7 +911,9 @@ func (b *backend) pathPatchIssuer(ctx context.Context, req *logical.Request, dat
 		}
 		if newName != issuer.Name {
 			oldName = issuer.Name
-			issuer.Name = newName
+			if err := sc.renameIssuer(issuer, newName); err != nil {
+				return nil, err
+			}
 			issuer.LastModified = time.Now().UTC()
 			// See note in updateDefaultIssuerId about why this is necessary.
 			b.CrlBuilder().invalidateCRLBuildTime()
@@ -863,6 +996,60 @@ func (b *backend) pathPatchIssuer(ctx context.Context, req *logical.Request, dat
 		}
 	}
 
+	// Delegated OCSP responder changes
+	rawOCSPResponderCert, ok := data.GetOk("ocsp_responder_certificate")
+	if ok {
+		ocspResponderCert := strings.TrimSpace(rawOCSPResponderCert.(string))
+		var ocspResponderKeyID issuing.KeyID
+		if ocspResponderCert != "" {
+			ocspResponderKeyID, err = sc.validateOCSPResponder(issuer, ocspResponderCert)
+			if err != nil {
+				switch err.(type) {
+				case errutil.UserError:
+					return logical.ErrorResponse(err.Error()), nil
+				default:
+					return nil, err
+				}
+			}
+		}
+
+		if ocspResponderCert != issuer.OCSPResponderCertificate || ocspResponderKeyID != issuer.OCSPResponderKeyID {
+			issuer.OCSPResponderCertificate = ocspResponderCert
+			issuer.OCSPResponderKeyID = ocspResponderKeyID
+			modified = true
+		}
+	}
+
+	// Revocation lifetime changes
+	for _, field := range []struct {
+		name string
+		dest *string
+	}{
+		{"crl_expiry", &issuer.CRLExpiry},
+		{"ocsp_expiry", &issuer.OCSPExpiry},
+	} {
+		rawExpiry, ok := data.GetOk(field.name)
+		if !ok {
+			continue
+		}
+		expiry := strings.TrimSpace(rawExpiry.(string))
+		if err := validateIssuerRevocationExpiry(field.name, expiry); err != nil {
+			return logical.ErrorResponse(err.Error()), nil
+		}
+		if expiry != *field.dest {
+			*field.dest = expiry
+			modified = true
+		}
+	}
+	rawCRLIssuingDistributionPoint, ok := data.GetOk("crl_issuing_distribution_point")
+	if ok {
+		crlIssuingDistributionPoint := rawCRLIssuingDistributionPoint.(bool)
+		if crlIssuingDistributionPoint != issuer.CRLIssuingDistributionPoint {
+			issuer.CRLIssuingDistributionPoint = crlIssuingDistributionPoint
+			modified = true
+		}
+	}
+
 	// AIA access changes.
 	if issuer.AIAURIs == nil {
 		issuer.AIAURIs = &issuing.AiaConfigEntry{}
@@ -1162,7 +1349,9 @@ Use /issuer/:ref/der or /issuer/:ref/pem to return just the certificate in
 raw DER or PEM form, without the JSON structure of /issuer/:ref.
 
 Writing to /issuer/:ref allows updating of the name field associated with
-the certificate.
+the certificate. When an issuer is renamed, its prior name is retained in
+previous_issuer_names and continues to resolve to this issuer, unless it is
+later claimed by another issuer.
 `
 )
 
@@ -36,7 +36,7 @@ func pathSetSignedIntermediate(b *backend) *framework.Path {
 			OperationSuffix: "intermediate",
 		},
 
-		Fields: map[string]*framework.FieldSchema{
+		Fields: addIssuerImportFields(map[string]*framework.FieldSchema{
 			"certificate": {
 				Type: framework.TypeString,
 				Description: `PEM-format certificate. This must be a CA
@@ -45,7 +45,7 @@ previously-generated key from the generation
 endpoint. Additional parent CAs may be optionally
 appended to the bundle.`,
 			},
-		},
+		}),
 		Operations: map[logical.Operation]framework.OperationHandler{
 			logical.UpdateOperation: &framework.PathOperation{
 				Callback: b.pathImportIssuers,
@@ -190,11 +190,9 @@ func (b *backend) pathGenerateIntermediate(ctx context.Context, req *logical.Req
 		return nil, fmt.Errorf("unsupported format argument: %s", format)
 	}
 
-	if data.Get("private_key_format").(string) == "pkcs8" {
-		err = convertRespToPKCS8(resp)
-		if err != nil {
-			return nil, err
-		}
+	err = formatRespPrivateKey(resp, data)
+	if err != nil {
+		return nil, err
 	}
 
 	myKey, _, err := sc.importKey(csrb.PrivateKey, keyName, csrb.PrivateKeyType)
@@ -7,6 +7,7 @@ import (
 	"bytes"
 	"context"
 	"crypto/rand"
+	"crypto/x509"
 	"encoding/base64"
 	"encoding/pem"
 	"fmt"
@@ -15,6 +16,7 @@ import (
 	"time"
 
 	"github.com/hashicorp/vault/builtin/logical/pki/issuing"
+	"github.com/hashicorp/vault/helper/pkcs12"
 	"github.com/hashicorp/vault/sdk/framework"
 	"github.com/hashicorp/vault/sdk/helper/certutil"
 	"github.com/hashicorp/vault/sdk/helper/consts"
@@ -22,6 +24,8 @@ import (
 	"github.com/hashicorp/vault/sdk/logical"
 )
 
+const leafTruncationWarning = "the certificate's notAfter was truncated to the issuer's notAfter"
+
 func pathIssue(b *backend) *framework.Path {
 	pattern := "issue/" + framework.GenericNameRegex("role")
 
@@ -93,6 +97,16 @@ func buildPathIssue(b *backend, pattern string, displayAttrs *framework.DisplayA
 								Description: `Private key type`,
 								Required:    false,
 							},
+							"pkcs12": {
+								Type:        framework.TypeString,
+								Description: `PKCS#12 archive of the private key and certificates`,
+								Required:    false,
+							},
+							"private_key_wrap_info": {
+								Type:        framework.TypeMap,
+								Description: `Response wrapping token holding the private key, when private_key_wrap_ttl is set`,
+								Required:    false,
+							},
 						},
 					}},
 				},
@@ -104,6 +118,10 @@ func buildPathIssue(b *backend, pattern string, displayAttrs *framework.DisplayA
 	}
 
 	ret.Fields = addNonCACommonFields(map[string]*framework.FieldSchema{})
+	ret.Fields = addSubjectRDNsField(ret.Fields)
+	ret.Fields = addExtensionsField(ret.Fields)
+	ret.Fields = addPKCS12Fields(ret.Fields)
+	ret.Fields = addPrivateKeyWrapField(ret.Fields)
 	return ret
 }
 
@@ -179,6 +197,8 @@ func buildPathSign(b *backend, pattern string, displayAttrs *framework.DisplayAt
 	}
 
 	ret.Fields = addNonCACommonFields(map[string]*framework.FieldSchema{})
+	ret.Fields = addSubjectRDNsField(ret.Fields)
+	ret.Fields = addExtensionsField(ret.Fields)
 
 	ret.Fields["csr"] = &framework.FieldSchema{
 		Type:        framework.TypeString,
@@ -333,12 +353,69 @@ func (b *backend) pathSignVerbatim(ctx context.Context, req *logical.Request, da
 		if role.NotBeforeDuration > 0 {
 			opts = append(opts, issuing.WithNotBeforeDuration(role.NotBeforeDuration))
 		}
+
+		if role.RestrictSignVerbatim {
+			restrictOpts, err := signVerbatimRestrictions(data, role)
+			if err != nil {
+				return nil, err
+			}
+			opts = append(opts, restrictOpts...)
+		}
 	}
 
 	entry := issuing.SignVerbatimRoleWithOpts(opts...)
 	return b.pathIssueSignCert(ctx, req, data, entry, true, true)
 }
 
+// signVerbatimRestrictions returns the modifiers constraining a sign-verbatim
+// request by a role with restrict_sign_verbatim set. Requests may only ask for
+// key usages of the role, and get those of the role when they ask for none.
+func signVerbatimRestrictions(data *framework.FieldData, role *issuing.RoleEntry) ([]issuing.RoleModifier, error) {
+	opts := []issuing.RoleModifier{issuing.WithSignVerbatimRestrictions(role)}
+
+	keyUsages := role.KeyUsage
+	if rawKeyUsages, ok := data.GetOk("key_usage"); ok {
+		keyUsages = rawKeyUsages.([]string)
+	}
+
+	var extKeyUsages, extKeyUsageOIDs []string
+	rawExtKeyUsages, extKeyUsagesOk := data.GetOk("ext_key_usage")
+	rawExtKeyUsageOIDs, extKeyUsageOIDsOk := data.GetOk("ext_key_usage_oids")
+	if extKeyUsagesOk || extKeyUsageOIDsOk {
+		if extKeyUsagesOk {
+			extKeyUsages = rawExtKeyUsages.([]string)
+		}
+		if extKeyUsageOIDsOk {
+			extKeyUsageOIDs = rawExtKeyUsageOIDs.([]string)
+		}
+	} else {
+		extKeyUsages = append(extKeyUsages, role.ExtKeyUsage...)
+		if role.ServerFlag {
+			extKeyUsages = append(extKeyUsages, "ServerAuth")
+		}
+		if role.ClientFlag {
+			extKeyUsages = append(extKeyUsages, "ClientAuth")
+		}
+		if role.CodeSigningFlag {
+			extKeyUsages = append(extKeyUsages, "CodeSigning")
+		}
+		if role.EmailProtectionFlag {
+			extKeyUsages = append(extKeyUsages, "EmailProtection")
+		}
+		extKeyUsageOIDs = role.ExtKeyUsageOIDs
+	}
+
+	if err := issuing.ValidateSignVerbatimUsages(role, keyUsages, extKeyUsages, extKeyUsageOIDs); err != nil {
+		return nil, err
+	}
+
+	return append(opts,
+		issuing.WithKeyUsage(keyUsages),
+		issuing.WithExtKeyUsage(extKeyUsages),
+		issuing.WithExtKeyUsageOIDs(extKeyUsageOIDs),
+	), nil
+}
+
 func (b *backend) pathIssueSignCert(ctx context.Context, req *logical.Request, data *framework.FieldData, role *issuing.RoleEntry, useCSR, useCSRValues bool) (*logical.Response, error) {
 	// Error out early if incompatible fields set:
 	certMetadata, metadataInRequest := data.GetOk("cert_metadata")
@@ -349,6 +426,8 @@ func (b *backend) pathIssueSignCert(ctx context.Context, req *logical.Request, d
 		}
 	}
 
+	sc := b.makeStorageContext(ctx, req.Storage)
+
 	// If storing the certificate or certMetadata about this certificate and on a performance standby, forward this request
 	// on to the primary
 	// Allow performance secondaries to generate and store certificates and certMetadata locally to them.
@@ -383,14 +462,24 @@ func (b *backend) pathIssueSignCert(ctx context.Context, req *logical.Request, d
 	}
 
 	format := getFormat(data)
+	if !useCSR {
+		format = getIssueFormat(data)
+	}
 	if format == "" {
 		return logical.ErrorResponse(
 			`the "format" path parameter must be "pem", "der", or "pem_bundle"`), nil
 	}
+	if !useCSR && data.Get("private_key_passphrase").(string) != "" && (format == "pem_bundle" || format == "pkcs12") {
+		return logical.ErrorResponse(
+			`the "private_key_passphrase" parameter is not supported with the %q format`, format), nil
+	}
+	if !useCSR && data.Get("private_key_wrap_ttl").(int) > 0 && format == "pem_bundle" {
+		return logical.ErrorResponse(
+			`the "private_key_wrap_ttl" parameter is not supported with the "pem_bundle" format`), nil
+	}
 
 	var caErr error
-	sc := b.makeStorageContext(ctx, req.Storage)
-	signingBundle, caErr := sc.fetchCAInfo(issuerName, issuing.IssuanceUsage)
+	signingBundle, signingIssuerId, caErr := sc.fetchCAInfoWithIssuer(issuerName, issuing.IssuanceUsage)
 	if caErr != nil {
 		switch caErr.(type) {
 		case errutil.UserError:
@@ -410,22 +499,26 @@ func (b *backend) pathIssueSignCert(ctx context.Context, req *logical.Request, d
 		}
 	}
 	input := &inputBundle{
-		req:     req,
-		apiData: data,
-		role:    role,
+		req:      req,
+		apiData:  data,
+		role:     role,
+		issuerId: signingIssuerId,
 	}
 	var parsedBundle *certutil.ParsedCertBundle
 	var warnings []string
 	if useCSR {
-		parsedBundle, warnings, err = signCert(b.System(), input, signingBundle, false, useCSRValues)
+		parsedBundle, warnings, err = signCert(sc, input, signingBundle, false, useCSRValues)
 	} else {
 		parsedBundle, warnings, err = generateCert(sc, input, signingBundle, false, rand.Reader)
 	}
 	if err != nil {
+		if err == logical.ErrReadOnly {
+			return nil, err
+		}
 		switch err.(type) {
 		case errutil.UserError:
 			return logical.ErrorResponse(err.Error()), nil
-		case errutil.InternalError:
+		case errutil.InternalError, logical.HTTPCodedError:
 			return nil, err
 		default:
 			return nil, fmt.Errorf("error signing/generating certificate: %w", err)
@@ -442,11 +535,29 @@ func (b *backend) pathIssueSignCert(ctx context.Context, req *logical.Request, d
 		return nil, err
 	}
 
+	if !useCSR {
+		if wrapTTL := time.Second * time.Duration(data.Get("private_key_wrap_ttl").(int)); wrapTTL > 0 {
+			if err := b.wrapRespPrivateKey(ctx, resp, wrapTTL); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if signingBundle.LeafNotAfterBehavior == certutil.TruncateNotAfterBehavior &&
+		signingBundle.Certificate.NotAfter.Equal(parsedBundle.Certificate.NotAfter) {
+		resp.AddWarningWithCode(logical.WarningCodeIssuerNearExpiry, leafTruncationWarning)
+	}
+
 	if !role.NoStore {
 		err = issuing.StoreCertificate(ctx, req.Storage, b.GetCertificateCounter(), parsedBundle)
 		if err != nil {
 			return nil, err
 		}
+
+		err = storeCertIndex(ctx, req.Storage, parsedBundle.Certificate, role.Name, signingIssuerId, req.EntityID)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	if metadataInRequest {
@@ -466,13 +577,15 @@ func (b *backend) pathIssueSignCert(ctx context.Context, req *logical.Request, d
 		if role.UseCSRCommonName && data.Get("common_name").(string) != "" {
 			resp.AddWarning("the common_name field was provided but the role is set with \"use_csr_common_name\" set to true")
 		}
-		if role.UseCSRSANs && data.Get("alt_names").(string) != "" {
+		if role.CSRSANsMode("dns") == issuing.CSRMergeModeUse && role.CSRSANsMode("email") == issuing.CSRMergeModeUse && data.Get("alt_names").(string) != "" {
 			resp.AddWarning("the alt_names field was provided but the role is set with \"use_csr_sans\" set to true")
 		}
 	}
 
 	resp = addWarnings(resp, warnings)
 
+	b.emitIssuedMetric(role.Name, signingIssuerId)
+
 	return resp, nil
 }
 
@@ -540,6 +653,9 @@ func signIssueApiResponse(b *backend, data *framework.FieldData, parsedBundle *c
 	}
 
 	format := getFormat(data)
+	if includeKey {
+		format = getIssueFormat(data)
+	}
 	switch format {
 	case "pem":
 		respData["issuing_ca"] = signingCB.Certificate
@@ -575,6 +691,25 @@ func signIssueApiResponse(b *backend, data *framework.FieldData, parsedBundle *c
 			respData["private_key"] = base64.StdEncoding.EncodeToString(parsedBundle.PrivateKeyBytes)
 			respData["private_key_type"] = cb.PrivateKeyType
 		}
+
+	case "pkcs12":
+		// The private key is only returned within the archive.
+		respData["issuing_ca"] = signingCB.Certificate
+		respData["certificate"] = cb.Certificate
+		if caChainGen.containsChain() {
+			respData["ca_chain"] = caChainGen.pemEncodedChain()
+		}
+
+		var caCerts []*x509.Certificate
+		for _, block := range caChainGen.chain {
+			caCerts = append(caCerts, block.Certificate)
+		}
+		pfx, err := pkcs12.Encode(rand.Reader, parsedBundle.PrivateKey, parsedBundle.Certificate, caCerts, data.Get("pkcs12_password").(string))
+		if err != nil {
+			return nil, fmt.Errorf("error encoding PKCS#12 archive: %w", err)
+		}
+		respData["pkcs12"] = base64.StdEncoding.EncodeToString(pfx)
+		respData["private_key_type"] = cb.PrivateKeyType
 	default:
 		return nil, fmt.Errorf("unsupported format: %s", format)
 	}
@@ -593,12 +728,10 @@ func signIssueApiResponse(b *backend, data *framework.FieldData, parsedBundle *c
 		}
 	}
 
-	if includeKey {
-		if keyFormat := data.Get("private_key_format"); keyFormat == "pkcs8" {
-			err := convertRespToPKCS8(resp)
-			if err != nil {
-				return nil, err
-			}
+	if includeKey && format != "pkcs12" {
+		err := formatRespPrivateKey(resp, data)
+		if err != nil {
+			return nil, err
 		}
 	}
 
@@ -607,6 +740,40 @@ func signIssueApiResponse(b *backend, data *framework.FieldData, parsedBundle *c
 	return resp, nil
 }
 
+// wrapRespPrivateKey moves the private key of the response, or the PKCS#12
+// archive holding it, into a single-use response wrapping token, so that it
+// can only be read by the holder of the token. The private_key_type field is
+// not secret, so it stays in the response next to the certificate.
+func (b *backend) wrapRespPrivateKey(ctx context.Context, resp *logical.Response, ttl time.Duration) error {
+	wrapped := map[string]interface{}{}
+	for _, field := range []string{"private_key", "pkcs12"} {
+		if value, ok := resp.Data[field]; ok {
+			wrapped[field] = value
+		}
+	}
+	if _, ok := wrapped["private_key"]; !ok {
+		if _, ok := wrapped["pkcs12"]; !ok {
+			return nil
+		}
+	}
+
+	wrapInfo, err := b.System().ResponseWrapData(ctx, wrapped, ttl, false)
+	if err != nil {
+		return fmt.Errorf("error wrapping private key: %w", err)
+	}
+
+	delete(resp.Data, "private_key")
+	delete(resp.Data, "pkcs12")
+	resp.Data["private_key_wrap_info"] = map[string]interface{}{
+		"token":         wrapInfo.Token,
+		"accessor":      wrapInfo.Accessor,
+		"ttl":           int64(wrapInfo.TTL.Seconds()),
+		"creation_time": wrapInfo.CreationTime.Format(time.RFC3339Nano),
+		"creation_path": wrapInfo.CreationPath,
+	}
+	return nil
+}
+
 const pathIssueHelpSyn = `
 Request a certificate using a certain role with the provided details.
 `
@@ -197,6 +197,13 @@ func buildPathGenerateIntermediate(b *backend, pattern string, displayAttrs *fra
 extension with CA: true. Only needed as a
 workaround in some compatibility scenarios
 with Active Directory Certificate Services.`,
+	}
+	ret.Fields["max_path_length"] = &framework.FieldSchema{
+		Type:    framework.TypeInt,
+		Default: -1,
+		Description: `The maximum allowable path length to request
+of the signing issuer. If set, the CSR includes a
+Basic Constraints extension carrying it.`,
 	}
 	ret.Fields = addCaCsrKeyUsage(ret.Fields)
 
@@ -217,13 +224,13 @@ func pathImportIssuer(b *backend) *framework.Path {
 			OperationSuffix: "cert|bundle",
 		},
 
-		Fields: map[string]*framework.FieldSchema{
+		Fields: addIssuerImportFields(map[string]*framework.FieldSchema{
 			"pem_bundle": {
 				Type: framework.TypeString,
 				Description: `PEM-format, concatenated unencrypted
 secret-key (optional) and certificates.`,
 			},
-		},
+		}),
 
 		Operations: map[logical.Operation]framework.OperationHandler{
 			logical.UpdateOperation: &framework.PathOperation{
@@ -272,11 +279,6 @@ secret-key (optional) and certificates.`,
 }
 
 func (b *backend) pathImportIssuers(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
-	// Since we're planning on updating issuers here, grab the lock so we've
-	// got a consistent view.
-	b.issuersLock.Lock()
-	defer b.issuersLock.Unlock()
-
 	keysAllowed := strings.HasSuffix(req.Path, "bundle") || req.Path == "config/ca"
 
 	if b.UseLegacyBundleCaStorage() {
@@ -371,6 +373,85 @@ func (b *backend) pathImportIssuers(ctx context.Context, req *logical.Request, d
 
 	sc := b.makeStorageContext(ctx, req.Storage)
 
+	var parent *x509.Certificate
+	var parsedIssuers []*x509.Certificate
+	var fetchedIssuers []string
+	var fetchProblems []string
+	if len(issuers) > 0 {
+		var err error
+		parent, err = parseImportParentCertificate(data.Get("parent_certificate").(string))
+		if err != nil {
+			return logical.ErrorResponse(err.Error()), nil
+		}
+
+		parsedIssuers = make([]*x509.Certificate, 0, len(issuers))
+		for certIndex, certPem := range issuers {
+			cert, err := parseCertificateFromBytes([]byte(certPem))
+			if err != nil {
+				return logical.ErrorResponse(fmt.Sprintf("Error parsing issuer %v: %v\n%v", certIndex, err, certPem)), nil
+			}
+			parsedIssuers = append(parsedIssuers, cert)
+		}
+
+		// Complete partial chains by fetching the missing parents, so the
+		// imported issuers get a ca_chain clients can validate. This happens
+		// before taking the issuers lock, so that slow or unreachable
+		// servers do not hold up every other issuer operation; parents
+		// imported meanwhile are deduplicated by importIssuer.
+		if data.Get("fetch_issuing_certificates").(bool) {
+			existingCerts, err := sc.listIssuerCertificates()
+			if err != nil {
+				return nil, err
+			}
+			candidates := append(append([]*x509.Certificate{}, parsedIssuers...), existingCerts...)
+			fetched, problems := fetchMissingImportParents(ctx, parsedIssuers, candidates)
+			for _, cert := range fetched {
+				parsedIssuers = append(parsedIssuers, cert)
+				issuers = append(issuers, string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})))
+				fetchedIssuers = append(fetchedIssuers, cert.Subject.String())
+			}
+			fetchProblems = problems
+		}
+	}
+
+	// Since we're planning on updating issuers here, grab the lock so we've
+	// got a consistent view.
+	b.issuersLock.Lock()
+	defer b.issuersLock.Unlock()
+
+	// Validate the imported CA certificates against their parents before
+	// anything is persisted, so broken hierarchies are caught at import
+	// time rather than when clients fail to build a chain.
+	var chainProblems []string
+	var expiredIssuers []string
+	var incompleteChains []string
+	now := time.Now()
+	if len(issuers) > 0 {
+		existingCerts, err := sc.listIssuerCertificates()
+		if err != nil {
+			return nil, err
+		}
+
+		var chainCycles []string
+		incompleteChains, chainCycles = checkImportedIssuerChains(parsedIssuers, append(append([]*x509.Certificate{}, parsedIssuers...), existingCerts...))
+		if len(chainCycles) > 0 {
+			return logical.ErrorResponse("refusing to import certificates whose chain forms a cycle:\n\t%v", strings.Join(chainCycles, "\n\t")), nil
+		}
+
+		chainProblems, err = sc.validateImportedIssuerChain(parsedIssuers, parent)
+		if err != nil {
+			return nil, err
+		}
+		if len(chainProblems) > 0 && data.Get("enforce_chain_validation").(bool) {
+			return logical.ErrorResponse("refusing to import certificates which failed chain validation:\n\t%v", strings.Join(chainProblems, "\n\t")), nil
+		}
+
+		expiredIssuers = findExpiredImportedIssuers(parsedIssuers, now)
+		if len(expiredIssuers) > 0 && !data.Get("allow_expired_ca").(bool) {
+			return logical.ErrorResponse("refusing to import expired CA certificates; set allow_expired_ca=true to import them anyway:\n\t%v", strings.Join(expiredIssuers, "\n\t")), nil
+		}
+	}
+
 	for keyIndex, keyPem := range keys {
 		// Handle import of private key.
 		key, existing, err := importKeyFromBytes(sc, keyPem, "")
@@ -385,12 +466,20 @@ func (b *backend) pathImportIssuers(ctx context.Context, req *logical.Request, d
 		}
 	}
 
+	deriveNames := data.Get("derive_issuer_names").(bool)
+	issuerExpirations := make(map[issuing.IssuerID]time.Time, len(issuers))
 	for certIndex, certPem := range issuers {
-		cert, existing, err := sc.importIssuer(certPem, "")
+		var issuerName string
+		if deriveNames {
+			issuerName = deriveIssuerName(parsedIssuers[certIndex])
+		}
+
+		cert, existing, err := sc.importIssuer(certPem, issuerName)
 		if err != nil {
 			return logical.ErrorResponse(fmt.Sprintf("Error parsing issuer %v: %v\n%v", certIndex, err, certPem)), nil
 		}
 
+		issuerExpirations[cert.ID] = parsedIssuers[certIndex].NotAfter
 		issuerKeyMap[cert.ID.String()] = cert.KeyID.String()
 		if !existing {
 			createdIssuers = append(createdIssuers, cert.ID.String())
@@ -409,24 +498,48 @@ func (b *backend) pathImportIssuers(ctx context.Context, req *logical.Request, d
 		},
 	}
 
+	for _, problem := range chainProblems {
+		response.AddWarning("Chain validation: " + problem)
+	}
+	for _, expired := range expiredIssuers {
+		response.AddWarning("Imported expired CA certificate: " + expired)
+	}
+	for _, fetched := range fetchedIssuers {
+		response.AddWarning("Fetched and imported missing parent certificate: " + fetched)
+	}
+	for _, problem := range fetchProblems {
+		response.AddWarning("Fetching parent certificate failed: " + problem)
+	}
+	for _, incomplete := range incompleteChains {
+		response.AddWarning("Incomplete chain: " + incomplete)
+	}
+
 	if len(createdIssuers) > 0 {
-		warnings, err := b.CrlBuilder().Rebuild(sc, true)
-		if err != nil {
-			// Before returning, check if the error message includes the
-			// string "PSS". If so, it indicates we might've wanted to modify
-			// this issuer, so convert the error to a warning.
-			if strings.Contains(err.Error(), "PSS") || strings.Contains(err.Error(), "pss") {
-				err = fmt.Errorf("Rebuilding the CRL failed with a message relating to the PSS signature algorithm. This likely means the revocation_signature_algorithm needs to be set on the newly imported issuer(s) because a managed key supports only the PSS algorithm; by default PKCS#1v1.5 was used to build the CRLs. CRLs will not be generated until this has been addressed, however the import was successful. The original error is reproduced below:\n\n\t%w", err)
-			} else {
-				// Note to the caller that while this is an error, we did
-				// successfully import the issuers.
-				err = fmt.Errorf("Rebuilding the CRL failed. While this is indicative of a problem with the imported issuers (perhaps because of their revocation_signature_algorithm), they did import successfully and are now usable. It is strongly suggested to fix the CRL building errors before continuing. The original error is reproduced below:\n\n\t%w", err)
-			}
+		if data.Get("defer_crl_rebuild").(bool) {
+			// Bulk imports would otherwise rebuild every CRL once per
+			// request; instead, let the periodic function (or an explicit
+			// call to crl/rotate) pick this up once.
+			b.CrlBuilder().requestRebuildIfActiveNode(b)
+			response.AddWarning("CRL rebuild was deferred; the CRLs will not include the newly imported issuers until the next periodic rebuild or an explicit read of crl/rotate.")
+		} else {
+			warnings, err := b.CrlBuilder().Rebuild(sc, true)
+			if err != nil {
+				// Before returning, check if the error message includes the
+				// string "PSS". If so, it indicates we might've wanted to modify
+				// this issuer, so convert the error to a warning.
+				if strings.Contains(err.Error(), "PSS") || strings.Contains(err.Error(), "pss") {
+					err = fmt.Errorf("Rebuilding the CRL failed with a message relating to the PSS signature algorithm. This likely means the revocation_signature_algorithm needs to be set on the newly imported issuer(s) because a managed key supports only the PSS algorithm; by default PKCS#1v1.5 was used to build the CRLs. CRLs will not be generated until this has been addressed, however the import was successful. The original error is reproduced below:\n\n\t%w", err)
+				} else {
+					// Note to the caller that while this is an error, we did
+					// successfully import the issuers.
+					err = fmt.Errorf("Rebuilding the CRL failed. While this is indicative of a problem with the imported issuers (perhaps because of their revocation_signature_algorithm), they did import successfully and are now usable. It is strongly suggested to fix the CRL building errors before continuing. The original error is reproduced below:\n\n\t%w", err)
+				}
 
-			return nil, err
-		}
-		for index, warning := range warnings {
-			response.AddWarning(fmt.Sprintf("Warning %d during CRL rebuild: %v", index+1, warning))
+				return nil, err
+			}
+			for index, warning := range warnings {
+				response.AddWarning(fmt.Sprintf("Warning %d during CRL rebuild: %v", index+1, warning))
+			}
 		}
 
 		var issuersWithKeys []string
@@ -489,6 +602,21 @@ func (b *backend) pathImportIssuers(ctx context.Context, req *logical.Request, d
 		}
 	}
 
+	// Warn about roles which would issue certificates outliving the newly
+	// imported issuers. This is done after the default issuer has been
+	// updated, so roles referencing the default issuer are resolved against
+	// the new configuration.
+	roles, timeout, err := sc.findRolesExceedingIssuerLifetime(issuerExpirations, b.System().MaxLeaseTTL(), now)
+	if err != nil {
+		response.AddWarning("Unable to check roles for TTLs exceeding the imported issuers' lifetime: " + err.Error())
+	} else if len(roles) > 0 {
+		msg := "The following roles have a max TTL exceeding the remaining lifetime of an imported issuer; certificates issued from them may be truncated or fail to issue: " + strings.Join(roles, ", ")
+		if timeout {
+			msg += " (role scan stopped early; additional roles may be affected)"
+		}
+		response.AddWarning(msg)
+	}
+
 	// Also while we're here, we should let the user know the next steps.
 	// In particular, if there's no default AIA URLs configuration, we should
 	// tell the user that's probably next.
@@ -545,6 +673,11 @@ func pathRevokeIssuer(b *backend) *framework.Path {
 								Description: `Name of the issuer`,
 								Required:    true,
 							},
+							"previous_issuer_names": {
+								Type:        framework.TypeStringSlice,
+								Description: `Names this issuer was previously known by`,
+								Required:    false,
+							},
 							"key_id": {
 								Type:        framework.TypeString,
 								Description: `ID of the Key`,
@@ -600,6 +733,31 @@ func pathRevokeIssuer(b *backend) *framework.Path {
 								Description: `Specifies the URL values for the OCSP Servers field`,
 								Required:    true,
 							},
+							"ocsp_responder_certificate": {
+								Type:        framework.TypeString,
+								Description: `Delegated OCSP responder certificate`,
+								Required:    false,
+							},
+							"ocsp_responder_key_id": {
+								Type:        framework.TypeString,
+								Description: `Key Id of the delegated OCSP responder`,
+								Required:    false,
+							},
+							"crl_expiry": {
+								Type:        framework.TypeString,
+								Description: `CRL lifetime of this issuer, if overriding the mount's`,
+								Required:    false,
+							},
+							"ocsp_expiry": {
+								Type:        framework.TypeString,
+								Description: `OCSP response validity of this issuer, if overriding the mount's`,
+								Required:    false,
+							},
+							"crl_issuing_distribution_point": {
+								Type:        framework.TypeBool,
+								Description: `Whether CRLs include the Issuing Distribution Point extension`,
+								Required:    false,
+							},
 							"revocation_time": {
 								Type:        framework.TypeInt64,
 								Description: `Time of revocation`,
@@ -136,10 +136,19 @@ func (b *backend) pathGenerateKeyHandler(ctx context.Context, req *logical.Reque
 		keyType := data.Get(keyTypeParam).(string)
 		keyBits := data.Get(keyBitsParam).(int)
 
-		keyBits, _, err := certutil.ValidateDefaultOrValueKeyTypeSignatureLength(keyType, keyBits, 0)
+		cryptoPolicy, err := sc.getCryptoPolicyConfig()
+		if err != nil {
+			return nil, err
+		}
+		keyBits = cryptoPolicy.DefaultKeyBits(keyType, keyBits)
+
+		keyBits, _, err = certutil.ValidateDefaultOrValueKeyTypeSignatureLength(keyType, keyBits, 0)
 		if err != nil {
 			return logical.ErrorResponse("Validation for key_type, key_bits failed: %s", err.Error()), nil
 		}
+		if err := cryptoPolicy.ValidateKey(keyType, keyBits); err != nil {
+			return logical.ErrorResponse(err.Error()), nil
+		}
 
 		// Internal key generation, stored in storage
 		keyBundle, err = certutil.CreateKeyBundle(keyType, keyBits, b.GetRandomReader())
@@ -199,7 +199,12 @@ func (b *backend) ocspHandler(ctx context.Context, request *logical.Request, dat
 		return logAndReturnInternalError(b.Logger(), err), nil
 	}
 
-	byteResp, err := genResponse(cfg, caBundle, ocspStatus, ocspReq.HashAlgorithm, issuer.RevocationSigAlg)
+	responderBundle, err := sc.fetchOCSPResponderBundle(issuer)
+	if err != nil {
+		return logAndReturnInternalError(b.Logger(), err), nil
+	}
+
+	byteResp, err := genResponse(cfg, issuer, caBundle, responderBundle, ocspStatus, ocspReq.HashAlgorithm)
 	if err != nil {
 		return logAndReturnInternalError(b.Logger(), err), nil
 	}
@@ -236,12 +241,12 @@ func generateUnknownResponse(cfg *pki_backend.CrlConfig, sc *storageContext, ocs
 		return logAndReturnInternalError(sc.Logger(), err)
 	}
 
-	if config.DefaultIssuerId == "" {
+	if config.OCSPIssuerId() == "" {
 		// If we don't have any issuers or default issuers set, no way to sign a response so Unauthorized it is.
 		return OcspUnauthorizedResponse
 	}
 
-	caBundle, issuer, err := getOcspIssuerParsedBundle(sc, config.DefaultIssuerId)
+	caBundle, issuer, err := getOcspIssuerParsedBundle(sc, config.OCSPIssuerId())
 	if err != nil {
 		if errors.Is(err, ErrUnknownIssuer) || errors.Is(err, ErrIssuerHasNoKey) {
 			// We must have raced on a delete/update of the default issuer, anyways
@@ -261,7 +266,9 @@ func generateUnknownResponse(cfg *pki_backend.CrlConfig, sc *storageContext, ocs
 		ocspStatus:   ocsp.Unknown,
 	}
 
-	byteResp, err := genResponse(cfg, caBundle, info, ocspReq.HashAlgorithm, issuer.RevocationSigAlg)
+	// A delegated responder of the default issuer is not authorized to answer
+	// about the issuer of the request, so this is always signed by the issuer.
+	byteResp, err := genResponse(cfg, issuer, caBundle, nil, info, ocspReq.HashAlgorithm)
 	if err != nil {
 		return logAndReturnInternalError(sc.Logger(), err)
 	}
@@ -477,13 +484,21 @@ func doesRequestMatchIssuer(parsedBundle *certutil.ParsedCertBundle, req *ocsp.R
 	return bytes.Equal(req.IssuerKeyHash, issuerKeyHash) && bytes.Equal(req.IssuerNameHash, issuerNameHash), nil
 }
 
-func genResponse(cfg *pki_backend.CrlConfig, caBundle *certutil.ParsedCertBundle, info *ocspRespInfo, reqHash crypto.Hash, revSigAlg x509.SignatureAlgorithm) ([]byte, error) {
+// genResponse builds the OCSP response signed by the issuer, or by its
+// delegated responder when responderBundle is set.
+func genResponse(cfg *pki_backend.CrlConfig, issuer *issuing.IssuerEntry, caBundle *certutil.ParsedCertBundle, responderBundle *certutil.ParsedCertBundle, info *ocspRespInfo, reqHash crypto.Hash) ([]byte, error) {
 	curTime := time.Now()
-	duration, err := parseutil.ParseDurationSecond(cfg.OcspExpiry)
+	ocspExpiry := cfg.OcspExpiry
+	if issuer.OCSPExpiry != "" {
+		ocspExpiry = issuer.OCSPExpiry
+	}
+	duration, err := parseutil.ParseDurationSecond(ocspExpiry)
 	if err != nil {
 		return nil, err
 	}
 
+	revSigAlg := issuer.RevocationSigAlg
+
 	// x/crypto/ocsp lives outside of the standard library's crypto/x509 and includes
 	// ripped-off variants of many internal structures and functions. These
 	// lack support for PSS signatures altogether, so if we have revSigAlg
@@ -527,6 +542,15 @@ func genResponse(cfg *pki_backend.CrlConfig, caBundle *certutil.ParsedCertBundle
 		template.RevocationReason = ocsp.Unspecified
 	}
 
+	if responderBundle != nil {
+		// The issuer's revocation signature algorithm may not suit the key
+		// of the responder, so let it be picked from that key. Clients need
+		// the responder certificate to verify the response, so include it.
+		template.SignatureAlgorithm = x509.UnknownSignatureAlgorithm
+		template.Certificate = responderBundle.Certificate
+		return ocsp.CreateResponse(caBundle.Certificate, responderBundle.Certificate, template, responderBundle.PrivateKey)
+	}
+
 	return ocsp.CreateResponse(caBundle.Certificate, caBundle.Certificate, template, caBundle.PrivateKey)
 }
 
@@ -7,8 +7,13 @@ import (
 	"bytes"
 	"context"
 	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
 	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/base64"
+	"encoding/pem"
 	"fmt"
 	"io"
 	"net/http"
@@ -372,6 +377,90 @@ func TestOcsp_MultipleMatchingIssuersOneWithoutSigningUsage(t *testing.T) {
 	requireOcspResponseSignedBy(t, ocspResp, rotatedCert)
 }
 
+// Make sure responses are signed by the delegated responder of an issuer when
+// one is set, and include its certificate.
+func TestOcsp_DelegatedResponder(t *testing.T) {
+	t.Parallel()
+	b, s, testEnv := setupOcspEnv(t, "ec")
+	issuerPath := "issuer/" + testEnv.issuerId1.String()
+
+	responderKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(responderKey)
+	require.NoError(t, err)
+	csrDer, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject: pkix.Name{CommonName: "ocsp-responder"},
+	}, responderKey)
+	require.NoError(t, err)
+
+	resp, err := CBWrite(b, s, issuerPath+"/sign-verbatim", map[string]interface{}{
+		"csr":           string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csrDer})),
+		"ext_key_usage": "OCSPSigning",
+		"ttl":           "10h",
+	})
+	requireSuccessNonNilResponse(t, resp, err, "sign-verbatim")
+	responderPEM := resp.Data["certificate"].(string)
+	responderCert := parseCert(t, responderPEM)
+
+	// The key of the responder has not been imported yet
+	resp, err = CBPatch(b, s, issuerPath, map[string]interface{}{
+		"ocsp_responder_certificate": responderPEM,
+	})
+	require.ErrorContains(t, err, "import it through keys/import first")
+
+	resp, err = CBWrite(b, s, "keys/import", map[string]interface{}{
+		"pem_bundle": string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})),
+	})
+	requireSuccessNonNilResponse(t, resp, err, "keys/import")
+	responderKeyId := resp.Data["key_id"].(issuing.KeyID)
+
+	// The leaf lacks the OCSPSigning extended key usage
+	resp, err = CBPatch(b, s, issuerPath, map[string]interface{}{
+		"ocsp_responder_certificate": string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: testEnv.leafCertIssuer1.Raw})),
+	})
+	require.ErrorContains(t, err, "lacks the OCSPSigning extended key usage")
+
+	// The responder was not issued by the second issuer
+	resp, err = CBPatch(b, s, "issuer/"+testEnv.issuerId2.String(), map[string]interface{}{
+		"ocsp_responder_certificate": responderPEM,
+	})
+	require.ErrorContains(t, err, "was not issued by this issuer")
+
+	resp, err = CBPatch(b, s, issuerPath, map[string]interface{}{
+		"ocsp_responder_certificate": responderPEM,
+	})
+	requireSuccessNonNilResponse(t, resp, err, "setting the ocsp responder")
+	require.Equal(t, responderKeyId, resp.Data["ocsp_responder_key_id"])
+
+	resp, err = SendOcspRequest(t, b, s, "get", testEnv.leafCertIssuer1, testEnv.issuer1, crypto.SHA256)
+	requireSuccessNonNilResponse(t, resp, err, "ocsp get request")
+	require.Equal(t, 200, resp.Data["http_status_code"])
+
+	ocspResp, err := ocsp.ParseResponse(resp.Data["http_raw_body"].([]byte), testEnv.issuer1)
+	require.NoError(t, err, "parsing ocsp get response")
+	require.Equal(t, ocsp.Good, ocspResp.Status)
+	require.NotNil(t, ocspResp.Certificate)
+	require.Equal(t, responderCert.Raw, ocspResp.Certificate.Raw)
+	requireOcspResponseSignedBy(t, ocspResp, responderCert)
+
+	// The key of the responder cannot be deleted while in use
+	resp, err = CBDelete(b, s, "key/"+responderKeyId.String())
+	require.ErrorContains(t, err, "Key in Use by Issuer")
+
+	// Clearing the responder has the issuer sign responses again
+	resp, err = CBPatch(b, s, issuerPath, map[string]interface{}{
+		"ocsp_responder_certificate": "",
+	})
+	requireSuccessNonNilResponse(t, resp, err, "clearing the ocsp responder")
+
+	resp, err = SendOcspRequest(t, b, s, "get", testEnv.leafCertIssuer1, testEnv.issuer1, crypto.SHA256)
+	requireSuccessNonNilResponse(t, resp, err, "ocsp get request")
+	ocspResp, err = ocsp.ParseResponse(resp.Data["http_raw_body"].([]byte), testEnv.issuer1)
+	require.NoError(t, err, "parsing ocsp get response")
+	require.Nil(t, ocspResp.Certificate)
+	requireOcspResponseSignedBy(t, ocspResp, testEnv.issuer1)
+}
+
 // Make sure OCSP GET/POST requests work through the entire stack, and not just
 // through the quicker backend layer the other tests are doing.
 func TestOcsp_HigherLevel(t *testing.T) {
@@ -483,6 +572,49 @@ func TestOcsp_NextUpdate(t *testing.T) {
 	runOcspRequestTest(t, "POST", "ec", 0, 0, crypto.SHA256, 24*time.Hour)
 }
 
+// TestOcsp_IssuerExpiry makes sure an issuer's ocsp_expiry overrides the
+// mount's for the responses about its certificates.
+func TestOcsp_IssuerExpiry(t *testing.T) {
+	t.Parallel()
+	b, s, testEnv := setupOcspEnv(t, "ec")
+	issuerPath := "issuer/" + testEnv.issuerId1.String()
+
+	_, err := CBPatch(b, s, issuerPath, map[string]interface{}{
+		"ocsp_expiry": "-1h",
+	})
+	require.ErrorContains(t, err, "must not be negative")
+
+	resp, err := CBPatch(b, s, issuerPath, map[string]interface{}{
+		"ocsp_expiry": "1h",
+	})
+	requireSuccessNonNilResponse(t, resp, err, "setting the ocsp expiry")
+	require.Equal(t, "1h", resp.Data["ocsp_expiry"])
+
+	resp, err = SendOcspRequest(t, b, s, "get", testEnv.leafCertIssuer1, testEnv.issuer1, crypto.SHA256)
+	requireSuccessNonNilResponse(t, resp, err, "ocsp get request")
+	ocspResp, err := ocsp.ParseResponse(resp.Data["http_raw_body"].([]byte), testEnv.issuer1)
+	require.NoError(t, err, "parsing ocsp get response")
+	require.Equal(t, time.Hour, ocspResp.NextUpdate.Sub(ocspResp.ThisUpdate))
+
+	// The other issuer keeps the mount's expiry
+	resp, err = SendOcspRequest(t, b, s, "get", testEnv.leafCertIssuer2, testEnv.issuer2, crypto.SHA256)
+	requireSuccessNonNilResponse(t, resp, err, "ocsp get request")
+	ocspResp, err = ocsp.ParseResponse(resp.Data["http_raw_body"].([]byte), testEnv.issuer2)
+	require.NoError(t, err, "parsing ocsp get response")
+	require.Equal(t, 12*time.Hour, ocspResp.NextUpdate.Sub(ocspResp.ThisUpdate))
+
+	// An expiry of zero omits NextUpdate
+	_, err = CBPatch(b, s, issuerPath, map[string]interface{}{
+		"ocsp_expiry": "0",
+	})
+	require.NoError(t, err)
+	resp, err = SendOcspRequest(t, b, s, "get", testEnv.leafCertIssuer1, testEnv.issuer1, crypto.SHA256)
+	requireSuccessNonNilResponse(t, resp, err, "ocsp get request")
+	ocspResp, err = ocsp.ParseResponse(resp.Data["http_raw_body"].([]byte), testEnv.issuer1)
+	require.NoError(t, err, "parsing ocsp get response")
+	require.True(t, ocspResp.NextUpdate.IsZero())
+}
+
 func TestOcsp_ValidRequests(t *testing.T) {
 	type caKeyConf struct {
 		keyType string
@@ -40,6 +40,8 @@ var (
 	akOid       = asn1.ObjectIdentifier{2, 5, 29, 35}
 	crlNumOid   = asn1.ObjectIdentifier{2, 5, 29, 20}
 	deltaCrlOid = asn1.ObjectIdentifier{2, 5, 29, 27}
+
+	oidExtensionIssuingDistributionPoint = asn1.ObjectIdentifier{2, 5, 29, 28}
 )
 
 func pathResignCrls(b *backend) *framework.Path {
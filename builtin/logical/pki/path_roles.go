@@ -9,6 +9,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"slices"
 	"strings"
 	"time"
 
@@ -174,6 +175,14 @@ Any valid URI is accepted, these values support globbing.`,
 			Type:        framework.TypeCommaStringSlice,
 			Description: `If set, an array of allowed user-ids to put in user system login name specified here: https://www.rfc-editor.org/rfc/rfc1274#section-9.3.1`,
 		},
+		"subject_rdns": {
+			Type:        framework.TypeStringSlice,
+			Description: `The ordered RDNs, as TYPE=value, placed in the subject of certificates issued by this role.`,
+		},
+		"allowed_subject_rdns": {
+			Type:        framework.TypeStringSlice,
+			Description: `If set, an array of RDNs, as TYPE=value, which requests may place in the subject. These values support globbing.`,
+		},
 		"server_flag": {
 			Type:    framework.TypeBool,
 			Default: true,
@@ -230,6 +239,12 @@ SHA-2-512. Defaults to 0 to automatically detect based on key length
 			Required: false,
 			Description: `Whether or not to use PSS signatures when using a
 RSA key-type issuer. Defaults to false.`,
+		},
+		"serial_number_bits": {
+			Type:     framework.TypeInt,
+			Required: false,
+			Description: `The number of random bits of the serial numbers
+of issued certificates, from 64 to 159. Defaults to 0 for 159 bits.`,
 		},
 		"key_usage": {
 			Type:     framework.TypeCommaStringSlice,
@@ -260,6 +275,18 @@ Section 4.2.1.12.`,
 			Description: `A comma-separated string or list of extended key usage oids.`,
 		},
 
+		"allowed_extensions": {
+			Type:        framework.TypeCommaStringSlice,
+			Required:    true,
+			Description: `A comma-separated string or list of the OIDs of custom extensions requests may add.`,
+		},
+
+		"restrict_sign_verbatim": {
+			Type:        framework.TypeBool,
+			Required:    true,
+			Description: `If set, sign-verbatim requests using this role are constrained by its name, key, key usage and extension restrictions.`,
+		},
+
 		"use_csr_common_name": {
 			Type:     framework.TypeBool,
 			Required: true,
@@ -278,6 +305,16 @@ include the Common Name (cn); use use_csr_common_name
 for that. Defaults to true.`,
 		},
 
+		"csr_merge_mode": {
+			Type:     framework.TypeKVPairs,
+			Required: true,
+			Description: `Per SAN type (dns, email, ip, uri or other), how
+the SANs in a CSR combine with those of the request when signing: ignore
+(request only), use (CSR only), enforce (CSR only, failing on requested
+SANs absent from the CSR) or merge (both). SAN types not set follow
+use_csr_sans.`,
+		},
+
 		"ou": {
 			Type: framework.TypeCommaStringSlice,
 			Description: `If set, OU (OrganizationalUnit) will be set to
@@ -371,6 +408,10 @@ information, which must include an oid, and may include a notice and/or cps url,
 			Type:        framework.TypeBool,
 			Description: `Mark Basic Constraints valid when issuing non-CA certificates.`,
 		},
+		"ct_submission": {
+			Type:        framework.TypeBool,
+			Description: `Whether certificates are submitted to the CT logs of config/ct, embedding their SCTs.`,
+		},
 		"not_before_duration": {
 			Type:        framework.TypeInt64,
 			Description: `The duration in seconds before now which the certificate needs to be backdated by.`,
@@ -548,6 +589,24 @@ Any valid URI is accepted, these values support globbing.`,
 				Description: `If set, an array of allowed user-ids to put in user system login name specified here: https://www.rfc-editor.org/rfc/rfc1274#section-9.3.1`,
 			},
 
+			"subject_rdns": {
+				Type: framework.TypeStringSlice,
+				Description: `If set, the subject of certificates issued by
+this role is made of these relative distinguished names, in this order,
+followed by the serial number and common name. Each RDN is given as TYPE=value,
+where TYPE is one of C, ST, L, STREET, O, OU, POSTALCODE, DC, UID or
+EMAILADDRESS, or a dotted OID. Types may be repeated. Cannot be combined with
+ou, organization, country, locality, province, street_address or postal_code.`,
+			},
+
+			"allowed_subject_rdns": {
+				Type: framework.TypeStringSlice,
+				Description: `If set, an array of RDNs, as TYPE=value, which
+requests may place in the subject through their subject_rdns parameter, in
+place of those of the role. These values support globbing, e.g. "OU=*" or
+"DC=*". Empty by default, which does not allow requests to set subject_rdns.`,
+			},
+
 			"server_flag": {
 				Type:    framework.TypeBool,
 				Default: true,
@@ -613,6 +672,17 @@ SHA-2-512. Defaults to 0 to automatically detect based on key length
 RSA key-type issuer. Defaults to false.`,
 			},
 
+			"serial_number_bits": {
+				Type:    framework.TypeInt,
+				Default: 0,
+				Description: `The number of random bits of the serial numbers
+of issued certificates, from 64 to 159. Some device and legacy systems
+only handle shorter serial numbers. Defaults to 0 for 159 bits.`,
+				DisplayAttrs: &framework.DisplayAttributes{
+					Name: "Serial number bits",
+				},
+			},
+
 			"key_usage": {
 				Type:    framework.TypeCommaStringSlice,
 				Default: []string{"DigitalSignature", "KeyAgreement", "KeyEncipherment"},
@@ -650,6 +720,33 @@ Section 4.2.1.12.`,
 				},
 			},
 
+			"allowed_extensions": {
+				Type: framework.TypeCommaStringSlice,
+				Description: `A comma-separated string or list of the OIDs of
+custom extensions which requests may add to certificates through their
+extensions parameter. Extensions set by Vault itself, such as those of the
+2.5.29 arc, cannot be allowed. Empty by default, which does not allow
+requests to add extensions.`,
+				DisplayAttrs: &framework.DisplayAttributes{
+					Name: "Allowed Extensions",
+				},
+			},
+
+			"restrict_sign_verbatim": {
+				Type:    framework.TypeBool,
+				Default: false,
+				Description: `If set, sign-verbatim requests using this role
+are subject to its restrictions instead of signing the CSR as-is: the names of
+the CSR are validated like those of sign requests, the key of the CSR must match
+key_type and key_bits, the requested key_usage, ext_key_usage and
+ext_key_usage_oids must be amongst those of the role, and extensions of the CSR
+other than the Subject Alternative Name are only copied when listed in
+allowed_extensions. Defaults to false.`,
+				DisplayAttrs: &framework.DisplayAttributes{
+					Name: "Restrict Sign Verbatim",
+				},
+			},
+
 			"use_csr_common_name": {
 				Type:    framework.TypeBool,
 				Default: true,
@@ -676,6 +773,18 @@ for that. Defaults to true.`,
 				},
 			},
 
+			"csr_merge_mode": {
+				Type: framework.TypeKVPairs,
+				Description: `Per SAN type (dns, email, ip, uri or other), how
+the SANs in a CSR combine with those of the request when signing: ignore
+(request only), use (CSR only), enforce (CSR only, failing on requested
+SANs absent from the CSR) or merge (both). SAN types not set follow
+use_csr_sans.`,
+				DisplayAttrs: &framework.DisplayAttributes{
+					Name: "CSR Merge Mode",
+				},
+			},
+
 			"ou": {
 				Type: framework.TypeCommaStringSlice,
 				Description: `If set, OU (OrganizationalUnit) will be set to
@@ -789,6 +898,17 @@ information, which must include an oid, and may include a notice and/or cps url,
 					Name: "Basic Constraints Valid for Non-CA",
 				},
 			},
+			"ct_submission": {
+				Type:    framework.TypeBool,
+				Default: false,
+				Description: `If set, certificates issued by this role are first
+submitted as precertificates to the Certificate Transparency logs configured in
+config/ct, and the Signed Certificate Timestamps (SCTs) returned by the logs
+are embedded into the issued certificates. Defaults to false.`,
+				DisplayAttrs: &framework.DisplayAttributes{
+					Name: "CT Submission",
+				},
+			},
 			"not_before_duration": {
 				Type:        framework.TypeDurationSecond,
 				Default:     30,
@@ -961,12 +1081,16 @@ func (b *backend) pathRoleCreate(ctx context.Context, req *logical.Request, data
 		KeyType:                       data.Get("key_type").(string),
 		KeyBits:                       data.Get("key_bits").(int),
 		SignatureBits:                 data.Get("signature_bits").(int),
+		SerialNumberBits:              data.Get("serial_number_bits").(int),
 		UsePSS:                        data.Get("use_pss").(bool),
 		UseCSRCommonName:              data.Get("use_csr_common_name").(bool),
 		UseCSRSANs:                    data.Get("use_csr_sans").(bool),
+		CSRMergeMode:                  data.Get("csr_merge_mode").(map[string]string),
 		KeyUsage:                      data.Get("key_usage").([]string),
 		ExtKeyUsage:                   data.Get("ext_key_usage").([]string),
 		ExtKeyUsageOIDs:               data.Get("ext_key_usage_oids").([]string),
+		AllowedExtensions:             data.Get("allowed_extensions").([]string),
+		RestrictSignVerbatim:          data.Get("restrict_sign_verbatim").(bool),
 		OU:                            data.Get("ou").([]string),
 		Organization:                  data.Get("organization").([]string),
 		Country:                       data.Get("country").([]string),
@@ -981,8 +1105,11 @@ func (b *backend) pathRoleCreate(ctx context.Context, req *logical.Request, data
 		CNValidations:                 data.Get("cn_validations").([]string),
 		AllowedSerialNumbers:          data.Get("allowed_serial_numbers").([]string),
 		AllowedUserIDs:                data.Get("allowed_user_ids").([]string),
+		SubjectRDNs:                   data.Get("subject_rdns").([]string),
+		AllowedSubjectRDNs:            data.Get("allowed_subject_rdns").([]string),
 		PolicyIdentifiers:             getPolicyIdentifier(data, nil),
 		BasicConstraintsValidForNonCA: data.Get("basic_constraints_valid_for_non_ca").(bool),
+		CTSubmission:                  data.Get("ct_submission").(bool),
 		NotBeforeDuration:             time.Duration(data.Get("not_before_duration").(int)) * time.Second,
 		NotAfter:                      data.Get("not_after").(string),
 		Issuer:                        data.Get("issuer_ref").(string),
@@ -1057,9 +1184,23 @@ func validateRole(b *backend, entry *issuing.RoleEntry, ctx context.Context, s l
 		), nil
 	}
 
+	sc := b.makeStorageContext(ctx, s)
+	cryptoPolicy, err := sc.getCryptoPolicyConfig()
+	if err != nil {
+		return nil, err
+	}
+	entry.KeyBits = cryptoPolicy.DefaultKeyBits(entry.KeyType, entry.KeyBits)
+
 	if entry.KeyBits, entry.SignatureBits, err = certutil.ValidateDefaultOrValueKeyTypeSignatureLength(entry.KeyType, entry.KeyBits, entry.SignatureBits); err != nil {
 		return logical.ErrorResponse(err.Error()), nil
 	}
+	if err := cryptoPolicy.ValidateKey(entry.KeyType, entry.KeyBits); err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
+	if entry.SerialNumberBits != 0 && (entry.SerialNumberBits < certutil.MinSerialNumberBits || entry.SerialNumberBits > certutil.DefaultSerialNumberBits) {
+		return logical.ErrorResponse(fmt.Sprintf("serial_number_bits must be 0 or between %d and %d", certutil.MinSerialNumberBits, certutil.DefaultSerialNumberBits)), nil
+	}
 
 	if len(entry.ExtKeyUsageOIDs) > 0 {
 		for _, oidstr := range entry.ExtKeyUsageOIDs {
@@ -1077,6 +1218,22 @@ func validateRole(b *backend, entry *issuing.RoleEntry, ctx context.Context, s l
 		}
 	}
 
+	for _, oidStr := range entry.AllowedExtensions {
+		if err := issuing.ValidateAllowedExtension(oidStr); err != nil {
+			return logical.ErrorResponse(fmt.Sprintf("error parsing allowed_extensions: %v", err)), nil
+		}
+	}
+
+	if len(entry.SubjectRDNs) > 0 {
+		if _, err := issuing.ParseSubjectRDNs(entry.SubjectRDNs); err != nil {
+			return logical.ErrorResponse(fmt.Sprintf("error parsing subject_rdns: %v", err)), nil
+		}
+		if len(entry.OU) > 0 || len(entry.Organization) > 0 || len(entry.Country) > 0 || len(entry.Locality) > 0 ||
+			len(entry.Province) > 0 || len(entry.StreetAddress) > 0 || len(entry.PostalCode) > 0 {
+			return logical.ErrorResponse("subject_rdns cannot be combined with ou, organization, country, locality, province, street_address or postal_code"), nil
+		}
+	}
+
 	// Ensure issuers ref is set to a non-empty value. Note that we never
 	// resolve the reference (to an issuerId) at role creation time; instead,
 	// resolve it at use time. This allows values such as `default` or other
@@ -1086,7 +1243,6 @@ func validateRole(b *backend, entry *issuing.RoleEntry, ctx context.Context, s l
 	}
 	// Check that the issuers reference set resolves to something
 	if !b.UseLegacyBundleCaStorage() {
-		sc := b.makeStorageContext(ctx, s)
 		issuerId, err := sc.resolveIssuerReference(entry.Issuer)
 		if err != nil {
 			if issuerId == issuing.IssuerRefNotFound {
@@ -1109,6 +1265,12 @@ func validateRole(b *backend, entry *issuing.RoleEntry, ctx context.Context, s l
 		return nil, errutil.UserError{Err: err.Error()}
 	}
 
+	// Ensures the CSR merge modes are alright
+	entry.CSRMergeMode, err = checkCSRMergeMode(entry.CSRMergeMode)
+	if err != nil {
+		return nil, errutil.UserError{Err: err.Error()}
+	}
+
 	resp.Data = entry.ToResponseData()
 	return resp, nil
 }
@@ -1162,12 +1324,16 @@ func (b *backend) pathRolePatch(ctx context.Context, req *logical.Request, data
 		KeyType:                       getWithExplicitDefault(data, "key_type", oldEntry.KeyType).(string),
 		KeyBits:                       getWithExplicitDefault(data, "key_bits", oldEntry.KeyBits).(int),
 		SignatureBits:                 getWithExplicitDefault(data, "signature_bits", oldEntry.SignatureBits).(int),
+		SerialNumberBits:              getWithExplicitDefault(data, "serial_number_bits", oldEntry.SerialNumberBits).(int),
 		UsePSS:                        getWithExplicitDefault(data, "use_pss", oldEntry.UsePSS).(bool),
 		UseCSRCommonName:              getWithExplicitDefault(data, "use_csr_common_name", oldEntry.UseCSRCommonName).(bool),
 		UseCSRSANs:                    getWithExplicitDefault(data, "use_csr_sans", oldEntry.UseCSRSANs).(bool),
+		CSRMergeMode:                  getWithExplicitDefault(data, "csr_merge_mode", oldEntry.CSRMergeMode).(map[string]string),
 		KeyUsage:                      getWithExplicitDefault(data, "key_usage", oldEntry.KeyUsage).([]string),
 		ExtKeyUsage:                   getWithExplicitDefault(data, "ext_key_usage", oldEntry.ExtKeyUsage).([]string),
 		ExtKeyUsageOIDs:               getWithExplicitDefault(data, "ext_key_usage_oids", oldEntry.ExtKeyUsageOIDs).([]string),
+		AllowedExtensions:             getWithExplicitDefault(data, "allowed_extensions", oldEntry.AllowedExtensions).([]string),
+		RestrictSignVerbatim:          getWithExplicitDefault(data, "restrict_sign_verbatim", oldEntry.RestrictSignVerbatim).(bool),
 		OU:                            getWithExplicitDefault(data, "ou", oldEntry.OU).([]string),
 		Organization:                  getWithExplicitDefault(data, "organization", oldEntry.Organization).([]string),
 		Country:                       getWithExplicitDefault(data, "country", oldEntry.Country).([]string),
@@ -1182,8 +1348,11 @@ func (b *backend) pathRolePatch(ctx context.Context, req *logical.Request, data
 		CNValidations:                 getWithExplicitDefault(data, "cn_validations", oldEntry.CNValidations).([]string),
 		AllowedSerialNumbers:          getWithExplicitDefault(data, "allowed_serial_numbers", oldEntry.AllowedSerialNumbers).([]string),
 		AllowedUserIDs:                getWithExplicitDefault(data, "allowed_user_ids", oldEntry.AllowedUserIDs).([]string),
+		SubjectRDNs:                   getWithExplicitDefault(data, "subject_rdns", oldEntry.SubjectRDNs).([]string),
+		AllowedSubjectRDNs:            getWithExplicitDefault(data, "allowed_subject_rdns", oldEntry.AllowedSubjectRDNs).([]string),
 		PolicyIdentifiers:             getPolicyIdentifier(data, &oldEntry.PolicyIdentifiers),
 		BasicConstraintsValidForNonCA: getWithExplicitDefault(data, "basic_constraints_valid_for_non_ca", oldEntry.BasicConstraintsValidForNonCA).(bool),
+		CTSubmission:                  getWithExplicitDefault(data, "ct_submission", oldEntry.CTSubmission).(bool),
 		NotBeforeDuration:             getTimeWithExplicitDefault(data, "not_before_duration", oldEntry.NotBeforeDuration),
 		NotAfter:                      getWithExplicitDefault(data, "not_after", oldEntry.NotAfter).(string),
 		Issuer:                        getWithExplicitDefault(data, "issuer_ref", oldEntry.Issuer).(string),
@@ -1301,6 +1470,33 @@ func checkCNValidations(validations []string) ([]string, error) {
 	return result, nil
 }
 
+func checkCSRMergeMode(modes map[string]string) (map[string]string, error) {
+	if len(modes) == 0 {
+		return nil, nil
+	}
+
+	result := make(map[string]string, len(modes))
+	for sanType, mode := range modes {
+		sanType = strings.ToLower(sanType)
+		if !slices.Contains(issuing.CSRMergeModeSANTypes, sanType) {
+			return nil, fmt.Errorf("csr_merge_mode value incorrect: unknown SAN type: `%s`", sanType)
+		}
+		if _, ok := result[sanType]; ok {
+			return nil, fmt.Errorf("csr_merge_mode value incorrect: `%s` specified multiple times", sanType)
+		}
+
+		mode = strings.ToLower(mode)
+		switch mode {
+		case issuing.CSRMergeModeIgnore, issuing.CSRMergeModeUse, issuing.CSRMergeModeEnforce, issuing.CSRMergeModeMerge:
+		default:
+			return nil, fmt.Errorf("csr_merge_mode value incorrect: unknown mode for `%s`: `%s`", sanType, mode)
+		}
+		result[sanType] = mode
+	}
+
+	return result, nil
+}
+
 const pathListRolesHelpSyn = `List the existing roles in this backend`
 
 const pathListRolesHelpDesc = `Roles will be listed by the role name.`
@@ -248,11 +248,9 @@ func (b *backend) pathCAGenerateRoot(ctx context.Context, req *logical.Request,
 		return nil, fmt.Errorf("unsupported format argument: %s", format)
 	}
 
-	if data.Get("private_key_format").(string) == "pkcs8" {
-		err = convertRespToPKCS8(resp)
-		if err != nil {
-			return nil, err
-		}
+	err = formatRespPrivateKey(resp, data)
+	if err != nil {
+		return nil, err
 	}
 
 	// Store it as the CA bundle
@@ -400,7 +398,7 @@ func (b *backend) pathIssuerSignIntermediate(ctx context.Context, req *logical.R
 		apiData: data,
 		role:    role,
 	}
-	parsedBundle, warnings, err := signCert(b.System(), input, signingBundle, true, useCSRValues)
+	parsedBundle, warnings, err := signCert(sc, input, signingBundle, true, useCSRValues)
 	if err != nil {
 		switch err.(type) {
 		case errutil.UserError:
@@ -427,7 +425,7 @@ func (b *backend) pathIssuerSignIntermediate(ctx context.Context, req *logical.R
 
 	if warnAboutTruncate &&
 		signingBundle.Certificate.NotAfter.Equal(parsedBundle.Certificate.NotAfter) {
-		resp.AddWarning(intCaTruncatationWarning)
+		resp.AddWarningWithCode(logical.WarningCodeIssuerNearExpiry, intCaTruncatationWarning)
 	}
 
 	return resp, nil
@@ -452,7 +450,7 @@ func signIntermediateResponse(signingBundle *certutil.CAInfoBundle, parsedBundle
 	}
 
 	if signingBundle.Certificate.NotAfter.Before(parsedBundle.Certificate.NotAfter) {
-		resp.AddWarning("The expiration time for the signed certificate is after the CA's expiration time. If the new certificate is not treated as a root, validation paths with the certificate past the issuing CA's expiration time will fail.")
+		resp.AddWarningWithCode(logical.WarningCodeIssuerNearExpiry, "The expiration time for the signed certificate is after the CA's expiration time. If the new certificate is not treated as a root, validation paths with the certificate past the issuing CA's expiration time will fail.")
 	}
 
 	if len(parsedBundle.Certificate.RawSubject) <= 2 {
@@ -0,0 +1,525 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package pki
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/builtin/logical/pki/issuing"
+	"github.com/hashicorp/vault/helper/pkcs7"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/certutil"
+	"github.com/hashicorp/vault/sdk/helper/errutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	scepOpGetCACaps    = "GetCACaps"
+	scepOpGetCACert    = "GetCACert"
+	scepOpPKIOperation = "PKIOperation"
+
+	// Message types, statuses and failure reasons of RFC 8894 Section 3.2.1.
+	scepMessageTypeCertRep    = "3"
+	scepMessageTypeRenewalReq = "17"
+	scepMessageTypePKCSReq    = "19"
+
+	scepPKIStatusSuccess = "0"
+	scepPKIStatusFailure = "2"
+
+	scepFailInfoBadMessageCheck = "1"
+	scepFailInfoBadRequest      = "2"
+
+	scepCACertContentType   = "application/x-x509-ca-cert"
+	scepCARACertContentType = "application/x-x509-ca-ra-cert"
+	scepPKIMessageType      = "application/x-pki-message"
+
+	// scepMaximumRequestSize bounds the PKIOperation messages we are willing
+	// to read.
+	scepMaximumRequestSize = 64 * 1024
+)
+
+var (
+	oidScepMessageType    = asn1.ObjectIdentifier{2, 16, 840, 1, 113733, 1, 9, 2}
+	oidScepPKIStatus      = asn1.ObjectIdentifier{2, 16, 840, 1, 113733, 1, 9, 3}
+	oidScepFailInfo       = asn1.ObjectIdentifier{2, 16, 840, 1, 113733, 1, 9, 4}
+	oidScepSenderNonce    = asn1.ObjectIdentifier{2, 16, 840, 1, 113733, 1, 9, 5}
+	oidScepRecipientNonce = asn1.ObjectIdentifier{2, 16, 840, 1, 113733, 1, 9, 6}
+	oidScepTransactionID  = asn1.ObjectIdentifier{2, 16, 840, 1, 113733, 1, 9, 7}
+
+	oidChallengePassword = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 7}
+)
+
+// scepCACaps lists the capabilities advertised by GetCACaps, per RFC 8894
+// Section 3.5.2.
+var scepCACaps = []string{
+	"AES",
+	"DES3",
+	"POSTPKIOperation",
+	"Renewal",
+	"SCEPStandard",
+	"SHA-1",
+	"SHA-256",
+	"SHA-512",
+}
+
+// setupScepPaths registers the SCEP endpoint underneath the given prefix.
+// Clients commonly append the CGI name of the original implementation to
+// the URL, so the endpoint is also served as pkiclient.exe. Like EST, these
+// paths authenticate their clients on their own and are hence
+// unauthenticated within Vault.
+func setupScepPaths(b *backend, scepPrefix string, unauthPrefix string) {
+	for _, suffix := range []string{"", "/pkiclient.exe"} {
+		b.Backend.Paths = append(b.Backend.Paths, pathScep(b, scepPrefix+regexp.QuoteMeta(suffix)))
+
+		// SCEP messages are not JSON, so all paths are binary.
+		b.PathsSpecial.Unauthenticated = append(b.PathsSpecial.Unauthenticated, unauthPrefix+suffix)
+		b.PathsSpecial.Binary = append(b.PathsSpecial.Binary, unauthPrefix+suffix)
+	}
+}
+
+func pathScep(b *backend, pattern string) *framework.Path {
+	fields := map[string]*framework.FieldSchema{
+		"operation": {
+			Type:        framework.TypeString,
+			Description: `The SCEP operation: GetCACaps, GetCACert or PKIOperation`,
+			Query:       true,
+		},
+		"message": {
+			Type:        framework.TypeString,
+			Description: `The base64-encoded message of a PKIOperation sent through GET`,
+			Query:       true,
+		},
+	}
+	if strings.Contains(pattern, framework.GenericNameRegex("role")) {
+		fields["role"] = &framework.FieldSchema{
+			Type:        framework.TypeString,
+			Description: `The desired role for the SCEP request`,
+			Required:    true,
+		}
+	}
+
+	return &framework.Path{
+		Pattern: pattern,
+		Fields:  fields,
+		Operations: map[logical.Operation]framework.OperationHandler{
+			// PKIOperation issues certificates through either method, so
+			// both must run on a node able to write to storage; see
+			// backend.go for more details.
+			logical.ReadOperation: &framework.PathOperation{
+				Callback:                    b.pathScepOperation,
+				ForwardPerformanceStandby:   true,
+				ForwardPerformanceSecondary: false,
+			},
+			logical.UpdateOperation: &framework.PathOperation{
+				Callback:                    b.pathScepOperation,
+				ForwardPerformanceStandby:   true,
+				ForwardPerformanceSecondary: false,
+			},
+		},
+
+		HelpSynopsis:    pathScepHelpSyn,
+		HelpDescription: pathScepHelpDesc,
+	}
+}
+
+// loadScepRequestPolicy loads the configuration and policy of a SCEP
+// request: a role path uses that role, and otherwise the default policy
+// applies.
+func (b *backend) loadScepRequestPolicy(sc *storageContext, data *framework.FieldData) (*scepConfigEntry, *enrollmentPolicy, error) {
+	config, err := getScepConfig(sc)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !config.Enabled {
+		return nil, nil, logical.CodedError(http.StatusForbidden, "SCEP is disabled on this mount")
+	}
+
+	policyName := config.DefaultPathPolicy
+	if roleRaw, ok := data.GetOk("role"); ok {
+		policyName = rolePrefix + roleRaw.(string)
+	}
+
+	policy, err := resolveEnrollmentPolicy(sc, policyName)
+	if err != nil {
+		switch err.(type) {
+		case errutil.UserError:
+			return nil, nil, logical.CodedError(http.StatusNotFound, err.Error())
+		default:
+			return nil, nil, err
+		}
+	}
+
+	return config, policy, nil
+}
+
+func (b *backend) pathScepOperation(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	sc := b.makeStorageContext(ctx, req.Storage)
+	config, policy, err := b.loadScepRequestPolicy(sc, data)
+	if err != nil {
+		return nil, err
+	}
+
+	// Binary POST requests carry their parameters only in the URL.
+	operation := data.Get("operation").(string)
+	if operation == "" && req.HTTPRequest != nil {
+		operation = req.HTTPRequest.URL.Query().Get("operation")
+	}
+
+	switch operation {
+	case scepOpGetCACaps:
+		return &logical.Response{
+			Data: map[string]interface{}{
+				logical.HTTPContentType: "text/plain",
+				logical.HTTPStatusCode:  http.StatusOK,
+				logical.HTTPRawBody:     []byte(strings.Join(scepCACaps, "\n")),
+			},
+		}, nil
+	case scepOpGetCACert:
+		return b.scepGetCACert(sc, policy)
+	case scepOpPKIOperation:
+		message, err := readScepMessage(req, data)
+		if err != nil {
+			return nil, logical.CodedError(http.StatusBadRequest, fmt.Sprintf("failed to read the SCEP message: %s", err))
+		}
+		return b.scepPKIOperation(sc, req, config, policy, message)
+	default:
+		return nil, logical.CodedError(http.StatusBadRequest, fmt.Sprintf("unsupported SCEP operation %q", operation))
+	}
+}
+
+// scepGetCACert returns the issuer certificate alone as DER, or its chain as
+// a degenerate PKCS#7, per RFC 8894 Section 4.2.1.
+func (b *backend) scepGetCACert(sc *storageContext, policy *enrollmentPolicy) (*logical.Response, error) {
+	issuerId, err := sc.resolveIssuerReference(policy.issuerRef())
+	if err != nil {
+		return nil, err
+	}
+	issuer, err := sc.fetchIssuerById(issuerId)
+	if err != nil {
+		return nil, err
+	}
+
+	var chain []byte
+	for _, certPEM := range issuer.CAChain {
+		block, _ := pem.Decode([]byte(certPEM))
+		if block == nil {
+			return nil, fmt.Errorf("unable to decode the CA chain of issuer %v", issuerId)
+		}
+		chain = append(chain, block.Bytes...)
+	}
+
+	if len(issuer.CAChain) == 1 {
+		return scepRawResponse(scepCACertContentType, chain), nil
+	}
+
+	p7, err := pkcs7.DegenerateCertificate(chain)
+	if err != nil {
+		return nil, fmt.Errorf("failed building PKCS#7 response: %w", err)
+	}
+	return scepRawResponse(scepCARACertContentType, p7), nil
+}
+
+// readScepMessage reads the DER-encoded PKIOperation message out of the body
+// of a POST request, or the base64-encoded message parameter of a GET
+// request.
+func readScepMessage(req *logical.Request, data *framework.FieldData) ([]byte, error) {
+	if req.Operation == logical.ReadOperation {
+		// An unescaped '+' in the query is decoded as a space.
+		encoded := strings.ReplaceAll(data.Get("message").(string), " ", "+")
+		if encoded == "" {
+			return nil, errors.New("no message parameter in request")
+		}
+		return base64.StdEncoding.DecodeString(encoded)
+	}
+
+	if req.HTTPRequest == nil || req.HTTPRequest.Body == nil {
+		return nil, errors.New("no data in request body")
+	}
+	rawBody := req.HTTPRequest.Body
+	defer rawBody.Close()
+
+	body, err := io.ReadAll(io.LimitReader(rawBody, scepMaximumRequestSize))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) >= scepMaximumRequestSize {
+		return nil, errors.New("request is too large")
+	}
+
+	return body, nil
+}
+
+// scepRequest holds what a CertRep needs from the PKCSReq or RenewalReq it
+// answers.
+type scepRequest struct {
+	signer              *x509.Certificate
+	messageType         string
+	transactionID       string
+	senderNonce         []byte
+	encryptionAlgorithm int
+}
+
+// scepPKIOperation answers a PKCSReq or RenewalReq message, per RFC 8894
+// Section 3.3. Malformed messages are rejected at the HTTP level; requests
+// which are well-formed but can not be served get a CertRep with a FAILURE
+// status.
+func (b *backend) scepPKIOperation(sc *storageContext, req *logical.Request, config *scepConfigEntry, policy *enrollmentPolicy, message []byte) (*logical.Response, error) {
+	signingBundle, err := sc.fetchCAInfo(policy.issuerRef(), issuing.IssuanceUsage)
+	if err != nil {
+		return nil, fmt.Errorf("failed loading CA %s: %w", policy.issuerRef(), err)
+	}
+	issuerKey, ok := signingBundle.PrivateKey.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("SCEP requires an issuer with an RSA key stored in Vault")
+	}
+
+	scepReq, csrDER, err := parseScepPKIMessage(message, signingBundle.Certificate, issuerKey)
+	if err != nil {
+		return nil, logical.CodedError(http.StatusBadRequest, fmt.Sprintf("invalid SCEP message: %s", err))
+	}
+
+	csr, err := x509.ParseCertificateRequest(csrDER)
+	if err == nil {
+		err = csr.CheckSignature()
+	}
+	if err != nil {
+		b.Logger().Debug("rejecting SCEP request with an invalid CSR", "transaction_id", scepReq.transactionID, "error", err)
+		return scepCertRep(signingBundle, scepReq, scepFailInfoBadMessageCheck, nil)
+	}
+
+	switch scepReq.messageType {
+	case scepMessageTypePKCSReq:
+		challenge, err := scepChallengePassword(csr)
+		if err != nil {
+			return scepCertRep(signingBundle, scepReq, scepFailInfoBadRequest, nil)
+		}
+		valid, err := b.validateScepChallenge(sc, config, challenge)
+		if err != nil {
+			return nil, err
+		}
+		if !valid {
+			b.Logger().Debug("rejecting SCEP request with an invalid challenge password", "transaction_id", scepReq.transactionID)
+			return scepCertRep(signingBundle, scepReq, scepFailInfoBadRequest, nil)
+		}
+	case scepMessageTypeRenewalReq:
+		if err := validateScepRenewal(scepReq.signer, csr, signingBundle.Certificate); err != nil {
+			b.Logger().Debug("rejecting SCEP renewal request", "transaction_id", scepReq.transactionID, "error", err)
+			return scepCertRep(signingBundle, scepReq, scepFailInfoBadRequest, nil)
+		}
+		// A revoked certificate must not be able to renew itself
+		revInfo, err := fetchRevocationInfo(sc, serialFromCert(scepReq.signer))
+		if err != nil {
+			return nil, err
+		}
+		if revInfo != nil {
+			b.Logger().Debug("rejecting SCEP renewal request signed with a revoked certificate", "transaction_id", scepReq.transactionID)
+			return scepCertRep(signingBundle, scepReq, scepFailInfoBadRequest, nil)
+		}
+	default:
+		return scepCertRep(signingBundle, scepReq, scepFailInfoBadRequest, nil)
+	}
+
+	parsedBundle, err := b.issueEnrollmentCert(sc, req, policy, csr)
+	if err != nil {
+		switch err.(type) {
+		case errutil.UserError:
+			b.Logger().Debug("rejecting SCEP request", "transaction_id", scepReq.transactionID, "error", err)
+			return scepCertRep(signingBundle, scepReq, scepFailInfoBadRequest, nil)
+		default:
+			return nil, err
+		}
+	}
+
+	return scepCertRep(signingBundle, scepReq, "", parsedBundle.Certificate)
+}
+
+// parseScepPKIMessage verifies the signature of a pkiMessage, reads its
+// attributes and decrypts the CSR out of its pkcsPKIEnvelope.
+func parseScepPKIMessage(message []byte, issuerCert *x509.Certificate, issuerKey *rsa.PrivateKey) (*scepRequest, []byte, error) {
+	p7, err := pkcs7.Parse(message)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := p7.Verify(); err != nil {
+		return nil, nil, err
+	}
+
+	scepReq := &scepRequest{
+		signer: p7.GetOnlySigner(),
+	}
+	if scepReq.signer == nil {
+		return nil, nil, errors.New("message must have a single signer")
+	}
+	// The reply is encrypted to the key of the signer.
+	if _, ok := scepReq.signer.PublicKey.(*rsa.PublicKey); !ok {
+		return nil, nil, errors.New("the signer certificate must have an RSA key")
+	}
+
+	if err := p7.UnmarshalSignedAttribute(oidScepMessageType, &scepReq.messageType); err != nil {
+		return nil, nil, fmt.Errorf("failed reading messageType: %w", err)
+	}
+	if err := p7.UnmarshalSignedAttribute(oidScepTransactionID, &scepReq.transactionID); err != nil {
+		return nil, nil, fmt.Errorf("failed reading transactionID: %w", err)
+	}
+	if err := p7.UnmarshalSignedAttribute(oidScepSenderNonce, &scepReq.senderNonce); err != nil {
+		return nil, nil, fmt.Errorf("failed reading senderNonce: %w", err)
+	}
+
+	envelope, err := pkcs7.Parse(p7.Content)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed parsing pkcsPKIEnvelope: %w", err)
+	}
+	scepReq.encryptionAlgorithm, err = envelope.EncryptionAlgorithm()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed parsing pkcsPKIEnvelope: %w", err)
+	}
+	csrDER, err := envelope.Decrypt(issuerCert, issuerKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed decrypting pkcsPKIEnvelope: %w", err)
+	}
+
+	return scepReq, csrDER, nil
+}
+
+// scepChallengePassword reads the challengePassword attribute of the CSR,
+// which the x509 package does not expose.
+func scepChallengePassword(csr *x509.CertificateRequest) (string, error) {
+	var tbs struct {
+		Raw           asn1.RawContent
+		Version       int
+		Subject       asn1.RawValue
+		PublicKey     asn1.RawValue
+		RawAttributes []asn1.RawValue `asn1:"tag:0"`
+	}
+	if _, err := asn1.Unmarshal(csr.RawTBSCertificateRequest, &tbs); err != nil {
+		return "", err
+	}
+
+	for _, rawAttr := range tbs.RawAttributes {
+		var attr struct {
+			Type   asn1.ObjectIdentifier
+			Values []asn1.RawValue `asn1:"set"`
+		}
+		if _, err := asn1.Unmarshal(rawAttr.FullBytes, &attr); err != nil {
+			return "", err
+		}
+		if !attr.Type.Equal(oidChallengePassword) {
+			continue
+		}
+		if len(attr.Values) != 1 {
+			return "", errors.New("challengePassword must have a single value")
+		}
+
+		var challenge string
+		if _, err := asn1.Unmarshal(attr.Values[0].FullBytes, &challenge); err != nil {
+			return "", err
+		}
+		return challenge, nil
+	}
+
+	return "", nil
+}
+
+// validateScepRenewal enforces that a RenewalReq is signed with a currently
+// valid certificate of the same issuer and subject, per RFC 8894 Section 3.3.1.2.
+func validateScepRenewal(signer *x509.Certificate, csr *x509.CertificateRequest, issuerCert *x509.Certificate) error {
+	if err := signer.CheckSignatureFrom(issuerCert); err != nil {
+		return fmt.Errorf("the signer certificate was not issued by this issuer: %w", err)
+	}
+
+	now := time.Now()
+	if now.Before(signer.NotBefore) || now.After(signer.NotAfter) {
+		return errors.New("the signer certificate is not currently valid")
+	}
+
+	if !bytes.Equal(signer.RawSubject, csr.RawSubject) {
+		return errors.New("the subject of the request does not match the signer certificate")
+	}
+
+	return nil
+}
+
+// scepCertRep builds the CertRep answering the request, signed by the
+// issuer. Without a failInfo, the issued certificate is returned encrypted to
+// the requester with the algorithm of its request.
+func scepCertRep(signingBundle *certutil.CAInfoBundle, scepReq *scepRequest, failInfo string, cert *x509.Certificate) (*logical.Response, error) {
+	senderNonce := make([]byte, 16)
+	if _, err := rand.Read(senderNonce); err != nil {
+		return nil, err
+	}
+
+	attributes := []pkcs7.Attribute{
+		{Type: oidScepMessageType, Value: scepMessageTypeCertRep},
+		{Type: oidScepTransactionID, Value: scepReq.transactionID},
+		{Type: oidScepRecipientNonce, Value: scepReq.senderNonce},
+		{Type: oidScepSenderNonce, Value: senderNonce},
+	}
+
+	var content []byte
+	if failInfo != "" {
+		attributes = append(attributes,
+			pkcs7.Attribute{Type: oidScepPKIStatus, Value: scepPKIStatusFailure},
+			pkcs7.Attribute{Type: oidScepFailInfo, Value: failInfo})
+	} else {
+		attributes = append(attributes,
+			pkcs7.Attribute{Type: oidScepPKIStatus, Value: scepPKIStatusSuccess})
+
+		degenerate, err := pkcs7.DegenerateCertificate(cert.Raw)
+		if err != nil {
+			return nil, fmt.Errorf("failed building PKCS#7 response: %w", err)
+		}
+		content, err = pkcs7.EncryptWithAlgorithm(degenerate, []*x509.Certificate{scepReq.signer}, scepReq.encryptionAlgorithm)
+		if err != nil {
+			return nil, fmt.Errorf("failed encrypting response: %w", err)
+		}
+	}
+
+	signedData, err := pkcs7.NewSignedData(content)
+	if err != nil {
+		return nil, err
+	}
+	if err := signedData.AddSigner(signingBundle.Certificate, signingBundle.PrivateKey, pkcs7.SignerInfoConfig{
+		ExtraSignedAttributes: attributes,
+	}); err != nil {
+		return nil, fmt.Errorf("failed signing response: %w", err)
+	}
+	certRep, err := signedData.Finish()
+	if err != nil {
+		return nil, fmt.Errorf("failed signing response: %w", err)
+	}
+
+	return scepRawResponse(scepPKIMessageType, certRep), nil
+}
+
+func scepRawResponse(contentType string, body []byte) *logical.Response {
+	return &logical.Response{
+		Data: map[string]interface{}{
+			logical.HTTPContentType: contentType,
+			logical.HTTPStatusCode:  http.StatusOK,
+			logical.HTTPRawBody:     body,
+		},
+	}
+}
+
+const pathScepHelpSyn = `SCEP (RFC 8894) enrollment endpoint`
+
+const pathScepHelpDesc = `
+This endpoint implements the GetCACaps, GetCACert and PKIOperation operations
+of the Simple Certificate Enrollment Protocol (RFC 8894), selected through the
+operation query parameter. It is configured through the config/scep endpoint.
+`
@@ -0,0 +1,329 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package pki
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/helper/pkcs7"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+// TestScepConfig validates the SCEP configuration is checked before it is
+// stored, and that the static challenge is not returned.
+func TestScepConfig(t *testing.T) {
+	t.Parallel()
+	b, s := CreateBackendWithStorage(t)
+
+	resp, err := CBRead(b, s, "config/scep")
+	require.NoError(t, err)
+	require.Equal(t, false, resp.Data["enabled"])
+	require.Equal(t, scepChallengeTypeStatic, resp.Data["challenge_type"])
+
+	for name, data := range map[string]map[string]interface{}{
+		"enabled-without-policy":    {"enabled": true, "static_challenge": "secret"},
+		"enabled-without-challenge": {"enabled": true, "default_path_policy": "sign-verbatim"},
+		"unknown-policy":            {"default_path_policy": "forbid"},
+		"missing-role":              {"default_path_policy": "role:missing"},
+		"unknown-challenge-type":    {"challenge_type": "none"},
+	} {
+		_, err = CBWrite(b, s, "config/scep", data)
+		require.Error(t, err, "expected config %s to be rejected", name)
+	}
+
+	resp, err = CBWrite(b, s, "config/scep", map[string]interface{}{
+		"enabled":             true,
+		"default_path_policy": "sign-verbatim",
+		"static_challenge":    "secret",
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, resp.Data["last_updated"])
+
+	resp, err = CBRead(b, s, "config/scep")
+	require.NoError(t, err)
+	require.Equal(t, true, resp.Data["static_challenge_set"])
+	require.NotContains(t, resp.Data, "static_challenge")
+
+	// Static challenges can not be generated.
+	_, err = CBWrite(b, s, "scep/challenge", map[string]interface{}{})
+	require.Error(t, err)
+}
+
+// TestScepEnrollment enrolls and renews certificates through PKIOperation,
+// with both static and dynamic challenges.
+func TestScepEnrollment(t *testing.T) {
+	t.Parallel()
+	b, s := CreateBackendWithStorage(t)
+
+	resp, err := CBWrite(b, s, "root/generate/internal", map[string]interface{}{
+		"common_name": "Root SCEP CA",
+		"key_type":    "rsa",
+		"ttl":         "720h",
+	})
+	require.NoError(t, err)
+	rootCert := parseCert(t, resp.Data["certificate"].(string))
+
+	_, err = CBWrite(b, s, "roles/devices", map[string]interface{}{
+		"allowed_domains":  "devices.example.com",
+		"allow_subdomains": true,
+		"key_type":         "rsa",
+		"ttl":              "24h",
+	})
+	require.NoError(t, err)
+
+	// SCEP is disabled by default.
+	_, err = scepGet(b, s, "scep", scepOpGetCACaps)
+	require.Error(t, err)
+
+	_, err = CBWrite(b, s, "config/scep", map[string]interface{}{
+		"enabled":             true,
+		"default_path_policy": "sign-verbatim",
+		"static_challenge":    "secret",
+	})
+	require.NoError(t, err)
+
+	resp, err = scepGet(b, s, "scep/pkiclient.exe", scepOpGetCACaps)
+	require.NoError(t, err)
+	require.Contains(t, string(resp.Data[logical.HTTPRawBody].([]byte)), "POSTPKIOperation")
+
+	resp, err = scepGet(b, s, "scep", scepOpGetCACert)
+	require.NoError(t, err)
+	require.Equal(t, scepCACertContentType, resp.Data[logical.HTTPContentType])
+	require.Equal(t, rootCert.Raw, resp.Data[logical.HTTPRawBody])
+
+	client := newScepTestClient(t, "printer.example.org")
+
+	// The static challenge must match.
+	status, _ := client.enroll(t, b, s, "scep/pkiclient.exe", scepMessageTypePKCSReq, "wrong", rootCert)
+	require.Equal(t, scepPKIStatusFailure, status)
+
+	status, cert := client.enroll(t, b, s, "scep/pkiclient.exe", scepMessageTypePKCSReq, "secret", rootCert)
+	require.Equal(t, scepPKIStatusSuccess, status)
+	require.Equal(t, "printer.example.org", cert.Subject.CommonName)
+	require.NoError(t, cert.CheckSignatureFrom(rootCert))
+
+	resp, err = CBRead(b, s, "cert/"+normalizeSerialFromBigInt(cert.SerialNumber))
+	require.NoError(t, err)
+	require.NotNil(t, resp)
+
+	// Renewals are authenticated by the issued certificate, without a
+	// challenge, while self-signed certificates are rejected.
+	status, _ = client.enroll(t, b, s, "scep", scepMessageTypeRenewalReq, "", rootCert)
+	require.Equal(t, scepPKIStatusFailure, status)
+
+	client.cert = cert
+	status, renewed := client.enroll(t, b, s, "scep", scepMessageTypeRenewalReq, "", rootCert)
+	require.Equal(t, scepPKIStatusSuccess, status)
+	require.Equal(t, cert.RawSubject, renewed.RawSubject)
+	require.NotEqual(t, cert.SerialNumber, renewed.SerialNumber)
+
+	// Revoked certificates can not be renewed.
+	_, err = CBWrite(b, s, "revoke", map[string]interface{}{
+		"serial_number": normalizeSerialFromBigInt(renewed.SerialNumber),
+	})
+	require.NoError(t, err)
+	client.cert = renewed
+	status, _ = client.enroll(t, b, s, "scep", scepMessageTypeRenewalReq, "", rootCert)
+	require.Equal(t, scepPKIStatusFailure, status)
+
+	// Role paths validate the request against the role.
+	client = newScepTestClient(t, "printer.example.org")
+	status, _ = client.enroll(t, b, s, "roles/devices/scep", scepMessageTypePKCSReq, "secret", rootCert)
+	require.Equal(t, scepPKIStatusFailure, status)
+
+	client = newScepTestClient(t, "switch.devices.example.com")
+	status, cert = client.enroll(t, b, s, "roles/devices/scep", scepMessageTypePKCSReq, "secret", rootCert)
+	require.Equal(t, scepPKIStatusSuccess, status)
+	require.Equal(t, "switch.devices.example.com", cert.Subject.CommonName)
+
+	// Dynamic challenges can only be used once.
+	_, err = CBWrite(b, s, "config/scep", map[string]interface{}{
+		"challenge_type": scepChallengeTypeDynamic,
+	})
+	require.NoError(t, err)
+
+	resp, err = CBWrite(b, s, "scep/challenge", map[string]interface{}{})
+	require.NoError(t, err)
+	challenge := resp.Data["challenge"].(string)
+
+	client = newScepTestClient(t, "printer.example.org")
+	status, _ = client.enroll(t, b, s, "scep", scepMessageTypePKCSReq, "secret", rootCert)
+	require.Equal(t, scepPKIStatusFailure, status)
+
+	status, _ = client.enroll(t, b, s, "scep", scepMessageTypePKCSReq, challenge, rootCert)
+	require.Equal(t, scepPKIStatusSuccess, status)
+
+	status, _ = client.enroll(t, b, s, "scep", scepMessageTypePKCSReq, challenge, rootCert)
+	require.Equal(t, scepPKIStatusFailure, status)
+
+	// Expired challenges are tidied.
+	resp, err = CBWrite(b, s, "scep/challenge", map[string]interface{}{})
+	require.NoError(t, err)
+	entry, err := s.Get(context.Background(), scepChallengeStorageKey(resp.Data["challenge"].(string)))
+	require.NoError(t, err)
+	require.NotNil(t, entry)
+	entry, err = logical.StorageEntryJSON(entry.Key, &scepChallengeEntry{Expiration: time.Now().Add(-time.Minute)})
+	require.NoError(t, err)
+	require.NoError(t, s.Put(context.Background(), entry))
+
+	require.NoError(t, b.tidyScepChallenges(b.makeStorageContext(context.Background(), s)))
+	keys, err := s.List(context.Background(), storageScepChallengePrefix)
+	require.NoError(t, err)
+	require.Empty(t, keys)
+}
+
+func scepGet(b *backend, s logical.Storage, path string, operation string) (*logical.Response, error) {
+	return b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      path,
+		Storage:   s,
+		Data:      map[string]interface{}{"operation": operation},
+	})
+}
+
+type scepTestClient struct {
+	commonName string
+	key        *rsa.PrivateKey
+	cert       *x509.Certificate
+}
+
+// newScepTestClient creates a client with a self-signed certificate, as
+// devices use to sign their initial enrollment request.
+func newScepTestClient(t *testing.T, commonName string) *scepTestClient {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return &scepTestClient{commonName: commonName, key: key, cert: cert}
+}
+
+// csr builds a certificate request carrying the challenge password, which
+// the x509 package can not encode.
+func (c *scepTestClient) csr(t *testing.T, challenge string) []byte {
+	subject, err := asn1.Marshal(pkix.Name{CommonName: c.commonName}.ToRDNSequence())
+	require.NoError(t, err)
+	publicKey, err := x509.MarshalPKIXPublicKey(c.key.Public())
+	require.NoError(t, err)
+
+	var attributes []asn1.RawValue
+	if challenge != "" {
+		attribute, err := asn1.Marshal(struct {
+			Type   asn1.ObjectIdentifier
+			Values []interface{} `asn1:"set"`
+		}{oidChallengePassword, []interface{}{challenge}})
+		require.NoError(t, err)
+		attributes = append(attributes, asn1.RawValue{FullBytes: attribute})
+	}
+
+	tbs, err := asn1.Marshal(struct {
+		Version    int
+		Subject    asn1.RawValue
+		PublicKey  asn1.RawValue
+		Attributes []asn1.RawValue `asn1:"tag:0"`
+	}{0, asn1.RawValue{FullBytes: subject}, asn1.RawValue{FullBytes: publicKey}, attributes})
+	require.NoError(t, err)
+
+	digest := sha256.Sum256(tbs)
+	signature, err := rsa.SignPKCS1v15(rand.Reader, c.key, crypto.SHA256, digest[:])
+	require.NoError(t, err)
+
+	csr, err := asn1.Marshal(struct {
+		TBS       asn1.RawValue
+		Algorithm pkix.AlgorithmIdentifier
+		Signature asn1.BitString
+	}{
+		asn1.RawValue{FullBytes: tbs},
+		pkix.AlgorithmIdentifier{Algorithm: asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 11}, Parameters: asn1.NullRawValue},
+		asn1.BitString{Bytes: signature, BitLength: len(signature) * 8},
+	})
+	require.NoError(t, err)
+	return csr
+}
+
+// enroll sends a PKIOperation and returns the status of the CertRep, along
+// with the issued certificate on success.
+func (c *scepTestClient) enroll(t *testing.T, b *backend, s logical.Storage, path string, messageType string, challenge string, caCert *x509.Certificate) (string, *x509.Certificate) {
+	envelope, err := pkcs7.EncryptWithAlgorithm(c.csr(t, challenge), []*x509.Certificate{caCert}, pkcs7.EncryptionAlgorithmAES128CBC)
+	require.NoError(t, err)
+
+	nonce := make([]byte, 16)
+	_, err = rand.Read(nonce)
+	require.NoError(t, err)
+
+	signedData, err := pkcs7.NewSignedData(envelope)
+	require.NoError(t, err)
+	require.NoError(t, signedData.AddSigner(c.cert, c.key, pkcs7.SignerInfoConfig{
+		ExtraSignedAttributes: []pkcs7.Attribute{
+			{Type: oidScepMessageType, Value: messageType},
+			{Type: oidScepTransactionID, Value: "test-transaction"},
+			{Type: oidScepSenderNonce, Value: nonce},
+		},
+	}))
+	message, err := signedData.Finish()
+	require.NoError(t, err)
+
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation:   logical.UpdateOperation,
+		Path:        path,
+		Storage:     s,
+		HTTPRequest: httptest.NewRequest(http.MethodPost, "/v1/pki/"+path+"?operation="+scepOpPKIOperation, bytes.NewReader(message)),
+	})
+	require.NoError(t, err)
+	require.Equal(t, scepPKIMessageType, resp.Data[logical.HTTPContentType])
+
+	certRep, err := pkcs7.Parse(resp.Data[logical.HTTPRawBody].([]byte))
+	require.NoError(t, err)
+	require.NoError(t, certRep.Verify())
+
+	var repMessageType, status, transactionID string
+	var recipientNonce []byte
+	require.NoError(t, certRep.UnmarshalSignedAttribute(oidScepMessageType, &repMessageType))
+	require.NoError(t, certRep.UnmarshalSignedAttribute(oidScepPKIStatus, &status))
+	require.NoError(t, certRep.UnmarshalSignedAttribute(oidScepTransactionID, &transactionID))
+	require.NoError(t, certRep.UnmarshalSignedAttribute(oidScepRecipientNonce, &recipientNonce))
+	require.Equal(t, scepMessageTypeCertRep, repMessageType)
+	require.Equal(t, "test-transaction", transactionID)
+	require.Equal(t, nonce, recipientNonce)
+	if status != scepPKIStatusSuccess {
+		return status, nil
+	}
+
+	repEnvelope, err := pkcs7.Parse(certRep.Content)
+	require.NoError(t, err)
+	algorithm, err := repEnvelope.EncryptionAlgorithm()
+	require.NoError(t, err)
+	require.Equal(t, pkcs7.EncryptionAlgorithmAES128CBC, algorithm)
+	degenerate, err := repEnvelope.Decrypt(c.cert, c.key)
+	require.NoError(t, err)
+	certs, err := pkcs7.Parse(degenerate)
+	require.NoError(t, err)
+	require.Len(t, certs.Certificates, 1)
+	require.Equal(t, c.commonName, certs.Certificates[0].Subject.CommonName)
+
+	return status, certs.Certificates[0]
+}
@@ -48,6 +48,7 @@ type tidyStatus struct {
 	tidyRevokedCerts      bool
 	tidyRevokedAssocs     bool
 	tidyExpiredIssuers    bool
+	tidyDanglingKeys      bool
 	tidyBackupBundle      bool
 	tidyRevocationQueue   bool
 	tidyCrossRevokedCerts bool
@@ -72,6 +73,7 @@ type tidyStatus struct {
 	crossRevokedDeletedCount uint
 	certMetadataDeletedCount uint
 	cmpv2NonceDeletedCount   uint
+	danglingKeysDeletedCount uint
 
 	acmeAccountsCount        uint
 	acmeAccountsRevokedCount uint
@@ -89,6 +91,7 @@ type tidyConfig struct {
 	RevokedCerts      bool `json:"tidy_revoked_certs"`
 	IssuerAssocs      bool `json:"tidy_revoked_cert_issuer_associations"`
 	ExpiredIssuers    bool `json:"tidy_expired_issuers"`
+	DanglingKeys      bool `json:"tidy_dangling_keys"`
 	BackupBundle      bool `json:"tidy_move_legacy_ca_bundle"`
 	RevocationQueue   bool `json:"tidy_revocation_queue"`
 	CrossRevokedCerts bool `json:"tidy_cross_cluster_revoked_certs"`
@@ -109,11 +112,11 @@ type tidyConfig struct {
 }
 
 func (tc *tidyConfig) IsAnyTidyEnabled() bool {
-	return tc.CertStore || tc.RevokedCerts || tc.IssuerAssocs || tc.ExpiredIssuers || tc.BackupBundle || tc.TidyAcme || tc.CrossRevokedCerts || tc.RevocationQueue || tc.CertMetadata || tc.CMPV2NonceStore
+	return tc.CertStore || tc.RevokedCerts || tc.IssuerAssocs || tc.ExpiredIssuers || tc.DanglingKeys || tc.BackupBundle || tc.TidyAcme || tc.CrossRevokedCerts || tc.RevocationQueue || tc.CertMetadata || tc.CMPV2NonceStore
 }
 
 func (tc *tidyConfig) AnyTidyConfig() string {
-	return "tidy_cert_store / tidy_revoked_certs / tidy_revoked_cert_issuer_associations / tidy_expired_issuers / tidy_move_legacy_ca_bundle / tidy_revocation_queue / tidy_cross_cluster_revoked_certs / tidy_acme"
+	return "tidy_cert_store / tidy_revoked_certs / tidy_revoked_cert_issuer_associations / tidy_expired_issuers / tidy_dangling_keys / tidy_move_legacy_ca_bundle / tidy_revocation_queue / tidy_cross_cluster_revoked_certs / tidy_acme"
 }
 
 var defaultTidyConfig = tidyConfig{
@@ -123,6 +126,7 @@ var defaultTidyConfig = tidyConfig{
 	RevokedCerts:            false,
 	IssuerAssocs:            false,
 	ExpiredIssuers:          false,
+	DanglingKeys:            false,
 	BackupBundle:            false,
 	TidyAcme:                false,
 	SafetyBuffer:            72 * time.Hour,
@@ -227,6 +231,11 @@ func pathTidyCancel(b *backend) *framework.Path {
 								Description: `Tidy expired issuers`,
 								Required:    false,
 							},
+							"tidy_dangling_keys": {
+								Type:        framework.TypeBool,
+								Description: `Tidy dangling keys`,
+								Required:    false,
+							},
 							"tidy_cert_metadata": {
 								Type:        framework.TypeBool,
 								Description: `Tidy cert metadata`,
@@ -351,6 +360,11 @@ func pathTidyCancel(b *backend) *framework.Path {
 								Description: `The number of CMPv2 nonces removed`,
 								Required:    false,
 							},
+							"dangling_keys_deleted_count": {
+								Type:        framework.TypeInt,
+								Description: `The number of dangling keys removed`,
+								Required:    false,
+							},
 						},
 					}},
 				},
@@ -419,6 +433,11 @@ func pathTidyStatus(b *backend) *framework.Path {
 								Description: `Tidy expired issuers`,
 								Required:    true,
 							},
+							"tidy_dangling_keys": {
+								Type:        framework.TypeBool,
+								Description: `Tidy dangling keys`,
+								Required:    true,
+							},
 							"tidy_cross_cluster_revoked_certs": {
 								Type:        framework.TypeBool,
 								Description: `Tidy the cross-cluster revoked certificate store`,
@@ -549,6 +568,11 @@ func pathTidyStatus(b *backend) *framework.Path {
 								Description: `The number of CMPv2 nonces removed`,
 								Required:    false,
 							},
+							"dangling_keys_deleted_count": {
+								Type:        framework.TypeInt,
+								Description: `The number of dangling keys removed`,
+								Required:    false,
+							},
 						},
 					}},
 				},
@@ -632,6 +656,11 @@ available on the tidy-status endpoint.`,
 								Description: `Specifies whether tidy expired issuers`,
 								Required:    true,
 							},
+							"tidy_dangling_keys": {
+								Type:        framework.TypeBool,
+								Description: `Specifies whether to tidy dangling keys`,
+								Required:    true,
+							},
 							"tidy_acme": {
 								Type:        framework.TypeBool,
 								Description: `Tidy Unused Acme Accounts, and Orders`,
@@ -735,6 +764,11 @@ available on the tidy-status endpoint.`,
 								Description: `Specifies whether tidy expired issuers`,
 								Required:    true,
 							},
+							"tidy_dangling_keys": {
+								Type:        framework.TypeBool,
+								Description: `Specifies whether to tidy dangling keys`,
+								Required:    true,
+							},
 							"tidy_acme": {
 								Type:        framework.TypeBool,
 								Description: `Tidy Unused Acme Accounts, and Orders`,
@@ -814,6 +848,7 @@ func (b *backend) pathTidyWrite(ctx context.Context, req *logical.Request, d *fr
 	tidyRevokedCerts := d.Get("tidy_revoked_certs").(bool) || d.Get("tidy_revocation_list").(bool)
 	tidyRevokedAssocs := d.Get("tidy_revoked_cert_issuer_associations").(bool)
 	tidyExpiredIssuers := d.Get("tidy_expired_issuers").(bool)
+	tidyDanglingKeys := d.Get("tidy_dangling_keys").(bool)
 	tidyBackupBundle := d.Get("tidy_move_legacy_ca_bundle").(bool)
 	issuerSafetyBuffer := d.Get("issuer_safety_buffer").(int)
 	pauseDurationStr := d.Get("pause_duration").(string)
@@ -871,6 +906,7 @@ func (b *backend) pathTidyWrite(ctx context.Context, req *logical.Request, d *fr
 		RevokedCerts:            tidyRevokedCerts,
 		IssuerAssocs:            tidyRevokedAssocs,
 		ExpiredIssuers:          tidyExpiredIssuers,
+		DanglingKeys:            tidyDanglingKeys,
 		BackupBundle:            tidyBackupBundle,
 		SafetyBuffer:            bufferDuration,
 		IssuerSafetyBuffer:      issuerBufferDuration,
@@ -969,6 +1005,17 @@ func (b *backend) startTidyOperation(req *logical.Request, config *tidyConfig) {
 				return tidyCancelledError
 			}
 
+			if config.DanglingKeys {
+				if err := b.doTidyDanglingKeys(ctx, req, logger, config); err != nil {
+					return err
+				}
+			}
+
+			// Check for cancel before continuing.
+			if atomic.CompareAndSwapUint32(b.tidyCancelCAS, 1, 0) {
+				return tidyCancelledError
+			}
+
 			if config.BackupBundle {
 				if err := b.doTidyMoveCABundle(ctx, req, logger, config); err != nil {
 					return err
@@ -1081,6 +1128,9 @@ func (b *backend) doTidyCertStore(ctx context.Context, req *logical.Request, log
 			if err := req.Storage.Delete(ctx, issuing.PathCerts+serial); err != nil {
 				return fmt.Errorf("error deleting nil entry with serial %s: %w", serial, err)
 			}
+			if err := req.Storage.Delete(ctx, pathCertIndex+serial); err != nil {
+				return fmt.Errorf("error deleting index entry of serial %q from storage: %w", serial, err)
+			}
 			b.tidyStatusIncCertStoreCount()
 			continue
 		}
@@ -1090,6 +1140,9 @@ func (b *backend) doTidyCertStore(ctx context.Context, req *logical.Request, log
 			if err := req.Storage.Delete(ctx, issuing.PathCerts+serial); err != nil {
 				return fmt.Errorf("error deleting entry with nil value with serial %s: %w", serial, err)
 			}
+			if err := req.Storage.Delete(ctx, pathCertIndex+serial); err != nil {
+				return fmt.Errorf("error deleting index entry of serial %q from storage: %w", serial, err)
+			}
 			b.tidyStatusIncCertStoreCount()
 			continue
 		}
@@ -1103,7 +1156,11 @@ func (b *backend) doTidyCertStore(ctx context.Context, req *logical.Request, log
 			if err := req.Storage.Delete(ctx, issuing.PathCerts+serial); err != nil {
 				return fmt.Errorf("error deleting serial %q from storage: %w", serial, err)
 			}
+			if err := req.Storage.Delete(ctx, pathCertIndex+serial); err != nil {
+				return fmt.Errorf("error deleting index entry of serial %q from storage: %w", serial, err)
+			}
 			b.tidyStatusIncCertStoreCount()
+			b.certEvent(ctx, "tidy", "", cert)
 		}
 	}
 
@@ -1214,6 +1271,9 @@ func (b *backend) doTidyRevocationStore(ctx context.Context, req *logical.Reques
 				if err := req.Storage.Delete(ctx, issuing.PathCerts+serial); err != nil {
 					return fmt.Errorf("error deleting serial %q from store when tidying revoked: %w", serial, err)
 				}
+				if err := req.Storage.Delete(ctx, pathCertIndex+serial); err != nil {
+					return fmt.Errorf("error deleting index entry of serial %q from storage: %w", serial, err)
+				}
 				rebuildCRL = true
 				storeCert = false
 				b.tidyStatusIncRevokedCertCount()
@@ -1330,8 +1390,8 @@ func (b *backend) doTidyExpiredIssuers(ctx context.Context, req *logical.Request
 		// after we read it from storage, we have more info here to tell the
 		// user that their default has expired AND has passed the safety
 		// buffer.
-		if iConfig.DefaultIssuerId == issuer {
-			msg = "[Tidy on mount: %v] Issuer %v has expired and would be removed via tidy, but won't be, as it is currently the default issuer."
+		if iConfig.IsDefaultForAnyUse(issuer) {
+			msg = "[Tidy on mount: %v] Issuer %v has expired and would be removed via tidy, but won't be, as it is currently a default issuer."
 			msg = fmt.Sprintf(msg, b.backendUUID, idAndName)
 			b.Logger().Warn(msg)
 			continue
@@ -1380,6 +1440,105 @@ func (b *backend) doTidyExpiredIssuers(ctx context.Context, req *logical.Request
 	return nil
 }
 
+func (b *backend) doTidyDanglingKeys(ctx context.Context, req *logical.Request, logger hclog.Logger, config *tidyConfig) error {
+	// We do not support cancelling within the dangling keys operation.
+	// Any cancellation will occur before or after this operation.
+
+	if b.System().ReplicationState().HasState(consts.ReplicationDRSecondary|consts.ReplicationPerformanceStandby) ||
+		(!b.System().LocalMount() && b.System().ReplicationState().HasState(consts.ReplicationPerformanceSecondary)) {
+		b.Logger().Debug("skipping dangling key tidy as we're not on the primary or secondary with a local mount")
+		return nil
+	}
+
+	// As with expired issuers, leave legacy mounts alone until they've
+	// finished migrating.
+	if b.UseLegacyBundleCaStorage() {
+		return nil
+	}
+
+	b.issuersLock.Lock()
+	defer b.issuersLock.Unlock()
+
+	sc := b.makeStorageContext(ctx, req.Storage)
+
+	// Keys are in use when they back an issuer, sign its OCSP responses or
+	// are the default key.
+	inUse := make(map[issuing.KeyID]bool)
+	issuers, err := sc.listIssuers()
+	if err != nil {
+		return err
+	}
+	for _, issuerId := range issuers {
+		issuer, err := sc.fetchIssuerById(issuerId)
+		if err != nil {
+			return err
+		}
+		inUse[issuer.KeyID] = true
+		if issuer.OCSPResponderKeyID != "" {
+			inUse[issuer.OCSPResponderKeyID] = true
+		}
+	}
+
+	kConfig, err := sc.getKeysConfig()
+	if err != nil {
+		return err
+	}
+	inUse[kConfig.DefaultKeyId] = true
+
+	keys, err := sc.listKeys()
+	if err != nil {
+		return err
+	}
+
+	// Keys carry no creation time, and a key may be waiting on its issuer
+	// (e.g., that of an intermediate CSR being signed elsewhere). So record
+	// when a key is first seen without an issuer and only remove it once it
+	// has remained so past the issuer_safety_buffer.
+	now := time.Now()
+	for _, keyId := range keys {
+		key, err := sc.fetchKeyById(keyId)
+		if err != nil {
+			return err
+		}
+
+		if inUse[keyId] {
+			if !key.UnusedSince.IsZero() {
+				key.UnusedSince = time.Time{}
+				if err := sc.writeKey(*key); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		if key.UnusedSince.IsZero() {
+			key.UnusedSince = now
+			if err := sc.writeKey(*key); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if now.Sub(key.UnusedSince) <= config.IssuerSafetyBuffer {
+			continue
+		}
+
+		// Log the key's identity so an admin knows what was removed.
+		msg := "[Tidy on mount: %v] Key [id:%v/name:%v] has had no issuer since %v and is being removed."
+		msg = fmt.Sprintf(msg, b.backendUUID, key.ID, key.Name, key.UnusedSince.Format(time.RFC3339))
+		logger.Info(msg)
+
+		if _, err := sc.deleteKey(keyId); err != nil {
+			logger.Error(fmt.Sprintf("failed to remove key %v: %v", keyId, err))
+			return err
+		}
+
+		b.tidyStatusIncDanglingKeysDeletedCount()
+	}
+
+	return nil
+}
+
 func (b *backend) doTidyMoveCABundle(ctx context.Context, req *logical.Request, logger hclog.Logger, config *tidyConfig) error {
 	// We do not support cancelling within this operation; any cancel will
 	// occur before or after this operation.
@@ -1743,6 +1902,7 @@ func (b *backend) pathTidyStatusRead(_ context.Context, _ *logical.Request, _ *f
 			"tidy_revoked_certs":                    nil,
 			"tidy_revoked_cert_issuer_associations": nil,
 			"tidy_expired_issuers":                  nil,
+			"tidy_dangling_keys":                    nil,
 			"tidy_move_legacy_ca_bundle":            nil,
 			"tidy_revocation_queue":                 nil,
 			"tidy_cross_cluster_revoked_certs":      nil,
@@ -1770,6 +1930,7 @@ func (b *backend) pathTidyStatusRead(_ context.Context, _ *logical.Request, _ *f
 			"acme_account_safety_buffer":            nil,
 			"cert_metadata_deleted_count":           nil,
 			"cmpv2_nonce_deleted_count":             nil,
+			"dangling_keys_deleted_count":           nil,
 		},
 	}
 
@@ -1799,6 +1960,7 @@ func (b *backend) pathTidyStatusRead(_ context.Context, _ *logical.Request, _ *f
 	resp.Data["tidy_revoked_certs"] = b.tidyStatus.tidyRevokedCerts
 	resp.Data["tidy_revoked_cert_issuer_associations"] = b.tidyStatus.tidyRevokedAssocs
 	resp.Data["tidy_expired_issuers"] = b.tidyStatus.tidyExpiredIssuers
+	resp.Data["tidy_dangling_keys"] = b.tidyStatus.tidyDanglingKeys
 	resp.Data["tidy_move_legacy_ca_bundle"] = b.tidyStatus.tidyBackupBundle
 	resp.Data["tidy_revocation_queue"] = b.tidyStatus.tidyRevocationQueue
 	resp.Data["tidy_cross_cluster_revoked_certs"] = b.tidyStatus.tidyCrossRevokedCerts
@@ -1822,6 +1984,7 @@ func (b *backend) pathTidyStatusRead(_ context.Context, _ *logical.Request, _ *f
 	resp.Data["acme_account_safety_buffer"] = b.tidyStatus.acmeAccountSafetyBuffer
 	resp.Data["cert_metadata_deleted_count"] = b.tidyStatus.certMetadataDeletedCount
 	resp.Data["cmpv2_nonce_deleted_count"] = b.tidyStatus.cmpv2NonceDeletedCount
+	resp.Data["dangling_keys_deleted_count"] = b.tidyStatus.danglingKeysDeletedCount
 
 	switch b.tidyStatus.state {
 	case tidyStatusStarted:
@@ -1910,6 +2073,10 @@ func (b *backend) pathConfigAutoTidyWrite(ctx context.Context, req *logical.Requ
 		config.ExpiredIssuers = expiredIssuers.(bool)
 	}
 
+	if danglingKeys, ok := d.GetOk("tidy_dangling_keys"); ok {
+		config.DanglingKeys = danglingKeys.(bool)
+	}
+
 	if issuerSafetyBufferRaw, ok := d.GetOk("issuer_safety_buffer"); ok {
 		config.IssuerSafetyBuffer = time.Duration(issuerSafetyBufferRaw.(int)) * time.Second
 		if config.IssuerSafetyBuffer < 1*time.Second {
@@ -1993,6 +2160,7 @@ func (b *backend) tidyStatusStart(config *tidyConfig) {
 		tidyRevokedCerts:        config.RevokedCerts,
 		tidyRevokedAssocs:       config.IssuerAssocs,
 		tidyExpiredIssuers:      config.ExpiredIssuers,
+		tidyDanglingKeys:        config.DanglingKeys,
 		tidyBackupBundle:        config.BackupBundle,
 		tidyRevocationQueue:     config.RevocationQueue,
 		tidyCrossRevokedCerts:   config.CrossRevokedCerts,
@@ -2114,6 +2282,13 @@ func (b *backend) tidyStatusIncCMPV2NonceDeletedCount() {
 	b.tidyStatus.cmpv2NonceDeletedCount++
 }
 
+func (b *backend) tidyStatusIncDanglingKeysDeletedCount() {
+	b.tidyStatusLock.Lock()
+	defer b.tidyStatusLock.Unlock()
+
+	b.tidyStatus.danglingKeysDeletedCount++
+}
+
 const pathTidyHelpSyn = `
 Tidy up the backend by removing expired certificates, revocation information,
 or both.
@@ -2177,6 +2352,8 @@ The result includes the following fields:
 * 'missing_issuer_cert_count': The number of revoked certificates which were missing a valid issuer reference
 * 'tidy_expired_issuers': the value of this parameter when initiating the tidy operation
 * 'issuer_safety_buffer': the value of this parameter when initiating the tidy operation
+* 'tidy_dangling_keys': the value of this parameter when initiating the tidy operation
+* 'dangling_keys_deleted_count': the number of keys without an issuer deleted
 * 'tidy_move_legacy_ca_bundle': the value of this parameter when initiating the tidy operation
 * 'tidy_revocation_queue': the value of this parameter when initiating the tidy operation
 * 'revocation_queue_deleted_count': the number of revocation queue entries deleted
@@ -2214,6 +2391,7 @@ func getTidyConfigData(config tidyConfig) map[string]interface{} {
 		"tidy_revoked_certs":                       config.RevokedCerts,
 		"tidy_revoked_cert_issuer_associations":    config.IssuerAssocs,
 		"tidy_expired_issuers":                     config.ExpiredIssuers,
+		"tidy_dangling_keys":                       config.DanglingKeys,
 		"tidy_move_legacy_ca_bundle":               config.BackupBundle,
 		"tidy_acme":                                config.TidyAcme,
 		"safety_buffer":                            int(config.SafetyBuffer / time.Second),
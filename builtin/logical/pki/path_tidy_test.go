@@ -568,6 +568,59 @@ func TestTidyIssuerConfig(t *testing.T) {
 	require.Equal(t, 5, resp.Data["issuer_safety_buffer"])
 }
 
+func TestTidyDanglingKeys(t *testing.T) {
+	t.Parallel()
+
+	b, s := CreateBackendWithStorage(t)
+
+	resp, err := CBWrite(b, s, "root/generate/internal", map[string]interface{}{
+		"common_name": "root example.com",
+		"key_name":    "root-key",
+		"key_type":    "ec",
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+
+	resp, err = CBWrite(b, s, "keys/generate/internal", map[string]interface{}{
+		"key_name": "dangling-key",
+		"key_type": "ec",
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+
+	// The first tidy run only notes the key has no issuer.
+	_, err = CBWrite(b, s, "tidy", map[string]interface{}{
+		"tidy_dangling_keys":   true,
+		"issuer_safety_buffer": "1s",
+	})
+	require.NoError(t, err)
+
+	// Wait for tidy to finish.
+	time.Sleep(2 * time.Second)
+
+	resp, err = CBRead(b, s, "key/dangling-key")
+	requireSuccessNonNilResponse(t, resp, err, "dangling key should still be present")
+
+	// The second tidy run, past the safety buffer, removes it.
+	_, err = CBWrite(b, s, "tidy", map[string]interface{}{
+		"tidy_dangling_keys":   true,
+		"issuer_safety_buffer": "1s",
+	})
+	require.NoError(t, err)
+
+	// Wait for tidy to finish.
+	time.Sleep(2 * time.Second)
+
+	resp, err = CBRead(b, s, "key/dangling-key")
+	require.Error(t, err)
+	require.Nil(t, resp)
+	resp, err = CBRead(b, s, "key/root-key")
+	requireSuccessNonNilResponse(t, resp, err, "key in use should still be present")
+
+	statusResp, err := CBRead(b, s, "tidy-status")
+	requireSuccessNonNilResponse(t, statusResp, err)
+	require.Equal(t, true, statusResp.Data["tidy_dangling_keys"])
+	require.Equal(t, uint(1), statusResp.Data["dangling_keys_deleted_count"])
+}
+
 // TestCertStorageMetrics ensures that when enabled, metrics are able to count the number of certificates in storage and
 // number of revoked certificates in storage.  Moreover, this test ensures that the gauge is emitted periodically, so
 // that the metric does not disappear or go stale.
@@ -50,6 +50,8 @@ const (
 	autoTidyConfigPath = "config/auto-tidy"
 	clusterConfigPath  = "config/cluster"
 
+	cryptoPolicyConfigPath = issuing.CryptoPolicyConfigPath
+
 	maxRolesToScanOnIssuerChange = 100
 	maxRolesToFindOnIssuerChange = 10
 )
@@ -315,6 +317,10 @@ func (sc *storageContext) writeIssuer(issuer *issuing.IssuerEntry) error {
 	return issuing.WriteIssuer(sc.Context, sc.Storage, issuer)
 }
 
+func (sc *storageContext) renameIssuer(issuer *issuing.IssuerEntry, newName string) error {
+	return issuing.RenameIssuer(sc.Context, sc.Storage, issuer, newName)
+}
+
 func (sc *storageContext) deleteIssuer(id issuing.IssuerID) (bool, error) {
 	return issuing.DeleteIssuer(sc.Context, sc.Storage, id)
 }
@@ -585,7 +591,7 @@ func (sc *storageContext) isKeyInUse(keyId string) (inUse bool, issuerId string,
 		if issuerEntry == nil {
 			return true, issuerId.String(), errutil.InternalError{Err: fmt.Sprintf("Issuer listed: %s does not exist", issuerId.String())}
 		}
-		if issuerEntry.KeyID.String() == keyId {
+		if issuerEntry.KeyID.String() == keyId || issuerEntry.OCSPResponderKeyID.String() == keyId {
 			return true, issuerId.String(), nil
 		}
 	}
@@ -769,6 +775,33 @@ func (sc *storageContext) writeClusterConfig(config *issuing.ClusterConfigEntry)
 	return sc.Storage.Put(sc.Context, entry)
 }
 
+func (sc *storageContext) getCryptoPolicyConfig() (*issuing.CryptoPolicyConfigEntry, error) {
+	entry, err := sc.Storage.Get(sc.Context, cryptoPolicyConfigPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var result issuing.CryptoPolicyConfigEntry
+	if entry == nil {
+		return &result, nil
+	}
+
+	if err = entry.DecodeJSON(&result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (sc *storageContext) writeCryptoPolicyConfig(config *issuing.CryptoPolicyConfigEntry) error {
+	entry, err := logical.StorageEntryJSON(cryptoPolicyConfigPath, config)
+	if err != nil {
+		return err
+	}
+
+	return sc.Storage.Put(sc.Context, entry)
+}
+
 func fetchRevocationInfo(sc pki_backend.StorageContext, serial string) (*revocation.RevocationInfo, error) {
 	var revInfo *revocation.RevocationInfo
 	revEntry, err := fetchCertBySerial(sc, revocation.RevokedPath, serial)
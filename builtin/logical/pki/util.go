@@ -4,7 +4,9 @@
 package pki
 
 import (
+	"crypto/sha256"
 	"crypto/x509"
+	"encoding/hex"
 	"fmt"
 	"math/big"
 	"net/http"
@@ -26,6 +28,10 @@ const (
 	managedKeyIdArg   = "managed_key_id"
 	defaultRef        = issuing.DefaultRef
 
+	// maxDerivedIssuerNameLength bounds the common name portion of names
+	// generated by deriveIssuerName.
+	maxDerivedIssuerNameLength = 48
+
 	// Constants for If-Modified-Since operation
 	headerIfModifiedSince = "If-Modified-Since"
 	headerLastModified    = "Last-Modified"
@@ -149,6 +155,36 @@ func getManagedKeyNameOrUUID(data *framework.FieldData) (name string, UUID strin
 	return keyName, keyUUID, nil
 }
 
+// deriveIssuerName builds a friendly, stable name for an issuer from its
+// certificate's common name and a prefix of its SHA-256 fingerprint, e.g.,
+// "example-root-ca-1a2b3c4d" for a CN of "Example Root CA".
+func deriveIssuerName(cert *x509.Certificate) string {
+	var builder strings.Builder
+	pendingDash := false
+	for _, r := range strings.ToLower(cert.Subject.CommonName) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if pendingDash && builder.Len() > 0 {
+				builder.WriteRune('-')
+			}
+			builder.WriteRune(r)
+			pendingDash = false
+		} else {
+			pendingDash = true
+		}
+	}
+
+	base := builder.String()
+	if len(base) > maxDerivedIssuerNameLength {
+		base = strings.TrimRight(base[:maxDerivedIssuerNameLength], "-")
+	}
+	if len(base) == 0 {
+		base = "issuer"
+	}
+
+	fingerprint := sha256.Sum256(cert.Raw)
+	return base + "-" + hex.EncodeToString(fingerprint[:4])
+}
+
 func getIssuerName(sc *storageContext, data *framework.FieldData) (string, error) {
 	issuerName := ""
 	issuerNameIface, ok := data.GetOk("issuer_name")
@@ -165,7 +201,17 @@ func getIssuerName(sc *storageContext, data *framework.FieldData) (string, error
 		}
 		issuerId, err := sc.resolveIssuerReference(issuerName)
 		if err == nil {
-			return issuerName, errIssuerNameInUse
+			// Names only held in another issuer's name history may be
+			// claimed; RenameIssuer removes them from that history.
+			issuer, err := sc.fetchIssuerById(issuerId)
+			if err != nil {
+				return issuerName, errutil.InternalError{Err: err.Error()}
+			}
+			if issuer.Name == issuerName || issuerId.String() == issuerName {
+				return issuerName, errIssuerNameInUse
+			}
+
+			return issuerName, nil
 		}
 
 		if err != nil && issuerId != issuing.IssuerRefNotFound {
@@ -1728,6 +1728,109 @@ func TestBackend_DefExtTemplatingEnabled(t *testing.T) {
 	}
 }
 
+func TestBackend_DefCritOptTemplatingEnabled(t *testing.T) {
+	cluster, userpassToken := getSshCaTestCluster(t, testUserName)
+	defer cluster.Cleanup()
+	client := cluster.Cores[0].Client
+	rootToken := client.Token()
+
+	// Get auth accessor for identity template.
+	auths, err := client.Sys().ListAuth()
+	if err != nil {
+		t.Fatal(err)
+	}
+	userpassAccessor := auths["userpass/"].Accessor
+
+	// Write SSH role.
+	_, err = client.Logical().Write("ssh/roles/test", map[string]interface{}{
+		"key_type":                          "ca",
+		"allowed_critical_options":          "source-address",
+		"allow_user_certificates":           true,
+		"allowed_users":                     "tuber",
+		"default_user":                      "tuber",
+		"default_critical_options_template": true,
+		"default_critical_options": map[string]interface{}{
+			"force-command":  "/usr/bin/login-as {{identity.entity.aliases." + userpassAccessor + ".name}}",
+			"source-address": "10.0.0.0/8",
+		},
+		"default_extensions": map[string]interface{}{
+			"permit-pty": "",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sshKeyID := "vault-userpass-" + testUserName + "-9bd0f01b7dfc50a13aa5e5cd11aea19276968755c8f1f9c98965d04147f30ed0"
+	extensions := map[string]string{
+		"permit-pty": "",
+	}
+
+	// Issue SSH certificate with default critical options templating enabled, and no user-provided critical options
+	client.SetToken(userpassToken)
+	resp, err := client.Logical().Write("ssh/sign/test", map[string]interface{}{
+		"public_key": publicKey4096,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	signedKey := resp.Data["signed_key"].(string)
+	key, _ := base64.StdEncoding.DecodeString(strings.Split(signedKey, " ")[1])
+
+	parsedKey, err := ssh.ParsePublicKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defaultCriticalOptions := map[string]string{
+		"force-command":  "/usr/bin/login-as " + testUserName,
+		"source-address": "10.0.0.0/8",
+	}
+
+	err = validateSSHCertificate(parsedKey.(*ssh.Certificate), sshKeyID, ssh.UserCert, []string{"tuber"}, defaultCriticalOptions, extensions, 16*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Issue SSH certificate with user-provided critical options. The certificate
+	// should only have the user-provided critical options.
+	userProvidedCriticalOptions := map[string]string{
+		"source-address": "192.168.0.0/16",
+	}
+	resp, err = client.Logical().Write("ssh/sign/test", map[string]interface{}{
+		"public_key":       publicKey4096,
+		"critical_options": userProvidedCriticalOptions,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	signedKey = resp.Data["signed_key"].(string)
+	key, _ = base64.StdEncoding.DecodeString(strings.Split(signedKey, " ")[1])
+
+	parsedKey, err = ssh.ParsePublicKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = validateSSHCertificate(parsedKey.(*ssh.Certificate), sshKeyID, ssh.UserCert, []string{"tuber"}, userProvidedCriticalOptions, extensions, 16*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Signing without identity entity information must fail rather than
+	// drop the templated critical option.
+	client.SetToken(rootToken)
+	_, err = client.Logical().Write("ssh/sign/test", map[string]interface{}{
+		"public_key": publicKey4096,
+	})
+	if err == nil {
+		t.Fatal("expected an error while signing without entity information")
+	}
+	if !strings.Contains(err.Error(), "lacked identity entity information") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
 func TestBackend_EmptyAllowedExtensionFailsClosed(t *testing.T) {
 	cluster, userpassToken := getSshCaTestCluster(t, testUserName)
 	defer cluster.Cleanup()
@@ -91,7 +91,7 @@ func (b *backend) pathSignIssueCertificateHelper(ctx context.Context, req *logic
 		return logical.ErrorResponse(err.Error()), nil
 	}
 
-	criticalOptions, err := b.calculateCriticalOptions(data, role)
+	criticalOptions, err := b.calculateCriticalOptions(data, req, role)
 	if err != nil {
 		return logical.ErrorResponse(err.Error()), nil
 	}
@@ -286,10 +286,33 @@ func (b *backend) calculateKeyID(data *framework.FieldData, req *logical.Request
 	return keyID, nil
 }
 
-func (b *backend) calculateCriticalOptions(data *framework.FieldData, role *sshRole) (map[string]string, error) {
+func (b *backend) calculateCriticalOptions(data *framework.FieldData, req *logical.Request, role *sshRole) (map[string]string, error) {
 	unparsedCriticalOptions := data.Get("critical_options").(map[string]interface{})
 	if len(unparsedCriticalOptions) == 0 {
-		return role.DefaultCriticalOptions, nil
+		if !role.DefaultCriticalOptionsTemplate {
+			return role.DefaultCriticalOptions, nil
+		}
+
+		criticalOptions := make(map[string]string, len(role.DefaultCriticalOptions))
+		for option, value := range role.DefaultCriticalOptions {
+			// Look for templating markers {{ .* }}
+			if !containsTemplateRegex.MatchString(value) {
+				criticalOptions[option] = value
+				continue
+			}
+
+			// Unlike extensions, critical options restrict the use of the
+			// certificate, so one that cannot be rendered must not be skipped.
+			if req.EntityID == "" {
+				return nil, fmt.Errorf("critical option %q requires identity templating, but the request lacked identity entity information", option)
+			}
+			rendered, err := framework.PopulateIdentityTemplate(value, req.EntityID, b.System())
+			if err != nil {
+				return nil, fmt.Errorf("template '%s' could not be rendered -> %s", value, err)
+			}
+			criticalOptions[option] = rendered
+		}
+		return criticalOptions, nil
 	}
 
 	criticalOptions := convertMapToStringValue(unparsedCriticalOptions)
@@ -38,6 +38,8 @@ func Backend() *backend {
 			pathListKeys(&b),
 			pathKeys(&b),
 			pathCode(&b),
+			pathCodeBatch(&b),
+			pathEnroll(&b),
 		},
 
 		Secrets:     []*framework.Secret{},
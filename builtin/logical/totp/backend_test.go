@@ -1241,3 +1241,366 @@ func testAccStepValidateCode(t *testing.T, name string, code string, valid, expe
 		},
 	}
 }
+
+func TestBackend_generatedKeyEnrollment(t *testing.T) {
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+	b, err := Factory(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request := func(op logical.Operation, path string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := b.HandleRequest(namespace.RootContext(nil), &logical.Request{
+			Path:      path,
+			Operation: op,
+			Storage:   config.StorageView,
+			Data:      data,
+		})
+		if err != nil {
+			t.Fatalf("bad: path %q: err: %v", path, err)
+		}
+		return resp
+	}
+
+	resp := request(logical.UpdateOperation, "keys/test", map[string]interface{}{
+		"generate":     true,
+		"issuer":       "Vault",
+		"account_name": "Test",
+		"enroll":       true,
+	})
+	if resp == nil || resp.IsError() || resp.Data["url"] == nil {
+		t.Fatalf("bad: %#v", resp)
+	}
+	urlObject, err := url.Parse(resp.Data["url"].(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret := urlObject.Query().Get("secret")
+
+	resp = request(logical.ReadOperation, "keys/test", nil)
+	if resp.Data["pending"] != true {
+		t.Fatalf("expected key to be pending: %#v", resp.Data)
+	}
+
+	// Pending keys cannot be used yet
+	resp = request(logical.ReadOperation, "code/test", nil)
+	if !resp.IsError() {
+		t.Fatalf("expected an error generating a code for a pending key")
+	}
+
+	// The enrollment can be resumed
+	resp = request(logical.ReadOperation, "enroll/test", nil)
+	if resp.IsError() {
+		t.Fatalf("bad: %#v", resp)
+	}
+	if resp.Data["issuer"] != "Vault" || resp.Data["account_name"] != "Test" {
+		t.Fatalf("bad metadata: %#v", resp.Data)
+	}
+	if resp.Data["barcode"] == "" || resp.Data["expiration"] == nil {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+	reread, err := url.Parse(resp.Data["url"].(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reread.Query().Get("secret") != secret {
+		t.Fatalf("expected the same secret, got %q", reread.Query().Get("secret"))
+	}
+
+	resp = request(logical.UpdateOperation, "enroll/test", map[string]interface{}{
+		"code": "000000",
+	})
+	if resp.Data["valid"] != false {
+		t.Fatalf("expected an invalid code: %#v", resp.Data)
+	}
+
+	code, err := generateCode(secret, 30, otplib.DigitsSix, otplib.AlgorithmSHA1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp = request(logical.UpdateOperation, "enroll/test", map[string]interface{}{
+		"code": code,
+	})
+	if resp.Data["valid"] != true {
+		t.Fatalf("expected a valid code: %#v", resp.Data)
+	}
+
+	resp = request(logical.ReadOperation, "keys/test", nil)
+	if resp.Data["pending"] != false {
+		t.Fatalf("expected key not to be pending: %#v", resp.Data)
+	}
+
+	// The confirmation code cannot be replayed, and the enrollment is over
+	resp = request(logical.UpdateOperation, "code/test", map[string]interface{}{
+		"code": code,
+	})
+	if !resp.IsError() {
+		t.Fatalf("expected the confirmation code to be used: %#v", resp)
+	}
+	resp = request(logical.ReadOperation, "enroll/test", nil)
+	if !resp.IsError() {
+		t.Fatalf("expected an error reading a confirmed enrollment: %#v", resp)
+	}
+	resp = request(logical.ReadOperation, "code/test", nil)
+	if resp.IsError() {
+		t.Fatalf("bad: %#v", resp)
+	}
+}
+
+func TestBackend_enrollNonGeneratedKey(t *testing.T) {
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+	b, err := Factory(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, _ := createKey()
+	resp, err := b.HandleRequest(namespace.RootContext(nil), &logical.Request{
+		Path:      "keys/test",
+		Operation: logical.UpdateOperation,
+		Storage:   config.StorageView,
+		Data: map[string]interface{}{
+			"key":    key,
+			"enroll": true,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error enrolling a non-generated key")
+	}
+}
+
+func TestBackend_alphabetCode(t *testing.T) {
+	key, err := createKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Decimal digits encode the same value as the standard scheme, least
+	// significant digit first
+	entry := &keyEntry{
+		Key:       key,
+		Period:    30,
+		Algorithm: otplib.AlgorithmSHA1,
+		Digits:    otplib.DigitsSix,
+		Alphabet:  "0123456789",
+	}
+	now := time.Now()
+	code, err := entry.generateCode(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := totplib.GenerateCodeCustom(key, now, totplib.ValidateOpts{
+		Period:    30,
+		Digits:    otplib.DigitsSix,
+		Algorithm: otplib.AlgorithmSHA1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reversed := []byte(code)
+	for i, j := 0, len(reversed)-1; i < j; i, j = i+1, j-1 {
+		reversed[i], reversed[j] = reversed[j], reversed[i]
+	}
+	if string(reversed) != expected {
+		t.Fatalf("expected code %q to be the reverse of %q", code, expected)
+	}
+
+	valid, err := entry.validateCode(code, now)
+	if err != nil || !valid {
+		t.Fatalf("expected code %q to be valid: %v", code, err)
+	}
+	if _, err := entry.validateCode(code+"0", now); err != otplib.ErrValidateInputInvalidLength {
+		t.Fatalf("expected an invalid length error, got: %v", err)
+	}
+}
+
+func TestBackend_steamKey(t *testing.T) {
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+	b, err := Factory(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request := func(op logical.Operation, path string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := b.HandleRequest(namespace.RootContext(nil), &logical.Request{
+			Path:      path,
+			Operation: op,
+			Storage:   config.StorageView,
+			Data:      data,
+		})
+		if err != nil {
+			t.Fatalf("bad: path %q: err: %v", path, err)
+		}
+		return resp
+	}
+
+	key, err := createKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp := request(logical.UpdateOperation, "keys/steam", map[string]interface{}{
+		"url": "otpauth://totp/Steam:test?secret=" + key + "&issuer=Steam&encoder=steam",
+	})
+	if resp != nil && resp.IsError() {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	resp = request(logical.ReadOperation, "keys/steam", nil)
+	if resp.Data["alphabet"] != steamAlphabet || resp.Data["digits"] != otplib.Digits(steamDigits) {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	resp = request(logical.ReadOperation, "code/steam", nil)
+	if resp.IsError() {
+		t.Fatalf("bad: %#v", resp)
+	}
+	code := resp.Data["code"].(string)
+	if len(code) != steamDigits || strings.Trim(code, steamAlphabet) != "" {
+		t.Fatalf("bad code: %q", code)
+	}
+
+	resp = request(logical.UpdateOperation, "code/steam", map[string]interface{}{
+		"code": code,
+	})
+	if resp.IsError() || resp.Data["valid"] != true {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	// Generated keys carry the encoder in their url
+	resp = request(logical.UpdateOperation, "keys/generated", map[string]interface{}{
+		"generate":     true,
+		"issuer":       "Steam",
+		"account_name": "test",
+		"alphabet":     steamAlphabet,
+		"digits":       steamDigits,
+		"qr_size":      0,
+	})
+	if resp.IsError() || !strings.Contains(resp.Data["url"].(string), "encoder=steam") {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	for _, data := range []map[string]interface{}{
+		{"key": key, "alphabet": "AABC"},
+		{"key": key, "alphabet": "A"},
+		{"key": key, "alphabet": "AB C"},
+		{"key": key, "alphabet": steamAlphabet, "digits": 3},
+		// Too few distinct codes
+		{"key": key, "alphabet": "AB", "digits": 10},
+		{"key": key, "alphabet": "ABCDEFGHIJ", "digits": 5},
+		// Authenticators would generate decimal codes for the exported key
+		{"generate": true, "issuer": "Vault", "account_name": "test", "alphabet": "ABCDEFGHIJKLMNOP"},
+	} {
+		resp = request(logical.UpdateOperation, "keys/invalid", data)
+		if resp == nil || !resp.IsError() {
+			t.Fatalf("expected an error for %v", data)
+		}
+	}
+}
+
+func TestBackend_batchCode(t *testing.T) {
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+	b, err := Factory(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request := func(op logical.Operation, path string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := b.HandleRequest(namespace.RootContext(nil), &logical.Request{
+			Path:      path,
+			Operation: op,
+			Storage:   config.StorageView,
+			Data:      data,
+		})
+		if err != nil {
+			t.Fatalf("bad: path %q: err: %v", path, err)
+		}
+		return resp
+	}
+
+	keys := map[string]string{}
+	for _, name := range []string{"foo", "bar"} {
+		key, err := createKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys[name] = key
+		request(logical.UpdateOperation, "keys/"+name, map[string]interface{}{
+			"key": key,
+		})
+	}
+
+	barCode, err := generateCode(keys["bar"], 30, otplib.DigitsSix, otplib.AlgorithmSHA1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp := request(logical.UpdateOperation, "code", map[string]interface{}{
+		"batch_input": []interface{}{
+			map[string]interface{}{"name": "foo", "reference": "first"},
+			map[string]interface{}{"name": "bar", "code": barCode},
+			map[string]interface{}{"name": "unknown"},
+			map[string]interface{}{"code": "123456"},
+		},
+	})
+	if resp.IsError() {
+		t.Fatalf("bad: %#v", resp)
+	}
+	results := resp.Data["batch_results"].([]batchResponseCodeItem)
+	if len(results) != 4 {
+		t.Fatalf("bad: %#v", results)
+	}
+
+	fooCode, err := generateCode(keys["foo"], 30, otplib.DigitsSix, otplib.AlgorithmSHA1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Name != "foo" || results[0].Code != fooCode || results[0].Reference != "first" || results[0].Error != "" {
+		t.Fatalf("bad generated code: %#v", results[0])
+	}
+	if results[1].Valid == nil || !*results[1].Valid || results[1].Error != "" {
+		t.Fatalf("bad validation: %#v", results[1])
+	}
+	if results[2].Error != "unknown key: unknown" {
+		t.Fatalf("expected an unknown key error: %#v", results[2])
+	}
+	if results[3].Error == "" {
+		t.Fatalf("expected a missing name error: %#v", results[3])
+	}
+
+	// Batches may not try more than one code per key, nor hold too many
+	// items
+	oversized := make([]interface{}, maxCodeBatchSize+1)
+	for i := range oversized {
+		oversized[i] = map[string]interface{}{"name": fmt.Sprintf("key-%d", i)}
+	}
+	for name, batchInput := range map[string]interface{}{
+		"empty batch": nil,
+		"repeated key": []interface{}{
+			map[string]interface{}{"name": "bar", "code": "000000"},
+			map[string]interface{}{"name": "bar", "code": "000001"},
+		},
+		"oversized batch": oversized,
+	} {
+		resp, err = b.HandleRequest(namespace.RootContext(nil), &logical.Request{
+			Path:      "code",
+			Operation: logical.UpdateOperation,
+			Storage:   config.StorageView,
+			Data: map[string]interface{}{
+				"batch_input": batchInput,
+			},
+		})
+		if err != logical.ErrInvalidRequest || !resp.IsError() {
+			t.Fatalf("expected an error for %s: %#v, %v", name, resp, err)
+		}
+	}
+}
@@ -5,11 +5,18 @@ package totp
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/errutil"
 	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/mitchellh/mapstructure"
 	otplib "github.com/pquerna/otp"
 	totplib "github.com/pquerna/otp/totp"
 )
@@ -54,26 +61,133 @@ func pathCode(b *backend) *framework.Path {
 	}
 }
 
-func (b *backend) pathReadCode(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
-	name := data.Get("name").(string)
+// maxCodeBatchSize is the largest number of items a batch may hold.
+const maxCodeBatchSize = 128
 
-	// Get the key
-	key, err := b.Key(ctx, req.Storage, name)
-	if err != nil {
-		return nil, err
+func pathCodeBatch(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "code/?$",
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixTOTP,
+			OperationSuffix: "codes",
+		},
+
+		Fields: map[string]*framework.FieldSchema{
+			"batch_input": {
+				Type: framework.TypeSlice,
+				Description: `
+Specifies a list of items to be processed in a single batch. Each item holds
+the name of a key, and a code to validate against it. If the code is omitted,
+a code is generated for the key instead. Each key may only appear once, and a
+batch may hold at most 128 items. Any batch output will preserve the order of
+the batch input.`,
+			},
+		},
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.UpdateOperation: &framework.PathOperation{
+				Callback: b.pathBatchCode,
+				DisplayAttrs: &framework.DisplayAttributes{
+					OperationVerb: "process",
+				},
+			},
+		},
+
+		HelpSynopsis:    pathCodeBatchHelpSyn,
+		HelpDescription: pathCodeBatchHelpDesc,
 	}
-	if key == nil {
-		return logical.ErrorResponse(fmt.Sprintf("unknown key: %s", name)), nil
+}
+
+// batchRequestCodeItem represents a request item for batch processing
+type batchRequestCodeItem struct {
+	Name      string `json:"name" mapstructure:"name"`
+	Code      string `json:"code" mapstructure:"code"`
+	Reference string `json:"reference" mapstructure:"reference"`
+}
+
+// batchResponseCodeItem represents a response item for batch processing
+type batchResponseCodeItem struct {
+	Name string `json:"name" mapstructure:"name"`
+
+	// Code is the code generated for the key, if no code was given
+	Code string `json:"code,omitempty" mapstructure:"code"`
+
+	// Valid is set to whether the given code is valid for the key
+	Valid *bool `json:"valid,omitempty" mapstructure:"valid"`
+
+	// Reference is an arbitrary caller supplied string value that will be
+	// placed on the batch response to ease correlation
+	Reference string `json:"reference,omitempty" mapstructure:"reference"`
+
+	// Error, if set represents a failure encountered while processing the
+	// item
+	Error string `json:"error,omitempty" mapstructure:"error"`
+}
+
+func (b *backend) pathBatchCode(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	var batchInputItems []batchRequestCodeItem
+	if err := mapstructure.Decode(data.Raw["batch_input"], &batchInputItems); err != nil {
+		return nil, fmt.Errorf("failed to parse batch input: %w", err)
+	}
+	if len(batchInputItems) == 0 {
+		return logical.ErrorResponse("missing batch input to process"), logical.ErrInvalidRequest
+	}
+	if len(batchInputItems) > maxCodeBatchSize {
+		return logical.ErrorResponse("batch input must not hold more than %d items", maxCodeBatchSize), logical.ErrInvalidRequest
 	}
 
-	// Generate password using totp library
-	totpToken, err := totplib.GenerateCodeCustom(key.Key, time.Now(), totplib.ValidateOpts{
-		Period:    key.Period,
-		Digits:    key.Digits,
-		Algorithm: key.Algorithm,
-	})
+	// A key may only appear once, so that a batch allows no more guesses of
+	// its code than a single request to code/:name
+	names := make(map[string]struct{}, len(batchInputItems))
+	for _, item := range batchInputItems {
+		if _, ok := names[item.Name]; ok {
+			return logical.ErrorResponse("key %q appears more than once in the batch input", item.Name), logical.ErrInvalidRequest
+		}
+		names[item.Name] = struct{}{}
+	}
+
+	response := make([]batchResponseCodeItem, len(batchInputItems))
+	for i, item := range batchInputItems {
+		response[i].Name = item.Name
+		response[i].Reference = item.Reference
+
+		if item.Name == "" {
+			response[i].Error = "missing name of the key"
+			continue
+		}
+
+		var err error
+		if item.Code == "" {
+			response[i].Code, err = b.generateKeyCode(ctx, req.Storage, item.Name)
+		} else {
+			var valid bool
+			valid, err = b.validateKeyCode(ctx, req.Storage, item.Name, item.Code)
+			if err == nil {
+				response[i].Valid = &valid
+			}
+		}
+
+		switch err.(type) {
+		case nil:
+		case errutil.UserError:
+			response[i].Error = err.Error()
+		default:
+			return nil, err
+		}
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"batch_results": response,
+		},
+	}, nil
+}
+
+func (b *backend) pathReadCode(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	totpToken, err := b.generateKeyCode(ctx, req.Storage, data.Get("name").(string))
 	if err != nil {
-		return nil, err
+		return codeErrorResponse(err)
 	}
 
 	// Return the secret
@@ -85,38 +199,77 @@ func (b *backend) pathReadCode(ctx context.Context, req *logical.Request, data *
 }
 
 func (b *backend) pathValidateCode(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
-	name := data.Get("name").(string)
-	code := data.Get("code").(string)
+	valid, err := b.validateKeyCode(ctx, req.Storage, data.Get("name").(string), data.Get("code").(string))
+	if err != nil {
+		return codeErrorResponse(err)
+	}
 
-	// Enforce input value requirements
-	if code == "" {
-		return logical.ErrorResponse("the code value is required"), nil
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"valid": valid,
+		},
+	}, nil
+}
+
+// codeErrorResponse returns user errors from the code helpers as error
+// responses, and any other error as is.
+func codeErrorResponse(err error) (*logical.Response, error) {
+	switch err.(type) {
+	case errutil.UserError:
+		return logical.ErrorResponse(err.Error()), nil
+	default:
+		return nil, err
 	}
+}
 
-	// Get the key's stored values
-	key, err := b.Key(ctx, req.Storage, name)
+// usableKey returns the named key if it can generate and validate codes, or
+// a user error otherwise.
+func (b *backend) usableKey(ctx context.Context, s logical.Storage, name string) (*keyEntry, error) {
+	key, err := b.Key(ctx, s, name)
 	if err != nil {
 		return nil, err
 	}
 	if key == nil {
-		return logical.ErrorResponse(fmt.Sprintf("unknown key: %s", name)), nil
+		return nil, errutil.UserError{Err: fmt.Sprintf("unknown key: %s", name)}
+	}
+	if key.Pending {
+		return nil, errutil.UserError{Err: fmt.Sprintf("key %s is pending enrollment", name)}
+	}
+	return key, nil
+}
+
+func (b *backend) generateKeyCode(ctx context.Context, s logical.Storage, name string) (string, error) {
+	key, err := b.usableKey(ctx, s, name)
+	if err != nil {
+		return "", err
+	}
+
+	// Generate password using totp library
+	return key.generateCode(time.Now())
+}
+
+func (b *backend) validateKeyCode(ctx context.Context, s logical.Storage, name, code string) (bool, error) {
+	// Enforce input value requirements
+	if code == "" {
+		return false, errutil.UserError{Err: "the code value is required"}
+	}
+
+	// Get the key's stored values
+	key, err := b.usableKey(ctx, s, name)
+	if err != nil {
+		return false, err
 	}
 
 	usedName := fmt.Sprintf("%s_%s", name, code)
 
 	_, ok := b.usedCodes.Get(usedName)
 	if ok {
-		return logical.ErrorResponse("code already used; wait until the next time period"), nil
+		return false, errutil.UserError{Err: "code already used; wait until the next time period"}
 	}
 
-	valid, err := totplib.ValidateCustom(code, key.Key, time.Now(), totplib.ValidateOpts{
-		Period:    key.Period,
-		Skew:      key.Skew,
-		Digits:    key.Digits,
-		Algorithm: key.Algorithm,
-	})
+	valid, err := key.validateCode(code, time.Now())
 	if err != nil && err != otplib.ErrValidateInputInvalidLength {
-		return logical.ErrorResponse("an error occurred while validating the code"), err
+		return false, fmt.Errorf("an error occurred while validating the code: %w", err)
 	}
 
 	// Take the key skew, add two for behind and in front, and multiple that by
@@ -126,14 +279,85 @@ func (b *backend) pathValidateCode(ctx context.Context, req *logical.Request, da
 			int64(key.Period)*
 			int64((2+key.Skew))))
 	if err != nil {
-		return nil, fmt.Errorf("error adding code to used cache: %w", err)
+		return false, fmt.Errorf("error adding code to used cache: %w", err)
 	}
 
-	return &logical.Response{
-		Data: map[string]interface{}{
-			"valid": valid,
-		},
-	}, nil
+	return valid, nil
+}
+
+// generateCode returns the code of the key for the period containing t. Keys
+// with an alphabet use it in place of decimal digits.
+func (k *keyEntry) generateCode(t time.Time) (string, error) {
+	if k.Alphabet == "" {
+		return totplib.GenerateCodeCustom(k.Key, t, totplib.ValidateOpts{
+			Period:    k.Period,
+			Digits:    k.Digits,
+			Algorithm: k.Algorithm,
+		})
+	}
+
+	return k.alphabetCode(uint64(t.Unix()) / uint64(k.Period))
+}
+
+// validateCode checks the code against the codes of the key for the periods
+// within its skew of t.
+func (k *keyEntry) validateCode(code string, t time.Time) (bool, error) {
+	if k.Alphabet == "" {
+		return totplib.ValidateCustom(code, k.Key, t, totplib.ValidateOpts{
+			Period:    k.Period,
+			Skew:      k.Skew,
+			Digits:    k.Digits,
+			Algorithm: k.Algorithm,
+		})
+	}
+
+	code = strings.TrimSpace(code)
+	if len(code) != k.Digits.Length() {
+		return false, otplib.ErrValidateInputInvalidLength
+	}
+
+	counter := int64(t.Unix()) / int64(k.Period)
+	for i := -int64(k.Skew); i <= int64(k.Skew); i++ {
+		expected, err := k.alphabetCode(uint64(counter + i))
+		if err != nil {
+			return false, err
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// alphabetCode computes the HOTP value of the counter as in RFC 4226, and
+// encodes it with the alphabet of the key, least significant character
+// first, as done by Steam Guard.
+func (k *keyEntry) alphabetCode(counter uint64) (string, error) {
+	secret := strings.ToUpper(strings.TrimSpace(k.Key))
+	if n := len(secret) % 8; n != 0 {
+		secret += strings.Repeat("=", 8-n)
+	}
+	secretBytes, err := base32.StdEncoding.DecodeString(secret)
+	if err != nil {
+		return "", otplib.ErrValidateSecretInvalidBase32
+	}
+
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, counter)
+	mac := hmac.New(k.Algorithm.Hash, secretBytes)
+	mac.Write(buf)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0xf
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	alphabetLen := uint32(len(k.Alphabet))
+	code := make([]byte, k.Digits.Length())
+	for i := range code {
+		code[i] = k.Alphabet[value%alphabetLen]
+		value /= alphabetLen
+	}
+	return string(code), nil
 }
 
 const pathCodeHelpSyn = `
@@ -144,3 +368,13 @@ const pathCodeHelpDesc = `
 This path generates and validates time-based one-time use passwords for a certain key. 
 
 `
+
+const pathCodeBatchHelpSyn = `
+Request or validate time-based one-time use passwords for several keys.
+`
+
+const pathCodeBatchHelpDesc = `
+This path generates and validates time-based one-time use passwords for a
+batch of keys in a single request. Items which fail, for instance because the
+key is unknown, report an error without failing the rest of the batch.
+`
@@ -0,0 +1,227 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package totp
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"image/png"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+	otplib "github.com/pquerna/otp"
+)
+
+func pathEnroll(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "enroll/" + framework.GenericNameWithAtRegex("name"),
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixTOTP,
+			OperationSuffix: "enrollment",
+		},
+
+		Fields: map[string]*framework.FieldSchema{
+			"name": {
+				Type:        framework.TypeString,
+				Description: "Name of the key.",
+			},
+			"code": {
+				Type:        framework.TypeString,
+				Description: "The first TOTP code produced by the enrolled device.",
+			},
+			"qr_size": {
+				Type:        framework.TypeInt,
+				Default:     200,
+				Query:       true,
+				Description: `The pixel size of the returned square QR code. If this value is 0, a QR code will not be returned.`,
+			},
+		},
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.ReadOperation: &framework.PathOperation{
+				Callback: b.pathReadEnrollment,
+				DisplayAttrs: &framework.DisplayAttributes{
+					OperationVerb: "read",
+				},
+			},
+			logical.UpdateOperation: &framework.PathOperation{
+				Callback: b.pathConfirmEnrollment,
+				DisplayAttrs: &framework.DisplayAttributes{
+					OperationVerb: "confirm",
+				},
+			},
+		},
+
+		HelpSynopsis:    pathEnrollHelpSyn,
+		HelpDescription: pathEnrollHelpDesc,
+	}
+}
+
+// pendingKey returns the named key, or an error response if it does not exist
+// or is not waiting on a confirmation.
+func (b *backend) pendingKey(ctx context.Context, s logical.Storage, name string) (*keyEntry, *logical.Response, error) {
+	key, err := b.Key(ctx, s, name)
+	if err != nil {
+		return nil, nil, err
+	}
+	if key == nil {
+		return nil, logical.ErrorResponse(fmt.Sprintf("unknown key: %s", name)), nil
+	}
+	if !key.Pending {
+		return nil, logical.ErrorResponse(fmt.Sprintf("key %s is not pending enrollment", name)), nil
+	}
+	if key.enrollmentExpired(time.Now()) {
+		return nil, logical.ErrorResponse(fmt.Sprintf("enrollment of key %s has expired; delete and generate it again", name)), nil
+	}
+
+	return key, nil, nil
+}
+
+func (b *backend) pathReadEnrollment(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	name := data.Get("name").(string)
+	qrSize := data.Get("qr_size").(int)
+
+	if qrSize < 0 {
+		return logical.ErrorResponse("the qr_size value must be greater than or equal to zero"), nil
+	}
+
+	key, errResp, err := b.pendingKey(ctx, req.Storage, name)
+	if err != nil || errResp != nil {
+		return errResp, err
+	}
+
+	response := &logical.Response{
+		Data: map[string]interface{}{
+			"issuer":       key.Issuer,
+			"account_name": key.AccountName,
+			"period":       key.Period,
+			"algorithm":    key.Algorithm.String(),
+			"digits":       key.Digits,
+			"alphabet":     key.Alphabet,
+		},
+	}
+	if !key.EnrollmentExpiration.IsZero() {
+		response.Data["expiration"] = key.EnrollmentExpiration.Format(time.RFC3339)
+	}
+
+	// The url and QR code carry the shared secret, so they are only handed
+	// out again if the key was generated with exported set.
+	if !key.Exported {
+		return response, nil
+	}
+
+	keyObject, err := otplib.NewKeyFromURL(key.url())
+	if err != nil {
+		return nil, fmt.Errorf("failed to build key url: %w", err)
+	}
+	response.Data["url"] = keyObject.String()
+
+	if qrSize > 0 {
+		barcode, err := keyObject.Image(qrSize, qrSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate QR code image: %w", err)
+		}
+
+		var buff bytes.Buffer
+		png.Encode(&buff, barcode)
+		response.Data["barcode"] = base64.StdEncoding.EncodeToString(buff.Bytes())
+	}
+
+	return response, nil
+}
+
+func (b *backend) pathConfirmEnrollment(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	name := data.Get("name").(string)
+	code := data.Get("code").(string)
+
+	// Enforce input value requirements
+	if code == "" {
+		return logical.ErrorResponse("the code value is required"), nil
+	}
+
+	key, errResp, err := b.pendingKey(ctx, req.Storage, name)
+	if err != nil || errResp != nil {
+		return errResp, err
+	}
+
+	valid, err := key.validateCode(code, time.Now())
+	if err != nil && err != otplib.ErrValidateInputInvalidLength {
+		return logical.ErrorResponse("an error occurred while validating the code"), err
+	}
+	if !valid {
+		return &logical.Response{
+			Data: map[string]interface{}{
+				"valid": false,
+			},
+		}, nil
+	}
+
+	key.Pending = false
+	key.Exported = false
+	key.EnrollmentExpiration = time.Time{}
+
+	entry, err := logical.StorageEntryJSON("key/"+name, key)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+
+	// The confirmation code must not be accepted again by the code endpoint
+	err = b.usedCodes.Add(fmt.Sprintf("%s_%s", name, code), nil, time.Duration(
+		int64(time.Second)*
+			int64(key.Period)*
+			int64((2+key.Skew))))
+	if err != nil {
+		return nil, fmt.Errorf("error adding code to used cache: %w", err)
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"valid": true,
+		},
+	}, nil
+}
+
+// url returns the otpauth url of the key, in the form generated keys are
+// first returned in.
+func (k *keyEntry) url() string {
+	v := url.Values{}
+	v.Set("secret", k.Key)
+	v.Set("issuer", k.Issuer)
+	v.Set("period", strconv.FormatUint(uint64(k.Period), 10))
+	v.Set("algorithm", k.Algorithm.String())
+	v.Set("digits", k.Digits.String())
+	if k.Alphabet == steamAlphabet {
+		v.Set("encoder", "steam")
+	}
+
+	u := url.URL{
+		Scheme:   "otpauth",
+		Host:     "totp",
+		Path:     "/" + k.Issuer + ":" + k.AccountName,
+		RawQuery: v.Encode(),
+	}
+	return u.String()
+}
+
+const pathEnrollHelpSyn = `
+Read or confirm the enrollment of a pending key.
+`
+
+const pathEnrollHelpDesc = `
+Keys generated with enroll set are pending until the device they were
+provisioned to proves it holds the secret. Reading this path returns the
+issuer and account metadata of a pending key and, if the key was generated
+with exported set, its url and QR code so the enrollment can be resumed.
+Writing the first code produced by the device to this path confirms the
+enrollment, after which the key can generate and validate codes.
+`
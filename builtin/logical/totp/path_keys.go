@@ -8,11 +8,13 @@ import (
 	"context"
 	"encoding/base32"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"image/png"
 	"net/url"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/hashicorp/vault/sdk/framework"
 	"github.com/hashicorp/vault/sdk/logical"
@@ -20,6 +22,18 @@ import (
 	totplib "github.com/pquerna/otp/totp"
 )
 
+const (
+	// steamAlphabet and steamDigits are the parameters of the codes used by
+	// Steam Guard.
+	steamAlphabet = "23456789BCDFGHJKMNPQRTVWXY"
+	steamDigits   = 5
+
+	// minCodeSpace is the least number of distinct codes keys with an
+	// alphabet may have, that of 6 decimal digits, so that guessing a code
+	// is no easier than with the default keys.
+	minCodeSpace = 1000000
+)
+
 func pathListKeys(b *backend) *framework.Path {
 	return &framework.Path{
 		Pattern: "keys/?$",
@@ -101,7 +115,12 @@ func pathKeys(b *backend) *framework.Path {
 			"digits": {
 				Type:        framework.TypeInt,
 				Default:     6,
-				Description: `The number of digits in the generated TOTP token. This value can either be 6 or 8.`,
+				Description: `The number of digits in the generated TOTP token. This value can either be 6 or 8, or between 4 and 10 if an alphabet is set, as long as the alphabet and digits allow at least a million distinct codes.`,
+			},
+
+			"alphabet": {
+				Type:        framework.TypeString,
+				Description: `The characters used to encode TOTP tokens in place of decimal digits, as done by Steam Guard with "23456789BCDFGHJKMNPQRTVWXY". If a url is given, an encoder=steam query parameter selects the Steam Guard alphabet and 5 digits. Generated keys with any other alphabet cannot be exported, as authenticators would generate decimal codes for them.`,
 			},
 
 			"skew": {
@@ -120,6 +139,18 @@ func pathKeys(b *backend) *framework.Path {
 				Type:        framework.TypeString,
 				Description: `A TOTP url string containing all of the parameters for key setup. Only used if generate is false.`,
 			},
+
+			"enroll": {
+				Type:        framework.TypeBool,
+				Default:     false,
+				Description: `Determines if the key is created pending enrollment. A pending key cannot generate or validate codes until a first code is confirmed at the enroll endpoint. Only used if generate is true.`,
+			},
+
+			"enrollment_ttl": {
+				Type:        framework.TypeDurationSecond,
+				Default:     600,
+				Description: `The length of time a pending key has to be confirmed. If this value is 0, pending keys do not expire. Only used if enroll is true.`,
+			},
 		},
 
 		Operations: map[logical.Operation]framework.OperationHandler{
@@ -194,6 +225,8 @@ func (b *backend) pathKeyRead(ctx context.Context, req *logical.Request, data *f
 			"period":       key.Period,
 			"algorithm":    algorithm,
 			"digits":       key.Digits,
+			"alphabet":     key.Alphabet,
+			"pending":      key.Pending,
 		},
 	}, nil
 }
@@ -221,6 +254,16 @@ func (b *backend) pathKeyCreate(ctx context.Context, req *logical.Request, data
 	qrSize := data.Get("qr_size").(int)
 	keySize := data.Get("key_size").(int)
 	inputURL := data.Get("url").(string)
+	enroll := data.Get("enroll").(bool)
+	enrollmentTTL := data.Get("enrollment_ttl").(int)
+	alphabet := data.Get("alphabet").(string)
+
+	if enroll && !generate {
+		return logical.ErrorResponse("enroll can only be used if generate is true"), nil
+	}
+	if enrollmentTTL < 0 {
+		return logical.ErrorResponse("the enrollment_ttl value must be greater than or equal to zero"), nil
+	}
 
 	if generate {
 		if keyString != "" {
@@ -289,14 +332,38 @@ func (b *backend) pathKeyCreate(ctx context.Context, req *logical.Request, data
 		if algorithmQuery != "" {
 			algorithm = algorithmQuery
 		}
+
+		// Read encoder, as set by authenticators supporting Steam Guard
+		if strings.EqualFold(urlQuery.Get("encoder"), "steam") {
+			alphabet = steamAlphabet
+			if digitsQuery == "" {
+				digits = steamDigits
+			}
+		}
 	}
 
 	// Translate digits and algorithm to a format the totp library understands
 	var keyDigits otplib.Digits
-	switch digits {
-	case 6:
+	switch {
+	case alphabet != "":
+		if err := validateAlphabet(alphabet); err != nil {
+			return logical.ErrorResponse(err.Error()), nil
+		}
+		if digits < 4 || digits > 10 {
+			return logical.ErrorResponse("the digits value must be between 4 and 10 if an alphabet is set"), nil
+		}
+		if codeSpace(alphabet, digits) < minCodeSpace {
+			return logical.ErrorResponse("the alphabet and digits values must allow at least %d distinct codes", minCodeSpace), nil
+		}
+		// Authenticators only learn of the Steam Guard alphabet from the url,
+		// and would generate decimal codes for any other
+		if generate && exported && alphabet != steamAlphabet {
+			return logical.ErrorResponse("keys with an alphabet other than the Steam Guard one cannot be exported"), nil
+		}
+		keyDigits = otplib.Digits(digits)
+	case digits == 6:
 		keyDigits = otplib.DigitsSix
-	case 8:
+	case digits == 8:
 		keyDigits = otplib.DigitsEight
 	default:
 		return logical.ErrorResponse("the digits value can only be 6 or 8"), nil
@@ -370,6 +437,14 @@ func (b *backend) pathKeyCreate(ctx context.Context, req *logical.Request, data
 		// Get key string value
 		keyString = keyObject.Secret()
 
+		// Let authenticators supporting Steam Guard pick up its alphabet
+		if alphabet == steamAlphabet {
+			keyObject, err = otplib.NewKeyFromURL(keyObject.String() + "&encoder=steam")
+			if err != nil {
+				return nil, fmt.Errorf("failed to set key encoder: %w", err)
+			}
+		}
+
 		// Skip returning the QR code and url if exported is set to false
 		if exported {
 			// Prepare the url and barcode
@@ -415,8 +490,7 @@ func (b *backend) pathKeyCreate(ctx context.Context, req *logical.Request, data
 		}
 	}
 
-	// Store it
-	entry, err := logical.StorageEntryJSON("key/"+name, &keyEntry{
+	key := &keyEntry{
 		Key:         keyString,
 		Issuer:      issuer,
 		AccountName: accountName,
@@ -424,7 +498,18 @@ func (b *backend) pathKeyCreate(ctx context.Context, req *logical.Request, data
 		Algorithm:   keyAlgorithm,
 		Digits:      keyDigits,
 		Skew:        uintSkew,
-	})
+		Alphabet:    alphabet,
+	}
+	if enroll {
+		key.Pending = true
+		key.Exported = exported
+		if enrollmentTTL > 0 {
+			key.EnrollmentExpiration = time.Now().Add(time.Duration(enrollmentTTL) * time.Second)
+		}
+	}
+
+	// Store it
+	entry, err := logical.StorageEntryJSON("key/"+name, key)
 	if err != nil {
 		return nil, err
 	}
@@ -443,6 +528,52 @@ type keyEntry struct {
 	Algorithm   otplib.Algorithm `json:"algorithm" mapstructure:"algorithm" structs:"algorithm"`
 	Digits      otplib.Digits    `json:"digits" mapstructure:"digits" structs:"digits"`
 	Skew        uint             `json:"skew" mapstructure:"skew" structs:"skew"`
+
+	// Alphabet holds the characters encoding the codes of the key, which
+	// are made of decimal digits if empty.
+	Alphabet string `json:"alphabet,omitempty" mapstructure:"alphabet" structs:"alphabet"`
+
+	// Pending is set on keys generated with enroll until a first code is
+	// confirmed. Exported records whether the QR code and url of a pending
+	// key may be read back while the enrollment is in progress.
+	Pending              bool      `json:"pending,omitempty" mapstructure:"pending" structs:"pending"`
+	Exported             bool      `json:"exported,omitempty" mapstructure:"exported" structs:"exported"`
+	EnrollmentExpiration time.Time `json:"enrollment_expiration,omitempty" mapstructure:"enrollment_expiration" structs:"enrollment_expiration"`
+}
+
+// validateAlphabet checks that the alphabet is made of at least two distinct
+// printable ASCII characters, so that codes can be typed in and compared.
+func validateAlphabet(alphabet string) error {
+	if len(alphabet) < 2 {
+		return errors.New("the alphabet value must have at least 2 characters")
+	}
+	seen := make(map[rune]struct{}, len(alphabet))
+	for _, c := range alphabet {
+		if c <= ' ' || c > '~' {
+			return fmt.Errorf("the alphabet value can only contain printable ASCII characters, found %q", c)
+		}
+		if _, ok := seen[c]; ok {
+			return fmt.Errorf("the alphabet value contains %q more than once", c)
+		}
+		seen[c] = struct{}{}
+	}
+	return nil
+}
+
+// codeSpace returns the number of distinct codes of the given number of
+// digits made of the alphabet, capped past minCodeSpace.
+func codeSpace(alphabet string, digits int) int {
+	space := 1
+	for i := 0; i < digits && space < minCodeSpace; i++ {
+		space *= len(alphabet)
+	}
+	return space
+}
+
+// enrollmentExpired reports whether the key is pending and past the deadline
+// to confirm its enrollment.
+func (k *keyEntry) enrollmentExpired(now time.Time) bool {
+	return k.Pending && !k.EnrollmentExpiration.IsZero() && now.After(k.EnrollmentExpiration)
 }
 
 const pathKeyHelpSyn = `
@@ -48,6 +48,7 @@ func Backend(ctx context.Context, conf *logical.BackendConfig) (*backend, error)
 			SealWrapStorage: []string{
 				"archive/",
 				"policy/",
+				kmsStoragePrefix,
 			},
 		},
 
@@ -61,12 +62,18 @@ func Backend(ctx context.Context, conf *logical.BackendConfig) (*backend, error)
 			b.pathImportVersion(),
 			b.pathKeys(),
 			b.pathListKeys(),
+			b.pathKMS(),
+			b.pathListKMS(),
 			b.pathBYOKExportKeys(),
 			b.pathExportKeys(),
+			b.pathExportSplitKeys(),
 			b.pathKeysConfig(),
 			b.pathEncrypt(),
 			b.pathDecrypt(),
 			b.pathDatakey(),
+			b.pathDerive(),
+			b.pathEncode(),
+			b.pathDecode(),
 			b.pathRandom(),
 			b.pathHash(),
 			b.pathHMAC(),
@@ -129,6 +136,7 @@ type backend struct {
 	checkAutoRotateAfter time.Time
 	autoRotateOnce       sync.Once
 	backendUUID          string
+	kmsKeys              kmsKeyCache
 }
 
 func GetCacheSizeFromStorage(ctx context.Context, s logical.Storage) (int, error) {
@@ -199,6 +207,8 @@ func (b *backend) invalidate(ctx context.Context, key string) {
 		b.configMutex.Lock()
 		defer b.configMutex.Unlock()
 		b.cacheSizeChanged = true
+	case strings.HasPrefix(key, kmsStoragePrefix):
+		b.kmsKeys.invalidate()
 	}
 
 	b.invalidateEnt(ctx, key)
@@ -0,0 +1,350 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package transit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	wrapping "github.com/hashicorp/go-kms-wrapping/v2"
+	"github.com/hashicorp/vault/internalshared/configutil"
+	"github.com/hashicorp/vault/sdk/helper/keysutil"
+	"github.com/hashicorp/vault/sdk/logical"
+	"google.golang.org/protobuf/proto"
+)
+
+const kmsStoragePrefix = "kms/"
+
+var errKMSEncryptionOnly = errors.New("keys backed by a cloud KMS only support encryption and decryption")
+
+// kmsWrapperFactories create the wrappers proxying the operations on keys
+// backed by a cloud KMS to the provider, keyed by provider.
+var kmsWrapperFactories = map[string]func(config map[string]string) (wrapping.Wrapper, error){
+	wrapping.WrapperTypeAwsKms.String(): func(config map[string]string) (wrapping.Wrapper, error) {
+		w, _, err := configutil.GetAWSKMSFunc(&configutil.KMS{Type: wrapping.WrapperTypeAwsKms.String(), Config: config})
+		return w, err
+	},
+	wrapping.WrapperTypeGcpCkms.String(): func(config map[string]string) (wrapping.Wrapper, error) {
+		w, _, err := configutil.GetGCPCKMSKMSFunc(&configutil.KMS{Type: wrapping.WrapperTypeGcpCkms.String(), Config: config})
+		return w, err
+	},
+	wrapping.WrapperTypeAzureKeyVault.String(): func(config map[string]string) (wrapping.Wrapper, error) {
+		w, _, err := configutil.GetAzureKeyVaultKMSFunc(&configutil.KMS{Type: wrapping.WrapperTypeAzureKeyVault.String(), Config: config})
+		return w, err
+	},
+}
+
+// kmsConfig describes a key held by a cloud KMS which transit keys of type
+// managed_key can be backed by.
+type kmsConfig struct {
+	Name     string            `json:"name"`
+	UUID     string            `json:"uuid"`
+	Provider string            `json:"provider"`
+	Config   map[string]string `json:"config"`
+}
+
+func getKMSConfig(ctx context.Context, s logical.Storage, name string) (*kmsConfig, error) {
+	entry, err := s.Get(ctx, kmsStoragePrefix+name)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var config kmsConfig
+	if err := entry.DecodeJSON(&config); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+func getKMSConfigByUUID(ctx context.Context, s logical.Storage, uuid string) (*kmsConfig, error) {
+	names, err := s.List(ctx, kmsStoragePrefix)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		config, err := getKMSConfig(ctx, s, name)
+		if err != nil {
+			return nil, err
+		}
+		if config != nil && config.UUID == uuid {
+			return config, nil
+		}
+	}
+	return nil, nil
+}
+
+// kmsKeyCache holds the cloud KMS keys in use, keyed by the UUID of their
+// configuration, so that their wrappers are only configured once.
+type kmsKeyCache struct {
+	l    sync.RWMutex
+	keys map[string]*kmsManagedKey
+}
+
+func (c *kmsKeyCache) get(uuid string) *kmsManagedKey {
+	c.l.RLock()
+	defer c.l.RUnlock()
+	return c.keys[uuid]
+}
+
+func (c *kmsKeyCache) load(config *kmsConfig) (*kmsManagedKey, error) {
+	c.l.Lock()
+	defer c.l.Unlock()
+
+	if key, ok := c.keys[config.UUID]; ok {
+		return key, nil
+	}
+
+	factory, ok := kmsWrapperFactories[config.Provider]
+	if !ok {
+		return nil, fmt.Errorf("unsupported KMS provider %q", config.Provider)
+	}
+	w, err := factory(config.Config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure KMS %q: %w", config.Name, err)
+	}
+
+	key := &kmsManagedKey{config: config, wrapper: w}
+	if c.keys == nil {
+		c.keys = make(map[string]*kmsManagedKey)
+	}
+	c.keys[config.UUID] = key
+	return key, nil
+}
+
+func (c *kmsKeyCache) invalidate() {
+	c.l.Lock()
+	defer c.l.Unlock()
+	c.keys = nil
+}
+
+// kmsManagedKey is a managed key whose operations are proxied to a cloud KMS.
+type kmsManagedKey struct {
+	config  *kmsConfig
+	wrapper wrapping.Wrapper
+}
+
+var _ logical.ManagedEncryptingKey = (*kmsManagedKey)(nil)
+
+func (k *kmsManagedKey) Name() string {
+	return k.config.Name
+}
+
+func (k *kmsManagedKey) UUID() string {
+	return k.config.UUID
+}
+
+func (k *kmsManagedKey) Present(context.Context) (bool, error) {
+	return true, nil
+}
+
+func (k *kmsManagedKey) AllowsAll(usages []logical.KeyUsage) bool {
+	for _, usage := range usages {
+		switch usage {
+		case logical.KeyUsageEncrypt, logical.KeyUsageDecrypt:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func (k *kmsManagedKey) Encrypt(ctx context.Context, plaintext []byte, options ...wrapping.Option) ([]byte, error) {
+	blob, err := k.wrapper.Encrypt(ctx, plaintext, options...)
+	if err != nil {
+		return nil, err
+	}
+	return proto.Marshal(blob)
+}
+
+func (k *kmsManagedKey) Decrypt(ctx context.Context, ciphertext []byte, options ...wrapping.Option) ([]byte, error) {
+	var blob wrapping.BlobInfo
+	if err := proto.Unmarshal(ciphertext, &blob); err != nil {
+		return nil, fmt.Errorf("failed to decode ciphertext: %w", err)
+	}
+	return k.wrapper.Decrypt(ctx, &blob, options...)
+}
+
+// kmsManagedKeySystemView resolves the managed keys of transit keys to the
+// cloud KMS keys configured on the mount, and defers to the managed keys of
+// the system view, if any, for the others.
+type kmsManagedKeySystemView struct {
+	b       *backend
+	storage logical.Storage
+	next    logical.ManagedKeySystemView
+}
+
+var _ logical.ManagedKeySystemView = (*kmsManagedKeySystemView)(nil)
+
+// managedKeySystemView returns the view used to access the managed keys of
+// transit keys of type managed_key.
+func (b *backend) managedKeySystemView(s logical.Storage) logical.ManagedKeySystemView {
+	next, _ := b.System().(logical.ManagedKeySystemView)
+	return &kmsManagedKeySystemView{
+		b:       b,
+		storage: s,
+		next:    next,
+	}
+}
+
+// managedKeyFactory returns the factory passing the managed key parameters
+// to the encryption and decryption of transit keys of type managed_key.
+func (b *backend) managedKeyFactory(ctx context.Context, s logical.Storage) ManagedKeyFactory {
+	return ManagedKeyFactory{
+		managedKeyParams: keysutil.ManagedKeyParameters{
+			ManagedKeySystemView: b.managedKeySystemView(s),
+			BackendUUID:          b.backendUUID,
+			Context:              ctx,
+		},
+	}
+}
+
+// managedKeyUUID returns the UUID of the managed key with the given name or
+// UUID, looking up the cloud KMS keys configured on the mount first.
+func (b *backend) managedKeyUUID(ctx context.Context, s logical.Storage, keyName string, keyId string) (string, error) {
+	var config *kmsConfig
+	var err error
+	switch {
+	case keyName != "":
+		config, err = getKMSConfig(ctx, s, keyName)
+	case keyId != "":
+		config, err = getKMSConfigByUUID(ctx, s, keyId)
+	}
+	if err != nil {
+		return "", err
+	}
+	if config != nil {
+		return config.UUID, nil
+	}
+
+	return GetManagedKeyUUID(ctx, b, keyName, keyId)
+}
+
+func (v *kmsManagedKeySystemView) kmsKeyByName(ctx context.Context, keyName string) (*kmsManagedKey, error) {
+	config, err := getKMSConfig(ctx, v.storage, keyName)
+	if err != nil || config == nil {
+		return nil, err
+	}
+	return v.b.kmsKeys.load(config)
+}
+
+func (v *kmsManagedKeySystemView) kmsKeyByUUID(ctx context.Context, keyUuid string) (*kmsManagedKey, error) {
+	if key := v.b.kmsKeys.get(keyUuid); key != nil {
+		return key, nil
+	}
+
+	config, err := getKMSConfigByUUID(ctx, v.storage, keyUuid)
+	if err != nil || config == nil {
+		return nil, err
+	}
+	return v.b.kmsKeys.load(config)
+}
+
+func (v *kmsManagedKeySystemView) WithManagedKeyByName(ctx context.Context, keyName, backendUUID string, f logical.ManagedKeyConsumer) error {
+	key, err := v.kmsKeyByName(ctx, keyName)
+	switch {
+	case err != nil:
+		return err
+	case key != nil:
+		return f(ctx, key)
+	case v.next == nil:
+		return errEntOnly
+	}
+	return v.next.WithManagedKeyByName(ctx, keyName, backendUUID, f)
+}
+
+func (v *kmsManagedKeySystemView) WithManagedKeyByUUID(ctx context.Context, keyUuid, backendUUID string, f logical.ManagedKeyConsumer) error {
+	key, err := v.kmsKeyByUUID(ctx, keyUuid)
+	switch {
+	case err != nil:
+		return err
+	case key != nil:
+		return f(ctx, key)
+	case v.next == nil:
+		return errEntOnly
+	}
+	return v.next.WithManagedKeyByUUID(ctx, keyUuid, backendUUID, f)
+}
+
+func (v *kmsManagedKeySystemView) WithManagedSigningKeyByName(ctx context.Context, keyName, backendUUID string, f logical.ManagedSigningKeyConsumer) error {
+	key, err := v.kmsKeyByName(ctx, keyName)
+	switch {
+	case err != nil:
+		return err
+	case key != nil:
+		return errKMSEncryptionOnly
+	case v.next == nil:
+		return errEntOnly
+	}
+	return v.next.WithManagedSigningKeyByName(ctx, keyName, backendUUID, f)
+}
+
+func (v *kmsManagedKeySystemView) WithManagedSigningKeyByUUID(ctx context.Context, keyUuid, backendUUID string, f logical.ManagedSigningKeyConsumer) error {
+	key, err := v.kmsKeyByUUID(ctx, keyUuid)
+	switch {
+	case err != nil:
+		return err
+	case key != nil:
+		return errKMSEncryptionOnly
+	case v.next == nil:
+		return errEntOnly
+	}
+	return v.next.WithManagedSigningKeyByUUID(ctx, keyUuid, backendUUID, f)
+}
+
+func (v *kmsManagedKeySystemView) WithManagedEncryptingKeyByName(ctx context.Context, keyName, backendUUID string, f logical.ManagedEncryptingKeyConsumer) error {
+	key, err := v.kmsKeyByName(ctx, keyName)
+	switch {
+	case err != nil:
+		return err
+	case key != nil:
+		return f(ctx, key)
+	case v.next == nil:
+		return errEntOnly
+	}
+	return v.next.WithManagedEncryptingKeyByName(ctx, keyName, backendUUID, f)
+}
+
+func (v *kmsManagedKeySystemView) WithManagedEncryptingKeyByUUID(ctx context.Context, keyUuid, backendUUID string, f logical.ManagedEncryptingKeyConsumer) error {
+	key, err := v.kmsKeyByUUID(ctx, keyUuid)
+	switch {
+	case err != nil:
+		return err
+	case key != nil:
+		return f(ctx, key)
+	case v.next == nil:
+		return errEntOnly
+	}
+	return v.next.WithManagedEncryptingKeyByUUID(ctx, keyUuid, backendUUID, f)
+}
+
+func (v *kmsManagedKeySystemView) WithManagedMACKeyByName(ctx context.Context, keyName, backendUUID string, f logical.ManagedMACKeyConsumer) error {
+	key, err := v.kmsKeyByName(ctx, keyName)
+	switch {
+	case err != nil:
+		return err
+	case key != nil:
+		return errKMSEncryptionOnly
+	case v.next == nil:
+		return errEntOnly
+	}
+	return v.next.WithManagedMACKeyByName(ctx, keyName, backendUUID, f)
+}
+
+func (v *kmsManagedKeySystemView) WithManagedMACKeyByUUID(ctx context.Context, keyUUID, backendUUID string, f logical.ManagedMACKeyConsumer) error {
+	key, err := v.kmsKeyByUUID(ctx, keyUUID)
+	switch {
+	case err != nil:
+		return err
+	case key != nil:
+		return errKMSEncryptionOnly
+	case v.next == nil:
+		return errEntOnly
+	}
+	return v.next.WithManagedMACKeyByUUID(ctx, keyUUID, backendUUID, f)
+}
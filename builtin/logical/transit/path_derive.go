@@ -0,0 +1,294 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package transit
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/errutil"
+	"github.com/hashicorp/vault/sdk/helper/keysutil"
+	"github.com/hashicorp/vault/sdk/helper/pointerutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	defaultDerivePBKDF2Iterations = 100000
+	minDerivePBKDF2Iterations     = 10000
+	maxDerivePBKDF2Iterations     = 1000000
+)
+
+func (b *backend) pathDerive() *framework.Path {
+	return &framework.Path{
+		Pattern: "derive/" + framework.GenericNameRegex("name"),
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixTransit,
+			OperationVerb:   "derive",
+			OperationSuffix: "key",
+		},
+
+		Fields: map[string]*framework.FieldSchema{
+			"name": {
+				Type:        framework.TypeString,
+				Description: "The key to derive from",
+			},
+
+			"algorithm": {
+				Type:    framework.TypeString,
+				Default: keysutil.KeyDerivation_HKDF_SHA256,
+				Description: `Derivation algorithm to use; one of hkdf-sha256,
+hkdf-sha512 or pbkdf2-sha256. Defaults to hkdf-sha256.`,
+			},
+
+			"info": {
+				Type: framework.TypeString,
+				Description: `Info identifying the derived key, e.g. its purpose
+or position in a key hierarchy. Must start with one of the
+allowed_derivation_info prefixes of the key, if any.`,
+			},
+
+			"salt": {
+				Type: framework.TypeString,
+				Description: `Base64 encoded salt. Optional for HKDF, required
+for PBKDF2.`,
+			},
+
+			"iterations": {
+				Type:    framework.TypeInt,
+				Default: defaultDerivePBKDF2Iterations,
+				Minimum: pointerutil.IntPtr(minDerivePBKDF2Iterations),
+				Maximum: pointerutil.IntPtr(maxDerivePBKDF2Iterations),
+				Description: `Number of PBKDF2 iterations, between 10000 and
+1000000. Defaults to 100000.`,
+			},
+
+			"bits": {
+				Type:          framework.TypeInt,
+				Default:       256,
+				AllowedValues: []interface{}{128, 256, 512},
+				Description: `Number of bits of the derived key; currently 128,
+256 and 512 bits are supported. Defaults to 256.`,
+			},
+
+			"key_version": {
+				Type: framework.TypeInt,
+				Description: `The version of the key to derive from. Must be 0
+(for latest) or a value greater than or equal to the
+min_encryption_version configured on the key.`,
+			},
+
+			"plaintext": {
+				Type: framework.TypeString,
+				Description: `Base64 encoded plaintext to encrypt with the derived
+key, instead of returning it.`,
+			},
+
+			"ciphertext": {
+				Type: framework.TypeString,
+				Description: `Ciphertext to decrypt with the derived key, as
+returned when encrypting with this endpoint.`,
+			},
+		},
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.UpdateOperation: &framework.PathOperation{
+				Callback: b.pathDeriveWrite,
+			},
+		},
+
+		HelpSynopsis:    pathDeriveHelpSyn,
+		HelpDescription: pathDeriveHelpDesc,
+	}
+}
+
+func (b *backend) pathDeriveWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+	ver := d.Get("key_version").(int)
+	algorithm := d.Get("algorithm").(string)
+	info := d.Get("info").(string)
+	plaintextRaw := d.Get("plaintext").(string)
+	ciphertext := d.Get("ciphertext").(string)
+
+	if plaintextRaw != "" && ciphertext != "" {
+		return logical.ErrorResponse("only one of plaintext or ciphertext may be provided"), logical.ErrInvalidRequest
+	}
+
+	var salt []byte
+	if saltRaw := d.Get("salt").(string); saltRaw != "" {
+		var err error
+		salt, err = base64.StdEncoding.DecodeString(saltRaw)
+		if err != nil {
+			return logical.ErrorResponse("failed to base64-decode salt"), logical.ErrInvalidRequest
+		}
+	}
+
+	iterations := d.Get("iterations").(int)
+	if algorithm == keysutil.KeyDerivation_PBKDF2_SHA256 &&
+		(iterations < minDerivePBKDF2Iterations || iterations > maxDerivePBKDF2Iterations) {
+		return logical.ErrorResponse(fmt.Sprintf("iterations must be between %d and %d", minDerivePBKDF2Iterations, maxDerivePBKDF2Iterations)), logical.ErrInvalidRequest
+	}
+
+	bits := d.Get("bits").(int)
+	switch bits {
+	case 128, 256:
+	case 512:
+		if plaintextRaw != "" || ciphertext != "" {
+			return logical.ErrorResponse("512 bit keys cannot be used for encryption"), logical.ErrInvalidRequest
+		}
+	default:
+		return logical.ErrorResponse("invalid bit length"), logical.ErrInvalidRequest
+	}
+
+	// The key version of a ciphertext takes precedence over key_version
+	var sealed []byte
+	if ciphertext != "" {
+		if !strings.HasPrefix(ciphertext, "vault:v") {
+			return logical.ErrorResponse("invalid ciphertext: no prefix"), logical.ErrInvalidRequest
+		}
+		splitVerCiphertext := strings.SplitN(strings.TrimPrefix(ciphertext, "vault:v"), ":", 2)
+		if len(splitVerCiphertext) != 2 {
+			return logical.ErrorResponse("invalid ciphertext: wrong number of fields"), logical.ErrInvalidRequest
+		}
+		var err error
+		ver, err = strconv.Atoi(splitVerCiphertext[0])
+		if err != nil {
+			return logical.ErrorResponse("invalid ciphertext: version number could not be decoded"), logical.ErrInvalidRequest
+		}
+		sealed, err = base64.StdEncoding.DecodeString(splitVerCiphertext[1])
+		if err != nil {
+			return logical.ErrorResponse("invalid ciphertext: could not decode base64"), logical.ErrInvalidRequest
+		}
+	}
+
+	// Get the policy
+	p, _, err := b.GetPolicy(ctx, keysutil.PolicyRequest{
+		Storage: req.Storage,
+		Name:    name,
+	}, b.GetRandomReader())
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return logical.ErrorResponse("key not found"), logical.ErrInvalidRequest
+	}
+	if !b.System().CachingDisabled() {
+		p.Lock(false)
+	}
+	defer p.Unlock()
+
+	if !p.AllowDerivation {
+		return logical.ErrorResponse("derivation is not allowed for key %q", name), logical.ErrInvalidRequest
+	}
+
+	if len(p.AllowedDerivationInfo) != 0 {
+		allowed := false
+		for _, prefix := range p.AllowedDerivationInfo {
+			if strings.HasPrefix(info, prefix) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return logical.ErrorResponse("info %q is not allowed for key %q", info, name), logical.ErrInvalidRequest
+		}
+	}
+
+	if plaintextRaw == "" && ciphertext == "" && !p.AllowDerivedKeyExport {
+		return logical.ErrorResponse("export of derived keys is not allowed for key %q", name), logical.ErrInvalidRequest
+	}
+
+	switch {
+	case ciphertext != "":
+		if ver < p.MinDecryptionVersion {
+			return logical.ErrorResponse("ciphertext or signature version is disallowed by policy (too old)"), logical.ErrInvalidRequest
+		}
+	case ver == 0:
+		ver = p.LatestVersion
+	case ver < p.MinEncryptionVersion:
+		return logical.ErrorResponse("requested version for derivation is less than the minimum encryption key version"), logical.ErrInvalidRequest
+	}
+
+	derivedKey, err := p.DeriveExternalKey(algorithm, []byte(info), salt, iterations, ver, bits/8)
+	if err != nil {
+		switch err.(type) {
+		case errutil.UserError:
+			return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+		default:
+			return nil, err
+		}
+	}
+
+	resp := &logical.Response{
+		Data: map[string]interface{}{
+			"algorithm":   algorithm,
+			"key_version": ver,
+		},
+	}
+
+	switch {
+	case plaintextRaw != "":
+		plaintext, err := base64.StdEncoding.DecodeString(plaintextRaw)
+		if err != nil {
+			return logical.ErrorResponse("failed to base64-decode plaintext"), logical.ErrInvalidRequest
+		}
+		gcm, err := derivedKeyGCM(derivedKey)
+		if err != nil {
+			return nil, err
+		}
+		nonce := make([]byte, gcm.NonceSize())
+		if _, err := b.GetRandomReader().Read(nonce); err != nil {
+			return nil, err
+		}
+		sealed := gcm.Seal(nonce, nonce, plaintext, nil)
+		resp.Data["ciphertext"] = fmt.Sprintf("vault:v%d:%s", ver, base64.StdEncoding.EncodeToString(sealed))
+
+	case ciphertext != "":
+		gcm, err := derivedKeyGCM(derivedKey)
+		if err != nil {
+			return nil, err
+		}
+		if len(sealed) < gcm.NonceSize() {
+			return logical.ErrorResponse("invalid ciphertext length"), logical.ErrInvalidRequest
+		}
+		plaintext, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+		if err != nil {
+			return logical.ErrorResponse("cipher: message authentication failed"), logical.ErrInvalidRequest
+		}
+		resp.Data["plaintext"] = base64.StdEncoding.EncodeToString(plaintext)
+
+	default:
+		resp.Data["derived_key"] = base64.StdEncoding.EncodeToString(derivedKey)
+	}
+
+	return resp, nil
+}
+
+func derivedKeyGCM(key []byte) (cipher.AEAD, error) {
+	aesCipher, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(aesCipher)
+}
+
+const pathDeriveHelpSyn = `Derive a key from a named key`
+
+const pathDeriveHelpDesc = `
+This path derives a key from the named key with HKDF or PBKDF2, for
+use in hierarchical key schemes without exporting the named key. The
+derived key is identified by the info, which must start with one of
+the allowed_derivation_info prefixes of the key, if any.
+
+If plaintext or ciphertext is given, the derived key is used to
+encrypt or decrypt it with AES-GCM and is not returned. Otherwise the
+derived key is returned, which requires allow_derived_key_export to
+be set on the key.
+`
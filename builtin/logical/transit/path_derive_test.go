@@ -0,0 +1,160 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package transit
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"io"
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/hkdf"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+func TestTransit_Derive(t *testing.T) {
+	ctx := context.Background()
+	b, s := createBackendWithStorage(t)
+
+	handle := func(op logical.Operation, path string, data map[string]interface{}) (*logical.Response, error) {
+		t.Helper()
+		return b.HandleRequest(ctx, &logical.Request{
+			Path:      path,
+			Operation: op,
+			Storage:   s,
+			Data:      data,
+		})
+	}
+	derive := func(data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := handle(logical.UpdateOperation, "derive/foo", data)
+		require.NoError(t, err)
+		require.False(t, resp.IsError(), "resp: %#v", resp)
+		return resp
+	}
+	deriveErr := func(data map[string]interface{}) {
+		t.Helper()
+		resp, err := handle(logical.UpdateOperation, "derive/foo", data)
+		require.Error(t, err)
+		require.True(t, resp.IsError())
+	}
+
+	_, err := handle(logical.UpdateOperation, "keys/foo", map[string]interface{}{
+		"exportable": true,
+	})
+	require.NoError(t, err)
+	resp, err := handle(logical.ReadOperation, "export/encryption-key/foo/1", nil)
+	require.NoError(t, err)
+	rootKey, err := base64.StdEncoding.DecodeString(resp.Data["keys"].(map[string]string)["1"])
+	require.NoError(t, err)
+
+	// Derivation must be enabled on the key
+	deriveErr(map[string]interface{}{"info": "tenant/a"})
+
+	resp, err = handle(logical.UpdateOperation, "keys/foo/config", map[string]interface{}{
+		"allow_derivation":        true,
+		"allowed_derivation_info": "tenant/,service/",
+	})
+	require.NoError(t, err)
+	require.Equal(t, true, resp.Data["allow_derivation"])
+	require.Equal(t, false, resp.Data["allow_derived_key_export"])
+	require.Equal(t, []string{"tenant/", "service/"}, resp.Data["allowed_derivation_info"])
+
+	// Derived keys are used internally until their export is allowed
+	deriveErr(map[string]interface{}{"info": "tenant/a"})
+	resp = derive(map[string]interface{}{
+		"info":      "tenant/a",
+		"plaintext": base64.StdEncoding.EncodeToString([]byte("hello")),
+	})
+	ciphertext := resp.Data["ciphertext"].(string)
+	require.Regexp(t, "^vault:v1:", ciphertext)
+
+	resp = derive(map[string]interface{}{
+		"info":       "tenant/a",
+		"ciphertext": ciphertext,
+	})
+	require.Equal(t, base64.StdEncoding.EncodeToString([]byte("hello")), resp.Data["plaintext"])
+
+	// Another info derives another key
+	deriveErr(map[string]interface{}{
+		"info":       "tenant/b",
+		"ciphertext": ciphertext,
+	})
+	deriveErr(map[string]interface{}{
+		"info":      "other/a",
+		"plaintext": base64.StdEncoding.EncodeToString([]byte("hello")),
+	})
+
+	_, err = handle(logical.UpdateOperation, "keys/foo/config", map[string]interface{}{
+		"allow_derived_key_export": true,
+	})
+	require.NoError(t, err)
+
+	resp = derive(map[string]interface{}{"info": "tenant/a"})
+	expected := make([]byte, 32)
+	_, err = io.ReadFull(hkdf.Expand(sha256.New, rootKey, []byte("tenant/a")), expected)
+	require.NoError(t, err)
+	require.Equal(t, base64.StdEncoding.EncodeToString(expected), resp.Data["derived_key"])
+	require.Equal(t, 1, resp.Data["key_version"])
+
+	salt := []byte("0123456789abcdef")
+	resp = derive(map[string]interface{}{
+		"info":      "service/api",
+		"algorithm": "hkdf-sha512",
+		"salt":      base64.StdEncoding.EncodeToString(salt),
+		"bits":      512,
+	})
+	expected = make([]byte, 64)
+	_, err = io.ReadFull(hkdf.New(sha512.New, rootKey, salt, []byte("service/api")), expected)
+	require.NoError(t, err)
+	require.Equal(t, base64.StdEncoding.EncodeToString(expected), resp.Data["derived_key"])
+
+	resp = derive(map[string]interface{}{
+		"info":       "service/api",
+		"algorithm":  "pbkdf2-sha256",
+		"salt":       base64.StdEncoding.EncodeToString(salt),
+		"iterations": 10000,
+		"bits":       128,
+	})
+	expected = pbkdf2.Key(rootKey, append([]byte("service/api"), salt...), 10000, 16, sha256.New)
+	require.Equal(t, base64.StdEncoding.EncodeToString(expected), resp.Data["derived_key"])
+
+	for name, data := range map[string]map[string]interface{}{
+		"unknown algorithm":   {"info": "tenant/a", "algorithm": "scrypt"},
+		"pbkdf2 without salt": {"info": "tenant/a", "algorithm": "pbkdf2-sha256"},
+		"too few iterations":  {"info": "tenant/a", "algorithm": "pbkdf2-sha256", "salt": "c2FsdA==", "iterations": 1000},
+		"invalid bits":        {"info": "tenant/a", "bits": 64},
+		"encrypt with 512":    {"info": "tenant/a", "bits": 512, "plaintext": "aGVsbG8="},
+		"unknown version":     {"info": "tenant/a", "key_version": 2},
+	} {
+		t.Run(name, func(t *testing.T) {
+			deriveErr(data)
+		})
+	}
+
+	// Derived and asymmetric keys cannot allow derivation
+	_, err = handle(logical.UpdateOperation, "keys/derived", map[string]interface{}{
+		"derived": true,
+	})
+	require.NoError(t, err)
+	resp, err = handle(logical.UpdateOperation, "keys/derived/config", map[string]interface{}{
+		"allow_derivation": true,
+	})
+	require.NoError(t, err)
+	require.True(t, resp.IsError())
+
+	_, err = handle(logical.UpdateOperation, "keys/signing", map[string]interface{}{
+		"type": "ecdsa-p256",
+	})
+	require.NoError(t, err)
+	resp, err = handle(logical.UpdateOperation, "keys/signing/config", map[string]interface{}{
+		"allow_derivation": true,
+	})
+	require.NoError(t, err)
+	require.True(t, resp.IsError())
+}
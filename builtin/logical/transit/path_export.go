@@ -70,6 +70,27 @@ func (b *backend) pathPolicyExportRead(ctx context.Context, req *logical.Request
 	name := d.Get("name").(string)
 	version := d.Get("version").(string)
 
+	p, retKeys, errResp, err := b.exportPolicyKeys(ctx, req, exportType, name, version)
+	if errResp != nil || err != nil || p == nil {
+		return errResp, err
+	}
+
+	resp := &logical.Response{
+		Data: map[string]interface{}{
+			"name": p.Name,
+			"type": p.Type.String(),
+			"keys": retKeys,
+		},
+	}
+
+	return resp, nil
+}
+
+// exportPolicyKeys returns the named policy along with its keys of the given
+// export type, keyed by version. If version is empty all versions are
+// returned. An error response is returned for invalid requests, and a nil
+// policy if the key does not exist.
+func (b *backend) exportPolicyKeys(ctx context.Context, req *logical.Request, exportType, name, version string) (*keysutil.Policy, map[string]string, *logical.Response, error) {
 	switch exportType {
 	case exportTypeEncryptionKey:
 	case exportTypeSigningKey:
@@ -78,10 +99,10 @@ func (b *backend) pathPolicyExportRead(ctx context.Context, req *logical.Request
 	case exportTypeCertificateChain:
 	case exportTypeCMACKey:
 		if !constants.IsEnterprise {
-			return logical.ErrorResponse(ErrCmacEntOnly.Error()), logical.ErrInvalidRequest
+			return nil, nil, logical.ErrorResponse(ErrCmacEntOnly.Error()), logical.ErrInvalidRequest
 		}
 	default:
-		return logical.ErrorResponse(fmt.Sprintf("invalid export type: %s", exportType)), logical.ErrInvalidRequest
+		return nil, nil, logical.ErrorResponse(fmt.Sprintf("invalid export type: %s", exportType)), logical.ErrInvalidRequest
 	}
 
 	p, _, err := b.GetPolicy(ctx, keysutil.PolicyRequest{
@@ -89,10 +110,10 @@ func (b *backend) pathPolicyExportRead(ctx context.Context, req *logical.Request
 		Name:    name,
 	}, b.GetRandomReader())
 	if err != nil {
-		return nil, err
+		return nil, nil, nil, err
 	}
 	if p == nil {
-		return nil, nil
+		return nil, nil, nil, nil
 	}
 	if !b.System().CachingDisabled() {
 		p.Lock(false)
@@ -100,21 +121,21 @@ func (b *backend) pathPolicyExportRead(ctx context.Context, req *logical.Request
 	defer p.Unlock()
 
 	if !p.Exportable && exportType != exportTypePublicKey && exportType != exportTypeCertificateChain {
-		return logical.ErrorResponse("private key material is not exportable"), nil
+		return nil, nil, logical.ErrorResponse("private key material is not exportable"), nil
 	}
 
 	switch exportType {
 	case exportTypeEncryptionKey:
 		if !p.Type.EncryptionSupported() {
-			return logical.ErrorResponse("encryption not supported for the key"), logical.ErrInvalidRequest
+			return nil, nil, logical.ErrorResponse("encryption not supported for the key"), logical.ErrInvalidRequest
 		}
 	case exportTypeSigningKey:
 		if !p.Type.SigningSupported() {
-			return logical.ErrorResponse("signing not supported for the key"), logical.ErrInvalidRequest
+			return nil, nil, logical.ErrorResponse("signing not supported for the key"), logical.ErrInvalidRequest
 		}
 	case exportTypeCertificateChain:
 		if !p.Type.SigningSupported() {
-			return logical.ErrorResponse("certificate chain not supported for keys that do not support signing"), logical.ErrInvalidRequest
+			return nil, nil, logical.ErrorResponse("certificate chain not supported for keys that do not support signing"), logical.ErrInvalidRequest
 		}
 	}
 
@@ -124,7 +145,7 @@ func (b *backend) pathPolicyExportRead(ctx context.Context, req *logical.Request
 		for k, v := range p.Keys {
 			exportKey, err := getExportKey(p, &v, exportType)
 			if err != nil {
-				return nil, err
+				return nil, nil, nil, err
 			}
 			retKeys[k] = exportKey
 		}
@@ -137,35 +158,27 @@ func (b *backend) pathPolicyExportRead(ctx context.Context, req *logical.Request
 			version = strings.TrimPrefix(version, "v")
 			versionValue, err = strconv.Atoi(version)
 			if err != nil {
-				return logical.ErrorResponse("invalid key version"), logical.ErrInvalidRequest
+				return nil, nil, logical.ErrorResponse("invalid key version"), logical.ErrInvalidRequest
 			}
 		}
 
 		if versionValue < p.MinDecryptionVersion {
-			return logical.ErrorResponse("version for export is below minimum decryption version"), logical.ErrInvalidRequest
+			return nil, nil, logical.ErrorResponse("version for export is below minimum decryption version"), logical.ErrInvalidRequest
 		}
 		key, ok := p.Keys[strconv.Itoa(versionValue)]
 		if !ok {
-			return logical.ErrorResponse("version does not exist or cannot be found"), logical.ErrInvalidRequest
+			return nil, nil, logical.ErrorResponse("version does not exist or cannot be found"), logical.ErrInvalidRequest
 		}
 
 		exportKey, err := getExportKey(p, &key, exportType)
 		if err != nil {
-			return nil, err
+			return nil, nil, nil, err
 		}
 
 		retKeys[strconv.Itoa(versionValue)] = exportKey
 	}
 
-	resp := &logical.Response{
-		Data: map[string]interface{}{
-			"name": p.Name,
-			"type": p.Type.String(),
-			"keys": retKeys,
-		},
-	}
-
-	return resp, nil
+	return p, retKeys, nil, nil
 }
 
 func getExportKey(policy *keysutil.Policy, key *keysutil.KeyEntry, exportType string) (string, error) {
@@ -0,0 +1,212 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package transit
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"hash"
+	"strings"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/keysutil"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/hashicorp/vault/shamir"
+)
+
+// minSplitExportRSAKeySize is the smallest RSA public key which key shares
+// may be encrypted to.
+const minSplitExportRSAKeySize = 2048
+
+func (b *backend) pathExportSplitKeys() *framework.Path {
+	return &framework.Path{
+		Pattern: "export-split/" + framework.GenericNameRegex("type") + "/" + framework.GenericNameRegex("name") + framework.OptionalParamRegex("version"),
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixTransit,
+			OperationVerb:   "export-split",
+			OperationSuffix: "key|key-version",
+		},
+
+		Fields: map[string]*framework.FieldSchema{
+			"type": {
+				Type:        framework.TypeString,
+				Description: "Type of key to export (encryption-key, signing-key, hmac-key, cmac-key)",
+			},
+			"name": {
+				Type:        framework.TypeString,
+				Description: "Name of the key",
+			},
+			"version": {
+				Type:        framework.TypeString,
+				Description: "Version of the key",
+			},
+			"public_keys": {
+				Type: framework.TypeStringSlice,
+				Description: `PEM encoded RSA public keys of the share holders. The key
+is split into one share per public key, and each share is encrypted to the
+corresponding public key.`,
+			},
+			"threshold": {
+				Type:        framework.TypeInt,
+				Description: "Number of shares required to reconstruct the key.",
+			},
+			"hash": {
+				Type:        framework.TypeString,
+				Description: "Hash function to use for OAEP encryption of the shares. Defaults to SHA256.",
+				Default:     "SHA256",
+			},
+		},
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.UpdateOperation: &framework.PathOperation{
+				Callback: b.pathPolicyExportSplitWrite,
+			},
+		},
+
+		HelpSynopsis:    pathExportSplitHelpSyn,
+		HelpDescription: pathExportSplitHelpDesc,
+	}
+}
+
+func (b *backend) pathPolicyExportSplitWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	exportType := d.Get("type").(string)
+	name := d.Get("name").(string)
+	version := d.Get("version").(string)
+	threshold := d.Get("threshold").(int)
+
+	if exportType == exportTypePublicKey || exportType == exportTypeCertificateChain {
+		return logical.ErrorResponse("split export is only supported for private key material"), logical.ErrInvalidRequest
+	}
+
+	publicKeys, err := parseSplitExportPublicKeys(d.Get("public_keys").([]string))
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
+	switch {
+	case len(publicKeys) < 2:
+		return logical.ErrorResponse("at least two public keys are required"), logical.ErrInvalidRequest
+	case len(publicKeys) > 255:
+		return logical.ErrorResponse("at most 255 public keys may be provided"), logical.ErrInvalidRequest
+	case threshold < 2 || threshold > len(publicKeys):
+		return logical.ErrorResponse("threshold must be at least 2 and at most the number of public keys"), logical.ErrInvalidRequest
+	}
+
+	hasher, err := parseHashFn(d.Get("hash").(string))
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
+	p, exportKeys, errResp, err := b.exportPolicyKeys(ctx, req, exportType, name, version)
+	if errResp != nil || err != nil || p == nil {
+		return errResp, err
+	}
+
+	retShares := make(map[string][]string, len(exportKeys))
+	for ver, exportKey := range exportKeys {
+		if exportKey == "" {
+			// The private key material of this version is not present,
+			// e.g. because only the public key was imported.
+			continue
+		}
+
+		shares, err := splitExportKey([]byte(exportKey), publicKeys, threshold, hasher)
+		if err != nil {
+			return nil, err
+		}
+		retShares[ver] = shares
+	}
+
+	resp := &logical.Response{
+		Data: map[string]interface{}{
+			"name":      p.Name,
+			"type":      p.Type.String(),
+			"threshold": threshold,
+			"shares":    retShares,
+		},
+	}
+
+	return resp, nil
+}
+
+// parseSplitExportPublicKeys parses the PEM encoded RSA public keys which
+// shares will be encrypted to, ensuring that no key is provided twice.
+func parseSplitExportPublicKeys(pemKeys []string) ([]*rsa.PublicKey, error) {
+	seen := make(map[string]struct{}, len(pemKeys))
+	keys := make([]*rsa.PublicKey, 0, len(pemKeys))
+
+	for i, pemKey := range pemKeys {
+		block, _ := pem.Decode([]byte(strings.TrimSpace(pemKey)))
+		if block == nil {
+			return nil, fmt.Errorf("public key %d is not PEM encoded", i)
+		}
+
+		parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse public key %d: %w", i, err)
+		}
+
+		rsaKey, ok := parsed.(*rsa.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("public key %d is not an RSA public key", i)
+		}
+		if rsaKey.N.BitLen() < minSplitExportRSAKeySize {
+			return nil, fmt.Errorf("public key %d must be at least %d bits", i, minSplitExportRSAKeySize)
+		}
+
+		if _, ok := seen[string(block.Bytes)]; ok {
+			return nil, fmt.Errorf("public key %d was provided more than once", i)
+		}
+		seen[string(block.Bytes)] = struct{}{}
+
+		keys = append(keys, rsaKey)
+	}
+
+	return keys, nil
+}
+
+// splitExportKey splits the exported key into one Shamir share per public key
+// and encrypts each share to its public key, returning the encrypted shares in
+// the same order as the public keys.
+func splitExportKey(exportKey []byte, publicKeys []*rsa.PublicKey, threshold int, hasher hash.Hash) ([]string, error) {
+	shares, err := shamir.Split(exportKey, len(publicKeys), threshold)
+	if err != nil {
+		return nil, fmt.Errorf("failed to split key: %w", err)
+	}
+	if len(shares) != len(publicKeys) {
+		return nil, errors.New("unexpected number of key shares")
+	}
+
+	encrypted := make([]string, 0, len(shares))
+	for i, share := range shares {
+		// Shares are encrypted using the same CKM_RSA_AES_KEY_WRAP scheme
+		// used for BYOK export, treating each share as opaque key material.
+		wrappingKey := &keysutil.KeyEntry{RSAPublicKey: publicKeys[i]}
+		wrapped, err := wrappingKey.WrapKey(share, keysutil.KeyType_HMAC, hasher)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt key share %d: %w", i, err)
+		}
+		encrypted = append(encrypted, wrapped)
+	}
+
+	return encrypted, nil
+}
+
+const pathExportSplitHelpSyn = `Export named key as encrypted Shamir shares`
+
+const pathExportSplitHelpDesc = `
+This path is used to export the named keys that are configured as
+exportable, without any single party receiving the whole key material.
+
+The exported key, in the same format returned by the /export path, is split
+into one Shamir share per provided RSA public key, of which threshold shares
+are required to reconstruct it. Each share is encrypted to its public key
+using the same CKM_RSA_AES_KEY_WRAP scheme used by /byok-export, and the
+shares are returned in the same order as the public keys.
+`
@@ -0,0 +1,184 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package transit
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/hashicorp/vault/shamir"
+	"github.com/stretchr/testify/require"
+	"github.com/tink-crypto/tink-go/v2/kwp/subtle"
+)
+
+func TestTransit_ExportSplit(t *testing.T) {
+	testExportSplit(t, "aes256-gcm96", exportTypeEncryptionKey)
+	testExportSplit(t, "ecdsa-p256", exportTypeSigningKey)
+	testExportSplit(t, "rsa-2048", exportTypeSigningKey)
+	testExportSplit(t, "hmac", exportTypeHMACKey)
+}
+
+func testExportSplit(t *testing.T, keyType, exportType string) {
+	t.Helper()
+
+	ctx := context.Background()
+	b, s := createBackendWithStorage(t)
+
+	keyReq := &logical.Request{
+		Path:      "keys/foo",
+		Operation: logical.UpdateOperation,
+		Storage:   s,
+		Data: map[string]interface{}{
+			"type":       keyType,
+			"exportable": true,
+		},
+	}
+	if keyType == "hmac" {
+		keyReq.Data["key_size"] = 32
+	}
+	resp, err := b.HandleRequest(ctx, keyReq)
+	require.NoError(t, err)
+	require.False(t, resp != nil && resp.IsError(), "resp: %#v", resp)
+
+	resp, err = b.HandleRequest(ctx, &logical.Request{
+		Path:      "export/" + exportType + "/foo/1",
+		Operation: logical.ReadOperation,
+		Storage:   s,
+	})
+	require.NoError(t, err)
+	expected := resp.Data["keys"].(map[string]string)["1"]
+
+	var privateKeys []*rsa.PrivateKey
+	var publicKeys []string
+	for i := 0; i < 3; i++ {
+		privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		require.NoError(t, err)
+		der, err := x509.MarshalPKIXPublicKey(privateKey.Public())
+		require.NoError(t, err)
+
+		privateKeys = append(privateKeys, privateKey)
+		publicKeys = append(publicKeys, string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})))
+	}
+
+	resp, err = b.HandleRequest(ctx, &logical.Request{
+		Path:      "export-split/" + exportType + "/foo/1",
+		Operation: logical.UpdateOperation,
+		Storage:   s,
+		Data: map[string]interface{}{
+			"public_keys": publicKeys,
+			"threshold":   2,
+		},
+	})
+	require.NoError(t, err)
+	require.False(t, resp.IsError(), "resp: %#v", resp)
+	require.Equal(t, 2, resp.Data["threshold"])
+
+	shares := resp.Data["shares"].(map[string][]string)["1"]
+	require.Len(t, shares, 3)
+
+	// Any two share holders can reconstruct the key, but not one alone.
+	first := unwrapExportSplitShare(t, privateKeys[0], shares[0])
+	last := unwrapExportSplitShare(t, privateKeys[2], shares[2])
+	require.NotEqual(t, expected, string(first))
+
+	combined, err := shamir.Combine([][]byte{first, last})
+	require.NoError(t, err)
+	require.Equal(t, expected, string(combined))
+}
+
+func unwrapExportSplitShare(t *testing.T, privateKey *rsa.PrivateKey, share string) []byte {
+	t.Helper()
+
+	blob, err := base64.StdEncoding.DecodeString(share)
+	require.NoError(t, err)
+
+	keySize := privateKey.PublicKey.Size()
+	ephKey, err := rsa.DecryptOAEP(sha256.New(), rand.Reader, privateKey, blob[:keySize], []byte{})
+	require.NoError(t, err)
+
+	kwp, err := subtle.NewKWP(ephKey)
+	require.NoError(t, err)
+	unwrapped, err := kwp.Unwrap(blob[keySize:])
+	require.NoError(t, err)
+
+	return unwrapped
+}
+
+func TestTransit_ExportSplit_InvalidRequests(t *testing.T) {
+	ctx := context.Background()
+	b, s := createBackendWithStorage(t)
+
+	for name, exportable := range map[string]bool{"exportable": true, "not-exportable": false} {
+		resp, err := b.HandleRequest(ctx, &logical.Request{
+			Path:      "keys/" + name,
+			Operation: logical.UpdateOperation,
+			Storage:   s,
+			Data:      map[string]interface{}{"exportable": exportable},
+		})
+		require.NoError(t, err)
+		require.False(t, resp != nil && resp.IsError())
+	}
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(privateKey.Public())
+	require.NoError(t, err)
+	publicKey := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	der, err = x509.MarshalPKIXPublicKey(otherKey.Public())
+	require.NoError(t, err)
+	otherPublicKey := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+
+	tests := map[string]struct {
+		path string
+		data map[string]interface{}
+	}{
+		"single-public-key": {
+			path: "export-split/encryption-key/exportable",
+			data: map[string]interface{}{"public_keys": []string{publicKey}, "threshold": 2},
+		},
+		"duplicate-public-key": {
+			path: "export-split/encryption-key/exportable",
+			data: map[string]interface{}{"public_keys": []string{publicKey, publicKey}, "threshold": 2},
+		},
+		"threshold-too-high": {
+			path: "export-split/encryption-key/exportable",
+			data: map[string]interface{}{"public_keys": []string{publicKey, otherPublicKey}, "threshold": 3},
+		},
+		"invalid-public-key": {
+			path: "export-split/encryption-key/exportable",
+			data: map[string]interface{}{"public_keys": []string{publicKey, "not a key"}, "threshold": 2},
+		},
+		"public-key-export": {
+			path: "export-split/public-key/exportable",
+			data: map[string]interface{}{"public_keys": []string{publicKey, otherPublicKey}, "threshold": 2},
+		},
+		"not-exportable": {
+			path: "export-split/encryption-key/not-exportable",
+			data: map[string]interface{}{"public_keys": []string{publicKey, otherPublicKey}, "threshold": 2},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			resp, _ := b.HandleRequest(ctx, &logical.Request{
+				Path:      tc.path,
+				Operation: logical.UpdateOperation,
+				Storage:   s,
+				Data:      tc.data,
+			})
+			require.NotNil(t, resp)
+			require.True(t, resp.IsError(), "resp: %#v", resp)
+		})
+	}
+}
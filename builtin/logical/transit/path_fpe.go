@@ -0,0 +1,234 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package transit
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/errutil"
+	"github.com/hashicorp/vault/sdk/helper/keysutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	fpeDigits       = "0123456789"
+	fpeAlphanumeric = fpeDigits + "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+)
+
+// fpeTemplate describes the values a format-preserving encryption template
+// accepts. Characters of a value outside of the template's alphabet, such as
+// separators, are left in place; the others are encrypted.
+type fpeTemplate struct {
+	alphabet string
+	pattern  *regexp.Regexp
+}
+
+var fpeTemplates = map[string]fpeTemplate{
+	"creditcard": {
+		alphabet: fpeDigits,
+		pattern:  regexp.MustCompile(`^[0-9](?:[ -]?[0-9]){12,18}$`),
+	},
+	"ssn": {
+		alphabet: fpeDigits,
+		pattern:  regexp.MustCompile(`^[0-9]{3}-?[0-9]{2}-?[0-9]{4}$`),
+	},
+	"numeric": {
+		alphabet: fpeDigits,
+		pattern:  regexp.MustCompile(`^[0-9]+$`),
+	},
+	"alphanumeric": {
+		alphabet: fpeAlphanumeric,
+		pattern:  regexp.MustCompile(`^[0-9A-Za-z]+$`),
+	},
+}
+
+func (b *backend) pathEncode() *framework.Path {
+	return b.pathFPE("encode", "The value to encode", b.pathEncodeWrite, pathEncodeHelpSyn, pathEncodeHelpDesc)
+}
+
+func (b *backend) pathDecode() *framework.Path {
+	return b.pathFPE("decode", "The encoded value to decode", b.pathDecodeWrite, pathDecodeHelpSyn, pathDecodeHelpDesc)
+}
+
+func (b *backend) pathFPE(verb, valueDescription string, callback framework.OperationFunc, helpSyn, helpDesc string) *framework.Path {
+	return &framework.Path{
+		Pattern: verb + "/" + framework.GenericNameRegex("name"),
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixTransit,
+			OperationVerb:   verb,
+			OperationSuffix: "value",
+		},
+
+		Fields: map[string]*framework.FieldSchema{
+			"name": {
+				Type:        framework.TypeString,
+				Description: "The format-preserving encryption key to use",
+			},
+
+			"value": {
+				Type:        framework.TypeString,
+				Description: valueDescription,
+			},
+
+			"template": {
+				Type:          framework.TypeString,
+				Default:       "alphanumeric",
+				AllowedValues: []interface{}{"creditcard", "ssn", "numeric", "alphanumeric"},
+				Description: `The format of the value; one of creditcard, ssn,
+numeric or alphanumeric. Defaults to alphanumeric.`,
+			},
+
+			"tweak": {
+				Type: framework.TypeString,
+				Description: `Base64 encoded 7 byte tweak. The same tweak must be
+used to decode as was used to encode. Defaults to all zeros.`,
+			},
+
+			"key_version": {
+				Type: framework.TypeInt,
+				Description: `The version of the key to use. The same version
+must be used to decode as was used to encode. Defaults to the latest
+version.`,
+			},
+		},
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.UpdateOperation: &framework.PathOperation{
+				Callback: callback,
+			},
+		},
+
+		HelpSynopsis:    helpSyn,
+		HelpDescription: helpDesc,
+	}
+}
+
+func (b *backend) pathEncodeWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	return b.fpeWrite(ctx, req, d, false)
+}
+
+func (b *backend) pathDecodeWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	return b.fpeWrite(ctx, req, d, true)
+}
+
+func (b *backend) fpeWrite(ctx context.Context, req *logical.Request, d *framework.FieldData, decode bool) (*logical.Response, error) {
+	name := d.Get("name").(string)
+	ver := d.Get("key_version").(int)
+	value := d.Get("value").(string)
+
+	templateName := d.Get("template").(string)
+	template, ok := fpeTemplates[templateName]
+	if !ok {
+		return logical.ErrorResponse(fmt.Sprintf("unknown template %q", templateName)), logical.ErrInvalidRequest
+	}
+	if !template.pattern.MatchString(value) {
+		return logical.ErrorResponse(fmt.Sprintf("value does not match the %s template", templateName)), logical.ErrInvalidRequest
+	}
+
+	tweak := make([]byte, keysutil.FF31TweakSize)
+	if tweakRaw := d.Get("tweak").(string); tweakRaw != "" {
+		var err error
+		tweak, err = base64.StdEncoding.DecodeString(tweakRaw)
+		if err != nil {
+			return logical.ErrorResponse("failed to base64-decode tweak"), logical.ErrInvalidRequest
+		}
+		if len(tweak) != keysutil.FF31TweakSize {
+			return logical.ErrorResponse(fmt.Sprintf("tweak must be %d bytes", keysutil.FF31TweakSize)), logical.ErrInvalidRequest
+		}
+	}
+
+	// Get the policy
+	p, _, err := b.GetPolicy(ctx, keysutil.PolicyRequest{
+		Storage: req.Storage,
+		Name:    name,
+	}, b.GetRandomReader())
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return logical.ErrorResponse("encryption key not found"), logical.ErrInvalidRequest
+	}
+	if !b.System().CachingDisabled() {
+		p.Lock(false)
+	}
+	defer p.Unlock()
+
+	if !p.Type.FormatPreservingEncryptionSupported() {
+		return logical.ErrorResponse(fmt.Sprintf("format-preserving encryption not supported for key type %v", p.Type)), logical.ErrInvalidRequest
+	}
+
+	switch {
+	case ver == 0:
+		ver = p.LatestVersion
+	case decode && ver < p.MinDecryptionVersion:
+		return logical.ErrorResponse("cannot decode: version is too old (disallowed by policy)"), logical.ErrInvalidRequest
+	case !decode && p.MinEncryptionVersion > 0 && ver < p.MinEncryptionVersion:
+		return logical.ErrorResponse("cannot encode: version is too old (disallowed by policy)"), logical.ErrInvalidRequest
+	}
+
+	// Only the characters of the template's alphabet are encrypted; the
+	// others keep their position.
+	chars := []rune(value)
+	var positions []int
+	var numerals strings.Builder
+	for i, r := range chars {
+		if strings.ContainsRune(template.alphabet, r) {
+			positions = append(positions, i)
+			numerals.WriteRune(r)
+		}
+	}
+
+	var result string
+	if decode {
+		result, err = p.FF31Decrypt(ver, template.alphabet, tweak, numerals.String())
+	} else {
+		result, err = p.FF31Encrypt(ver, template.alphabet, tweak, numerals.String())
+	}
+	if err != nil {
+		switch err.(type) {
+		case errutil.UserError:
+			return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+		default:
+			return nil, err
+		}
+	}
+
+	for i, r := range []rune(result) {
+		chars[positions[i]] = r
+	}
+
+	field := "encoded_value"
+	if decode {
+		field = "decoded_value"
+	}
+	return &logical.Response{
+		Data: map[string]interface{}{
+			field:         string(chars),
+			"key_version": ver,
+		},
+	}, nil
+}
+
+const pathEncodeHelpSyn = `Encode a value with format-preserving encryption`
+
+const pathEncodeHelpDesc = `
+This path encrypts a value with the named key using FF3-1 format-preserving
+encryption, so that the encoded value has the format of the original: the
+characters of the template's alphabet are replaced by others of the same
+alphabet, and any other characters, such as separators, are kept in place.
+Encoding is deterministic for a given key version and tweak.
+`
+
+const pathDecodeHelpSyn = `Decode a value encoded with format-preserving encryption`
+
+const pathDecodeHelpDesc = `
+This path decrypts a value encoded with the named key on the encode path. The
+template, tweak and key version must match those used for encoding.
+`
@@ -0,0 +1,107 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package transit
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransit_FPE(t *testing.T) {
+	ctx := context.Background()
+	b, s := createBackendWithStorage(t)
+
+	handle := func(path string, data map[string]interface{}) (*logical.Response, error) {
+		t.Helper()
+		return b.HandleRequest(ctx, &logical.Request{
+			Path:      path,
+			Operation: logical.UpdateOperation,
+			Storage:   s,
+			Data:      data,
+		})
+	}
+	fpe := func(path string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := handle(path, data)
+		require.NoError(t, err)
+		require.False(t, resp.IsError(), "resp: %#v", resp)
+		return resp
+	}
+	fpeErr := func(path string, data map[string]interface{}) {
+		t.Helper()
+		resp, err := handle(path, data)
+		require.Error(t, err)
+		require.True(t, resp.IsError())
+	}
+
+	fpe("keys/fpe", map[string]interface{}{"type": "aes256-ff3-1"})
+	fpe("keys/aes", map[string]interface{}{})
+
+	// Keys of other types cannot encode
+	fpeErr("encode/aes", map[string]interface{}{"value": "123456789", "template": "ssn"})
+
+	tweak := base64.StdEncoding.EncodeToString([]byte("tweak!!"))
+	for template, value := range map[string]string{
+		"creditcard":   "4111-1111-1111-1111",
+		"ssn":          "078-05-1120",
+		"numeric":      "00012345",
+		"alphanumeric": "AbCd1234xyz",
+	} {
+		resp := fpe("encode/fpe", map[string]interface{}{
+			"value":    value,
+			"template": template,
+			"tweak":    tweak,
+		})
+		encoded := resp.Data["encoded_value"].(string)
+		require.Equal(t, 1, resp.Data["key_version"])
+		require.Len(t, encoded, len(value))
+		require.NotEqual(t, value, encoded)
+		require.Regexp(t, fpeTemplates[template].pattern, encoded)
+		for i := range value {
+			if value[i] == '-' {
+				require.Equal(t, byte('-'), encoded[i], "separators must be kept in place")
+			}
+		}
+
+		// Encoding is deterministic
+		resp = fpe("encode/fpe", map[string]interface{}{
+			"value":    value,
+			"template": template,
+			"tweak":    tweak,
+		})
+		require.Equal(t, encoded, resp.Data["encoded_value"])
+
+		resp = fpe("decode/fpe", map[string]interface{}{
+			"value":    encoded,
+			"template": template,
+			"tweak":    tweak,
+		})
+		require.Equal(t, value, resp.Data["decoded_value"])
+	}
+
+	// Values must match their template and the length bounds of FF3-1
+	fpeErr("encode/fpe", map[string]interface{}{"value": "078-05-112", "template": "ssn"})
+	fpeErr("encode/fpe", map[string]interface{}{"value": "12345", "template": "numeric"})
+	fpeErr("encode/fpe", map[string]interface{}{"value": "abc", "template": "numeric"})
+	fpeErr("encode/fpe", map[string]interface{}{"value": "123456", "tweak": base64.StdEncoding.EncodeToString([]byte("short"))})
+
+	// Values encoded with an older version decode with that version only
+	resp := fpe("encode/fpe", map[string]interface{}{"value": "123456789", "template": "numeric"})
+	encoded := resp.Data["encoded_value"].(string)
+	fpe("keys/fpe/rotate", nil)
+
+	resp = fpe("encode/fpe", map[string]interface{}{"value": "123456789", "template": "numeric"})
+	require.Equal(t, 2, resp.Data["key_version"])
+	require.NotEqual(t, encoded, resp.Data["encoded_value"])
+
+	resp = fpe("decode/fpe", map[string]interface{}{"value": encoded, "template": "numeric", "key_version": 1})
+	require.Equal(t, "123456789", resp.Data["decoded_value"])
+
+	fpe("keys/fpe/config", map[string]interface{}{"min_decryption_version": 2})
+	fpeErr("decode/fpe", map[string]interface{}{"value": encoded, "template": "numeric", "key_version": 1})
+}
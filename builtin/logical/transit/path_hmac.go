@@ -7,7 +7,6 @@ import (
 	"context"
 	"crypto/hmac"
 	"encoding/base64"
-	"errors"
 	"fmt"
 	"strconv"
 	"strings"
@@ -207,12 +206,7 @@ func (b *backend) pathHMACWrite(ctx context.Context, req *logical.Request, d *fr
 		var retBytes []byte
 
 		if p.Type == keysutil.KeyType_MANAGED_KEY {
-			managedKeySystemView, ok := b.System().(logical.ManagedKeySystemView)
-			if !ok {
-				response[i].err = errors.New("unsupported system view")
-			}
-
-			retBytes, err = p.HMACWithManagedKey(ctx, ver, managedKeySystemView, b.backendUUID, algorithm, input)
+			retBytes, err = p.HMACWithManagedKey(ctx, ver, b.managedKeySystemView(req.Storage), b.backendUUID, algorithm, input)
 			if err != nil {
 				response[i].err = err
 			}
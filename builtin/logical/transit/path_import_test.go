@@ -36,6 +36,7 @@ var keyTypes = []string{
 	"rsa-3072",
 	"rsa-4096",
 	"hmac",
+	"aes256-ff3-1",
 }
 
 var hashFns = []string{
@@ -956,7 +957,7 @@ func wrapTargetKeyForImport(t *testing.T, wrappingKey *rsa.PublicKey, targetKey
 	var ok bool
 	var err error
 	switch targetKeyType {
-	case "aes128-gcm96", "aes256-gcm96", "chacha20-poly1305", "hmac":
+	case "aes128-gcm96", "aes256-gcm96", "chacha20-poly1305", "hmac", "aes256-ff3-1":
 		preppedTargetKey, ok = targetKey.([]byte)
 		if !ok {
 			t.Fatal("failed to wrap target key for import: symmetric key not provided in byte format")
@@ -1013,7 +1014,7 @@ func generateKey(keyType string) (interface{}, error) {
 	switch keyType {
 	case "aes128-gcm96":
 		return uuid.GenerateRandomBytes(16)
-	case "aes256-gcm96", "hmac":
+	case "aes256-gcm96", "hmac", "aes256-ff3-1":
 		return uuid.GenerateRandomBytes(32)
 	case "chacha20-poly1305":
 		return uuid.GenerateRandomBytes(32)
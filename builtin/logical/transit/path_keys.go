@@ -60,7 +60,7 @@ func (b *backend) pathKeys() *framework.Path {
 				Description: `
 The type of key to create. Currently, "aes128-gcm96" (symmetric), "aes256-gcm96" (symmetric), "ecdsa-p256"
 (asymmetric), "ecdsa-p384" (asymmetric), "ecdsa-p521" (asymmetric), "ed25519" (asymmetric), "rsa-2048" (asymmetric), "rsa-3072"
-(asymmetric), "rsa-4096" (asymmetric) are supported.  Defaults to "aes256-gcm96".
+(asymmetric), "rsa-4096" (asymmetric), "aes256-ff3-1" (format-preserving) are supported.  Defaults to "aes256-gcm96".
 `,
 			},
 
@@ -227,6 +227,8 @@ func (b *backend) pathPolicyWrite(ctx context.Context, req *logical.Request, d *
 		polReq.KeyType = keysutil.KeyType_AES128_CMAC
 	case "aes256-cmac":
 		polReq.KeyType = keysutil.KeyType_AES256_CMAC
+	case "aes256-ff3-1":
+		polReq.KeyType = keysutil.KeyType_AES256_FF3_1
 	default:
 		return logical.ErrorResponse(fmt.Sprintf("unknown key type %v", keyType)), logical.ErrInvalidRequest
 	}
@@ -241,7 +243,7 @@ func (b *backend) pathPolicyWrite(ctx context.Context, req *logical.Request, d *
 	}
 
 	if polReq.KeyType == keysutil.KeyType_MANAGED_KEY {
-		keyId, err := GetManagedKeyUUID(ctx, b, managedKeyName, managedKeyId)
+		keyId, err := b.managedKeyUUID(ctx, req.Storage, managedKeyName, managedKeyId)
 		if err != nil {
 			return nil, err
 		}
@@ -316,28 +318,34 @@ func (b *backend) formatKeyPolicy(p *keysutil.Policy, context []byte) (*logical.
 	// Return the response
 	resp := &logical.Response{
 		Data: map[string]interface{}{
-			"name":                   p.Name,
-			"type":                   p.Type.String(),
-			"derived":                p.Derived,
-			"deletion_allowed":       p.DeletionAllowed,
-			"min_available_version":  p.MinAvailableVersion,
-			"min_decryption_version": p.MinDecryptionVersion,
-			"min_encryption_version": p.MinEncryptionVersion,
-			"latest_version":         p.LatestVersion,
-			"exportable":             p.Exportable,
-			"allow_plaintext_backup": p.AllowPlaintextBackup,
-			"supports_encryption":    p.Type.EncryptionSupported(),
-			"supports_decryption":    p.Type.DecryptionSupported(),
-			"supports_signing":       p.Type.SigningSupported(),
-			"supports_derivation":    p.Type.DerivationSupported(),
-			"auto_rotate_period":     int64(p.AutoRotatePeriod.Seconds()),
-			"imported_key":           p.Imported,
+			"name":                     p.Name,
+			"type":                     p.Type.String(),
+			"derived":                  p.Derived,
+			"deletion_allowed":         p.DeletionAllowed,
+			"min_available_version":    p.MinAvailableVersion,
+			"min_decryption_version":   p.MinDecryptionVersion,
+			"min_encryption_version":   p.MinEncryptionVersion,
+			"latest_version":           p.LatestVersion,
+			"exportable":               p.Exportable,
+			"allow_plaintext_backup":   p.AllowPlaintextBackup,
+			"allow_derivation":         p.AllowDerivation,
+			"allow_derived_key_export": p.AllowDerivedKeyExport,
+			"supports_encryption":      p.Type.EncryptionSupported(),
+			"supports_decryption":      p.Type.DecryptionSupported(),
+			"supports_signing":         p.Type.SigningSupported(),
+			"supports_derivation":      p.Type.DerivationSupported(),
+			"auto_rotate_period":       int64(p.AutoRotatePeriod.Seconds()),
+			"imported_key":             p.Imported,
 		},
 	}
 	if p.KeySize != 0 {
 		resp.Data["key_size"] = p.KeySize
 	}
 
+	if len(p.AllowedDerivationInfo) != 0 {
+		resp.Data["allowed_derivation_info"] = p.AllowedDerivationInfo
+	}
+
 	if p.Imported {
 		resp.Data["imported_key_allow_rotation"] = p.AllowImportedKeyRotation
 	}
@@ -370,7 +378,7 @@ func (b *backend) formatKeyPolicy(p *keysutil.Policy, context []byte) (*logical.
 	}
 
 	switch p.Type {
-	case keysutil.KeyType_AES128_GCM96, keysutil.KeyType_AES256_GCM96, keysutil.KeyType_ChaCha20_Poly1305:
+	case keysutil.KeyType_AES128_GCM96, keysutil.KeyType_AES256_GCM96, keysutil.KeyType_ChaCha20_Poly1305, keysutil.KeyType_AES256_FF3_1:
 		retKeys := map[string]int64{}
 		for k, v := range p.Keys {
 			retKeys[k] = v.DeprecatedCreationTime
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/hashicorp/go-secure-stdlib/strutil"
 	"github.com/hashicorp/vault/sdk/framework"
 	"github.com/hashicorp/vault/sdk/helper/keysutil"
 	"github.com/hashicorp/vault/sdk/logical"
@@ -59,6 +60,22 @@ the latest version of the key is allowed.`,
 				Description: `Enables taking a backup of the named key in plaintext format. Once set, this cannot be disabled.`,
 			},
 
+			"allow_derivation": {
+				Type:        framework.TypeBool,
+				Description: `Enables deriving keys from the named key with the derive endpoint.`,
+			},
+
+			"allowed_derivation_info": {
+				Type: framework.TypeCommaStringSlice,
+				Description: `Prefixes which the info of derivations must start with.
+If empty, any info is allowed.`,
+			},
+
+			"allow_derived_key_export": {
+				Type:        framework.TypeBool,
+				Description: `Enables returning derived keys from the derive endpoint. Once set, this cannot be disabled.`,
+			},
+
 			"auto_rotate_period": {
 				Type: framework.TypeDurationSecond,
 				Description: `Amount of time the key should live before
@@ -104,6 +121,9 @@ func (b *backend) pathKeysConfigWrite(ctx context.Context, req *logical.Request,
 	originalDeletionAllowed := p.DeletionAllowed
 	originalExportable := p.Exportable
 	originalAllowPlaintextBackup := p.AllowPlaintextBackup
+	originalAllowDerivation := p.AllowDerivation
+	originalAllowedDerivationInfo := p.AllowedDerivationInfo
+	originalAllowDerivedKeyExport := p.AllowDerivedKeyExport
 
 	defer func() {
 		if retErr != nil || (resp != nil && resp.IsError()) {
@@ -112,6 +132,9 @@ func (b *backend) pathKeysConfigWrite(ctx context.Context, req *logical.Request,
 			p.DeletionAllowed = originalDeletionAllowed
 			p.Exportable = originalExportable
 			p.AllowPlaintextBackup = originalAllowPlaintextBackup
+			p.AllowDerivation = originalAllowDerivation
+			p.AllowedDerivationInfo = originalAllowedDerivationInfo
+			p.AllowDerivedKeyExport = originalAllowDerivedKeyExport
 		}
 	}()
 
@@ -203,6 +226,40 @@ func (b *backend) pathKeysConfigWrite(ctx context.Context, req *logical.Request,
 		}
 	}
 
+	allowDerivationRaw, ok := d.GetOk("allow_derivation")
+	if ok {
+		allowDerivation := allowDerivationRaw.(bool)
+		if allowDerivation != p.AllowDerivation {
+			if allowDerivation && !p.Type.KeyDerivationSupported() {
+				return logical.ErrorResponse("derivation is only supported for symmetric keys"), nil
+			}
+			if allowDerivation && p.Derived {
+				return logical.ErrorResponse("derivation is not supported for derived keys"), nil
+			}
+			p.AllowDerivation = allowDerivation
+			persistNeeded = true
+		}
+	}
+
+	allowedDerivationInfoRaw, ok := d.GetOk("allowed_derivation_info")
+	if ok {
+		allowedDerivationInfo := allowedDerivationInfoRaw.([]string)
+		if !strutil.EquivalentSlices(allowedDerivationInfo, p.AllowedDerivationInfo) {
+			p.AllowedDerivationInfo = allowedDerivationInfo
+			persistNeeded = true
+		}
+	}
+
+	allowDerivedKeyExportRaw, ok := d.GetOk("allow_derived_key_export")
+	if ok {
+		allowDerivedKeyExport := allowDerivedKeyExportRaw.(bool)
+		// Don't unset the already set value
+		if allowDerivedKeyExport && !p.AllowDerivedKeyExport {
+			p.AllowDerivedKeyExport = allowDerivedKeyExport
+			persistNeeded = true
+		}
+	}
+
 	autoRotatePeriodRaw, ok, err := d.GetOkErr("auto_rotate_period")
 	if err != nil {
 		return nil, err
@@ -0,0 +1,272 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package transit
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/go-secure-stdlib/strutil"
+	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/keysutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// kmsSensitiveConfig are the provider configuration values which are never
+// returned when reading a KMS.
+var kmsSensitiveConfig = []string{
+	"access_key",
+	"secret_key",
+	"session_token",
+	"client_secret",
+	"credentials",
+}
+
+func (b *backend) pathListKMS() *framework.Path {
+	return &framework.Path{
+		Pattern: "kms/?$",
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixTransit,
+			OperationSuffix: "kms",
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ListOperation: b.pathKMSList,
+		},
+
+		HelpSynopsis:    pathKMSHelpSyn,
+		HelpDescription: pathKMSHelpDesc,
+	}
+}
+
+func (b *backend) pathKMS() *framework.Path {
+	return &framework.Path{
+		Pattern: "kms/" + framework.GenericNameRegex("name"),
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixTransit,
+			OperationSuffix: "kms",
+		},
+
+		Fields: map[string]*framework.FieldSchema{
+			"name": {
+				Type:        framework.TypeString,
+				Description: "Name of the KMS key",
+			},
+			"provider": {
+				Type: framework.TypeString,
+				Description: `The cloud KMS holding the key: "awskms",
+"gcpckms" or "azurekeyvault". Cannot be changed once set.`,
+			},
+			"config": {
+				Type: framework.TypeKVPairs,
+				Description: `Provider specific configuration identifying the
+key and the credentials used to access it, using the same parameters as the
+corresponding seal stanza, for example kms_key_id and region for awskms.`,
+			},
+		},
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.UpdateOperation: &framework.PathOperation{
+				Callback: b.pathKMSWrite,
+				DisplayAttrs: &framework.DisplayAttributes{
+					OperationVerb: "configure",
+				},
+			},
+			logical.ReadOperation: &framework.PathOperation{
+				Callback: b.pathKMSRead,
+				DisplayAttrs: &framework.DisplayAttributes{
+					OperationVerb: "read",
+				},
+			},
+			logical.DeleteOperation: &framework.PathOperation{
+				Callback: b.pathKMSDelete,
+				DisplayAttrs: &framework.DisplayAttributes{
+					OperationVerb: "delete",
+				},
+			},
+		},
+
+		HelpSynopsis:    pathKMSHelpSyn,
+		HelpDescription: pathKMSHelpDesc,
+	}
+}
+
+func (b *backend) pathKMSList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	entries, err := req.Storage.List(ctx, kmsStoragePrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	return logical.ListResponse(entries), nil
+}
+
+func (b *backend) pathKMSRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	config, err := getKMSConfig(ctx, req.Storage, d.Get("name").(string))
+	if err != nil {
+		return nil, err
+	}
+	if config == nil {
+		return nil, nil
+	}
+
+	return kmsResponse(config), nil
+}
+
+func (b *backend) pathKMSWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	config, err := getKMSConfig(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if config == nil {
+		id, err := uuid.GenerateUUID()
+		if err != nil {
+			return nil, err
+		}
+		config = &kmsConfig{
+			Name: name,
+			UUID: id,
+		}
+	}
+
+	if provider, ok := d.GetOk("provider"); ok {
+		if config.Provider != "" && config.Provider != provider.(string) {
+			return logical.ErrorResponse("the provider of a KMS cannot be changed"), logical.ErrInvalidRequest
+		}
+		config.Provider = provider.(string)
+	}
+	if _, ok := kmsWrapperFactories[config.Provider]; !ok {
+		providers := make([]string, 0, len(kmsWrapperFactories))
+		for provider := range kmsWrapperFactories {
+			providers = append(providers, provider)
+		}
+		sort.Strings(providers)
+		return logical.ErrorResponse("provider must be one of %s", strings.Join(providers, ", ")), logical.ErrInvalidRequest
+	}
+	if rawConfig, ok := d.GetOk("config"); ok {
+		config.Config = rawConfig.(map[string]string)
+	}
+
+	// Make sure the key can be accessed with the configuration before saving
+	// it, without caching its wrapper
+	if _, err := kmsWrapperFactories[config.Provider](config.Config); err != nil {
+		return logical.ErrorResponse("failed to configure KMS: %s", err), logical.ErrInvalidRequest
+	}
+
+	entry, err := logical.StorageEntryJSON(kmsStoragePrefix+name, config)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+	b.kmsKeys.invalidate()
+
+	return kmsResponse(config), nil
+}
+
+func (b *backend) pathKMSDelete(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	config, err := getKMSConfig(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if config == nil {
+		return nil, nil
+	}
+
+	// Deleting the KMS would make the data encrypted by the transit keys it
+	// backs impossible to decrypt
+	keys, err := b.kmsBackedKeys(ctx, req.Storage, config.UUID)
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) > 0 {
+		return logical.ErrorResponse("KMS %q backs the transit keys %s, which must be deleted first", name, strings.Join(keys, ", ")), logical.ErrInvalidRequest
+	}
+
+	if err := req.Storage.Delete(ctx, kmsStoragePrefix+name); err != nil {
+		return nil, err
+	}
+	b.kmsKeys.invalidate()
+
+	return nil, nil
+}
+
+// kmsBackedKeys returns the names of the transit keys with a version backed by
+// the KMS with the given UUID.
+func (b *backend) kmsBackedKeys(ctx context.Context, s logical.Storage, kmsUUID string) ([]string, error) {
+	names, err := s.List(ctx, "policy/")
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	for _, name := range names {
+		p, _, err := b.GetPolicy(ctx, keysutil.PolicyRequest{
+			Storage: s,
+			Name:    name,
+		}, b.GetRandomReader())
+		if err != nil {
+			return nil, err
+		}
+		if p == nil {
+			continue
+		}
+		if !b.System().CachingDisabled() {
+			p.Lock(false)
+		}
+		if p.Type == keysutil.KeyType_MANAGED_KEY {
+			for _, entry := range p.Keys {
+				if entry.ManagedKeyUUID == kmsUUID {
+					keys = append(keys, name)
+					break
+				}
+			}
+		}
+		p.Unlock()
+	}
+
+	return keys, nil
+}
+
+func kmsResponse(config *kmsConfig) *logical.Response {
+	redacted := make(map[string]string, len(config.Config))
+	for k, v := range config.Config {
+		if strutil.StrListContains(kmsSensitiveConfig, k) {
+			continue
+		}
+		redacted[k] = v
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"name":     config.Name,
+			"uuid":     config.UUID,
+			"provider": config.Provider,
+			"config":   redacted,
+		},
+	}
+}
+
+const pathKMSHelpSyn = `Manage the cloud KMS keys backing transit keys`
+
+const pathKMSHelpDesc = `
+This path is used to configure keys held by a cloud KMS, such as AWS KMS, GCP
+Cloud KMS or Azure Key Vault. Transit keys of type managed_key created with
+managed_key_name set to the name of a KMS key, or rotated to one, are backed
+by it: encryption and decryption with them are proxied to the provider, so the
+key material never leaves the KMS, while the transit API stays the same.
+
+Keys backed by a KMS only support encryption, decryption, rewrapping and data
+key generation. A KMS cannot be deleted while transit keys are backed by it.
+Credentials in the configuration, such as secret_key or client_secret, are
+never returned.
+`
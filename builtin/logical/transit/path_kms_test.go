@@ -0,0 +1,133 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package transit
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	wrapping "github.com/hashicorp/go-kms-wrapping/v2"
+	aeadwrapper "github.com/hashicorp/go-kms-wrapping/wrappers/aead/v2"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransit_KMS(t *testing.T) {
+	// Stand in for a cloud KMS with a local AEAD wrapper
+	kmsWrapperFactories["test"] = func(config map[string]string) (wrapping.Wrapper, error) {
+		w := aeadwrapper.NewWrapper()
+		if _, err := w.SetConfig(context.Background(), wrapping.WithConfigMap(config)); err != nil {
+			return nil, err
+		}
+		return w, nil
+	}
+	defer delete(kmsWrapperFactories, "test")
+
+	ctx := context.Background()
+	b, s := createBackendWithStorage(t)
+
+	handle := func(op logical.Operation, path string, data map[string]interface{}) (*logical.Response, error) {
+		t.Helper()
+		return b.HandleRequest(ctx, &logical.Request{
+			Path:      path,
+			Operation: op,
+			Storage:   s,
+			Data:      data,
+		})
+	}
+	success := func(op logical.Operation, path string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := handle(op, path, data)
+		require.NoError(t, err)
+		require.False(t, resp.IsError(), "resp: %#v", resp)
+		return resp
+	}
+	failure := func(op logical.Operation, path string, data map[string]interface{}) string {
+		t.Helper()
+		resp, err := handle(op, path, data)
+		require.Error(t, err)
+		require.True(t, resp.IsError())
+		return resp.Error().Error()
+	}
+
+	require.Contains(t, failure(logical.UpdateOperation, "kms/cloud", map[string]interface{}{
+		"provider": "unknown",
+	}), "provider must be one of")
+
+	resp := success(logical.UpdateOperation, "kms/cloud", map[string]interface{}{
+		"provider": "test",
+		"config": map[string]interface{}{
+			"key":        base64.StdEncoding.EncodeToString(make([]byte, 32)),
+			"key_id":     "cloud-key",
+			"secret_key": "secret",
+		},
+	})
+	kmsUUID := resp.Data["uuid"].(string)
+	require.NotEmpty(t, kmsUUID)
+	require.NotContains(t, resp.Data["config"], "secret_key")
+
+	resp = success(logical.ListOperation, "kms/", nil)
+	require.Equal(t, []string{"cloud"}, resp.Data["keys"])
+
+	require.Contains(t, failure(logical.UpdateOperation, "kms/cloud", map[string]interface{}{
+		"provider": "awskms",
+	}), "cannot be changed")
+
+	// The transit key is backed by the KMS through the standard API
+	success(logical.UpdateOperation, "keys/hybrid", map[string]interface{}{
+		"type":             "managed_key",
+		"managed_key_name": "cloud",
+	})
+
+	plaintext := base64.StdEncoding.EncodeToString([]byte("regulated data"))
+	resp = success(logical.UpdateOperation, "encrypt/hybrid", map[string]interface{}{
+		"plaintext": plaintext,
+	})
+	ciphertext := resp.Data["ciphertext"].(string)
+	require.True(t, strings.HasPrefix(ciphertext, "vault:v1:"), ciphertext)
+
+	resp = success(logical.UpdateOperation, "decrypt/hybrid", map[string]interface{}{
+		"ciphertext": ciphertext,
+	})
+	require.Equal(t, plaintext, resp.Data["plaintext"])
+
+	resp = success(logical.UpdateOperation, "datakey/plaintext/hybrid", nil)
+	datakey := success(logical.UpdateOperation, "decrypt/hybrid", map[string]interface{}{
+		"ciphertext": resp.Data["ciphertext"],
+	})
+	require.Equal(t, resp.Data["plaintext"], datakey.Data["plaintext"])
+
+	// Rotating to the KMS again adds a version backed by it
+	success(logical.UpdateOperation, "keys/hybrid/rotate", map[string]interface{}{
+		"managed_key_id": kmsUUID,
+	})
+	resp = success(logical.UpdateOperation, "rewrap/hybrid", map[string]interface{}{
+		"ciphertext": ciphertext,
+	})
+	rewrapped := resp.Data["ciphertext"].(string)
+	require.True(t, strings.HasPrefix(rewrapped, "vault:v2:"), rewrapped)
+	resp = success(logical.UpdateOperation, "decrypt/hybrid", map[string]interface{}{
+		"ciphertext": rewrapped,
+	})
+	require.Equal(t, plaintext, resp.Data["plaintext"])
+
+	_, err := handle(logical.UpdateOperation, "sign/hybrid", map[string]interface{}{
+		"input": plaintext,
+	})
+	require.Error(t, err)
+
+	// The KMS cannot be deleted while it backs transit keys
+	require.Contains(t, failure(logical.DeleteOperation, "kms/cloud", nil), "hybrid")
+	success(logical.UpdateOperation, "keys/hybrid/config", map[string]interface{}{
+		"deletion_allowed": true,
+	})
+	success(logical.DeleteOperation, "keys/hybrid", nil)
+	success(logical.DeleteOperation, "kms/cloud", nil)
+
+	resp, err = handle(logical.ReadOperation, "kms/cloud", nil)
+	require.NoError(t, err)
+	require.Nil(t, resp)
+}
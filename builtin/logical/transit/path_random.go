@@ -8,6 +8,7 @@ import (
 
 	"github.com/hashicorp/vault/helper/random"
 	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/pointerutil"
 	"github.com/hashicorp/vault/sdk/logical"
 )
 
@@ -30,13 +31,16 @@ func (b *backend) pathRandom() *framework.Path {
 			"bytes": {
 				Type:        framework.TypeInt,
 				Default:     32,
+				Minimum:     pointerutil.IntPtr(1),
+				Maximum:     pointerutil.IntPtr(random.APIMaxBytes),
 				Description: "The number of bytes to generate (POST body parameter). Defaults to 32 (256 bits).",
 			},
 
 			"format": {
-				Type:        framework.TypeString,
-				Default:     "base64",
-				Description: `Encoding format to use. Can be "hex" or "base64". Defaults to "base64".`,
+				Type:          framework.TypeString,
+				Default:       "base64",
+				AllowedValues: []interface{}{"hex", "base64"},
+				Description:   `Encoding format to use. Can be "hex" or "base64". Defaults to "base64".`,
 			},
 
 			"source": {
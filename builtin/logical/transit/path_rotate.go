@@ -68,7 +68,7 @@ func (b *backend) pathRotateWrite(ctx context.Context, req *logical.Request, d *
 
 	if p.Type == keysutil.KeyType_MANAGED_KEY {
 		var keyId string
-		keyId, err = GetManagedKeyUUID(ctx, b, managedKeyName, managedKeyId)
+		keyId, err = b.managedKeyUUID(ctx, req.Storage, managedKeyName, managedKeyId)
 		if err != nil {
 			p.Unlock()
 			return nil, err
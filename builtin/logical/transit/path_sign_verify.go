@@ -7,7 +7,6 @@ import (
 	"context"
 	"crypto/rsa"
 	"encoding/base64"
-	"errors"
 	"fmt"
 	"strconv"
 	"strings"
@@ -449,13 +448,8 @@ func (b *backend) pathSignWrite(ctx context.Context, req *logical.Request, d *fr
 
 		var managedKeyParameters keysutil.ManagedKeyParameters
 		if p.Type == keysutil.KeyType_MANAGED_KEY {
-			managedKeySystemView, ok := b.System().(logical.ManagedKeySystemView)
-			if !ok {
-				return nil, errors.New("unsupported system view")
-			}
-
 			managedKeyParameters = keysutil.ManagedKeyParameters{
-				ManagedKeySystemView: managedKeySystemView,
+				ManagedKeySystemView: b.managedKeySystemView(req.Storage),
 				BackendUUID:          b.backendUUID,
 				Context:              ctx,
 			}
@@ -718,13 +712,8 @@ func (b *backend) pathVerifyWrite(ctx context.Context, req *logical.Request, d *
 		}
 		var managedKeyParameters keysutil.ManagedKeyParameters
 		if p.Type == keysutil.KeyType_MANAGED_KEY {
-			managedKeySystemView, ok := b.System().(logical.ManagedKeySystemView)
-			if !ok {
-				return nil, errors.New("unsupported system view")
-			}
-
 			managedKeyParameters = keysutil.ManagedKeyParameters{
-				ManagedKeySystemView: managedKeySystemView,
+				ManagedKeySystemView: b.managedKeySystemView(req.Storage),
 				BackendUUID:          b.backendUUID,
 				Context:              ctx,
 			}
@@ -9,6 +9,7 @@ import (
 	"net/rpc"
 	"reflect"
 	"sync"
+	"sync/atomic"
 
 	log "github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/go-multierror"
@@ -114,6 +115,16 @@ type PluginBackend struct {
 
 	// Used to detect if plugin is set
 	loaded bool
+
+	// restarts counts the number of times the plugin was reloaded after it
+	// crashed or was otherwise shut down
+	restarts atomic.Uint64
+}
+
+// PluginRestartCount returns the number of times the plugin backend has been
+// reloaded after crashing.
+func (b *PluginBackend) PluginRestartCount() uint64 {
+	return b.restarts.Load()
 }
 
 // startBackend starts a plugin backend
@@ -199,6 +210,7 @@ func (b *PluginBackend) lazyLoadBackend(ctx context.Context, storage logical.Sto
 				b.Unlock()
 				return err
 			}
+			b.restarts.Add(1)
 			b.canary, err = uuid.GenerateUUID()
 			if err != nil {
 				b.Unlock()
@@ -7,6 +7,7 @@ import (
 	"context"
 	"net/rpc"
 	"sync"
+	"sync/atomic"
 
 	"github.com/hashicorp/go-uuid"
 	"github.com/hashicorp/vault/sdk/helper/consts"
@@ -47,6 +48,16 @@ type backend struct {
 
 	// Used to detect if we already reloaded
 	canary string
+
+	// restarts counts the number of times the plugin was reloaded after it
+	// crashed or was otherwise shut down
+	restarts atomic.Uint64
+}
+
+// PluginRestartCount returns the number of times the plugin backend has been
+// reloaded after crashing.
+func (b *backend) PluginRestartCount() uint64 {
+	return b.restarts.Load()
 }
 
 func (b *backend) reloadBackend(ctx context.Context, storage logical.Storage) error {
@@ -84,6 +95,7 @@ func (b *backend) reloadBackend(ctx context.Context, storage logical.Storage) er
 		return err
 	}
 
+	b.restarts.Add(1)
 	return nil
 }
 
@@ -36,8 +36,11 @@ import (
 	"github.com/hashicorp/vault/command/agentproxyshared/auth"
 	"github.com/hashicorp/vault/command/agentproxyshared/cache"
 	"github.com/hashicorp/vault/command/agentproxyshared/sink"
+	"github.com/hashicorp/vault/command/agentproxyshared/sink/awssecretsmanager"
+	sinkexec "github.com/hashicorp/vault/command/agentproxyshared/sink/exec"
 	"github.com/hashicorp/vault/command/agentproxyshared/sink/file"
 	"github.com/hashicorp/vault/command/agentproxyshared/sink/inmem"
+	"github.com/hashicorp/vault/command/agentproxyshared/sink/kubernetes"
 	"github.com/hashicorp/vault/command/agentproxyshared/winsvc"
 	"github.com/hashicorp/vault/helper/logging"
 	"github.com/hashicorp/vault/helper/metricsutil"
@@ -349,29 +352,37 @@ func (c *AgentCommand) Run(args []string) int {
 		}
 
 		for _, sc := range config.AutoAuth.Sinks {
+			config := &sink.SinkConfig{
+				Logger:    c.logger.Named("sink." + sc.Type),
+				Config:    sc.Config,
+				Client:    sinkClient,
+				WrapTTL:   sc.WrapTTL,
+				DHType:    sc.DHType,
+				DeriveKey: sc.DeriveKey,
+				DHPath:    sc.DHPath,
+				AAD:       sc.AAD,
+			}
+
+			var s sink.Sink
 			switch sc.Type {
 			case "file":
-				config := &sink.SinkConfig{
-					Logger:    c.logger.Named("sink.file"),
-					Config:    sc.Config,
-					Client:    sinkClient,
-					WrapTTL:   sc.WrapTTL,
-					DHType:    sc.DHType,
-					DeriveKey: sc.DeriveKey,
-					DHPath:    sc.DHPath,
-					AAD:       sc.AAD,
-				}
-				s, err := file.NewFileSink(config)
-				if err != nil {
-					c.UI.Error(fmt.Errorf("error creating file sink: %w", err).Error())
-					return 1
-				}
-				config.Sink = s
-				sinks = append(sinks, config)
+				s, err = file.NewFileSink(config)
+			case "exec":
+				s, err = sinkexec.NewExecSink(config)
+			case "kubernetes_secret":
+				s, err = kubernetes.NewKubernetesSink(config)
+			case "aws_secrets_manager":
+				s, err = awssecretsmanager.NewAWSSecretsManagerSink(config)
 			default:
 				c.UI.Error(fmt.Sprintf("Unknown sink type %q", sc.Type))
 				return 1
 			}
+			if err != nil {
+				c.UI.Error(fmt.Errorf("error creating %s sink: %w", sc.Type, err).Error())
+				return 1
+			}
+			config.Sink = s
+			sinks = append(sinks, config)
 		}
 
 		authConfig := &auth.AuthConfig{
@@ -513,6 +524,8 @@ func (c *AgentCommand) Run(args []string) int {
 		return 1
 	}
 
+	var updater *cache.StaticSecretCacheUpdater
+
 	// Parse agent cache configurations
 	if config.Cache != nil {
 		cacheLogger := c.logger.Named("cache")
@@ -520,11 +533,13 @@ func (c *AgentCommand) Run(args []string) int {
 		// Create the lease cache proxier and set its underlying proxier to
 		// the API proxier.
 		leaseCache, err = cache.NewLeaseCache(&cache.LeaseCacheConfig{
-			Client:              proxyClient,
-			BaseContext:         ctx,
-			Proxier:             apiProxy,
-			Logger:              cacheLogger.Named("leasecache"),
-			CacheDynamicSecrets: true,
+			Client:             proxyClient,
+			BaseContext:        ctx,
+			Proxier:            apiProxy,
+			Logger:             cacheLogger.Named("leasecache"),
+			CacheStaticSecrets: config.Cache.CacheStaticSecrets,
+			// dynamic secrets are configured as default-on to preserve backwards compatibility
+			CacheDynamicSecrets: !config.Cache.DisableCachingDynamicSecrets,
 			UserAgentToUse:      useragent.ProxyAPIProxyString(),
 		})
 		if err != nil {
@@ -532,6 +547,8 @@ func (c *AgentCommand) Run(args []string) int {
 			return 1
 		}
 
+		cacheLogger.Info("cache configured", "cache_static_secrets", config.Cache.CacheStaticSecrets, "disable_caching_dynamic_secrets", config.Cache.DisableCachingDynamicSecrets)
+
 		// Configure persistent storage and add to LeaseCache
 		if config.Cache.Persist != nil {
 			deferFunc, oldToken, err := agentproxyshared.AddPersistentStorageToLeaseCache(ctx, leaseCache, config.Cache.Persist, cacheLogger)
@@ -544,6 +561,46 @@ func (c *AgentCommand) Run(args []string) int {
 				defer deferFunc()
 			}
 		}
+
+		// If we're caching static secrets, we need to start the updater, too
+		if config.Cache.CacheStaticSecrets {
+			staticSecretCacheUpdaterLogger := c.logger.Named("cache.staticsecretcacheupdater")
+			inmemSink, err := inmem.New(&sink.SinkConfig{
+				Logger: staticSecretCacheUpdaterLogger,
+			}, leaseCache)
+			if err != nil {
+				c.UI.Error(fmt.Sprintf("Error creating inmem sink for static secret updater susbsystem: %v", err))
+				return 1
+			}
+			sinks = append(sinks, &sink.SinkConfig{
+				Logger: staticSecretCacheUpdaterLogger,
+				Sink:   inmemSink,
+			})
+
+			updater, err = cache.NewStaticSecretCacheUpdater(&cache.StaticSecretCacheUpdaterConfig{
+				Client:     client,
+				LeaseCache: leaseCache,
+				Logger:     staticSecretCacheUpdaterLogger,
+				TokenSink:  inmemSink,
+			})
+			if err != nil {
+				c.UI.Error(fmt.Sprintf("Error creating static secret cache updater: %v", err))
+				return 1
+			}
+
+			capabilityManager, err := cache.NewStaticSecretCapabilityManager(&cache.StaticSecretCapabilityManagerConfig{
+				LeaseCache: leaseCache,
+				Logger:     c.logger.Named("cache.staticsecretcapabilitymanager"),
+				Client:     client,
+				StaticSecretTokenCapabilityRefreshInterval:  config.Cache.StaticSecretTokenCapabilityRefreshInterval,
+				StaticSecretTokenCapabilityRefreshBehaviour: config.Cache.StaticSecretTokenCapabilityRefreshBehaviour,
+			})
+			if err != nil {
+				c.UI.Error(fmt.Sprintf("Error creating static secret capability manager: %v", err))
+				return 1
+			}
+			leaseCache.SetCapabilityManager(capabilityManager)
+		}
 	}
 
 	// Create the AuthHandler, SinkServer, TemplateServer, and ExecServer now so that we can pass AuthHandler struct
@@ -867,6 +924,14 @@ func (c *AgentCommand) Run(args []string) int {
 			es.Close()
 		})
 
+		// Add the static secret cache updater, if appropriate
+		if updater != nil {
+			g.Add(func() error {
+				return updater.Run(ctx, ah.AuthInProgress, ah.InvalidToken)
+			}, func(error) {
+				cancelFunc()
+			})
+		}
 	}
 
 	// Server configuration output
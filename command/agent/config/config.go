@@ -115,13 +115,18 @@ type APIProxy struct {
 
 // Cache contains any configuration needed for Cache mode
 type Cache struct {
-	UseAutoAuthTokenRaw interface{}                     `hcl:"use_auto_auth_token"`
-	UseAutoAuthToken    bool                            `hcl:"-"`
-	ForceAutoAuthToken  bool                            `hcl:"-"`
-	EnforceConsistency  string                          `hcl:"enforce_consistency"`
-	WhenInconsistent    string                          `hcl:"when_inconsistent"`
-	Persist             *agentproxyshared.PersistConfig `hcl:"persist"`
-	InProcDialer        transportDialer                 `hcl:"-"`
+	UseAutoAuthTokenRaw                           interface{}                     `hcl:"use_auto_auth_token"`
+	UseAutoAuthToken                              bool                            `hcl:"-"`
+	ForceAutoAuthToken                            bool                            `hcl:"-"`
+	EnforceConsistency                            string                          `hcl:"enforce_consistency"`
+	WhenInconsistent                              string                          `hcl:"when_inconsistent"`
+	Persist                                       *agentproxyshared.PersistConfig `hcl:"persist"`
+	InProcDialer                                  transportDialer                 `hcl:"-"`
+	CacheStaticSecrets                            bool                            `hcl:"cache_static_secrets"`
+	DisableCachingDynamicSecrets                  bool                            `hcl:"disable_caching_dynamic_secrets"`
+	StaticSecretTokenCapabilityRefreshIntervalRaw interface{}                     `hcl:"static_secret_token_capability_refresh_interval"`
+	StaticSecretTokenCapabilityRefreshInterval    time.Duration                   `hcl:"-"`
+	StaticSecretTokenCapabilityRefreshBehaviour   string                          `hcl:"static_secret_token_capability_refresh_behavior"`
 }
 
 // AutoAuth is the configured authentication method and sinks
@@ -348,11 +353,30 @@ func (c *Config) ValidateConfig() error {
 	}
 
 	if c.AutoAuth != nil {
+		cacheStaticSecrets := c.Cache != nil && c.Cache.CacheStaticSecrets
 		if len(c.AutoAuth.Sinks) == 0 &&
 			(c.APIProxy == nil || !c.APIProxy.UseAutoAuthToken) &&
 			len(c.Templates) == 0 &&
-			len(c.EnvTemplates) == 0 {
-			return fmt.Errorf("auto_auth requires at least one sink or at least one template or api_proxy.use_auto_auth_token=true")
+			len(c.EnvTemplates) == 0 &&
+			!cacheStaticSecrets {
+			return fmt.Errorf("auto_auth requires at least one sink or at least one template or api_proxy.use_auto_auth_token=true or cache.cache_static_secrets=true")
+		}
+	}
+
+	if c.Cache != nil && c.Cache.CacheStaticSecrets && c.AutoAuth == nil {
+		return fmt.Errorf("cache.cache_static_secrets=true requires an auto-auth block configured, to use the token to connect with Vault's event system")
+	}
+
+	if c.Cache != nil && !c.Cache.CacheStaticSecrets && c.Cache.DisableCachingDynamicSecrets {
+		return fmt.Errorf("to enable the cache, the cache must be configured to either cache static secrets or dynamic secrets")
+	}
+
+	if c.Cache != nil && c.Cache.StaticSecretTokenCapabilityRefreshBehaviour != "" {
+		switch c.Cache.StaticSecretTokenCapabilityRefreshBehaviour {
+		case "pessimistic":
+		case "optimistic":
+		default:
+			return fmt.Errorf("cache.static_secret_token_capability_refresh_behavior must be either \"optimistic\" or \"pessimistic\"")
 		}
 	}
 
@@ -885,6 +909,14 @@ func parseCache(result *Config, list *ast.ObjectList) error {
 		return fmt.Errorf("error parsing persist: %w", err)
 	}
 
+	if result.Cache.StaticSecretTokenCapabilityRefreshIntervalRaw != nil {
+		var err error
+		if result.Cache.StaticSecretTokenCapabilityRefreshInterval, err = parseutil.ParseDurationSecond(result.Cache.StaticSecretTokenCapabilityRefreshIntervalRaw); err != nil {
+			return fmt.Errorf("error parsing static_secret_token_capability_refresh_interval, must be provided as a duration string: %w", err)
+		}
+		result.Cache.StaticSecretTokenCapabilityRefreshIntervalRaw = nil
+	}
+
 	return nil
 }
 
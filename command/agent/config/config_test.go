@@ -1039,6 +1039,79 @@ func TestLoadConfigFile_AgentCache_PersistMissingType(t *testing.T) {
 	}
 }
 
+// TestLoadConfigFile_AgentCache_StaticSecrets tests loading a config file with
+// static secret caching enabled, and that no sink is required alongside it.
+func TestLoadConfigFile_AgentCache_StaticSecrets(t *testing.T) {
+	config, err := LoadConfigFile("./test-fixtures/config-cache-static-secrets.hcl")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	expected := &Config{
+		AutoAuth: &AutoAuth{
+			Method: &Method{
+				Type:      "aws",
+				MountPath: "auth/aws",
+				Config: map[string]interface{}{
+					"role": "foobar",
+				},
+			},
+		},
+		Cache: &Cache{
+			CacheStaticSecrets:                          true,
+			DisableCachingDynamicSecrets:                true,
+			StaticSecretTokenCapabilityRefreshInterval:  1 * time.Hour,
+			StaticSecretTokenCapabilityRefreshBehaviour: "pessimistic",
+		},
+		SharedConfig: &configutil.SharedConfig{
+			PidFile: "./pidfile",
+			Listeners: []*configutil.Listener{
+				{
+					Type:       "tcp",
+					Address:    "127.0.0.1:8300",
+					TLSDisable: true,
+				},
+			},
+		},
+		TemplateConfig: &TemplateConfig{
+			MaxConnectionsPerHost: DefaultTemplateConfigMaxConnsPerHost,
+		},
+	}
+
+	config.Prune()
+	if diff := deep.Equal(config, expected); diff != nil {
+		t.Fatal(diff)
+	}
+
+	if err := config.ValidateConfig(); err != nil {
+		t.Fatalf("expected config to be valid, got: %v", err)
+	}
+}
+
+// TestLoadConfigFile_Bad_AgentCache_StaticSecretsNoAutoAuth tests that static
+// secret caching cannot be enabled without auto-auth.
+func TestLoadConfigFile_Bad_AgentCache_StaticSecretsNoAutoAuth(t *testing.T) {
+	config, err := LoadConfigFile("./test-fixtures/bad-config-cache-static-no-auto-auth.hcl")
+	if err != nil {
+		t.Fatalf("LoadConfigFile should not return an error for this config, err: %v", err)
+	}
+	if err := config.ValidateConfig(); err == nil {
+		t.Fatal("expected error, as static secret caching requires auto-auth")
+	}
+}
+
+// TestLoadConfigFile_Bad_AgentCache_NothingCached tests that the cache cannot
+// be configured to cache neither static nor dynamic secrets.
+func TestLoadConfigFile_Bad_AgentCache_NothingCached(t *testing.T) {
+	config, err := LoadConfigFile("./test-fixtures/bad-config-cache-disable-dynamic-no-static.hcl")
+	if err != nil {
+		t.Fatalf("LoadConfigFile should not return an error for this config, err: %v", err)
+	}
+	if err := config.ValidateConfig(); err == nil {
+		t.Fatal("expected error, as the cache must cache either static or dynamic secrets")
+	}
+}
+
 func TestLoadConfigFile_TemplateConfig(t *testing.T) {
 	testCases := map[string]struct {
 		fixturePath            string
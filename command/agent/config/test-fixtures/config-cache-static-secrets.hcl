@@ -0,0 +1,25 @@
+# Copyright (c) HashiCorp, Inc.
+# SPDX-License-Identifier: BUSL-1.1
+
+pid_file = "./pidfile"
+
+auto_auth {
+	method {
+		type = "aws"
+		config = {
+			role = "foobar"
+		}
+	}
+}
+
+cache {
+    cache_static_secrets = true
+    disable_caching_dynamic_secrets = true
+    static_secret_token_capability_refresh_interval = "1h"
+    static_secret_token_capability_refresh_behavior = "pessimistic"
+}
+
+listener "tcp" {
+    address = "127.0.0.1:8300"
+    tls_disable = true
+}
@@ -0,0 +1,132 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package ctmanager
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	dep "github.com/hashicorp/consul-template/dependency"
+)
+
+const (
+	pkiKeyFileMode = 0o600
+	pkiCAFileMode  = 0o644
+)
+
+// pkiFuncMap returns the template functions which write the private key and
+// CA chain of a certificate returned by the pkiCert function to files of their
+// own. pkiCert issues the certificate, and issues a new one once the lease
+// renewal threshold of its lifetime has passed; these functions are run when
+// the template is rendered, so the key and chain files are always written
+// before the template's destination.
+//
+//	{{ with pkiCert "pki/issue/web" "common_name=web.example.com" }}
+//	{{ .Cert }}{{ pkiKey "/etc/tls/web.key" . }}{{ pkiCA "/etc/tls/ca.pem" . }}
+//	{{ end }}
+func pkiFuncMap() template.FuncMap {
+	return template.FuncMap{
+		"pkiKey": pkiKeyFunc,
+		"pkiCA":  pkiCAFunc,
+	}
+}
+
+// pkiKeyFunc writes the private key of the certificate to path, returning an
+// empty string so that the template's output is unchanged.
+//
+// The private key is only returned when the certificate is issued. When the
+// certificate is instead read back from the template's destination, such as
+// after a restart, the key previously written to path is kept as long as it
+// matches the certificate.
+func pkiKeyFunc(path string, pems dep.PemEncoded) (string, error) {
+	if pems.Cert == "" {
+		return "", errors.New("pkiKey: no certificate given")
+	}
+
+	key := pems.Key
+	if key == "" {
+		existing, err := os.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			return "", fmt.Errorf("pkiKey: error reading existing private key: %w", err)
+		}
+		if len(existing) == 0 {
+			return "", fmt.Errorf("pkiKey: the private key of the certificate is not available, remove the template's destination to issue a new certificate")
+		}
+		key = string(existing)
+	}
+
+	if _, err := tls.X509KeyPair([]byte(pems.Cert), []byte(key)); err != nil {
+		return "", fmt.Errorf("pkiKey: private key does not match the certificate: %w", err)
+	}
+
+	if err := writeFileAtomic(path, []byte(key), pkiKeyFileMode); err != nil {
+		return "", fmt.Errorf("pkiKey: %w", err)
+	}
+	return "", nil
+}
+
+// pkiCAFunc writes the CA chain of the certificate to path, returning an
+// empty string so that the template's output is unchanged.
+func pkiCAFunc(path string, pems dep.PemEncoded) (string, error) {
+	chain := pems.CAChain
+	if len(chain) == 0 && pems.CA != "" {
+		chain = []string{pems.CA}
+	}
+	if len(chain) == 0 {
+		return "", errors.New("pkiCA: no CA certificates given")
+	}
+
+	var ca strings.Builder
+	for _, cert := range chain {
+		ca.WriteString(strings.TrimSpace(cert))
+		ca.WriteString("\n")
+	}
+
+	if err := writeFileAtomic(path, []byte(ca.String()), pkiCAFileMode); err != nil {
+		return "", fmt.Errorf("pkiCA: %w", err)
+	}
+	return "", nil
+}
+
+// writeFileAtomic writes data to path by renaming a temporary file in the
+// same directory over it, so that readers never see a partially written
+// file. Nothing is written if the file's contents are unchanged.
+func writeFileAtomic(path string, data []byte, mode os.FileMode) error {
+	if existing, err := os.ReadFile(path); err == nil && bytes.Equal(existing, data) {
+		return os.Chmod(path, mode)
+	}
+
+	tmpFile, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp.")
+	if err != nil {
+		return fmt.Errorf("error creating temp file for %s: %w", path, err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if err := tmpFile.Chmod(mode); err != nil {
+		tmpFile.Close()
+		return fmt.Errorf("error setting mode of %s: %w", tmpFile.Name(), err)
+	}
+	if _, err := tmpFile.Write(data); err != nil {
+		tmpFile.Close()
+		return fmt.Errorf("error writing to %s: %w", tmpFile.Name(), err)
+	}
+	if err := tmpFile.Sync(); err != nil {
+		tmpFile.Close()
+		return fmt.Errorf("error syncing %s: %w", tmpFile.Name(), err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		return fmt.Errorf("error closing %s: %w", tmpFile.Name(), err)
+	}
+
+	if err := os.Rename(tmpFile.Name(), path); err != nil {
+		return fmt.Errorf("error renaming temp file %s to %s: %w", tmpFile.Name(), path, err)
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package ctmanager
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	dep "github.com/hashicorp/consul-template/dependency"
+	"github.com/stretchr/testify/require"
+)
+
+func testPKIPems(t *testing.T) dep.PemEncoded {
+	t.Helper()
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	caTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "ca"},
+		NotBefore:             time.Now().Add(-time.Minute),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTmpl, caTmpl, caKey.Public(), caKey)
+	require.NoError(t, err)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "web.example.com"},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, caTmpl, key.Public(), caKey)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	ca := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER}))
+	return dep.PemEncoded{
+		Cert:    string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		Key:     string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})),
+		CA:      ca,
+		CAChain: []string{ca},
+	}
+}
+
+func TestPKIKeyFunc(t *testing.T) {
+	pems := testPKIPems(t)
+	path := filepath.Join(t.TempDir(), "web.key")
+
+	out, err := pkiKeyFunc(path, pems)
+	require.NoError(t, err)
+	require.Empty(t, out)
+
+	key, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, pems.Key, string(key))
+	fi, err := os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(pkiKeyFileMode), fi.Mode().Perm())
+
+	// A certificate read back from the template's destination has no key, so
+	// the key written previously is kept
+	noKey := pems
+	noKey.Key = ""
+	_, err = pkiKeyFunc(path, noKey)
+	require.NoError(t, err)
+	key, err = os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, pems.Key, string(key))
+
+	// ...unless it belongs to another certificate
+	other := testPKIPems(t)
+	other.Key = ""
+	_, err = pkiKeyFunc(path, other)
+	require.ErrorContains(t, err, "does not match")
+
+	// ...or there is none
+	_, err = pkiKeyFunc(filepath.Join(t.TempDir(), "missing.key"), noKey)
+	require.ErrorContains(t, err, "not available")
+
+	// A mismatched key is never written
+	mismatched := testPKIPems(t)
+	mismatched.Key = pems.Key
+	_, err = pkiKeyFunc(path, mismatched)
+	require.ErrorContains(t, err, "does not match")
+}
+
+func TestPKICAFunc(t *testing.T) {
+	pems := testPKIPems(t)
+	path := filepath.Join(t.TempDir(), "ca.pem")
+
+	out, err := pkiCAFunc(path, pems)
+	require.NoError(t, err)
+	require.Empty(t, out)
+
+	ca, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, pems.CA, string(ca))
+	fi, err := os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(pkiCAFileMode), fi.Mode().Perm())
+
+	_, err = pkiCAFunc(path, dep.PemEncoded{Cert: pems.Cert})
+	require.ErrorContains(t, err, "no CA certificates")
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"text/template"
 
 	ctconfig "github.com/hashicorp/consul-template/config"
 	ctlogging "github.com/hashicorp/consul-template/logging"
@@ -28,6 +29,19 @@ func NewConfig(mc ManagerConfig, templates ctconfig.TemplateConfigs) (*ctconfig.
 	conf := ctconfig.DefaultConfig()
 	conf.Templates = templates.Copy()
 
+	// Add Vault's own template functions, without overriding any functions
+	// of the same name already set on a template
+	for _, tmpl := range *conf.Templates {
+		if tmpl.ExtFuncMap == nil {
+			tmpl.ExtFuncMap = make(template.FuncMap)
+		}
+		for name, fn := range pkiFuncMap() {
+			if _, ok := tmpl.ExtFuncMap[name]; !ok {
+				tmpl.ExtFuncMap[name] = fn
+			}
+		}
+	}
+
 	// Setup the Vault config
 	// Always set these to ensure nothing is picked up from the environment
 	conf.Vault.RenewToken = pointerutil.BoolPtr(false)
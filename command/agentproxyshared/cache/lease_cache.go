@@ -19,6 +19,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/armon/go-metrics"
 	"github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/go-secure-stdlib/base62"
@@ -232,7 +233,15 @@ func (c *LeaseCache) checkCacheForDynamicSecretRequest(id string) (*SendResponse
 // request.
 func (c *LeaseCache) checkCacheForStaticSecretRequest(id string, req *SendRequest) (*SendResponse, error) {
 	c.logger.Trace("checking cache for static secret request", "id", id)
-	return c.checkCacheForRequest(id, req)
+	resp, err := c.checkCacheForRequest(id, req)
+	if err == nil {
+		if resp != nil {
+			metrics.IncrCounter([]string{"agent", "cache", "static_secret", "hit"}, 1)
+		} else {
+			metrics.IncrCounter([]string{"agent", "cache", "static_secret", "miss"}, 1)
+		}
+	}
+	return resp, err
 }
 
 // checkCacheForRequest checks the cache for a particular request based on its
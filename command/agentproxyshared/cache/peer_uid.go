@@ -0,0 +1,67 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package cache
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+
+	"github.com/armon/go-metrics"
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+type ctxKeyPeerUID struct{}
+
+// peerUIDResult is the user ID of the process on the other end of a unix
+// socket connection, or the reason it could not be determined.
+type peerUIDResult struct {
+	uid int
+	err error
+}
+
+// PeerUIDConnContext records the user ID of the process connected to a unix
+// socket listener in the context of the requests received on the connection.
+// It is meant to be used as the ConnContext of an http.Server.
+func PeerUIDConnContext(ctx context.Context, conn net.Conn) context.Context {
+	if tlsConn, ok := conn.(*tls.Conn); ok {
+		conn = tlsConn.NetConn()
+	}
+
+	unixConn, ok := conn.(*net.UnixConn)
+	if !ok {
+		return context.WithValue(ctx, ctxKeyPeerUID{}, peerUIDResult{err: fmt.Errorf("connection is not a unix socket connection")})
+	}
+
+	uid, err := peerUID(unixConn)
+	return context.WithValue(ctx, ctxKeyPeerUID{}, peerUIDResult{uid: uid, err: err})
+}
+
+// AllowedClientUIDsHandler rejects the requests not sent by a process running
+// as one of the allowed user IDs. The connection context must have been set up
+// with PeerUIDConnContext.
+func AllowedClientUIDsHandler(h http.Handler, logger hclog.Logger, allowedUIDs []int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		result, ok := r.Context().Value(ctxKeyPeerUID{}).(peerUIDResult)
+		switch {
+		case !ok:
+			result.err = fmt.Errorf("the user ID of the client was not recorded")
+		case result.err == nil && !slices.Contains(allowedUIDs, result.uid):
+			result.err = fmt.Errorf("user ID %d is not allowed", result.uid)
+		}
+
+		if result.err != nil {
+			logger.Warn("rejecting request from disallowed client", "path", r.URL.Path, "error", result.err)
+			metrics.IncrCounter([]string{"agent", "proxy", "client_rejected"}, 1)
+			logical.RespondError(w, http.StatusForbidden, fmt.Errorf("client is not allowed to use this listener"))
+			return
+		}
+
+		h.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,15 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+//go:build !linux && !darwin
+
+package cache
+
+import (
+	"errors"
+	"net"
+)
+
+func peerUID(*net.UnixConn) (int, error) {
+	return 0, errors.New("the user ID of unix socket clients cannot be determined on this platform")
+}
@@ -0,0 +1,64 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package cache
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/sdk/helper/logging"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAllowedClientUIDsHandler tests that requests over a unix socket are
+// only served when the client runs as one of the allowed user IDs.
+func TestAllowedClientUIDsHandler(t *testing.T) {
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
+		t.Skip("peer credentials are only supported on linux and darwin")
+	}
+
+	logger := logging.NewVaultLogger(hclog.Trace)
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	for name, tc := range map[string]struct {
+		allowedUIDs []int
+		expected    int
+	}{
+		"allowed":     {allowedUIDs: []int{os.Getuid()}, expected: http.StatusNoContent},
+		"not allowed": {allowedUIDs: []int{os.Getuid() + 1}, expected: http.StatusForbidden},
+	} {
+		t.Run(name, func(t *testing.T) {
+			socket := filepath.Join(t.TempDir(), "proxy.sock")
+			ln, err := net.Listen("unix", socket)
+			require.NoError(t, err)
+
+			server := &http.Server{
+				Handler:     AllowedClientUIDsHandler(ok, logger, tc.allowedUIDs),
+				ConnContext: PeerUIDConnContext,
+			}
+			go server.Serve(ln)
+			t.Cleanup(func() { server.Close() })
+
+			client := &http.Client{
+				Transport: &http.Transport{
+					DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+						return (&net.Dialer{}).DialContext(ctx, "unix", socket)
+					},
+				},
+			}
+			resp, err := client.Get("http://proxy/v1/secret/foo")
+			require.NoError(t, err)
+			resp.Body.Close()
+			require.Equal(t, tc.expected, resp.StatusCode)
+		})
+	}
+}
@@ -17,6 +17,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/armon/go-metrics"
 	"github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/vault/api"
@@ -537,6 +538,7 @@ func (updater *StaticSecretCacheUpdater) updateStaticSecret(ctx context.Context,
 		if err != nil {
 			return err
 		}
+		metrics.IncrCounter([]string{"agent", "cache", "static_secret", "update"}, 1)
 	} else {
 		// No token could successfully update the secret, or secret was deleted.
 		// We should evict the cache instead of re-storing the secret.
@@ -545,6 +547,7 @@ func (updater *StaticSecretCacheUpdater) updateStaticSecret(ctx context.Context,
 		if err != nil {
 			return err
 		}
+		metrics.IncrCounter([]string{"agent", "cache", "static_secret", "eviction"}, 1)
 	}
 
 	return nil
@@ -0,0 +1,131 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package awssecretsmanager
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/aws/aws-sdk-go/service/secretsmanager/secretsmanageriface"
+	hclog "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/go-secure-stdlib/awsutil"
+	"github.com/hashicorp/vault/command/agentproxyshared/sink"
+)
+
+// awsSecretsManagerSink is a Sink implementation that writes a token as the
+// value of a secret in AWS Secrets Manager
+type awsSecretsManagerSink struct {
+	client   secretsmanageriface.SecretsManagerAPI
+	secretID string
+	kmsKeyID string
+	logger   hclog.Logger
+}
+
+// NewAWSSecretsManagerSink creates a new AWS Secrets Manager sink with the
+// given configuration
+func NewAWSSecretsManagerSink(conf *sink.SinkConfig) (sink.Sink, error) {
+	if conf.Logger == nil {
+		return nil, errors.New("nil logger provided")
+	}
+
+	conf.Logger.Info("creating aws secrets manager sink")
+
+	a := &awsSecretsManagerSink{
+		logger: conf.Logger,
+	}
+
+	secretIDRaw, ok := conf.Config["secret_id"]
+	if !ok {
+		return nil, errors.New("'secret_id' not specified for aws secrets manager sink")
+	}
+	a.secretID, ok = secretIDRaw.(string)
+	if !ok || a.secretID == "" {
+		return nil, errors.New("could not parse 'secret_id' as a non-empty string")
+	}
+
+	stringValues := map[string]string{}
+	for _, key := range []string{"kms_key_id", "region", "endpoint", "access_key", "secret_key", "session_token"} {
+		raw, ok := conf.Config[key]
+		if !ok {
+			continue
+		}
+		val, ok := raw.(string)
+		if !ok {
+			return nil, fmt.Errorf("could not parse '%s' as string", key)
+		}
+		stringValues[key] = val
+	}
+	a.kmsKeyID = stringValues["kms_key_id"]
+
+	region := stringValues["region"]
+	if region == "" {
+		region = awsutil.DefaultRegion
+	}
+
+	credsConfig := &awsutil.CredentialsConfig{
+		AccessKey:    stringValues["access_key"],
+		SecretKey:    stringValues["secret_key"],
+		SessionToken: stringValues["session_token"],
+		Region:       region,
+		Logger:       a.logger,
+	}
+	creds, err := credsConfig.GenerateCredentialChain()
+	if err != nil {
+		return nil, fmt.Errorf("error generating aws credentials: %w", err)
+	}
+
+	awsConfig := &aws.Config{
+		Credentials: creds,
+		Region:      aws.String(region),
+	}
+	if endpoint := stringValues["endpoint"]; endpoint != "" {
+		awsConfig.Endpoint = aws.String(endpoint)
+	}
+
+	sess, err := session.NewSession(awsConfig)
+	if err != nil {
+		return nil, fmt.Errorf("error creating aws session: %w", err)
+	}
+	a.client = secretsmanager.New(sess)
+
+	a.logger.Info("aws secrets manager sink configured", "secret_id", a.secretID, "region", region)
+
+	return a, nil
+}
+
+// WriteToken implements the Server interface and writes the token as a new
+// version of the secret, creating the secret if it does not exist.
+func (a *awsSecretsManagerSink) WriteToken(token string) error {
+	a.logger.Trace("enter write_token", "secret_id", a.secretID)
+	defer a.logger.Trace("exit write_token", "secret_id", a.secretID)
+
+	_, err := a.client.PutSecretValue(&secretsmanager.PutSecretValueInput{
+		SecretId:     aws.String(a.secretID),
+		SecretString: aws.String(token),
+	})
+
+	var aErr awserr.Error
+	if errors.As(err, &aErr) && aErr.Code() == secretsmanager.ErrCodeResourceNotFoundException {
+		a.logger.Debug("secret does not exist, creating it", "secret_id", a.secretID)
+		input := &secretsmanager.CreateSecretInput{
+			Name:         aws.String(a.secretID),
+			Description:  aws.String("Vault token written by Vault Agent auto-auth"),
+			SecretString: aws.String(token),
+		}
+		if a.kmsKeyID != "" {
+			input.KmsKeyId = aws.String(a.kmsKeyID)
+		}
+		_, err = a.client.CreateSecret(input)
+	}
+	if err != nil {
+		return fmt.Errorf("error writing token to aws secrets manager secret %s: %w", a.secretID, err)
+	}
+
+	a.logger.Info("token written", "secret_id", a.secretID)
+	return nil
+}
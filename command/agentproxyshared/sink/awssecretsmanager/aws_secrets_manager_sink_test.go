@@ -0,0 +1,87 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package awssecretsmanager
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/aws/aws-sdk-go/service/secretsmanager/secretsmanageriface"
+	hclog "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/command/agentproxyshared/sink"
+	"github.com/hashicorp/vault/sdk/helper/logging"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSecretsManager stores secret values in memory
+type fakeSecretsManager struct {
+	secretsmanageriface.SecretsManagerAPI
+	secrets  map[string]string
+	kmsKeyID map[string]string
+}
+
+func (f *fakeSecretsManager) PutSecretValue(input *secretsmanager.PutSecretValueInput) (*secretsmanager.PutSecretValueOutput, error) {
+	if _, ok := f.secrets[*input.SecretId]; !ok {
+		return nil, awserr.New(secretsmanager.ErrCodeResourceNotFoundException, "secret not found", nil)
+	}
+	f.secrets[*input.SecretId] = *input.SecretString
+	return &secretsmanager.PutSecretValueOutput{}, nil
+}
+
+func (f *fakeSecretsManager) CreateSecret(input *secretsmanager.CreateSecretInput) (*secretsmanager.CreateSecretOutput, error) {
+	f.secrets[*input.Name] = *input.SecretString
+	f.kmsKeyID[*input.Name] = aws.StringValue(input.KmsKeyId)
+	return &secretsmanager.CreateSecretOutput{}, nil
+}
+
+func TestAWSSecretsManagerSink(t *testing.T) {
+	log := logging.NewVaultLogger(hclog.Trace)
+
+	s, err := NewAWSSecretsManagerSink(&sink.SinkConfig{
+		Logger: log.Named("sink.aws_secrets_manager"),
+		Config: map[string]interface{}{
+			"secret_id":  "vault-agent/token",
+			"kms_key_id": "alias/vault-agent",
+			"region":     "us-west-2",
+			"endpoint":   "http://127.0.0.1:0",
+			"access_key": "AKIAEXAMPLE",
+			"secret_key": "secret",
+		},
+	})
+	require.NoError(t, err)
+
+	fake := &fakeSecretsManager{
+		secrets:  map[string]string{},
+		kmsKeyID: map[string]string{},
+	}
+	s.(*awsSecretsManagerSink).client = fake
+
+	// The secret is created by the first write, and updated by later writes
+	require.NoError(t, s.WriteToken("token1"))
+	require.Equal(t, "token1", fake.secrets["vault-agent/token"])
+	require.Equal(t, "alias/vault-agent", fake.kmsKeyID["vault-agent/token"])
+
+	require.NoError(t, s.WriteToken("token2"))
+	require.Equal(t, "token2", fake.secrets["vault-agent/token"])
+}
+
+func TestAWSSecretsManagerSink_Errors(t *testing.T) {
+	log := logging.NewVaultLogger(hclog.Trace)
+
+	for name, conf := range map[string]map[string]interface{}{
+		"missing secret_id": {},
+		"empty secret_id":   {"secret_id": ""},
+		"bad region":        {"secret_id": "foo", "region": 5},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewAWSSecretsManagerSink(&sink.SinkConfig{
+				Logger: log.Named("sink.aws_secrets_manager"),
+				Config: conf,
+			})
+			require.Error(t, err)
+		})
+	}
+}
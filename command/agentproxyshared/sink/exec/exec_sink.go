@@ -0,0 +1,121 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package exec
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	osexec "os/exec"
+	"strings"
+	"time"
+
+	hclog "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/go-secure-stdlib/parseutil"
+	"github.com/hashicorp/vault/command/agentproxyshared/sink"
+)
+
+const (
+	defaultTimeout = 30 * time.Second
+
+	// maxErrorOutput is the number of bytes of the command's output that are
+	// included in the error returned when it fails
+	maxErrorOutput = 512
+)
+
+// execSink is a Sink implementation that pipes a token into the standard
+// input of a user-provided command
+type execSink struct {
+	command []string
+	timeout time.Duration
+	logger  hclog.Logger
+}
+
+// NewExecSink creates a new exec sink with the given configuration
+func NewExecSink(conf *sink.SinkConfig) (sink.Sink, error) {
+	if conf.Logger == nil {
+		return nil, errors.New("nil logger provided")
+	}
+
+	conf.Logger.Info("creating exec sink")
+
+	e := &execSink{
+		logger:  conf.Logger,
+		timeout: defaultTimeout,
+	}
+
+	commandRaw, ok := conf.Config["command"]
+	if !ok {
+		return nil, errors.New("'command' not specified for exec sink")
+	}
+	command, err := parseutil.ParseCommaStringSlice(commandRaw)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse 'command' as a list of strings: %w", err)
+	}
+	if len(command) == 0 || command[0] == "" {
+		return nil, errors.New("'command' must not be empty")
+	}
+	e.command = command
+
+	if timeoutRaw, ok := conf.Config["timeout"]; ok {
+		timeout, err := parseutil.ParseDurationSecond(timeoutRaw)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse 'timeout': %w", err)
+		}
+		if timeout <= 0 {
+			return nil, errors.New("'timeout' must be positive")
+		}
+		e.timeout = timeout
+	}
+
+	if _, err := osexec.LookPath(e.command[0]); err != nil {
+		return nil, fmt.Errorf("error finding command %q: %w", e.command[0], err)
+	}
+
+	e.logger.Info("exec sink configured", "command", e.command[0], "timeout", e.timeout)
+
+	return e, nil
+}
+
+// WriteToken implements the Server interface and runs the command, writing
+// the token to its standard input. The token is not passed in the command's
+// arguments or environment, where it would be visible to other processes.
+func (e *execSink) WriteToken(token string) error {
+	e.logger.Trace("enter write_token", "command", e.command[0])
+	defer e.logger.Trace("exit write_token", "command", e.command[0])
+
+	ctx, cancel := context.WithTimeout(context.Background(), e.timeout)
+	defer cancel()
+
+	var output bytes.Buffer
+	cmd := osexec.CommandContext(ctx, e.command[0], e.command[1:]...)
+	cmd.Stdin = strings.NewReader(token)
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	// Don't wait on the output of any children left running after the command
+	// is killed on timeout
+	cmd.WaitDelay = time.Second
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			err = fmt.Errorf("timed out after %s", e.timeout)
+		}
+		return fmt.Errorf("error running command %q: %w: %s", e.command[0], err, errorOutput(output.String(), token))
+	}
+
+	e.logger.Info("token written", "command", e.command[0])
+	return nil
+}
+
+// errorOutput returns the output of a failed command for inclusion in an
+// error, which ends up in the logs. The token is redacted in case the command
+// echoed its input, and the output is truncated to maxErrorOutput bytes.
+func errorOutput(output, token string) string {
+	output = strings.TrimSpace(strings.ReplaceAll(output, token, "[redacted]"))
+	if len(output) > maxErrorOutput {
+		output = strings.ToValidUTF8(output[:maxErrorOutput], "") + "... (truncated)"
+	}
+	return output
+}
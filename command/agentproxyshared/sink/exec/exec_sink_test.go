@@ -0,0 +1,113 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package exec
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	hclog "github.com/hashicorp/go-hclog"
+	uuid "github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/command/agentproxyshared/sink"
+	"github.com/hashicorp/vault/sdk/helper/logging"
+)
+
+func TestExecSink(t *testing.T) {
+	log := logging.NewVaultLogger(hclog.Trace)
+	path := filepath.Join(t.TempDir(), "token")
+
+	config := &sink.SinkConfig{
+		Logger: log.Named("sink.exec"),
+		Config: map[string]interface{}{
+			"command": []interface{}{"sh", "-c", `cat > "$0"`, path},
+		},
+	}
+	s, err := NewExecSink(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	uuidStr, _ := uuid.GenerateUUID()
+	if err := s.WriteToken(uuidStr); err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(token) != uuidStr {
+		t.Fatalf("expected %s, got %s", uuidStr, string(token))
+	}
+}
+
+func TestExecSink_Errors(t *testing.T) {
+	log := logging.NewVaultLogger(hclog.Trace)
+
+	for name, conf := range map[string]map[string]interface{}{
+		"missing command": {},
+		"empty command":   {"command": []interface{}{}},
+		"unknown command": {"command": []interface{}{"vault-no-such-command"}},
+		"bad timeout":     {"command": []interface{}{"true"}, "timeout": "-1s"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewExecSink(&sink.SinkConfig{
+				Logger: log.Named("sink.exec"),
+				Config: conf,
+			})
+			if err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+
+	s, err := NewExecSink(&sink.SinkConfig{
+		Logger: log.Named("sink.exec"),
+		Config: map[string]interface{}{
+			"command": []interface{}{"sh", "-c", "echo failed writing token; exit 1"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = s.WriteToken("foo")
+	if err == nil || !strings.Contains(err.Error(), "failed writing token") {
+		t.Fatalf("expected error including the command's output, got %v", err)
+	}
+
+	// The token is redacted from the output, and long output is truncated
+	s, err = NewExecSink(&sink.SinkConfig{
+		Logger: log.Named("sink.exec"),
+		Config: map[string]interface{}{
+			"command": []interface{}{"sh", "-c", "cat; head -c 4096 /dev/zero | tr '\\0' x; exit 1"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = s.WriteToken("s.secret-token")
+	if err == nil || strings.Contains(err.Error(), "s.secret-token") || !strings.Contains(err.Error(), "[redacted]") {
+		t.Fatalf("expected error with the token redacted, got %v", err)
+	}
+	if !strings.HasSuffix(err.Error(), "... (truncated)") || len(err.Error()) > 2*maxErrorOutput {
+		t.Fatalf("expected truncated error, got %d bytes", len(err.Error()))
+	}
+
+	s, err = NewExecSink(&sink.SinkConfig{
+		Logger: log.Named("sink.exec"),
+		Config: map[string]interface{}{
+			"command": []interface{}{"sleep", "10"},
+			"timeout": "100ms",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = s.WriteToken("foo")
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Fatalf("expected timeout error, got %v", err)
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package kubernetes
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	hclog "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/command/agentproxyshared/sink"
+	"github.com/hashicorp/vault/serviceregistration/kubernetes/client"
+)
+
+const (
+	defaultKey          = "token"
+	defaultFieldManager = "vault-agent"
+)
+
+// kubernetesSink is a Sink implementation that writes a token to a key of a
+// Kubernetes Secret, using the service account of the pod it runs in.
+type kubernetesSink struct {
+	client       *client.Client
+	namespace    string
+	secretName   string
+	key          string
+	fieldManager string
+	logger       hclog.Logger
+}
+
+// NewKubernetesSink creates a new Kubernetes Secret sink with the given
+// configuration
+func NewKubernetesSink(conf *sink.SinkConfig) (sink.Sink, error) {
+	if conf.Logger == nil {
+		return nil, errors.New("nil logger provided")
+	}
+
+	conf.Logger.Info("creating kubernetes secret sink")
+
+	k := &kubernetesSink{
+		logger:       conf.Logger,
+		key:          defaultKey,
+		fieldManager: defaultFieldManager,
+	}
+
+	secretNameRaw, ok := conf.Config["secret_name"]
+	if !ok {
+		return nil, errors.New("'secret_name' not specified for kubernetes secret sink")
+	}
+	k.secretName, ok = secretNameRaw.(string)
+	if !ok || k.secretName == "" {
+		return nil, errors.New("could not parse 'secret_name' as a non-empty string")
+	}
+
+	if namespaceRaw, ok := conf.Config["namespace"]; ok {
+		k.namespace, ok = namespaceRaw.(string)
+		if !ok {
+			return nil, errors.New("could not parse 'namespace' as string")
+		}
+	}
+	if k.namespace == "" {
+		k.namespace = os.Getenv(client.EnvVarKubernetesNamespace)
+	}
+	if k.namespace == "" {
+		namespace, err := os.ReadFile(client.NamespaceFile)
+		if err != nil {
+			return nil, fmt.Errorf("'namespace' not specified and unable to read the service account's namespace: %w", err)
+		}
+		k.namespace = strings.TrimSpace(string(namespace))
+	}
+
+	if keyRaw, ok := conf.Config["key"]; ok {
+		k.key, ok = keyRaw.(string)
+		if !ok || k.key == "" {
+			return nil, errors.New("could not parse 'key' as a non-empty string")
+		}
+	}
+
+	if fieldManagerRaw, ok := conf.Config["field_manager"]; ok {
+		k.fieldManager, ok = fieldManagerRaw.(string)
+		if !ok || k.fieldManager == "" {
+			return nil, errors.New("could not parse 'field_manager' as a non-empty string")
+		}
+	}
+
+	var err error
+	k.client, err = client.New(k.logger)
+	if err != nil {
+		return nil, fmt.Errorf("error creating kubernetes client: %w", err)
+	}
+
+	k.logger.Info("kubernetes secret sink configured", "namespace", k.namespace, "secret_name", k.secretName, "key", k.key)
+
+	return k, nil
+}
+
+// WriteToken implements the Server interface and writes the token to the
+// configured key of the Kubernetes Secret, creating the secret if it does not
+// exist. Other keys of the secret are left untouched.
+func (k *kubernetesSink) WriteToken(token string) error {
+	k.logger.Trace("enter write_token", "secret_name", k.secretName)
+	defer k.logger.Trace("exit write_token", "secret_name", k.secretName)
+
+	if err := k.client.ApplySecret(k.namespace, k.secretName, k.fieldManager, map[string][]byte{
+		k.key: []byte(token),
+	}); err != nil {
+		return fmt.Errorf("error writing token to kubernetes secret %s/%s: %w", k.namespace, k.secretName, err)
+	}
+
+	k.logger.Info("token written", "namespace", k.namespace, "secret_name", k.secretName)
+	return nil
+}
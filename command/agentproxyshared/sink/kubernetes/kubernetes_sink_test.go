@@ -0,0 +1,117 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package kubernetes
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	hclog "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/command/agentproxyshared/sink"
+	"github.com/hashicorp/vault/sdk/helper/logging"
+	"github.com/hashicorp/vault/serviceregistration/kubernetes/client"
+	kubetest "github.com/hashicorp/vault/serviceregistration/kubernetes/testing"
+	"github.com/stretchr/testify/require"
+)
+
+// testSecretsServer fakes server-side applies of secrets by the Kubernetes
+// API, and configures the in-cluster client to use it.
+func testSecretsServer(t *testing.T) (secrets *sync.Map) {
+	// Reuse the token and CA files written for the service registration tests
+	_, testConf, closeFunc := kubetest.Server(t)
+	t.Cleanup(closeFunc)
+
+	secrets = &sync.Map{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPatch ||
+			r.Header.Get("Content-Type") != "application/apply-patch+yaml" ||
+			r.URL.Query().Get("fieldManager") == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var secret client.Secret
+		if err := json.NewDecoder(r.Body).Decode(&secret); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		secrets.Store(r.URL.Path, &secret)
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(ts.Close)
+
+	u, err := url.Parse(ts.URL)
+	require.NoError(t, err)
+	host, port, err := net.SplitHostPort(u.Host)
+	require.NoError(t, err)
+
+	client.Scheme = testConf.ClientScheme
+	client.TokenFile = testConf.PathToTokenFile
+	client.RootCAFile = testConf.PathToRootCAFile
+	t.Setenv(client.EnvVarKubernetesServiceHost, host)
+	t.Setenv(client.EnvVarKubernetesServicePort, port)
+
+	return secrets
+}
+
+func TestKubernetesSink(t *testing.T) {
+	secrets := testSecretsServer(t)
+	log := logging.NewVaultLogger(hclog.Trace)
+
+	s, err := NewKubernetesSink(&sink.SinkConfig{
+		Logger: log.Named("sink.kubernetes_secret"),
+		Config: map[string]interface{}{
+			"secret_name": "vault-token",
+			"namespace":   "apps",
+			"key":         "vault-token",
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, s.WriteToken("foo"))
+
+	raw, ok := secrets.Load("/api/v1/namespaces/apps/secrets/vault-token")
+	require.True(t, ok)
+	secret := raw.(*client.Secret)
+	require.Equal(t, "Secret", secret.Kind)
+	require.Equal(t, "vault-token", secret.Metadata.Name)
+	require.Equal(t, "apps", secret.Metadata.Namespace)
+	require.Equal(t, map[string][]byte{"vault-token": []byte("foo")}, secret.Data)
+}
+
+func TestKubernetesSink_DefaultNamespace(t *testing.T) {
+	secrets := testSecretsServer(t)
+	log := logging.NewVaultLogger(hclog.Trace)
+
+	namespaceFile := filepath.Join(t.TempDir(), "namespace")
+	require.NoError(t, os.WriteFile(namespaceFile, []byte("default\n"), 0o600))
+	oldNamespaceFile := client.NamespaceFile
+	client.NamespaceFile = namespaceFile
+	t.Cleanup(func() { client.NamespaceFile = oldNamespaceFile })
+	t.Setenv(client.EnvVarKubernetesNamespace, "")
+
+	s, err := NewKubernetesSink(&sink.SinkConfig{
+		Logger: log.Named("sink.kubernetes_secret"),
+		Config: map[string]interface{}{
+			"secret_name": "vault-token",
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, s.WriteToken("foo"))
+
+	raw, ok := secrets.Load("/api/v1/namespaces/default/secrets/vault-token")
+	require.True(t, ok)
+	require.Equal(t, map[string][]byte{defaultKey: []byte("foo")}, raw.(*client.Secret).Data)
+
+	_, err = NewKubernetesSink(&sink.SinkConfig{
+		Logger: log.Named("sink.kubernetes_secret"),
+		Config: map[string]interface{}{},
+	})
+	require.Error(t, err)
+}
@@ -90,6 +90,9 @@ func (b *BaseCommand) PredictVaultAvailableMounts() complete.Predictor {
 		"consul",
 		"database",
 		"generic",
+		"gpg",
+		"notary",
+		"oauth2",
 		"pki",
 		"plugin",
 		"rabbitmq",
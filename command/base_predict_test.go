@@ -359,6 +359,7 @@ func TestPredict_Plugins(t *testing.T) {
 				"gcp",
 				"gcpkms",
 				"github",
+				"gpg",
 				"hana-database-plugin",
 				"influxdb-database-plugin",
 				"jwt",
@@ -377,6 +378,8 @@ func TestPredict_Plugins(t *testing.T) {
 				"mysql-legacy-database-plugin",
 				"mysql-rds-database-plugin",
 				"nomad",
+				"notary",
+				"oauth2",
 				"oci",
 				"oidc",
 				"okta",
@@ -159,6 +159,7 @@ func newMinimalVaultHandlers() *vaultHandlers {
 		},
 		auditBackends: map[string]audit.Factory{
 			"file":   audit.NewFileBackend,
+			"kafka":  audit.NewKafkaBackend,
 			"socket": audit.NewSocketBackend,
 			"syslog": audit.NewSyslogBackend,
 		},
@@ -468,6 +469,13 @@ func initCommands(ui, serverCmdUi cli.Ui, runOpts *RunOptions) map[string]cli.Co
 				BaseCommand: getBaseCommand(),
 			}, nil
 		},
+		"operator storage-bench": func() (cli.Command, error) {
+			return &OperatorStorageBenchCommand{
+				BaseCommand:      getBaseCommand(),
+				PhysicalBackends: handlers.physicalBackends,
+				ShutdownCh:       MakeShutdownCh(),
+			}, nil
+		},
 		"operator usage": func() (cli.Command, error) {
 			return &OperatorUsageCommand{
 				BaseCommand: getBaseCommand(),
@@ -33,6 +33,13 @@ var VisibleReqParams = []string{
 	"street_address",
 	"postal_code",
 	"permitted_dns_domains",
+	"excluded_dns_domains",
+	"permitted_ip_ranges",
+	"excluded_ip_ranges",
+	"permitted_email_addresses",
+	"excluded_email_addresses",
+	"permitted_uri_domains",
+	"excluded_uri_domains",
 	"policy_identifiers",
 	"ext_key_usage_oids",
 }
@@ -0,0 +1,326 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package healthcheck
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/go-secure-stdlib/parseutil"
+	"github.com/hashicorp/hcl"
+	"github.com/hashicorp/vault/sdk/helper/strutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// unsafeEndpoints are representative request paths of the endpoints of a
+// mount which sign certificates without the restrictions of a role, or which
+// sign new CA certificates.
+var unsafeEndpoints = []string{
+	"sign-verbatim",
+	"sign-verbatim/role",
+	"issuer/default/sign-verbatim",
+	"issuer/default/sign-verbatim/role",
+	"root/sign-intermediate",
+	"issuer/default/sign-intermediate",
+	"root/sign-self-issued",
+	"issuer/default/sign-self-issued",
+}
+
+type policyPathRules struct {
+	Policy       string   `hcl:"policy"`
+	Capabilities []string `hcl:"capabilities"`
+}
+
+type policyRules struct {
+	Paths map[string]policyPathRules `hcl:"path"`
+}
+
+type PolicyAllowEndpoints struct {
+	Enabled            bool
+	UnsupportedVersion bool
+
+	AllowedPolicies map[string]bool
+
+	PolicyListFetchIssue *PathFetch
+	PolicyFetchIssues    map[string]*PathFetch
+	PolicyRulesMap       map[string]string
+}
+
+func NewPolicyAllowEndpointsCheck() Check {
+	return &PolicyAllowEndpoints{
+		AllowedPolicies:   make(map[string]bool),
+		PolicyFetchIssues: make(map[string]*PathFetch),
+		PolicyRulesMap:    make(map[string]string),
+	}
+}
+
+func (h *PolicyAllowEndpoints) Name() string {
+	return "policy_allow_endpoints"
+}
+
+func (h *PolicyAllowEndpoints) IsEnabled() bool {
+	return h.Enabled
+}
+
+func (h *PolicyAllowEndpoints) DefaultConfig() map[string]interface{} {
+	return map[string]interface{}{
+		"allowed_policies": []string{},
+	}
+}
+
+func (h *PolicyAllowEndpoints) LoadConfig(config map[string]interface{}) error {
+	value, present := config["allowed_policies"].([]interface{})
+	if present {
+		for _, rawValue := range value {
+			h.AllowedPolicies[rawValue.(string)] = true
+		}
+	}
+
+	enabled, err := parseutil.ParseBool(config["enabled"])
+	if err != nil {
+		return fmt.Errorf("error parsing %v.enabled: %w", h.Name(), err)
+	}
+	h.Enabled = enabled
+
+	return nil
+}
+
+func (h *PolicyAllowEndpoints) FetchResources(e *Executor) error {
+	listRet, err := e.FetchIfNotFetched(logical.ListOperation, "/sys/policy")
+	if err != nil {
+		return err
+	}
+	if !listRet.IsSecretOK() {
+		if listRet.IsUnsupportedPathError() {
+			h.UnsupportedVersion = true
+		}
+		h.PolicyListFetchIssue = listRet
+		return nil
+	}
+
+	for _, rawName := range listRet.Secret.Data["keys"].([]interface{}) {
+		name := rawName.(string)
+		if name == "root" || h.AllowedPolicies[name] {
+			// The root policy has no rules to inspect.
+			continue
+		}
+
+		policyRet, err := e.FetchIfNotFetched(logical.ReadOperation, "/sys/policy/"+name)
+		if err != nil {
+			return err
+		}
+		if !policyRet.IsSecretOK() {
+			h.PolicyFetchIssues[name] = policyRet
+			continue
+		}
+
+		rules, _ := policyRet.Secret.Data["rules"].(string)
+		h.PolicyRulesMap[name] = rules
+	}
+
+	return nil
+}
+
+func (h *PolicyAllowEndpoints) Evaluate(e *Executor) (results []*Result, err error) {
+	if h.UnsupportedVersion {
+		// Shouldn't happen; policies have been around forever.
+		ret := Result{
+			Status:   ResultInvalidVersion,
+			Endpoint: "/sys/policy",
+			Message:  "This health check requires Vault 1.11+ but an earlier version of Vault Server was contacted, preventing this health check from running.",
+		}
+		return []*Result{&ret}, nil
+	}
+
+	if h.PolicyListFetchIssue != nil {
+		if !h.PolicyListFetchIssue.IsSecretPermissionsError() {
+			return nil, nil
+		}
+
+		ret := Result{
+			Status:   ResultInsufficientPermissions,
+			Endpoint: h.PolicyListFetchIssue.Path,
+			Message:  "lacks permission to list the ACL policies. Without this information, this health check is unable to function.",
+		}
+		if e.Client.Token() == "" {
+			ret.Message = "No token available and so this health check " + ret.Message
+		} else {
+			ret.Message = "This token " + ret.Message
+		}
+		return []*Result{&ret}, nil
+	}
+
+	for name, fetchPath := range h.PolicyFetchIssues {
+		if fetchPath.IsSecretPermissionsError() {
+			ret := Result{
+				Status:   ResultInsufficientPermissions,
+				Endpoint: fetchPath.Path,
+				Message:  fmt.Sprintf("This token lacks permission to read the ACL policy %v, so whether it allows access to problematic endpoints could not be checked.", name),
+			}
+			results = append(results, &ret)
+		}
+	}
+
+	var names []string
+	for name := range h.PolicyRulesMap {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		var rules policyRules
+		if err := hcl.Decode(&rules, h.PolicyRulesMap[name]); err != nil {
+			ret := Result{
+				Status:   ResultInformational,
+				Endpoint: "/sys/policy/" + name,
+				Message:  fmt.Sprintf("Unable to parse the ACL policy %v, so whether it allows access to problematic endpoints could not be checked: %v", name, err),
+			}
+			results = append(results, &ret)
+			continue
+		}
+
+		granting := unsafeEndpointGrants(e.Mount, rules)
+		if len(granting) == 0 {
+			continue
+		}
+
+		ret := Result{
+			Status:   ResultCritical,
+			Endpoint: "/sys/policy/" + name,
+			Message:  fmt.Sprintf("ACL policy %v allows writing to endpoints of this mount which sign certificates without the restrictions of a role or sign new CA certificates (sign-verbatim, sign-intermediate or sign-self-issued), through the path(s) %v. Unless this is intended, narrow these paths or explicitly deny these endpoints; otherwise add this policy to allowed_policies.", name, strings.Join(granting, ", ")),
+		}
+		results = append(results, &ret)
+	}
+
+	if len(results) == 0 {
+		ret := Result{
+			Status:   ResultOK,
+			Endpoint: "/sys/policy",
+			Message:  "No ACL policy allows writing to problematic endpoints of this mount.",
+		}
+		results = append(results, &ret)
+	}
+
+	return
+}
+
+// unsafeEndpointGrants returns the policy paths which allow writing to one
+// of the unsafe endpoints under mount. As when Vault evaluates a policy, only
+// the most specific path matching an endpoint applies to it, so endpoints
+// which a more specific rule restricts or denies are not reported.
+func unsafeEndpointGrants(mount string, rules policyRules) []string {
+	mount = strings.Trim(mount, "/")
+
+	var granting []string
+	for _, endpoint := range unsafeEndpoints {
+		path := mount + "/" + endpoint
+
+		var best string
+		found := false
+		for pattern := range rules.Paths {
+			if !policyPathMatches(strings.TrimPrefix(pattern, "/"), path) {
+				continue
+			}
+			if !found || policyPathLess(strings.TrimPrefix(best, "/"), strings.TrimPrefix(pattern, "/")) {
+				best = pattern
+				found = true
+			}
+		}
+		if !found || !policyRuleAllowsWrite(rules.Paths[best]) {
+			continue
+		}
+
+		if !strutil.StrListContains(granting, best) {
+			granting = append(granting, best)
+		}
+	}
+
+	sort.Strings(granting)
+	return granting
+}
+
+// policyRuleAllowsWrite reports whether rule grants write access, ignoring
+// rules which also deny it.
+func policyRuleAllowsWrite(rule policyPathRules) bool {
+	if rule.Policy == "deny" || strutil.StrListContains(rule.Capabilities, "deny") {
+		return false
+	}
+
+	switch rule.Policy {
+	case "write", "sudo":
+		return true
+	}
+	for _, capability := range rule.Capabilities {
+		switch capability {
+		case "create", "update", "sudo":
+			return true
+		}
+	}
+
+	return false
+}
+
+// policyPathLess reports whether the ACL policy path pattern a has a lower
+// priority than b when both match a path, following the order Vault itself
+// uses: exact paths win, then the later first wildcard or glob, paths not
+// ending in a glob, fewer + segments, longer paths and finally the
+// lexicographically larger path.
+func policyPathLess(a string, b string) bool {
+	aExact := !strings.ContainsAny(a, "+*")
+	bExact := !strings.ContainsAny(b, "+*")
+	if aExact != bExact {
+		return bExact
+	}
+
+	aFirst, bFirst := strings.IndexAny(a, "+*"), strings.IndexAny(b, "+*")
+	if aFirst != bFirst {
+		return aFirst < bFirst
+	}
+
+	aPrefix, bPrefix := strings.HasSuffix(a, "*"), strings.HasSuffix(b, "*")
+	if aPrefix != bPrefix {
+		return aPrefix
+	}
+	a, b = strings.TrimSuffix(a, "*"), strings.TrimSuffix(b, "*")
+
+	aWildcards, bWildcards := strings.Count(a, "+"), strings.Count(b, "+")
+	if aWildcards != bWildcards {
+		return aWildcards > bWildcards
+	}
+
+	if len(a) != len(b) {
+		return len(a) < len(b)
+	}
+
+	return a < b
+}
+
+// policyPathMatches reports whether an ACL policy path pattern, which may use
+// + for a single segment and a trailing * for a prefix, matches path.
+func policyPathMatches(pattern string, path string) bool {
+	isPrefix := strings.HasSuffix(pattern, "*")
+	pattern = strings.TrimSuffix(pattern, "*")
+
+	patternSegments := strings.Split(pattern, "/")
+	pathSegments := strings.Split(path, "/")
+	if len(patternSegments) > len(pathSegments) || (!isPrefix && len(patternSegments) != len(pathSegments)) {
+		return false
+	}
+
+	for index, segment := range patternSegments {
+		last := index == len(patternSegments)-1
+		switch {
+		case segment == "+":
+		case last && isPrefix:
+			if !strings.HasPrefix(pathSegments[index], segment) {
+				return false
+			}
+		case segment != pathSegments[index]:
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,208 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package healthcheck
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/hashicorp/go-secure-stdlib/parseutil"
+)
+
+type RoleMaxTTL struct {
+	Enabled            bool
+	UnsupportedVersion bool
+
+	MaxTTLCritical time.Duration
+	MaxTTLWarning  time.Duration
+
+	RoleListFetchIssue *PathFetch
+	RoleFetchIssues    map[string]*PathFetch
+	RoleEntryMap       map[string]map[string]interface{}
+
+	// MountMaxTTL is the max lease TTL of the mount, used for roles without
+	// an explicit max_ttl; zero if it could not be read.
+	MountMaxTTL time.Duration
+}
+
+func NewRoleMaxTTLCheck() Check {
+	return &RoleMaxTTL{
+		RoleFetchIssues: make(map[string]*PathFetch),
+		RoleEntryMap:    make(map[string]map[string]interface{}),
+	}
+}
+
+func (h *RoleMaxTTL) Name() string {
+	return "role_max_ttl"
+}
+
+func (h *RoleMaxTTL) IsEnabled() bool {
+	return h.Enabled
+}
+
+func (h *RoleMaxTTL) DefaultConfig() map[string]interface{} {
+	return map[string]interface{}{
+		"max_ttl_critical": "398d",
+		"max_ttl_warning":  "90d",
+	}
+}
+
+func (h *RoleMaxTTL) fromConfig(config map[string]interface{}, param string) (time.Duration, error) {
+	value, err := parseutil.ParseDurationSecond(config[param])
+	if err != nil {
+		return time.Duration(0), fmt.Errorf("failed to parse parameter %v.%v=%v: %w", h.Name(), param, config[param], err)
+	}
+
+	return value, nil
+}
+
+func (h *RoleMaxTTL) LoadConfig(config map[string]interface{}) error {
+	var err error
+
+	h.MaxTTLCritical, err = h.fromConfig(config, "max_ttl_critical")
+	if err != nil {
+		return err
+	}
+
+	h.MaxTTLWarning, err = h.fromConfig(config, "max_ttl_warning")
+	if err != nil {
+		return err
+	}
+
+	h.Enabled, err = parseutil.ParseBool(config["enabled"])
+	if err != nil {
+		return fmt.Errorf("error parsing %v.enabled: %w", h.Name(), err)
+	}
+
+	return nil
+}
+
+func (h *RoleMaxTTL) FetchResources(e *Executor) error {
+	exit, f, roles, err := pkiFetchRolesList(e, func() {
+		h.UnsupportedVersion = true
+	})
+	if exit || err != nil {
+		if f != nil && f.IsSecretPermissionsError() {
+			h.RoleListFetchIssue = f
+		}
+		return err
+	}
+
+	for _, role := range roles {
+		skip, f, entry, err := pkiFetchRole(e, role, func() {
+			h.UnsupportedVersion = true
+		})
+		if skip || err != nil || entry == nil {
+			if f != nil && f.IsSecretPermissionsError() {
+				h.RoleFetchIssues[role] = f
+			}
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		h.RoleEntryMap[role] = entry
+	}
+
+	// Roles without a max_ttl fall back to the mount's; when it cannot be
+	// read, those roles are skipped rather than failing the whole check.
+	exit, _, tune, err := fetchMountTune(e, func() {})
+	if err != nil {
+		return err
+	}
+	if !exit && tune != nil {
+		if maxTTL, err := parseutil.ParseDurationSecond(tune["max_lease_ttl"]); err == nil {
+			h.MountMaxTTL = maxTTL
+		}
+	}
+
+	return nil
+}
+
+func (h *RoleMaxTTL) Evaluate(e *Executor) (results []*Result, err error) {
+	if h.UnsupportedVersion {
+		// Shouldn't happen; roles have been around forever.
+		ret := Result{
+			Status:   ResultInvalidVersion,
+			Endpoint: "/{{mount}}/roles",
+			Message:  "This health check requires Vault 1.11+ but an earlier version of Vault Server was contacted, preventing this health check from running.",
+		}
+		return []*Result{&ret}, nil
+	}
+
+	if h.RoleListFetchIssue != nil && h.RoleListFetchIssue.IsSecretPermissionsError() {
+		ret := Result{
+			Status:   ResultInsufficientPermissions,
+			Endpoint: h.RoleListFetchIssue.Path,
+			Message:  "lacks permission either to list the roles. This restricts the ability to fully execute this health check.",
+		}
+		if e.Client.Token() == "" {
+			ret.Message = "No token available and so this health check " + ret.Message
+		} else {
+			ret.Message = "This token " + ret.Message
+		}
+		return []*Result{&ret}, nil
+	}
+
+	for role, fetchPath := range h.RoleFetchIssues {
+		if fetchPath != nil && fetchPath.IsSecretPermissionsError() {
+			delete(h.RoleEntryMap, role)
+			ret := Result{
+				Status:   ResultInsufficientPermissions,
+				Endpoint: fetchPath.Path,
+				Message:  "Without this information, this health check is unable to function.",
+			}
+
+			if e.Client.Token() == "" {
+				ret.Message = "No token available so unable for the endpoint for this mount. " + ret.Message
+			} else {
+				ret.Message = "This token lacks permission the endpoint for this mount. " + ret.Message
+			}
+
+			results = append(results, &ret)
+		}
+	}
+
+	var roles []string
+	for role := range h.RoleEntryMap {
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+
+	for _, role := range roles {
+		entry := h.RoleEntryMap[role]
+		maxTTL, err := parseutil.ParseDurationSecond(entry["max_ttl"])
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse max_ttl of role %v: %w", role, err)
+		}
+
+		source := "max_ttl"
+		if maxTTL == 0 {
+			if h.MountMaxTTL == 0 {
+				continue
+			}
+			maxTTL = h.MountMaxTTL
+			source = "inherited mount max_lease_ttl"
+		}
+
+		ret := Result{
+			Status:   ResultOK,
+			Endpoint: "/{{mount}}/roles/" + role,
+			Message:  fmt.Sprintf("Role allows issuing certificates valid for up to %v (%v), which is below the warning threshold of %v.", FormatDuration(maxTTL), source, FormatDuration(h.MaxTTLWarning)),
+		}
+		if maxTTL > h.MaxTTLCritical {
+			ret.Status = ResultCritical
+			ret.Message = fmt.Sprintf("Role allows issuing certificates valid for up to %v (%v), exceeding the critical threshold of %v. Long-lived leaf certificates stay usable long after a compromise and may be rejected by clients enforcing maximum validity periods; consider lowering max_ttl on this role.", FormatDuration(maxTTL), source, FormatDuration(h.MaxTTLCritical))
+		} else if maxTTL > h.MaxTTLWarning {
+			ret.Status = ResultWarning
+			ret.Message = fmt.Sprintf("Role allows issuing certificates valid for up to %v (%v), exceeding the warning threshold of %v. Consider lowering max_ttl on this role and relying on automated renewal instead.", FormatDuration(maxTTL), source, FormatDuration(h.MaxTTLWarning))
+		}
+
+		results = append(results, &ret)
+	}
+
+	return
+}
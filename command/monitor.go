@@ -10,6 +10,7 @@ import (
 
 	"github.com/hashicorp/cli"
 	"github.com/hashicorp/go-secure-stdlib/strutil"
+	"github.com/hashicorp/vault/api"
 	"github.com/posener/complete"
 )
 
@@ -21,8 +22,10 @@ var (
 type MonitorCommand struct {
 	*BaseCommand
 
-	logLevel  string
-	logFormat string
+	logLevel   string
+	logFormat  string
+	subsystems []string
+	sampleRate float64
 
 	// ShutdownCh is used to capture interrupt signal and end streaming
 	ShutdownCh chan struct{}
@@ -41,6 +44,16 @@ Usage: vault monitor [options]
 	the server may be logging at the INFO level, but with the monitor command
 	you can set -log-level=DEBUG.
 
+	Only stream the debug logs of the Raft storage backend and the expiration
+	manager, in the JSON format:
+
+	  $ vault monitor -log-level=debug -log-format=json \
+	      -subsystem=storage.raft -subsystem=expiration
+
+	Only stream a tenth of the trace logs, along with all warnings and errors:
+
+	  $ vault monitor -log-level=trace -sample-rate=0.1
+
 ` + c.Flags().Help()
 
 	return strings.TrimSpace(helpText)
@@ -66,6 +79,19 @@ func (c *MonitorCommand) Flags() *FlagSets {
 		Completion: complete.PredictSet("standard", "json"),
 		Usage:      "Output format of logs. Supported values are \"standard\" and \"json\".",
 	})
+	f.StringSliceVar(&StringSliceVar{
+		Name:   "subsystem",
+		Target: &c.subsystems,
+		Usage: "If passed, only stream logs emitted by this subsystem, such as " +
+			"\"storage.raft\" or \"expiration\", or by subsystems named beneath " +
+			"it. This can be specified multiple times.",
+	})
+	f.Float64Var(&Float64Var{
+		Name:   "sample-rate",
+		Target: &c.sampleRate,
+		Usage: "If passed, the fraction of logs below the warn level to stream, " +
+			"between 0 and 1. Warnings and errors are always streamed.",
+	})
 
 	return set
 }
@@ -106,6 +132,11 @@ func (c *MonitorCommand) Run(args []string) int {
 		return 1
 	}
 
+	if c.sampleRate < 0 || c.sampleRate > 1 {
+		c.UI.Error(fmt.Sprintf("%v is an invalid sample rate. The sample rate must be between 0 and 1", c.sampleRate))
+		return 1
+	}
+
 	client, err := c.Client()
 	if err != nil {
 		c.UI.Error(err.Error())
@@ -118,7 +149,12 @@ func (c *MonitorCommand) Run(args []string) int {
 	var logCh chan string
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
-	logCh, err = client.Sys().Monitor(ctx, c.logLevel, c.logFormat)
+	logCh, err = client.Sys().MonitorWithOptions(ctx, &api.MonitorInput{
+		LogLevel:   c.logLevel,
+		LogFormat:  c.logFormat,
+		Subsystems: c.subsystems,
+		SampleRate: c.sampleRate,
+	})
 	if err != nil {
 		c.UI.Error(fmt.Sprintf("Error starting monitor: %s", err))
 		return 1
@@ -126,9 +126,9 @@ func (c *OperatorInitCommand) Flags() *FlagSets {
 		Value:      (*pgpkeys.PubKeyFilesFlag)(&c.flagPGPKeys),
 		Completion: complete.PredictAnything,
 		Usage: "Comma-separated list of paths to files on disk containing " +
-			"public PGP keys OR a comma-separated list of Keybase usernames using " +
-			"the format \"keybase:<username>\". When supplied, the generated " +
-			"unseal keys will be encrypted and base64-encoded in the order " +
+			"public PGP keys, age recipients (\"age1...\") OR a comma-separated list " +
+			"of Keybase usernames using the format \"keybase:<username>\". When " +
+			"supplied, the generated unseal keys will be encrypted and base64-encoded in the order " +
 			"specified in this list. The number of entries must match -key-shares, " +
 			"unless -stored-shares are used.",
 	})
@@ -283,36 +283,46 @@ func (c *OperatorMigrateCommand) createDestinationBackend(kind string, conf map[
 
 	switch kind {
 	case "raft":
-		if len(config.ClusterAddr) == 0 {
-			return nil, errors.New("cluster_addr config not set")
+		if err := bootstrapRaftStorage(storage, config.ClusterAddr); err != nil {
+			return nil, err
 		}
+	}
 
-		raftStorage, ok := storage.(*raft.RaftBackend)
-		if !ok {
-			return nil, errors.New("wrong storage type for raft backend")
-		}
+	return storage, nil
+}
 
-		parsedClusterAddr, err := url.Parse(config.ClusterAddr)
-		if err != nil {
-			return nil, fmt.Errorf("error parsing cluster address: %w", err)
-		}
-		if err := raftStorage.Bootstrap([]raft.Peer{
-			{
-				ID:      raftStorage.NodeID(),
-				Address: parsedClusterAddr.Host,
-			},
-		}); err != nil {
-			return nil, fmt.Errorf("could not bootstrap clustered storage: %w", err)
-		}
+// bootstrapRaftStorage bootstraps a new single-node cluster on the given raft
+// storage, so that it can be written to without a Vault server.
+func bootstrapRaftStorage(storage physical.Backend, clusterAddr string) error {
+	if len(clusterAddr) == 0 {
+		return errors.New("cluster_addr config not set")
+	}
 
-		if err := raftStorage.SetupCluster(context.Background(), raft.SetupOpts{
-			StartAsLeader: true,
-		}); err != nil {
-			return nil, fmt.Errorf("could not start clustered storage: %w", err)
-		}
+	raftStorage, ok := storage.(*raft.RaftBackend)
+	if !ok {
+		return errors.New("wrong storage type for raft backend")
 	}
 
-	return storage, nil
+	parsedClusterAddr, err := url.Parse(clusterAddr)
+	if err != nil {
+		return fmt.Errorf("error parsing cluster address: %w", err)
+	}
+	if err := raftStorage.Bootstrap([]raft.Peer{
+		{
+			ID:      raftStorage.NodeID(),
+			Address: parsedClusterAddr.Host,
+		},
+	}); err != nil {
+		return fmt.Errorf("could not bootstrap clustered storage: %w", err)
+	}
+
+	if err := raftStorage.SetupCluster(context.Background(), raft.SetupOpts{
+		StartAsLeader: true,
+	}); err != nil {
+		return fmt.Errorf("could not start clustered storage: %w", err)
+	}
+
+	return nil
 }
 
 // loadMigratorConfig loads the configuration at the given path
@@ -40,6 +40,7 @@ type OperatorRekeyCommand struct {
 	flagStatus       bool
 	flagTarget       string
 	flagVerify       bool
+	flagRotateShares bool
 
 	// Backup options
 	flagBackup         bool
@@ -84,6 +85,14 @@ Usage: vault operator rekey [options] [KEY]
           -key-threshold=2 \
           -pgp-keys="keybase:hashicorp,keybase:jefferai,keybase:sethvargo"
 
+  Reissue the unseal keys without changing the root key:
+
+      $ vault operator rekey \
+          -init \
+          -key-shares=5 \
+          -key-threshold=3 \
+          -rotate-shares
+
   Store encrypted PGP keys in Vault's core:
 
       $ vault operator rekey \
@@ -183,13 +192,22 @@ func (c *OperatorRekeyCommand) Flags() *FlagSets {
 			"attempt.",
 	})
 
+	f.BoolVar(&BoolVar{
+		Name:    "rotate-shares",
+		Target:  &c.flagRotateShares,
+		Default: false,
+		Usage: "Reissue the unseal keys without changing the root key. The " +
+			"existing unseal keys are invalidated once the rekey completes. This " +
+			"is only supported for the barrier target of Shamir seals.",
+	})
+
 	f.VarFlag(&VarFlag{
 		Name:       "pgp-keys",
 		Value:      (*pgpkeys.PubKeyFilesFlag)(&c.flagPGPKeys),
 		Completion: complete.PredictAnything,
 		Usage: "Comma-separated list of paths to files on disk containing " +
-			"public PGP keys OR a comma-separated list of Keybase usernames using " +
-			"the format \"keybase:<username>\". When supplied, the generated " +
+			"public PGP keys, age recipients (\"age1...\") OR a comma-separated list " +
+			"of Keybase usernames using the format \"keybase:<username>\". When supplied, the generated " +
 			"unseal or recovery keys will be encrypted and base64-encoded in the order " +
 			"specified in this list.",
 	})
@@ -298,6 +316,7 @@ func (c *OperatorRekeyCommand) init(client *api.Client) int {
 		PGPKeys:             c.flagPGPKeys,
 		Backup:              c.flagBackup,
 		RequireVerification: c.flagVerify,
+		RotateShares:        c.flagRotateShares,
 	})
 	if err != nil {
 		c.UI.Error(fmt.Sprintf("Error initializing rekey: %s", err))
@@ -636,6 +655,7 @@ func (c *OperatorRekeyCommand) printStatus(in interface{}) int {
 			out = append(out, fmt.Sprintf("New Shares | %d", status.N))
 			out = append(out, fmt.Sprintf("New Threshold | %d", status.T))
 			out = append(out, fmt.Sprintf("Verification Required | %t", status.VerificationRequired))
+			out = append(out, fmt.Sprintf("Rotate Shares Only | %t", status.RotateShares))
 			if status.VerificationNonce != "" {
 				out = append(out, fmt.Sprintf("Verification Nonce | %s", status.VerificationNonce))
 			}
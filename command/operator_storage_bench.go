@@ -0,0 +1,394 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package command
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/cli"
+	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/command/server"
+	"github.com/hashicorp/vault/sdk/helper/logging"
+	"github.com/hashicorp/vault/sdk/physical"
+	"github.com/posener/complete"
+)
+
+var (
+	_ cli.Command             = (*OperatorStorageBenchCommand)(nil)
+	_ cli.CommandAutocomplete = (*OperatorStorageBenchCommand)(nil)
+)
+
+// storageBenchListDirs is the number of directories the benchmark keys are
+// spread over, which the list operations list.
+const storageBenchListDirs = 10
+
+type OperatorStorageBenchCommand struct {
+	*BaseCommand
+
+	PhysicalBackends map[string]physical.Factory
+	flagConfig       string
+	flagOperations   int
+	flagValueSize    int
+	flagMaxParallel  int
+	flagPrefix       string
+	logger           log.Logger
+	ShutdownCh       chan struct{}
+}
+
+// storageBenchResult holds the latencies of one operation of the benchmark,
+// in milliseconds.
+type storageBenchResult struct {
+	Operation    string  `json:"operation"`
+	Count        int     `json:"count"`
+	Errors       int     `json:"errors"`
+	OpsPerSecond float64 `json:"ops_per_second"`
+	Mean         float64 `json:"mean_ms"`
+	P50          float64 `json:"p50_ms"`
+	P90          float64 `json:"p90_ms"`
+	P99          float64 `json:"p99_ms"`
+	Max          float64 `json:"max_ms"`
+
+	err error
+}
+
+func (c *OperatorStorageBenchCommand) Synopsis() string {
+	return "Benchmarks the latency of a storage backend"
+}
+
+func (c *OperatorStorageBenchCommand) Help() string {
+	helpText := `
+Usage: vault operator storage-bench [options]
+
+  This command runs a standardized workload of writes, reads, lists and
+  deletes against the storage backend of a Vault server configuration file,
+  and reports the latency percentiles of each operation. It operates directly
+  on the storage backend and does not require a Vault server, nor any
+  unsealing.
+
+  The workload writes its own keys under a unique prefix, and deletes them
+  once it completes. Benchmarking the storage of a running Vault cluster adds
+  load to it; benchmark a candidate backend before migrating to it instead
+  where possible. Raft storage can only be benchmarked with a fresh data
+  directory, and requires cluster_addr to be set.
+
+  Benchmark the storage backend of a configuration file:
+
+      $ vault operator storage-bench -config=candidate.hcl
+
+  Benchmark with larger values and more parallelism:
+
+      $ vault operator storage-bench -config=candidate.hcl -value-size=8192 -max-parallel=32
+
+` + c.Flags().Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *OperatorStorageBenchCommand) Flags() *FlagSets {
+	set := NewFlagSets(c.UI)
+	f := set.NewFlagSet("Command Options")
+
+	f.StringVar(&StringVar{
+		Name:   "config",
+		Target: &c.flagConfig,
+		Completion: complete.PredictOr(
+			complete.PredictFiles("*.hcl"),
+			complete.PredictFiles("*.json"),
+		),
+		Usage: "Path to a Vault server configuration file or directory. The " +
+			"storage stanza of the configuration is benchmarked.",
+	})
+
+	f.IntVar(&IntVar{
+		Name:    "operations",
+		Default: 1000,
+		Target:  &c.flagOperations,
+		Usage:   "Number of operations of each kind to run.",
+	})
+
+	f.IntVar(&IntVar{
+		Name:    "value-size",
+		Default: 1024,
+		Target:  &c.flagValueSize,
+		Usage:   "Size in bytes of the values written.",
+	})
+
+	f.IntVar(&IntVar{
+		Name:    "max-parallel",
+		Default: 10,
+		Target:  &c.flagMaxParallel,
+		Usage:   "Maximum number of operations run in parallel.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:    "prefix",
+		Target:  &c.flagPrefix,
+		Default: "storage-bench/",
+		Usage: "Storage prefix under which the benchmark writes its keys. " +
+			"Each run writes to a unique sub-path of it.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "format",
+		Target:     &c.flagFormat,
+		Default:    "table",
+		EnvVar:     EnvVaultFormat,
+		Completion: complete.PredictSet("table", "json", "yaml"),
+		Usage:      `Print the output in the given format. Valid formats are "table", "json", or "yaml".`,
+	})
+
+	return set
+}
+
+func (c *OperatorStorageBenchCommand) AutocompleteArgs() complete.Predictor {
+	return nil
+}
+
+func (c *OperatorStorageBenchCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *OperatorStorageBenchCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+	c.logger = logging.NewVaultLogger(log.Warn)
+
+	switch {
+	case c.flagConfig == "":
+		c.UI.Error("Must specify exactly one config path using -config")
+		return 1
+	case c.flagOperations < 1:
+		c.UI.Error("Argument to flag -operations must be at least 1")
+		return 1
+	case c.flagValueSize < 1:
+		c.UI.Error("Argument to flag -value-size must be at least 1")
+		return 1
+	case c.flagMaxParallel < 1:
+		c.UI.Error(fmt.Sprintf("Argument to flag -max-parallel must be between 1 and %d", math.MaxInt))
+		return 1
+	}
+	c.flagFormat = strings.ToLower(c.flagFormat)
+	if _, ok := Formatters[c.flagFormat]; !ok {
+		c.UI.Error(fmt.Sprintf("Invalid output format: %s", c.flagFormat))
+		return 1
+	}
+	if !strings.HasSuffix(c.flagPrefix, "/") {
+		c.flagPrefix += "/"
+	}
+
+	config, err := server.LoadConfig(c.flagConfig)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error loading configuration from %s: %s", c.flagConfig, err))
+		return 1
+	}
+	if config.Storage == nil {
+		c.UI.Error(fmt.Sprintf("No storage stanza in configuration %s", c.flagConfig))
+		return 1
+	}
+
+	backend, err := c.newBackend(config)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error creating storage backend: %s", err))
+		return 2
+	}
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+	go func() {
+		select {
+		case <-c.ShutdownCh:
+			c.UI.Output("==> Benchmark shutdown triggered, cleaning up\n")
+			cancelFunc()
+		case <-ctx.Done():
+		}
+	}()
+
+	results, err := c.bench(ctx, backend)
+	if err == context.Canceled {
+		return 0
+	}
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error benchmarking storage: %s", err))
+		return 2
+	}
+
+	ret := 0
+	for _, result := range results {
+		if result.err != nil {
+			c.UI.Error(fmt.Sprintf("%d %s operations failed, the first with: %s", result.Errors, result.Operation, result.err))
+			ret = 2
+		}
+	}
+
+	switch c.flagFormat {
+	case "table":
+		out := []string{"Operation | Count | Errors | Ops/s | Mean | P50 | P90 | P99 | Max"}
+		for _, r := range results {
+			out = append(out, fmt.Sprintf("%s | %d | %d | %.1f | %.2fms | %.2fms | %.2fms | %.2fms | %.2fms",
+				r.Operation, r.Count, r.Errors, r.OpsPerSecond, r.Mean, r.P50, r.P90, r.P99, r.Max))
+		}
+		c.UI.Output(fmt.Sprintf("Storage: %s\n", config.Storage.Type))
+		c.UI.Output(tableOutput(out, nil))
+	default:
+		if err := Formatters[c.flagFormat].Output(c.UI, nil, results); err != nil {
+			c.UI.Error(fmt.Sprintf("Could not format output: %s", err))
+			return 1
+		}
+	}
+
+	return ret
+}
+
+func (c *OperatorStorageBenchCommand) newBackend(config *server.Config) (physical.Backend, error) {
+	factory, ok := c.PhysicalBackends[config.Storage.Type]
+	if !ok {
+		return nil, fmt.Errorf("no Vault storage backend named: %+q", config.Storage.Type)
+	}
+
+	backend, err := factory(config.Storage.Config, c.logger)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.Storage.Type == storageTypeRaft {
+		if err := bootstrapRaftStorage(backend, config.ClusterAddr); err != nil {
+			return nil, err
+		}
+	}
+
+	return backend, nil
+}
+
+// bench runs the benchmark workload against the backend. The keys written
+// are deleted even if the context is cancelled.
+func (c *OperatorStorageBenchCommand) bench(ctx context.Context, b physical.Backend) ([]*storageBenchResult, error) {
+	runID, err := uuid.GenerateUUID()
+	if err != nil {
+		return nil, err
+	}
+	prefix := c.flagPrefix + runID + "/"
+
+	keys := make([]string, c.flagOperations)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("%s%02d/%08d", prefix, i%storageBenchListDirs, i)
+	}
+	value := make([]byte, c.flagValueSize)
+	if _, err := rand.Read(value); err != nil {
+		return nil, err
+	}
+
+	var results []*storageBenchResult
+	for _, op := range []struct {
+		name string
+		f    func(ctx context.Context, i int) error
+	}{
+		{"put", func(ctx context.Context, i int) error {
+			return b.Put(ctx, &physical.Entry{Key: keys[i], Value: value})
+		}},
+		{"get", func(ctx context.Context, i int) error {
+			entry, err := b.Get(ctx, keys[i])
+			if err == nil && entry == nil {
+				err = fmt.Errorf("key %q not found", keys[i])
+			}
+			return err
+		}},
+		{"list", func(ctx context.Context, i int) error {
+			_, err := b.List(ctx, fmt.Sprintf("%s%02d/", prefix, i%storageBenchListDirs))
+			return err
+		}},
+	} {
+		if ctx.Err() != nil {
+			break
+		}
+		results = append(results, c.run(ctx, op.name, op.f))
+	}
+
+	// Deletes double as the clean-up of the run, so they are not cancelled
+	results = append(results, c.run(context.Background(), "delete", func(ctx context.Context, i int) error {
+		return b.Delete(ctx, keys[i])
+	}))
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	return results, nil
+}
+
+// run runs f for each operation of the benchmark, with at most
+// -max-parallel operations at a time, and summarizes their latencies.
+func (c *OperatorStorageBenchCommand) run(ctx context.Context, name string, f func(ctx context.Context, i int) error) *storageBenchResult {
+	result := &storageBenchResult{
+		Operation: name,
+		Count:     c.flagOperations,
+	}
+	latencies := make([]time.Duration, c.flagOperations)
+
+	var l sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, c.flagMaxParallel)
+	start := time.Now()
+	for i := 0; i < c.flagOperations; i++ {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			opStart := time.Now()
+			err := f(ctx, i)
+			latencies[i] = time.Since(opStart)
+			if err != nil {
+				l.Lock()
+				result.Errors++
+				if result.err == nil {
+					result.err = err
+				}
+				l.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	var total time.Duration
+	for _, latency := range latencies {
+		total += latency
+	}
+	result.OpsPerSecond = float64(c.flagOperations) / elapsed.Seconds()
+	result.Mean = durationMillis(total / time.Duration(len(latencies)))
+	result.P50 = durationMillis(percentile(latencies, 0.5))
+	result.P90 = durationMillis(percentile(latencies, 0.9))
+	result.P99 = durationMillis(percentile(latencies, 0.99))
+	result.Max = durationMillis(latencies[len(latencies)-1])
+	return result
+}
+
+// percentile returns the q-th percentile of the sorted latencies, using the
+// nearest-rank method.
+func percentile(sorted []time.Duration, q float64) time.Duration {
+	rank := int(math.Ceil(q * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+func durationMillis(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
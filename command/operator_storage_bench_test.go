@@ -0,0 +1,134 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package command
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/cli"
+	log "github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/require"
+)
+
+func testOperatorStorageBenchCommand(tb testing.TB) (*cli.MockUi, *OperatorStorageBenchCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &OperatorStorageBenchCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
+		PhysicalBackends: newVaultHandlers().physicalBackends,
+		ShutdownCh:       make(chan struct{}),
+	}
+}
+
+func TestOperatorStorageBenchCommand_Run(t *testing.T) {
+	t.Parallel()
+
+	storagePath := t.TempDir()
+	configPath := filepath.Join(t.TempDir(), "config.hcl")
+	config := fmt.Sprintf(`
+storage "file" {
+  path = %q
+}
+`, storagePath)
+	require.NoError(t, os.WriteFile(configPath, []byte(config), 0o600))
+
+	cases := []struct {
+		name string
+		args []string
+		out  string
+		code int
+	}{
+		{
+			"no_config",
+			nil,
+			"Must specify exactly one config path",
+			1,
+		},
+		{
+			"invalid_operations",
+			[]string{"-config", configPath, "-operations", "0"},
+			"-operations must be at least 1",
+			1,
+		},
+		{
+			"json",
+			[]string{"-config", configPath, "-operations", "10", "-format", "json"},
+			`"p99_ms"`,
+			0,
+		},
+		{
+			"default",
+			[]string{"-config", configPath, "-operations", "50"},
+			"Storage: file",
+			0,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			ui, cmd := testOperatorStorageBenchCommand(t)
+
+			code := cmd.Run(tc.args)
+			combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+			require.Equal(t, tc.code, code, combined)
+			require.Contains(t, combined, tc.out)
+		})
+	}
+
+	// The keys written by the benchmark are deleted
+	entries, err := os.ReadDir(filepath.Join(storagePath, "storage-bench"))
+	if err == nil {
+		require.Empty(t, entries)
+	}
+}
+
+func TestOperatorStorageBenchCommand_Bench(t *testing.T) {
+	t.Parallel()
+
+	_, cmd := testOperatorStorageBenchCommand(t)
+	cmd.flagOperations = 100
+	cmd.flagValueSize = 16
+	cmd.flagMaxParallel = 4
+	cmd.flagPrefix = "bench/"
+
+	b, err := cmd.PhysicalBackends["inmem"](nil, log.NewNullLogger())
+	require.NoError(t, err)
+
+	results, err := cmd.bench(context.Background(), b)
+	require.NoError(t, err)
+
+	var ops []string
+	for _, r := range results {
+		ops = append(ops, r.Operation)
+		require.NoError(t, r.err)
+		require.Equal(t, 100, r.Count)
+		require.LessOrEqual(t, r.P50, r.P90)
+		require.LessOrEqual(t, r.P90, r.P99)
+		require.LessOrEqual(t, r.P99, r.Max)
+	}
+	require.Equal(t, "put,get,list,delete", strings.Join(ops, ","))
+
+	keys, err := b.List(context.Background(), "bench/")
+	require.NoError(t, err)
+	require.Empty(t, keys)
+
+	// A cancelled run still deletes its keys
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = cmd.bench(ctx, b)
+	require.ErrorIs(t, err, context.Canceled)
+	keys, err = b.List(context.Background(), "bench/")
+	require.NoError(t, err)
+	require.Empty(t, keys)
+}
@@ -214,6 +214,8 @@ func (c *PKIHealthCheckCommand) Run(args []string) int {
 	executor.AddCheck(healthcheck.NewRoleAllowsLocalhostCheck())
 	executor.AddCheck(healthcheck.NewRoleAllowsGlobWildcardsCheck())
 	executor.AddCheck(healthcheck.NewRoleNoStoreFalseCheck())
+	executor.AddCheck(healthcheck.NewRoleMaxTTLCheck())
+	executor.AddCheck(healthcheck.NewPolicyAllowEndpointsCheck())
 	executor.AddCheck(healthcheck.NewAuditVisibilityCheck())
 	executor.AddCheck(healthcheck.NewAllowIfModifiedSinceCheck())
 	executor.AddCheck(healthcheck.NewEnableAutoTidyCheck())
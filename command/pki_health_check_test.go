@@ -129,6 +129,7 @@ func TestPKIHC_AllBad(t *testing.T) {
 		"no_store":                    false,
 		"key_type":                    "ec",
 		"ttl":                         "30d",
+		"max_ttl":                     "3650d",
 	}); err != nil {
 		t.Fatalf("failed to write role: %v", err)
 	}
@@ -146,11 +147,81 @@ func TestPKIHC_AllBad(t *testing.T) {
 		t.Fatalf("failed to write auto-tidy config: %v", err)
 	}
 
+	if err := client.Sys().PutPolicy("pki-operator", `path "pki/*" { capabilities = ["read", "update"] }`); err != nil {
+		t.Fatalf("failed to write policy: %v", err)
+	}
+
 	_, _, results := execPKIHC(t, client, true)
 
 	validateExpectedPKIHC(t, expectedAllBad, results)
 }
 
+func TestPKIHC_RoleMaxTTLAndPolicies(t *testing.T) {
+	t.Parallel()
+
+	client, closer := testVaultServer(t)
+	defer closer()
+
+	if err := client.Sys().Mount("pki", &api.MountInput{
+		Type: "pki",
+		Config: api.MountConfigInput{
+			MaxLeaseTTL: "36500d",
+		},
+	}); err != nil {
+		t.Fatalf("pki mount error: %#v", err)
+	}
+
+	if resp, err := client.Logical().Write("pki/root/generate/internal", map[string]interface{}{
+		"key_type":    "ec",
+		"common_name": "Root X1",
+		"ttl":         "3650d",
+	}); err != nil || resp == nil {
+		t.Fatalf("failed to prime CA: %v", err)
+	}
+
+	for role, maxTTL := range map[string]string{
+		"inherited": "",
+		"long":      "180d",
+		"short":     "30d",
+	} {
+		data := map[string]interface{}{
+			"allow_any_name": true,
+			"no_store":       true,
+		}
+		if maxTTL != "" {
+			data["max_ttl"] = maxTTL
+		}
+		if _, err := client.Logical().Write("pki/roles/"+role, data); err != nil {
+			t.Fatalf("failed to write role %v: %v", role, err)
+		}
+	}
+
+	// Only the most specific path of a policy applies, so broad grants
+	// narrowed by more specific rules are not reported, while specific
+	// grants under broad read-only rules are.
+	if err := client.Sys().PutPolicy("pki-narrowed", `
+path "pki/*" { capabilities = ["read", "update"] }
+path "pki/sign-verbatim*" { capabilities = ["deny"] }
+path "pki/root/sign-*" { capabilities = ["read"] }
+path "pki/issuer/+/sign-*" { capabilities = ["read"] }
+`); err != nil {
+		t.Fatalf("failed to write policy: %v", err)
+	}
+	if err := client.Sys().PutPolicy("pki-overbroad", `
+path "pki/*" { capabilities = ["read"] }
+path "pki/+/sign-intermediate" { capabilities = ["update"] }
+`); err != nil {
+		t.Fatalf("failed to write policy: %v", err)
+	}
+
+	_, _, results := execPKIHC(t, client, true)
+
+	validateExpectedPKIHC(t, expectedRoleMaxTTLAndPolicies, results)
+	require.Contains(t, results["policy_allow_endpoints"][0]["endpoint"], "pki-overbroad")
+	require.Contains(t, results["policy_allow_endpoints"][0]["message"], "pki/+/sign-intermediate")
+	require.NotContains(t, results["policy_allow_endpoints"][0]["message"], "pki/*")
+}
+
 func TestPKIHC_OnlyIssuer(t *testing.T) {
 	t.Parallel()
 
@@ -415,6 +486,14 @@ var expectedAllGood = map[string][]map[string]interface{}{
 			"status": "ok",
 		},
 	},
+	// The role inherits the mount's long max lease TTL; this check is
+	// covered by TestPKIHC_RoleMaxTTLAndPolicies.
+	"role_max_ttl": nil,
+	"policy_allow_endpoints": {
+		{
+			"status": "ok",
+		},
+	},
 }
 
 var expectedAllBad = map[string][]map[string]interface{}{
@@ -532,6 +611,27 @@ var expectedAllBad = map[string][]map[string]interface{}{
 		{
 			"status": "informational",
 		},
+		{
+			"status": "informational",
+		},
+		{
+			"status": "informational",
+		},
+		{
+			"status": "informational",
+		},
+		{
+			"status": "informational",
+		},
+		{
+			"status": "informational",
+		},
+		{
+			"status": "informational",
+		},
+		{
+			"status": "informational",
+		},
 	},
 	"enable_acme_issuance": {
 		{
@@ -573,6 +673,51 @@ var expectedAllBad = map[string][]map[string]interface{}{
 			"status": "ok",
 		},
 	},
+	"role_max_ttl": {
+		{
+			"status": "critical",
+		},
+	},
+	"policy_allow_endpoints": {
+		{
+			"status": "critical",
+		},
+	},
+}
+
+var expectedRoleMaxTTLAndPolicies = map[string][]map[string]interface{}{
+	"ca_validity_period":         nil,
+	"crl_validity_period":        nil,
+	"allow_acme_headers":         nil,
+	"allow_if_modified_since":    nil,
+	"audit_visibility":           nil,
+	"enable_acme_issuance":       nil,
+	"enable_auto_tidy":           nil,
+	"role_allows_glob_wildcards": nil,
+	"role_allows_localhost":      nil,
+	"role_no_store_false":        nil,
+	"root_issued_leaves":         nil,
+	"tidy_last_run":              nil,
+	"too_many_certs":             nil,
+	"role_max_ttl": {
+		{
+			"status":   "critical",
+			"endpoint": "/pki/roles/inherited",
+		},
+		{
+			"status":   "warning",
+			"endpoint": "/pki/roles/long",
+		},
+		{
+			"status":   "ok",
+			"endpoint": "/pki/roles/short",
+		},
+	},
+	"policy_allow_endpoints": {
+		{
+			"status": "critical",
+		},
+	},
 }
 
 var expectedEmptyWithIssuer = map[string][]map[string]interface{}{
@@ -624,6 +769,12 @@ var expectedEmptyWithIssuer = map[string][]map[string]interface{}{
 			"status": "ok",
 		},
 	},
+	"role_max_ttl": nil,
+	"policy_allow_endpoints": {
+		{
+			"status": "ok",
+		},
+	},
 }
 
 var expectedNoPerm = map[string][]map[string]interface{}{
@@ -690,4 +841,14 @@ var expectedNoPerm = map[string][]map[string]interface{}{
 			"status": "insufficient_permissions",
 		},
 	},
+	"role_max_ttl": {
+		{
+			"status": "insufficient_permissions",
+		},
+	},
+	"policy_allow_endpoints": {
+		{
+			"status": "insufficient_permissions",
+		},
+	},
 }
@@ -155,20 +155,27 @@ func parseTemplateCertificate(certificate x509.Certificate, useExistingKey bool,
 		"uri_sans":    certutil.MakeUriCommaSeparatedString(certificate.URIs),
 		// other_sans (string: "") - Specifies custom OID/UTF8-string SANs. These must match values specified on the role in allowed_other_sans (see role creation for allowed_other_sans globbing rules). The format is the same as OpenSSL: <oid>;<type>:<value> where the only current valid type is UTF8. This can be a comma-delimited list or a JSON string slice.
 		// Punting on Other_SANs, shouldn't really be on CAs
-		"signature_bits":        certutil.FindSignatureBits(certificate.SignatureAlgorithm),
-		"exclude_cn_from_sans":  certutil.DetermineExcludeCnFromCertSans(certificate),
-		"ou":                    certificate.Subject.OrganizationalUnit,
-		"organization":          certificate.Subject.Organization,
-		"country":               certificate.Subject.Country,
-		"locality":              certificate.Subject.Locality,
-		"province":              certificate.Subject.Province,
-		"street_address":        certificate.Subject.StreetAddress,
-		"postal_code":           certificate.Subject.PostalCode,
-		"serial_number":         certificate.Subject.SerialNumber,
-		"ttl":                   (certificate.NotAfter.Sub(certificate.NotBefore)).String(),
-		"max_path_length":       certificate.MaxPathLen,
-		"permitted_dns_domains": strings.Join(certificate.PermittedDNSDomains, ","),
-		"use_pss":               certutil.IsPSS(certificate.SignatureAlgorithm),
+		"signature_bits":            certutil.FindSignatureBits(certificate.SignatureAlgorithm),
+		"exclude_cn_from_sans":      certutil.DetermineExcludeCnFromCertSans(certificate),
+		"ou":                        certificate.Subject.OrganizationalUnit,
+		"organization":              certificate.Subject.Organization,
+		"country":                   certificate.Subject.Country,
+		"locality":                  certificate.Subject.Locality,
+		"province":                  certificate.Subject.Province,
+		"street_address":            certificate.Subject.StreetAddress,
+		"postal_code":               certificate.Subject.PostalCode,
+		"serial_number":             certificate.Subject.SerialNumber,
+		"ttl":                       (certificate.NotAfter.Sub(certificate.NotBefore)).String(),
+		"max_path_length":           certificate.MaxPathLen,
+		"permitted_dns_domains":     strings.Join(certificate.PermittedDNSDomains, ","),
+		"excluded_dns_domains":      strings.Join(certificate.ExcludedDNSDomains, ","),
+		"permitted_ip_ranges":       certutil.MakeIpRangeCommaSeparatedString(certificate.PermittedIPRanges),
+		"excluded_ip_ranges":        certutil.MakeIpRangeCommaSeparatedString(certificate.ExcludedIPRanges),
+		"permitted_email_addresses": strings.Join(certificate.PermittedEmailAddresses, ","),
+		"excluded_email_addresses":  strings.Join(certificate.ExcludedEmailAddresses, ","),
+		"permitted_uri_domains":     strings.Join(certificate.PermittedURIDomains, ","),
+		"excluded_uri_domains":      strings.Join(certificate.ExcludedURIDomains, ","),
+		"use_pss":                   certutil.IsPSS(certificate.SignatureAlgorithm),
 	}
 
 	if useExistingKey {
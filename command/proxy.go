@@ -32,8 +32,11 @@ import (
 	"github.com/hashicorp/vault/command/agentproxyshared/auth"
 	"github.com/hashicorp/vault/command/agentproxyshared/cache"
 	"github.com/hashicorp/vault/command/agentproxyshared/sink"
+	"github.com/hashicorp/vault/command/agentproxyshared/sink/awssecretsmanager"
+	sinkexec "github.com/hashicorp/vault/command/agentproxyshared/sink/exec"
 	"github.com/hashicorp/vault/command/agentproxyshared/sink/file"
 	"github.com/hashicorp/vault/command/agentproxyshared/sink/inmem"
+	"github.com/hashicorp/vault/command/agentproxyshared/sink/kubernetes"
 	"github.com/hashicorp/vault/command/agentproxyshared/winsvc"
 	proxyConfig "github.com/hashicorp/vault/command/proxy/config"
 	"github.com/hashicorp/vault/helper/logging"
@@ -320,29 +323,37 @@ func (c *ProxyCommand) Run(args []string) int {
 		}
 
 		for _, sc := range config.AutoAuth.Sinks {
+			config := &sink.SinkConfig{
+				Logger:    c.logger.Named("sink." + sc.Type),
+				Config:    sc.Config,
+				Client:    sinkClient,
+				WrapTTL:   sc.WrapTTL,
+				DHType:    sc.DHType,
+				DeriveKey: sc.DeriveKey,
+				DHPath:    sc.DHPath,
+				AAD:       sc.AAD,
+			}
+
+			var s sink.Sink
 			switch sc.Type {
 			case "file":
-				config := &sink.SinkConfig{
-					Logger:    c.logger.Named("sink.file"),
-					Config:    sc.Config,
-					Client:    sinkClient,
-					WrapTTL:   sc.WrapTTL,
-					DHType:    sc.DHType,
-					DeriveKey: sc.DeriveKey,
-					DHPath:    sc.DHPath,
-					AAD:       sc.AAD,
-				}
-				s, err := file.NewFileSink(config)
-				if err != nil {
-					c.UI.Error(fmt.Errorf("error creating file sink: %w", err).Error())
-					return 1
-				}
-				config.Sink = s
-				sinks = append(sinks, config)
+				s, err = file.NewFileSink(config)
+			case "exec":
+				s, err = sinkexec.NewExecSink(config)
+			case "kubernetes_secret":
+				s, err = kubernetes.NewKubernetesSink(config)
+			case "aws_secrets_manager":
+				s, err = awssecretsmanager.NewAWSSecretsManagerSink(config)
 			default:
 				c.UI.Error(fmt.Sprintf("Unknown sink type %q", sc.Type))
 				return 1
 			}
+			if err != nil {
+				c.UI.Error(fmt.Errorf("error creating %s sink: %w", sc.Type, err).Error())
+				return 1
+			}
+			config.Sink = s
+			sinks = append(sinks, config)
 		}
 
 		authConfig := &auth.AuthConfig{
@@ -673,10 +684,18 @@ func (c *ProxyCommand) Run(args []string) int {
 		info[infoKey] = scheme + ln.Addr().String()
 		infoKeys = append(infoKeys, infoKey)
 
+		var handler http.Handler = mux
+		var connContext func(context.Context, net.Conn) context.Context
+		if lnConfig.ProxyAPI != nil && len(lnConfig.ProxyAPI.AllowedClientUIDs) > 0 {
+			handler = cache.AllowedClientUIDsHandler(mux, apiProxyLogger, lnConfig.ProxyAPI.AllowedClientUIDs)
+			connContext = cache.PeerUIDConnContext
+		}
+
 		server := &http.Server{
 			Addr:              ln.Addr().String(),
 			TLSConfig:         tlsCfg,
-			Handler:           mux,
+			Handler:           handler,
+			ConnContext:       connContext,
 			ReadHeaderTimeout: 10 * time.Second,
 			ReadTimeout:       30 * time.Second,
 			IdleTimeout:       5 * time.Minute,
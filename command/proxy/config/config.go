@@ -268,6 +268,12 @@ func (c *Config) ValidateConfig() error {
 		return fmt.Errorf("to enable the cache, the cache must be configured to either cache static secrets or dynamic secrets")
 	}
 
+	for _, l := range c.Listeners {
+		if l.ProxyAPI != nil && len(l.ProxyAPI.AllowedClientUIDs) > 0 && l.Type != "unix" {
+			return fmt.Errorf("proxy_api.allowed_client_uids can only be set on unix listeners")
+		}
+	}
+
 	if c.AutoAuth == nil && c.Cache == nil && len(c.Listeners) == 0 {
 		return fmt.Errorf("no auto_auth, cache, or listener block found in config")
 	}
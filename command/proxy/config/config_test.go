@@ -145,6 +145,22 @@ func TestLoadConfigFile_StaticSecretCachingWithoutAutoAuth(t *testing.T) {
 	}
 }
 
+// TestLoadConfigFile_AllowedClientUIDsOnTCPListener tests that loading a
+// config file restricting the client user IDs of a tcp listener will fail.
+func TestLoadConfigFile_AllowedClientUIDsOnTCPListener(t *testing.T) {
+	cfg, err := LoadConfigFile("./test-fixtures/config-allowed-client-uids-tcp.hcl")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.Listeners[0].ProxyAPI == nil || len(cfg.Listeners[0].ProxyAPI.AllowedClientUIDs) != 1 {
+		t.Fatalf("expected allowed_client_uids to be parsed, got %#v", cfg.Listeners[0].ProxyAPI)
+	}
+	if err := cfg.ValidateConfig(); err == nil {
+		t.Fatalf("expected error, as allowed_client_uids can only be set on unix listeners")
+	}
+}
+
 // TestLoadConfigFile_ProxyCacheStaticSecrets tests loading a config file containing a cache
 // as well as a valid proxy config with static secret caching enabled
 func TestLoadConfigFile_ProxyCacheStaticSecrets(t *testing.T) {
@@ -0,0 +1,17 @@
+# Copyright (c) HashiCorp, Inc.
+# SPDX-License-Identifier: BUSL-1.1
+
+pid_file = "./pidfile"
+
+listener "tcp" {
+    address = "127.0.0.1:8300"
+    tls_disable = true
+    proxy_api {
+        allowed_client_uids = [1000]
+    }
+}
+
+vault {
+	address = "http://127.0.0.1:1111"
+	tls_skip_verify = "true"
+}
@@ -143,6 +143,9 @@ type ServerCommand struct {
 	flagTestServerConfig   bool
 	flagDevConsul          bool
 	flagExitOnCoreShutdown bool
+	flagValidateConfig     bool
+	flagStrictConfig       bool
+	flagFormat             string
 
 	sealsToFinalize []*vault.Seal
 }
@@ -204,6 +207,31 @@ func (c *ServerCommand) Flags() *FlagSets {
 		Usage:   "Exit the vault server if the vault core is shutdown.",
 	})
 
+	f.BoolVar(&BoolVar{
+		Name:    "validate-config",
+		Target:  &c.flagValidateConfig,
+		Default: false,
+		Usage: "Parse and cross-check the configuration files given by -config, " +
+			"report any problems found and exit without starting the server.",
+	})
+
+	f.BoolVar(&BoolVar{
+		Name:    "strict-config",
+		Target:  &c.flagStrictConfig,
+		Default: false,
+		Usage: "Treat configuration warnings, such as unknown keys, as errors. " +
+			"The server refuses to start if any configuration problem is found.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "format",
+		Target:     &c.flagFormat,
+		Default:    "table",
+		Completion: complete.PredictSet("table", "json"),
+		Usage: "Output format of -validate-config. Valid formats are \"table\" " +
+			"and \"json\".",
+	})
+
 	f.BoolVar(&BoolVar{
 		Name:   "recovery",
 		Target: &c.flagRecovery,
@@ -1023,6 +1051,10 @@ func (c *ServerCommand) Run(args []string) int {
 		c.logWriter = os.Stdout
 	}
 
+	if c.flagValidateConfig {
+		return c.runValidateConfig()
+	}
+
 	if c.flagRecovery {
 		return c.runRecoveryMode()
 	}
@@ -1109,10 +1141,21 @@ func (c *ServerCommand) Run(args []string) int {
 	for _, cErr := range configErrors {
 		c.logger.Warn(cErr.String())
 	}
+	mergedConfigErrors := config.ValidateMerged()
+	for _, cErr := range mergedConfigErrors {
+		c.logger.Warn(cErr.Problem)
+	}
 
 	// Ensure logging is flushed if initialization fails
 	defer c.flushLog()
 
+	if c.flagStrictConfig && len(configErrors)+len(mergedConfigErrors) > 0 {
+		c.UI.Error(wrapAtLength(
+			"Refusing to start with configuration problems when -strict-config is " +
+				"set. Run with -validate-config to list the problems found."))
+		return 1
+	}
+
 	// create GRPC logger
 	namedGRPCLogFaker := c.logger.Named("grpclogfaker")
 	c.allLoggers = append(c.allLoggers, namedGRPCLogFaker)
@@ -1951,6 +1994,13 @@ func (c *ServerCommand) configureLogging(config *server.Config) (hclog.Intercept
 	logCfg.LogRotateBytes = config.LogRotateBytes
 	logCfg.LogRotateMaxFiles = config.LogRotateMaxFiles
 
+	if config.LogRedact {
+		logCfg.Redactor, err = loghelper.NewRedactor(config.LogRedactPatterns)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return loghelper.Setup(logCfg, c.logWriter)
 }
 
@@ -2897,6 +2947,7 @@ func createCoreConfig(c *ServerCommand, config *server.Config, backend physical.
 		Logger:                         c.logger,
 		DetectDeadlocks:                config.DetectDeadlocks,
 		ImpreciseLeaseRoleTracking:     config.ImpreciseLeaseRoleTracking,
+		LazyMountInitialization:        config.LazyMountInitialization,
 		DisableSentinelTrace:           config.DisableSentinelTrace,
 		DisableCache:                   config.DisableCache,
 		DisableMlock:                   config.DisableMlock,
@@ -2922,6 +2973,7 @@ func createCoreConfig(c *ServerCommand, config *server.Config, backend physical.
 		SecureRandomReader:             secureRandomReader,
 		EnableResponseHeaderHostname:   config.EnableResponseHeaderHostname,
 		EnableResponseHeaderRaftNodeID: config.EnableResponseHeaderRaftNodeID,
+		KeySharesMaxAge:                config.KeySharesMaxAge,
 		License:                        config.License,
 		LicensePath:                    config.LicensePath,
 		DisableSSCTokens:               config.DisableSSCTokens,
@@ -109,6 +109,11 @@ type Config struct {
 
 	ImpreciseLeaseRoleTracking bool `hcl:"imprecise_lease_role_tracking"`
 
+	LazyMountInitialization bool `hcl:"lazy_mount_initialization"`
+
+	KeySharesMaxAge    time.Duration `hcl:"-"`
+	KeySharesMaxAgeRaw interface{}   `hcl:"key_shares_max_age"`
+
 	EnableResponseHeaderRaftNodeID    bool        `hcl:"-"`
 	EnableResponseHeaderRaftNodeIDRaw interface{} `hcl:"enable_response_header_raft_node_id"`
 
@@ -415,6 +420,16 @@ func (c *Config) Merge(c2 *Config) *Config {
 		result.ImpreciseLeaseRoleTracking = c2.ImpreciseLeaseRoleTracking
 	}
 
+	result.LazyMountInitialization = c.LazyMountInitialization
+	if c2.LazyMountInitialization {
+		result.LazyMountInitialization = c2.LazyMountInitialization
+	}
+
+	result.KeySharesMaxAge = c.KeySharesMaxAge
+	if c2.KeySharesMaxAge != 0 {
+		result.KeySharesMaxAge = c2.KeySharesMaxAge
+	}
+
 	result.EnableResponseHeaderRaftNodeID = c.EnableResponseHeaderRaftNodeID
 	if c2.EnableResponseHeaderRaftNodeID {
 		result.EnableResponseHeaderRaftNodeID = c2.EnableResponseHeaderRaftNodeID
@@ -701,6 +716,12 @@ func ParseConfig(d, source string) (*Config, error) {
 		}
 	}
 
+	if result.KeySharesMaxAgeRaw != nil {
+		if result.KeySharesMaxAge, err = parseutil.ParseDurationSecond(result.KeySharesMaxAgeRaw); err != nil {
+			return nil, err
+		}
+	}
+
 	list, ok := obj.Node.(*ast.ObjectList)
 	if !ok {
 		return nil, fmt.Errorf("error parsing: file doesn't contain a root object")
@@ -1150,6 +1171,11 @@ func (c *Config) Sanitized() map[string]interface{} {
 		"detect_deadlocks": c.DetectDeadlocks,
 
 		"imprecise_lease_role_tracking": c.ImpreciseLeaseRoleTracking,
+
+		"lazy_mount_initialization": c.LazyMountInitialization,
+	}
+	if c.KeySharesMaxAge != 0 {
+		result["key_shares_max_age"] = c.KeySharesMaxAge / time.Second
 	}
 	for k, v := range sharedResult {
 		result[k] = v
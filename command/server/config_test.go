@@ -294,3 +294,49 @@ func TestCheckConfig(t *testing.T) {
 		})
 	}
 }
+
+// TestConfig_ValidateMerged verifies that conflicting listeners and seal
+// stanzas are reported by ValidateMerged.
+func TestConfig_ValidateMerged(t *testing.T) {
+	config, err := ParseConfig(`
+listener "tcp" {
+  address         = "127.0.0.1:8200"
+  cluster_address = "127.0.0.1:8201"
+}
+listener "tcp" {
+  address = "127.0.0.1:8201"
+}
+listener "tcp" {
+  address = "127.0.0.1:0"
+}
+listener "tcp" {
+  address = "127.0.0.1:0"
+}
+seal "transit" {
+  name     = "primary"
+  priority = 1
+}
+seal "awskms" {
+  name     = "primary"
+  priority = 1
+}
+`, "")
+	require.NoError(t, err)
+
+	var problems []string
+	for _, cErr := range config.ValidateMerged() {
+		problems = append(problems, cErr.Problem)
+	}
+	require.Len(t, problems, 3, "%v", problems)
+	require.Contains(t, problems[0], `listener 2 (tcp) address "127.0.0.1:8201" conflicts with listener 1 (tcp) cluster_address "127.0.0.1:8201"`)
+	require.Contains(t, problems[1], `seal name "primary" is used by more than one seal stanza`)
+	require.Contains(t, problems[2], `have the same priority 1`)
+
+	config, err = ParseConfig(`
+listener "tcp" {
+  address = "127.0.0.1:8200"
+}
+`, "")
+	require.NoError(t, err)
+	require.Empty(t, config.ValidateMerged())
+}
@@ -854,6 +854,7 @@ func testConfig_Sanitized(t *testing.T) {
 		},
 		"administrative_namespace_path": "admin/",
 		"imprecise_lease_role_tracking": false,
+		"lazy_mount_initialization":     false,
 	}
 
 	addExpectedEntSanitizedConfig(expected, []string{"http"})
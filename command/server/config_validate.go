@@ -0,0 +1,151 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package server
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/hashicorp/vault/internalshared/configutil"
+)
+
+// ValidateMerged cross-checks the merged configuration for problems which only
+// become apparent across stanzas, possibly loaded from different files, such
+// as conflicting listeners or seal stanzas. Unlike Validate, the problems found
+// here would prevent the server from running as configured.
+func (c *Config) ValidateMerged() []configutil.ConfigError {
+	if c == nil {
+		return nil
+	}
+
+	var results []configutil.ConfigError
+	if c.SharedConfig != nil {
+		results = append(results, validateListenerConflicts(c.Listeners)...)
+		results = append(results, validateSeals(c.Seals)...)
+	}
+
+	return results
+}
+
+// listenerAddr is an address some listener binds to, along with a description
+// of its origin for use in errors.
+type listenerAddr struct {
+	network string
+	address string
+	desc    string
+}
+
+func validateListenerConflicts(listeners []*configutil.Listener) []configutil.ConfigError {
+	var results []configutil.ConfigError
+	var addrs []listenerAddr
+
+	for i, l := range listeners {
+		if l == nil {
+			continue
+		}
+
+		network := string(l.Type)
+		if network == "" {
+			network = "tcp"
+		}
+
+		bound := []listenerAddr{{
+			network: network,
+			address: l.Address,
+			desc:    fmt.Sprintf("listener %d (%s) address %q", i+1, network, l.Address),
+		}}
+		if l.ClusterAddress != "" && network == "tcp" {
+			bound = append(bound, listenerAddr{
+				network: network,
+				address: l.ClusterAddress,
+				desc:    fmt.Sprintf("listener %d (%s) cluster_address %q", i+1, network, l.ClusterAddress),
+			})
+		}
+
+		for _, b := range bound {
+			if b.address == "" {
+				continue
+			}
+			for _, existing := range addrs {
+				if existing.network == b.network && addressesConflict(b.network, existing.address, b.address) {
+					results = append(results, configutil.ConfigError{
+						Problem: fmt.Sprintf("%s conflicts with %s", b.desc, existing.desc),
+					})
+				}
+			}
+			addrs = append(addrs, b)
+		}
+	}
+
+	return results
+}
+
+// addressesConflict returns true if binding both addresses would fail, either
+// because they are the same, or because one is a wildcard address on the same
+// port as the other. Port 0 never conflicts, as a free port is chosen for it.
+func addressesConflict(network, a, b string) bool {
+	if network != "tcp" {
+		return a == b
+	}
+
+	hostA, portA, errA := net.SplitHostPort(a)
+	hostB, portB, errB := net.SplitHostPort(b)
+	if errA != nil || errB != nil {
+		return a == b
+	}
+	if portA != portB || portA == "0" {
+		return false
+	}
+	if hostA == hostB {
+		return true
+	}
+
+	isWildcard := func(host string) bool {
+		if host == "" {
+			return true
+		}
+		ip := net.ParseIP(host)
+		return ip != nil && ip.IsUnspecified()
+	}
+
+	return isWildcard(hostA) || isWildcard(hostB)
+}
+
+func validateSeals(seals []*configutil.KMS) []configutil.ConfigError {
+	var results []configutil.ConfigError
+	names := make(map[string]struct{}, len(seals))
+	priorities := make(map[int]string, len(seals))
+
+	for _, seal := range seals {
+		if seal == nil {
+			continue
+		}
+
+		if seal.Disabled && seal.Type == "shamir" {
+			results = append(results, configutil.ConfigError{
+				Problem: "shamir seals cannot be set disabled (they should simply not be set)",
+			})
+		}
+
+		if seal.Name != "" {
+			if _, ok := names[seal.Name]; ok {
+				results = append(results, configutil.ConfigError{
+					Problem: fmt.Sprintf("seal name %q is used by more than one seal stanza", seal.Name),
+				})
+			}
+			names[seal.Name] = struct{}{}
+		}
+
+		if len(seals) > 1 && !seal.Disabled && seal.Priority > 0 {
+			if other, ok := priorities[seal.Priority]; ok {
+				results = append(results, configutil.ConfigError{
+					Problem: fmt.Sprintf("seals %q and %q have the same priority %d", other, seal.Name, seal.Priority),
+				})
+			}
+			priorities[seal.Priority] = seal.Name
+		}
+	}
+
+	return results
+}
@@ -85,6 +85,19 @@ listener "tcp" {
   tls_cert_file = "TMPDIR/reload_cert.pem"
   tls_key_file  = "TMPDIR/reload_key.pem"
 }
+`
+	conflictingListenersHCL = `
+listener "tcp" {
+  address     = "127.0.0.1:8200"
+  tls_disable = "true"
+}
+listener "tcp" {
+  address     = "0.0.0.0:8200"
+  tls_disable = "true"
+}
+`
+	unknownKeyHCL = `
+not_a_real_key = true
 `
 	cloudHCL = `
 cloud {
@@ -275,6 +288,55 @@ func TestServer(t *testing.T) {
 			0,
 			[]string{"-test-verify-only", "-recovery"},
 		},
+		{
+			"validate_config",
+			testBaseHCL(t, "") + inmemHCL,
+			"Configuration is valid",
+			0,
+			[]string{"-validate-config"},
+		},
+		{
+			"validate_config_json",
+			testBaseHCL(t, "") + inmemHCL,
+			`"valid": true`,
+			0,
+			[]string{"-validate-config", "-format=json"},
+		},
+		{
+			"validate_config_unknown_key",
+			testBaseHCL(t, "") + inmemHCL + unknownKeyHCL,
+			"unknown or unsupported field not_a_real_key",
+			2,
+			[]string{"-validate-config"},
+		},
+		{
+			"validate_config_unknown_key_strict",
+			testBaseHCL(t, "") + inmemHCL + unknownKeyHCL,
+			"Configuration is invalid",
+			1,
+			[]string{"-validate-config", "-strict-config"},
+		},
+		{
+			"validate_config_conflicting_listeners",
+			testBaseHCL(t, "") + inmemHCL + conflictingListenersHCL,
+			`conflicts with listener 2 (tcp) address \"127.0.0.1:8200\"`,
+			1,
+			[]string{"-validate-config", "-format=json"},
+		},
+		{
+			"validate_config_no_storage",
+			testBaseHCL(t, ""),
+			"a storage backend must be specified",
+			1,
+			[]string{"-validate-config"},
+		},
+		{
+			"strict_config_unknown_key",
+			testBaseHCL(t, "") + inmemHCL + unknownKeyHCL,
+			"Refusing to start with configuration problems",
+			1,
+			[]string{"-test-verify-only", "-strict-config"},
+		},
 	}
 
 	for _, tc := range cases {
@@ -0,0 +1,146 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/vault/command/server"
+	"github.com/hashicorp/vault/internalshared/configutil"
+)
+
+// configValidationProblem is a single problem found by -validate-config.
+type configValidationProblem struct {
+	Problem  string `json:"problem"`
+	Position string `json:"position,omitempty"`
+}
+
+// configValidationResult is the machine-readable result of -validate-config.
+type configValidationResult struct {
+	Valid    bool                      `json:"valid"`
+	Strict   bool                      `json:"strict"`
+	Files    []string                  `json:"files"`
+	Errors   []configValidationProblem `json:"errors"`
+	Warnings []configValidationProblem `json:"warnings"`
+}
+
+func newConfigValidationProblem(cErr configutil.ConfigError) configValidationProblem {
+	problem := configValidationProblem{Problem: cErr.Problem}
+	if cErr.Position.IsValid() || cErr.Position.Filename != "" {
+		problem.Position = cErr.Position.String()
+	}
+	return problem
+}
+
+// validateConfig loads every configuration file given by -config, continuing
+// past files which fail to load so that all problems are reported at once,
+// and cross-checks the merged configuration.
+func (c *ServerCommand) validateConfig() *configValidationResult {
+	result := &configValidationResult{
+		Strict:   c.flagStrictConfig,
+		Files:    c.flagConfigs,
+		Errors:   []configValidationProblem{},
+		Warnings: []configValidationProblem{},
+	}
+
+	if len(c.flagConfigs) == 0 {
+		result.Errors = append(result.Errors, configValidationProblem{
+			Problem: "must specify at least one config path using -config",
+		})
+		return result
+	}
+
+	var config *server.Config
+	for _, path := range c.flagConfigs {
+		current, err := server.LoadConfig(path)
+		if err != nil {
+			result.Errors = append(result.Errors, configValidationProblem{
+				Problem:  err.Error(),
+				Position: path,
+			})
+			continue
+		}
+
+		for _, cErr := range current.Validate(path) {
+			result.Warnings = append(result.Warnings, newConfigValidationProblem(cErr))
+		}
+
+		if config == nil {
+			config = current
+		} else {
+			config = config.Merge(current)
+		}
+	}
+
+	switch {
+	case config == nil && len(result.Errors) == 0:
+		result.Errors = append(result.Errors, configValidationProblem{
+			Problem: "no configuration files found; directories must contain files with the .hcl or .json extension",
+		})
+	case config != nil:
+		if config.Storage == nil {
+			result.Errors = append(result.Errors, configValidationProblem{
+				Problem: "a storage backend must be specified",
+			})
+		}
+		for _, cErr := range config.ValidateMerged() {
+			result.Errors = append(result.Errors, newConfigValidationProblem(cErr))
+		}
+	}
+
+	result.Valid = len(result.Errors) == 0 && (!c.flagStrictConfig || len(result.Warnings) == 0)
+	return result
+}
+
+// runValidateConfig validates the configuration without starting the server.
+// It exits with 0 if the configuration is valid, 2 if it is valid but there
+// are warnings, and 1 otherwise.
+func (c *ServerCommand) runValidateConfig() int {
+	result := c.validateConfig()
+
+	switch strings.ToLower(c.flagFormat) {
+	case "json":
+		out, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error marshaling validation result: %s", err))
+			return 1
+		}
+		c.UI.Output(string(out))
+	case "table", "":
+		for _, p := range result.Errors {
+			c.UI.Error(formatConfigValidationProblem("Error", p))
+		}
+		for _, p := range result.Warnings {
+			c.UI.Warn(formatConfigValidationProblem("Warning", p))
+		}
+		switch {
+		case !result.Valid:
+			c.UI.Error("Configuration is invalid")
+		case len(result.Warnings) > 0:
+			c.UI.Output("Configuration is valid, with warnings")
+		default:
+			c.UI.Output("Configuration is valid")
+		}
+	default:
+		c.UI.Error(fmt.Sprintf("Invalid output format: %s", c.flagFormat))
+		return 1
+	}
+
+	switch {
+	case !result.Valid:
+		return 1
+	case len(result.Warnings) > 0:
+		return 2
+	}
+	return 0
+}
+
+func formatConfigValidationProblem(severity string, p configValidationProblem) string {
+	if p.Position == "" {
+		return fmt.Sprintf("%s: %s", severity, p.Problem)
+	}
+	return fmt.Sprintf("%s: %s at %s", severity, p.Problem, p.Position)
+}
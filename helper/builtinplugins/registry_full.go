@@ -37,7 +37,10 @@ import (
 	credRadius "github.com/hashicorp/vault/builtin/credential/radius"
 	logicalAws "github.com/hashicorp/vault/builtin/logical/aws"
 	logicalConsul "github.com/hashicorp/vault/builtin/logical/consul"
+	logicalGPG "github.com/hashicorp/vault/builtin/logical/gpg"
 	logicalNomad "github.com/hashicorp/vault/builtin/logical/nomad"
+	logicalNotary "github.com/hashicorp/vault/builtin/logical/notary"
+	logicalOAuth2 "github.com/hashicorp/vault/builtin/logical/oauth2"
 	logicalRabbit "github.com/hashicorp/vault/builtin/logical/rabbitmq"
 	logicalTotp "github.com/hashicorp/vault/builtin/logical/totp"
 	dbCass "github.com/hashicorp/vault/plugins/database/cassandra"
@@ -112,6 +115,7 @@ func newFullAddonRegistry() *registry {
 			"consul":     {Factory: logicalConsul.Factory},
 			"gcp":        {Factory: logicalGcp.Factory},
 			"gcpkms":     {Factory: logicalGcpKms.Factory},
+			"gpg":        {Factory: logicalGPG.Factory},
 			"kubernetes": {Factory: logicalKube.Factory},
 			"mongodb": {
 				Factory:           removedFactory,
@@ -127,6 +131,8 @@ func newFullAddonRegistry() *registry {
 				DeprecationStatus: consts.Removed,
 			},
 			"nomad":    {Factory: logicalNomad.Factory},
+			"notary":   {Factory: logicalNotary.Factory},
+			"oauth2":   {Factory: logicalOAuth2.Factory},
 			"openldap": {Factory: logicalLDAP.Factory},
 			"ldap":     {Factory: logicalLDAP.Factory},
 			"postgresql": {
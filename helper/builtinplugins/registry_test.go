@@ -109,7 +109,7 @@ func Test_RegistryKeyCounts(t *testing.T) {
 		{
 			name:       "number of secrets plugins",
 			pluginType: consts.PluginTypeSecrets,
-			want:       19,
+			want:       22,
 			entWant:    3,
 		},
 	}
@@ -257,10 +257,10 @@ func Test_RegistryMatchesGenOpenapi(t *testing.T) {
 
 		var (
 			credentialBackends   []string
-			credentialBackendsRe = regexp.MustCompile(leading + `vault auth enable (?:-.+ )*(?:"([a-zA-Z]+)"|([a-zA-Z]+))$`)
+			credentialBackendsRe = regexp.MustCompile(leading + `vault auth enable (?:-.+ )*(?:"([a-zA-Z][a-zA-Z0-9]*)"|([a-zA-Z][a-zA-Z0-9]*))$`)
 
 			secretsBackends   []string
-			secretsBackendsRe = regexp.MustCompile(leading + `vault secrets enable (?:-.+ )*(?:"([a-zA-Z]+)"|([a-zA-Z]+))$`)
+			secretsBackendsRe = regexp.MustCompile(leading + `vault secrets enable (?:-.+ )*(?:"([a-zA-Z][a-zA-Z0-9]*)"|([a-zA-Z][a-zA-Z0-9]*))$`)
 		)
 
 		scanner := bufio.NewScanner(f)
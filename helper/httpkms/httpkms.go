@@ -0,0 +1,365 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+// Package httpkms provides a seal wrapper delegating encryption to an external
+// key management service over a small JSON over HTTP protocol, so that KMS
+// products and HSMs without a dedicated wrapper can be used for auto-unseal by
+// running an adapter in front of them.
+//
+// The service must implement two endpoints relative to the configured address:
+//
+//	POST /encrypt  {"key_id": "...", "plaintext": "<base64>", "aad": "<base64>"}
+//	           ->  {"key_id": "...", "ciphertext": "<base64>"}
+//
+//	POST /decrypt  {"key_id": "...", "ciphertext": "<base64>", "aad": "<base64>"}
+//	           ->  {"plaintext": "<base64>"}
+//
+// The key_id returned by /encrypt identifies the key version used, and is
+// passed back to /decrypt; it defaults to the configured key_id when omitted.
+// Any non-2xx status is treated as a failure, and the service may describe it
+// in an {"errors": ["..."]} body.
+package httpkms
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/hashicorp/go-cleanhttp"
+	wrapping "github.com/hashicorp/go-kms-wrapping/v2"
+	"github.com/hashicorp/go-secure-stdlib/parseutil"
+)
+
+// WrapperType is the seal type of the HTTP KMS wrapper.
+const WrapperType = wrapping.WrapperType("httpkms")
+
+const (
+	defaultTimeout = 30 * time.Second
+
+	// maxResponseSize bounds the size of the responses read from the KMS
+	// service.
+	maxResponseSize = 1024 * 1024
+)
+
+var (
+	_ wrapping.Wrapper       = (*Wrapper)(nil)
+	_ wrapping.InitFinalizer = (*Wrapper)(nil)
+)
+
+// Wrapper is a wrapper that encrypts and decrypts using an external KMS
+// service speaking the protocol described in the package documentation.
+type Wrapper struct {
+	client       *http.Client
+	address      string
+	token        string
+	keyId        string
+	currentKeyId *atomic.Value
+}
+
+type encryptRequest struct {
+	KeyId     string `json:"key_id"`
+	Plaintext []byte `json:"plaintext"`
+	AAD       []byte `json:"aad,omitempty"`
+}
+
+type encryptResponse struct {
+	KeyId      string `json:"key_id"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+type decryptRequest struct {
+	KeyId      string `json:"key_id"`
+	Ciphertext []byte `json:"ciphertext"`
+	AAD        []byte `json:"aad,omitempty"`
+}
+
+type decryptResponse struct {
+	Plaintext []byte `json:"plaintext"`
+}
+
+type errorResponse struct {
+	Errors []string `json:"errors"`
+}
+
+// NewWrapper creates a new HTTP KMS wrapper.
+func NewWrapper() *Wrapper {
+	w := &Wrapper{
+		currentKeyId: new(atomic.Value),
+	}
+	w.currentKeyId.Store("")
+	return w
+}
+
+// SetConfig processes the config info from the server config.
+func (w *Wrapper) SetConfig(_ context.Context, opt ...wrapping.Option) (*wrapping.WrapperConfig, error) {
+	opts, err := wrapping.GetOpts(opt...)
+	if err != nil {
+		return nil, err
+	}
+	config := opts.WithConfigMap
+
+	w.address = strings.TrimSuffix(config["address"], "/")
+	if w.address == "" {
+		return nil, errors.New("'address' must be provided to configure the HTTP KMS seal")
+	}
+	tlsDisable := false
+	if raw, ok := config["tls_disable"]; ok {
+		tlsDisable, err = parseutil.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing 'tls_disable': %w", err)
+		}
+	}
+	if err := validateAddress(w.address, tlsDisable); err != nil {
+		return nil, err
+	}
+
+	w.keyId = config["key_id"]
+	if opts.WithKeyId != "" {
+		w.keyId = opts.WithKeyId
+	}
+	if w.keyId == "" {
+		return nil, errors.New("'key_id' must be provided to configure the HTTP KMS seal")
+	}
+	w.currentKeyId.Store(w.keyId)
+
+	w.token = config["token"]
+
+	timeout := defaultTimeout
+	if raw, ok := config["timeout"]; ok {
+		timeout, err = parseutil.ParseDurationSecond(raw)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing 'timeout': %w", err)
+		}
+	}
+
+	tlsConfig, err := newTLSConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	w.client = cleanhttp.DefaultPooledClient()
+	w.client.Timeout = timeout
+	w.client.Transport.(*http.Transport).TLSClientConfig = tlsConfig
+	// Redirects are not followed, so that the key material and the token are
+	// only ever sent to the configured address.
+	w.client.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	wrapConfig := new(wrapping.WrapperConfig)
+	wrapConfig.Metadata = map[string]string{
+		"address": w.address,
+		"key_id":  w.keyId,
+	}
+	return wrapConfig, nil
+}
+
+// validateAddress checks that the address of the KMS service uses TLS, since
+// the key material and the token are sent to it. Plain HTTP is only allowed
+// with tls_disable, and to a loopback address, for adapters running alongside
+// Vault.
+func validateAddress(address string, tlsDisable bool) error {
+	u, err := url.Parse(address)
+	if err != nil {
+		return fmt.Errorf("error parsing 'address': %w", err)
+	}
+
+	switch u.Scheme {
+	case "https":
+		return nil
+	case "http":
+		if !tlsDisable {
+			return errors.New("'address' must use https unless 'tls_disable' is set")
+		}
+		host := u.Hostname()
+		if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+			return errors.New("'tls_disable' is only allowed with a loopback 'address'")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported scheme %q in 'address'", u.Scheme)
+	}
+}
+
+func newTLSConfig(config map[string]string) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: config["tls_server_name"],
+	}
+
+	if raw, ok := config["tls_skip_verify"]; ok {
+		skipVerify, err := parseutil.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing 'tls_skip_verify': %w", err)
+		}
+		tlsConfig.InsecureSkipVerify = skipVerify
+	}
+
+	if caCert := config["tls_ca_cert"]; caCert != "" {
+		pem, err := os.ReadFile(caCert)
+		if err != nil {
+			return nil, fmt.Errorf("error reading 'tls_ca_cert': %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.New("no certificates found in 'tls_ca_cert'")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	clientCert, clientKey := config["tls_client_cert"], config["tls_client_key"]
+	switch {
+	case clientCert != "" && clientKey != "":
+		cert, err := tls.LoadX509KeyPair(clientCert, clientKey)
+		if err != nil {
+			return nil, fmt.Errorf("error loading client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	case clientCert != "" || clientKey != "":
+		return nil, errors.New("'tls_client_cert' and 'tls_client_key' must be provided together")
+	}
+
+	return tlsConfig, nil
+}
+
+// Init is called during core.Initialize.
+func (w *Wrapper) Init(_ context.Context, _ ...wrapping.Option) error {
+	return nil
+}
+
+// Finalize is called during shutdown.
+func (w *Wrapper) Finalize(_ context.Context, _ ...wrapping.Option) error {
+	if w.client != nil {
+		w.client.CloseIdleConnections()
+	}
+	return nil
+}
+
+// Type returns the type for this particular Wrapper implementation.
+func (w *Wrapper) Type(_ context.Context) (wrapping.WrapperType, error) {
+	return WrapperType, nil
+}
+
+// KeyId returns the last known key id.
+func (w *Wrapper) KeyId(_ context.Context) (string, error) {
+	return w.currentKeyId.Load().(string), nil
+}
+
+// Encrypt sends the plaintext to the KMS service to be encrypted.
+func (w *Wrapper) Encrypt(ctx context.Context, plaintext []byte, opt ...wrapping.Option) (*wrapping.BlobInfo, error) {
+	opts, err := wrapping.GetOpts(opt...)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp encryptResponse
+	if err := w.do(ctx, "encrypt", &encryptRequest{
+		KeyId:     w.keyId,
+		Plaintext: plaintext,
+		AAD:       opts.WithAad,
+	}, &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Ciphertext) == 0 {
+		return nil, errors.New("no ciphertext returned by the HTTP KMS")
+	}
+
+	keyId := resp.KeyId
+	if keyId == "" {
+		keyId = w.keyId
+	}
+	w.currentKeyId.Store(keyId)
+
+	return &wrapping.BlobInfo{
+		Ciphertext: resp.Ciphertext,
+		KeyInfo: &wrapping.KeyInfo{
+			KeyId: keyId,
+		},
+	}, nil
+}
+
+// Decrypt sends the ciphertext to the KMS service to be decrypted.
+func (w *Wrapper) Decrypt(ctx context.Context, in *wrapping.BlobInfo, opt ...wrapping.Option) ([]byte, error) {
+	if in == nil {
+		return nil, errors.New("given input for decryption is nil")
+	}
+	opts, err := wrapping.GetOpts(opt...)
+	if err != nil {
+		return nil, err
+	}
+
+	keyId := w.keyId
+	if in.KeyInfo != nil && in.KeyInfo.KeyId != "" {
+		keyId = in.KeyInfo.KeyId
+	}
+
+	var resp decryptResponse
+	if err := w.do(ctx, "decrypt", &decryptRequest{
+		KeyId:      keyId,
+		Ciphertext: in.Ciphertext,
+		AAD:        opts.WithAad,
+	}, &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Plaintext) == 0 {
+		return nil, errors.New("no plaintext returned by the HTTP KMS")
+	}
+
+	return resp.Plaintext, nil
+}
+
+// do posts the request to the given endpoint of the KMS service, and decodes
+// its response into out.
+func (w *Wrapper) do(ctx context.Context, endpoint string, in, out interface{}) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.address+"/"+endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if w.token != "" {
+		req.Header.Set("Authorization", "Bearer "+w.token)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error sending %s request to the HTTP KMS: %w", endpoint, err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize+1))
+	if err != nil {
+		return fmt.Errorf("error reading %s response from the HTTP KMS: %w", endpoint, err)
+	}
+	if len(respBody) > maxResponseSize {
+		return fmt.Errorf("%s response from the HTTP KMS exceeds %d bytes", endpoint, maxResponseSize)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var errResp errorResponse
+		if json.Unmarshal(respBody, &errResp) == nil && len(errResp.Errors) > 0 {
+			return fmt.Errorf("HTTP KMS %s request failed with status %d: %s", endpoint, resp.StatusCode, strings.Join(errResp.Errors, ", "))
+		}
+		return fmt.Errorf("HTTP KMS %s request failed with status %d", endpoint, resp.StatusCode)
+	}
+
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("error decoding %s response from the HTTP KMS: %w", endpoint, err)
+	}
+	return nil
+}
@@ -0,0 +1,325 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package httpkms
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	wrapping "github.com/hashicorp/go-kms-wrapping/v2"
+	"github.com/stretchr/testify/require"
+)
+
+// testKMS is a minimal implementation of the HTTP KMS protocol, encrypting
+// with AES-GCM under a key per key_id. The key_id it reports is suffixed with
+// the key version so that rotation can be tested.
+type testKMS struct {
+	token   string
+	version string
+	keys    map[string]cipher.AEAD
+}
+
+func newTestKMS(t *testing.T, token string) (*testKMS, *httptest.Server) {
+	t.Helper()
+
+	kms := &testKMS{
+		token:   token,
+		version: "1",
+		keys:    make(map[string]cipher.AEAD),
+	}
+	srv := httptest.NewTLSServer(kms)
+	t.Cleanup(srv.Close)
+	return kms, srv
+}
+
+// testCACert writes the certificate of the test server to a file, to be used
+// as tls_ca_cert.
+func testCACert(t *testing.T, srv *httptest.Server) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	pemBytes := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	require.NoError(t, os.WriteFile(path, pemBytes, 0o600))
+	return path
+}
+
+func (k *testKMS) key(keyId string) cipher.AEAD {
+	if aead, ok := k.keys[keyId]; ok {
+		return aead
+	}
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		panic(err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		panic(err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		panic(err)
+	}
+	k.keys[keyId] = aead
+	return aead
+}
+
+func (k *testKMS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	writeError := func(status int, msg string) {
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(&errorResponse{Errors: []string{msg}})
+	}
+
+	if k.token != "" && r.Header.Get("Authorization") != "Bearer "+k.token {
+		writeError(http.StatusForbidden, "permission denied")
+		return
+	}
+
+	switch r.URL.Path {
+	case "/encrypt":
+		var req encryptRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(http.StatusBadRequest, err.Error())
+			return
+		}
+		keyId := req.KeyId + ":" + k.version
+		aead := k.key(keyId)
+		nonce := make([]byte, aead.NonceSize())
+		if _, err := rand.Read(nonce); err != nil {
+			writeError(http.StatusInternalServerError, err.Error())
+			return
+		}
+		json.NewEncoder(w).Encode(&encryptResponse{
+			KeyId:      keyId,
+			Ciphertext: aead.Seal(nonce, nonce, req.Plaintext, req.AAD),
+		})
+
+	case "/decrypt":
+		var req decryptRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(http.StatusBadRequest, err.Error())
+			return
+		}
+		aead, ok := k.keys[req.KeyId]
+		if !ok || len(req.Ciphertext) < aead.NonceSize() {
+			writeError(http.StatusBadRequest, "invalid ciphertext")
+			return
+		}
+		nonce, ciphertext := req.Ciphertext[:aead.NonceSize()], req.Ciphertext[aead.NonceSize():]
+		plaintext, err := aead.Open(nil, nonce, ciphertext, req.AAD)
+		if err != nil {
+			writeError(http.StatusBadRequest, "invalid ciphertext")
+			return
+		}
+		json.NewEncoder(w).Encode(&decryptResponse{Plaintext: plaintext})
+
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func newTestWrapper(t *testing.T, config map[string]string) *Wrapper {
+	t.Helper()
+
+	w := NewWrapper()
+	_, err := w.SetConfig(context.Background(), wrapping.WithConfigMap(config))
+	require.NoError(t, err)
+	return w
+}
+
+// TestWrapper_Lifecycle checks that values round trip through the KMS, and
+// that values encrypted before a key rotation can still be decrypted.
+func TestWrapper_Lifecycle(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	kms, srv := newTestKMS(t, "secret-token")
+	w := newTestWrapper(t, map[string]string{
+		"address":     srv.URL + "/",
+		"key_id":      "vault-unseal",
+		"token":       "secret-token",
+		"tls_ca_cert": testCACert(t, srv),
+	})
+
+	typ, err := w.Type(ctx)
+	require.NoError(t, err)
+	require.Equal(t, WrapperType, typ)
+
+	keyId, err := w.KeyId(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "vault-unseal", keyId)
+
+	aad := wrapping.WithAad([]byte("aad"))
+	blob, err := w.Encrypt(ctx, []byte("foo"), aad)
+	require.NoError(t, err)
+	require.Equal(t, "vault-unseal:1", blob.KeyInfo.KeyId)
+
+	keyId, err = w.KeyId(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "vault-unseal:1", keyId)
+
+	plaintext, err := w.Decrypt(ctx, blob, aad)
+	require.NoError(t, err)
+	require.Equal(t, []byte("foo"), plaintext)
+
+	// The additional authenticated data must match.
+	_, err = w.Decrypt(ctx, blob, wrapping.WithAad([]byte("other")))
+	require.ErrorContains(t, err, "HTTP KMS decrypt request failed with status 400: invalid ciphertext")
+
+	// Rotate the key, values encrypted with the previous version are still
+	// decrypted with it.
+	kms.version = "2"
+	rotated, err := w.Encrypt(ctx, []byte("bar"))
+	require.NoError(t, err)
+	require.Equal(t, "vault-unseal:2", rotated.KeyInfo.KeyId)
+
+	plaintext, err = w.Decrypt(ctx, blob, aad)
+	require.NoError(t, err)
+	require.Equal(t, []byte("foo"), plaintext)
+
+	require.NoError(t, w.Finalize(ctx))
+}
+
+// TestWrapper_Unauthorized checks that errors returned by the KMS are
+// surfaced.
+func TestWrapper_Unauthorized(t *testing.T) {
+	t.Parallel()
+
+	_, srv := newTestKMS(t, "secret-token")
+	w := newTestWrapper(t, map[string]string{
+		"address":     srv.URL,
+		"key_id":      "vault-unseal",
+		"token":       "wrong-token",
+		"tls_ca_cert": testCACert(t, srv),
+	})
+
+	_, err := w.Encrypt(context.Background(), []byte("foo"))
+	require.EqualError(t, err, "HTTP KMS encrypt request failed with status 403: permission denied")
+}
+
+// TestWrapper_InvalidResponses checks that redirects are not followed, and
+// that oversized responses and empty plaintexts are rejected.
+func TestWrapper_InvalidResponses(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		handler        http.HandlerFunc
+		wantErrMessage string
+	}{
+		"redirect": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.Redirect(w, r, "https://attacker.example.com/decrypt", http.StatusTemporaryRedirect)
+			},
+			wantErrMessage: "HTTP KMS decrypt request failed with status 307",
+		},
+		"oversized": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`{"plaintext": "` + strings.Repeat("A", maxResponseSize) + `"}`))
+			},
+			wantErrMessage: "decrypt response from the HTTP KMS exceeds",
+		},
+		"empty-plaintext": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`{"plaintext": ""}`))
+			},
+			wantErrMessage: "no plaintext returned by the HTTP KMS",
+		},
+	}
+
+	for name, tc := range tests {
+		name := name
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			srv := httptest.NewServer(tc.handler)
+			t.Cleanup(srv.Close)
+			w := newTestWrapper(t, map[string]string{
+				"address":     srv.URL,
+				"key_id":      "vault-unseal",
+				"tls_disable": "true",
+			})
+
+			_, err := w.Decrypt(context.Background(), &wrapping.BlobInfo{Ciphertext: []byte("foo")})
+			require.ErrorContains(t, err, tc.wantErrMessage)
+		})
+	}
+}
+
+// TestWrapper_SetConfig checks that invalid configurations are rejected.
+func TestWrapper_SetConfig(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		config         map[string]string
+		wantErrMessage string
+	}{
+		"valid": {
+			config: map[string]string{"address": "https://kms.example.com", "key_id": "key", "timeout": "10s"},
+		},
+		"no-address": {
+			config:         map[string]string{"key_id": "key"},
+			wantErrMessage: "'address' must be provided to configure the HTTP KMS seal",
+		},
+		"loopback-http": {
+			config: map[string]string{"address": "http://127.0.0.1:8080", "key_id": "key", "tls_disable": "true"},
+		},
+		"http-without-tls-disable": {
+			config:         map[string]string{"address": "http://127.0.0.1:8080", "key_id": "key"},
+			wantErrMessage: "'address' must use https unless 'tls_disable' is set",
+		},
+		"non-loopback-http": {
+			config:         map[string]string{"address": "http://kms.example.com", "key_id": "key", "tls_disable": "true"},
+			wantErrMessage: "'tls_disable' is only allowed with a loopback 'address'",
+		},
+		"bad-scheme": {
+			config:         map[string]string{"address": "ftp://kms.example.com", "key_id": "key"},
+			wantErrMessage: `unsupported scheme "ftp" in 'address'`,
+		},
+		"no-key-id": {
+			config:         map[string]string{"address": "https://kms.example.com"},
+			wantErrMessage: "'key_id' must be provided to configure the HTTP KMS seal",
+		},
+		"bad-timeout": {
+			config:         map[string]string{"address": "https://kms.example.com", "key_id": "key", "timeout": "soon"},
+			wantErrMessage: "error parsing 'timeout'",
+		},
+		"bad-tls-skip-verify": {
+			config:         map[string]string{"address": "https://kms.example.com", "key_id": "key", "tls_skip_verify": "maybe"},
+			wantErrMessage: "error parsing 'tls_skip_verify'",
+		},
+		"missing-ca-cert": {
+			config:         map[string]string{"address": "https://kms.example.com", "key_id": "key", "tls_ca_cert": "/nonexistent/ca.pem"},
+			wantErrMessage: "error reading 'tls_ca_cert'",
+		},
+		"client-cert-without-key": {
+			config:         map[string]string{"address": "https://kms.example.com", "key_id": "key", "tls_client_cert": "/tmp/cert.pem"},
+			wantErrMessage: "'tls_client_cert' and 'tls_client_key' must be provided together",
+		},
+	}
+
+	for name, tc := range tests {
+		name := name
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := NewWrapper().SetConfig(context.Background(), wrapping.WithConfigMap(tc.config))
+			if tc.wantErrMessage != "" {
+				require.ErrorContains(t, err, tc.wantErrMessage)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
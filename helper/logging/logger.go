@@ -55,6 +55,9 @@ type LogConfig struct {
 	// The creator of this struct *must* ensure that it is assigned before doing
 	// anything with LogConfig!
 	DefaultFileName string
+
+	// Redactor, if set, redacts sensitive values from all log output.
+	Redactor *Redactor
 }
 
 // NewLogConfig should be used to initialize the LogConfig struct.
@@ -161,11 +164,16 @@ func Setup(config *LogConfig, w io.Writer) (hclog.InterceptLogger, error) {
 		writers = append(writers, logFile)
 	}
 
+	output := io.MultiWriter(writers...)
+	if config.Redactor != nil {
+		output = config.Redactor.Writer(output)
+	}
+
 	logger := hclog.NewInterceptLogger(&hclog.LoggerOptions{
 		Name:              config.Name,
 		Level:             config.LogLevel,
 		IndependentLevels: true,
-		Output:            io.MultiWriter(writers...),
+		Output:            output,
 		JSONFormat:        config.isFormatJson(),
 	})
 
@@ -0,0 +1,89 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package logging
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+)
+
+// RedactedValue replaces any sensitive value matched by a Redactor.
+const RedactedValue = "[REDACTED]"
+
+// builtinRedactionPatterns match values which should never appear in
+// operational logs, regardless of any patterns supplied by the operator.
+var builtinRedactionPatterns = []string{
+	// PEM encoded private keys of any type, across any number of lines, with
+	// newlines either raw or escaped as they are in JSON formatted logs.
+	`-----BEGIN [A-Z0-9 ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z0-9 ]*PRIVATE KEY-----`,
+
+	// Vault service, batch and recovery tokens, in both the current and
+	// legacy formats.
+	`\bhv[sbr]\.[A-Za-z0-9_-]{24,}`,
+	`\b[sbr]\.[A-Za-z0-9]{24}\b`,
+
+	// AWS access key IDs, and secret access keys when logged alongside
+	// their usual key names.
+	`\b(?:AKIA|ASIA)[A-Z0-9]{16}\b`,
+	`(?i)(aws_?secret_?(?:access_?)?key["']?\s*[:=]\s*["']?)[A-Za-z0-9/+=]{40}`,
+}
+
+// Redactor replaces values which match any of its patterns in log output.
+type Redactor struct {
+	patterns []*regexp.Regexp
+}
+
+// NewRedactor creates a Redactor which matches the built-in patterns for
+// sensitive values, along with any additional regular expressions supplied.
+func NewRedactor(additional []string) (*Redactor, error) {
+	r := &Redactor{}
+
+	for _, p := range append(append([]string{}, builtinRedactionPatterns...), additional...) {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid log redaction pattern %q: %w", p, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+
+	return r, nil
+}
+
+// Redact returns a copy of p with all matched values replaced. When a pattern
+// contains a capture group, the text matched by the first group is retained
+// so that the name of the redacted value remains visible.
+func (r *Redactor) Redact(p []byte) []byte {
+	for _, re := range r.patterns {
+		if re.NumSubexp() > 0 {
+			p = re.ReplaceAll(p, []byte("${1}"+RedactedValue))
+		} else {
+			p = re.ReplaceAllLiteral(p, []byte(RedactedValue))
+		}
+	}
+
+	return p
+}
+
+// Writer wraps w so that everything written to it is redacted first. Loggers
+// write each entry in a single call, so values are never split across writes.
+func (r *Redactor) Writer(w io.Writer) io.Writer {
+	return &redactingWriter{w: w, redactor: r}
+}
+
+// redactingWriter redacts log entries before writing them to the wrapped writer.
+type redactingWriter struct {
+	w        io.Writer
+	redactor *Redactor
+}
+
+func (w *redactingWriter) Write(p []byte) (int, error) {
+	if _, err := w.w.Write(w.redactor.Redact(p)); err != nil {
+		return 0, err
+	}
+
+	// Report the length of the original entry, since that is what the caller
+	// asked to write.
+	return len(p), nil
+}
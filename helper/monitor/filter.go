@@ -0,0 +1,107 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package monitor
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	log "github.com/hashicorp/go-hclog"
+	"go.uber.org/atomic"
+)
+
+// Filter restricts which log messages are streamed by a Monitor.
+type Filter struct {
+	// Subsystems, if set, limits the streamed messages to those emitted by
+	// loggers with one of these names, or by loggers named beneath them. For
+	// example, "storage" matches both "storage" and "storage.raft", but not
+	// "storagepacker".
+	Subsystems []string
+
+	// SampleRate, if set, is the fraction of messages below the warn level
+	// which are streamed. Warnings and errors are always streamed. A rate of
+	// zero or one streams every message.
+	SampleRate float64
+}
+
+// Validate returns an error if the filter is not valid.
+func (f *Filter) Validate() error {
+	if f == nil {
+		return nil
+	}
+	if math.IsNaN(f.SampleRate) || f.SampleRate < 0 || f.SampleRate > 1 {
+		return fmt.Errorf("sample rate must be between 0 and 1")
+	}
+	for _, s := range f.Subsystems {
+		if strings.TrimSpace(s) == "" {
+			return fmt.Errorf("subsystem names must not be empty")
+		}
+	}
+	return nil
+}
+
+// filteredSink wraps a SinkAdapter, only passing on the messages which are
+// accepted by the filter.
+type filteredSink struct {
+	log.SinkAdapter
+
+	subsystems []string
+	sampleRate float64
+
+	// sampled counts the messages subject to sampling, so that the rate is
+	// applied evenly rather than randomly.
+	sampled *atomic.Uint64
+}
+
+func newFilteredSink(sink log.SinkAdapter, filter *Filter) log.SinkAdapter {
+	if filter == nil || (len(filter.Subsystems) == 0 && (filter.SampleRate == 0 || filter.SampleRate == 1)) {
+		return sink
+	}
+
+	fs := &filteredSink{
+		SinkAdapter: sink,
+		sampleRate:  filter.SampleRate,
+		sampled:     atomic.NewUint64(0),
+	}
+	for _, s := range filter.Subsystems {
+		fs.subsystems = append(fs.subsystems, strings.ToLower(strings.TrimSpace(s)))
+	}
+
+	return fs
+}
+
+func (f *filteredSink) Accept(name string, level log.Level, msg string, args ...interface{}) {
+	if !f.matchesSubsystem(name) || !f.sample(level) {
+		return
+	}
+	f.SinkAdapter.Accept(name, level, msg, args...)
+}
+
+func (f *filteredSink) matchesSubsystem(name string) bool {
+	if len(f.subsystems) == 0 {
+		return true
+	}
+
+	// Logger names may be prefixed by the name of the root logger, so match
+	// the subsystem against any run of dot separated segments of the name.
+	name = "." + strings.ToLower(name) + "."
+	for _, s := range f.subsystems {
+		if strings.Contains(name, "."+s+".") {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *filteredSink) sample(level log.Level) bool {
+	if f.sampleRate == 0 || f.sampleRate == 1 || level >= log.Warn {
+		return true
+	}
+
+	// Stream a message each time the running total of the rate crosses an
+	// integer, e.g. every fourth message for a rate of 0.25.
+	n := f.sampled.Inc()
+	return math.Floor(float64(n)*f.sampleRate) > math.Floor(float64(n-1)*f.sampleRate)
+}
@@ -57,13 +57,22 @@ type monitor struct {
 // NewMonitor creates a new Monitor. Start must be called in order to actually start
 // streaming logs. buf is the buffer size of the channel that sends log messages.
 func NewMonitor(buf int, logger log.InterceptLogger, opts *log.LoggerOptions) (Monitor, error) {
-	return newMonitor(buf, logger, opts)
+	return newMonitor(buf, logger, opts, nil)
 }
 
-func newMonitor(buf int, logger log.InterceptLogger, opts *log.LoggerOptions) (*monitor, error) {
+// NewFilteredMonitor creates a new Monitor which only streams the log messages
+// accepted by filter.
+func NewFilteredMonitor(buf int, logger log.InterceptLogger, opts *log.LoggerOptions, filter *Filter) (Monitor, error) {
+	return newMonitor(buf, logger, opts, filter)
+}
+
+func newMonitor(buf int, logger log.InterceptLogger, opts *log.LoggerOptions, filter *Filter) (*monitor, error) {
 	if buf <= 0 {
 		return nil, fmt.Errorf("buf must be greater than zero")
 	}
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
 
 	sw := &monitor{
 		logger:            logger,
@@ -76,8 +85,7 @@ func newMonitor(buf int, logger log.InterceptLogger, opts *log.LoggerOptions) (*
 	}
 
 	opts.Output = sw
-	sink := log.NewSinkAdapter(opts)
-	sw.sink = sink
+	sw.sink = newFilteredSink(log.NewSinkAdapter(opts), filter)
 
 	return sw, nil
 }
@@ -112,7 +112,7 @@ func TestMonitor_DroppedMessages(t *testing.T) {
 
 	m, _ := newMonitor(5, logger, &log.LoggerOptions{
 		Level: log.Debug,
-	})
+	}, nil)
 	m.dropCheckInterval = 5 * time.Millisecond
 
 	logCh := m.Start()
@@ -138,3 +138,73 @@ func TestMonitor_DroppedMessages(t *testing.T) {
 		require.Fail(t, "expected to see warn dropped messages")
 	}
 }
+
+// TestMonitor_Filter verifies that only messages from the requested
+// subsystems are streamed, and that sampling thins out messages below the
+// warn level.
+func TestMonitor_Filter(t *testing.T) {
+	t.Parallel()
+
+	logger := log.NewInterceptLogger(&log.LoggerOptions{
+		Level: log.Error,
+	})
+
+	m, err := NewFilteredMonitor(512, logger, &log.LoggerOptions{
+		Level: log.Debug,
+	}, &Filter{
+		Subsystems: []string{"storage"},
+		SampleRate: 0.5,
+	})
+	require.NoError(t, err)
+
+	logCh := m.Start()
+	defer m.Stop()
+
+	raft := logger.Named("storage").Named("raft")
+	logger.Named("expiration").Debug("expiration message")
+	logger.Named("storagex").Debug("storagex message")
+	for i := 0; i < 4; i++ {
+		raft.Debug(fmt.Sprintf("raft message %d", i))
+	}
+	raft.Warn("raft warning")
+
+	var received []string
+	timeout := time.After(5 * time.Second)
+	for len(received) < 3 {
+		select {
+		case l := <-logCh:
+			received = append(received, string(l))
+		case <-timeout:
+			t.Fatalf("expected 3 log messages, got %d: %v", len(received), received)
+		}
+	}
+
+	require.Contains(t, received[0], "storage.raft: raft message 1")
+	require.Contains(t, received[1], "storage.raft: raft message 3")
+	require.Contains(t, received[2], "storage.raft: raft warning")
+
+	select {
+	case l := <-logCh:
+		t.Fatalf("unexpected log message: %s", l)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestMonitor_Filter_Invalid(t *testing.T) {
+	t.Parallel()
+
+	logger := log.NewInterceptLogger(&log.LoggerOptions{
+		Level: log.Error,
+	})
+
+	for _, filter := range []*Filter{
+		{SampleRate: -0.5},
+		{SampleRate: 2},
+		{Subsystems: []string{""}},
+	} {
+		_, err := NewFilteredMonitor(512, logger, &log.LoggerOptions{
+			Level: log.Debug,
+		}, filter)
+		require.Error(t, err)
+	}
+}
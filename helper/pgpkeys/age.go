@@ -0,0 +1,77 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package pgpkeys
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+
+	"filippo.io/age"
+)
+
+// agePrefix is the prefix of age X25519 recipients, which may be given in
+// place of PGP keys to encrypt shares to.
+const agePrefix = "age1"
+
+// IsAgeRecipient returns true if the given key is an age recipient rather than
+// a base64-encoded PGP key.
+func IsAgeRecipient(key string) bool {
+	return strings.HasPrefix(strings.TrimSpace(key), agePrefix)
+}
+
+// ParseAgeRecipient parses the given age X25519 recipient.
+func ParseAgeRecipient(key string) (*age.X25519Recipient, error) {
+	recipient, err := age.ParseX25519Recipient(strings.TrimSpace(key))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing given age recipient: %w", err)
+	}
+	return recipient, nil
+}
+
+// encryptAge encrypts the input to the given age recipient, returning the
+// binary age ciphertext.
+func encryptAge(input []byte, recipient *age.X25519Recipient) ([]byte, error) {
+	ctBuf := bytes.NewBuffer(nil)
+	w, err := age.Encrypt(ctBuf, recipient)
+	if err != nil {
+		return nil, fmt.Errorf("error setting up encryption for age message: %w", err)
+	}
+	if _, err := w.Write(input); err != nil {
+		return nil, fmt.Errorf("error encrypting age message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("error encrypting age message: %w", err)
+	}
+	return ctBuf.Bytes(), nil
+}
+
+// DecryptAgeBytes takes in base64-encoded encrypted bytes and an age X25519
+// identity (AGE-SECRET-KEY-1...) and decrypts it, in the same manner as
+// DecryptBytes does for PGP.
+func DecryptAgeBytes(encodedCrypt, identity string) (*bytes.Buffer, error) {
+	id, err := age.ParseX25519Identity(strings.TrimSpace(identity))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing age identity: %w", err)
+	}
+
+	cryptBytes, err := base64.StdEncoding.DecodeString(encodedCrypt)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding base64 crypted bytes: %w", err)
+	}
+
+	r, err := age.Decrypt(bytes.NewReader(cryptBytes), id)
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting the messages: %w", err)
+	}
+
+	ptBuf := bytes.NewBuffer(nil)
+	if _, err := io.Copy(ptBuf, r); err != nil {
+		return nil, fmt.Errorf("error decrypting the messages: %w", err)
+	}
+
+	return ptBuf, nil
+}
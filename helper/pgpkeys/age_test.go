@@ -0,0 +1,59 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package pgpkeys
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"filippo.io/age"
+)
+
+func TestEncryptShares_Age(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	recipient := identity.Recipient().String()
+
+	if !IsAgeRecipient(recipient) {
+		t.Fatalf("expected %q to be an age recipient", recipient)
+	}
+	if IsAgeRecipient(pubKey1) {
+		t.Fatal("did not expect a PGP key to be an age recipient")
+	}
+
+	keys, err := ParsePGPKeys([]string{recipient})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fingerprints, encrypted, err := EncryptShares([][]byte{[]byte("share")}, keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fingerprints) != 1 || fingerprints[0] != recipient {
+		t.Fatalf("bad fingerprints: %v", fingerprints)
+	}
+
+	fingerprints, err = GetFingerprints(keys, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fingerprints) != 1 || fingerprints[0] != recipient {
+		t.Fatalf("bad fingerprints: %v", fingerprints)
+	}
+
+	decrypted, err := DecryptAgeBytes(base64.StdEncoding.EncodeToString(encrypted[0]), identity.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decrypted.String() != "share" {
+		t.Fatalf("bad decrypted share: %q", decrypted.String())
+	}
+
+	if _, err := ParseAgeRecipient("age1invalid"); err == nil {
+		t.Fatal("expected error parsing invalid recipient")
+	}
+}
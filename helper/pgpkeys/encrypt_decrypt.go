@@ -14,7 +14,9 @@ import (
 
 // EncryptShares takes an ordered set of byte slices to encrypt and the
 // corresponding base64-encoded public keys to encrypt them with, encrypts each
-// byte slice with the corresponding public key.
+// byte slice with the corresponding public key. Age recipients may be given in
+// place of PGP keys, in which case the fingerprint returned for the share is
+// the recipient itself.
 //
 // Note: There is no corresponding test function; this functionality is
 // thoroughly tested in the init and rekey command unit tests
@@ -23,11 +25,28 @@ func EncryptShares(input [][]byte, pgpKeys []string) ([]string, [][]byte, error)
 		return nil, nil, fmt.Errorf("mismatch between number items to encrypt and number of PGP keys")
 	}
 	encryptedShares := make([][]byte, 0, len(pgpKeys))
-	entities, err := GetEntities(pgpKeys)
-	if err != nil {
-		return nil, nil, err
-	}
-	for i, entity := range entities {
+	fingerprints := make([]string, 0, len(pgpKeys))
+	for i, key := range pgpKeys {
+		if IsAgeRecipient(key) {
+			recipient, err := ParseAgeRecipient(key)
+			if err != nil {
+				return nil, nil, err
+			}
+			ct, err := encryptAge(input[i], recipient)
+			if err != nil {
+				return nil, nil, err
+			}
+			encryptedShares = append(encryptedShares, ct)
+			fingerprints = append(fingerprints, recipient.String())
+			continue
+		}
+
+		entities, err := GetEntities([]string{key})
+		if err != nil {
+			return nil, nil, err
+		}
+		entity := entities[0]
+
 		ctBuf := bytes.NewBuffer(nil)
 		pt, err := openpgp.Encrypt(ctBuf, []*openpgp.Entity{entity}, nil, nil, nil)
 		if err != nil {
@@ -39,11 +58,7 @@ func EncryptShares(input [][]byte, pgpKeys []string) ([]string, [][]byte, error)
 		}
 		pt.Close()
 		encryptedShares = append(encryptedShares, ctBuf.Bytes())
-	}
-
-	fingerprints, err := GetFingerprints(nil, entities)
-	if err != nil {
-		return nil, nil, err
+		fingerprints = append(fingerprints, fmt.Sprintf("%x", entity.PrimaryKey.Fingerprint))
 	}
 
 	return fingerprints, encryptedShares, nil
@@ -51,14 +66,28 @@ func EncryptShares(input [][]byte, pgpKeys []string) ([]string, [][]byte, error)
 
 // GetFingerprints takes in a list of openpgp Entities and returns the
 // fingerprints. If entities is nil, it will instead parse both entities and
-// fingerprints from the pgpKeys string slice.
+// fingerprints from the pgpKeys string slice, which may also contain age
+// recipients; the fingerprint of an age recipient is the recipient itself.
 func GetFingerprints(pgpKeys []string, entities []*openpgp.Entity) ([]string, error) {
 	if entities == nil {
-		var err error
-		entities, err = GetEntities(pgpKeys)
-		if err != nil {
-			return nil, err
+		ret := make([]string, 0, len(pgpKeys))
+		for _, key := range pgpKeys {
+			if IsAgeRecipient(key) {
+				recipient, err := ParseAgeRecipient(key)
+				if err != nil {
+					return nil, err
+				}
+				ret = append(ret, recipient.String())
+				continue
+			}
+
+			keyEntities, err := GetEntities([]string{key})
+			if err != nil {
+				return nil, err
+			}
+			ret = append(ret, fmt.Sprintf("%x", keyEntities[0].PrimaryKey.Fingerprint))
 		}
+		return ret, nil
 	}
 	ret := make([]string, 0, len(entities))
 	for _, entity := range entities {
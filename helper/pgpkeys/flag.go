@@ -66,7 +66,7 @@ func (p *PubKeyFilesFlag) Example() string { return "keybase:user1, keybase:user
 
 // ParsePGPKeys takes a list of PGP keys and parses them either using keybase
 // or reading them from disk and returns the "expanded" list of pgp keys in
-// the same order.
+// the same order. Age recipients are returned as given.
 func ParsePGPKeys(keyfiles []string) ([]string, error) {
 	keys := make([]string, len(keyfiles))
 
@@ -78,6 +78,11 @@ func ParsePGPKeys(keyfiles []string) ([]string, error) {
 	for i, keyfile := range keyfiles {
 		keyfile = strings.TrimSpace(keyfile)
 
+		if IsAgeRecipient(keyfile) {
+			keys[i] = keyfile
+			continue
+		}
+
 		if strings.HasPrefix(keyfile, kbPrefix) {
 			key, ok := keybaseMap[keyfile]
 			if !ok || key == "" {
@@ -0,0 +1,377 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+// Package pkcs12 encodes private keys and their certificates into
+// password-protected PKCS#12 (RFC 7292) archives, as consumed by Java
+// keystores and the Windows certificate store.
+//
+// Archives use the algorithms of current OpenSSL releases: the private key is
+// encrypted with PBES2 (PBKDF2 with HMAC-SHA256 and AES-256-CBC), and the
+// archive is integrity protected with HMAC-SHA256. Certificates are stored
+// unencrypted.
+package pkcs12
+
+import (
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"io"
+	"unicode/utf16"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	// keyIterations is used for the derivation of the key encryption key. As
+	// encrypted keys may end up stored or logged, it follows the OWASP
+	// recommendation for PBKDF2-HMAC-SHA256 rather than OpenSSL's default.
+	keyIterations = 600000
+	// macIterations is used for the derivation of the MAC key, matching
+	// OpenSSL's default for compatibility with PKCS#12 consumers.
+	macIterations = 2048
+	saltLength    = 16
+)
+
+var (
+	oidData                = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	oidPKCS8ShroudedKeyBag = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 10, 1, 2}
+	oidCertBag             = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 10, 1, 3}
+	oidCertTypeX509        = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 22, 1}
+	oidLocalKeyID          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 21}
+	oidPBES2               = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	oidPBKDF2              = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+	oidHMACWithSHA256      = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}
+	oidAES256CBC           = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+	oidSHA256              = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+)
+
+type pfxPdu struct {
+	Version  int
+	AuthSafe contentInfo
+	MacData  macData
+}
+
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"tag:0,explicit"`
+}
+
+type macData struct {
+	Mac        digestInfo
+	MacSalt    []byte
+	Iterations int
+}
+
+type digestInfo struct {
+	Algorithm pkix.AlgorithmIdentifier
+	Digest    []byte
+}
+
+type safeBag struct {
+	ID         asn1.ObjectIdentifier
+	Value      asn1.RawValue     `asn1:"tag:0,explicit"`
+	Attributes []pkcs12Attribute `asn1:"set,optional"`
+}
+
+type pkcs12Attribute struct {
+	ID    asn1.ObjectIdentifier
+	Value asn1.RawValue `asn1:"set"`
+}
+
+type certBag struct {
+	ID   asn1.ObjectIdentifier
+	Data []byte `asn1:"tag:0,explicit"`
+}
+
+type encryptedPrivateKeyInfo struct {
+	Algorithm     pkix.AlgorithmIdentifier
+	EncryptedData []byte
+}
+
+type pbes2Params struct {
+	KeyDerivationFunc pkix.AlgorithmIdentifier
+	EncryptionScheme  pkix.AlgorithmIdentifier
+}
+
+type pbkdf2Params struct {
+	Salt       []byte
+	Iterations int
+	KeyLength  int
+	PRF        pkix.AlgorithmIdentifier
+}
+
+// Encode returns a PKCS#12 archive holding the private key and its
+// certificate, followed by the CA certificates of its chain, protected with
+// the given password. The password may be empty, which most consumers accept.
+func Encode(rand io.Reader, privateKey crypto.PrivateKey, certificate *x509.Certificate, caCerts []*x509.Certificate, password string) ([]byte, error) {
+	if certificate == nil {
+		return nil, errors.New("pkcs12: a certificate is required")
+	}
+
+	// The key and its certificate are tied together through their local key
+	// ID, for which the certificate's SHA-1 thumbprint is conventionally used.
+	thumbprint := sha1.Sum(certificate.Raw)
+	localKeyID, err := marshalLocalKeyID(thumbprint[:])
+	if err != nil {
+		return nil, err
+	}
+
+	keyBag, err := encryptPrivateKey(rand, privateKey, password)
+	if err != nil {
+		return nil, err
+	}
+	keyBag.Attributes = localKeyID
+
+	var certBags []safeBag
+	for index, cert := range append([]*x509.Certificate{certificate}, caCerts...) {
+		bag, err := newCertBag(cert)
+		if err != nil {
+			return nil, err
+		}
+		if index == 0 {
+			bag.Attributes = localKeyID
+		}
+		certBags = append(certBags, *bag)
+	}
+
+	var authenticatedSafe []contentInfo
+	for _, bags := range [][]safeBag{certBags, {*keyBag}} {
+		info, err := newDataContentInfo(bags)
+		if err != nil {
+			return nil, err
+		}
+		authenticatedSafe = append(authenticatedSafe, *info)
+	}
+
+	authenticatedSafeBytes, err := asn1.Marshal(authenticatedSafe)
+	if err != nil {
+		return nil, err
+	}
+
+	mac, err := computeMac(rand, authenticatedSafeBytes, password)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := asn1.Marshal(authenticatedSafeBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	return asn1.Marshal(pfxPdu{
+		Version: 3,
+		AuthSafe: contentInfo{
+			ContentType: oidData,
+			Content:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: content},
+		},
+		MacData: *mac,
+	})
+}
+
+func marshalLocalKeyID(id []byte) ([]pkcs12Attribute, error) {
+	value, err := asn1.Marshal(id)
+	if err != nil {
+		return nil, err
+	}
+	return []pkcs12Attribute{{
+		ID:    oidLocalKeyID,
+		Value: asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: value},
+	}}, nil
+}
+
+func newCertBag(cert *x509.Certificate) (*safeBag, error) {
+	value, err := asn1.Marshal(certBag{ID: oidCertTypeX509, Data: cert.Raw})
+	if err != nil {
+		return nil, err
+	}
+	return &safeBag{
+		ID:    oidCertBag,
+		Value: asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: value},
+	}, nil
+}
+
+// newDataContentInfo wraps the bags into an unencrypted data content info.
+func newDataContentInfo(bags []safeBag) (*contentInfo, error) {
+	safeContents, err := asn1.Marshal(bags)
+	if err != nil {
+		return nil, err
+	}
+	content, err := asn1.Marshal(safeContents)
+	if err != nil {
+		return nil, err
+	}
+	return &contentInfo{
+		ContentType: oidData,
+		Content:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: content},
+	}, nil
+}
+
+// MarshalEncryptedPKCS8PrivateKey returns the PKCS#8 EncryptedPrivateKeyInfo
+// of the key, encrypted with the password as in the shrouded key bags of
+// PKCS#12 archives. Its PEM encoding uses the "ENCRYPTED PRIVATE KEY" type.
+func MarshalEncryptedPKCS8PrivateKey(rand io.Reader, privateKey crypto.PrivateKey, password string) ([]byte, error) {
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("pkcs12: failed marshaling private key: %w", err)
+	}
+
+	salt := make([]byte, saltLength)
+	iv := make([]byte, aes.BlockSize)
+	if _, err := io.ReadFull(rand, salt); err != nil {
+		return nil, err
+	}
+	if _, err := io.ReadFull(rand, iv); err != nil {
+		return nil, err
+	}
+
+	key := pbkdf2.Key([]byte(password), salt, keyIterations, 32, sha256.New)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	padding := aes.BlockSize - len(pkcs8)%aes.BlockSize
+	plaintext := append(pkcs8, make([]byte, padding)...)
+	for i := len(pkcs8); i < len(plaintext); i++ {
+		plaintext[i] = byte(padding)
+	}
+	ciphertext := make([]byte, len(plaintext))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(ciphertext, plaintext)
+
+	kdfParams, err := asn1.Marshal(pbkdf2Params{
+		Salt:       salt,
+		Iterations: keyIterations,
+		KeyLength:  len(key),
+		PRF:        pkix.AlgorithmIdentifier{Algorithm: oidHMACWithSHA256, Parameters: asn1.NullRawValue},
+	})
+	if err != nil {
+		return nil, err
+	}
+	ivParams, err := asn1.Marshal(iv)
+	if err != nil {
+		return nil, err
+	}
+	params, err := asn1.Marshal(pbes2Params{
+		KeyDerivationFunc: pkix.AlgorithmIdentifier{Algorithm: oidPBKDF2, Parameters: asn1.RawValue{FullBytes: kdfParams}},
+		EncryptionScheme:  pkix.AlgorithmIdentifier{Algorithm: oidAES256CBC, Parameters: asn1.RawValue{FullBytes: ivParams}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return asn1.Marshal(encryptedPrivateKeyInfo{
+		Algorithm:     pkix.AlgorithmIdentifier{Algorithm: oidPBES2, Parameters: asn1.RawValue{FullBytes: params}},
+		EncryptedData: ciphertext,
+	})
+}
+
+// encryptPrivateKey returns a shrouded key bag holding the encrypted PKCS#8
+// encoding of the key.
+func encryptPrivateKey(rand io.Reader, privateKey crypto.PrivateKey, password string) (*safeBag, error) {
+	value, err := MarshalEncryptedPKCS8PrivateKey(rand, privateKey, password)
+	if err != nil {
+		return nil, err
+	}
+	return &safeBag{
+		ID:    oidPKCS8ShroudedKeyBag,
+		Value: asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: value},
+	}, nil
+}
+
+// computeMac returns the HMAC-SHA256 of the authenticated safe, keyed with
+// the key derived from the password per RFC 7292 Appendix B.
+func computeMac(rand io.Reader, authenticatedSafe []byte, password string) (*macData, error) {
+	salt := make([]byte, saltLength)
+	if _, err := io.ReadFull(rand, salt); err != nil {
+		return nil, err
+	}
+
+	key := pbkdf(bmpString(password), salt, macIterations, 3, sha256.Size)
+	mac := hmac.New(sha256.New, key)
+	mac.Write(authenticatedSafe)
+
+	return &macData{
+		Mac: digestInfo{
+			Algorithm: pkix.AlgorithmIdentifier{Algorithm: oidSHA256, Parameters: asn1.NullRawValue},
+			Digest:    mac.Sum(nil),
+		},
+		MacSalt:    salt,
+		Iterations: macIterations,
+	}, nil
+}
+
+// bmpString encodes the password as the NULL-terminated big-endian UTF-16
+// string the PKCS#12 key derivation function takes.
+func bmpString(password string) []byte {
+	encoded := make([]byte, 0, 2*len(password)+2)
+	for _, r := range utf16.Encode([]rune(password)) {
+		encoded = append(encoded, byte(r>>8), byte(r))
+	}
+	return append(encoded, 0, 0)
+}
+
+// pbkdf is the PKCS#12 key derivation function of RFC 7292 Appendix B.2,
+// instantiated with SHA-256.
+func pbkdf(password, salt []byte, iterations int, id byte, size int) []byte {
+	const u = sha256.Size
+	const v = sha256.BlockSize
+
+	d := make([]byte, v)
+	for i := range d {
+		d[i] = id
+	}
+
+	fill := func(in []byte) []byte {
+		if len(in) == 0 {
+			return nil
+		}
+		out := make([]byte, v*((len(in)+v-1)/v))
+		for i := range out {
+			out[i] = in[i%len(in)]
+		}
+		return out
+	}
+	i := append(fill(salt), fill(password)...)
+
+	var result []byte
+	for len(result) < size {
+		hash := sha256.New()
+		hash.Write(d)
+		hash.Write(i)
+		a := hash.Sum(nil)
+		for round := 1; round < iterations; round++ {
+			sum := sha256.Sum256(a)
+			a = sum[:]
+		}
+		result = append(result, a...)
+
+		if len(result) >= size {
+			break
+		}
+
+		// Each block of I is incremented by B + 1, where B is A repeated
+		// to v bytes.
+		b := make([]byte, v)
+		for j := range b {
+			b[j] = a[j%u]
+		}
+		for start := 0; start < len(i); start += v {
+			carry := 1
+			for j := v - 1; j >= 0; j-- {
+				sum := int(i[start+j]) + int(b[j]) + carry
+				i[start+j] = byte(sum)
+				carry = sum >> 8
+			}
+		}
+	}
+
+	return result[:size]
+}
@@ -0,0 +1,252 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package pkcs12
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+func createCert(t *testing.T, name string, key crypto.Signer, parent *x509.Certificate, parentKey crypto.Signer) *x509.Certificate {
+	t.Helper()
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Minute),
+		NotAfter:              time.Now().Add(time.Hour),
+		BasicConstraintsValid: true,
+		IsCA:                  parent == nil,
+	}
+	if parent == nil {
+		parent = template
+		parentKey = key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, key.Public(), parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+// TestEncode checks archives against OpenSSL, which verifies their MAC and
+// decrypts their private key.
+func TestEncode(t *testing.T) {
+	openssl, err := exec.LookPath("openssl")
+	if err != nil {
+		t.Skip("openssl is not available")
+	}
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caCert := createCert(t, "Test CA", caKey, nil, nil)
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, tc := range map[string]struct {
+		key      crypto.Signer
+		password string
+	}{
+		"rsa":            {rsaKey, "correct horse"},
+		"ec":             {ecKey, "battery staple"},
+		"ed25519":        {edKey, "pässwörd"},
+		"empty password": {ecKey, ""},
+	} {
+		t.Run(name, func(t *testing.T) {
+			leaf := createCert(t, "leaf.example.com", tc.key, caCert, caKey)
+			pfx, err := Encode(rand.Reader, tc.key, leaf, []*x509.Certificate{caCert}, tc.password)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			path := filepath.Join(t.TempDir(), "test.p12")
+			if err := os.WriteFile(path, pfx, 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			out, err := exec.Command(openssl, "pkcs12", "-in", path, "-passin", "pass:"+tc.password, "-nodes").CombinedOutput()
+			if err != nil {
+				t.Fatalf("openssl failed to read archive: %v\n%s", err, out)
+			}
+
+			var certs [][]byte
+			var keyDer []byte
+			for rest := out; ; {
+				var block *pem.Block
+				block, rest = pem.Decode(rest)
+				if block == nil {
+					break
+				}
+				switch block.Type {
+				case "CERTIFICATE":
+					certs = append(certs, block.Bytes)
+				case "PRIVATE KEY":
+					keyDer = block.Bytes
+				}
+			}
+
+			if len(certs) != 2 || !bytes.Equal(certs[0], leaf.Raw) || !bytes.Equal(certs[1], caCert.Raw) {
+				t.Fatalf("unexpected certificates in archive:\n%s", out)
+			}
+			expectedKey, err := x509.MarshalPKCS8PrivateKey(tc.key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(keyDer, expectedKey) {
+				t.Fatalf("unexpected private key in archive:\n%s", out)
+			}
+			if !strings.Contains(string(out), "localKeyID") {
+				t.Fatalf("missing local key ID in archive:\n%s", out)
+			}
+
+			out, err = exec.Command(openssl, "pkcs12", "-in", path, "-passin", "pass:wrong"+tc.password, "-nodes").CombinedOutput()
+			if err == nil {
+				t.Fatalf("openssl accepted a wrong password:\n%s", out)
+			}
+		})
+	}
+}
+
+func TestEncodeRequiresCertificate(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Encode(rand.Reader, key, nil, nil, "password"); err == nil {
+		t.Fatal("expected an error without a certificate")
+	}
+}
+
+// TestMarshalEncryptedPKCS8PrivateKey checks encrypted keys against OpenSSL.
+func TestMarshalEncryptedPKCS8PrivateKey(t *testing.T) {
+	openssl, err := exec.LookPath("openssl")
+	if err != nil {
+		t.Skip("openssl is not available")
+	}
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := MarshalEncryptedPKCS8PrivateKey(rand.Reader, key, "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "key.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "ENCRYPTED PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := exec.Command(openssl, "pkcs8", "-in", path, "-passin", "pass:correct horse", "-outform", "DER").Output()
+	if err != nil {
+		t.Fatalf("openssl failed to decrypt key: %v", err)
+	}
+	// Depending on its version, OpenSSL outputs the decrypted key either as
+	// PKCS#8 or as PKCS#1.
+	decrypted, err := x509.ParsePKCS8PrivateKey(out)
+	if err != nil {
+		decrypted, err = x509.ParsePKCS1PrivateKey(out)
+	}
+	if err != nil {
+		t.Fatalf("failed parsing decrypted key: %v", err)
+	}
+	if !key.Equal(decrypted) {
+		t.Fatal("decrypted key does not match")
+	}
+
+	if out, err := exec.Command(openssl, "pkcs8", "-in", path, "-passin", "pass:wrong", "-outform", "DER").CombinedOutput(); err == nil {
+		t.Fatalf("openssl accepted a wrong password:\n%s", out)
+	}
+}
+
+// TestMarshalEncryptedPKCS8PrivateKey_Iterations checks that encrypted keys
+// are derived with the key encryption iteration count, and decrypt with the
+// count stored in their parameters.
+func TestMarshalEncryptedPKCS8PrivateKey_Iterations(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := MarshalEncryptedPKCS8PrivateKey(rand.Reader, key, "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var info encryptedPrivateKeyInfo
+	if _, err := asn1.Unmarshal(der, &info); err != nil {
+		t.Fatal(err)
+	}
+	var params pbes2Params
+	if _, err := asn1.Unmarshal(info.Algorithm.Parameters.FullBytes, &params); err != nil {
+		t.Fatal(err)
+	}
+	var kdfParams pbkdf2Params
+	if _, err := asn1.Unmarshal(params.KeyDerivationFunc.Parameters.FullBytes, &kdfParams); err != nil {
+		t.Fatal(err)
+	}
+	var iv []byte
+	if _, err := asn1.Unmarshal(params.EncryptionScheme.Parameters.FullBytes, &iv); err != nil {
+		t.Fatal(err)
+	}
+	if kdfParams.Iterations != keyIterations || kdfParams.Iterations < 600000 {
+		t.Fatalf("unexpected iteration count %d", kdfParams.Iterations)
+	}
+
+	block, err := aes.NewCipher(pbkdf2.Key([]byte("correct horse"), kdfParams.Salt, kdfParams.Iterations, kdfParams.KeyLength, sha256.New))
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext := make([]byte, len(info.EncryptedData))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plaintext, info.EncryptedData)
+	padding := int(plaintext[len(plaintext)-1])
+	if padding < 1 || padding > aes.BlockSize {
+		t.Fatalf("invalid padding %d", padding)
+	}
+
+	decrypted, err := x509.ParsePKCS8PrivateKey(plaintext[:len(plaintext)-padding])
+	if err != nil {
+		t.Fatalf("failed parsing decrypted key: %v", err)
+	}
+	if !key.Equal(decrypted) {
+		t.Fatal("decrypted key does not match")
+	}
+}
@@ -42,6 +42,33 @@ func (p7 *PKCS7) Decrypt(cert *x509.Certificate, pkey crypto.PrivateKey) ([]byte
 	return nil, ErrUnsupportedAlgorithm
 }
 
+// EncryptionAlgorithm returns the content encryption algorithm of enveloped
+// data, as one of the EncryptionAlgorithm constants, so that replies can be
+// encrypted the same way.
+func (p7 *PKCS7) EncryptionAlgorithm() (int, error) {
+	data, ok := p7.raw.(envelopedData)
+	if !ok {
+		return 0, ErrNotEncryptedContent
+	}
+	alg := data.EncryptedContentInfo.ContentEncryptionAlgorithm.Algorithm
+	switch {
+	case alg.Equal(OIDEncryptionAlgorithmDESCBC):
+		return EncryptionAlgorithmDESCBC, nil
+	case alg.Equal(OIDEncryptionAlgorithmDESEDE3CBC):
+		return EncryptionAlgorithmDESEDE3CBC, nil
+	case alg.Equal(OIDEncryptionAlgorithmAES128CBC):
+		return EncryptionAlgorithmAES128CBC, nil
+	case alg.Equal(OIDEncryptionAlgorithmAES256CBC):
+		return EncryptionAlgorithmAES256CBC, nil
+	case alg.Equal(OIDEncryptionAlgorithmAES128GCM):
+		return EncryptionAlgorithmAES128GCM, nil
+	case alg.Equal(OIDEncryptionAlgorithmAES256GCM):
+		return EncryptionAlgorithmAES256GCM, nil
+	default:
+		return 0, ErrUnsupportedAlgorithm
+	}
+}
+
 // DecryptUsingPSK decrypts encrypted data using caller provided
 // pre-shared secret
 func (p7 *PKCS7) DecryptUsingPSK(key []byte) ([]byte, error) {
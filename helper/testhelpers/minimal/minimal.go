@@ -63,6 +63,7 @@ func NewTestSoloCluster(t testing.TB, config *vault.CoreConfig) *vault.TestClust
 	if mycfg.AuditBackends == nil {
 		mycfg.AuditBackends = map[string]audit.Factory{
 			"file":   audit.NewFileBackend,
+			"kafka":  audit.NewKafkaBackend,
 			"socket": audit.NewSocketBackend,
 			"syslog": audit.NewSyslogBackend,
 		}
@@ -318,6 +318,7 @@ func ClusterSetup(conf *vault.CoreConfig, opts *vault.TestClusterOptions, setup
 	if localConf.AuditBackends == nil {
 		localConf.AuditBackends = map[string]audit.Factory{
 			"file":   audit.NewFileBackend,
+			"kafka":  audit.NewKafkaBackend,
 			"socket": audit.NewSocketBackend,
 			"syslog": audit.NewSyslogBackend,
 			"noop":   audit.NoopAuditFactory(nil),
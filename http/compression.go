@@ -0,0 +1,268 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package http
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/go-secure-stdlib/strutil"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	encodingGzip = "gzip"
+	encodingZstd = "zstd"
+
+	// compressionMinSize is the size under which responses are not worth
+	// compressing, as they fit in a single packet either way.
+	compressionMinSize = 1400
+)
+
+var (
+	gzipWriterPool = sync.Pool{
+		New: func() interface{} {
+			w, _ := gzip.NewWriterLevel(nil, gzip.DefaultCompression)
+			return w
+		},
+	}
+	zstdWriterPool = sync.Pool{
+		New: func() interface{} {
+			w, _ := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+			return w
+		},
+	}
+)
+
+// wrapResponseCompressionHandler compresses the responses to API requests
+// with gzip or zstd, as negotiated with the client's Accept-Encoding header.
+// If paths is non-empty, only the responses to requests for paths matching
+// one of its globs are compressed. Compressed responses are streamed to the
+// client as they are compressed, using chunked transfer encoding, rather than
+// being buffered in full.
+func wrapResponseCompressionHandler(h http.Handler, paths []string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/v1/") || r.Method == http.MethodHead {
+			h.ServeHTTP(w, r)
+			return
+		}
+		if len(paths) > 0 && !compressionPathMatches(paths, strings.TrimPrefix(r.URL.Path, "/v1/")) {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Add("Vary", "Accept-Encoding")
+
+		encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+		if encoding == "" {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		cw := &compressResponseWriter{
+			wrapped:  w,
+			encoding: encoding,
+		}
+		defer cw.Close()
+
+		h.ServeHTTP(cw, r)
+	})
+}
+
+func compressionPathMatches(paths []string, path string) bool {
+	for _, pattern := range paths {
+		if strutil.GlobbedStringsMatch(pattern, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// negotiateEncoding returns the encoding to compress the response with given
+// the request's Accept-Encoding header, preferring zstd over gzip when the
+// client weighs them equally. It returns an empty string if the client
+// accepts neither.
+func negotiateEncoding(acceptEncoding string) string {
+	var encoding string
+	var bestQ float64
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q <= 0 {
+			continue
+		}
+
+		switch name {
+		case encodingZstd:
+			if q >= bestQ {
+				encoding, bestQ = encodingZstd, q
+			}
+		case encodingGzip, "*":
+			if q > bestQ {
+				encoding, bestQ = encodingGzip, q
+			}
+		}
+	}
+	return encoding
+}
+
+// compressResponseWriter buffers the start of the response until it knows
+// whether the response is large enough to be worth compressing, then streams
+// the rest of it through the compressor.
+type compressResponseWriter struct {
+	wrapped  http.ResponseWriter
+	encoding string
+
+	statusCode int
+	buf        bytes.Buffer
+
+	// decided is set once the response headers have been written, at which
+	// point the response is compressed if compressor is set.
+	decided    bool
+	compressor io.WriteCloser
+}
+
+func (w *compressResponseWriter) Header() http.Header {
+	return w.wrapped.Header()
+}
+
+func (w *compressResponseWriter) WriteHeader(code int) {
+	if w.decided || w.statusCode != 0 {
+		return
+	}
+	w.statusCode = code
+}
+
+func (w *compressResponseWriter) Write(p []byte) (int, error) {
+	if w.statusCode == 0 {
+		w.statusCode = http.StatusOK
+	}
+	if !w.decided {
+		w.buf.Write(p)
+		if w.buf.Len() < compressionMinSize {
+			return len(p), nil
+		}
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+	if w.compressor != nil {
+		return w.compressor.Write(p)
+	}
+	return w.wrapped.Write(p)
+}
+
+// decide writes the response headers, compressing the response if compress is
+// set and the handler has not already encoded it, then writes the buffered
+// start of the response.
+func (w *compressResponseWriter) decide(compress bool) error {
+	w.decided = true
+
+	header := w.wrapped.Header()
+	if compress && header.Get("Content-Encoding") == "" && w.statusCode != http.StatusNoContent {
+		header.Set("Content-Encoding", w.encoding)
+		header.Del("Content-Length")
+
+		switch w.encoding {
+		case encodingZstd:
+			enc := zstdWriterPool.Get().(*zstd.Encoder)
+			enc.Reset(w.wrapped)
+			w.compressor = enc
+		default:
+			gz := gzipWriterPool.Get().(*gzip.Writer)
+			gz.Reset(w.wrapped)
+			w.compressor = gz
+		}
+	}
+
+	if w.statusCode == 0 {
+		w.statusCode = http.StatusOK
+	}
+	w.wrapped.WriteHeader(w.statusCode)
+
+	if w.buf.Len() == 0 {
+		return nil
+	}
+	var err error
+	if w.compressor != nil {
+		_, err = w.compressor.Write(w.buf.Bytes())
+	} else {
+		_, err = w.wrapped.Write(w.buf.Bytes())
+	}
+	w.buf.Reset()
+	return err
+}
+
+// Flush sends what has been written so far to the client. Responses that are
+// flushed before being large enough to be compressed, such as streamed logs,
+// are sent uncompressed.
+func (w *compressResponseWriter) Flush() {
+	if !w.decided {
+		w.decide(false)
+	}
+	if w.compressor != nil {
+		switch c := w.compressor.(type) {
+		case *gzip.Writer:
+			c.Flush()
+		case *zstd.Encoder:
+			c.Flush()
+		}
+	}
+	if f, ok := w.wrapped.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack allows websocket upgrades to take over the connection.
+func (w *compressResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.wrapped.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer does not support hijacking")
+	}
+	w.decided = true
+	return h.Hijack()
+}
+
+// Close completes the response, writing it uncompressed if it was too small
+// to be worth compressing.
+func (w *compressResponseWriter) Close() error {
+	if !w.decided {
+		if w.statusCode == 0 {
+			// Nothing was written by the handler
+			return nil
+		}
+		return w.decide(false)
+	}
+	if w.compressor == nil {
+		return nil
+	}
+
+	err := w.compressor.Close()
+	switch c := w.compressor.(type) {
+	case *gzip.Writer:
+		gzipWriterPool.Put(c)
+	case *zstd.Encoder:
+		zstdWriterPool.Put(c)
+	}
+	w.compressor = nil
+	return err
+}
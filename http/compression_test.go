@@ -0,0 +1,144 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package http
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-cleanhttp"
+	"github.com/hashicorp/vault/internalshared/configutil"
+	"github.com/hashicorp/vault/sdk/helper/consts"
+	"github.com/hashicorp/vault/vault"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	tests := map[string]string{
+		"":                        "",
+		"identity":                "",
+		"gzip":                    encodingGzip,
+		"gzip, deflate, br":       encodingGzip,
+		"zstd":                    encodingZstd,
+		"gzip, zstd":              encodingZstd,
+		"zstd;q=0.5, gzip":        encodingGzip,
+		"zstd;q=0, gzip;q=0.1":    encodingGzip,
+		"gzip;q=0":                "",
+		"*":                       encodingGzip,
+		"ZSTD;q=1.0, gzip;q=0.9":  encodingZstd,
+		"gzip;q=invalid, zstd;q=": "",
+	}
+	for acceptEncoding, expected := range tests {
+		require.Equal(t, expected, negotiateEncoding(acceptEncoding), acceptEncoding)
+	}
+}
+
+func TestWrapResponseCompressionHandler(t *testing.T) {
+	large := strings.Repeat("compressible ", 1000)
+	handler := wrapResponseCompressionHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if r.URL.Query().Get("small") != "" {
+			io.WriteString(w, "small")
+			return
+		}
+		// Write the body in pieces to exercise the buffering
+		for i := 0; i < 10; i++ {
+			io.WriteString(w, large[i*len(large)/10:(i+1)*len(large)/10])
+		}
+	}), []string{"pki/*"})
+
+	serve := func(path, acceptEncoding string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code)
+		return rec
+	}
+
+	t.Run("gzip", func(t *testing.T) {
+		rec := serve("/v1/pki/crl", "gzip")
+		require.Equal(t, encodingGzip, rec.Header().Get("Content-Encoding"))
+		require.Equal(t, "Accept-Encoding", rec.Header().Get("Vary"))
+		require.Less(t, rec.Body.Len(), len(large))
+
+		r, err := gzip.NewReader(rec.Body)
+		require.NoError(t, err)
+		body, err := io.ReadAll(r)
+		require.NoError(t, err)
+		require.Equal(t, large, string(body))
+	})
+
+	t.Run("zstd", func(t *testing.T) {
+		rec := serve("/v1/pki/crl", "gzip, zstd")
+		require.Equal(t, encodingZstd, rec.Header().Get("Content-Encoding"))
+
+		r, err := zstd.NewReader(rec.Body)
+		require.NoError(t, err)
+		defer r.Close()
+		body, err := io.ReadAll(r)
+		require.NoError(t, err)
+		require.Equal(t, large, string(body))
+	})
+
+	t.Run("small", func(t *testing.T) {
+		rec := serve("/v1/pki/crl?small=true", "gzip")
+		require.Empty(t, rec.Header().Get("Content-Encoding"))
+		require.Equal(t, "small", rec.Body.String())
+	})
+
+	t.Run("not accepted", func(t *testing.T) {
+		rec := serve("/v1/pki/crl", "br")
+		require.Empty(t, rec.Header().Get("Content-Encoding"))
+		require.Equal(t, large, rec.Body.String())
+	})
+
+	t.Run("path not matching", func(t *testing.T) {
+		rec := serve("/v1/secret/foo", "gzip")
+		require.Empty(t, rec.Header().Get("Content-Encoding"))
+		require.Empty(t, rec.Header().Get("Vary"))
+		require.Equal(t, large, rec.Body.String())
+	})
+}
+
+// TestHandler_ResponseCompression verifies that API responses are compressed
+// when response compression is enabled on the listener.
+func TestHandler_ResponseCompression(t *testing.T) {
+	ln, addr := TestListener(t)
+	core, _, token := vault.TestCoreUnsealed(t)
+	TestServerWithListenerAndProperties(t, ln, addr, core, &vault.HandlerProperties{
+		Core: core,
+		ListenerConfig: &configutil.Listener{
+			Address:             addr,
+			ResponseCompression: true,
+		},
+	})
+	defer ln.Close()
+
+	req, err := http.NewRequest(http.MethodGet, addr+"/v1/sys/mounts", nil)
+	require.NoError(t, err)
+	req.Header.Set(consts.AuthHeaderName, token)
+	req.Header.Set("Accept-Encoding", "zstd")
+
+	resp, err := cleanhttp.DefaultClient().Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, encodingZstd, resp.Header.Get("Content-Encoding"))
+
+	r, err := zstd.NewReader(resp.Body)
+	require.NoError(t, err)
+	defer r.Close()
+
+	var actual map[string]interface{}
+	require.NoError(t, json.NewDecoder(r).Decode(&actual))
+	require.Contains(t, actual["data"], "secret/")
+}
@@ -0,0 +1,231 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/eventlogger"
+	"github.com/hashicorp/eventlogger/formatter_filters/cloudevents"
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/hashicorp/vault/vault"
+	"github.com/ryanuber/go-glob"
+	"google.golang.org/protobuf/proto"
+	"nhooyr.io/websocket"
+)
+
+const eventsSubscribePrefix = "sys/events/subscribe/"
+
+type eventSubscriber struct {
+	ctx               context.Context
+	core              *vault.Core
+	logger            hclog.Logger
+	clientToken       string
+	namespacePatterns []string
+	pattern           string
+	bexprFilter       string
+	json              bool
+	w                 http.ResponseWriter
+	r                 *http.Request
+}
+
+// handleEventsSubscribe serves sys/events/subscribe/:eventType, streaming the
+// events matching the event type pattern over a WebSocket. Each event is only
+// delivered if the token may subscribe to the data it refers to.
+func handleEventsSubscribe(core *vault.Core, req *logical.Request) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger := core.Logger().Named("events-subscribe")
+		logger.Trace("got request to", "url", r.URL, "version", r.Proto)
+
+		ctx := r.Context()
+
+		// ACL check
+		_, _, err := core.CheckToken(ctx, req, false)
+		if err != nil {
+			if errors.Is(err, logical.ErrPermissionDenied) {
+				respondError(w, http.StatusForbidden, logical.ErrPermissionDenied)
+				return
+			}
+			logger.Debug("error validating token", "error", err)
+			respondError(w, http.StatusInternalServerError, fmt.Errorf("error validating token"))
+			return
+		}
+
+		ns, err := namespace.FromContext(ctx)
+		if err != nil {
+			logger.Info("could not find namespace", "error", err)
+			respondError(w, http.StatusInternalServerError, fmt.Errorf("could not find namespace"))
+			return
+		}
+
+		pattern := strings.TrimSpace(strings.TrimPrefix(req.Path, eventsSubscribePrefix))
+		if pattern == "" {
+			respondError(w, http.StatusBadRequest, fmt.Errorf("did not specify eventType to subscribe to"))
+			return
+		}
+
+		json := false
+		if jsonRaw := r.URL.Query().Get("json"); jsonRaw != "" {
+			json, err = strconv.ParseBool(jsonRaw)
+			if err != nil {
+				respondError(w, http.StatusBadRequest, fmt.Errorf("invalid parameter for JSON: %v", jsonRaw))
+				return
+			}
+		}
+
+		ctx, cancelCtx := context.WithCancel(ctx)
+		defer cancelCtx()
+
+		sub := &eventSubscriber{
+			ctx:               ctx,
+			core:              core,
+			logger:            logger,
+			clientToken:       req.ClientToken,
+			namespacePatterns: prependNamespacePatterns(r.URL.Query()["namespaces"], ns),
+			pattern:           pattern,
+			bexprFilter:       strings.TrimSpace(r.URL.Query().Get("filter")),
+			json:              json,
+			w:                 w,
+			r:                 r,
+		}
+		sub.subscribe()
+	})
+}
+
+// prependNamespacePatterns returns the namespace patterns to subscribe to,
+// relative to the namespace of the request, which is always included.
+func prependNamespacePatterns(patterns []string, requestNamespace *namespace.Namespace) []string {
+	prepend := strings.Trim(requestNamespace.Path, "/")
+	newPatterns := []string{prepend}
+	for _, pattern := range patterns {
+		pattern = strings.Trim(strings.TrimSpace(pattern), "/")
+		if pattern == "" {
+			continue
+		}
+		newPatterns = append(newPatterns, path.Join(prepend, pattern))
+	}
+	return newPatterns
+}
+
+// subscribe accepts the WebSocket connection and forwards the events received
+// on the event bus until either side goes away.
+func (sub *eventSubscriber) subscribe() {
+	// Subscribe before accepting the connection so that no event sent in
+	// between is missed.
+	ch, cancel, err := sub.core.Events().SubscribeMultipleNamespaces(sub.ctx, sub.namespacePatterns, sub.pattern, sub.bexprFilter)
+	if err != nil {
+		sub.logger.Info("error subscribing", "error", err)
+		respondError(sub.w, http.StatusBadRequest, fmt.Errorf("error subscribing: %w", err))
+		return
+	}
+	defer cancel()
+
+	conn, err := websocket.Accept(sub.w, sub.r, nil)
+	if err != nil {
+		sub.logger.Info("could not accept as websocket", "error", err)
+		return
+	}
+
+	// We don't expect any incoming messages; CloseRead cancels the context
+	// once the client disconnects.
+	ctx := conn.CloseRead(sub.ctx)
+
+	closeStatus, closeReason := websocket.StatusNormalClosure, ""
+	defer func() {
+		if err := conn.Close(closeStatus, closeReason); err != nil {
+			sub.logger.Debug("error closing websocket", "error", err)
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			sub.logger.Trace("websocket closed", "reason", ctx.Err())
+			return
+		case message, ok := <-ch:
+			if !ok {
+				closeStatus, closeReason = websocket.StatusGoingAway, "subscription closed"
+				return
+			}
+			allowed, err := sub.allowMessage(message.Payload.(*logical.EventReceived))
+			if err != nil {
+				sub.logger.Debug("error checking event permissions", "error", err)
+				closeStatus, closeReason = websocket.StatusPolicyViolation, "token is no longer valid"
+				return
+			}
+			if !allowed {
+				continue
+			}
+			if err := sub.write(ctx, conn, message); err != nil {
+				sub.logger.Debug("error writing event", "error", err)
+				closeStatus, closeReason = websocket.StatusInternalError, "error writing event"
+				return
+			}
+		}
+	}
+}
+
+func (sub *eventSubscriber) write(ctx context.Context, conn *websocket.Conn, message *eventlogger.Event) error {
+	if sub.json {
+		messageBytes, ok := message.Format(string(cloudevents.FormatJSON))
+		if !ok {
+			return errors.New("could not get cloudevents JSON format")
+		}
+		return conn.Write(ctx, websocket.MessageText, messageBytes)
+	}
+
+	messageBytes, err := proto.Marshal(message.Payload.(*logical.EventReceived))
+	if err != nil {
+		return err
+	}
+	return conn.Write(ctx, websocket.MessageBinary, messageBytes)
+}
+
+// allowMessage checks that the token is still valid and, for events that
+// refer to data, that it holds list and subscribe capabilities on the data
+// path and that the event type is one it may subscribe to there.
+func (sub *eventSubscriber) allowMessage(event *logical.EventReceived) (bool, error) {
+	var dataPath string
+	if event.Event != nil && event.Event.Metadata != nil {
+		if value, ok := event.Event.Metadata.Fields[logical.EventMetadataDataPath]; ok {
+			dataPath = value.GetStringValue()
+		}
+	}
+	if dataPath == "" {
+		// Nothing to check beyond the validity of the token.
+		dataPath = eventsSubscribePrefix + sub.pattern
+	} else if event.Namespace != "" {
+		dataPath = path.Join(event.Namespace, dataPath)
+	}
+
+	capabilities, eventTypes, err := sub.core.CapabilitiesAndSubscribeEventTypes(sub.ctx, sub.clientToken, dataPath)
+	if err != nil {
+		return false, err
+	}
+	if slices.Contains(capabilities, vault.RootCapability) {
+		return true, nil
+	}
+	if dataPath == eventsSubscribePrefix+sub.pattern {
+		return slices.Contains(capabilities, vault.ReadCapability), nil
+	}
+
+	if !slices.Contains(capabilities, vault.ListCapability) || !slices.Contains(capabilities, vault.SubscribeCapability) {
+		return false, nil
+	}
+	for _, eventType := range eventTypes {
+		if glob.Glob(eventType, event.EventType) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,149 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/hashicorp/vault/vault"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+	"nhooyr.io/websocket"
+)
+
+// sendTestEvent sends an event of the given type on the event bus of core, as
+// if sent by the plugin mounted at secret/. If set, dataPath is relative to
+// that mount.
+func sendTestEvent(t *testing.T, core *vault.Core, eventType, dataPath string) string {
+	t.Helper()
+	event, err := logical.NewEvent()
+	require.NoError(t, err)
+	if dataPath != "" {
+		event.Metadata = &structpb.Struct{Fields: map[string]*structpb.Value{
+			logical.EventMetadataDataPath: structpb.NewStringValue(dataPath),
+		}}
+	}
+	pluginInfo := &logical.EventPluginInfo{MountPath: "secret/"}
+	err = core.Events().SendEventInternal(context.Background(), namespace.RootNamespace, pluginInfo, logical.EventType(eventType), event)
+	require.NoError(t, err)
+	return event.Id
+}
+
+// subscribeTestEvents opens a JSON subscription to eventType with token.
+func subscribeTestEvents(t *testing.T, addr, token, eventType string) (*websocket.Conn, *http.Response, error) {
+	t.Helper()
+	url := strings.Replace(addr, "http", "ws", 1) + "/v1/sys/events/subscribe/" + eventType + "?json=true"
+	conn, resp, err := websocket.Dial(context.Background(), url, &websocket.DialOptions{
+		HTTPHeader: http.Header{"X-Vault-Token": []string{token}},
+	})
+	if err == nil {
+		t.Cleanup(func() { conn.Close(websocket.StatusNormalClosure, "") })
+	}
+	return conn, resp, err
+}
+
+// readTestEvent returns the ID and event type of the next event on conn.
+func readTestEvent(t *testing.T, ctx context.Context, conn *websocket.Conn) (string, string, error) {
+	t.Helper()
+	_, msg, err := conn.Read(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	var event struct {
+		Data struct {
+			Event struct {
+				ID string `json:"id"`
+			} `json:"event"`
+			EventType string `json:"event_type"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(msg, &event))
+	return event.Data.Event.ID, event.Data.EventType, nil
+}
+
+// TestEventsSubscribe checks that events sent on the event bus are streamed
+// to subscribers of a matching event type.
+func TestEventsSubscribe(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealed(t)
+	ln, addr := TestServer(t, core)
+	defer ln.Close()
+
+	conn, _, err := subscribeTestEvents(t, addr, token, "kv*")
+	require.NoError(t, err)
+
+	// Events that don't match the pattern are not delivered.
+	sendTestEvent(t, core, "pki/issue", "")
+	id := sendTestEvent(t, core, "kv-v2/data-write", "data/foo")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	gotID, gotType, err := readTestEvent(t, ctx, conn)
+	require.NoError(t, err)
+	require.Equal(t, id, gotID)
+	require.Equal(t, "kv-v2/data-write", gotType)
+}
+
+// TestEventsSubscribe_Policy checks that subscribing requires read on the
+// subscribe path, and that only events for data paths the token may
+// subscribe to are delivered.
+func TestEventsSubscribe_Policy(t *testing.T) {
+	core, _, rootToken := vault.TestCoreUnsealed(t)
+	ln, addr := TestServer(t, core)
+	defer ln.Close()
+
+	config := api.DefaultConfig()
+	config.Address = addr
+	client, err := api.NewClient(config)
+	require.NoError(t, err)
+	client.SetToken(rootToken)
+
+	err = client.Sys().PutPolicy("events", `
+path "sys/events/subscribe/*" {
+	capabilities = ["read"]
+}
+path "secret/data/allowed" {
+	capabilities = ["list", "subscribe"]
+	subscribe_event_types = ["kv*"]
+}
+`)
+	require.NoError(t, err)
+
+	secret, err := client.Auth().Token().Create(&api.TokenCreateRequest{Policies: []string{"events"}})
+	require.NoError(t, err)
+	eventsToken := secret.Auth.ClientToken
+
+	secret, err = client.Auth().Token().Create(&api.TokenCreateRequest{Policies: []string{"default"}})
+	require.NoError(t, err)
+	_, resp, err := subscribeTestEvents(t, addr, secret.Auth.ClientToken, "kv*")
+	require.Error(t, err)
+	require.NotNil(t, resp)
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	conn, _, err := subscribeTestEvents(t, addr, eventsToken, "kv*")
+	require.NoError(t, err)
+
+	sendTestEvent(t, core, "kv-v2/data-write", "data/denied")
+	id := sendTestEvent(t, core, "kv-v2/data-write", "data/allowed")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	gotID, _, err := readTestEvent(t, ctx, conn)
+	require.NoError(t, err)
+	require.Equal(t, id, gotID)
+
+	// Once the token is revoked, the subscription is closed.
+	require.NoError(t, client.Auth().Token().RevokeOrphan(eventsToken))
+	sendTestEvent(t, core, "kv-v2/data-write", "data/allowed")
+	_, _, err = readTestEvent(t, ctx, conn)
+	require.Error(t, err)
+	require.Equal(t, websocket.StatusPolicyViolation, websocket.CloseStatus(err))
+}
@@ -24,6 +24,7 @@ import (
 	"regexp"
 	"strings"
 	"time"
+	"unicode"
 
 	"github.com/hashicorp/errwrap"
 	"github.com/hashicorp/go-cleanhttp"
@@ -59,6 +60,10 @@ const (
 	// required to perform MFA on any path.
 	MFAHeaderName = "X-Vault-MFA"
 
+	// problemJSONContentType is the media type of RFC 7807 problem documents,
+	// which clients may request errors be returned as via the Accept header.
+	problemJSONContentType = "application/problem+json"
+
 	// canonicalMFAHeaderName is the MFA header value's format in the request
 	// headers. Do not alter the casing of this string.
 	canonicalMFAHeaderName = "X-Vault-Mfa"
@@ -67,6 +72,15 @@ const (
 	// soft-mandatory Sentinel policies.
 	PolicyOverrideHeaderName = "X-Vault-Policy-Override"
 
+	// ClientNameHeaderName and ClientVersionHeaderName are the headers set
+	// to identify the application making the request.
+	ClientNameHeaderName    = "X-Vault-Client-Name"
+	ClientVersionHeaderName = "X-Vault-Client-Version"
+
+	// maxClientIdentificationLength is the maximum length of the values of
+	// the client identification headers.
+	maxClientIdentificationLength = 128
+
 	VaultIndexHeaderName        = "X-Vault-Index"
 	VaultInconsistentHeaderName = "X-Vault-Inconsistent"
 	VaultForwardHeaderName      = "X-Vault-Forward"
@@ -276,6 +290,12 @@ func handler(props *vault.HandlerProperties) http.Handler {
 		wrappedHandler = wrapRequestLimiterHandler(wrappedHandler, props)
 	}
 
+	// Add an extra wrapping handler if response compression is enabled that
+	// will compress responses as negotiated with the client.
+	if props.ListenerConfig != nil && props.ListenerConfig.ResponseCompression {
+		wrappedHandler = wrapResponseCompressionHandler(wrappedHandler, props.ListenerConfig.ResponseCompressionPaths)
+	}
+
 	return wrappedHandler
 }
 
@@ -378,6 +398,15 @@ func wrapGenericHandler(core *vault.Core, h http.Handler, props *vault.HandlerPr
 
 		nw := logical.NewStatusHeaderResponseWriter(w, customHeaders)
 
+		// Clients may opt in to receiving errors as RFC 7807 problem
+		// documents; the request ID is filled in once it is known.
+		var rw http.ResponseWriter = nw
+		var pw *problemResponseWriter
+		if acceptsProblemJSON(r) {
+			pw = &problemResponseWriter{StatusHeaderResponseWriter: nw}
+			rw = pw
+		}
+
 		// Set the Cache-Control header for all the responses returned
 		// by Vault
 		nw.Header().Set("Cache-Control", "no-store")
@@ -391,10 +420,27 @@ func wrapGenericHandler(core *vault.Core, h http.Handler, props *vault.HandlerPr
 		if r.URL.Path == "/v1/sys/monitor" || strings.HasPrefix(r.URL.Path, "/v1/sys/events/subscribe") {
 			ctx, cancelFunc = context.WithCancel(ctx)
 		} else {
-			ctx, cancelFunc = context.WithTimeout(ctx, maxRequestDuration)
+			requestDuration := maxRequestDuration
+			// Paths may be given a shorter maximum duration than the
+			// listener's, with the namespace from the header prepended to
+			// the request path
+			if strings.HasPrefix(r.URL.Path, "/v1/") {
+				nsPath := namespace.Canonicalize(r.Header.Get(consts.NamespaceHeaderName))
+				if d, ok := core.RequestMaxDuration(nsPath + strings.TrimPrefix(r.URL.Path, "/v1/")); ok && d < requestDuration {
+					requestDuration = d
+				}
+			}
+			ctx, cancelFunc = context.WithTimeout(ctx, requestDuration)
 		}
 
 		ctx = logical.CreateContextOriginalRequestPath(ctx, r.URL.Path)
+		// Requests forwarded by standbys already record the listener they
+		// were received on
+		if props.ListenerConfig != nil && props.ListenerConfig.Name != "" {
+			if _, ok := vault.ListenerNameFromContext(ctx); !ok {
+				ctx = vault.ContextWithListenerName(ctx, props.ListenerConfig.Name)
+			}
+		}
 		r = r.WithContext(ctx)
 		r = r.WithContext(namespace.ContextWithNamespace(r.Context(), namespace.RootNamespace))
 
@@ -417,7 +463,7 @@ func wrapGenericHandler(core *vault.Core, h http.Handler, props *vault.HandlerPr
 			// Setting the namespace in the header to be included in the error message
 			newR, status, err := adjustRequest(core, props.ListenerConfig, r)
 			if status != 0 {
-				respondError(nw, status, err)
+				respondError(rw, status, err)
 				cancelFunc()
 				return
 			}
@@ -452,7 +498,7 @@ func wrapGenericHandler(core *vault.Core, h http.Handler, props *vault.HandlerPr
 					}
 				}
 			}
-			respondError(nw, http.StatusNotFound, nil)
+			respondError(rw, http.StatusNotFound, nil)
 			cancelFunc()
 			return
 		}
@@ -467,7 +513,10 @@ func wrapGenericHandler(core *vault.Core, h http.Handler, props *vault.HandlerPr
 		}
 		inFlightReqID, err := reqIDGen()
 		if err != nil {
-			respondError(nw, http.StatusInternalServerError, fmt.Errorf("failed to generate an identifier for the in-flight request"))
+			respondError(rw, http.StatusInternalServerError, fmt.Errorf("failed to generate an identifier for the in-flight request"))
+		}
+		if pw != nil {
+			pw.requestID = inFlightReqID
 		}
 		// adding an entry to the context to enable updating in-flight
 		// data with ClientID in the logical layer
@@ -493,6 +542,7 @@ func wrapGenericHandler(core *vault.Core, h http.Handler, props *vault.HandlerPr
 				ReqPath:          r.URL.Path,
 				ClientRemoteAddr: clientAddr,
 				Method:           requestMethod,
+				Cancel:           cancelFunc,
 			})
 		defer func() {
 			// Not expecting this fail, so skipping the assertion check
@@ -504,7 +554,7 @@ func wrapGenericHandler(core *vault.Core, h http.Handler, props *vault.HandlerPr
 			nw.Header().Set(consts.NamespaceHeaderName, ns)
 		}
 
-		h.ServeHTTP(nw, r)
+		h.ServeHTTP(rw, r)
 
 		cancelFunc()
 	}
@@ -1228,6 +1278,27 @@ func requestPolicyOverride(r *http.Request, req *logical.Request) error {
 	return nil
 }
 
+// requestClientIdentification adds the name and version of the application
+// making the request, if set, to the logical.Request
+func requestClientIdentification(r *http.Request, req *logical.Request) error {
+	for header, field := range map[string]*string{
+		ClientNameHeaderName:    &req.ClientName,
+		ClientVersionHeaderName: &req.ClientVersion,
+	} {
+		value := strings.TrimSpace(r.Header.Get(header))
+		if len(value) > maxClientIdentificationLength {
+			return fmt.Errorf("%s header must be at most %d characters", header, maxClientIdentificationLength)
+		}
+		for _, c := range value {
+			if !unicode.IsPrint(c) {
+				return fmt.Errorf("%s header must only contain printable characters", header)
+			}
+		}
+		*field = value
+	}
+	return nil
+}
+
 // requestWrapInfo adds the WrapInfo value to the logical.Request if wrap info exists
 func requestWrapInfo(r *http.Request, req *logical.Request) (*logical.Request, error) {
 	// First try for the header value
@@ -1339,13 +1410,101 @@ func isForm(head []byte, contentType string) bool {
 }
 
 func respondError(w http.ResponseWriter, status int, err error) {
+	if pw, ok := w.(*problemResponseWriter); ok {
+		respondProblem(pw, status, nil, err)
+		return
+	}
 	logical.RespondError(w, status, err)
 }
 
 func respondErrorAndData(w http.ResponseWriter, status int, data interface{}, err error) {
+	if pw, ok := w.(*problemResponseWriter); ok {
+		respondProblem(pw, status, data, err)
+		return
+	}
 	logical.RespondErrorAndData(w, status, data, err)
 }
 
+// problemResponseWriter is used for requests which asked for errors to be
+// returned as RFC 7807 problem documents, by sending an Accept header of
+// application/problem+json.
+type problemResponseWriter struct {
+	*logical.StatusHeaderResponseWriter
+
+	// requestID correlates the error with the request in server and audit
+	// logs. It is the logical request ID once one has been assigned.
+	requestID string
+}
+
+// problemDetails is an RFC 7807 problem document. The errors and data members
+// carry the same content as the regular error response, alongside the
+// error_code, retryable and request_id extension members.
+type problemDetails struct {
+	Type      string      `json:"type"`
+	Title     string      `json:"title"`
+	Status    int         `json:"status"`
+	Detail    string      `json:"detail,omitempty"`
+	Errors    []string    `json:"errors"`
+	ErrorCode string      `json:"error_code,omitempty"`
+	Retryable bool        `json:"retryable"`
+	RequestID string      `json:"request_id,omitempty"`
+	Data      interface{} `json:"data,omitempty"`
+}
+
+// acceptsProblemJSON returns true if the request's Accept header lists the
+// application/problem+json media type.
+func acceptsProblemJSON(r *http.Request) bool {
+	for _, header := range r.Header.Values("Accept") {
+		for _, value := range strings.Split(header, ",") {
+			mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(value))
+			if err == nil && mediaType == problemJSONContentType {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// retryableStatus returns true if a request failing with the given status
+// may succeed if retried later without modification.
+func retryableStatus(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests,
+		http.StatusPreconditionFailed,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+func respondProblem(w *problemResponseWriter, status int, data interface{}, err error) {
+	logical.AdjustErrorStatusCode(&status, err)
+
+	problem := &problemDetails{
+		Type:      "about:blank",
+		Title:     http.StatusText(status),
+		Status:    status,
+		Errors:    make([]string, 0, 1),
+		Retryable: retryableStatus(status),
+		RequestID: w.requestID,
+		Data:      data,
+	}
+	if err != nil {
+		problem.Detail = err.Error()
+		problem.Errors = append(problem.Errors, err.Error())
+		problem.ErrorCode = logical.ErrorCode(nil, err)
+	}
+
+	w.Header().Set("Content-Type", problemJSONContentType)
+	w.WriteHeader(status)
+
+	enc := json.NewEncoder(w)
+	enc.Encode(problem)
+}
+
 func respondErrorCommon(w http.ResponseWriter, req *logical.Request, resp *logical.Response, err error) bool {
 	statusCode, newErr := logical.RespondErrorCommon(req, resp, err)
 	if newErr == nil && statusCode == 0 {
@@ -384,6 +384,51 @@ func TestHandler_MissingToken(t *testing.T) {
 	}
 }
 
+// TestHandler_ProblemJSON verifies that errors are returned as RFC 7807
+// problem documents only when the client asks for them.
+func TestHandler_ProblemJSON(t *testing.T) {
+	core, _, _ := vault.TestCoreUnsealed(t)
+	ln, addr := TestServer(t, core)
+	defer ln.Close()
+
+	client := cleanhttp.DefaultClient()
+
+	req, err := http.NewRequest("GET", addr+"/v1/secret/foo", nil)
+	require.NoError(t, err)
+	req.Header.Set("Accept", "application/json, application/problem+json;q=0.9")
+
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	require.Equal(t, "application/problem+json", resp.Header.Get("Content-Type"))
+
+	var problem map[string]interface{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&problem))
+	require.Equal(t, "about:blank", problem["type"])
+	require.Equal(t, "Forbidden", problem["title"])
+	require.Equal(t, float64(http.StatusForbidden), problem["status"])
+	require.Equal(t, false, problem["retryable"])
+	require.Contains(t, problem["detail"], "permission denied")
+	require.Len(t, problem["errors"], 1)
+	require.NotEmpty(t, problem["request_id"])
+
+	// Without the Accept header the regular error format is used.
+	req, err = http.NewRequest("GET", addr+"/v1/secret/foo", nil)
+	require.NoError(t, err)
+
+	resp, err = client.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	require.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+
+	var plain map[string]interface{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&plain))
+	require.Contains(t, plain, "errors")
+	require.NotContains(t, plain, "type")
+}
+
 func TestHandler_Accepted(t *testing.T) {
 	core, _, token := vault.TestCoreUnsealed(t)
 	ln, addr := TestServer(t, core)
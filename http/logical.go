@@ -289,6 +289,11 @@ func buildLogicalRequest(core *vault.Core, w http.ResponseWriter, r *http.Reques
 		return nil, nil, http.StatusBadRequest, fmt.Errorf("failed to parse %s header: %w", PolicyOverrideHeaderName, err)
 	}
 
+	err = requestClientIdentification(r, req)
+	if err != nil {
+		return nil, nil, http.StatusBadRequest, err
+	}
+
 	return req, origBody, 0, nil
 }
 
@@ -353,6 +358,9 @@ func handleLogicalInternal(core *vault.Core, injectDataIntoTopLevel bool, noForw
 			respondError(w, statusCode, err)
 			return
 		}
+		if pw, ok := w.(*problemResponseWriter); ok {
+			pw.requestID = req.ID
+		}
 
 		// Websockets need to be handled at HTTP layer instead of logical requests.
 		ns, err := namespace.FromContext(r.Context())
@@ -368,10 +376,11 @@ func handleLogicalInternal(core *vault.Core, injectDataIntoTopLevel bool, noForw
 		}
 		if websocketPaths.HasPath(trimmedPath) {
 			handler := entHandleEventsSubscribe(core, req)
-			if handler != nil {
-				handler.ServeHTTP(w, r)
-				return
+			if handler == nil {
+				handler = handleEventsSubscribe(core, req)
 			}
+			handler.ServeHTTP(w, r)
+			return
 		}
 		handler := handleEntPaths(nsPath, core, r)
 		if handler != nil {
@@ -19,6 +19,7 @@ import (
 	"time"
 
 	"github.com/go-test/deep"
+	"github.com/hashicorp/go-cleanhttp"
 	log "github.com/hashicorp/go-hclog"
 	kv "github.com/hashicorp/vault-plugin-secrets-kv"
 	"github.com/hashicorp/vault/api"
@@ -249,6 +250,45 @@ func TestLogical_CreateToken(t *testing.T) {
 	}
 }
 
+// TestLogical_ClientIdentification verifies that the client identification
+// headers are recorded on the tokens created by the request, and that invalid
+// header values are rejected.
+func TestLogical_ClientIdentification(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealed(t)
+	ln, addr := TestServer(t, core)
+	defer ln.Close()
+	TestServerAuth(t, addr, token)
+
+	createToken := func(name, version string) *http.Response {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodPost, addr+"/v1/auth/token/create", strings.NewReader(`{"meta": {"team": "payments"}}`))
+		require.NoError(t, err)
+		req.Header.Set(consts.AuthHeaderName, token)
+		req.Header.Set(ClientNameHeaderName, name)
+		req.Header.Set(ClientVersionHeaderName, version)
+		resp, err := cleanhttp.DefaultClient().Do(req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	resp := createToken(" billing-service ", "1.4.2")
+	testResponseStatus(t, resp, 200)
+
+	var actual map[string]interface{}
+	testResponseBody(t, resp, &actual)
+	require.Equal(t, map[string]interface{}{
+		"team":           "payments",
+		"client_name":    "billing-service",
+		"client_version": "1.4.2",
+	}, actual["auth"].(map[string]interface{})["metadata"])
+
+	resp = createToken(strings.Repeat("a", 129), "")
+	testResponseStatus(t, resp, 400)
+
+	resp = createToken("billing\tservice", "")
+	testResponseStatus(t, resp, 400)
+}
+
 func TestLogical_RawHTTP(t *testing.T) {
 	core, _, token := vault.TestCoreUnsealed(t)
 	ln, addr := TestServer(t, core)
@@ -136,3 +136,53 @@ func TestSysAuditHash(t *testing.T) {
 		t.Fatalf("bad: expected:\n%#v\n, got:\n%#v\n", expected, actual)
 	}
 }
+
+func TestSysAuditHash_RotateBulk(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealed(t)
+	ln, addr := TestServer(t, core)
+	defer ln.Close()
+	TestServerAuth(t, addr, token)
+
+	resp := testHttpPost(t, token, addr+"/v1/sys/audit/noop", map[string]interface{}{
+		"type": "noop",
+	})
+	testResponseStatus(t, resp, 204)
+
+	resp = testHttpPost(t, token, addr+"/v1/sys/audit-hash/noop", map[string]interface{}{
+		"inputs": []string{"bar", "baz"},
+	})
+	testResponseStatus(t, resp, 200)
+	var before map[string]interface{}
+	testResponseBody(t, resp, &before)
+	beforeData := before["data"].(map[string]interface{})
+	// The first hash matches the single input hash in TestSysAuditHash.
+	expected := "hmac-sha256:f9320baf0249169e73850cd6156ded0106e2bb6ad8cab01b7bbbebe6d1065317"
+	if hashes := beforeData["hashes"].([]interface{}); len(hashes) != 2 || hashes[0] != expected {
+		t.Fatalf("bad: %#v", beforeData)
+	}
+	if _, ok := beforeData["previous_hashes"]; ok {
+		t.Fatalf("unexpected previous hashes before rotation: %#v", beforeData)
+	}
+
+	resp = testHttpPost(t, token, addr+"/v1/sys/audit-hash-rotate/noop", map[string]interface{}{
+		"overlap": "1h",
+	})
+	testResponseStatus(t, resp, 204)
+
+	resp = testHttpPost(t, token, addr+"/v1/sys/audit-hash/noop", map[string]interface{}{
+		"inputs": []string{"bar", "baz"},
+	})
+	testResponseStatus(t, resp, 200)
+	var after map[string]interface{}
+	testResponseBody(t, resp, &after)
+	afterData := after["data"].(map[string]interface{})
+	if reflect.DeepEqual(afterData["hashes"], beforeData["hashes"]) {
+		t.Fatalf("expected hashes to change after rotation: %#v", afterData)
+	}
+	if !reflect.DeepEqual(afterData["previous_hashes"], beforeData["hashes"]) {
+		t.Fatalf("expected previous hashes to match hashes before rotation:\nbefore: %#v\nafter: %#v", beforeData, afterData)
+	}
+	if afterData["previous_expires_at"] == nil {
+		t.Fatalf("expected previous_expires_at: %#v", afterData)
+	}
+}
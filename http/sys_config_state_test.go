@@ -177,6 +177,7 @@ func TestSysConfigState_Sanitized(t *testing.T) {
 				"storage":                       tc.expectedStorageOutput,
 				"administrative_namespace_path": "",
 				"imprecise_lease_role_tracking": false,
+				"lazy_mount_initialization":     false,
 			}
 
 			if tc.expectedHAStorageOutput != nil {
@@ -10,6 +10,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/hashicorp/vault/api"
 	"github.com/hashicorp/vault/helper/testhelpers"
 	"github.com/hashicorp/vault/vault"
 )
@@ -126,3 +127,79 @@ func TestSysMonitorStreamingLogs(t *testing.T) {
 		})
 	}
 }
+
+func TestSysMonitorInvalidSampleRate(t *testing.T) {
+	t.Parallel()
+	cluster := vault.NewTestCluster(t, nil, &vault.TestClusterOptions{
+		HandlerFunc: Handler,
+		NumCores:    1,
+	})
+	defer cluster.Cleanup()
+
+	client := cluster.Cores[0].Client
+	request := client.NewRequest("GET", "/v1/sys/monitor")
+	request.Params.Add("sample_rate", "1.5")
+	_, err := client.RawRequest(request)
+
+	if err == nil {
+		t.Fatal("expected to get an error, but didn't")
+	} else {
+		if !strings.Contains(err.Error(), "Code: 400") {
+			t.Fatalf("expected to receive a 400 error, but got %s instead", err)
+		}
+
+		if !strings.Contains(err.Error(), "sample rate must be between 0 and 1") {
+			t.Fatalf("expected to receive a message indicating an invalid sample rate, but got %s instead", err)
+		}
+	}
+}
+
+func TestSysMonitorSubsystems(t *testing.T) {
+	t.Parallel()
+	cluster := vault.NewTestCluster(t, nil, &vault.TestClusterOptions{
+		HandlerFunc: Handler,
+		NumCores:    1,
+	})
+	defer cluster.Cleanup()
+
+	client := cluster.Cores[0].Client
+	stopCh := testhelpers.GenerateDebugLogs(t, client)
+	defer close(stopCh)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	logCh, err := client.Sys().MonitorWithOptions(ctx, &api.MonitorInput{
+		LogLevel:   "DEBUG",
+		LogFormat:  "json",
+		Subsystems: []string{"core"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type jsonlog struct {
+		Module string `json:"@module"`
+	}
+
+	count := 0
+	timeCh := time.After(120 * time.Second)
+	for count < 3 {
+		select {
+		case log, ok := <-logCh:
+			if !ok {
+				t.Fatal("Monitor closed before receiving core logs")
+			}
+			jsonLog := &jsonlog{}
+			if err := json.Unmarshal([]byte(log), jsonLog); err != nil {
+				t.Fatal("Expected JSON log from channel")
+			}
+			if !strings.HasSuffix(jsonLog.Module, ".core") && !strings.Contains(jsonLog.Module, ".core.") {
+				t.Fatalf("expected only logs of the core subsystem, got %q", log)
+			}
+			count++
+		case <-timeCh:
+			t.Fatal("Failed to get core logs after 120 seconds")
+		}
+	}
+}
@@ -95,6 +95,7 @@ func handleSysRekeyInitGet(ctx context.Context, core *vault.Core, recovery bool,
 		status.Progress = progress
 		status.VerificationRequired = rekeyConf.VerificationRequired
 		status.VerificationNonce = rekeyConf.VerificationNonce
+		status.RotateShares = rekeyConf.RotateShares
 		if rekeyConf.PGPKeys != nil && len(rekeyConf.PGPKeys) != 0 {
 			pgpFingerprints, err := pgpkeys.GetFingerprints(rekeyConf.PGPKeys, nil)
 			if err != nil {
@@ -134,6 +135,7 @@ func handleSysRekeyInitPut(ctx context.Context, core *vault.Core, recovery bool,
 		PGPKeys:              req.PGPKeys,
 		Backup:               req.Backup,
 		VerificationRequired: req.RequireVerification,
+		RotateShares:         req.RotateShares,
 	}, recovery)
 	if err != nil {
 		respondError(w, err.Code(), err)
@@ -364,6 +366,7 @@ type RekeyRequest struct {
 	PGPKeys             []string `json:"pgp_keys"`
 	Backup              bool     `json:"backup"`
 	RequireVerification bool     `json:"require_verification"`
+	RotateShares        bool     `json:"rotate_shares"`
 }
 
 type RekeyStatusResponse struct {
@@ -377,6 +380,7 @@ type RekeyStatusResponse struct {
 	Backup               bool     `json:"backup"`
 	VerificationRequired bool     `json:"verification_required"`
 	VerificationNonce    string   `json:"verification_nonce,omitempty"`
+	RotateShares         bool     `json:"rotate_shares,omitempty"`
 }
 
 type RekeyUpdateRequest struct {
@@ -13,7 +13,10 @@ import (
 	"github.com/hashicorp/eventlogger"
 )
 
-var _ eventlogger.Node = (*MetricsCounter)(nil)
+var (
+	_ eventlogger.Node          = (*MetricsCounter)(nil)
+	_ eventlogger.NodeUnwrapper = (*MetricsCounter)(nil)
+)
 
 // MetricsCounter offers a way for nodes to emit metrics which increment a label by 1.
 type MetricsCounter struct {
@@ -75,3 +78,9 @@ func (m MetricsCounter) Reopen() error {
 func (m MetricsCounter) Type() eventlogger.NodeType {
 	return m.Node.Type()
 }
+
+// Unwrap returns the underlying eventlogger.Node, so that the broker is able to
+// close it when it supports being closed.
+func (m MetricsCounter) Unwrap() eventlogger.Node {
+	return m.Node
+}
@@ -4,6 +4,7 @@
 package event
 
 import (
+	"crypto/tls"
 	"fmt"
 	"os"
 	"reflect"
@@ -21,14 +22,21 @@ type Option func(*options) error
 
 // Options are used to represent configuration for an Event.
 type options struct {
-	withID          string
-	withNow         time.Time
-	withFacility    string
-	withTag         string
-	withSocketType  string
-	withMaxDuration time.Duration
-	withFileMode    *os.FileMode
-	withLogger      hclog.Logger
+	withID            string
+	withNow           time.Time
+	withFacility      string
+	withTag           string
+	withSocketType    string
+	withMaxDuration   time.Duration
+	withFileMode      *os.FileMode
+	withLogger        hclog.Logger
+	withBatchSize     int
+	withBatchTimeout  time.Duration
+	withFailOpen      bool
+	withTLSConfig     *tls.Config
+	withSASLMechanism string
+	withSASLUsername  string
+	withSASLPassword  string
 }
 
 // getDefaultOptions returns Options with their default values.
@@ -36,12 +44,14 @@ func getDefaultOptions() options {
 	fileMode := os.FileMode(0o600)
 
 	return options{
-		withNow:         time.Now(),
-		withFacility:    "AUTH",
-		withTag:         "vault",
-		withSocketType:  "tcp",
-		withMaxDuration: 2 * time.Second,
-		withFileMode:    &fileMode,
+		withNow:          time.Now(),
+		withFacility:     "AUTH",
+		withTag:          "vault",
+		withSocketType:   "tcp",
+		withMaxDuration:  2 * time.Second,
+		withFileMode:     &fileMode,
+		withBatchSize:    100,
+		withBatchTimeout: 10 * time.Millisecond,
 	}
 }
 
@@ -216,3 +226,103 @@ func WithLogger(l hclog.Logger) Option {
 		return nil
 	}
 }
+
+// WithBatchSize provides an Option to represent the maximum number of events
+// a Kafka sink sends to the broker in a single batch.
+func WithBatchSize(size string) Option {
+	return func(o *options) error {
+		size = strings.TrimSpace(size)
+		if size == "" {
+			return nil
+		}
+
+		parsed, err := strconv.Atoi(size)
+		switch {
+		case err != nil:
+			return fmt.Errorf("unable to parse batch size: %w: %w", ErrInvalidParameter, err)
+		case parsed < 1:
+			return fmt.Errorf("batch size must be at least 1: %w", ErrInvalidParameter)
+		}
+
+		o.withBatchSize = parsed
+
+		return nil
+	}
+}
+
+// WithBatchTimeout provides an Option to represent how long a Kafka sink waits
+// for a batch to fill up before sending it to the broker.
+func WithBatchTimeout(duration string) Option {
+	return func(o *options) error {
+		duration = strings.TrimSpace(duration)
+		if duration == "" {
+			return nil
+		}
+
+		parsed, err := parseutil.ParseDurationSecond(duration)
+		if err != nil {
+			return fmt.Errorf("unable to parse batch timeout: %w: %w", ErrInvalidParameter, err)
+		}
+
+		o.withBatchTimeout = parsed
+
+		return nil
+	}
+}
+
+// WithFailOpen provides an Option to represent whether a sink should report
+// success for events it failed to write, rather than failing them.
+func WithFailOpen(failOpen string) Option {
+	return func(o *options) error {
+		failOpen = strings.TrimSpace(failOpen)
+		if failOpen == "" {
+			return nil
+		}
+
+		parsed, err := parseutil.ParseBool(failOpen)
+		if err != nil {
+			return fmt.Errorf("unable to parse fail open: %w: %w", ErrInvalidParameter, err)
+		}
+
+		o.withFailOpen = parsed
+
+		return nil
+	}
+}
+
+// WithTLSConfig provides an Option to supply the TLS configuration used to
+// connect to a Kafka broker. A nil configuration disables TLS.
+func WithTLSConfig(config *tls.Config) Option {
+	return func(o *options) error {
+		o.withTLSConfig = config
+
+		return nil
+	}
+}
+
+// WithSASL provides an Option to represent the SASL mechanism and credentials
+// used to authenticate to a Kafka broker. Supported mechanisms are "plain",
+// "scram-sha-256" and "scram-sha-512"; an empty mechanism disables SASL.
+func WithSASL(mechanism, username, password string) Option {
+	return func(o *options) error {
+		mechanism = strings.ToLower(strings.TrimSpace(mechanism))
+
+		switch mechanism {
+		case "":
+			return nil
+		case "plain", "scram-sha-256", "scram-sha-512":
+		default:
+			return fmt.Errorf("unsupported SASL mechanism %q: %w", mechanism, ErrInvalidParameter)
+		}
+
+		if username == "" {
+			return fmt.Errorf("SASL username is required: %w", ErrInvalidParameter)
+		}
+
+		o.withSASLMechanism = mechanism
+		o.withSASLUsername = username
+		o.withSASLPassword = password
+
+		return nil
+	}
+}
@@ -107,6 +107,9 @@ func TestOptions_Default(t *testing.T) {
 	require.Equal(t, "AUTH", opts.withFacility)
 	require.Equal(t, "vault", opts.withTag)
 	require.Equal(t, 2*time.Second, opts.withMaxDuration)
+	require.Equal(t, 100, opts.withBatchSize)
+	require.Equal(t, 10*time.Millisecond, opts.withBatchTimeout)
+	require.False(t, opts.withFailOpen)
 }
 
 // TestOptions_Opts exercises getOpts with various Option values.
@@ -458,3 +461,216 @@ func TestOptions_WithLogger(t *testing.T) {
 		})
 	}
 }
+
+// TestOptions_WithBatchSize exercises WithBatchSize Option to ensure it performs as expected.
+func TestOptions_WithBatchSize(t *testing.T) {
+	tests := map[string]struct {
+		Value                string
+		ExpectedValue        int
+		IsErrorExpected      bool
+		ExpectedErrorMessage string
+	}{
+		"empty-gives-default": {
+			Value: "",
+		},
+		"whitespace-give-default": {
+			Value: "    ",
+		},
+		"bad-value": {
+			Value:                "juan",
+			IsErrorExpected:      true,
+			ExpectedErrorMessage: "unable to parse batch size: invalid parameter: strconv.Atoi: parsing \"juan\": invalid syntax",
+		},
+		"zero": {
+			Value:                "0",
+			IsErrorExpected:      true,
+			ExpectedErrorMessage: "batch size must be at least 1: invalid parameter",
+		},
+		"negative": {
+			Value:                "-1",
+			IsErrorExpected:      true,
+			ExpectedErrorMessage: "batch size must be at least 1: invalid parameter",
+		},
+		"spacey-value": {
+			Value:         "   50   ",
+			ExpectedValue: 50,
+		},
+	}
+
+	for name, tc := range tests {
+		name := name
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			opts := &options{}
+			applyOption := WithBatchSize(tc.Value)
+			err := applyOption(opts)
+			switch {
+			case tc.IsErrorExpected:
+				require.Error(t, err)
+				require.EqualError(t, err, tc.ExpectedErrorMessage)
+			default:
+				require.NoError(t, err)
+				require.Equal(t, tc.ExpectedValue, opts.withBatchSize)
+			}
+		})
+	}
+}
+
+// TestOptions_WithBatchTimeout exercises WithBatchTimeout Option to ensure it performs as expected.
+func TestOptions_WithBatchTimeout(t *testing.T) {
+	tests := map[string]struct {
+		Value                string
+		ExpectedValue        time.Duration
+		IsErrorExpected      bool
+		ExpectedErrorMessage string
+	}{
+		"empty-gives-default": {
+			Value: "",
+		},
+		"whitespace-give-default": {
+			Value: "    ",
+		},
+		"bad-value": {
+			Value:                "juan",
+			IsErrorExpected:      true,
+			ExpectedErrorMessage: "unable to parse batch timeout: invalid parameter: time: invalid duration \"juan\"",
+		},
+		"duration-50ms": {
+			Value:         "50ms",
+			ExpectedValue: 50 * time.Millisecond,
+		},
+		"duration-1s": {
+			Value:         "1s",
+			ExpectedValue: time.Second,
+		},
+	}
+
+	for name, tc := range tests {
+		name := name
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			opts := &options{}
+			applyOption := WithBatchTimeout(tc.Value)
+			err := applyOption(opts)
+			switch {
+			case tc.IsErrorExpected:
+				require.Error(t, err)
+				require.EqualError(t, err, tc.ExpectedErrorMessage)
+			default:
+				require.NoError(t, err)
+				require.Equal(t, tc.ExpectedValue, opts.withBatchTimeout)
+			}
+		})
+	}
+}
+
+// TestOptions_WithFailOpen exercises WithFailOpen Option to ensure it performs as expected.
+func TestOptions_WithFailOpen(t *testing.T) {
+	tests := map[string]struct {
+		Value                string
+		ExpectedValue        bool
+		IsErrorExpected      bool
+		ExpectedErrorMessage string
+	}{
+		"empty-gives-default": {
+			Value: "",
+		},
+		"bad-value": {
+			Value:                "juan",
+			IsErrorExpected:      true,
+			ExpectedErrorMessage: "unable to parse fail open: invalid parameter: cannot parse '' as bool: strconv.ParseBool: parsing \"juan\": invalid syntax",
+		},
+		"true": {
+			Value:         "true",
+			ExpectedValue: true,
+		},
+		"false": {
+			Value:         " false ",
+			ExpectedValue: false,
+		},
+	}
+
+	for name, tc := range tests {
+		name := name
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			opts := &options{}
+			applyOption := WithFailOpen(tc.Value)
+			err := applyOption(opts)
+			switch {
+			case tc.IsErrorExpected:
+				require.Error(t, err)
+				require.EqualError(t, err, tc.ExpectedErrorMessage)
+			default:
+				require.NoError(t, err)
+				require.Equal(t, tc.ExpectedValue, opts.withFailOpen)
+			}
+		})
+	}
+}
+
+// TestOptions_WithSASL exercises WithSASL Option to ensure it performs as expected.
+func TestOptions_WithSASL(t *testing.T) {
+	tests := map[string]struct {
+		Mechanism            string
+		Username             string
+		Password             string
+		ExpectedMechanism    string
+		IsErrorExpected      bool
+		ExpectedErrorMessage string
+	}{
+		"empty-disables-sasl": {
+			Mechanism: "",
+			Username:  "juan",
+		},
+		"unsupported-mechanism": {
+			Mechanism:            "gssapi",
+			Username:             "juan",
+			IsErrorExpected:      true,
+			ExpectedErrorMessage: "unsupported SASL mechanism \"gssapi\": invalid parameter",
+		},
+		"username-missing": {
+			Mechanism:            "plain",
+			IsErrorExpected:      true,
+			ExpectedErrorMessage: "SASL username is required: invalid parameter",
+		},
+		"plain": {
+			Mechanism:         "plain",
+			Username:          "juan",
+			Password:          "hunter2",
+			ExpectedMechanism: "plain",
+		},
+		"scram-mixed-case": {
+			Mechanism:         " SCRAM-SHA-512 ",
+			Username:          "juan",
+			Password:          "hunter2",
+			ExpectedMechanism: "scram-sha-512",
+		},
+	}
+
+	for name, tc := range tests {
+		name := name
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			opts := &options{}
+			applyOption := WithSASL(tc.Mechanism, tc.Username, tc.Password)
+			err := applyOption(opts)
+			switch {
+			case tc.IsErrorExpected:
+				require.Error(t, err)
+				require.EqualError(t, err, tc.ExpectedErrorMessage)
+			default:
+				require.NoError(t, err)
+				require.Equal(t, tc.ExpectedMechanism, opts.withSASLMechanism)
+				if tc.ExpectedMechanism != "" {
+					require.Equal(t, tc.Username, opts.withSASLUsername)
+					require.Equal(t, tc.Password, opts.withSASLPassword)
+				}
+			}
+		})
+	}
+}
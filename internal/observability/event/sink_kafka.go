@@ -0,0 +1,162 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package event
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/eventlogger"
+	"github.com/hashicorp/go-hclog"
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
+)
+
+var (
+	_ eventlogger.Node   = (*KafkaSink)(nil)
+	_ eventlogger.Closer = (*KafkaSink)(nil)
+)
+
+// KafkaSink is a sink node which handles publishing events to a Kafka topic.
+// Events written concurrently are sent to the brokers in batches, and writes
+// block until their batch has been acknowledged by all in-sync replicas, so
+// that a slow or unavailable cluster applies backpressure to the callers.
+type KafkaSink struct {
+	requiredFormat string
+	topic          string
+	failOpen       bool
+	writer         *kafka.Writer
+	logger         hclog.Logger
+}
+
+// NewKafkaSink should be used to create a new KafkaSink.
+// Accepted options: WithMaxDuration, WithBatchSize, WithBatchTimeout,
+// WithFailOpen, WithTLSConfig, WithSASL and WithLogger.
+func NewKafkaSink(brokers []string, topic string, format string, opt ...Option) (*KafkaSink, error) {
+	var addresses []string
+	for _, broker := range brokers {
+		if broker = strings.TrimSpace(broker); broker != "" {
+			addresses = append(addresses, broker)
+		}
+	}
+	if len(addresses) == 0 {
+		return nil, fmt.Errorf("at least one broker is required: %w", ErrInvalidParameter)
+	}
+
+	topic = strings.TrimSpace(topic)
+	if topic == "" {
+		return nil, fmt.Errorf("topic is required: %w", ErrInvalidParameter)
+	}
+
+	format = strings.TrimSpace(format)
+	if format == "" {
+		return nil, fmt.Errorf("format is required: %w", ErrInvalidParameter)
+	}
+
+	opts, err := getOpts(opt...)
+	if err != nil {
+		return nil, err
+	}
+
+	mechanism, err := kafkaSASLMechanism(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	writer := &kafka.Writer{
+		Addr:         kafka.TCP(addresses...),
+		Topic:        topic,
+		Balancer:     &kafka.LeastBytes{},
+		BatchSize:    opts.withBatchSize,
+		BatchTimeout: opts.withBatchTimeout,
+		ReadTimeout:  opts.withMaxDuration,
+		WriteTimeout: opts.withMaxDuration,
+		RequiredAcks: kafka.RequireAll,
+		Transport: &kafka.Transport{
+			DialTimeout: opts.withMaxDuration,
+			TLS:         opts.withTLSConfig,
+			SASL:        mechanism,
+		},
+	}
+
+	return &KafkaSink{
+		requiredFormat: format,
+		topic:          topic,
+		failOpen:       opts.withFailOpen,
+		writer:         writer,
+		logger:         opts.withLogger,
+	}, nil
+}
+
+// kafkaSASLMechanism returns the SASL mechanism configured in opts, if any.
+func kafkaSASLMechanism(opts options) (sasl.Mechanism, error) {
+	switch opts.withSASLMechanism {
+	case "":
+		return nil, nil
+	case "plain":
+		return plain.Mechanism{Username: opts.withSASLUsername, Password: opts.withSASLPassword}, nil
+	case "scram-sha-256":
+		return scram.Mechanism(scram.SHA256, opts.withSASLUsername, opts.withSASLPassword)
+	case "scram-sha-512":
+		return scram.Mechanism(scram.SHA512, opts.withSASLUsername, opts.withSASLPassword)
+	default:
+		return nil, fmt.Errorf("unsupported SASL mechanism %q: %w", opts.withSASLMechanism, ErrInvalidParameter)
+	}
+}
+
+// Process handles publishing the event to the Kafka topic.
+func (s *KafkaSink) Process(ctx context.Context, e *eventlogger.Event) (_ *eventlogger.Event, retErr error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	if e == nil {
+		return nil, fmt.Errorf("event is nil: %w", ErrInvalidParameter)
+	}
+
+	formatted, found := e.Format(s.requiredFormat)
+	if !found {
+		return nil, fmt.Errorf("unable to retrieve event formatted as %q: %w", s.requiredFormat, ErrInvalidParameter)
+	}
+
+	err := s.writer.WriteMessages(ctx, kafka.Message{Value: formatted})
+	if err == nil {
+		return nil, nil
+	}
+
+	err = fmt.Errorf("error writing to kafka topic %q: %w", s.topic, err)
+	if !s.failOpen {
+		return nil, err
+	}
+
+	// When failing open, the event is dropped, but it is still worth
+	// reporting in the operational logs.
+	if s.logger != nil {
+		s.logger.Error("kafka sink dropped event", "error", err)
+	}
+
+	// return nil for the event to indicate the pipeline is complete.
+	return nil, nil
+}
+
+// Reopen is a no-op for the Kafka sink, as connections to the brokers are
+// re-established as required.
+func (*KafkaSink) Reopen() error {
+	return nil
+}
+
+// Type describes the type of this node (sink).
+func (*KafkaSink) Type() eventlogger.NodeType {
+	return eventlogger.NodeTypeSink
+}
+
+// Close flushes any pending events and closes the connections to the brokers.
+func (s *KafkaSink) Close(_ context.Context) error {
+	return s.writer.Close()
+}
@@ -0,0 +1,162 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package event
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/eventlogger"
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNewKafkaSink ensures that we validate the input arguments and can create
+// the KafkaSink if everything goes to plan.
+func TestNewKafkaSink(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		brokers        []string
+		topic          string
+		format         string
+		opts           []Option
+		wantErr        bool
+		expectedErrMsg string
+	}{
+		"brokers-empty": {
+			brokers:        nil,
+			topic:          "vault",
+			format:         "json",
+			wantErr:        true,
+			expectedErrMsg: "at least one broker is required: invalid parameter",
+		},
+		"brokers-whitespace": {
+			brokers:        []string{"   "},
+			topic:          "vault",
+			format:         "json",
+			wantErr:        true,
+			expectedErrMsg: "at least one broker is required: invalid parameter",
+		},
+		"topic-empty": {
+			brokers:        []string{"localhost:9092"},
+			topic:          "",
+			format:         "json",
+			wantErr:        true,
+			expectedErrMsg: "topic is required: invalid parameter",
+		},
+		"format-whitespace": {
+			brokers:        []string{"localhost:9092"},
+			topic:          "vault",
+			format:         "   ",
+			wantErr:        true,
+			expectedErrMsg: "format is required: invalid parameter",
+		},
+		"bad-batch-size": {
+			brokers:        []string{"localhost:9092"},
+			topic:          "vault",
+			format:         "json",
+			opts:           []Option{WithBatchSize("0")},
+			wantErr:        true,
+			expectedErrMsg: "batch size must be at least 1: invalid parameter",
+		},
+		"bad-sasl-mechanism": {
+			brokers:        []string{"localhost:9092"},
+			topic:          "vault",
+			format:         "json",
+			opts:           []Option{WithSASL("gssapi", "juan", "")},
+			wantErr:        true,
+			expectedErrMsg: "unsupported SASL mechanism \"gssapi\": invalid parameter",
+		},
+		"happy": {
+			brokers: []string{"localhost:9092", " localhost:9093 "},
+			topic:   "vault",
+			format:  "json",
+			opts:    []Option{WithSASL("scram-sha-256", "juan", "hunter2")},
+		},
+	}
+
+	for name, tc := range tests {
+		name := name
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := NewKafkaSink(tc.brokers, tc.topic, tc.format, tc.opts...)
+
+			if tc.wantErr {
+				require.Error(t, err)
+				require.EqualError(t, err, tc.expectedErrMsg)
+				require.Nil(t, got)
+			} else {
+				require.NoError(t, err)
+				require.NotNil(t, got)
+				require.Equal(t, "json", got.requiredFormat)
+				require.Equal(t, "vault", got.topic)
+				require.Equal(t, "localhost:9092,localhost:9093", got.writer.Addr.String())
+				require.Equal(t, 100, got.writer.BatchSize)
+				require.NotNil(t, got.writer.Transport)
+				require.NoError(t, got.Close(context.Background()))
+			}
+		})
+	}
+}
+
+// TestKafkaSink_Process ensures that failures to publish an event are only
+// reported when the sink fails closed.
+func TestKafkaSink_Process(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		failOpen string
+		wantErr  bool
+	}{
+		"fail-closed": {
+			failOpen: "false",
+			wantErr:  true,
+		},
+		"fail-open": {
+			failOpen: "true",
+			wantErr:  false,
+		},
+	}
+
+	for name, tc := range tests {
+		name := name
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			// Nothing listens on the discard port, so every write fails.
+			sink, err := NewKafkaSink([]string{"127.0.0.1:9"}, "vault", "json",
+				WithMaxDuration("100ms"),
+				WithFailOpen(tc.failOpen),
+				WithLogger(hclog.NewNullLogger()),
+			)
+			require.NoError(t, err)
+			defer sink.Close(context.Background())
+
+			event := &eventlogger.Event{
+				Type:      "audit",
+				CreatedAt: time.Now(),
+				Formatted: make(map[string][]byte),
+				Payload:   struct{ ID string }{ID: "123"},
+			}
+			event.FormattedAs("json", []byte(`{"id":"123"}`))
+
+			ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+			defer cancel()
+
+			got, err := sink.Process(ctx, event)
+			require.Nil(t, got)
+			if tc.wantErr {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "error writing to kafka topic \"vault\"")
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
@@ -50,6 +50,13 @@ type SharedConfig struct {
 	LogRotateMaxFiles    int         `hcl:"log_rotate_max_files"`
 	LogRotateMaxFilesRaw interface{} `hcl:"log_rotate_max_files"`
 
+	// LogRedact enables redaction of sensitive values, such as private keys
+	// and tokens, from log output. LogRedactPatterns are regular expressions
+	// matching additional values to redact.
+	LogRedact         bool        `hcl:"-"`
+	LogRedactRaw      interface{} `hcl:"log_redact"`
+	LogRedactPatterns []string    `hcl:"log_redact_patterns"`
+
 	PidFile string `hcl:"pid_file"`
 
 	ClusterName string `hcl:"cluster_name"`
@@ -87,6 +94,13 @@ func ParseConfig(d string) (*SharedConfig, error) {
 		result.DisableMlockRaw = nil
 	}
 
+	if result.LogRedactRaw != nil {
+		if result.LogRedact, err = parseutil.ParseBool(result.LogRedactRaw); err != nil {
+			return nil, err
+		}
+		result.FoundKeys = append(result.FoundKeys, "LogRedact")
+	}
+
 	list, ok := obj.Node.(*ast.ObjectList)
 	if !ok {
 		return nil, fmt.Errorf("error parsing: file doesn't contain a root object")
@@ -199,6 +213,12 @@ func (c *SharedConfig) Sanitized() map[string]interface{} {
 	if c.LogRotateMaxFiles != 0 {
 		result["log_rotate_max_files"] = c.LogRotateMaxFiles
 	}
+	if c.LogRedact {
+		result["log_redact"] = c.LogRedact
+	}
+	if len(c.LogRedactPatterns) != 0 {
+		result["log_redact_patterns"] = c.LogRedactPatterns
+	}
 
 	// Sanitize listeners
 	if len(c.Listeners) != 0 {
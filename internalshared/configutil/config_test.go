@@ -155,3 +155,23 @@ func TestSharedConfig_Sanitized_LogFields(t *testing.T) {
 		})
 	}
 }
+
+// TestParseConfig_LogRedact ensures that log redaction settings are parsed and
+// included in the sanitized config.
+func TestParseConfig_LogRedact(t *testing.T) {
+	cfg, err := ParseConfig(`
+log_redact          = "true"
+log_redact_patterns = ["ghp_[A-Za-z0-9]{36}"]
+`)
+	require.NoError(t, err)
+	require.True(t, cfg.LogRedact)
+	require.Equal(t, []string{"ghp_[A-Za-z0-9]{36}"}, cfg.LogRedactPatterns)
+
+	sanitized := cfg.Sanitized()
+	require.Equal(t, true, sanitized["log_redact"])
+	require.Equal(t, []string{"ghp_[A-Za-z0-9]{36}"}, sanitized["log_redact_patterns"])
+
+	merged := cfg.Merge(&SharedConfig{})
+	require.True(t, merged.LogRedact)
+	require.Equal(t, cfg.LogRedactPatterns, merged.LogRedactPatterns)
+}
@@ -82,6 +82,12 @@ var (
 		transit.EnvVaultTransitSealDisableRenewal: "disable_renewal",
 	}
 
+	HTTPKMSEnvVars = map[string]string{
+		"VAULT_HTTPKMS_SEAL_ADDRESS": "address",
+		"VAULT_HTTPKMS_SEAL_KEY_ID":  "key_id",
+		"VAULT_HTTPKMS_SEAL_TOKEN":   "token",
+	}
+
 	// TransitPrioritizeConfigValues are the variables where file config takes precedence over env vars in transit seals
 	TransitPrioritizeConfigValues = []string{
 		"token",
@@ -28,6 +28,7 @@ import (
 	"github.com/hashicorp/go-secure-stdlib/parseutil"
 	"github.com/hashicorp/hcl"
 	"github.com/hashicorp/hcl/hcl/ast"
+	"github.com/hashicorp/vault/helper/httpkms"
 	"github.com/hashicorp/vault/sdk/helper/strutil"
 	"github.com/hashicorp/vault/sdk/logical"
 )
@@ -259,6 +260,9 @@ func configureWrapper(configKMS *KMS, infoKeys *[]string, info *map[string]strin
 	case wrapping.WrapperTypeTransit:
 		wrapper, kmsInfo, err = GetTransitKMSFunc(configKMS, opts...)
 
+	case httpkms.WrapperType:
+		wrapper, kmsInfo, err = GetHTTPKMSFunc(configKMS, opts...)
+
 	case wrapping.WrapperTypePkcs11:
 		return nil, fmt.Errorf("KMS type 'pkcs11' requires the Vault Enterprise HSM binary")
 
@@ -421,6 +425,20 @@ var GetTransitKMSFunc = func(kms *KMS, opts ...wrapping.Option) (wrapping.Wrappe
 	return wrapper, info, nil
 }
 
+func GetHTTPKMSFunc(kms *KMS, opts ...wrapping.Option) (wrapping.Wrapper, map[string]string, error) {
+	wrapper := httpkms.NewWrapper()
+	wrapperInfo, err := wrapper.SetConfig(context.Background(), append(opts, wrapping.WithConfigMap(kms.Config))...)
+	if err != nil {
+		return nil, nil, err
+	}
+	info := make(map[string]string)
+	if wrapperInfo != nil {
+		info["HTTP KMS Address"] = wrapperInfo.Metadata["address"]
+		info["HTTP KMS Key ID"] = wrapperInfo.Metadata["key_id"]
+	}
+	return wrapper, info, nil
+}
+
 func createSecureRandomReader(_ *SharedConfig, _ []*EntropySourcerInfo, _ hclog.Logger) (io.Reader, error) {
 	return rand.Reader, nil
 }
@@ -442,6 +460,8 @@ func getEnvConfig(kms *KMS) map[string]string {
 		wrapperEnvVars = OCIKMSEnvVars
 	case wrapping.WrapperTypeTransit:
 		wrapperEnvVars = TransitEnvVars
+	case httpkms.WrapperType:
+		wrapperEnvVars = HTTPKMSEnvVars
 	default:
 		return nil
 	}
@@ -70,6 +70,15 @@ func Test_getEnvConfig(t *testing.T) {
 			map[string]string{"VAULT_ADDR": "test_address", "VAULT_TOKEN": "test_token", "VAULT_TRANSIT_SEAL_KEY_NAME": "test_key_name", "VAULT_TRANSIT_SEAL_MOUNT_PATH": "test_mount_path"},
 			map[string]string{"address": "test_address", "token": "test_token", "key_name": "test_key_name", "mount_path": "test_mount_path"},
 		},
+		{
+			"HTTP KMS wrapper",
+			&KMS{
+				Type:     "httpkms",
+				Priority: 1,
+			},
+			map[string]string{"VAULT_HTTPKMS_SEAL_ADDRESS": "test_address", "VAULT_HTTPKMS_SEAL_KEY_ID": "test_key_id", "VAULT_HTTPKMS_SEAL_TOKEN": "test_token"},
+			map[string]string{"address": "test_address", "key_id": "test_key_id", "token": "test_token"},
+		},
 		{
 			"Environment vars not set",
 			&KMS{
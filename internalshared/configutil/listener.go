@@ -58,6 +58,10 @@ type Listener struct {
 	PurposeRaw interface{} `hcl:"purpose"`
 	Role       string      `hcl:"role"`
 
+	// Name identifies the listener, such as in the network requirements of
+	// auth mounts
+	Name string `hcl:"name"`
+
 	Address                 string        `hcl:"address"`
 	ClusterAddress          string        `hcl:"cluster_address"`
 	MaxRequestSize          int64         `hcl:"-"`
@@ -149,6 +153,12 @@ type Listener struct {
 	// DisableRequestLimiter allows per-listener disabling of the Request Limiter.
 	DisableRequestLimiterRaw any  `hcl:"disable_request_limiter"`
 	DisableRequestLimiter    bool `hcl:"-"`
+
+	// ResponseCompression enables the gzip and zstd compression of responses,
+	// optionally only for the request paths matching ResponseCompressionPaths.
+	ResponseCompressionRaw   any      `hcl:"response_compression"`
+	ResponseCompression      bool     `hcl:"-"`
+	ResponseCompressionPaths []string `hcl:"response_compression_paths"`
 }
 
 // AgentAPI allows users to select which parts of the Agent API they want enabled.
@@ -159,6 +169,10 @@ type AgentAPI struct {
 // ProxyAPI allows users to select which parts of the Vault Proxy API they want enabled.
 type ProxyAPI struct {
 	EnableQuit bool `hcl:"enable_quit"`
+
+	// AllowedClientUIDs restricts the clients of a unix socket listener to
+	// the processes running as one of the given user IDs.
+	AllowedClientUIDs []int `hcl:"allowed_client_uids"`
 }
 
 func (l *Listener) GoString() string {
@@ -264,6 +278,7 @@ func parseListener(item *ast.ObjectItem) (*Listener, error) {
 		l.parseRedactionSettings,
 		l.parseDisableReplicationStatusEndpointSettings,
 		l.parseDisableRequestLimiter,
+		l.parseResponseCompressionSettings,
 	} {
 		err := parser()
 		if err != nil {
@@ -388,6 +403,23 @@ func (l *Listener) parseDisableRequestLimiter() error {
 	return nil
 }
 
+// parseResponseCompressionSettings attempts to parse the raw response
+// compression settings. The receiving Listener's ResponseCompression field
+// will be set with the successfully parsed value or return an error.
+func (l *Listener) parseResponseCompressionSettings() error {
+	if err := parseAndClearBool(&l.ResponseCompressionRaw, &l.ResponseCompression); err != nil {
+		return fmt.Errorf("invalid value for response_compression: %w", err)
+	}
+
+	for _, path := range l.ResponseCompressionPaths {
+		if strings.TrimSpace(path) == "" {
+			return errors.New("response_compression_paths must not contain empty paths")
+		}
+	}
+
+	return nil
+}
+
 // parseChrootNamespace attempts to parse the raw listener chroot namespace settings.
 // The state of the listener will be modified, raw data will be cleared upon
 // successful parsing.
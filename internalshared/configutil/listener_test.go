@@ -1113,6 +1113,69 @@ func TestListener_parseRedactionSettings(t *testing.T) {
 	}
 }
 
+// TestListener_parseResponseCompressionSettings exercises the listener receiver
+// parseResponseCompressionSettings.
+func TestListener_parseResponseCompressionSettings(t *testing.T) {
+	tests := map[string]struct {
+		rawResponseCompression      any
+		expectedResponseCompression bool
+		responseCompressionPaths    []string
+		isErrorExpected             bool
+		errorMessage                string
+	}{
+		"missing": {
+			isErrorExpected:             false,
+			expectedResponseCompression: false,
+		},
+		"response-compression-bad": {
+			rawResponseCompression: "juan",
+			isErrorExpected:        true,
+			errorMessage:           "invalid value for response_compression",
+		},
+		"response-compression-good": {
+			rawResponseCompression:      "true",
+			expectedResponseCompression: true,
+			responseCompressionPaths:    []string{"pki/crl*", "sys/*"},
+			isErrorExpected:             false,
+		},
+		"response-compression-paths-empty": {
+			rawResponseCompression:   "true",
+			responseCompressionPaths: []string{"pki/crl", " "},
+			isErrorExpected:          true,
+			errorMessage:             "response_compression_paths must not contain empty paths",
+		},
+	}
+
+	for name, tc := range tests {
+		name := name
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			// Configure listener with raw values
+			l := &Listener{
+				ResponseCompressionRaw:   tc.rawResponseCompression,
+				ResponseCompressionPaths: tc.responseCompressionPaths,
+			}
+
+			err := l.parseResponseCompressionSettings()
+
+			switch {
+			case tc.isErrorExpected:
+				require.Error(t, err)
+				require.ErrorContains(t, err, tc.errorMessage)
+			default:
+				// Assert we got the relevant values.
+				require.NoError(t, err)
+				require.Equal(t, tc.expectedResponseCompression, l.ResponseCompression)
+
+				// Ensure the state was modified for the raw values.
+				require.Nil(t, l.ResponseCompressionRaw)
+			}
+		})
+	}
+}
+
 func TestParseAndClearBool(t *testing.T) {
 	testcases := []struct {
 		name           string
@@ -83,6 +83,17 @@ func (c *SharedConfig) Merge(c2 *SharedConfig) *SharedConfig {
 		result.LogRotateMaxFilesRaw = c2.LogRotateMaxFilesRaw
 	}
 
+	result.LogRedact = c.LogRedact
+	if c2.LogRedactRaw != nil {
+		result.LogRedact = c2.LogRedact
+		result.LogRedactRaw = c2.LogRedactRaw
+	}
+
+	result.LogRedactPatterns = c.LogRedactPatterns
+	if len(c2.LogRedactPatterns) != 0 {
+		result.LogRedactPatterns = c2.LogRedactPatterns
+	}
+
 	result.LogRotateDuration = c.LogRotateDuration
 	if c2.LogRotateDuration != "" {
 		result.LogRotateDuration = c2.LogRotateDuration
@@ -24,6 +24,15 @@ import (
 	"go.etcd.io/etcd/client/v3/concurrency"
 )
 
+const (
+	// defaultMaxTxnOps matches the default '--max-txn-ops' of etcd servers.
+	defaultMaxTxnOps = 128
+
+	// lockRetryInterval is how often the lease of a held lock is checked while
+	// its keepalive cannot be re-established.
+	lockRetryInterval = time.Second
+)
+
 // EtcdBackend is a physical backend that stores data at specific
 // prefix within etcd. It is used for most production situations as
 // it allows Vault to run on multiple machines in a highly-available manner.
@@ -33,6 +42,7 @@ type EtcdBackend struct {
 	haEnabled      bool
 	lockTimeout    time.Duration
 	requestTimeout time.Duration
+	maxTxnOps      int
 
 	permitPool *physical.PermitPool
 
@@ -41,9 +51,11 @@ type EtcdBackend struct {
 
 // Verify EtcdBackend satisfies the correct interfaces
 var (
-	_ physical.Backend   = (*EtcdBackend)(nil)
-	_ physical.HABackend = (*EtcdBackend)(nil)
-	_ physical.Lock      = (*EtcdLock)(nil)
+	_ physical.Backend             = (*EtcdBackend)(nil)
+	_ physical.HABackend           = (*EtcdBackend)(nil)
+	_ physical.Transactional       = (*EtcdBackend)(nil)
+	_ physical.TransactionalLimits = (*EtcdBackend)(nil)
+	_ physical.Lock                = (*EtcdLock)(nil)
 )
 
 // newEtcd3Backend constructs a etcd3 backend.
@@ -94,6 +106,16 @@ func newEtcd3Backend(conf map[string]string, logger log.Logger) (physical.Backen
 		if err != nil {
 			return nil, err
 		}
+
+		if serverID, ok := conf["tls_server_spiffe_id"]; ok && serverID != "" {
+			if !hasCa {
+				return nil, errors.New("'tls_ca_file' is required to verify 'tls_server_spiffe_id'")
+			}
+			if err := configureSPIFFEVerification(tlscfg, ca, serverID); err != nil {
+				return nil, err
+			}
+		}
+
 		cfg.TLS = tlscfg
 	}
 
@@ -175,6 +197,17 @@ func newEtcd3Backend(conf map[string]string, logger log.Logger) (physical.Backen
 		return nil, fmt.Errorf("value [%v] of 'lock_timeout' could not be understood: %w", sLock, err)
 	}
 
+	maxTxnOps := defaultMaxTxnOps
+	if sMaxTxnOps, ok := conf["max_txn_ops"]; ok {
+		maxTxnOps, err = strconv.Atoi(sMaxTxnOps)
+		if err != nil {
+			return nil, fmt.Errorf("value of 'max_txn_ops' (%v) could not be understood: %w", sMaxTxnOps, err)
+		}
+		if maxTxnOps < 4 {
+			return nil, fmt.Errorf("value of 'max_txn_ops' (%v) must be at least 4", sMaxTxnOps)
+		}
+	}
+
 	return &EtcdBackend{
 		path:           path,
 		etcd:           etcd,
@@ -183,6 +216,7 @@ func newEtcd3Backend(conf map[string]string, logger log.Logger) (physical.Backen
 		haEnabled:      haEnabledBool,
 		lockTimeout:    lock,
 		requestTimeout: reqTimeout,
+		maxTxnOps:      maxTxnOps,
 	}, nil
 }
 
@@ -274,16 +308,100 @@ func (e *EtcdBackend) HAEnabled() bool {
 	return e.haEnabled
 }
 
+// Transaction runs the given entries atomically as a single etcd v3
+// transaction.
+func (c *EtcdBackend) Transaction(ctx context.Context, txns []*physical.TxnEntry) error {
+	if len(txns) == 0 {
+		return nil
+	}
+	defer metrics.MeasureSince([]string{"etcd", "transaction"}, time.Now())
+
+	if len(txns) > c.maxTxnOps {
+		return fmt.Errorf("transaction of %d operations exceeds 'max_txn_ops' of %d", len(txns), c.maxTxnOps)
+	}
+
+	// etcd rejects transactions which write the same key more than once, so
+	// only the last write to each key is sent, which has the same outcome.
+	lastWrite := make(map[string]int, len(txns))
+	for i, t := range txns {
+		if t.Operation == physical.PutOperation || t.Operation == physical.DeleteOperation {
+			lastWrite[t.Entry.Key] = i
+		}
+	}
+
+	ops := make([]clientv3.Op, 0, len(txns))
+	var gets []*physical.TxnEntry
+	for i, t := range txns {
+		key := path.Join(c.path, t.Entry.Key)
+		switch t.Operation {
+		case physical.GetOperation:
+			ops = append(ops, clientv3.OpGet(key))
+			gets = append(gets, t)
+		case physical.PutOperation:
+			if lastWrite[t.Entry.Key] == i {
+				ops = append(ops, clientv3.OpPut(key, string(t.Entry.Value)))
+			}
+		case physical.DeleteOperation:
+			if lastWrite[t.Entry.Key] == i {
+				ops = append(ops, clientv3.OpDelete(key))
+			}
+		default:
+			return fmt.Errorf("%q is not a supported transaction operation", t.Operation)
+		}
+	}
+
+	c.permitPool.Acquire()
+	defer c.permitPool.Release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.requestTimeout)
+	defer cancel()
+	resp, err := c.etcd.Txn(ctx).Then(ops...).Commit()
+	if err != nil {
+		return err
+	}
+
+	// Populate the values of get operations from their responses, which are
+	// returned in the same order as the operations were given.
+	getIdx := 0
+	for _, r := range resp.Responses {
+		rangeResp := r.GetResponseRange()
+		if rangeResp == nil {
+			continue
+		}
+		if getIdx >= len(gets) {
+			return errors.New("unexpected number of get responses in transaction")
+		}
+		if len(rangeResp.Kvs) > 0 {
+			gets[getIdx].Entry.Value = rangeResp.Kvs[0].Value
+		}
+		getIdx++
+	}
+
+	return nil
+}
+
+// TransactionLimits returns the limits on the size of transactions. Each
+// transaction may be accompanied by an equal number of reads, so half of
+// 'max_txn_ops' is available, less one for any operation Vault adds. The size
+// limit leaves room below etcd's default 1.5MiB request limit.
+func (c *EtcdBackend) TransactionLimits() (int, int) {
+	return c.maxTxnOps/2 - 1, 1024 * 1024
+}
+
 // EtcdLock implements a lock using and etcd backend.
 type EtcdLock struct {
 	lock           sync.Mutex
 	held           bool
 	timeout        time.Duration
 	requestTimeout time.Duration
+	logger         log.Logger
 
 	etcdSession *concurrency.Session
 	etcdMu      *concurrency.Mutex
 
+	// stopMonitor stops monitoring the lease of the held lock.
+	stopMonitor context.CancelFunc
+
 	prefix string
 	value  string
 
@@ -299,6 +417,7 @@ func (c *EtcdBackend) LockWith(key, value string) (physical.Lock, error) {
 		etcd:           c.etcd,
 		timeout:        c.lockTimeout,
 		requestTimeout: c.requestTimeout,
+		logger:         c.logger,
 	}, nil
 }
 
@@ -306,49 +425,49 @@ func (c *EtcdLock) Lock(stopCh <-chan struct{}) (<-chan struct{}, error) {
 	c.lock.Lock()
 	defer c.lock.Unlock()
 
-	if c.etcdMu == nil {
-		if err := c.initMu(); err != nil {
-			return nil, err
-		}
-	}
-
 	if c.held {
 		return nil, EtcdLockHeldError
 	}
 
-	select {
-	case _, ok := <-c.etcdSession.Done():
-		if !ok {
-			// The session's done channel is closed, so the session is over,
-			// and we need a new lock with a new session.
-			if err := c.initMu(); err != nil {
-				return nil, err
-			}
-		}
-	default:
+	// Each attempt uses a new lease, so that a lease which expired while the
+	// lock was not held is never reused.
+	c.closeSession()
+	if err := c.initMu(); err != nil {
+		return nil, err
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 	go func() {
-		<-stopCh
-		cancel()
+		select {
+		case <-stopCh:
+			cancel()
+		case <-ctx.Done():
+		}
 	}()
 	if err := c.etcdMu.Lock(ctx); err != nil {
+		c.closeSession()
 		if err == context.Canceled {
 			return nil, nil
 		}
 		return nil, err
 	}
 
-	pctx, cancel := context.WithTimeout(context.Background(), c.requestTimeout)
-	defer cancel()
+	pctx, pcancel := context.WithTimeout(context.Background(), c.requestTimeout)
+	defer pcancel()
 	if _, err := c.etcd.Put(pctx, c.etcdMu.Key(), c.value, clientv3.WithLease(c.etcdSession.Lease())); err != nil {
+		c.closeSession()
 		return nil, err
 	}
 
+	leaderLostCh := make(chan struct{})
+	monitorCtx, stopMonitor := context.WithCancel(context.Background())
+	c.stopMonitor = stopMonitor
+	go c.monitorLease(monitorCtx, c.etcdSession.Lease(), leaderLostCh)
+
 	c.held = true
 
-	return c.etcdSession.Done(), nil
+	return leaderLostCh, nil
 }
 
 func (c *EtcdLock) Unlock() error {
@@ -359,9 +478,18 @@ func (c *EtcdLock) Unlock() error {
 		return EtcdLockNotHeldError
 	}
 
+	c.held = false
+	c.stopMonitor()
+
 	ctx, cancel := context.WithTimeout(context.Background(), c.requestTimeout)
 	defer cancel()
-	return c.etcdMu.Unlock(ctx)
+	err := c.etcdMu.Unlock(ctx)
+
+	// Revoking the lease lets standbys take over without waiting for it to
+	// expire, even if the lock key could not be deleted.
+	c.closeSession()
+
+	return err
 }
 
 func (c *EtcdLock) Value() (bool, string, error) {
@@ -390,3 +518,89 @@ func (c *EtcdLock) initMu() error {
 	c.etcdMu = concurrency.NewMutex(session, c.prefix)
 	return nil
 }
+
+// closeSession revokes the lease of the current session, if any.
+func (c *EtcdLock) closeSession() {
+	if c.etcdSession == nil {
+		return
+	}
+	if err := c.etcdSession.Close(); err != nil && c.logger != nil {
+		c.logger.Debug("failed to revoke etcd lock lease", "error", err)
+	}
+	c.etcdSession = nil
+	c.etcdMu = nil
+}
+
+// monitorLease keeps the lease of the held lock alive, closing leaderLostCh
+// once it has been lost. The session's own keepalive gives up as soon as its
+// keepalive stream ends, which happens on transient etcd or network errors
+// even though the lease, and so the lock, remains valid on the server. Here
+// the keepalive is instead re-established for as long as the lease has not
+// expired, so that leadership is only given up when the lock is really lost.
+func (c *EtcdLock) monitorLease(ctx context.Context, leaseID clientv3.LeaseID, leaderLostCh chan struct{}) {
+	defer close(leaderLostCh)
+
+	expires := time.Now().Add(c.timeout)
+	for {
+		keepAliveCh, err := c.etcd.KeepAlive(ctx, leaseID)
+		if err == nil {
+			for resp := range keepAliveCh {
+				expires = time.Now().Add(time.Duration(resp.TTL) * time.Second)
+			}
+		}
+		if ctx.Err() != nil {
+			// The lock was released.
+			return
+		}
+
+		ttl, err := c.leaseTTL(ctx, leaseID, expires)
+		if err != nil {
+			if ctx.Err() == nil && c.logger != nil {
+				c.logger.Warn("lost etcd lock lease", "error", err)
+			}
+			return
+		}
+		expires = time.Now().Add(ttl)
+
+		if c.logger != nil {
+			c.logger.Debug("re-establishing etcd lock lease keepalive", "ttl", ttl)
+		}
+	}
+}
+
+// leaseTTL returns the remaining TTL of the lease, retrying transient errors
+// until the lease would have expired.
+func (c *EtcdLock) leaseTTL(ctx context.Context, leaseID clientv3.LeaseID, expires time.Time) (time.Duration, error) {
+	retry := time.NewTimer(0)
+	defer retry.Stop()
+
+	var lastErr error
+	for {
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-retry.C:
+		}
+
+		if !time.Now().Before(expires) {
+			if lastErr == nil {
+				lastErr = errors.New("lease expired")
+			}
+			return 0, lastErr
+		}
+
+		tctx, cancel := context.WithTimeout(ctx, c.requestTimeout)
+		resp, err := c.etcd.TimeToLive(tctx, leaseID)
+		cancel()
+		switch {
+		case err != nil:
+			lastErr = err
+		case resp.TTL <= 0:
+			return 0, errors.New("lease expired")
+		default:
+			return time.Duration(resp.TTL) * time.Second, nil
+		}
+
+		retry.Reset(lockRetryInterval)
+	}
+}
@@ -43,4 +43,5 @@ func TestEtcd3Backend(t *testing.T) {
 	physical.ExerciseBackend(t, b)
 	physical.ExerciseBackend_ListPrefix(t, b)
 	physical.ExerciseHABackend(t, b.(physical.HABackend), b2.(physical.HABackend))
+	physical.ExerciseTransactionalBackend(t, b)
 }
@@ -0,0 +1,114 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package etcd
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+)
+
+const spiffeScheme = "spiffe"
+
+// configureSPIFFEVerification configures tlscfg to authenticate etcd servers by
+// their SPIFFE ID rather than by hostname, as SPIFFE X.509 SVIDs identify the
+// workload using a URI SAN and commonly carry no DNS names. The expected ID
+// may be a full SPIFFE ID, which must match exactly, or just a trust domain
+// (spiffe://example.org), which accepts any workload within it.
+//
+// The CA bundle is re-read from caFile on every handshake so that rotated
+// trust bundles are picked up without a restart; client certificates are
+// already re-read on every handshake by the etcd transport.
+func configureSPIFFEVerification(tlscfg *tls.Config, caFile, expectedID string) error {
+	expected, err := parseSPIFFEID(expectedID)
+	if err != nil {
+		return fmt.Errorf("value [%v] of 'tls_server_spiffe_id' could not be understood: %w", expectedID, err)
+	}
+
+	// Hostname verification is replaced by the SPIFFE ID check below, which
+	// also performs the chain verification that this disables.
+	tlscfg.InsecureSkipVerify = true
+	tlscfg.VerifyPeerCertificate = func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		return verifySPIFFEPeer(rawCerts, caFile, expected)
+	}
+
+	return nil
+}
+
+func parseSPIFFEID(id string) (*url.URL, error) {
+	u, err := url.Parse(id)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != spiffeScheme || u.Host == "" {
+		return nil, errors.New("must be a SPIFFE ID or trust domain of the form spiffe://<trust domain>[/<path>]")
+	}
+	if u.User != nil || u.Port() != "" || u.RawQuery != "" || u.Fragment != "" {
+		return nil, errors.New("must not contain a user, port, query or fragment")
+	}
+	return u, nil
+}
+
+func verifySPIFFEPeer(rawCerts [][]byte, caFile string, expected *url.URL) error {
+	if len(rawCerts) == 0 {
+		return errors.New("etcd server presented no certificate")
+	}
+
+	certs := make([]*x509.Certificate, 0, len(rawCerts))
+	for _, raw := range rawCerts {
+		cert, err := x509.ParseCertificate(raw)
+		if err != nil {
+			return fmt.Errorf("failed to parse etcd server certificate: %w", err)
+		}
+		certs = append(certs, cert)
+	}
+
+	caPEM, err := os.ReadFile(caFile)
+	if err != nil {
+		return fmt.Errorf("failed to read etcd CA bundle: %w", err)
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(caPEM) {
+		return errors.New("etcd CA bundle contains no certificates")
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	leaf := certs[0]
+	if _, err := leaf.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}); err != nil {
+		return fmt.Errorf("failed to verify etcd server certificate: %w", err)
+	}
+
+	// An X.509 SVID contains exactly one URI SAN, its SPIFFE ID.
+	var ids []*url.URL
+	for _, uri := range leaf.URIs {
+		if uri.Scheme == spiffeScheme {
+			ids = append(ids, uri)
+		}
+	}
+	if len(ids) != 1 {
+		return fmt.Errorf("etcd server certificate must contain exactly one SPIFFE ID, found %d", len(ids))
+	}
+
+	id := ids[0]
+	switch {
+	case !strings.EqualFold(id.Host, expected.Host):
+		return fmt.Errorf("etcd server SPIFFE ID %q is not in trust domain %q", id.String(), expected.Host)
+	case strings.TrimSuffix(expected.Path, "/") != "" && id.Path != expected.Path:
+		return fmt.Errorf("etcd server SPIFFE ID %q does not match %q", id.String(), expected.String())
+	}
+
+	return nil
+}
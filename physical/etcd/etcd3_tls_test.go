@@ -0,0 +1,89 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package etcd
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestVerifySPIFFEPeer verifies that etcd servers are authenticated by the
+// SPIFFE ID in their certificate, against a CA bundle read from disk.
+func TestVerifySPIFFEPeer(t *testing.T) {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	caTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "ca"},
+		NotBefore:             time.Now().Add(-time.Minute),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTmpl, caTmpl, &caKey.PublicKey, caKey)
+	require.NoError(t, err)
+	ca, err := x509.ParseCertificate(caDER)
+	require.NoError(t, err)
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER}), 0o600))
+
+	leafKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	spiffeID, err := url.Parse("spiffe://example.org/etcd/server")
+	require.NoError(t, err)
+	leafDER, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		URIs:         []*url.URL{spiffeID},
+	}, ca, &leafKey.PublicKey, caKey)
+	require.NoError(t, err)
+
+	for expected, valid := range map[string]bool{
+		"spiffe://example.org/etcd/server": true,
+		"spiffe://example.org":             true,
+		"spiffe://example.org/etcd/other":  false,
+		"spiffe://other.org":               false,
+	} {
+		id, err := parseSPIFFEID(expected)
+		require.NoError(t, err)
+
+		err = verifySPIFFEPeer([][]byte{leafDER}, caFile, id)
+		if valid {
+			require.NoError(t, err, expected)
+		} else {
+			require.Error(t, err, expected)
+		}
+	}
+
+	// A certificate from an untrusted CA must be rejected
+	otherCAKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	otherCADER, err := x509.CreateCertificate(rand.Reader, caTmpl, caTmpl, &otherCAKey.PublicKey, otherCAKey)
+	require.NoError(t, err)
+	otherCAFile := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(otherCAFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: otherCADER}), 0o600))
+	id, err := parseSPIFFEID("spiffe://example.org")
+	require.NoError(t, err)
+	require.Error(t, verifySPIFFEPeer([][]byte{leafDER}, otherCAFile, id))
+
+	_, err = parseSPIFFEID("https://example.org")
+	require.Error(t, err)
+}
@@ -75,12 +75,15 @@ vault secrets enable "consul"
 vault secrets enable "database"
 vault secrets enable "gcp"
 vault secrets enable "gcpkms"
+vault secrets enable "gpg"
 vault secrets enable "kubernetes"
 vault secrets enable -path="kv-v1/" -version=1 "kv"
 vault secrets enable -path="kv-v2/" -version=2 "kv"
 vault secrets enable "ldap"
 vault secrets enable "mongodbatlas"
 vault secrets enable "nomad"
+vault secrets enable "notary"
+vault secrets enable "oauth2"
 vault secrets enable "pki"
 vault secrets enable "rabbitmq"
 vault secrets enable "ssh"
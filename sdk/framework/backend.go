@@ -240,11 +240,14 @@ func (b *Backend) HandleRequest(ctx context.Context, req *logical.Request) (*log
 	// Build up the data for the route, with the URL taking priority
 	// for the fields over the PUT data.
 	raw := make(map[string]interface{}, len(path.Fields))
-	var ignored []string
+	var ignored, deprecated []string
 	for k, v := range req.Data {
 		raw[k] = v
-		if !path.TakesArbitraryInput && path.Fields[k] == nil {
+		switch field := path.Fields[k]; {
+		case field == nil && !path.TakesArbitraryInput:
 			ignored = append(ignored, k)
+		case field != nil && field.Deprecated:
+			deprecated = append(deprecated, k)
 		}
 	}
 
@@ -328,11 +331,30 @@ func (b *Backend) HandleRequest(ctx context.Context, req *logical.Request) (*log
 		if len(replaced) != 0 {
 			resp.AddWarning(fmt.Sprintf("Endpoint replaced the value of these parameters with the values captured from the endpoint's path: %v", replaced))
 		}
+		// If deprecated fields were supplied and the handler did not already
+		// warn about them itself, add a coded warning naming them so that
+		// clients can detect the use of deprecated parameters.
+		sort.Strings(deprecated)
+
+		if len(deprecated) != 0 && !hasWarningCode(resp, logical.WarningCodeDeprecatedParameter) {
+			resp.AddWarningWithCode(logical.WarningCodeDeprecatedParameter, fmt.Sprintf("Endpoint received these deprecated parameters: %v", deprecated))
+		}
 	}
 
 	return resp, nil
 }
 
+// hasWarningCode returns whether resp already carries a warning with the
+// given code.
+func hasWarningCode(resp *logical.Response, code logical.WarningCode) bool {
+	for _, w := range resp.WarningsDetail {
+		if w != nil && w.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
 // HandlePatchOperation acts as an abstraction for performing JSON merge patch
 // operations (see https://datatracker.ietf.org/doc/html/rfc7396) for HTTP
 // PATCH requests. It is responsible for properly processing and marshalling
@@ -785,6 +807,16 @@ type FieldSchema struct {
 	// dynamic UI generation.
 	AllowedValues []interface{}
 
+	// Minimum and Maximum optionally bound the value of integer and float
+	// fields, and MinLength and MaxLength the length of string fields. Like
+	// AllowedValues, these constraints are not enforced by the framework, but
+	// are output as part of OpenAPI generation so that clients can validate
+	// requests before sending them.
+	Minimum   *int
+	Maximum   *int
+	MinLength int
+	MaxLength int
+
 	// DisplayAttrs provides hints for UI and documentation generators. They
 	// will be included in OpenAPI output if set.
 	DisplayAttrs *DisplayAttributes
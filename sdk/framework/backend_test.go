@@ -97,6 +97,58 @@ func TestBackendHandleRequestFieldWarnings(t *testing.T) {
 	require.True(t, strutil.StrListContains(resp.Warnings, "Endpoint replaced the value of these parameters with the values captured from the endpoint's path: [name]"))
 }
 
+func TestBackendHandleRequestDeprecatedFieldWarnings(t *testing.T) {
+	var handlerWarns bool
+	handler := func(ctx context.Context, req *logical.Request, data *FieldData) (*logical.Response, error) {
+		resp := &logical.Response{}
+		if handlerWarns {
+			resp.AddWarningWithCode(logical.WarningCodeDeprecatedParameter, "use new_name instead of old_name")
+		}
+		return resp, nil
+	}
+
+	backend := &Backend{
+		Paths: []*Path{
+			{
+				Pattern: "foo",
+				Fields: map[string]*FieldSchema{
+					"new_name": {Type: TypeString},
+					"old_name": {Type: TypeString, Deprecated: true},
+					"old_ttl":  {Type: TypeDurationSecond, Deprecated: true},
+				},
+				Operations: map[logical.Operation]OperationHandler{
+					logical.UpdateOperation: &PathOperation{Callback: handler},
+				},
+			},
+		},
+	}
+	req := func() *logical.Request {
+		return &logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      "foo",
+			Data: map[string]interface{}{
+				"new_name": "a",
+				"old_name": "b",
+				"old_ttl":  10,
+			},
+		}
+	}
+
+	resp, err := backend.HandleRequest(context.Background(), req())
+	require.NoError(t, err)
+	require.Equal(t, []*logical.WarningDetail{{
+		Code:    logical.WarningCodeDeprecatedParameter,
+		Message: "Endpoint received these deprecated parameters: [old_name old_ttl]",
+	}}, resp.WarningsDetail)
+
+	// Handlers that explain the deprecation themselves are not warned twice
+	handlerWarns = true
+	resp, err = backend.HandleRequest(context.Background(), req())
+	require.NoError(t, err)
+	require.Len(t, resp.Warnings, 1)
+	require.Equal(t, "use new_name instead of old_name", resp.Warnings[0])
+}
+
 func TestBackendHandleRequest(t *testing.T) {
 	callback := func(ctx context.Context, req *logical.Request, data *FieldData) (*logical.Response, error) {
 		return &logical.Response{
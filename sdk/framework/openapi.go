@@ -135,6 +135,12 @@ type OASOperation struct {
 	RequestBody *OASRequestBody      `json:"requestBody,omitempty"`
 	Responses   map[int]*OASResponse `json:"responses"`
 	Deprecated  bool                 `json:"deprecated,omitempty"`
+
+	// RequiredCapabilities lists the policy capabilities which allow the
+	// operation; either of "create" and "update" is required for operations
+	// which create or update depending on whether the target exists, and
+	// "sudo" is required in addition to the others.
+	RequiredCapabilities []string `json:"x-vault-requiredCapabilities,omitempty" mapstructure:"x-vault-requiredCapabilities"`
 }
 
 type OASParameter struct {
@@ -174,6 +180,10 @@ type OASSchema struct {
 	Format     string        `json:"format,omitempty"`
 	Pattern    string        `json:"pattern,omitempty"`
 	Enum       []interface{} `json:"enum,omitempty"`
+	Minimum    *int          `json:"minimum,omitempty"`
+	Maximum    *int          `json:"maximum,omitempty"`
+	MinLength  int           `json:"minLength,omitempty"`
+	MaxLength  int           `json:"maxLength,omitempty"`
 	Default    interface{}   `json:"default,omitempty"`
 	Example    interface{}   `json:"example,omitempty"`
 	Deprecated bool          `json:"deprecated,omitempty"`
@@ -311,6 +321,7 @@ func documentPath(p *Path, backend *Backend, requestResponsePrefix string, doc *
 				Required:   true,
 				Deprecated: field.Deprecated,
 			}
+			addFieldConstraints(p.Schema, field)
 			pi.Parameters = append(pi.Parameters, p)
 		}
 
@@ -352,6 +363,7 @@ func documentPath(p *Path, backend *Backend, requestResponsePrefix string, doc *
 			op.Description = props.Description
 			op.Deprecated = props.Deprecated
 			op.OperationID = operationID
+			op.RequiredCapabilities = requiredCapabilities(p, opType, operations, pi.Sudo)
 
 			switch opType {
 			// For the operation types which map to POST/PUT methods, and so allow for request body parameters,
@@ -460,6 +472,7 @@ func documentPath(p *Path, backend *Backend, requestResponsePrefix string, doc *
 						},
 						Deprecated: field.Deprecated,
 					}
+					addFieldConstraints(p.Schema, field)
 					op.Parameters = append(op.Parameters, p)
 				}
 
@@ -542,6 +555,7 @@ func documentPath(p *Path, backend *Backend, requestResponsePrefix string, doc *
 								Type: openapiField.items,
 							}
 						}
+						addFieldConstraints(&p, field)
 						responseSchema.Properties[name] = &p
 					}
 
@@ -629,6 +643,7 @@ func documentPath(p *Path, backend *Backend, requestResponsePrefix string, doc *
 
 				Get: listOperation,
 			}
+			listOperation.RequiredCapabilities = requiredCapabilities(p, logical.ListOperation, operations, listPathItem.Sudo)
 
 			openAPIPath := "/" + path
 			if doc.Paths[openAPIPath] != nil {
@@ -664,10 +679,56 @@ func addFieldToOASSchema(s *OASSchema, name string, field *FieldSchema) {
 			Type: openapiField.items,
 		}
 	}
+	addFieldConstraints(&p, field)
 
 	s.Properties[name] = &p
 }
 
+// addFieldConstraints adds the validation constraints of the field to its
+// schema. Bounds only apply to the types they were declared for: numeric
+// bounds to integer and number fields, and length bounds to string fields.
+func addFieldConstraints(s *OASSchema, field *FieldSchema) {
+	switch s.Type {
+	case "integer", "number":
+		s.Minimum = field.Minimum
+		s.Maximum = field.Maximum
+	case "string":
+		s.MinLength = field.MinLength
+		s.MaxLength = field.MaxLength
+	}
+}
+
+// requiredCapabilities returns the policy capabilities allowing an operation
+// of the given type on the path.
+func requiredCapabilities(p *Path, opType logical.Operation, operations map[logical.Operation]OperationHandler, sudo bool) []string {
+	var caps []string
+	switch opType {
+	case logical.CreateOperation:
+		caps = []string{"create"}
+	case logical.UpdateOperation:
+		// Requests to paths with an existence check are create operations
+		// when the target does not exist yet
+		if p.ExistenceCheck != nil && operations[logical.CreateOperation] != nil {
+			caps = []string{"create", "update"}
+		} else {
+			caps = []string{"update"}
+		}
+	case logical.ReadOperation:
+		caps = []string{"read"}
+	case logical.DeleteOperation:
+		caps = []string{"delete"}
+	case logical.ListOperation:
+		caps = []string{"list"}
+	default:
+		return nil
+	}
+
+	if sudo {
+		caps = append(caps, "sudo")
+	}
+	return caps
+}
+
 // specialPathMatch checks whether the given path matches one of the special
 // paths, taking into account * and + wildcards (e.g. foo/+/bar/*)
 func specialPathMatch(path string, specialPaths []string) bool {
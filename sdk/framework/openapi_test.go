@@ -5,6 +5,7 @@ package framework
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"io/ioutil"
 	"path/filepath"
@@ -18,6 +19,7 @@ import (
 	"github.com/hashicorp/vault/sdk/helper/jsonutil"
 	"github.com/hashicorp/vault/sdk/helper/wrapping"
 	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
 )
 
 func TestOpenAPI_Regex(t *testing.T) {
@@ -639,6 +641,66 @@ func TestOpenAPI_CustomDecoder(t *testing.T) {
 	}
 }
 
+func TestOpenAPI_Constraints(t *testing.T) {
+	minIterations, maxIterations := 1000, 100000
+	p := &Path{
+		Pattern: "roles/" + GenericNameRegex("name"),
+		Fields: map[string]*FieldSchema{
+			"name": {
+				Type:      TypeString,
+				MaxLength: 64,
+			},
+			"iterations": {
+				Type:    TypeInt,
+				Minimum: &minIterations,
+				Maximum: &maxIterations,
+				// Length bounds do not apply to integers
+				MaxLength: 10,
+			},
+			"mode": {
+				Type:          TypeString,
+				AllowedValues: []interface{}{"fast", "slow"},
+				MinLength:     4,
+			},
+		},
+		ExistenceCheck: func(context.Context, *logical.Request, *FieldData) (bool, error) {
+			return false, nil
+		},
+		Operations: map[logical.Operation]OperationHandler{
+			logical.CreateOperation: &PathOperation{},
+			logical.UpdateOperation: &PathOperation{},
+			logical.ReadOperation:   &PathOperation{},
+		},
+	}
+
+	doc := NewOASDocument("version")
+	err := documentPath(p, &Backend{
+		BackendType:  logical.TypeLogical,
+		PathsSpecial: &logical.Paths{Root: []string{"roles/*"}},
+	}, "kv", doc)
+	require.NoError(t, err)
+
+	pi := doc.Paths["/roles/{name}"]
+	require.Equal(t, 64, pi.Parameters[0].Schema.MaxLength)
+	require.Equal(t, []string{"read", "sudo"}, pi.Get.RequiredCapabilities)
+	require.Equal(t, []string{"create", "update", "sudo"}, pi.Post.RequiredCapabilities)
+
+	var request *OASSchema
+	for _, schema := range doc.Components.Schemas {
+		if _, ok := schema.Properties["iterations"]; ok {
+			request = schema
+		}
+	}
+	require.NotNil(t, request)
+	iterations := request.Properties["iterations"]
+	require.Equal(t, &minIterations, iterations.Minimum)
+	require.Equal(t, &maxIterations, iterations.Maximum)
+	require.Zero(t, iterations.MaxLength)
+	mode := request.Properties["mode"]
+	require.Equal(t, []interface{}{"fast", "slow"}, mode.Enum)
+	require.Equal(t, 4, mode.MinLength)
+}
+
 func TestOpenAPI_CleanResponse(t *testing.T) {
 	// Verify that an all-null input results in empty JSON
 	orig := &logical.Response{}
@@ -419,6 +419,16 @@ func GenerateSerialNumber() (*big.Int, error) {
 	return generateSerialNumber(rand.Reader)
 }
 
+// GenerateSerialNumberWithBits generates a serial number suitable for a
+// certificate from the given number of random bits, between
+// MinSerialNumberBits and DefaultSerialNumberBits.
+func GenerateSerialNumberWithBits(bits int) (*big.Int, error) {
+	if bits < MinSerialNumberBits || bits > DefaultSerialNumberBits {
+		return nil, errutil.UserError{Err: fmt.Sprintf("serial numbers must have between %d and %d random bits; got %d", MinSerialNumberBits, DefaultSerialNumberBits, bits)}
+	}
+	return generateSerialNumberWithBits(rand.Reader, bits)
+}
+
 // GenerateSerialNumberWithRandomSource generates a serial number suitable
 // for a certificate with custom entropy.
 func GenerateSerialNumberWithRandomSource(randReader io.Reader) (*big.Int, error) {
@@ -426,7 +436,11 @@ func GenerateSerialNumberWithRandomSource(randReader io.Reader) (*big.Int, error
 }
 
 func generateSerialNumber(randReader io.Reader) (*big.Int, error) {
-	serial, err := rand.Int(randReader, (&big.Int{}).Exp(big.NewInt(2), big.NewInt(159), nil))
+	return generateSerialNumberWithBits(randReader, DefaultSerialNumberBits)
+}
+
+func generateSerialNumberWithBits(randReader io.Reader, bits int) (*big.Int, error) {
+	serial, err := rand.Int(randReader, (&big.Int{}).Exp(big.NewInt(2), big.NewInt(int64(bits)), nil))
 	if err != nil {
 		return nil, errutil.InternalError{Err: fmt.Sprintf("error generating serial number: %v", err)}
 	}
@@ -550,6 +564,35 @@ func AddPolicyIdentifiers(data *CreationBundle, certTemplate *x509.Certificate)
 	}
 }
 
+// AddExtensions adds the custom extensions, based on CreationBundle
+func AddExtensions(data *CreationBundle, certTemplate *x509.Certificate) {
+	certTemplate.ExtraExtensions = append(certTemplate.ExtraExtensions, data.Params.Extensions...)
+}
+
+// AddNameConstraints adds the name constraints extension, marked critical,
+// based on CreationBundle
+func AddNameConstraints(data *CreationBundle, certTemplate *x509.Certificate) {
+	certTemplate.PermittedDNSDomains = data.Params.PermittedDNSDomains
+	certTemplate.ExcludedDNSDomains = data.Params.ExcludedDNSDomains
+	certTemplate.PermittedIPRanges = data.Params.PermittedIPRanges
+	certTemplate.ExcludedIPRanges = data.Params.ExcludedIPRanges
+	certTemplate.PermittedEmailAddresses = data.Params.PermittedEmailAddresses
+	certTemplate.ExcludedEmailAddresses = data.Params.ExcludedEmailAddresses
+	certTemplate.PermittedURIDomains = data.Params.PermittedURIDomains
+	certTemplate.ExcludedURIDomains = data.Params.ExcludedURIDomains
+
+	// The critical flag applies to the whole extension, which is only
+	// emitted when at least one constraint is present.
+	certTemplate.PermittedDNSDomainsCritical = len(certTemplate.PermittedDNSDomains) > 0 ||
+		len(certTemplate.ExcludedDNSDomains) > 0 ||
+		len(certTemplate.PermittedIPRanges) > 0 ||
+		len(certTemplate.ExcludedIPRanges) > 0 ||
+		len(certTemplate.PermittedEmailAddresses) > 0 ||
+		len(certTemplate.ExcludedEmailAddresses) > 0 ||
+		len(certTemplate.PermittedURIDomains) > 0 ||
+		len(certTemplate.ExcludedURIDomains) > 0
+}
+
 // AddExtKeyUsageOids adds custom extended key usage OIDs to certificate
 func AddExtKeyUsageOids(data *CreationBundle, certTemplate *x509.Certificate) {
 	for _, oidstr := range data.Params.ExtKeyUsageOIDs {
@@ -889,11 +932,20 @@ func selectSignatureAlgorithmForRSA(data *CreationBundle) x509.SignatureAlgorith
 	}
 }
 
+// generateSerialNumberForParams generates a serial number of the requested
+// SerialNumberBits, if any.
+func generateSerialNumberForParams(params *CreationParameters) (*big.Int, error) {
+	if params.SerialNumberBits == 0 {
+		return GenerateSerialNumber()
+	}
+	return GenerateSerialNumberWithBits(params.SerialNumberBits)
+}
+
 func createCertificate(data *CreationBundle, randReader io.Reader, privateKeyGenerator KeyGenerator) (*ParsedCertBundle, error) {
 	var err error
 	result := &ParsedCertBundle{}
 
-	serialNumber, err := GenerateSerialNumber()
+	serialNumber, err := generateSerialNumberForParams(data.Params)
 	if err != nil {
 		return nil, err
 	}
@@ -938,10 +990,7 @@ func createCertificate(data *CreationBundle, randReader io.Reader, privateKeyGen
 	}
 
 	// This will only be filled in from the generation paths
-	if len(data.Params.PermittedDNSDomains) > 0 {
-		certTemplate.PermittedDNSDomains = data.Params.PermittedDNSDomains
-		certTemplate.PermittedDNSDomainsCritical = true
-	}
+	AddNameConstraints(data, certTemplate)
 
 	AddPolicyIdentifiers(data, certTemplate)
 
@@ -949,6 +998,8 @@ func createCertificate(data *CreationBundle, randReader io.Reader, privateKeyGen
 
 	AddExtKeyUsageOids(data, certTemplate)
 
+	AddExtensions(data, certTemplate)
+
 	certTemplate.IssuingCertificateURL = data.Params.URLs.IssuingCertificates
 	certTemplate.CRLDistributionPoints = data.Params.URLs.CRLDistributionPoints
 	certTemplate.OCSPServer = data.Params.URLs.OCSPServers
@@ -1128,7 +1179,13 @@ func createCSR(data *CreationBundle, addBasicConstraints bool, randReader io.Rea
 			IsCA       bool `asn1:"optional"`
 			MaxPathLen int  `asn1:"optional,default:-1"`
 		}
-		val, err := asn1.Marshal(basicConstraints{IsCA: true, MaxPathLen: -1})
+		// A path length constraint is only requested when the parameters
+		// are explicitly those of a CA.
+		maxPathLen := -1
+		if data.Params.IsCA {
+			maxPathLen = data.Params.MaxPathLength
+		}
+		val, err := asn1.Marshal(basicConstraints{IsCA: true, MaxPathLen: maxPathLen})
 		if err != nil {
 			return nil, errutil.InternalError{Err: errwrap.Wrapf("error marshaling basic constraints: {{err}}", err).Error()}
 		}
@@ -1252,7 +1309,7 @@ func signCertificate(data *CreationBundle, randReader io.Reader) (*ParsedCertBun
 
 	result := &ParsedCertBundle{}
 
-	serialNumber, err := GenerateSerialNumber()
+	serialNumber, err := generateSerialNumberForParams(data.Params)
 	if err != nil {
 		return nil, err
 	}
@@ -1327,6 +1384,8 @@ func signCertificate(data *CreationBundle, randReader io.Reader) (*ParsedCertBun
 
 	AddExtKeyUsageOids(data, certTemplate)
 
+	AddExtensions(data, certTemplate)
+
 	var certBytes []byte
 
 	certTemplate.IssuingCertificateURL = data.Params.URLs.IssuingCertificates
@@ -1351,10 +1410,7 @@ func signCertificate(data *CreationBundle, randReader io.Reader) (*ParsedCertBun
 		certTemplate.IsCA = false
 	}
 
-	if len(data.Params.PermittedDNSDomains) > 0 {
-		certTemplate.PermittedDNSDomains = data.Params.PermittedDNSDomains
-		certTemplate.PermittedDNSDomainsCritical = true
-	}
+	AddNameConstraints(data, certTemplate)
 
 	certBytes, err = x509.CreateCertificate(randReader, certTemplate, caCert, data.CSR.PublicKey, data.SigningBundle.PrivateKey)
 	if err != nil {
@@ -1791,7 +1847,14 @@ func ParseCertificateToCreationParameters(certificate x509.Certificate) (creatio
 		// The following two values are on creation parameters, but are impossible to parse from the certificate
 		// ForceAppendCaChain
 		// UseCSRValues
-		PermittedDNSDomains: certificate.PermittedDNSDomains,
+		PermittedDNSDomains:     certificate.PermittedDNSDomains,
+		ExcludedDNSDomains:      certificate.ExcludedDNSDomains,
+		PermittedIPRanges:       certificate.PermittedIPRanges,
+		ExcludedIPRanges:        certificate.ExcludedIPRanges,
+		PermittedEmailAddresses: certificate.PermittedEmailAddresses,
+		ExcludedEmailAddresses:  certificate.ExcludedEmailAddresses,
+		PermittedURIDomains:     certificate.PermittedURIDomains,
+		ExcludedURIDomains:      certificate.ExcludedURIDomains,
 		// URLs: punting on this for now
 		MaxPathLength:     certificate.MaxPathLen,
 		NotBeforeDuration: time.Now().Sub(certificate.NotBefore), // Assumes Certificate was created this moment
@@ -1933,28 +1996,35 @@ func ParseCertificateToFields(certificate x509.Certificate) (map[string]interfac
 	}
 
 	templateData := map[string]interface{}{
-		"common_name":           certificate.Subject.CommonName,
-		"alt_names":             MakeAltNamesCommaSeparatedString(certificate.DNSNames, certificate.EmailAddresses),
-		"ip_sans":               MakeIpAddressCommaSeparatedString(certificate.IPAddresses),
-		"uri_sans":              MakeUriCommaSeparatedString(certificate.URIs),
-		"other_sans":            otherSans,
-		"signature_bits":        FindSignatureBits(certificate.SignatureAlgorithm),
-		"exclude_cn_from_sans":  DetermineExcludeCnFromCertSans(certificate),
-		"ou":                    makeCommaSeparatedString(certificate.Subject.OrganizationalUnit),
-		"organization":          makeCommaSeparatedString(certificate.Subject.Organization),
-		"country":               makeCommaSeparatedString(certificate.Subject.Country),
-		"locality":              makeCommaSeparatedString(certificate.Subject.Locality),
-		"province":              makeCommaSeparatedString(certificate.Subject.Province),
-		"street_address":        makeCommaSeparatedString(certificate.Subject.StreetAddress),
-		"postal_code":           makeCommaSeparatedString(certificate.Subject.PostalCode),
-		"serial_number":         certificate.Subject.SerialNumber,
-		"ttl":                   (certificate.NotAfter.Sub(certificate.NotBefore)).String(),
-		"max_path_length":       certificate.MaxPathLen,
-		"permitted_dns_domains": strings.Join(certificate.PermittedDNSDomains, ","),
-		"use_pss":               IsPSS(certificate.SignatureAlgorithm),
-		"skid":                  hex.EncodeToString(certificate.SubjectKeyId),
-		"key_type":              GetKeyType(certificate.PublicKeyAlgorithm.String()),
-		"key_bits":              FindBitLength(certificate.PublicKey),
+		"common_name":               certificate.Subject.CommonName,
+		"alt_names":                 MakeAltNamesCommaSeparatedString(certificate.DNSNames, certificate.EmailAddresses),
+		"ip_sans":                   MakeIpAddressCommaSeparatedString(certificate.IPAddresses),
+		"uri_sans":                  MakeUriCommaSeparatedString(certificate.URIs),
+		"other_sans":                otherSans,
+		"signature_bits":            FindSignatureBits(certificate.SignatureAlgorithm),
+		"exclude_cn_from_sans":      DetermineExcludeCnFromCertSans(certificate),
+		"ou":                        makeCommaSeparatedString(certificate.Subject.OrganizationalUnit),
+		"organization":              makeCommaSeparatedString(certificate.Subject.Organization),
+		"country":                   makeCommaSeparatedString(certificate.Subject.Country),
+		"locality":                  makeCommaSeparatedString(certificate.Subject.Locality),
+		"province":                  makeCommaSeparatedString(certificate.Subject.Province),
+		"street_address":            makeCommaSeparatedString(certificate.Subject.StreetAddress),
+		"postal_code":               makeCommaSeparatedString(certificate.Subject.PostalCode),
+		"serial_number":             certificate.Subject.SerialNumber,
+		"ttl":                       (certificate.NotAfter.Sub(certificate.NotBefore)).String(),
+		"max_path_length":           certificate.MaxPathLen,
+		"permitted_dns_domains":     strings.Join(certificate.PermittedDNSDomains, ","),
+		"excluded_dns_domains":      strings.Join(certificate.ExcludedDNSDomains, ","),
+		"permitted_ip_ranges":       MakeIpRangeCommaSeparatedString(certificate.PermittedIPRanges),
+		"excluded_ip_ranges":        MakeIpRangeCommaSeparatedString(certificate.ExcludedIPRanges),
+		"permitted_email_addresses": strings.Join(certificate.PermittedEmailAddresses, ","),
+		"excluded_email_addresses":  strings.Join(certificate.ExcludedEmailAddresses, ","),
+		"permitted_uri_domains":     strings.Join(certificate.PermittedURIDomains, ","),
+		"excluded_uri_domains":      strings.Join(certificate.ExcludedURIDomains, ","),
+		"use_pss":                   IsPSS(certificate.SignatureAlgorithm),
+		"skid":                      hex.EncodeToString(certificate.SubjectKeyId),
+		"key_type":                  GetKeyType(certificate.PublicKeyAlgorithm.String()),
+		"key_bits":                  FindBitLength(certificate.PublicKey),
 	}
 
 	return templateData, nil
@@ -1994,6 +2064,14 @@ func MakeIpAddressCommaSeparatedString(addresses []net.IP) string {
 	return strings.Join(stringAddresses, ",")
 }
 
+func MakeIpRangeCommaSeparatedString(ranges []*net.IPNet) string {
+	stringRanges := make([]string, len(ranges))
+	for i, ipRange := range ranges {
+		stringRanges[i] = ipRange.String()
+	}
+	return strings.Join(stringRanges, ",")
+}
+
 func makeCommaSeparatedString(values []string) string {
 	return strings.Join(values, ",")
 }
@@ -39,6 +39,15 @@ const (
 	PrivateKeyTypeP521 = "p521"
 )
 
+// Bounds on the number of random bits of generated serial numbers. At least
+// 64 bits are required by the CA/Browser Forum Baseline Requirements, while
+// 159 bits keep the DER encoding, with its sign bit, within the 20 octets
+// allowed by RFC 5280.
+const (
+	MinSerialNumberBits     = 64
+	DefaultSerialNumberBits = 159
+)
+
 // This can be one of a few key types so the different params may or may not be filled
 type ClusterKeyParams struct {
 	Type string   `json:"type" structs:"type" mapstructure:"type"`
@@ -804,9 +813,19 @@ type CreationParameters struct {
 	UsePSS                        bool
 	ForceAppendCaChain            bool
 
+	// Custom extensions to add to the certificate as-is
+	Extensions []pkix.Extension
+
 	// Only used when signing a CA cert
-	UseCSRValues        bool
-	PermittedDNSDomains []string
+	UseCSRValues            bool
+	PermittedDNSDomains     []string
+	ExcludedDNSDomains      []string
+	PermittedIPRanges       []*net.IPNet
+	ExcludedIPRanges        []*net.IPNet
+	PermittedEmailAddresses []string
+	ExcludedEmailAddresses  []string
+	PermittedURIDomains     []string
+	ExcludedURIDomains      []string
 
 	// URLs to encode into the certificate
 	URLs *URLEntries
@@ -820,6 +839,10 @@ type CreationParameters struct {
 	// The explicit SKID to use; especially useful for cross-signing.
 	SKID []byte
 
+	// The number of random bits of the serial number; DefaultSerialNumberBits
+	// when zero.
+	SerialNumberBits int
+
 	// Ignore validating the CSR's signature. This should only be enabled if the
 	// sender of the CSR has proven proof of possession of the associated
 	// private key by some other means, otherwise keep this set to false.
@@ -0,0 +1,234 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package keysutil
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"fmt"
+	"math/big"
+
+	"github.com/hashicorp/vault/sdk/helper/errutil"
+)
+
+// FF31TweakSize is the size in bytes of the 56-bit tweak of FF3-1.
+const FF31TweakSize = 7
+
+const (
+	ff31Rounds = 8
+	// The domain of FF3-1 must hold at least a million values.
+	ff31MinDomainSize = 1000000
+)
+
+// FF31Encrypt encrypts value with the FF3-1 format-preserving encryption
+// mode of NIST SP 800-38G Rev. 1, using the given key version. The result
+// has the length of value and consists of characters of alphabet only, of
+// which value must consist as well.
+func (p *Policy) FF31Encrypt(ver int, alphabet string, tweak []byte, value string) (string, error) {
+	return p.ff31(ver, alphabet, tweak, value, false)
+}
+
+// FF31Decrypt reverses FF31Encrypt.
+func (p *Policy) FF31Decrypt(ver int, alphabet string, tweak []byte, value string) (string, error) {
+	return p.ff31(ver, alphabet, tweak, value, true)
+}
+
+func (p *Policy) ff31(ver int, alphabet string, tweak []byte, value string, decrypt bool) (string, error) {
+	if !p.Type.FormatPreservingEncryptionSupported() {
+		return "", errutil.UserError{Err: fmt.Sprintf("format-preserving encryption not supported for key type %v", p.Type)}
+	}
+
+	if p.Keys == nil || p.LatestVersion == 0 {
+		return "", errutil.InternalError{Err: "unable to access the key; no key versions found"}
+	}
+
+	if ver == 0 {
+		ver = p.LatestVersion
+	}
+	if ver < 0 || ver > p.LatestVersion {
+		return "", errutil.UserError{Err: "invalid key version"}
+	}
+
+	if len(tweak) != FF31TweakSize {
+		return "", errutil.UserError{Err: fmt.Sprintf("tweak must be %d bytes", FF31TweakSize)}
+	}
+
+	symbols := []rune(alphabet)
+	radix := len(symbols)
+	indices := make(map[rune]int, radix)
+	for i, r := range symbols {
+		if _, ok := indices[r]; ok {
+			return "", errutil.UserError{Err: fmt.Sprintf("alphabet contains %q more than once", r)}
+		}
+		indices[r] = i
+	}
+	minLen, maxLen, err := ff31LengthBounds(radix)
+	if err != nil {
+		return "", errutil.UserError{Err: err.Error()}
+	}
+
+	chars := []rune(value)
+	if len(chars) < minLen || len(chars) > maxLen {
+		return "", errutil.UserError{Err: fmt.Sprintf("value must be between %d and %d characters of the alphabet", minLen, maxLen)}
+	}
+	numerals := make([]int, len(chars))
+	for i, r := range chars {
+		n, ok := indices[r]
+		if !ok {
+			return "", errutil.UserError{Err: fmt.Sprintf("value contains %q, which is not in the alphabet", r)}
+		}
+		numerals[i] = n
+	}
+
+	keyEntry, err := p.safeGetKeyEntry(ver)
+	if err != nil {
+		return "", err
+	}
+	block, err := ff3Cipher(keyEntry.Key)
+	if err != nil {
+		return "", err
+	}
+
+	tweakLeft, tweakRight := ff31SplitTweak(tweak)
+	for i, n := range ff3Rounds(block, radix, tweakLeft, tweakRight, numerals, decrypt) {
+		chars[i] = symbols[n]
+	}
+	return string(chars), nil
+}
+
+// ff31LengthBounds returns the minimum and maximum length of values FF3-1
+// can encrypt for the given radix.
+func ff31LengthBounds(radix int) (int, int, error) {
+	if radix < 2 || radix > 1<<16 {
+		return 0, 0, fmt.Errorf("alphabet must have between 2 and %d characters", 1<<16)
+	}
+
+	bigRadix := big.NewInt(int64(radix))
+	minLen := 2
+	domain := new(big.Int).Exp(bigRadix, big.NewInt(int64(minLen)), nil)
+	for domain.Cmp(big.NewInt(ff31MinDomainSize)) < 0 {
+		domain.Mul(domain, bigRadix)
+		minLen++
+	}
+
+	// The maximum length is twice the number of numerals fitting into the
+	// 96 bits FF3-1 reserves for half of the value.
+	limit := new(big.Int).Lsh(big.NewInt(1), 96)
+	half := 0
+	for power := new(big.Int).Set(bigRadix); power.Cmp(limit) <= 0; power.Mul(power, bigRadix) {
+		half++
+	}
+
+	return minLen, 2 * half, nil
+}
+
+// ff31SplitTweak splits the 56-bit tweak of FF3-1 into the two 32-bit halves
+// used by the rounds of FF3, the middle nibble going to the right one.
+func ff31SplitTweak(tweak []byte) ([]byte, []byte) {
+	return []byte{tweak[0], tweak[1], tweak[2], tweak[3] & 0xF0},
+		[]byte{tweak[4], tweak[5], tweak[6], tweak[3] << 4}
+}
+
+// ff3Cipher returns the AES block cipher FF3 uses for key, whose bytes it
+// takes in reverse order.
+func ff3Cipher(key []byte) (cipher.Block, error) {
+	reversed := make([]byte, len(key))
+	for i, b := range key {
+		reversed[len(key)-1-i] = b
+	}
+	return aes.NewCipher(reversed)
+}
+
+// ff3Rounds runs the Feistel rounds shared by FF3 and FF3-1 over numerals,
+// which are taken least significant first within each half, as FF3 reverses
+// both halves before interpreting them as numbers.
+func ff3Rounds(block cipher.Block, radix int, tweakLeft, tweakRight []byte, numerals []int, decrypt bool) []int {
+	n := len(numerals)
+	u := (n + 1) / 2
+	v := n - u
+
+	a := append([]int{}, numerals[:u]...)
+	b := append([]int{}, numerals[u:]...)
+
+	bigRadix := big.NewInt(int64(radix))
+	modU := new(big.Int).Exp(bigRadix, big.NewInt(int64(u)), nil)
+	modV := new(big.Int).Exp(bigRadix, big.NewInt(int64(v)), nil)
+
+	for r := 0; r < ff31Rounds; r++ {
+		i := r
+		if decrypt {
+			i = ff31Rounds - 1 - r
+		}
+
+		m, mod, w := u, modU, tweakRight
+		if i%2 == 1 {
+			m, mod, w = v, modV, tweakLeft
+		}
+
+		// The round function is keyed on the half left unchanged by this
+		// round: B when encrypting, A when decrypting.
+		in := b
+		if decrypt {
+			in = a
+		}
+
+		var block16 [aes.BlockSize]byte
+		copy(block16[:4], w)
+		block16[3] ^= byte(i)
+		ff3Num(in, bigRadix).FillBytes(block16[4:])
+
+		reverseBytes(block16[:])
+		block.Encrypt(block16[:], block16[:])
+		reverseBytes(block16[:])
+		y := new(big.Int).SetBytes(block16[:])
+
+		out := a
+		if decrypt {
+			out = b
+		}
+		c := ff3Num(out, bigRadix)
+		if decrypt {
+			c.Sub(c, y)
+		} else {
+			c.Add(c, y)
+		}
+		c.Mod(c, mod)
+
+		if decrypt {
+			b, a = a, ff3Str(c, bigRadix, m)
+		} else {
+			a, b = b, ff3Str(c, bigRadix, m)
+		}
+	}
+
+	return append(a, b...)
+}
+
+// ff3Num returns the number whose numerals, least significant first, are x.
+func ff3Num(x []int, radix *big.Int) *big.Int {
+	num := new(big.Int)
+	for i := len(x) - 1; i >= 0; i-- {
+		num.Mul(num, radix)
+		num.Add(num, big.NewInt(int64(x[i])))
+	}
+	return num
+}
+
+// ff3Str returns the m numerals, least significant first, of num.
+func ff3Str(num *big.Int, radix *big.Int, m int) []int {
+	x := make([]int, m)
+	rem := new(big.Int)
+	num = new(big.Int).Set(num)
+	for i := 0; i < m; i++ {
+		num.QuoRem(num, radix, rem)
+		x[i] = int(rem.Int64())
+	}
+	return x
+}
+
+func reverseBytes(b []byte) {
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+}
@@ -0,0 +1,182 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package keysutil
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// Test_FF3Rounds checks the Feistel rounds shared by FF3 and FF3-1 against
+// the FF3 samples of NIST SP 800-38G, which differ from FF3-1 only in the
+// split of their 64-bit tweaks.
+func Test_FF3Rounds(t *testing.T) {
+	tests := []struct {
+		key        string
+		radix      int
+		alphabet   string
+		tweak      string
+		plaintext  string
+		ciphertext string
+	}{
+		{
+			key:        "EF4359D8D580AA4F7F036D6F04FC6A94",
+			radix:      10,
+			alphabet:   "0123456789",
+			tweak:      "D8E7920AFA330A73",
+			plaintext:  "890121234567890000",
+			ciphertext: "750918814058654607",
+		},
+		{
+			key:        "EF4359D8D580AA4F7F036D6F04FC6A94",
+			radix:      10,
+			alphabet:   "0123456789",
+			tweak:      "9A768A92F60E12D8",
+			plaintext:  "890121234567890000",
+			ciphertext: "018989839189395384",
+		},
+		{
+			key:        "EF4359D8D580AA4F7F036D6F04FC6A94",
+			radix:      26,
+			alphabet:   "0123456789abcdefghijklmnop",
+			tweak:      "9A768A92F60E12D8",
+			plaintext:  "0123456789abcdefghi",
+			ciphertext: "g2pk40i992fn20cjakb",
+		},
+	}
+
+	for _, test := range tests {
+		key, _ := hex.DecodeString(test.key)
+		tweak, _ := hex.DecodeString(test.tweak)
+		block, err := ff3Cipher(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		numerals := make([]int, len(test.plaintext))
+		for i, r := range test.plaintext {
+			numerals[i] = strings.IndexRune(test.alphabet, r)
+		}
+
+		encrypted := ff3Rounds(block, test.radix, tweak[:4], tweak[4:], numerals, false)
+		var ciphertext strings.Builder
+		for _, n := range encrypted {
+			ciphertext.WriteByte(test.alphabet[n])
+		}
+		if ciphertext.String() != test.ciphertext {
+			t.Fatalf("bad ciphertext for %s: expected %s, got %s", test.plaintext, test.ciphertext, ciphertext.String())
+		}
+
+		decrypted := ff3Rounds(block, test.radix, tweak[:4], tweak[4:], encrypted, true)
+		for i := range numerals {
+			if decrypted[i] != numerals[i] {
+				t.Fatalf("decrypting %s did not return the plaintext", test.ciphertext)
+			}
+		}
+	}
+}
+
+func Test_FF31SplitTweak(t *testing.T) {
+	key, _ := hex.DecodeString("EF4359D8D580AA4F7F036D6F04FC6A94")
+	tweak, _ := hex.DecodeString("D8E7920AFA330A")
+	block, err := ff3Cipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	numerals := []int{8, 9, 0, 1, 2, 1, 2, 3, 4, 5, 6, 7, 8, 9, 0, 0, 0, 0}
+	expected := []int{4, 7, 7, 0, 6, 4, 1, 8, 5, 1, 2, 4, 3, 5, 4, 6, 6, 2}
+
+	tweakLeft, tweakRight := ff31SplitTweak(tweak)
+	encrypted := ff3Rounds(block, 10, tweakLeft, tweakRight, numerals, false)
+	for i := range expected {
+		if encrypted[i] != expected[i] {
+			t.Fatalf("bad ciphertext: expected %v, got %v", expected, encrypted)
+		}
+	}
+}
+
+func Test_FF31LengthBounds(t *testing.T) {
+	for radix, expected := range map[int][2]int{
+		10:    {6, 56},
+		26:    {5, 40},
+		62:    {4, 32},
+		65536: {2, 12},
+	} {
+		minLen, maxLen, err := ff31LengthBounds(radix)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if minLen != expected[0] || maxLen != expected[1] {
+			t.Fatalf("bad bounds for radix %d: expected %v, got [%d %d]", radix, expected, minLen, maxLen)
+		}
+	}
+
+	if _, _, err := ff31LengthBounds(1); err == nil {
+		t.Fatal("expected an error for radix 1")
+	}
+}
+
+func TestPolicy_FF31(t *testing.T) {
+	ctx := context.Background()
+	lm, _ := NewLockManager(false, 0)
+	p, _, err := lm.GetPolicy(ctx, PolicyRequest{
+		Upsert:  true,
+		Storage: &logical.InmemStorage{},
+		KeyType: KeyType_AES256_FF3_1,
+		Name:    "test",
+	}, rand.Reader)
+	if err != nil {
+		t.Fatalf("failed loading policy: %v", err)
+	}
+	p.Unlock()
+
+	const alphabet = "0123456789"
+	tweak := []byte{1, 2, 3, 4, 5, 6, 7}
+
+	encrypted, err := p.FF31Encrypt(0, alphabet, tweak, "4111111111111111")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(encrypted) != 16 || strings.Trim(encrypted, alphabet) != "" {
+		t.Fatalf("format of the value was not preserved: %s", encrypted)
+	}
+	if encrypted == "4111111111111111" {
+		t.Fatal("value was not encrypted")
+	}
+
+	decrypted, err := p.FF31Decrypt(1, alphabet, tweak, encrypted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decrypted != "4111111111111111" {
+		t.Fatalf("expected the plaintext, got %s", decrypted)
+	}
+
+	// A different tweak gives a different encryption.
+	other, err := p.FF31Encrypt(0, alphabet, []byte{7, 6, 5, 4, 3, 2, 1}, "4111111111111111")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other == encrypted {
+		t.Fatal("expected the tweak to change the encryption")
+	}
+
+	for _, value := range []string{"12345", "123456789012345678901234567890123456789012345678901234567", "12a456"} {
+		if _, err := p.FF31Encrypt(0, alphabet, tweak, value); err == nil {
+			t.Fatalf("expected an error encrypting %s", value)
+		}
+	}
+	if _, err := p.FF31Encrypt(0, alphabet, tweak[:6], "123456"); err == nil {
+		t.Fatal("expected an error with a short tweak")
+	}
+	if _, err := p.FF31Encrypt(2, alphabet, tweak, "123456"); err == nil {
+		t.Fatal("expected an error with a missing key version")
+	}
+}
@@ -397,7 +397,7 @@ func (lm *LockManager) GetPolicy(ctx context.Context, req PolicyRequest, rand io
 				return nil, false, fmt.Errorf("key derivation and convergent encryption not supported for keys of type %v", req.KeyType)
 			}
 
-		case KeyType_AES128_CMAC, KeyType_AES256_CMAC:
+		case KeyType_AES128_CMAC, KeyType_AES256_CMAC, KeyType_AES256_FF3_1:
 			if req.Derived || req.Convergent {
 				cleanup()
 				return nil, false, fmt.Errorf("key derivation and convergent encryption not supported for keys of type %v", req.KeyType)
@@ -8,7 +8,11 @@ package keysutil
 import (
 	"context"
 	"errors"
+	"strconv"
+	"time"
 
+	wrapping "github.com/hashicorp/go-kms-wrapping/v2"
+	"github.com/hashicorp/vault/sdk/helper/errutil"
 	"github.com/hashicorp/vault/sdk/logical"
 )
 
@@ -20,12 +24,37 @@ type ManagedKeyParameters struct {
 
 var errEntOnly = errors.New("managed keys are supported within enterprise edition only")
 
+// decryptWithManagedKey and encryptWithManagedKey are only supported when the
+// backend provides a system view resolving the key, such as the KMS-backed
+// keys of transit.
 func (p *Policy) decryptWithManagedKey(params ManagedKeyParameters, keyEntry KeyEntry, ciphertext []byte, nonce []byte, aad []byte) (plaintext []byte, err error) {
-	return nil, errEntOnly
+	if params.ManagedKeySystemView == nil {
+		return nil, errEntOnly
+	}
+	if len(nonce) != 0 {
+		return nil, errutil.UserError{Err: "cannot provide a nonce to managed-key backed decryption"}
+	}
+
+	err = params.ManagedKeySystemView.WithManagedEncryptingKeyByUUID(params.Context, keyEntry.ManagedKeyUUID, params.BackendUUID, func(ctx context.Context, key logical.ManagedEncryptingKey) error {
+		plaintext, err = key.Decrypt(ctx, ciphertext, wrapping.WithAad(aad))
+		return err
+	})
+	return plaintext, err
 }
 
 func (p *Policy) encryptWithManagedKey(params ManagedKeyParameters, keyEntry KeyEntry, plaintext []byte, nonce []byte, aad []byte) (ciphertext []byte, err error) {
-	return nil, errEntOnly
+	if params.ManagedKeySystemView == nil {
+		return nil, errEntOnly
+	}
+	if len(nonce) != 0 {
+		return nil, errutil.UserError{Err: "cannot use convergent encryption or provide a nonce to managed-key backed encryption"}
+	}
+
+	err = params.ManagedKeySystemView.WithManagedEncryptingKeyByUUID(params.Context, keyEntry.ManagedKeyUUID, params.BackendUUID, func(ctx context.Context, key logical.ManagedEncryptingKey) error {
+		ciphertext, err = key.Encrypt(ctx, plaintext, wrapping.WithAad(aad))
+		return err
+	})
+	return ciphertext, err
 }
 
 func (p *Policy) signWithManagedKey(options *SigningOptions, keyEntry KeyEntry, input []byte) (sig []byte, err error) {
@@ -40,6 +69,44 @@ func (p *Policy) HMACWithManagedKey(ctx context.Context, ver int, managedKeySyst
 	return nil, errEntOnly
 }
 
-func (p *Policy) RotateManagedKey(ctx context.Context, storage logical.Storage, managedKeyUUID string) error {
-	return errEntOnly
+// RotateManagedKey adds a new version to the policy, backed by the given
+// managed key.
+func (p *Policy) RotateManagedKey(ctx context.Context, storage logical.Storage, managedKeyUUID string) (retErr error) {
+	if managedKeyUUID == "" {
+		return errEntOnly
+	}
+
+	priorLatestVersion := p.LatestVersion
+	priorMinDecryptionVersion := p.MinDecryptionVersion
+	var priorKeys keyEntryMap
+	if p.Keys != nil {
+		priorKeys = keyEntryMap{}
+		for k, v := range p.Keys {
+			priorKeys[k] = v
+		}
+	}
+
+	defer func() {
+		if retErr != nil {
+			p.LatestVersion = priorLatestVersion
+			p.MinDecryptionVersion = priorMinDecryptionVersion
+			p.Keys = priorKeys
+		}
+	}()
+
+	now := time.Now()
+	p.LatestVersion += 1
+	if p.Keys == nil {
+		p.Keys = keyEntryMap{}
+	}
+	p.Keys[strconv.Itoa(p.LatestVersion)] = KeyEntry{
+		ManagedKeyUUID:         managedKeyUUID,
+		CreationTime:           now,
+		DeprecatedCreationTime: now.Unix(),
+	}
+	if p.MinDecryptionVersion == 0 {
+		p.MinDecryptionVersion = 1
+	}
+
+	return p.Persist(ctx, storage)
 }
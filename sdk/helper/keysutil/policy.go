@@ -15,6 +15,7 @@ import (
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/sha256"
+	"crypto/sha512"
 	"crypto/x509"
 	"encoding/asn1"
 	"encoding/base64"
@@ -42,6 +43,7 @@ import (
 	"golang.org/x/crypto/chacha20poly1305"
 	"golang.org/x/crypto/ed25519"
 	"golang.org/x/crypto/hkdf"
+	"golang.org/x/crypto/pbkdf2"
 )
 
 // Careful with iota; don't put anything before it in this const block because
@@ -54,6 +56,13 @@ const (
 	HmacMaxKeySize = 4096 / 8
 )
 
+// Algorithms supported by DeriveExternalKey
+const (
+	KeyDerivation_HKDF_SHA256   = "hkdf-sha256"
+	KeyDerivation_HKDF_SHA512   = "hkdf-sha512"
+	KeyDerivation_PBKDF2_SHA256 = "pbkdf2-sha256"
+)
+
 // Or this one...we need the default of zero to be the original AES256-GCM96
 const (
 	KeyType_AES256_GCM96 = iota
@@ -70,6 +79,7 @@ const (
 	KeyType_HMAC
 	KeyType_AES128_CMAC
 	KeyType_AES256_CMAC
+	KeyType_AES256_FF3_1
 	// If adding to this list please update allTestKeyTypes in policy_test.go
 )
 
@@ -163,6 +173,16 @@ func (kt KeyType) DerivationSupported() bool {
 	return false
 }
 
+// KeyDerivationSupported reports whether keys of this type may be used as
+// input keying material for HKDF or PBKDF2 derivations.
+func (kt KeyType) KeyDerivationSupported() bool {
+	switch kt {
+	case KeyType_AES128_GCM96, KeyType_AES256_GCM96, KeyType_ChaCha20_Poly1305:
+		return true
+	}
+	return false
+}
+
 func (kt KeyType) AssociatedDataSupported() bool {
 	switch kt {
 	case KeyType_AES128_GCM96, KeyType_AES256_GCM96, KeyType_ChaCha20_Poly1305, KeyType_MANAGED_KEY:
@@ -180,6 +200,15 @@ func (kt KeyType) CMACSupported() bool {
 	}
 }
 
+func (kt KeyType) FormatPreservingEncryptionSupported() bool {
+	switch kt {
+	case KeyType_AES256_FF3_1:
+		return true
+	default:
+		return false
+	}
+}
+
 func (kt KeyType) HMACSupported() bool {
 	switch {
 	case kt.CMACSupported():
@@ -229,6 +258,8 @@ func (kt KeyType) String() string {
 		return "aes128-cmac"
 	case KeyType_AES256_CMAC:
 		return "aes256-cmac"
+	case KeyType_AES256_FF3_1:
+		return "aes256-ff3-1"
 	}
 
 	return "[unknown]"
@@ -470,6 +501,19 @@ type Policy struct {
 	// AllowPlaintextBackup allows taking backup of the policy in plaintext
 	AllowPlaintextBackup bool `json:"allow_plaintext_backup"`
 
+	// AllowDerivation allows keys to be derived from the policy's keys with
+	// HKDF or PBKDF2, for example to build hierarchical key schemes.
+	AllowDerivation bool `json:"allow_derivation"`
+
+	// AllowedDerivationInfo restricts the info which derivations may use to
+	// values starting with one of these prefixes. Any info is allowed when
+	// empty.
+	AllowedDerivationInfo []string `json:"allowed_derivation_info"`
+
+	// AllowDerivedKeyExport allows derived keys to be returned to the caller,
+	// rather than only used internally for encryption and decryption
+	AllowDerivedKeyExport bool `json:"allow_derived_key_export"`
+
 	// VersionTemplate is used to prefix the ciphertext with information about
 	// the key version. It must inclide {{version}} and a delimiter between the
 	// version prefix and the ciphertext.
@@ -904,6 +948,70 @@ func (p *Policy) DeriveKey(context, salt []byte, ver int, numBytes int) ([]byte,
 	}
 }
 
+// DeriveExternalKey derives numBytes of key material from the given key
+// version for use outside of the policy, e.g. to build hierarchical key
+// schemes, using one of the KeyDerivation algorithms. For HKDF, the key is
+// used as the pseudorandom key of HKDF-Expand, or extracted with the salt
+// first when one is given. For PBKDF2, the info is prepended to the salt.
+// This does not check the policy's AllowDerivation flag, which callers are
+// responsible for.
+func (p *Policy) DeriveExternalKey(algorithm string, info, salt []byte, iterations, ver, numBytes int) ([]byte, error) {
+	if !p.Type.KeyDerivationSupported() {
+		return nil, errutil.UserError{Err: fmt.Sprintf("key derivation not supported for key type %v", p.Type)}
+	}
+
+	if p.Derived {
+		return nil, errutil.UserError{Err: "key derivation not supported for derived keys"}
+	}
+
+	if p.Keys == nil || p.LatestVersion == 0 {
+		return nil, errutil.InternalError{Err: "unable to access the key; no key versions found"}
+	}
+
+	if ver <= 0 || ver > p.LatestVersion {
+		return nil, errutil.UserError{Err: "invalid key version"}
+	}
+
+	keyEntry, err := p.safeGetKeyEntry(ver)
+	if err != nil {
+		return nil, err
+	}
+
+	var hashFunc func() hash.Hash
+	switch algorithm {
+	case KeyDerivation_HKDF_SHA256, KeyDerivation_PBKDF2_SHA256:
+		hashFunc = sha256.New
+	case KeyDerivation_HKDF_SHA512:
+		hashFunc = sha512.New
+	default:
+		return nil, errutil.UserError{Err: fmt.Sprintf("unsupported key derivation algorithm %q", algorithm)}
+	}
+
+	switch algorithm {
+	case KeyDerivation_PBKDF2_SHA256:
+		if len(salt) == 0 {
+			return nil, errutil.UserError{Err: "a salt is required for PBKDF2 derivation"}
+		}
+		if iterations <= 0 {
+			return nil, errutil.UserError{Err: "invalid number of PBKDF2 iterations"}
+		}
+		return pbkdf2.Key(keyEntry.Key, append(append([]byte{}, info...), salt...), iterations, numBytes, hashFunc), nil
+
+	default:
+		var reader io.Reader
+		if len(salt) == 0 {
+			reader = hkdf.Expand(hashFunc, keyEntry.Key, info)
+		} else {
+			reader = hkdf.New(hashFunc, keyEntry.Key, salt, info)
+		}
+		derived := make([]byte, numBytes)
+		if _, err := io.ReadFull(reader, derived); err != nil {
+			return nil, errutil.UserError{Err: fmt.Sprintf("error reading derived bytes: %v", err)}
+		}
+		return derived, nil
+	}
+}
+
 func (p *Policy) safeGetKeyEntry(ver int) (KeyEntry, error) {
 	keyVerStr := strconv.Itoa(ver)
 	keyEntry, ok := p.Keys[keyVerStr]
@@ -1544,12 +1652,12 @@ func (p *Policy) ImportPublicOrPrivate(ctx context.Context, storage logical.Stor
 	}
 
 	if ((p.Type == KeyType_AES128_GCM96 || p.Type == KeyType_AES128_CMAC) && len(key) != 16) ||
-		((p.Type == KeyType_AES256_GCM96 || p.Type == KeyType_ChaCha20_Poly1305 || p.Type == KeyType_AES256_CMAC) && len(key) != 32) ||
+		((p.Type == KeyType_AES256_GCM96 || p.Type == KeyType_ChaCha20_Poly1305 || p.Type == KeyType_AES256_CMAC || p.Type == KeyType_AES256_FF3_1) && len(key) != 32) ||
 		(p.Type == KeyType_HMAC && (len(key) < HmacMinKeySize || len(key) > HmacMaxKeySize)) {
 		return fmt.Errorf("invalid key size %d bytes for key type %s", len(key), p.Type)
 	}
 
-	if p.Type == KeyType_AES128_GCM96 || p.Type == KeyType_AES256_GCM96 || p.Type == KeyType_ChaCha20_Poly1305 || p.Type == KeyType_HMAC || p.Type == KeyType_AES128_CMAC || p.Type == KeyType_AES256_CMAC {
+	if p.Type == KeyType_AES128_GCM96 || p.Type == KeyType_AES256_GCM96 || p.Type == KeyType_ChaCha20_Poly1305 || p.Type == KeyType_HMAC || p.Type == KeyType_AES128_CMAC || p.Type == KeyType_AES256_CMAC || p.Type == KeyType_AES256_FF3_1 {
 		entry.Key = key
 		if p.Type == KeyType_HMAC {
 			p.KeySize = len(key)
@@ -1671,7 +1779,7 @@ func (p *Policy) RotateInMemory(randReader io.Reader) (retErr error) {
 
 	var err error
 	switch p.Type {
-	case KeyType_AES128_GCM96, KeyType_AES256_GCM96, KeyType_ChaCha20_Poly1305, KeyType_HMAC, KeyType_AES128_CMAC, KeyType_AES256_CMAC:
+	case KeyType_AES128_GCM96, KeyType_AES256_GCM96, KeyType_ChaCha20_Poly1305, KeyType_HMAC, KeyType_AES128_CMAC, KeyType_AES256_CMAC, KeyType_AES256_FF3_1:
 		// Default to 256 bit key
 		numBytes := 32
 		if p.Type == KeyType_AES128_GCM96 || p.Type == KeyType_AES128_CMAC {
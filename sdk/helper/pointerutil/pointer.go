@@ -32,6 +32,11 @@ func FileModePtr(o os.FileMode) *os.FileMode {
 	return &o
 }
 
+// IntPtr returns a pointer to an int value
+func IntPtr(i int) *int {
+	return &i
+}
+
 // Int64Ptr returns a pointer to an int64 value
 func Int64Ptr(i int64) *int64 {
 	return &i
@@ -71,6 +71,82 @@ var (
 	ErrNotFound = errors.New("not found")
 )
 
+// ErrorCodeKey is the key under which a stable error code is carried in the
+// data of error responses, and in the body of HTTP error responses.
+const ErrorCodeKey = "error_code"
+
+// AuthErrorCode is a stable, documented code describing why a login request
+// failed. Unlike error messages, these values will not change between
+// releases and may be relied upon by automation.
+type AuthErrorCode string
+
+const (
+	// AuthErrorCodeInvalidCredentials indicates the supplied credentials
+	// were not recognized.
+	AuthErrorCodeInvalidCredentials AuthErrorCode = "invalid_credentials"
+
+	// AuthErrorCodeExpiredCredential indicates the supplied credential was
+	// valid but has expired.
+	AuthErrorCodeExpiredCredential AuthErrorCode = "expired_credential"
+
+	// AuthErrorCodeUnauthorizedRole indicates the credentials were valid but
+	// are not permitted to log in with the requested role.
+	AuthErrorCodeUnauthorizedRole AuthErrorCode = "unauthorized_role"
+
+	// AuthErrorCodeUnauthorizedSource indicates the login was attempted from
+	// an address outside of the bound CIDRs.
+	AuthErrorCodeUnauthorizedSource AuthErrorCode = "unauthorized_source"
+
+	// AuthErrorCodeMFARequired indicates the login requires MFA which could
+	// not be requested from the client.
+	AuthErrorCodeMFARequired AuthErrorCode = "mfa_required"
+
+	// AuthErrorCodeMFAFailed indicates the supplied MFA credentials did not
+	// satisfy the MFA enforcement.
+	AuthErrorCodeMFAFailed AuthErrorCode = "mfa_failed"
+
+	// AuthErrorCodeLockedOut indicates the user is locked out after too many
+	// failed login attempts.
+	AuthErrorCodeLockedOut AuthErrorCode = "locked_out"
+)
+
+// AuthError wraps an error returned for a failed login with a stable
+// AuthErrorCode. The wrapped error determines the response status code.
+type AuthError struct {
+	Code AuthErrorCode
+	Err  error
+}
+
+func NewAuthError(code AuthErrorCode, err error) *AuthError {
+	return &AuthError{
+		Code: code,
+		Err:  err,
+	}
+}
+
+func (e *AuthError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *AuthError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorCode returns the stable error code carried by the given response or
+// error, or an empty string if neither carries one.
+func ErrorCode(resp *Response, err error) string {
+	var authErr *AuthError
+	if errors.As(err, &authErr) {
+		return string(authErr.Code)
+	}
+	if resp.IsError() {
+		if code, ok := resp.Data[ErrorCodeKey].(string); ok {
+			return code
+		}
+	}
+	return ""
+}
+
 type DelegatedAuthErrorHandler func(ctx context.Context, initiatingRequest, authRequest *Request, authResponse *Response, err error) (*Response, error)
 
 var _ error = &RequestDelegatedAuthError{}
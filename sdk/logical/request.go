@@ -259,6 +259,13 @@ type Request struct {
 
 	// RequestLimiterDisabled tells whether the request context has Request Limiter applied.
 	RequestLimiterDisabled bool `json:"request_limiter_disabled,omitempty"`
+
+	// ClientName and ClientVersion identify the application making the
+	// request, as supplied in the X-Vault-Client-Name and
+	// X-Vault-Client-Version headers. They are recorded on the tokens and
+	// leases the request creates.
+	ClientName    string `json:"client_name,omitempty" structs:"client_name" mapstructure:"client_name" sentinel:""`
+	ClientVersion string `json:"client_version,omitempty" structs:"client_version" mapstructure:"client_version" sentinel:""`
 }
 
 // Clone returns a deep copy (almost) of the request.
@@ -79,6 +79,11 @@ type Response struct {
 	// to user actions without failing the action outright.
 	Warnings []string `json:"warnings" structs:"warnings" mapstructure:"warnings"`
 
+	// WarningsDetail holds the warnings added with a WarningCode, so that
+	// clients can react to them without matching on their text. Each of them
+	// is also present in Warnings.
+	WarningsDetail []*WarningDetail `json:"warnings_detail,omitempty" structs:"warnings_detail" mapstructure:"warnings_detail"`
+
 	// Information for wrapping the response in a cubbyhole
 	WrapInfo *wrapping.ResponseWrapInfo `json:"wrap_info" structs:"wrap_info" mapstructure:"wrap_info"`
 
@@ -99,10 +104,31 @@ func (r *Response) AddWarning(warning string) {
 	r.Warnings = append(r.Warnings, warning)
 }
 
+// AddWarningWithCode adds a warning into the response's warning list, and
+// records its code in the response's warnings detail
+func (r *Response) AddWarningWithCode(code WarningCode, warning string) {
+	r.AddWarning(warning)
+	r.WarningsDetail = append(r.WarningsDetail, &WarningDetail{
+		Code:    code,
+		Message: warning,
+	})
+}
+
 // IsError returns true if this response seems to indicate an error.
 func (r *Response) IsError() bool {
-	// If the response data contains only an 'error' element, or an 'error' and a 'data' element only
-	return r != nil && r.Data != nil && r.Data["error"] != nil && (len(r.Data) == 1 || (r.Data["data"] != nil && len(r.Data) == 2))
+	// If the response data contains only an 'error' element, optionally
+	// accompanied by a 'data' element and/or an 'error_code' element
+	if r == nil || r.Data == nil || r.Data["error"] == nil {
+		return false
+	}
+	expected := 1
+	if r.Data["data"] != nil {
+		expected++
+	}
+	if _, ok := r.Data[ErrorCodeKey].(string); ok {
+		expected++
+	}
+	return len(r.Data) == expected
 }
 
 func (r *Response) Error() error {
@@ -141,6 +167,15 @@ func ErrorResponse(text string, vargs ...interface{}) *Response {
 	}
 }
 
+// AuthErrorResponse is used to format an error response for a failed login
+// which carries one of the stable AuthErrorCode values, so that clients can
+// branch on the cause of the failure.
+func AuthErrorResponse(code AuthErrorCode, text string, vargs ...interface{}) *Response {
+	resp := ErrorResponse(text, vargs...)
+	resp.Data[ErrorCodeKey] = string(code)
+	return resp
+}
+
 // ListResponse is used to format a response to a list operation.
 func ListResponse(keys []string) *Response {
 	resp := &Response{
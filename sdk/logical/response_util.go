@@ -148,6 +148,9 @@ func RespondErrorCommon(req *Request, resp *Response, err error) (int, error) {
 
 	if respErr := resp.Error(); respErr != nil {
 		err = fmt.Errorf("%s", respErr.Error())
+		if code := ErrorCode(resp, nil); code != "" {
+			err = NewAuthError(AuthErrorCode(code), err)
+		}
 
 		// Don't let other error codes override the overloaded status code
 		if strings.Contains(respErr.Error(), consts.ErrOverloaded.Error()) {
@@ -201,11 +204,13 @@ func RespondError(w http.ResponseWriter, status int, err error) {
 	w.WriteHeader(status)
 
 	type ErrorResponse struct {
-		Errors []string `json:"errors"`
+		Errors    []string `json:"errors"`
+		ErrorCode string   `json:"error_code,omitempty"`
 	}
 	resp := &ErrorResponse{Errors: make([]string, 0, 1)}
 	if err != nil {
 		resp.Errors = append(resp.Errors, err.Error())
+		resp.ErrorCode = ErrorCode(nil, err)
 	}
 
 	enc := json.NewEncoder(w)
@@ -219,12 +224,14 @@ func RespondErrorAndData(w http.ResponseWriter, status int, data interface{}, er
 	w.WriteHeader(status)
 
 	type ErrorAndDataResponse struct {
-		Errors []string    `json:"errors"`
-		Data   interface{} `json:"data"`
+		Errors    []string    `json:"errors"`
+		ErrorCode string      `json:"error_code,omitempty"`
+		Data      interface{} `json:"data"`
 	}
 	resp := &ErrorAndDataResponse{Errors: make([]string, 0, 1)}
 	if err != nil {
 		resp.Errors = append(resp.Errors, err.Error())
+		resp.ErrorCode = ErrorCode(nil, err)
 	}
 	resp.Data = data
 
@@ -5,6 +5,7 @@ package logical
 
 import (
 	"errors"
+	"net/http/httptest"
 	"strings"
 	"testing"
 
@@ -117,3 +118,55 @@ func TestResponseUtil_RespondErrorCommon_basic(t *testing.T) {
 		})
 	}
 }
+
+func TestResponseUtil_RespondError_ErrorCode(t *testing.T) {
+	testCases := []struct {
+		title        string
+		resp         *Response
+		respErr      error
+		expectedCode string
+	}{
+		{
+			title:        "Error response with code",
+			resp:         AuthErrorResponse(AuthErrorCodeInvalidCredentials, "invalid username or password"),
+			respErr:      ErrInvalidCredentials,
+			expectedCode: `"error_code":"invalid_credentials"`,
+		},
+		{
+			title:        "Wrapped error with code",
+			respErr:      NewAuthError(AuthErrorCodeLockedOut, ErrPermissionDenied),
+			expectedCode: `"error_code":"locked_out"`,
+		},
+		{
+			title:   "Error response without code",
+			resp:    ErrorResponse("some failure"),
+			respErr: ErrInvalidRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			status, err := RespondErrorCommon(&Request{Operation: UpdateOperation}, tc.resp, tc.respErr)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+
+			w := httptest.NewRecorder()
+			RespondError(w, status, err)
+			body := w.Body.String()
+			if tc.expectedCode == "" {
+				if strings.Contains(body, "error_code") {
+					t.Fatalf("expected no error code, got: %s", body)
+				}
+				return
+			}
+			if !strings.Contains(body, tc.expectedCode) {
+				t.Fatalf("expected body to contain %s, got: %s", tc.expectedCode, body)
+			}
+		})
+	}
+
+	if !AuthErrorResponse(AuthErrorCodeMFAFailed, "failed").IsError() {
+		t.Fatal("expected response with an error code to be an error")
+	}
+}
@@ -0,0 +1,29 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package logical
+
+// WarningCode is a stable, machine-readable identifier of the kind of a
+// response warning. Unlike the text of warnings, codes do not change between
+// releases.
+type WarningCode string
+
+const (
+	// WarningCodeDeprecatedParameter is used when a request sets a
+	// deprecated parameter, which may be ignored in favor of its replacement.
+	WarningCodeDeprecatedParameter WarningCode = "deprecated_parameter"
+
+	// WarningCodeTTLTruncated is used when the TTL or period of a lease or
+	// token was capped to a lower maximum than the one requested.
+	WarningCodeTTLTruncated WarningCode = "ttl_truncated"
+
+	// WarningCodeIssuerNearExpiry is used when a certificate issued by a CA
+	// was limited by, or outlives, the expiry of the CA certificate.
+	WarningCodeIssuerNearExpiry WarningCode = "issuer_near_expiry"
+)
+
+// WarningDetail is a response warning along with its code.
+type WarningDetail struct {
+	Code    WarningCode `json:"code" structs:"code" mapstructure:"code"`
+	Message string      `json:"message" structs:"message" mapstructure:"message"`
+}
@@ -16,10 +16,11 @@ import (
 // values we don't.
 func LogicalResponseToHTTPResponse(input *Response) *HTTPResponse {
 	httpResp := &HTTPResponse{
-		Data:      input.Data,
-		Warnings:  input.Warnings,
-		Headers:   input.Headers,
-		MountType: input.MountType,
+		Data:           input.Data,
+		Warnings:       input.Warnings,
+		WarningsDetail: input.WarningsDetail,
+		Headers:        input.Headers,
+		MountType:      input.MountType,
 	}
 
 	if input.Secret != nil {
@@ -53,10 +54,11 @@ func LogicalResponseToHTTPResponse(input *Response) *HTTPResponse {
 
 func HTTPResponseToLogicalResponse(input *HTTPResponse) *Response {
 	logicalResp := &Response{
-		Data:      input.Data,
-		Warnings:  input.Warnings,
-		Headers:   input.Headers,
-		MountType: input.MountType,
+		Data:           input.Data,
+		Warnings:       input.Warnings,
+		WarningsDetail: input.WarningsDetail,
+		Headers:        input.Headers,
+		MountType:      input.MountType,
 	}
 
 	if input.LeaseID != "" {
@@ -92,16 +94,17 @@ func HTTPResponseToLogicalResponse(input *HTTPResponse) *Response {
 }
 
 type HTTPResponse struct {
-	RequestID     string                 `json:"request_id"`
-	LeaseID       string                 `json:"lease_id"`
-	Renewable     bool                   `json:"renewable"`
-	LeaseDuration int                    `json:"lease_duration"`
-	Data          map[string]interface{} `json:"data"`
-	WrapInfo      *HTTPWrapInfo          `json:"wrap_info"`
-	Warnings      []string               `json:"warnings"`
-	Headers       map[string][]string    `json:"-"`
-	Auth          *HTTPAuth              `json:"auth"`
-	MountType     string                 `json:"mount_type"`
+	RequestID      string                 `json:"request_id"`
+	LeaseID        string                 `json:"lease_id"`
+	Renewable      bool                   `json:"renewable"`
+	LeaseDuration  int                    `json:"lease_duration"`
+	Data           map[string]interface{} `json:"data"`
+	WrapInfo       *HTTPWrapInfo          `json:"wrap_info"`
+	Warnings       []string               `json:"warnings"`
+	WarningsDetail []*WarningDetail       `json:"warnings_detail,omitempty"`
+	Headers        map[string][]string    `json:"-"`
+	Auth           *HTTPAuth              `json:"auth"`
+	MountType      string                 `json:"mount_type"`
 }
 
 type HTTPAuth struct {
@@ -403,15 +403,24 @@ func ProtoResponseToLogicalResponse(r *Response) (*logical.Response, error) {
 		}
 	}
 
+	var warningsDetail []*logical.WarningDetail
+	for _, w := range r.WarningsDetail {
+		warningsDetail = append(warningsDetail, &logical.WarningDetail{
+			Code:    logical.WarningCode(w.Code),
+			Message: w.Message,
+		})
+	}
+
 	return &logical.Response{
-		Secret:    secret,
-		Auth:      auth,
-		Data:      data,
-		Redirect:  r.Redirect,
-		Warnings:  r.Warnings,
-		WrapInfo:  wrapInfo,
-		Headers:   headers,
-		MountType: r.MountType,
+		Secret:         secret,
+		Auth:           auth,
+		Data:           data,
+		Redirect:       r.Redirect,
+		Warnings:       r.Warnings,
+		WarningsDetail: warningsDetail,
+		WrapInfo:       wrapInfo,
+		Headers:        headers,
+		MountType:      r.MountType,
 	}, nil
 }
 
@@ -491,15 +500,24 @@ func LogicalResponseToProtoResponse(r *logical.Response) (*Response, error) {
 		headers[k] = &Header{Header: v}
 	}
 
+	var warningsDetail []*WarningDetail
+	for _, w := range r.WarningsDetail {
+		warningsDetail = append(warningsDetail, &WarningDetail{
+			Code:    string(w.Code),
+			Message: w.Message,
+		})
+	}
+
 	return &Response{
-		Secret:    secret,
-		Auth:      auth,
-		Data:      string(buf[:]),
-		Redirect:  r.Redirect,
-		Warnings:  r.Warnings,
-		WrapInfo:  wrapInfo,
-		Headers:   headers,
-		MountType: r.MountType,
+		Secret:         secret,
+		Auth:           auth,
+		Data:           string(buf[:]),
+		Redirect:       r.Redirect,
+		Warnings:       r.Warnings,
+		WarningsDetail: warningsDetail,
+		WrapInfo:       wrapInfo,
+		Headers:        headers,
+		MountType:      r.MountType,
 	}, nil
 }
 
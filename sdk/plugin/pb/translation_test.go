@@ -198,6 +198,15 @@ func TestTranslation_Response(t *testing.T) {
 			},
 			Warnings: []string{"warning"},
 		},
+		{
+			Data: map[string]interface{}{
+				"data": "blah",
+			},
+			Warnings: []string{"warning", "ttl capped"},
+			WarningsDetail: []*logical.WarningDetail{
+				{Code: logical.WarningCodeTTLTruncated, Message: "ttl capped"},
+			},
+		},
 		{
 			Data: map[string]interface{}{
 				"string": "string",
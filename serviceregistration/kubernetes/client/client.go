@@ -11,6 +11,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 	"unicode"
@@ -27,9 +28,10 @@ var (
 	RetryMax     = 10
 
 	// Standard errs
-	ErrNamespaceUnset = errors.New(`"namespace" is unset`)
-	ErrPodNameUnset   = errors.New(`"podName" is unset`)
-	ErrNotInCluster   = errors.New("unable to load in-cluster configuration, KUBERNETES_SERVICE_HOST and KUBERNETES_SERVICE_PORT must be defined")
+	ErrNamespaceUnset  = errors.New(`"namespace" is unset`)
+	ErrPodNameUnset    = errors.New(`"podName" is unset`)
+	ErrSecretNameUnset = errors.New(`"secretName" is unset`)
+	ErrNotInCluster    = errors.New("unable to load in-cluster configuration, KUBERNETES_SERVICE_HOST and KUBERNETES_SERVICE_PORT must be defined")
 )
 
 // Client is a minimal Kubernetes client. We rolled our own because the existing
@@ -127,6 +129,43 @@ func (c *Client) PatchPod(namespace, podName string, patches ...*Patch) error {
 	return c.do(req, nil)
 }
 
+// ApplySecret creates the secret, or updates its data if it already exists,
+// using a server-side apply. Only the given keys of the secret's data are
+// owned by fieldManager, so other keys of an existing secret are retained.
+func (c *Client) ApplySecret(namespace, secretName, fieldManager string, data map[string][]byte) error {
+	endpoint := fmt.Sprintf("/api/v1/namespaces/%s/secrets/%s?fieldManager=%s&force=true", namespace, secretName, url.QueryEscape(fieldManager))
+	method := http.MethodPatch
+
+	// Validate that we received required parameters.
+	if namespace == "" {
+		return ErrNamespaceUnset
+	}
+	if secretName == "" {
+		return ErrSecretNameUnset
+	}
+
+	body, err := json.Marshal(&Secret{
+		APIVersion: "v1",
+		Kind:       "Secret",
+		Metadata: &Metadata{
+			Name:      secretName,
+			Namespace: namespace,
+		},
+		Type: "Opaque",
+		Data: data,
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(method, c.config.Host+endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	// JSON is valid YAML, so it may be sent as an apply patch.
+	req.Header.Set("Content-Type", "application/apply-patch+yaml")
+	return c.do(req, nil)
+}
+
 // do executes the given request, retrying if necessary.
 func (c *Client) do(req *http.Request, ptrToReturnObj interface{}) error {
 	// Finish setting up a valid request.
@@ -228,8 +267,17 @@ type Pod struct {
 	Metadata *Metadata `json:"metadata,omitempty"`
 }
 
+type Secret struct {
+	APIVersion string            `json:"apiVersion,omitempty"`
+	Kind       string            `json:"kind,omitempty"`
+	Metadata   *Metadata         `json:"metadata,omitempty"`
+	Type       string            `json:"type,omitempty"`
+	Data       map[string][]byte `json:"data,omitempty"`
+}
+
 type Metadata struct {
-	Name string `json:"name,omitempty"`
+	Name      string `json:"name,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
 
 	// This map will be nil if no "labels" key was provided.
 	// It will be populated but have a length of zero if the
@@ -40,6 +40,10 @@ var (
 	Scheme     = "https://"
 	TokenFile  = "/var/run/secrets/kubernetes.io/serviceaccount/token"
 	RootCAFile = "/var/run/secrets/kubernetes.io/serviceaccount/ca.crt"
+
+	// NamespaceFile contains the namespace of the service account, which
+	// callers may default to when no namespace has been configured.
+	NamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
 )
 
 // inClusterConfig returns a config object which uses the service account
@@ -36,6 +36,13 @@ const (
 	// barrier view for the audit backends.
 	auditBarrierPrefix = "audit/"
 
+	// auditSaltRotationSubPath and localAuditSaltRotationSubPath are the
+	// sub-paths of the system barrier view under which salt rotations of
+	// replicated and local audit devices are recorded. Other nodes drop the
+	// cached salt of the device when the record is invalidated.
+	auditSaltRotationSubPath      = "audit-salt-rotation/"
+	localAuditSaltRotationSubPath = "local-audit-salt-rotation/"
+
 	// auditTableType is the value we expect to find for the audit table and
 	// corresponding entries
 	auditTableType = "audit"
@@ -563,6 +570,10 @@ func (c *Core) newAuditBackend(entry *MountEntry, view logical.Storage, conf map
 		})
 
 		c.reloadFuncsLock.Unlock()
+	case audit.TypeKafka:
+		if auditLogger.IsDebug() && entry.Options != nil {
+			auditLogger.Debug("kafka backend options", "path", entry.Path, "brokers", entry.Options["brokers"], "topic", entry.Options["topic"])
+		}
 	case audit.TypeSocket:
 		if auditLogger.IsDebug() && entry.Options != nil {
 			auditLogger.Debug("socket backend options", "path", entry.Path, "address", entry.Options["address"], "socket type", entry.Options["socket_type"])
@@ -780,6 +780,7 @@ func (c *Core) setupCredentials(ctx context.Context) error {
 
 	for _, entry := range c.auth.sortEntriesByPathDepth().Entries {
 		var backend logical.Backend
+		var setupErr error
 
 		// Create a barrier view using the UUID
 		viewPath := entry.ViewPath()
@@ -820,6 +821,7 @@ func (c *Core) setupCredentials(ctx context.Context) error {
 			}
 			if mountable {
 				c.logger.Warn("skipping plugin-based auth entry", "path", entry.Path)
+				setupErr = err
 				goto ROUTER_MOUNT
 			}
 			return errors.Join(errLoadAuthFailed, err)
@@ -840,6 +842,7 @@ func (c *Core) setupCredentials(ctx context.Context) error {
 				c.logger.Error("skipping deprecated auth entry", "name", entry.Type, "path", entry.Path, "error", err)
 				backend.Cleanup(ctx)
 				backend = nil
+				setupErr = err
 				goto ROUTER_MOUNT
 			}
 		}
@@ -870,6 +873,7 @@ func (c *Core) setupCredentials(ctx context.Context) error {
 			c.logger.Error("failed to mount auth entry", "path", entry.Path, "namespace", entry.Namespace(), "error", err)
 			return errLoadAuthFailed
 		}
+		c.router.recordMountInitError(entry, setupErr)
 
 		if c.logger.IsInfo() {
 			c.logger.Info("successfully mounted", "type", entry.Type, "version", entry.RunningVersion, "path", entry.Path, "namespace", entry.Namespace())
@@ -916,10 +920,19 @@ func (c *Core) setupCredentials(ctx context.Context) error {
 					view.setReadOnlyErr(origViewReadOnlyErr)
 				}
 
-				err := backend.Initialize(ctx, &logical.InitializationRequest{Storage: view})
-				if err != nil {
-					postUnsealLogger.Error("failed to initialize auth backend", "error", err)
+				initialize := func() {
+					err := backend.Initialize(ctx, &logical.InitializationRequest{Storage: view})
+					if err != nil {
+						postUnsealLogger.Error("failed to initialize auth backend", "error", err)
+					}
+					c.router.recordMountInitError(localEntry, err)
 				}
+				if c.lazyMountInitialization && !strutil.StrListContains(singletonMounts, localEntry.Type) &&
+					c.router.deferMountInit(localEntry, initialize) {
+					postUnsealLogger.Debug("deferring auth backend initialization to first request")
+					return
+				}
+				initialize()
 			})
 		}
 	}
@@ -0,0 +1,151 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/hashicorp/go-secure-stdlib/strutil"
+	"github.com/hashicorp/vault/helper/forwarding"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// listenerNameHeader carries the name of the listener a request was received
+// on when it is forwarded to the active node. It is only trusted on requests
+// forwarded over the cluster port.
+var listenerNameHeader = http.CanonicalHeaderKey("X-Vault-Forwarded-Listener")
+
+type ctxKeyListenerName struct{}
+
+// ContextWithListenerName returns a context recording the name of the
+// listener the request was received on.
+func ContextWithListenerName(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, ctxKeyListenerName{}, name)
+}
+
+// ListenerNameFromContext returns the name of the listener the request was
+// received on, if it was recorded.
+func ListenerNameFromContext(ctx context.Context) (string, bool) {
+	name, ok := ctx.Value(ctxKeyListenerName{}).(string)
+	return name, ok
+}
+
+// AuthNetworkRequirements restrict the network paths logins to an auth mount
+// may arrive through, set through sys/auth/:path/network-requirements.
+type AuthNetworkRequirements struct {
+	// AllowedListeners are the names of the listeners logins must be
+	// received on. Logins are accepted on any listener when empty.
+	AllowedListeners []string `json:"allowed_listeners,omitempty"`
+
+	// ClientCAPEM holds the CA certificates one of which must have issued
+	// the TLS client certificate presented by logins, if set.
+	ClientCAPEM string `json:"client_ca_pem,omitempty"`
+}
+
+func (r *AuthNetworkRequirements) validate() error {
+	if len(r.AllowedListeners) == 0 && r.ClientCAPEM == "" {
+		return errors.New("at least one of allowed_listeners or client_ca_pem must be set")
+	}
+	if r.ClientCAPEM != "" {
+		if _, err := r.clientCAPool(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *AuthNetworkRequirements) clientCAPool() (*x509.CertPool, error) {
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM([]byte(r.ClientCAPEM)) {
+		return nil, errors.New("client_ca_pem does not contain any PEM-encoded certificate")
+	}
+	return pool, nil
+}
+
+// check returns an error describing why the login request does not meet the
+// requirements, if it does not.
+func (r *AuthNetworkRequirements) check(ctx context.Context, req *logical.Request) error {
+	if len(r.AllowedListeners) > 0 {
+		listener, _ := ListenerNameFromContext(ctx)
+		if listener == "" {
+			return errors.New("request was not received on a named listener")
+		}
+		if !strutil.StrListContains(r.AllowedListeners, listener) {
+			return fmt.Errorf("request was received on listener %q, which is not allowed", listener)
+		}
+	}
+
+	if r.ClientCAPEM != "" {
+		if req.Connection == nil || req.Connection.ConnState == nil || len(req.Connection.ConnState.PeerCertificates) == 0 {
+			return errors.New("no TLS client certificate was presented")
+		}
+		roots, err := r.clientCAPool()
+		if err != nil {
+			return err
+		}
+
+		peerCerts := req.Connection.ConnState.PeerCertificates
+		intermediates := x509.NewCertPool()
+		for _, cert := range peerCerts[1:] {
+			intermediates.AddCert(cert)
+		}
+		if _, err := peerCerts[0].Verify(x509.VerifyOptions{
+			Roots:         roots,
+			Intermediates: intermediates,
+			KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		}); err != nil {
+			return fmt.Errorf("TLS client certificate was not issued by a required CA: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// checkAuthNetworkRequirements denies login requests to auth mounts which do
+// not meet the network requirements of the mount.
+func (c *Core) checkAuthNetworkRequirements(ctx context.Context, entry *MountEntry, req *logical.Request) error {
+	if entry == nil || entry.Table != credentialTableType || entry.NetworkRequirements == nil {
+		return nil
+	}
+
+	if err := entry.NetworkRequirements.check(ctx, req); err != nil {
+		var remoteAddr string
+		if req.Connection != nil {
+			remoteAddr = req.Connection.RemoteAddr
+		}
+		c.logger.Warn("login request does not meet the network requirements of the auth mount",
+			"path", req.Path, "remote_address", remoteAddr, "error", err)
+		return logical.ErrPermissionDenied
+	}
+	return nil
+}
+
+// setForwardedListenerName records the listener the request was received on
+// in the request forwarded to the active node, replacing any value set by the
+// client.
+func setForwardedListenerName(req *http.Request, freq *forwarding.Request) {
+	delete(freq.HeaderEntries, listenerNameHeader)
+	if name, ok := ListenerNameFromContext(req.Context()); ok && name != "" {
+		if freq.HeaderEntries == nil {
+			freq.HeaderEntries = make(map[string]*forwarding.HeaderEntry)
+		}
+		freq.HeaderEntries[listenerNameHeader] = &forwarding.HeaderEntry{Values: []string{name}}
+	}
+}
+
+// forwardedListenerName returns the request forwarded by a standby with the
+// name of the listener it was received on recorded in its context.
+func forwardedListenerName(req *http.Request) *http.Request {
+	name := strings.TrimSpace(req.Header.Get(listenerNameHeader))
+	req.Header.Del(listenerNameHeader)
+	if name == "" {
+		return req
+	}
+	return req.WithContext(ContextWithListenerName(req.Context(), name))
+}
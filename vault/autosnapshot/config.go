@@ -0,0 +1,106 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+// Package autosnapshot provides the destinations that automated raft snapshots
+// can be written to, and the configuration describing them.
+package autosnapshot
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	StorageTypeLocal     = "local"
+	StorageTypeAWSS3     = "aws-s3"
+	StorageTypeGoogleGCS = "google-gcs"
+	StorageTypeAzureBlob = "azure-blob"
+
+	DefaultFilePrefix = "vault-snapshot"
+	DefaultRetain     = 1
+
+	// fileSuffix is appended to the name of every snapshot.
+	fileSuffix = ".snap"
+)
+
+// Config describes how often automated snapshots are taken, where they are
+// written to and how many of them are retained.
+type Config struct {
+	Interval    time.Duration `json:"interval"`
+	Retain      int           `json:"retain"`
+	PathPrefix  string        `json:"path_prefix"`
+	FilePrefix  string        `json:"file_prefix"`
+	StorageType string        `json:"storage_type"`
+
+	LocalMaxSpace int64 `json:"local_max_space,omitempty"`
+
+	AWSS3Bucket               string `json:"aws_s3_bucket,omitempty"`
+	AWSS3Region               string `json:"aws_s3_region,omitempty"`
+	AWSAccessKeyID            string `json:"aws_access_key_id,omitempty"`
+	AWSSecretAccessKey        string `json:"aws_secret_access_key,omitempty"`
+	AWSSessionToken           string `json:"aws_session_token,omitempty"`
+	AWSS3Endpoint             string `json:"aws_s3_endpoint,omitempty"`
+	AWSS3DisableTLS           bool   `json:"aws_s3_disable_tls,omitempty"`
+	AWSS3ForcePathStyle       bool   `json:"aws_s3_force_path_style,omitempty"`
+	AWSS3EnableKMS            bool   `json:"aws_s3_enable_kms,omitempty"`
+	AWSS3ServerSideEncryption bool   `json:"aws_s3_server_side_encryption,omitempty"`
+	AWSS3KMSKey               string `json:"aws_s3_kms_key,omitempty"`
+
+	GoogleGCSBucket         string `json:"google_gcs_bucket,omitempty"`
+	GoogleServiceAccountKey string `json:"google_service_account_key,omitempty"`
+	GoogleEndpoint          string `json:"google_endpoint,omitempty"`
+	GoogleDisableTLS        bool   `json:"google_disable_tls,omitempty"`
+}
+
+// Validate checks that the configuration is complete and consistent.
+func (c *Config) Validate() error {
+	switch {
+	case c.Interval <= 0:
+		return errors.New("interval must be greater than zero")
+	case c.Retain < 1:
+		return errors.New("retain must be at least 1")
+	case c.PathPrefix == "":
+		return errors.New("path_prefix is required")
+	case c.FilePrefix == "":
+		return errors.New("file_prefix cannot be empty")
+	}
+
+	switch c.StorageType {
+	case StorageTypeLocal:
+		if c.LocalMaxSpace <= 0 {
+			return errors.New("local_max_space must be greater than zero")
+		}
+	case StorageTypeAWSS3:
+		if c.AWSS3Bucket == "" {
+			return errors.New("aws_s3_bucket is required")
+		}
+		if c.AWSS3Region == "" {
+			return errors.New("aws_s3_region is required")
+		}
+		if c.AWSS3EnableKMS && c.AWSS3ServerSideEncryption {
+			return errors.New("aws_s3_enable_kms and aws_s3_server_side_encryption cannot both be set")
+		}
+		if c.AWSS3KMSKey != "" && !c.AWSS3EnableKMS {
+			return errors.New("aws_s3_kms_key requires aws_s3_enable_kms to be set")
+		}
+	case StorageTypeGoogleGCS:
+		if c.GoogleGCSBucket == "" {
+			return errors.New("google_gcs_bucket is required")
+		}
+	case StorageTypeAzureBlob:
+		return fmt.Errorf("storage_type %q is not supported", c.StorageType)
+	case "":
+		return errors.New("storage_type is required")
+	default:
+		return fmt.Errorf("unknown storage_type %q", c.StorageType)
+	}
+
+	return nil
+}
+
+// SnapshotName returns the name of a snapshot taken at the given time. Names
+// sort in the order the snapshots were taken.
+func (c *Config) SnapshotName(t time.Time) string {
+	return fmt.Sprintf("%s-%d%s", c.FilePrefix, t.UnixNano(), fileSuffix)
+}
@@ -0,0 +1,153 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package autosnapshot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestConfig_Validate checks that incomplete or inconsistent configurations
+// are rejected.
+func TestConfig_Validate(t *testing.T) {
+	t.Parallel()
+
+	valid := func() *Config {
+		return &Config{
+			Interval:      time.Hour,
+			Retain:        DefaultRetain,
+			PathPrefix:    "/tmp/snapshots",
+			FilePrefix:    DefaultFilePrefix,
+			StorageType:   StorageTypeLocal,
+			LocalMaxSpace: 1024,
+		}
+	}
+
+	tests := map[string]struct {
+		modify         func(*Config)
+		wantErrMessage string
+	}{
+		"valid-local": {
+			modify: func(*Config) {},
+		},
+		"no-interval": {
+			modify:         func(c *Config) { c.Interval = 0 },
+			wantErrMessage: "interval must be greater than zero",
+		},
+		"no-retain": {
+			modify:         func(c *Config) { c.Retain = 0 },
+			wantErrMessage: "retain must be at least 1",
+		},
+		"no-path-prefix": {
+			modify:         func(c *Config) { c.PathPrefix = "" },
+			wantErrMessage: "path_prefix is required",
+		},
+		"no-file-prefix": {
+			modify:         func(c *Config) { c.FilePrefix = "" },
+			wantErrMessage: "file_prefix cannot be empty",
+		},
+		"no-storage-type": {
+			modify:         func(c *Config) { c.StorageType = "" },
+			wantErrMessage: "storage_type is required",
+		},
+		"unknown-storage-type": {
+			modify:         func(c *Config) { c.StorageType = "juan" },
+			wantErrMessage: `unknown storage_type "juan"`,
+		},
+		"azure-blob": {
+			modify:         func(c *Config) { c.StorageType = StorageTypeAzureBlob },
+			wantErrMessage: `storage_type "azure-blob" is not supported`,
+		},
+		"local-no-max-space": {
+			modify:         func(c *Config) { c.LocalMaxSpace = 0 },
+			wantErrMessage: "local_max_space must be greater than zero",
+		},
+		"valid-s3": {
+			modify: func(c *Config) {
+				c.StorageType = StorageTypeAWSS3
+				c.AWSS3Bucket = "bucket"
+				c.AWSS3Region = "us-east-1"
+				c.AWSS3EnableKMS = true
+				c.AWSS3KMSKey = "key"
+			},
+		},
+		"s3-no-bucket": {
+			modify: func(c *Config) {
+				c.StorageType = StorageTypeAWSS3
+				c.AWSS3Region = "us-east-1"
+			},
+			wantErrMessage: "aws_s3_bucket is required",
+		},
+		"s3-no-region": {
+			modify: func(c *Config) {
+				c.StorageType = StorageTypeAWSS3
+				c.AWSS3Bucket = "bucket"
+			},
+			wantErrMessage: "aws_s3_region is required",
+		},
+		"s3-kms-and-sse": {
+			modify: func(c *Config) {
+				c.StorageType = StorageTypeAWSS3
+				c.AWSS3Bucket = "bucket"
+				c.AWSS3Region = "us-east-1"
+				c.AWSS3EnableKMS = true
+				c.AWSS3ServerSideEncryption = true
+			},
+			wantErrMessage: "aws_s3_enable_kms and aws_s3_server_side_encryption cannot both be set",
+		},
+		"s3-kms-key-without-kms": {
+			modify: func(c *Config) {
+				c.StorageType = StorageTypeAWSS3
+				c.AWSS3Bucket = "bucket"
+				c.AWSS3Region = "us-east-1"
+				c.AWSS3KMSKey = "key"
+			},
+			wantErrMessage: "aws_s3_kms_key requires aws_s3_enable_kms to be set",
+		},
+		"valid-gcs": {
+			modify: func(c *Config) {
+				c.StorageType = StorageTypeGoogleGCS
+				c.GoogleGCSBucket = "bucket"
+			},
+		},
+		"gcs-no-bucket": {
+			modify:         func(c *Config) { c.StorageType = StorageTypeGoogleGCS },
+			wantErrMessage: "google_gcs_bucket is required",
+		},
+	}
+
+	for name, tc := range tests {
+		name := name
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			c := valid()
+			tc.modify(c)
+			err := c.Validate()
+			if tc.wantErrMessage != "" {
+				require.EqualError(t, err, tc.wantErrMessage)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+// TestConfig_SnapshotName checks that snapshot names sort in the order the
+// snapshots were taken.
+func TestConfig_SnapshotName(t *testing.T) {
+	t.Parallel()
+
+	c := &Config{FilePrefix: "nightly"}
+	now := time.Now()
+	first := c.SnapshotName(now)
+	second := c.SnapshotName(now.Add(time.Second))
+
+	require.True(t, isSnapshot(first, "nightly"))
+	require.False(t, isSnapshot(first, "night"))
+	require.Less(t, first, second)
+}
@@ -0,0 +1,92 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package autosnapshot
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/go-hclog"
+)
+
+// Object is a snapshot held in a Store.
+type Object struct {
+	Name string
+	Size int64
+}
+
+// Store is a destination that snapshots are written to.
+type Store interface {
+	// Put writes a snapshot of the given size under name.
+	Put(ctx context.Context, name string, r io.ReadSeeker, size int64) error
+
+	// List returns the snapshots in the store which were written with the
+	// configured file prefix.
+	List(ctx context.Context) ([]Object, error)
+
+	// Delete removes the named snapshot.
+	Delete(ctx context.Context, name string) error
+
+	// URL returns a URL identifying the named snapshot.
+	URL(name string) string
+}
+
+// NewStore returns the Store described by the configuration.
+func NewStore(ctx context.Context, config *Config, logger hclog.Logger) (Store, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	switch config.StorageType {
+	case StorageTypeLocal:
+		return newLocalStore(config), nil
+	case StorageTypeAWSS3:
+		return newS3Store(config, logger)
+	case StorageTypeGoogleGCS:
+		return newGCSStore(ctx, config)
+	default:
+		return nil, fmt.Errorf("unknown storage_type %q", config.StorageType)
+	}
+}
+
+// Prune deletes the oldest snapshots in the store, so that at most retain of
+// them are kept. It returns the names of the deleted snapshots.
+func Prune(ctx context.Context, store Store, retain int) ([]string, error) {
+	objects, err := store.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error listing snapshots: %w", err)
+	}
+	if len(objects) <= retain {
+		return nil, nil
+	}
+
+	sort.Slice(objects, func(i, j int) bool {
+		return objects[i].Name < objects[j].Name
+	})
+
+	var deleted []string
+	for _, object := range objects[:len(objects)-retain] {
+		if err := store.Delete(ctx, object.Name); err != nil {
+			return deleted, fmt.Errorf("error deleting snapshot %q: %w", object.Name, err)
+		}
+		deleted = append(deleted, object.Name)
+	}
+
+	return deleted, nil
+}
+
+// objectKey returns the key of the named snapshot within a bucket.
+func objectKey(pathPrefix, name string) string {
+	return strings.TrimPrefix(path.Join(pathPrefix, name), "/")
+}
+
+// isSnapshot reports whether the name belongs to a snapshot written with the
+// given file prefix.
+func isSnapshot(name, filePrefix string) bool {
+	return strings.HasPrefix(name, filePrefix+"-") && strings.HasSuffix(name, fileSuffix)
+}
@@ -0,0 +1,98 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package autosnapshot
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path"
+	"strings"
+
+	"cloud.google.com/go/storage"
+	"github.com/hashicorp/vault/helper/useragent"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+)
+
+var _ Store = (*gcsStore)(nil)
+
+// gcsStore writes snapshots to a Google Cloud Storage bucket.
+type gcsStore struct {
+	bucket     *storage.BucketHandle
+	bucketName string
+	pathPrefix string
+	filePrefix string
+}
+
+func newGCSStore(ctx context.Context, config *Config) (*gcsStore, error) {
+	opts := []option.ClientOption{option.WithUserAgent(useragent.String())}
+	if config.GoogleServiceAccountKey != "" {
+		opts = append(opts, option.WithCredentialsJSON([]byte(config.GoogleServiceAccountKey)))
+	}
+	if endpoint := config.GoogleEndpoint; endpoint != "" {
+		if !strings.Contains(endpoint, "://") {
+			scheme := "https://"
+			if config.GoogleDisableTLS {
+				scheme = "http://"
+			}
+			endpoint = scheme + endpoint
+		}
+		opts = append(opts, option.WithEndpoint(endpoint))
+		if config.GoogleServiceAccountKey == "" {
+			// Emulators such as fake-gcs-server don't require credentials.
+			opts = append(opts, option.WithoutAuthentication())
+		}
+	}
+
+	client, err := storage.NewClient(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &gcsStore{
+		bucket:     client.Bucket(config.GoogleGCSBucket),
+		bucketName: config.GoogleGCSBucket,
+		pathPrefix: config.PathPrefix,
+		filePrefix: config.FilePrefix,
+	}, nil
+}
+
+func (s *gcsStore) Put(ctx context.Context, name string, r io.ReadSeeker, _ int64) error {
+	w := s.bucket.Object(objectKey(s.pathPrefix, name)).NewWriter(ctx)
+	if _, err := io.Copy(w, r); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+func (s *gcsStore) List(ctx context.Context) ([]Object, error) {
+	var objects []Object
+	it := s.bucket.Objects(ctx, &storage.Query{Prefix: objectKey(s.pathPrefix, s.filePrefix)})
+	for {
+		attrs, err := it.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		name := path.Base(attrs.Name)
+		if !isSnapshot(name, s.filePrefix) {
+			continue
+		}
+		objects = append(objects, Object{Name: name, Size: attrs.Size})
+	}
+
+	return objects, nil
+}
+
+func (s *gcsStore) Delete(ctx context.Context, name string) error {
+	return s.bucket.Object(objectKey(s.pathPrefix, name)).Delete(ctx)
+}
+
+func (s *gcsStore) URL(name string) string {
+	return "gs://" + s.bucketName + "/" + objectKey(s.pathPrefix, name)
+}
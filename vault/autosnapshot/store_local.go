@@ -0,0 +1,104 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package autosnapshot
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+)
+
+var _ Store = (*localStore)(nil)
+
+// localStore writes snapshots to a directory on the local disk, within a
+// maximum amount of space.
+type localStore struct {
+	dir        string
+	filePrefix string
+	maxSpace   int64
+}
+
+func newLocalStore(config *Config) *localStore {
+	return &localStore{
+		dir:        config.PathPrefix,
+		filePrefix: config.FilePrefix,
+		maxSpace:   config.LocalMaxSpace,
+	}
+}
+
+func (s *localStore) Put(ctx context.Context, name string, r io.ReadSeeker, size int64) error {
+	existing, err := s.List(ctx)
+	if err != nil {
+		return err
+	}
+	used := size
+	for _, object := range existing {
+		used += object.Size
+	}
+	if used > s.maxSpace {
+		return fmt.Errorf("not enough space to write snapshot: %d bytes required, local_max_space is %d bytes", used, s.maxSpace)
+	}
+
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return err
+	}
+
+	// Write to a temporary file first, so that a partially written snapshot is
+	// never mistaken for a complete one.
+	f, err := os.CreateTemp(s.dir, ".tmp-"+name+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), filepath.Join(s.dir, name))
+}
+
+func (s *localStore) List(_ context.Context) ([]Object, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var objects []Object
+	for _, entry := range entries {
+		if entry.IsDir() || !isSnapshot(entry.Name(), s.filePrefix) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, Object{Name: entry.Name(), Size: info.Size()})
+	}
+
+	return objects, nil
+}
+
+func (s *localStore) Delete(_ context.Context, name string) error {
+	return os.Remove(filepath.Join(s.dir, name))
+}
+
+func (s *localStore) URL(name string) string {
+	u := url.URL{Scheme: "file", Path: filepath.ToSlash(filepath.Join(s.dir, name))}
+	return u.String()
+}
@@ -0,0 +1,99 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package autosnapshot
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLocalStore(t *testing.T, maxSpace int64) (Store, *Config) {
+	t.Helper()
+
+	config := &Config{
+		Interval:      time.Hour,
+		Retain:        2,
+		PathPrefix:    filepath.Join(t.TempDir(), "snapshots"),
+		FilePrefix:    DefaultFilePrefix,
+		StorageType:   StorageTypeLocal,
+		LocalMaxSpace: maxSpace,
+	}
+	store, err := NewStore(context.Background(), config, hclog.NewNullLogger())
+	require.NoError(t, err)
+	return store, config
+}
+
+// TestLocalStore_PutListPrune checks that snapshots are written to the
+// configured directory, and that Prune only keeps the newest of them.
+func TestLocalStore_PutListPrune(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	store, config := newTestLocalStore(t, 1024)
+
+	// Files which weren't written with the file prefix are left alone.
+	require.NoError(t, os.MkdirAll(config.PathPrefix, 0o700))
+	unrelated := filepath.Join(config.PathPrefix, "other-1.snap")
+	require.NoError(t, os.WriteFile(unrelated, []byte("other"), 0o600))
+
+	start := time.Now()
+	var names []string
+	for i := 0; i < 3; i++ {
+		name := config.SnapshotName(start.Add(time.Duration(i) * time.Second))
+		data := []byte("snapshot")
+		require.NoError(t, store.Put(ctx, name, bytes.NewReader(data), int64(len(data))))
+		names = append(names, name)
+	}
+
+	objects, err := store.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, objects, 3)
+	for _, object := range objects {
+		require.Equal(t, int64(len("snapshot")), object.Size)
+	}
+
+	deleted, err := Prune(ctx, store, config.Retain)
+	require.NoError(t, err)
+	require.Equal(t, names[:1], deleted)
+
+	objects, err = store.List(ctx)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []Object{{Name: names[1], Size: 8}, {Name: names[2], Size: 8}}, objects)
+	require.FileExists(t, unrelated)
+
+	require.Equal(t, "file://"+filepath.ToSlash(filepath.Join(config.PathPrefix, names[2])), store.URL(names[2]))
+}
+
+// TestLocalStore_MaxSpace checks that a snapshot which would take the
+// snapshots beyond local_max_space isn't written.
+func TestLocalStore_MaxSpace(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	store, config := newTestLocalStore(t, 10)
+
+	data := []byte("snapshot")
+	first := config.SnapshotName(time.Now())
+	require.NoError(t, store.Put(ctx, first, bytes.NewReader(data), int64(len(data))))
+
+	second := config.SnapshotName(time.Now().Add(time.Second))
+	err := store.Put(ctx, second, bytes.NewReader(data), int64(len(data)))
+	require.ErrorContains(t, err, "not enough space to write snapshot")
+
+	objects, err := store.List(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []Object{{Name: first, Size: 8}}, objects)
+
+	// No temporary files are left behind either.
+	entries, err := os.ReadDir(config.PathPrefix)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+}
@@ -0,0 +1,131 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package autosnapshot
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"path"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/hashicorp/go-cleanhttp"
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/go-secure-stdlib/awsutil"
+)
+
+var _ Store = (*s3Store)(nil)
+
+// s3Store writes snapshots to an AWS S3 bucket, or any other S3 compatible
+// object storage.
+type s3Store struct {
+	client               *s3.S3
+	bucket               string
+	pathPrefix           string
+	filePrefix           string
+	serverSideEncryption string
+	kmsKeyID             string
+}
+
+func newS3Store(config *Config, logger hclog.Logger) (*s3Store, error) {
+	credsConfig := &awsutil.CredentialsConfig{
+		AccessKey:    config.AWSAccessKeyID,
+		SecretKey:    config.AWSSecretAccessKey,
+		SessionToken: config.AWSSessionToken,
+		Logger:       logger,
+	}
+	creds, err := credsConfig.GenerateCredentialChain()
+	if err != nil {
+		return nil, err
+	}
+
+	awsConfig := &aws.Config{
+		Credentials: creds,
+		HTTPClient: &http.Client{
+			Transport: cleanhttp.DefaultPooledTransport(),
+		},
+		Region:           aws.String(config.AWSS3Region),
+		S3ForcePathStyle: aws.Bool(config.AWSS3ForcePathStyle),
+		DisableSSL:       aws.Bool(config.AWSS3DisableTLS),
+	}
+	if config.AWSS3Endpoint != "" {
+		awsConfig.Endpoint = aws.String(config.AWSS3Endpoint)
+	}
+
+	sess, err := session.NewSession(awsConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &s3Store{
+		client:     s3.New(sess),
+		bucket:     config.AWSS3Bucket,
+		pathPrefix: config.PathPrefix,
+		filePrefix: config.FilePrefix,
+	}
+	switch {
+	case config.AWSS3EnableKMS:
+		s.serverSideEncryption = s3.ServerSideEncryptionAwsKms
+		s.kmsKeyID = config.AWSS3KMSKey
+	case config.AWSS3ServerSideEncryption:
+		s.serverSideEncryption = s3.ServerSideEncryptionAes256
+	}
+
+	return s, nil
+}
+
+func (s *s3Store) Put(ctx context.Context, name string, r io.ReadSeeker, size int64) error {
+	input := &s3.PutObjectInput{
+		Bucket:        aws.String(s.bucket),
+		Key:           aws.String(objectKey(s.pathPrefix, name)),
+		Body:          r,
+		ContentLength: aws.Int64(size),
+	}
+	if s.serverSideEncryption != "" {
+		input.ServerSideEncryption = aws.String(s.serverSideEncryption)
+	}
+	if s.kmsKeyID != "" {
+		input.SSEKMSKeyId = aws.String(s.kmsKeyID)
+	}
+
+	_, err := s.client.PutObjectWithContext(ctx, input)
+	return err
+}
+
+func (s *s3Store) List(ctx context.Context) ([]Object, error) {
+	var objects []Object
+	input := &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.bucket),
+		Prefix: aws.String(objectKey(s.pathPrefix, s.filePrefix)),
+	}
+	err := s.client.ListObjectsV2PagesWithContext(ctx, input, func(page *s3.ListObjectsV2Output, _ bool) bool {
+		for _, object := range page.Contents {
+			name := path.Base(aws.StringValue(object.Key))
+			if !isSnapshot(name, s.filePrefix) {
+				continue
+			}
+			objects = append(objects, Object{Name: name, Size: aws.Int64Value(object.Size)})
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return objects, nil
+}
+
+func (s *s3Store) Delete(ctx context.Context, name string) error {
+	_, err := s.client.DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(objectKey(s.pathPrefix, name)),
+	})
+	return err
+}
+
+func (s *s3Store) URL(name string) string {
+	return "s3://" + s.bucket + "/" + objectKey(s.pathPrefix, name)
+}
@@ -59,9 +59,12 @@ type barrierInit struct {
 
 // Validate AESGCMBarrier satisfies SecurityBarrier interface
 var (
-	_                      SecurityBarrier = &AESGCMBarrier{}
-	barrierEncryptsMetric                  = []string{"barrier", "estimated_encryptions"}
-	barrierRotationsMetric                 = []string{"barrier", "auto_rotation"}
+	_                         SecurityBarrier = &AESGCMBarrier{}
+	barrierEncryptsMetric                     = []string{"barrier", "estimated_encryptions"}
+	barrierRotationsMetric                    = []string{"barrier", "auto_rotation"}
+	barrierEncryptTimeMetric                  = []string{"barrier", "encrypt"}
+	barrierDecryptTimeMetric                  = []string{"barrier", "decrypt"}
+	barrierDecryptErrorMetric                 = []string{"barrier", "decrypt", "error"}
 )
 
 // AESGCMBarrier is a SecurityBarrier implementation that uses the AES
@@ -1017,6 +1020,8 @@ func (b *AESGCMBarrier) aeadFromKey(key []byte) (cipher.AEAD, error) {
 
 // encrypt is used to encrypt a value
 func (b *AESGCMBarrier) encrypt(path string, term uint32, gcm cipher.AEAD, plain []byte) ([]byte, error) {
+	defer metrics.MeasureSince(barrierEncryptTimeMetric, time.Now())
+
 	// Allocate the output buffer with room for term, version byte,
 	// nonce, GCM tag and the plaintext
 
@@ -1072,7 +1077,14 @@ func termLabel(term uint32) []metrics.Label {
 }
 
 // decrypt is used to decrypt a value using the keyring
-func (b *AESGCMBarrier) decrypt(path string, gcm cipher.AEAD, cipher []byte) ([]byte, error) {
+func (b *AESGCMBarrier) decrypt(path string, gcm cipher.AEAD, cipher []byte) (plain []byte, err error) {
+	defer func(now time.Time) {
+		metrics.MeasureSince(barrierDecryptTimeMetric, now)
+		if err != nil {
+			metrics.IncrCounter(barrierDecryptErrorMetric, 1)
+		}
+	}(time.Now())
+
 	if len(cipher) < 5+gcm.NonceSize() {
 		return nil, fmt.Errorf("invalid cipher length")
 	}
@@ -0,0 +1,550 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/go-secure-stdlib/strutil"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	// breakGlassSubPath is the sub-path of the system barrier view under
+	// which the break-glass configuration and requests are stored.
+	breakGlassSubPath = "break-glass/"
+
+	breakGlassConfigKey     = "config"
+	breakGlassRequestPrefix = "request/"
+
+	// breakGlassRequestRetention is how long requests are kept once they are
+	// expired or revoked; they are tidied every breakGlassTidyInterval.
+	breakGlassRequestRetention = 30 * 24 * time.Hour
+	breakGlassTidyInterval     = time.Hour
+)
+
+const (
+	BreakGlassStatusPending = "pending"
+	BreakGlassStatusActive  = "active"
+	BreakGlassStatusExpired = "expired"
+	BreakGlassStatusRevoked = "revoked"
+)
+
+var errBreakGlassRequestNotFound = errors.New("break-glass request not found")
+
+// BreakGlassConfig controls which policies operators may escalate to, and how
+// many approvals an escalation needs.
+type BreakGlassConfig struct {
+	// AllowedPolicies are the policies that may be requested. No escalation
+	// can be requested while it is empty.
+	AllowedPolicies []string `json:"allowed_policies"`
+
+	// RequiredApprovals is the number of distinct operators, other than the
+	// requester, who must approve a request before it is granted.
+	RequiredApprovals int `json:"required_approvals"`
+
+	// MaxTTL is the longest time an escalation may be granted for.
+	MaxTTL time.Duration `json:"max_ttl"`
+
+	// ApprovalTTL is the time a request may wait for its approvals.
+	ApprovalTTL time.Duration `json:"approval_ttl"`
+
+	// ReasonCodes are the reasons an escalation may be requested for.
+	ReasonCodes []string `json:"reason_codes"`
+}
+
+func defaultBreakGlassConfig() *BreakGlassConfig {
+	return &BreakGlassConfig{
+		RequiredApprovals: 2,
+		MaxTTL:            time.Hour,
+		ApprovalTTL:       30 * time.Minute,
+		ReasonCodes:       []string{"incident", "outage", "security", "recovery"},
+	}
+}
+
+func (c *BreakGlassConfig) validate() error {
+	switch {
+	case c.RequiredApprovals < 1:
+		return errors.New("required_approvals must be at least 1")
+	case c.MaxTTL <= 0:
+		return errors.New("max_ttl must be greater than zero")
+	case c.ApprovalTTL <= 0:
+		return errors.New("approval_ttl must be greater than zero")
+	case len(c.ReasonCodes) == 0:
+		return errors.New("at least one reason code must be given")
+	case strutil.StrListContains(c.AllowedPolicies, "root"):
+		return errors.New(`the "root" policy cannot be allowed`)
+	}
+	return nil
+}
+
+// BreakGlassApproval is the approval of a break-glass request by an operator.
+type BreakGlassApproval struct {
+	Accessor    string    `json:"accessor"`
+	EntityID    string    `json:"entity_id"`
+	DisplayName string    `json:"display_name"`
+	Time        time.Time `json:"time"`
+}
+
+// BreakGlassRequest is a request to temporarily add policies to an existing
+// token, which takes effect once enough operators have approved it.
+type BreakGlassRequest struct {
+	ID string `json:"id"`
+
+	// Accessor, EntityID, NamespaceID and DisplayName identify the token the
+	// policies are requested for.
+	Accessor    string `json:"accessor"`
+	EntityID    string `json:"entity_id"`
+	NamespaceID string `json:"namespace_id"`
+	DisplayName string `json:"display_name"`
+
+	Policies      []string      `json:"policies"`
+	TTL           time.Duration `json:"ttl"`
+	ReasonCode    string        `json:"reason_code"`
+	Justification string        `json:"justification"`
+
+	RequiredApprovals int                   `json:"required_approvals"`
+	Approvals         []*BreakGlassApproval `json:"approvals"`
+
+	CreationTime     time.Time `json:"creation_time"`
+	ApprovalDeadline time.Time `json:"approval_deadline"`
+	GrantTime        time.Time `json:"grant_time"`
+	ExpireTime       time.Time `json:"expire_time"`
+	RevokeTime       time.Time `json:"revoke_time"`
+}
+
+// Status returns the status of the request at the given time.
+func (r *BreakGlassRequest) Status(now time.Time) string {
+	switch {
+	case !r.RevokeTime.IsZero():
+		return BreakGlassStatusRevoked
+	case r.GrantTime.IsZero() && now.After(r.ApprovalDeadline):
+		return BreakGlassStatusExpired
+	case r.GrantTime.IsZero():
+		return BreakGlassStatusPending
+	case now.Before(r.ExpireTime):
+		return BreakGlassStatusActive
+	default:
+		return BreakGlassStatusExpired
+	}
+}
+
+// finishedTime returns the time the request expired or was revoked, or the
+// zero time if it is still pending or active at the given time.
+func (r *BreakGlassRequest) finishedTime(now time.Time) time.Time {
+	switch r.Status(now) {
+	case BreakGlassStatusRevoked:
+		return r.RevokeTime
+	case BreakGlassStatusExpired:
+		if r.GrantTime.IsZero() {
+			return r.ApprovalDeadline
+		}
+		return r.ExpireTime
+	default:
+		return time.Time{}
+	}
+}
+
+// BreakGlassStore keeps the break-glass requests in memory, since the active
+// ones are evaluated on every request made with the escalated tokens, which
+// granted indexes by token accessor. Changes made on the active node reach
+// standbys through invalidation.
+type BreakGlassStore struct {
+	l        sync.RWMutex
+	view     *BarrierView
+	logger   log.Logger
+	config   *BreakGlassConfig
+	requests map[string]*BreakGlassRequest
+	granted  map[string]map[string]*BreakGlassRequest
+}
+
+// setupBreakGlass loads the break-glass configuration and requests from
+// storage, and starts tidying the finished requests.
+func (c *Core) setupBreakGlass(ctx context.Context) error {
+	store := &BreakGlassStore{
+		view:     c.systemBarrierView.SubView(breakGlassSubPath),
+		logger:   c.logger.Named("break-glass"),
+		config:   defaultBreakGlassConfig(),
+		requests: make(map[string]*BreakGlassRequest),
+		granted:  make(map[string]map[string]*BreakGlassRequest),
+	}
+
+	entry, err := store.view.Get(ctx, breakGlassConfigKey)
+	if err != nil {
+		return fmt.Errorf("failed to read break-glass config: %w", err)
+	}
+	if entry != nil {
+		if err := entry.DecodeJSON(store.config); err != nil {
+			return fmt.Errorf("failed to decode break-glass config: %w", err)
+		}
+	}
+
+	keys, err := store.view.List(ctx, breakGlassRequestPrefix)
+	if err != nil {
+		return fmt.Errorf("failed to list break-glass requests: %w", err)
+	}
+	for _, key := range keys {
+		entry, err := store.view.Get(ctx, breakGlassRequestPrefix+key)
+		if err != nil {
+			return fmt.Errorf("failed to read break-glass request %q: %w", key, err)
+		}
+		if entry == nil {
+			continue
+		}
+		request := new(BreakGlassRequest)
+		if err := entry.DecodeJSON(request); err != nil {
+			return fmt.Errorf("failed to decode break-glass request %q: %w", key, err)
+		}
+		store.setLocked(request)
+	}
+
+	c.breakGlass = store
+
+	if c.breakGlassCancel == nil {
+		var tidyCtx context.Context
+		tidyCtx, c.breakGlassCancel = context.WithCancel(namespace.RootContext(c.activeContext))
+		go store.run(tidyCtx)
+	}
+	return nil
+}
+
+// teardownBreakGlass stops tidying the finished break-glass requests.
+func (c *Core) teardownBreakGlass() {
+	if c.breakGlassCancel != nil {
+		c.breakGlassCancel()
+		c.breakGlassCancel = nil
+	}
+}
+
+func (s *BreakGlassStore) run(ctx context.Context) {
+	ticker := time.NewTicker(breakGlassTidyInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.tidy(ctx, time.Now()); err != nil {
+				s.logger.Error("failed to tidy break-glass requests", "error", err)
+			}
+		}
+	}
+}
+
+// tidy deletes the requests which expired or were revoked more than
+// breakGlassRequestRetention before the given time, and stops indexing the
+// grants which are no longer active.
+func (s *BreakGlassStore) tidy(ctx context.Context, now time.Time) error {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	for id, request := range s.requests {
+		finished := request.finishedTime(now)
+		if finished.IsZero() {
+			continue
+		}
+		if now.Sub(finished) < breakGlassRequestRetention {
+			s.unindexGrantLocked(request)
+			continue
+		}
+		if err := s.view.Delete(ctx, breakGlassRequestPrefix+id); err != nil {
+			return fmt.Errorf("failed to delete break-glass request %q: %w", id, err)
+		}
+		s.deleteLocked(id)
+	}
+	return nil
+}
+
+// setLocked stores the request in memory, and indexes it by accessor while
+// it is granted. It must be called with the lock held.
+func (s *BreakGlassStore) setLocked(request *BreakGlassRequest) {
+	if existing, ok := s.requests[request.ID]; ok {
+		s.unindexGrantLocked(existing)
+	}
+	s.requests[request.ID] = request
+
+	if !request.GrantTime.IsZero() && request.RevokeTime.IsZero() {
+		if s.granted[request.Accessor] == nil {
+			s.granted[request.Accessor] = make(map[string]*BreakGlassRequest)
+		}
+		s.granted[request.Accessor][request.ID] = request
+	}
+}
+
+// deleteLocked removes the request from memory. It must be called with the
+// lock held.
+func (s *BreakGlassStore) deleteLocked(id string) {
+	if existing, ok := s.requests[id]; ok {
+		s.unindexGrantLocked(existing)
+		delete(s.requests, id)
+	}
+}
+
+func (s *BreakGlassStore) unindexGrantLocked(request *BreakGlassRequest) {
+	grants, ok := s.granted[request.Accessor]
+	if !ok {
+		return
+	}
+	delete(grants, request.ID)
+	if len(grants) == 0 {
+		delete(s.granted, request.Accessor)
+	}
+}
+
+// Config returns the break-glass configuration.
+func (s *BreakGlassStore) Config() *BreakGlassConfig {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	return s.config
+}
+
+// SetConfig validates and saves the break-glass configuration.
+func (s *BreakGlassStore) SetConfig(ctx context.Context, config *BreakGlassConfig) error {
+	if err := config.validate(); err != nil {
+		return err
+	}
+
+	entry, err := logical.StorageEntryJSON(breakGlassConfigKey, config)
+	if err != nil {
+		return fmt.Errorf("failed to create break-glass config entry: %w", err)
+	}
+
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	if err := s.view.Put(ctx, entry); err != nil {
+		return fmt.Errorf("failed to save break-glass config: %w", err)
+	}
+	s.config = config
+	return nil
+}
+
+// Create validates the request against the configuration and saves it as
+// pending.
+func (s *BreakGlassStore) Create(ctx context.Context, request *BreakGlassRequest) error {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	config := s.config
+	switch {
+	case request.Accessor == "":
+		return errors.New("break-glass escalations can only be requested for service tokens")
+	case request.EntityID == "":
+		return errors.New("break-glass escalations can only be requested by tokens with an identity entity")
+	case len(request.Policies) == 0:
+		return errors.New("at least one policy must be requested")
+	case !strutil.StrListContains(config.ReasonCodes, request.ReasonCode):
+		return fmt.Errorf("reason_code must be one of %s", strings.Join(config.ReasonCodes, ", "))
+	case strings.TrimSpace(request.Justification) == "":
+		return errors.New("a justification must be given")
+	}
+	for _, policy := range request.Policies {
+		if !strutil.StrListContains(config.AllowedPolicies, policy) {
+			return fmt.Errorf("policy %q is not allowed for break-glass escalations", policy)
+		}
+	}
+
+	if request.TTL <= 0 {
+		request.TTL = config.MaxTTL
+	}
+	if request.TTL > config.MaxTTL {
+		return fmt.Errorf("ttl must not exceed the max_ttl of %s", config.MaxTTL)
+	}
+
+	request.RequiredApprovals = config.RequiredApprovals
+	request.CreationTime = time.Now()
+	request.ApprovalDeadline = request.CreationTime.Add(config.ApprovalTTL)
+
+	if err := s.persist(ctx, request); err != nil {
+		return err
+	}
+	s.setLocked(request)
+
+	s.logger.Warn("break-glass escalation requested", "id", request.ID, "accessor", request.Accessor,
+		"display_name", request.DisplayName, "policies", request.Policies, "reason_code", request.ReasonCode)
+	return nil
+}
+
+// Approve records the approval of a pending request, and grants it once it
+// has the required number of approvals. Operators are told apart by entity,
+// since tokens created by the requester, such as child tokens, share its
+// entity: the requester cannot approve their own request, nor can an operator
+// approve the same request twice.
+func (s *BreakGlassStore) Approve(ctx context.Context, id string, approval *BreakGlassApproval) (*BreakGlassRequest, error) {
+	if approval.EntityID == "" {
+		return nil, errors.New("break-glass requests can only be approved by tokens with an identity entity")
+	}
+
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	existing, ok := s.requests[id]
+	if !ok {
+		return nil, errBreakGlassRequestNotFound
+	}
+	if status := existing.Status(approval.Time); status != BreakGlassStatusPending {
+		return nil, fmt.Errorf("break-glass request is %s", status)
+	}
+
+	if approval.EntityID == existing.EntityID {
+		return nil, errors.New("break-glass requests cannot be approved by their requester")
+	}
+	for _, previous := range existing.Approvals {
+		if approval.EntityID == previous.EntityID {
+			return nil, errors.New("break-glass request already approved by this operator")
+		}
+	}
+
+	request := *existing
+	request.Approvals = append(append([]*BreakGlassApproval{}, existing.Approvals...), approval)
+	if len(request.Approvals) >= request.RequiredApprovals {
+		request.GrantTime = approval.Time
+		request.ExpireTime = approval.Time.Add(request.TTL)
+	}
+
+	if err := s.persist(ctx, &request); err != nil {
+		return nil, err
+	}
+	s.setLocked(&request)
+
+	if !request.GrantTime.IsZero() {
+		s.logger.Warn("break-glass escalation granted", "id", request.ID, "accessor", request.Accessor,
+			"policies", request.Policies, "reason_code", request.ReasonCode, "expire_time", request.ExpireTime)
+	}
+	return &request, nil
+}
+
+// Revoke ends a pending or active request.
+func (s *BreakGlassStore) Revoke(ctx context.Context, id string) (*BreakGlassRequest, error) {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	existing, ok := s.requests[id]
+	if !ok {
+		return nil, errBreakGlassRequestNotFound
+	}
+	now := time.Now()
+	switch status := existing.Status(now); status {
+	case BreakGlassStatusPending, BreakGlassStatusActive:
+	default:
+		return nil, fmt.Errorf("break-glass request is %s", status)
+	}
+
+	request := *existing
+	request.RevokeTime = now
+	if err := s.persist(ctx, &request); err != nil {
+		return nil, err
+	}
+	s.setLocked(&request)
+
+	s.logger.Warn("break-glass escalation revoked", "id", request.ID, "accessor", request.Accessor)
+	return &request, nil
+}
+
+// Get returns the request with the given ID, or nil if it does not exist.
+func (s *BreakGlassStore) Get(id string) *BreakGlassRequest {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	return s.requests[id]
+}
+
+// List returns the IDs of the requests, sorted by creation time.
+func (s *BreakGlassStore) List() []string {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	requests := make([]*BreakGlassRequest, 0, len(s.requests))
+	for _, request := range s.requests {
+		requests = append(requests, request)
+	}
+	sort.Slice(requests, func(i, j int) bool {
+		return requests[i].CreationTime.Before(requests[j].CreationTime)
+	})
+
+	ids := make([]string, 0, len(requests))
+	for _, request := range requests {
+		ids = append(ids, request.ID)
+	}
+	return ids
+}
+
+// ActivePolicies returns the policies granted to the token with the given
+// accessor by its active escalations.
+func (s *BreakGlassStore) ActivePolicies(accessor string) []string {
+	if s == nil || accessor == "" {
+		return nil
+	}
+
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	var policies []string
+	now := time.Now()
+	for _, request := range s.granted[accessor] {
+		if request.Status(now) == BreakGlassStatusActive {
+			policies = append(policies, request.Policies...)
+		}
+	}
+	return policies
+}
+
+// invalidate reloads the configuration or request stored under the key,
+// relative to the break-glass sub-path, after another node changed it.
+func (s *BreakGlassStore) invalidate(ctx context.Context, key string) {
+	entry, err := s.view.Get(ctx, key)
+	if err != nil {
+		s.logger.Error("failed to read invalidated break-glass entry", "key", key, "error", err)
+		return
+	}
+
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	switch {
+	case key == breakGlassConfigKey:
+		config := defaultBreakGlassConfig()
+		if entry != nil {
+			if err := entry.DecodeJSON(config); err != nil {
+				s.logger.Error("failed to decode invalidated break-glass config", "error", err)
+				return
+			}
+		}
+		s.config = config
+
+	case strings.HasPrefix(key, breakGlassRequestPrefix):
+		id := strings.TrimPrefix(key, breakGlassRequestPrefix)
+		if entry == nil {
+			s.deleteLocked(id)
+			return
+		}
+		request := new(BreakGlassRequest)
+		if err := entry.DecodeJSON(request); err != nil {
+			s.logger.Error("failed to decode invalidated break-glass request", "id", id, "error", err)
+			return
+		}
+		s.setLocked(request)
+	}
+}
+
+func (s *BreakGlassStore) persist(ctx context.Context, request *BreakGlassRequest) error {
+	entry, err := logical.StorageEntryJSON(breakGlassRequestPrefix+request.ID, request)
+	if err != nil {
+		return fmt.Errorf("failed to create break-glass request entry: %w", err)
+	}
+	if err := s.view.Put(ctx, entry); err != nil {
+		return fmt.Errorf("failed to save break-glass request: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,295 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	// brokerSessionSubPath is the sub-path of the system barrier view under
+	// which the broker sessions are stored.
+	brokerSessionSubPath = "broker-sessions/"
+
+	defaultBrokerSessionHeartbeatTimeout = time.Minute
+	minBrokerSessionHeartbeatTimeout     = 10 * time.Second
+	maxBrokerSessionHeartbeatTimeout     = time.Hour
+	defaultBrokerSessionMaxTTL           = 8 * time.Hour
+
+	// brokerSessionCleanupInterval is how often sessions which missed their
+	// heartbeats or outlived their max TTL are ended.
+	brokerSessionCleanupInterval = 10 * time.Second
+)
+
+var (
+	errBrokerSessionNotFound = errors.New("broker session not found")
+	errBrokerSessionNotOwner = errors.New("broker sessions can only be used by the token which created them")
+)
+
+// BrokerSession scopes the credentials an external broker requests on behalf
+// of a user session, such as a proxied database connection. The leases of the
+// credentials are revoked when the broker ends the session, or when it stops
+// sending heartbeats for the session.
+type BrokerSession struct {
+	ID string `json:"id"`
+
+	// Accessor, NamespaceID and DisplayName identify the broker token which
+	// created the session, and which alone may use it.
+	Accessor    string `json:"accessor"`
+	NamespaceID string `json:"namespace_id"`
+	DisplayName string `json:"display_name"`
+
+	Metadata map[string]string `json:"metadata"`
+
+	HeartbeatTimeout time.Duration `json:"heartbeat_timeout"`
+	MaxTTL           time.Duration `json:"max_ttl"`
+
+	LeaseIDs []string `json:"lease_ids"`
+
+	CreationTime  time.Time `json:"creation_time"`
+	LastHeartbeat time.Time `json:"last_heartbeat"`
+}
+
+// ExpireTime returns the time the session ends unless a heartbeat is
+// received.
+func (s *BrokerSession) ExpireTime() time.Time {
+	expireTime := s.LastHeartbeat.Add(s.HeartbeatTimeout)
+	if maxExpireTime := s.CreationTime.Add(s.MaxTTL); maxExpireTime.Before(expireTime) {
+		return maxExpireTime
+	}
+	return expireTime
+}
+
+// BrokerSessionStore keeps the open broker sessions in memory, so that
+// sessions which missed their heartbeats can be found without reading
+// storage.
+type BrokerSessionStore struct {
+	l        sync.RWMutex
+	view     *BarrierView
+	logger   log.Logger
+	core     *Core
+	sessions map[string]*BrokerSession
+}
+
+// setupBrokerSessions loads the broker sessions from storage, and starts
+// ending the sessions which missed their heartbeats.
+func (c *Core) setupBrokerSessions(ctx context.Context) error {
+	store := &BrokerSessionStore{
+		view:     c.systemBarrierView.SubView(brokerSessionSubPath),
+		logger:   c.logger.Named("broker-sessions"),
+		core:     c,
+		sessions: make(map[string]*BrokerSession),
+	}
+
+	keys, err := store.view.List(ctx, "")
+	if err != nil {
+		return fmt.Errorf("failed to list broker sessions: %w", err)
+	}
+	for _, key := range keys {
+		entry, err := store.view.Get(ctx, key)
+		if err != nil {
+			return fmt.Errorf("failed to read broker session %q: %w", key, err)
+		}
+		if entry == nil {
+			continue
+		}
+		session := new(BrokerSession)
+		if err := entry.DecodeJSON(session); err != nil {
+			return fmt.Errorf("failed to decode broker session %q: %w", key, err)
+		}
+		store.sessions[session.ID] = session
+	}
+
+	c.brokerSessions = store
+
+	if c.brokerSessionsCancel == nil {
+		var cleanupCtx context.Context
+		cleanupCtx, c.brokerSessionsCancel = context.WithCancel(namespace.RootContext(c.activeContext))
+		go store.run(cleanupCtx)
+	}
+	return nil
+}
+
+// teardownBrokerSessions stops ending the sessions which missed their
+// heartbeats.
+func (c *Core) teardownBrokerSessions() {
+	if c.brokerSessionsCancel != nil {
+		c.brokerSessionsCancel()
+		c.brokerSessionsCancel = nil
+	}
+}
+
+func (s *BrokerSessionStore) run(ctx context.Context) {
+	ticker := time.NewTicker(brokerSessionCleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.endExpired(ctx, time.Now())
+		}
+	}
+}
+
+// endExpired ends the sessions which expired before the given time.
+func (s *BrokerSessionStore) endExpired(ctx context.Context, now time.Time) {
+	s.l.RLock()
+	var expired []string
+	for id, session := range s.sessions {
+		if !now.Before(session.ExpireTime()) {
+			expired = append(expired, id)
+		}
+	}
+	s.l.RUnlock()
+
+	for _, id := range expired {
+		if _, err := s.End(ctx, id, "expired"); err != nil && !errors.Is(err, errBrokerSessionNotFound) {
+			s.logger.Error("failed to end expired broker session", "id", id, "error", err)
+		}
+	}
+}
+
+// Create validates and saves a new session.
+func (s *BrokerSessionStore) Create(ctx context.Context, session *BrokerSession) error {
+	switch {
+	case session.Accessor == "":
+		return errors.New("broker sessions can only be created with service tokens")
+	case session.HeartbeatTimeout < minBrokerSessionHeartbeatTimeout || session.HeartbeatTimeout > maxBrokerSessionHeartbeatTimeout:
+		return fmt.Errorf("heartbeat_timeout must be between %s and %s", minBrokerSessionHeartbeatTimeout, maxBrokerSessionHeartbeatTimeout)
+	case session.MaxTTL < session.HeartbeatTimeout:
+		return errors.New("max_ttl must not be shorter than heartbeat_timeout")
+	}
+
+	session.CreationTime = time.Now()
+	session.LastHeartbeat = session.CreationTime
+
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	if err := s.persist(ctx, session); err != nil {
+		return err
+	}
+	s.sessions[session.ID] = session
+
+	s.logger.Debug("broker session created", "id", session.ID, "accessor", session.Accessor)
+	return nil
+}
+
+// Heartbeat extends the session by its heartbeat timeout.
+func (s *BrokerSessionStore) Heartbeat(ctx context.Context, id, accessor string) (*BrokerSession, error) {
+	return s.update(ctx, id, accessor, func(session *BrokerSession) {
+		session.LastHeartbeat = time.Now()
+	})
+}
+
+// AddLease records a lease of credentials requested for the session, so that
+// it is revoked when the session ends.
+func (s *BrokerSessionStore) AddLease(ctx context.Context, id, accessor, leaseID string) (*BrokerSession, error) {
+	return s.update(ctx, id, accessor, func(session *BrokerSession) {
+		session.LeaseIDs = append(append([]string{}, session.LeaseIDs...), leaseID)
+	})
+}
+
+func (s *BrokerSessionStore) update(ctx context.Context, id, accessor string, f func(*BrokerSession)) (*BrokerSession, error) {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	existing, ok := s.sessions[id]
+	if !ok {
+		return nil, errBrokerSessionNotFound
+	}
+	if existing.Accessor != accessor {
+		return nil, errBrokerSessionNotOwner
+	}
+	if !time.Now().Before(existing.ExpireTime()) {
+		return nil, errors.New("broker session has expired")
+	}
+
+	session := *existing
+	f(&session)
+	if err := s.persist(ctx, &session); err != nil {
+		return nil, err
+	}
+	s.sessions[id] = &session
+	return &session, nil
+}
+
+// End removes the session and revokes the leases of its credentials. The
+// leases are revoked in the background by the expiration manager.
+func (s *BrokerSessionStore) End(ctx context.Context, id, reason string) (*BrokerSession, error) {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	session, ok := s.sessions[id]
+	if !ok {
+		return nil, errBrokerSessionNotFound
+	}
+
+	if len(session.LeaseIDs) != 0 && s.core.expiration == nil {
+		return nil, errors.New("leases of broker session cannot be revoked: expiration manager is not running")
+	}
+	for _, leaseID := range session.LeaseIDs {
+		if err := s.core.expiration.LazyRevoke(ctx, leaseID); err != nil {
+			return nil, fmt.Errorf("failed to revoke lease %q of broker session: %w", leaseID, err)
+		}
+	}
+	if err := s.view.Delete(ctx, id); err != nil {
+		return nil, fmt.Errorf("failed to delete broker session: %w", err)
+	}
+	delete(s.sessions, id)
+
+	s.logger.Info("broker session ended", "id", id, "accessor", session.Accessor,
+		"reason", reason, "revoked_leases", len(session.LeaseIDs))
+	return session, nil
+}
+
+// Get returns the session with the given ID, or nil if it does not exist.
+func (s *BrokerSessionStore) Get(id string) *BrokerSession {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	return s.sessions[id]
+}
+
+// List returns the IDs of the sessions, sorted by creation time.
+func (s *BrokerSessionStore) List() []string {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	sessions := make([]*BrokerSession, 0, len(s.sessions))
+	for _, session := range s.sessions {
+		sessions = append(sessions, session)
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].CreationTime.Before(sessions[j].CreationTime)
+	})
+
+	ids := make([]string, 0, len(sessions))
+	for _, session := range sessions {
+		ids = append(ids, session.ID)
+	}
+	return ids
+}
+
+func (s *BrokerSessionStore) persist(ctx context.Context, session *BrokerSession) error {
+	entry, err := logical.StorageEntryJSON(session.ID, session)
+	if err != nil {
+		return fmt.Errorf("failed to create broker session entry: %w", err)
+	}
+	if err := s.view.Put(ctx, entry); err != nil {
+		return fmt.Errorf("failed to save broker session: %w", err)
+	}
+	return nil
+}
@@ -80,6 +80,15 @@ func (c *Core) CapabilitiesAndSubscribeEventTypes(ctx context.Context, token, pa
 		policyCount++
 	}
 
+	if !te.NoIdentityPolicies {
+		adminPolicies, err := c.mountAdminPolicies(ctx, entity)
+		if err != nil {
+			return nil, nil, err
+		}
+		policies = append(policies, adminPolicies...)
+		policyCount += len(adminPolicies)
+	}
+
 	if policyCount == 0 {
 		return []string{DenyCapability}, nil, nil
 	}
@@ -0,0 +1,401 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	// configHistorySubPath is the sub-path of the system barrier view under
+	// which the configuration change history is stored.
+	configHistorySubPath = "config-history/"
+
+	configHistoryEntryPrefix = "entry/"
+	configHistoryStatePrefix = "state/"
+
+	// maxConfigHistoryEntries is the number of changes kept in the history;
+	// the oldest ones are pruned past it every configHistoryPruneInterval.
+	maxConfigHistoryEntries    = 10000
+	configHistoryPruneInterval = 10 * time.Minute
+
+	configHistoryRedacted = "<redacted>"
+)
+
+const (
+	configChangeCategoryMount        = "mount"
+	configChangeCategoryAuth         = "auth"
+	configChangeCategoryPolicy       = "policy"
+	configChangeCategorySysConfig    = "sys-config"
+	configChangeCategoryEngineConfig = "engine-config"
+)
+
+// configHistoryPlainFields are, per category, the request fields whose values
+// are written to the history. They are fields of Vault itself known to never
+// hold secrets. The values of all the other fields, including every field of
+// engine configurations whose schemas are up to their plugins, are never
+// written: their changes are still recorded, as redacted.
+var configHistoryPlainFields = map[string][]string{
+	configChangeCategoryMount: {
+		"type", "description", "config", "options", "local", "seal_wrap",
+		"external_entropy_access", "plugin_name", "plugin_version", "from", "to",
+	},
+	configChangeCategoryAuth: {
+		"type", "description", "config", "options", "local", "seal_wrap",
+		"external_entropy_access", "plugin_name", "plugin_version",
+	},
+	configChangeCategoryPolicy: {
+		"name", "policy", "enforcement_level",
+	},
+	configChangeCategorySysConfig: {
+		"enabled", "allowed_origins", "allowed_headers", "hmac",
+		"group_policy_application_mode",
+	},
+}
+
+// ConfigChange is a single change to the configuration of Vault, as recorded
+// in sys/config/history.
+type ConfigChange struct {
+	ID          string                      `json:"id"`
+	Time        time.Time                   `json:"time"`
+	Namespace   string                      `json:"namespace"`
+	Path        string                      `json:"path"`
+	Operation   logical.Operation           `json:"operation"`
+	Category    string                      `json:"category"`
+	DisplayName string                      `json:"display_name"`
+	EntityID    string                      `json:"entity_id"`
+	Accessor    string                      `json:"accessor"`
+	RemoteAddr  string                      `json:"remote_address"`
+	Diff        map[string]*ConfigFieldDiff `json:"diff"`
+}
+
+// ConfigFieldDiff is the change of a single field of a configuration path. A
+// nil Old means the field was added, and a nil New that it was removed.
+type ConfigFieldDiff struct {
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+}
+
+// ConfigHistory records the changes made to mounts, auth methods, policies and
+// engine configurations. Along with the changes, it keeps the last known state
+// of each path, which the changes are diffed against.
+type ConfigHistory struct {
+	l      sync.Mutex
+	view   *BarrierView
+	logger log.Logger
+}
+
+// setupConfigHistory sets up the configuration change history, and starts
+// pruning its oldest changes.
+func (c *Core) setupConfigHistory(_ context.Context) error {
+	c.configHistory = &ConfigHistory{
+		view:   c.systemBarrierView.SubView(configHistorySubPath),
+		logger: c.logger.Named("config-history"),
+	}
+
+	if c.configHistoryCancel == nil {
+		var pruneCtx context.Context
+		pruneCtx, c.configHistoryCancel = context.WithCancel(namespace.RootContext(c.activeContext))
+		go c.configHistory.run(pruneCtx)
+	}
+	return nil
+}
+
+// teardownConfigHistory stops pruning the configuration change history.
+func (c *Core) teardownConfigHistory() {
+	if c.configHistoryCancel != nil {
+		c.configHistoryCancel()
+		c.configHistoryCancel = nil
+	}
+}
+
+func (h *ConfigHistory) run(ctx context.Context) {
+	ticker := time.NewTicker(configHistoryPruneInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := h.prune(ctx); err != nil {
+				h.logger.Error("failed to prune configuration changes", "error", err)
+			}
+		}
+	}
+}
+
+// configChangeCategory returns the category of configuration the request
+// changes, or an empty string if it does not change any tracked configuration.
+func configChangeCategory(req *logical.Request, entry *MountEntry) string {
+	switch req.Operation {
+	case logical.CreateOperation, logical.UpdateOperation, logical.PatchOperation, logical.DeleteOperation:
+	default:
+		return ""
+	}
+
+	switch {
+	case strings.HasPrefix(req.Path, "sys/mounts/"), req.Path == "sys/remount":
+		return configChangeCategoryMount
+	case strings.HasPrefix(req.Path, "sys/auth/"):
+		return configChangeCategoryAuth
+	case strings.HasPrefix(req.Path, "sys/policy/"), strings.HasPrefix(req.Path, "sys/policies/"):
+		return configChangeCategoryPolicy
+	case strings.HasPrefix(req.Path, "sys/config/"):
+		return configChangeCategorySysConfig
+	case strings.HasPrefix(req.Path, "sys/"):
+		return ""
+	}
+
+	if entry == nil {
+		return ""
+	}
+	mountPath := entry.Path
+	if entry.Table == credentialTableType {
+		mountPath = credentialRoutePrefix + mountPath
+	}
+	relative := strings.TrimPrefix(req.Path, mountPath)
+	if relative == "config" || strings.HasPrefix(relative, "config/") {
+		return configChangeCategoryEngineConfig
+	}
+	return ""
+}
+
+// recordConfigChange records the change made by a successful request to the
+// configuration history, if the request changes a tracked configuration. The
+// change has already been applied, so failing to record it is logged rather
+// than returned.
+func (c *Core) recordConfigChange(ctx context.Context, req *logical.Request, entry *MountEntry, auth *logical.Auth) {
+	if c.configHistory == nil {
+		return
+	}
+
+	category := configChangeCategory(req, entry)
+	if category == "" {
+		return
+	}
+
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return
+	}
+
+	change := &ConfigChange{
+		Time:      time.Now().UTC(),
+		Namespace: ns.Path,
+		Path:      req.Path,
+		Operation: req.Operation,
+		Category:  category,
+	}
+	if auth != nil {
+		change.DisplayName = auth.DisplayName
+		change.EntityID = auth.EntityID
+		change.Accessor = auth.Accessor
+	}
+	if req.Connection != nil {
+		change.RemoteAddr = req.Connection.RemoteAddr
+	}
+
+	if err := c.configHistory.record(ctx, change, req.Data); err != nil {
+		c.configHistory.logger.Warn("failed to record configuration change", "path", req.Path, "error", err)
+	}
+}
+
+func (h *ConfigHistory) record(ctx context.Context, change *ConfigChange, data map[string]interface{}) error {
+	h.l.Lock()
+	defer h.l.Unlock()
+
+	stateKey := configHistoryStateKey(change.Namespace, change.Path)
+	previous := make(map[string]interface{})
+	entry, err := h.view.Get(ctx, stateKey)
+	if err != nil {
+		return err
+	}
+	if entry != nil {
+		if err := entry.DecodeJSON(&previous); err != nil {
+			return err
+		}
+	}
+
+	// Deletions clear the path; writes update the fields they carry
+	current := make(map[string]interface{})
+	if change.Operation != logical.DeleteOperation {
+		for k, v := range previous {
+			current[k] = v
+		}
+		for k, v := range configHistoryNormalize(change.Category, data) {
+			current[k] = v
+		}
+	}
+
+	change.Diff = configHistoryDiff(change.Category, previous, current)
+
+	id, err := uuid.GenerateUUID()
+	if err != nil {
+		return err
+	}
+	// IDs sort in the order the changes were made
+	change.ID = fmt.Sprintf("%020d-%s", change.Time.UnixNano(), id[:8])
+
+	entry, err = logical.StorageEntryJSON(configHistoryEntryPrefix+change.ID, change)
+	if err != nil {
+		return err
+	}
+	if err := h.view.Put(ctx, entry); err != nil {
+		return err
+	}
+
+	if len(current) == 0 {
+		err = h.view.Delete(ctx, stateKey)
+	} else {
+		entry, err = logical.StorageEntryJSON(stateKey, current)
+		if err == nil {
+			err = h.view.Put(ctx, entry)
+		}
+	}
+	return err
+}
+
+// prune removes the oldest changes past maxConfigHistoryEntries.
+func (h *ConfigHistory) prune(ctx context.Context) error {
+	h.l.Lock()
+	defer h.l.Unlock()
+
+	ids, err := h.view.List(ctx, configHistoryEntryPrefix)
+	if err != nil {
+		return err
+	}
+	if len(ids) <= maxConfigHistoryEntries {
+		return nil
+	}
+
+	sort.Strings(ids)
+	for _, id := range ids[:len(ids)-maxConfigHistoryEntries] {
+		if err := h.view.Delete(ctx, configHistoryEntryPrefix+id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Changes returns the recorded changes, oldest first.
+func (h *ConfigHistory) Changes(ctx context.Context) ([]*ConfigChange, error) {
+	ids, err := h.view.List(ctx, configHistoryEntryPrefix)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(ids)
+
+	changes := make([]*ConfigChange, 0, len(ids))
+	for _, id := range ids {
+		change, err := h.Change(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if change != nil {
+			changes = append(changes, change)
+		}
+	}
+	return changes, nil
+}
+
+// Change returns the recorded change with the given ID.
+func (h *ConfigHistory) Change(ctx context.Context, id string) (*ConfigChange, error) {
+	entry, err := h.view.Get(ctx, configHistoryEntryPrefix+id)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	change := new(ConfigChange)
+	if err := entry.DecodeJSON(change); err != nil {
+		return nil, err
+	}
+	return change, nil
+}
+
+func configHistoryStateKey(ns, path string) string {
+	sum := sha256.Sum256([]byte(ns + path))
+	return configHistoryStatePrefix + hex.EncodeToString(sum[:])
+}
+
+// configHistoryNormalize round-trips the request data through JSON, so that it
+// compares equal to the state read back from storage, and replaces the values
+// of all but the plain fields of the category by their hash.
+func configHistoryNormalize(category string, data map[string]interface{}) map[string]interface{} {
+	normalized := make(map[string]interface{}, len(data))
+	for k, v := range data {
+		if !configHistoryIsPlain(category, k) {
+			raw, _ := json.Marshal(v)
+			sum := sha256.Sum256(raw)
+			normalized[k] = "sha256:" + hex.EncodeToString(sum[:])
+			continue
+		}
+
+		raw, err := json.Marshal(v)
+		if err != nil {
+			normalized[k] = fmt.Sprintf("%v", v)
+			continue
+		}
+		var out interface{}
+		if err := json.Unmarshal(raw, &out); err != nil {
+			normalized[k] = string(raw)
+			continue
+		}
+		normalized[k] = out
+	}
+	return normalized
+}
+
+func configHistoryIsPlain(category, field string) bool {
+	for _, plain := range configHistoryPlainFields[category] {
+		if field == plain {
+			return true
+		}
+	}
+	return false
+}
+
+func configHistoryDiff(category string, previous, current map[string]interface{}) map[string]*ConfigFieldDiff {
+	diff := make(map[string]*ConfigFieldDiff)
+	for k, old := range previous {
+		if v, ok := current[k]; !ok || !reflect.DeepEqual(old, v) {
+			diff[k] = &ConfigFieldDiff{Old: old, New: current[k]}
+		}
+	}
+	for k, v := range current {
+		if _, ok := previous[k]; !ok {
+			diff[k] = &ConfigFieldDiff{New: v}
+		}
+	}
+
+	// Hashes of redacted values only serve to detect their changes
+	for k, d := range diff {
+		if !configHistoryIsPlain(category, k) {
+			if d.Old != nil {
+				d.Old = configHistoryRedacted
+			}
+			if d.New != nil {
+				d.New = configHistoryRedacted
+			}
+		}
+	}
+	return diff
+}
@@ -46,6 +46,7 @@ import (
 	"github.com/hashicorp/vault/command/server"
 	"github.com/hashicorp/vault/helper/identity/mfa"
 	"github.com/hashicorp/vault/helper/locking"
+	loghelper "github.com/hashicorp/vault/helper/logging"
 	"github.com/hashicorp/vault/helper/metricsutil"
 	"github.com/hashicorp/vault/helper/namespace"
 	"github.com/hashicorp/vault/helper/osutil"
@@ -533,6 +534,37 @@ type Core struct {
 	// CORS Information
 	corsConfig *CORSConfig
 
+	// ttlPolicies are the TTL policies capping the TTLs of leases and tokens
+	ttlPolicies *TTLPolicyStore
+
+	// configHistory records the changes made to the configuration of Vault,
+	// and configHistoryCancel stops pruning its oldest changes
+	configHistory       *ConfigHistory
+	configHistoryCancel context.CancelFunc
+
+	// breakGlass holds the quorum-approved policy escalations of tokens, and
+	// breakGlassCancel stops tidying the finished ones
+	breakGlass       *BreakGlassStore
+	breakGlassCancel context.CancelFunc
+
+	// mountAdminPolicyCache holds the parsed *Policy granted through the
+	// admin grants of each mount, keyed by mount accessor and path
+	mountAdminPolicyCache sync.Map
+
+	// mountAdminGrants holds the map[string][]mountAdminGrant indexing the
+	// admin grants of the mounts by group ID
+	mountAdminGrants atomic.Value
+
+	// brokerSessions holds the sessions credentials are brokered for, and
+	// brokerSessionsCancel stops ending the sessions which missed their
+	// heartbeats
+	brokerSessions       *BrokerSessionStore
+	brokerSessionsCancel context.CancelFunc
+
+	// requestMaxDurations holds the *RequestMaxDurations capping the
+	// duration of requests to specific paths
+	requestMaxDurations atomic.Value
+
 	// replicationState keeps the current replication state cached for quick
 	// lookup; activeNodeReplicationState stores the active value on standbys
 	replicationState           *uint32
@@ -629,6 +661,8 @@ type Core struct {
 	raftTLSRotationStopCh chan struct{}
 	// Stores the pending peers we are waiting to give answers
 	pendingRaftPeers *sync.Map
+	// raftAutoSnapshots takes the automated raft snapshots on the active node
+	raftAutoSnapshots *raftAutoSnapshotManager
 
 	// rawConfig stores the config as-is from the provided server configuration.
 	rawConfig *atomic.Value
@@ -718,6 +752,14 @@ type Core struct {
 	// If any role based quota (LCQ or RLQ) is enabled, don't track lease counts by role
 	impreciseLeaseRoleTracking bool
 
+	// lazyMountInitialization defers the initialization of mounts to their
+	// first request
+	lazyMountInitialization bool
+
+	// keySharesMaxAge is the age after which a rekey reminder is logged for
+	// the unseal and recovery key shares, if non-zero.
+	keySharesMaxAge time.Duration
+
 	WellKnownRedirects *wellKnownRedirectRegistry // RFC 5785
 	// Config value for "detect_deadlocks".
 	detectDeadlocks []string
@@ -805,6 +847,14 @@ type CoreConfig struct {
 	// If any role based quota (LCQ or RLQ) is enabled, don't track lease counts by role
 	ImpreciseLeaseRoleTracking bool
 
+	// LazyMountInitialization defers initializing secrets engines and auth
+	// methods from unseal until they are first accessed
+	LazyMountInitialization bool
+
+	// KeySharesMaxAge is the age after which the unseal and recovery key
+	// shares should be rotated; reminders are logged once it is exceeded.
+	KeySharesMaxAge time.Duration
+
 	// Disables the trace display for Sentinel checks
 	DisableSentinelTrace bool
 
@@ -1083,6 +1133,8 @@ func CreateCore(conf *CoreConfig) (*Core, error) {
 		rollbackMountPathMetrics:       conf.MetricSink.TelemetryConsts.RollbackMetricsIncludeMountPoint,
 		numRollbackWorkers:             conf.NumRollbackWorkers,
 		impreciseLeaseRoleTracking:     conf.ImpreciseLeaseRoleTracking,
+		lazyMountInitialization:        conf.LazyMountInitialization,
+		keySharesMaxAge:                conf.KeySharesMaxAge,
 		WellKnownRedirects:             NewWellKnownRedirects(),
 		detectDeadlocks:                detectDeadlocks,
 		echoDuration:                   uberAtomic.NewDuration(0),
@@ -1345,6 +1397,7 @@ func NewCore(conf *CoreConfig) (*Core, error) {
 	}
 	c.events = events
 	c.events.Start()
+	c.router.events = events
 
 	c.clusterAddrBridge = conf.ClusterAddrBridge
 
@@ -2571,6 +2624,11 @@ func buildUnsealSetupFunctionSlice(c *Core) []func(context.Context) error {
 			return c.setupManagedKeyRegistry()
 		},
 		c.loadCORSConfig,
+		c.setupTTLPolicies,
+		c.setupConfigHistory,
+		c.setupBreakGlass,
+		c.setupBrokerSessions,
+		c.setupRequestMaxDurations,
 		c.loadCredentials,
 		func(_ context.Context) error {
 			return c.entSetupFilteredPaths()
@@ -2887,6 +2945,10 @@ func (c *Core) preSeal() error {
 		c.updateLockedUserEntriesCancel = nil
 	}
 
+	c.teardownConfigHistory()
+	c.teardownBreakGlass()
+	c.teardownBrokerSessions()
+
 	if seal, ok := c.seal.(*autoSeal); ok {
 		seal.StopHealthCheck()
 	}
@@ -3563,6 +3625,16 @@ func (c *Core) LogFormat() string {
 	return conf.(*server.Config).LogFormat
 }
 
+// LogRedactor returns the redactor of sensitive values in log output, or nil
+// when log redaction is not enabled.
+func (c *Core) LogRedactor() (*loghelper.Redactor, error) {
+	conf := c.rawConfig.Load()
+	if conf == nil || !conf.(*server.Config).LogRedact {
+		return nil, nil
+	}
+	return loghelper.NewRedactor(conf.(*server.Config).LogRedactPatterns)
+}
+
 // LogLevel returns the log level provided by level provided by config, CLI flag, or env
 func (c *Core) LogLevel() string {
 	return c.logLevel
@@ -4033,6 +4105,9 @@ type InFlightReqData struct {
 	ReqPath          string    `json:"request_path"`
 	Method           string    `json:"request_method"`
 	ClientID         string    `json:"client_id"`
+
+	// Cancel cancels the context of the request
+	Cancel context.CancelFunc `json:"-"`
 }
 
 func (c *Core) StoreInFlightReqData(reqID string, data InFlightReqData) {
@@ -4053,6 +4128,22 @@ func (c *Core) FinalizeInFlightReqData(reqID string, statusCode int) {
 	c.inFlightReqData.InFlightReqCount.Dec()
 }
 
+// CancelInFlightRequest cancels the context of the in-flight request with
+// the given ID. It returns false if no such request is in flight.
+func (c *Core) CancelInFlightRequest(reqID string) bool {
+	v, ok := c.inFlightReqData.InFlightReqMap.Load(reqID)
+	if !ok {
+		return false
+	}
+
+	reqData := v.(InFlightReqData)
+	if reqData.Cancel == nil {
+		return false
+	}
+	reqData.Cancel()
+	return true
+}
+
 // LoadInFlightReqData creates a snapshot map of the current
 // in-flight requests
 func (c *Core) LoadInFlightReqData() map[string]InFlightReqData {
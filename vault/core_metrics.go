@@ -40,6 +40,15 @@ func (c *Core) metricsLoop(stopCh chan struct{}) {
 		identityCountTimer = nil
 	}
 
+	// Only check the age of the key shares on the active node, as only it
+	// may record when shares issued before tracking began were first seen.
+	keySharesAgeTimer := time.Tick(keySharesAgeCheckInterval)
+	if stopped, haState := stopOrHAState(); stopped {
+		return
+	} else if haState != consts.Active || c.IsDRSecondary() {
+		keySharesAgeTimer = nil
+	}
+
 	writeTimer := time.Tick(time.Second * 30)
 	// Do not process the writeTimer on DR Secondary nodes
 	if c.IsDRSecondary() {
@@ -50,6 +59,7 @@ func (c *Core) metricsLoop(stopCh chan struct{}) {
 	// vault.expire.num_leases
 	// vault.core.unsealed
 	// vault.identity.num_entities
+	// vault.core.key_shares.age_seconds
 	// and the non-telemetry request counters shown in the UI.
 	for {
 		select {
@@ -179,6 +189,12 @@ func (c *Core) metricsLoop(stopCh chan struct{}) {
 				}
 			}
 			c.stateLock.RUnlock()
+		case <-keySharesAgeTimer:
+			go func() {
+				ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+				defer cancel()
+				c.checkKeySharesAge(ctx)
+			}()
 		case <-identityCountTimer:
 			// TODO: this can be replaced by the identity gauge counter; we need to
 			// sum across all namespaces.
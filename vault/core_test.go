@@ -3668,14 +3668,14 @@ func TestBuildUnsealSetupFunctionSlice(t *testing.T) {
 			core: &Core{
 				replicationState: uint32Ptr(uint32(0)),
 			},
-			expectedLength: 25,
+			expectedLength: 30,
 		},
 		{
 			name: "dr secondary core",
 			core: &Core{
 				replicationState: uint32Ptr(uint32(consts.ReplicationDRSecondary)),
 			},
-			expectedLength: 14,
+			expectedLength: 19,
 		},
 	} {
 		funcs := buildUnsealSetupFunctionSlice(testcase.core)
@@ -3700,3 +3700,23 @@ func TestBarrier_DeadlockDetection(t *testing.T) {
 		t.Fatal("barrierLock doesn't have deadlock detection enabled, it should")
 	}
 }
+
+// TestCore_LogRedactor verifies that a redactor for streamed log messages is
+// only returned when log redaction is enabled in the server configuration.
+func TestCore_LogRedactor(t *testing.T) {
+	c, _, _ := TestCoreUnsealed(t)
+
+	c.rawConfig.Store(&server.Config{SharedConfig: &configutil.SharedConfig{}})
+	redactor, err := c.LogRedactor()
+	require.NoError(t, err)
+	require.Nil(t, redactor)
+
+	c.rawConfig.Store(&server.Config{SharedConfig: &configutil.SharedConfig{LogRedact: true}})
+	redactor, err = c.LogRedactor()
+	require.NoError(t, err)
+	require.NotNil(t, redactor)
+
+	redacted := string(redactor.Redact([]byte(`[DEBUG] token created: token=hvs.CAESIJ2kbJ5bCgQkHn0eBtZ8Ch1Nc3Rva2Vu`)))
+	require.Contains(t, redacted, "token created")
+	require.NotContains(t, redacted, "hvs.CAES")
+}
@@ -8,6 +8,8 @@ package vault
 import (
 	"context"
 	"fmt"
+	"strings"
+	"sync"
 
 	"github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/vault/helper/namespace"
@@ -171,22 +173,111 @@ func (c *Core) initSealsForMigration() {}
 
 func (c *Core) postSealMigration(ctx context.Context) error { return nil }
 
-func (c *Core) applyLeaseCountQuota(_ context.Context, in *quotas.Request) (*quotas.Response, error) {
-	return &quotas.Response{Allowed: true}, nil
+// applyLeaseCountQuota checks the request against the lease count quota
+// applicable to it.
+func (c *Core) applyLeaseCountQuota(ctx context.Context, in *quotas.Request) (*quotas.Response, error) {
+	if c.quotaManager == nil {
+		return &quotas.Response{Allowed: true}, nil
+	}
+
+	in.Type = quotas.TypeLeaseCount
+	resp, err := c.quotaManager.ApplyQuota(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
 }
 
+// ackLeaseQuota releases the lease reserved by the lease count quota which
+// allowed the request. If the quota expires its oldest leases once its limit is
+// reached, those leases are revoked to make room for the lease generated by the
+// request, if any.
 func (c *Core) ackLeaseQuota(access quotas.Access, leaseGenerated bool) error {
-	return nil
+	if c.quotaManager == nil {
+		return nil
+	}
+	return c.quotaManager.AckLease(access, leaseGenerated, func(leaseID string) error {
+		return c.expiration.LazyRevoke(namespace.RootContext(nil), leaseID)
+	})
 }
 
+// quotaLeaseWalker calls the callback with a quota request for every lease
+// known to the expiration manager, so that lease count quotas can be computed.
 func (c *Core) quotaLeaseWalker(ctx context.Context, callback func(request *quotas.Request) bool) error {
+	m := c.expiration
+	if m == nil {
+		return nil
+	}
+
+	pendingCallback := func(key, value interface{}) bool {
+		le := value.(pendingInfo).cachedLeaseInfo
+		if le == nil {
+			return true
+		}
+		return callback(c.quotaLeaseRequest(ctx, &quotas.QuotaLeaseInformation{
+			LeaseId:   key.(string),
+			Role:      le.LoginRole,
+			IssueTime: le.IssueTime,
+		}))
+	}
+	irrevocableCallback := func(key, value interface{}) bool {
+		le := value.(*leaseEntry)
+		if le == nil {
+			return true
+		}
+		return callback(c.quotaLeaseRequest(ctx, &quotas.QuotaLeaseInformation{
+			LeaseId:   key.(string),
+			Role:      le.LoginRole,
+			IssueTime: le.IssueTime,
+		}))
+	}
+
+	m.pendingLock.RLock()
+	toWalk := []*sync.Map{&m.pending, &m.nonexpiring}
+	irrevocable := &m.irrevocable
+	m.pendingLock.RUnlock()
+
+	for _, leases := range toWalk {
+		leases.Range(pendingCallback)
+	}
+	irrevocable.Range(irrevocableCallback)
+
 	return nil
 }
 
 func (c *Core) quotasHandleLeases(ctx context.Context, action quotas.LeaseAction, leases []*quotas.QuotaLeaseInformation) error {
+	if c.quotaManager == nil {
+		return nil
+	}
+
+	for _, lease := range leases {
+		if err := c.quotaManager.HandleLease(action, c.quotaLeaseRequest(ctx, lease)); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// quotaLeaseRequest builds the quota request used to find the lease count
+// quota applicable to the lease. The request path is the path the lease was
+// issued on, which is the lease ID without its trailing identifier.
+func (c *Core) quotaLeaseRequest(ctx context.Context, lease *quotas.QuotaLeaseInformation) *quotas.Request {
+	reqPath := lease.LeaseId
+	if idx := strings.LastIndex(reqPath, "/"); idx != -1 {
+		reqPath = reqPath[:idx]
+	}
+
+	return &quotas.Request{
+		Type:          quotas.TypeLeaseCount,
+		Path:          reqPath,
+		Role:          lease.Role,
+		NamespacePath: namespace.RootNamespace.Path,
+		MountPath:     c.router.MatchingMount(namespace.RootContext(ctx), reqPath),
+		Lease:         lease,
+	}
+}
+
 func (c *Core) namespaceByPath(path string) *namespace.Namespace {
 	return namespace.RootNamespace
 }
@@ -119,6 +119,15 @@ func (e extendedSystemViewImpl) SudoPrivilege(ctx context.Context, path string,
 		policies = append(policies, inlinePolicy)
 	}
 
+	if !te.NoIdentityPolicies {
+		adminPolicies, err := e.core.mountAdminPolicies(ctx, entity)
+		if err != nil {
+			e.core.logger.Error("failed to fetch mount admin policies", "error", err)
+			return false
+		}
+		policies = append(policies, adminPolicies...)
+	}
+
 	// Construct the corresponding ACL object. Derive and use a new context that
 	// uses the req.ClientToken's namespace
 	acl, err := e.core.policyStore.ACL(tokenCtx, entity, policyNames, policies...)
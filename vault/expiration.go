@@ -86,6 +86,10 @@ const (
 	MaxIrrevocableLeasesWarning = "Command halted because many irrevocable leases were found. To emit the entire list, re-run the command with force set true."
 )
 
+// errIrrevocableLeaseNotFound is returned when acting on a lease which is not
+// irrevocable
+var errIrrevocableLeaseNotFound = errors.New("lease not found among irrevocable leases")
+
 type pendingInfo struct {
 	// A subset of the lease entry, cached in memory
 	cachedLeaseInfo  *leaseEntry
@@ -258,7 +262,7 @@ func (r *revocationJob) OnFailure(err error) {
 			reason = "lease has consumed all retry attempts"
 			err = fmt.Errorf("%v: %w", outOfRetriesMessage, err)
 		}
-		r.m.logger.Trace("failed to revoke lease, marking lease as irrevocable", "lease_id", r.leaseID, "error", err, "reason", reason)
+		r.m.logger.Debug("failed to revoke lease, quarantining lease as irrevocable", "lease_id", r.leaseID, "error", err, "reason", reason)
 
 		le, loadErr := r.m.loadEntry(r.nsCtx, r.leaseID)
 		if loadErr != nil {
@@ -847,7 +851,7 @@ func (m *ExpirationManager) processRestore(ctx context.Context, leaseID string)
 
 	// Update quotas with relevant lease information
 	if le != nil {
-		leaseInfo := &quotas.QuotaLeaseInformation{LeaseId: le.LeaseID, Role: le.LoginRole}
+		leaseInfo := &quotas.QuotaLeaseInformation{LeaseId: le.LeaseID, Role: le.LoginRole, IssueTime: le.IssueTime}
 		if err := m.core.quotasHandleLeases(context.Background(), quotas.LeaseActionLoaded, []*quotas.QuotaLeaseInformation{leaseInfo}); err != nil {
 			// We don't want to fail the start-up due to leases not being able
 			// to be loaded into the quota manager. When the leases get loaded,
@@ -1273,7 +1277,14 @@ func (m *ExpirationManager) Renew(ctx context.Context, leaseID string, increment
 		return nil, err
 	}
 	for _, warning := range warnings {
-		resp.AddWarning(warning)
+		resp.AddWarningWithCode(logical.WarningCodeTTLTruncated, warning)
+	}
+	ttl, warnings, err = m.core.applyTTLPolicies(le.namespace, le.Path, ttl, le.IssueTime)
+	if err != nil {
+		return nil, err
+	}
+	for _, warning := range warnings {
+		resp.AddWarningWithCode(logical.WarningCodeTTLTruncated, warning)
 	}
 	resp.Secret.TTL = ttl
 
@@ -1406,7 +1417,14 @@ func (m *ExpirationManager) RenewToken(ctx context.Context, req *logical.Request
 	}
 	retResp := &logical.Response{}
 	for _, warning := range warnings {
-		retResp.AddWarning(warning)
+		retResp.AddWarningWithCode(logical.WarningCodeTTLTruncated, warning)
+	}
+	ttl, warnings, err = m.core.applyTTLPolicies(le.namespace, le.Path, ttl, le.IssueTime)
+	if err != nil {
+		return nil, err
+	}
+	for _, warning := range warnings {
+		retResp.AddWarningWithCode(logical.WarningCodeTTLTruncated, warning)
 	}
 	resp.Auth.TTL = ttl
 
@@ -1486,6 +1504,8 @@ func (m *ExpirationManager) Register(ctx context.Context, req *logical.Request,
 		Data:            resp.Data,
 		Secret:          resp.Secret,
 		LoginRole:       loginRole,
+		ClientName:      req.ClientName,
+		ClientVersion:   req.ClientVersion,
 		IssueTime:       time.Now(),
 		ExpireTime:      resp.Secret.ExpirationTime(),
 		namespace:       ns,
@@ -1627,15 +1647,17 @@ func (m *ExpirationManager) RegisterAuth(ctx context.Context, te *logical.TokenE
 
 	// Create a lease entry
 	le := leaseEntry{
-		LeaseID:     leaseID,
-		ClientToken: auth.ClientToken,
-		Auth:        auth,
-		Path:        te.Path,
-		LoginRole:   loginRole,
-		IssueTime:   time.Now(),
-		ExpireTime:  authExpirationTime,
-		namespace:   tokenNS,
-		Version:     1,
+		LeaseID:       leaseID,
+		ClientToken:   auth.ClientToken,
+		Auth:          auth,
+		Path:          te.Path,
+		LoginRole:     loginRole,
+		ClientName:    auth.Metadata[tokenMetaClientName],
+		ClientVersion: auth.Metadata[tokenMetaClientVersion],
+		IssueTime:     time.Now(),
+		ExpireTime:    authExpirationTime,
+		namespace:     tokenNS,
+		Version:       1,
 	}
 
 	leaseLock := m.lockForLeaseID(leaseID)
@@ -1735,6 +1757,8 @@ func (m *ExpirationManager) leaseTimesForExport(le *leaseEntry) *leaseEntry {
 		IssueTime:       le.IssueTime,
 		ExpireTime:      le.ExpireTime,
 		LastRenewalTime: le.LastRenewalTime,
+		ClientName:      le.ClientName,
+		ClientVersion:   le.ClientVersion,
 	}
 	if le.Secret != nil {
 		ret.Secret = &logical.Secret{}
@@ -1856,7 +1880,7 @@ func (m *ExpirationManager) updatePendingInternal(le *leaseEntry) {
 			// accurately update quota lease information.
 			// Note that cachedLeaseInfo should never be nil under normal operation.
 			if pending.cachedLeaseInfo != nil {
-				leaseInfo := &quotas.QuotaLeaseInformation{LeaseId: le.LeaseID, Role: le.LoginRole}
+				leaseInfo := &quotas.QuotaLeaseInformation{LeaseId: le.LeaseID, Role: le.LoginRole, IssueTime: le.IssueTime}
 				if err := m.core.quotasHandleLeases(m.quitContext, quotas.LeaseActionDeleted, []*quotas.QuotaLeaseInformation{leaseInfo}); err != nil {
 					m.logger.Error("failed to update quota on lease deletion", "error", err)
 					return
@@ -1923,7 +1947,7 @@ func (m *ExpirationManager) updatePendingInternal(le *leaseEntry) {
 			// accurately update quota lease information.
 			// Note that cachedLeaseInfo should never be nil under normal operation.
 			if pending.cachedLeaseInfo != nil {
-				leaseInfo := &quotas.QuotaLeaseInformation{LeaseId: le.LeaseID, Role: le.LoginRole}
+				leaseInfo := &quotas.QuotaLeaseInformation{LeaseId: le.LeaseID, Role: le.LoginRole, IssueTime: le.IssueTime}
 				if err := m.core.quotasHandleLeases(m.quitContext, quotas.LeaseActionCreated, []*quotas.QuotaLeaseInformation{leaseInfo}); err != nil {
 					m.logger.Error("failed to update quota on lease creation", "error", err)
 					return
@@ -2567,6 +2591,17 @@ func (m *ExpirationManager) markLeaseIrrevocable(ctx context.Context, le *leaseE
 		return
 	}
 
+	le.RevokeErr = irrevocableErrorString(err)
+	m.persistEntry(ctx, le)
+
+	m.irrevocable.Store(le.LeaseID, m.inMemoryLeaseInfo(le))
+	m.irrevocableLeaseCount++
+	m.removeFromPending(ctx, le.LeaseID, false)
+	m.nonexpiring.Delete(le.LeaseID)
+}
+
+// irrevocableErrorString returns the error recorded on an irrevocable lease.
+func irrevocableErrorString(err error) string {
 	var errStr string
 	if err != nil {
 		errStr = err.Error()
@@ -2577,14 +2612,58 @@ func (m *ExpirationManager) markLeaseIrrevocable(ctx context.Context, le *leaseE
 	if len(errStr) > maxIrrevocableErrorLength {
 		errStr = errStr[:maxIrrevocableErrorLength]
 	}
+	return errStr
+}
 
-	le.RevokeErr = errStr
-	m.persistEntry(ctx, le)
+// RetryIrrevocable attempts again to revoke an irrevocable lease. If the
+// revocation fails again, the lease stays irrevocable and its error is updated.
+func (m *ExpirationManager) RetryIrrevocable(ctx context.Context, leaseID string) error {
+	if _, ok := m.irrevocable.Load(leaseID); !ok {
+		return errIrrevocableLeaseNotFound
+	}
 
-	m.irrevocable.Store(le.LeaseID, m.inMemoryLeaseInfo(le))
-	m.irrevocableLeaseCount++
-	m.removeFromPending(ctx, le.LeaseID, false)
-	m.nonexpiring.Delete(le.LeaseID)
+	revokeErr := m.revokeCommon(ctx, leaseID, false, false)
+	if revokeErr == nil {
+		return nil
+	}
+
+	leaseLock := m.lockForLeaseID(leaseID)
+	leaseLock.Lock()
+	defer leaseLock.Unlock()
+
+	le, err := m.loadEntry(ctx, leaseID)
+	if err != nil {
+		return err
+	}
+	if le != nil {
+		le.RevokeErr = irrevocableErrorString(revokeErr)
+		if err := m.persistEntry(ctx, le); err != nil {
+			return err
+		}
+
+		m.pendingLock.Lock()
+		if _, ok := m.irrevocable.Load(leaseID); ok {
+			m.irrevocable.Store(leaseID, m.inMemoryLeaseInfo(le))
+		}
+		m.pendingLock.Unlock()
+	}
+
+	return revokeErr
+}
+
+// AcknowledgeIrrevocable removes an irrevocable lease without revoking it in
+// its backend, once an operator has dealt with the secret out of band.
+func (m *ExpirationManager) AcknowledgeIrrevocable(ctx context.Context, leaseID string) error {
+	if _, ok := m.irrevocable.Load(leaseID); !ok {
+		return errIrrevocableLeaseNotFound
+	}
+
+	if err := m.revokeCommon(ctx, leaseID, true, false); err != nil {
+		return err
+	}
+
+	m.logger.Info("irrevocable lease acknowledged and removed", "lease_id", leaseID)
+	return nil
 }
 
 func (m *ExpirationManager) getNamespaceFromLeaseID(ctx context.Context, leaseID string) (*namespace.Namespace, error) {
@@ -2670,6 +2749,59 @@ func (m *ExpirationManager) getIrrevocableLeaseCounts(ctx context.Context, inclu
 	return resp, nil
 }
 
+// getLeaseClientCounts counts the pending leases of the namespace per client
+// name and version, as recorded from the client identification headers of the
+// requests that created them. Leases created without a client name are not
+// counted.
+func (m *ExpirationManager) getLeaseClientCounts(ctx context.Context, includeChildNamespaces bool) (map[string]interface{}, error) {
+	requestNS, err := namespace.FromContext(ctx)
+	if err != nil {
+		m.logger.Error("could not get namespace from context", "error", err)
+		return nil, err
+	}
+
+	numMatchingLeasesPerClient := make(map[string]map[string]int)
+	numMatchingLeases := 0
+	callback := func(k, v interface{}) bool {
+		lease := v.(pendingInfo).cachedLeaseInfo
+		if lease == nil || lease.ClientName == "" {
+			return true
+		}
+
+		leaseNS, err := m.getNamespaceFromLeaseID(ctx, k.(string))
+		if err != nil {
+			m.logger.Warn("could not get lease namespace from ID", "error", err)
+			return true
+		}
+		if leaseNS.ID != requestNS.ID && !(includeChildNamespaces && leaseNS.HasParent(requestNS)) {
+			return true
+		}
+
+		versions, ok := numMatchingLeasesPerClient[lease.ClientName]
+		if !ok {
+			versions = make(map[string]int)
+			numMatchingLeasesPerClient[lease.ClientName] = versions
+		}
+		versions[lease.ClientVersion]++
+		numMatchingLeases++
+
+		return true
+	}
+
+	m.pendingLock.RLock()
+	toWalk := []*sync.Map{&m.pending, &m.nonexpiring}
+	m.pendingLock.RUnlock()
+
+	for _, m := range toWalk {
+		m.Range(callback)
+	}
+
+	return map[string]interface{}{
+		"lease_count": numMatchingLeases,
+		"clients":     numMatchingLeasesPerClient,
+	}, nil
+}
+
 type leaseResponse struct {
 	LeaseID    string `json:"lease_id"`
 	MountID    string `json:"mount_id"`
@@ -2763,6 +2895,12 @@ type leaseEntry struct {
 	// based on login roles upon lease expiry.
 	LoginRole string `json:"login_role"`
 
+	// ClientName and ClientVersion identify the application that made the
+	// request which created the lease, as supplied in the client
+	// identification headers.
+	ClientName    string `json:"client_name,omitempty"`
+	ClientVersion string `json:"client_version,omitempty"`
+
 	// Version is used to track new different versions of leases. V0 (or
 	// zero-value) had non-root namespaced secondary indexes live in the root
 	// namespace, and V1 has secondary indexes live in the matching namespace.
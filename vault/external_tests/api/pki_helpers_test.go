@@ -0,0 +1,130 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package api
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/builtin/logical/pki"
+	vaulthttp "github.com/hashicorp/vault/http"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/hashicorp/vault/vault"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPKIHelpers(t *testing.T) {
+	t.Parallel()
+
+	coreConfig := &vault.CoreConfig{
+		LogicalBackends: map[string]logical.Factory{
+			"pki": pki.Factory,
+		},
+	}
+
+	cluster := vault.NewTestCluster(t, coreConfig, &vault.TestClusterOptions{
+		HandlerFunc: vaulthttp.Handler,
+	})
+	cluster.Start()
+	defer cluster.Cleanup()
+
+	core := cluster.Cores[0].Core
+	client := cluster.Cores[0].Client
+	vault.TestWaitActive(t, core)
+
+	ctx := context.Background()
+	err := client.Sys().MountWithContext(ctx, "pki", &api.MountInput{
+		Type: "pki",
+		Config: api.MountConfigInput{
+			MaxLeaseTTL: "87600h",
+		},
+	})
+	require.NoError(t, err)
+
+	_, err = client.Logical().WriteWithContext(ctx, "pki/root/generate/internal", map[string]interface{}{
+		"common_name": "root.example.com",
+		"issuer_name": "root",
+		"ttl":         "8760h",
+	})
+	require.NoError(t, err)
+	_, err = client.Logical().WriteWithContext(ctx, "pki/roles/example", map[string]interface{}{
+		"allowed_domains":  "example.com",
+		"allow_subdomains": true,
+		"max_ttl":          "24h",
+		"key_type":         "ec",
+		"key_bits":         256,
+	})
+	require.NoError(t, err)
+
+	pkiClient := client.PKI("pki")
+
+	t.Run("fetch CA chain", func(t *testing.T) {
+		chain, err := pkiClient.FetchCAChain(ctx)
+		require.NoError(t, err)
+		require.Len(t, chain, 1)
+		require.Equal(t, "root.example.com", chain[0].Subject.CommonName)
+	})
+
+	t.Run("issue certificate", func(t *testing.T) {
+		cert, err := pkiClient.IssueCertificate(ctx, "example", &api.PKIIssueRequest{
+			IssuerRef:  "root",
+			CommonName: "www.example.com",
+			AltNames:   []string{"api.example.com"},
+			TTL:        time.Hour,
+		})
+		require.NoError(t, err)
+		require.Equal(t, "www.example.com", cert.Certificate.Subject.CommonName)
+		require.ElementsMatch(t, []string{"www.example.com", "api.example.com"}, cert.Certificate.DNSNames)
+		require.Equal(t, "root.example.com", cert.IssuingCA.Subject.CommonName)
+		require.Len(t, cert.CAChain, 1)
+		require.Equal(t, "ec", cert.PrivateKeyType)
+		require.NotEmpty(t, cert.PrivateKeyPEM)
+		require.Equal(t, cert.Certificate.NotAfter.Unix(), cert.Expiration.Unix())
+		require.NoError(t, cert.Certificate.CheckSignatureFrom(cert.IssuingCA))
+
+		revokedAt, err := pkiClient.RevokeBySerial(ctx, cert.SerialNumber)
+		require.NoError(t, err)
+		require.WithinDuration(t, time.Now(), revokedAt, time.Minute)
+	})
+
+	t.Run("sign CSR", func(t *testing.T) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		csrDER, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+			Subject: pkix.Name{CommonName: "csr.example.com"},
+		}, key)
+		require.NoError(t, err)
+		csr, err := x509.ParseCertificateRequest(csrDER)
+		require.NoError(t, err)
+
+		cert, err := pkiClient.SignCSR(ctx, "example", csr, nil)
+		require.NoError(t, err)
+		require.Equal(t, "csr.example.com", cert.Certificate.Subject.CommonName)
+		require.Equal(t, &key.PublicKey, cert.Certificate.PublicKey)
+		require.Empty(t, cert.PrivateKeyPEM)
+
+		// The role constraints apply to the subject of the request
+		csrDER, err = x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+			Subject: pkix.Name{CommonName: "www.other.com"},
+		}, key)
+		require.NoError(t, err)
+		csr, err = x509.ParseCertificateRequest(csrDER)
+		require.NoError(t, err)
+
+		_, err = pkiClient.SignCSR(ctx, "example", csr, nil)
+		require.Error(t, err)
+	})
+
+	t.Run("revoke unknown serial", func(t *testing.T) {
+		_, err := pkiClient.RevokeBySerial(ctx, "00:11:22")
+		require.Error(t, err)
+	})
+}
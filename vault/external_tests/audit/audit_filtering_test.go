@@ -0,0 +1,73 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package audit
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/helper/testhelpers/minimal"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAuditFiltering ensures that an audit device enabled with the 'filter'
+// option only writes the audit entries matching the filter.
+func TestAuditFiltering(t *testing.T) {
+	t.Parallel()
+	cluster := minimal.NewTestSoloCluster(t, nil)
+	client := cluster.Cores[0].Client
+
+	// Create an audit device that only audits requests to a single policy.
+	logFile, err := os.CreateTemp(t.TempDir(), "")
+	require.NoError(t, err)
+	filterDevicePath := "filtered"
+	filterDeviceData := map[string]any{
+		"type":        "file",
+		"description": "",
+		"local":       false,
+		"options": map[string]any{
+			"file_path": logFile.Name(),
+			"filter":    `path == "sys/policies/acl/filtered"`,
+		},
+	}
+	_, err = client.Logical().Write("sys/audit/"+filterDevicePath, filterDeviceData)
+	require.NoError(t, err)
+
+	devices, err := client.Sys().ListAudit()
+	require.NoError(t, err)
+	require.Len(t, devices, 1)
+	require.Equal(t, `path == "sys/policies/acl/filtered"`, devices[filterDevicePath+"/"].Options["filter"])
+
+	// Requests filtered out by the only audit device must still succeed.
+	for _, name := range []string{"unfiltered", "filtered"} {
+		err = client.Sys().PutPolicy(name, `path "secret/*" { capabilities = ["read"] }`)
+		require.NoError(t, err)
+	}
+
+	logged, err := os.ReadFile(logFile.Name())
+	require.NoError(t, err)
+	var paths []string
+	for _, line := range strings.Split(strings.TrimSpace(string(logged)), "\n") {
+		var entry struct {
+			Type    string `json:"type"`
+			Request struct {
+				Path string `json:"path"`
+			} `json:"request"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		paths = append(paths, entry.Type+" "+entry.Request.Path)
+	}
+	require.Equal(t, []string{
+		"request sys/policies/acl/filtered",
+		"response sys/policies/acl/filtered",
+	}, paths)
+
+	// A device cannot be created with an invalid filter.
+	filterDeviceData["options"].(map[string]any)["filter"] = "foo == bar"
+	_, err = client.Logical().Write("sys/audit/invalid", filterDeviceData)
+	require.Error(t, err)
+	require.ErrorContains(t, err, "filter references an unsupported field")
+}
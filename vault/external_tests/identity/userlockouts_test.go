@@ -148,6 +148,9 @@ func TestIdentityStore_DisableUserLockoutTest(t *testing.T) {
 				if !strings.Contains(err.Error(), "invalid username or password") {
 					t.Fatal(err)
 				}
+				if respErr, ok := err.(*api.ResponseError); !ok || respErr.ErrorCode != string(logical.AuthErrorCodeInvalidCredentials) {
+					t.Fatalf("expected invalid credentials error code, got %v", err)
+				}
 			}
 
 			// login to check if user locked
@@ -163,6 +166,9 @@ func TestIdentityStore_DisableUserLockoutTest(t *testing.T) {
 				if !strings.Contains(err.Error(), logical.ErrPermissionDenied.Error()) {
 					t.Fatalf("expected user to get locked but got %v", err)
 				}
+				if respErr, ok := err.(*api.ResponseError); !ok || respErr.ErrorCode != string(logical.AuthErrorCodeLockedOut) {
+					t.Fatalf("expected locked out error code, got %v", err)
+				}
 				// user locked, unlock user to perform next test iteration
 				if _, err = client.Logical().Write("sys/locked-users/"+mountAccessor+"/unlock/bsmith", nil); err != nil {
 					t.Fatal(err)
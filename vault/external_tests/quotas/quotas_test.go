@@ -4,6 +4,7 @@
 package quotas
 
 import (
+	"encoding/json"
 	"fmt"
 	"testing"
 	"time"
@@ -541,3 +542,82 @@ func TestQuotas_RateLimitQuota(t *testing.T) {
 		t.Fatalf("unexpected number of failed requests: %d", numFail)
 	}
 }
+
+func TestQuotas_LeaseCountQuota(t *testing.T) {
+	conf, opts := teststorage.ClusterSetup(coreConfig, nil, nil)
+	opts.NoDefaultQuotas = true
+	cluster := vault.NewTestCluster(t, conf, opts)
+	cluster.Start()
+	defer cluster.Cleanup()
+
+	core := cluster.Cores[0].Core
+	client := cluster.Cores[0].Client
+	vault.TestWaitActive(t, core)
+
+	setupMounts(t, client)
+
+	login := func() (string, error) {
+		secret, err := client.Logical().Write("auth/userpass/login/foo", map[string]interface{}{
+			"password": "bar",
+		})
+		if err != nil {
+			return "", err
+		}
+		return secret.Auth.ClientToken, nil
+	}
+
+	_, err := client.Logical().Write("sys/quotas/lease-count/lcq", map[string]interface{}{
+		"max_leases": 2,
+		"path":       "auth/userpass",
+	})
+	require.NoError(t, err)
+
+	oldest, err := login()
+	require.NoError(t, err)
+	newest, err := login()
+	require.NoError(t, err)
+
+	// The quota is reached, further logins are rejected
+	_, err = login()
+	require.ErrorContains(t, err, "lease count quota exceeded")
+
+	s, err := client.Logical().Read("sys/quotas/lease-count/lcq")
+	require.NoError(t, err)
+	require.Equal(t, "auth/userpass/", s.Data["path"])
+	require.Equal(t, json.Number("2"), s.Data["max_leases"])
+	require.Equal(t, json.Number("2"), s.Data["counter"])
+	require.Equal(t, false, s.Data["expire_oldest"])
+
+	// Revoking a lease makes room for a new one
+	require.NoError(t, client.Auth().Token().RevokeOrphan(newest))
+	_, err = login()
+	require.NoError(t, err)
+
+	// Once the oldest lease is expired by the quota, new logins are allowed
+	_, err = client.Logical().Write("sys/quotas/lease-count/lcq", map[string]interface{}{
+		"max_leases":    2,
+		"path":          "auth/userpass",
+		"expire_oldest": true,
+	})
+	require.NoError(t, err)
+
+	_, err = login()
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		_, err := client.Auth().Token().Lookup(oldest)
+		return err != nil
+	}, 10*time.Second, 100*time.Millisecond)
+
+	require.Eventually(t, func() bool {
+		s, err := client.Logical().Read("sys/quotas/lease-count/lcq")
+		return err == nil && s.Data["counter"] == json.Number("2")
+	}, 10*time.Second, 100*time.Millisecond)
+
+	// Logins are not limited once the quota is removed
+	_, err = client.Logical().Delete("sys/quotas/lease-count/lcq")
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		_, err = login()
+		require.NoError(t, err)
+	}
+}
@@ -11,6 +11,8 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"os"
+	"path/filepath"
 	"strings"
 	"sync"
 	"testing"
@@ -484,6 +486,84 @@ func TestRaft_SnapshotAPI(t *testing.T) {
 	}
 }
 
+// TestRaft_SnapshotAuto verifies that automated snapshots are written to the
+// configured local directory, that only the configured number of them are
+// retained, and that the status reports on them.
+func TestRaft_SnapshotAuto(t *testing.T) {
+	t.Parallel()
+	cluster, _ := raftCluster(t, nil)
+	defer cluster.Cleanup()
+
+	leaderClient := cluster.Cores[0].Client
+	dir := t.TempDir()
+
+	_, err := leaderClient.Logical().Write("sys/storage/raft/snapshot-auto/config/hourly", map[string]interface{}{
+		"interval":     "1h",
+		"storage_type": "azure-blob",
+		"path_prefix":  dir,
+	})
+	require.Error(t, err)
+
+	_, err = leaderClient.Logical().Write("sys/storage/raft/snapshot-auto/config/frequent", map[string]interface{}{
+		"interval":        "1s",
+		"retain":          2,
+		"storage_type":    "local",
+		"path_prefix":     dir,
+		"local_max_space": 100 * 1024 * 1024,
+	})
+	require.NoError(t, err)
+
+	list, err := leaderClient.Logical().List("sys/storage/raft/snapshot-auto/config")
+	require.NoError(t, err)
+	require.Equal(t, []interface{}{"frequent"}, list.Data["keys"])
+
+	config, err := leaderClient.Logical().Read("sys/storage/raft/snapshot-auto/config/frequent")
+	require.NoError(t, err)
+	require.Equal(t, "local", config.Data["storage_type"])
+	require.Equal(t, "vault-snapshot", config.Data["file_prefix"])
+
+	// Wait for more snapshots to have been taken than are retained.
+	var status *api.Secret
+	corehelpers.RetryUntil(t, 30*time.Second, func() error {
+		status, err = leaderClient.Logical().Read("sys/storage/raft/snapshot-auto/status/frequent")
+		if err != nil {
+			return err
+		}
+		if status == nil || status.Data["last_snapshot_url"] == "" {
+			return errors.New("no snapshot taken yet")
+		}
+		if status.Data["last_snapshot_error"] != "" {
+			return fmt.Errorf("snapshot failed: %v", status.Data["last_snapshot_error"])
+		}
+
+		matches, err := filepath.Glob(filepath.Join(dir, "vault-snapshot-*.snap"))
+		if err != nil {
+			return err
+		}
+		if len(matches) != 2 {
+			return fmt.Errorf("expected 2 snapshots, found %d", len(matches))
+		}
+		for _, match := range matches {
+			info, err := os.Stat(match)
+			if err != nil {
+				return err
+			}
+			if info.Size() == 0 {
+				return fmt.Errorf("snapshot %s is empty", match)
+			}
+		}
+		return nil
+	})
+	require.True(t, strings.HasPrefix(status.Data["last_snapshot_url"].(string), "file://"+dir))
+
+	_, err = leaderClient.Logical().Delete("sys/storage/raft/snapshot-auto/config/frequent")
+	require.NoError(t, err)
+
+	status, err = leaderClient.Logical().Read("sys/storage/raft/snapshot-auto/status/frequent")
+	require.NoError(t, err)
+	require.Nil(t, status)
+}
+
 func TestRaft_SnapshotAPI_MidstreamFailure(t *testing.T) {
 	// defer goleak.VerifyNone(t)
 	t.Parallel()
@@ -0,0 +1,125 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package trustbundle
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"strings"
+	"testing"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/helper/pkcs7"
+	"github.com/hashicorp/vault/helper/testhelpers/minimal"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrustBundle(t *testing.T) {
+	cluster := minimal.NewTestSoloCluster(t, nil)
+	client := cluster.Cores[0].Client
+
+	require.NoError(t, client.Sys().Mount("pki", &api.MountInput{Type: "pki"}))
+	require.NoError(t, client.Sys().Mount("ssh", &api.MountInput{Type: "ssh"}))
+
+	resp, err := client.Logical().Write("pki/root/generate/internal", map[string]interface{}{
+		"common_name": "example.com",
+	})
+	require.NoError(t, err)
+	rootCA := strings.TrimSpace(resp.Data["certificate"].(string))
+
+	resp, err = client.Logical().Write("ssh/config/ca", map[string]interface{}{
+		"generate_signing_key": true,
+	})
+	require.NoError(t, err)
+	sshPublicKey := strings.TrimSpace(resp.Data["public_key"].(string))
+
+	_, err = client.Logical().Write("sys/trust-bundles/hosts", map[string]interface{}{
+		"pki_mounts": "pki",
+		"ssh_mounts": "ssh",
+	})
+	require.NoError(t, err)
+
+	resp, err = client.Logical().Read("sys/trust-bundles/hosts")
+	require.NoError(t, err)
+	require.Equal(t, []interface{}{"pki/"}, resp.Data["pki_mounts"])
+	require.Equal(t, []interface{}{"ssh/"}, resp.Data["ssh_mounts"])
+	block, _ := pem.Decode([]byte(resp.Data["signing_certificate"].(string)))
+	require.NotNil(t, block)
+	signingCert, err := x509.ParseCertificate(block.Bytes)
+	require.NoError(t, err)
+
+	// The signing key is kept across updates
+	_, err = client.Logical().Write("sys/trust-bundles/hosts", map[string]interface{}{
+		"pki_mounts": "pki",
+	})
+	require.NoError(t, err)
+
+	resp, err = client.Logical().Read("sys/trust-bundles/hosts/bundle")
+	require.NoError(t, err)
+	require.Equal(t, "json", resp.Data["format"])
+	require.Equal(t, string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: signingCert.Raw})), resp.Data["signing_certificate"].(string)+"\n")
+
+	jws, err := jose.ParseSigned(resp.Data["bundle"].(string))
+	require.NoError(t, err)
+	payload, err := jws.Verify(signingCert.PublicKey)
+	require.NoError(t, err)
+
+	var doc struct {
+		Name string `json:"name"`
+		PKI  []struct {
+			Mount   string   `json:"mount"`
+			CAChain []string `json:"ca_chain"`
+		} `json:"pki"`
+		SSH []struct {
+			Mount     string `json:"mount"`
+			PublicKey string `json:"public_key"`
+		} `json:"ssh"`
+	}
+	require.NoError(t, json.Unmarshal(payload, &doc))
+	require.Equal(t, "hosts", doc.Name)
+	require.Len(t, doc.PKI, 1)
+	require.Equal(t, "pki/", doc.PKI[0].Mount)
+	require.Equal(t, []string{rootCA}, doc.PKI[0].CAChain)
+	require.Len(t, doc.SSH, 1)
+	require.Equal(t, "ssh/", doc.SSH[0].Mount)
+	require.Equal(t, sshPublicKey, doc.SSH[0].PublicKey)
+
+	resp, err = client.Logical().ReadWithData("sys/trust-bundles/hosts/bundle", map[string][]string{
+		"format": {"cms"},
+	})
+	require.NoError(t, err)
+	der, err := base64.StdEncoding.DecodeString(resp.Data["bundle"].(string))
+	require.NoError(t, err)
+	p7, err := pkcs7.Parse(der)
+	require.NoError(t, err)
+	require.NoError(t, p7.Verify())
+	require.Equal(t, signingCert.Raw, p7.GetOnlySigner().Raw)
+	require.NoError(t, json.Unmarshal(p7.Content, &doc))
+	require.Equal(t, []string{rootCA}, doc.PKI[0].CAChain)
+
+	resp, err = client.Logical().List("sys/trust-bundles")
+	require.NoError(t, err)
+	require.Equal(t, []interface{}{"hosts"}, resp.Data["keys"])
+
+	for name, data := range map[string]map[string]interface{}{
+		"no mounts":      {"pki_mounts": ""},
+		"unknown mount":  {"pki_mounts": "nonexistent"},
+		"wrong type":     {"pki_mounts": "ssh"},
+		"mount sub-path": {"ssh_mounts": "ssh/foo"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := client.Logical().Write("sys/trust-bundles/invalid", data)
+			require.Error(t, err)
+		})
+	}
+
+	_, err = client.Logical().Delete("sys/trust-bundles/hosts")
+	require.NoError(t, err)
+	resp, err = client.Logical().Read("sys/trust-bundles/hosts/bundle")
+	require.NoError(t, err)
+	require.Nil(t, resp)
+}
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/armon/go-metrics"
+	"github.com/go-jose/go-jose/v3/jwt"
 	"github.com/golang/protobuf/ptypes"
 	log "github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/go-memdb"
@@ -121,6 +122,7 @@ func NewIdentityStore(ctx context.Context, core *Core, config *logical.BackendCo
 		},
 		PeriodicFunc: func(ctx context.Context, req *logical.Request) error {
 			iStore.oidcPeriodicFunc(ctx)
+			iStore.tidyEntityAPITokens(ctx)
 
 			return nil
 		},
@@ -129,6 +131,7 @@ func NewIdentityStore(ctx context.Context, core *Core, config *logical.BackendCo
 
 	iStore.oidcCache = newOIDCCache(cache.NoExpiration, cache.NoExpiration)
 	iStore.oidcAuthCodeCache = newOIDCCache(5*time.Minute, 5*time.Minute)
+	iStore.oidcClientAssertionCache = newOIDCCache(maxClientAssertionLifetime+jwt.DefaultLeeway, 5*time.Minute)
 
 	err = iStore.Setup(ctx, config)
 	if err != nil {
@@ -141,6 +144,7 @@ func NewIdentityStore(ctx context.Context, core *Core, config *logical.BackendCo
 func (i *IdentityStore) paths() []*framework.Path {
 	return framework.PathAppend(
 		entityPaths(i),
+		entityAPITokenPaths(i),
 		aliasPaths(i),
 		groupAliasPaths(i),
 		groupPaths(i),
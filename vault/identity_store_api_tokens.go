@@ -0,0 +1,492 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-secure-stdlib/strutil"
+	"github.com/hashicorp/vault/helper/identity"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/consts"
+	"github.com/hashicorp/vault/sdk/helper/policyutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	// apiTokenPathPrefix is the storage prefix under which API token records
+	// are kept, keyed by entity ID and token name
+	apiTokenPathPrefix = "api-tokens/"
+
+	apiTokenNameMeta = "api_token_name"
+
+	// apiTokenTidyInterval is how often the records of expired API tokens
+	// are removed by the periodic func of the identity store
+	apiTokenTidyInterval = time.Hour
+)
+
+// entityAPIToken is the record of a token issued to an entity through the
+// identity store. The token itself lives in the token store; the record only
+// tracks it so it can be listed and revoked by name.
+type entityAPIToken struct {
+	Name           string    `json:"name"`
+	EntityID       string    `json:"entity_id"`
+	Accessor       string    `json:"accessor"`
+	Policies       []string  `json:"policies"`
+	CreationTime   time.Time `json:"creation_time"`
+	ExpirationTime time.Time `json:"expiration_time"`
+}
+
+func (t *entityAPIToken) expired(now time.Time) bool {
+	return now.After(t.ExpirationTime)
+}
+
+func (t *entityAPIToken) responseData() map[string]interface{} {
+	return map[string]interface{}{
+		"name":            t.Name,
+		"entity_id":       t.EntityID,
+		"accessor":        t.Accessor,
+		"policies":        t.Policies,
+		"creation_time":   t.CreationTime.Format(time.RFC3339),
+		"expiration_time": t.ExpirationTime.Format(time.RFC3339),
+	}
+}
+
+// entityAPITokenPaths returns the API endpoints to manage the API tokens of
+// entities.
+// Following are the paths supported:
+// entity/:id/tokens - To list the API tokens of an entity
+// entity/:id/tokens/:name - To create, read and revoke an API token
+func entityAPITokenPaths(i *IdentityStore) []*framework.Path {
+	return []*framework.Path{
+		{
+			Pattern: "entity/" + framework.GenericNameRegex("id") + "/tokens/" + framework.GenericNameRegex("name"),
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "entity",
+				OperationSuffix: "api-token",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"id": {
+					Type:        framework.TypeString,
+					Description: "ID of the entity.",
+				},
+				"name": {
+					Type:        framework.TypeString,
+					Description: "Name of the API token.",
+				},
+				"policies": {
+					Type:        framework.TypeCommaStringSlice,
+					Description: "Policies to scope the token to. Each must be attached to the entity, directly or through its groups, or be the default policy. Required.",
+				},
+				"ttl": {
+					Type:        framework.TypeDurationSecond,
+					Description: "Lifetime of the token. Defaults to, and cannot exceed, the system max lease TTL.",
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: i.pathEntityAPITokenCreate(),
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "create",
+					},
+				},
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: i.pathEntityAPITokenRead(),
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "read",
+					},
+				},
+				logical.DeleteOperation: &framework.PathOperation{
+					Callback: i.pathEntityAPITokenRevoke(),
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "revoke",
+					},
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(entityHelp["entity-api-token"][0]),
+			HelpDescription: strings.TrimSpace(entityHelp["entity-api-token"][1]),
+		},
+		{
+			Pattern: "entity/" + framework.GenericNameRegex("id") + "/tokens/?$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "entity",
+				OperationSuffix: "api-tokens",
+				OperationVerb:   "list",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"id": {
+					Type:        framework.TypeString,
+					Description: "ID of the entity.",
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ListOperation: &framework.PathOperation{
+					Callback: i.pathEntityAPITokenList(),
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(entityHelp["entity-api-token-list"][0]),
+			HelpDescription: strings.TrimSpace(entityHelp["entity-api-token-list"][1]),
+		},
+	}
+}
+
+// entityForAPITokens returns the entity with the given ID if it belongs to the
+// namespace of the request.
+func (i *IdentityStore) entityForAPITokens(ctx context.Context, entityID string) (*identity.Entity, error) {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	entity, err := i.MemDBEntityByID(entityID, false)
+	if err != nil {
+		return nil, err
+	}
+	if entity == nil || entity.NamespaceID != ns.ID {
+		return nil, nil
+	}
+
+	return entity, nil
+}
+
+func (i *IdentityStore) pathEntityAPITokenCreate() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		entityID := d.Get("id").(string)
+		name := d.Get("name").(string)
+
+		entity, err := i.entityForAPITokens(ctx, entityID)
+		if err != nil {
+			return nil, err
+		}
+		if entity == nil {
+			return logical.ErrorResponse("entity %q not found", entityID), nil
+		}
+		if entity.Disabled {
+			return logical.ErrorResponse("entity %q is disabled", entityID), nil
+		}
+
+		policies := policyutil.SanitizePolicies(d.Get("policies").([]string), policyutil.DoNotAddDefaultPolicy)
+		if len(policies) == 0 {
+			return logical.ErrorResponse("at least one policy is required"), nil
+		}
+
+		// The token can only carry a subset of the policies the entity holds
+		// in its namespace
+		groupPolicies, err := i.groupPoliciesByEntityID(entity.ID)
+		if err != nil {
+			return nil, err
+		}
+		allowed := append(append([]string{"default"}, entity.Policies...), groupPolicies[entity.NamespaceID]...)
+		for _, policy := range policies {
+			if policy == "root" || !strutil.StrListContains(allowed, policy) {
+				return logical.ErrorResponse("policy %q is not attached to entity %q", policy, entityID), nil
+			}
+		}
+
+		maxTTL := i.System().MaxLeaseTTL()
+		ttl := time.Duration(d.Get("ttl").(int)) * time.Second
+		switch {
+		case ttl < 0:
+			return logical.ErrorResponse("ttl must not be negative"), nil
+		case ttl == 0:
+			ttl = maxTTL
+		case ttl > maxTTL:
+			return logical.ErrorResponse("ttl %s exceeds the max lease TTL of %s", ttl, maxTTL), nil
+		}
+
+		i.apiTokenLock.Lock()
+		defer i.apiTokenLock.Unlock()
+
+		existing, err := i.entityAPIToken(ctx, entity.ID, name)
+		if err != nil {
+			return nil, err
+		}
+		if existing != nil {
+			return logical.ErrorResponse("API token %q already exists for entity %q", name, entityID), nil
+		}
+
+		now := time.Now()
+		te := &logical.TokenEntry{
+			Type:               logical.TokenTypeService,
+			Path:               req.MountPoint + req.Path,
+			DisplayName:        "api-token-" + name,
+			Policies:           policies,
+			NoIdentityPolicies: true,
+			EntityID:           entity.ID,
+			NamespaceID:        entity.NamespaceID,
+			CreationTime:       now.Unix(),
+			TTL:                ttl,
+			ExplicitMaxTTL:     ttl,
+			Meta: map[string]string{
+				apiTokenNameMeta: name,
+			},
+		}
+		if err := i.tokenStorer.RegisterToken(ctx, te); err != nil {
+			return nil, fmt.Errorf("failed to create API token: %w", err)
+		}
+
+		record := &entityAPIToken{
+			Name:           name,
+			EntityID:       entity.ID,
+			Accessor:       te.Accessor,
+			Policies:       policies,
+			CreationTime:   now,
+			ExpirationTime: now.Add(ttl),
+		}
+		entry, err := logical.StorageEntryJSON(apiTokenPathPrefix+entity.ID+"/"+name, record)
+		if err == nil {
+			err = i.view.Put(ctx, entry)
+		}
+		if err != nil {
+			if err := i.tokenStorer.RevokeTokenByAccessor(ctx, te.Accessor); err != nil {
+				i.logger.Warn("failed to revoke untracked API token", "entity_id", entity.ID, "name", name, "error", err)
+			}
+			return nil, err
+		}
+
+		token := te.ID
+		if te.ExternalID != "" {
+			token = te.ExternalID
+		}
+
+		data := record.responseData()
+		data["token"] = token
+		return &logical.Response{
+			Data: data,
+		}, nil
+	}
+}
+
+func (i *IdentityStore) pathEntityAPITokenRead() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		entity, err := i.entityForAPITokens(ctx, d.Get("id").(string))
+		if err != nil {
+			return nil, err
+		}
+		if entity == nil {
+			return nil, nil
+		}
+
+		record, err := i.entityAPIToken(ctx, entity.ID, d.Get("name").(string))
+		if err != nil {
+			return nil, err
+		}
+		if record == nil {
+			return nil, nil
+		}
+
+		return &logical.Response{
+			Data: record.responseData(),
+		}, nil
+	}
+}
+
+func (i *IdentityStore) pathEntityAPITokenRevoke() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		entity, err := i.entityForAPITokens(ctx, d.Get("id").(string))
+		if err != nil {
+			return nil, err
+		}
+		if entity == nil {
+			return nil, nil
+		}
+
+		i.apiTokenLock.Lock()
+		defer i.apiTokenLock.Unlock()
+
+		record, err := i.entityAPIToken(ctx, entity.ID, d.Get("name").(string))
+		if err != nil {
+			return nil, err
+		}
+		if record == nil {
+			return nil, nil
+		}
+
+		return nil, i.revokeEntityAPIToken(ctx, record)
+	}
+}
+
+func (i *IdentityStore) pathEntityAPITokenList() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		entity, err := i.entityForAPITokens(ctx, d.Get("id").(string))
+		if err != nil {
+			return nil, err
+		}
+		if entity == nil {
+			return nil, nil
+		}
+
+		names, err := i.view.List(ctx, apiTokenPathPrefix+entity.ID+"/")
+		if err != nil {
+			return nil, err
+		}
+
+		var keys []string
+		keyInfo := make(map[string]interface{})
+		for _, name := range names {
+			record, err := i.entityAPIToken(ctx, entity.ID, name)
+			if err != nil {
+				return nil, err
+			}
+			if record == nil {
+				continue
+			}
+			keys = append(keys, name)
+			keyInfo[name] = map[string]interface{}{
+				"policies":        record.Policies,
+				"expiration_time": record.ExpirationTime.Format(time.RFC3339),
+			}
+		}
+
+		return logical.ListResponseWithInfo(keys, keyInfo), nil
+	}
+}
+
+// entityAPIToken returns the record of the named API token of an entity.
+// Records of tokens past their expiration are not returned; they are left in
+// storage for tidyEntityAPITokens to remove, so that reads never write.
+func (i *IdentityStore) entityAPIToken(ctx context.Context, entityID, name string) (*entityAPIToken, error) {
+	record, err := i.loadEntityAPIToken(ctx, entityID, name)
+	if err != nil {
+		return nil, err
+	}
+	if record == nil || record.expired(time.Now()) {
+		return nil, nil
+	}
+
+	return record, nil
+}
+
+// loadEntityAPIToken returns the stored record of the named API token of an
+// entity, whether or not it has expired.
+func (i *IdentityStore) loadEntityAPIToken(ctx context.Context, entityID, name string) (*entityAPIToken, error) {
+	entry, err := i.view.Get(ctx, apiTokenPathPrefix+entityID+"/"+name)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var record entityAPIToken
+	if err := entry.DecodeJSON(&record); err != nil {
+		return nil, err
+	}
+
+	return &record, nil
+}
+
+// revokeEntityAPIToken revokes the token of an API token record and removes
+// the record.
+func (i *IdentityStore) revokeEntityAPIToken(ctx context.Context, record *entityAPIToken) error {
+	if err := i.tokenStorer.RevokeTokenByAccessor(ctx, record.Accessor); err != nil {
+		return fmt.Errorf("failed to revoke API token %q: %w", record.Name, err)
+	}
+
+	return i.view.Delete(ctx, apiTokenPathPrefix+record.EntityID+"/"+record.Name)
+}
+
+// revokeEntityAPITokens revokes all the API tokens of an entity. It is called
+// when the entity is deleted.
+func (i *IdentityStore) revokeEntityAPITokens(ctx context.Context, entityID string) error {
+	i.apiTokenLock.Lock()
+	defer i.apiTokenLock.Unlock()
+
+	names, err := i.view.List(ctx, apiTokenPathPrefix+entityID+"/")
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		record, err := i.loadEntityAPIToken(ctx, entityID, name)
+		if err != nil {
+			return err
+		}
+		if record == nil {
+			continue
+		}
+		if record.expired(time.Now()) {
+			if err := i.view.Delete(ctx, apiTokenPathPrefix+entityID+"/"+name); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := i.revokeEntityAPIToken(ctx, record); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// tidyEntityAPITokens removes the records of expired API tokens. The tokens
+// themselves expire in the token store, so only their records are left. It is
+// called from the periodic func of the identity store, and only does a pass
+// once per apiTokenTidyInterval.
+func (i *IdentityStore) tidyEntityAPITokens(ctx context.Context) {
+	// The records are replicated, so only remove them on the primary cluster.
+	// The periodic func does not run on perf standbys or DR secondaries.
+	if i.System().ReplicationState().HasState(consts.ReplicationPerformanceSecondary) {
+		return
+	}
+
+	now := time.Now()
+	i.apiTokenLock.Lock()
+	if now.Before(i.apiTokenNextTidy) {
+		i.apiTokenLock.Unlock()
+		return
+	}
+	i.apiTokenNextTidy = now.Add(apiTokenTidyInterval)
+	i.apiTokenLock.Unlock()
+
+	entityIDs, err := i.view.List(ctx, apiTokenPathPrefix)
+	if err != nil {
+		i.logger.Warn("failed to list entities with API tokens", "error", err)
+		return
+	}
+
+	for _, entityID := range entityIDs {
+		entityID = strings.TrimSuffix(entityID, "/")
+		names, err := i.view.List(ctx, apiTokenPathPrefix+entityID+"/")
+		if err != nil {
+			i.logger.Warn("failed to list API tokens", "entity_id", entityID, "error", err)
+			continue
+		}
+
+		for _, name := range names {
+			if err := i.tidyEntityAPIToken(ctx, entityID, name, now); err != nil {
+				i.logger.Warn("failed to tidy API token", "entity_id", entityID, "name", name, "error", err)
+			}
+		}
+	}
+}
+
+// tidyEntityAPIToken removes the record of the named API token of an entity
+// if it expired before now.
+func (i *IdentityStore) tidyEntityAPIToken(ctx context.Context, entityID, name string, now time.Time) error {
+	i.apiTokenLock.Lock()
+	defer i.apiTokenLock.Unlock()
+
+	record, err := i.loadEntityAPIToken(ctx, entityID, name)
+	if err != nil {
+		return err
+	}
+	if record == nil || !record.expired(now) {
+		return nil
+	}
+
+	return i.view.Delete(ctx, apiTokenPathPrefix+entityID+"/"+name)
+}
@@ -0,0 +1,180 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdentityStore_EntityAPITokens(t *testing.T) {
+	ctx := namespace.RootContext(nil)
+	c, is, ts, _ := testCoreWithIdentityTokenGithub(ctx, t)
+
+	resp, err := is.HandleRequest(ctx, &logical.Request{
+		Path:      "entity",
+		Operation: logical.UpdateOperation,
+		Data: map[string]interface{}{
+			"name":     "developer",
+			"policies": []string{"deploy", "read-only"},
+		},
+	})
+	require.NoError(t, err)
+	entityID := resp.Data["id"].(string)
+
+	resp, err = is.HandleRequest(ctx, &logical.Request{
+		Path:      "group",
+		Operation: logical.UpdateOperation,
+		Data: map[string]interface{}{
+			"name":              "admins",
+			"policies":          []string{"admin"},
+			"member_entity_ids": []string{entityID},
+		},
+	})
+	require.NoError(t, err)
+	require.False(t, resp.IsError(), resp)
+
+	tokenPath := "entity/" + entityID + "/tokens/ci"
+
+	t.Run("policies must be attached to the entity", func(t *testing.T) {
+		resp, err := is.HandleRequest(ctx, &logical.Request{
+			Path:      tokenPath,
+			Operation: logical.UpdateOperation,
+			Data: map[string]interface{}{
+				"policies": []string{"deploy", "unrelated"},
+			},
+		})
+		require.NoError(t, err)
+		require.True(t, resp.IsError())
+
+		resp, err = is.HandleRequest(ctx, &logical.Request{
+			Path:      tokenPath,
+			Operation: logical.UpdateOperation,
+			Data: map[string]interface{}{
+				"policies": []string{"root"},
+			},
+		})
+		require.NoError(t, err)
+		require.True(t, resp.IsError())
+	})
+
+	resp, err = is.HandleRequest(ctx, &logical.Request{
+		Path:       tokenPath,
+		MountPoint: "identity/",
+		Operation:  logical.UpdateOperation,
+		Data: map[string]interface{}{
+			"policies": []string{"deploy", "admin"},
+			"ttl":      "24h",
+		},
+	})
+	require.NoError(t, err)
+	require.False(t, resp.IsError(), resp)
+	token := resp.Data["token"].(string)
+	accessor := resp.Data["accessor"].(string)
+	require.Equal(t, []string{"admin", "deploy"}, resp.Data["policies"])
+
+	te, err := ts.Lookup(ctx, token)
+	require.NoError(t, err)
+	require.NotNil(t, te)
+	require.Equal(t, entityID, te.EntityID)
+	require.Equal(t, []string{"admin", "deploy"}, te.Policies)
+	require.True(t, te.NoIdentityPolicies)
+	require.Equal(t, "ci", te.Meta[apiTokenNameMeta])
+
+	// Names are unique per entity
+	resp, err = is.HandleRequest(ctx, &logical.Request{
+		Path:      tokenPath,
+		Operation: logical.UpdateOperation,
+		Data: map[string]interface{}{
+			"policies": []string{"deploy"},
+		},
+	})
+	require.NoError(t, err)
+	require.True(t, resp.IsError())
+
+	resp, err = is.HandleRequest(ctx, &logical.Request{
+		Path:      tokenPath,
+		Operation: logical.ReadOperation,
+	})
+	require.NoError(t, err)
+	require.Equal(t, accessor, resp.Data["accessor"])
+	require.NotContains(t, resp.Data, "token")
+
+	resp, err = is.HandleRequest(ctx, &logical.Request{
+		Path:      "entity/" + entityID + "/tokens",
+		Operation: logical.ListOperation,
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"ci"}, resp.Data["keys"])
+
+	resp, err = is.HandleRequest(ctx, &logical.Request{
+		Path:      tokenPath,
+		Operation: logical.DeleteOperation,
+	})
+	require.NoError(t, err)
+	require.Nil(t, resp)
+
+	te, err = ts.Lookup(ctx, token)
+	require.NoError(t, err)
+	require.Nil(t, te)
+
+	resp, err = is.HandleRequest(ctx, &logical.Request{
+		Path:      tokenPath,
+		Operation: logical.ReadOperation,
+	})
+	require.NoError(t, err)
+	require.Nil(t, resp)
+
+	// Deleting the entity revokes its remaining tokens
+	resp, err = is.HandleRequest(ctx, &logical.Request{
+		Path:       "entity/" + entityID + "/tokens/other",
+		MountPoint: "identity/",
+		Operation:  logical.UpdateOperation,
+		Data: map[string]interface{}{
+			"policies": []string{"read-only"},
+		},
+	})
+	require.NoError(t, err)
+	require.False(t, resp.IsError(), resp)
+	token = resp.Data["token"].(string)
+
+	_, err = is.HandleRequest(ctx, &logical.Request{
+		Path:      "entity/id/" + entityID,
+		Operation: logical.DeleteOperation,
+	})
+	require.NoError(t, err)
+
+	te, err = c.LookupToken(ctx, token)
+	require.NoError(t, err)
+	require.Nil(t, te)
+
+	// Records of expired tokens are ignored by reads, which leave them in
+	// storage, and removed by the periodic tidy
+	expired, err := logical.StorageEntryJSON(apiTokenPathPrefix+"other-entity/old", &entityAPIToken{
+		Name:           "old",
+		EntityID:       "other-entity",
+		ExpirationTime: time.Now().Add(-time.Minute),
+	})
+	require.NoError(t, err)
+	require.NoError(t, is.view.Put(ctx, expired))
+
+	record, err := is.entityAPIToken(ctx, "other-entity", "old")
+	require.NoError(t, err)
+	require.Nil(t, record)
+	entry, err := is.view.Get(ctx, expired.Key)
+	require.NoError(t, err)
+	require.NotNil(t, entry)
+
+	is.apiTokenLock.Lock()
+	is.apiTokenNextTidy = time.Time{}
+	is.apiTokenLock.Unlock()
+	is.tidyEntityAPITokens(ctx)
+	entry, err = is.view.Get(ctx, expired.Key)
+	require.NoError(t, err)
+	require.Nil(t, entry)
+}
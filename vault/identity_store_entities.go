@@ -698,6 +698,10 @@ func (i *IdentityStore) handleEntityDeleteCommon(ctx context.Context, txn *memdb
 		return nil
 	}
 
+	if err := i.revokeEntityAPITokens(ctx, entity.ID); err != nil {
+		return err
+	}
+
 	// Remove entity ID as a member from all the groups it belongs, both
 	// internal and external
 	groups, err := i.MemDBGroupsByMemberEntityIDInTxn(txn, entity.ID, true, false)
@@ -1188,4 +1192,14 @@ var entityHelp = map[string][2]string{
 		"Delete all of the entities provided",
 		"",
 	},
+	"entity-api-token": {
+		"Create, read or revoke an API token of an entity",
+		`API tokens are named, long-lived tokens issued to an entity and scoped to
+a subset of the policies attached to it. They are revoked when their TTL runs
+out, when they are deleted from this path, or when the entity is deleted.`,
+	},
+	"entity-api-token-list": {
+		"List the API tokens of an entity",
+		"",
+	},
 }
@@ -42,6 +42,18 @@ const (
 	defaultKeyName           = "default"
 	allowAllAssignmentName   = "allow_all"
 
+	// Client authentication methods used at the Token Endpoint. See details at
+	// https://openid.net/specs/openid-connect-core-1_0.html#ClientAuthentication
+	// and https://datatracker.ietf.org/doc/html/rfc8705#section-2.1
+	authMethodNone              = "none"
+	authMethodClientSecretBasic = "client_secret_basic"
+	authMethodClientSecretPost  = "client_secret_post"
+	authMethodPrivateKeyJWT     = "private_key_jwt"
+	authMethodTLSClientAuth     = "tls_client_auth"
+
+	clientAssertionTypeJWTBearer = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+	maxClientAssertionLifetime   = 5 * time.Minute
+
 	// Storage path constants
 	oidcProviderPrefix = "oidc_provider/"
 	assignmentPath     = oidcProviderPrefix + "assignment/"
@@ -105,6 +117,14 @@ type client struct {
 	AccessTokenTTL time.Duration `json:"access_token_ttl"`
 	Type           clientType    `json:"type"`
 
+	// Client authentication parameters used at the Token Endpoint. The
+	// JWKS is only used by 'private_key_jwt' clients, and the subject DN
+	// and CA are only used by 'tls_client_auth' clients.
+	TokenEndpointAuthMethod string `json:"token_endpoint_auth_method,omitempty"`
+	JWKS                    string `json:"jwks,omitempty"`
+	TLSClientAuthSubjectDN  string `json:"tls_client_auth_subject_dn,omitempty"`
+	TLSClientAuthCAPEM      string `json:"tls_client_auth_ca_pem,omitempty"`
+
 	// Generated values that are used in OIDC endpoints
 	ClientID     string `json:"client_id"`
 	ClientSecret string `json:"client_secret"`
@@ -118,6 +138,30 @@ const (
 	public
 )
 
+// tokenEndpointAuthMethod returns the method the client uses to authenticate
+// to the Token Endpoint. Clients created before the method was configurable
+// authenticate with their client secret.
+func (c *client) tokenEndpointAuthMethod() string {
+	if c.Type == public {
+		return authMethodNone
+	}
+	if c.TokenEndpointAuthMethod == "" {
+		return authMethodClientSecretBasic
+	}
+	return c.TokenEndpointAuthMethod
+}
+
+// usesClientSecret returns true if the client authenticates to the Token
+// Endpoint using its client secret.
+func (c *client) usesClientSecret() bool {
+	switch c.tokenEndpointAuthMethod() {
+	case authMethodClientSecretBasic, authMethodClientSecretPost:
+		return true
+	default:
+		return false
+	}
+}
+
 type provider struct {
 	Issuer           string   `json:"issuer"`
 	AllowedClientIDs []string `json:"allowed_client_ids"`
@@ -156,6 +200,7 @@ type providerDiscovery struct {
 	Subjects              []string `json:"subject_types_supported"`
 	GrantTypes            []string `json:"grant_types_supported"`
 	AuthMethods           []string `json:"token_endpoint_auth_methods_supported"`
+	AuthSigningAlgs       []string `json:"token_endpoint_auth_signing_alg_values_supported"`
 	CodeChallengeMethods  []string `json:"code_challenge_methods_supported"`
 }
 
@@ -316,6 +361,22 @@ func oidcProviderPaths(i *IdentityStore) []*framework.Path {
 					Description: "The client type based on its ability to maintain confidentiality of credentials. The following client types are supported: 'confidential', 'public'. Defaults to 'confidential'.",
 					Default:     "confidential",
 				},
+				"token_endpoint_auth_method": {
+					Type:        framework.TypeString,
+					Description: "The method a confidential client uses to authenticate to the token endpoint. The following methods are supported: 'client_secret_basic', 'client_secret_post', 'private_key_jwt', 'tls_client_auth'. Defaults to 'client_secret_basic'. Public clients always use 'none'.",
+				},
+				"jwks": {
+					Type:        framework.TypeString,
+					Description: "A JSON Web Key Set containing the public keys the client signs its assertions with. Required when token_endpoint_auth_method is 'private_key_jwt'.",
+				},
+				"tls_client_auth_subject_dn": {
+					Type:        framework.TypeString,
+					Description: "The expected subject distinguished name of the client's TLS certificate, e.g. 'CN=my-app,O=Example'. Required when token_endpoint_auth_method is 'tls_client_auth'.",
+				},
+				"tls_client_auth_ca_pem": {
+					Type:        framework.TypeString,
+					Description: "PEM-encoded CA certificates used to verify the client's TLS certificate. Required when token_endpoint_auth_method is 'tls_client_auth'.",
+				},
 			},
 			Operations: map[logical.Operation]framework.OperationHandler{
 				logical.UpdateOperation: &framework.PathOperation{
@@ -584,6 +645,17 @@ func oidcProviderPaths(i *IdentityStore) []*framework.Path {
 					Type:        framework.TypeString,
 					Description: "The secret of the requesting client.",
 				},
+				// For confidential clients using the 'private_key_jwt' authentication
+				// method, a signed JWT is provided in place of the client secret. See
+				// https://datatracker.ietf.org/doc/html/rfc7523#section-2.2
+				"client_assertion_type": {
+					Type:        framework.TypeString,
+					Description: "The format of the client assertion. The following types are supported: 'urn:ietf:params:oauth:client-assertion-type:jwt-bearer'.",
+				},
+				"client_assertion": {
+					Type:        framework.TypeString,
+					Description: "A JWT signed by the requesting client to authenticate it.",
+				},
 			},
 			Operations: map[logical.Operation]framework.OperationHandler{
 				logical.UpdateOperation: &framework.PathOperation{
@@ -1167,8 +1239,28 @@ func (i *IdentityStore) pathOIDCCreateUpdateClient(ctx context.Context, req *log
 		client.ClientID = clientID
 	}
 
-	// client secrets are only generated for confidential clients
-	if client.Type == confidential && client.ClientSecret == "" {
+	if authMethodRaw, ok := d.GetOk("token_endpoint_auth_method"); ok {
+		client.TokenEndpointAuthMethod = authMethodRaw.(string)
+	}
+	if jwksRaw, ok := d.GetOk("jwks"); ok {
+		client.JWKS = jwksRaw.(string)
+	}
+	if subjectDNRaw, ok := d.GetOk("tls_client_auth_subject_dn"); ok {
+		client.TLSClientAuthSubjectDN = subjectDNRaw.(string)
+	}
+	if caPEMRaw, ok := d.GetOk("tls_client_auth_ca_pem"); ok {
+		client.TLSClientAuthCAPEM = caPEMRaw.(string)
+	}
+	if err := validateClientAuthMethod(&client); err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
+	// client secrets are only generated for confidential clients which
+	// authenticate using them
+	switch {
+	case !client.usesClientSecret():
+		client.ClientSecret = ""
+	case client.ClientSecret == "":
 		// generate client_secret
 		clientSecret, err := base62.Random(clientSecretLength)
 		if err != nil {
@@ -1241,11 +1333,19 @@ func (i *IdentityStore) pathOIDCReadClient(ctx context.Context, req *logical.Req
 			"access_token_ttl": int64(client.AccessTokenTTL.Seconds()),
 			"client_id":        client.ClientID,
 			"client_type":      client.Type.String(),
+
+			"token_endpoint_auth_method": client.tokenEndpointAuthMethod(),
 		},
 	}
 
-	if client.Type == confidential {
+	switch client.tokenEndpointAuthMethod() {
+	case authMethodClientSecretBasic, authMethodClientSecretPost:
 		resp.Data["client_secret"] = client.ClientSecret
+	case authMethodPrivateKeyJWT:
+		resp.Data["jwks"] = client.JWKS
+	case authMethodTLSClientAuth:
+		resp.Data["tls_client_auth_subject_dn"] = client.TLSClientAuthSubjectDN
+		resp.Data["tls_client_auth_ca_pem"] = client.TLSClientAuthCAPEM
 	}
 
 	return resp, nil
@@ -1559,10 +1659,13 @@ func (i *IdentityStore) pathOIDCProviderDiscovery(ctx context.Context, req *logi
 		GrantTypes:            []string{"authorization_code"},
 		AuthMethods: []string{
 			// PKCE is required for auth method "none"
-			"none",
-			"client_secret_basic",
-			"client_secret_post",
+			authMethodNone,
+			authMethodClientSecretBasic,
+			authMethodClientSecretPost,
+			authMethodPrivateKeyJWT,
+			authMethodTLSClientAuth,
 		},
+		AuthSigningAlgs: supportedAlgs,
 		CodeChallengeMethods: []string{
 			codeChallengeMethodPlain,
 			codeChallengeMethodS256,
@@ -1940,9 +2043,19 @@ func (i *IdentityStore) pathOIDCToken(ctx context.Context, req *logical.Request,
 
 	// client_secret_basic - Check for client credentials in the Authorization header
 	clientID, clientSecret, okBasicAuth := basicAuth(req)
+	assertion := d.Get("client_assertion").(string)
 	if !okBasicAuth {
 		// client_secret_post - Check for client credentials in the request body
 		clientID = d.Get("client_id").(string)
+
+		// private_key_jwt - The client_id may be omitted in favor of the
+		// subject of the client assertion, which is verified below
+		if clientID == "" && assertion != "" {
+			clientID, err = clientAssertionSubject(assertion)
+			if err != nil {
+				return tokenResponse(nil, ErrTokenInvalidRequest, "client_assertion parameter is malformed")
+			}
+		}
 		if clientID == "" {
 			return tokenResponse(nil, ErrTokenInvalidRequest, "client_id parameter is required")
 		}
@@ -1959,10 +2072,28 @@ func (i *IdentityStore) pathOIDCToken(ctx context.Context, req *logical.Request,
 
 	// Authenticate the client if it's a confidential client type.
 	// Details at https://openid.net/specs/openid-connect-core-1_0.html#ClientAuthentication
-	if client.Type == confidential &&
-		subtle.ConstantTimeCompare([]byte(client.ClientSecret), []byte(clientSecret)) == 0 {
-		i.Logger().Debug("client failed to authenticate with invalid client secret", "client_id", clientID)
-		return tokenResponse(nil, ErrTokenInvalidClient, "client failed to authenticate")
+	switch client.tokenEndpointAuthMethod() {
+	case authMethodClientSecretBasic, authMethodClientSecretPost:
+		if subtle.ConstantTimeCompare([]byte(client.ClientSecret), []byte(clientSecret)) == 0 {
+			i.Logger().Debug("client failed to authenticate with invalid client secret", "client_id", clientID)
+			return tokenResponse(nil, ErrTokenInvalidClient, "client failed to authenticate")
+		}
+	case authMethodPrivateKeyJWT:
+		if okBasicAuth || clientSecret != "" {
+			return tokenResponse(nil, ErrTokenInvalidRequest, "client must use exactly one authentication method")
+		}
+		if err := i.verifyClientAssertion(ns, provider, client, d.Get("client_assertion_type").(string), assertion); err != nil {
+			i.Logger().Debug("client failed to authenticate with invalid client assertion", "client_id", clientID, "error", err)
+			return tokenResponse(nil, ErrTokenInvalidClient, "client failed to authenticate")
+		}
+	case authMethodTLSClientAuth:
+		if okBasicAuth || clientSecret != "" || assertion != "" {
+			return tokenResponse(nil, ErrTokenInvalidRequest, "client must use exactly one authentication method")
+		}
+		if err := verifyClientCertificate(req, client); err != nil {
+			i.Logger().Debug("client failed to authenticate with invalid client certificate", "client_id", clientID, "error", err)
+			return tokenResponse(nil, ErrTokenInvalidClient, "client failed to authenticate")
+		}
 	}
 
 	// Validate that the client is authorized to use the provider
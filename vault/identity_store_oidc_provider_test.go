@@ -4,15 +4,25 @@
 package vault
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/base64"
 	"encoding/json"
+	"encoding/pem"
 	"fmt"
+	"math/big"
 	"net/http"
 	"sort"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/go-jose/go-jose/v3"
+	"github.com/go-jose/go-jose/v3/jwt"
 	"github.com/go-test/deep"
 	"github.com/hashicorp/go-secure-stdlib/parseutil"
 	"github.com/hashicorp/vault/helper/namespace"
@@ -607,6 +617,204 @@ func TestOIDC_Path_OIDC_Token(t *testing.T) {
 	}
 }
 
+// TestOIDC_Path_OIDC_Token_ClientAuthMethods tests that confidential clients
+// can authenticate to the token endpoint using the 'private_key_jwt' and
+// 'tls_client_auth' methods instead of a client secret.
+func TestOIDC_Path_OIDC_Token_ClientAuthMethods(t *testing.T) {
+	c, _, _ := TestCoreUnsealed(t)
+	ctx := namespace.RootContext(nil)
+	s := new(logical.InmemStorage)
+
+	entityID, _, _, clientID, _ := setupOIDCCommon(t, c, s)
+	issuer := "/v1/identity/oidc/provider/test-provider"
+
+	// authorize returns an authorization code for the test client
+	authorize := func(t *testing.T) string {
+		te := &logical.TokenEntry{
+			Path:         "test",
+			Policies:     []string{"default"},
+			TTL:          time.Hour * 24,
+			CreationTime: time.Now().Unix(),
+		}
+		testMakeTokenDirectly(t, c.tokenStore, te)
+
+		req := testAuthorizeReq(s, clientID)
+		req.EntityID = entityID
+		req.ClientToken = te.ID
+		resp, err := c.identityStore.HandleRequest(ctx, req)
+		expectSuccess(t, resp, err)
+
+		var authRes struct {
+			Code string `json:"code"`
+		}
+		require.NoError(t, json.Unmarshal(resp.Data["http_raw_body"].([]byte), &authRes))
+		return authRes.Code
+	}
+
+	// token sends the token request and returns the error code, if any
+	token := func(t *testing.T, req *logical.Request) string {
+		resp, err := c.identityStore.HandleRequest(ctx, req)
+		expectSuccess(t, resp, err)
+
+		var tokenRes struct {
+			IDToken string `json:"id_token"`
+			Error   string `json:"error"`
+		}
+		require.NoError(t, json.Unmarshal(resp.Data["http_raw_body"].([]byte), &tokenRes))
+		if tokenRes.Error == "" {
+			require.NotEmpty(t, tokenRes.IDToken)
+		}
+		return tokenRes.Error
+	}
+
+	// Configure the client to authenticate using a signed assertion
+	clientKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	jwks, err := json.Marshal(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{
+		Key:       clientKey.Public(),
+		KeyID:     "client-key",
+		Algorithm: string(jose.ES256),
+		Use:       "sig",
+	}}})
+	require.NoError(t, err)
+	resp, err := c.identityStore.HandleRequest(ctx, &logical.Request{
+		Storage:   s,
+		Path:      "oidc/client/test-client",
+		Operation: logical.UpdateOperation,
+		Data: map[string]interface{}{
+			"token_endpoint_auth_method": "private_key_jwt",
+			"jwks":                       string(jwks),
+		},
+	})
+	expectSuccess(t, resp, err)
+
+	resp, err = c.identityStore.HandleRequest(ctx, &logical.Request{
+		Storage:   s,
+		Path:      "oidc/client/test-client",
+		Operation: logical.ReadOperation,
+	})
+	expectSuccess(t, resp, err)
+	require.Equal(t, "private_key_jwt", resp.Data["token_endpoint_auth_method"])
+	require.Equal(t, string(jwks), resp.Data["jwks"])
+	require.NotContains(t, resp.Data, "client_secret")
+
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.ES256, Key: clientKey},
+		(&jose.SignerOptions{}).WithHeader("kid", "client-key"))
+	require.NoError(t, err)
+	assertion := func(t *testing.T, audience string) string {
+		raw, err := jwt.Signed(signer).Claims(jwt.Claims{
+			Issuer:   clientID,
+			Subject:  clientID,
+			Audience: jwt.Audience{audience},
+			Expiry:   jwt.NewNumericDate(time.Now().Add(time.Minute)),
+			ID:       fmt.Sprintf("%d", time.Now().UnixNano()),
+		}).CompactSerialize()
+		require.NoError(t, err)
+		return raw
+	}
+	assertionReq := func(code, assertion string) *logical.Request {
+		req := testTokenReq(s, code, "", "")
+		req.Headers = nil
+		req.Data["client_assertion_type"] = clientAssertionTypeJWTBearer
+		req.Data["client_assertion"] = assertion
+		return req
+	}
+
+	validAssertion := assertion(t, issuer+"/token")
+	require.Empty(t, token(t, assertionReq(authorize(t), validAssertion)))
+
+	// Assertions cannot be replayed
+	require.Equal(t, ErrTokenInvalidClient, token(t, assertionReq(authorize(t), validAssertion)))
+
+	// Assertions must be intended for the provider
+	require.Equal(t, ErrTokenInvalidClient, token(t, assertionReq(authorize(t), assertion(t, "https://example.com"))))
+
+	// Assertions must be signed by the client's key
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	signer, err = jose.NewSigner(jose.SigningKey{Algorithm: jose.ES256, Key: otherKey},
+		(&jose.SignerOptions{}).WithHeader("kid", "client-key"))
+	require.NoError(t, err)
+	require.Equal(t, ErrTokenInvalidClient, token(t, assertionReq(authorize(t), assertion(t, issuer))))
+
+	// Configure the client to authenticate using its TLS certificate
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Client CA"},
+		NotBefore:             time.Now().Add(-time.Minute),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, caKey.Public(), caKey)
+	require.NoError(t, err)
+	caCert, err := x509.ParseCertificate(caDER)
+	require.NoError(t, err)
+	leafDER, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "my-app", Organization: []string{"Example"}},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, caCert, clientKey.Public(), caKey)
+	require.NoError(t, err)
+	leafCert, err := x509.ParseCertificate(leafDER)
+	require.NoError(t, err)
+
+	resp, err = c.identityStore.HandleRequest(ctx, &logical.Request{
+		Storage:   s,
+		Path:      "oidc/client/test-client",
+		Operation: logical.UpdateOperation,
+		Data: map[string]interface{}{
+			"token_endpoint_auth_method": "tls_client_auth",
+			"tls_client_auth_subject_dn": "CN=my-app,O=Example",
+			"tls_client_auth_ca_pem":     string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER})),
+		},
+	})
+	expectSuccess(t, resp, err)
+
+	req := testTokenReq(s, authorize(t), "", "")
+	req.Headers = nil
+	req.Data["client_id"] = clientID
+	require.Equal(t, ErrTokenInvalidClient, token(t, req))
+
+	req = testTokenReq(s, authorize(t), "", "")
+	req.Headers = nil
+	req.Data["client_id"] = clientID
+	req.Connection = &logical.Connection{ConnState: &tls.ConnectionState{
+		PeerCertificates: []*x509.Certificate{leafCert},
+	}}
+	require.Empty(t, token(t, req))
+
+	// The authentication method must be compatible with the client
+	resp, err = c.identityStore.HandleRequest(ctx, &logical.Request{
+		Storage:   s,
+		Path:      "oidc/client/test-client",
+		Operation: logical.UpdateOperation,
+		Data: map[string]interface{}{
+			"token_endpoint_auth_method": "none",
+		},
+	})
+	expectError(t, resp, err)
+
+	resp, err = c.identityStore.HandleRequest(ctx, &logical.Request{
+		Storage:   s,
+		Path:      "oidc/client/public-client",
+		Operation: logical.CreateOperation,
+		Data: map[string]interface{}{
+			"key":                        "test-key",
+			"client_type":                "public",
+			"token_endpoint_auth_method": "private_key_jwt",
+			"jwks":                       string(jwks),
+		},
+	})
+	expectError(t, resp, err)
+}
+
 func TestOIDC_Path_OIDC_Authorize(t *testing.T) {
 	c, _, _ := TestCoreUnsealed(t)
 	ctx := namespace.RootContext(nil)
@@ -1906,6 +2114,8 @@ func TestOIDC_Path_OIDC_ProviderClient(t *testing.T) {
 		"client_id":        resp.Data["client_id"],
 		"client_secret":    resp.Data["client_secret"],
 		"client_type":      confidential.String(),
+
+		"token_endpoint_auth_method": "client_secret_basic",
 	}
 	if diff := deep.Equal(expected, resp.Data); diff != nil {
 		t.Fatal(diff)
@@ -1961,6 +2171,8 @@ func TestOIDC_Path_OIDC_ProviderClient(t *testing.T) {
 		"client_id":        resp.Data["client_id"],
 		"client_secret":    resp.Data["client_secret"],
 		"client_type":      confidential.String(),
+
+		"token_endpoint_auth_method": "client_secret_basic",
 	}
 	if diff := deep.Equal(expected, resp.Data); diff != nil {
 		t.Fatal(diff)
@@ -2040,6 +2252,8 @@ func TestOIDC_Path_OIDC_ProviderClient_Deduplication(t *testing.T) {
 		"access_token_ttl": int64(86400),
 		"client_id":        resp.Data["client_id"],
 		"client_type":      public.String(),
+
+		"token_endpoint_auth_method": "none",
 	}
 	if diff := deep.Equal(expected, resp.Data); diff != nil {
 		t.Fatal(diff)
@@ -2102,6 +2316,8 @@ func TestOIDC_Path_OIDC_ProviderClient_Update(t *testing.T) {
 		"client_id":        resp.Data["client_id"],
 		"client_secret":    resp.Data["client_secret"],
 		"client_type":      confidential.String(),
+
+		"token_endpoint_auth_method": "client_secret_basic",
 	}
 	if diff := deep.Equal(expected, resp.Data); diff != nil {
 		t.Fatal(diff)
@@ -2136,6 +2352,8 @@ func TestOIDC_Path_OIDC_ProviderClient_Update(t *testing.T) {
 		"client_id":        resp.Data["client_id"],
 		"client_secret":    resp.Data["client_secret"],
 		"client_type":      confidential.String(),
+
+		"token_endpoint_auth_method": "client_secret_basic",
 	}
 	if diff := deep.Equal(expected, resp.Data); diff != nil {
 		t.Fatal(diff)
@@ -3635,7 +3853,8 @@ func TestOIDC_Path_OpenIDProviderConfig(t *testing.T) {
 		TokenEndpoint:         basePath + "/token",
 		UserinfoEndpoint:      basePath + "/userinfo",
 		GrantTypes:            []string{"authorization_code"},
-		AuthMethods:           []string{"none", "client_secret_basic", "client_secret_post"},
+		AuthMethods:           []string{"none", "client_secret_basic", "client_secret_post", "private_key_jwt", "tls_client_auth"},
+		AuthSigningAlgs:       supportedAlgs,
 		RequestParameter:      false,
 		RequestURIParameter:   false,
 		CodeChallengeMethods:  []string{codeChallengeMethodPlain, codeChallengeMethodS256},
@@ -3692,7 +3911,8 @@ func TestOIDC_Path_OpenIDProviderConfig(t *testing.T) {
 		TokenEndpoint:         basePath + "/token",
 		UserinfoEndpoint:      basePath + "/userinfo",
 		GrantTypes:            []string{"authorization_code"},
-		AuthMethods:           []string{"none", "client_secret_basic", "client_secret_post"},
+		AuthMethods:           []string{"none", "client_secret_basic", "client_secret_post", "private_key_jwt", "tls_client_auth"},
+		AuthSigningAlgs:       supportedAlgs,
 		RequestParameter:      false,
 		RequestURIParameter:   false,
 		CodeChallengeMethods:  []string{codeChallengeMethodPlain, codeChallengeMethodS256},
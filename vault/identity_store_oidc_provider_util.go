@@ -6,14 +6,20 @@ package vault
 import (
 	"crypto/sha256"
 	"crypto/sha512"
+	"crypto/x509"
 	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"hash"
 	"net/http"
 	"net/url"
+	"time"
 
 	"github.com/go-jose/go-jose/v3"
+	"github.com/go-jose/go-jose/v3/jwt"
 	"github.com/hashicorp/go-secure-stdlib/strutil"
+	"github.com/hashicorp/vault/helper/namespace"
 	"github.com/hashicorp/vault/sdk/logical"
 )
 
@@ -108,3 +114,177 @@ func basicAuth(req *logical.Request) (string, string, bool) {
 	headerReq := &http.Request{Header: req.Headers}
 	return headerReq.BasicAuth()
 }
+
+// validateClientAuthMethod checks that the client's token endpoint
+// authentication method is compatible with its type and that the
+// parameters the method depends on are present and well-formed.
+func validateClientAuthMethod(c *client) error {
+	method := c.TokenEndpointAuthMethod
+	if c.Type == public {
+		if method != "" && method != authMethodNone {
+			return fmt.Errorf("public clients must use the %q token_endpoint_auth_method", authMethodNone)
+		}
+		return nil
+	}
+
+	switch method {
+	case "", authMethodClientSecretBasic, authMethodClientSecretPost:
+		return nil
+	case authMethodPrivateKeyJWT:
+		if c.JWKS == "" {
+			return fmt.Errorf("jwks is required for the %q token_endpoint_auth_method", method)
+		}
+		keySet, err := parseClientJWKS(c.JWKS)
+		if err != nil {
+			return err
+		}
+		for _, key := range keySet.Keys {
+			if !key.IsPublic() {
+				return errors.New("jwks must only contain public keys")
+			}
+		}
+		return nil
+	case authMethodTLSClientAuth:
+		if c.TLSClientAuthSubjectDN == "" {
+			return fmt.Errorf("tls_client_auth_subject_dn is required for the %q token_endpoint_auth_method", method)
+		}
+		if !x509.NewCertPool().AppendCertsFromPEM([]byte(c.TLSClientAuthCAPEM)) {
+			return fmt.Errorf("tls_client_auth_ca_pem must contain at least one PEM-encoded certificate for the %q token_endpoint_auth_method", method)
+		}
+		return nil
+	case authMethodNone:
+		return fmt.Errorf("confidential clients cannot use the %q token_endpoint_auth_method", method)
+	default:
+		return fmt.Errorf("invalid token_endpoint_auth_method %q", method)
+	}
+}
+
+// parseClientJWKS parses a client's JSON Web Key Set, requiring at least one key.
+func parseClientJWKS(raw string) (*jose.JSONWebKeySet, error) {
+	var keySet jose.JSONWebKeySet
+	if err := json.Unmarshal([]byte(raw), &keySet); err != nil {
+		return nil, fmt.Errorf("failed to parse jwks: %w", err)
+	}
+	if len(keySet.Keys) == 0 {
+		return nil, errors.New("jwks must contain at least one key")
+	}
+	return &keySet, nil
+}
+
+// clientAssertionSubject returns the unverified subject of a client assertion.
+// It must only be used to look up the client whose keys verify the assertion.
+func clientAssertionSubject(assertion string) (string, error) {
+	parsed, err := jwt.ParseSigned(assertion)
+	if err != nil {
+		return "", err
+	}
+	var claims jwt.Claims
+	if err := parsed.UnsafeClaimsWithoutVerification(&claims); err != nil {
+		return "", err
+	}
+	return claims.Subject, nil
+}
+
+// verifyClientAssertion authenticates a 'private_key_jwt' client using the
+// signed assertion it provided. See details at
+// https://openid.net/specs/openid-connect-core-1_0.html#ClientAuthentication
+// and https://datatracker.ietf.org/doc/html/rfc7523#section-3
+func (i *IdentityStore) verifyClientAssertion(ns *namespace.Namespace, p *provider, c *client, assertionType, assertion string) error {
+	if assertionType != clientAssertionTypeJWTBearer {
+		return fmt.Errorf("unsupported client_assertion_type %q", assertionType)
+	}
+	if assertion == "" {
+		return errors.New("client_assertion parameter is required")
+	}
+
+	parsed, err := jwt.ParseSigned(assertion)
+	if err != nil {
+		return fmt.Errorf("failed to parse client assertion: %w", err)
+	}
+	if len(parsed.Headers) != 1 || !strutil.StrListContains(supportedAlgs, parsed.Headers[0].Algorithm) {
+		return errors.New("client assertion is signed with an unsupported algorithm")
+	}
+
+	keySet, err := parseClientJWKS(c.JWKS)
+	if err != nil {
+		return err
+	}
+	keys := keySet.Keys
+	if kid := parsed.Headers[0].KeyID; kid != "" {
+		keys = keySet.Key(kid)
+	}
+
+	var claims jwt.Claims
+	verified := false
+	for _, key := range keys {
+		if err := parsed.Claims(key.Key, &claims); err == nil {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return errors.New("client assertion signature could not be verified")
+	}
+
+	now := time.Now()
+	if claims.Expiry == nil || claims.ID == "" {
+		return errors.New("client assertion must contain the exp and jti claims")
+	}
+	if claims.Expiry.Time().After(now.Add(maxClientAssertionLifetime)) {
+		return fmt.Errorf("client assertion must expire within %s", maxClientAssertionLifetime)
+	}
+	if err := claims.ValidateWithLeeway(jwt.Expected{
+		Issuer:  c.ClientID,
+		Subject: c.ClientID,
+		Time:    now,
+	}, jwt.DefaultLeeway); err != nil {
+		return err
+	}
+	if !claims.Audience.Contains(p.effectiveIssuer) && !claims.Audience.Contains(p.effectiveIssuer+"/token") {
+		return errors.New("client assertion audience must contain the issuer or token endpoint")
+	}
+
+	// Each assertion may only be used once during its lifetime
+	jtiKey := "client_assertion:" + c.ClientID + ":" + claims.ID
+	_, used, err := i.oidcClientAssertionCache.Get(ns, jtiKey)
+	if err != nil {
+		return err
+	}
+	if used {
+		return errors.New("client assertion has already been used")
+	}
+	return i.oidcClientAssertionCache.SetDefault(ns, jtiKey, struct{}{})
+}
+
+// verifyClientCertificate authenticates a 'tls_client_auth' client using the
+// certificate it presented on the TLS connection. See details at
+// https://datatracker.ietf.org/doc/html/rfc8705#section-2.1
+func verifyClientCertificate(req *logical.Request, c *client) error {
+	if req.Connection == nil || req.Connection.ConnState == nil ||
+		len(req.Connection.ConnState.PeerCertificates) == 0 {
+		return errors.New("no client certificate presented")
+	}
+	peerCerts := req.Connection.ConnState.PeerCertificates
+
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM([]byte(c.TLSClientAuthCAPEM)) {
+		return errors.New("client has no valid tls_client_auth_ca_pem")
+	}
+	intermediates := x509.NewCertPool()
+	for _, cert := range peerCerts[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	leaf := peerCerts[0]
+	if _, err := leaf.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}); err != nil {
+		return fmt.Errorf("failed to verify client certificate: %w", err)
+	}
+	if leaf.Subject.String() != c.TLSClientAuthSubjectDN {
+		return fmt.Errorf("client certificate subject %q does not match", leaf.Subject.String())
+	}
+	return nil
+}
@@ -7,6 +7,7 @@ import (
 	"context"
 	"regexp"
 	"sync"
+	"time"
 
 	log "github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/go-memdb"
@@ -65,6 +66,14 @@ type IdentityStore struct {
 	// groupLock is used to protect modifications to group entries
 	groupLock sync.RWMutex
 
+	// apiTokenLock is used to protect modifications to entity API token
+	// records
+	apiTokenLock sync.Mutex
+
+	// apiTokenNextTidy is when the records of expired API tokens are next
+	// removed. It is protected by apiTokenLock.
+	apiTokenNextTidy time.Time
+
 	// oidcCache stores common response data as well as when the periodic func needs
 	// to run. This is conservatively managed, and most writes to the OIDC endpoints
 	// will invalidate the cache.
@@ -74,6 +83,10 @@ type IdentityStore struct {
 	// for an ID token during an authorization code flow.
 	oidcAuthCodeCache *oidcCache
 
+	// oidcClientAssertionCache stores the IDs of client assertions used to
+	// authenticate to the OIDC token endpoint to prevent their replay.
+	oidcClientAssertionCache *oidcCache
+
 	// logger is the server logger copied over from core
 	logger log.Logger
 
@@ -151,6 +164,8 @@ var _ GroupUpdater = &Core{}
 type TokenStorer interface {
 	LookupToken(context.Context, string) (*logical.TokenEntry, error)
 	CreateToken(context.Context, *logical.TokenEntry) error
+	RegisterToken(context.Context, *logical.TokenEntry) error
+	RevokeTokenByAccessor(context.Context, string) error
 }
 
 var _ TokenStorer = &Core{}
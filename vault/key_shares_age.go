@@ -0,0 +1,147 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/hashicorp/vault/sdk/helper/jsonutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	// coreKeySharesRotationPath is the path used to store when the unseal and
+	// recovery key shares were last issued.
+	coreKeySharesRotationPath = "core/key-shares-rotation"
+
+	// keySharesAgeCheckInterval is how often the age of the key shares is
+	// emitted, and checked against the configured maximum age.
+	keySharesAgeCheckInterval = time.Hour
+
+	keySharesTypeUnseal   = "unseal"
+	keySharesTypeRecovery = "recovery"
+)
+
+// keySharesRotation records when each type of key shares was last issued, by
+// initialization or by a rekey.
+type keySharesRotation struct {
+	UnsealKeys   time.Time `json:"unseal_keys,omitempty"`
+	RecoveryKeys time.Time `json:"recovery_keys,omitempty"`
+}
+
+func (c *Core) loadKeySharesRotation(ctx context.Context) (*keySharesRotation, error) {
+	entry, err := c.barrier.Get(ctx, coreKeySharesRotationPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key shares rotation: %w", err)
+	}
+
+	rotation := &keySharesRotation{}
+	if entry == nil {
+		return rotation, nil
+	}
+	if err := jsonutil.DecodeJSON(entry.Value, rotation); err != nil {
+		return nil, fmt.Errorf("failed to decode key shares rotation: %w", err)
+	}
+	return rotation, nil
+}
+
+func (c *Core) persistKeySharesRotation(ctx context.Context, rotation *keySharesRotation) error {
+	entry, err := logical.StorageEntryJSON(coreKeySharesRotationPath, rotation)
+	if err != nil {
+		return fmt.Errorf("failed to encode key shares rotation: %w", err)
+	}
+	if err := c.barrier.Put(ctx, entry); err != nil {
+		return fmt.Errorf("failed to persist key shares rotation: %w", err)
+	}
+	return nil
+}
+
+// recordKeySharesRotated stores the current time as the time the unseal or
+// recovery key shares were issued. Failing to do so is logged but does not
+// fail the rekey, as the new shares have already been committed.
+func (c *Core) recordKeySharesRotated(ctx context.Context, recovery bool) {
+	rotation, err := c.loadKeySharesRotation(ctx)
+	if err != nil {
+		c.logger.Error("failed to record key shares rotation", "error", err)
+		return
+	}
+
+	if recovery {
+		rotation.RecoveryKeys = time.Now().UTC()
+	} else {
+		rotation.UnsealKeys = time.Now().UTC()
+	}
+
+	if err := c.persistKeySharesRotation(ctx, rotation); err != nil {
+		c.logger.Error("failed to record key shares rotation", "error", err)
+	}
+}
+
+// keySharesAges returns the age of each type of key shares in use, keyed by
+// keySharesTypeUnseal and keySharesTypeRecovery. Key shares issued before
+// their age was tracked are treated as issued now.
+func (c *Core) keySharesAges(ctx context.Context) (map[string]time.Duration, error) {
+	rotation, err := c.loadKeySharesRotation(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	ages := make(map[string]time.Duration, 2)
+	modified := false
+
+	// Unseal key shares only exist for Shamir seals; auto unseal seals use
+	// recovery keys in their place.
+	if c.seal.BarrierSealConfigType() == SealConfigTypeShamir {
+		if rotation.UnsealKeys.IsZero() {
+			rotation.UnsealKeys = now
+			modified = true
+		}
+		ages[keySharesTypeUnseal] = now.Sub(rotation.UnsealKeys)
+	}
+	if c.seal.RecoveryKeySupported() {
+		if rotation.RecoveryKeys.IsZero() {
+			rotation.RecoveryKeys = now
+			modified = true
+		}
+		ages[keySharesTypeRecovery] = now.Sub(rotation.RecoveryKeys)
+	}
+
+	if modified {
+		if err := c.persistKeySharesRotation(ctx, rotation); err != nil {
+			return nil, err
+		}
+	}
+
+	return ages, nil
+}
+
+// checkKeySharesAge emits the age of the key shares, and logs a reminder to
+// rekey for any shares older than the configured maximum age. This should
+// only be called on the active node.
+func (c *Core) checkKeySharesAge(ctx context.Context) {
+	ages, err := c.keySharesAges(ctx)
+	if err != nil {
+		c.logger.Error("failed to determine key shares age", "error", err)
+		return
+	}
+
+	for keyType, age := range ages {
+		c.metricSink.SetGaugeWithLabels([]string{"core", "key_shares", "age_seconds"}, float32(age.Seconds()),
+			[]metrics.Label{{Name: "type", Value: keyType}})
+
+		if c.keySharesMaxAge > 0 && age > c.keySharesMaxAge {
+			c.metricSink.SetGaugeWithLabels([]string{"core", "key_shares", "expired"}, 1,
+				[]metrics.Label{{Name: "type", Value: keyType}})
+			c.logger.Warn("key shares are older than the configured maximum age, a rekey is recommended",
+				"type", keyType, "age", age.Truncate(time.Second).String(), "max_age", c.keySharesMaxAge.String())
+		} else {
+			c.metricSink.SetGaugeWithLabels([]string{"core", "key_shares", "expired"}, 0,
+				[]metrics.Label{{Name: "type", Value: keyType}})
+		}
+	}
+}
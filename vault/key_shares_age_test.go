@@ -0,0 +1,56 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestCore_KeySharesAge verifies that the age of the unseal key shares is
+// tracked from when it is first checked, and reset by a rekey.
+func TestCore_KeySharesAge(t *testing.T) {
+	bc := &SealConfig{
+		SecretShares:    1,
+		SecretThreshold: 1,
+	}
+	c, keys, _, _ := TestCoreUnsealedWithConfigs(t, bc, nil)
+	ctx := context.Background()
+
+	ages, err := c.keySharesAges(ctx)
+	require.NoError(t, err)
+	require.Contains(t, ages, keySharesTypeUnseal)
+	require.NotContains(t, ages, keySharesTypeRecovery)
+	require.Less(t, ages[keySharesTypeUnseal], time.Minute)
+
+	// Backdate the shares so they exceed the maximum age
+	require.NoError(t, c.persistKeySharesRotation(ctx, &keySharesRotation{
+		UnsealKeys: time.Now().Add(-48 * time.Hour),
+	}))
+	ages, err = c.keySharesAges(ctx)
+	require.NoError(t, err)
+	require.Greater(t, ages[keySharesTypeUnseal], 47*time.Hour)
+
+	c.keySharesMaxAge = 24 * time.Hour
+	c.checkKeySharesAge(ctx)
+
+	// A rekey issues new shares, resetting their age
+	require.Nil(t, c.RekeyInit(&SealConfig{
+		Type:            c.seal.BarrierSealConfigType().String(),
+		SecretShares:    1,
+		SecretThreshold: 1,
+	}, false))
+	rkconf, hErr := c.RekeyConfig(false)
+	require.Nil(t, hErr)
+	result, err := c.RekeyUpdate(ctx, keys[0], rkconf.Nonce, false)
+	require.NoError(t, err)
+	require.NotNil(t, result)
+
+	ages, err = c.keySharesAges(ctx)
+	require.NoError(t, err)
+	require.Less(t, ages[keySharesTypeUnseal], time.Minute)
+}
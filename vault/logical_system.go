@@ -111,6 +111,11 @@ func NewSystemBackend(core *Core, logger log.Logger, config *logical.BackendConf
 				"remount",
 				"audit",
 				"audit/*",
+				"audit-hash-rotate/*",
+				"break-glass/config",
+				"break-glass/approve/*",
+				"requests/in-flight/*",
+				"requests/max-durations",
 				"raw",
 				"raw/*",
 				"replication/primary/secondary-token",
@@ -122,6 +127,8 @@ func NewSystemBackend(core *Core, logger log.Logger, config *logical.BackendConf
 				"rotate",
 				"config/cors",
 				"config/auditing/*",
+				"config/history",
+				"config/history/*",
 				"config/ui/headers/*",
 				"plugins/catalog/*",
 				"plugins/runtimes/catalog/*",
@@ -129,6 +136,7 @@ func NewSystemBackend(core *Core, logger log.Logger, config *logical.BackendConf
 				"revoke-force/*",
 				"leases/revoke-prefix/*",
 				"leases/revoke-force/*",
+				"leases/irrevocable/acknowledge",
 				"leases/lookup/*",
 				"storage/raft/snapshot-auto/config/*",
 				"leases",
@@ -185,6 +193,7 @@ func NewSystemBackend(core *Core, logger log.Logger, config *logical.BackendConf
 			LocalStorage: []string{
 				expirationSubPath,
 				countersSubPath,
+				localAuditSaltRotationSubPath,
 			},
 
 			SealWrapStorage: []string{
@@ -196,6 +205,7 @@ func NewSystemBackend(core *Core, logger log.Logger, config *logical.BackendConf
 
 	b.Backend.Paths = append(b.Backend.Paths, entPaths(b)...)
 	b.Backend.Paths = append(b.Backend.Paths, b.configPaths()...)
+	b.Backend.Paths = append(b.Backend.Paths, b.configHistoryPaths()...)
 	b.Backend.Paths = append(b.Backend.Paths, b.rekeyPaths()...)
 	b.Backend.Paths = append(b.Backend.Paths, b.sealPaths()...)
 	b.Backend.Paths = append(b.Backend.Paths, b.statusPaths()...)
@@ -208,11 +218,21 @@ func NewSystemBackend(core *Core, logger log.Logger, config *logical.BackendConf
 	b.Backend.Paths = append(b.Backend.Paths, b.pluginsRuntimesCatalogCRUDPath())
 	b.Backend.Paths = append(b.Backend.Paths, b.pluginsRuntimesCatalogListPaths()...)
 	b.Backend.Paths = append(b.Backend.Paths, b.auditPaths()...)
+	b.Backend.Paths = append(b.Backend.Paths, b.breakGlassPaths()...)
+	b.Backend.Paths = append(b.Backend.Paths, b.brokerSessionPaths()...)
+	b.Backend.Paths = append(b.Backend.Paths, b.requestsPaths()...)
+	b.Backend.Paths = append(b.Backend.Paths, b.mountAdminPaths()...)
+	b.Backend.Paths = append(b.Backend.Paths, b.mountSLOPaths()...)
+	b.Backend.Paths = append(b.Backend.Paths, b.authNetworkRequirementsPaths()...)
+	b.Backend.Paths = append(b.Backend.Paths, b.mountBlueprintPaths()...)
 	b.Backend.Paths = append(b.Backend.Paths, b.mountPaths()...)
 	b.Backend.Paths = append(b.Backend.Paths, b.authPaths()...)
 	b.Backend.Paths = append(b.Backend.Paths, b.lockedUserPaths()...)
+	b.Backend.Paths = append(b.Backend.Paths, b.irrevocableLeasePaths()...)
 	b.Backend.Paths = append(b.Backend.Paths, b.leasePaths()...)
 	b.Backend.Paths = append(b.Backend.Paths, b.policyPaths()...)
+	b.Backend.Paths = append(b.Backend.Paths, b.ttlPolicyPaths()...)
+	b.Backend.Paths = append(b.Backend.Paths, b.trustBundlePaths()...)
 	b.Backend.Paths = append(b.Backend.Paths, b.wrappingPaths()...)
 	b.Backend.Paths = append(b.Backend.Paths, b.toolsPaths()...)
 	b.Backend.Paths = append(b.Backend.Paths, b.capabilitiesPaths()...)
@@ -223,6 +243,8 @@ func NewSystemBackend(core *Core, logger log.Logger, config *logical.BackendConf
 	b.Backend.Paths = append(b.Backend.Paths, b.monitorPath())
 	b.Backend.Paths = append(b.Backend.Paths, b.inFlightRequestPath())
 	b.Backend.Paths = append(b.Backend.Paths, b.hostInfoPath())
+	b.Backend.Paths = append(b.Backend.Paths, b.secretsManifestPath())
+	b.Backend.Paths = append(b.Backend.Paths, b.kvExportPath())
 	b.Backend.Paths = append(b.Backend.Paths, b.quotasPaths()...)
 	b.Backend.Paths = append(b.Backend.Paths, b.rootActivityPaths()...)
 	b.Backend.Paths = append(b.Backend.Paths, b.loginMFAPaths()...)
@@ -246,7 +268,8 @@ func NewSystemBackend(core *Core, logger log.Logger, config *logical.BackendConf
 		b.Backend.PathsSpecial.Unauthenticated = append(b.Backend.PathsSpecial.Unauthenticated, "storage/raft/remove-peer")
 	}
 
-	b.Backend.Invalidate = sysInvalidate(b)
+	b.entInvalidate = sysInvalidate(b)
+	b.Backend.Invalidate = b.invalidate
 	b.Backend.InitializeFunc = sysInitialize(b)
 	b.Backend.Clean = sysClean(b)
 	b.entInit()
@@ -275,6 +298,38 @@ type SystemBackend struct {
 	logger      log.Logger
 	mfaBackend  *PolicyMFABackend
 	syncBackend *SecretsSyncBackend
+
+	// entInvalidate handles the invalidation of the keys of enterprise
+	// features, if any
+	entInvalidate func(context.Context, string)
+}
+
+// invalidate drops the state cached in memory for keys of the system backend
+// changed by another node, so that standbys do not keep acting on stale state.
+func (b *SystemBackend) invalidate(ctx context.Context, key string) {
+	if b.entInvalidate != nil {
+		b.entInvalidate(ctx, key)
+	}
+
+	switch {
+	case strings.HasPrefix(key, auditSaltRotationSubPath), strings.HasPrefix(key, localAuditSaltRotationSubPath):
+		if b.Core.auditBroker != nil {
+			path := strings.TrimPrefix(strings.TrimPrefix(key, auditSaltRotationSubPath), localAuditSaltRotationSubPath)
+			b.Core.auditBroker.InvalidateSalt(ctx, path+"/")
+		}
+	case strings.HasPrefix(key, ttlPolicySubPath):
+		if b.Core.ttlPolicies != nil {
+			b.Core.ttlPolicies.invalidate(ctx, strings.TrimPrefix(key, ttlPolicySubPath))
+		}
+	case strings.HasPrefix(key, breakGlassSubPath):
+		if b.Core.breakGlass != nil {
+			b.Core.breakGlass.invalidate(ctx, strings.TrimPrefix(key, breakGlassSubPath))
+		}
+	case key == requestMaxDurationsKey:
+		if err := b.Core.setupRequestMaxDurations(ctx); err != nil {
+			b.logger.Error("failed to reload invalidated request max durations", "error", err)
+		}
+	}
 }
 
 // handleConfigStateSanitized returns the current configuration state. The configuration
@@ -376,6 +431,23 @@ func (b *SystemBackend) handleLeaseCount(ctx context.Context, req *logical.Reque
 	}, nil
 }
 
+// handleLeaseClientCount returns the number of leases of the namespace per
+// client name and version
+func (b *SystemBackend) handleLeaseClientCount(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	counts, err := b.Core.expiration.getLeaseClientCounts(ctx, d.Get("include_child_namespaces").(bool))
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &logical.Response{
+		Data: counts,
+	}
+	if b.Core.expiration.inRestoreMode() {
+		resp.AddWarning("Leases are still being restored, the counts may be incomplete.")
+	}
+	return resp, nil
+}
+
 func processLimit(d *framework.FieldData) (bool, int, error) {
 	limitStr := ""
 	limitRaw, ok := d.GetOk("limit")
@@ -1439,6 +1511,18 @@ func (b *SystemBackend) mountInfo(ctx context.Context, entry *MountEntry, legacy
 	return info
 }
 
+// addMountHealth adds the runtime health of the mount to its info when
+// requested, provided the mount is currently routed
+func (b *SystemBackend) addMountHealth(data *framework.FieldData, entry *MountEntry, info map[string]interface{}) {
+	if !data.Get("include_health").(bool) {
+		return
+	}
+
+	if health := b.Core.router.MountHealth(entry); health != nil {
+		info["health"] = health
+	}
+}
+
 // handleMountTable handles the "mounts" endpoint to provide the mount table
 func (b *SystemBackend) handleMountTable(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
 	ns, err := namespace.FromContext(ctx)
@@ -1469,6 +1553,7 @@ func (b *SystemBackend) handleMountTable(ctx context.Context, req *logical.Reque
 
 		// Populate mount info
 		info := b.mountInfo(ctx, entry, true)
+		b.addMountHealth(data, entry, info)
 
 		resp.Data[entry.Path] = info
 	}
@@ -1754,6 +1839,7 @@ func (b *SystemBackend) handleReadMount(ctx context.Context, req *logical.Reques
 	resp := &logical.Response{
 		Data: b.mountInfo(ctx, entry, true),
 	}
+	b.addMountHealth(data, entry, resp.Data)
 	if entry.Version != "" && entry.Version != entry.RunningVersion {
 		warning := fmt.Sprintf("Plugin version is configured as %q, but running %q", entry.Version, entry.RunningVersion)
 		if pin, _ := b.Core.pluginCatalog.GetPinnedVersion(ctx, consts.PluginTypeSecrets, entry.Type); pin != nil && pin.Version == entry.RunningVersion {
@@ -2197,6 +2283,10 @@ func (b *SystemBackend) handleMountTuneWrite(ctx context.Context, req *logical.R
 		return logical.ErrorResponse("missing path"), nil
 	}
 
+	if resp, err := b.checkMountAdminTune(ctx, req, data); resp != nil || err != nil {
+		return resp, err
+	}
+
 	// This call will write both logical backend's configuration as well as auth methods'.
 	// Retaining this behavior for backward compatibility. If this behavior is not desired,
 	// an error can be returned if path has a prefix of "auth/".
@@ -2922,6 +3012,12 @@ func (b *SystemBackend) handleLeaseLookup(ctx context.Context, req *logical.Requ
 		resp.Data["expire_time"] = leaseTimes.ExpireTime
 		resp.Data["ttl"] = leaseTimes.ttl()
 	}
+	if leaseTimes.ClientName != "" {
+		resp.Data["client_name"] = leaseTimes.ClientName
+	}
+	if leaseTimes.ClientVersion != "" {
+		resp.Data["client_version"] = leaseTimes.ClientVersion
+	}
 	return resp, nil
 }
 
@@ -3074,6 +3170,7 @@ func (b *SystemBackend) handleAuthTable(ctx context.Context, req *logical.Reques
 		}
 
 		info := b.mountInfo(ctx, entry, true)
+		b.addMountHealth(data, entry, info)
 		resp.Data[entry.Path] = info
 	}
 
@@ -3106,8 +3203,10 @@ func (b *SystemBackend) handleReadAuth(ctx context.Context, req *logical.Request
 			continue
 		}
 
+		info := b.mountInfo(ctx, entry, true)
+		b.addMountHealth(data, entry, info)
 		return &logical.Response{
-			Data: b.mountInfo(ctx, entry, true),
+			Data: info,
 		}, nil
 	}
 
@@ -3595,7 +3694,7 @@ func (b *SystemBackend) handlePoliciesSet(policyType PolicyType) framework.Opera
 			if resp == nil {
 				resp = &logical.Response{}
 			}
-			resp.AddWarning("'rules' is deprecated, please use 'policy' instead")
+			resp.AddWarningWithCode(logical.WarningCodeDeprecatedParameter, "'rules' is deprecated, please use 'policy' instead")
 		}
 		if policy.Raw == "" {
 			return logical.ErrorResponse("'policy' parameter not supplied or empty"), nil
@@ -3877,22 +3976,91 @@ func (b *SystemBackend) handleAuditTable(ctx context.Context, req *logical.Reque
 func (b *SystemBackend) handleAuditHash(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
 	path := data.Get("path").(string)
 	input := data.Get("input").(string)
-	if input == "" {
+	inputs := data.Get("inputs").([]string)
+	switch {
+	case input == "" && len(inputs) == 0:
 		return logical.ErrorResponse("the \"input\" parameter is empty"), nil
+	case input != "" && len(inputs) > 0:
+		return logical.ErrorResponse("only one of \"input\" or \"inputs\" may be specified"), nil
 	}
 
 	path = sanitizePath(path)
 
-	hash, err := b.Core.auditBroker.GetHash(ctx, path, input)
+	single := input != ""
+	if single {
+		inputs = []string{input}
+	}
+
+	hashes, err := b.Core.auditBroker.GetHashes(ctx, path, inputs)
 	if err != nil {
 		return logical.ErrorResponse(err.Error()), nil
 	}
 
-	return &logical.Response{
-		Data: map[string]interface{}{
-			"hash": hash,
-		},
-	}, nil
+	resp := &logical.Response{
+		Data: map[string]interface{}{},
+	}
+	if single {
+		resp.Data["hash"] = hashes.Hashes[0]
+	} else {
+		resp.Data["hashes"] = hashes.Hashes
+	}
+
+	if len(hashes.PreviousHashes) > 0 {
+		if single {
+			resp.Data["previous_hash"] = hashes.PreviousHashes[0]
+		} else {
+			resp.Data["previous_hashes"] = hashes.PreviousHashes
+		}
+		resp.Data["previous_expires_at"] = hashes.PreviousExpiresAt.Format(time.RFC3339)
+	}
+
+	return resp, nil
+}
+
+// handleAuditHashRotate is used to rotate the salt of the specified audit
+// backend, keeping the previous salt available for the requested overlap
+func (b *SystemBackend) handleAuditHashRotate(ctx context.Context, _ *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	path := sanitizePath(data.Get("path").(string))
+	overlap := time.Duration(data.Get("overlap").(int)) * time.Second
+	if overlap < 0 {
+		return logical.ErrorResponse("\"overlap\" cannot be negative"), nil
+	}
+
+	local, err := b.Core.auditBroker.IsLocal(path)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
+	// Salts of non-local audit devices are replicated from the primary, so
+	// they can only be rotated there.
+	if !local && b.Core.ReplicationState().HasState(consts.ReplicationPerformanceSecondary) {
+		return nil, logical.ErrReadOnly
+	}
+
+	if err := b.Core.auditBroker.RotateSalt(ctx, path, overlap); err != nil {
+		if errors.Is(err, audit.ErrInvalidParameter) {
+			return logical.ErrorResponse(err.Error()), nil
+		}
+		return nil, err
+	}
+
+	// Record the rotation, so that the other nodes drop the salt they have
+	// loaded when the record is invalidated
+	prefix := auditSaltRotationSubPath
+	if local {
+		prefix = localAuditSaltRotationSubPath
+	}
+	entry, err := logical.StorageEntryJSON(prefix+strings.TrimSuffix(path, "/"), map[string]interface{}{
+		"rotation_time": time.Now().UTC(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := b.Core.systemBarrierView.Put(ctx, entry); err != nil {
+		return nil, fmt.Errorf("failed to record audit salt rotation: %w", err)
+	}
+
+	return nil, nil
 }
 
 // handleEnableAudit is used to enable a new audit backend
@@ -4404,6 +4572,21 @@ func (b *SystemBackend) handleMonitor(ctx context.Context, req *logical.Request,
 		return logical.ErrorResponse("unknown log format"), nil
 	}
 
+	// Sinks receive log messages before the logger's own output is
+	// redacted, so redact the streamed messages separately
+	redactor, err := b.Core.LogRedactor()
+	if err != nil {
+		return nil, err
+	}
+
+	filter := &monitor.Filter{
+		Subsystems: data.Get("subsystems").([]string),
+		SampleRate: data.Get("sample_rate").(float64),
+	}
+	if err := filter.Validate(); err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
 	flusher, ok := w.ResponseWriter.(http.Flusher)
 	if !ok {
 		// http.ResponseWriter is wrapped in wrapGenericHandler, so let's
@@ -4421,10 +4604,10 @@ func (b *SystemBackend) handleMonitor(ctx context.Context, req *logical.Request,
 	isJson := b.Core.LogFormat() == "json" || lf == "json"
 	logger := b.Core.Logger().(log.InterceptLogger)
 
-	mon, err := monitor.NewMonitor(512, logger, &log.LoggerOptions{
+	mon, err := monitor.NewFilteredMonitor(512, logger, &log.LoggerOptions{
 		Level:      logLevel,
 		JSONFormat: isJson,
-	})
+	}, filter)
 	if err != nil {
 		return nil, err
 	}
@@ -4468,6 +4651,10 @@ func (b *SystemBackend) handleMonitor(ctx context.Context, req *logical.Request,
 		case <-ctx.Done():
 			return nil, nil
 		case l := <-logCh:
+			if redactor != nil {
+				l = redactor.Redact(l)
+			}
+
 			// We still return the error, but this will be ignored upstream
 			// due to the fact that we've already sent a response by
 			// writing the header and flushing the writer above.
@@ -6323,6 +6510,15 @@ west coast.
 and max_lease_ttl.`,
 	},
 
+	"mount_include_health": {
+		`Whether to include the runtime health of the mount in the response.`,
+	},
+
+	"mount_health": {
+		`Runtime health of this mount, including initialization errors,
+plugin crash counts, and the time of the last successful operation.`,
+	},
+
 	"mount_local": {
 		`Mark the mount as a local mount, which is not replicated
 and is unaffected by replication.`,
@@ -6613,7 +6809,101 @@ or delete a policy.
 
 	"audit-hash": {
 		"The hash of the given string via the given audit backend",
-		"",
+		`
+Returns the HMAC of the given input, or of each of the given inputs, using
+the salt of the audit device. If the salt of the audit device was rotated
+recently, the HMACs using the previous salt are also returned until the end
+of the overlap window requested when rotating.
+		`,
+	},
+
+	"audit-hash-input": {
+		"The input string to hash.",
+	},
+
+	"audit-hash-inputs": {
+		"A list of input strings to hash in a single request.",
+	},
+
+	"break-glass-config": {
+		"Configure the break-glass policy escalations.",
+		`
+Break-glass escalations temporarily add policies to an existing operator token
+once enough other operators have approved them, as an alternative to generating
+a root token during an incident.
+
+This path configures the policies that may be requested, the number of
+approvals they need, how long they may be granted for and the reason codes
+they may be requested with.
+		`,
+	},
+	"break-glass-request": {
+		"Request, inspect and revoke break-glass policy escalations.",
+		`
+A break-glass request asks for policies to be added to the token making the
+request, for one of the configured reason codes. It is granted, for its TTL,
+once the configured number of distinct operators other than the requester have
+approved it. Requests that are not approved within the approval TTL expire.
+		`,
+	},
+	"break-glass-approve": {
+		"Approve a break-glass policy escalation.",
+		`
+Records the approval of a pending break-glass request by the operator making
+the request. The requester cannot approve their own request, and each operator,
+identified by their entity or otherwise by their token, is counted once.
+		`,
+	},
+	"requests-in-flight": {
+		"List and cancel the requests in flight on this node.",
+		`
+Lists the requests this node is handling, longest-running first, and cancels
+a specific request by its ID. Cancelling a request cancels its context, so
+that backends which honor it, such as a database plugin stuck creating
+credentials, give up and release their locks. Requests are tracked per node,
+and only the requests of the node handling the call are listed.
+		`,
+	},
+	"requests-max-durations": {
+		"Configure the maximum duration of requests to specific paths.",
+		`
+Maps path prefixes, including the namespace path, to the maximum duration of
+requests to them. The longest matching prefix applies; other requests are
+limited by the max_request_duration of their listener.
+		`,
+	},
+	"broker-sessions": {
+		"Open, extend, inspect and end the sessions credentials are brokered for.",
+		`
+Broker sessions let an external broker, such as a proxy of database or SSH
+connections, scope the dynamic credentials it requests to a user session. The
+broker sends heartbeats while the session is in use, and ends it when the user
+disconnects. The leases of the credentials requested for the session are
+revoked when it ends, when it misses its heartbeats, or when it reaches its
+max TTL, whichever comes first.
+		`,
+	},
+	"broker-session-creds": {
+		"Request dynamic credentials for a broker session.",
+		`
+Requests dynamic credentials from the given path with the token of the broker,
+and records their lease on the session so that it is revoked when the session
+ends. The path must return leased credentials. Only the token which opened the
+session may request credentials for it.
+		`,
+	},
+	"audit-hash-rotate": {
+		"Rotate the salt used to HMAC values via the given audit backend",
+		`
+Replaces the salt the audit device uses to HMAC sensitive values with a newly
+generated one. The previous salt remains available to the audit-hash endpoint
+for the overlap window, so that values logged before and after the rotation
+can still be correlated.
+		`,
+	},
+
+	"audit-hash-rotate-overlap": {
+		"How long the previous salt remains available to the audit-hash endpoint. Defaults to 24h.",
 	},
 
 	"audit-table": {
@@ -6920,6 +7210,14 @@ This path responds to the following HTTP methods.
 		"The name of the key used to sign plugin identity tokens. Defaults to the default key.",
 		"",
 	},
+	"leases-irrevocable": {
+		"List, retry, or acknowledge irrevocable leases.",
+		`Leases whose revocation keeps failing, such as when the database they were
+		issued by is unreachable, are quarantined as irrevocable rather than retried
+		forever. They are retried once a day, and their revocation can be retried on
+		demand. Once the secret of an irrevocable lease has been dealt with out of
+		band, acknowledging the lease removes it without revoking it in its backend.`,
+	},
 	"leases": {
 		`View or list lease metadata.`,
 		`
@@ -7066,6 +7364,85 @@ This path responds to the following HTTP methods.
 		The information that gets collected includes host hardware information, and CPU,
 		disk, and memory utilization`,
 	},
+	"monitor": {
+		"Stream the server's logs.",
+		`Stream the server's logs at the requested level, in either the standard or
+		JSON format. The logs may be limited to those of particular subsystems, such
+		as "storage.raft", and logs below the warn level may be sampled to reduce
+		their volume.`,
+	},
+	"secrets-manifest": {
+		"Render the values of a manifest of secrets in a single request.",
+		`Each secret of the manifest is requested on behalf of the client, subject to
+		the client's policies, and rendered either as the value of one of its keys, as
+		a template, or as JSON. The version of KV version 2 secrets and the lease of
+		dynamic secrets are returned alongside each value. A failure to render one
+		secret is returned as the error of that item, rather than failing the request.`,
+	},
+	"kv-export": {
+		"Render a KV subtree into a bootstrap file format.",
+		`Every secret under the given path of a KV secrets engine is listed and read on
+		behalf of the client, subject to the client's policies, and rendered as a
+		dotenv file, a Java properties file, a JSON tree or a systemd EnvironmentFile.
+		Variables and properties are named after the path of each key within the
+		subtree. The parts of the subtree the client may not list or read are skipped
+		and returned, rather than failing the export.`,
+	},
+	"mount-blueprints": {
+		"Read, Modify, Delete, or Instantiate mount blueprints.",
+		`A mount blueprint is a parameterized bundle of a secrets engine mount: its
+		type and tune options, the writes configuring it, such as roles, and the ACL
+		policies granting access to it. Instantiating a blueprint with the values of
+		its parameters, such as a team or application name, mounts and configures the
+		engine in one call, so that mounts set up for many teams stay consistent.
+		Each step of the instantiation is made on behalf of the client, subject to its
+		policies, and a failed instantiation is rolled back.`,
+	},
+	"mount-admins": {
+		"Read, Modify, or Delete the admin grants of a mount.",
+		`Admin grants give the members of identity groups, direct or inherited,
+		admin rights over a secrets mount without writing sys policies: full access
+		to the config and roles paths of the mount, and the right to read and tune
+		the mount. Tuning the parameters which control the auditing and plugin of
+		the mount requires a policy of its own.`,
+	},
+	"config-history": {
+		"List and read the recorded changes to the configuration of Vault.",
+		`Every successful change to mounts, auth methods, policies, sys/config and
+		the config paths of secrets engines and auth methods is recorded, along
+		with who made it, when, and a diff of the fields it changed. Values of
+		sensitive fields, such as passwords and secret keys, are redacted. Only
+		the most recent 10000 changes are kept.`,
+	},
+	"trust-bundles": {
+		"Read, Modify, or Delete trust bundles, or fetch them signed.",
+		`A trust bundle aggregates the CA chains of PKI mounts and the CA public keys
+		of SSH mounts into a single document, signed by a key dedicated to the bundle,
+		so that host provisioning can fetch and verify all of them in one request. The
+		bundle is signed either as a JWS or as a CMS SignedData, and its signing
+		certificate can be pinned by clients.`,
+	},
+	"mount-slo": {
+		"Read, Modify, or Delete the service level objectives of a mount.",
+		`Service level objectives set latency targets for the read and write requests
+		of a mount, and size targets for their request and response bodies. When less
+		than the objective fraction of the requests of a window meet a target, a
+		vault.route.slo.breach metric is emitted and a mount/slo-breach event is sent.`,
+	},
+	"auth-network-requirements": {
+		"Read, Modify, or Delete the network requirements of logins to an auth mount.",
+		`Network requirements restrict the network paths logins to an auth mount may
+		arrive through. Logins may be required to be received on one of the named
+		listeners, and to present a TLS client certificate issued by one of the given
+		CAs. Logins which do not meet the requirements are denied.`,
+	},
+	"ttl-policies": {
+		"Read, Modify, or Delete TTL policies.",
+		`TTL policies impose a maximum TTL on the leases and tokens issued by requests
+		to the paths they match, across all mounts and namespaces. The maximum TTL is
+		counted from when the lease or token was issued, and applies to its renewals
+		too. When several policies match a path, the smallest maximum TTL applies.`,
+	},
 	"activity-query": {
 		"Query the historical count of clients.",
 		"Query the historical count of clients.",
@@ -7086,6 +7463,14 @@ This path responds to the following HTTP methods.
 		"Count of leases associated with this Vault cluster",
 		"Count of leases associated with this Vault cluster",
 	},
+	"lease-clients": {
+		"Count of leases per client name and version",
+		`
+Returns the number of leases created by each client, as identified by the
+X-Vault-Client-Name and X-Vault-Client-Version headers of the request that
+created the lease. Leases created without a client name are not counted.
+		`,
+	},
 	"list-leases": {
 		"List leases associated with this Vault cluster",
 		"Requires sudo capability. List leases associated with this Vault cluster",
@@ -0,0 +1,177 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/hashicorp/go-secure-stdlib/strutil"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/consts"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func (b *SystemBackend) authNetworkRequirementsPaths() []*framework.Path {
+	return []*framework.Path{
+		{
+			Pattern: "auth/(?P<path>.+?)/network-requirements$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "auth",
+				OperationSuffix: "network-requirements",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"path": {
+					Type:        framework.TypeString,
+					Description: "The path of the auth mount, relative to auth/.",
+				},
+				"allowed_listeners": {
+					Type:        framework.TypeCommaStringSlice,
+					Description: "Names of the listeners logins must be received on.",
+				},
+				"client_ca_pem": {
+					Type:        framework.TypeString,
+					Description: "PEM-encoded CA certificates, one of which must have issued the TLS client certificate presented by logins.",
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleAuthNetworkRequirementsRead,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "read",
+					},
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"allowed_listeners": {
+									Type: framework.TypeCommaStringSlice,
+								},
+								"client_ca_pem": {
+									Type: framework.TypeString,
+								},
+							},
+						}},
+					},
+					Summary: "Read the network requirements of logins to an auth mount.",
+				},
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleAuthNetworkRequirementsUpdate,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "configure",
+					},
+					Responses: map[int][]framework.Response{
+						http.StatusNoContent: {{
+							Description: "OK",
+						}},
+					},
+					Summary: "Set the network requirements of logins to an auth mount.",
+				},
+				logical.DeleteOperation: &framework.PathOperation{
+					Callback: b.handleAuthNetworkRequirementsDelete,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "delete",
+					},
+					Responses: map[int][]framework.Response{
+						http.StatusNoContent: {{
+							Description: "OK",
+						}},
+					},
+					Summary: "Remove the network requirements of logins to an auth mount.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["auth-network-requirements"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["auth-network-requirements"][1]),
+		},
+	}
+}
+
+func (b *SystemBackend) handleAuthNetworkRequirementsRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	path := sanitizePath(data.Get("path").(string))
+
+	b.Core.authLock.RLock()
+	defer b.Core.authLock.RUnlock()
+
+	mountEntry, err := b.authNetworkRequirementsEntry(ctx, path)
+	if err != nil {
+		return handleError(err)
+	}
+	if mountEntry.NetworkRequirements == nil {
+		return nil, nil
+	}
+
+	allowedListeners := mountEntry.NetworkRequirements.AllowedListeners
+	if allowedListeners == nil {
+		allowedListeners = []string{}
+	}
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"allowed_listeners": allowedListeners,
+			"client_ca_pem":     mountEntry.NetworkRequirements.ClientCAPEM,
+		},
+	}, nil
+}
+
+func (b *SystemBackend) handleAuthNetworkRequirementsUpdate(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	requirements := &AuthNetworkRequirements{
+		AllowedListeners: strutil.RemoveDuplicates(data.Get("allowed_listeners").([]string), false),
+		ClientCAPEM:      strings.TrimSpace(data.Get("client_ca_pem").(string)),
+	}
+	if err := requirements.validate(); err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
+	return b.setAuthNetworkRequirements(ctx, sanitizePath(data.Get("path").(string)), requirements)
+}
+
+func (b *SystemBackend) handleAuthNetworkRequirementsDelete(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	return b.setAuthNetworkRequirements(ctx, sanitizePath(data.Get("path").(string)), nil)
+}
+
+func (b *SystemBackend) setAuthNetworkRequirements(ctx context.Context, path string, requirements *AuthNetworkRequirements) (*logical.Response, error) {
+	b.Core.authLock.Lock()
+	defer b.Core.authLock.Unlock()
+
+	mountEntry, err := b.authNetworkRequirementsEntry(ctx, path)
+	if err != nil {
+		return handleError(err)
+	}
+	if !mountEntry.Local && b.Core.ReplicationState().HasState(consts.ReplicationPerformanceSecondary) {
+		return nil, logical.ErrReadOnly
+	}
+
+	oldRequirements := mountEntry.NetworkRequirements
+	mountEntry.NetworkRequirements = requirements
+	if err := b.Core.persistAuth(ctx, b.Core.auth, &mountEntry.Local); err != nil {
+		mountEntry.NetworkRequirements = oldRequirements
+		return handleError(err)
+	}
+
+	if b.Core.logger.IsInfo() {
+		b.Core.logger.Info("updated auth mount network requirements", "path", path)
+	}
+
+	return nil, nil
+}
+
+// authNetworkRequirementsEntry returns the auth mount at exactly the given
+// path, relative to auth/. The token auth method cannot be restricted.
+func (b *SystemBackend) authNetworkRequirementsEntry(ctx context.Context, path string) (*MountEntry, error) {
+	if path == "token/" {
+		return nil, fmt.Errorf("cannot set network requirements on %q", credentialRoutePrefix+path)
+	}
+
+	mountEntry := b.Core.router.MatchingMountEntry(ctx, credentialRoutePrefix+path)
+	if mountEntry == nil || mountEntry.Table != credentialTableType || mountEntry.Path != path {
+		return nil, fmt.Errorf("no auth mount found at %q", credentialRoutePrefix+path)
+	}
+
+	return mountEntry, nil
+}
@@ -0,0 +1,123 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/helper/testhelpers/certhelpers"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSystemBackend_AuthNetworkRequirements(t *testing.T) {
+	noop := &NoopBackend{
+		Login: []string{"login"},
+		Response: &logical.Response{
+			Auth: &logical.Auth{
+				Policies: []string{"default"},
+			},
+		},
+		BackendType: logical.TypeCredential,
+	}
+	c, _, root := TestCoreUnsealed(t)
+	c.credentialBackends["noop"] = func(ctx context.Context, conf *logical.BackendConfig) (logical.Backend, error) {
+		return noop, nil
+	}
+	ctx := namespace.RootContext(nil)
+
+	handle := func(op logical.Operation, path string, data map[string]interface{}) (*logical.Response, error) {
+		t.Helper()
+		req := logical.TestRequest(t, op, path)
+		req.ClientToken = root
+		req.Data = data
+		return c.HandleRequest(ctx, req)
+	}
+	login := func(ctx context.Context, certs ...certhelpers.Certificate) error {
+		t.Helper()
+		req := &logical.Request{
+			Operation:  logical.UpdateOperation,
+			Path:       "auth/trusted/login",
+			Connection: &logical.Connection{RemoteAddr: "127.0.0.1"},
+		}
+		if len(certs) > 0 {
+			req.Connection.ConnState = &tls.ConnectionState{}
+			for _, cert := range certs {
+				parsed, err := x509.ParseCertificate(cert.RawCert)
+				require.NoError(t, err)
+				req.Connection.ConnState.PeerCertificates = append(req.Connection.ConnState.PeerCertificates, parsed)
+			}
+		}
+		_, err := c.HandleRequest(ctx, req)
+		return err
+	}
+
+	_, err := handle(logical.UpdateOperation, "sys/auth/trusted", map[string]interface{}{"type": "noop"})
+	require.NoError(t, err)
+
+	resp, err := handle(logical.ReadOperation, "sys/auth/trusted/network-requirements", nil)
+	require.NoError(t, err)
+	require.Nil(t, resp)
+	require.NoError(t, login(ctx))
+
+	for name, data := range map[string]map[string]interface{}{
+		"no requirements": {},
+		"invalid CA":      {"client_ca_pem": "not a certificate"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			resp, err := handle(logical.UpdateOperation, "sys/auth/trusted/network-requirements", data)
+			require.ErrorIs(t, err, logical.ErrInvalidRequest)
+			require.True(t, resp.IsError())
+		})
+	}
+	_, err = handle(logical.UpdateOperation, "sys/auth/token/network-requirements", map[string]interface{}{
+		"allowed_listeners": "internal",
+	})
+	require.Error(t, err)
+	_, err = handle(logical.UpdateOperation, "sys/auth/unknown/network-requirements", map[string]interface{}{
+		"allowed_listeners": "internal",
+	})
+	require.Error(t, err)
+
+	// Logins must arrive on the internal listener
+	_, err = handle(logical.UpdateOperation, "sys/auth/trusted/network-requirements", map[string]interface{}{
+		"allowed_listeners": "internal,admin",
+	})
+	require.NoError(t, err)
+	require.ErrorIs(t, login(ctx), logical.ErrPermissionDenied)
+	require.ErrorIs(t, login(ContextWithListenerName(ctx, "public")), logical.ErrPermissionDenied)
+	require.NoError(t, login(ContextWithListenerName(ctx, "internal")))
+
+	// and present a client certificate issued by the trusted CA
+	ca := certhelpers.NewCert(t, certhelpers.CommonName("trusted-ca"), certhelpers.IsCA(true), certhelpers.SelfSign())
+	client := certhelpers.NewCert(t, certhelpers.CommonName("client"), certhelpers.Parent(ca))
+	otherCA := certhelpers.NewCert(t, certhelpers.CommonName("other-ca"), certhelpers.IsCA(true), certhelpers.SelfSign())
+	otherClient := certhelpers.NewCert(t, certhelpers.CommonName("client"), certhelpers.Parent(otherCA))
+
+	_, err = handle(logical.UpdateOperation, "sys/auth/trusted/network-requirements", map[string]interface{}{
+		"allowed_listeners": "internal",
+		"client_ca_pem":     string(ca.Pem),
+	})
+	require.NoError(t, err)
+
+	resp, err = handle(logical.ReadOperation, "sys/auth/trusted/network-requirements", nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{"internal"}, resp.Data["allowed_listeners"])
+	require.Equal(t, strings.TrimSpace(string(ca.Pem)), resp.Data["client_ca_pem"])
+
+	internal := ContextWithListenerName(ctx, "internal")
+	require.ErrorIs(t, login(internal), logical.ErrPermissionDenied)
+	require.ErrorIs(t, login(internal, otherClient), logical.ErrPermissionDenied)
+	require.ErrorIs(t, login(ctx, client), logical.ErrPermissionDenied)
+	require.NoError(t, login(internal, client))
+
+	_, err = handle(logical.DeleteOperation, "sys/auth/trusted/network-requirements", nil)
+	require.NoError(t, err)
+	require.NoError(t, login(ctx))
+}
@@ -0,0 +1,492 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func (b *SystemBackend) breakGlassPaths() []*framework.Path {
+	requestIDField := &framework.FieldSchema{
+		Type:        framework.TypeString,
+		Description: "The ID of the break-glass request.",
+	}
+	requestResponse := map[int][]framework.Response{
+		http.StatusOK: {{
+			Description: "OK",
+			Fields:      breakGlassRequestResponseFields,
+		}},
+	}
+
+	return []*framework.Path{
+		{
+			Pattern: "break-glass/config$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "break-glass",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"allowed_policies": {
+					Type:        framework.TypeCommaStringSlice,
+					Description: `The policies that may be requested. The "root" policy cannot be allowed.`,
+				},
+				"required_approvals": {
+					Type:        framework.TypeInt,
+					Description: "The number of distinct operators, other than the requester, who must approve a request.",
+				},
+				"max_ttl": {
+					Type:        framework.TypeDurationSecond,
+					Description: "The longest time an escalation may be granted for.",
+				},
+				"approval_ttl": {
+					Type:        framework.TypeDurationSecond,
+					Description: "The time a request may wait for its approvals.",
+				},
+				"reason_codes": {
+					Type:        framework.TypeCommaStringSlice,
+					Description: "The reasons an escalation may be requested for.",
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleBreakGlassConfigRead,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb:   "read",
+						OperationSuffix: "configuration",
+					},
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"allowed_policies": {
+									Type:     framework.TypeCommaStringSlice,
+									Required: true,
+								},
+								"required_approvals": {
+									Type:     framework.TypeInt,
+									Required: true,
+								},
+								"max_ttl": {
+									Type:     framework.TypeDurationSecond,
+									Required: true,
+								},
+								"approval_ttl": {
+									Type:     framework.TypeDurationSecond,
+									Required: true,
+								},
+								"reason_codes": {
+									Type:     framework.TypeCommaStringSlice,
+									Required: true,
+								},
+							},
+						}},
+					},
+					Summary: "Read the break-glass configuration.",
+				},
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleBreakGlassConfigUpdate,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "configure",
+					},
+					Responses: map[int][]framework.Response{
+						http.StatusNoContent: {{
+							Description: "OK",
+						}},
+					},
+					Summary: "Configure which policies may be requested, and how many approvals they need.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["break-glass-config"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["break-glass-config"][1]),
+		},
+
+		{
+			Pattern: "break-glass/request$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "break-glass",
+				OperationVerb:   "request",
+				OperationSuffix: "escalation",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"policies": {
+					Type:        framework.TypeCommaStringSlice,
+					Description: "The policies to add to the token making the request.",
+					Required:    true,
+				},
+				"ttl": {
+					Type:        framework.TypeDurationSecond,
+					Description: "How long the policies are granted for once approved. Defaults to the configured max_ttl.",
+				},
+				"reason_code": {
+					Type:        framework.TypeString,
+					Description: "The reason of the escalation, one of the configured reason codes.",
+					Required:    true,
+				},
+				"justification": {
+					Type:        framework.TypeString,
+					Description: "A description of why the escalation is needed.",
+					Required:    true,
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback:  b.handleBreakGlassRequest,
+					Responses: requestResponse,
+					Summary:   "Request a temporary policy escalation for the token making the request.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["break-glass-request"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["break-glass-request"][1]),
+		},
+
+		{
+			Pattern: "break-glass/requests/?$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "break-glass",
+				OperationSuffix: "requests",
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ListOperation: &framework.PathOperation{
+					Callback: b.handleBreakGlassRequestsList,
+					Summary:  "List the break-glass requests, oldest first.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["break-glass-request"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["break-glass-request"][1]),
+		},
+
+		{
+			Pattern: "break-glass/requests/" + framework.GenericNameRegex("id") + "$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "break-glass",
+				OperationVerb:   "read",
+				OperationSuffix: "request",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"id": requestIDField,
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback:  b.handleBreakGlassRequestRead,
+					Responses: requestResponse,
+					Summary:   "Read a break-glass request and its approvals.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["break-glass-request"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["break-glass-request"][1]),
+		},
+
+		{
+			Pattern: "break-glass/approve/" + framework.GenericNameRegex("id") + "$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "break-glass",
+				OperationVerb:   "approve",
+				OperationSuffix: "request",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"id": requestIDField,
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback:  b.handleBreakGlassApprove,
+					Responses: requestResponse,
+					Summary:   "Approve a break-glass request.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["break-glass-approve"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["break-glass-approve"][1]),
+		},
+
+		{
+			Pattern: "break-glass/revoke/" + framework.GenericNameRegex("id") + "$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "break-glass",
+				OperationVerb:   "revoke",
+				OperationSuffix: "request",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"id": requestIDField,
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback:  b.handleBreakGlassRevoke,
+					Responses: requestResponse,
+					Summary:   "End a pending or active break-glass escalation.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["break-glass-request"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["break-glass-request"][1]),
+		},
+	}
+}
+
+var breakGlassRequestResponseFields = map[string]*framework.FieldSchema{
+	"id": {
+		Type:     framework.TypeString,
+		Required: true,
+	},
+	"status": {
+		Type:        framework.TypeString,
+		Description: `One of "pending", "active", "expired" or "revoked".`,
+		Required:    true,
+	},
+	"accessor": {
+		Type:     framework.TypeString,
+		Required: true,
+	},
+	"entity_id": {
+		Type:     framework.TypeString,
+		Required: true,
+	},
+	"display_name": {
+		Type:     framework.TypeString,
+		Required: true,
+	},
+	"policies": {
+		Type:     framework.TypeCommaStringSlice,
+		Required: true,
+	},
+	"ttl": {
+		Type:     framework.TypeDurationSecond,
+		Required: true,
+	},
+	"reason_code": {
+		Type:     framework.TypeString,
+		Required: true,
+	},
+	"justification": {
+		Type:     framework.TypeString,
+		Required: true,
+	},
+	"required_approvals": {
+		Type:     framework.TypeInt,
+		Required: true,
+	},
+	"approvals": {
+		Type:     framework.TypeSlice,
+		Required: true,
+	},
+	"creation_time": {
+		Type:     framework.TypeTime,
+		Required: true,
+	},
+	"approval_deadline": {
+		Type:     framework.TypeTime,
+		Required: true,
+	},
+	"grant_time": {
+		Type:     framework.TypeTime,
+		Required: false,
+	},
+	"expire_time": {
+		Type:     framework.TypeTime,
+		Required: false,
+	},
+	"revoke_time": {
+		Type:     framework.TypeTime,
+		Required: false,
+	},
+}
+
+// handleBreakGlassConfigRead returns the break-glass configuration
+func (b *SystemBackend) handleBreakGlassConfigRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	config := b.Core.breakGlass.Config()
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"allowed_policies":   config.AllowedPolicies,
+			"required_approvals": config.RequiredApprovals,
+			"max_ttl":            int64(config.MaxTTL.Seconds()),
+			"approval_ttl":       int64(config.ApprovalTTL.Seconds()),
+			"reason_codes":       config.ReasonCodes,
+		},
+	}, nil
+}
+
+// handleBreakGlassConfigUpdate updates the break-glass configuration. Requests
+// already made keep the number of approvals required when they were made.
+func (b *SystemBackend) handleBreakGlassConfigUpdate(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	config := *b.Core.breakGlass.Config()
+	if allowedPolicies, ok := d.GetOk("allowed_policies"); ok {
+		config.AllowedPolicies = allowedPolicies.([]string)
+	}
+	if requiredApprovals, ok := d.GetOk("required_approvals"); ok {
+		config.RequiredApprovals = requiredApprovals.(int)
+	}
+	if maxTTL, ok := d.GetOk("max_ttl"); ok {
+		config.MaxTTL = time.Duration(maxTTL.(int)) * time.Second
+	}
+	if approvalTTL, ok := d.GetOk("approval_ttl"); ok {
+		config.ApprovalTTL = time.Duration(approvalTTL.(int)) * time.Second
+	}
+	if reasonCodes, ok := d.GetOk("reason_codes"); ok {
+		config.ReasonCodes = reasonCodes.([]string)
+	}
+
+	if err := config.validate(); err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+	if err := b.Core.breakGlass.SetConfig(ctx, &config); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+// handleBreakGlassRequest requests an escalation for the token making the
+// request
+func (b *SystemBackend) handleBreakGlassRequest(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	te, err := b.Core.LookupToken(ctx, req.ClientToken)
+	if err != nil {
+		return nil, err
+	}
+	if te == nil || te.Type != logical.TokenTypeService {
+		return logical.ErrorResponse("break-glass escalations can only be requested for service tokens"), logical.ErrInvalidRequest
+	}
+
+	id, err := uuid.GenerateUUID()
+	if err != nil {
+		return nil, err
+	}
+	request := &BreakGlassRequest{
+		ID:            id,
+		Accessor:      te.Accessor,
+		EntityID:      te.EntityID,
+		NamespaceID:   te.NamespaceID,
+		DisplayName:   te.DisplayName,
+		Policies:      d.Get("policies").([]string),
+		TTL:           time.Duration(d.Get("ttl").(int)) * time.Second,
+		ReasonCode:    d.Get("reason_code").(string),
+		Justification: d.Get("justification").(string),
+	}
+	if err := b.Core.breakGlass.Create(ctx, request); err != nil {
+		return handleError(err)
+	}
+
+	return breakGlassRequestResponse(request), nil
+}
+
+// handleBreakGlassRequestsList returns the IDs of the break-glass requests
+func (b *SystemBackend) handleBreakGlassRequestsList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	return logical.ListResponse(b.Core.breakGlass.List()), nil
+}
+
+// handleBreakGlassRequestRead returns a break-glass request
+func (b *SystemBackend) handleBreakGlassRequestRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	request := b.Core.breakGlass.Get(d.Get("id").(string))
+	if request == nil {
+		return nil, nil
+	}
+
+	return breakGlassRequestResponse(request), nil
+}
+
+// handleBreakGlassApprove records the approval of a break-glass request by the
+// operator making the request
+func (b *SystemBackend) handleBreakGlassApprove(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	te, err := b.Core.LookupToken(ctx, req.ClientToken)
+	if err != nil {
+		return nil, err
+	}
+	if te == nil || te.Type != logical.TokenTypeService {
+		return logical.ErrorResponse("break-glass requests can only be approved with service tokens"), logical.ErrInvalidRequest
+	}
+
+	request, err := b.Core.breakGlass.Approve(ctx, d.Get("id").(string), &BreakGlassApproval{
+		Accessor:    te.Accessor,
+		EntityID:    te.EntityID,
+		DisplayName: te.DisplayName,
+		Time:        time.Now(),
+	})
+	switch {
+	case errors.Is(err, errBreakGlassRequestNotFound):
+		return nil, logical.CodedError(http.StatusNotFound, err.Error())
+	case err != nil:
+		return handleError(err)
+	}
+
+	return breakGlassRequestResponse(request), nil
+}
+
+// handleBreakGlassRevoke ends a break-glass request
+func (b *SystemBackend) handleBreakGlassRevoke(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	request, err := b.Core.breakGlass.Revoke(ctx, d.Get("id").(string))
+	switch {
+	case errors.Is(err, errBreakGlassRequestNotFound):
+		return nil, logical.CodedError(http.StatusNotFound, err.Error())
+	case err != nil:
+		return handleError(err)
+	}
+
+	return breakGlassRequestResponse(request), nil
+}
+
+func breakGlassRequestResponse(request *BreakGlassRequest) *logical.Response {
+	approvals := make([]map[string]interface{}, 0, len(request.Approvals))
+	for _, approval := range request.Approvals {
+		approvals = append(approvals, map[string]interface{}{
+			"accessor":     approval.Accessor,
+			"entity_id":    approval.EntityID,
+			"display_name": approval.DisplayName,
+			"time":         approval.Time,
+		})
+	}
+
+	data := map[string]interface{}{
+		"id":                 request.ID,
+		"status":             request.Status(time.Now()),
+		"accessor":           request.Accessor,
+		"entity_id":          request.EntityID,
+		"display_name":       request.DisplayName,
+		"policies":           request.Policies,
+		"ttl":                int64(request.TTL.Seconds()),
+		"reason_code":        request.ReasonCode,
+		"justification":      request.Justification,
+		"required_approvals": request.RequiredApprovals,
+		"approvals":          approvals,
+		"creation_time":      request.CreationTime,
+		"approval_deadline":  request.ApprovalDeadline,
+	}
+	if !request.GrantTime.IsZero() {
+		data["grant_time"] = request.GrantTime
+		data["expire_time"] = request.ExpireTime
+	}
+	if !request.RevokeTime.IsZero() {
+		data["revoke_time"] = request.RevokeTime
+	}
+
+	return &logical.Response{
+		Data: data,
+	}
+}
@@ -0,0 +1,228 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSystemBackend_BreakGlass(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	ctx := namespace.RootContext(nil)
+
+	handle := func(token string, op logical.Operation, path string, data map[string]interface{}) (*logical.Response, error) {
+		t.Helper()
+		req := logical.TestRequest(t, op, path)
+		req.ClientToken = token
+		req.Data = data
+		return c.HandleRequest(ctx, req)
+	}
+
+	for name, policy := range map[string]string{
+		"incident-admin": `path "secret/*" { capabilities = ["read"] }`,
+		"operator":       `path "sys/break-glass/request" { capabilities = ["update"] }`,
+		"approver":       `path "sys/break-glass/approve/*" { capabilities = ["update", "sudo"] }`,
+	} {
+		_, err := handle(root, logical.UpdateOperation, "sys/policy/"+name, map[string]interface{}{
+			"policy": policy,
+		})
+		require.NoError(t, err)
+	}
+	createEntity := func(name string) string {
+		t.Helper()
+		resp, err := handle(root, logical.UpdateOperation, "identity/entity", map[string]interface{}{
+			"name": name,
+		})
+		require.NoError(t, err)
+		return resp.Data["id"].(string)
+	}
+	createToken := func(entityID string, policies ...string) string {
+		t.Helper()
+		te := &logical.TokenEntry{
+			Path:     "auth/token/create",
+			EntityID: entityID,
+			Policies: policies,
+			TTL:      time.Hour,
+		}
+		testMakeTokenDirectly(t, c.tokenStore, te)
+		return te.ID
+	}
+	operatorEntity := createEntity("operator")
+	operator := createToken(operatorEntity, "operator", "approver")
+	approvers := []string{
+		createToken(createEntity("approver-1"), "approver"),
+		createToken(createEntity("approver-2"), "approver"),
+	}
+
+	_, err := handle(root, logical.UpdateOperation, "secret/foo", map[string]interface{}{
+		"value": "bar",
+	})
+	require.NoError(t, err)
+
+	// Only allowed policies may be requested
+	request := map[string]interface{}{
+		"policies":      "incident-admin",
+		"reason_code":   "incident",
+		"justification": "database credentials leaked",
+	}
+	resp, err := handle(operator, logical.UpdateOperation, "sys/break-glass/request", request)
+	require.Error(t, err)
+	require.True(t, resp.IsError())
+
+	resp, err = handle(root, logical.UpdateOperation, "sys/break-glass/config", map[string]interface{}{
+		"allowed_policies": "incident-admin",
+		"max_ttl":          "30m",
+	})
+	require.NoError(t, err)
+	require.Nil(t, resp)
+
+	for name, data := range map[string]map[string]interface{}{
+		"unknown reason code": {"policies": "incident-admin", "reason_code": "curiosity", "justification": "none"},
+		"no justification":    {"policies": "incident-admin", "reason_code": "incident"},
+		"ttl above max":       {"policies": "incident-admin", "reason_code": "incident", "justification": "none", "ttl": "2h"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			resp, err := handle(operator, logical.UpdateOperation, "sys/break-glass/request", data)
+			require.Error(t, err)
+			require.True(t, resp.IsError())
+		})
+	}
+
+	resp, err = handle(operator, logical.UpdateOperation, "sys/break-glass/request", request)
+	require.NoError(t, err)
+	require.Equal(t, BreakGlassStatusPending, resp.Data["status"])
+	require.Equal(t, 2, resp.Data["required_approvals"])
+	require.Equal(t, int64(1800), resp.Data["ttl"])
+	id := resp.Data["id"].(string)
+
+	_, err = handle(operator, logical.ReadOperation, "secret/foo", nil)
+	require.ErrorIs(t, err, logical.ErrPermissionDenied)
+
+	// The requester cannot approve their own request, even with sudo or with
+	// another of their tokens
+	for _, token := range []string{operator, createToken(operatorEntity, "approver")} {
+		resp, err = handle(token, logical.UpdateOperation, "sys/break-glass/approve/"+id, nil)
+		require.Error(t, err)
+		require.Contains(t, resp.Error().Error(), "requester")
+	}
+
+	// Operators are told apart by entity, so tokens without one cannot
+	// approve requests
+	resp, err = handle(createToken("", "approver"), logical.UpdateOperation, "sys/break-glass/approve/"+id, nil)
+	require.Error(t, err)
+	require.Contains(t, resp.Error().Error(), "identity entity")
+
+	resp, err = handle(approvers[0], logical.UpdateOperation, "sys/break-glass/approve/"+id, nil)
+	require.NoError(t, err)
+	require.Equal(t, BreakGlassStatusPending, resp.Data["status"])
+
+	resp, err = handle(approvers[0], logical.UpdateOperation, "sys/break-glass/approve/"+id, nil)
+	require.Error(t, err)
+	require.Contains(t, resp.Error().Error(), "already approved")
+
+	_, err = handle(operator, logical.ReadOperation, "secret/foo", nil)
+	require.ErrorIs(t, err, logical.ErrPermissionDenied)
+
+	resp, err = handle(approvers[1], logical.UpdateOperation, "sys/break-glass/approve/"+id, nil)
+	require.NoError(t, err)
+	require.Equal(t, BreakGlassStatusActive, resp.Data["status"])
+	require.Len(t, resp.Data["approvals"], 2)
+
+	resp, err = handle(operator, logical.ReadOperation, "secret/foo", nil)
+	require.NoError(t, err)
+	require.Equal(t, "bar", resp.Data["value"])
+
+	resp, err = handle(root, logical.ListOperation, "sys/break-glass/requests", nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{id}, resp.Data["keys"])
+
+	resp, err = handle(root, logical.UpdateOperation, "sys/break-glass/revoke/"+id, nil)
+	require.NoError(t, err)
+	require.Equal(t, BreakGlassStatusRevoked, resp.Data["status"])
+
+	_, err = handle(operator, logical.ReadOperation, "secret/foo", nil)
+	require.ErrorIs(t, err, logical.ErrPermissionDenied)
+
+	resp, err = handle(root, logical.ReadOperation, "sys/break-glass/requests/"+id, nil)
+	require.NoError(t, err)
+	require.Equal(t, BreakGlassStatusRevoked, resp.Data["status"])
+	require.Equal(t, "incident", resp.Data["reason_code"])
+
+	// Requests are restored on unseal
+	require.NoError(t, c.setupBreakGlass(ctx))
+	require.NotNil(t, c.breakGlass.Get(id))
+	require.Equal(t, []string{"incident-admin"}, c.breakGlass.Config().AllowedPolicies)
+
+	// Changes made by another node are picked up on invalidation
+	stored := *c.breakGlass.Get(id)
+	stored.Justification = "changed on another node"
+	entry, err := logical.StorageEntryJSON(breakGlassRequestPrefix+id, &stored)
+	require.NoError(t, err)
+	require.NoError(t, c.breakGlass.view.Put(ctx, entry))
+	require.NoError(t, c.breakGlass.view.Delete(ctx, breakGlassConfigKey))
+	c.systemBackend.invalidate(ctx, breakGlassSubPath+breakGlassRequestPrefix+id)
+	c.systemBackend.invalidate(ctx, breakGlassSubPath+breakGlassConfigKey)
+	require.Equal(t, "changed on another node", c.breakGlass.Get(id).Justification)
+	require.Empty(t, c.breakGlass.Config().AllowedPolicies)
+}
+
+// TestBreakGlassStore_Tidy checks that requests are only deleted once they
+// have been finished for longer than the retention period, and that grants
+// stop being indexed once they expire.
+func TestBreakGlassStore_Tidy(t *testing.T) {
+	c, _, _ := TestCoreUnsealed(t)
+	ctx := namespace.RootContext(nil)
+	s := c.breakGlass
+
+	now := time.Now()
+	requests := map[string]*BreakGlassRequest{
+		"active": {
+			GrantTime:  now.Add(-time.Minute),
+			ExpireTime: now.Add(time.Hour),
+		},
+		"pending": {
+			ApprovalDeadline: now.Add(time.Minute),
+		},
+		"recently-expired": {
+			GrantTime:  now.Add(-2 * time.Hour),
+			ExpireTime: now.Add(-time.Hour),
+		},
+		"old-expired": {
+			GrantTime:  now.Add(-breakGlassRequestRetention - 2*time.Hour),
+			ExpireTime: now.Add(-breakGlassRequestRetention - time.Hour),
+		},
+		"old-revoked": {
+			GrantTime:  now.Add(-breakGlassRequestRetention - 2*time.Hour),
+			ExpireTime: now.Add(time.Hour),
+			RevokeTime: now.Add(-breakGlassRequestRetention - time.Hour),
+		},
+		"old-unapproved": {
+			ApprovalDeadline: now.Add(-breakGlassRequestRetention - time.Hour),
+		},
+	}
+	for id, request := range requests {
+		request.ID = id
+		request.Accessor = "accessor-" + id
+		request.Policies = []string{"incident-admin"}
+		require.NoError(t, s.persist(ctx, request))
+		s.setLocked(request)
+	}
+	require.Len(t, s.granted, 3)
+	require.Equal(t, []string{"incident-admin"}, s.ActivePolicies("accessor-active"))
+	require.Empty(t, s.ActivePolicies("accessor-recently-expired"))
+
+	require.NoError(t, s.tidy(ctx, now))
+	require.ElementsMatch(t, []string{"active", "pending", "recently-expired"}, s.List())
+	require.Len(t, s.granted, 1)
+	require.Equal(t, []string{"incident-admin"}, s.ActivePolicies("accessor-active"))
+
+	keys, err := s.view.List(ctx, breakGlassRequestPrefix)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"active", "pending", "recently-expired"}, keys)
+}
@@ -0,0 +1,424 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func (b *SystemBackend) brokerSessionPaths() []*framework.Path {
+	sessionIDField := &framework.FieldSchema{
+		Type:        framework.TypeString,
+		Description: "The ID of the broker session.",
+	}
+	sessionResponse := map[int][]framework.Response{
+		http.StatusOK: {{
+			Description: "OK",
+			Fields:      brokerSessionResponseFields,
+		}},
+	}
+
+	return []*framework.Path{
+		{
+			Pattern: "broker/sessions/?$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "broker",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"heartbeat_timeout": {
+					Type:        framework.TypeDurationSecond,
+					Description: "How long the session stays open without a heartbeat. Defaults to 1 minute.",
+					Default:     int(defaultBrokerSessionHeartbeatTimeout.Seconds()),
+				},
+				"max_ttl": {
+					Type:        framework.TypeDurationSecond,
+					Description: "The longest time the session stays open, regardless of heartbeats. Defaults to 8 hours.",
+					Default:     int(defaultBrokerSessionMaxTTL.Seconds()),
+				},
+				"metadata": {
+					Type:        framework.TypeKVPairs,
+					Description: "Arbitrary key/value pairs describing the session, such as the user and target of a proxied connection.",
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleBrokerSessionCreate,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb:   "create",
+						OperationSuffix: "session",
+					},
+					Responses: sessionResponse,
+					Summary:   "Open a session to broker credentials for.",
+				},
+				logical.ListOperation: &framework.PathOperation{
+					Callback: b.handleBrokerSessionsList,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb:   "list",
+						OperationSuffix: "sessions",
+					},
+					Summary: "List the open broker sessions, oldest first.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["broker-sessions"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["broker-sessions"][1]),
+		},
+
+		{
+			Pattern: "broker/sessions/" + framework.GenericNameRegex("id") + "$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "broker",
+				OperationSuffix: "session",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"id": sessionIDField,
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback:  b.handleBrokerSessionRead,
+					Responses: sessionResponse,
+					Summary:   "Read a broker session and the leases of its credentials.",
+				},
+				logical.DeleteOperation: &framework.PathOperation{
+					Callback: b.handleBrokerSessionEnd,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "end",
+					},
+					Responses: sessionResponse,
+					Summary:   "End a broker session and revoke the leases of its credentials.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["broker-sessions"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["broker-sessions"][1]),
+		},
+
+		{
+			Pattern: "broker/sessions/" + framework.GenericNameRegex("id") + "/heartbeat$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "broker",
+				OperationVerb:   "heartbeat",
+				OperationSuffix: "session",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"id": sessionIDField,
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback:  b.handleBrokerSessionHeartbeat,
+					Responses: sessionResponse,
+					Summary:   "Keep a broker session open for another heartbeat timeout.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["broker-sessions"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["broker-sessions"][1]),
+		},
+
+		{
+			Pattern: "broker/sessions/" + framework.GenericNameRegex("id") + "/creds$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "broker",
+				OperationVerb:   "generate",
+				OperationSuffix: "session-credentials",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"id": sessionIDField,
+				"path": {
+					Type:        framework.TypeString,
+					Description: "The path of the dynamic credentials to request, such as database/creds/readonly.",
+					Required:    true,
+				},
+				"data": {
+					Type:        framework.TypeMap,
+					Description: "The data of the request. If given, the credentials are requested with a write rather than a read.",
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleBrokerSessionCreds,
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"lease_id": {
+									Type:     framework.TypeString,
+									Required: true,
+								},
+								"lease_duration": {
+									Type:     framework.TypeDurationSecond,
+									Required: true,
+								},
+								"renewable": {
+									Type:     framework.TypeBool,
+									Required: true,
+								},
+								"data": {
+									Type:     framework.TypeMap,
+									Required: true,
+								},
+							},
+						}},
+					},
+					Summary: "Request dynamic credentials whose lease is revoked when the broker session ends.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["broker-session-creds"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["broker-session-creds"][1]),
+		},
+	}
+}
+
+var brokerSessionResponseFields = map[string]*framework.FieldSchema{
+	"id": {
+		Type:     framework.TypeString,
+		Required: true,
+	},
+	"accessor": {
+		Type:     framework.TypeString,
+		Required: true,
+	},
+	"display_name": {
+		Type:     framework.TypeString,
+		Required: true,
+	},
+	"metadata": {
+		Type:     framework.TypeKVPairs,
+		Required: true,
+	},
+	"heartbeat_timeout": {
+		Type:     framework.TypeDurationSecond,
+		Required: true,
+	},
+	"max_ttl": {
+		Type:     framework.TypeDurationSecond,
+		Required: true,
+	},
+	"lease_ids": {
+		Type:     framework.TypeCommaStringSlice,
+		Required: true,
+	},
+	"creation_time": {
+		Type:     framework.TypeTime,
+		Required: true,
+	},
+	"last_heartbeat": {
+		Type:     framework.TypeTime,
+		Required: true,
+	},
+	"expire_time": {
+		Type:     framework.TypeTime,
+		Required: true,
+	},
+}
+
+// brokerSessionToken returns the token making the request, which must be a
+// service token.
+func (b *SystemBackend) brokerSessionToken(ctx context.Context, req *logical.Request) (*logical.TokenEntry, error) {
+	te, err := b.Core.LookupToken(ctx, req.ClientToken)
+	if err != nil {
+		return nil, err
+	}
+	if te == nil || te.Type != logical.TokenTypeService {
+		return nil, logical.CodedError(http.StatusBadRequest, "broker sessions can only be used with service tokens")
+	}
+	return te, nil
+}
+
+// handleBrokerSessionCreate opens a session owned by the token making the
+// request
+func (b *SystemBackend) handleBrokerSessionCreate(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	te, err := b.brokerSessionToken(ctx, req)
+	if err != nil {
+		return handleError(err)
+	}
+
+	id, err := uuid.GenerateUUID()
+	if err != nil {
+		return nil, err
+	}
+	session := &BrokerSession{
+		ID:               id,
+		Accessor:         te.Accessor,
+		NamespaceID:      te.NamespaceID,
+		DisplayName:      te.DisplayName,
+		Metadata:         d.Get("metadata").(map[string]string),
+		HeartbeatTimeout: time.Duration(d.Get("heartbeat_timeout").(int)) * time.Second,
+		MaxTTL:           time.Duration(d.Get("max_ttl").(int)) * time.Second,
+	}
+	if err := b.Core.brokerSessions.Create(ctx, session); err != nil {
+		return handleError(err)
+	}
+
+	return brokerSessionResponse(session), nil
+}
+
+// handleBrokerSessionsList returns the IDs of the open broker sessions
+func (b *SystemBackend) handleBrokerSessionsList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	return logical.ListResponse(b.Core.brokerSessions.List()), nil
+}
+
+// handleBrokerSessionRead returns a broker session
+func (b *SystemBackend) handleBrokerSessionRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	session := b.Core.brokerSessions.Get(d.Get("id").(string))
+	if session == nil {
+		return nil, nil
+	}
+
+	return brokerSessionResponse(session), nil
+}
+
+// handleBrokerSessionHeartbeat extends a broker session
+func (b *SystemBackend) handleBrokerSessionHeartbeat(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	te, err := b.brokerSessionToken(ctx, req)
+	if err != nil {
+		return handleError(err)
+	}
+
+	session, err := b.Core.brokerSessions.Heartbeat(ctx, d.Get("id").(string), te.Accessor)
+	if err != nil {
+		return brokerSessionError(err)
+	}
+
+	return brokerSessionResponse(session), nil
+}
+
+// handleBrokerSessionEnd ends a broker session. Sessions can be ended by
+// their broker, or by any token allowed to delete them, such as an operator
+// cleaning up after a broker which is gone.
+func (b *SystemBackend) handleBrokerSessionEnd(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	session, err := b.Core.brokerSessions.End(ctx, d.Get("id").(string), "ended")
+	if err != nil {
+		return brokerSessionError(err)
+	}
+
+	return brokerSessionResponse(session), nil
+}
+
+// handleBrokerSessionCreds requests dynamic credentials on behalf of the
+// broker, and records their lease on the session
+func (b *SystemBackend) handleBrokerSessionCreds(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	te, err := b.brokerSessionToken(ctx, req)
+	if err != nil {
+		return handleError(err)
+	}
+
+	id := d.Get("id").(string)
+	session := b.Core.brokerSessions.Get(id)
+	switch {
+	case session == nil:
+		return brokerSessionError(errBrokerSessionNotFound)
+	case session.Accessor != te.Accessor:
+		return brokerSessionError(errBrokerSessionNotOwner)
+	}
+
+	path := strings.TrimPrefix(d.Get("path").(string), "/")
+	if path == "" {
+		return logical.ErrorResponse("path is required"), logical.ErrInvalidRequest
+	}
+	if strings.HasPrefix(path, "sys/") {
+		return logical.ErrorResponse("credentials cannot be requested from system paths"), logical.ErrInvalidRequest
+	}
+
+	var op logical.Operation = logical.ReadOperation
+	data, ok := d.GetOk("data")
+	if ok {
+		op = logical.UpdateOperation
+	}
+
+	requestID, err := uuid.GenerateUUID()
+	if err != nil {
+		return nil, err
+	}
+	subReq := &logical.Request{
+		ID:          requestID,
+		Operation:   op,
+		Path:        path,
+		ClientToken: req.ClientToken,
+		Connection:  req.Connection,
+	}
+	if data != nil {
+		subReq.Data = data.(map[string]interface{})
+	}
+	resp, err := b.Core.handleSubrequest(ctx, subReq)
+	switch {
+	case err != nil:
+		return nil, err
+	case resp != nil && resp.IsError():
+		return resp, logical.ErrInvalidRequest
+	case resp == nil || resp.Secret == nil || resp.Secret.LeaseID == "":
+		return logical.ErrorResponse("path %q did not return leased credentials", path), logical.ErrInvalidRequest
+	}
+
+	if _, err := b.Core.brokerSessions.AddLease(ctx, id, te.Accessor, resp.Secret.LeaseID); err != nil {
+		// The session ended while the credentials were requested
+		if revokeErr := b.Core.expiration.LazyRevoke(ctx, resp.Secret.LeaseID); revokeErr != nil {
+			b.Core.logger.Error("failed to revoke lease of ended broker session", "lease_id", resp.Secret.LeaseID, "error", revokeErr)
+		}
+		return brokerSessionError(err)
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"lease_id":       resp.Secret.LeaseID,
+			"lease_duration": int64(resp.Secret.TTL.Seconds()),
+			"renewable":      resp.Secret.Renewable,
+			"data":           resp.Data,
+		},
+	}, nil
+}
+
+func brokerSessionError(err error) (*logical.Response, error) {
+	switch {
+	case errors.Is(err, errBrokerSessionNotFound):
+		return nil, logical.CodedError(http.StatusNotFound, err.Error())
+	case errors.Is(err, errBrokerSessionNotOwner):
+		return logical.ErrorResponse(err.Error()), logical.ErrPermissionDenied
+	default:
+		return handleError(err)
+	}
+}
+
+func brokerSessionResponse(session *BrokerSession) *logical.Response {
+	leaseIDs := session.LeaseIDs
+	if leaseIDs == nil {
+		leaseIDs = []string{}
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"id":                session.ID,
+			"accessor":          session.Accessor,
+			"display_name":      session.DisplayName,
+			"metadata":          session.Metadata,
+			"heartbeat_timeout": int64(session.HeartbeatTimeout.Seconds()),
+			"max_ttl":           int64(session.MaxTTL.Seconds()),
+			"lease_ids":         leaseIDs,
+			"creation_time":     session.CreationTime,
+			"last_heartbeat":    session.LastHeartbeat,
+			"expire_time":       session.ExpireTime(),
+		},
+	}
+}
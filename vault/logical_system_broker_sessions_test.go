@@ -0,0 +1,218 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSystemBackend_BrokerSessions(t *testing.T) {
+	c, _, root := TestCoreUnsealedWithConfig(t, &CoreConfig{
+		LogicalBackends: map[string]logical.Factory{
+			"kv": LeasedPassthroughBackendFactory,
+		},
+	})
+	ctx := namespace.RootContext(nil)
+
+	handle := func(token string, op logical.Operation, path string, data map[string]interface{}) (*logical.Response, error) {
+		t.Helper()
+		req := logical.TestRequest(t, op, path)
+		req.ClientToken = token
+		req.Data = data
+		req.Connection = &logical.Connection{RemoteAddr: "127.0.0.1"}
+		return c.HandleRequest(ctx, req)
+	}
+	leaseRevoked := func(leaseID string) bool {
+		le, err := c.expiration.FetchLeaseTimes(ctx, leaseID)
+		require.NoError(t, err)
+		return le == nil
+	}
+
+	_, err := handle(root, logical.UpdateOperation, "secret/db", map[string]interface{}{
+		"username": "app",
+		"lease":    "1h",
+	})
+	require.NoError(t, err)
+
+	_, err = handle(root, logical.UpdateOperation, "sys/policy/broker", map[string]interface{}{
+		"policy": `
+path "sys/broker/sessions/*" {
+	capabilities = ["create", "read", "update", "delete", "list"]
+}
+path "sys/broker/sessions" {
+	capabilities = ["update", "list"]
+}
+path "secret/*" {
+	capabilities = ["read"]
+}`,
+	})
+	require.NoError(t, err)
+	createToken := func() string {
+		t.Helper()
+		resp, err := handle(root, logical.UpdateOperation, "auth/token/create", map[string]interface{}{
+			"policies": "broker",
+		})
+		require.NoError(t, err)
+		return resp.Auth.ClientToken
+	}
+	broker, otherBroker := createToken(), createToken()
+
+	for name, data := range map[string]map[string]interface{}{
+		"heartbeat too short":     {"heartbeat_timeout": "1s"},
+		"max ttl below heartbeat": {"heartbeat_timeout": "5m", "max_ttl": "1m"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			resp, err := handle(broker, logical.UpdateOperation, "sys/broker/sessions", data)
+			require.Error(t, err)
+			require.True(t, resp.IsError())
+		})
+	}
+
+	resp, err := handle(broker, logical.UpdateOperation, "sys/broker/sessions", map[string]interface{}{
+		"metadata": map[string]interface{}{"user": "alice", "target": "db-prod"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(60), resp.Data["heartbeat_timeout"])
+	require.Equal(t, map[string]string{"user": "alice", "target": "db-prod"}, resp.Data["metadata"])
+	id := resp.Data["id"].(string)
+
+	resp, err = handle(broker, logical.UpdateOperation, "sys/broker/sessions/"+id+"/creds", map[string]interface{}{
+		"path": "secret/db",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "app", resp.Data["data"].(map[string]interface{})["username"])
+	require.Equal(t, int64(3600), resp.Data["lease_duration"])
+	leaseID := resp.Data["lease_id"].(string)
+	require.False(t, leaseRevoked(leaseID))
+
+	for name, path := range map[string]string{
+		"system path":    "sys/health",
+		"unleased path":  "cubbyhole/foo",
+		"forbidden path": "auth/token/lookup-self",
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := handle(broker, logical.UpdateOperation, "sys/broker/sessions/"+id+"/creds", map[string]interface{}{
+				"path": path,
+			})
+			require.Error(t, err)
+		})
+	}
+
+	// Error responses of the credentials backend are passed through
+	resp, err = handle(root, logical.UpdateOperation, "sys/broker/sessions", nil)
+	require.NoError(t, err)
+	rootID := resp.Data["id"].(string)
+	resp, err = handle(root, logical.UpdateOperation, "sys/broker/sessions/"+rootID+"/creds", map[string]interface{}{
+		"path": "secret/db",
+		"data": map[string]interface{}{},
+	})
+	require.ErrorIs(t, err, logical.ErrInvalidRequest)
+	require.Contains(t, resp.Error().Error(), "missing data fields")
+	_, err = handle(root, logical.DeleteOperation, "sys/broker/sessions/"+rootID, nil)
+	require.NoError(t, err)
+
+	// Only the broker which opened the session may use it
+	_, err = handle(otherBroker, logical.UpdateOperation, "sys/broker/sessions/"+id+"/heartbeat", nil)
+	require.ErrorIs(t, err, logical.ErrPermissionDenied)
+	_, err = handle(otherBroker, logical.UpdateOperation, "sys/broker/sessions/"+id+"/creds", map[string]interface{}{
+		"path": "secret/db",
+	})
+	require.ErrorIs(t, err, logical.ErrPermissionDenied)
+
+	resp, err = handle(broker, logical.UpdateOperation, "sys/broker/sessions/"+id+"/heartbeat", nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{leaseID}, resp.Data["lease_ids"])
+
+	resp, err = handle(broker, logical.ListOperation, "sys/broker/sessions", nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{id}, resp.Data["keys"])
+
+	// Sessions are restored on unseal
+	require.NoError(t, c.setupBrokerSessions(ctx))
+	require.NotNil(t, c.brokerSessions.Get(id))
+
+	// Ending the session revokes its leases
+	resp, err = handle(broker, logical.DeleteOperation, "sys/broker/sessions/"+id, nil)
+	require.NoError(t, err)
+	require.Equal(t, id, resp.Data["id"])
+	require.Eventually(t, func() bool { return leaseRevoked(leaseID) }, 10*time.Second, 50*time.Millisecond)
+
+	_, err = handle(broker, logical.UpdateOperation, "sys/broker/sessions/"+id+"/heartbeat", nil)
+	require.Error(t, err)
+
+	// Sessions which miss their heartbeats are ended
+	resp, err = handle(broker, logical.UpdateOperation, "sys/broker/sessions", map[string]interface{}{
+		"heartbeat_timeout": "10s",
+	})
+	require.NoError(t, err)
+	id = resp.Data["id"].(string)
+	resp, err = handle(broker, logical.UpdateOperation, "sys/broker/sessions/"+id+"/creds", map[string]interface{}{
+		"path": "secret/db",
+	})
+	require.NoError(t, err)
+	leaseID = resp.Data["lease_id"].(string)
+
+	c.brokerSessions.endExpired(ctx, time.Now())
+	require.NotNil(t, c.brokerSessions.Get(id))
+	c.brokerSessions.endExpired(ctx, time.Now().Add(11*time.Second))
+	require.Nil(t, c.brokerSessions.Get(id))
+	require.Eventually(t, func() bool { return leaseRevoked(leaseID) }, 10*time.Second, 50*time.Millisecond)
+}
+
+// TestSystemBackend_BrokerSessions_RateLimit verifies that the rate limit
+// quotas of the path of the brokered credentials apply to them.
+func TestSystemBackend_BrokerSessions_RateLimit(t *testing.T) {
+	c, _, root := TestCoreUnsealedWithConfig(t, &CoreConfig{
+		LogicalBackends: map[string]logical.Factory{
+			"kv": LeasedPassthroughBackendFactory,
+		},
+	})
+	ctx := namespace.RootContext(nil)
+
+	handle := func(op logical.Operation, path string, data map[string]interface{}) (*logical.Response, error) {
+		t.Helper()
+		req := logical.TestRequest(t, op, path)
+		req.ClientToken = root
+		req.Data = data
+		req.Connection = &logical.Connection{RemoteAddr: "127.0.0.1"}
+		return c.HandleRequest(ctx, req)
+	}
+
+	_, err := handle(logical.UpdateOperation, "secret/db", map[string]interface{}{
+		"username": "app",
+		"lease":    "1h",
+	})
+	require.NoError(t, err)
+
+	_, err = handle(logical.UpdateOperation, "sys/quotas/rate-limit/secret", map[string]interface{}{
+		"path":     "secret/",
+		"rate":     1,
+		"interval": "1h",
+	})
+	require.NoError(t, err)
+
+	resp, err := handle(logical.UpdateOperation, "sys/broker/sessions", nil)
+	require.NoError(t, err)
+	id := resp.Data["id"].(string)
+
+	_, err = handle(logical.UpdateOperation, "sys/broker/sessions/"+id+"/creds", map[string]interface{}{
+		"path": "secret/db",
+	})
+	require.NoError(t, err)
+
+	resp, err = handle(logical.UpdateOperation, "sys/broker/sessions/"+id+"/creds", map[string]interface{}{
+		"path": "secret/db",
+	})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "rate limit quota exceeded")
+
+	resp, err = handle(logical.ReadOperation, "sys/broker/sessions/"+id, nil)
+	require.NoError(t, err)
+	require.Len(t, resp.Data["lease_ids"], 1)
+}
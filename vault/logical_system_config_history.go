@@ -0,0 +1,196 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-secure-stdlib/parseutil"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func (b *SystemBackend) configHistoryPaths() []*framework.Path {
+	return []*framework.Path{
+		{
+			Pattern: "config/history/?$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "config-history",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"path": {
+					Type:        framework.TypeString,
+					Description: "Only return the changes to request paths starting with this prefix.",
+					Query:       true,
+				},
+				"category": {
+					Type:        framework.TypeString,
+					Description: `Only return the changes of this category: "mount", "auth", "policy", "sys-config" or "engine-config".`,
+					Query:       true,
+				},
+				"since": {
+					Type:        framework.TypeString,
+					Description: "Only return the changes made at or after this RFC 3339 timestamp.",
+					Query:       true,
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ListOperation: &framework.PathOperation{
+					Callback: b.handleConfigHistoryList,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "list",
+					},
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"keys": {
+									Type:     framework.TypeStringSlice,
+									Required: true,
+								},
+								"key_info": {
+									Type:     framework.TypeMap,
+									Required: true,
+								},
+							},
+						}},
+					},
+					Summary: "List the recorded configuration changes, oldest first.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["config-history"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["config-history"][1]),
+		},
+
+		{
+			Pattern: "config/history/" + framework.GenericNameRegex("id"),
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "config-history",
+				OperationSuffix: "change",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"id": {
+					Type:        framework.TypeString,
+					Description: "The ID of the configuration change.",
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleConfigHistoryRead,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "read",
+					},
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+						}},
+					},
+					Summary: "Read a recorded configuration change, along with its diff.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["config-history"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["config-history"][1]),
+		},
+	}
+}
+
+// handleConfigHistoryList returns the configuration changes made in the
+// namespace of the request and its children, matching the filters
+func (b *SystemBackend) handleConfigHistoryList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	pathPrefix := d.Get("path").(string)
+	category := d.Get("category").(string)
+	switch category {
+	case "", configChangeCategoryMount, configChangeCategoryAuth, configChangeCategoryPolicy,
+		configChangeCategorySysConfig, configChangeCategoryEngineConfig:
+	default:
+		return logical.ErrorResponse("unknown category %q", category), logical.ErrInvalidRequest
+	}
+
+	var since time.Time
+	if raw := d.Get("since").(string); raw != "" {
+		since, err = parseutil.ParseAbsoluteTime(raw)
+		if err != nil {
+			return logical.ErrorResponse("invalid since: %s", err), logical.ErrInvalidRequest
+		}
+	}
+
+	changes, err := b.Core.configHistory.Changes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := []string{}
+	keyInfo := make(map[string]interface{})
+	for _, change := range changes {
+		switch {
+		case !strings.HasPrefix(change.Namespace, ns.Path),
+			!strings.HasPrefix(change.Path, pathPrefix),
+			category != "" && change.Category != category,
+			change.Time.Before(since):
+			continue
+		}
+
+		keys = append(keys, change.ID)
+		keyInfo[change.ID] = map[string]interface{}{
+			"time":         change.Time.Format(time.RFC3339Nano),
+			"namespace":    change.Namespace,
+			"path":         change.Path,
+			"operation":    change.Operation,
+			"category":     change.Category,
+			"display_name": change.DisplayName,
+			"entity_id":    change.EntityID,
+		}
+	}
+
+	return logical.ListResponseWithInfo(keys, keyInfo), nil
+}
+
+// handleConfigHistoryRead returns a configuration change
+func (b *SystemBackend) handleConfigHistoryRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	change, err := b.Core.configHistory.Change(ctx, d.Get("id").(string))
+	if err != nil {
+		return nil, err
+	}
+	if change == nil || !strings.HasPrefix(change.Namespace, ns.Path) {
+		return nil, nil
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"id":             change.ID,
+			"time":           change.Time.Format(time.RFC3339Nano),
+			"namespace":      change.Namespace,
+			"path":           change.Path,
+			"operation":      change.Operation,
+			"category":       change.Category,
+			"display_name":   change.DisplayName,
+			"entity_id":      change.EntityID,
+			"accessor":       change.Accessor,
+			"remote_address": change.RemoteAddr,
+			"diff":           change.Diff,
+		},
+	}, nil
+}
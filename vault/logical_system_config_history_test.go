@@ -0,0 +1,130 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"testing"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSystemBackend_ConfigHistory(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	ctx := namespace.RootContext(nil)
+
+	handle := func(op logical.Operation, path string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		req := logical.TestRequest(t, op, path)
+		req.ClientToken = root
+		req.Data = data
+		resp, err := c.HandleRequest(ctx, req)
+		require.NoError(t, err)
+		require.False(t, resp.IsError(), resp)
+		return resp
+	}
+
+	handle(logical.UpdateOperation, "sys/policy/ops", map[string]interface{}{
+		"policy": `path "secret/*" { capabilities = ["read"] }`,
+	})
+	handle(logical.UpdateOperation, "sys/policy/ops", map[string]interface{}{
+		"policy": `path "secret/*" { capabilities = ["read", "list"] }`,
+	})
+	handle(logical.UpdateOperation, "sys/mounts/kv", map[string]interface{}{
+		"type":        "kv",
+		"description": "team secrets",
+	})
+	handle(logical.UpdateOperation, "sys/mounts/database", map[string]interface{}{
+		"type": "kv",
+	})
+	handle(logical.UpdateOperation, "database/config", map[string]interface{}{
+		"connection_url": "postgresql://vault:hunter2@db:5432/app",
+		"password":       "hunter2",
+	})
+	handle(logical.UpdateOperation, "database/config", map[string]interface{}{
+		"password": "correct-horse",
+	})
+	handle(logical.DeleteOperation, "sys/policy/ops", nil)
+
+	// Reads and writes to untracked paths are not recorded
+	handle(logical.ReadOperation, "sys/mounts/kv", nil)
+	handle(logical.UpdateOperation, "kv/foo", map[string]interface{}{"bar": "baz"})
+
+	resp := handle(logical.ListOperation, "sys/config/history", nil)
+	keys := resp.Data["keys"].([]string)
+	keyInfo := resp.Data["key_info"].(map[string]interface{})
+
+	// The first change is the mount of secret/ by TestCoreUnsealed
+	require.Len(t, keys, 8)
+	keys = keys[1:]
+
+	paths := make([]string, 0, len(keys))
+	for _, key := range keys {
+		paths = append(paths, keyInfo[key].(map[string]interface{})["path"].(string))
+	}
+	require.Equal(t, []string{
+		"sys/policy/ops",
+		"sys/policy/ops",
+		"sys/mounts/kv",
+		"sys/mounts/database",
+		"database/config",
+		"database/config",
+		"sys/policy/ops",
+	}, paths)
+
+	read := func(id string) (map[string]*ConfigFieldDiff, *logical.Response) {
+		t.Helper()
+		resp := handle(logical.ReadOperation, "sys/config/history/"+id, nil)
+		return resp.Data["diff"].(map[string]*ConfigFieldDiff), resp
+	}
+
+	diff, resp := read(keys[1])
+	require.Equal(t, "policy", resp.Data["category"])
+	require.Equal(t, "root", resp.Data["display_name"])
+	require.Equal(t, `path "secret/*" { capabilities = ["read"] }`, diff["policy"].Old)
+	require.Equal(t, `path "secret/*" { capabilities = ["read", "list"] }`, diff["policy"].New)
+
+	diff, _ = read(keys[2])
+	require.Equal(t, &ConfigFieldDiff{New: "kv"}, diff["type"])
+	require.Equal(t, &ConfigFieldDiff{New: "team secrets"}, diff["description"])
+
+	// The values of engine configurations are redacted, as their fields may
+	// hold secrets, but their changes still show
+	diff, resp = read(keys[4])
+	require.Equal(t, "engine-config", resp.Data["category"])
+	require.Equal(t, &ConfigFieldDiff{New: configHistoryRedacted}, diff["connection_url"])
+	require.Equal(t, &ConfigFieldDiff{New: configHistoryRedacted}, diff["password"])
+
+	diff, _ = read(keys[5])
+	require.Len(t, diff, 1)
+	require.Equal(t, &ConfigFieldDiff{Old: configHistoryRedacted, New: configHistoryRedacted}, diff["password"])
+
+	diff, _ = read(keys[6])
+	require.Equal(t, &ConfigFieldDiff{Old: `path "secret/*" { capabilities = ["read", "list"] }`}, diff["policy"])
+
+	resp = handle(logical.ListOperation, "sys/config/history", map[string]interface{}{
+		"category": "mount",
+		"path":     "sys/mounts/",
+		"since":    keyInfo[keys[0]].(map[string]interface{})["time"],
+	})
+	require.Equal(t, keys[2:4], resp.Data["keys"])
+
+	resp = handle(logical.ListOperation, "sys/config/history", map[string]interface{}{
+		"path": "database/",
+	})
+	require.Equal(t, keys[4:6], resp.Data["keys"])
+
+	resp = handle(logical.ListOperation, "sys/config/history", map[string]interface{}{
+		"since": "2999-01-01T00:00:00Z",
+	})
+	require.Empty(t, resp.Data["keys"])
+
+	req := logical.TestRequest(t, logical.ListOperation, "sys/config/history")
+	req.ClientToken = root
+	req.Data = map[string]interface{}{"category": "unknown"}
+	resp, err := c.HandleRequest(ctx, req)
+	require.Error(t, err)
+	require.True(t, resp.IsError())
+}
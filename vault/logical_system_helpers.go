@@ -245,19 +245,6 @@ var (
 			"plugins/reload/backend/status$": {operations: []logical.Operation{logical.ReadOperation}},
 		})...)
 
-		// quotas paths
-		paths = append(paths, buildEnterpriseOnlyPaths(map[string]enterprisePathStub{
-			"quotas/lease-count/?$": {operations: []logical.Operation{logical.ListOperation}},
-			"quotas/lease-count/" + framework.GenericNameRegex("name"): {parameters: []string{"name"}, operations: []logical.Operation{logical.DeleteOperation, logical.ReadOperation, logical.UpdateOperation}},
-		})...)
-
-		// raft auto-snapshot paths
-		paths = append(paths, buildEnterpriseOnlyPaths(map[string]enterprisePathStub{
-			"storage/raft/snapshot-auto/config/":                                      {operations: []logical.Operation{logical.ListOperation}},
-			"storage/raft/snapshot-auto/config/" + framework.GenericNameRegex("name"): {parameters: []string{"name"}, operations: []logical.Operation{logical.DeleteOperation, logical.ReadOperation, logical.UpdateOperation}},
-			"storage/raft/snapshot-auto/status/" + framework.GenericNameRegex("name"): {parameters: []string{"name"}, operations: []logical.Operation{logical.ReadOperation}},
-		})...)
-
 		paths = append(paths, buildEnterpriseOnlyPaths(map[string]enterprisePathStub{
 			"managed-keys/" + framework.GenericNameRegex("type") + "/?":                                                    {parameters: []string{"type"}, operations: []logical.Operation{logical.ListOperation}},
 			"managed-keys/" + framework.GenericNameRegex("type") + "/" + framework.GenericNameRegex("name"):                {parameters: []string{"type", "name"}, operations: []logical.Operation{logical.CreateOperation, logical.DeleteOperation, logical.ReadOperation, logical.UpdateOperation}},
@@ -0,0 +1,237 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func (b *SystemBackend) irrevocableLeasePaths() []*framework.Path {
+	leaseIDField := &framework.FieldSchema{
+		Type:        framework.TypeString,
+		Description: "The ID of the irrevocable lease.",
+		Required:    true,
+	}
+
+	return []*framework.Path{
+		{
+			Pattern: "leases/irrevocable/?$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "leases",
+				OperationSuffix: "irrevocable",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"include_child_namespaces": {
+					Type:        framework.TypeBool,
+					Default:     false,
+					Description: "Also return the irrevocable leases of child namespaces.",
+					Query:       true,
+				},
+				"limit": {
+					Type:        framework.TypeString,
+					Default:     "",
+					Description: `The maximum number of leases to return, or "none" to return all of them.`,
+					Query:       true,
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleIrrevocableLeasesList,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "list",
+					},
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"lease_count": {
+									Type:     framework.TypeInt,
+									Required: true,
+								},
+								"leases": {
+									Type:     framework.TypeSlice,
+									Required: true,
+								},
+							},
+						}},
+					},
+					Summary: "List the leases quarantined after their revocation failed.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["leases-irrevocable"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["leases-irrevocable"][1]),
+		},
+
+		{
+			Pattern: "leases/irrevocable/retry$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "leases",
+				OperationVerb:   "retry",
+				OperationSuffix: "irrevocable",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"lease_id": leaseIDField,
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleIrrevocableLeaseRetry,
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"lease_id": {
+									Type:     framework.TypeString,
+									Required: true,
+								},
+								"revoked": {
+									Type:     framework.TypeBool,
+									Required: true,
+								},
+								"error": {
+									Type:     framework.TypeString,
+									Required: false,
+								},
+							},
+						}},
+					},
+					Summary: "Retry the revocation of an irrevocable lease.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["leases-irrevocable"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["leases-irrevocable"][1]),
+		},
+
+		{
+			Pattern: "leases/irrevocable/acknowledge$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "leases",
+				OperationVerb:   "acknowledge",
+				OperationSuffix: "irrevocable",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"lease_id": leaseIDField,
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleIrrevocableLeaseAcknowledge,
+					Responses: map[int][]framework.Response{
+						http.StatusNoContent: {{
+							Description: "OK",
+						}},
+					},
+					Summary: "Remove an irrevocable lease without revoking it in its backend.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["leases-irrevocable"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["leases-irrevocable"][1]),
+		},
+	}
+}
+
+// handleIrrevocableLeasesList returns the irrevocable leases of the namespace
+func (b *SystemBackend) handleIrrevocableLeasesList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	includeAll, maxResults, err := processLimit(d)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
+	leases, warning, err := b.Core.expiration.listIrrevocableLeases(ctx, d.Get("include_child_namespaces").(bool), includeAll, maxResults)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &logical.Response{
+		Data: leases,
+	}
+	if warning != "" {
+		resp.AddWarning(warning)
+	}
+	return resp, nil
+}
+
+// handleIrrevocableLeaseRetry attempts again to revoke an irrevocable lease.
+// A failed attempt is reported in the response, rather than as an error, as the
+// lease is still quarantined.
+func (b *SystemBackend) handleIrrevocableLeaseRetry(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	leaseID := d.Get("lease_id").(string)
+	leaseCtx, errResp := b.irrevocableLeaseContext(ctx, leaseID)
+	if errResp != nil {
+		return errResp, logical.ErrInvalidRequest
+	}
+
+	data := map[string]interface{}{
+		"lease_id": leaseID,
+		"revoked":  true,
+	}
+	err := b.Core.expiration.RetryIrrevocable(leaseCtx, leaseID)
+	switch {
+	case errors.Is(err, errIrrevocableLeaseNotFound):
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	case err != nil:
+		data["revoked"] = false
+		data["error"] = irrevocableErrorString(err)
+	}
+
+	return &logical.Response{
+		Data: data,
+	}, nil
+}
+
+// handleIrrevocableLeaseAcknowledge removes an irrevocable lease without
+// revoking it in its backend
+func (b *SystemBackend) handleIrrevocableLeaseAcknowledge(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	leaseID := d.Get("lease_id").(string)
+	leaseCtx, errResp := b.irrevocableLeaseContext(ctx, leaseID)
+	if errResp != nil {
+		return errResp, logical.ErrInvalidRequest
+	}
+
+	err := b.Core.expiration.AcknowledgeIrrevocable(leaseCtx, leaseID)
+	switch {
+	case errors.Is(err, errIrrevocableLeaseNotFound):
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	case err != nil:
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+// irrevocableLeaseContext returns the context of the namespace of the lease,
+// which must be the namespace of the request or one of its children.
+func (b *SystemBackend) irrevocableLeaseContext(ctx context.Context, leaseID string) (context.Context, *logical.Response) {
+	if leaseID == "" {
+		return nil, logical.ErrorResponse("lease_id must be set")
+	}
+
+	requestNS, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, logical.ErrorResponse(err.Error())
+	}
+	leaseNS, err := b.Core.expiration.getNamespaceFromLeaseID(ctx, leaseID)
+	if err != nil || (leaseNS.ID != requestNS.ID && !leaseNS.HasParent(requestNS)) {
+		return nil, logical.ErrorResponse(errIrrevocableLeaseNotFound.Error())
+	}
+
+	return namespace.ContextWithNamespace(ctx, leaseNS), nil
+}
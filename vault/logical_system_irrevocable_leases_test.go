@@ -0,0 +1,91 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSystemBackend_IrrevocableLeases(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	exp := c.expiration
+	ctx := namespace.RootContext(nil)
+
+	handle := func(op logical.Operation, path string, data map[string]interface{}) (*logical.Response, error) {
+		t.Helper()
+		req := logical.TestRequest(t, op, path)
+		req.ClientToken = root
+		req.Data = data
+		return c.HandleRequest(ctx, req)
+	}
+
+	// Leases registered under an unmounted path can never be revoked
+	var leaseIDs []string
+	for i := 0; i < 2; i++ {
+		leaseID := registerOneLease(t, ctx, exp)
+		le, err := exp.loadEntry(ctx, leaseID)
+		require.NoError(t, err)
+
+		exp.pendingLock.Lock()
+		exp.markLeaseIrrevocable(ctx, le, errors.New("database unreachable"))
+		exp.pendingLock.Unlock()
+		leaseIDs = append(leaseIDs, leaseID)
+	}
+
+	resp, err := handle(logical.ReadOperation, "sys/leases/irrevocable", nil)
+	require.NoError(t, err)
+	require.Equal(t, 2, resp.Data["lease_count"])
+	require.Len(t, resp.Data["leases"], 2)
+
+	resp, err = handle(logical.UpdateOperation, "sys/leases/irrevocable/retry", map[string]interface{}{
+		"lease_id": leaseIDs[0],
+	})
+	require.NoError(t, err)
+	require.Equal(t, false, resp.Data["revoked"])
+	require.Contains(t, resp.Data["error"], "no handler for route")
+
+	// The new error is recorded on the lease, which stays quarantined
+	le, err := exp.loadEntry(ctx, leaseIDs[0])
+	require.NoError(t, err)
+	require.True(t, le.isIrrevocable())
+	require.Contains(t, le.RevokeErr, "no handler for route")
+	cached, ok := exp.irrevocable.Load(leaseIDs[0])
+	require.True(t, ok)
+	require.Equal(t, le.RevokeErr, cached.(*leaseEntry).RevokeErr)
+
+	resp, err = handle(logical.UpdateOperation, "sys/leases/irrevocable/acknowledge", map[string]interface{}{
+		"lease_id": leaseIDs[0],
+	})
+	require.NoError(t, err)
+	require.Nil(t, resp)
+
+	le, err = exp.loadEntry(ctx, leaseIDs[0])
+	require.NoError(t, err)
+	require.Nil(t, le)
+
+	resp, err = handle(logical.ReadOperation, "sys/leases/irrevocable", nil)
+	require.NoError(t, err)
+	require.Equal(t, 1, resp.Data["lease_count"])
+
+	// Only irrevocable leases can be retried or acknowledged
+	pendingLeaseID := registerOneLease(t, ctx, exp)
+	for _, path := range []string{"sys/leases/irrevocable/retry", "sys/leases/irrevocable/acknowledge"} {
+		for _, leaseID := range []string{leaseIDs[0], pendingLeaseID, ""} {
+			resp, err = handle(logical.UpdateOperation, path, map[string]interface{}{
+				"lease_id": leaseID,
+			})
+			require.Error(t, err)
+			require.True(t, resp.IsError())
+		}
+	}
+
+	le, err = exp.loadEntry(ctx, pendingLeaseID)
+	require.NoError(t, err)
+	require.NotNil(t, le)
+}
@@ -0,0 +1,429 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	uuid "github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	kvExportFormatDotenv     = "dotenv"
+	kvExportFormatProperties = "properties"
+	kvExportFormatJSON       = "json"
+	kvExportFormatSystemd    = "systemd"
+
+	// maxKVExportSecrets is the maximum number of secrets which may be
+	// exported by a single request.
+	maxKVExportSecrets = 1000
+
+	// maxKVExportFolders is the maximum number of folders which may be listed
+	// by a single request.
+	maxKVExportFolders = 1000
+)
+
+var kvExportInvalidVarChars = regexp.MustCompile(`[^A-Z0-9_]`)
+
+// kvExportSecret is a secret of an exported KV subtree, along with its path
+// relative to the root of the subtree.
+type kvExportSecret struct {
+	path []string
+	data map[string]interface{}
+}
+
+func (b *SystemBackend) kvExportPath() *framework.Path {
+	return &framework.Path{
+		Pattern: "kv-export$",
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationVerb:   "export",
+			OperationSuffix: "kv-subtree",
+		},
+
+		Fields: map[string]*framework.FieldSchema{
+			"path": {
+				Type:        framework.TypeString,
+				Description: "The path of the KV subtree to export, including the mount path, such as \"secret/app/prod\".",
+				Required:    true,
+			},
+			"format": {
+				Type:          framework.TypeString,
+				Description:   "The format to render the subtree in: \"dotenv\", \"properties\", \"json\" or \"systemd\".",
+				Default:       kvExportFormatJSON,
+				AllowedValues: []interface{}{kvExportFormatDotenv, kvExportFormatProperties, kvExportFormatJSON, kvExportFormatSystemd},
+			},
+			"prefix": {
+				Type:        framework.TypeString,
+				Description: "A prefix prepended to the names of the exported variables or properties.",
+			},
+		},
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.UpdateOperation: &framework.PathOperation{
+				Callback: b.handleKVExport,
+				Summary:  "Render a KV subtree into a bootstrap file format.",
+				Responses: map[int][]framework.Response{
+					http.StatusOK: {{
+						Description: "OK",
+						Fields: map[string]*framework.FieldSchema{
+							"format": {
+								Type:     framework.TypeString,
+								Required: true,
+							},
+							"content": {
+								Type:     framework.TypeString,
+								Required: true,
+							},
+							"secret_count": {
+								Type:     framework.TypeInt,
+								Required: true,
+							},
+							"skipped": {
+								Type:        framework.TypeStringSlice,
+								Description: "The paths of the subtree the client may not list or read.",
+								Required:    true,
+							},
+						},
+					}},
+				},
+			},
+		},
+
+		HelpSynopsis:    strings.TrimSpace(sysHelp["kv-export"][0]),
+		HelpDescription: strings.TrimSpace(sysHelp["kv-export"][1]),
+	}
+}
+
+// handleKVExport lists and reads the secrets of a KV subtree on behalf of the
+// client, so that each is subject to the client's policies and audited as
+// usual. The parts of the subtree the client may not list or read are skipped
+// and returned, rather than failing the whole export. Each list and read uses
+// the client's token once, so a token with limited uses may run out before
+// the subtree is exported, in which case the export fails rather than being
+// returned incomplete.
+func (b *SystemBackend) handleKVExport(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	path := strings.Trim(strings.TrimSpace(d.Get("path").(string)), "/")
+	if path == "" {
+		return logical.ErrorResponse("path must be given"), logical.ErrInvalidRequest
+	}
+	format := d.Get("format").(string)
+	prefix := d.Get("prefix").(string)
+
+	me := b.Core.router.MatchingMountEntry(ctx, path+"/")
+	if me == nil || (me.Type != "kv" && me.Type != "generic") {
+		return logical.ErrorResponse("path %q is not in a KV secrets engine", path), logical.ErrInvalidRequest
+	}
+	mountPath := b.Core.router.MatchingMount(ctx, path+"/")
+
+	secrets, skipped, err := b.walkKVSubtree(ctx, req, mountPath, strings.TrimPrefix(path+"/", mountPath), isKVv2Mount(me))
+	if errors.Is(err, logical.ErrInvalidToken) {
+		return logical.ErrorResponse("token ran out of uses before the subtree was exported"), logical.ErrPermissionDenied
+	}
+	if err != nil {
+		return handleError(err)
+	}
+	if len(secrets) == 0 && len(skipped) > 0 {
+		return nil, logical.ErrPermissionDenied
+	}
+
+	var content string
+	switch format {
+	case kvExportFormatDotenv, kvExportFormatSystemd:
+		content, err = renderKVExportEnv(secrets, prefix, format)
+	case kvExportFormatProperties:
+		content, err = renderKVExportProperties(secrets, prefix)
+	case kvExportFormatJSON:
+		content, err = renderKVExportJSON(secrets, prefix)
+	default:
+		return logical.ErrorResponse("unsupported format %q", format), logical.ErrInvalidRequest
+	}
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"format":       format,
+			"content":      content,
+			"secret_count": len(secrets),
+			"skipped":      skipped,
+		},
+	}, nil
+}
+
+// walkKVSubtree returns the secrets under the given prefix of a KV mount,
+// sorted by path, and the paths which were skipped as the client may not list
+// or read them.
+func (b *SystemBackend) walkKVSubtree(ctx context.Context, req *logical.Request, mountPath, prefix string, kvv2 bool) ([]*kvExportSecret, []string, error) {
+	listPath, readPath := mountPath, mountPath
+	if kvv2 {
+		listPath, readPath = mountPath+"metadata/", mountPath+"data/"
+	}
+
+	var secrets []*kvExportSecret
+	skipped := []string{}
+	folders := []string{prefix}
+	for listed := 0; len(folders) > 0; listed++ {
+		if listed == maxKVExportFolders {
+			return nil, nil, fmt.Errorf("subtree has more than %d folders", maxKVExportFolders)
+		}
+		folder := folders[0]
+		folders = folders[1:]
+
+		resp, err := b.kvExportRequest(ctx, req, logical.ListOperation, listPath+folder)
+		switch {
+		case errors.Is(err, logical.ErrInvalidToken):
+			return nil, nil, err
+		case errors.Is(err, logical.ErrPermissionDenied):
+			skipped = append(skipped, mountPath+folder)
+			continue
+		case err != nil:
+			return nil, nil, err
+		case resp == nil:
+			continue
+		}
+		keys, _ := resp.Data["keys"].([]string)
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			if strings.HasSuffix(key, "/") {
+				folders = append(folders, folder+key)
+				continue
+			}
+			if len(secrets) == maxKVExportSecrets {
+				return nil, nil, fmt.Errorf("subtree has more than %d secrets", maxKVExportSecrets)
+			}
+
+			resp, err := b.kvExportRequest(ctx, req, logical.ReadOperation, readPath+folder+key)
+			switch {
+			case errors.Is(err, logical.ErrInvalidToken):
+				return nil, nil, err
+			case errors.Is(err, logical.ErrPermissionDenied):
+				skipped = append(skipped, mountPath+folder+key)
+				continue
+			case err != nil:
+				return nil, nil, err
+			case resp == nil:
+				continue
+			}
+			data := resp.Data
+			if kvv2 {
+				// Deleted and destroyed versions have no data
+				if data, _ = resp.Data["data"].(map[string]interface{}); data == nil {
+					continue
+				}
+			}
+
+			secrets = append(secrets, &kvExportSecret{
+				path: strings.Split(strings.TrimPrefix(folder+key, prefix), "/"),
+				data: data,
+			})
+		}
+	}
+
+	sort.Slice(secrets, func(i, j int) bool {
+		return strings.Join(secrets[i].path, "/") < strings.Join(secrets[j].path, "/")
+	})
+	return secrets, skipped, nil
+}
+
+func (b *SystemBackend) kvExportRequest(ctx context.Context, req *logical.Request, op logical.Operation, path string) (*logical.Response, error) {
+	id, err := uuid.GenerateUUID()
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := b.Core.handleSubrequest(ctx, &logical.Request{
+		ID:          id,
+		Operation:   op,
+		Path:        path,
+		ClientToken: req.ClientToken,
+		Connection:  req.Connection,
+	})
+	switch {
+	case err != nil:
+		return nil, err
+	case resp != nil && resp.IsError():
+		return nil, resp.Error()
+	}
+	return resp, nil
+}
+
+// kvExportValue returns the value of a key of a secret as a string, encoding
+// values which are not strings as JSON.
+func kvExportValue(value interface{}) (string, error) {
+	if s, ok := value.(string); ok {
+		return s, nil
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(encoded), nil
+}
+
+// kvExportEntries flattens the secrets into their keys, naming each with the
+// given function of its path within the subtree. Two keys with the same name
+// are an error.
+func kvExportEntries(secrets []*kvExportSecret, name func([]string) string) ([][2]string, error) {
+	var entries [][2]string
+	seen := make(map[string]string)
+	for _, secret := range secrets {
+		keys := make([]string, 0, len(secret.data))
+		for key := range secret.data {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			path := append(append([]string{}, secret.path...), key)
+			entryName := name(path)
+			if other, ok := seen[entryName]; ok {
+				return nil, fmt.Errorf("%q and %q are both exported as %q", other, strings.Join(path, "/"), entryName)
+			}
+			seen[entryName] = strings.Join(path, "/")
+
+			value, err := kvExportValue(secret.data[key])
+			if err != nil {
+				return nil, fmt.Errorf("error encoding %q: %w", strings.Join(path, "/"), err)
+			}
+			entries = append(entries, [2]string{entryName, value})
+		}
+	}
+	return entries, nil
+}
+
+// renderKVExportEnv renders the secrets as environment variables, named after
+// their upper-cased paths, as read by dotenv libraries or by systemd's
+// EnvironmentFile.
+func renderKVExportEnv(secrets []*kvExportSecret, prefix, format string) (string, error) {
+	entries, err := kvExportEntries(secrets, func(path []string) string {
+		name := kvExportInvalidVarChars.ReplaceAllString(strings.ToUpper(prefix+strings.Join(path, "_")), "_")
+		if name == "" || unicode.IsDigit(rune(name[0])) {
+			name = "_" + name
+		}
+		return name
+	})
+	if err != nil {
+		return "", err
+	}
+
+	// Unlike dotenv, systemd does not expand variables in EnvironmentFiles
+	replacements := []string{`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`}
+	if format == kvExportFormatDotenv {
+		replacements = append(replacements, "$", `\$`)
+	}
+	escaper := strings.NewReplacer(replacements...)
+
+	var sb strings.Builder
+	for _, entry := range entries {
+		fmt.Fprintf(&sb, "%s=\"%s\"\n", entry[0], escaper.Replace(entry[1]))
+	}
+	return sb.String(), nil
+}
+
+// renderKVExportProperties renders the secrets as Java properties, named after
+// their dotted paths.
+func renderKVExportProperties(secrets []*kvExportSecret, prefix string) (string, error) {
+	entries, err := kvExportEntries(secrets, func(path []string) string {
+		return prefix + strings.Join(path, ".")
+	})
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	for _, entry := range entries {
+		fmt.Fprintf(&sb, "%s=%s\n", escapeProperty(entry[0], true), escapeProperty(entry[1], false))
+	}
+	return sb.String(), nil
+}
+
+// escapeProperty escapes a key or value of a Java properties file, as
+// specified by java.util.Properties.
+func escapeProperty(s string, isKey bool) string {
+	var sb strings.Builder
+	for i, r := range s {
+		switch {
+		case r == '\\':
+			sb.WriteString(`\\`)
+		case r == '\n':
+			sb.WriteString(`\n`)
+		case r == '\r':
+			sb.WriteString(`\r`)
+		case r == '\t':
+			sb.WriteString(`\t`)
+		case r == '\f':
+			sb.WriteString(`\f`)
+		case r == '=', r == ':', r == '#', r == '!':
+			sb.WriteRune('\\')
+			sb.WriteRune(r)
+		case r == ' ' && (isKey || i == 0):
+			sb.WriteString(`\ `)
+		case r < 0x20 || r > 0x7e:
+			for _, u := range utf16Units(r) {
+				fmt.Fprintf(&sb, `\u%04X`, u)
+			}
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+func utf16Units(r rune) []rune {
+	if r < 0x10000 {
+		return []rune{r}
+	}
+	r -= 0x10000
+	return []rune{0xD800 + (r>>10)&0x3FF, 0xDC00 + r&0x3FF}
+}
+
+// renderKVExportJSON renders the secrets as a JSON tree following their paths.
+func renderKVExportJSON(secrets []*kvExportSecret, prefix string) (string, error) {
+	tree := make(map[string]interface{})
+	for _, secret := range secrets {
+		path := secret.path
+		if prefix != "" {
+			path = append([]string{prefix}, path...)
+		}
+
+		node := tree
+		for i, segment := range path {
+			child, ok := node[segment]
+			if !ok {
+				child = make(map[string]interface{})
+				node[segment] = child
+			}
+			childNode, ok := child.(map[string]interface{})
+			if !ok {
+				return "", fmt.Errorf("%q is both a key and a folder", strings.Join(path[:i+1], "/"))
+			}
+			node = childNode
+		}
+		for key, value := range secret.data {
+			if _, ok := node[key]; ok {
+				return "", fmt.Errorf("%q is both a key and a folder", strings.Join(append(path, key), "/"))
+			}
+			node[key] = value
+		}
+	}
+
+	encoded, err := json.MarshalIndent(tree, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(encoded) + "\n", nil
+}
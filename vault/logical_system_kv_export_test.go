@@ -0,0 +1,234 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	logicalKv "github.com/hashicorp/vault-plugin-secrets-kv"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSystemBackend_KVExport verifies that KV subtrees are rendered in each
+// format according to the client's policies.
+func TestSystemBackend_KVExport(t *testing.T) {
+	c, _, root := TestCoreUnsealedWithConfig(t, &CoreConfig{
+		LogicalBackends: map[string]logical.Factory{
+			"kv": logicalKv.Factory,
+		},
+	})
+	ctx := namespace.RootContext(nil)
+
+	handle := func(token string, op logical.Operation, path string, data map[string]interface{}) (*logical.Response, error) {
+		t.Helper()
+		req := logical.TestRequest(t, op, path)
+		req.ClientToken = token
+		req.Data = data
+		return c.HandleRequest(ctx, req)
+	}
+
+	require.NoError(t, c.mount(ctx, &MountEntry{
+		Table:   mountTableType,
+		Path:    "kv2/",
+		Type:    "kv",
+		Options: map[string]string{"version": "2"},
+	}))
+
+	secrets := map[string]map[string]interface{}{
+		"app/prod/db":           {"username": "app", "password": `p"a$s`},
+		"app/prod/api":          {"key": "abc 123", "retries": 3},
+		"app/prod/private/cert": {"pem": "-----BEGIN-----\nMIIB\n-----END-----"},
+		"app/dev/db":            {"username": "dev"},
+	}
+	for path, data := range secrets {
+		// Retry while the mount is upgraded
+		require.Eventually(t, func() bool {
+			resp, err := handle(root, logical.UpdateOperation, "kv2/data/"+path, map[string]interface{}{
+				"data": data,
+			})
+			return err == nil && !resp.IsError()
+		}, 10*time.Second, 50*time.Millisecond)
+
+		_, err := handle(root, logical.UpdateOperation, "secret/"+path, data)
+		require.NoError(t, err)
+	}
+
+	_, err := handle(root, logical.UpdateOperation, "sys/policy/export", map[string]interface{}{
+		"policy": `
+path "kv2/metadata/app/prod/*" {
+	capabilities = ["list"]
+}
+path "kv2/data/app/prod/*" {
+	capabilities = ["read"]
+}
+path "kv2/data/app/prod/private/*" {
+	capabilities = ["deny"]
+}
+path "sys/kv-export" {
+	capabilities = ["update"]
+}`,
+	})
+	require.NoError(t, err)
+	resp, err := handle(root, logical.UpdateOperation, "auth/token/create", map[string]interface{}{
+		"policies": []string{"export"},
+	})
+	require.NoError(t, err)
+	token := resp.Auth.ClientToken
+
+	export := func(token, path, format, prefix string) *logical.Response {
+		t.Helper()
+		resp, err := handle(token, logical.UpdateOperation, "sys/kv-export", map[string]interface{}{
+			"path":   path,
+			"format": format,
+			"prefix": prefix,
+		})
+		require.NoError(t, err)
+		require.False(t, resp.IsError(), resp)
+		return resp
+	}
+
+	resp = export(token, "kv2/app/prod", "dotenv", "")
+	require.Equal(t, 2, resp.Data["secret_count"])
+	require.Equal(t, []string{"kv2/app/prod/private/cert"}, resp.Data["skipped"])
+	require.Equal(t, `API_KEY="abc 123"
+API_RETRIES="3"
+DB_PASSWORD="p\"a\$s"
+DB_USERNAME="app"
+`, resp.Data["content"])
+
+	resp = export(root, "secret/app/prod/", "systemd", "myapp_")
+	require.Equal(t, 3, resp.Data["secret_count"])
+	require.Empty(t, resp.Data["skipped"])
+	require.Equal(t, `MYAPP_API_KEY="abc 123"
+MYAPP_API_RETRIES="3"
+MYAPP_DB_PASSWORD="p\"a$s"
+MYAPP_DB_USERNAME="app"
+MYAPP_PRIVATE_CERT_PEM="-----BEGIN-----\nMIIB\n-----END-----"
+`, resp.Data["content"])
+
+	resp = export(root, "kv2/app", "properties", "")
+	require.Equal(t, `dev.db.username=dev
+prod.api.key=abc 123
+prod.api.retries=3
+prod.db.password=p"a$s
+prod.db.username=app
+prod.private.cert.pem=-----BEGIN-----\nMIIB\n-----END-----
+`, resp.Data["content"])
+
+	resp = export(root, "secret/app/dev", "json", "")
+	require.Equal(t, `{
+  "db": {
+    "username": "dev"
+  }
+}
+`, resp.Data["content"])
+
+	// Clients which may not list the subtree are denied
+	_, err = handle(token, logical.UpdateOperation, "sys/kv-export", map[string]interface{}{
+		"path": "kv2/app/dev",
+	})
+	require.ErrorIs(t, err, logical.ErrPermissionDenied)
+
+	for name, data := range map[string]map[string]interface{}{
+		"missing path":   {},
+		"not kv":         {"path": "sys/policy"},
+		"invalid format": {"path": "secret/app", "format": "yaml"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			resp, err := handle(root, logical.UpdateOperation, "sys/kv-export", data)
+			require.Error(t, err)
+			require.True(t, resp.IsError())
+		})
+	}
+}
+
+// TestSystemBackend_KVExport_FolderLimit verifies that exporting a subtree
+// with more folders than may be listed fails, however few secrets it has.
+func TestSystemBackend_KVExport_FolderLimit(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	ctx := namespace.RootContext(nil)
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "secret/tree/"+strings.Repeat("d/", maxKVExportFolders)+"leaf")
+	req.ClientToken = root
+	req.Data["value"] = "deep"
+	_, err := c.HandleRequest(ctx, req)
+	require.NoError(t, err)
+
+	req = logical.TestRequest(t, logical.UpdateOperation, "sys/kv-export")
+	req.ClientToken = root
+	req.Data["path"] = "secret/tree"
+	resp, err := c.HandleRequest(ctx, req)
+	require.Error(t, err)
+	require.Contains(t, resp.Error().Error(), fmt.Sprintf("more than %d folders", maxKVExportFolders))
+}
+
+// TestSystemBackend_KVExport_RateLimit verifies that the rate limit quotas of
+// the exported mount apply to the requests made to list and read its secrets.
+func TestSystemBackend_KVExport_RateLimit(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	ctx := namespace.RootContext(nil)
+
+	handle := func(op logical.Operation, path string, data map[string]interface{}) (*logical.Response, error) {
+		t.Helper()
+		req := logical.TestRequest(t, op, path)
+		req.ClientToken = root
+		req.Data = data
+		req.Connection = &logical.Connection{RemoteAddr: "127.0.0.1"}
+		return c.HandleRequest(ctx, req)
+	}
+
+	_, err := handle(logical.UpdateOperation, "secret/app/db", map[string]interface{}{"password": "hunter2"})
+	require.NoError(t, err)
+
+	_, err = handle(logical.UpdateOperation, "sys/quotas/rate-limit/secret", map[string]interface{}{
+		"path":     "secret/",
+		"rate":     1,
+		"interval": "1h",
+	})
+	require.NoError(t, err)
+
+	// Listing the subtree uses up the quota, so reading its secret is limited
+	resp, err := handle(logical.UpdateOperation, "sys/kv-export", map[string]interface{}{
+		"path": "secret/app",
+	})
+	require.Error(t, err)
+	require.Contains(t, resp.Error().Error(), "rate limit quota exceeded")
+}
+
+// TestSystemBackend_KVExport_NumUses verifies that an export fails, rather
+// than returning an incomplete subtree, when the client's token runs out of
+// uses partway through.
+func TestSystemBackend_KVExport_NumUses(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	ctx := namespace.RootContext(nil)
+
+	for _, path := range []string{"secret/app/cache", "secret/app/db"} {
+		req := logical.TestRequest(t, logical.UpdateOperation, path)
+		req.ClientToken = root
+		req.Data["password"] = "hunter2"
+		_, err := c.HandleRequest(ctx, req)
+		require.NoError(t, err)
+	}
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "auth/token/create")
+	req.ClientToken = root
+	req.Data["num_uses"] = 3
+	resp, err := c.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.False(t, resp.IsError(), resp)
+
+	// The export, the list and the first read use up the token, leaving
+	// none for the second read
+	req = logical.TestRequest(t, logical.UpdateOperation, "sys/kv-export")
+	req.ClientToken = resp.Auth.ClientToken
+	req.Data["path"] = "secret/app"
+	resp, err = c.HandleRequest(ctx, req)
+	require.ErrorIs(t, err, logical.ErrPermissionDenied)
+	require.Contains(t, resp.Error().Error(), "ran out of uses")
+}
@@ -0,0 +1,408 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/hashicorp/go-secure-stdlib/strutil"
+	"github.com/hashicorp/vault/helper/identity"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/consts"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func (b *SystemBackend) mountAdminPaths() []*framework.Path {
+	return []*framework.Path{
+		{
+			Pattern: "mounts/(?P<path>.+?)/admins$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "mounts",
+				OperationSuffix: "admins",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"path": {
+					Type:        framework.TypeString,
+					Description: strings.TrimSpace(sysHelp["mount_path"][0]),
+				},
+				"group_ids": {
+					Type:        framework.TypeCommaStringSlice,
+					Description: "IDs of the identity groups granted admin rights over the mount.",
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleMountAdminsRead,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "read",
+					},
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"group_ids": {
+									Type:     framework.TypeCommaStringSlice,
+									Required: true,
+								},
+							},
+						}},
+					},
+					Summary: "Read the identity groups granted admin rights over a mount.",
+				},
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleMountAdminsUpdate,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "configure",
+					},
+					Responses: map[int][]framework.Response{
+						http.StatusNoContent: {{
+							Description: "OK",
+						}},
+					},
+					Summary: "Set the identity groups granted admin rights over a mount.",
+				},
+				logical.DeleteOperation: &framework.PathOperation{
+					Callback: b.handleMountAdminsDelete,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "delete",
+					},
+					Responses: map[int][]framework.Response{
+						http.StatusNoContent: {{
+							Description: "OK",
+						}},
+					},
+					Summary: "Remove all admin grants of a mount.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["mount-admins"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["mount-admins"][1]),
+		},
+	}
+}
+
+func (b *SystemBackend) handleMountAdminsRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	path := sanitizePath(data.Get("path").(string))
+
+	b.Core.mountsLock.RLock()
+	defer b.Core.mountsLock.RUnlock()
+
+	mountEntry, err := b.mountAdminsEntry(ctx, path)
+	if err != nil {
+		return handleError(err)
+	}
+
+	groupIDs := mountEntry.AdminGroupIDs
+	if groupIDs == nil {
+		groupIDs = []string{}
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"group_ids": groupIDs,
+		},
+	}, nil
+}
+
+func (b *SystemBackend) handleMountAdminsUpdate(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	path := sanitizePath(data.Get("path").(string))
+	groupIDs := strutil.RemoveDuplicates(data.Get("group_ids").([]string), false)
+
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, groupID := range groupIDs {
+		group, err := b.Core.identityStore.MemDBGroupByID(groupID, false)
+		if err != nil {
+			return nil, err
+		}
+		if group == nil || group.NamespaceID != ns.ID {
+			return logical.ErrorResponse("group %q not found", groupID), logical.ErrInvalidRequest
+		}
+	}
+
+	return b.setMountAdmins(ctx, path, groupIDs)
+}
+
+func (b *SystemBackend) handleMountAdminsDelete(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	return b.setMountAdmins(ctx, sanitizePath(data.Get("path").(string)), nil)
+}
+
+func (b *SystemBackend) setMountAdmins(ctx context.Context, path string, groupIDs []string) (*logical.Response, error) {
+	b.Core.mountsLock.Lock()
+	defer b.Core.mountsLock.Unlock()
+
+	mountEntry, err := b.mountAdminsEntry(ctx, path)
+	if err != nil {
+		return handleError(err)
+	}
+	if !mountEntry.Local && b.Core.ReplicationState().HasState(consts.ReplicationPerformanceSecondary) {
+		return nil, logical.ErrReadOnly
+	}
+
+	oldGroupIDs := mountEntry.AdminGroupIDs
+	mountEntry.AdminGroupIDs = groupIDs
+	if err := b.Core.persistMounts(ctx, b.Core.mounts, &mountEntry.Local); err != nil {
+		mountEntry.AdminGroupIDs = oldGroupIDs
+		return handleError(err)
+	}
+
+	if b.Core.logger.IsInfo() {
+		b.Core.logger.Info("updated mount admins", "path", path, "group_ids", groupIDs)
+	}
+
+	return nil, nil
+}
+
+// mountAdminsEntry returns the secrets mount at exactly the given path. Admin
+// grants cannot be set on auth mounts or on the mounts that cannot be tuned,
+// nor on KV version 1 mounts, whose config and roles paths hold secrets.
+func (b *SystemBackend) mountAdminsEntry(ctx context.Context, path string) (*MountEntry, error) {
+	for _, p := range untunableMounts {
+		if strings.HasPrefix(path, p) {
+			return nil, fmt.Errorf("cannot grant admin rights over %q", path)
+		}
+	}
+
+	mountEntry := b.Core.router.MatchingMountEntry(ctx, path)
+	if mountEntry == nil || mountEntry.Table != mountTableType || mountEntry.Path != path {
+		return nil, fmt.Errorf("no secrets mount found at %q", path)
+	}
+	if (mountEntry.Type == "kv" || mountEntry.Type == "generic") && mountEntry.Options["version"] != "2" {
+		return nil, fmt.Errorf("cannot grant admin rights over KV version 1 mount %q", path)
+	}
+
+	return mountEntry, nil
+}
+
+// mountAdminGrant is the part of a mount entry needed to build the policy
+// granted through its admin grants, copied so that it can be read without
+// holding the mounts lock.
+type mountAdminGrant struct {
+	accessor    string
+	path        string
+	namespaceID string
+}
+
+// updateMountAdminGrants rebuilds the index of the admin grants of the mounts
+// in the table by group ID, and drops the cached policies of the grants which
+// no longer exist, such as those of removed grants and of unmounted or
+// remounted mounts. It must be called whenever the mount table changes, with
+// the mounts lock held.
+func (c *Core) updateMountAdminGrants(table *MountTable) {
+	grants := make(map[string][]mountAdminGrant)
+	keys := make(map[string]struct{})
+	if table != nil {
+		for _, entry := range table.Entries {
+			for _, groupID := range entry.AdminGroupIDs {
+				grant := mountAdminGrant{
+					accessor:    entry.Accessor,
+					path:        entry.Path,
+					namespaceID: entry.NamespaceID,
+				}
+				grants[groupID] = append(grants[groupID], grant)
+				keys[grant.cacheKey()] = struct{}{}
+			}
+		}
+	}
+	c.mountAdminGrants.Store(grants)
+
+	c.mountAdminPolicyCache.Range(func(key, _ interface{}) bool {
+		if _, ok := keys[key.(string)]; !ok {
+			c.mountAdminPolicyCache.Delete(key)
+		}
+		return true
+	})
+}
+
+// cacheKey returns the key of the policy of the grant in the policy cache.
+func (g mountAdminGrant) cacheKey() string {
+	return g.accessor + "/" + g.path
+}
+
+// mountAdminPolicies returns the policies granted to the entity through the
+// admin grants of secrets mounts. Each grant gives members of the group, direct
+// or inherited, the right to manage the configuration and roles of the mount
+// and to read and tune it, but not access to the secrets it serves.
+func (c *Core) mountAdminPolicies(ctx context.Context, entity *identity.Entity) ([]*Policy, error) {
+	if entity == nil || c.identityStore == nil {
+		return nil, nil
+	}
+
+	grants, _ := c.mountAdminGrants.Load().(map[string][]mountAdminGrant)
+	if len(grants) == 0 {
+		return nil, nil
+	}
+
+	directGroups, inheritedGroups, err := c.identityStore.groupsByEntityID(entity.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch group memberships: %w", err)
+	}
+
+	var policies []*Policy
+	seen := make(map[string]struct{})
+	for _, group := range append(directGroups, inheritedGroups...) {
+		for _, grant := range grants[group.ID] {
+			if _, ok := seen[grant.accessor]; ok {
+				continue
+			}
+			seen[grant.accessor] = struct{}{}
+
+			policy, err := c.mountAdminPolicy(ctx, grant)
+			if err != nil {
+				return nil, err
+			}
+			if policy != nil {
+				policies = append(policies, policy)
+			}
+		}
+	}
+
+	return policies, nil
+}
+
+// mountAdminPolicy returns the policy granted through the admin grants of the
+// mount, parsing it on first use.
+func (c *Core) mountAdminPolicy(ctx context.Context, grant mountAdminGrant) (*Policy, error) {
+	key := grant.cacheKey()
+	if policy, ok := c.mountAdminPolicyCache.Load(key); ok {
+		return policy.(*Policy), nil
+	}
+
+	ns, err := NamespaceByID(ctx, grant.namespaceID, c)
+	if err != nil {
+		return nil, err
+	}
+	if ns == nil {
+		return nil, nil
+	}
+
+	policy, err := ParseACLPolicy(ns, fmt.Sprintf(`
+path %[1]q {
+	capabilities = ["create", "read", "update", "patch", "delete", "list"]
+}
+path %[2]q {
+	capabilities = ["create", "read", "update", "patch", "delete", "list"]
+}
+path %[3]q {
+	capabilities = ["create", "read", "update", "patch", "delete", "list"]
+}
+path %[4]q {
+	capabilities = ["create", "read", "update", "patch", "delete", "list"]
+}
+path %[5]q {
+	capabilities = ["read"]
+}
+path %[6]q {
+	capabilities = ["read", "update"]
+}
+`, grant.path+"config", grant.path+"config/*", grant.path+"roles", grant.path+"roles/*",
+		"sys/mounts/"+strings.TrimSuffix(grant.path, "/"), "sys/mounts/"+grant.path+"tune"))
+	if err != nil {
+		return nil, err
+	}
+	policy.Name = "mount-admin-" + grant.accessor
+
+	c.mountAdminPolicyCache.Store(key, policy)
+	return policy, nil
+}
+
+// mountAdminRestrictedTuneFields are the tune parameters which control the
+// auditing and plugin of a mount rather than its configuration, and so may not
+// be changed through the admin grants of the mount alone.
+var mountAdminRestrictedTuneFields = []string{
+	"audit_non_hmac_request_keys",
+	"audit_non_hmac_response_keys",
+	"passthrough_request_headers",
+	"plugin_version",
+	"options",
+}
+
+// checkMountAdminTune refuses the tune request if it changes any of the
+// restricted tune parameters while its client is only allowed to tune the
+// mount through the admin grants of mounts.
+func (b *SystemBackend) checkMountAdminTune(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	var restricted []string
+	for _, field := range mountAdminRestrictedTuneFields {
+		if _, ok := data.Raw[field]; ok {
+			restricted = append(restricted, field)
+		}
+	}
+	if len(restricted) == 0 {
+		return nil, nil
+	}
+
+	grantOnly, err := b.Core.mountAdminGrantOnly(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if grantOnly {
+		return logical.ErrorResponse("mount admins may not tune %s", strings.Join(restricted, ", ")), logical.ErrPermissionDenied
+	}
+
+	return nil, nil
+}
+
+// mountAdminGrantOnly reports whether the client of the system backend
+// request is only allowed to make it through the admin grants of mounts, that
+// is whether the policies of its token and entity alone would deny it.
+func (c *Core) mountAdminGrantOnly(ctx context.Context, req *logical.Request) (bool, error) {
+	grants, _ := c.mountAdminGrants.Load().(map[string][]mountAdminGrant)
+	if len(grants) == 0 || req.ClientToken == "" {
+		return false, nil
+	}
+
+	te, err := c.tokenStore.Lookup(ctx, req.ClientToken)
+	if err != nil {
+		return false, err
+	}
+	if te == nil || te.EntityID == "" || te.NoIdentityPolicies {
+		return false, nil
+	}
+
+	tokenNS, err := NamespaceByID(ctx, te.NamespaceID, c)
+	if err != nil {
+		return false, err
+	}
+	if tokenNS == nil {
+		return false, namespace.ErrNoNamespace
+	}
+
+	policyNames := map[string][]string{tokenNS.ID: te.Policies}
+	entity, identityPolicies, err := c.fetchEntityAndDerivedPolicies(ctx, tokenNS, te.EntityID, te.NoIdentityPolicies)
+	if err != nil {
+		return false, err
+	}
+	for nsID, nsPolicies := range identityPolicies {
+		policyNames[nsID] = append(policyNames[nsID], nsPolicies...)
+	}
+
+	var policies []*Policy
+	if te.InlinePolicy != "" {
+		inlinePolicy, err := ParseACLPolicy(tokenNS, te.InlinePolicy)
+		if err != nil {
+			return false, err
+		}
+		policies = append(policies, inlinePolicy)
+	}
+
+	acl, err := c.policyStore.ACL(namespace.ContextWithNamespace(ctx, tokenNS), entity, policyNames, policies...)
+	if err != nil {
+		return false, err
+	}
+
+	result := acl.AllowOperation(ctx, &logical.Request{
+		Operation: req.Operation,
+		Path:      "sys/" + req.Path,
+	}, true)
+	return !result.Allowed, nil
+}
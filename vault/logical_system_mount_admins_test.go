@@ -0,0 +1,179 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSystemBackend_MountAdmins(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	ctx := namespace.RootContext(nil)
+
+	handle := func(op logical.Operation, path string, data map[string]interface{}) (*logical.Response, error) {
+		t.Helper()
+		req := logical.TestRequest(t, op, path)
+		req.ClientToken = root
+		req.Data = data
+		return c.HandleRequest(ctx, req)
+	}
+
+	resp, err := handle(logical.UpdateOperation, "sys/mounts/kv", map[string]interface{}{
+		"type":    "kv",
+		"options": map[string]interface{}{"version": "2"},
+	})
+	require.NoError(t, err)
+
+	resp, err = handle(logical.UpdateOperation, "identity/entity", map[string]interface{}{
+		"name": "developer",
+	})
+	require.NoError(t, err)
+	entityID := resp.Data["id"].(string)
+
+	resp, err = handle(logical.UpdateOperation, "identity/group", map[string]interface{}{
+		"name":              "team",
+		"member_entity_ids": []string{entityID},
+	})
+	require.NoError(t, err)
+	teamID := resp.Data["id"].(string)
+
+	// Admin grants are inherited from parent groups
+	resp, err = handle(logical.UpdateOperation, "identity/group", map[string]interface{}{
+		"name":             "department",
+		"member_group_ids": []string{teamID},
+	})
+	require.NoError(t, err)
+	departmentID := resp.Data["id"].(string)
+
+	te := &logical.TokenEntry{
+		Type:        logical.TokenTypeService,
+		Path:        "auth/token/create",
+		Policies:    []string{"default"},
+		EntityID:    entityID,
+		NamespaceID: namespace.RootNamespaceID,
+		TTL:         time.Hour,
+	}
+	require.NoError(t, c.RegisterToken(ctx, te))
+	token := te.ID
+	if te.ExternalID != "" {
+		token = te.ExternalID
+	}
+
+	capabilities := func(path string) []string {
+		t.Helper()
+		caps, err := c.Capabilities(ctx, token, path)
+		require.NoError(t, err)
+		return caps
+	}
+
+	require.Equal(t, []string{DenyCapability}, capabilities("kv/roles/app"))
+	require.Equal(t, []string{DenyCapability}, capabilities("sys/mounts/kv/tune"))
+
+	resp, err = handle(logical.ReadOperation, "sys/mounts/kv/admins", nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{}, resp.Data["group_ids"])
+
+	resp, err = handle(logical.UpdateOperation, "sys/mounts/kv/admins", map[string]interface{}{
+		"group_ids": []string{departmentID},
+	})
+	require.NoError(t, err)
+	require.Nil(t, resp)
+
+	resp, err = handle(logical.ReadOperation, "sys/mounts/kv/admins", nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{departmentID}, resp.Data["group_ids"])
+
+	require.ElementsMatch(t, []string{"create", "read", "update", "patch", "delete", "list"}, capabilities("kv/roles/app"))
+	require.ElementsMatch(t, []string{"create", "read", "update", "patch", "delete", "list"}, capabilities("kv/config"))
+	require.Equal(t, []string{DenyCapability}, capabilities("kv/data/app"))
+	require.ElementsMatch(t, []string{"read", "update"}, capabilities("sys/mounts/kv/tune"))
+	require.Equal(t, []string{DenyCapability}, capabilities("sys/mounts/cubbyhole/tune"))
+	require.Equal(t, []string{DenyCapability}, capabilities("sys/mounts/kv/admins"))
+
+	// Mount admins may tune the mount, but not its auditing or plugin
+	tune := func(data map[string]interface{}) (*logical.Response, error) {
+		t.Helper()
+		req := logical.TestRequest(t, logical.UpdateOperation, "sys/mounts/kv/tune")
+		req.ClientToken = token
+		req.Data = data
+		return c.HandleRequest(ctx, req)
+	}
+	_, err = tune(map[string]interface{}{"max_lease_ttl": "1h"})
+	require.NoError(t, err)
+	for field, value := range map[string]interface{}{
+		"audit_non_hmac_request_keys":  "foo",
+		"audit_non_hmac_response_keys": "foo",
+		"passthrough_request_headers":  "X-Foo",
+		"plugin_version":               "v1.0.0",
+		"options":                      map[string]interface{}{"version": "2"},
+	} {
+		resp, err := tune(map[string]interface{}{field: value})
+		require.ErrorIs(t, err, logical.ErrPermissionDenied, field)
+		require.Contains(t, resp.Error().Error(), field)
+	}
+	me := c.router.MatchingMountEntry(ctx, "kv/")
+	require.Empty(t, me.Config.AuditNonHMACRequestKeys)
+
+	// A policy of its own on the tune path lifts the restriction
+	_, err = handle(logical.UpdateOperation, "sys/policies/acl/kv-tune", map[string]interface{}{
+		"policy": `path "sys/mounts/kv/tune" { capabilities = ["update"] }`,
+	})
+	require.NoError(t, err)
+	_, err = handle(logical.UpdateOperation, "identity/entity/id/"+entityID, map[string]interface{}{
+		"policies": []string{"kv-tune"},
+	})
+	require.NoError(t, err)
+	_, err = tune(map[string]interface{}{"audit_non_hmac_request_keys": "foo"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"foo"}, c.router.MatchingMountEntry(ctx, "kv/").Config.AuditNonHMACRequestKeys)
+
+	_, ok := c.mountAdminPolicyCache.Load(me.Accessor + "/kv/")
+	require.True(t, ok)
+
+	resp, err = handle(logical.DeleteOperation, "sys/mounts/kv/admins", nil)
+	require.NoError(t, err)
+	require.Nil(t, resp)
+	require.Equal(t, []string{DenyCapability}, capabilities("kv/roles/app"))
+
+	// The cached policy of a removed grant is dropped
+	_, ok = c.mountAdminPolicyCache.Load(me.Accessor + "/kv/")
+	require.False(t, ok)
+
+	for name, tc := range map[string]struct {
+		path string
+		data map[string]interface{}
+	}{
+		"unknown group":   {"sys/mounts/kv/admins", map[string]interface{}{"group_ids": "nonexistent"}},
+		"untunable mount": {"sys/mounts/sys/admins", map[string]interface{}{"group_ids": teamID}},
+		"mount subpath":   {"sys/mounts/kv/foo/admins", map[string]interface{}{"group_ids": teamID}},
+		"auth mount":      {"sys/mounts/auth/token/admins", map[string]interface{}{"group_ids": teamID}},
+		"kv v1 mount":     {"sys/mounts/secret/admins", map[string]interface{}{"group_ids": teamID}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			resp, err := handle(logical.UpdateOperation, tc.path, tc.data)
+			require.Error(t, err)
+			require.True(t, resp.IsError())
+		})
+	}
+
+	// The config and roles paths of a KV version 1 mount hold secrets, so
+	// they must not be reachable through an admin grant
+	_, err = handle(logical.UpdateOperation, "secret/config/x", map[string]interface{}{"foo": "bar"})
+	require.NoError(t, err)
+	_, err = handle(logical.UpdateOperation, "sys/mounts/secret/admins", map[string]interface{}{
+		"group_ids": []string{departmentID},
+	})
+	require.Error(t, err)
+	require.Equal(t, []string{DenyCapability}, capabilities("secret/config/x"))
+
+	req := logical.TestRequest(t, logical.ReadOperation, "secret/config/x")
+	req.ClientToken = token
+	_, err = c.HandleRequest(ctx, req)
+	require.ErrorIs(t, err, logical.ErrPermissionDenied)
+}
@@ -0,0 +1,616 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/hashicorp/go-secure-stdlib/strutil"
+	uuid "github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/mitchellh/mapstructure"
+)
+
+const mountBlueprintStoragePrefix = "mount-blueprints/"
+
+// mountBlueprintParameterNameRegex restricts the names of blueprint parameters
+// to those templates can refer to as {{.name}}.
+var mountBlueprintParameterNameRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// mountBlueprintParameterRegex restricts the values of blueprint parameters, so
+// that they cannot traverse out of the paths they are rendered into.
+var mountBlueprintParameterRegex = regexp.MustCompile(`^[\w-]+(\.[\w-]+)*$`)
+
+// mountBlueprint is a parameterized bundle of a secrets engine mount, along
+// with the writes configuring it and the policies granting access to it.
+// Strings of the blueprint are templates, rendered with the parameters given
+// when instantiating it.
+type mountBlueprint struct {
+	Name        string                 `json:"name"`
+	Parameters  []string               `json:"parameters"`
+	Path        string                 `json:"path"`
+	Type        string                 `json:"type"`
+	Description string                 `json:"description"`
+	Config      map[string]interface{} `json:"config"`
+	Options     map[string]string      `json:"options"`
+	Writes      []*mountBlueprintWrite `json:"writes"`
+	Policies    map[string]string      `json:"policies"`
+}
+
+// mountBlueprintWrite is a write to a path of the mount, such as a role or the
+// configuration of the engine.
+type mountBlueprintWrite struct {
+	Path string                 `json:"path" mapstructure:"path"`
+	Data map[string]interface{} `json:"data" mapstructure:"data"`
+}
+
+func (b *SystemBackend) mountBlueprintPaths() []*framework.Path {
+	return []*framework.Path{
+		{
+			Pattern: "mount-blueprints/?$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "mount-blueprints",
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ListOperation: &framework.PathOperation{
+					Callback: b.handleMountBlueprintsList,
+					Summary:  "List the mount blueprints.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["mount-blueprints"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["mount-blueprints"][1]),
+		},
+
+		{
+			Pattern: "mount-blueprints/" + framework.GenericNameRegex("name") + "/instantiate$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "mount-blueprints",
+				OperationVerb:   "instantiate",
+				OperationSuffix: "mount-blueprint",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"name": {
+					Type:        framework.TypeString,
+					Description: "The name of the mount blueprint.",
+				},
+				"parameters": {
+					Type:        framework.TypeKVPairs,
+					Description: "The values of the parameters of the blueprint.",
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleMountBlueprintInstantiate,
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"path": {
+									Type:     framework.TypeString,
+									Required: true,
+								},
+								"writes": {
+									Type:     framework.TypeStringSlice,
+									Required: true,
+								},
+								"policies": {
+									Type:     framework.TypeStringSlice,
+									Required: true,
+								},
+							},
+						}},
+					},
+					Summary: "Mount and configure a secrets engine from a blueprint.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["mount-blueprints"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["mount-blueprints"][1]),
+		},
+
+		{
+			Pattern: "mount-blueprints/" + framework.GenericNameRegex("name"),
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "mount-blueprints",
+				OperationSuffix: "mount-blueprint",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"name": {
+					Type:        framework.TypeString,
+					Description: "The name of the mount blueprint.",
+				},
+				"parameters": {
+					Type:        framework.TypeCommaStringSlice,
+					Description: "Names of the parameters which must be given when instantiating the blueprint, and which its templates may refer to as {{.name}}.",
+				},
+				"path": {
+					Type:        framework.TypeString,
+					Description: "Template of the path of the mount, such as kv/{{.team}}.",
+				},
+				"type": {
+					Type:        framework.TypeString,
+					Description: "The type of the secrets engine.",
+				},
+				"description": {
+					Type:        framework.TypeString,
+					Description: "Template of the description of the mount.",
+				},
+				"config": {
+					Type:        framework.TypeMap,
+					Description: "Tune options of the mount, as accepted by sys/mounts.",
+				},
+				"options": {
+					Type:        framework.TypeKVPairs,
+					Description: "Options of the secrets engine, as accepted by sys/mounts.",
+				},
+				"writes": {
+					Type:        framework.TypeSlice,
+					Description: "Writes to paths of the mount, relative to it, such as roles or the configuration of the engine. Each write is an object with a path and its data.",
+				},
+				"policies": {
+					Type:        framework.TypeKVPairs,
+					Description: "ACL policies to create, keyed by the template of their name.",
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleMountBlueprintSet,
+					Responses: map[int][]framework.Response{
+						http.StatusNoContent: {{
+							Description: "OK",
+						}},
+					},
+					Summary: "Add a new or update an existing mount blueprint.",
+				},
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleMountBlueprintRead,
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+						}},
+					},
+					Summary: "Read a mount blueprint.",
+				},
+				logical.DeleteOperation: &framework.PathOperation{
+					Callback: b.handleMountBlueprintDelete,
+					Responses: map[int][]framework.Response{
+						http.StatusNoContent: {{
+							Description: "OK",
+						}},
+					},
+					Summary: "Delete a mount blueprint.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["mount-blueprints"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["mount-blueprints"][1]),
+		},
+	}
+}
+
+// handleMountBlueprintsList returns the names of the mount blueprints
+func (b *SystemBackend) handleMountBlueprintsList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	names, err := req.Storage.List(ctx, mountBlueprintStoragePrefix)
+	if err != nil {
+		return nil, err
+	}
+	return logical.ListResponse(names), nil
+}
+
+// handleMountBlueprintSet creates or updates a mount blueprint
+func (b *SystemBackend) handleMountBlueprintSet(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	blueprint, err := b.mountBlueprint(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if blueprint == nil {
+		blueprint = &mountBlueprint{
+			Name: name,
+		}
+	}
+
+	if v, ok := d.GetOk("parameters"); ok {
+		blueprint.Parameters = v.([]string)
+	}
+	if v, ok := d.GetOk("path"); ok {
+		blueprint.Path = v.(string)
+	}
+	if v, ok := d.GetOk("type"); ok {
+		blueprint.Type = v.(string)
+	}
+	if v, ok := d.GetOk("description"); ok {
+		blueprint.Description = v.(string)
+	}
+	if v, ok := d.GetOk("config"); ok {
+		blueprint.Config = v.(map[string]interface{})
+	}
+	if v, ok := d.GetOk("options"); ok {
+		blueprint.Options = v.(map[string]string)
+	}
+	if v, ok := d.GetOk("writes"); ok {
+		var writes []*mountBlueprintWrite
+		if err := mapstructure.Decode(v, &writes); err != nil {
+			return logical.ErrorResponse("invalid writes: %s", err), logical.ErrInvalidRequest
+		}
+		blueprint.Writes = writes
+	}
+	if v, ok := d.GetOk("policies"); ok {
+		blueprint.Policies = v.(map[string]string)
+	}
+
+	if err := blueprint.validate(); err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
+	entry, err := logical.StorageEntryJSON(mountBlueprintStoragePrefix+name, blueprint)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+// handleMountBlueprintRead returns a mount blueprint
+func (b *SystemBackend) handleMountBlueprintRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	blueprint, err := b.mountBlueprint(ctx, req.Storage, d.Get("name").(string))
+	if err != nil {
+		return nil, err
+	}
+	if blueprint == nil {
+		return nil, nil
+	}
+
+	writes := make([]map[string]interface{}, 0, len(blueprint.Writes))
+	for _, write := range blueprint.Writes {
+		writes = append(writes, map[string]interface{}{
+			"path": write.Path,
+			"data": write.Data,
+		})
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"name":        blueprint.Name,
+			"parameters":  blueprint.Parameters,
+			"path":        blueprint.Path,
+			"type":        blueprint.Type,
+			"description": blueprint.Description,
+			"config":      blueprint.Config,
+			"options":     blueprint.Options,
+			"writes":      writes,
+			"policies":    blueprint.Policies,
+		},
+	}, nil
+}
+
+// handleMountBlueprintDelete deletes a mount blueprint. The mounts
+// instantiated from it are left as they are.
+func (b *SystemBackend) handleMountBlueprintDelete(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	if err := req.Storage.Delete(ctx, mountBlueprintStoragePrefix+d.Get("name").(string)); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+// handleMountBlueprintInstantiate mounts the secrets engine of a blueprint,
+// writes its configuration and creates its policies. Each step is a request
+// made on behalf of the client, subject to its policies. Policies which
+// already exist are not overwritten: the instantiation fails before mounting
+// anything. If a step fails, the policies created and the mount are removed.
+func (b *SystemBackend) handleMountBlueprintInstantiate(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	blueprint, err := b.mountBlueprint(ctx, req.Storage, d.Get("name").(string))
+	if err != nil {
+		return nil, err
+	}
+	if blueprint == nil {
+		return logical.ErrorResponse("mount blueprint %q not found", d.Get("name").(string)), logical.ErrInvalidRequest
+	}
+
+	params := d.Get("parameters").(map[string]string)
+	for _, name := range blueprint.Parameters {
+		value, ok := params[name]
+		if !ok {
+			return logical.ErrorResponse("missing parameter %q", name), logical.ErrInvalidRequest
+		}
+		if !mountBlueprintParameterRegex.MatchString(value) {
+			return logical.ErrorResponse("invalid value for parameter %q: only letters, digits, '-', '_' and '.' are allowed", name), logical.ErrInvalidRequest
+		}
+	}
+	for name := range params {
+		if !strutil.StrListContains(blueprint.Parameters, name) {
+			return logical.ErrorResponse("unknown parameter %q", name), logical.ErrInvalidRequest
+		}
+	}
+
+	rendered, err := blueprint.render(params)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
+	names := make([]string, 0, len(rendered.Policies))
+	for name := range rendered.Policies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		policy, err := b.Core.policyStore.GetPolicy(ctx, name, PolicyTypeACL)
+		if err != nil {
+			return nil, err
+		}
+		if policy != nil {
+			return logical.ErrorResponse("policy %q already exists", name), logical.ErrInvalidRequest
+		}
+	}
+
+	mountData := map[string]interface{}{
+		"type":        rendered.Type,
+		"description": rendered.Description,
+	}
+	if len(rendered.Config) > 0 {
+		mountData["config"] = rendered.Config
+	}
+	if len(rendered.Options) > 0 {
+		mountData["options"] = rendered.Options
+	}
+	if err := b.mountBlueprintRequest(ctx, req, logical.UpdateOperation, "sys/mounts/"+rendered.Path, mountData); err != nil {
+		return logical.ErrorResponse("failed to mount %q: %s", rendered.Path, err), logical.ErrInvalidRequest
+	}
+
+	// created holds the policies written by this instantiation, the only ones
+	// a rollback removes
+	var created []string
+	rollback := func() {
+		for _, policy := range created {
+			if err := b.mountBlueprintRollbackRequest(ctx, req, "sys/policies/acl/"+policy); err != nil {
+				b.logger.Warn("failed to remove policy of failed blueprint instantiation", "policy", policy, "error", err)
+			}
+		}
+		if err := b.mountBlueprintRollbackRequest(ctx, req, "sys/mounts/"+rendered.Path); err != nil {
+			b.logger.Warn("failed to unmount failed blueprint instantiation", "path", rendered.Path, "error", err)
+		}
+	}
+
+	writes := make([]string, 0, len(rendered.Writes))
+	for _, write := range rendered.Writes {
+		path := sanitizePath(rendered.Path) + strings.TrimPrefix(write.Path, "/")
+		if err := b.mountBlueprintRequest(ctx, req, logical.UpdateOperation, path, write.Data); err != nil {
+			rollback()
+			return logical.ErrorResponse("failed to write %q: %s", path, err), logical.ErrInvalidRequest
+		}
+		writes = append(writes, path)
+	}
+
+	for _, name := range names {
+		if err := b.mountBlueprintRequest(ctx, req, logical.UpdateOperation, "sys/policies/acl/"+name, map[string]interface{}{
+			"policy": rendered.Policies[name],
+		}); err != nil {
+			rollback()
+			return logical.ErrorResponse("failed to write policy %q: %s", name, err), logical.ErrInvalidRequest
+		}
+		created = append(created, name)
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"path":     sanitizePath(rendered.Path),
+			"writes":   writes,
+			"policies": names,
+		},
+	}, nil
+}
+
+// mountBlueprintRequest makes a request on behalf of the client of the given
+// request, subject to the same rate limit quotas as the client's own requests.
+func (b *SystemBackend) mountBlueprintRequest(ctx context.Context, req *logical.Request, op logical.Operation, path string, data map[string]interface{}) error {
+	id, err := uuid.GenerateUUID()
+	if err != nil {
+		return err
+	}
+
+	resp, err := b.Core.handleSubrequest(ctx, &logical.Request{
+		ID:          id,
+		Operation:   op,
+		Path:        path,
+		Data:        data,
+		ClientToken: req.ClientToken,
+		Connection:  req.Connection,
+	})
+	if resp != nil && resp.IsError() {
+		return resp.Error()
+	}
+	return err
+}
+
+// mountBlueprintRollbackRequest deletes the given path on behalf of the client
+// of the given request, to roll back a failed instantiation. Rate limit quotas
+// do not apply, so that an instantiation which exhausted them is not left half
+// applied.
+func (b *SystemBackend) mountBlueprintRollbackRequest(ctx context.Context, req *logical.Request, path string) error {
+	id, err := uuid.GenerateUUID()
+	if err != nil {
+		return err
+	}
+
+	resp, err := b.Core.handleCancelableRequest(ctx, &logical.Request{
+		ID:          id,
+		Operation:   logical.DeleteOperation,
+		Path:        path,
+		ClientToken: req.ClientToken,
+		Connection:  req.Connection,
+	})
+	if resp != nil && resp.IsError() {
+		return resp.Error()
+	}
+	return err
+}
+
+func (b *SystemBackend) mountBlueprint(ctx context.Context, s logical.Storage, name string) (*mountBlueprint, error) {
+	entry, err := s.Get(ctx, mountBlueprintStoragePrefix+name)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	blueprint := new(mountBlueprint)
+	if err := entry.DecodeJSON(blueprint); err != nil {
+		return nil, err
+	}
+	return blueprint, nil
+}
+
+func (m *mountBlueprint) validate() error {
+	if m.Type == "" {
+		return fmt.Errorf("type must be set")
+	}
+	if m.Path == "" {
+		return fmt.Errorf("path must be set")
+	}
+	for _, param := range m.Parameters {
+		if !mountBlueprintParameterNameRegex.MatchString(param) {
+			return fmt.Errorf("invalid parameter name %q", param)
+		}
+	}
+	for i, write := range m.Writes {
+		if write == nil || write.Path == "" {
+			return fmt.Errorf("write %d has no path", i)
+		}
+	}
+
+	// Rendering with placeholder values checks the templates, and that they
+	// only refer to declared parameters
+	params := make(map[string]string, len(m.Parameters))
+	for _, param := range m.Parameters {
+		params[param] = "x"
+	}
+	_, err := m.render(params)
+	return err
+}
+
+// render returns a copy of the blueprint with all of its templates rendered
+// with the given parameters.
+func (m *mountBlueprint) render(params map[string]string) (*mountBlueprint, error) {
+	var renderErr error
+	renderString := func(what, s string) string {
+		if renderErr != nil {
+			return ""
+		}
+		out, err := renderMountBlueprintTemplate(s, params)
+		if err != nil {
+			renderErr = fmt.Errorf("invalid template in %s: %w", what, err)
+		}
+		return out
+	}
+
+	config, err := renderMountBlueprintValue(m.Config, params)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template in config: %w", err)
+	}
+
+	rendered := &mountBlueprint{
+		Name:        m.Name,
+		Parameters:  m.Parameters,
+		Path:        renderString("path", m.Path),
+		Type:        m.Type,
+		Description: renderString("description", m.Description),
+		Options:     make(map[string]string, len(m.Options)),
+		Policies:    make(map[string]string, len(m.Policies)),
+	}
+	rendered.Config, _ = config.(map[string]interface{})
+
+	for k, v := range m.Options {
+		rendered.Options[k] = renderString("options", v)
+	}
+	for _, write := range m.Writes {
+		path := renderString("write path", write.Path)
+		if renderErr != nil {
+			return nil, renderErr
+		}
+		data, err := renderMountBlueprintValue(write.Data, params)
+		if err != nil {
+			return nil, fmt.Errorf("invalid template in data of write %q: %w", write.Path, err)
+		}
+		dataMap, _ := data.(map[string]interface{})
+		rendered.Writes = append(rendered.Writes, &mountBlueprintWrite{
+			Path: path,
+			Data: dataMap,
+		})
+	}
+	for name, policy := range m.Policies {
+		renderedName := renderString("policy name", name)
+		rendered.Policies[renderedName] = renderString("policy "+name, policy)
+	}
+	if renderErr != nil {
+		return nil, renderErr
+	}
+
+	return rendered, nil
+}
+
+// renderMountBlueprintValue renders the templates of all of the strings within
+// the value.
+func renderMountBlueprintValue(v interface{}, params map[string]string) (interface{}, error) {
+	switch v := v.(type) {
+	case string:
+		return renderMountBlueprintTemplate(v, params)
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			rendered, err := renderMountBlueprintValue(item, params)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = rendered
+		}
+		return out, nil
+	case []interface{}:
+		out := make([]interface{}, 0, len(v))
+		for _, item := range v {
+			rendered, err := renderMountBlueprintValue(item, params)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, rendered)
+		}
+		return out, nil
+	default:
+		return v, nil
+	}
+}
+
+func renderMountBlueprintTemplate(s string, params map[string]string) (string, error) {
+	tmpl, err := template.New("").Option("missingkey=error").Parse(s)
+	if err != nil {
+		return "", err
+	}
+
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, params); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
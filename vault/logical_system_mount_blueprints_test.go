@@ -0,0 +1,214 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSystemBackend_MountBlueprints(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	ctx := namespace.RootContext(nil)
+
+	handle := func(op logical.Operation, path string, data map[string]interface{}) (*logical.Response, error) {
+		t.Helper()
+		req := logical.TestRequest(t, op, path)
+		req.ClientToken = root
+		req.Data = data
+		return c.HandleRequest(ctx, req)
+	}
+
+	resp, err := handle(logical.UpdateOperation, "sys/mount-blueprints/team-kv", map[string]interface{}{
+		"parameters":  "team",
+		"path":        "kv-{{.team}}",
+		"type":        "kv",
+		"description": "Secrets of team {{.team}}",
+		"config": map[string]interface{}{
+			"max_lease_ttl": "2h",
+		},
+		"writes": []interface{}{
+			map[string]interface{}{
+				"path": "defaults",
+				"data": map[string]interface{}{
+					"owner": "{{.team}}",
+					"tags":  []interface{}{"team-{{.team}}"},
+				},
+			},
+		},
+		"policies": map[string]interface{}{
+			"{{.team}}-read": `path "kv-{{.team}}/*" { capabilities = ["read"] }`,
+		},
+	})
+	require.NoError(t, err)
+	require.Nil(t, resp)
+
+	resp, err = handle(logical.ReadOperation, "sys/mount-blueprints/team-kv", nil)
+	require.NoError(t, err)
+	require.Equal(t, "kv-{{.team}}", resp.Data["path"])
+	require.Equal(t, []string{"team"}, resp.Data["parameters"])
+
+	resp, err = handle(logical.ListOperation, "sys/mount-blueprints", nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{"team-kv"}, resp.Data["keys"])
+
+	resp, err = handle(logical.UpdateOperation, "sys/mount-blueprints/team-kv/instantiate", map[string]interface{}{
+		"parameters": map[string]interface{}{
+			"team": "payments",
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "kv-payments/", resp.Data["path"])
+	require.Equal(t, []string{"kv-payments/defaults"}, resp.Data["writes"])
+	require.Equal(t, []string{"payments-read"}, resp.Data["policies"])
+
+	me := c.router.MatchingMountEntry(ctx, "kv-payments/")
+	require.NotNil(t, me)
+	require.Equal(t, "kv", me.Type)
+	require.Equal(t, "Secrets of team payments", me.Description)
+	require.Equal(t, 2*time.Hour, me.Config.MaxLeaseTTL)
+
+	resp, err = handle(logical.ReadOperation, "kv-payments/defaults", nil)
+	require.NoError(t, err)
+	require.Equal(t, "payments", resp.Data["owner"])
+	require.Equal(t, []interface{}{"team-payments"}, resp.Data["tags"])
+
+	policy, err := c.policyStore.GetPolicy(ctx, "payments-read", PolicyTypeACL)
+	require.NoError(t, err)
+	require.NotNil(t, policy)
+	require.Equal(t, "kv-payments/", policy.Paths[0].Path)
+
+	t.Run("invalid instantiations", func(t *testing.T) {
+		for name, params := range map[string]map[string]interface{}{
+			"missing parameter": {},
+			"unknown parameter": {"team": "ops", "app": "web"},
+			"path traversal":    {"team": "../sys"},
+			"existing mount":    {"team": "payments"},
+		} {
+			t.Run(name, func(t *testing.T) {
+				resp, err := handle(logical.UpdateOperation, "sys/mount-blueprints/team-kv/instantiate", map[string]interface{}{
+					"parameters": params,
+				})
+				require.Error(t, err)
+				require.True(t, resp.IsError())
+			})
+		}
+	})
+
+	t.Run("failed instantiations are rolled back", func(t *testing.T) {
+		resp, err := handle(logical.UpdateOperation, "sys/mount-blueprints/broken", map[string]interface{}{
+			"parameters": "team",
+			"path":       "broken-{{.team}}",
+			"type":       "kv",
+			"policies": map[string]interface{}{
+				"{{.team}}-broken": `path "broken-{{.team}}/*" { capabilities = ["read"] }`,
+				"root":             `path "*" { capabilities = ["sudo"] }`,
+			},
+		})
+		require.NoError(t, err)
+		require.Nil(t, resp)
+
+		resp, err = handle(logical.UpdateOperation, "sys/mount-blueprints/broken/instantiate", map[string]interface{}{
+			"parameters": map[string]interface{}{
+				"team": "ops",
+			},
+		})
+		require.Error(t, err)
+		require.True(t, resp.IsError())
+
+		require.Nil(t, c.router.MatchingMountEntry(ctx, "broken-ops/"))
+		policy, err := c.policyStore.GetPolicy(ctx, "ops-broken", PolicyTypeACL)
+		require.NoError(t, err)
+		require.Nil(t, policy)
+	})
+
+	t.Run("existing policies are not overwritten", func(t *testing.T) {
+		existing := `path "kv-audit/*" { capabilities = ["list"] }`
+		_, err := handle(logical.UpdateOperation, "sys/policies/acl/audit-read", map[string]interface{}{
+			"policy": existing,
+		})
+		require.NoError(t, err)
+
+		resp, err := handle(logical.UpdateOperation, "sys/mount-blueprints/team-kv/instantiate", map[string]interface{}{
+			"parameters": map[string]interface{}{
+				"team": "audit",
+			},
+		})
+		require.Error(t, err)
+		require.Contains(t, resp.Error().Error(), `policy "audit-read" already exists`)
+
+		require.Nil(t, c.router.MatchingMountEntry(ctx, "kv-audit/"))
+		policy, err := c.policyStore.GetPolicy(ctx, "audit-read", PolicyTypeACL)
+		require.NoError(t, err)
+		require.NotNil(t, policy)
+		require.Equal(t, existing, policy.Raw)
+	})
+
+	for name, data := range map[string]map[string]interface{}{
+		"missing type":       {"path": "kv"},
+		"undeclared param":   {"type": "kv", "path": "kv-{{.app}}"},
+		"invalid template":   {"type": "kv", "path": "kv-{{.team"},
+		"invalid param name": {"type": "kv", "path": "kv", "parameters": "team-name"},
+		"write without path": {"type": "kv", "path": "kv", "writes": []interface{}{map[string]interface{}{"data": map[string]interface{}{}}}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			resp, err := handle(logical.UpdateOperation, "sys/mount-blueprints/invalid", data)
+			require.Error(t, err)
+			require.True(t, resp.IsError())
+		})
+	}
+}
+
+// TestSystemBackend_MountBlueprints_RateLimit verifies that the requests made
+// to instantiate a blueprint are subject to rate limit quotas, and that an
+// instantiation exceeding them is rolled back.
+func TestSystemBackend_MountBlueprints_RateLimit(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	ctx := namespace.RootContext(nil)
+
+	handle := func(op logical.Operation, path string, data map[string]interface{}) (*logical.Response, error) {
+		t.Helper()
+		req := logical.TestRequest(t, op, path)
+		req.ClientToken = root
+		req.Data = data
+		req.Connection = &logical.Connection{RemoteAddr: "127.0.0.1"}
+		return c.HandleRequest(ctx, req)
+	}
+
+	_, err := handle(logical.UpdateOperation, "sys/mount-blueprints/team-kv", map[string]interface{}{
+		"parameters": "team",
+		"path":       "kv-{{.team}}",
+		"type":       "kv",
+		"writes": []interface{}{
+			map[string]interface{}{
+				"path": "defaults",
+				"data": map[string]interface{}{"owner": "{{.team}}"},
+			},
+		},
+		"policies": map[string]interface{}{
+			"{{.team}}-read": `path "kv-{{.team}}/*" { capabilities = ["read"] }`,
+		},
+	})
+	require.NoError(t, err)
+
+	// The mount and the write use up the quota, so the policy is limited
+	_, err = handle(logical.UpdateOperation, "sys/quotas/rate-limit/global", map[string]interface{}{
+		"rate":     2,
+		"interval": "1h",
+	})
+	require.NoError(t, err)
+
+	resp, err := handle(logical.UpdateOperation, "sys/mount-blueprints/team-kv/instantiate", map[string]interface{}{
+		"parameters": map[string]interface{}{
+			"team": "payments",
+		},
+	})
+	require.Error(t, err)
+	require.Contains(t, resp.Error().Error(), "rate limit quota exceeded")
+	require.Nil(t, c.router.MatchingMountEntry(ctx, "kv-payments/"))
+}
@@ -0,0 +1,264 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-secure-stdlib/parseutil"
+	"github.com/hashicorp/vault/helper/locking"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/consts"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func (b *SystemBackend) mountSLOPaths() []*framework.Path {
+	return []*framework.Path{
+		{
+			Pattern: "mounts/(?P<path>.+?)/slo$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "mounts",
+				OperationSuffix: "slo",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"path": {
+					Type:        framework.TypeString,
+					Description: "The path of the mount; auth mounts are prefixed with auth/.",
+				},
+				"read_latency": {
+					Type:        framework.TypeString,
+					Description: "Target latency of read and list requests, such as 250ms. Not measured if unset.",
+				},
+				"write_latency": {
+					Type:        framework.TypeString,
+					Description: "Target latency of create, update, patch and delete requests, such as the issuance of credentials. Not measured if unset.",
+				},
+				"max_request_size": {
+					Type:        framework.TypeInt64,
+					Description: "Target size in bytes of request bodies. Not measured if unset.",
+				},
+				"max_response_size": {
+					Type:        framework.TypeInt64,
+					Description: "Target size in bytes of response data. Not measured if unset.",
+				},
+				"window": {
+					Type:        framework.TypeDurationSecond,
+					Description: "The window over which the objectives are evaluated.",
+					Default:     int(mountSLODefaultWindow.Seconds()),
+				},
+				"objective": {
+					Type:        framework.TypeFloat,
+					Description: "The fraction of the requests of a window which must meet each target.",
+					Default:     mountSLODefaultObjective,
+				},
+				"min_requests": {
+					Type:        framework.TypeInt,
+					Description: "The number of requests measured against a target in a window below which the objective is not evaluated.",
+					Default:     mountSLODefaultMinRequests,
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleMountSLORead,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "read",
+					},
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"read_latency": {
+									Type: framework.TypeString,
+								},
+								"write_latency": {
+									Type: framework.TypeString,
+								},
+								"max_request_size": {
+									Type: framework.TypeInt64,
+								},
+								"max_response_size": {
+									Type: framework.TypeInt64,
+								},
+								"window": {
+									Type: framework.TypeDurationSecond,
+								},
+								"objective": {
+									Type: framework.TypeFloat,
+								},
+								"min_requests": {
+									Type: framework.TypeInt,
+								},
+								"current_window": {
+									Type:        framework.TypeMap,
+									Description: "The measurements of the current window on this node.",
+								},
+							},
+						}},
+					},
+					Summary: "Read the service level objectives of a mount.",
+				},
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleMountSLOUpdate,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "configure",
+					},
+					Responses: map[int][]framework.Response{
+						http.StatusNoContent: {{
+							Description: "OK",
+						}},
+					},
+					Summary: "Set the service level objectives of a mount.",
+				},
+				logical.DeleteOperation: &framework.PathOperation{
+					Callback: b.handleMountSLODelete,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "delete",
+					},
+					Responses: map[int][]framework.Response{
+						http.StatusNoContent: {{
+							Description: "OK",
+						}},
+					},
+					Summary: "Remove the service level objectives of a mount.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["mount-slo"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["mount-slo"][1]),
+		},
+	}
+}
+
+func (b *SystemBackend) handleMountSLORead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	path := sanitizePath(data.Get("path").(string))
+
+	lock := b.mountSLOLock(path)
+	lock.RLock()
+	defer lock.RUnlock()
+
+	mountEntry, err := b.mountSLOEntry(ctx, path)
+	if err != nil {
+		return handleError(err)
+	}
+	if mountEntry.SLO == nil {
+		return nil, nil
+	}
+
+	slo := mountEntry.SLO
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"read_latency":      formatMountSLOLatency(slo.ReadLatency),
+			"write_latency":     formatMountSLOLatency(slo.WriteLatency),
+			"max_request_size":  slo.MaxRequestSize,
+			"max_response_size": slo.MaxResponseSize,
+			"window":            int64(slo.Window.Seconds()),
+			"objective":         slo.Objective,
+			"min_requests":      slo.MinRequests,
+			"current_window":    b.Core.router.MountSLOStatus(mountEntry),
+		},
+	}, nil
+}
+
+func (b *SystemBackend) handleMountSLOUpdate(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	var latencies [2]time.Duration
+	for i, field := range []string{"read_latency", "write_latency"} {
+		if raw := data.Get(field).(string); raw != "" {
+			latency, err := parseutil.ParseDurationSecond(raw)
+			if err != nil {
+				return logical.ErrorResponse("invalid %s: %s", field, err), logical.ErrInvalidRequest
+			}
+			latencies[i] = latency
+		}
+	}
+
+	slo := &MountSLO{
+		ReadLatency:     latencies[0],
+		WriteLatency:    latencies[1],
+		MaxRequestSize:  data.Get("max_request_size").(int64),
+		MaxResponseSize: data.Get("max_response_size").(int64),
+		Window:          time.Duration(data.Get("window").(int)) * time.Second,
+		Objective:       data.Get("objective").(float64),
+		MinRequests:     data.Get("min_requests").(int),
+	}
+	if err := slo.validate(); err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
+	return b.setMountSLO(ctx, sanitizePath(data.Get("path").(string)), slo)
+}
+
+func (b *SystemBackend) handleMountSLODelete(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	return b.setMountSLO(ctx, sanitizePath(data.Get("path").(string)), nil)
+}
+
+func (b *SystemBackend) setMountSLO(ctx context.Context, path string, slo *MountSLO) (*logical.Response, error) {
+	lock := b.mountSLOLock(path)
+	lock.Lock()
+	defer lock.Unlock()
+
+	mountEntry, err := b.mountSLOEntry(ctx, path)
+	if err != nil {
+		return handleError(err)
+	}
+	if !mountEntry.Local && b.Core.ReplicationState().HasState(consts.ReplicationPerformanceSecondary) {
+		return nil, logical.ErrReadOnly
+	}
+
+	oldSLO := mountEntry.SLO
+	mountEntry.SLO = slo
+	if mountEntry.Table == credentialTableType {
+		err = b.Core.persistAuth(ctx, b.Core.auth, &mountEntry.Local)
+	} else {
+		err = b.Core.persistMounts(ctx, b.Core.mounts, &mountEntry.Local)
+	}
+	if err != nil {
+		mountEntry.SLO = oldSLO
+		return handleError(err)
+	}
+	b.Core.router.setMountSLO(mountEntry, slo)
+
+	if b.Core.logger.IsInfo() {
+		b.Core.logger.Info("updated mount service level objectives", "path", path)
+	}
+
+	return nil, nil
+}
+
+func formatMountSLOLatency(latency time.Duration) string {
+	if latency == 0 {
+		return ""
+	}
+	return latency.String()
+}
+
+func (b *SystemBackend) mountSLOLock(path string) locking.RWMutex {
+	if strings.HasPrefix(path, credentialRoutePrefix) {
+		return b.Core.authLock
+	}
+	return b.Core.mountsLock
+}
+
+// mountSLOEntry returns the secrets or auth mount at exactly the given path.
+// Service level objectives cannot be set on the mounts that cannot be tuned.
+func (b *SystemBackend) mountSLOEntry(ctx context.Context, path string) (*MountEntry, error) {
+	for _, p := range untunableMounts {
+		if strings.HasPrefix(path, p) {
+			return nil, fmt.Errorf("cannot set service level objectives on %q", path)
+		}
+	}
+
+	mountEntry := b.Core.router.MatchingMountEntry(ctx, path)
+	if mountEntry == nil || mountEntry.APIPathNoNamespace() != path {
+		return nil, fmt.Errorf("no mount found at %q", path)
+	}
+
+	return mountEntry, nil
+}
@@ -0,0 +1,105 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSystemBackend_MountSLO(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	ctx := namespace.RootContext(nil)
+
+	handle := func(op logical.Operation, path string, data map[string]interface{}) (*logical.Response, error) {
+		t.Helper()
+		req := logical.TestRequest(t, op, path)
+		req.ClientToken = root
+		req.Data = data
+		return c.HandleRequest(ctx, req)
+	}
+
+	resp, err := handle(logical.ReadOperation, "sys/mounts/secret/slo", nil)
+	require.NoError(t, err)
+	require.Nil(t, resp)
+
+	for name, data := range map[string]map[string]interface{}{
+		"no targets":        {"window": "1m"},
+		"invalid latency":   {"read_latency": "fast"},
+		"invalid objective": {"read_latency": "1s", "objective": 1},
+		"short window":      {"read_latency": "1s", "window": "100ms"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			resp, err := handle(logical.UpdateOperation, "sys/mounts/secret/slo", data)
+			require.ErrorIs(t, err, logical.ErrInvalidRequest)
+			require.True(t, resp.IsError())
+		})
+	}
+
+	_, err = handle(logical.UpdateOperation, "sys/mounts/unknown/slo", map[string]interface{}{
+		"read_latency": "1s",
+	})
+	require.Error(t, err)
+
+	events, cancel, err := c.events.Subscribe(context.Background(), namespace.RootNamespace, mountSLOBreachEventType, "")
+	require.NoError(t, err)
+	defer cancel()
+
+	// Every write misses a 1ns target
+	_, err = handle(logical.UpdateOperation, "sys/mounts/secret/slo", map[string]interface{}{
+		"write_latency": "1ns",
+		"read_latency":  "1m",
+		"window":        "1s",
+		"min_requests":  2,
+	})
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		_, err = handle(logical.UpdateOperation, "secret/foo", map[string]interface{}{"bar": "baz"})
+		require.NoError(t, err)
+		_, err = handle(logical.ReadOperation, "secret/foo", nil)
+		require.NoError(t, err)
+	}
+
+	resp, err = handle(logical.ReadOperation, "sys/mounts/secret/slo", nil)
+	require.NoError(t, err)
+	require.Equal(t, "1ns", resp.Data["write_latency"])
+	require.Equal(t, int64(1), resp.Data["window"])
+	require.Equal(t, 0.99, resp.Data["objective"])
+	indicators := resp.Data["current_window"].(map[string]interface{})["indicators"]
+	require.Equal(t, map[string]sloCount{
+		sloIndicatorReadLatency:  {Requests: 2},
+		sloIndicatorWriteLatency: {Requests: 2, Violations: 2},
+	}, indicators)
+
+	// The breach is reported once the window ends
+	time.Sleep(time.Second)
+	_, err = handle(logical.ReadOperation, "secret/foo", nil)
+	require.NoError(t, err)
+
+	select {
+	case event := <-events:
+		received := event.Payload.(*logical.EventReceived)
+		require.Equal(t, mountSLOBreachEventType, received.EventType)
+		require.Equal(t, "secret/", received.PluginInfo.MountPath)
+		metadata := received.Event.Metadata.AsMap()
+		require.Equal(t, sloIndicatorWriteLatency, metadata["indicator"])
+		require.Equal(t, "1ns", metadata["target"])
+		require.Equal(t, "2", metadata["violations"])
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the SLO breach event")
+	}
+
+	// Deleting the objectives stops the measurements
+	_, err = handle(logical.DeleteOperation, "sys/mounts/secret/slo", nil)
+	require.NoError(t, err)
+	resp, err = handle(logical.ReadOperation, "sys/mounts/secret/slo", nil)
+	require.NoError(t, err)
+	require.Nil(t, resp)
+}
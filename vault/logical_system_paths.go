@@ -7,7 +7,9 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/hashicorp/vault/helper/random"
 	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/pointerutil"
 	"github.com/hashicorp/vault/sdk/logical"
 )
 
@@ -1507,7 +1509,13 @@ func (b *SystemBackend) auditHashPath() *framework.Path {
 			},
 
 			"input": {
-				Type: framework.TypeString,
+				Type:        framework.TypeString,
+				Description: strings.TrimSpace(sysHelp["audit-hash-input"][0]),
+			},
+
+			"inputs": {
+				Type:        framework.TypeStringSlice,
+				Description: strings.TrimSpace(sysHelp["audit-hash-inputs"][0]),
 			},
 		},
 
@@ -1520,7 +1528,23 @@ func (b *SystemBackend) auditHashPath() *framework.Path {
 						Fields: map[string]*framework.FieldSchema{
 							"hash": {
 								Type:     framework.TypeString,
-								Required: true,
+								Required: false,
+							},
+							"previous_hash": {
+								Type:     framework.TypeString,
+								Required: false,
+							},
+							"hashes": {
+								Type:     framework.TypeStringSlice,
+								Required: false,
+							},
+							"previous_hashes": {
+								Type:     framework.TypeStringSlice,
+								Required: false,
+							},
+							"previous_expires_at": {
+								Type:     framework.TypeTime,
+								Required: false,
 							},
 						},
 					}},
@@ -1533,9 +1557,49 @@ func (b *SystemBackend) auditHashPath() *framework.Path {
 	}
 }
 
+func (b *SystemBackend) auditHashRotatePath() *framework.Path {
+	return &framework.Path{
+		Pattern: "audit-hash-rotate/(?P<path>.+)",
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: "auditing",
+			OperationVerb:   "rotate",
+			OperationSuffix: "hash-key",
+		},
+
+		Fields: map[string]*framework.FieldSchema{
+			"path": {
+				Type:        framework.TypeString,
+				Description: strings.TrimSpace(sysHelp["audit_path"][0]),
+			},
+
+			"overlap": {
+				Type:        framework.TypeDurationSecond,
+				Default:     "24h",
+				Description: strings.TrimSpace(sysHelp["audit-hash-rotate-overlap"][0]),
+			},
+		},
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.UpdateOperation: &framework.PathOperation{
+				Callback: b.handleAuditHashRotate,
+				Responses: map[int][]framework.Response{
+					http.StatusNoContent: {{
+						Description: "OK",
+					}},
+				},
+			},
+		},
+
+		HelpSynopsis:    strings.TrimSpace(sysHelp["audit-hash-rotate"][0]),
+		HelpDescription: strings.TrimSpace(sysHelp["audit-hash-rotate"][1]),
+	}
+}
+
 func (b *SystemBackend) auditPaths() []*framework.Path {
 	return []*framework.Path{
 		b.auditHashPath(),
+		b.auditHashRotatePath(),
 
 		{
 			Pattern: "audit$",
@@ -2540,13 +2604,16 @@ func (b *SystemBackend) toolsPaths() []*framework.Path {
 				"bytes": {
 					Type:        framework.TypeInt,
 					Default:     32,
+					Minimum:     pointerutil.IntPtr(1),
+					Maximum:     pointerutil.IntPtr(random.APIMaxBytes),
 					Description: "The number of bytes to generate (POST body parameter). Defaults to 32 (256 bits).",
 				},
 
 				"format": {
-					Type:        framework.TypeString,
-					Default:     "base64",
-					Description: `Encoding format to use. Can be "hex" or "base64". Defaults to "base64".`,
+					Type:          framework.TypeString,
+					Default:       "base64",
+					AllowedValues: []interface{}{"hex", "base64"},
+					Description:   `Encoding format to use. Can be "hex" or "base64". Defaults to "base64".`,
 				},
 
 				"source": {
@@ -3220,6 +3287,16 @@ func (b *SystemBackend) leasePaths() []*framework.Path {
 									Description: "Time to Live set for the lease, returns 0 if unset",
 									Required:    true,
 								},
+								"client_name": {
+									Type:        framework.TypeString,
+									Description: "Name of the client that created the lease, if it was supplied",
+									Required:    false,
+								},
+								"client_version": {
+									Type:        framework.TypeString,
+									Description: "Version of the client that created the lease, if it was supplied",
+									Required:    false,
+								},
 							},
 						}},
 					},
@@ -3454,6 +3531,51 @@ func (b *SystemBackend) leasePaths() []*framework.Path {
 			HelpDescription: strings.TrimSpace(sysHelp["count-leases"][1]),
 		},
 
+		{
+			Pattern: "leases/clients/?$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "leases",
+				OperationVerb:   "count",
+				OperationSuffix: "by-client",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"include_child_namespaces": {
+					Type:        framework.TypeBool,
+					Default:     false,
+					Description: "Set true if you want counts for this namespace and its children.",
+					Query:       true,
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleLeaseClientCount,
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"lease_count": {
+									Type:        framework.TypeInt,
+									Description: "Number of leases created by an identified client",
+									Required:    true,
+								},
+								"clients": {
+									Type:        framework.TypeMap,
+									Description: "Number of leases per client name and version",
+									Required:    true,
+								},
+							},
+						}},
+					},
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["lease-clients"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["lease-clients"][1]),
+		},
+
 		{
 			Pattern: "leases$",
 
@@ -3642,6 +3764,16 @@ func (b *SystemBackend) monitorPath() *framework.Path {
 				Query:       true,
 				Default:     "standard",
 			},
+			"subsystems": {
+				Type:        framework.TypeCommaStringSlice,
+				Description: "Only stream logs emitted by these subsystems, such as \"storage.raft\" or \"expiration\". Logs of subsystems named beneath these are also streamed.",
+				Query:       true,
+			},
+			"sample_rate": {
+				Type:        framework.TypeFloat,
+				Description: "Fraction of logs below the warn level to stream, between 0 and 1. Warnings and errors are always streamed. The default is to stream every log.",
+				Query:       true,
+			},
 		},
 		Operations: map[logical.Operation]framework.OperationHandler{
 			logical.ReadOperation: &framework.PathOperation{
@@ -3746,6 +3878,15 @@ func (b *SystemBackend) authPaths() []*framework.Path {
 				OperationSuffix: "enabled-methods",
 			},
 
+			Fields: map[string]*framework.FieldSchema{
+				"include_health": {
+					Type:        framework.TypeBool,
+					Default:     false,
+					Description: strings.TrimSpace(sysHelp["mount_include_health"][0]),
+					Query:       true,
+				},
+			},
+
 			Operations: map[logical.Operation]framework.OperationHandler{
 				logical.ReadOperation: &framework.PathOperation{
 					Callback: b.handleAuthTable,
@@ -3946,6 +4087,12 @@ func (b *SystemBackend) authPaths() []*framework.Path {
 			},
 
 			Fields: map[string]*framework.FieldSchema{
+				"include_health": {
+					Type:        framework.TypeBool,
+					Default:     false,
+					Description: strings.TrimSpace(sysHelp["mount_include_health"][0]),
+					Query:       true,
+				},
 				"path": {
 					Type:        framework.TypeString,
 					Description: strings.TrimSpace(sysHelp["auth_path"][0]),
@@ -4050,6 +4197,11 @@ func (b *SystemBackend) authPaths() []*framework.Path {
 									Type:     framework.TypeString,
 									Required: false,
 								},
+								"health": {
+									Type:        framework.TypeMap,
+									Description: strings.TrimSpace(sysHelp["mount_health"][0]),
+									Required:    false,
+								},
 								"config": {
 									Type:     framework.TypeMap,
 									Required: true,
@@ -4818,6 +4970,12 @@ func (b *SystemBackend) mountPaths() []*framework.Path {
 			},
 
 			Fields: map[string]*framework.FieldSchema{
+				"include_health": {
+					Type:        framework.TypeBool,
+					Default:     false,
+					Description: strings.TrimSpace(sysHelp["mount_include_health"][0]),
+					Query:       true,
+				},
 				"path": {
 					Type:        framework.TypeString,
 					Description: strings.TrimSpace(sysHelp["mount_path"][0]),
@@ -4935,6 +5093,11 @@ func (b *SystemBackend) mountPaths() []*framework.Path {
 									Type:     framework.TypeString,
 									Required: false,
 								},
+								"health": {
+									Type:        framework.TypeMap,
+									Description: strings.TrimSpace(sysHelp["mount_health"][0]),
+									Required:    false,
+								},
 							},
 						}},
 					},
@@ -4980,6 +5143,15 @@ func (b *SystemBackend) mountPaths() []*framework.Path {
 				OperationSuffix: "secrets-engines",
 			},
 
+			Fields: map[string]*framework.FieldSchema{
+				"include_health": {
+					Type:        framework.TypeBool,
+					Default:     false,
+					Description: strings.TrimSpace(sysHelp["mount_include_health"][0]),
+					Query:       true,
+				},
+			},
+
 			Operations: map[logical.Operation]framework.OperationHandler{
 				logical.ReadOperation: &framework.PathOperation{
 					Callback: b.handleMountTable,
@@ -233,6 +233,136 @@ from any further requests until after the 'block_interval' has elapsed.`,
 			HelpSynopsis:    strings.TrimSpace(quotasHelp["rate-limit"][0]),
 			HelpDescription: strings.TrimSpace(quotasHelp["rate-limit"][1]),
 		},
+		{
+			Pattern: "quotas/lease-count/?$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "lease-count-quotas",
+				OperationVerb:   "list",
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ListOperation: &framework.PathOperation{
+					Callback: b.handleLeaseCountQuotasList(),
+				},
+			},
+			HelpSynopsis:    strings.TrimSpace(quotasHelp["lease-count-list"][0]),
+			HelpDescription: strings.TrimSpace(quotasHelp["lease-count-list"][1]),
+		},
+		{
+			Pattern: "quotas/lease-count/" + framework.GenericNameRegex("name"),
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "lease-count-quotas",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"type": {
+					Type:        framework.TypeString,
+					Description: "Type of the quota rule.",
+				},
+				"name": {
+					Type:        framework.TypeString,
+					Description: "Name of the quota rule.",
+				},
+				"path": {
+					Type: framework.TypeString,
+					Description: `Path of the mount or namespace to apply the quota. A blank path configures a
+global quota. For example namespace1/ adds a quota to a full namespace,
+namespace1/auth/userpass adds a quota to userpass in namespace1.`,
+				},
+				"role": {
+					Type: framework.TypeString,
+					Description: `Login role to apply this quota to. Note that when set, path must be configured
+to a valid auth method with a concept of roles.`,
+				},
+				"inheritable": {
+					Type:        framework.TypeBool,
+					Description: `Whether all child namespaces can inherit this namespace quota.`,
+				},
+				"max_leases": {
+					Type: framework.TypeInt,
+					Description: `The maximum number of leases to be allowed by the quota rule. The 'max_leases'
+must be positive.`,
+				},
+				"expire_oldest": {
+					Type: framework.TypeBool,
+					Description: `If set, when the quota is reached, the oldest lease counted by the quota is
+expired instead of rejecting the request creating a new lease.`,
+				},
+			},
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleLeaseCountQuotasUpdate(),
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "write",
+					},
+					Responses: map[int][]framework.Response{
+						http.StatusNoContent: {{
+							Description: http.StatusText(http.StatusNoContent),
+						}},
+					},
+				},
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleLeaseCountQuotasRead(),
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "read",
+					},
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"type": {
+									Type:     framework.TypeString,
+									Required: true,
+								},
+								"name": {
+									Type:     framework.TypeString,
+									Required: true,
+								},
+								"path": {
+									Type:     framework.TypeString,
+									Required: true,
+								},
+								"role": {
+									Type:     framework.TypeString,
+									Required: true,
+								},
+								"max_leases": {
+									Type:     framework.TypeInt,
+									Required: true,
+								},
+								"counter": {
+									Type:     framework.TypeInt,
+									Required: true,
+								},
+								"expire_oldest": {
+									Type:     framework.TypeBool,
+									Required: true,
+								},
+								"inheritable": {
+									Type:     framework.TypeBool,
+									Required: true,
+								},
+							},
+						}},
+					},
+				},
+				logical.DeleteOperation: &framework.PathOperation{
+					Callback: b.handleLeaseCountQuotasDelete(),
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "delete",
+					},
+					Responses: map[int][]framework.Response{
+						http.StatusNoContent: {{
+							Description: "OK",
+						}},
+					},
+				},
+			},
+			HelpSynopsis:    strings.TrimSpace(quotasHelp["lease-count"][0]),
+			HelpDescription: strings.TrimSpace(quotasHelp["lease-count"][1]),
+		},
 	}
 }
 
@@ -360,97 +490,248 @@ func (b *SystemBackend) handleRateLimitQuotasUpdate() framework.OperationFunc {
 			return logical.ErrorResponse("'block' is invalid"), nil
 		}
 
-		rawPath := sanitizePath(d.Get("path").(string))
-		mountPath := rawPath
+		factors, resp, err := b.quotaFactors(ctx, qType, name, d)
+		if resp != nil || err != nil {
+			return resp, err
+		}
 
-		// If the quota creation endpoint is being called from the privileged namespace, we want to prepend the namespace to the path
-		currentNamespace, err := namespace.FromContext(ctx)
+		// If a quota already exists, fetch and update it.
+		quota, err := b.Core.quotaManager.QuotaByName(qType, name)
 		if err != nil {
-			return logical.ErrorResponse(err.Error()), nil
+			return nil, err
+		}
+
+		switch {
+		case quota == nil:
+			quota = quotas.NewRateLimitQuota(name, factors.ns.Path, factors.mountPath, factors.pathSuffix, factors.role, factors.inheritable, interval, blockInterval, rate)
+		default:
+			// Re-inserting the already indexed object in memdb might cause problems.
+			// So, clone the object. See https://github.com/hashicorp/go-memdb/issues/76.
+			clonedQuota := quota.Clone()
+			rlq := clonedQuota.(*quotas.RateLimitQuota)
+			rlq.NamespacePath = factors.ns.Path
+			rlq.MountPath = factors.mountPath
+			rlq.PathSuffix = factors.pathSuffix
+			rlq.Rate = rate
+			rlq.Inheritable = factors.inheritable
+			rlq.Interval = interval
+			rlq.BlockInterval = blockInterval
+			quota = rlq
 		}
-		if currentNamespace.ID != namespace.RootNamespaceID && !strings.HasPrefix(mountPath, currentNamespace.Path) {
-			return logical.ErrorResponse(ErrInvalidQuotaOnParentNs), nil
+		if err := b.Core.quotaManager.SetQuota(ctx, qType, quota, false); err != nil {
+			return nil, err
 		}
 
-		// If there is a quota by the same name that was configured on a parent namespace, prohibit updating this quota
-		if currentNamespace.ID != namespace.RootNamespaceID {
-			quota, err := b.Core.quotaManager.QuotaByName(qType, name)
-			if err != nil {
-				return nil, err
-			}
-			if quota != nil && !strings.HasPrefix(quota.GetNamespacePath(), currentNamespace.Path) {
-				return logical.ErrorResponse(ErrInvalidQuotaUpdate), nil
-			}
+		return nil, nil
+	}
+}
+
+// quotaFactors holds the properties identifying the requests a quota rule
+// applies to.
+type quotaFactors struct {
+	ns          *namespace.Namespace
+	mountPath   string
+	pathSuffix  string
+	role        string
+	inheritable bool
+}
+
+// quotaFactors validates the path, role and inheritable fields of a quota rule
+// update request, and resolves them into the properties of the quota. An error
+// response is returned if the fields are invalid or conflict with an existing
+// quota rule.
+func (b *SystemBackend) quotaFactors(ctx context.Context, qType, name string, d *framework.FieldData) (*quotaFactors, *logical.Response, error) {
+	rawPath := sanitizePath(d.Get("path").(string))
+	mountPath := rawPath
+
+	// If the quota creation endpoint is being called from the privileged namespace, we want to prepend the namespace to the path
+	currentNamespace, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, logical.ErrorResponse(err.Error()), nil
+	}
+	if currentNamespace.ID != namespace.RootNamespaceID && !strings.HasPrefix(mountPath, currentNamespace.Path) {
+		return nil, logical.ErrorResponse(ErrInvalidQuotaOnParentNs), nil
+	}
+
+	// If there is a quota by the same name that was configured on a parent namespace, prohibit updating this quota
+	if currentNamespace.ID != namespace.RootNamespaceID {
+		quota, err := b.Core.quotaManager.QuotaByName(qType, name)
+		if err != nil {
+			return nil, nil, err
 		}
+		if quota != nil && !strings.HasPrefix(quota.GetNamespacePath(), currentNamespace.Path) {
+			return nil, logical.ErrorResponse(ErrInvalidQuotaUpdate), nil
+		}
+	}
 
-		ns := b.Core.namespaceByPath(mountPath)
-		if ns.ID != namespace.RootNamespaceID {
-			mountPath = strings.TrimPrefix(mountPath, ns.Path)
+	ns := b.Core.namespaceByPath(mountPath)
+	if ns.ID != namespace.RootNamespaceID {
+		mountPath = strings.TrimPrefix(mountPath, ns.Path)
+	}
+
+	var pathSuffix string
+	if mountPath != "" {
+		me := b.Core.router.MatchingMountEntry(namespace.ContextWithNamespace(ctx, ns), mountPath)
+		if me == nil {
+			return nil, logical.ErrorResponse("invalid mount path %q", mountPath), nil
 		}
 
-		var pathSuffix string
-		if mountPath != "" {
-			me := b.Core.router.MatchingMountEntry(namespace.ContextWithNamespace(ctx, ns), mountPath)
-			if me == nil {
-				return logical.ErrorResponse("invalid mount path %q", mountPath), nil
-			}
+		mountAPIPath := me.APIPathNoNamespace()
+		pathSuffix = strings.TrimSuffix(strings.TrimPrefix(mountPath, mountAPIPath), "/")
+		mountPath = mountAPIPath
+	}
 
-			mountAPIPath := me.APIPathNoNamespace()
-			pathSuffix = strings.TrimSuffix(strings.TrimPrefix(mountPath, mountAPIPath), "/")
-			mountPath = mountAPIPath
+	role := d.Get("role").(string)
+	// If this is a quota with a role, ensure the backend supports role resolution
+	if role != "" {
+		if pathSuffix != "" {
+			return nil, logical.ErrorResponse("Quotas cannot contain both a path suffix and a role. If a role is provided, path must be a valid auth mount with a concept of roles"), nil
+		}
+		authBackend := b.Core.router.MatchingBackend(namespace.ContextWithNamespace(ctx, ns), mountPath)
+		if authBackend == nil || authBackend.Type() != logical.TypeCredential {
+			return nil, logical.ErrorResponse("Mount path %q is not a valid auth method and therefore unsuitable for use with role-based quotas", mountPath), nil
 		}
+		// We will always error as we aren't supplying real data, but we're looking for "unsupported operation" in particular
+		_, err := authBackend.HandleRequest(ctx, &logical.Request{
+			Path:      "login",
+			Operation: logical.ResolveRoleOperation,
+		})
+		if err != nil && (err == logical.ErrUnsupportedOperation || err == logical.ErrUnsupportedPath) {
+			return nil, logical.ErrorResponse("Mount path %q does not support use with role-based quotas", mountPath), nil
+		}
+	}
 
-		role := d.Get("role").(string)
-		// If this is a quota with a role, ensure the backend supports role resolution
-		if role != "" {
-			if pathSuffix != "" {
-				return logical.ErrorResponse("Quotas cannot contain both a path suffix and a role. If a role is provided, path must be a valid auth mount with a concept of roles"), nil
-			}
-			authBackend := b.Core.router.MatchingBackend(namespace.ContextWithNamespace(ctx, ns), mountPath)
-			if authBackend == nil || authBackend.Type() != logical.TypeCredential {
-				return logical.ErrorResponse("Mount path %q is not a valid auth method and therefore unsuitable for use with role-based quotas", mountPath), nil
-			}
-			// We will always error as we aren't supplying real data, but we're looking for "unsupported operation" in particular
-			_, err := authBackend.HandleRequest(ctx, &logical.Request{
-				Path:      "login",
-				Operation: logical.ResolveRoleOperation,
-			})
-			if err != nil && (err == logical.ErrUnsupportedOperation || err == logical.ErrUnsupportedPath) {
-				return logical.ErrorResponse("Mount path %q does not support use with role-based quotas", mountPath), nil
+	var inheritable bool
+	// All global quotas should be inherited by default
+	if rawPath == "" {
+		inheritable = true
+	}
+
+	if inheritableRaw, ok := d.GetOk("inheritable"); ok {
+		inheritable = inheritableRaw.(bool)
+		if inheritable {
+			if pathSuffix != "" || role != "" || mountPath != "" {
+				return nil, logical.ErrorResponse("only namespace quotas can be configured as inheritable"), nil
 			}
+		} else if rawPath == "" {
+			// User should not try to configure a global quota that cannot be inherited
+			return nil, logical.ErrorResponse("all global quotas must be inheritable"), nil
+		}
+	}
+
+	// User should not try to configure a global quota to be uninheritable
+	if rawPath == "" && !inheritable {
+		return nil, logical.ErrorResponse("all global quotas must be inheritable"), nil
+	}
+
+	// Disallow creation of new quota that has properties similar to an
+	// existing quota.
+	quotaByFactors, err := b.Core.quotaManager.QuotaByFactors(ctx, qType, ns.Path, mountPath, pathSuffix, role)
+	if err != nil {
+		return nil, nil, err
+	}
+	if quotaByFactors != nil && quotaByFactors.QuotaName() != name {
+		return nil, logical.ErrorResponse("quota rule with similar properties exists under the name %q", quotaByFactors.QuotaName()), nil
+	}
+
+	return &quotaFactors{
+		ns:          ns,
+		mountPath:   mountPath,
+		pathSuffix:  pathSuffix,
+		role:        role,
+		inheritable: inheritable,
+	}, nil, nil
+}
+
+func (b *SystemBackend) handleRateLimitQuotasRead() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		name := d.Get("name").(string)
+		qType := quotas.TypeRateLimit.String()
+
+		quota, err := b.Core.quotaManager.QuotaByName(qType, name)
+		if err != nil {
+			return nil, err
+		}
+		if quota == nil {
+			return nil, nil
 		}
 
-		var inheritable bool
-		// All global quotas should be inherited by default
-		if rawPath == "" {
-			inheritable = true
+		rlq := quota.(*quotas.RateLimitQuota)
+
+		nsPath := rlq.NamespacePath
+		if rlq.NamespacePath == "root" {
+			nsPath = ""
 		}
 
-		if inheritableRaw, ok := d.GetOk("inheritable"); ok {
-			inheritable = inheritableRaw.(bool)
-			if inheritable {
-				if pathSuffix != "" || role != "" || mountPath != "" {
-					return logical.ErrorResponse("only namespace quotas can be configured as inheritable"), nil
-				}
-			} else if rawPath == "" {
-				// User should not try to configure a global quota that cannot be inherited
-				return logical.ErrorResponse("all global quotas must be inheritable"), nil
+		data := map[string]interface{}{
+			"type":           qType,
+			"name":           rlq.Name,
+			"path":           nsPath + rlq.MountPath + rlq.PathSuffix,
+			"role":           rlq.Role,
+			"rate":           rlq.Rate,
+			"inheritable":    rlq.Inheritable,
+			"interval":       int(rlq.Interval.Seconds()),
+			"block_interval": int(rlq.BlockInterval.Seconds()),
+		}
+
+		return &logical.Response{
+			Data: data,
+		}, nil
+	}
+}
+
+func (b *SystemBackend) handleRateLimitQuotasDelete() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		name := d.Get("name").(string)
+		qType := quotas.TypeRateLimit.String()
+
+		ns, err := namespace.FromContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if ns.ID != namespace.RootNamespaceID {
+			quota, err := b.Core.quotaManager.QuotaByName(qType, name)
+			if err != nil {
+				return nil, err
+			}
+			if quota != nil && !strings.HasPrefix(quota.GetNamespacePath(), ns.Path) {
+				return logical.ErrorResponse(ErrInvalidQuotaDeletion), nil
 			}
 		}
 
-		// User should not try to configure a global quota to be uninheritable
-		if rawPath == "" && !inheritable {
-			return logical.ErrorResponse("all global quotas must be inheritable"), nil
+		if err := b.Core.quotaManager.DeleteQuota(ctx, qType, name); err != nil {
+			return nil, err
 		}
 
-		// Disallow creation of new quota that has properties similar to an
-		// existing quota.
-		quotaByFactors, err := b.Core.quotaManager.QuotaByFactors(ctx, qType, ns.Path, mountPath, pathSuffix, role)
+		return nil, nil
+	}
+}
+
+func (b *SystemBackend) handleLeaseCountQuotasList() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		names, err := b.Core.quotaManager.QuotaNames(quotas.TypeLeaseCount)
 		if err != nil {
 			return nil, err
 		}
-		if quotaByFactors != nil && quotaByFactors.QuotaName() != name {
-			return logical.ErrorResponse("quota rule with similar properties exists under the name %q", quotaByFactors.QuotaName()), nil
+
+		return logical.ListResponse(names), nil
+	}
+}
+
+func (b *SystemBackend) handleLeaseCountQuotasUpdate() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		name := d.Get("name").(string)
+
+		qType := quotas.TypeLeaseCount.String()
+		maxLeases := d.Get("max_leases").(int)
+		if maxLeases <= 0 {
+			return logical.ErrorResponse("'max_leases' is invalid"), nil
+		}
+		expireOldest := d.Get("expire_oldest").(bool)
+
+		factors, resp, err := b.quotaFactors(ctx, qType, name, d)
+		if resp != nil || err != nil {
+			return resp, err
 		}
 
 		// If a quota already exists, fetch and update it.
@@ -461,20 +742,20 @@ func (b *SystemBackend) handleRateLimitQuotasUpdate() framework.OperationFunc {
 
 		switch {
 		case quota == nil:
-			quota = quotas.NewRateLimitQuota(name, ns.Path, mountPath, pathSuffix, role, inheritable, interval, blockInterval, rate)
+			quota = quotas.NewLeaseCountQuota(name, factors.ns.Path, factors.mountPath, factors.pathSuffix, factors.role, factors.inheritable, maxLeases, expireOldest)
 		default:
 			// Re-inserting the already indexed object in memdb might cause problems.
 			// So, clone the object. See https://github.com/hashicorp/go-memdb/issues/76.
 			clonedQuota := quota.Clone()
-			rlq := clonedQuota.(*quotas.RateLimitQuota)
-			rlq.NamespacePath = ns.Path
-			rlq.MountPath = mountPath
-			rlq.PathSuffix = pathSuffix
-			rlq.Rate = rate
-			rlq.Inheritable = inheritable
-			rlq.Interval = interval
-			rlq.BlockInterval = blockInterval
-			quota = rlq
+			lcq := clonedQuota.(*quotas.LeaseCountQuota)
+			lcq.NamespacePath = factors.ns.Path
+			lcq.MountPath = factors.mountPath
+			lcq.PathSuffix = factors.pathSuffix
+			lcq.Role = factors.role
+			lcq.Inheritable = factors.inheritable
+			lcq.MaxLeases = maxLeases
+			lcq.ExpireOldest = expireOldest
+			quota = lcq
 		}
 		if err := b.Core.quotaManager.SetQuota(ctx, qType, quota, false); err != nil {
 			return nil, err
@@ -484,10 +765,10 @@ func (b *SystemBackend) handleRateLimitQuotasUpdate() framework.OperationFunc {
 	}
 }
 
-func (b *SystemBackend) handleRateLimitQuotasRead() framework.OperationFunc {
+func (b *SystemBackend) handleLeaseCountQuotasRead() framework.OperationFunc {
 	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
 		name := d.Get("name").(string)
-		qType := quotas.TypeRateLimit.String()
+		qType := quotas.TypeLeaseCount.String()
 
 		quota, err := b.Core.quotaManager.QuotaByName(qType, name)
 		if err != nil {
@@ -497,22 +778,22 @@ func (b *SystemBackend) handleRateLimitQuotasRead() framework.OperationFunc {
 			return nil, nil
 		}
 
-		rlq := quota.(*quotas.RateLimitQuota)
+		lcq := quota.(*quotas.LeaseCountQuota)
 
-		nsPath := rlq.NamespacePath
-		if rlq.NamespacePath == "root" {
+		nsPath := lcq.NamespacePath
+		if lcq.NamespacePath == "root" {
 			nsPath = ""
 		}
 
 		data := map[string]interface{}{
-			"type":           qType,
-			"name":           rlq.Name,
-			"path":           nsPath + rlq.MountPath + rlq.PathSuffix,
-			"role":           rlq.Role,
-			"rate":           rlq.Rate,
-			"inheritable":    rlq.Inheritable,
-			"interval":       int(rlq.Interval.Seconds()),
-			"block_interval": int(rlq.BlockInterval.Seconds()),
+			"type":          qType,
+			"name":          lcq.Name,
+			"path":          nsPath + lcq.MountPath + lcq.PathSuffix,
+			"role":          lcq.Role,
+			"max_leases":    lcq.MaxLeases,
+			"counter":       lcq.LeaseCount(),
+			"expire_oldest": lcq.ExpireOldest,
+			"inheritable":   lcq.Inheritable,
 		}
 
 		return &logical.Response{
@@ -521,10 +802,10 @@ func (b *SystemBackend) handleRateLimitQuotasRead() framework.OperationFunc {
 	}
 }
 
-func (b *SystemBackend) handleRateLimitQuotasDelete() framework.OperationFunc {
+func (b *SystemBackend) handleLeaseCountQuotasDelete() framework.OperationFunc {
 	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
 		name := d.Get("name").(string)
-		qType := quotas.TypeRateLimit.String()
+		qType := quotas.TypeLeaseCount.String()
 
 		ns, err := namespace.FromContext(ctx)
 		if err != nil {
@@ -565,4 +846,17 @@ IP address.`,
 		"Lists the names of all the rate limit quotas.",
 		"This list contains quota definitions from all the namespaces.",
 	},
+	"lease-count": {
+		`Get, create or update lease count resource quota for an optional namespace,
+mount or login role.`,
+		`A lease count quota limits the number of leases held at any time by the
+namespace, mount or login role specified by 'path' and 'role'. Requests which
+would create a lease beyond 'max_leases' are rejected, unless 'expire_oldest' is
+set, in which case the oldest lease counted by the quota is expired to make
+room for the new one.`,
+	},
+	"lease-count-list": {
+		"Lists the names of all the lease count quotas.",
+		"This list contains quota definitions from all the namespaces.",
+	},
 }
@@ -21,6 +21,7 @@ import (
 	"github.com/hashicorp/vault/sdk/framework"
 	"github.com/hashicorp/vault/sdk/logical"
 	"github.com/hashicorp/vault/sdk/physical"
+	"github.com/hashicorp/vault/vault/autosnapshot"
 	"github.com/hashicorp/vault/vault/seal"
 	"github.com/mitchellh/mapstructure"
 )
@@ -227,6 +228,163 @@ func (b *SystemBackend) raftStoragePaths() []*framework.Path {
 			HelpSynopsis:    strings.TrimSpace(sysRaftHelp["raft-autopilot-configuration"][0]),
 			HelpDescription: strings.TrimSpace(sysRaftHelp["raft-autopilot-configuration"][1]),
 		},
+		{
+			Pattern: "storage/raft/snapshot-auto/config/?$",
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ListOperation: &framework.PathOperation{
+					Callback: b.handleStorageRaftSnapshotAutoConfigList(),
+					Summary:  "Lists the automated snapshot configurations.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysRaftHelp["raft-snapshot-auto-config-list"][0]),
+			HelpDescription: strings.TrimSpace(sysRaftHelp["raft-snapshot-auto-config-list"][1]),
+		},
+		{
+			Pattern: "storage/raft/snapshot-auto/config/" + framework.GenericNameRegex("name"),
+			Fields: map[string]*framework.FieldSchema{
+				"name": {
+					Type:        framework.TypeString,
+					Description: "Name of the automated snapshot configuration.",
+				},
+				"interval": {
+					Type:        framework.TypeDurationSecond,
+					Description: "Time between snapshots.",
+				},
+				"retain": {
+					Type:        framework.TypeInt,
+					Description: "How many snapshots to keep. Older snapshots are deleted once a new one is written.",
+					Default:     autosnapshot.DefaultRetain,
+				},
+				"path_prefix": {
+					Type:        framework.TypeString,
+					Description: "For storage_type=local, the directory to write the snapshots in. For cloud storage types, the bucket prefix to use.",
+				},
+				"file_prefix": {
+					Type:        framework.TypeString,
+					Description: "The prefix of the names of the snapshot files or objects.",
+					Default:     autosnapshot.DefaultFilePrefix,
+				},
+				"storage_type": {
+					Type:          framework.TypeString,
+					Description:   `Where to write the snapshots. One of "local", "aws-s3" or "google-gcs".`,
+					AllowedValues: []interface{}{autosnapshot.StorageTypeLocal, autosnapshot.StorageTypeAWSS3, autosnapshot.StorageTypeGoogleGCS},
+				},
+				"local_max_space": {
+					Type:        framework.TypeInt64,
+					Description: "For storage_type=local, the maximum space, in bytes, to use for all the snapshots with the given file_prefix.",
+				},
+				"aws_s3_bucket": {
+					Type:        framework.TypeString,
+					Description: "For storage_type=aws-s3, the bucket to write snapshots to.",
+				},
+				"aws_s3_region": {
+					Type:        framework.TypeString,
+					Description: "For storage_type=aws-s3, the region the bucket is in.",
+				},
+				"aws_access_key_id": {
+					Type:        framework.TypeString,
+					Description: "For storage_type=aws-s3, the AWS access key ID.",
+				},
+				"aws_secret_access_key": {
+					Type:        framework.TypeString,
+					Description: "For storage_type=aws-s3, the AWS secret access key.",
+					DisplayAttrs: &framework.DisplayAttributes{
+						Sensitive: true,
+					},
+				},
+				"aws_session_token": {
+					Type:        framework.TypeString,
+					Description: "For storage_type=aws-s3, the AWS session token.",
+					DisplayAttrs: &framework.DisplayAttributes{
+						Sensitive: true,
+					},
+				},
+				"aws_s3_endpoint": {
+					Type:        framework.TypeString,
+					Description: "For storage_type=aws-s3, the S3 endpoint, when using a non-AWS S3 implementation.",
+				},
+				"aws_s3_disable_tls": {
+					Type:        framework.TypeBool,
+					Description: "For storage_type=aws-s3, disable TLS for the S3 endpoint. Should only be used for testing.",
+				},
+				"aws_s3_force_path_style": {
+					Type:        framework.TypeBool,
+					Description: "For storage_type=aws-s3, use the endpoint/bucket URL style instead of bucket.endpoint.",
+				},
+				"aws_s3_enable_kms": {
+					Type:        framework.TypeBool,
+					Description: "For storage_type=aws-s3, use KMS to encrypt the snapshots.",
+				},
+				"aws_s3_server_side_encryption": {
+					Type:        framework.TypeBool,
+					Description: "For storage_type=aws-s3, use AES256 to encrypt the snapshots.",
+				},
+				"aws_s3_kms_key": {
+					Type:        framework.TypeString,
+					Description: "For storage_type=aws-s3, the KMS key to use when aws_s3_enable_kms is set.",
+				},
+				"google_gcs_bucket": {
+					Type:        framework.TypeString,
+					Description: "For storage_type=google-gcs, the bucket to write snapshots to.",
+				},
+				"google_service_account_key": {
+					Type:        framework.TypeString,
+					Description: "For storage_type=google-gcs, the service account key in JSON format.",
+					DisplayAttrs: &framework.DisplayAttributes{
+						Sensitive: true,
+					},
+				},
+				"google_endpoint": {
+					Type:        framework.TypeString,
+					Description: "For storage_type=google-gcs, the GCS endpoint, when using a non-Google GCS implementation.",
+				},
+				"google_disable_tls": {
+					Type:        framework.TypeBool,
+					Description: "For storage_type=google-gcs, disable TLS for the GCS endpoint. Should only be used for testing.",
+				},
+			},
+			ExistenceCheck: b.handleStorageRaftSnapshotAutoConfigExistenceCheck,
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleStorageRaftSnapshotAutoConfigRead(),
+					Summary:  "Reads an automated snapshot configuration.",
+				},
+				logical.CreateOperation: &framework.PathOperation{
+					Callback: b.handleStorageRaftSnapshotAutoConfigUpdate(),
+					Summary:  "Creates an automated snapshot configuration.",
+				},
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleStorageRaftSnapshotAutoConfigUpdate(),
+					Summary:  "Updates an automated snapshot configuration.",
+				},
+				logical.DeleteOperation: &framework.PathOperation{
+					Callback: b.handleStorageRaftSnapshotAutoConfigDelete(),
+					Summary:  "Deletes an automated snapshot configuration.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysRaftHelp["raft-snapshot-auto-config"][0]),
+			HelpDescription: strings.TrimSpace(sysRaftHelp["raft-snapshot-auto-config"][1]),
+		},
+		{
+			Pattern: "storage/raft/snapshot-auto/status/" + framework.GenericNameRegex("name"),
+			Fields: map[string]*framework.FieldSchema{
+				"name": {
+					Type:        framework.TypeString,
+					Description: "Name of the automated snapshot configuration.",
+				},
+			},
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleStorageRaftSnapshotAutoStatusRead(),
+					Summary:  "Reads the status of an automated snapshot configuration.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysRaftHelp["raft-snapshot-auto-status"][0]),
+			HelpDescription: strings.TrimSpace(sysRaftHelp["raft-snapshot-auto-status"][1]),
+		},
 	}
 }
 
@@ -572,6 +730,191 @@ func (b *SystemBackend) handleStorageRaftAutopilotConfigUpdate() framework.Opera
 	}
 }
 
+func (b *SystemBackend) handleStorageRaftSnapshotAutoConfigList() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		if _, ok := b.Core.underlyingPhysical.(*raft.RaftBackend); !ok {
+			return logical.ErrorResponse("raft storage is not in use"), logical.ErrInvalidRequest
+		}
+
+		names, err := b.Core.barrier.List(ctx, raftAutoSnapshotConfigStoragePrefix)
+		if err != nil {
+			return nil, err
+		}
+
+		return logical.ListResponse(names), nil
+	}
+}
+
+func (b *SystemBackend) handleStorageRaftSnapshotAutoConfigExistenceCheck(ctx context.Context, req *logical.Request, d *framework.FieldData) (bool, error) {
+	config, err := b.Core.loadRaftAutoSnapshotConfig(ctx, d.Get("name").(string))
+	if err != nil {
+		return false, err
+	}
+	return config != nil, nil
+}
+
+func (b *SystemBackend) handleStorageRaftSnapshotAutoConfigRead() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		if _, ok := b.Core.underlyingPhysical.(*raft.RaftBackend); !ok {
+			return logical.ErrorResponse("raft storage is not in use"), logical.ErrInvalidRequest
+		}
+
+		config, err := b.Core.loadRaftAutoSnapshotConfig(ctx, d.Get("name").(string))
+		if err != nil {
+			return nil, err
+		}
+		if config == nil {
+			return nil, nil
+		}
+
+		// Credentials are never returned.
+		data := map[string]interface{}{
+			"interval":     int64(config.Interval.Seconds()),
+			"retain":       config.Retain,
+			"path_prefix":  config.PathPrefix,
+			"file_prefix":  config.FilePrefix,
+			"storage_type": config.StorageType,
+		}
+		switch config.StorageType {
+		case autosnapshot.StorageTypeLocal:
+			data["local_max_space"] = config.LocalMaxSpace
+		case autosnapshot.StorageTypeAWSS3:
+			data["aws_s3_bucket"] = config.AWSS3Bucket
+			data["aws_s3_region"] = config.AWSS3Region
+			data["aws_access_key_id"] = config.AWSAccessKeyID
+			data["aws_s3_endpoint"] = config.AWSS3Endpoint
+			data["aws_s3_disable_tls"] = config.AWSS3DisableTLS
+			data["aws_s3_force_path_style"] = config.AWSS3ForcePathStyle
+			data["aws_s3_enable_kms"] = config.AWSS3EnableKMS
+			data["aws_s3_server_side_encryption"] = config.AWSS3ServerSideEncryption
+			data["aws_s3_kms_key"] = config.AWSS3KMSKey
+		case autosnapshot.StorageTypeGoogleGCS:
+			data["google_gcs_bucket"] = config.GoogleGCSBucket
+			data["google_endpoint"] = config.GoogleEndpoint
+			data["google_disable_tls"] = config.GoogleDisableTLS
+		}
+
+		return &logical.Response{
+			Data: data,
+		}, nil
+	}
+}
+
+func (b *SystemBackend) handleStorageRaftSnapshotAutoConfigUpdate() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		if _, ok := b.Core.underlyingPhysical.(*raft.RaftBackend); !ok {
+			return logical.ErrorResponse("raft storage is not in use"), logical.ErrInvalidRequest
+		}
+
+		name := d.Get("name").(string)
+		config, err := b.Core.loadRaftAutoSnapshotConfig(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		if config == nil {
+			config = &autosnapshot.Config{
+				Retain:     d.Get("retain").(int),
+				FilePrefix: d.Get("file_prefix").(string),
+			}
+		}
+
+		if v, ok := d.GetOk("interval"); ok {
+			config.Interval = time.Duration(v.(int)) * time.Second
+		}
+		stringFields := map[string]*string{
+			"path_prefix":                &config.PathPrefix,
+			"file_prefix":                &config.FilePrefix,
+			"storage_type":               &config.StorageType,
+			"aws_s3_bucket":              &config.AWSS3Bucket,
+			"aws_s3_region":              &config.AWSS3Region,
+			"aws_access_key_id":          &config.AWSAccessKeyID,
+			"aws_secret_access_key":      &config.AWSSecretAccessKey,
+			"aws_session_token":          &config.AWSSessionToken,
+			"aws_s3_endpoint":            &config.AWSS3Endpoint,
+			"aws_s3_kms_key":             &config.AWSS3KMSKey,
+			"google_gcs_bucket":          &config.GoogleGCSBucket,
+			"google_service_account_key": &config.GoogleServiceAccountKey,
+			"google_endpoint":            &config.GoogleEndpoint,
+		}
+		for field, value := range stringFields {
+			if v, ok := d.GetOk(field); ok {
+				*value = strings.TrimSpace(v.(string))
+			}
+		}
+		boolFields := map[string]*bool{
+			"aws_s3_disable_tls":            &config.AWSS3DisableTLS,
+			"aws_s3_force_path_style":       &config.AWSS3ForcePathStyle,
+			"aws_s3_enable_kms":             &config.AWSS3EnableKMS,
+			"aws_s3_server_side_encryption": &config.AWSS3ServerSideEncryption,
+			"google_disable_tls":            &config.GoogleDisableTLS,
+		}
+		for field, value := range boolFields {
+			if v, ok := d.GetOk(field); ok {
+				*value = v.(bool)
+			}
+		}
+		if v, ok := d.GetOk("retain"); ok {
+			config.Retain = v.(int)
+		}
+		if v, ok := d.GetOk("local_max_space"); ok {
+			config.LocalMaxSpace = v.(int64)
+		}
+
+		if err := config.Validate(); err != nil {
+			return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+		}
+
+		entry, err := logical.StorageEntryJSON(raftAutoSnapshotConfigStoragePrefix+name, config)
+		if err != nil {
+			return nil, err
+		}
+		if err := b.Core.barrier.Put(ctx, entry); err != nil {
+			return nil, err
+		}
+
+		if m := b.Core.raftAutoSnapshots; m != nil {
+			m.schedule(name, config)
+		}
+
+		return nil, nil
+	}
+}
+
+func (b *SystemBackend) handleStorageRaftSnapshotAutoConfigDelete() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		if _, ok := b.Core.underlyingPhysical.(*raft.RaftBackend); !ok {
+			return logical.ErrorResponse("raft storage is not in use"), logical.ErrInvalidRequest
+		}
+
+		name := d.Get("name").(string)
+		if err := b.Core.barrier.Delete(ctx, raftAutoSnapshotConfigStoragePrefix+name); err != nil {
+			return nil, err
+		}
+
+		if m := b.Core.raftAutoSnapshots; m != nil {
+			m.unschedule(name)
+		}
+
+		return nil, nil
+	}
+}
+
+func (b *SystemBackend) handleStorageRaftSnapshotAutoStatusRead() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		m := b.Core.raftAutoSnapshots
+		if m == nil {
+			return logical.ErrorResponse("raft storage is not in use"), logical.ErrInvalidRequest
+		}
+
+		status := m.status(d.Get("name").(string))
+		if status == nil {
+			return nil, nil
+		}
+
+		return status.response(), nil
+	}
+}
+
 func (b *SystemBackend) handleStorageRaftSnapshotWrite(force bool, makeSealer func() snapshot.Sealer) framework.OperationFunc {
 	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
 		raftStorage, ok := b.Core.underlyingPhysical.(*raft.RaftBackend)
@@ -722,6 +1065,20 @@ var sysRaftHelp = map[string][2]string{
 		"Returns autopilot configuration.",
 		"",
 	},
+	"raft-snapshot-auto-config-list": {
+		"Lists the automated snapshot configurations.",
+		"",
+	},
+	"raft-snapshot-auto-config": {
+		"Manages an automated snapshot configuration.",
+		`Each configuration has an interval controlling how often snapshots are
+taken, a destination where the snapshots are written, as well as a retention
+policy governing when older snapshots get deleted.`,
+	},
+	"raft-snapshot-auto-status": {
+		"Returns the status of an automated snapshot configuration.",
+		"",
+	},
 }
 
 func NewSealAccessSealer(access seal.Access, logger hclog.Logger, use string) snapshot.Sealer {
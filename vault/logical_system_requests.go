@@ -0,0 +1,244 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-secure-stdlib/parseutil"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func (b *SystemBackend) requestsPaths() []*framework.Path {
+	maxDurationsResponse := map[int][]framework.Response{
+		http.StatusOK: {{
+			Description: "OK",
+			Fields: map[string]*framework.FieldSchema{
+				"paths": {
+					Type:     framework.TypeMap,
+					Required: true,
+				},
+			},
+		}},
+	}
+
+	return []*framework.Path{
+		{
+			Pattern: "requests/in-flight/?$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "requests",
+				OperationVerb:   "list",
+				OperationSuffix: "in-flight",
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ListOperation: &framework.PathOperation{
+					Callback: b.handleInFlightRequestsList,
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"keys": {
+									Type:     framework.TypeStringSlice,
+									Required: true,
+								},
+								"key_info": {
+									Type:     framework.TypeMap,
+									Required: true,
+								},
+							},
+						}},
+					},
+					Summary: "List the requests in flight on this node, longest-running first.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["requests-in-flight"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["requests-in-flight"][1]),
+		},
+
+		{
+			Pattern: "requests/in-flight/" + framework.GenericNameRegex("id") + "$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "requests",
+				OperationVerb:   "cancel",
+				OperationSuffix: "in-flight",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"id": {
+					Type:        framework.TypeString,
+					Description: "The ID of the in-flight request.",
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.DeleteOperation: &framework.PathOperation{
+					Callback: b.handleInFlightRequestCancel,
+					Responses: map[int][]framework.Response{
+						http.StatusNoContent: {{
+							Description: "OK",
+						}},
+					},
+					Summary: "Cancel a request in flight on this node.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["requests-in-flight"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["requests-in-flight"][1]),
+		},
+
+		{
+			Pattern: "requests/max-durations$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "requests",
+				OperationSuffix: "max-durations",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"paths": {
+					Type:        framework.TypeMap,
+					Description: "Map of path prefixes, including the namespace path, to the maximum duration of requests to them, such as 30s. The longest matching prefix applies.",
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleRequestMaxDurationsRead,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "read",
+					},
+					Responses: maxDurationsResponse,
+					Summary:   "Read the maximum durations of requests to specific paths.",
+				},
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleRequestMaxDurationsUpdate,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "configure",
+					},
+					Responses: maxDurationsResponse,
+					Summary:   "Configure the maximum durations of requests to specific paths.",
+				},
+				logical.DeleteOperation: &framework.PathOperation{
+					Callback: b.handleRequestMaxDurationsDelete,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "delete",
+					},
+					Responses: map[int][]framework.Response{
+						http.StatusNoContent: {{
+							Description: "OK",
+						}},
+					},
+					Summary: "Remove the maximum durations of requests to specific paths.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["requests-max-durations"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["requests-max-durations"][1]),
+		},
+	}
+}
+
+// handleInFlightRequestsList returns the requests in flight on this node,
+// with the longest-running ones first
+func (b *SystemBackend) handleInFlightRequestsList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	requests := b.Core.LoadInFlightReqData()
+
+	ids := make([]string, 0, len(requests))
+	for id := range requests {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return requests[ids[i]].StartTime.Before(requests[ids[j]].StartTime)
+	})
+
+	now := time.Now()
+	keyInfo := make(map[string]interface{}, len(ids))
+	for _, id := range ids {
+		r := requests[id]
+		keyInfo[id] = map[string]interface{}{
+			"request_path":          r.ReqPath,
+			"request_method":        r.Method,
+			"client_id":             r.ClientID,
+			"client_remote_address": r.ClientRemoteAddr,
+			"start_time":            r.StartTime.Format(time.RFC3339Nano),
+			"duration":              now.Sub(r.StartTime).String(),
+		}
+	}
+
+	return logical.ListResponseWithInfo(ids, keyInfo), nil
+}
+
+// handleInFlightRequestCancel cancels the context of a request in flight on
+// this node, so that the backends handling it give up
+func (b *SystemBackend) handleInFlightRequestCancel(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	id := d.Get("id").(string)
+	if !b.Core.CancelInFlightRequest(id) {
+		return nil, logical.CodedError(http.StatusNotFound, fmt.Sprintf("no request with ID %q is in flight on this node", id))
+	}
+
+	b.Core.Logger().Info("in-flight request cancelled", "request_id", id)
+	return nil, nil
+}
+
+func requestMaxDurationsResponse(durations *RequestMaxDurations) *logical.Response {
+	paths := make(map[string]interface{}, len(durations.Paths))
+	for prefix, duration := range durations.Paths {
+		paths[prefix] = duration.String()
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"paths": paths,
+		},
+	}
+}
+
+// handleRequestMaxDurationsRead returns the per-path maximum request
+// durations
+func (b *SystemBackend) handleRequestMaxDurationsRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	return requestMaxDurationsResponse(b.Core.RequestMaxDurations()), nil
+}
+
+// handleRequestMaxDurationsUpdate replaces the per-path maximum request
+// durations
+func (b *SystemBackend) handleRequestMaxDurationsUpdate(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	durations := &RequestMaxDurations{
+		Paths: make(map[string]time.Duration),
+	}
+	for prefix, raw := range d.Get("paths").(map[string]interface{}) {
+		duration, err := parseutil.ParseDurationSecond(raw)
+		if err != nil {
+			return logical.ErrorResponse(fmt.Sprintf("invalid maximum duration of %q: %s", prefix, err)), logical.ErrInvalidRequest
+		}
+		durations.Paths[prefix] = duration
+	}
+
+	if err := durations.validate(); err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+	if err := b.Core.SetRequestMaxDurations(ctx, durations); err != nil {
+		return nil, err
+	}
+
+	return requestMaxDurationsResponse(durations), nil
+}
+
+// handleRequestMaxDurationsDelete removes the per-path maximum request
+// durations
+func (b *SystemBackend) handleRequestMaxDurationsDelete(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	if err := b.Core.SetRequestMaxDurations(ctx, nil); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
@@ -0,0 +1,160 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSystemBackend_InFlightRequests(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	ctx := namespace.RootContext(nil)
+
+	reqCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c.StoreInFlightReqData("stuck", InFlightReqData{
+		StartTime: time.Now().Add(-time.Hour),
+		ReqPath:   "/v1/database/creds/readonly",
+		Method:    http.MethodGet,
+		Cancel:    cancel,
+	})
+	c.StoreInFlightReqData("recent", InFlightReqData{
+		StartTime: time.Now(),
+		ReqPath:   "/v1/secret/foo",
+		Method:    http.MethodGet,
+	})
+
+	req := logical.TestRequest(t, logical.ListOperation, "sys/requests/in-flight")
+	req.ClientToken = root
+	resp, err := c.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, []string{"stuck", "recent"}, resp.Data["keys"])
+	info := resp.Data["key_info"].(map[string]interface{})["stuck"].(map[string]interface{})
+	require.Equal(t, "/v1/database/creds/readonly", info["request_path"])
+
+	req = logical.TestRequest(t, logical.DeleteOperation, "sys/requests/in-flight/stuck")
+	req.ClientToken = root
+	_, err = c.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.ErrorIs(t, reqCtx.Err(), context.Canceled)
+
+	req = logical.TestRequest(t, logical.DeleteOperation, "sys/requests/in-flight/missing")
+	req.ClientToken = root
+	_, err = c.HandleRequest(ctx, req)
+	var codedErr logical.HTTPCodedError
+	require.ErrorAs(t, err, &codedErr)
+	require.Equal(t, http.StatusNotFound, codedErr.Code())
+}
+
+func TestSystemBackend_RequestMaxDurations(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	ctx := namespace.RootContext(nil)
+
+	handle := func(op logical.Operation, data map[string]interface{}) (*logical.Response, error) {
+		t.Helper()
+		req := logical.TestRequest(t, op, "sys/requests/max-durations")
+		req.ClientToken = root
+		req.Data = data
+		return c.HandleRequest(ctx, req)
+	}
+
+	for name, paths := range map[string]map[string]interface{}{
+		"empty prefix":      {"": "10s"},
+		"zero duration":     {"database/": "0s"},
+		"invalid duration":  {"database/": "soon"},
+		"negative duration": {"database/": "-5s"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := handle(logical.UpdateOperation, map[string]interface{}{"paths": paths})
+			require.Error(t, err)
+		})
+	}
+
+	resp, err := handle(logical.UpdateOperation, map[string]interface{}{
+		"paths": map[string]interface{}{
+			"database/":               "30s",
+			"database/creds/reports/": "5m",
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{
+		"database/":               "30s",
+		"database/creds/reports/": "5m0s",
+	}, resp.Data["paths"])
+
+	for path, expected := range map[string]time.Duration{
+		"database/creds/readonly":       30 * time.Second,
+		"database/creds/reports/weekly": 5 * time.Minute,
+	} {
+		d, ok := c.RequestMaxDuration(path)
+		require.True(t, ok, path)
+		require.Equal(t, expected, d, path)
+	}
+	_, ok := c.RequestMaxDuration("secret/foo")
+	require.False(t, ok)
+
+	// The durations are restored on unseal
+	require.NoError(t, c.setupRequestMaxDurations(ctx))
+	d, ok := c.RequestMaxDuration("database/creds/readonly")
+	require.True(t, ok)
+	require.Equal(t, 30*time.Second, d)
+
+	_, err = handle(logical.DeleteOperation, nil)
+	require.NoError(t, err)
+	require.NoError(t, c.setupRequestMaxDurations(ctx))
+	_, ok = c.RequestMaxDuration("database/creds/readonly")
+	require.False(t, ok)
+}
+
+// TestSystemBackend_RequestMaxDurations_Invalidate checks that durations
+// changed on another node are picked up on invalidation.
+func TestSystemBackend_RequestMaxDurations_Invalidate(t *testing.T) {
+	c, keys, root := TestCoreUnsealed(t)
+	ctx := namespace.RootContext(nil)
+
+	c2, err := NewCore(&CoreConfig{
+		DisableMlock: true,
+		Physical:     c.physical,
+	})
+	require.NoError(t, err)
+	defer c2.Shutdown()
+	for _, key := range keys {
+		_, err := TestCoreUnseal(c2, key)
+		require.NoError(t, err)
+	}
+	require.False(t, c2.Sealed())
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "sys/requests/max-durations")
+	req.ClientToken = root
+	req.Data["paths"] = map[string]interface{}{"database/": "30s"}
+	_, err = c.HandleRequest(ctx, req)
+	require.NoError(t, err)
+
+	// The second core only sees the write once its cache is purged and the
+	// key invalidated, as happens when the change is replicated
+	_, ok := c2.RequestMaxDuration("database/creds/readonly")
+	require.False(t, ok)
+	c2.physicalCache.Purge(ctx)
+	c2.systemBackend.invalidate(ctx, requestMaxDurationsKey)
+	d, ok := c2.RequestMaxDuration("database/creds/readonly")
+	require.True(t, ok)
+	require.Equal(t, 30*time.Second, d)
+
+	req = logical.TestRequest(t, logical.DeleteOperation, "sys/requests/max-durations")
+	req.ClientToken = root
+	_, err = c.HandleRequest(ctx, req)
+	require.NoError(t, err)
+
+	c2.physicalCache.Purge(ctx)
+	c2.systemBackend.invalidate(ctx, requestMaxDurationsKey)
+	_, ok = c2.RequestMaxDuration("database/creds/readonly")
+	require.False(t, ok)
+}
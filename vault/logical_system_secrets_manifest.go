@@ -0,0 +1,316 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/hashicorp/go-secure-stdlib/parseutil"
+	uuid "github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/mitchellh/mapstructure"
+)
+
+const (
+	// maxSecretsManifestItems is the maximum number of items which may be
+	// rendered by a single secrets manifest request.
+	maxSecretsManifestItems = 256
+
+	// maxSecretsManifestValueSize is the maximum size of a rendered value.
+	maxSecretsManifestValueSize = 1024 * 1024
+)
+
+// secretsManifestFanOutPath matches the system endpoints which make requests
+// of their own, such that items requesting them would multiply the requests
+// made by a single manifest. The endpoints are matched under any namespace
+// prefix, such as ns1/sys/kv-export, since the namespace of an item is only
+// resolved once it is requested.
+var secretsManifestFanOutPath = regexp.MustCompile(`^(?:[^/]+/)*sys/(secrets-manifest|kv-export|mount-blueprints/[^/]+/instantiate|broker/sessions/[^/]+/creds)`)
+
+// secretsManifestItem is a reference to a secret in a secrets manifest, along
+// with how to render its value.
+type secretsManifestItem struct {
+	Name     string                 `mapstructure:"name"`
+	Path     string                 `mapstructure:"path"`
+	Method   string                 `mapstructure:"method"`
+	Args     map[string]interface{} `mapstructure:"args"`
+	Key      string                 `mapstructure:"key"`
+	Template string                 `mapstructure:"template"`
+}
+
+// secretsManifestTemplateData is the data available to the template of a
+// secrets manifest item.
+type secretsManifestTemplateData struct {
+	Data     map[string]interface{}
+	Metadata map[string]interface{}
+	Version  int64
+	LeaseID  string
+}
+
+func (b *SystemBackend) secretsManifestPath() *framework.Path {
+	return &framework.Path{
+		Pattern: "secrets-manifest$",
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationVerb:   "render",
+			OperationSuffix: "secrets-manifest",
+		},
+
+		Fields: map[string]*framework.FieldSchema{
+			"items": {
+				Type:        framework.TypeSlice,
+				Description: "List of secrets to render. Each item has a unique \"name\", the \"path\" of the secret, and optionally the \"method\" (GET, PUT or POST) and \"args\" used to request it, and either the \"key\" of the secret's data or a \"template\" to render its value with.",
+				Required:    true,
+			},
+		},
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.UpdateOperation: &framework.PathOperation{
+				Callback: b.handleSecretsManifest,
+				Summary:  "Render the values of a manifest of secrets in a single request.",
+				Responses: map[int][]framework.Response{
+					http.StatusOK: {{
+						Description: "OK",
+						Fields: map[string]*framework.FieldSchema{
+							"items": {
+								Type:     framework.TypeSlice,
+								Required: true,
+							},
+						},
+					}},
+				},
+			},
+		},
+
+		HelpSynopsis:    strings.TrimSpace(sysHelp["secrets-manifest"][0]),
+		HelpDescription: strings.TrimSpace(sysHelp["secrets-manifest"][1]),
+	}
+}
+
+// handleSecretsManifest requests each secret of the manifest on behalf of the
+// client, so that each is subject to the client's policies and audited as
+// usual. A failure to render an item is returned as that item's error rather
+// than failing the whole request. Since every item is its own request, each
+// consumes a use of a token with limited uses; a manifest with more items than
+// the token has uses left is refused before any item is requested, rather than
+// failing partway through.
+func (b *SystemBackend) handleSecretsManifest(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	var items []*secretsManifestItem
+	if err := mapstructure.Decode(d.Get("items"), &items); err != nil {
+		return logical.ErrorResponse("invalid items: %s", err), nil
+	}
+	if len(items) == 0 {
+		return logical.ErrorResponse("at least one item must be given"), nil
+	}
+	if len(items) > maxSecretsManifestItems {
+		return logical.ErrorResponse("at most %d items may be given", maxSecretsManifestItems), nil
+	}
+
+	names := make(map[string]struct{}, len(items))
+	for i, item := range items {
+		if item == nil {
+			return logical.ErrorResponse("item %d is empty", i), nil
+		}
+		if err := item.validate(); err != nil {
+			return logical.ErrorResponse("invalid item %d: %s", i, err), nil
+		}
+		if _, ok := names[item.Name]; ok {
+			return logical.ErrorResponse("item name %q is not unique", item.Name), nil
+		}
+		names[item.Name] = struct{}{}
+	}
+
+	te, err := b.Core.tokenStore.Lookup(ctx, req.ClientToken)
+	if err != nil {
+		return nil, err
+	}
+	if te == nil {
+		return logical.ErrorResponse("token has no uses left to request the items with"), logical.ErrPermissionDenied
+	}
+	if te.NumUses > 0 && te.NumUses < len(items) {
+		return logical.ErrorResponse("token has %d uses left, but %d items were given", te.NumUses, len(items)), logical.ErrPermissionDenied
+	}
+
+	results := make([]map[string]interface{}, 0, len(items))
+	for _, item := range items {
+		result, err := b.renderSecretsManifestItem(ctx, req, item)
+		if err != nil {
+			result = map[string]interface{}{
+				"name":  item.Name,
+				"error": err.Error(),
+			}
+		}
+		results = append(results, result)
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"items": results,
+		},
+	}, nil
+}
+
+func (i *secretsManifestItem) validate() error {
+	i.Path = strings.TrimPrefix(strings.TrimSpace(i.Path), "/")
+	i.Method = strings.ToUpper(strings.TrimSpace(i.Method))
+
+	switch {
+	case i.Name == "":
+		return errors.New("name must be given")
+	case i.Path == "":
+		return errors.New("path must be given")
+	case secretsManifestFanOutPath.MatchString(i.Path):
+		return errors.New("path must not be an endpoint making requests of its own")
+	case i.Key != "" && i.Template != "":
+		return errors.New("only one of key or template may be given")
+	}
+
+	switch i.Method {
+	case "", http.MethodGet:
+		if len(i.Args) > 0 {
+			return errors.New("args may only be given with the PUT or POST methods")
+		}
+	case http.MethodPut, http.MethodPost:
+	default:
+		return fmt.Errorf("unsupported method %q", i.Method)
+	}
+
+	return nil
+}
+
+func (b *SystemBackend) renderSecretsManifestItem(ctx context.Context, req *logical.Request, item *secretsManifestItem) (map[string]interface{}, error) {
+	var op logical.Operation = logical.ReadOperation
+	if item.Method == http.MethodPut || item.Method == http.MethodPost {
+		op = logical.UpdateOperation
+	}
+
+	id, err := uuid.GenerateUUID()
+	if err != nil {
+		return nil, err
+	}
+	itemReq := &logical.Request{
+		ID:          id,
+		Operation:   op,
+		Path:        item.Path,
+		Data:        item.Args,
+		ClientToken: req.ClientToken,
+		Connection:  req.Connection,
+	}
+
+	resp, err := b.Core.handleSubrequest(ctx, itemReq)
+	switch {
+	case err != nil:
+		return nil, err
+	case resp != nil && resp.IsError():
+		return nil, resp.Error()
+	case resp == nil || resp.Data == nil:
+		return nil, fmt.Errorf("no secret found at %q", item.Path)
+	}
+
+	tmplData := &secretsManifestTemplateData{
+		Data: resp.Data,
+	}
+	if resp.Secret != nil {
+		tmplData.LeaseID = resp.Secret.LeaseID
+	}
+
+	// Values of KV version 2 secrets are nested within their metadata
+	if me := b.Core.router.MatchingMountEntry(ctx, item.Path); me != nil && isKVv2Mount(me) {
+		if data, ok := resp.Data["data"].(map[string]interface{}); ok {
+			tmplData.Data = data
+		}
+		if metadata, ok := resp.Data["metadata"].(map[string]interface{}); ok {
+			tmplData.Metadata = metadata
+			if version, err := parseutil.ParseInt(metadata["version"]); err == nil {
+				tmplData.Version = version
+			}
+		}
+	}
+
+	value, err := item.render(tmplData)
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[string]interface{}{
+		"name":  item.Name,
+		"value": value,
+	}
+	if tmplData.Version != 0 {
+		result["version"] = tmplData.Version
+	}
+	if resp.Secret != nil && resp.Secret.LeaseID != "" {
+		result["lease_id"] = resp.Secret.LeaseID
+		result["lease_duration"] = int64(resp.Secret.TTL.Seconds())
+		result["renewable"] = resp.Secret.Renewable
+	}
+
+	return result, nil
+}
+
+// render returns the value of the item: the rendered template if one was
+// given, otherwise the value of the given key, otherwise all of the secret's
+// data encoded as JSON.
+func (i *secretsManifestItem) render(data *secretsManifestTemplateData) (string, error) {
+	switch {
+	case i.Template != "":
+		tmpl, err := template.New(i.Name).Option("missingkey=error").Parse(i.Template)
+		if err != nil {
+			return "", fmt.Errorf("error parsing template: %w", err)
+		}
+		var out limitedBuffer
+		out.limit = maxSecretsManifestValueSize
+		if err := tmpl.Execute(&out, data); err != nil {
+			return "", fmt.Errorf("error rendering template: %w", err)
+		}
+		return out.String(), nil
+
+	case i.Key != "":
+		value, ok := data.Data[i.Key]
+		if !ok {
+			return "", fmt.Errorf("key %q not found in secret", i.Key)
+		}
+		if s, ok := value.(string); ok {
+			return s, nil
+		}
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return "", fmt.Errorf("error encoding value of key %q: %w", i.Key, err)
+		}
+		return string(encoded), nil
+
+	default:
+		encoded, err := json.Marshal(data.Data)
+		if err != nil {
+			return "", fmt.Errorf("error encoding secret: %w", err)
+		}
+		return string(encoded), nil
+	}
+}
+
+func isKVv2Mount(me *MountEntry) bool {
+	return (me.Type == "kv" || me.Type == "generic") && me.Options["version"] == "2"
+}
+
+// limitedBuffer is a bytes.Buffer which fails writes beyond its limit.
+type limitedBuffer struct {
+	bytes.Buffer
+	limit int
+}
+
+func (l *limitedBuffer) Write(p []byte) (int, error) {
+	if l.Len()+len(p) > l.limit {
+		return 0, fmt.Errorf("rendered value exceeds %d bytes", l.limit)
+	}
+	return l.Buffer.Write(p)
+}
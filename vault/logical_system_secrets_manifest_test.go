@@ -0,0 +1,225 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	logicalKv "github.com/hashicorp/vault-plugin-secrets-kv"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSystemBackend_SecretsManifest verifies that the items of a secrets
+// manifest are rendered according to the client's policies, and that errors
+// are reported per item.
+func TestSystemBackend_SecretsManifest(t *testing.T) {
+	c, _, root := TestCoreUnsealedWithConfig(t, &CoreConfig{
+		LogicalBackends: map[string]logical.Factory{
+			"kv": logicalKv.Factory,
+		},
+	})
+	ctx := namespace.RootContext(nil)
+
+	require.NoError(t, c.mount(ctx, &MountEntry{
+		Table:   mountTableType,
+		Path:    "kv2/",
+		Type:    "kv",
+		Options: map[string]string{"version": "2"},
+	}))
+
+	// Write two versions of the secret, retrying while the mount is upgraded
+	for _, password := range []string{"old", "new"} {
+		require.Eventually(t, func() bool {
+			req := logical.TestRequest(t, logical.UpdateOperation, "kv2/data/app")
+			req.ClientToken = root
+			req.Data = map[string]interface{}{
+				"data": map[string]interface{}{
+					"username": "app",
+					"password": password,
+				},
+			}
+			resp, err := c.HandleRequest(ctx, req)
+			return err == nil && !resp.IsError()
+		}, 10*time.Second, 50*time.Millisecond)
+	}
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "sys/policy/manifest")
+	req.ClientToken = root
+	req.Data["policy"] = `
+path "kv2/data/app" {
+	capabilities = ["read"]
+}
+path "sys/secrets-manifest" {
+	capabilities = ["update"]
+}`
+	resp, err := c.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.False(t, resp.IsError(), resp)
+
+	req = logical.TestRequest(t, logical.UpdateOperation, "auth/token/create")
+	req.ClientToken = root
+	req.Data["policies"] = []string{"manifest"}
+	resp, err = c.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.False(t, resp.IsError(), resp)
+	token := resp.Auth.ClientToken
+
+	req = logical.TestRequest(t, logical.UpdateOperation, "sys/secrets-manifest")
+	req.ClientToken = token
+	req.Data["items"] = []interface{}{
+		map[string]interface{}{"name": "password", "path": "kv2/data/app", "key": "password"},
+		map[string]interface{}{"name": "dsn", "path": "kv2/data/app", "template": "{{ .Data.username }}:{{ .Data.password }}@v{{ .Version }}"},
+		map[string]interface{}{"name": "all", "path": "/kv2/data/app"},
+		map[string]interface{}{"name": "denied", "path": "kv2/data/other"},
+		map[string]interface{}{"name": "missing-key", "path": "kv2/data/app", "key": "nope"},
+	}
+	resp, err = c.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.False(t, resp.IsError(), resp)
+
+	items := resp.Data["items"].([]map[string]interface{})
+	require.Len(t, items, 5)
+
+	require.Equal(t, map[string]interface{}{"name": "password", "value": "new", "version": int64(2)}, items[0])
+	require.Equal(t, map[string]interface{}{"name": "dsn", "value": "app:new@v2", "version": int64(2)}, items[1])
+	require.JSONEq(t, `{"username":"app","password":"new"}`, items[2]["value"].(string))
+
+	require.Equal(t, "denied", items[3]["name"])
+	require.Contains(t, items[3]["error"], "permission denied")
+	require.NotContains(t, items[3], "value")
+
+	require.Equal(t, "missing-key", items[4]["name"])
+	require.Contains(t, items[4]["error"], `key "nope" not found`)
+}
+
+func TestSystemBackend_SecretsManifest_Invalid(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	ctx := namespace.RootContext(nil)
+
+	for name, items := range map[string][]interface{}{
+		"no items":         {},
+		"missing name":     {map[string]interface{}{"path": "secret/foo"}},
+		"missing path":     {map[string]interface{}{"name": "foo"}},
+		"duplicate name":   {map[string]interface{}{"name": "foo", "path": "secret/foo"}, map[string]interface{}{"name": "foo", "path": "secret/bar"}},
+		"key and template": {map[string]interface{}{"name": "foo", "path": "secret/foo", "key": "a", "template": "{{ .Data.a }}"}},
+		"bad method":       {map[string]interface{}{"name": "foo", "path": "secret/foo", "method": "DELETE"}},
+		"args with GET":    {map[string]interface{}{"name": "foo", "path": "secret/foo", "args": map[string]interface{}{"a": "b"}}},
+		"recursive":        {map[string]interface{}{"name": "foo", "path": "sys/secrets-manifest"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			req := logical.TestRequest(t, logical.UpdateOperation, "sys/secrets-manifest")
+			req.ClientToken = root
+			req.Data["items"] = items
+			resp, _ := c.HandleRequest(ctx, req)
+			require.True(t, resp.IsError())
+		})
+	}
+
+	// Endpoints which fan out into requests of their own are rejected
+	for _, path := range []string{
+		"sys/secrets-manifest",
+		"sys/kv-export",
+		"/sys/mount-blueprints/team-kv/instantiate",
+		"sys/broker/sessions/abc/creds",
+		"ns1/sys/kv-export",
+		"ns1/ns2/sys/secrets-manifest",
+		"ns1/sys/broker/sessions/abc/creds",
+	} {
+		item := &secretsManifestItem{Name: "foo", Path: path, Method: http.MethodPost}
+		require.ErrorContains(t, item.validate(), "requests of its own", path)
+	}
+	for _, path := range []string{"sys/mount-blueprints/team-kv", "ns1/sys/broker/sessions/abc", "secret/sys"} {
+		item := &secretsManifestItem{Name: "foo", Path: path, Method: http.MethodPost}
+		require.NoError(t, item.validate(), path)
+	}
+}
+
+// TestSystemBackend_SecretsManifest_RateLimit verifies that the rate limit
+// quotas of the paths of the items apply to them.
+func TestSystemBackend_SecretsManifest_RateLimit(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	ctx := namespace.RootContext(nil)
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "secret/app")
+	req.ClientToken = root
+	req.Data["password"] = "hunter2"
+	_, err := c.HandleRequest(ctx, req)
+	require.NoError(t, err)
+
+	req = logical.TestRequest(t, logical.UpdateOperation, "sys/quotas/rate-limit/secret")
+	req.ClientToken = root
+	req.Data = map[string]interface{}{
+		"path":     "secret/",
+		"rate":     1,
+		"interval": "1h",
+	}
+	_, err = c.HandleRequest(ctx, req)
+	require.NoError(t, err)
+
+	req = logical.TestRequest(t, logical.UpdateOperation, "sys/secrets-manifest")
+	req.ClientToken = root
+	req.Connection = &logical.Connection{RemoteAddr: "127.0.0.1"}
+	req.Data["items"] = []interface{}{
+		map[string]interface{}{"name": "first", "path": "secret/app", "key": "password"},
+		map[string]interface{}{"name": "second", "path": "secret/app", "key": "password"},
+	}
+	resp, err := c.HandleRequest(ctx, req)
+	require.NoError(t, err)
+
+	items := resp.Data["items"].([]map[string]interface{})
+	require.Equal(t, "hunter2", items[0]["value"])
+	require.Contains(t, items[1]["error"], "rate limit quota exceeded")
+}
+
+// TestSystemBackend_SecretsManifest_NumUses verifies that a manifest with more
+// items than the client's token has uses left is refused before any item is
+// requested.
+func TestSystemBackend_SecretsManifest_NumUses(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	ctx := namespace.RootContext(nil)
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "secret/app")
+	req.ClientToken = root
+	req.Data["password"] = "hunter2"
+	_, err := c.HandleRequest(ctx, req)
+	require.NoError(t, err)
+
+	req = logical.TestRequest(t, logical.UpdateOperation, "auth/token/create")
+	req.ClientToken = root
+	req.Data["num_uses"] = 4
+	resp, err := c.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.False(t, resp.IsError(), resp)
+	token := resp.Auth.ClientToken
+
+	item := func(name string) interface{} {
+		return map[string]interface{}{"name": name, "path": "secret/app", "key": "password"}
+	}
+
+	// The manifest request itself uses the token once, leaving three uses
+	req = logical.TestRequest(t, logical.UpdateOperation, "sys/secrets-manifest")
+	req.ClientToken = token
+	req.Data["items"] = []interface{}{item("a"), item("b"), item("c"), item("d")}
+	resp, err = c.HandleRequest(ctx, req)
+	require.ErrorIs(t, err, logical.ErrPermissionDenied)
+	require.Contains(t, resp.Error().Error(), "3 uses left")
+
+	// Two uses are left for the items after this request
+	req = logical.TestRequest(t, logical.UpdateOperation, "sys/secrets-manifest")
+	req.ClientToken = token
+	req.Data["items"] = []interface{}{item("a"), item("b")}
+	resp, err = c.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	items := resp.Data["items"].([]map[string]interface{})
+	require.Equal(t, "hunter2", items[0]["value"])
+	require.Equal(t, "hunter2", items[1]["value"])
+
+	te, err := c.tokenStore.Lookup(ctx, token)
+	require.NoError(t, err)
+	require.Nil(t, te)
+}
@@ -45,6 +45,7 @@ import (
 	"github.com/hashicorp/vault/sdk/helper/logging"
 	"github.com/hashicorp/vault/sdk/helper/pluginruntimeutil"
 	"github.com/hashicorp/vault/sdk/helper/pluginutil"
+	"github.com/hashicorp/vault/sdk/helper/salt"
 	"github.com/hashicorp/vault/sdk/helper/testhelpers/schema"
 	"github.com/hashicorp/vault/sdk/logical"
 	"github.com/hashicorp/vault/vault/plugincatalog"
@@ -1456,6 +1457,70 @@ func TestSystemBackend_leases(t *testing.T) {
 	}
 }
 
+func TestSystemBackend_leases_clients(t *testing.T) {
+	coreConfig := &CoreConfig{
+		LogicalBackends: map[string]logical.Factory{
+			"kv": LeasedPassthroughBackendFactory,
+		},
+	}
+	core, _, root := TestCoreUnsealedWithConfig(t, coreConfig)
+	b := core.systemBackend
+	ctx := namespace.RootContext(nil)
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "secret/foo")
+	req.Data["foo"] = "bar"
+	req.ClientToken = root
+	_, err := core.HandleRequest(ctx, req)
+	require.NoError(t, err)
+
+	// Leases record the client that created them
+	var leaseID string
+	for _, clientName := range []string{"billing-service", "billing-service", ""} {
+		req = logical.TestRequest(t, logical.ReadOperation, "secret/foo")
+		req.ClientToken = root
+		req.ClientName = clientName
+		req.ClientVersion = "1.4.2"
+		resp, err := core.HandleRequest(ctx, req)
+		require.NoError(t, err)
+		require.NotNil(t, resp.Secret)
+		leaseID = resp.Secret.LeaseID
+	}
+
+	req = logical.TestRequest(t, logical.UpdateOperation, "auth/token/create")
+	req.Data["ttl"] = "1h"
+	req.ClientToken = root
+	req.ClientName = "reporting"
+	resp, err := core.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"client_name": "reporting"}, resp.Auth.Metadata)
+
+	te, err := core.LookupToken(ctx, resp.Auth.ClientToken)
+	require.NoError(t, err)
+	require.Equal(t, "reporting", te.Meta["client_name"])
+
+	req = logical.TestRequest(t, logical.UpdateOperation, "leases/lookup")
+	req.Data["lease_id"] = leaseID
+	resp, err = b.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.NotContains(t, resp.Data, "client_name")
+	require.Equal(t, "1.4.2", resp.Data["client_version"])
+
+	req = logical.TestRequest(t, logical.ReadOperation, "leases/clients")
+	resp, err = b.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	schema.ValidateResponse(
+		t,
+		schema.GetResponseSchema(t, b.Route(req.Path), req.Operation),
+		resp,
+		true,
+	)
+	require.Equal(t, 3, resp.Data["lease_count"])
+	require.Equal(t, map[string]map[string]int{
+		"billing-service": {"1.4.2": 2},
+		"reporting":       {"": 1},
+	}, resp.Data["clients"])
+}
+
 func TestSystemBackend_leases_list(t *testing.T) {
 	coreConfig := &CoreConfig{
 		LogicalBackends: map[string]logical.Factory{
@@ -2939,6 +3004,44 @@ func TestSystemBackend_auditHash(t *testing.T) {
 	}
 }
 
+// TestSystemBackend_auditHashRotateInvalidate verifies that the salt of an
+// audit device, rotated by another node, is reloaded once the record of the
+// rotation is invalidated.
+func TestSystemBackend_auditHashRotateInvalidate(t *testing.T) {
+	c, b, _ := testCoreSystemBackend(t)
+	ctx := namespace.RootContext(nil)
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "audit/foo")
+	req.Data = map[string]any{
+		"type": audit.TypeFile,
+		"options": map[string]string{
+			"file_path": "discard",
+		},
+	}
+	_, err := b.HandleRequest(ctx, req)
+	require.NoError(t, err)
+
+	hash := func() string {
+		t.Helper()
+		req := logical.TestRequest(t, logical.UpdateOperation, "audit-hash/foo")
+		req.Data["input"] = "bar"
+		resp, err := b.HandleRequest(ctx, req)
+		require.NoError(t, err)
+		return resp.Data["hash"].(string)
+	}
+	before := hash()
+
+	// Replace the salt in storage, as a rotation on another node would
+	entry, err := c.audit.find(ctx, "foo/")
+	require.NoError(t, err)
+	view := NewBarrierView(c.barrier, auditBarrierPrefix+entry.UUID+"/")
+	require.NoError(t, view.Put(ctx, &logical.StorageEntry{Key: salt.DefaultLocation, Value: []byte("rotated")}))
+	require.Equal(t, before, hash())
+
+	b.(*SystemBackend).invalidate(ctx, auditSaltRotationSubPath+"foo")
+	require.NotEqual(t, before, hash())
+}
+
 func TestSystemBackend_enableAudit_invalid(t *testing.T) {
 	b := testSystemBackend(t)
 	req := logical.TestRequest(t, logical.UpdateOperation, "audit/foo")
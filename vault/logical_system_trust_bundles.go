@@ -0,0 +1,563 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/hashicorp/go-secure-stdlib/strutil"
+	"github.com/hashicorp/vault/helper/pkcs7"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	trustBundleStoragePrefix = "trust-bundles/"
+
+	trustBundleFormatJSON = "json"
+	trustBundleFormatCMS  = "cms"
+
+	// trustBundleSigningCertValidity is the validity of the self-signed
+	// certificate of the key signing a trust bundle.
+	trustBundleSigningCertValidity = 10 * 365 * 24 * time.Hour
+)
+
+// trustBundle is the configuration of a trust bundle: the mounts whose CAs it
+// aggregates, and the key signing it.
+type trustBundle struct {
+	Name               string   `json:"name"`
+	PKIMounts          []string `json:"pki_mounts"`
+	SSHMounts          []string `json:"ssh_mounts"`
+	SigningKey         []byte   `json:"signing_key"`
+	SigningCertificate []byte   `json:"signing_certificate"`
+}
+
+// trustBundleDocument is the document signed and served as a trust bundle.
+type trustBundleDocument struct {
+	Name     string                 `json:"name"`
+	IssuedAt time.Time              `json:"issued_at"`
+	PKI      []*trustBundlePKIEntry `json:"pki"`
+	SSH      []*trustBundleSSHEntry `json:"ssh"`
+}
+
+type trustBundlePKIEntry struct {
+	Mount   string   `json:"mount"`
+	CAChain []string `json:"ca_chain"`
+}
+
+type trustBundleSSHEntry struct {
+	Mount     string `json:"mount"`
+	PublicKey string `json:"public_key"`
+}
+
+func (b *SystemBackend) trustBundlePaths() []*framework.Path {
+	return []*framework.Path{
+		{
+			Pattern: "trust-bundles/?$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "trust-bundles",
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ListOperation: &framework.PathOperation{
+					Callback: b.handleTrustBundlesList,
+					Summary:  "List the configured trust bundles.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["trust-bundles"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["trust-bundles"][1]),
+		},
+
+		{
+			Pattern: "trust-bundles/" + framework.GenericNameRegex("name") + "/bundle$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "trust-bundles",
+				OperationSuffix: "bundle",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"name": {
+					Type:        framework.TypeString,
+					Description: "The name of the trust bundle.",
+				},
+				"format": {
+					Type:        framework.TypeString,
+					Description: `The format of the signed bundle: "json" for a JWS signing the JSON document, or "cms" for a base64-encoded CMS SignedData wrapping it.`,
+					Default:     trustBundleFormatJSON,
+					Query:       true,
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleTrustBundleFetch,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "read",
+					},
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"document": {
+									Type:     framework.TypeMap,
+									Required: true,
+								},
+								"bundle": {
+									Type:     framework.TypeString,
+									Required: true,
+								},
+								"format": {
+									Type:     framework.TypeString,
+									Required: true,
+								},
+								"signing_certificate": {
+									Type:     framework.TypeString,
+									Required: true,
+								},
+							},
+						}},
+					},
+					Summary: "Build and sign the trust bundle from the current CAs of its mounts.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["trust-bundles"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["trust-bundles"][1]),
+		},
+
+		{
+			Pattern: "trust-bundles/" + framework.GenericNameRegex("name"),
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "trust-bundles",
+				OperationSuffix: "trust-bundle",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"name": {
+					Type:        framework.TypeString,
+					Description: "The name of the trust bundle.",
+				},
+				"pki_mounts": {
+					Type:        framework.TypeCommaStringSlice,
+					Description: "Paths of the PKI mounts whose CA chains the bundle includes.",
+				},
+				"ssh_mounts": {
+					Type:        framework.TypeCommaStringSlice,
+					Description: "Paths of the SSH mounts whose CA public keys the bundle includes.",
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleTrustBundleSet,
+					Responses: map[int][]framework.Response{
+						http.StatusNoContent: {{
+							Description: "OK",
+						}},
+					},
+					Summary: "Add a new or update an existing trust bundle.",
+				},
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleTrustBundleRead,
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"name": {
+									Type:     framework.TypeString,
+									Required: true,
+								},
+								"pki_mounts": {
+									Type:     framework.TypeCommaStringSlice,
+									Required: true,
+								},
+								"ssh_mounts": {
+									Type:     framework.TypeCommaStringSlice,
+									Required: true,
+								},
+								"signing_certificate": {
+									Type:     framework.TypeString,
+									Required: true,
+								},
+							},
+						}},
+					},
+					Summary: "Read the configuration of a trust bundle.",
+				},
+				logical.DeleteOperation: &framework.PathOperation{
+					Callback: b.handleTrustBundleDelete,
+					Responses: map[int][]framework.Response{
+						http.StatusNoContent: {{
+							Description: "OK",
+						}},
+					},
+					Summary: "Delete a trust bundle.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["trust-bundles"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["trust-bundles"][1]),
+		},
+	}
+}
+
+// handleTrustBundlesList returns the names of the trust bundles
+func (b *SystemBackend) handleTrustBundlesList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	names, err := req.Storage.List(ctx, trustBundleStoragePrefix)
+	if err != nil {
+		return nil, err
+	}
+	return logical.ListResponse(names), nil
+}
+
+// handleTrustBundleSet creates or updates a trust bundle. The key signing the
+// bundle is generated when the bundle is created, and kept across updates so
+// that clients can pin its certificate.
+func (b *SystemBackend) handleTrustBundleSet(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	bundle, err := b.trustBundle(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if bundle == nil {
+		bundle = &trustBundle{
+			Name: name,
+		}
+		if err := bundle.generateSigningKey(); err != nil {
+			return nil, err
+		}
+	}
+
+	if mounts, ok := d.GetOk("pki_mounts"); ok {
+		bundle.PKIMounts = mounts.([]string)
+	}
+	if mounts, ok := d.GetOk("ssh_mounts"); ok {
+		bundle.SSHMounts = mounts.([]string)
+	}
+	bundle.PKIMounts = strutil.RemoveDuplicates(sanitizeTrustBundleMounts(bundle.PKIMounts), false)
+	bundle.SSHMounts = strutil.RemoveDuplicates(sanitizeTrustBundleMounts(bundle.SSHMounts), false)
+
+	if len(bundle.PKIMounts) == 0 && len(bundle.SSHMounts) == 0 {
+		return logical.ErrorResponse("at least one of pki_mounts and ssh_mounts must be set"), logical.ErrInvalidRequest
+	}
+	for _, mount := range bundle.PKIMounts {
+		if err := b.checkTrustBundleMount(ctx, mount, "pki"); err != nil {
+			return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+		}
+	}
+	for _, mount := range bundle.SSHMounts {
+		if err := b.checkTrustBundleMount(ctx, mount, "ssh"); err != nil {
+			return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+		}
+	}
+
+	entry, err := logical.StorageEntryJSON(trustBundleStoragePrefix+name, bundle)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+// handleTrustBundleRead returns the configuration of a trust bundle
+func (b *SystemBackend) handleTrustBundleRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	bundle, err := b.trustBundle(ctx, req.Storage, d.Get("name").(string))
+	if err != nil {
+		return nil, err
+	}
+	if bundle == nil {
+		return nil, nil
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"name":                bundle.Name,
+			"pki_mounts":          bundle.PKIMounts,
+			"ssh_mounts":          bundle.SSHMounts,
+			"signing_certificate": bundle.signingCertificatePEM(),
+		},
+	}, nil
+}
+
+// handleTrustBundleDelete deletes a trust bundle
+func (b *SystemBackend) handleTrustBundleDelete(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	if err := req.Storage.Delete(ctx, trustBundleStoragePrefix+d.Get("name").(string)); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+// handleTrustBundleFetch builds the trust bundle document from the current CA
+// chains and public keys of its mounts, and returns it signed
+func (b *SystemBackend) handleTrustBundleFetch(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	format := d.Get("format").(string)
+	switch format {
+	case trustBundleFormatJSON, trustBundleFormatCMS:
+	default:
+		return logical.ErrorResponse("unsupported format %q", format), logical.ErrInvalidRequest
+	}
+
+	bundle, err := b.trustBundle(ctx, req.Storage, d.Get("name").(string))
+	if err != nil {
+		return nil, err
+	}
+	if bundle == nil {
+		return nil, nil
+	}
+
+	doc := &trustBundleDocument{
+		Name:     bundle.Name,
+		IssuedAt: time.Now().UTC().Truncate(time.Second),
+		PKI:      []*trustBundlePKIEntry{},
+		SSH:      []*trustBundleSSHEntry{},
+	}
+	for _, mount := range bundle.PKIMounts {
+		resp, err := b.readTrustBundleMount(ctx, mount, "pki", "cert/ca_chain")
+		if err != nil {
+			return logical.ErrorResponse(err.Error()), nil
+		}
+		chain, _ := resp.Data["certificate"].(string)
+		entry := &trustBundlePKIEntry{
+			Mount:   mount,
+			CAChain: []string{},
+		}
+		for rest := []byte(chain); ; {
+			var block *pem.Block
+			block, rest = pem.Decode(rest)
+			if block == nil {
+				break
+			}
+			entry.CAChain = append(entry.CAChain, strings.TrimSpace(string(pem.EncodeToMemory(block))))
+		}
+		if len(entry.CAChain) == 0 {
+			return logical.ErrorResponse("no CA configured on mount %q", mount), nil
+		}
+		doc.PKI = append(doc.PKI, entry)
+	}
+	for _, mount := range bundle.SSHMounts {
+		resp, err := b.readTrustBundleMount(ctx, mount, "ssh", "config/ca")
+		if err != nil {
+			return logical.ErrorResponse(err.Error()), nil
+		}
+		publicKey, _ := resp.Data["public_key"].(string)
+		doc.SSH = append(doc.SSH, &trustBundleSSHEntry{
+			Mount:     mount,
+			PublicKey: strings.TrimSpace(publicKey),
+		})
+	}
+
+	payload, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	var signed string
+	switch format {
+	case trustBundleFormatJSON:
+		signed, err = bundle.signJWS(payload)
+	case trustBundleFormatCMS:
+		signed, err = bundle.signCMS(payload)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign trust bundle: %w", err)
+	}
+
+	var document map[string]interface{}
+	if err := json.Unmarshal(payload, &document); err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"document":            document,
+			"bundle":              signed,
+			"format":              format,
+			"signing_certificate": bundle.signingCertificatePEM(),
+		},
+	}, nil
+}
+
+func (b *SystemBackend) trustBundle(ctx context.Context, s logical.Storage, name string) (*trustBundle, error) {
+	entry, err := s.Get(ctx, trustBundleStoragePrefix+name)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	bundle := new(trustBundle)
+	if err := entry.DecodeJSON(bundle); err != nil {
+		return nil, err
+	}
+	return bundle, nil
+}
+
+// checkTrustBundleMount checks that a secrets engine of the given type is
+// mounted at exactly the given path.
+func (b *SystemBackend) checkTrustBundleMount(ctx context.Context, mount, mountType string) error {
+	me := b.Core.router.MatchingMountEntry(ctx, mount)
+	if me == nil || me.Table != mountTableType || me.Path != mount {
+		return fmt.Errorf("no secrets mount found at %q", mount)
+	}
+	if me.Type != mountType {
+		return fmt.Errorf("mount %q is of type %q, not %q", mount, me.Type, mountType)
+	}
+	return nil
+}
+
+// readTrustBundleMount reads the path of a mount holding its CA. The request
+// is routed directly, as the mounts of a bundle were chosen by an operator
+// allowed to configure it.
+func (b *SystemBackend) readTrustBundleMount(ctx context.Context, mount, mountType, path string) (*logical.Response, error) {
+	if err := b.checkTrustBundleMount(ctx, mount, mountType); err != nil {
+		return nil, err
+	}
+
+	resp, err := b.Core.router.Route(ctx, &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      mount + path,
+	})
+	switch {
+	case err != nil:
+		return nil, fmt.Errorf("failed to read the CA of mount %q: %w", mount, err)
+	case resp == nil:
+		return nil, fmt.Errorf("no CA configured on mount %q", mount)
+	case resp.IsError():
+		return nil, fmt.Errorf("failed to read the CA of mount %q: %w", mount, resp.Error())
+	}
+	return resp, nil
+}
+
+func sanitizeTrustBundleMounts(mounts []string) []string {
+	sanitized := make([]string, 0, len(mounts))
+	for _, mount := range mounts {
+		if mount = strings.TrimSpace(mount); mount != "" {
+			sanitized = append(sanitized, sanitizePath(mount))
+		}
+	}
+	return sanitized
+}
+
+// generateSigningKey generates the ECDSA P-256 key signing the bundle, along
+// with the self-signed certificate CMS signatures require.
+func (t *trustBundle) generateSigningKey() error {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject: pkix.Name{
+			CommonName: "Vault trust bundle " + t.Name,
+		},
+		NotBefore:             now.Add(-30 * time.Second),
+		NotAfter:              now.Add(trustBundleSigningCertValidity),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+	}
+	cert, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		return err
+	}
+
+	t.SigningKey, err = x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return err
+	}
+	t.SigningCertificate = cert
+	return nil
+}
+
+func (t *trustBundle) signingKey() (*ecdsa.PrivateKey, error) {
+	key, err := x509.ParsePKCS8PrivateKey(t.SigningKey)
+	if err != nil {
+		return nil, err
+	}
+	ecKey, ok := key.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("unexpected signing key type %T", key)
+	}
+	return ecKey, nil
+}
+
+func (t *trustBundle) signingCertificatePEM() string {
+	return strings.TrimSpace(string(pem.EncodeToMemory(&pem.Block{
+		Type:  "CERTIFICATE",
+		Bytes: t.SigningCertificate,
+	})))
+}
+
+// signJWS returns the compact serialization of a JWS signing the payload.
+func (t *trustBundle) signJWS(payload []byte) (string, error) {
+	key, err := t.signingKey()
+	if err != nil {
+		return "", err
+	}
+
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.ES256, Key: key}, &jose.SignerOptions{})
+	if err != nil {
+		return "", err
+	}
+	signature, err := signer.Sign(payload)
+	if err != nil {
+		return "", err
+	}
+	return signature.CompactSerialize()
+}
+
+// signCMS returns the base64-encoded DER of a CMS SignedData wrapping the
+// payload, which includes the signing certificate.
+func (t *trustBundle) signCMS(payload []byte) (string, error) {
+	key, err := t.signingKey()
+	if err != nil {
+		return "", err
+	}
+	cert, err := x509.ParseCertificate(t.SigningCertificate)
+	if err != nil {
+		return "", err
+	}
+
+	sd, err := pkcs7.NewSignedData(payload)
+	if err != nil {
+		return "", err
+	}
+	sd.SetDigestAlgorithm(pkcs7.OIDDigestAlgorithmSHA256)
+	if err := sd.AddSigner(cert, key, pkcs7.SignerInfoConfig{}); err != nil {
+		return "", err
+	}
+	der, err := sd.Finish()
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(der), nil
+}
@@ -0,0 +1,185 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func (b *SystemBackend) ttlPolicyPaths() []*framework.Path {
+	return []*framework.Path{
+		{
+			Pattern: "ttl-policies/?$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "ttl-policies",
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ListOperation: &framework.PathOperation{
+					Callback: b.handleTTLPoliciesList,
+					Summary:  "List the existing TTL policies.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["ttl-policies"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["ttl-policies"][1]),
+		},
+
+		{
+			Pattern: "ttl-policies/(?P<name>.+)$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "ttl-policies",
+				OperationSuffix: "ttl-policy",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"name": {
+					Type:        framework.TypeString,
+					Description: "The name of the TTL policy.",
+				},
+				"paths": {
+					Type:        framework.TypeCommaStringSlice,
+					Description: `Request paths the policy applies to, including the namespace path. A leading or trailing "*" matches any prefix or suffix, such as "prod/*".`,
+				},
+				"max_ttl": {
+					Type:        framework.TypeDurationSecond,
+					Description: "The maximum TTL of leases and tokens issued by requests to the paths, counted from when they were issued.",
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleTTLPolicySet,
+					Responses: map[int][]framework.Response{
+						http.StatusNoContent: {{
+							Description: "OK",
+						}},
+					},
+					Summary: "Add a new or update an existing TTL policy.",
+				},
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleTTLPolicyRead,
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"name": {
+									Type:     framework.TypeString,
+									Required: true,
+								},
+								"paths": {
+									Type:     framework.TypeCommaStringSlice,
+									Required: true,
+								},
+								"max_ttl": {
+									Type:     framework.TypeDurationSecond,
+									Required: true,
+								},
+							},
+						}},
+					},
+					Summary: "Retrieve an existing TTL policy.",
+				},
+				logical.DeleteOperation: &framework.PathOperation{
+					Callback: b.handleTTLPolicyDelete,
+					Responses: map[int][]framework.Response{
+						http.StatusNoContent: {{
+							Description: "OK",
+						}},
+					},
+					Summary: "Delete a TTL policy.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["ttl-policies"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["ttl-policies"][1]),
+		},
+	}
+}
+
+// handleTTLPoliciesList returns the names of the TTL policies
+func (b *SystemBackend) handleTTLPoliciesList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	return logical.ListResponse(b.Core.ttlPolicies.List()), nil
+}
+
+// checkTTLPoliciesNamespace rejects requests made outside of the root
+// namespace, as TTL policies apply to the paths of every namespace.
+func checkTTLPoliciesNamespace(ctx context.Context) (*logical.Response, error) {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if ns.ID != namespace.RootNamespaceID {
+		return logical.ErrorResponse("TTL policies can only be managed in the root namespace"), logical.ErrInvalidRequest
+	}
+	return nil, nil
+}
+
+// handleTTLPolicySet creates or updates a TTL policy
+func (b *SystemBackend) handleTTLPolicySet(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	if resp, err := checkTTLPoliciesNamespace(ctx); resp != nil || err != nil {
+		return resp, err
+	}
+	name := d.Get("name").(string)
+
+	policy := &TTLPolicy{
+		Name: name,
+	}
+	if existing := b.Core.ttlPolicies.Get(name); existing != nil {
+		policy.Paths = existing.Paths
+		policy.MaxTTL = existing.MaxTTL
+	}
+	if paths, ok := d.GetOk("paths"); ok {
+		policy.Paths = paths.([]string)
+	}
+	if maxTTL, ok := d.GetOk("max_ttl"); ok {
+		policy.MaxTTL = time.Duration(maxTTL.(int)) * time.Second
+	}
+
+	if err := policy.validate(); err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+	if err := b.Core.ttlPolicies.Set(ctx, policy); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+// handleTTLPolicyRead returns a TTL policy
+func (b *SystemBackend) handleTTLPolicyRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	policy := b.Core.ttlPolicies.Get(d.Get("name").(string))
+	if policy == nil {
+		return nil, nil
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"name":    policy.Name,
+			"paths":   policy.Paths,
+			"max_ttl": int64(policy.MaxTTL.Seconds()),
+		},
+	}, nil
+}
+
+// handleTTLPolicyDelete deletes a TTL policy
+func (b *SystemBackend) handleTTLPolicyDelete(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	if resp, err := checkTTLPoliciesNamespace(ctx); resp != nil || err != nil {
+		return resp, err
+	}
+	if err := b.Core.ttlPolicies.Delete(ctx, d.Get("name").(string)); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
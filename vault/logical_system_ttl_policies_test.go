@@ -0,0 +1,197 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSystemBackend_TTLPolicies(t *testing.T) {
+	b := testSystemBackend(t)
+	ctx := namespace.RootContext(nil)
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "ttl-policies/prod")
+	req.Data["paths"] = "prod/*,auth/prod-*"
+	req.Data["max_ttl"] = "4h"
+	resp, err := b.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.Nil(t, resp)
+
+	// Updating only the max TTL keeps the paths
+	req = logical.TestRequest(t, logical.UpdateOperation, "ttl-policies/prod")
+	req.Data["max_ttl"] = "2h"
+	resp, err = b.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.Nil(t, resp)
+
+	req = logical.TestRequest(t, logical.ReadOperation, "ttl-policies/prod")
+	resp, err = b.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{
+		"name":    "prod",
+		"paths":   []string{"prod/*", "auth/prod-*"},
+		"max_ttl": int64(7200),
+	}, resp.Data)
+
+	req = logical.TestRequest(t, logical.ListOperation, "ttl-policies")
+	resp, err = b.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, []string{"prod"}, resp.Data["keys"])
+
+	req = logical.TestRequest(t, logical.DeleteOperation, "ttl-policies/prod")
+	_, err = b.HandleRequest(ctx, req)
+	require.NoError(t, err)
+
+	req = logical.TestRequest(t, logical.ReadOperation, "ttl-policies/prod")
+	resp, err = b.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.Nil(t, resp)
+
+	for name, data := range map[string]map[string]interface{}{
+		"no paths":   {"max_ttl": "1h"},
+		"no max_ttl": {"paths": "prod/*"},
+		"empty path": {"paths": []string{""}, "max_ttl": "1h"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			req := logical.TestRequest(t, logical.UpdateOperation, "ttl-policies/invalid")
+			req.Data = data
+			resp, err := b.HandleRequest(ctx, req)
+			require.ErrorIs(t, err, logical.ErrInvalidRequest)
+			require.True(t, resp.IsError())
+		})
+	}
+}
+
+func TestCore_TTLPolicies_Enforced(t *testing.T) {
+	c, _, root := TestCoreUnsealedWithConfig(t, &CoreConfig{
+		LogicalBackends: map[string]logical.Factory{
+			"kv": LeasedPassthroughBackendFactory,
+		},
+	})
+	ctx := namespace.RootContext(nil)
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "sys/ttl-policies/secrets")
+	req.ClientToken = root
+	req.Data["paths"] = "secret/*,auth/token/*"
+	req.Data["max_ttl"] = "1h"
+	_, err := c.HandleRequest(ctx, req)
+	require.NoError(t, err)
+
+	req = logical.TestRequest(t, logical.UpdateOperation, "secret/foo")
+	req.ClientToken = root
+	req.Data["foo"] = "bar"
+	req.Data["lease"] = "10h"
+	_, err = c.HandleRequest(ctx, req)
+	require.NoError(t, err)
+
+	// Reading the secret issues a lease capped by the policy
+	req = logical.TestRequest(t, logical.ReadOperation, "secret/foo")
+	req.ClientToken = root
+	resp, err := c.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.NotNil(t, resp.Secret)
+	require.Equal(t, time.Hour, resp.Secret.TTL)
+	require.NotEmpty(t, resp.Warnings)
+	require.NotEmpty(t, resp.WarningsDetail)
+	require.Equal(t, logical.WarningCodeTTLTruncated, resp.WarningsDetail[0].Code)
+
+	// Renewals are capped too
+	req = logical.TestRequest(t, logical.UpdateOperation, "sys/leases/renew")
+	req.ClientToken = root
+	req.Data["lease_id"] = resp.Secret.LeaseID
+	req.Data["increment"] = "10h"
+	resp, err = c.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.LessOrEqual(t, resp.Secret.TTL, time.Hour)
+
+	// As are tokens
+	req = logical.TestRequest(t, logical.UpdateOperation, "auth/token/create")
+	req.ClientToken = root
+	req.Data["ttl"] = "10h"
+	req.Data["policies"] = []string{"default"}
+	resp, err = c.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, time.Hour, resp.Auth.TTL)
+
+	// Paths not matching any policy are not affected
+	req = logical.TestRequest(t, logical.UpdateOperation, "sys/ttl-policies/secrets")
+	req.ClientToken = root
+	req.Data["paths"] = "prod/*"
+	_, err = c.HandleRequest(ctx, req)
+	require.NoError(t, err)
+
+	req = logical.TestRequest(t, logical.ReadOperation, "secret/foo")
+	req.ClientToken = root
+	resp, err = c.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, 10*time.Hour, resp.Secret.TTL)
+}
+
+func TestCore_ApplyTTLPolicies_PastMaxTTL(t *testing.T) {
+	c, _, _ := TestCoreUnsealed(t)
+	ctx := namespace.RootContext(nil)
+
+	require.NoError(t, c.ttlPolicies.Set(ctx, &TTLPolicy{
+		Name:   "short",
+		Paths:  []string{"secret/*"},
+		MaxTTL: time.Hour,
+	}))
+
+	ttl, warnings, err := c.applyTTLPolicies(namespace.RootNamespace, "secret/foo", 30*time.Minute, time.Now().Add(-45*time.Minute))
+	require.NoError(t, err)
+	require.LessOrEqual(t, ttl, 15*time.Minute)
+	require.Len(t, warnings, 1)
+
+	_, _, err = c.applyTTLPolicies(namespace.RootNamespace, "secret/foo", 30*time.Minute, time.Now().Add(-2*time.Hour))
+	require.Error(t, err)
+
+	ttl, warnings, err = c.applyTTLPolicies(namespace.RootNamespace, "other/foo", 30*time.Minute, time.Time{})
+	require.NoError(t, err)
+	require.Equal(t, 30*time.Minute, ttl)
+	require.Empty(t, warnings)
+}
+
+func TestSystemBackend_TTLPolicies_RootNamespaceOnly(t *testing.T) {
+	c, _, _ := TestCoreUnsealed(t)
+	childCtx := namespace.ContextWithNamespace(context.Background(), &namespace.Namespace{
+		ID:   "child",
+		Path: "child/",
+	})
+
+	for _, op := range []logical.Operation{logical.UpdateOperation, logical.DeleteOperation} {
+		req := logical.TestRequest(t, op, "ttl-policies/prod")
+		req.Data["paths"] = "*"
+		req.Data["max_ttl"] = "1m"
+		resp, err := c.systemBackend.HandleRequest(childCtx, req)
+		require.ErrorIs(t, err, logical.ErrInvalidRequest)
+		require.True(t, resp.IsError())
+	}
+	require.Empty(t, c.ttlPolicies.List())
+}
+
+func TestTTLPolicyStore_Invalidate(t *testing.T) {
+	c, _, _ := TestCoreUnsealed(t)
+	ctx := namespace.RootContext(nil)
+
+	// Policies changed by another node are picked up on invalidation
+	entry, err := logical.StorageEntryJSON("prod", &TTLPolicy{
+		Name:   "prod",
+		Paths:  []string{"prod/*"},
+		MaxTTL: time.Hour,
+	})
+	require.NoError(t, err)
+	require.NoError(t, c.ttlPolicies.view.Put(ctx, entry))
+	c.systemBackend.invalidate(ctx, ttlPolicySubPath+"prod")
+	require.Equal(t, time.Hour, c.ttlPolicies.Get("prod").MaxTTL)
+
+	require.NoError(t, c.ttlPolicies.view.Delete(ctx, "prod"))
+	c.systemBackend.invalidate(ctx, ttlPolicySubPath+"prod")
+	require.Nil(t, c.ttlPolicies.Get("prod"))
+}
@@ -746,7 +746,7 @@ func (b *LoginMFABackend) handleMFALoginValidate(ctx context.Context, req *logic
 	for _, eConfig := range matchedMfaEnforcementList {
 		err = b.Core.validateLoginMFA(ctx, eConfig, entity, req.Connection.RemoteAddr, mfaCreds)
 		if err != nil {
-			return logical.ErrorResponse(fmt.Sprintf("failed to satisfy enforcement %s. error: %s", eConfig.Name, err.Error())), logical.ErrPermissionDenied
+			return logical.AuthErrorResponse(logical.AuthErrorCodeMFAFailed, "failed to satisfy enforcement %s. error: %s", eConfig.Name, err.Error()), logical.ErrPermissionDenied
 		}
 	}
 
@@ -332,6 +332,18 @@ type MountEntry struct {
 	MountState            string            `json:"mount_state,omitempty"`             // The current mount state.  The only non-empty mount state right now is "unmounting"
 	NamespaceID           string            `json:"namespace_id"`
 
+	// AdminGroupIDs are the identity groups granted admin rights over the
+	// mount through sys/mounts/:path/admins
+	AdminGroupIDs []string `json:"admin_group_ids,omitempty"`
+
+	// SLO holds the service level objectives of the mount set through
+	// sys/mounts/:path/slo
+	SLO *MountSLO `json:"slo,omitempty"`
+
+	// NetworkRequirements restrict the network paths logins to an auth
+	// mount may arrive through, set through sys/auth/:path/network-requirements
+	NetworkRequirements *AuthNetworkRequirements `json:"network_requirements,omitempty"`
+
 	// namespace contains the populated namespace
 	namespace *namespace.Namespace
 
@@ -1501,6 +1513,7 @@ func (c *Core) persistMounts(ctx context.Context, table *MountTable, local *bool
 		c.tableMetrics(len(nonLocalMounts.Entries), false, false, compressedBytes)
 	}
 
+	c.updateMountAdminGrants(table)
 	return nil
 }
 
@@ -1510,6 +1523,8 @@ func (c *Core) setupMounts(ctx context.Context) error {
 	c.mountsLock.Lock()
 	defer c.mountsLock.Unlock()
 
+	c.updateMountAdminGrants(c.mounts)
+
 	for _, entry := range c.mounts.sortEntriesByPathDepth().Entries {
 		// Initialize the backend, special casing for system
 		barrierPath := entry.ViewPath()
@@ -1548,6 +1563,7 @@ func (c *Core) setupMounts(ctx context.Context) error {
 		}
 
 		var backend logical.Backend
+		var setupErr error
 		// Create the new backend
 		sysView := c.mountEntrySysView(entry)
 		backend, err = c.newLogicalBackend(ctx, entry, sysView, view)
@@ -1560,6 +1576,7 @@ func (c *Core) setupMounts(ctx context.Context) error {
 			}
 			if mountable {
 				c.logger.Warn("skipping plugin-based mount entry", "path", entry.Path)
+				setupErr = err
 				goto ROUTER_MOUNT
 			}
 			return errors.Join(errLoadMountsFailed, err)
@@ -1577,6 +1594,7 @@ func (c *Core) setupMounts(ctx context.Context) error {
 				c.logger.Error("skipping deprecated mount entry", "name", entry.Type, "path", entry.Path, "error", err)
 				backend.Cleanup(ctx)
 				backend = nil
+				setupErr = err
 				goto ROUTER_MOUNT
 			}
 		}
@@ -1611,6 +1629,7 @@ func (c *Core) setupMounts(ctx context.Context) error {
 			c.logger.Error("failed to mount entry", "path", entry.Path, "error", err)
 			return errLoadMountsFailed
 		}
+		c.router.recordMountInitError(entry, setupErr)
 
 		// Initialize
 		if !nilMount {
@@ -1627,10 +1646,19 @@ func (c *Core) setupMounts(ctx context.Context) error {
 				}
 
 				nsActiveContext := namespace.ContextWithNamespace(c.activeContext, localEntry.Namespace())
-				err := backend.Initialize(nsActiveContext, &logical.InitializationRequest{Storage: view})
-				if err != nil {
-					postUnsealLogger.Error("failed to initialize mount backend", "error", err)
+				initialize := func() {
+					err := backend.Initialize(nsActiveContext, &logical.InitializationRequest{Storage: view})
+					if err != nil {
+						postUnsealLogger.Error("failed to initialize mount backend", "error", err)
+					}
+					c.router.recordMountInitError(localEntry, err)
+				}
+				if c.lazyMountInitialization && !strutil.StrListContains(singletonMounts, localEntry.Type) &&
+					c.router.deferMountInit(localEntry, initialize) {
+					postUnsealLogger.Debug("deferring mount initialization to first request")
+					return
 				}
+				initialize()
 			})
 		}
 
@@ -1673,6 +1701,7 @@ func (c *Core) unloadMounts(ctx context.Context) error {
 	}
 
 	c.mounts = nil
+	c.updateMountAdminGrants(nil)
 	c.router.reset()
 	c.systemBarrierView = nil
 	return nil
@@ -0,0 +1,130 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"errors"
+	"net/rpc"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/hashicorp/vault/sdk/plugin"
+)
+
+const (
+	mountHealthStatusHealthy     = "healthy"
+	mountHealthStatusDegraded    = "degraded"
+	mountHealthStatusUnavailable = "unavailable"
+)
+
+// pluginRestartCounter is implemented by plugin backend wrappers which
+// transparently restart their plugin process after it crashes.
+type pluginRestartCounter interface {
+	PluginRestartCount() uint64
+}
+
+// mountHealth holds runtime health information about a mounted backend. It
+// is only kept in memory and starts fresh whenever the mount is loaded into
+// the router, e.g. after an unseal or a remount.
+type mountHealth struct {
+	// lastSuccess is the unix time in nanoseconds of the last request the
+	// backend handled without returning an error
+	lastSuccess atomic.Int64
+
+	// pluginShutdownErrors counts the requests which failed because the
+	// backing plugin process had gone away and could not be restarted
+	pluginShutdownErrors atomic.Uint64
+
+	l             sync.RWMutex
+	initError     string
+	initErrorTime time.Time
+}
+
+// recordInitError records an error encountered while creating or
+// initializing the backend. A nil error records a successful
+// (re)initialization, clearing any error recorded before it.
+func (h *mountHealth) recordInitError(err error) {
+	h.l.Lock()
+	defer h.l.Unlock()
+
+	if err == nil {
+		h.initError = ""
+		h.initErrorTime = time.Time{}
+		return
+	}
+
+	h.initError = err.Error()
+	h.initErrorTime = time.Now()
+}
+
+// recordResult updates the health information using the outcome of a request
+// handled by the backend.
+func (h *mountHealth) recordResult(resp *logical.Response, err error) {
+	switch {
+	case err != nil:
+		if isPluginShutdownError(err) {
+			h.pluginShutdownErrors.Add(1)
+		}
+	case resp != nil && resp.IsError():
+	default:
+		h.lastSuccess.Store(time.Now().UnixNano())
+	}
+}
+
+// info returns the health information in a form suitable for API responses.
+// The backend is the one currently serving the mount, if any, and initState
+// the state of its deferred initialization, if any.
+func (h *mountHealth) info(backend logical.Backend, initState string) map[string]interface{} {
+	h.l.RLock()
+	initError, initErrorTime := h.initError, h.initErrorTime
+	h.l.RUnlock()
+
+	status := mountHealthStatusHealthy
+	switch {
+	case backend == nil:
+		status = mountHealthStatusUnavailable
+	case initError != "":
+		status = mountHealthStatusDegraded
+	}
+
+	if initState == "" {
+		initState = mountInitStateReady
+		if initError != "" {
+			initState = mountInitStateFailed
+		}
+	}
+
+	var crashCount uint64
+	if counter, ok := backend.(pluginRestartCounter); ok {
+		crashCount = counter.PluginRestartCount()
+	}
+
+	var lastSuccess string
+	if ts := h.lastSuccess.Load(); ts != 0 {
+		lastSuccess = time.Unix(0, ts).UTC().Format(time.RFC3339)
+	}
+
+	var initErrorTimeStr string
+	if !initErrorTime.IsZero() {
+		initErrorTimeStr = initErrorTime.UTC().Format(time.RFC3339)
+	}
+
+	return map[string]interface{}{
+		"status":                    status,
+		"initialization_state":      initState,
+		"initialization_error":      initError,
+		"initialization_error_time": initErrorTimeStr,
+		"plugin_crash_count":        crashCount,
+		"plugin_shutdown_errors":    h.pluginShutdownErrors.Load(),
+		"last_successful_operation": lastSuccess,
+	}
+}
+
+func isPluginShutdownError(err error) bool {
+	// Need to compare string value for case were err comes from plugin RPC
+	// and is returned as plugin.BasicError type.
+	return errors.Is(err, plugin.ErrPluginShutdown) || err.Error() == rpc.ErrShutdown.Error()
+}
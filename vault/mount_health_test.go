@@ -0,0 +1,93 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/hashicorp/vault/sdk/plugin"
+	"github.com/stretchr/testify/require"
+)
+
+type testRestartingBackend struct {
+	*framework.Backend
+	restarts uint64
+}
+
+func (b *testRestartingBackend) PluginRestartCount() uint64 {
+	return b.restarts
+}
+
+// TestMountHealth verifies that request outcomes and initialization errors
+// are reflected in the health information for a mount.
+func TestMountHealth(t *testing.T) {
+	var h mountHealth
+
+	info := h.info(nil, "")
+	require.Equal(t, mountHealthStatusUnavailable, info["status"])
+
+	backend := &testRestartingBackend{Backend: &framework.Backend{}, restarts: 2}
+	info = h.info(backend, "")
+	require.Equal(t, mountHealthStatusHealthy, info["status"])
+	require.Equal(t, mountInitStateReady, info["initialization_state"])
+	require.Equal(t, uint64(2), info["plugin_crash_count"])
+	require.Empty(t, info["last_successful_operation"])
+
+	h.recordResult(logical.ErrorResponse("bad request"), nil)
+	require.Empty(t, h.info(backend, "")["last_successful_operation"])
+
+	h.recordResult(&logical.Response{}, nil)
+	require.NotEmpty(t, h.info(backend, "")["last_successful_operation"])
+
+	h.recordResult(nil, plugin.ErrPluginShutdown)
+	require.Equal(t, uint64(1), h.info(backend, "")["plugin_shutdown_errors"])
+
+	h.recordInitError(errors.New("failed to initialize"))
+	info = h.info(backend, "")
+	require.Equal(t, mountHealthStatusDegraded, info["status"])
+	require.Equal(t, mountInitStateFailed, info["initialization_state"])
+	require.Equal(t, "failed to initialize", info["initialization_error"])
+	require.NotEmpty(t, info["initialization_error_time"])
+
+	// A later successful initialization clears the error
+	h.recordInitError(nil)
+	info = h.info(backend, "")
+	require.Equal(t, mountHealthStatusHealthy, info["status"])
+	require.Equal(t, mountInitStateReady, info["initialization_state"])
+	require.Empty(t, info["initialization_error"])
+	require.Empty(t, info["initialization_error_time"])
+
+	require.Equal(t, mountInitStatePending, h.info(backend, mountInitStatePending)["initialization_state"])
+}
+
+// TestSystemBackend_mountHealth verifies that sys/mounts includes runtime
+// health information for mounted backends.
+func TestSystemBackend_mountHealth(t *testing.T) {
+	b := testSystemBackend(t)
+
+	req := logical.TestRequest(t, logical.ReadOperation, "mounts/secret")
+	resp, err := b.HandleRequest(namespace.RootContext(nil), req)
+	require.NoError(t, err)
+	require.NotNil(t, resp)
+	require.NotContains(t, resp.Data, "health")
+
+	req.Data["include_health"] = true
+	resp, err = b.HandleRequest(namespace.RootContext(nil), req)
+	require.NoError(t, err)
+	require.NotNil(t, resp)
+
+	health, ok := resp.Data["health"].(map[string]interface{})
+	require.True(t, ok, "expected health in response: %#v", resp.Data)
+	require.Equal(t, mountHealthStatusHealthy, health["status"])
+
+	req = logical.TestRequest(t, logical.ReadOperation, "mounts")
+	req.Data["include_health"] = true
+	resp, err = b.HandleRequest(namespace.RootContext(nil), req)
+	require.NoError(t, err)
+	require.Contains(t, resp.Data["secret/"], "health")
+}
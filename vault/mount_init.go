@@ -0,0 +1,83 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	mountInitStatePending      = "pending"
+	mountInitStateInitializing = "initializing"
+	mountInitStateReady        = "ready"
+	mountInitStateFailed       = "failed"
+)
+
+// deferredMountInit holds the initialization of a mount which, with lazy
+// mount initialization enabled, is deferred from unseal to the first request
+// routed to the mount.
+type deferredMountInit struct {
+	// pending is set while the initialization has not completed, so that
+	// the request path only needs to take the lock for the first requests
+	pending atomic.Bool
+	running atomic.Bool
+
+	l    sync.Mutex
+	init func()
+}
+
+// deferInit stores the function initializing the mount, to be run by the
+// first request to the mount.
+func (d *deferredMountInit) deferInit(init func()) {
+	d.l.Lock()
+	defer d.l.Unlock()
+
+	d.init = init
+	d.pending.Store(true)
+}
+
+// cancel drops a deferred initialization, e.g. when the backend was
+// initialized by a reload.
+func (d *deferredMountInit) cancel() {
+	d.l.Lock()
+	defer d.l.Unlock()
+
+	d.init = nil
+	d.pending.Store(false)
+}
+
+// run runs the deferred initialization if it has not run yet. Concurrent
+// callers wait for the initialization to complete.
+func (d *deferredMountInit) run() {
+	if !d.pending.Load() {
+		return
+	}
+
+	d.l.Lock()
+	defer d.l.Unlock()
+
+	if d.init == nil {
+		return
+	}
+
+	d.running.Store(true)
+	d.init()
+	d.init = nil
+	d.running.Store(false)
+	d.pending.Store(false)
+}
+
+// state returns the state of a deferred initialization, or an empty string
+// if none is outstanding.
+func (d *deferredMountInit) state() string {
+	switch {
+	case !d.pending.Load():
+		return ""
+	case d.running.Load():
+		return mountInitStateInitializing
+	default:
+		return mountInitStatePending
+	}
+}
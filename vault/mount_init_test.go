@@ -0,0 +1,102 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCore_LazyMountInitialization verifies that with lazy mount
+// initialization, mounts are initialized by their first request rather than
+// on unseal.
+func TestCore_LazyMountInitialization(t *testing.T) {
+	backend := &InitializableBackend{
+		&NoopBackend{
+			BackendType: logical.TypeLogical,
+		}, false,
+	}
+
+	c, _, _ := TestCoreUnsealed(t)
+	c.lazyMountInitialization = true
+	c.logicalBackends["initable"] = func(context.Context, *logical.BackendConfig) (logical.Backend, error) {
+		return backend, nil
+	}
+
+	entry := &MountEntry{
+		Table:            mountTableType,
+		Path:             "foo/",
+		Type:             "initable",
+		UUID:             "abcd",
+		Accessor:         "initable-abcd",
+		BackendAwareUUID: "abcde",
+		NamespaceID:      namespace.RootNamespaceID,
+		namespace:        namespace.RootNamespace,
+	}
+	c.mounts = &MountTable{
+		Type:    mountTableType,
+		Entries: []*MountEntry{entry},
+	}
+
+	ctx := namespace.RootContext(nil)
+	require.NoError(t, c.setupMounts(ctx))
+	for _, f := range c.postUnsealFuncs {
+		f()
+	}
+	require.False(t, backend.isInitialized)
+	require.Equal(t, mountInitStatePending, c.router.MountHealth(entry)["initialization_state"])
+
+	_, err := c.router.Route(ctx, &logical.Request{Operation: logical.ReadOperation, Path: "foo/bar"})
+	require.NoError(t, err)
+	require.True(t, backend.isInitialized)
+	require.Equal(t, mountInitStateReady, c.router.MountHealth(entry)["initialization_state"])
+}
+
+// TestCore_LazyMountInitialization_Rollback verifies that the first periodic
+// rollback initializes a mount no request has reached, so that its background
+// work is not held back.
+func TestCore_LazyMountInitialization_Rollback(t *testing.T) {
+	backend := &InitializableBackend{
+		&NoopBackend{
+			BackendType: logical.TypeLogical,
+		}, false,
+	}
+
+	c, _, _ := TestCoreUnsealed(t)
+	c.lazyMountInitialization = true
+	c.logicalBackends["initable"] = func(context.Context, *logical.BackendConfig) (logical.Backend, error) {
+		return backend, nil
+	}
+
+	entry := &MountEntry{
+		Table:            mountTableType,
+		Path:             "foo/",
+		Type:             "initable",
+		UUID:             "abcd",
+		Accessor:         "initable-abcd",
+		BackendAwareUUID: "abcde",
+		NamespaceID:      namespace.RootNamespaceID,
+		namespace:        namespace.RootNamespace,
+	}
+	c.mounts = &MountTable{
+		Type:    mountTableType,
+		Entries: []*MountEntry{entry},
+	}
+
+	ctx := namespace.RootContext(nil)
+	require.NoError(t, c.setupMounts(ctx))
+	for _, f := range c.postUnsealFuncs {
+		f()
+	}
+	require.False(t, backend.isInitialized)
+
+	_, err := c.router.Route(ctx, &logical.Request{Operation: logical.RollbackOperation, Path: "foo/"})
+	require.NoError(t, err)
+	require.True(t, backend.isInitialized)
+	require.Equal(t, mountInitStateReady, c.router.MountHealth(entry)["initialization_state"])
+}
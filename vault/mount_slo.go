@@ -0,0 +1,301 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/hashicorp/vault/sdk/logical"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const (
+	mountSLODefaultWindow      = 5 * time.Minute
+	mountSLODefaultObjective   = 0.99
+	mountSLODefaultMinRequests = 10
+
+	// mountSLOBreachEventType is the type of the events sent when a mount
+	// misses one of its service level objectives over a window
+	mountSLOBreachEventType = "mount/slo-breach"
+)
+
+// The indicators measured against the service level objectives of a mount
+const (
+	sloIndicatorReadLatency  = "read_latency"
+	sloIndicatorWriteLatency = "write_latency"
+	sloIndicatorRequestSize  = "request_size"
+	sloIndicatorResponseSize = "response_size"
+)
+
+// MountSLO holds the service level objectives of a mount, set through
+// sys/mounts/:path/slo. A request violates a target when it exceeds it, and
+// an objective is breached when less than Objective of the requests measured
+// against it over a window met the target.
+type MountSLO struct {
+	// ReadLatency is the target latency of read and list requests, and
+	// WriteLatency the one of create, update, patch and delete requests,
+	// such as the issuance of credentials
+	ReadLatency  time.Duration `json:"read_latency,omitempty"`
+	WriteLatency time.Duration `json:"write_latency,omitempty"`
+
+	// MaxRequestSize and MaxResponseSize are the target sizes in bytes of
+	// request and response bodies
+	MaxRequestSize  int64 `json:"max_request_size,omitempty"`
+	MaxResponseSize int64 `json:"max_response_size,omitempty"`
+
+	Window      time.Duration `json:"window"`
+	Objective   float64       `json:"objective"`
+	MinRequests int           `json:"min_requests"`
+}
+
+func (s *MountSLO) validate() error {
+	switch {
+	case s.ReadLatency < 0 || s.WriteLatency < 0:
+		return errors.New("latency targets cannot be negative")
+	case s.MaxRequestSize < 0 || s.MaxResponseSize < 0:
+		return errors.New("size targets cannot be negative")
+	case s.ReadLatency == 0 && s.WriteLatency == 0 && s.MaxRequestSize == 0 && s.MaxResponseSize == 0:
+		return errors.New("at least one of read_latency, write_latency, max_request_size or max_response_size must be set")
+	case s.Window < time.Second:
+		return errors.New("window must be at least one second")
+	case s.Objective <= 0 || s.Objective >= 1:
+		return errors.New("objective must be greater than 0 and less than 1")
+	case s.MinRequests < 1:
+		return errors.New("min_requests must be at least 1")
+	}
+	return nil
+}
+
+// target returns the target of the indicator in the units it is measured in,
+// or zero if the indicator is not measured.
+func (s *MountSLO) target(indicator string) int64 {
+	switch indicator {
+	case sloIndicatorReadLatency:
+		return int64(s.ReadLatency)
+	case sloIndicatorWriteLatency:
+		return int64(s.WriteLatency)
+	case sloIndicatorRequestSize:
+		return s.MaxRequestSize
+	case sloIndicatorResponseSize:
+		return s.MaxResponseSize
+	}
+	return 0
+}
+
+// sloCount counts the requests measured against an indicator in a window,
+// and those which violated its target.
+type sloCount struct {
+	Requests   uint64 `json:"requests"`
+	Violations uint64 `json:"violations"`
+}
+
+// sloBreach describes an objective missed by a mount over a window.
+type sloBreach struct {
+	indicator   string
+	count       sloCount
+	windowStart time.Time
+}
+
+// mountSLOTracker measures the requests handled by a mounted backend against
+// its service level objectives. Like the health of a mount, the measurements
+// are only kept in memory, per node, and start fresh whenever the mount is
+// loaded into the router or its objectives change.
+type mountSLOTracker struct {
+	config atomic.Pointer[MountSLO]
+
+	l           sync.Mutex
+	windowStart time.Time
+	counts      map[string]*sloCount
+}
+
+func (t *mountSLOTracker) setConfig(slo *MountSLO) {
+	t.l.Lock()
+	defer t.l.Unlock()
+
+	t.config.Store(slo)
+	t.windowStart = time.Time{}
+	t.counts = nil
+}
+
+// record measures a request against the objectives of the mount. Windows are
+// evaluated when the first request after their end is recorded; the breaches
+// of the window which ended, if any, are returned.
+func (t *mountSLOTracker) record(slo *MountSLO, now time.Time, measurements map[string]int64) []sloBreach {
+	t.l.Lock()
+	defer t.l.Unlock()
+
+	// The objectives were changed while the request was handled
+	if t.config.Load() != slo {
+		return nil
+	}
+
+	var breaches []sloBreach
+	switch {
+	case t.windowStart.IsZero():
+		t.windowStart = now
+	case now.Sub(t.windowStart) >= slo.Window:
+		for indicator, count := range t.counts {
+			if count.Requests < uint64(slo.MinRequests) {
+				continue
+			}
+			if float64(count.Requests-count.Violations)/float64(count.Requests) < slo.Objective {
+				breaches = append(breaches, sloBreach{
+					indicator:   indicator,
+					count:       *count,
+					windowStart: t.windowStart,
+				})
+			}
+		}
+		sort.Slice(breaches, func(i, j int) bool {
+			return breaches[i].indicator < breaches[j].indicator
+		})
+		t.windowStart = now
+		t.counts = nil
+	}
+
+	if t.counts == nil {
+		t.counts = make(map[string]*sloCount)
+	}
+	for indicator, value := range measurements {
+		count, ok := t.counts[indicator]
+		if !ok {
+			count = &sloCount{}
+			t.counts[indicator] = count
+		}
+		count.Requests++
+		if value > slo.target(indicator) {
+			count.Violations++
+		}
+	}
+
+	return breaches
+}
+
+// status returns the measurements of the current window in a form suitable
+// for API responses.
+func (t *mountSLOTracker) status() map[string]interface{} {
+	t.l.Lock()
+	defer t.l.Unlock()
+
+	var windowStart string
+	if !t.windowStart.IsZero() {
+		windowStart = t.windowStart.UTC().Format(time.RFC3339)
+	}
+	counts := make(map[string]sloCount, len(t.counts))
+	for indicator, count := range t.counts {
+		counts[indicator] = *count
+	}
+
+	return map[string]interface{}{
+		"window_start": windowStart,
+		"indicators":   counts,
+	}
+}
+
+// recordMountSLO measures a request handled by the backend of the route entry
+// against the objectives of its mount, and alerts on the objectives breached
+// through metrics, the log and the event bus.
+func (r *Router) recordMountSLO(ctx context.Context, re *routeEntry, req *logical.Request, resp *logical.Response, latency time.Duration) {
+	slo := re.slo.config.Load()
+	if slo == nil {
+		return
+	}
+
+	measurements := make(map[string]int64, 2)
+	switch req.Operation {
+	case logical.ReadOperation, logical.ListOperation:
+		if slo.ReadLatency > 0 {
+			measurements[sloIndicatorReadLatency] = int64(latency)
+		}
+	case logical.CreateOperation, logical.UpdateOperation, logical.PatchOperation, logical.DeleteOperation:
+		if slo.WriteLatency > 0 {
+			measurements[sloIndicatorWriteLatency] = int64(latency)
+		}
+	default:
+		// Rollbacks, renewals, revocations and the like are not measured
+		return
+	}
+	if slo.MaxRequestSize > 0 {
+		measurements[sloIndicatorRequestSize] = requestSize(req)
+	}
+	if slo.MaxResponseSize > 0 && resp != nil && resp.Data != nil {
+		if body, err := json.Marshal(resp.Data); err == nil {
+			measurements[sloIndicatorResponseSize] = int64(len(body))
+		}
+	}
+
+	for _, breach := range re.slo.record(slo, time.Now(), measurements) {
+		r.alertMountSLOBreach(ctx, re.mountEntry, req.MountPoint, slo, breach)
+	}
+}
+
+// requestSize returns the size in bytes of the body of the request.
+func requestSize(req *logical.Request) int64 {
+	if req.HTTPRequest != nil && req.HTTPRequest.ContentLength >= 0 {
+		return req.HTTPRequest.ContentLength
+	}
+	if len(req.Data) == 0 {
+		return 0
+	}
+	body, err := json.Marshal(req.Data)
+	if err != nil {
+		return 0
+	}
+	return int64(len(body))
+}
+
+func (r *Router) alertMountSLOBreach(ctx context.Context, entry *MountEntry, mountPoint string, slo *MountSLO, breach sloBreach) {
+	metrics.IncrCounterWithLabels([]string{"route", "slo", "breach"}, 1, []metrics.Label{
+		{Name: "mount_point", Value: mountPoint},
+		{Name: "indicator", Value: breach.indicator},
+	})
+
+	r.logger.Warn("mount missed its service level objective",
+		"path", mountPoint, "indicator", breach.indicator,
+		"requests", breach.count.Requests, "violations", breach.count.Violations,
+		"objective", slo.Objective, "window_start", breach.windowStart)
+
+	if r.events == nil || entry.namespace == nil {
+		return
+	}
+
+	ev, err := logical.NewEvent()
+	if err != nil {
+		r.logger.Error("failed to create mount SLO breach event", "error", err)
+		return
+	}
+	target := strconv.FormatInt(slo.target(breach.indicator), 10)
+	switch breach.indicator {
+	case sloIndicatorReadLatency, sloIndicatorWriteLatency:
+		target = time.Duration(slo.target(breach.indicator)).String()
+	}
+	ev.Metadata = &structpb.Struct{Fields: map[string]*structpb.Value{
+		"indicator":    structpb.NewStringValue(breach.indicator),
+		"target":       structpb.NewStringValue(target),
+		"requests":     structpb.NewStringValue(strconv.FormatUint(breach.count.Requests, 10)),
+		"violations":   structpb.NewStringValue(strconv.FormatUint(breach.count.Violations, 10)),
+		"objective":    structpb.NewStringValue(strconv.FormatFloat(slo.Objective, 'f', -1, 64)),
+		"window":       structpb.NewStringValue(slo.Window.String()),
+		"window_start": structpb.NewStringValue(breach.windowStart.UTC().Format(time.RFC3339)),
+	}}
+
+	err = r.events.SendEventInternal(ctx, entry.namespace, &logical.EventPluginInfo{
+		MountClass:    entry.MountClass(),
+		MountAccessor: entry.Accessor,
+		MountPath:     entry.Path,
+		Plugin:        entry.Type,
+		Version:       entry.Options["version"],
+	}, mountSLOBreachEventType, ev)
+	if err != nil {
+		r.logger.Error("failed to send mount SLO breach event", "path", mountPoint, "error", err)
+	}
+}
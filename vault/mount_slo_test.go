@@ -0,0 +1,62 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMountSLOTracker_Record(t *testing.T) {
+	slo := &MountSLO{
+		ReadLatency: 100 * time.Millisecond,
+		Window:      time.Minute,
+		Objective:   0.9,
+		MinRequests: 5,
+	}
+
+	var tracker mountSLOTracker
+	tracker.setConfig(slo)
+
+	start := time.Now()
+	record := func(at time.Duration, latency time.Duration) []sloBreach {
+		return tracker.record(slo, start.Add(at), map[string]int64{
+			sloIndicatorReadLatency: int64(latency),
+		})
+	}
+
+	// 1 slow request out of 10 meets the objective
+	for i := 0; i < 9; i++ {
+		require.Empty(t, record(0, 10*time.Millisecond))
+	}
+	require.Empty(t, record(time.Second, time.Second))
+
+	// 2 slow requests out of 10 do not, which is reported by the first
+	// request after the window ends
+	require.Empty(t, record(time.Minute, time.Second))
+	for i := 0; i < 8; i++ {
+		require.Empty(t, record(time.Minute, 10*time.Millisecond))
+	}
+	require.Empty(t, record(time.Minute, time.Second))
+
+	breaches := record(2*time.Minute, 10*time.Millisecond)
+	require.Len(t, breaches, 1)
+	require.Equal(t, sloIndicatorReadLatency, breaches[0].indicator)
+	require.Equal(t, sloCount{Requests: 10, Violations: 2}, breaches[0].count)
+	require.Equal(t, start.Add(time.Minute), breaches[0].windowStart)
+
+	// Windows with less than the minimum number of requests are not evaluated
+	for i := 0; i < 3; i++ {
+		require.Empty(t, record(2*time.Minute, time.Second))
+	}
+	require.Empty(t, record(3*time.Minute, 10*time.Millisecond))
+
+	// Requests measured against objectives which have since been replaced
+	// are ignored
+	tracker.setConfig(&MountSLO{ReadLatency: time.Second, Window: time.Minute, Objective: 0.9, MinRequests: 1})
+	require.Empty(t, record(4*time.Minute, time.Second))
+	require.Empty(t, tracker.status()["indicators"])
+}
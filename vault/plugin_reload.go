@@ -263,9 +263,11 @@ func (c *Core) reloadBackendCommon(ctx context.Context, entry *MountEntry, isAut
 		// for initialization unless the plugin process is killed. Reload of a v5 backend
 		// results in a new plugin process, so we must initialize the backend here.
 		err := backend.Initialize(ctx, &logical.InitializationRequest{Storage: view})
+		re.health.recordInitError(err)
 		if err != nil {
 			return err
 		}
+		re.deferredInit.cancel()
 
 		// Set paths as well
 		paths := backend.SpecialPaths()
@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"path"
 	"strings"
+	"time"
 
 	log "github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/go-memdb"
@@ -128,6 +129,10 @@ var _ Access = (*access)(nil)
 // access implements the Access interface
 type access struct {
 	quotaID string
+
+	// expireLeases holds the leases a lease count quota expires to allow the
+	// request, once it has generated a lease.
+	expireLeases []string
 }
 
 // QuotaID returns the identifier of the quota rule to which this access refers
@@ -176,6 +181,9 @@ type QuotaLeaseInformation struct {
 	// We need the role as it's not part of the leaseId, and is required
 	// to uniquely identify a lease count quota
 	Role string
+
+	// IssueTime is used to find the oldest leases held by a lease count quota
+	IssueTime time.Time
 }
 
 // Quota represents the common properties of every quota type
@@ -223,6 +231,12 @@ type Response struct {
 	// Headers defines any optional headers that may be returned by the quota rule
 	// to clients.
 	Headers map[string]string
+
+	// ExpireLeases holds the IDs of the leases expired to allow the request.
+	// This is only set by lease count quotas which expire their oldest leases
+	// once the limit is reached, and the leases are only revoked once the
+	// request is acknowledged as having generated a lease.
+	ExpireLeases []string
 }
 
 // Config holds operator preferences around quota behaviors
@@ -269,6 +283,10 @@ type Request struct {
 	// ClientAddress is client unique addressable string (e.g. IP address). It can
 	// be empty if the quota type does not need it.
 	ClientAddress string
+
+	// Lease is the lease the request refers to when the quota manager is
+	// informed about leases, or walks them. It is empty for regular requests.
+	Lease *QuotaLeaseInformation
 }
 
 // NewManager creates and initializes a new quota manager to hold all the quota
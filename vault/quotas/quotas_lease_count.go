@@ -0,0 +1,336 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package quotas
+
+import (
+	"container/heap"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/armon/go-metrics"
+	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/helper/metricsutil"
+	"github.com/hashicorp/vault/sdk/helper/cryptoutil"
+)
+
+// Ensure that LeaseCountQuota implements the Quota interface
+var _ Quota = (*LeaseCountQuota)(nil)
+
+// LeaseCountQuota represents the quota rule properties that is used to limit the
+// number of leases held by a namespace, mount, path or login role.
+type LeaseCountQuota struct {
+	// ID is the identifier of the quota
+	ID string `json:"id"`
+
+	// Type of quota this represents
+	Type Type `json:"type"`
+
+	// Name of the quota rule
+	Name string `json:"name"`
+
+	// NamespacePath is the path of the namespace to which this quota is
+	// applicable.
+	NamespacePath string `json:"namespace_path"`
+
+	// MountPath is the path of the mount to which this quota is applicable
+	MountPath string `json:"mount_path"`
+
+	// Role is the role on an auth mount to apply the quota to upon /login requests
+	// Not applicable for use with path suffixes
+	Role string `json:"role"`
+
+	// PathSuffix is the path suffix to which this quota is applicable
+	PathSuffix string `json:"path_suffix"`
+
+	// Inheritable indicates whether the quota will be inherited by child namespaces
+	Inheritable bool `json:"inheritable"`
+
+	// MaxLeases is the maximum number of leases allowed by the quota.
+	MaxLeases int `json:"max_leases"`
+
+	// ExpireOldest, if set, makes requests which would exceed MaxLeases expire
+	// the oldest lease counted by the quota instead of being rejected.
+	ExpireOldest bool `json:"expire_oldest"`
+
+	lock       *sync.Mutex
+	leases     leaseHeap
+	leaseIndex map[string]*quotaLease
+	expiring   map[string]*quotaLease
+	pending    int
+	logger     log.Logger
+	metricSink *metricsutil.ClusterMetricSink
+}
+
+// quotaLease is a lease counted by a lease count quota.
+type quotaLease struct {
+	id        string
+	issueTime time.Time
+	index     int
+}
+
+// leaseHeap orders the leases counted by a quota from the oldest to the newest,
+// so that the oldest one can be expired.
+type leaseHeap []*quotaLease
+
+func (h leaseHeap) Len() int           { return len(h) }
+func (h leaseHeap) Less(i, j int) bool { return h[i].issueTime.Before(h[j].issueTime) }
+func (h leaseHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *leaseHeap) Push(x interface{}) {
+	lease := x.(*quotaLease)
+	lease.index = len(*h)
+	*h = append(*h, lease)
+}
+
+func (h *leaseHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	lease := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return lease
+}
+
+func (q *LeaseCountQuota) GetNamespacePath() string {
+	return q.NamespacePath
+}
+
+// NewLeaseCountQuota creates a quota checker for imposing limits on the number
+// of leases held by a namespace, mount, path or login role. If expireOldest is
+// set, reaching the limit expires the oldest lease rather than rejecting new
+// ones.
+func NewLeaseCountQuota(name, nsPath, mountPath, pathSuffix, role string, inheritable bool, maxLeases int, expireOldest bool) *LeaseCountQuota {
+	id, err := uuid.GenerateUUID()
+	if err != nil {
+		// Fall back to generating with a hash of the name, later in initialize
+		id = ""
+	}
+	return &LeaseCountQuota{
+		Name:          name,
+		ID:            id,
+		Type:          TypeLeaseCount,
+		NamespacePath: nsPath,
+		MountPath:     mountPath,
+		Role:          role,
+		PathSuffix:    pathSuffix,
+		Inheritable:   inheritable,
+		MaxLeases:     maxLeases,
+		ExpireOldest:  expireOldest,
+	}
+}
+
+func (q *LeaseCountQuota) Clone() Quota {
+	return &LeaseCountQuota{
+		ID:            q.ID,
+		Name:          q.Name,
+		MountPath:     q.MountPath,
+		Role:          q.Role,
+		Inheritable:   q.Inheritable,
+		Type:          q.Type,
+		NamespacePath: q.NamespacePath,
+		PathSuffix:    q.PathSuffix,
+		MaxLeases:     q.MaxLeases,
+		ExpireOldest:  q.ExpireOldest,
+	}
+}
+
+func (q *LeaseCountQuota) IsInheritable() bool {
+	return q.Inheritable
+}
+
+// initialize ensures the namespace and max leases are initialized, sets the ID
+// if it's currently empty, and resets the lease counter. The counter is
+// populated again by the quota manager.
+func (q *LeaseCountQuota) initialize(logger log.Logger, ms *metricsutil.ClusterMetricSink) error {
+	if q.lock == nil {
+		q.lock = new(sync.Mutex)
+	}
+
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	// Memdb requires a non-empty value for indexing
+	if q.NamespacePath == "" {
+		q.NamespacePath = "root"
+	}
+
+	if q.MaxLeases <= 0 {
+		return fmt.Errorf("invalid max leases: %v", q.MaxLeases)
+	}
+
+	if logger != nil {
+		q.logger = logger
+	}
+
+	if q.metricSink == nil {
+		q.metricSink = ms
+	}
+
+	if q.ID == "" {
+		q.ID = hex.EncodeToString(cryptoutil.Blake2b256Hash(q.Name))
+	}
+
+	q.resetLocked()
+	q.metricSink.SetGaugeWithLabels([]string{"quota", "lease_count", "max"}, float32(q.MaxLeases), q.metricLabels())
+
+	return nil
+}
+
+func (q *LeaseCountQuota) metricLabels() []metrics.Label {
+	return []metrics.Label{{Name: "name", Value: q.Name}}
+}
+
+// resetLocked forgets all the counted leases. It must be called with the lock
+// held.
+func (q *LeaseCountQuota) resetLocked() {
+	q.leases = nil
+	q.leaseIndex = make(map[string]*quotaLease)
+	q.expiring = make(map[string]*quotaLease)
+	q.pending = 0
+}
+
+// countLocked returns the number of leases counted against the quota, including
+// the ones reserved by requests in flight. It must be called with the lock
+// held.
+func (q *LeaseCountQuota) countLocked() int {
+	return len(q.leases) + len(q.expiring) + q.pending
+}
+
+// LeaseCount returns the number of leases counted against the quota.
+func (q *LeaseCountQuota) LeaseCount() int {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return len(q.leases) + len(q.expiring)
+}
+
+// addLease counts the lease against the quota. Adding a lease which is already
+// counted is a no-op.
+func (q *LeaseCountQuota) addLease(leaseID string, issueTime time.Time) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if _, ok := q.leaseIndex[leaseID]; ok {
+		return
+	}
+	if _, ok := q.expiring[leaseID]; ok {
+		return
+	}
+
+	lease := &quotaLease{id: leaseID, issueTime: issueTime}
+	q.leaseIndex[leaseID] = lease
+	heap.Push(&q.leases, lease)
+
+	q.metricSink.SetGaugeWithLabels([]string{"quota", "lease_count", "counter"}, float32(len(q.leases)+len(q.expiring)), q.metricLabels())
+}
+
+// removeLease stops counting the lease against the quota.
+func (q *LeaseCountQuota) removeLease(leaseID string) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if lease, ok := q.leaseIndex[leaseID]; ok {
+		heap.Remove(&q.leases, lease.index)
+		delete(q.leaseIndex, leaseID)
+	} else if _, ok := q.expiring[leaseID]; ok {
+		delete(q.expiring, leaseID)
+	} else {
+		return
+	}
+
+	q.metricSink.SetGaugeWithLabels([]string{"quota", "lease_count", "counter"}, float32(len(q.leases)+len(q.expiring)), q.metricLabels())
+}
+
+// release gives back the lease reserved by an allowed request, once the
+// request has completed. If the request generated a lease, it has been counted
+// by then.
+func (q *LeaseCountQuota) release() {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if q.pending > 0 {
+		q.pending--
+	}
+}
+
+// restoreLease counts the lease, which the quota has expired to allow a
+// request, as a regular lease again. This is done when the request did not
+// generate a lease, or when the lease could not be revoked.
+func (q *LeaseCountQuota) restoreLease(leaseID string) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	lease, ok := q.expiring[leaseID]
+	if !ok {
+		return
+	}
+	delete(q.expiring, leaseID)
+	q.leaseIndex[leaseID] = lease
+	heap.Push(&q.leases, lease)
+}
+
+// quotaID returns the identifier of the quota rule
+func (q *LeaseCountQuota) quotaID() string {
+	return q.ID
+}
+
+// QuotaName returns the name of the quota rule
+func (q *LeaseCountQuota) QuotaName() string {
+	return q.Name
+}
+
+// allow decides if the request is allowed by the quota. An allowed request
+// reserves a lease until it is acknowledged, so that concurrent requests
+// cannot exceed the quota. When the quota is reached and ExpireOldest is set,
+// the request is allowed and the oldest lease is returned to be expired once
+// the request has generated a lease.
+func (q *LeaseCountQuota) allow(_ context.Context, _ *Request) (Response, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	resp := Response{
+		Access: &access{quotaID: q.ID},
+	}
+
+	if q.countLocked() < q.MaxLeases {
+		q.pending++
+		resp.Allowed = true
+		return resp, nil
+	}
+
+	if q.ExpireOldest && len(q.leases) > 0 {
+		oldest := heap.Pop(&q.leases).(*quotaLease)
+		delete(q.leaseIndex, oldest.id)
+		q.expiring[oldest.id] = oldest
+
+		q.pending++
+		resp.Allowed = true
+		resp.Access = &access{quotaID: q.ID, expireLeases: []string{oldest.id}}
+		resp.ExpireLeases = []string{oldest.id}
+		q.metricSink.IncrCounterWithLabels([]string{"quota", "lease_count", "expired"}, 1, q.metricLabels())
+		return resp, nil
+	}
+
+	resp.Access = nil
+	q.metricSink.IncrCounterWithLabels([]string{"quota", "lease_count", "violation"}, 1, q.metricLabels())
+	return resp, nil
+}
+
+// close is a no-op for lease count quotas.
+func (q *LeaseCountQuota) close(_ context.Context) error {
+	return nil
+}
+
+func (q *LeaseCountQuota) handleRemount(mountpath, nspath string) {
+	q.MountPath = mountpath
+	q.NamespacePath = nspath
+}
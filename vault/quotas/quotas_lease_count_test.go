@@ -0,0 +1,246 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package quotas
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/helper/metricsutil"
+	"github.com/hashicorp/vault/sdk/helper/logging"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewLeaseCountQuota(t *testing.T) {
+	testCases := []struct {
+		name      string
+		lcq       *LeaseCountQuota
+		expectErr bool
+	}{
+		{"valid max leases", NewLeaseCountQuota("test-lease-count", "qa", "/foo/bar", "", "", false, 10, false), false},
+		{"invalid max leases", NewLeaseCountQuota("test-lease-count", "qa", "/foo/bar", "", "", false, 0, false), true},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.lcq.initialize(logging.NewVaultLogger(log.Trace), metricsutil.BlackholeSink())
+			require.Equal(t, tc.expectErr, err != nil, err)
+		})
+	}
+}
+
+// TestLeaseCountQuota_Allow checks that requests are rejected once the leases
+// and the reservations of requests in flight reach the limit.
+func TestLeaseCountQuota_Allow(t *testing.T) {
+	ctx := context.Background()
+	lcq := NewLeaseCountQuota("test-lease-count", "", "", "", "", true, 2, false)
+	require.NoError(t, lcq.initialize(logging.NewVaultLogger(log.Trace), metricsutil.BlackholeSink()))
+
+	resp, err := lcq.allow(ctx, &Request{})
+	require.NoError(t, err)
+	require.True(t, resp.Allowed)
+	require.NotNil(t, resp.Access)
+
+	resp, err = lcq.allow(ctx, &Request{})
+	require.NoError(t, err)
+	require.True(t, resp.Allowed)
+
+	// Both leases are reserved by requests in flight
+	resp, err = lcq.allow(ctx, &Request{})
+	require.NoError(t, err)
+	require.False(t, resp.Allowed)
+	require.Nil(t, resp.Access)
+
+	// The first request generated a lease, the second one did not
+	lcq.addLease("foo/1", time.Now())
+	lcq.release()
+	lcq.release()
+	require.Equal(t, 1, lcq.LeaseCount())
+
+	resp, err = lcq.allow(ctx, &Request{})
+	require.NoError(t, err)
+	require.True(t, resp.Allowed)
+	lcq.addLease("foo/2", time.Now())
+	lcq.release()
+
+	resp, err = lcq.allow(ctx, &Request{})
+	require.NoError(t, err)
+	require.False(t, resp.Allowed)
+
+	lcq.removeLease("foo/1")
+	require.Equal(t, 1, lcq.LeaseCount())
+
+	resp, err = lcq.allow(ctx, &Request{})
+	require.NoError(t, err)
+	require.True(t, resp.Allowed)
+}
+
+// TestLeaseCountQuota_ExpireOldest checks that the oldest leases are expired
+// once the limit is reached when expire_oldest is set.
+func TestLeaseCountQuota_ExpireOldest(t *testing.T) {
+	ctx := context.Background()
+	lcq := NewLeaseCountQuota("test-lease-count", "", "", "", "", true, 2, true)
+	require.NoError(t, lcq.initialize(logging.NewVaultLogger(log.Trace), metricsutil.BlackholeSink()))
+
+	now := time.Now()
+	lcq.addLease("foo/2", now.Add(-time.Minute))
+	lcq.addLease("foo/1", now.Add(-time.Hour))
+
+	resp, err := lcq.allow(ctx, &Request{})
+	require.NoError(t, err)
+	require.True(t, resp.Allowed)
+	require.Equal(t, []string{"foo/1"}, resp.ExpireLeases)
+
+	// The expired lease is counted until it is revoked
+	lcq.addLease("foo/3", now)
+	lcq.release()
+	require.Equal(t, 3, lcq.LeaseCount())
+	lcq.removeLease("foo/1")
+	require.Equal(t, 2, lcq.LeaseCount())
+
+	resp, err = lcq.allow(ctx, &Request{})
+	require.NoError(t, err)
+	require.True(t, resp.Allowed)
+	require.Equal(t, []string{"foo/2"}, resp.ExpireLeases)
+}
+
+// TestQuotas_LeaseCount_Manager checks that the manager counts the existing
+// leases when a quota is created, and keeps the counters up to date as leases
+// are created and deleted.
+func TestQuotas_LeaseCount_Manager(t *testing.T) {
+	ctx := context.Background()
+	leases := []*QuotaLeaseInformation{
+		{LeaseId: "auth/userpass/login/foo/1", IssueTime: time.Now()},
+		{LeaseId: "auth/userpass/login/foo/2", IssueTime: time.Now()},
+		{LeaseId: "secret/creds/foo/3", IssueTime: time.Now()},
+	}
+	leaseRequest := func(lease *QuotaLeaseInformation) *Request {
+		path := lease.LeaseId[:len(lease.LeaseId)-2]
+		mountPath := "secret/"
+		if path != "secret/creds/foo" {
+			mountPath = "auth/userpass/"
+		}
+		return &Request{
+			Path:          path,
+			MountPath:     mountPath,
+			NamespacePath: "root",
+			Lease:         lease,
+		}
+	}
+	leaseWalkFunc := func(_ context.Context, cb func(request *Request) bool) error {
+		for _, lease := range leases {
+			if !cb(leaseRequest(lease)) {
+				return nil
+			}
+		}
+		return nil
+	}
+
+	qm, err := NewManager(logging.NewVaultLogger(log.Trace), leaseWalkFunc, metricsutil.BlackholeSink(), true)
+	require.NoError(t, err)
+	require.NoError(t, qm.Setup(ctx, &logical.InmemStorage{}, nil))
+
+	quota := NewLeaseCountQuota("lcq", "root", "auth/userpass/", "", "", false, 3, false)
+	require.NoError(t, qm.SetQuota(ctx, TypeLeaseCount.String(), quota, false))
+	require.Equal(t, 2, quota.LeaseCount())
+
+	req := &Request{Type: TypeLeaseCount, Path: "auth/userpass/login/foo", MountPath: "auth/userpass/", NamespacePath: "root"}
+	resp, err := qm.ApplyQuota(ctx, req)
+	require.NoError(t, err)
+	require.True(t, resp.Allowed)
+
+	lease := &QuotaLeaseInformation{LeaseId: "auth/userpass/login/foo/4", IssueTime: time.Now()}
+	require.NoError(t, qm.HandleLease(LeaseActionCreated, leaseRequest(lease)))
+	require.NoError(t, qm.AckLease(resp.Access, true, nil))
+	require.Equal(t, 3, quota.LeaseCount())
+
+	resp, err = qm.ApplyQuota(ctx, req)
+	require.NoError(t, err)
+	require.False(t, resp.Allowed)
+
+	require.NoError(t, qm.HandleLease(LeaseActionDeleted, leaseRequest(leases[0])))
+	require.Equal(t, 2, quota.LeaseCount())
+
+	// Requests to paths which are not known to create leases are allowed
+	resp, err = qm.ApplyQuota(ctx, &Request{Type: TypeLeaseCount, Path: "auth/userpass/users/foo", MountPath: "auth/userpass/", NamespacePath: "root"})
+	require.NoError(t, err)
+	require.True(t, resp.Allowed)
+	require.Nil(t, resp.Access)
+
+	for i := 0; i < 2; i++ {
+		require.NoError(t, qm.HandleLease(LeaseActionCreated, leaseRequest(&QuotaLeaseInformation{LeaseId: fmt.Sprintf("secret/creds/foo/%d", i+5)})))
+	}
+	require.Equal(t, 2, quota.LeaseCount())
+}
+
+// TestQuotas_LeaseCount_ExpireOldestAck checks that the oldest lease is only
+// revoked once the request allowed by expiring it has generated a lease, and
+// that it is counted again when the request did not or revoking it failed.
+func TestQuotas_LeaseCount_ExpireOldestAck(t *testing.T) {
+	ctx := context.Background()
+	qm, err := NewManager(logging.NewVaultLogger(log.Trace), nil, metricsutil.BlackholeSink(), true)
+	require.NoError(t, err)
+	require.NoError(t, qm.Setup(ctx, &logical.InmemStorage{}, nil))
+
+	quota := NewLeaseCountQuota("lcq", "root", "secret/", "", "", false, 1, true)
+	require.NoError(t, qm.SetQuota(ctx, TypeLeaseCount.String(), quota, false))
+
+	leaseRequest := func(leaseID string) *Request {
+		return &Request{
+			Path:          "secret/creds/foo",
+			MountPath:     "secret/",
+			NamespacePath: "root",
+			Lease:         &QuotaLeaseInformation{LeaseId: leaseID, IssueTime: time.Now()},
+		}
+	}
+	require.NoError(t, qm.HandleLease(LeaseActionCreated, leaseRequest("secret/creds/foo/1")))
+	require.Equal(t, 1, quota.LeaseCount())
+
+	req := &Request{Type: TypeLeaseCount, Path: "secret/creds/foo", MountPath: "secret/", NamespacePath: "root"}
+	var revoked []string
+	revokeFunc := func(leaseID string) error {
+		revoked = append(revoked, leaseID)
+		return nil
+	}
+
+	// The request did not generate a lease, so the oldest one is kept
+	resp, err := qm.ApplyQuota(ctx, req)
+	require.NoError(t, err)
+	require.True(t, resp.Allowed)
+	require.Equal(t, []string{"secret/creds/foo/1"}, resp.ExpireLeases)
+	require.NoError(t, qm.AckLease(resp.Access, false, revokeFunc))
+	require.Empty(t, revoked)
+	require.Equal(t, 1, quota.LeaseCount())
+
+	// Revoking the oldest lease failed, so it is still counted and expired by
+	// the next request
+	resp, err = qm.ApplyQuota(ctx, req)
+	require.NoError(t, err)
+	require.True(t, resp.Allowed)
+	require.NoError(t, qm.HandleLease(LeaseActionCreated, leaseRequest("secret/creds/foo/2")))
+	require.NoError(t, qm.AckLease(resp.Access, true, func(string) error {
+		return errors.New("revocation failed")
+	}))
+	require.Equal(t, 2, quota.LeaseCount())
+
+	resp, err = qm.ApplyQuota(ctx, req)
+	require.NoError(t, err)
+	require.True(t, resp.Allowed)
+	require.Equal(t, []string{"secret/creds/foo/1"}, resp.ExpireLeases)
+	require.NoError(t, qm.HandleLease(LeaseActionCreated, leaseRequest("secret/creds/foo/3")))
+	require.NoError(t, qm.AckLease(resp.Access, true, revokeFunc))
+	require.Equal(t, []string{"secret/creds/foo/1"}, revoked)
+
+	// The lease is counted until the expiration manager deletes it
+	require.Equal(t, 3, quota.LeaseCount())
+	require.NoError(t, qm.HandleLease(LeaseActionDeleted, leaseRequest("secret/creds/foo/1")))
+	require.Equal(t, 2, quota.LeaseCount())
+}
@@ -7,78 +7,171 @@ package quotas
 
 import (
 	"context"
+	"sync"
 
-	log "github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/go-memdb"
-	"github.com/hashicorp/vault/helper/metricsutil"
 )
 
 func quotaTypes() []string {
 	return []string{
+		TypeLeaseCount.String(),
 		TypeRateLimit.String(),
 	}
 }
 
-func (m *Manager) init(walkFunc leaseWalkFunc) {}
+func (m *Manager) init(walkFunc leaseWalkFunc) {
+	m.walkFunc = walkFunc
+	m.leasePathCache = make(map[string]struct{})
+}
 
+// recomputeLeaseCounts resets the counters of all the lease count quotas in the
+// transaction, and counts the existing leases against them again. It must be
+// called with the quota lock held.
 func (m *Manager) recomputeLeaseCounts(ctx context.Context, txn *memdb.Txn) error {
-	return nil
-}
+	iter, err := txn.Get(TypeLeaseCount.String(), indexID)
+	if err != nil {
+		return err
+	}
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		lcq := raw.(*LeaseCountQuota)
+		lcq.lock.Lock()
+		lcq.resetLocked()
+		lcq.lock.Unlock()
+	}
 
-func (m *Manager) setIsPerfStandby(quota Quota) {}
+	if m.walkFunc == nil {
+		return nil
+	}
 
-func (m *Manager) inLeasePathCache(path string) bool {
-	return false
+	var queryErr error
+	err = m.walkFunc(ctx, func(req *Request) bool {
+		if req.Lease == nil {
+			return true
+		}
+		m.addLeasePath(req.Path)
+
+		req.Type = TypeLeaseCount
+		quota, err := m.queryQuota(txn, req)
+		if err != nil {
+			queryErr = err
+			return false
+		}
+		if quota != nil {
+			quota.(*LeaseCountQuota).addLease(req.Lease.LeaseId, req.Lease.IssueTime)
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	return queryErr
 }
 
-func (m *Manager) setupDefaultLeaseCountQuotaInStorage(_ctx context.Context) error {
+// HandleLease updates the lease count quota applicable to the request with the
+// action taken on its lease by the expiration manager.
+func (m *Manager) HandleLease(action LeaseAction, req *Request) error {
+	if req.Lease == nil {
+		return nil
+	}
+
+	m.dbAndCacheLock.RLock()
+	defer m.dbAndCacheLock.RUnlock()
+
+	if action == LeaseActionCreated || action == LeaseActionLoaded {
+		m.addLeasePath(req.Path)
+	}
+
+	req.Type = TypeLeaseCount
+	quota, err := m.queryQuota(nil, req)
+	if err != nil {
+		return err
+	}
+	if quota == nil {
+		return nil
+	}
+
+	lcq := quota.(*LeaseCountQuota)
+	switch action {
+	case LeaseActionCreated, LeaseActionLoaded:
+		lcq.addLease(req.Lease.LeaseId, req.Lease.IssueTime)
+	case LeaseActionDeleted:
+		lcq.removeLease(req.Lease.LeaseId)
+	}
 	return nil
 }
 
-type entManager struct {
-	isPerfStandby bool
-	isDRSecondary bool
-	isNewInstall  bool
-}
+// AckLease releases the lease reserved by a lease count quota when it allowed
+// a request, once the request has completed. If the quota expires its oldest
+// leases to allow the request, they are revoked with revokeFunc when the request
+// generated a lease, and counted again when it did not or when they could not
+// be revoked.
+func (m *Manager) AckLease(quotaAccess Access, leaseGenerated bool, revokeFunc func(leaseID string) error) error {
+	quota, err := m.QuotaByID(TypeLeaseCount.String(), quotaAccess.QuotaID())
+	if err != nil {
+		return err
+	}
+	if quota == nil {
+		return nil
+	}
+
+	lcq := quota.(*LeaseCountQuota)
+	lcq.release()
 
-func (*entManager) Reset() error {
+	a, ok := quotaAccess.(*access)
+	if !ok {
+		return nil
+	}
+	for _, leaseID := range a.expireLeases {
+		if !leaseGenerated {
+			lcq.restoreLease(leaseID)
+			continue
+		}
+		if err := revokeFunc(leaseID); err != nil {
+			m.logger.Error("failed to expire lease held by lease count quota", "lease_id", leaseID, "error", err)
+			lcq.restoreLease(leaseID)
+		}
+	}
 	return nil
 }
 
-type LeaseCountQuota struct{}
+func (m *Manager) setIsPerfStandby(quota Quota) {}
 
-func (l LeaseCountQuota) GetNamespacePath() string {
-	panic("implement me")
-}
+func (m *Manager) addLeasePath(path string) {
+	m.leasePathCacheLock.Lock()
+	defer m.leasePathCacheLock.Unlock()
 
-func (l LeaseCountQuota) IsInheritable() bool {
-	panic("implement me")
+	m.leasePathCache[path] = struct{}{}
 }
 
-func (l LeaseCountQuota) allow(_ context.Context, _ *Request) (Response, error) {
-	panic("implement me")
-}
+// inLeasePathCache checks whether requests to the path are known to generate
+// leases. Lease count quotas are only applied to such requests.
+func (m *Manager) inLeasePathCache(path string) bool {
+	m.leasePathCacheLock.RLock()
+	defer m.leasePathCacheLock.RUnlock()
 
-func (l LeaseCountQuota) quotaID() string {
-	panic("implement me")
+	_, ok := m.leasePathCache[path]
+	return ok
 }
 
-func (l LeaseCountQuota) QuotaName() string {
-	panic("implement me")
+func (m *Manager) setupDefaultLeaseCountQuotaInStorage(_ctx context.Context) error {
+	return nil
 }
 
-func (l LeaseCountQuota) initialize(logger log.Logger, sink *metricsutil.ClusterMetricSink) error {
-	panic("implement me")
-}
+type entManager struct {
+	isPerfStandby bool
+	isDRSecondary bool
+	isNewInstall  bool
 
-func (l LeaseCountQuota) close(_ context.Context) error {
-	panic("implement me")
-}
+	walkFunc leaseWalkFunc
 
-func (l LeaseCountQuota) Clone() Quota {
-	panic("implement me")
+	leasePathCacheLock sync.RWMutex
+	leasePathCache     map[string]struct{}
 }
 
-func (l LeaseCountQuota) handleRemount(mountPath, nsPath string) {
-	panic("implement me")
+func (e *entManager) Reset() error {
+	e.leasePathCacheLock.Lock()
+	defer e.leasePathCacheLock.Unlock()
+
+	e.leasePathCache = make(map[string]struct{})
+	return nil
 }
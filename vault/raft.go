@@ -336,6 +336,10 @@ func (c *Core) setupRaftActiveNode(ctx context.Context) error {
 		return err
 	}
 
+	if err := c.startRaftAutoSnapshots(c.activeContext); err != nil {
+		return err
+	}
+
 	autopilotConfig, err := c.loadAutopilotConfiguration(ctx)
 	if err != nil {
 		c.logger.Error("failed to load autopilot config from storage when setting up cluster; continuing since autopilot falls back to default config", "error", err)
@@ -391,6 +395,7 @@ func (c *Core) stopRaftActiveNode() {
 
 	c.pendingRaftPeers = nil
 	c.stopPeriodicRaftTLSRotate()
+	c.stopRaftAutoSnapshots()
 }
 
 func (c *Core) startPeriodicRaftTLSRotate(ctx context.Context) error {
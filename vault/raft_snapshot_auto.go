@@ -0,0 +1,287 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/physical/raft"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/hashicorp/vault/vault/autosnapshot"
+)
+
+const raftAutoSnapshotConfigStoragePrefix = "core/raft/snapshot-auto/config/"
+
+// raftAutoSnapshotStatus reports on the snapshots taken for a configuration.
+// The snapshot_* fields describe the most recent attempt, and the
+// last_snapshot_* fields the most recent successful one.
+type raftAutoSnapshotStatus struct {
+	SnapshotStart     time.Time
+	SnapshotURL       string
+	LastSnapshotStart time.Time
+	LastSnapshotEnd   time.Time
+	LastSnapshotURL   string
+	LastSnapshotError string
+}
+
+// raftAutoSnapshotManager takes snapshots of the raft storage on the active
+// node, according to the automated snapshot configurations.
+type raftAutoSnapshotManager struct {
+	core   *Core
+	logger hclog.Logger
+	ctx    context.Context
+
+	l        sync.Mutex
+	cancels  map[string]context.CancelFunc
+	statuses map[string]*raftAutoSnapshotStatus
+	wg       sync.WaitGroup
+}
+
+// loadRaftAutoSnapshotConfig reads the named automated snapshot configuration.
+func (c *Core) loadRaftAutoSnapshotConfig(ctx context.Context, name string) (*autosnapshot.Config, error) {
+	entry, err := c.barrier.Get(ctx, raftAutoSnapshotConfigStoragePrefix+name)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var config autosnapshot.Config
+	if err := entry.DecodeJSON(&config); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// startRaftAutoSnapshots schedules the automated snapshot configurations. It
+// is a no-op unless raft is used for storage.
+func (c *Core) startRaftAutoSnapshots(ctx context.Context) error {
+	if _, ok := c.underlyingPhysical.(*raft.RaftBackend); !ok {
+		return nil
+	}
+
+	m := &raftAutoSnapshotManager{
+		core:     c,
+		logger:   c.logger.Named("raft.snapshot-auto"),
+		ctx:      ctx,
+		cancels:  make(map[string]context.CancelFunc),
+		statuses: make(map[string]*raftAutoSnapshotStatus),
+	}
+	c.AddLogger(m.logger)
+
+	names, err := c.barrier.List(ctx, raftAutoSnapshotConfigStoragePrefix)
+	if err != nil {
+		return fmt.Errorf("failed to list automated snapshot configurations: %w", err)
+	}
+	for _, name := range names {
+		config, err := c.loadRaftAutoSnapshotConfig(ctx, name)
+		if err != nil {
+			return fmt.Errorf("failed to load automated snapshot configuration %q: %w", name, err)
+		}
+		if config != nil {
+			m.schedule(name, config)
+		}
+	}
+
+	c.raftAutoSnapshots = m
+	return nil
+}
+
+// stopRaftAutoSnapshots stops taking automated snapshots, waiting for any
+// snapshot in progress to finish.
+func (c *Core) stopRaftAutoSnapshots() {
+	m := c.raftAutoSnapshots
+	if m == nil {
+		return
+	}
+	c.raftAutoSnapshots = nil
+
+	m.l.Lock()
+	for name, cancel := range m.cancels {
+		cancel()
+		delete(m.cancels, name)
+	}
+	m.l.Unlock()
+	m.wg.Wait()
+}
+
+// schedule starts taking snapshots for the named configuration, replacing any
+// previous schedule for it.
+func (m *raftAutoSnapshotManager) schedule(name string, config *autosnapshot.Config) {
+	m.l.Lock()
+	defer m.l.Unlock()
+
+	if cancel, ok := m.cancels[name]; ok {
+		cancel()
+	}
+	if _, ok := m.statuses[name]; !ok {
+		m.statuses[name] = &raftAutoSnapshotStatus{}
+	}
+
+	ctx, cancel := context.WithCancel(m.ctx)
+	m.cancels[name] = cancel
+
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+
+		ticker := time.NewTicker(config.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				m.snapshot(ctx, name, config)
+			}
+		}
+	}()
+}
+
+// unschedule stops taking snapshots for the named configuration.
+func (m *raftAutoSnapshotManager) unschedule(name string) {
+	m.l.Lock()
+	defer m.l.Unlock()
+
+	if cancel, ok := m.cancels[name]; ok {
+		cancel()
+	}
+	delete(m.cancels, name)
+	delete(m.statuses, name)
+}
+
+// status returns a copy of the status of the named configuration, or nil if
+// it isn't scheduled.
+func (m *raftAutoSnapshotManager) status(name string) *raftAutoSnapshotStatus {
+	m.l.Lock()
+	defer m.l.Unlock()
+
+	status, ok := m.statuses[name]
+	if !ok {
+		return nil
+	}
+	statusCopy := *status
+	return &statusCopy
+}
+
+// updateStatus applies f to the status of the named configuration, unless it
+// has been unscheduled in the meantime.
+func (m *raftAutoSnapshotManager) updateStatus(name string, f func(*raftAutoSnapshotStatus)) {
+	m.l.Lock()
+	defer m.l.Unlock()
+
+	if status, ok := m.statuses[name]; ok {
+		f(status)
+	}
+}
+
+// snapshot takes a snapshot for the named configuration, writes it to the
+// configured store and deletes the snapshots beyond the retention count.
+func (m *raftAutoSnapshotManager) snapshot(ctx context.Context, name string, config *autosnapshot.Config) {
+	start := time.Now()
+	snapshotName := config.SnapshotName(start)
+	logger := m.logger.With("config", name)
+
+	var url string
+	err := func() error {
+		store, err := autosnapshot.NewStore(ctx, config, logger)
+		if err != nil {
+			return fmt.Errorf("error configuring storage: %w", err)
+		}
+		url = store.URL(snapshotName)
+		m.updateStatus(name, func(status *raftAutoSnapshotStatus) {
+			status.SnapshotStart = start
+			status.SnapshotURL = url
+		})
+
+		raftBackend, ok := m.core.underlyingPhysical.(*raft.RaftBackend)
+		if !ok {
+			return fmt.Errorf("raft storage is not in use")
+		}
+
+		// Take the snapshot to a temporary file first, so that its size is
+		// known before writing it to the store.
+		f, err := os.CreateTemp("", "vault-snapshot-auto-*")
+		if err != nil {
+			return err
+		}
+		defer func() {
+			f.Close()
+			os.Remove(f.Name())
+		}()
+
+		sealer := NewSealAccessSealer(m.core.seal.GetAccess(), logger, "snapshot_auto")
+		if err := raftBackend.Snapshot(f, sealer); err != nil {
+			return fmt.Errorf("error taking snapshot: %w", err)
+		}
+		size, err := f.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return err
+		}
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+
+		if err := store.Put(ctx, snapshotName, f, size); err != nil {
+			return fmt.Errorf("error writing snapshot: %w", err)
+		}
+
+		deleted, err := autosnapshot.Prune(ctx, store, config.Retain)
+		if len(deleted) > 0 {
+			logger.Debug("deleted old snapshots", "snapshots", deleted)
+		}
+		if err != nil {
+			// The snapshot itself was written, so only log this.
+			logger.Error("error deleting old snapshots", "error", err)
+		}
+		return nil
+	}()
+
+	end := time.Now()
+	if err != nil {
+		logger.Error("automated snapshot failed", "error", err)
+	} else {
+		logger.Info("automated snapshot written", "url", url, "duration", end.Sub(start))
+	}
+
+	m.updateStatus(name, func(status *raftAutoSnapshotStatus) {
+		if err != nil {
+			status.LastSnapshotError = err.Error()
+			return
+		}
+		status.LastSnapshotError = ""
+		status.LastSnapshotStart = start
+		status.LastSnapshotEnd = end
+		status.LastSnapshotURL = url
+	})
+}
+
+// response returns the status as the data of an API response.
+func (s *raftAutoSnapshotStatus) response() *logical.Response {
+	formatTime := func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format(time.RFC3339)
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"snapshot_start":      formatTime(s.SnapshotStart),
+			"snapshot_url":        s.SnapshotURL,
+			"last_snapshot_start": formatTime(s.LastSnapshotStart),
+			"last_snapshot_end":   formatTime(s.LastSnapshotEnd),
+			"last_snapshot_url":   s.LastSnapshotURL,
+			"last_snapshot_error": s.LastSnapshotError,
+		},
+	}
+}
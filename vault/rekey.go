@@ -180,7 +180,13 @@ func (c *Core) BarrierRekeyInit(config *SealConfig) logical.HTTPCodedError {
 			c.logger.Warn("shamir stored keys supported, forcing rekey shares/threshold to 1")
 			config.StoredShares = 1
 		}
+		if config.RotateShares && c.seal.StoredKeysSupported() != seal.StoredKeysSupportedShamirRoot {
+			return logical.CodedError(http.StatusBadRequest, "share rotation not supported for legacy shamir seals; perform a regular rekey first")
+		}
 	default:
+		if config.RotateShares {
+			return logical.CodedError(http.StatusBadRequest, "share rotation is only supported for shamir seals")
+		}
 		if config.StoredShares != 1 {
 			c.logger.Warn("stored keys supported, forcing rekey shares/threshold to 1")
 			config.StoredShares = 1
@@ -248,6 +254,9 @@ func (c *Core) RecoveryRekeyInit(config *SealConfig) logical.HTTPCodedError {
 	if config.StoredShares > 0 {
 		return logical.CodedError(http.StatusBadRequest, "stored shares not supported by recovery key")
 	}
+	if config.RotateShares {
+		return logical.CodedError(http.StatusBadRequest, "share rotation not supported by recovery key; rekeying the recovery key never replaces the root key")
+	}
 
 	// Check if the seal configuration is valid
 	if err := config.Validate(); err != nil {
@@ -535,29 +544,50 @@ func (c *Core) performBarrierRekey(ctx context.Context, newSealKey []byte) logic
 		c.seal.SetCachedBarrierConfig(existingConfig)
 	}
 
-	if c.seal.StoredKeysSupported() != seal.StoredKeysSupportedGeneric {
-		err := c.seal.GetAccess().SetShamirSealKey(newSealKey)
+	if c.barrierRekeyConfig.RotateShares {
+		// Only the shares are being rotated: re-wrap the existing root key
+		// with the new unseal key, leaving the barrier keyring untouched.
+		// The root key must be read before the unseal key is replaced.
+		rootKeys, err := c.seal.GetStoredKeys(ctx)
 		if err != nil {
+			c.logger.Error("failed to read root key", "error", err)
+			return logical.CodedError(http.StatusInternalServerError, fmt.Errorf("failed to read root key: %w", err).Error())
+		}
+		if err := c.seal.GetAccess().SetShamirSealKey(newSealKey); err != nil {
 			return logical.CodedError(http.StatusInternalServerError, fmt.Errorf("failed to update barrier seal key: %w", err).Error())
 		}
-	}
+		if err := c.seal.SetStoredKeys(ctx, rootKeys); err != nil {
+			c.logger.Error("failed to store keys", "error", err)
+			return logical.CodedError(http.StatusInternalServerError, fmt.Errorf("failed to store keys: %w", err).Error())
+		}
+		if c.logger.IsInfo() {
+			c.logger.Info("unseal key shares rotated", "shares", c.barrierRekeyConfig.SecretShares, "threshold", c.barrierRekeyConfig.SecretThreshold)
+		}
+	} else {
+		if c.seal.StoredKeysSupported() != seal.StoredKeysSupportedGeneric {
+			err := c.seal.GetAccess().SetShamirSealKey(newSealKey)
+			if err != nil {
+				return logical.CodedError(http.StatusInternalServerError, fmt.Errorf("failed to update barrier seal key: %w", err).Error())
+			}
+		}
 
-	newRootKey, err := c.barrier.GenerateKey(c.secureRandomReader)
-	if err != nil {
-		return logical.CodedError(http.StatusInternalServerError, fmt.Errorf("failed to perform rekey: %w", err).Error())
-	}
-	if err := c.seal.SetStoredKeys(ctx, [][]byte{newRootKey}); err != nil {
-		c.logger.Error("failed to store keys", "error", err)
-		return logical.CodedError(http.StatusInternalServerError, fmt.Errorf("failed to store keys: %w", err).Error())
-	}
+		newRootKey, err := c.barrier.GenerateKey(c.secureRandomReader)
+		if err != nil {
+			return logical.CodedError(http.StatusInternalServerError, fmt.Errorf("failed to perform rekey: %w", err).Error())
+		}
+		if err := c.seal.SetStoredKeys(ctx, [][]byte{newRootKey}); err != nil {
+			c.logger.Error("failed to store keys", "error", err)
+			return logical.CodedError(http.StatusInternalServerError, fmt.Errorf("failed to store keys: %w", err).Error())
+		}
 
-	// Rekey the barrier
-	if err := c.barrier.Rekey(ctx, newRootKey); err != nil {
-		c.logger.Error("failed to rekey barrier", "error", err)
-		return logical.CodedError(http.StatusInternalServerError, fmt.Errorf("failed to rekey barrier: %w", err).Error())
-	}
-	if c.logger.IsInfo() {
-		c.logger.Info("security barrier rekeyed", "stored", c.barrierRekeyConfig.StoredShares, "shares", c.barrierRekeyConfig.SecretShares, "threshold", c.barrierRekeyConfig.SecretThreshold)
+		// Rekey the barrier
+		if err := c.barrier.Rekey(ctx, newRootKey); err != nil {
+			c.logger.Error("failed to rekey barrier", "error", err)
+			return logical.CodedError(http.StatusInternalServerError, fmt.Errorf("failed to rekey barrier: %w", err).Error())
+		}
+		if c.logger.IsInfo() {
+			c.logger.Info("security barrier rekeyed", "stored", c.barrierRekeyConfig.StoredShares, "shares", c.barrierRekeyConfig.SecretShares, "threshold", c.barrierRekeyConfig.SecretThreshold)
+		}
 	}
 
 	if len(newSealKey) > 0 {
@@ -590,6 +620,10 @@ func (c *Core) performBarrierRekey(ctx context.Context, newSealKey []byte) logic
 
 	c.barrierRekeyConfig.RekeyProgress = nil
 
+	if c.seal.BarrierSealConfigType() == SealConfigTypeShamir {
+		c.recordKeySharesRotated(ctx, false)
+	}
+
 	return nil
 }
 
@@ -792,6 +826,8 @@ func (c *Core) performRecoveryRekey(ctx context.Context, newRootKey []byte) logi
 
 	c.recoveryRekeyConfig.RekeyProgress = nil
 
+	c.recordKeySharesRotated(ctx, true)
+
 	return nil
 }
 
@@ -11,7 +11,9 @@ import (
 	"testing"
 
 	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/helper/namespace"
 	"github.com/hashicorp/vault/sdk/helper/logging"
+	"github.com/hashicorp/vault/sdk/logical"
 	"github.com/hashicorp/vault/sdk/physical"
 	"github.com/hashicorp/vault/sdk/physical/inmem"
 	"github.com/hashicorp/vault/vault/seal"
@@ -336,6 +338,105 @@ func testCore_Rekey_Update_Common_Error(t *testing.T, c *Core, keys [][]byte, ro
 	}
 }
 
+func TestCore_Rekey_RotateShares(t *testing.T) {
+	bc := &SealConfig{
+		SecretShares:    1,
+		SecretThreshold: 1,
+	}
+	c, masterKeys, _, root := TestCoreUnsealedWithConfigs(t, bc, nil)
+
+	// Write a value to ensure it survives the rotation
+	req := logical.TestRequest(t, logical.UpdateOperation, "cubbyhole/test")
+	req.Data["foo"] = "bar"
+	req.ClientToken = root
+	if _, err := c.HandleRequest(namespace.RootContext(nil), req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	keyInfo, err := c.barrier.ActiveKeyInfo()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	newConf := &SealConfig{
+		Type:            c.seal.BarrierSealConfigType().String(),
+		SecretThreshold: 1,
+		SecretShares:    1,
+		RotateShares:    true,
+	}
+	if hErr := c.RekeyInit(newConf, false); hErr != nil {
+		t.Fatalf("err: %v", hErr)
+	}
+	rkconf, hErr := c.RekeyConfig(false)
+	if hErr != nil {
+		t.Fatalf("err: %v", hErr)
+	}
+	if !rkconf.RotateShares {
+		t.Fatal("expected rekey config to rotate shares")
+	}
+
+	result, err := c.RekeyUpdate(context.Background(), masterKeys[0], rkconf.Nonce, false)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if result == nil || len(result.SecretShares) != 1 {
+		t.Fatalf("bad: %#v", result)
+	}
+
+	// The barrier keyring must not have been rotated
+	newKeyInfo, err := c.barrier.ActiveKeyInfo()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if newKeyInfo.Term != keyInfo.Term {
+		t.Fatalf("expected term %d, got %d", keyInfo.Term, newKeyInfo.Term)
+	}
+
+	if err := c.Seal(root); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The old share must no longer unseal
+	if _, err := TestCoreUnseal(c, TestKeyCopy(masterKeys[0])); err == nil {
+		t.Fatal("expected error unsealing with the old share")
+	}
+
+	unsealed, err := TestCoreUnseal(c, TestKeyCopy(result.SecretShares[0]))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !unsealed {
+		t.Fatal("should be unsealed")
+	}
+
+	req = logical.TestRequest(t, logical.ReadOperation, "cubbyhole/test")
+	req.ClientToken = root
+	resp, err := c.HandleRequest(namespace.RootContext(nil), req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp == nil || resp.Data["foo"] != "bar" {
+		t.Fatalf("bad: %#v", resp)
+	}
+}
+
+func TestCore_Rekey_RotateShares_Recovery(t *testing.T) {
+	bc := &SealConfig{
+		SecretShares:    1,
+		SecretThreshold: 1,
+	}
+	c, _, _, _ := TestCoreUnsealedWithConfigs(t, bc, nil)
+
+	err := c.RekeyInit(&SealConfig{
+		SecretThreshold: 1,
+		SecretShares:    1,
+		RotateShares:    true,
+	}, true)
+	if err == nil {
+		t.Fatal("expected error rotating recovery shares")
+	}
+}
+
 func TestCore_Rekey_Legacy(t *testing.T) {
 	bc := &SealConfig{
 		SecretShares:    1,
@@ -0,0 +1,104 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// requestMaxDurationsKey is the key in the system barrier view under which
+// the per-path maximum request durations are stored.
+const requestMaxDurationsKey = "request-max-durations"
+
+// RequestMaxDurations caps how long requests to paths starting with the given
+// prefixes may run. Prefixes include the namespace path, and the longest
+// matching prefix applies.
+type RequestMaxDurations struct {
+	Paths map[string]time.Duration `json:"paths"`
+}
+
+func (d *RequestMaxDurations) validate() error {
+	for prefix, duration := range d.Paths {
+		switch {
+		case prefix == "":
+			return errors.New("path prefixes must not be empty")
+		case duration <= 0:
+			return fmt.Errorf("maximum duration of %q must be greater than zero", prefix)
+		}
+	}
+	return nil
+}
+
+// setupRequestMaxDurations loads the per-path maximum request durations from
+// storage.
+func (c *Core) setupRequestMaxDurations(ctx context.Context) error {
+	durations := &RequestMaxDurations{}
+
+	entry, err := c.systemBarrierView.Get(ctx, requestMaxDurationsKey)
+	if err != nil {
+		return fmt.Errorf("failed to read request max durations: %w", err)
+	}
+	if entry != nil {
+		if err := entry.DecodeJSON(durations); err != nil {
+			return fmt.Errorf("failed to decode request max durations: %w", err)
+		}
+	}
+
+	c.requestMaxDurations.Store(durations)
+	return nil
+}
+
+// RequestMaxDurations returns the per-path maximum request durations.
+func (c *Core) RequestMaxDurations() *RequestMaxDurations {
+	if durations, ok := c.requestMaxDurations.Load().(*RequestMaxDurations); ok {
+		return durations
+	}
+	return &RequestMaxDurations{}
+}
+
+// SetRequestMaxDurations validates and saves the per-path maximum request
+// durations. Passing nil removes them.
+func (c *Core) SetRequestMaxDurations(ctx context.Context, durations *RequestMaxDurations) error {
+	if durations == nil || len(durations.Paths) == 0 {
+		if err := c.systemBarrierView.Delete(ctx, requestMaxDurationsKey); err != nil {
+			return fmt.Errorf("failed to delete request max durations: %w", err)
+		}
+		c.requestMaxDurations.Store(&RequestMaxDurations{})
+		return nil
+	}
+
+	if err := durations.validate(); err != nil {
+		return err
+	}
+	entry, err := logical.StorageEntryJSON(requestMaxDurationsKey, durations)
+	if err != nil {
+		return fmt.Errorf("failed to create request max durations entry: %w", err)
+	}
+	if err := c.systemBarrierView.Put(ctx, entry); err != nil {
+		return fmt.Errorf("failed to save request max durations: %w", err)
+	}
+	c.requestMaxDurations.Store(durations)
+	return nil
+}
+
+// RequestMaxDuration returns the maximum duration of requests to the given
+// path, which includes the namespace path, if one is configured for it.
+func (c *Core) RequestMaxDuration(path string) (time.Duration, bool) {
+	durations := c.RequestMaxDurations()
+
+	var match string
+	var duration time.Duration
+	for prefix, d := range durations.Paths {
+		if strings.HasPrefix(path, prefix) && len(prefix) > len(match) {
+			match, duration = prefix, d
+		}
+	}
+	return duration, match != ""
+}
@@ -10,6 +10,7 @@ import (
 	"errors"
 	"fmt"
 	"maps"
+	"net/http"
 	"net/textproto"
 	"os"
 	paths "path"
@@ -282,6 +283,9 @@ func (c *Core) fetchACLTokenEntryAndEntity(ctx context.Context, req *logical.Req
 	// Add tokens policies
 	policyNames[te.NamespaceID] = append(policyNames[te.NamespaceID], te.Policies...)
 
+	// Add the policies of the token's active break-glass escalations
+	policyNames[te.NamespaceID] = append(policyNames[te.NamespaceID], c.breakGlass.ActivePolicies(te.Accessor)...)
+
 	tokenNS, err := NamespaceByID(ctx, te.NamespaceID, c)
 	if err != nil {
 		c.logger.Error("failed to fetch token namespace", "error", err)
@@ -329,6 +333,16 @@ func (c *Core) fetchACLTokenEntryAndEntity(ctx context.Context, req *logical.Req
 		policies = append(policies, inlinePolicy)
 	}
 
+	// Add the policies of the mounts the entity's groups administer
+	if !te.NoIdentityPolicies {
+		adminPolicies, err := c.mountAdminPolicies(ctx, entity)
+		if err != nil {
+			c.logger.Error("failed to fetch mount admin policies", "error", err)
+			return nil, nil, nil, nil, ErrInternalError
+		}
+		policies = append(policies, adminPolicies...)
+	}
+
 	// Construct the corresponding ACL object. ACL construction should be
 	// performed on the token's namespace.
 	acl, err := c.policyStore.ACL(tokenCtx, entity, policyNames, policies...)
@@ -600,6 +614,9 @@ func (c *Core) switchedLockHandleRequest(httpCtx context.Context, req *logical.R
 	if ok {
 		ctx = logical.CreateContextRedactionSettings(ctx, redactVersion, redactAddresses, redactClusterName)
 	}
+	if listenerName, ok := ListenerNameFromContext(httpCtx); ok {
+		ctx = ContextWithListenerName(ctx, listenerName)
+	}
 	inFlightRequestPriority, ok := httpCtx.Value(logical.CtxKeyInFlightRequestPriority{}).(priority.AOPWritePriority)
 	if ok {
 		ctx = context.WithValue(ctx, logical.CtxKeyInFlightRequestPriority{}, inFlightRequestPriority)
@@ -859,8 +876,9 @@ func (c *Core) handleCancelableRequest(ctx context.Context, req *logical.Request
 			err = cubbyErr
 		} else {
 			wrappingResp := &logical.Response{
-				WrapInfo: resp.WrapInfo,
-				Warnings: resp.Warnings,
+				WrapInfo:       resp.WrapInfo,
+				Warnings:       resp.Warnings,
+				WarningsDetail: resp.WarningsDetail,
 			}
 			resp = wrappingResp
 		}
@@ -950,6 +968,37 @@ func (c *Core) handleCancelableRequest(ctx context.Context, req *logical.Request
 	return
 }
 
+// handleSubrequest handles a request made internally on behalf of a client,
+// such as an item of a secrets manifest. Requests from clients have the rate
+// limit quotas applied by the HTTP layer, which internal requests do not go
+// through, so they are applied here before the request is handled.
+func (c *Core) handleSubrequest(ctx context.Context, req *logical.Request) (*logical.Response, error) {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	quotaReq := &quotas.Request{
+		Type:          quotas.TypeRateLimit,
+		Path:          req.Path,
+		MountPath:     strings.TrimPrefix(c.MatchingMount(ctx, req.Path), ns.Path),
+		NamespacePath: ns.Path,
+	}
+	if req.Connection != nil {
+		quotaReq.ClientAddress = req.Connection.RemoteAddr
+	}
+
+	quotaResp, err := c.ApplyRateLimitQuota(ctx, quotaReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply quota: %w", err)
+	}
+	if !quotaResp.Allowed {
+		return nil, logical.CodedError(http.StatusTooManyRequests, fmt.Sprintf("request path %q: %s", req.Path, quotas.ErrRateLimitQuotaExceeded))
+	}
+
+	return c.handleCancelableRequest(ctx, req)
+}
+
 func isControlGroupRun(req *logical.Request) bool {
 	return req.ControlGroup != nil
 }
@@ -1181,6 +1230,9 @@ func (c *Core) handleRequest(ctx context.Context, req *logical.Request) (retResp
 
 	// Route the request
 	resp, routeErr := c.doRouting(ctx, req)
+	if routeErr == nil && !resp.IsError() {
+		c.recordConfigChange(ctx, req, entry, auth)
+	}
 	if resp != nil {
 		// Add mount type information to the response
 		if entry != nil {
@@ -1286,7 +1338,14 @@ func (c *Core) handleRequest(ctx context.Context, req *logical.Request) (retResp
 				return nil, nil, err
 			}
 			for _, warning := range warnings {
-				resp.AddWarning(warning)
+				resp.AddWarningWithCode(logical.WarningCodeTTLTruncated, warning)
+			}
+			ttl, warnings, err = c.applyTTLPolicies(ns, req.Path, ttl, time.Time{})
+			if err != nil {
+				return nil, nil, err
+			}
+			for _, warning := range warnings {
+				resp.AddWarningWithCode(logical.WarningCodeTTLTruncated, warning)
 			}
 			resp.Secret.TTL = ttl
 
@@ -1456,6 +1515,9 @@ func (c *Core) handleLoginRequest(ctx context.Context, req *logical.Request) (re
 	if ctErr == logical.ErrPerfStandbyPleaseForward {
 		return nil, nil, ctErr
 	}
+	if ctErr == nil {
+		ctErr = c.checkAuthNetworkRequirements(ctx, entry, req)
+	}
 
 	// Updating in-flight request data with client/entity ID
 	inFlightReqID, ok := ctx.Value(logical.CtxKeyInFlightRequestID{}).(string)
@@ -1531,7 +1593,7 @@ func (c *Core) handleLoginRequest(ctx context.Context, req *logical.Request) (re
 		}
 		if isloginUserLocked {
 			c.logger.Error("login attempts exceeded, user is locked out", "request_path", req.Path)
-			return nil, nil, logical.ErrPermissionDenied
+			return nil, nil, logical.NewAuthError(logical.AuthErrorCodeLockedOut, logical.ErrPermissionDenied)
 		}
 	}
 
@@ -1774,7 +1836,7 @@ func (c *Core) handleLoginRequest(ctx context.Context, req *logical.Request) (re
 				for _, eConfig := range matchedMfaEnforcementList {
 					err = c.validateLoginMFA(ctx, eConfig, entity, req.Connection.RemoteAddr, req.MFACreds)
 					if err != nil {
-						return nil, nil, logical.ErrPermissionDenied
+						return nil, nil, logical.NewAuthError(logical.AuthErrorCodeMFAFailed, logical.ErrPermissionDenied)
 					}
 				}
 			} else if len(matchedMfaEnforcementList) > 0 && len(req.MFACreds) == 0 {
@@ -1826,6 +1888,9 @@ func (c *Core) handleLoginRequest(ctx context.Context, req *logical.Request) (re
 		// Attach the display name, might be used by audit backends
 		req.DisplayName = auth.DisplayName
 
+		// Record the client that logged in on the token
+		auth.Metadata = clientIdentificationMeta(req, auth.Metadata)
+
 		requiresLease := resp.Auth.TokenType != logical.TokenTypeBatch
 
 		// If role was not already determined by http.rateLimitQuotaWrapping
@@ -1981,7 +2046,8 @@ func (c *Core) handleDelegatedAuth(ctx context.Context, origReq *logical.Request
 	}
 	if authResp.Auth.ClientToken == "" {
 		if authResp.Auth.MFARequirement != nil {
-			return nil, nil, fmt.Errorf("%w: delegated auth request requiring MFA is not supported: %s", logical.ErrPermissionDenied, authReq.Path)
+			return nil, nil, logical.NewAuthError(logical.AuthErrorCodeMFARequired,
+				fmt.Errorf("%w: delegated auth request requiring MFA is not supported: %s", logical.ErrPermissionDenied, authReq.Path))
 		}
 		return nil, nil, fmt.Errorf("%w: delegated auth request did not return a client token for login path: %s", ErrInternalError, authReq.Path)
 	}
@@ -2000,6 +2066,9 @@ func (c *Core) handleDelegatedAuth(ctx context.Context, origReq *logical.Request
 	}
 	secondReq.ClientToken = authResp.Auth.ClientToken
 	secondReq.ClientTokenSource = logical.ClientTokenFromInternalAuth
+	// Backends serving binary paths, such as EST, read the raw body of the
+	// original HTTP request, which Clone does not carry over
+	secondReq.HTTPRequest = origReq.HTTPRequest
 	resp, err := c.handleCancelableRequest(ctx, secondReq)
 	return resp, nil, err
 }
@@ -2032,7 +2101,14 @@ func (c *Core) LoginCreateToken(ctx context.Context, ns *namespace.Namespace, re
 		return false, nil, err
 	}
 	for _, warning := range warnings {
-		resp.AddWarning(warning)
+		resp.AddWarningWithCode(logical.WarningCodeTTLTruncated, warning)
+	}
+	tokenTTL, warnings, err = c.applyTTLPolicies(ns, reqPath, tokenTTL, time.Time{})
+	if err != nil {
+		return false, nil, err
+	}
+	for _, warning := range warnings {
+		resp.AddWarningWithCode(logical.WarningCodeTTLTruncated, warning)
 	}
 
 	_, identityPolicies, err := c.fetchEntityAndDerivedPolicies(ctx, ns, auth.EntityID, false)
@@ -20,6 +20,7 @@ import (
 	"github.com/hashicorp/vault/sdk/helper/consts"
 	"github.com/hashicorp/vault/sdk/helper/salt"
 	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/hashicorp/vault/vault/eventbus"
 )
 
 var deniedPassthroughRequestHeaders = []string{
@@ -43,6 +44,9 @@ type Router struct {
 	storagePrefix            *radix.Tree
 	logger                   hclog.Logger
 	rollbackMetricsMountName bool
+	// events is used to alert on the service level objectives missed by
+	// mounts; it may be nil
+	events *eventbus.EventBus
 }
 
 // NewRouter returns a new router
@@ -73,6 +77,14 @@ type routeEntry struct {
 	limitedPaths  atomic.Value
 	// l is the lock used to protect access to backend during reloads
 	l sync.RWMutex
+	// health tracks runtime health information for the mount
+	health mountHealth
+	// deferredInit holds the initialization of the backend when it is
+	// deferred to the first request
+	deferredInit deferredMountInit
+	// slo measures the requests handled by the backend against the service
+	// level objectives of the mount
+	slo mountSLOTracker
 }
 
 type wildcardPath struct {
@@ -206,6 +218,7 @@ func (r *Router) Mount(backend logical.Backend, prefix string, mountEntry *Mount
 		storageView:   storageView,
 	}
 	re.tainted.Store(mountEntry.Tainted)
+	re.slo.setConfig(mountEntry.SLO)
 	re.rootPaths.Store(pathsToRadix(paths.Root))
 	loginPathsEntry, err := parseUnauthenticatedPaths(paths.Unauthenticated)
 	if err != nil {
@@ -370,6 +383,83 @@ func (r *Router) MatchingMountByAccessor(mountAccessor string) *MountEntry {
 	return raw.(*MountEntry)
 }
 
+// routeEntryForMount returns the route entry serving the given mount entry,
+// or nil if the mount is not currently routed
+func (r *Router) routeEntryForMount(entry *MountEntry) *routeEntry {
+	if entry == nil || entry.Namespace() == nil {
+		return nil
+	}
+
+	r.l.RLock()
+	raw, ok := r.root.Get(entry.APIPath())
+	r.l.RUnlock()
+	if !ok {
+		return nil
+	}
+
+	re := raw.(*routeEntry)
+	if re.mountEntry.UUID != entry.UUID {
+		return nil
+	}
+	return re
+}
+
+// MountHealth returns runtime health information for the given mount entry,
+// or nil if the mount is not currently routed
+func (r *Router) MountHealth(entry *MountEntry) map[string]interface{} {
+	re := r.routeEntryForMount(entry)
+	if re == nil {
+		return nil
+	}
+
+	re.l.RLock()
+	backend := re.backend
+	re.l.RUnlock()
+
+	return re.health.info(backend, re.deferredInit.state())
+}
+
+// MountSLOStatus returns the measurements of the current window of the
+// service level objectives of the given mount entry, or nil if the mount is
+// not currently routed
+func (r *Router) MountSLOStatus(entry *MountEntry) map[string]interface{} {
+	re := r.routeEntryForMount(entry)
+	if re == nil {
+		return nil
+	}
+	return re.slo.status()
+}
+
+// setMountSLO updates the service level objectives the requests to the given
+// mount entry are measured against
+func (r *Router) setMountSLO(entry *MountEntry, slo *MountSLO) {
+	if re := r.routeEntryForMount(entry); re != nil {
+		re.slo.setConfig(slo)
+	}
+}
+
+// deferMountInit defers the initialization of the backend of the given mount
+// entry to the first request routed to it. It returns false if the mount is
+// not currently routed.
+func (r *Router) deferMountInit(entry *MountEntry, init func()) bool {
+	re := r.routeEntryForMount(entry)
+	if re == nil {
+		return false
+	}
+
+	re.deferredInit.deferInit(init)
+	return true
+}
+
+// recordMountInitError records an error encountered while setting up or
+// initializing the backend for the given mount entry, or clears the recorded
+// error if err is nil
+func (r *Router) recordMountInitError(entry *MountEntry, err error) {
+	if re := r.routeEntryForMount(entry); re != nil {
+		re.health.recordInitError(err)
+	}
+}
+
 // MatchingMount returns the mount prefix that would be used for a path
 func (r *Router) MatchingMount(ctx context.Context, path string) string {
 	r.l.RLock()
@@ -621,6 +711,14 @@ func (r *Router) routeCommon(ctx context.Context, req *logical.Request, existenc
 		}
 	}
 
+	// Initialize the backend if its initialization was deferred. Periodic
+	// rollbacks initialize it too, so that the background work started by
+	// Initialize or run by the periodic function, such as credential rotation,
+	// is not held back on mounts no client has accessed since unseal.
+	if re.deferredInit.pending.Load() {
+		re.deferredInit.run()
+	}
+
 	// Adjust the path to exclude the routing prefix
 	originalPath := req.Path
 	req.Path = strings.TrimPrefix(ns.Path+req.Path, mount)
@@ -787,7 +885,10 @@ func (r *Router) routeCommon(ctx context.Context, req *logical.Request, existenc
 		ok, exists, err := re.backend.HandleExistenceCheck(ctx, req)
 		return nil, ok, exists, err
 	} else {
+		start := time.Now()
 		resp, err := re.backend.HandleRequest(ctx, req)
+		re.health.recordResult(resp, err)
+		r.recordMountSLO(ctx, re, req, resp, time.Since(start))
 		if resp != nil {
 			if len(allowedResponseHeaders) > 0 {
 				resp.Headers = filteredHeaders(resp.Headers, allowedResponseHeaders, nil)
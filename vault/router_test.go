@@ -13,6 +13,7 @@ import (
 	"github.com/hashicorp/vault/helper/namespace"
 	"github.com/hashicorp/vault/sdk/logical"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestRouter_Mount(t *testing.T) {
@@ -683,3 +684,34 @@ func TestWellKnownRedirectMatching(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestWellKnownRedirectConflicts(t *testing.T) {
+	ctx := context.Background()
+	apiRedir := NewWellKnownRedirects()
+
+	require.NoError(t, apiRedir.TryRegister(ctx, nil, "mount-a", "est", "est"))
+	require.NoError(t, apiRedir.TryRegister(ctx, nil, "mount-a", "acme/", "acme"))
+
+	// Re-registering the same source from the same mount updates it.
+	require.NoError(t, apiRedir.TryRegister(ctx, nil, "mount-a", "est", "est/v2"))
+	r, ok := apiRedir.Get("est")
+	require.True(t, ok)
+	require.Equal(t, "est/v2", r.prefix)
+
+	// Sources which share a prefix but not a path segment don't conflict.
+	require.NoError(t, apiRedir.TryRegister(ctx, nil, "mount-b", "est-legacy", "est"))
+
+	for _, src := range []string{"est", "est/", "est/simpleenroll", "acme/directory"} {
+		err := apiRedir.TryRegister(ctx, nil, "mount-b", src, "dest")
+		require.Error(t, err, src)
+		require.Contains(t, err.Error(), "mount-a")
+	}
+
+	require.NoError(t, apiRedir.TryRegister(ctx, nil, "mount-b", "mdm/enroll", "mdm"))
+	err := apiRedir.TryRegister(ctx, nil, "mount-a", "mdm", "mdm")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "mdm/enroll")
+
+	require.True(t, apiRedir.DeregisterSource("mount-a", "acme/"))
+	require.NoError(t, apiRedir.TryRegister(ctx, nil, "mount-b", "acme", "acme"))
+}
@@ -683,7 +683,7 @@ GATHER_RESULTS:
 func (a *access) tryEncrypt(ctx context.Context, sealWrapper *SealWrapper, plaintext []byte, options ...wrapping.Option) (*wrapping.BlobInfo, error) {
 	now := time.Now()
 	var encryptErr error
-	mLabels := []metrics.Label{{Name: "seal_wrapper_name", Value: sealWrapper.Name}}
+	mLabels := sealWrapper.metricLabels()
 
 	defer func(now time.Time) {
 		metrics.MeasureSinceWithLabels([]string{"seal", "encrypt", "time"}, now, mLabels)
@@ -842,7 +842,7 @@ GATHER_RESULTS:
 func (a *access) tryDecrypt(ctx context.Context, sealWrapper *SealWrapper, value *MultiWrapValue, options []wrapping.Option) ([]byte, bool, error) {
 	now := time.Now()
 	var decryptErr error
-	mLabels := []metrics.Label{{Name: "seal_wrapper_name", Value: sealWrapper.Name}}
+	mLabels := sealWrapper.metricLabels()
 
 	defer func(now time.Time) {
 		metrics.MeasureSinceWithLabels([]string{"seal", "decrypt", "time"}, now, mLabels)
@@ -12,6 +12,7 @@ import (
 	"sync"
 	"time"
 
+	metrics "github.com/armon/go-metrics"
 	wrapping "github.com/hashicorp/go-kms-wrapping/v2"
 )
 
@@ -105,7 +106,15 @@ var (
 	HealthTestTimeout           = 1 * time.Minute
 )
 
-func (sw *SealWrapper) CheckHealth(ctx context.Context, checkTime time.Time) error {
+func (sw *SealWrapper) CheckHealth(ctx context.Context, checkTime time.Time) (err error) {
+	mLabels := sw.metricLabels()
+	defer func(now time.Time) {
+		metrics.MeasureSinceWithLabels([]string{"seal", "health_check", "time"}, now, mLabels)
+		if err != nil {
+			metrics.IncrCounterWithLabels([]string{"seal", "health_check", "error"}, 1, mLabels)
+		}
+	}(time.Now())
+
 	testVal := fmt.Sprintf("Heartbeat %d", mathrand.Intn(1000))
 	ciphertext, err := sw.Wrapper.Encrypt(ctx, []byte(testVal), nil)
 	if err != nil {
@@ -130,6 +139,15 @@ func (sw *SealWrapper) CheckHealth(ctx context.Context, checkTime time.Time) err
 	return nil
 }
 
+// metricLabels returns the labels identifying the seal wrapper in telemetry,
+// so that the latency of each KMS can be told apart.
+func (sw *SealWrapper) metricLabels() []metrics.Label {
+	return []metrics.Label{
+		{Name: "seal_wrapper_name", Value: sw.Name},
+		{Name: "seal_type", Value: sw.SealConfigType},
+	}
+}
+
 // getHealth is the only function allowed to inspect the health fields directly
 func getHealth(sw *SealWrapper) (healthy bool, lastSeenHealthy time.Time, lastHealthCheck time.Time) {
 	sw.hcLock.RLock()
@@ -8,6 +8,7 @@ import (
 	"context"
 	"errors"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -210,6 +211,25 @@ func TestAutoSeal_HealthCheck(t *testing.T) {
 	if !autoSeal.Healthy() {
 		t.Fatal("Expected seals to be healthy")
 	}
+
+	var sawTime, sawError bool
+	for _, interval := range inmemSink.Data() {
+		interval.RLock()
+		for name := range interval.Samples {
+			if strings.HasPrefix(name, "seal.health_check.time;") && strings.Contains(name, "seal_wrapper_name=health-test") {
+				sawTime = true
+			}
+		}
+		for name := range interval.Counters {
+			if strings.HasPrefix(name, "seal.health_check.error;") && strings.Contains(name, "seal_wrapper_name=health-test") {
+				sawError = true
+			}
+		}
+		interval.RUnlock()
+	}
+	if !sawTime || !sawError {
+		t.Fatalf("expected seal health check metrics, got time: %t, error: %t", sawTime, sawError)
+	}
 }
 
 func TestAutoSeal_BarrierSealConfigType(t *testing.T) {
@@ -11,6 +11,7 @@ import (
 	"github.com/ProtonMail/go-crypto/openpgp"
 	"github.com/ProtonMail/go-crypto/openpgp/packet"
 	wrapping "github.com/hashicorp/go-kms-wrapping/v2"
+	"github.com/hashicorp/vault/helper/pgpkeys"
 )
 
 // SealConfig is used to describe the seal configuration
@@ -65,6 +66,11 @@ type SealConfig struct {
 
 	// Name is the name provided in the seal configuration to identify the seal
 	Name string `json:"name" mapstructure:"name"`
+
+	// RotateShares indicates that a barrier rekey should only reissue the
+	// unseal key shares, by replacing the unseal key which protects the root
+	// key, without replacing the root key itself.
+	RotateShares bool `json:"-"`
 }
 
 // Validate is used to sanity check the seal configuration
@@ -95,6 +101,12 @@ func (s *SealConfig) Validate() error {
 	}
 	if len(s.PGPKeys) > 0 {
 		for _, keystring := range s.PGPKeys {
+			if pgpkeys.IsAgeRecipient(keystring) {
+				if _, err := pgpkeys.ParseAgeRecipient(keystring); err != nil {
+					return err
+				}
+				continue
+			}
 			data, err := base64.StdEncoding.DecodeString(keystring)
 			if err != nil {
 				return fmt.Errorf("error decoding given PGP key: %w", err)
@@ -119,6 +131,7 @@ func (s *SealConfig) Clone() *SealConfig {
 		VerificationRequired: s.VerificationRequired,
 		VerificationNonce:    s.VerificationNonce,
 		Name:                 s.Name,
+		RotateShares:         s.RotateShares,
 	}
 	if len(s.PGPKeys) > 0 {
 		ret.PGPKeys = make([]string, len(s.PGPKeys))
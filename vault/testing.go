@@ -135,6 +135,7 @@ func TestCoreWithSeal(t testing.TB, testSeal Seal, enableRaw bool) *Core {
 		BuiltinRegistry: corehelpers.NewMockBuiltinRegistry(),
 		AuditBackends: map[string]audit.Factory{
 			audit.TypeFile:   audit.NewFileBackend,
+			audit.TypeKafka:  audit.NewKafkaBackend,
 			audit.TypeSocket: audit.NewSocketBackend,
 			audit.TypeSyslog: audit.NewSyslogBackend,
 		},
@@ -1439,6 +1440,7 @@ func NewTestCluster(t testing.TB, base *CoreConfig, opts *TestClusterOptions) *T
 		CredentialBackends: make(map[string]logical.Factory),
 		AuditBackends: map[string]audit.Factory{
 			audit.TypeFile:   audit.NewFileBackend,
+			audit.TypeKafka:  audit.NewKafkaBackend,
 			audit.TypeSocket: audit.NewSocketBackend,
 			audit.TypeSyslog: audit.NewSyslogBackend,
 		},
@@ -1479,6 +1481,7 @@ func NewTestCluster(t testing.TB, base *CoreConfig, opts *TestClusterOptions) *T
 		coreConfig.AdministrativeNamespacePath = base.AdministrativeNamespacePath
 		coreConfig.ServiceRegistration = base.ServiceRegistration
 		coreConfig.ImpreciseLeaseRoleTracking = base.ImpreciseLeaseRoleTracking
+		coreConfig.LazyMountInitialization = base.LazyMountInitialization
 
 		if base.BuiltinRegistry != nil {
 			coreConfig.BuiltinRegistry = base.BuiltinRegistry
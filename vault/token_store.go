@@ -94,6 +94,20 @@ const (
 	// IgnoreForBilling used for HCP Link batch tokens and inserted into the InternalMeta
 	// Tokens created for the purpose of HCP Link should bypass counting for billing purposes
 	IgnoreForBilling = "ignore_for_billing"
+
+	// maxRenewBatchSize is the maximum number of distinct accessors that may
+	// be renewed in a single call to auth/token/renew-batch
+	maxRenewBatchSize = 10000
+
+	// renewBatchParallelism is the number of tokens of a call to
+	// auth/token/renew-batch that are renewed concurrently
+	renewBatchParallelism = 16
+
+	// tokenMetaClientName and tokenMetaClientVersion are the token metadata
+	// keys under which the client identification headers of the request that
+	// created the token are recorded
+	tokenMetaClientName    = "client_name"
+	tokenMetaClientVersion = "client_version"
 )
 
 var (
@@ -506,6 +520,35 @@ func (ts *TokenStore) paths() []*framework.Path {
 			HelpDescription: strings.TrimSpace(tokenRenewAccessorHelp),
 		},
 
+		{
+			Pattern: "renew-batch$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: operationPrefixToken,
+				OperationVerb:   "renew",
+				OperationSuffix: "batch",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"accessors": {
+					Type:        framework.TypeCommaStringSlice,
+					Description: "Accessors of the tokens to renew (request body)",
+				},
+				"increment": {
+					Type:        framework.TypeDurationSecond,
+					Default:     0,
+					Description: "The desired increment in seconds to the token expiration, applied to every token",
+				},
+			},
+
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.UpdateOperation: ts.handleUpdateRenewBatch,
+			},
+
+			HelpSynopsis:    strings.TrimSpace(tokenRenewBatchHelp),
+			HelpDescription: strings.TrimSpace(tokenRenewBatchDesc),
+		},
+
 		{
 			Pattern: "renew-self$",
 
@@ -702,6 +745,85 @@ func (c *Core) CreateToken(ctx context.Context, entry *logical.TokenEntry) error
 	return c.tokenStore.create(ctx, entry)
 }
 
+// RegisterToken creates the given service token in the core's token store and
+// registers its lease with the expiration manager, so that it is revoked when
+// its TTL runs out.
+func (c *Core) RegisterToken(ctx context.Context, entry *logical.TokenEntry) error {
+	if c.tokenStore == nil || c.expiration == nil {
+		return errors.New("unable to register token with nil token store")
+	}
+	if entry.Type != logical.TokenTypeService {
+		return errors.New("only service tokens can be registered")
+	}
+
+	if err := c.tokenStore.create(ctx, entry); err != nil {
+		return err
+	}
+
+	auth := &logical.Auth{
+		ClientToken:    entry.ID,
+		Accessor:       entry.Accessor,
+		DisplayName:    entry.DisplayName,
+		Policies:       entry.Policies,
+		TokenPolicies:  entry.Policies,
+		Metadata:       entry.Meta,
+		EntityID:       entry.EntityID,
+		ExplicitMaxTTL: entry.ExplicitMaxTTL,
+		TokenType:      entry.Type,
+		LeaseOptions: logical.LeaseOptions{
+			TTL: entry.TTL,
+		},
+	}
+	if err := c.expiration.RegisterAuth(ctx, entry, auth, ""); err != nil {
+		if err := c.tokenStore.revokeOrphan(ctx, entry.ID); err != nil {
+			c.logger.Warn("failed to clean up token after failed lease registration", "error", err)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// RevokeTokenByAccessor revokes the token with the given accessor, along with
+// its children. It is a no-op if no such token exists.
+func (c *Core) RevokeTokenByAccessor(ctx context.Context, accessor string) error {
+	if c.tokenStore == nil || c.expiration == nil {
+		return errors.New("unable to revoke token with nil token store")
+	}
+
+	aEntry, err := c.tokenStore.lookupByAccessor(ctx, accessor, false, true)
+	if err != nil {
+		return err
+	}
+	if aEntry == nil {
+		return nil
+	}
+
+	te, err := c.tokenStore.Lookup(ctx, aEntry.TokenID)
+	if err != nil {
+		return err
+	}
+	if te == nil {
+		return nil
+	}
+
+	tokenNS, err := NamespaceByID(ctx, te.NamespaceID, c)
+	if err != nil {
+		return err
+	}
+	if tokenNS == nil {
+		return namespace.ErrNoNamespace
+	}
+
+	revokeCtx := namespace.ContextWithNamespace(c.tokenStore.quitContext, tokenNS)
+	leaseID, err := c.expiration.CreateOrFetchRevocationLeaseByToken(revokeCtx, te)
+	if err != nil {
+		return err
+	}
+
+	return c.expiration.Revoke(revokeCtx, leaseID)
+}
+
 // TokenStore is used to manage client tokens. Tokens are used for
 // clients to authenticate, and each token is mapped to an applicable
 // set of policy which is used for authorization.
@@ -2546,6 +2668,110 @@ func (ts *TokenStore) handleUpdateRenewAccessor(ctx context.Context, req *logica
 	return resp, nil
 }
 
+// handleUpdateRenewBatch handles the auth/token/renew-batch path for renewing
+// many tokens, identified by their accessors, in a single request. Duplicate
+// accessors are renewed only once per call. The renewals of distinct tokens
+// still lock and write their own lease entry, one storage write each, since
+// the barrier has no transactions to group the writes of distinct leases
+// into; they are run concurrently so that those writes overlap rather than
+// being made one after the other. Failures are reported per accessor rather
+// than failing the whole batch.
+func (ts *TokenStore) handleUpdateRenewBatch(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	accessors := strutil.RemoveDuplicates(data.Get("accessors").([]string), false)
+	if len(accessors) == 0 {
+		return nil, &logical.StatusBadRequest{Err: "missing accessors"}
+	}
+	if len(accessors) > maxRenewBatchSize {
+		return nil, &logical.StatusBadRequest{Err: fmt.Sprintf("number of accessors (%d) exceeds the maximum batch size of %d", len(accessors), maxRenewBatchSize)}
+	}
+
+	increment := time.Duration(data.Get("increment").(int)) * time.Second
+
+	renewed := make([]map[string]interface{}, len(accessors))
+	errs := make([]error, len(accessors))
+	sem := make(chan struct{}, renewBatchParallelism)
+	var wg sync.WaitGroup
+	for i, accessor := range accessors {
+		if ctx.Err() != nil {
+			break
+		}
+
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(i int, accessor string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			renewed[i], errs[i] = ts.renewByAccessor(ctx, req, accessor, increment)
+		}(i, accessor)
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	results := make(map[string]interface{}, len(accessors))
+	var failed int
+	for i, accessor := range accessors {
+		if errs[i] != nil {
+			failed++
+			results[accessor] = map[string]interface{}{
+				"error": errs[i].Error(),
+			}
+			continue
+		}
+		results[accessor] = renewed[i]
+	}
+
+	resp := &logical.Response{
+		Data: map[string]interface{}{
+			"results":   results,
+			"renewed":   len(accessors) - failed,
+			"failed":    failed,
+			"increment": int64(increment.Seconds()),
+		},
+	}
+	if failed > 0 {
+		resp.AddWarning(fmt.Sprintf("%d of %d tokens could not be renewed; see results for details", failed, len(accessors)))
+	}
+
+	return resp, nil
+}
+
+// renewByAccessor renews the token referenced by the given accessor and
+// returns a summary of the renewed lease suitable for batch responses. The
+// token ID is never included in the result.
+func (ts *TokenStore) renewByAccessor(ctx context.Context, req *logical.Request, accessor string, increment time.Duration) (map[string]interface{}, error) {
+	aEntry, err := ts.lookupByAccessor(ctx, accessor, false, false)
+	if err != nil {
+		return nil, err
+	}
+	if aEntry == nil || aEntry.TokenID == "" {
+		return nil, errors.New("invalid accessor")
+	}
+
+	resp, err := ts.renewToken(ctx, req, aEntry.TokenID, increment)
+	if err != nil && (resp == nil || !resp.IsError()) {
+		return nil, err
+	}
+	if resp == nil || resp.Auth == nil {
+		if resp != nil && resp.IsError() {
+			return nil, resp.Error()
+		}
+		return nil, errors.New("token could not be renewed")
+	}
+
+	result := map[string]interface{}{
+		"lease_duration": int64(resp.Auth.TTL.Seconds()),
+		"renewable":      resp.Auth.Renewable,
+	}
+	if len(resp.Warnings) > 0 {
+		result["warnings"] = resp.Warnings
+	}
+	return result, nil
+}
+
 // handleUpdateRevokeAccessor handles the auth/token/revoke-accessor path for revoking
 // the token associated with the accessor
 func (ts *TokenStore) handleUpdateRevokeAccessor(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
@@ -2606,6 +2832,28 @@ func (ts *TokenStore) handleCreate(ctx context.Context, req *logical.Request, d
 	return ts.handleCreateCommon(ctx, req, d, false, nil)
 }
 
+// clientIdentificationMeta adds the client name and version of the request to
+// the given token metadata. Metadata already set by the caller or the auth
+// method is left untouched.
+func clientIdentificationMeta(req *logical.Request, meta map[string]string) map[string]string {
+	for key, value := range map[string]string{
+		tokenMetaClientName:    req.ClientName,
+		tokenMetaClientVersion: req.ClientVersion,
+	} {
+		if value == "" {
+			continue
+		}
+		if _, ok := meta[key]; ok {
+			continue
+		}
+		if meta == nil {
+			meta = make(map[string]string)
+		}
+		meta[key] = value
+	}
+	return meta
+}
+
 // handleCreateCommon handles the auth/token/create path for creation of new tokens
 func (ts *TokenStore) handleCreateCommon(ctx context.Context, req *logical.Request, d *framework.FieldData, orphan bool, role *tsRoleEntry) (*logical.Response, error) {
 	// Read the parent policy
@@ -2789,6 +3037,7 @@ func (ts *TokenStore) handleCreateCommon(ctx context.Context, req *logical.Reque
 	if ok {
 		metaMap = meta.(map[string]string)
 	}
+	metaMap = clientIdentificationMeta(req, metaMap)
 
 	// Set up the token entry
 	te := logical.TokenEntry{
@@ -3152,7 +3401,14 @@ func (ts *TokenStore) handleCreateCommon(ctx context.Context, req *logical.Reque
 			return nil, err
 		}
 		for _, warning := range warnings {
-			resp.AddWarning(warning)
+			resp.AddWarningWithCode(logical.WarningCodeTTLTruncated, warning)
+		}
+		ttl, warnings, err = ts.core.applyTTLPolicies(ns, req.MountPoint+req.Path, ttl, time.Time{})
+		if err != nil {
+			return nil, err
+		}
+		for _, warning := range warnings {
+			resp.AddWarningWithCode(logical.WarningCodeTTLTruncated, warning)
 		}
 		te.TTL = ttl
 	}
@@ -3470,6 +3726,12 @@ func (ts *TokenStore) handleRenew(ctx context.Context, req *logical.Request, dat
 	// Convert the increment
 	increment := time.Duration(incrementRaw) * time.Second
 
+	return ts.renewToken(ctx, req, id, increment)
+}
+
+// renewToken renews the token with the given ID and its children. It is
+// shared by the single and batch renewal endpoints.
+func (ts *TokenStore) renewToken(ctx context.Context, req *logical.Request, id string, increment time.Duration) (*logical.Response, error) {
 	// Lookup the token
 	te, err := ts.Lookup(ctx, id)
 	if err != nil {
@@ -3479,16 +3741,12 @@ func (ts *TokenStore) handleRenew(ctx context.Context, req *logical.Request, dat
 		return logical.ErrorResponse("token not found"), logical.ErrInvalidRequest
 	}
 
-	var resp *logical.Response
-
 	if te.Type == logical.TokenTypeBatch {
 		return logical.ErrorResponse("batch tokens cannot be renewed"), nil
 	}
 
 	// Renew the token and its children
-	resp, err = ts.expiration.RenewToken(ctx, req, te, increment)
-
-	return resp, err
+	return ts.expiration.RenewToken(ctx, req, te, increment)
 }
 
 func (ts *TokenStore) authRenew(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
@@ -3792,7 +4050,7 @@ func (ts *TokenStore) tokenStoreRoleCreateUpdate(ctx context.Context, req *logic
 			if resp == nil {
 				resp = &logical.Response{}
 			}
-			resp.AddWarning("Both 'token_period' and deprecated 'period' value supplied, ignoring the deprecated value")
+			resp.AddWarningWithCode(logical.WarningCodeDeprecatedParameter, "Both 'token_period' and deprecated 'period' value supplied, ignoring the deprecated value")
 		}
 		entry.Period = 0
 	}
@@ -3814,7 +4072,7 @@ func (ts *TokenStore) tokenStoreRoleCreateUpdate(ctx context.Context, req *logic
 			if resp == nil {
 				resp = &logical.Response{}
 			}
-			resp.AddWarning("Both 'token_bound_cidrs' and deprecated 'bound_cidrs' value supplied, ignoring the deprecated value")
+			resp.AddWarningWithCode(logical.WarningCodeDeprecatedParameter, "Both 'token_bound_cidrs' and deprecated 'bound_cidrs' value supplied, ignoring the deprecated value")
 		}
 		entry.BoundCIDRs = nil
 	}
@@ -3834,7 +4092,7 @@ func (ts *TokenStore) tokenStoreRoleCreateUpdate(ctx context.Context, req *logic
 			if resp == nil {
 				resp = &logical.Response{}
 			}
-			resp.AddWarning("Both 'token_explicit_max_ttl' and deprecated 'explicit_max_ttl' value supplied, ignoring the deprecated value")
+			resp.AddWarningWithCode(logical.WarningCodeDeprecatedParameter, "Both 'token_explicit_max_ttl' and deprecated 'explicit_max_ttl' value supplied, ignoring the deprecated value")
 		}
 		entry.ExplicitMaxTTL = 0
 	}
@@ -4197,6 +4455,12 @@ This endpoint performs cleanup tasks that can be run to clean up token and
 lease entries after certain error conditions. Usually running this is not
 necessary, and is only required if upgrade notes or support personnel suggest
 it.
+`
+	tokenRenewBatchDesc = `
+This endpoint renews many tokens, identified by their accessors, in a single
+request. It is intended for agents and orchestrators that need to keep a large
+number of tokens alive. Duplicate accessors are renewed only once, and the
+result of each renewal (or the reason it failed) is returned keyed by accessor.
 `
 	tokenBackendHelp = `The token credential backend is always enabled and builtin to Vault.
 Client tokens are used to identify a client and to allow Vault to associate policies and ACLs
@@ -4216,6 +4480,7 @@ as revocation of tokens. The tokens are renewable if associated with a lease.`
 	tokenRevokeOrphanHelp    = `This endpoint will delete the token and orphan its child tokens.`
 	tokenRenewHelp           = `This endpoint will renew the given token and prevent expiration.`
 	tokenRenewSelfHelp       = `This endpoint will renew the token used to call it and prevent expiration.`
+	tokenRenewBatchHelp      = `This endpoint will renew the tokens associated with the given accessors. Response will not contain the token IDs.`
 	tokenAllowedPoliciesHelp = `If set, tokens can be created with any subset of the policies in this
 list, rather than the normal semantics of tokens being a subset of the
 calling token's policies. The parameter is a comma-delimited string of
@@ -1006,6 +1006,88 @@ func TestTokenStore_HandleRequest_Renew_Revoke_Accessor(t *testing.T) {
 	}
 }
 
+func TestTokenStore_HandleRequest_RenewBatch(t *testing.T) {
+	exp := mockExpiration(t)
+	ts := exp.tokenStore
+
+	rootToken, err := ts.rootToken(namespace.RootContext(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := rootToken.ID
+
+	var accessors []string
+	for _, id := range []string{"tokenid1", "tokenid2"} {
+		testMakeServiceTokenViaBackend(t, ts, root, id, "", []string{"foo"})
+
+		te, err := ts.Lookup(namespace.RootContext(nil), id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if te == nil {
+			t.Fatal("token entry was nil")
+		}
+
+		auth := &logical.Auth{
+			ClientToken: id,
+			LeaseOptions: logical.LeaseOptions{
+				TTL:       time.Hour,
+				Renewable: true,
+			},
+		}
+		if err := exp.RegisterAuth(namespace.RootContext(nil), te, auth, ""); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		accessors = append(accessors, te.Accessor)
+	}
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "renew-batch")
+	req.Data = map[string]interface{}{
+		// Duplicates should be renewed only once
+		"accessors": append(accessors, accessors[0], "invalid-accessor"),
+	}
+	resp, err := ts.HandleRequest(namespace.RootContext(nil), req)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if resp == nil || resp.Data == nil {
+		t.Fatal("expected response data")
+	}
+	if resp.Data["renewed"].(int) != 2 {
+		t.Fatalf("expected 2 renewed tokens, got %v", resp.Data["renewed"])
+	}
+	if resp.Data["failed"].(int) != 1 {
+		t.Fatalf("expected 1 failed token, got %v", resp.Data["failed"])
+	}
+	if len(resp.Warnings) != 1 {
+		t.Fatalf("expected 1 warning, got %d", len(resp.Warnings))
+	}
+
+	results := resp.Data["results"].(map[string]interface{})
+	if len(results) != 3 {
+		t.Fatalf("expected 3 results, got %d", len(results))
+	}
+	for _, accessor := range accessors {
+		result := results[accessor].(map[string]interface{})
+		if _, ok := result["error"]; ok {
+			t.Fatalf("unexpected error renewing %q: %v", accessor, result["error"])
+		}
+		if result["lease_duration"].(int64) == 0 {
+			t.Fatalf("expected non-zero lease duration for %q", accessor)
+		}
+	}
+	if _, ok := results["invalid-accessor"].(map[string]interface{})["error"]; !ok {
+		t.Fatal("expected error for invalid accessor")
+	}
+
+	// An empty batch is a bad request
+	req = logical.TestRequest(t, logical.UpdateOperation, "renew-batch")
+	_, err = ts.HandleRequest(namespace.RootContext(nil), req)
+	if err == nil {
+		t.Fatal("expected error for empty batch")
+	}
+}
+
 func TestTokenStore_RootToken(t *testing.T) {
 	c, _, _ := TestCoreUnsealed(t)
 	ts := c.tokenStore
@@ -0,0 +1,231 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/go-secure-stdlib/strutil"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// ttlPolicySubPath is the sub-path of the system barrier view under which
+// TTL policies are stored.
+const ttlPolicySubPath = "ttl-policies/"
+
+// TTLPolicy imposes a maximum TTL on the leases and tokens issued by requests
+// to any of its paths, regardless of the TTLs configured on the mounts and
+// roles serving those paths.
+type TTLPolicy struct {
+	Name string `json:"name"`
+
+	// Paths are the request paths the policy applies to, including the
+	// namespace path. A trailing or leading "*" matches any suffix or prefix.
+	Paths []string `json:"paths"`
+
+	// MaxTTL is the maximum lifetime of leases and tokens issued by requests
+	// to the policy's paths, counted from when they were issued.
+	MaxTTL time.Duration `json:"max_ttl"`
+}
+
+// TTLPolicyStore keeps the TTL policies in memory, since they are evaluated on
+// every lease and token creation and renewal.
+type TTLPolicyStore struct {
+	l        sync.RWMutex
+	view     *BarrierView
+	logger   log.Logger
+	policies map[string]*TTLPolicy
+}
+
+// setupTTLPolicies loads the TTL policies from storage.
+func (c *Core) setupTTLPolicies(ctx context.Context) error {
+	store := &TTLPolicyStore{
+		view:     c.systemBarrierView.SubView(ttlPolicySubPath),
+		logger:   c.logger.Named("ttl-policies"),
+		policies: make(map[string]*TTLPolicy),
+	}
+
+	keys, err := logical.CollectKeys(ctx, store.view)
+	if err != nil {
+		return fmt.Errorf("failed to list TTL policies: %w", err)
+	}
+	for _, key := range keys {
+		entry, err := store.view.Get(ctx, key)
+		if err != nil {
+			return fmt.Errorf("failed to read TTL policy %q: %w", key, err)
+		}
+		if entry == nil {
+			continue
+		}
+		policy := new(TTLPolicy)
+		if err := entry.DecodeJSON(policy); err != nil {
+			return fmt.Errorf("failed to decode TTL policy %q: %w", key, err)
+		}
+		store.policies[policy.Name] = policy
+	}
+
+	c.ttlPolicies = store
+	return nil
+}
+
+func (p *TTLPolicy) validate() error {
+	switch {
+	case p.Name == "":
+		return errors.New("missing policy name")
+	case len(p.Paths) == 0:
+		return errors.New("at least one path must be given")
+	case p.MaxTTL <= 0:
+		return errors.New("max_ttl must be greater than zero")
+	}
+	for _, path := range p.Paths {
+		if strings.TrimSpace(path) == "" {
+			return errors.New("paths must not be empty")
+		}
+	}
+	return nil
+}
+
+// Set validates and saves the policy, replacing any policy of the same name.
+func (s *TTLPolicyStore) Set(ctx context.Context, policy *TTLPolicy) error {
+	if err := policy.validate(); err != nil {
+		return err
+	}
+
+	entry, err := logical.StorageEntryJSON(policy.Name, policy)
+	if err != nil {
+		return fmt.Errorf("failed to create TTL policy entry: %w", err)
+	}
+
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	if err := s.view.Put(ctx, entry); err != nil {
+		return fmt.Errorf("failed to save TTL policy: %w", err)
+	}
+	s.policies[policy.Name] = policy
+	return nil
+}
+
+// Get returns the named policy, or nil if it does not exist.
+func (s *TTLPolicyStore) Get(name string) *TTLPolicy {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	return s.policies[name]
+}
+
+// List returns the sorted names of the policies.
+func (s *TTLPolicyStore) List() []string {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	names := make([]string, 0, len(s.policies))
+	for name := range s.policies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Delete removes the named policy.
+func (s *TTLPolicyStore) Delete(ctx context.Context, name string) error {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	if err := s.view.Delete(ctx, name); err != nil {
+		return fmt.Errorf("failed to delete TTL policy: %w", err)
+	}
+	delete(s.policies, name)
+	return nil
+}
+
+// invalidate reloads the named policy from storage, as it was changed by
+// another node.
+func (s *TTLPolicyStore) invalidate(ctx context.Context, name string) {
+	entry, err := s.view.Get(ctx, name)
+	if err != nil {
+		s.logger.Error("failed to read invalidated TTL policy", "name", name, "error", err)
+		return
+	}
+
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	if entry == nil {
+		delete(s.policies, name)
+		return
+	}
+	policy := new(TTLPolicy)
+	if err := entry.DecodeJSON(policy); err != nil {
+		s.logger.Error("failed to decode invalidated TTL policy", "name", name, "error", err)
+		return
+	}
+	s.policies[name] = policy
+}
+
+// MaxTTL returns the smallest maximum TTL of the policies matching path, and
+// the name of the policy imposing it. A zero duration means that no policy
+// matches the path.
+func (s *TTLPolicyStore) MaxTTL(path string) (time.Duration, string) {
+	if s == nil {
+		return 0, ""
+	}
+
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	var maxTTL time.Duration
+	var name string
+	for _, policy := range s.policies {
+		if maxTTL != 0 && policy.MaxTTL >= maxTTL {
+			continue
+		}
+		for _, pattern := range policy.Paths {
+			if strutil.GlobbedStringsMatch(pattern, path) {
+				maxTTL, name = policy.MaxTTL, policy.Name
+				break
+			}
+		}
+	}
+	return maxTTL, name
+}
+
+// applyTTLPolicies caps ttl so that a lease or token issued at issueTime by a
+// request to path in ns does not outlive the maximum TTL of the TTL policies
+// matching that path. A zero issueTime means that it is being issued now.
+func (c *Core) applyTTLPolicies(ns *namespace.Namespace, path string, ttl time.Duration, issueTime time.Time) (time.Duration, []string, error) {
+	fullPath := path
+	if ns != nil {
+		fullPath = ns.Path + path
+	}
+
+	maxTTL, name := c.ttlPolicies.MaxTTL(fullPath)
+	if maxTTL == 0 {
+		return ttl, nil, nil
+	}
+
+	remaining := maxTTL
+	if !issueTime.IsZero() {
+		remaining = time.Until(issueTime.Add(maxTTL))
+	}
+	if remaining <= 0 {
+		return 0, nil, fmt.Errorf("past the max TTL of TTL policy %q, cannot renew", name)
+	}
+	if ttl > 0 && ttl <= remaining {
+		return ttl, nil, nil
+	}
+
+	return remaining, []string{
+		fmt.Sprintf("TTL of %q exceeded the effective max_ttl of %q set by TTL policy %q; TTL value is capped accordingly",
+			ttl.String(), remaining.Truncate(time.Second).String(), name),
+	}, nil
+}
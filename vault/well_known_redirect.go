@@ -36,12 +36,17 @@ func (reg *wellKnownRedirectRegistry) TryRegister(ctx context.Context, core *Cor
 	if strings.HasPrefix(dest, "/") {
 		return errors.New("redirect targets must be relative")
 	}
-	src = strings.TrimSuffix(src, "/")
+	src = strings.Trim(src, "/")
+	if src == "" {
+		return errors.New("redirect source must not be empty")
+	}
 	reg.lock.Lock()
 	defer reg.lock.Unlock()
-	_, _, found := reg.paths.LongestPrefix(src)
-	if found {
-		return fmt.Errorf("api redirect conflict for %s", src)
+
+	// A mount may re-register its own source, e.g. when the backend is
+	// reinitialized, but no source may overlap with another registered source.
+	if conflict, r := reg.conflict(mountUUID, src); r != nil {
+		return fmt.Errorf("api redirect conflict for %s: %s is already registered by mount %s", src, conflict, r.mountUUID)
 	}
 	reg.paths.Insert(src, &wellKnownRedirect{
 		c:         core,
@@ -51,6 +56,35 @@ func (reg *wellKnownRedirectRegistry) TryRegister(ctx context.Context, core *Cor
 	return nil
 }
 
+// conflict returns the registered source, and its redirect, which overlaps with
+// src: either the same path, a parent path segment of it or a child path
+// segment of it. The same path registered by the given mount is not considered
+// a conflict. The caller must hold the lock.
+func (reg *wellKnownRedirectRegistry) conflict(mountUUID, src string) (string, *wellKnownRedirect) {
+	var conflictSrc string
+	var conflict *wellKnownRedirect
+	reg.paths.WalkPath(src, func(k string, v interface{}) bool {
+		r := v.(*wellKnownRedirect)
+		if k == src && r.mountUUID == mountUUID {
+			return false
+		}
+		if k == src || strings.HasPrefix(src, k+"/") {
+			conflictSrc, conflict = k, r
+			return true
+		}
+		return false
+	})
+	if conflict != nil {
+		return conflictSrc, conflict
+	}
+
+	reg.paths.WalkPrefix(src+"/", func(k string, v interface{}) bool {
+		conflictSrc, conflict = k, v.(*wellKnownRedirect)
+		return true
+	})
+	return conflictSrc, conflict
+}
+
 // Find any relevant redirects for a given source path
 func (reg *wellKnownRedirectRegistry) Find(path string) (*wellKnownRedirect, string) {
 	reg.lock.RLock()
@@ -94,6 +128,7 @@ func (reg *wellKnownRedirectRegistry) DeregisterMount(mountUuid string) {
 
 // Remove a specific redirect for a mount
 func (reg *wellKnownRedirectRegistry) DeregisterSource(mountUuid, src string) bool {
+	src = strings.Trim(src, "/")
 	reg.lock.Lock()
 	defer reg.lock.Unlock()
 	var found bool